@@ -52,6 +52,12 @@ func main() {
             There are no limits on the types of data this server hosts. The rule of 
             thumb is that they must be common for a larger group of shards. 
             For example the root APIs. 
+
+            Shards authenticate with client certificates (--client-ca-file) or
+            tokens (--token-auth-file) as system:kcp:shard:<shard name>, and can
+            only write the objects of their own shard. Running without either
+            flag requires --insecure-allow-unauthenticated, which disables
+            authentication and authorization.
 		`),
 
 		RunE: func(c *cobra.Command, args []string) error {
@@ -18,6 +18,7 @@ package main
 
 import (
 	"net/http"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -29,31 +30,58 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// withOptionalClientCert creates a handler that verifies a request's client
-// cert if one is presented but passes through to the next handler if one is
-// not.
-func withOptionalClientCert(handler, failed http.Handler, auth authenticator.Request) http.Handler {
+// withOptionalAuthentication creates a handler that authenticates a request
+// if it presents a client cert, or a bearer token and a token authenticator is
+// configured. Requests with a client cert failing authentication are rejected.
+// Requests with neither, or with a bearer token not recognized by the proxy,
+// are passed through to the next handler unauthenticated, such that the
+// backend can authenticate them, e.g. service account tokens. Requests to the
+// excluded paths are always passed through unauthenticated.
+func withOptionalAuthentication(handler, failed http.Handler, auth authenticator.Request, excludedPaths []string) http.Handler {
 	if auth == nil {
 		return handler
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		hasClientCert := req.TLS != nil && len(req.TLS.PeerCertificates) > 0
+		hasToken := strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if (!hasClientCert && !hasToken) || isExcludedPath(req.URL.Path, excludedPaths) {
 			handler.ServeHTTP(w, req)
 			return
 		}
 		resp, ok, err := auth.AuthenticateRequest(req)
-		if err != nil || !ok {
+		if err != nil || (!ok && hasClientCert) {
 			if err != nil {
 				klog.ErrorS(err, "Unable to authenticate the request")
 			}
 			failed.ServeHTTP(w, req)
 			return
 		}
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		// the user is passed on in headers, not with the token
+		req.Header.Del("Authorization")
 		req = req.WithContext(request.WithUser(req.Context(), resp.User))
 		handler.ServeHTTP(w, req)
 	})
 }
 
+// isExcludedPath returns whether the path matches any of the excluded paths.
+// An excluded path ending in "*" matches all paths beginning with it.
+func isExcludedPath(path string, excludedPaths []string) bool {
+	for _, p := range excludedPaths {
+		if prefix := strings.TrimSuffix(p, "*"); prefix != p {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
 func newUnauthorizedHandler() http.Handler {
 	scheme := runtime.NewScheme()
 	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Group: "", Version: "v1"})
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type tokenAuthenticator map[string]string
+
+func (a tokenAuthenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		return &authenticator.Response{User: &user.DefaultInfo{Name: req.TLS.PeerCertificates[0].Subject.CommonName}}, true, nil
+	}
+	switch name, ok := a[req.Header.Get("Authorization")]; {
+	case !ok:
+		return nil, false, nil
+	case name == "":
+		return nil, false, errors.New("invalid token")
+	default:
+		return &authenticator.Response{User: &user.DefaultInfo{Name: name}}, true, nil
+	}
+}
+
+func TestWithOptionalAuthentication(t *testing.T) {
+	auth := tokenAuthenticator{
+		"Bearer alice-token":   "alice",
+		"Bearer invalid-token": "",
+	}
+
+	for _, tc := range []struct {
+		name          string
+		path          string
+		authorization string
+		clientCert    string
+		wantUser      string
+		wantAuthz     string
+		wantStatus    int
+	}{
+		{name: "unauthenticated", path: "/clusters/root/api", wantStatus: http.StatusOK},
+		{name: "client cert", path: "/clusters/root/api", clientCert: "bob", wantUser: "bob", wantStatus: http.StatusOK},
+		{name: "token", path: "/clusters/root/api", authorization: "Bearer alice-token", wantUser: "alice", wantStatus: http.StatusOK},
+		{name: "unknown token is passed on", path: "/clusters/root/api", authorization: "Bearer sa-token", wantAuthz: "Bearer sa-token", wantStatus: http.StatusOK},
+		{name: "invalid token", path: "/clusters/root/api", authorization: "Bearer invalid-token", wantStatus: http.StatusUnauthorized},
+		{name: "excluded path", path: "/openid/v1/jwks", authorization: "Bearer alice-token", wantAuthz: "Bearer alice-token", wantStatus: http.StatusOK},
+		{name: "excluded path prefix", path: "/.well-known/openid-configuration", authorization: "Bearer invalid-token", wantAuthz: "Bearer invalid-token", wantStatus: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotUser, gotAuthz string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if u, ok := request.UserFrom(req.Context()); ok {
+					gotUser = u.GetName()
+				}
+				gotAuthz = req.Header.Get("Authorization")
+			})
+			failed := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			if tc.clientCert != "" {
+				cert := &x509.Certificate{}
+				cert.Subject.CommonName = tc.clientCert
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+			}
+			rec := httptest.NewRecorder()
+			withOptionalAuthentication(handler, failed, auth, []string{"/openid/v1/jwks", "/.well-known/*"}).ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+			require.Equal(t, tc.wantUser, gotUser)
+			require.Equal(t, tc.wantAuthz, gotAuthz)
+		})
+	}
+}
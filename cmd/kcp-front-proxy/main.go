@@ -43,6 +43,8 @@ import (
 
 	frontproxyoptions "github.com/kcp-dev/kcp/cmd/kcp-front-proxy/options"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
+	cacheshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
@@ -75,9 +77,10 @@ func NewProxyCommand(ctx context.Context) *cobra.Command {
 	options := frontproxyoptions.NewOptions()
 	cmd := &cobra.Command{
 		Use:   "kcp-front-proxy",
-		Short: "Terminate TLS and handles client cert auth for backend API servers",
-		Long: `kcp-front-proxy is a reverse proxy that accepts client certificates and
-forwards Common Name and Organizations to backend API servers in HTTP headers.
+		Short: "Terminate TLS and handles client cert and token auth for backend API servers",
+		Long: `kcp-front-proxy is a reverse proxy that accepts client certificates and,
+with OIDC or a TokenReview webhook, bearer tokens, and forwards the authenticated
+user to backend API servers in HTTP headers.
 The proxy terminates TLS and communicates with API servers via mTLS. Traffic is
 routed based on paths.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -126,19 +129,43 @@ routed based on paths.`,
 
 			// start index
 			kcpSharedInformerFactory := kcpinformers.NewSharedInformerFactoryWithOptions(rootShardConfigInformerClient, 30*time.Minute)
-			indexController := index.NewController(
-				rootShardConfig.Host,
-				kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
-				func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (kcpclient.Interface, error) {
-					shardConfig := restclient.CopyConfig(rootShardConfig)
-					shardConfig.Host = shard.Spec.BaseURL
-					shardClient, err := kcpclient.NewForConfig(kcpclienthelper.SetCluster(restclient.CopyConfig(shardConfig), logicalcluster.Wildcard))
-					if err != nil {
-						return nil, fmt.Errorf("failed to create shard %q client: %w", shard.Name, err)
-					}
-					return shardClient, nil
-				},
-			)
+			var indexController *index.Controller
+			var staleReadConfig *restclient.Config
+			if len(options.CacheKubeconfig) > 0 {
+				cacheConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: options.CacheKubeconfig}, nil).ClientConfig()
+				if err != nil {
+					return fmt.Errorf("failed to load cache kubeconfig: %w", err)
+				}
+				staleReadConfig = restclient.CopyConfig(cacheConfig)
+				cacheConfig = cacheclient.WithShardRoundTripper(cacheConfig)
+				cacheConfig = cacheclient.WithDefaultShardRoundTripper(cacheConfig, cacheshard.Wildcard)
+				cacheClient, err := kcpclient.NewForConfig(kcpclienthelper.SetCluster(cacheConfig, logicalcluster.Wildcard))
+				if err != nil {
+					return fmt.Errorf("failed to create cache client for informers: %w", err)
+				}
+				cacheKcpSharedInformerFactory := kcpinformers.NewSharedInformerFactoryWithOptions(cacheClient, 30*time.Minute)
+				indexController = index.NewCacheController(
+					rootShardConfig.Host,
+					kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+					cacheKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+				)
+				cacheKcpSharedInformerFactory.Start(ctx.Done())
+				cacheKcpSharedInformerFactory.WaitForCacheSync(ctx.Done())
+			} else {
+				indexController = index.NewController(
+					rootShardConfig.Host,
+					kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+					func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (kcpclient.Interface, error) {
+						shardConfig := restclient.CopyConfig(rootShardConfig)
+						shardConfig.Host = shard.Spec.BaseURL
+						shardClient, err := kcpclient.NewForConfig(kcpclienthelper.SetCluster(restclient.CopyConfig(shardConfig), logicalcluster.Wildcard))
+						if err != nil {
+							return nil, fmt.Errorf("failed to create shard %q client: %w", shard.Name, err)
+						}
+						return shardClient, nil
+					},
+				)
+			}
 
 			go indexController.Start(ctx, 2)
 
@@ -146,12 +173,12 @@ routed based on paths.`,
 			kcpSharedInformerFactory.WaitForCacheSync(ctx.Done())
 
 			// start the server
-			handler, err := proxy.NewHandler(&options.Proxy, indexController)
+			handler, err := proxy.NewHandler(&options.Proxy, indexController, staleReadConfig)
 			if err != nil {
 				return err
 			}
 			failedHandler := newUnauthorizedHandler()
-			handler = withOptionalClientCert(handler, failedHandler, authenticationInfo.Authenticator)
+			handler = withOptionalAuthentication(handler, failedHandler, authenticationInfo.Authenticator, options.Authentication.ExcludedPaths)
 
 			requestInfoFactory := requestinfo.NewFactory()
 			handler = server.WithInClusterServiceAccountRequestRewrite(handler)
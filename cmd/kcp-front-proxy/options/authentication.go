@@ -23,32 +23,45 @@ import (
 	"github.com/spf13/pflag"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/request/union"
 	"k8s.io/apiserver/pkg/authentication/request/x509"
 	"k8s.io/apiserver/pkg/authentication/user"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	apiserveroptions "k8s.io/apiserver/pkg/server/options"
+	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
 	kcpauthentication "github.com/kcp-dev/kcp/cmd/kcp-front-proxy/authentication"
 )
 
-// Authentication wraps ClientCertAuthenticationOptions so we don't pull in
-// more auth machinery than we need with DelegatingAuthenticationOptions
+// Authentication wraps ClientCertAuthenticationOptions and the OIDC and webhook token
+// authentication options so we don't pull in more auth machinery than we need with
+// DelegatingAuthenticationOptions
 type Authentication struct {
 	ClientCert apiserveroptions.ClientCertAuthenticationOptions
+	// Tokens configures the OIDC and TokenReview webhook authentication of bearer tokens.
+	Tokens *kubeoptions.BuiltInAuthenticationOptions
 
 	PassOnGroups []string
 	DropGroups   []string
+
+	// ExcludedPaths are not authenticated by the front-proxy, but passed on to the backend as is.
+	ExcludedPaths []string
 }
 
 // NewAuthentication creates a default Authentication
 func NewAuthentication() *Authentication {
 	return &Authentication{
+		Tokens:     kubeoptions.NewBuiltInAuthenticationOptions().WithOIDC().WithWebHook(),
 		DropGroups: []string{user.SystemPrivilegedGroup},
 	}
 }
 
-// ApplyTo sets up the x509 Authenticator if the client-ca-file option was passed
+// ApplyTo sets up the x509 Authenticator if the client-ca-file option was passed, and the
+// bearer token authenticators if OIDC or a TokenReview webhook are configured.
 func (c *Authentication) ApplyTo(authenticationInfo *genericapiserver.AuthenticationInfo, servingInfo *genericapiserver.SecureServingInfo) error {
+	var authenticators []authenticator.Request
+
 	clientCAProvider, err := c.ClientCert.GetClientCAContentProvider()
 	if err != nil {
 		return fmt.Errorf("unable to load client CA provider: %w", err)
@@ -57,11 +70,32 @@ func (c *Authentication) ApplyTo(authenticationInfo *genericapiserver.Authentica
 		if err = authenticationInfo.ApplyClientCert(clientCAProvider, servingInfo); err != nil {
 			return fmt.Errorf("unable to assign client CA provider: %w", err)
 		}
-		authenticationInfo.Authenticator = x509.NewDynamic(clientCAProvider.VerifyOptions, x509.CommonNameUserConversion)
+		authenticators = append(authenticators, x509.NewDynamic(clientCAProvider.VerifyOptions, x509.CommonNameUserConversion))
+	}
+
+	tokenConfig, err := c.Tokens.ToAuthenticationConfig()
+	if err != nil {
+		return fmt.Errorf("unable to create token authentication config: %w", err)
+	}
+	tokenAuthenticator, _, err := tokenConfig.New()
+	if err != nil {
+		return fmt.Errorf("unable to create token authenticator: %w", err)
+	}
+	if tokenAuthenticator != nil {
+		authenticators = append(authenticators, tokenAuthenticator)
+	}
+	authenticationInfo.APIAudiences = c.Tokens.APIAudiences
+
+	switch len(authenticators) {
+	case 0:
+	case 1:
+		authenticationInfo.Authenticator = authenticators[0]
+	default:
+		authenticationInfo.Authenticator = union.New(authenticators...)
 	}
 
 	// only pass on those groups to the shards we want
-	if len(c.PassOnGroups) > 0 || len(c.DropGroups) > 0 {
+	if authenticationInfo.Authenticator != nil && (len(c.PassOnGroups) > 0 || len(c.DropGroups) > 0) {
 		filter := &kcpauthentication.GroupFilter{
 			Authenticator: authenticationInfo.Authenticator,
 			PassOnGroups:  sets.NewString(),
@@ -88,9 +122,10 @@ func (c *Authentication) ApplyTo(authenticationInfo *genericapiserver.Authentica
 	return nil
 }
 
-// AddFlags delegates to ClientCertAuthenticationOptions
+// AddFlags delegates to ClientCertAuthenticationOptions and the OIDC and webhook token authentication options
 func (c *Authentication) AddFlags(fs *pflag.FlagSet) {
 	c.ClientCert.AddFlags(fs)
+	c.Tokens.AddFlags(fs)
 
 	fs.StringSliceVar(&c.PassOnGroups, "authentication-pass-on-groups", c.PassOnGroups,
 		"Groups that are passed on to the shard. Empty matches all. \"prefix*\" matches "+
@@ -98,8 +133,20 @@ func (c *Authentication) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&c.DropGroups, "authentication-drop-groups", c.DropGroups,
 		"Groups that are not passed on to the shard. Empty matches none. \"prefix*\" matches "+
 			"all beginning with the given prefix. Dropping trumps over passing on.")
+	fs.StringSliceVar(&c.ExcludedPaths, "authentication-excluded-paths", c.ExcludedPaths,
+		"Paths that are not authenticated by the proxy, but passed on to the backend as is, e.g. "+
+			"\"/.well-known/openid-configuration\". \"prefix*\" matches all beginning with the given prefix.")
 }
 
 func (c *Authentication) Validate() []error {
-	return nil
+	var errs []error
+
+	errs = append(errs, c.Tokens.Validate()...)
+	for _, p := range c.ExcludedPaths {
+		if !strings.HasPrefix(p, "/") {
+			errs = append(errs, fmt.Errorf("--authentication-excluded-paths must start with /: %q", p))
+		}
+	}
+
+	return errs
 }
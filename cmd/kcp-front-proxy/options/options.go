@@ -17,6 +17,7 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -35,8 +36,9 @@ type Options struct {
 	Proxy          proxyoptions.Options
 	Logs           *logs.Options
 
-	RootKubeconfig string
-	RootDirectory  string
+	RootKubeconfig  string
+	CacheKubeconfig string
+	RootDirectory   string
 }
 
 func NewOptions() *Options {
@@ -46,8 +48,9 @@ func NewOptions() *Options {
 		Proxy:          *proxyoptions.NewOptions(),
 		Logs:           logs.NewOptions(),
 
-		RootKubeconfig: "",
-		RootDirectory:  ".kcp",
+		RootKubeconfig:  "",
+		CacheKubeconfig: "",
+		RootDirectory:   ".kcp",
 	}
 
 	// Default to -v=2
@@ -69,6 +72,7 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 
 	fs.StringVar(&o.RootDirectory, "root-directory", o.RootDirectory, "Root directory.")
 	fs.StringVar(&o.RootKubeconfig, "root-kubeconfig", o.RootKubeconfig, "The path to the kubeconfig of the root shard.")
+	fs.StringVar(&o.CacheKubeconfig, "cache-kubeconfig", o.CacheKubeconfig, "The path to the kubeconfig of the cache server. If set, requests are routed to shards by the ClusterWorkspaces and ClusterWorkspaceShards replicated into the cache server, instead of watching every shard. A custom replication policy of the cache server must include clusterworkspaces.tenancy.kcp.dev.")
 }
 
 func (o *Options) Complete() error {
@@ -104,6 +108,9 @@ func (o *Options) Validate() []error {
 	errs = append(errs, o.SecureServing.Validate()...)
 	errs = append(errs, o.Authentication.Validate()...)
 	errs = append(errs, o.Proxy.Validate()...)
+	if len(o.Proxy.StaleReadGroups) > 0 && len(o.CacheKubeconfig) == 0 {
+		errs = append(errs, fmt.Errorf("--stale-read-groups requires --cache-kubeconfig"))
+	}
 
 	return errs
 }
@@ -52,6 +52,7 @@ func startVirtual(ctx context.Context, index int, logDirPath string) (<-chan err
 		"--requestheader-client-ca-file=.kcp/requestheader-ca.crt",
 		"--requestheader-username-headers=X-Remote-User",
 		"--requestheader-group-headers=X-Remote-Group",
+		"--requestheader-extra-headers-prefix=X-Remote-Extra-",
 		fmt.Sprintf("--secure-port=%d", 7444+index),
 	)
 	fmt.Fprintf(out, "running: %v\n", strings.Join(commandLine, " ")) // nolint: errcheck
@@ -90,6 +90,13 @@ func Run(options *synceroptions.Options, ctx context.Context) error {
 	upstreamConfig.QPS = options.QPS
 	upstreamConfig.Burst = options.Burst
 
+	if options.KCPClientCertificateDir != "" {
+		if err := syncer.EnableClientCertificates(ctx, upstreamConfig, options.KCPClientCertificateDir,
+			logicalcluster.New(options.FromClusterName), options.SyncTargetName, options.SyncTargetUID); err != nil {
+			return err
+		}
+	}
+
 	downstreamConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		&clientcmd.ClientConfigLoadingRules{ExplicitPath: options.ToKubeconfig},
 		&clientcmd.ConfigOverrides{
@@ -44,7 +44,8 @@ type Options struct {
 	Logs                *logs.Options
 	SyncedResourceTypes []string
 
-	APIImportPollInterval time.Duration
+	APIImportPollInterval   time.Duration
+	KCPClientCertificateDir string
 }
 
 func NewOptions() *Options {
@@ -74,6 +75,8 @@ func (options *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&options.SyncTargetUID, "sync-target-uid", options.SyncTargetUID, "The UID from the SyncTarget resource in KCP.")
 	fs.StringArrayVarP(&options.SyncedResourceTypes, "resources", "r", options.SyncedResourceTypes, "Resources to be synchronized in kcp.")
 	fs.DurationVar(&options.APIImportPollInterval, "api-import-poll-interval", options.APIImportPollInterval, "Polling interval for API import.")
+	fs.StringVar(&options.KCPClientCertificateDir, "kcp-client-certificate-dir", options.KCPClientCertificateDir,
+		"Directory to store rotating client certificates for the -from cluster in. If set, the credentials of --from-kubeconfig are only used to request the first certificate.")
 	fs.Var(kcpfeatures.NewFlagValue(), "feature-gates", ""+
 		"A set of key=value pairs that describe feature gates for alpha/experimental features. "+
 		"Options are:\n"+strings.Join(kcpfeatures.KnownFeatures(), "\n")) // hide kube-only gates
@@ -145,6 +145,7 @@ func Run(ctx context.Context, o *options.Options) error {
 	if err != nil {
 		return err
 	}
+	rootAPIServerConfig.ExtraConfig.RateLimiter = o.VirtualWorkspaces.RateLimiting.Limiter()
 
 	completedRootAPIServerConfig := rootAPIServerConfig.Complete()
 	rootAPIServer, err := completedRootAPIServerConfig.New(genericapiserver.NewEmptyDelegate())
@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessgrant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/AccessGrant"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &accessGrant{
+				Handler:          admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: delegated.NewDelegatedAuthorizer,
+				now:              time.Now,
+			}, nil
+		})
+}
+
+// accessGrant sets spec.expirationTime of new AccessGrants to the current time plus spec.duration,
+// unless the user is in system:masters and spec.expirationTime is set, e.g. when the AccessGrant is
+// copied into another workspace.
+//
+// It validates AccessGrants:
+// - the spec is immutable,
+// - subjects are users or groups,
+// - roleRef references a ClusterRole, or a Role in spec.namespace,
+// - spec.duration is positive,
+// - spec.expirationTime is at most spec.duration from now, unless the user is in system:masters,
+// - the user must be allowed to bind the referenced role, unless the user is in system:masters.
+type accessGrant struct {
+	*admission.Handler
+	deepSARClient kubernetesclient.ClusterInterface
+
+	createAuthorizer delegated.DelegatedAuthorizerFactory
+	now              func() time.Time
+}
+
+// Ensure that the required admission interfaces are implemented.
+var (
+	_ = admission.MutationInterface(&accessGrant{})
+	_ = admission.ValidationInterface(&accessGrant{})
+	_ = admission.InitializationValidator(&accessGrant{})
+	_ = kcpinitializers.WantsDeepSARClient(&accessGrant{})
+)
+
+func (o *accessGrant) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("accessgrants") {
+		return nil
+	}
+	if a.GetSubresource() != "" || a.GetOperation() != admission.Create {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	grant, err := toAccessGrant(u)
+	if err != nil {
+		return err
+	}
+	if grant.Spec.ExpirationTime != nil && sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		return nil
+	}
+
+	expiration := metav1.NewTime(o.now().Add(grant.Spec.Duration.Duration))
+	return unstructured.SetNestedField(u.Object, expiration.UTC().Format(time.RFC3339), "spec", "expirationTime")
+}
+
+func (o *accessGrant) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("accessgrants") {
+		return nil
+	}
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	grant, err := toAccessGrant(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	if a.GetOperation() == admission.Update {
+		old, err := toAccessGrant(a.GetOldObject())
+		if err != nil {
+			return err
+		}
+		if !equality.Semantic.DeepEqual(old.Spec, grant.Spec) {
+			return admission.NewForbidden(a, errors.New("spec is immutable"))
+		}
+		return nil
+	}
+
+	for i, subject := range grant.Spec.Subjects {
+		if subject.Kind != rbacv1.UserKind && subject.Kind != rbacv1.GroupKind {
+			return admission.NewForbidden(a, fmt.Errorf("spec.subjects[%d].kind: must be %s or %s", i, rbacv1.UserKind, rbacv1.GroupKind))
+		}
+	}
+	if grant.Spec.RoleRef.APIGroup != rbacv1.GroupName {
+		return admission.NewForbidden(a, fmt.Errorf("spec.roleRef.apiGroup: must be %s", rbacv1.GroupName))
+	}
+	switch grant.Spec.RoleRef.Kind {
+	case "ClusterRole":
+	case "Role":
+		if grant.Spec.Namespace == "" {
+			return admission.NewForbidden(a, errors.New("spec.namespace: must be set when granting a Role"))
+		}
+	default:
+		return admission.NewForbidden(a, errors.New("spec.roleRef.kind: must be ClusterRole or Role"))
+	}
+	if grant.Spec.Duration.Duration <= 0 {
+		return admission.NewForbidden(a, errors.New("spec.duration: must be positive"))
+	}
+	if grant.Spec.ExpirationTime != nil && !sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		if grant.Spec.ExpirationTime.After(o.now().Add(grant.Spec.Duration.Duration)) {
+			return admission.NewForbidden(a, errors.New("spec.expirationTime: must not be later than spec.duration from now"))
+		}
+	}
+
+	if err := o.authorizeBind(ctx, a, clusterName, grant); err != nil {
+		return admission.NewForbidden(a, err)
+	}
+
+	return nil
+}
+
+// authorizeBind checks that the user can bind the role referenced by the AccessGrant.
+func (o *accessGrant) authorizeBind(ctx context.Context, a admission.Attributes, clusterName logicalcluster.Name, grant *tenancyv1alpha1.AccessGrant) error {
+	if sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		return nil
+	}
+
+	attr := authorizer.AttributesRecord{
+		User:            a.GetUserInfo(),
+		Verb:            "bind",
+		APIGroup:        rbacv1.GroupName,
+		APIVersion:      rbacv1.SchemeGroupVersion.Version,
+		Resource:        "clusterroles",
+		Namespace:       grant.Spec.Namespace,
+		Name:            grant.Spec.RoleRef.Name,
+		ResourceRequest: true,
+	}
+	if grant.Spec.RoleRef.Kind == "Role" {
+		attr.Resource = "roles"
+	}
+
+	authz, err := o.createAuthorizer(clusterName, o.deepSARClient)
+	if err != nil {
+		return fmt.Errorf("unable to determine access to workspace %s", clusterName)
+	}
+	if decision, _, err := authz.Authorize(ctx, attr); err != nil {
+		return fmt.Errorf("unable to determine access to workspace %s: %w", clusterName, err)
+	} else if decision != authorizer.DecisionAllow {
+		return fmt.Errorf("missing verb=%q permission on %s %q", attr.Verb, attr.Resource, attr.Name)
+	}
+	return nil
+}
+
+func toAccessGrant(obj runtime.Object) (*tenancyv1alpha1.AccessGrant, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	grant := &tenancyv1alpha1.AccessGrant{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, grant); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to AccessGrant: %w", err)
+	}
+	return grant, nil
+}
+
+func (o *accessGrant) ValidateInitialization() error {
+	if o.deepSARClient == nil {
+		return fmt.Errorf(PluginName + " plugin needs a deep SAR client")
+	}
+	return nil
+}
+
+func (o *accessGrant) SetDeepSARClient(client kubernetesclient.ClusterInterface) {
+	o.deepSARClient = client
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessgrant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func accessGrantAttr(obj, old *tenancyv1alpha1.AccessGrant, groups ...string) admission.Attributes {
+	op, opts, oldObj := admission.Create, runtime.Object(&metav1.CreateOptions{}), runtime.Object(nil)
+	if old != nil {
+		op, opts, oldObj = admission.Update, &metav1.UpdateOptions{}, helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		oldObj,
+		tenancyv1alpha1.Kind("AccessGrant").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("accessgrants").WithVersion("v1alpha1"),
+		"",
+		op,
+		opts,
+		false,
+		&user.DefaultInfo{Groups: groups},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	newAccessGrant := func(kind, namespace string, subjects ...rbacv1.Subject) *tenancyv1alpha1.AccessGrant {
+		return &tenancyv1alpha1.AccessGrant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "incident-42",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+			},
+			Spec: tenancyv1alpha1.AccessGrantSpec{
+				Subjects:  subjects,
+				RoleRef:   rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kind, Name: "admin"},
+				Namespace: namespace,
+				Duration:  metav1.Duration{Duration: time.Hour},
+				Reason:    "incident 42",
+			},
+		}
+	}
+	alice := rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "alice"}
+	serviceAccount := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "default", Name: "default"}
+	longer := newAccessGrant("ClusterRole", "", alice)
+	longer.Spec.Duration = metav1.Duration{Duration: 2 * time.Hour}
+	zero := newAccessGrant("ClusterRole", "", alice)
+	zero.Spec.Duration = metav1.Duration{}
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	extended := newAccessGrant("ClusterRole", "", alice)
+	extended.Spec.ExpirationTime = &metav1.Time{Time: now.Add(2 * time.Hour)}
+
+	tests := map[string]struct {
+		attr          admission.Attributes
+		authzDecision authorizer.Decision
+		wantAuthz     []string
+		wantErr       string
+	}{
+		"allows granting a bindable ClusterRole": {
+			attr:          accessGrantAttr(newAccessGrant("ClusterRole", "", alice), nil),
+			authzDecision: authorizer.DecisionAllow,
+			wantAuthz:     []string{"root:org bind clusterroles /admin"},
+		},
+		"allows granting a bindable Role in its namespace": {
+			attr:          accessGrantAttr(newAccessGrant("Role", "default", alice), nil),
+			authzDecision: authorizer.DecisionAllow,
+			wantAuthz:     []string{"root:org bind roles default/admin"},
+		},
+		"forbids granting a role without bind permission": {
+			attr:          accessGrantAttr(newAccessGrant("ClusterRole", "", alice), nil),
+			authzDecision: authorizer.DecisionDeny,
+			wantErr:       `missing verb="bind" permission on clusterroles "admin"`,
+		},
+		"allows system:masters to grant any role": {
+			attr:          accessGrantAttr(newAccessGrant("ClusterRole", "", alice), nil, user.SystemPrivilegedGroup),
+			authzDecision: authorizer.DecisionDeny,
+			wantAuthz:     []string{},
+		},
+		"forbids granting a Role without namespace": {
+			attr:    accessGrantAttr(newAccessGrant("Role", "", alice), nil),
+			wantErr: "spec.namespace: must be set when granting a Role",
+		},
+		"forbids service account subjects": {
+			attr:    accessGrantAttr(newAccessGrant("ClusterRole", "", serviceAccount), nil),
+			wantErr: "spec.subjects[0].kind: must be User or Group",
+		},
+		"forbids non-positive durations": {
+			attr:    accessGrantAttr(zero, nil),
+			wantErr: "spec.duration: must be positive",
+		},
+		"forbids expiration times later than the duration": {
+			attr:    accessGrantAttr(extended, nil),
+			wantErr: "spec.expirationTime: must not be later than spec.duration from now",
+		},
+		"allows system:masters to set any expiration time": {
+			attr:      accessGrantAttr(extended, nil, user.SystemPrivilegedGroup),
+			wantAuthz: []string{},
+		},
+		"forbids changing the spec": {
+			attr:    accessGrantAttr(longer, newAccessGrant("ClusterRole", "", alice)),
+			wantErr: "spec is immutable",
+		},
+		"allows updates without spec changes": {
+			attr:      accessGrantAttr(newAccessGrant("ClusterRole", "", alice), newAccessGrant("ClusterRole", "", alice)),
+			wantAuthz: []string{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			authz := []string{}
+			o := &accessGrant{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: func(clusterName logicalcluster.Name, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
+					return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+						authz = append(authz, clusterName.String()+" "+a.GetVerb()+" "+a.GetResource()+" "+a.GetNamespace()+"/"+a.GetName())
+						return tc.authzDecision, "reason", nil
+					}), nil
+				},
+				now: func() time.Time { return now },
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			err := o.Validate(ctx, tc.attr, nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if tc.wantAuthz != nil {
+				require.Equal(t, tc.wantAuthz, authz)
+			}
+		})
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	copied := metav1.NewTime(now.Add(-time.Minute))
+	newAccessGrant := func(expirationTime *metav1.Time) *tenancyv1alpha1.AccessGrant {
+		return &tenancyv1alpha1.AccessGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "incident-42"},
+			Spec: tenancyv1alpha1.AccessGrantSpec{
+				Subjects:       []rbacv1.Subject{{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "alice"}},
+				RoleRef:        rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "admin"},
+				Duration:       metav1.Duration{Duration: time.Hour},
+				Reason:         "incident 42",
+				ExpirationTime: expirationTime,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		attr admission.Attributes
+		want time.Time
+	}{
+		"sets the expiration time from the duration": {
+			attr: accessGrantAttr(newAccessGrant(nil), nil),
+			want: now.Add(time.Hour),
+		},
+		"overrides the expiration time of users": {
+			attr: accessGrantAttr(newAccessGrant(&copied), nil),
+			want: now.Add(time.Hour),
+		},
+		"keeps the expiration time of copies by system:masters": {
+			attr: accessGrantAttr(newAccessGrant(&copied), nil, user.SystemPrivilegedGroup),
+			want: copied.Time,
+		},
+		"sets the expiration time for system:masters if unset": {
+			attr: accessGrantAttr(newAccessGrant(nil), nil, user.SystemPrivilegedGroup),
+			want: now.Add(time.Hour),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := &accessGrant{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				now:     func() time.Time { return now },
+			}
+			require.NoError(t, o.Admit(context.Background(), tc.attr, nil))
+			grant, err := toAccessGrant(tc.attr.GetObject())
+			require.NoError(t, err)
+			require.NotNil(t, grant.Spec.ExpirationTime)
+			require.True(t, tc.want.Equal(grant.Spec.ExpirationTime.Time), "expected %s, got %s", tc.want, grant.Spec.ExpirationTime)
+		})
+	}
+}
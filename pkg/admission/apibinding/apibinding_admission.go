@@ -26,18 +26,22 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/permissionclaims"
 	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 const (
@@ -59,6 +63,13 @@ type apiBindingAdmission struct {
 	deepSARClient kubernetesclient.ClusterInterface
 
 	createAuthorizer delegated.DelegatedAuthorizerFactory
+
+	apiExportsHasSynced     cache.InformerSynced
+	getAPIExportsByIdentity func(identityHash string) ([]*apisv1alpha1.APIExport, error)
+
+	// getCachedAPIExportsByIdentity resolves identities against the APIExports replicated
+	// into the cache server by all shards. It is nil if no cache server is configured.
+	getCachedAPIExportsByIdentity func(identityHash string) ([]*apisv1alpha1.APIExport, error)
 }
 
 // Ensure that the required admission interfaces are implemented.
@@ -67,6 +78,8 @@ var (
 	_ = admission.MutationInterface(&apiBindingAdmission{})
 	_ = admission.InitializationValidator(&apiBindingAdmission{})
 	_ = kcpinitializers.WantsDeepSARClient(&apiBindingAdmission{})
+	_ = kcpinitializers.WantsKcpInformers(&apiBindingAdmission{})
+	_ = kcpinitializers.WantsCacheKcpInformers(&apiBindingAdmission{})
 )
 
 func (o *apiBindingAdmission) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
@@ -84,6 +97,12 @@ func (o *apiBindingAdmission) Admit(ctx context.Context, a admission.Attributes,
 		return fmt.Errorf("failed to convert unstructured to APIBinding: %w", err)
 	}
 
+	if apiBinding.Spec.Reference.Identity != nil {
+		// The APIExport of a reference by identity is only known after the controller resolved it.
+		setExportLabel(apiBinding, apiBinding.Status.BoundAPIExport)
+		return writeBack(u, apiBinding)
+	}
+
 	if apiBinding.Spec.Reference.Workspace == nil {
 		return nil
 	}
@@ -98,19 +117,34 @@ func (o *apiBindingAdmission) Admit(ctx context.Context, a admission.Attributes,
 	}
 
 	// set labels
-	if apiBinding.Spec.Reference.Workspace == nil {
+	setExportLabel(apiBinding, &apiBinding.Spec.Reference)
+
+	return writeBack(u, apiBinding)
+}
+
+// setExportLabel sets the export label of the APIBinding to the given workspace reference, or removes it
+// if there is none.
+func setExportLabel(apiBinding *apisv1alpha1.APIBinding, ref *apisv1alpha1.ExportReference) {
+	value := exportLabelValue(ref)
+	if value == "" {
 		delete(apiBinding.Labels, apisv1alpha1.InternalAPIBindingExportLabelKey)
-	} else {
-		if apiBinding.Labels == nil {
-			apiBinding.Labels = make(map[string]string)
-		}
-		apiBinding.Labels[apisv1alpha1.InternalAPIBindingExportLabelKey] = permissionclaims.ToAPIBindingExportLabelValue(
-			logicalcluster.New(apiBinding.Spec.Reference.Workspace.Path),
-			apiBinding.Spec.Reference.Workspace.ExportName,
-		)
+		return
 	}
+	if apiBinding.Labels == nil {
+		apiBinding.Labels = make(map[string]string)
+	}
+	apiBinding.Labels[apisv1alpha1.InternalAPIBindingExportLabelKey] = value
+}
 
-	// write back
+// exportLabelValue returns the export label value for the workspace reference, or an empty string.
+func exportLabelValue(ref *apisv1alpha1.ExportReference) string {
+	if ref == nil || ref.Workspace == nil {
+		return ""
+	}
+	return permissionclaims.ToAPIBindingExportLabelValue(logicalcluster.New(ref.Workspace.Path), ref.Workspace.ExportName)
+}
+
+func writeBack(u *unstructured.Unstructured, apiBinding *apisv1alpha1.APIBinding) error {
 	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(apiBinding)
 	if err != nil {
 		return err
@@ -138,12 +172,13 @@ func (o *apiBindingAdmission) Validate(ctx context.Context, a admission.Attribut
 	}
 
 	// Return early if there's nothing to validate (but this should never happen because it's required via OpenAPI).
-	if apiBinding.Spec.Reference.Workspace == nil {
-		return admission.NewForbidden(a, fmt.Errorf(".spec.reference.workspace is required"))
+	if apiBinding.Spec.Reference.Workspace == nil && apiBinding.Spec.Reference.Identity == nil {
+		return admission.NewForbidden(a, fmt.Errorf(".spec.reference.workspace or .spec.reference.identity is required"))
 	}
 
 	// Object validation
 	var errs field.ErrorList
+	var old *apisv1alpha1.APIBinding
 
 	switch a.GetOperation() {
 	case admission.Create:
@@ -153,7 +188,7 @@ func (o *apiBindingAdmission) Validate(ctx context.Context, a admission.Attribut
 		if !ok {
 			return fmt.Errorf("unexpected type %T", a.GetOldObject())
 		}
-		old := &apisv1alpha1.APIBinding{}
+		old = &apisv1alpha1.APIBinding{}
 		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, old); err != nil {
 			return fmt.Errorf("failed to convert unstructured to APIBinding: %w", err)
 		}
@@ -164,6 +199,10 @@ func (o *apiBindingAdmission) Validate(ctx context.Context, a admission.Attribut
 		return admission.NewForbidden(a, fmt.Errorf("%v", errs))
 	}
 
+	if apiBinding.Spec.Reference.Identity != nil {
+		return o.validateIdentityReference(ctx, a, apiBinding, old)
+	}
+
 	// Verify the workspace reference.
 	var apiExportClusterName logicalcluster.Name
 	cluster, err := genericapirequest.ValidClusterFrom(ctx)
@@ -197,16 +236,66 @@ func (o *apiBindingAdmission) Validate(ctx context.Context, a admission.Attribut
 
 	// Access check
 	if err := o.checkAPIExportAccess(ctx, a.GetUserInfo(), apiExportClusterName, apiBinding.Spec.Reference.Workspace.ExportName); err != nil {
-		action := "create"
-		if a.GetOperation() == admission.Update {
-			action = "update"
+		return accessForbidden(a, err)
+	}
+
+	return nil
+}
+
+// validateIdentityReference validates an APIBinding referencing an APIExport by identity hash. The user
+// must be allowed to bind every APIExport with that identity known to this shard, to the cache server if
+// there is none on this shard, or the APIExport the binding is bound to already if there is none at all.
+func (o *apiBindingAdmission) validateIdentityReference(ctx context.Context, a admission.Attributes, apiBinding, old *apisv1alpha1.APIBinding) error {
+	// Verify the labels. The label follows status.boundExport, which the status update of the controller
+	// changes without touching the labels.
+	allowed := sets.NewString("", exportLabelValue(apiBinding.Status.BoundAPIExport))
+	if old != nil {
+		allowed.Insert(exportLabelValue(old.Status.BoundAPIExport))
+	}
+	if value := apiBinding.Labels[apisv1alpha1.InternalAPIBindingExportLabelKey]; !allowed.Has(value) {
+		return admission.NewForbidden(a, field.Invalid(field.NewPath("metadata").Child("labels").Key(apisv1alpha1.InternalAPIBindingExportLabelKey), value, "must match status.boundExport"))
+	}
+
+	identityHash := apiBinding.Spec.Reference.Identity.IdentityHash
+	apiExports, err := o.getAPIExportsByIdentity(identityHash)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error resolving identity hash %q: %w", identityHash, err))
+	}
+	if len(apiExports) == 0 && o.getCachedAPIExportsByIdentity != nil {
+		apiExports, err = o.getCachedAPIExportsByIdentity(identityHash)
+		if err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("error resolving identity hash %q: %w", identityHash, err))
+		}
+	}
+	var refs []apisv1alpha1.WorkspaceExportReference
+	for _, apiExport := range apiExports {
+		refs = append(refs, apisv1alpha1.WorkspaceExportReference{Path: logicalcluster.From(apiExport).String(), ExportName: apiExport.Name})
+	}
+	if len(refs) == 0 && apiBinding.Status.BoundAPIExport != nil && apiBinding.Status.BoundAPIExport.Workspace != nil {
+		refs = append(refs, *apiBinding.Status.BoundAPIExport.Workspace)
+	}
+	if len(refs) == 0 {
+		return admission.NewForbidden(a, field.NotFound(field.NewPath("spec", "reference", "identity", "identityHash"), identityHash))
+	}
+
+	// Access check
+	for _, ref := range refs {
+		if err := o.checkAPIExportAccess(ctx, a.GetUserInfo(), logicalcluster.New(ref.Path), ref.ExportName); err != nil {
+			return accessForbidden(a, err)
 		}
-		return admission.NewForbidden(a, fmt.Errorf("unable to %s APIImport: %w", action, err))
 	}
 
 	return nil
 }
 
+func accessForbidden(a admission.Attributes, err error) error {
+	action := "create"
+	if a.GetOperation() == admission.Update {
+		action = "update"
+	}
+	return admission.NewForbidden(a, fmt.Errorf("unable to %s APIImport: %w", action, err))
+}
+
 func (o *apiBindingAdmission) checkAPIExportAccess(ctx context.Context, user user.Info, apiExportClusterName logicalcluster.Name, apiExportName string) error {
 	logger := klog.FromContext(ctx)
 	authz, err := o.createAuthorizer(apiExportClusterName, o.deepSARClient)
@@ -241,6 +330,9 @@ func (o *apiBindingAdmission) ValidateInitialization() error {
 	if o.deepSARClient == nil {
 		return fmt.Errorf(PluginName + " plugin needs a Kubernetes ClusterInterface")
 	}
+	if o.getAPIExportsByIdentity == nil {
+		return fmt.Errorf(PluginName + " plugin needs an APIExport informer")
+	}
 
 	return nil
 }
@@ -250,3 +342,31 @@ func (o *apiBindingAdmission) ValidateInitialization() error {
 func (o *apiBindingAdmission) SetDeepSARClient(client kubernetesclient.ClusterInterface) {
 	o.deepSARClient = client
 }
+
+// SetKcpInformers implements the WantsKcpInformers interface.
+func (o *apiBindingAdmission) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
+	apiExportInformer := f.Apis().V1alpha1().APIExports()
+	indexers.AddIfNotPresentOrDie(apiExportInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.APIExportByIdentity: indexers.IndexAPIExportByIdentity,
+	})
+
+	o.apiExportsHasSynced = apiExportInformer.Informer().HasSynced
+	o.SetReadyFunc(func() bool {
+		return o.apiExportsHasSynced()
+	})
+	o.getAPIExportsByIdentity = func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+		return indexers.ByIndex[*apisv1alpha1.APIExport](apiExportInformer.Informer().GetIndexer(), indexers.APIExportByIdentity, identityHash)
+	}
+}
+
+// SetCacheKcpInformers implements the WantsCacheKcpInformers interface.
+func (o *apiBindingAdmission) SetCacheKcpInformers(f kcpinformers.SharedInformerFactory) {
+	apiExportInformer := f.Apis().V1alpha1().APIExports()
+	indexers.AddIfNotPresentOrDie(apiExportInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.APIExportByIdentity: indexers.IndexAPIExportByIdentity,
+	})
+
+	o.getCachedAPIExportsByIdentity = func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+		return indexers.ByIndex[*apisv1alpha1.APIExport](apiExportInformer.Informer().GetIndexer(), indexers.APIExportByIdentity, identityHash)
+	}
+}
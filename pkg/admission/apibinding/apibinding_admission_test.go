@@ -22,6 +22,7 @@ import (
 	"errors"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
@@ -104,6 +105,23 @@ func TestAdmit(t *testing.T) {
 			expectedObject: helpers.ToUnstructuredOrDie(newAPIBinding().withName("test").withAbsoluteWorkspaceReference("root:org:ws", "someExport").
 				withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:org:ws:someExport")).APIBinding),
 		},
+		{
+			name: "Create: with unresolved identity reference",
+			attr: createAttr(
+				newAPIBinding().withName("test").withIdentityReference("abc").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:org:ws:someExport")).APIBinding,
+			),
+			expectedObject: helpers.ToUnstructuredOrDie(newAPIBinding().withName("test").withIdentityReference("abc").APIBinding),
+		},
+		{
+			name: "Update: with resolved identity reference",
+			attr: updateAttr(
+				newAPIBinding().withIdentityReference("abc").withBoundExport("root:provider", "someExport").APIBinding,
+				newAPIBinding().withIdentityReference("abc").withBoundExport("root:provider", "someExport").APIBinding,
+			),
+			expectedObject: helpers.ToUnstructuredOrDie(newAPIBinding().withIdentityReference("abc").withBoundExport("root:provider", "someExport").
+				withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:provider:someExport")).APIBinding),
+		},
 		{
 			name: "Update: with absolute workspace reference",
 			attr: updateAttr(
@@ -180,7 +198,7 @@ func TestValidate(t *testing.T) {
 			attr: createAttr(
 				newAPIBinding().withName("test").APIBinding,
 			),
-			expectedErrors: []string{".spec.reference.workspace is required"},
+			expectedErrors: []string{".spec.reference.workspace or .spec.reference.identity is required"},
 		},
 		{
 			name: "Create: missing workspace reference fails",
@@ -204,6 +222,48 @@ func TestValidate(t *testing.T) {
 			),
 			authzDecision: authorizer.DecisionAllow,
 		},
+		{
+			name: "Create: scheduled upgrade policy passes with delay",
+			attr: createAttr(
+				newAPIBinding().withName("test").withAbsoluteWorkspaceReference("root:org:workspaceName", "someExport").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:org:workspaceName:someExport")).
+					withUpgradePolicy(apisv1alpha1.UpgradePolicyScheduled, &metav1.Duration{Duration: time.Hour}).APIBinding,
+			),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "Create: scheduled upgrade policy fails without delay",
+			attr: createAttr(
+				newAPIBinding().withName("test").withAbsoluteWorkspaceReference("root:org:workspaceName", "someExport").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:org:workspaceName:someExport")).
+					withUpgradePolicy(apisv1alpha1.UpgradePolicyScheduled, nil).APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"spec.upgradePolicy.delay: Required value"},
+		},
+		{
+			name: "Create: manual upgrade policy fails with delay",
+			attr: createAttr(
+				newAPIBinding().withName("test").withAbsoluteWorkspaceReference("root:org:workspaceName", "someExport").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:org:workspaceName:someExport")).
+					withUpgradePolicy(apisv1alpha1.UpgradePolicyManual, &metav1.Duration{Duration: time.Hour}).APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"spec.upgradePolicy.delay: Forbidden: only allowed when type is Scheduled"},
+		},
+		{
+			name: "Create: duplicate resources fail",
+			attr: createAttr(
+				newAPIBinding().withName("test").withAbsoluteWorkspaceReference("root:org:workspaceName", "someExport").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:org:workspaceName:someExport")).
+					withResources(
+						apisv1alpha1.GroupResource{Group: "wild.west", Resource: "cowboys"},
+						apisv1alpha1.GroupResource{Group: "wild.west", Resource: "cowboys"},
+					).APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"spec.resources[1]: Duplicate value"},
+		},
 		{
 			name: "Create: complete root absolute workspace reference passes when authorized",
 			attr: createAttr(
@@ -358,6 +418,90 @@ func TestValidate(t *testing.T) {
 			authzError:     errors.New("some error here"),
 			expectedErrors: []string{"unable to determine access to apiexports: some error here"},
 		},
+		{
+			name: "Create: workspace and identity reference fails",
+			attr: createAttr(
+				newAPIBinding().withName("test").withAbsoluteWorkspaceReference("root:org:workspaceName", "someExport").withIdentityReference("abc").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:org:workspaceName:someExport")).APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"spec.reference.identity: Forbidden: must not be set together with workspace"},
+		},
+		{
+			name: "Create: identity reference passes when authorized",
+			attr: createAttr(
+				newAPIBinding().withName("test").withIdentityReference("abc").APIBinding,
+			),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "Create: identity reference fails when denied",
+			attr: createAttr(
+				newAPIBinding().withName("test").withIdentityReference("abc").APIBinding,
+			),
+			authzDecision:  authorizer.DecisionDeny,
+			expectedErrors: []string{"missing verb='bind' permission on apiexports"},
+		},
+		{
+			name: "Create: unknown identity reference fails",
+			attr: createAttr(
+				newAPIBinding().withName("test").withIdentityReference("unknown").APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"spec.reference.identity.identityHash: Not found: \"unknown\""},
+		},
+		{
+			name: "Create: identity reference of export in the cache server passes when authorized",
+			attr: createAttr(
+				newAPIBinding().withName("test").withIdentityReference("cached").APIBinding,
+			),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "Create: identity reference of export in the cache server fails when denied",
+			attr: createAttr(
+				newAPIBinding().withName("test").withIdentityReference("cached").APIBinding,
+			),
+			authzDecision:  authorizer.DecisionDeny,
+			expectedErrors: []string{"missing verb='bind' permission on apiexports"},
+		},
+		{
+			name: "Create: identity reference fails with export label",
+			attr: createAttr(
+				newAPIBinding().withName("test").withIdentityReference("abc").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:provider:someExport")).APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"must match status.boundExport"},
+		},
+		{
+			name: "Update: identity reference passes when bound export moved",
+			attr: updateAttr(
+				newAPIBinding().withIdentityReference("abc").withBoundExport("root:moved", "someExport").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:provider:someExport")).APIBinding,
+				newAPIBinding().withIdentityReference("abc").withBoundExport("root:provider", "someExport").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:provider:someExport")).APIBinding,
+			),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "Update: identity reference of export on another shard passes when authorized",
+			attr: updateAttr(
+				newAPIBinding().withIdentityReference("unknown").withBoundExport("root:provider", "someExport").APIBinding,
+				newAPIBinding().withIdentityReference("unknown").APIBinding,
+			),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "Update: identity reference fails when export label is wrong",
+			attr: updateAttr(
+				newAPIBinding().withIdentityReference("abc").withBoundExport("root:provider", "someExport").
+					withLabel(apisv1alpha1.InternalAPIBindingExportLabelKey, toSha224Base62("root:provider:someOtherExport")).APIBinding,
+				newAPIBinding().withIdentityReference("abc").withBoundExport("root:provider", "someExport").APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"must match status.boundExport"},
+		},
 		{
 			name: "Update: transition from '' to binding passes",
 			attr: updateAttr(
@@ -442,6 +586,28 @@ func TestValidate(t *testing.T) {
 						tc.authzError,
 					}, nil
 				},
+				getAPIExportsByIdentity: func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+					if identityHash != "abc" {
+						return nil, nil
+					}
+					return []*apisv1alpha1.APIExport{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "someExport",
+							Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+						},
+					}}, nil
+				},
+				getCachedAPIExportsByIdentity: func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+					if identityHash != "cached" {
+						return nil, nil
+					}
+					return []*apisv1alpha1.APIExport{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "someExport",
+							Annotations: map[string]string{logicalcluster.AnnotationKey: "root:other-shard-provider"},
+						},
+					}}, nil
+				},
 			}
 
 			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.From(tc.attr.GetObject().(metav1.Object))})
@@ -499,6 +665,23 @@ func (b *bindingBuilder) withAbsoluteWorkspaceReference(path string, exportName
 	return b
 }
 
+func (b *bindingBuilder) withIdentityReference(identityHash string) *bindingBuilder {
+	b.Spec.Reference.Identity = &apisv1alpha1.IdentityExportReference{
+		IdentityHash: identityHash,
+	}
+	return b
+}
+
+func (b *bindingBuilder) withBoundExport(path string, exportName string) *bindingBuilder {
+	b.Status.BoundAPIExport = &apisv1alpha1.ExportReference{
+		Workspace: &apisv1alpha1.WorkspaceExportReference{
+			Path:       path,
+			ExportName: exportName,
+		},
+	}
+	return b
+}
+
 func (b *bindingBuilder) withLabel(k, v string) *bindingBuilder {
 	if b.Labels == nil {
 		b.Labels = make(map[string]string)
@@ -507,6 +690,19 @@ func (b *bindingBuilder) withLabel(k, v string) *bindingBuilder {
 	return b
 }
 
+func (b *bindingBuilder) withUpgradePolicy(policyType apisv1alpha1.UpgradePolicyType, delay *metav1.Duration) *bindingBuilder {
+	b.Spec.UpgradePolicy = &apisv1alpha1.UpgradePolicy{
+		Type:  policyType,
+		Delay: delay,
+	}
+	return b
+}
+
+func (b *bindingBuilder) withResources(resources ...apisv1alpha1.GroupResource) *bindingBuilder {
+	b.Spec.Resources = resources
+	return b
+}
+
 func (b *bindingBuilder) withPhase(phase apisv1alpha1.APIBindingPhaseType) *bindingBuilder {
 	b.Status.Phase = phase
 	return b
@@ -29,6 +29,8 @@ func ValidateAPIBinding(apiBinding *apisv1alpha1.APIBinding) field.ErrorList {
 	allErrs := field.ErrorList{}
 
 	allErrs = append(allErrs, ValidateAPIBindingReference(apiBinding.Spec.Reference, field.NewPath("spec", "reference"))...)
+	allErrs = append(allErrs, ValidateUpgradePolicy(apiBinding.Spec.UpgradePolicy, field.NewPath("spec", "upgradePolicy"))...)
+	allErrs = append(allErrs, ValidateResources(apiBinding.Spec.Resources, field.NewPath("spec", "resources"))...)
 
 	return allErrs
 }
@@ -55,7 +57,15 @@ func ValidateAPIBindingUpdate(oldBinding, newBinding *apisv1alpha1.APIBinding) f
 func ValidateAPIBindingReference(reference apisv1alpha1.ExportReference, path *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
-	// For now, workspace is required via OpenAPI. But just in case...
+	// Exactly one reference is required via OpenAPI. But just in case...
+	if reference.Workspace != nil && reference.Identity != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("identity"), "must not be set together with workspace"))
+	}
+
+	if identity := reference.Identity; identity != nil && identity.IdentityHash == "" {
+		allErrs = append(allErrs, field.Required(path.Child("identity").Child("identityHash"), ""))
+	}
+
 	if workspace := reference.Workspace; workspace != nil {
 		// These are required by OpenAPI, but just in case...
 		if workspace.Path == "" {
@@ -69,3 +79,45 @@ func ValidateAPIBindingReference(reference apisv1alpha1.ExportReference, path *f
 
 	return allErrs
 }
+
+// ValidateUpgradePolicy validates an APIBinding's UpgradePolicy.
+func ValidateUpgradePolicy(policy *apisv1alpha1.UpgradePolicy, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if policy == nil {
+		return allErrs
+	}
+
+	if policy.ApprovedGeneration < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("approvedGeneration"), policy.ApprovedGeneration, "must be non-negative"))
+	}
+
+	if policy.Type == apisv1alpha1.UpgradePolicyScheduled {
+		if policy.Delay == nil {
+			allErrs = append(allErrs, field.Required(path.Child("delay"), "required when type is Scheduled"))
+		} else if policy.Delay.Duration < 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("delay"), policy.Delay.Duration.String(), "must be non-negative"))
+		}
+	} else if policy.Delay != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("delay"), "only allowed when type is Scheduled"))
+	}
+
+	return allErrs
+}
+
+// ValidateResources validates the resources selected by an APIBinding.
+func ValidateResources(resources []apisv1alpha1.GroupResource, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	seen := map[apisv1alpha1.GroupResource]bool{}
+	for i, r := range resources {
+		if r.Resource == "" {
+			allErrs = append(allErrs, field.Required(path.Index(i).Child("resource"), ""))
+		}
+		if seen[r] {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i), r))
+		}
+		seen[r] = true
+	}
+
+	return allErrs
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingquota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+const (
+	PluginName = "apis.kcp.dev/APIBindingQuota"
+
+	// maxChargeAttempts is the number of attempts to charge an object against the quota usage of
+	// an APIBinding on conflicts.
+	maxChargeAttempts = 5
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName, func(configFile io.Reader) (admission.Interface, error) {
+		return NewAPIBindingQuota(), nil
+	})
+}
+
+type apiBindingQuota struct {
+	*admission.Handler
+
+	apiBindingsHasSynced cache.InformerSynced
+
+	listAPIBindings  func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error)
+	getAPIBinding    func(ctx context.Context, clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error)
+	updateAPIBinding func(ctx context.Context, clusterName logicalcluster.Name, apiBinding *apisv1alpha1.APIBinding) error
+}
+
+var _ admission.ValidationInterface = &apiBindingQuota{}
+var _ admission.InitializationValidator = &apiBindingQuota{}
+
+// NewAPIBindingQuota creates an admission plugin that rejects the creation of objects of bound resources
+// beyond the quota of their APIBinding. Admitted objects are charged against the quotaUsage in the
+// status of the APIBinding, such that concurrent creations cannot exceed the quota.
+func NewAPIBindingQuota() admission.ValidationInterface {
+	p := &apiBindingQuota{
+		Handler: admission.NewHandler(admission.Create),
+	}
+
+	p.SetReadyFunc(
+		func() bool {
+			return p.apiBindingsHasSynced()
+		},
+	)
+
+	return p
+}
+
+func (q *apiBindingQuota) Validate(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) error {
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	gr := a.GetResource().GroupResource()
+	apiBindings, err := q.listAPIBindings(clusterName)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error listing APIBindings: %w", err))
+	}
+	apiBinding := bindingFor(apiBindings, gr.Group, gr.Resource)
+	if apiBinding == nil {
+		return nil
+	}
+	name := apiBinding.Name
+
+	for attempt := 0; attempt < maxChargeAttempts; attempt++ {
+		maxObjects, found := quota(apiBinding, gr.Group, gr.Resource)
+		if !found {
+			return nil
+		}
+
+		apiBinding = apiBinding.DeepCopy()
+		usage := usageFor(apiBinding, gr.Group, gr.Resource, maxObjects)
+		if usage.ObjectCount >= maxObjects {
+			return admission.NewForbidden(a, fmt.Errorf("exceeded quota of APIBinding %s: %s limited to %d objects", name, gr, maxObjects))
+		}
+		if a.IsDryRun() {
+			return nil
+		}
+
+		usage.ObjectCount++
+		err := q.updateAPIBinding(ctx, clusterName, apiBinding)
+		if err == nil {
+			return nil
+		}
+		if !apierrors.IsConflict(err) {
+			return admission.NewForbidden(a, fmt.Errorf("error charging quota of APIBinding %s: %w", name, err))
+		}
+
+		apiBinding, err = q.getAPIBinding(ctx, clusterName, name)
+		if err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("error getting APIBinding %s: %w", name, err))
+		}
+	}
+
+	return admission.NewForbidden(a, fmt.Errorf("error charging quota of APIBinding %s: too many conflicts", name))
+}
+
+// bindingFor returns the APIBinding binding the given resource, or nil.
+func bindingFor(apiBindings []*apisv1alpha1.APIBinding, group, resource string) *apisv1alpha1.APIBinding {
+	for _, apiBinding := range apiBindings {
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if boundResource.Group == group && boundResource.Resource == resource {
+				return apiBinding
+			}
+		}
+	}
+	return nil
+}
+
+// quota returns the quota in effect for the given resource of the APIBinding, i.e. the lower of the
+// quota reported in its status and the one in its spec, and whether there is one.
+func quota(apiBinding *apisv1alpha1.APIBinding, group, resource string) (int64, bool) {
+	maxObjects, found := apiBinding.QuotaFor(group, resource)
+	for _, usage := range apiBinding.Status.QuotaUsage {
+		if usage.Group != group || usage.Resource != resource {
+			continue
+		}
+		if !found || usage.MaxObjects < maxObjects {
+			maxObjects, found = usage.MaxObjects, true
+		}
+	}
+	return maxObjects, found
+}
+
+// usageFor returns the quota usage of the given resource in the status of the APIBinding, adding it
+// if it does not exist yet.
+func usageFor(apiBinding *apisv1alpha1.APIBinding, group, resource string, maxObjects int64) *apisv1alpha1.ResourceQuotaUsage {
+	for i := range apiBinding.Status.QuotaUsage {
+		if usage := &apiBinding.Status.QuotaUsage[i]; usage.Group == group && usage.Resource == resource {
+			return usage
+		}
+	}
+	apiBinding.Status.QuotaUsage = append(apiBinding.Status.QuotaUsage, apisv1alpha1.ResourceQuotaUsage{
+		GroupResource: apisv1alpha1.GroupResource{Group: group, Resource: resource},
+		MaxObjects:    maxObjects,
+	})
+	return &apiBinding.Status.QuotaUsage[len(apiBinding.Status.QuotaUsage)-1]
+}
+
+// SetKcpInformers implements the WantsKcpInformers interface.
+func (q *apiBindingQuota) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
+	apiBindingInformer := f.Apis().V1alpha1().APIBindings()
+	q.apiBindingsHasSynced = apiBindingInformer.Informer().HasSynced
+	q.listAPIBindings = func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+		return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+	}
+}
+
+// SetKcpClusterClient implements the WantsKcpClusterClient interface.
+func (q *apiBindingQuota) SetKcpClusterClient(c kcpclient.ClusterInterface) {
+	q.getAPIBinding = func(ctx context.Context, clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error) {
+		return c.Cluster(clusterName).ApisV1alpha1().APIBindings().Get(ctx, name, metav1.GetOptions{})
+	}
+	q.updateAPIBinding = func(ctx context.Context, clusterName logicalcluster.Name, apiBinding *apisv1alpha1.APIBinding) error {
+		_, err := c.Cluster(clusterName).ApisV1alpha1().APIBindings().UpdateStatus(ctx, apiBinding, metav1.UpdateOptions{})
+		return err
+	}
+}
+
+func (q *apiBindingQuota) ValidateInitialization() error {
+	if q.apiBindingsHasSynced == nil {
+		return errors.New("missing apiBindingsHasSynced")
+	}
+	if q.listAPIBindings == nil {
+		return errors.New("missing listAPIBindings")
+	}
+	if q.updateAPIBinding == nil {
+		return errors.New("missing kcpClusterClient")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingquota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestValidate(t *testing.T) {
+	cowboys := apisv1alpha1.GroupResource{Group: "wildwest.dev", Resource: "cowboys"}
+	cowboysGVR := schema.GroupVersionResource{Group: "wildwest.dev", Version: "v1", Resource: "cowboys"}
+
+	newBinding := func(specQuota *int64, usage ...apisv1alpha1.ResourceQuotaUsage) *apisv1alpha1.APIBinding {
+		b := &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "wildwest"},
+			Status: apisv1alpha1.APIBindingStatus{
+				BoundResources: []apisv1alpha1.BoundAPIResource{{Group: cowboys.Group, Resource: cowboys.Resource}},
+				QuotaUsage:     usage,
+			},
+		}
+		if specQuota != nil {
+			b.Spec.Quotas = []apisv1alpha1.ResourceQuota{{GroupResource: cowboys, MaxObjects: *specQuota}}
+		}
+		return b
+	}
+	int64Ptr := func(i int64) *int64 { return &i }
+
+	tests := map[string]struct {
+		resource   schema.GroupVersionResource
+		apiBinding *apisv1alpha1.APIBinding
+		conflicts  int
+		dryRun     bool
+
+		wantError bool
+		wantCount int64 // -1 if no update is expected
+	}{
+		"resource not bound": {
+			resource:   schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			apiBinding: newBinding(nil, apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 1, ObjectCount: 1}),
+			wantCount:  -1,
+		},
+		"no quota": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(nil),
+			wantCount:  -1,
+		},
+		"below quota": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(nil, apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 499}),
+			wantCount:  500,
+		},
+		"quota exceeded": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(nil, apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 500}),
+			wantError:  true,
+			wantCount:  -1,
+		},
+		"tightened quota in the spec applies before the status is updated": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(int64Ptr(10), apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 10}),
+			wantError:  true,
+			wantCount:  -1,
+		},
+		"quota in the spec without usage yet": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(int64Ptr(10)),
+			wantCount:  1,
+		},
+		"dry run is not charged": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(nil, apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 1}),
+			dryRun:     true,
+			wantCount:  -1,
+		},
+		"conflicts are retried": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(nil, apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 1}),
+			conflicts:  2,
+			wantCount:  2,
+		},
+		"too many conflicts": {
+			resource:   cowboysGVR,
+			apiBinding: newBinding(nil, apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 1}),
+			conflicts:  maxChargeAttempts,
+			wantError:  true,
+			wantCount:  -1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			conflicts := tc.conflicts
+			var updated *apisv1alpha1.APIBinding
+			q := &apiBindingQuota{
+				Handler: admission.NewHandler(admission.Create),
+				listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+					return []*apisv1alpha1.APIBinding{tc.apiBinding}, nil
+				},
+				getAPIBinding: func(ctx context.Context, clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error) {
+					return tc.apiBinding, nil
+				},
+				updateAPIBinding: func(ctx context.Context, clusterName logicalcluster.Name, apiBinding *apisv1alpha1.APIBinding) error {
+					if conflicts > 0 {
+						conflicts--
+						return apierrors.NewConflict(apisv1alpha1.Resource("apibindings"), apiBinding.Name, nil)
+					}
+					updated = apiBinding
+					return nil
+				},
+			}
+
+			obj := &unstructured.Unstructured{}
+			obj.SetName("billy")
+			a := admission.NewAttributesRecord(obj, nil, schema.GroupVersionKind{}, "default", "billy", tc.resource, "", admission.Create, &metav1.CreateOptions{}, tc.dryRun, &user.DefaultInfo{})
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:consumer")})
+
+			err := q.Validate(ctx, a, nil)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.wantCount < 0 {
+				require.Nil(t, updated)
+				return
+			}
+			require.NotNil(t, updated)
+			require.Len(t, updated.Status.QuotaUsage, 1)
+			require.Equal(t, tc.wantCount, updated.Status.QuotaUsage[0].ObjectCount)
+			require.NotSame(t, tc.apiBinding, updated, "the informer copy must not be mutated")
+		})
+	}
+}
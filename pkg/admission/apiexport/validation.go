@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/validation/path"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingroles"
+)
+
+var supportedClaimVerbs = sets.NewString("get", "list", "watch", "create", "update", "patch", "delete", "deletecollection", "*")
+
+// ValidateAPIExport validates an APIExport.
+func ValidateAPIExport(apiExport *apisv1alpha1.APIExport) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	claimsPath := field.NewPath("spec", "permissionClaims")
+	for i := range apiExport.Spec.PermissionClaims {
+		allErrs = append(allErrs, ValidatePermissionClaim(&apiExport.Spec.PermissionClaims[i], claimsPath.Index(i))...)
+	}
+
+	templatesPath := field.NewPath("spec", "clusterRoleTemplates")
+	groupResources := exportedAndClaimedGroupResources(apiExport)
+	for i := range apiExport.Spec.ClusterRoleTemplates {
+		allErrs = append(allErrs, ValidateClusterRoleTemplate(&apiExport.Spec.ClusterRoleTemplates[i], groupResources, templatesPath.Index(i))...)
+	}
+
+	return allErrs
+}
+
+// ValidateClusterRoleTemplate validates a ClusterRoleTemplate of an APIExport. It must neither carry
+// aggregation labels nor grant access to anything but the given group resources, i.e. the exported
+// resources and the permission claims of the APIExport.
+func ValidateClusterRoleTemplate(template *apisv1alpha1.ClusterRoleTemplate, groupResources sets.String, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for k := range template.Labels {
+		if apibindingroles.IsAggregationLabel(k) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("labels").Key(k), "aggregation labels are not allowed, use spec.roleAggregation instead"))
+		}
+	}
+
+	for i, rule := range template.Rules {
+		if !apibindingroles.RuleCoveredBy(rule, groupResources) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("rules").Index(i), "must only grant access to the exported resources and the permission claims, without wildcards"))
+		}
+	}
+
+	return allErrs
+}
+
+// exportedAndClaimedGroupResources returns the group resources of the latest resource schemas and of
+// the permission claims of the APIExport, in the format of schema.GroupResource.String().
+func exportedAndClaimedGroupResources(apiExport *apisv1alpha1.APIExport) sets.String {
+	groupResources := sets.NewString()
+	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
+		// APIResourceSchemas are named <prefix>.<resource>.<group>, with group "core" for the core group.
+		parts := strings.SplitN(schemaName, ".", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		group := parts[2]
+		if group == "core" {
+			group = ""
+		}
+		groupResources.Insert(schema.GroupResource{Group: group, Resource: parts[1]}.String())
+	}
+	for _, claim := range apiExport.Spec.PermissionClaims {
+		groupResources.Insert(schema.GroupResource{Group: claim.Group, Resource: claim.Resource}.String())
+	}
+	return groupResources
+}
+
+// ValidatePermissionClaim validates a PermissionClaim of an APIExport.
+func ValidatePermissionClaim(claim *apisv1alpha1.PermissionClaim, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if claim.Resource == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("resource"), ""))
+	}
+
+	for i, verb := range claim.Verbs {
+		if !supportedClaimVerbs.Has(verb) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("verbs").Index(i), verb, supportedClaimVerbs.List()))
+		}
+	}
+
+	if selector := claim.ResourceSelector; selector != nil {
+		selectorPath := fldPath.Child("resourceSelector")
+		for i, name := range selector.Names {
+			if name == "" {
+				allErrs = append(allErrs, field.Required(selectorPath.Child("names").Index(i), ""))
+				continue
+			}
+			for _, msg := range path.IsValidPathSegmentName(name) {
+				allErrs = append(allErrs, field.Invalid(selectorPath.Child("names").Index(i), name, msg))
+			}
+		}
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(selector.LabelSelector, selectorPath.Child("labelSelector"))...)
+	}
+
+	return allErrs
+}
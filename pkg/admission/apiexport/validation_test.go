@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestValidateAPIExport(t *testing.T) {
+	tests := map[string]struct {
+		claim      apisv1alpha1.PermissionClaim
+		wantErrors []string
+	}{
+		"core resource claim restricted by labels": {
+			claim: apisv1alpha1.PermissionClaim{
+				GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"},
+				Verbs:         []string{"get", "list", "watch"},
+				ResourceSelector: &apisv1alpha1.ResourceSelector{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "my-service"},
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"backend"}},
+						},
+					},
+				},
+			},
+		},
+		"claim restricted by names": {
+			claim: apisv1alpha1.PermissionClaim{
+				GroupResource:    apisv1alpha1.GroupResource{Resource: "secrets"},
+				ResourceSelector: &apisv1alpha1.ResourceSelector{Names: []string{"credentials"}},
+			},
+		},
+		"missing resource": {
+			claim: apisv1alpha1.PermissionClaim{},
+			wantErrors: []string{
+				"spec.permissionClaims[0].resource: Required value",
+			},
+		},
+		"unsupported verb": {
+			claim: apisv1alpha1.PermissionClaim{
+				GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"},
+				Verbs:         []string{"get", "escalate"},
+			},
+			wantErrors: []string{
+				`spec.permissionClaims[0].verbs[1]: Unsupported value: "escalate"`,
+			},
+		},
+		"invalid names": {
+			claim: apisv1alpha1.PermissionClaim{
+				GroupResource:    apisv1alpha1.GroupResource{Resource: "configmaps"},
+				ResourceSelector: &apisv1alpha1.ResourceSelector{Names: []string{"", "a/b"}},
+			},
+			wantErrors: []string{
+				"spec.permissionClaims[0].resourceSelector.names[0]: Required value",
+				`spec.permissionClaims[0].resourceSelector.names[1]: Invalid value: "a/b"`,
+			},
+		},
+		"invalid label selector": {
+			claim: apisv1alpha1.PermissionClaim{
+				GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"},
+				ResourceSelector: &apisv1alpha1.ResourceSelector{
+					LabelSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "tier", Operator: metav1.LabelSelectorOpExists, Values: []string{"backend"}},
+						},
+					},
+				},
+			},
+			wantErrors: []string{
+				"spec.permissionClaims[0].resourceSelector.labelSelector.matchExpressions[0].values: Forbidden",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			apiExport := &apisv1alpha1.APIExport{
+				Spec: apisv1alpha1.APIExportSpec{
+					PermissionClaims: []apisv1alpha1.PermissionClaim{tc.claim},
+				},
+			}
+
+			errs := ValidateAPIExport(apiExport)
+			require.Len(t, errs, len(tc.wantErrors), "unexpected errors: %v", errs)
+			for i, want := range tc.wantErrors {
+				require.Contains(t, errs[i].Error(), want)
+			}
+		})
+	}
+}
+
+func TestValidateClusterRoleTemplates(t *testing.T) {
+	tests := map[string]struct {
+		template   apisv1alpha1.ClusterRoleTemplate
+		wantErrors []string
+	}{
+		"exported and claimed resources": {
+			template: apisv1alpha1.ClusterRoleTemplate{
+				Name:   "edit",
+				Labels: map[string]string{"example.io/role": "edit"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{"example.io"}, Resources: []string{"widgets", "widgets/status"}, Verbs: []string{"*"}},
+					{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+				},
+			},
+		},
+		"aggregation label": {
+			template: apisv1alpha1.ClusterRoleTemplate{
+				Name:   "admin",
+				Labels: map[string]string{"rbac.authorization.k8s.io/aggregate-to-admin": "true"},
+			},
+			wantErrors: []string{
+				"spec.clusterRoleTemplates[0].labels[rbac.authorization.k8s.io/aggregate-to-admin]: Forbidden",
+			},
+		},
+		"resources neither exported nor claimed": {
+			template: apisv1alpha1.ClusterRoleTemplate{
+				Name: "escalate",
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+					{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+					{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, Verbs: []string{"escalate"}},
+					{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+				},
+			},
+			wantErrors: []string{
+				"spec.clusterRoleTemplates[0].rules[0]: Forbidden",
+				"spec.clusterRoleTemplates[0].rules[1]: Forbidden",
+				"spec.clusterRoleTemplates[0].rules[2]: Forbidden",
+				"spec.clusterRoleTemplates[0].rules[3]: Forbidden",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			apiExport := &apisv1alpha1.APIExport{
+				Spec: apisv1alpha1.APIExportSpec{
+					LatestResourceSchemas: []string{"today.widgets.example.io"},
+					PermissionClaims: []apisv1alpha1.PermissionClaim{
+						{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+					},
+					ClusterRoleTemplates: []apisv1alpha1.ClusterRoleTemplate{tc.template},
+				},
+			}
+
+			errs := ValidateAPIExport(apiExport)
+			require.Len(t, errs, len(tc.wantErrors), "unexpected errors: %v", errs)
+			for i, want := range tc.wantErrors {
+				require.Contains(t, errs[i].Error(), want)
+			}
+		})
+	}
+}
@@ -27,6 +27,9 @@ import (
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	genericfeatures "k8s.io/apiserver/pkg/features"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
 	"sigs.k8s.io/yaml"
 
 	"github.com/kcp-dev/kcp/pkg/admission/helpers"
@@ -69,9 +72,10 @@ func updateAttr(s, old *apisv1alpha1.APIResourceSchema) admission.Attributes {
 
 func TestValidate(t *testing.T) {
 	tests := []struct {
-		name           string
-		attr           admission.Attributes
-		expectedErrors []string
+		name                         string
+		attr                         admission.Attributes
+		disableValidationExpressions bool
+		expectedErrors               []string
 	}{
 		{
 			name: "an APIResourceSchema can pass admission",
@@ -181,9 +185,353 @@ spec:
 				"spec.group: Invalid value: \"core\": must be empty string for the core group",
 			},
 		},
+		{
+			name: "an APIResourceSchema with webhook conversion can pass admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  conversion:
+    strategy: Webhook
+    webhook:
+      clientConfig:
+        url: https://converter.wild.west/convert
+      conversionReviewVersions:
+      - v1
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+  - name: v2
+    served: true
+    storage: false
+    schema:
+      type: object
+            `)),
+		},
+		{
+			name: "an APIResourceSchema with invalid webhook conversion fails admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  conversion:
+    strategy: Webhook
+    webhook:
+      clientConfig:
+        url: http://converter.wild.west/convert
+      conversionReviewVersions:
+      - v0
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+            `)),
+			expectedErrors: []string{
+				"spec.conversion.webhook.clientConfig.url: Invalid value: \"http\": 'https' is the only allowed URL scheme",
+				"spec.conversion.webhook.conversionReviewVersions: Invalid value: []string{\"v0\"}: must include at least one of v1, v1beta1",
+			},
+		},
+		{
+			name: "an APIResourceSchema with validation rules and defaults can pass admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          x-kubernetes-validations:
+          - rule: self.horses <= self.limit
+            message: horses must not exceed the limit
+          properties:
+            horses:
+              type: integer
+              default: 1
+            limit:
+              type: integer
+            `)),
+		},
+		{
+			name: "an APIResourceSchema with an invalid validation rule fails admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          x-kubernetes-validations:
+          - rule: self.cows <= self.limit
+            message: horses must not exceed the limit
+          properties:
+            horses:
+              type: integer
+              default: 1
+            limit:
+              type: integer
+            `)),
+			expectedErrors: []string{
+				"spec.versions[0].schema.openAPIV3Schema.properties[spec].x-kubernetes-validations[0].rule: Invalid value",
+			},
+		},
+		{
+			name: "an APIResourceSchema with a default not matching the schema fails admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          x-kubernetes-validations:
+          - rule: self.horses <= self.limit
+            message: horses must not exceed the limit
+          properties:
+            horses:
+              type: integer
+              default: "many"
+            limit:
+              type: integer
+            `)),
+			expectedErrors: []string{
+				"spec.versions[0].schema.openAPIV3Schema.properties[spec].properties[horses].default: Invalid value",
+			},
+		},
+		{
+			name: "an APIResourceSchema with validation rules fails admission if validation expressions are disabled",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          x-kubernetes-validations:
+          - rule: self.horses <= self.limit
+            message: horses must not exceed the limit
+          properties:
+            horses:
+              type: integer
+              default: 1
+            limit:
+              type: integer
+            `)),
+			disableValidationExpressions: true,
+			expectedErrors: []string{
+				"spec.versions[0].schema: Forbidden: x-kubernetes-validations require the CustomResourceValidationExpressions feature gate",
+			},
+		},
+		{
+			name: "an APIResourceSchema with custom subresources can pass admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        spec:
+          type: object
+    customSubresources:
+    - name: approve
+      verbs: ["get", "update", "patch"]
+      schema:
+        type: object
+        properties:
+          approver:
+            type: string
+            `)),
+		},
+		{
+			name: "an APIResourceSchema with invalid custom subresources fails admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        spec:
+          type: object
+    customSubresources:
+    - name: status
+      verbs: ["get"]
+      schema:
+        type: object
+    - name: Approve
+      verbs: ["create"]
+      schema:
+        type: object
+    - name: bind
+      verbs: ["get", "get"]
+            `)),
+			expectedErrors: []string{
+				"spec.versions[0].customSubresources[0].name: Invalid value: \"status\": is reserved",
+				"spec.versions[0].customSubresources[1].name: Invalid value: \"Approve\": must match ^[a-z]([-a-z0-9]*[a-z0-9])?$",
+				"spec.versions[0].customSubresources[1].verbs[0]: Unsupported value: \"create\": supported values: \"get\", \"patch\", \"update\"",
+				"spec.versions[0].customSubresources[2].verbs[1]: Duplicate value: \"get\"",
+				"spec.versions[0].customSubresources[2].schema: Required value: schemas are required",
+			},
+		},
+		{
+			name: "an APIResourceSchema with custom subresources shadowing or failing the schema fails admission",
+			attr: createAttr(unmarshalOrDie(`
+apiVersion: apis.kcp.sh/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: july.cowboys.wild.west
+spec:
+  group: wild.west
+  names:
+    plural: cowboys
+    singular: cowboy
+    kind: Cowboy
+    listKind: CowboyList
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        approve:
+          type: object
+    customSubresources:
+    - name: approve
+      verbs: ["update"]
+      schema:
+        type: object
+  - name: v2
+    served: true
+    storage: false
+    schema:
+      type: object
+    customSubresources:
+    - name: approve
+      verbs: ["update"]
+      schema:
+        type: thing
+            `)),
+			expectedErrors: []string{
+				"spec.versions[0].customSubresources[0].name: Invalid value: \"approve\": must not be a property of the schema",
+				"spec.versions[1].schema.openAPIV3Schema.properties[approve].type: Unsupported value: \"thing\"",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, genericfeatures.CustomResourceValidationExpressions, !tt.disableValidationExpressions)()
+
 			o := &apiResourceSchemaValidation{
 				Handler: admission.NewHandler(admission.Create, admission.Update),
 			}
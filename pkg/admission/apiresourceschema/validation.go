@@ -32,6 +32,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	genericfeatures "k8s.io/apiserver/pkg/features"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/apiserver/pkg/util/webhook"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 )
@@ -39,6 +42,10 @@ import (
 var (
 	namePrefixRE                 = regexp.MustCompile("^[a-z]([-a-z0-9]*[a-z0-9])?$")
 	singleSegmentGroupExceptions = sets.NewString("apps", "batch", "extensions", "policy", "autoscaling") // these are the sins of Kubernetes of single-word group names
+
+	// reservedCustomSubresourceNames are subresources and top-level fields that custom subresources must not shadow.
+	reservedCustomSubresourceNames  = sets.NewString("apiVersion", "kind", "metadata", "spec", "status", "scale")
+	supportedCustomSubresourceVerbs = sets.NewString("get", "update", "patch")
 )
 
 // ValidateAPIResourceSchema validates an APIResourceSchema.
@@ -156,8 +163,50 @@ func ValidateAPIResourceSchemaSpec(ctx context.Context, spec *apisv1alpha1.APIRe
 		allErrs = append(allErrs, crdvalidation.ValidateCustomResourceDefinitionNames(&crdNames, fldPath.Child("names"))...)
 	}
 
+	allErrs = append(allErrs, ValidateCustomResourceConversion(spec.Conversion, fldPath.Child("conversion"))...)
+
 	// TODO(sttts): validate predecessors
-	// TODO(sttts): validate conversions
+
+	return allErrs
+}
+
+var acceptedConversionReviewVersions = sets.NewString("v1", "v1beta1")
+
+// ValidateCustomResourceConversion validates the conversion of an APIResourceSchema.
+func ValidateCustomResourceConversion(conversion *apisv1alpha1.CustomResourceConversion, fldPath *field.Path) field.ErrorList {
+	if conversion == nil {
+		return nil
+	}
+
+	allErrs := field.ErrorList{}
+	switch conversion.Strategy {
+	case apisv1alpha1.NoneConverter:
+		if conversion.Webhook != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("webhook"), "should not be set when strategy is not set to Webhook"))
+		}
+	case apisv1alpha1.WebhookConverter:
+		if conversion.Webhook == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("webhook"), "required when strategy is set to Webhook"))
+			break
+		}
+		allErrs = append(allErrs, webhook.ValidateWebhookURL(fldPath.Child("webhook", "clientConfig", "url"), conversion.Webhook.ClientConfig.URL, true)...)
+
+		versionsPath := fldPath.Child("webhook", "conversionReviewVersions")
+		versions := sets.NewString()
+		for i, v := range conversion.Webhook.ConversionReviewVersions {
+			if versions.Has(v) {
+				allErrs = append(allErrs, field.Duplicate(versionsPath.Index(i), v))
+			}
+			versions.Insert(v)
+		}
+		if len(conversion.Webhook.ConversionReviewVersions) == 0 {
+			allErrs = append(allErrs, field.Required(versionsPath, "must contain at least one version"))
+		} else if !versions.HasAny(acceptedConversionReviewVersions.List()...) {
+			allErrs = append(allErrs, field.Invalid(versionsPath, conversion.Webhook.ConversionReviewVersions, fmt.Sprintf("must include at least one of %s", strings.Join(acceptedConversionReviewVersions.List(), ", "))))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("strategy"), conversion.Strategy, []string{string(apisv1alpha1.NoneConverter), string(apisv1alpha1.WebhookConverter)}))
+	}
 
 	return allErrs
 }
@@ -185,6 +234,9 @@ func ValidateAPIResourceVersion(ctx context.Context, version *apisv1alpha1.APIRe
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("deprecationWarning"), version.DeprecationWarning, err))
 	}
 
+	customSubresourcesErrs := validateCustomSubresources(version.CustomSubresources, fldPath.Child("customSubresources"))
+	allErrs = append(allErrs, customSubresourcesErrs...)
+
 	if len(version.Schema.Raw) == 0 || string(version.Schema.Raw) == "null" {
 		allErrs = append(allErrs, field.Required(fldPath.Child("schema"), "schemas are required"))
 	} else {
@@ -193,10 +245,17 @@ func ValidateAPIResourceVersion(ctx context.Context, version *apisv1alpha1.APIRe
 		var crdSchemaInternal apiextensionsinternal.CustomResourceValidation
 		if err := json.Unmarshal(version.Schema.Raw, &crdSchemaV1.OpenAPIV3Schema); err != nil {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("schema"), string(version.Schema.Raw), fmt.Sprintf("invalid JSON: %v", err)))
+		} else if errs := addCustomSubresourceSchemas(version, crdSchemaV1.OpenAPIV3Schema, customSubresourcesErrs, fldPath.Child("customSubresources")); len(errs) > 0 {
+			allErrs = append(allErrs, errs...)
 		} else if err := apiextensionsv1.Convert_v1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(&crdSchemaV1, &crdSchemaInternal, nil); err != nil {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("schema"), string(version.Schema.Raw), fmt.Sprintf("invalid schema: %v", err)))
 		} else {
 			allErrs = append(allErrs, crdvalidation.ValidateCustomResourceDefinitionValidation(ctx, &crdSchemaInternal, statusEnabled, defaultValidationOpts, fldPath.Child("schema"))...)
+
+			// bound CRDs drop validation rules if the feature is disabled. Reject them instead of silently not enforcing them.
+			if !utilfeature.DefaultFeatureGate.Enabled(genericfeatures.CustomResourceValidationExpressions) && crdvalidation.SchemaHas(crdSchemaInternal.OpenAPIV3Schema, hasXValidations) {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("schema"), fmt.Sprintf("x-kubernetes-validations require the %s feature gate", genericfeatures.CustomResourceValidationExpressions)))
+			}
 		}
 	}
 
@@ -219,6 +278,77 @@ func ValidateAPIResourceVersion(ctx context.Context, version *apisv1alpha1.APIRe
 	return allErrs
 }
 
+// validateCustomSubresources validates the custom subresources of a version, apart from their
+// schemas, which are validated as part of the schema of the version.
+func validateCustomSubresources(subresources []apisv1alpha1.CustomSubresource, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	names := sets.NewString()
+	for i, subresource := range subresources {
+		subresourcePath := fldPath.Index(i)
+
+		if len(subresource.Name) == 0 {
+			allErrs = append(allErrs, field.Required(subresourcePath.Child("name"), ""))
+		} else if !namePrefixRE.MatchString(subresource.Name) {
+			allErrs = append(allErrs, field.Invalid(subresourcePath.Child("name"), subresource.Name, "must match ^[a-z]([-a-z0-9]*[a-z0-9])?$"))
+		} else if reservedCustomSubresourceNames.Has(subresource.Name) {
+			allErrs = append(allErrs, field.Invalid(subresourcePath.Child("name"), subresource.Name, "is reserved"))
+		} else if names.Has(subresource.Name) {
+			allErrs = append(allErrs, field.Duplicate(subresourcePath.Child("name"), subresource.Name))
+		}
+		names.Insert(subresource.Name)
+
+		if len(subresource.Verbs) == 0 {
+			allErrs = append(allErrs, field.Required(subresourcePath.Child("verbs"), ""))
+		}
+		verbs := sets.NewString()
+		for j, verb := range subresource.Verbs {
+			if !supportedCustomSubresourceVerbs.Has(verb) {
+				allErrs = append(allErrs, field.NotSupported(subresourcePath.Child("verbs").Index(j), verb, supportedCustomSubresourceVerbs.List()))
+			} else if verbs.Has(verb) {
+				allErrs = append(allErrs, field.Duplicate(subresourcePath.Child("verbs").Index(j), verb))
+			}
+			verbs.Insert(verb)
+		}
+
+		if len(subresource.Schema.Raw) == 0 || string(subresource.Schema.Raw) == "null" {
+			allErrs = append(allErrs, field.Required(subresourcePath.Child("schema"), "schemas are required"))
+		} else if err := json.Unmarshal(subresource.Schema.Raw, &apiextensionsv1.JSONSchemaProps{}); err != nil {
+			allErrs = append(allErrs, field.Invalid(subresourcePath.Child("schema"), string(subresource.Schema.Raw), fmt.Sprintf("invalid JSON: %v", err)))
+		}
+	}
+
+	return allErrs
+}
+
+// addCustomSubresourceSchemas adds the schemas of the custom subresources as top-level properties to
+// the schema of the version, such that they are validated together like in the bound CRD. The schemas
+// are only added if the custom subresources are valid and do not shadow properties of the schema.
+func addCustomSubresourceSchemas(version *apisv1alpha1.APIResourceVersion, schema *apiextensionsv1.JSONSchemaProps, customSubresourcesErrs field.ErrorList, fldPath *field.Path) field.ErrorList {
+	if len(version.CustomSubresources) == 0 || len(customSubresourcesErrs) > 0 {
+		return nil
+	}
+
+	allErrs := field.ErrorList{}
+	for i, subresource := range version.CustomSubresources {
+		if _, found := schema.Properties[subresource.Name]; found {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("name"), subresource.Name, "must not be a property of the schema"))
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	if err := version.AddCustomSubresourceSchemas(schema); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, version.CustomSubresources, err.Error())}
+	}
+	return nil
+}
+
+func hasXValidations(s *apiextensionsinternal.JSONSchemaProps) bool {
+	return len(s.XValidations) > 0
+}
+
 // ValidateAPIResourceSchemaUpdate validates an APIResourceSchema on update.
 func ValidateAPIResourceSchemaUpdate(ctx context.Context, s, old *apisv1alpha1.APIResourceSchema) field.ErrorList {
 	allErrs := ValidateAPIResourceSchema(ctx, s)
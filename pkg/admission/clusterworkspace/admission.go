@@ -24,6 +24,7 @@ import (
 	"io"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/validation"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,10 +34,12 @@ import (
 	kuser "k8s.io/apiserver/pkg/authentication/user"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 )
 
 // Validate ClusterWorkspace creation and updates for
 // - immutability of fields like type
+// - feature gates being workspace-scoped and only set by system:masters
 // - valid phase transitions fulfilling pre-conditions
 // - status.location.current and status.baseURL cannot be unset.
 
@@ -123,12 +126,12 @@ func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes,
 		return fmt.Errorf("failed to convert unstructured to ClusterWorkspace: %w", err)
 	}
 
+	old := &tenancyv1alpha1.ClusterWorkspace{}
 	if a.GetOperation() == admission.Update {
 		u, ok = a.GetOldObject().(*unstructured.Unstructured)
 		if !ok {
 			return fmt.Errorf("unexpected type %T", a.GetOldObject())
 		}
-		old := &tenancyv1alpha1.ClusterWorkspace{}
 		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, old); err != nil {
 			return fmt.Errorf("failed to convert unstructured to ClusterWorkspace: %w", err)
 		}
@@ -153,6 +156,15 @@ func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes,
 		}
 	}
 
+	if err := kcpfeatures.ValidateWorkspaceFeatureGates(cw.Spec.FeatureGates); err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("spec.featureGates: %w", err))
+	}
+	if isSystemMaster := sets.NewString(a.GetUserInfo().GetGroups()...).Has(kuser.SystemPrivilegedGroup); !isSystemMaster {
+		if !equality.Semantic.DeepEqual(old.Spec.FeatureGates, cw.Spec.FeatureGates) {
+			return admission.NewForbidden(a, errors.New("spec.featureGates can only be changed by system:masters"))
+		}
+	}
+
 	if a.GetOperation() == admission.Create {
 		if isSystemMaster := sets.NewString(a.GetUserInfo().GetGroups()...).Has(kuser.SystemPrivilegedGroup); !isSystemMaster {
 			userInfo, err := ClusterWorkspaceOwnerAnnotationValue(a.GetUserInfo())
@@ -625,6 +625,72 @@ func TestValidate(t *testing.T) {
 			}),
 			expectedErrors: []string{"expected user annotation experimental.tenancy.kcp.dev/owner={\"username\":\"someone\",\"uid\":\"id\",\"groups\":[\"a\",\"b\"],\"extra\":{\"one\":[\"1\",\"01\"]}}"},
 		},
+		{
+			name: "accept feature gates on create when system:masters",
+			a: createAttrWithUser(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+						Name: "foo",
+						Path: "root:org",
+					},
+					FeatureGates: map[string]bool{"KCPSyncerTunnel": true},
+				},
+			}, &user.DefaultInfo{
+				Name:   "someone",
+				Groups: []string{"system:masters"},
+			}),
+		},
+		{
+			name: "rejects feature gates that are not workspace-scoped",
+			a: createAttrWithUser(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+						Name: "foo",
+						Path: "root:org",
+					},
+					FeatureGates: map[string]bool{"KCPLocationAPI": false},
+				},
+			}, &user.DefaultInfo{
+				Name:   "someone",
+				Groups: []string{"system:masters"},
+			}),
+			expectedErrors: []string{"spec.featureGates: [KCPLocationAPI] are not workspace-scoped feature gates"},
+		},
+		{
+			name: "rejects feature gate changes when not system:masters",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{"experimental.tenancy.kcp.dev/owner": "{}"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+						Name: "foo",
+						Path: "root:org",
+					},
+					FeatureGates: map[string]bool{"KCPSyncerTunnel": true},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "test",
+						Annotations: map[string]string{"experimental.tenancy.kcp.dev/owner": "{}"},
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+							Name: "foo",
+							Path: "root:org",
+						},
+					},
+				}),
+			expectedErrors: []string{"spec.featureGates can only be changed by system:masters"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,293 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clusters"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/ClusterWorkspaceMove"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &clusterWorkspaceMove{
+				Handler:          admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: delegated.NewDelegatedAuthorizer,
+			}, nil
+		})
+}
+
+// clusterWorkspaceMove defaults and validates moves of ClusterWorkspaces requested through spec.moveTo:
+// - an unset moveTo.parent defaults to the current parent, an unset moveTo.name to the current name,
+// - moveTo cannot be set on creation, and is immutable once set,
+// - only ready workspaces without child workspaces can be moved,
+// - the target parent must exist on the same shard, and the target name must be free,
+// - the user must be allowed to create workspaces in the target parent, and to delete the moved workspace,
+// - no workspaces can be created in a workspace that is being moved.
+type clusterWorkspaceMove struct {
+	*admission.Handler
+	workspaceLister tenancylisters.ClusterWorkspaceLister
+	deepSARClient   kubernetesclient.ClusterInterface
+
+	createAuthorizer delegated.DelegatedAuthorizerFactory
+}
+
+// Ensure that the required admission interfaces are implemented.
+var (
+	_ = admission.MutationInterface(&clusterWorkspaceMove{})
+	_ = admission.ValidationInterface(&clusterWorkspaceMove{})
+	_ = admission.InitializationValidator(&clusterWorkspaceMove{})
+	_ = kcpinitializers.WantsKcpInformers(&clusterWorkspaceMove{})
+	_ = kcpinitializers.WantsDeepSARClient(&clusterWorkspaceMove{})
+)
+
+// Admit defaults the parent and the name of a newly set spec.moveTo to the current location of the workspace.
+func (o *clusterWorkspaceMove) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
+		return nil
+	}
+	if a.GetOperation() != admission.Update {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	if _, found, err := unstructured.NestedMap(u.Object, "spec", "moveTo"); err != nil || !found {
+		return err
+	}
+	old, ok := a.GetOldObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetOldObject())
+	}
+	if _, found, err := unstructured.NestedMap(old.Object, "spec", "moveTo"); err != nil || found {
+		return err // immutable once set, left to validation
+	}
+
+	if parent, _, _ := unstructured.NestedString(u.Object, "spec", "moveTo", "parent"); parent == "" {
+		if err := unstructured.SetNestedField(u.Object, clusterName.String(), "spec", "moveTo", "parent"); err != nil {
+			return err
+		}
+	}
+	if name, _, _ := unstructured.NestedString(u.Object, "spec", "moveTo", "name"); name == "" {
+		if err := unstructured.SetNestedField(u.Object, u.GetName(), "spec", "moveTo", "name"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *clusterWorkspaceMove) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	cw := &tenancyv1alpha1.ClusterWorkspace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cw); err != nil {
+		return fmt.Errorf("failed to convert unstructured to ClusterWorkspace: %w", err)
+	}
+
+	if !o.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	switch a.GetOperation() {
+	case admission.Create:
+		if cw.Spec.MoveTo != nil {
+			return admission.NewForbidden(a, errors.New("spec.moveTo cannot be set on creation"))
+		}
+
+		// the content of a workspace being moved is copied once, so nothing must be added to it
+		if parent, name := clusterName.Split(); !parent.Empty() {
+			this, err := o.workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+			if err != nil && !apierrors.IsNotFound(err) {
+				return admission.NewForbidden(a, err)
+			}
+			if this != nil && this.Spec.MoveTo != nil {
+				return admission.NewForbidden(a, fmt.Errorf("cannot create workspaces in workspace %s while it is being moved", clusterName))
+			}
+		}
+
+	case admission.Update:
+		u, ok = a.GetOldObject().(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", a.GetOldObject())
+		}
+		old := &tenancyv1alpha1.ClusterWorkspace{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, old); err != nil {
+			return fmt.Errorf("failed to convert unstructured to ClusterWorkspace: %w", err)
+		}
+
+		if old.Spec.MoveTo != nil {
+			if !equality.Semantic.DeepEqual(old.Spec.MoveTo, cw.Spec.MoveTo) {
+				return admission.NewForbidden(a, errors.New("spec.moveTo is immutable once set"))
+			}
+			return nil
+		}
+		if cw.Spec.MoveTo == nil {
+			return nil
+		}
+
+		if err := o.validateMove(ctx, a, clusterName, cw); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+
+	return nil
+}
+
+// validateMove validates a newly set spec.moveTo of the given workspace in the given cluster.
+func (o *clusterWorkspaceMove) validateMove(ctx context.Context, a admission.Attributes, clusterName logicalcluster.Name, cw *tenancyv1alpha1.ClusterWorkspace) error {
+	if cw.Spec.MoveTo.Parent == "" || cw.Spec.MoveTo.Name == "" {
+		return errors.New("spec.moveTo.parent and spec.moveTo.name must be set")
+	}
+
+	source := clusterName.Join(cw.Name)
+	targetParent := logicalcluster.New(cw.Spec.MoveTo.Parent)
+	target := targetParent.Join(cw.Spec.MoveTo.Name)
+
+	if target == source {
+		return errors.New("spec.moveTo must differ from the current location of the workspace")
+	}
+	if targetParent == source || strings.HasPrefix(targetParent.String(), source.String()+":") {
+		return errors.New("spec.moveTo.parent cannot be inside of the moved workspace")
+	}
+	if cw.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return fmt.Errorf("only workspaces in phase %s can be moved", tenancyv1alpha1.ClusterWorkspacePhaseReady)
+	}
+
+	workspaces, err := o.workspaceLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, ws := range workspaces {
+		if logicalcluster.From(ws) == source {
+			return fmt.Errorf("workspace %s has child workspaces and cannot be moved", source)
+		}
+	}
+
+	// the target workspace is created and filled by the shard of the moved workspace
+	targetParentShard := tenancyv1alpha1.RootShard
+	if grandParent, parentName := targetParent.Split(); !grandParent.Empty() {
+		parentWorkspace, err := o.workspaceLister.Get(clusters.ToClusterAwareKey(grandParent, parentName))
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("target parent workspace %s not found", targetParent)
+		} else if err != nil {
+			return err
+		}
+		targetParentShard = parentWorkspace.Status.Location.Current
+	}
+	if targetParentShard != cw.Status.Location.Current {
+		return fmt.Errorf("target parent workspace %s is not on shard %q of workspace %s", targetParent, cw.Status.Location.Current, source)
+	}
+
+	if _, err := o.workspaceLister.Get(clusters.ToClusterAwareKey(targetParent, cw.Spec.MoveTo.Name)); err == nil {
+		return fmt.Errorf("workspace %s already exists", target)
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	for _, check := range []struct {
+		clusterName logicalcluster.Name
+		verb        string
+		name        string
+	}{
+		{clusterName: targetParent, verb: "create"},
+		{clusterName: clusterName, verb: "delete", name: cw.Name},
+	} {
+		authz, err := o.createAuthorizer(check.clusterName, o.deepSARClient)
+		if err != nil {
+			return fmt.Errorf("unable to determine access to workspace %s", check.clusterName)
+		}
+		attr := authorizer.AttributesRecord{
+			User:            a.GetUserInfo(),
+			Verb:            check.verb,
+			APIGroup:        tenancyv1alpha1.SchemeGroupVersion.Group,
+			APIVersion:      tenancyv1alpha1.SchemeGroupVersion.Version,
+			Resource:        "clusterworkspaces",
+			Name:            check.name,
+			ResourceRequest: true,
+		}
+		if decision, _, err := authz.Authorize(ctx, attr); err != nil {
+			return fmt.Errorf("unable to determine access to workspace %s: %w", check.clusterName, err)
+		} else if decision != authorizer.DecisionAllow {
+			return fmt.Errorf("unable to move workspace %s to %s: missing verb=%q permission on clusterworkspaces in %s", source, target, check.verb, check.clusterName)
+		}
+	}
+
+	return nil
+}
+
+func (o *clusterWorkspaceMove) ValidateInitialization() error {
+	if o.workspaceLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs an ClusterWorkspace lister")
+	}
+	if o.deepSARClient == nil {
+		return fmt.Errorf(PluginName + " plugin needs a deep SAR client")
+	}
+	return nil
+}
+
+func (o *clusterWorkspaceMove) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.SetReadyFunc(informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().HasSynced)
+	o.workspaceLister = informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+}
+
+func (o *clusterWorkspaceMove) SetDeepSARClient(client kubernetesclient.ClusterInterface) {
+	o.deepSARClient = client
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemove
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clusters"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func createAttr(obj *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		nil,
+		tenancyv1alpha1.Kind("ClusterWorkspace").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("clusterworkspaces").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func updateAttr(obj, old *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		helpers.ToUnstructuredOrDie(old),
+		tenancyv1alpha1.Kind("ClusterWorkspace").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("clusterworkspaces").WithVersion("v1alpha1"),
+		"",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	ready := tenancyv1alpha1.ClusterWorkspaceStatus{
+		Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+		Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "root"},
+	}
+	team := newWorkspace("root:team").withStatus(ready)
+
+	tests := []struct {
+		name          string
+		path          logicalcluster.Name
+		workspaces    []*tenancyv1alpha1.ClusterWorkspace
+		attr          admission.Attributes
+		authzDecision authorizer.Decision
+		wantErr       string
+	}{
+		{
+			name: "allows workspaces without moveTo",
+			path: logicalcluster.New("root:org"),
+			attr: createAttr(newWorkspace("root:org:ws").ClusterWorkspace),
+		},
+		{
+			name:    "forbids moveTo on creation",
+			path:    logicalcluster.New("root:org"),
+			attr:    createAttr(newWorkspace("root:org:ws").withMoveTo("root:team", "ws").ClusterWorkspace),
+			wantErr: "spec.moveTo cannot be set on creation",
+		},
+		{
+			name:       "forbids creating workspaces in a workspace being moved",
+			path:       logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{newWorkspace("root:org:ws").withMoveTo("root:team", "ws").ClusterWorkspace},
+			attr:       createAttr(newWorkspace("root:org:ws:child").ClusterWorkspace),
+			wantErr:    "while it is being moved",
+		},
+		{
+			name: "forbids changing moveTo",
+			path: logicalcluster.New("root:org"),
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "other").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+			),
+			wantErr: "spec.moveTo is immutable",
+		},
+		{
+			name: "forbids unsetting moveTo",
+			path: logicalcluster.New("root:org"),
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+			),
+			wantErr: "spec.moveTo is immutable",
+		},
+		{
+			name:          "allows moving to another parent",
+			path:          logicalcluster.New("root:org"),
+			workspaces:    []*tenancyv1alpha1.ClusterWorkspace{team.ClusterWorkspace},
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+		},
+		{
+			name:          "allows renaming",
+			path:          logicalcluster.New("root:org"),
+			workspaces:    []*tenancyv1alpha1.ClusterWorkspace{newWorkspace("root:org").withStatus(ready).ClusterWorkspace},
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:org", "renamed").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+		},
+		{
+			name:          "allows moving to root on the root shard",
+			path:          logicalcluster.New("root:org"),
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+		},
+		{
+			name:          "forbids moving to the current location",
+			path:          logicalcluster.New("root:org"),
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:org", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: "must differ from the current location",
+		},
+		{
+			name:          "forbids moving into itself",
+			path:          logicalcluster.New("root:org"),
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:org:ws", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: "cannot be inside of the moved workspace",
+		},
+		{
+			name:          "forbids moving workspaces which are not ready",
+			path:          logicalcluster.New("root:org"),
+			workspaces:    []*tenancyv1alpha1.ClusterWorkspace{team.ClusterWorkspace},
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withMoveTo("root:team", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").ClusterWorkspace,
+			),
+			wantErr: "only workspaces in phase Ready can be moved",
+		},
+		{
+			name: "forbids moving workspaces with children",
+			path: logicalcluster.New("root:org"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				team.ClusterWorkspace,
+				newWorkspace("root:org:ws:child").withStatus(ready).ClusterWorkspace,
+			},
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: "has child workspaces",
+		},
+		{
+			name:          "forbids moving to a missing parent",
+			path:          logicalcluster.New("root:org"),
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: "target parent workspace root:team not found",
+		},
+		{
+			name: "forbids moving to a parent on another shard",
+			path: logicalcluster.New("root:org"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:team").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "other"},
+				}).ClusterWorkspace,
+			},
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: "is not on shard",
+		},
+		{
+			name: "forbids moving to an existing workspace",
+			path: logicalcluster.New("root:org"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				team.ClusterWorkspace,
+				newWorkspace("root:team:ws").withStatus(ready).ClusterWorkspace,
+			},
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: "workspace root:team:ws already exists",
+		},
+		{
+			name:          "forbids moving without parent or name",
+			path:          logicalcluster.New("root:org"),
+			authzDecision: authorizer.DecisionAllow,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("", "renamed").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: "spec.moveTo.parent and spec.moveTo.name must be set",
+		},
+		{
+			name:          "forbids moving without permissions",
+			path:          logicalcluster.New("root:org"),
+			workspaces:    []*tenancyv1alpha1.ClusterWorkspace{team.ClusterWorkspace},
+			authzDecision: authorizer.DecisionDeny,
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withStatus(ready).withMoveTo("root:team", "ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").withStatus(ready).ClusterWorkspace,
+			),
+			wantErr: `missing verb="create" permission`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &clusterWorkspaceMove{
+				Handler:         admission.NewHandler(admission.Create, admission.Update),
+				workspaceLister: fakeClusterWorkspaceLister(tt.workspaces),
+				createAuthorizer: func(clusterName logicalcluster.Name, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
+					return &fakeAuthorizer{tt.authzDecision}, nil
+				},
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: tt.path})
+			err := o.Validate(ctx, tt.attr, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	tests := []struct {
+		name       string
+		attr       admission.Attributes
+		wantMoveTo *tenancyv1alpha1.ClusterWorkspaceMoveTarget
+	}{
+		{
+			name: "defaults the parent to the current parent",
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withMoveTo("", "renamed").ClusterWorkspace,
+				newWorkspace("root:org:ws").ClusterWorkspace,
+			),
+			wantMoveTo: &tenancyv1alpha1.ClusterWorkspaceMoveTarget{Parent: "root:org", Name: "renamed"},
+		},
+		{
+			name: "defaults the name to the current name",
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withMoveTo("root:team", "").ClusterWorkspace,
+				newWorkspace("root:org:ws").ClusterWorkspace,
+			),
+			wantMoveTo: &tenancyv1alpha1.ClusterWorkspaceMoveTarget{Parent: "root:team", Name: "ws"},
+		},
+		{
+			name: "keeps a complete moveTo",
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withMoveTo("root:team", "renamed").ClusterWorkspace,
+				newWorkspace("root:org:ws").ClusterWorkspace,
+			),
+			wantMoveTo: &tenancyv1alpha1.ClusterWorkspaceMoveTarget{Parent: "root:team", Name: "renamed"},
+		},
+		{
+			name: "does not default an already set moveTo",
+			attr: updateAttr(
+				newWorkspace("root:org:ws").withMoveTo("", "renamed").ClusterWorkspace,
+				newWorkspace("root:org:ws").withMoveTo("root:team", "renamed").ClusterWorkspace,
+			),
+			wantMoveTo: &tenancyv1alpha1.ClusterWorkspaceMoveTarget{Name: "renamed"},
+		},
+		{
+			name: "ignores workspaces without moveTo",
+			attr: updateAttr(
+				newWorkspace("root:org:ws").ClusterWorkspace,
+				newWorkspace("root:org:ws").ClusterWorkspace,
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &clusterWorkspaceMove{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			err := o.Admit(ctx, tt.attr, nil)
+			require.NoError(t, err)
+
+			cw := &tenancyv1alpha1.ClusterWorkspace{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(tt.attr.GetObject().(*unstructured.Unstructured).Object, cw)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantMoveTo, cw.Spec.MoveTo)
+		})
+	}
+}
+
+type fakeClusterWorkspaceLister []*tenancyv1alpha1.ClusterWorkspace
+
+func (l fakeClusterWorkspaceLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspace, err error) {
+	return l.ListWithContext(context.Background(), selector)
+}
+
+func (l fakeClusterWorkspaceLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspace, err error) {
+	return l, nil
+}
+
+func (l fakeClusterWorkspaceLister) Get(name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	return l.GetWithContext(context.Background(), name)
+}
+
+func (l fakeClusterWorkspaceLister) GetWithContext(ctx context.Context, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	for _, t := range l {
+		if clusters.ToClusterAwareKey(logicalcluster.From(t), t.Name) == name {
+			return t, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspace"), name)
+}
+
+type fakeAuthorizer struct {
+	authorized authorizer.Decision
+}
+
+func (a *fakeAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorized authorizer.Decision, reason string, err error) {
+	return a.authorized, "reason", nil
+}
+
+type wsBuilder struct {
+	*tenancyv1alpha1.ClusterWorkspace
+}
+
+func newWorkspace(qualifiedName string) wsBuilder {
+	path, name := logicalcluster.New(qualifiedName).Split()
+	return wsBuilder{ClusterWorkspace: &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: path.String(),
+			},
+		},
+	}}
+}
+
+func (b wsBuilder) withStatus(status tenancyv1alpha1.ClusterWorkspaceStatus) wsBuilder {
+	b.Status = status
+	return b
+}
+
+func (b wsBuilder) withMoveTo(parent, name string) wsBuilder {
+	b.Spec.MoveTo = &tenancyv1alpha1.ClusterWorkspaceMoveTarget{Parent: parent, Name: name}
+	return b
+}
@@ -18,20 +18,29 @@ package clusterworkspacetype
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/author"
+	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 )
 
 // Validate ClusterWorkspaceTypes creation and updates for
 //  - "organization" type is only created in root workspace.
+//  - feature gates are workspace-scoped, and only set or changed by system:masters.
+//  - default objects are of allowed kinds, and the user changing them is recorded as their author.
 
 const (
 	PluginName = "tenancy.kcp.dev/ClusterWorkspaceType"
@@ -51,8 +60,26 @@ type clusterWorkspaceType struct {
 }
 
 // Ensure that the required admission interfaces are implemented.
+var _ = admission.MutationInterface(&clusterWorkspaceType{})
 var _ = admission.ValidationInterface(&clusterWorkspaceType{})
 
+// Admit records the user as the author of the default objects on create, and on updates changing them.
+func (o *clusterWorkspaceType) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspacetypes") {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	changed, err := defaultObjectsChanged(a)
+	if err != nil {
+		return err
+	}
+	return author.Admit(a, u, changed)
+}
+
 func (o *clusterWorkspaceType) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
 	if err != nil {
@@ -96,5 +123,58 @@ func (o *clusterWorkspaceType) Validate(ctx context.Context, a admission.Attribu
 		}
 	}
 
-	return nil
+	if err := kcpfeatures.ValidateWorkspaceFeatureGates(cwt.Spec.FeatureGates); err != nil {
+		return admission.NewForbidden(a, fmt.Errorf(".spec.featureGates: %w", err))
+	}
+	if isSystemMaster := sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup); !isSystemMaster {
+		var oldFeatureGates map[string]bool
+		if a.GetOperation() == admission.Update {
+			old, ok := a.GetOldObject().(*unstructured.Unstructured)
+			if !ok {
+				return fmt.Errorf("unexpected type %T", a.GetOldObject())
+			}
+			oldCWT := &tenancyv1alpha1.ClusterWorkspaceType{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(old.Object, oldCWT); err != nil {
+				return fmt.Errorf("failed to convert unstructured to ClusterWorkspaceType: %w", err)
+			}
+			oldFeatureGates = oldCWT.Spec.FeatureGates
+		}
+		if !equality.Semantic.DeepEqual(oldFeatureGates, cwt.Spec.FeatureGates) {
+			return admission.NewForbidden(a, errors.New(".spec.featureGates can only be changed by system:masters"))
+		}
+	}
+
+	if errs := author.ValidateObjects(cwt.Spec.DefaultObjects, field.NewPath("spec", "defaultObjects")); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+	changed, err := defaultObjectsChanged(a)
+	if err != nil {
+		return err
+	}
+	old, _ := a.GetOldObject().(*unstructured.Unstructured)
+	return author.Validate(a, u, old, changed)
+}
+
+// defaultObjectsChanged returns whether an update changes the default objects of the ClusterWorkspaceType.
+func defaultObjectsChanged(a admission.Attributes) (bool, error) {
+	if a.GetOperation() != admission.Update {
+		return false, nil
+	}
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	old, ok := a.GetOldObject().(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("unexpected type %T", a.GetOldObject())
+	}
+	objs, _, err := unstructured.NestedSlice(u.Object, "spec", "defaultObjects")
+	if err != nil {
+		return false, err
+	}
+	oldObjs, _, err := unstructured.NestedSlice(old.Object, "spec", "defaultObjects")
+	if err != nil {
+		return false, err
+	}
+	return !equality.Semantic.DeepEqual(objs, oldObjs), nil
 }
@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacetype
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/author"
+	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
+)
+
+func attr(cwt, old *tenancyv1alpha1.ClusterWorkspaceType, info user.Info) admission.Attributes {
+	op := admission.Create
+	var oldObj runtime.Object
+	if old != nil {
+		op = admission.Update
+		oldObj = helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(cwt),
+		oldObj,
+		tenancyv1alpha1.Kind("ClusterWorkspaceType").WithVersion("v1alpha1"),
+		"",
+		cwt.Name,
+		tenancyv1alpha1.Resource("clusterworkspacetypes").WithVersion("v1alpha1"),
+		"",
+		op,
+		&metav1.CreateOptions{},
+		false,
+		info,
+	)
+}
+
+func TestDefaultObjects(t *testing.T) {
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{user.AllAuthenticated}}
+	bob := &user.DefaultInfo{Name: "bob", Groups: []string{user.AllAuthenticated}}
+	admin := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+	aliceValue, err := author.AnnotationValue(alice)
+	require.NoError(t, err)
+
+	configMap := tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"default"}}`),
+	}}
+	secret := tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"token","namespace":"default"}}`),
+	}}
+	webhook := tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"admissionregistration.k8s.io/v1","kind":"ValidatingWebhookConfiguration","metadata":{"name":"hook"}}`),
+	}}
+	newType := func(author string, objs ...tenancyv1alpha1.DefaultObject) *tenancyv1alpha1.ClusterWorkspaceType {
+		cwt := &tenancyv1alpha1.ClusterWorkspaceType{
+			ObjectMeta: metav1.ObjectMeta{Name: "team", Annotations: map[string]string{}},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{DefaultObjects: objs},
+		}
+		if author != "" {
+			cwt.Annotations[tenancyv1alpha1.ExperimentalAuthorAnnotationKey] = author
+		}
+		return cwt
+	}
+
+	tests := []struct {
+		name       string
+		cwt, old   *tenancyv1alpha1.ClusterWorkspaceType
+		user       user.Info
+		wantAuthor string
+		wantErr    bool
+	}{
+		{
+			name:       "creator is recorded as author",
+			cwt:        newType("", configMap),
+			user:       alice,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:       "forged author is replaced",
+			cwt:        newType(`{"username":"admin","groups":["system:masters"]}`, configMap),
+			user:       alice,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:       "changing the objects makes the user the author",
+			cwt:        newType(aliceValue, configMap, configMap),
+			old:        newType(aliceValue, configMap),
+			user:       bob,
+			wantAuthor: `{"username":"bob","groups":["system:authenticated"]}`,
+		},
+		{
+			name:       "other changes keep the author",
+			cwt:        newType(aliceValue, configMap),
+			old:        newType(aliceValue, configMap),
+			user:       bob,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:       "system:masters keep the author",
+			cwt:        newType(aliceValue, configMap),
+			user:       admin,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:    "secrets are rejected",
+			cwt:     newType("", secret),
+			user:    alice,
+			wantErr: true,
+		},
+		{
+			name:    "webhook configurations are rejected",
+			cwt:     newType("", webhook),
+			user:    admin,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			o := &clusterWorkspaceType{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			a := attr(tt.cwt, tt.old, tt.user)
+			require.NoError(t, o.Admit(ctx, a, nil))
+			err := o.Validate(ctx, a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAuthor, a.GetObject().(*unstructured.Unstructured).GetAnnotations()[tenancyv1alpha1.ExperimentalAuthorAnnotationKey])
+		})
+	}
+
+	t.Run("changing the author without the objects is rejected", func(t *testing.T) {
+		ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+		o := &clusterWorkspaceType{Handler: admission.NewHandler(admission.Create, admission.Update)}
+		err := o.Validate(ctx, attr(newType(`{"username":"admin","groups":["system:masters"]}`, configMap), newType(aliceValue, configMap), bob), nil)
+		require.Error(t, err)
+	})
+}
+
+func TestFeatureGates(t *testing.T) {
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{user.AllAuthenticated}}
+	admin := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+	newType := func(gates map[string]bool) *tenancyv1alpha1.ClusterWorkspaceType {
+		return &tenancyv1alpha1.ClusterWorkspaceType{
+			ObjectMeta: metav1.ObjectMeta{Name: "team"},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{FeatureGates: gates},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		cwt, old *tenancyv1alpha1.ClusterWorkspaceType
+		user     user.Info
+		wantErr  string
+	}{
+		{
+			name: "system:masters can set workspace-scoped feature gates",
+			cwt:  newType(map[string]bool{string(kcpfeatures.SyncerTunnel): true}),
+			user: admin,
+		},
+		{
+			name:    "system:masters cannot set process-wide feature gates",
+			cwt:     newType(map[string]bool{string(kcpfeatures.LocationAPI): true}),
+			user:    admin,
+			wantErr: "are not workspace-scoped feature gates",
+		},
+		{
+			name:    "other users cannot set feature gates on create",
+			cwt:     newType(map[string]bool{string(kcpfeatures.SyncerTunnel): true}),
+			user:    alice,
+			wantErr: "can only be changed by system:masters",
+		},
+		{
+			name:    "other users cannot change feature gates",
+			cwt:     newType(map[string]bool{string(kcpfeatures.SyncerTunnel): false}),
+			old:     newType(map[string]bool{string(kcpfeatures.SyncerTunnel): true}),
+			user:    alice,
+			wantErr: "can only be changed by system:masters",
+		},
+		{
+			name: "other users can keep feature gates",
+			cwt:  newType(map[string]bool{string(kcpfeatures.SyncerTunnel): true}),
+			old:  newType(map[string]bool{string(kcpfeatures.SyncerTunnel): true}),
+			user: alice,
+		},
+		{
+			name: "other users can create types without feature gates",
+			cwt:  newType(nil),
+			user: alice,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			o := &clusterWorkspaceType{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			a := attr(tt.cwt, tt.old, tt.user)
+			require.NoError(t, o.Admit(ctx, a, nil))
+			err := o.Validate(ctx, a, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -25,7 +25,9 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
 
 	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
@@ -42,6 +45,8 @@ import (
 	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/workspacepolicy"
 )
 
 const (
@@ -66,14 +71,20 @@ func Register(plugins *admission.Plugins) {
 
 // clusterWorkspaceTypeExists  does the following
 // - it checks existence of ClusterWorkspaceType in the same workspace,
+// - it defaults the type of new ClusterWorkspaces from the WorkspacePolicies of their ancestors,
+//   or from the type of their parent,
+// - it enforces the allowed parents, allowed children and maximal child depth of the types,
+// - it adds the shard constraints of the type to new ClusterWorkspaces,
 // - it applies the ClusterWorkspaceType initializers to the ClusterWorkspace when it
-//   transitions to the Initializing state.
+//   transitions to the Initializing state, ordered by their initializerOrder,
+// - it validates the initializer parameters of the ClusterWorkspace.
 type clusterWorkspaceTypeExists struct {
 	*admission.Handler
 	typeLister             tenancylisters.ClusterWorkspaceTypeLister
 	workspaceLister        tenancylisters.ClusterWorkspaceLister
 	deepSARClient          kubernetesclient.ClusterInterface
 	transitiveTypeResolver transitiveTypeResolver
+	listPolicies           func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error)
 
 	createAuthorizer delegated.DelegatedAuthorizerFactory
 }
@@ -87,7 +98,8 @@ var (
 	_ = kcpinitializers.WantsDeepSARClient(&clusterWorkspaceTypeExists{})
 )
 
-// Admit adds type initializer on transition to initializing phase.
+// Admit adds the type initializers of the lowest order on transition to initializing phase, and
+// those of the next order when the last initializer is removed while initializing.
 func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
 	if err != nil {
@@ -115,21 +127,30 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 	}
 
 	if a.GetOperation() == admission.Create {
-		// if the user has not provided any type, use the default from the parent workspace
+		// if the user has not provided any type, use the default from the WorkspacePolicies of the
+		// ancestors, or from the type of the parent workspace
 		empty := tenancyv1alpha1.ClusterWorkspaceTypeReference{}
 		if cw.Spec.Type == empty {
-			parentTypeRef, err := o.resolveParentType(clusterName)
+			policy, err := workspacepolicy.EffectivePolicy(o.listPolicies, clusterName.Join(cw.Name))
 			if err != nil {
-				return admission.NewForbidden(a, err)
+				return admission.NewForbidden(a, fmt.Errorf("error evaluating WorkspacePolicies: %w", err))
 			}
-			parentCwt, err := o.resolveTypeRef(clusterName, parentTypeRef)
-			if err != nil {
-				return admission.NewForbidden(a, err)
-			}
-			if parentCwt == nil || parentCwt.Spec.DefaultChildWorkspaceType == nil {
-				return admission.NewForbidden(a, errors.New("spec.type must be set"))
+			if policy.DefaultType != nil {
+				cw.Spec.Type = *policy.DefaultType
+			} else {
+				parentTypeRef, err := o.resolveParentType(clusterName)
+				if err != nil {
+					return admission.NewForbidden(a, err)
+				}
+				parentCwt, err := o.resolveTypeRef(clusterName, parentTypeRef)
+				if err != nil {
+					return admission.NewForbidden(a, err)
+				}
+				if parentCwt == nil || parentCwt.Spec.DefaultChildWorkspaceType == nil {
+					return admission.NewForbidden(a, fmt.Errorf("spec.type must be set, because the type %s of the parent workspace has no defaultChildWorkspaceType", parentTypeRef.String()))
+				}
+				cw.Spec.Type = *parentCwt.Spec.DefaultChildWorkspaceType
 			}
-			cw.Spec.Type = *parentCwt.Spec.DefaultChildWorkspaceType
 		}
 		cwt, err := o.resolveTypeRef(clusterName, cw.Spec.Type)
 		if err != nil {
@@ -138,6 +159,9 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 		cw.Spec.Type.Path = logicalcluster.From(cwt).String()
 
 		addAdditionalWorkspaceLabels(cwt, cw)
+		if err := addShardConstraints(cwt, cw); err != nil {
+			return admission.NewForbidden(a, err)
+		}
 
 		return updateUnstructured(u, cw)
 	}
@@ -158,15 +182,19 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 		return fmt.Errorf("failed to convert unstructured to ClusterWorkspace: %w", err)
 	}
 
-	// we only admit at state transition to initializing
+	// we only admit at state transition to initializing, and when the last initializer of an order
+	// is removed while initializing
 	transitioningToInitializing :=
 		old.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing &&
 			cw.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseInitializing
-	if !transitioningToInitializing {
+	finishingInitializers :=
+		old.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseInitializing &&
+			cw.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseInitializing &&
+			len(old.Status.Initializers) > 0 && len(cw.Status.Initializers) == 0
+	if !transitioningToInitializing && !finishingInitializers {
 		return nil
 	}
 
-	// add initializers from type and aliases to workspace
 	cwt, err := o.resolveTypeRef(clusterName, cw.Spec.Type)
 	if err != nil {
 		return admission.NewForbidden(a, err)
@@ -175,15 +203,69 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 	if err != nil {
 		return admission.NewForbidden(a, err)
 	}
-	for _, alias := range cwtAliases {
-		if alias.Spec.Initializer {
-			cw.Status.Initializers = initialization.EnsureInitializerPresent(initialization.InitializerForType(alias), cw.Status.Initializers)
+
+	var next []tenancyv1alpha1.ClusterWorkspaceInitializer
+	if transitioningToInitializing {
+		// add the initializers of the lowest order from type and aliases to workspace
+		next = nextInitializers(cwtAliases, nil)
+	} else {
+		// all initializers of an order have finished, add those of the next order. Initializers
+		// not belonging to the type are ignored.
+		finished, found := highestOrder(cwtAliases, old.Status.Initializers)
+		if !found {
+			return nil
 		}
+		next = nextInitializers(cwtAliases, &finished)
+	}
+	for _, initializer := range next {
+		cw.Status.Initializers = initialization.EnsureInitializerPresent(initializer, cw.Status.Initializers)
 	}
 
 	return updateUnstructured(u, cw)
 }
 
+// nextInitializers returns the initializers of the given types with the lowest order
+// greater than after, or with the lowest order if after is nil.
+func nextInitializers(types []*tenancyv1alpha1.ClusterWorkspaceType, after *int32) []tenancyv1alpha1.ClusterWorkspaceInitializer {
+	var order *int32
+	for _, cwt := range types {
+		if !cwt.Spec.Initializer || (after != nil && cwt.Spec.InitializerOrder <= *after) {
+			continue
+		}
+		if order == nil || cwt.Spec.InitializerOrder < *order {
+			o := cwt.Spec.InitializerOrder
+			order = &o
+		}
+	}
+	if order == nil {
+		return nil
+	}
+
+	var initializers []tenancyv1alpha1.ClusterWorkspaceInitializer
+	for _, cwt := range types {
+		if cwt.Spec.Initializer && cwt.Spec.InitializerOrder == *order {
+			initializers = initialization.EnsureInitializerPresent(initialization.InitializerForType(cwt), initializers)
+		}
+	}
+	return initializers
+}
+
+// highestOrder returns the highest order of the initializers of the given types found in initializers.
+func highestOrder(types []*tenancyv1alpha1.ClusterWorkspaceType, initializers []tenancyv1alpha1.ClusterWorkspaceInitializer) (int32, bool) {
+	var order int32
+	var found bool
+	for _, cwt := range types {
+		if !cwt.Spec.Initializer || !initialization.InitializerPresent(initialization.InitializerForType(cwt), initializers) {
+			continue
+		}
+		if !found || cwt.Spec.InitializerOrder > order {
+			order = cwt.Spec.InitializerOrder
+			found = true
+		}
+	}
+	return order, found
+}
+
 func (o *clusterWorkspaceTypeExists) resolveTypeRef(clusterName logicalcluster.Name, ref tenancyv1alpha1.ClusterWorkspaceTypeReference) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
 	if ref.Path != "" {
 		cwt, err := o.typeLister.Get(clusters.ToClusterAwareKey(logicalcluster.New(ref.Path), tenancyv1alpha1.ObjectName(ref.Name)))
@@ -236,6 +318,7 @@ func (o *clusterWorkspaceTypeExists) resolveParentType(parentClusterName logical
 // Validate ensures that
 // - has a valid type
 // - has valid initializers when transitioning to initializing
+// - has initializer parameters only for the initializers of the type, immutable after creation
 func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
 	if err != nil {
@@ -287,6 +370,9 @@ func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.A
 		if old.Spec.Type != cw.Spec.Type {
 			return admission.NewForbidden(a, errors.New("spec.type is immutable"))
 		}
+		if !equality.Semantic.DeepEqual(old.Spec.InitializerParameters, cw.Spec.InitializerParameters) {
+			return admission.NewForbidden(a, errors.New("spec.initializerParameters is immutable"))
+		}
 	}
 
 	// check type on create and on state transition
@@ -308,13 +394,11 @@ func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.A
 
 	// check initializer from type exist
 	if a.GetOperation() == admission.Update && transitioningToInitializing {
-		// this is a transition to initializing. Check that all initializers are there
-		// (no other admission plugin removed any).
-		for _, alias := range cwtAliases {
-			if alias.Spec.Initializer {
-				if initializer := initialization.InitializerForType(alias); !initialization.InitializerPresent(initializer, cw.Status.Initializers) {
-					return admission.NewForbidden(a, fmt.Errorf("spec.initializers %q does not exist", initializer))
-				}
+		// this is a transition to initializing. Check that all initializers of the lowest order
+		// are there (no other admission plugin removed any).
+		for _, initializer := range nextInitializers(cwtAliases, nil) {
+			if !initialization.InitializerPresent(initializer, cw.Status.Initializers) {
+				return admission.NewForbidden(a, fmt.Errorf("spec.initializers %q does not exist", initializer))
 			}
 		}
 	}
@@ -325,6 +409,10 @@ func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.A
 			return admission.NewForbidden(a, fmt.Errorf("spec.type.path must be set"))
 		}
 
+		if err := validateInitializerParameters(cwtAliases, cw.Spec.InitializerParameters); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+
 		for _, alias := range cwtAliases {
 			authz, err := o.createAuthorizer(logicalcluster.From(alias), o.deepSARClient)
 			if err != nil {
@@ -367,8 +455,73 @@ func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.A
 		if err := validateAllowedChildren(parentAliases, cwtAliases, parentTypeRef.String(), cw.Spec.Type.String()); err != nil {
 			return admission.NewForbidden(a, err)
 		}
+
+		if err := o.validateMaxChildDepth(clusterName, parentTypeRef, parentAliases); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+
+	return nil
+}
+
+// validateMaxChildDepth checks that a new workspace in the given parent does not exceed the
+// maxChildDepth of the types of the parent and of its ancestors. Ancestors whose types cannot
+// be resolved, e.g. because they live on another shard, are not checked.
+func (o *clusterWorkspaceTypeExists) validateMaxChildDepth(parentClusterName logicalcluster.Name, parentTypeRef tenancyv1alpha1.ClusterWorkspaceTypeReference, parentAliases []*tenancyv1alpha1.ClusterWorkspaceType) error {
+	ancestor, typeRef, aliases := parentClusterName, parentTypeRef, parentAliases
+	for depth := int32(1); ; depth++ {
+		for _, alias := range aliases {
+			if alias.Spec.MaxChildDepth == nil || depth <= *alias.Spec.MaxChildDepth {
+				continue
+			}
+			qualifiedAlias := logicalcluster.From(alias).Join(string(tenancyv1alpha1.TypeName(alias.Name))).String()
+			extending := ""
+			if qualifiedAlias != typeRef.String() {
+				extending = fmt.Sprintf(", which extends %s,", qualifiedAlias)
+			}
+			return fmt.Errorf("workspace %s of type %s%s allows at most %d levels of nested workspaces, but the new workspace would be %d levels below it: create it higher in the hierarchy or raise maxChildDepth of %s",
+				ancestor, typeRef.String(), extending, *alias.Spec.MaxChildDepth, depth, qualifiedAlias)
+		}
+
+		var hasParent bool
+		ancestor, hasParent = ancestor.Parent()
+		if !hasParent {
+			return nil
+		}
+		var err error
+		if typeRef, err = o.resolveParentType(ancestor); err != nil {
+			return nil
+		}
+		cwt, err := o.resolveTypeRef(ancestor, typeRef)
+		if err != nil {
+			return nil
+		}
+		if aliases, err = o.transitiveTypeResolver.Resolve(cwt); err != nil {
+			return nil
+		}
 	}
+}
 
+// validateInitializerParameters checks that every parameters are passed to an initializer of the given types,
+// at most once.
+func validateInitializerParameters(types []*tenancyv1alpha1.ClusterWorkspaceType, parameters []tenancyv1alpha1.ClusterWorkspaceInitializerParameters) error {
+	initializers := sets.NewString()
+	for _, cwt := range types {
+		if cwt.Spec.Initializer {
+			initializers.Insert(string(initialization.InitializerForType(cwt)))
+		}
+	}
+
+	seen := sets.NewString()
+	for i, p := range parameters {
+		if seen.Has(string(p.Initializer)) {
+			return fmt.Errorf("spec.initializerParameters[%d].initializer %q is duplicated", i, p.Initializer)
+		}
+		seen.Insert(string(p.Initializer))
+		if !initializers.Has(string(p.Initializer)) {
+			return fmt.Errorf("spec.initializerParameters[%d].initializer %q is not an initializer of the workspace type", i, p.Initializer)
+		}
+	}
 	return nil
 }
 
@@ -379,17 +532,31 @@ func (o *clusterWorkspaceTypeExists) ValidateInitialization() error {
 	if o.workspaceLister == nil {
 		return fmt.Errorf(PluginName + " plugin needs an ClusterWorkspace lister")
 	}
+	if o.listPolicies == nil {
+		return fmt.Errorf(PluginName + " plugin needs a WorkspacePolicy lister")
+	}
 	return nil
 }
 
 func (o *clusterWorkspaceTypeExists) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
 	typesReady := informers.Tenancy().V1alpha1().ClusterWorkspaceTypes().Informer().HasSynced
 	workspacesReady := informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().HasSynced
+	workspacePolicyInformer := informers.Tenancy().V1alpha1().WorkspacePolicies()
+	indexers.AddIfNotPresentOrDie(
+		workspacePolicyInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+		},
+	)
+	policiesReady := workspacePolicyInformer.Informer().HasSynced
 	o.SetReadyFunc(func() bool {
-		return typesReady() && workspacesReady()
+		return typesReady() && workspacesReady() && policiesReady()
 	})
 	o.typeLister = informers.Tenancy().V1alpha1().ClusterWorkspaceTypes().Lister()
 	o.workspaceLister = informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+	o.listPolicies = func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+		return indexers.ByIndex[*tenancyv1alpha1.WorkspacePolicy](workspacePolicyInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+	}
 }
 
 func (o *clusterWorkspaceTypeExists) SetDeepSARClient(client kubernetesclient.ClusterInterface) {
@@ -426,6 +593,54 @@ func addAdditionalWorkspaceLabels(
 	}
 }
 
+// addShardConstraints adds the shard constraints of the type to those of the workspace. The workspace
+// cannot require another shard name or region than its type.
+func addShardConstraints(
+	cwt *tenancyv1alpha1.ClusterWorkspaceType,
+	cw *tenancyv1alpha1.ClusterWorkspace,
+) error {
+	required := cwt.Spec.Shard
+	if required == nil {
+		return nil
+	}
+	if cw.Spec.Shard == nil {
+		cw.Spec.Shard = &tenancyv1alpha1.ShardConstraints{}
+	}
+	constraints := cw.Spec.Shard
+
+	if required.Name != "" {
+		if constraints.Name != "" && constraints.Name != required.Name {
+			return fmt.Errorf("spec.shard.name %q conflicts with shard %q required by type %s", constraints.Name, required.Name, cwt.Name)
+		}
+		constraints.Name = required.Name
+	}
+	if required.Region != "" {
+		if constraints.Region != "" && constraints.Region != required.Region {
+			return fmt.Errorf("spec.shard.region %q conflicts with region %q required by type %s", constraints.Region, required.Region, cwt.Name)
+		}
+		constraints.Region = required.Region
+	}
+	constraints.SameAsParent = constraints.SameAsParent || required.SameAsParent
+
+	if required.Selector != nil {
+		if constraints.Selector == nil {
+			constraints.Selector = &metav1.LabelSelector{}
+		}
+		for key, value := range required.Selector.MatchLabels {
+			if existing, found := constraints.Selector.MatchLabels[key]; found && existing != value {
+				return fmt.Errorf("spec.shard.selector label %s=%s conflicts with %s=%s required by type %s", key, existing, key, value, cwt.Name)
+			}
+			if constraints.Selector.MatchLabels == nil {
+				constraints.Selector.MatchLabels = map[string]string{}
+			}
+			constraints.Selector.MatchLabels[key] = value
+		}
+		constraints.Selector.MatchExpressions = append(constraints.Selector.MatchExpressions, required.Selector.MatchExpressions...)
+	}
+
+	return nil
+}
+
 type transitiveTypeResolver struct {
 	getter func(cluster logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspaceType, error)
 }
@@ -482,7 +697,13 @@ func (r *transitiveTypeResolver) resolve(cwt *tenancyv1alpha1.ClusterWorkspaceTy
 func validateAllowedParents(parentAliases, childAliases []*tenancyv1alpha1.ClusterWorkspaceType, parentType, childType string) error {
 	var errs []error
 	for _, childAlias := range childAliases {
-		if childAlias.Spec.LimitAllowedParents == nil || len(childAlias.Spec.LimitAllowedParents.Types) == 0 {
+		if childAlias.Spec.LimitAllowedParents == nil {
+			continue
+		}
+		if childAlias.Spec.LimitAllowedParents.None {
+			return fmt.Errorf("workspace type %s cannot be created in any workspace", childType)
+		}
+		if len(childAlias.Spec.LimitAllowedParents.Types) == 0 {
 			continue
 		}
 
@@ -516,12 +737,15 @@ func validateAllowedParents(parentAliases, childAliases []*tenancyv1alpha1.Clust
 func validateAllowedChildren(parentAliases, childAliases []*tenancyv1alpha1.ClusterWorkspaceType, parentType, childType string) error {
 	var errs []error
 	for _, parentAlias := range parentAliases {
-		if parentAlias.Spec.LimitAllowedChildren == nil || len(parentAlias.Spec.LimitAllowedChildren.Types) == 0 {
+		if parentAlias.Spec.LimitAllowedChildren == nil {
 			continue
 		}
 		if parentAlias.Spec.LimitAllowedChildren.None {
 			return fmt.Errorf("workspace type %s cannot have any children", parentType)
 		}
+		if len(parentAlias.Spec.LimitAllowedChildren.Types) == 0 {
+			continue
+		}
 
 		qualifiedParent := logicalcluster.From(parentAlias).Join(string(tenancyv1alpha1.TypeName(parentAlias.Name))).String()
 
@@ -80,6 +80,7 @@ func TestAdmit(t *testing.T) {
 		name        string
 		types       []*tenancyv1alpha1.ClusterWorkspaceType
 		workspaces  []*tenancyv1alpha1.ClusterWorkspace
+		policies    []*tenancyv1alpha1.WorkspacePolicy
 		clusterName logicalcluster.Name
 		a           admission.Attributes
 		expectedObj runtime.Object
@@ -133,6 +134,86 @@ func TestAdmit(t *testing.T) {
 				BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
 			}).ClusterWorkspace,
 		},
+		{
+			name: "adds only initializers of the lowest order during transition to initializing",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:networking").withInitializerOrder(0).ClusterWorkspaceType,
+				newType("root:org:rbac").withInitializerOrder(1).extending("root:org:networking").ClusterWorkspaceType,
+				newType("root:org:foo").withInitializerOrder(2).extending("root:org:rbac").ClusterWorkspaceType,
+			},
+			clusterName: logicalcluster.New("root:org:ws"),
+			a: updateAttr(
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+				}).ClusterWorkspace,
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				}).ClusterWorkspace,
+			),
+			expectedObj: newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+				Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+				Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"root:org:networking"},
+				Location:     tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+				BaseURL:      "https://kcp.bigcorp.com/clusters/org:test",
+			}).ClusterWorkspace,
+		},
+		{
+			name: "adds initializers of the next order when the last initializer of an order is removed",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:networking").withInitializerOrder(0).ClusterWorkspaceType,
+				newType("root:org:rbac").withInitializerOrder(1).extending("root:org:networking").ClusterWorkspaceType,
+				newType("root:org:quota").withInitializerOrder(1).ClusterWorkspaceType,
+				newType("root:org:foo").withInitializerOrder(2).extending("root:org:rbac").extending("root:org:quota").ClusterWorkspaceType,
+			},
+			clusterName: logicalcluster.New("root:org:ws"),
+			a: updateAttr(
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+				}).ClusterWorkspace,
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"root:org:networking"},
+					Location:     tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:      "https://kcp.bigcorp.com/clusters/org:test",
+				}).ClusterWorkspace,
+			),
+			expectedObj: newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+				Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+				Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"root:org:rbac", "root:org:quota"},
+				Location:     tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+				BaseURL:      "https://kcp.bigcorp.com/clusters/org:test",
+			}).ClusterWorkspace,
+		},
+		{
+			name: "does not add initializers when the last initializer of the last order is removed",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:networking").withInitializerOrder(0).ClusterWorkspaceType,
+				newType("root:org:foo").withInitializerOrder(1).extending("root:org:networking").ClusterWorkspaceType,
+			},
+			clusterName: logicalcluster.New("root:org:ws"),
+			a: updateAttr(
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+				}).ClusterWorkspace,
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"root:org:foo"},
+					Location:     tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:      "https://kcp.bigcorp.com/clusters/org:test",
+				}).ClusterWorkspace,
+			),
+			expectedObj: newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+				Phase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+				Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+				BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+			}).ClusterWorkspace,
+		},
 		{
 			name: "does not add initializers during transition not to initializing",
 			types: []*tenancyv1alpha1.ClusterWorkspaceType{
@@ -213,6 +294,38 @@ func TestAdmit(t *testing.T) {
 				"existing-label": "non-default",
 			}).ClusterWorkspace,
 		},
+		{
+			name: "adds shard constraints of the type",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:foo").withShard(tenancyv1alpha1.ShardConstraints{
+					Region:   "eu",
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+				}).ClusterWorkspaceType,
+			},
+			clusterName: logicalcluster.New("root:org:ws"),
+			a: createAttr(
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withShard(tenancyv1alpha1.ShardConstraints{
+					SameAsParent: true,
+					Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"disk": "ssd"}},
+				}).ClusterWorkspace,
+			),
+			expectedObj: newWorkspace("root:org:ws:test").withType("root:org:foo").withShard(tenancyv1alpha1.ShardConstraints{
+				Region:       "eu",
+				SameAsParent: true,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"disk": "ssd", "tier": "gold"}},
+			}).ClusterWorkspace,
+		},
+		{
+			name: "rejects conflicting region",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:foo").withShard(tenancyv1alpha1.ShardConstraints{Region: "eu"}).ClusterWorkspaceType,
+			},
+			clusterName: logicalcluster.New("root:org:ws"),
+			a: createAttr(
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withShard(tenancyv1alpha1.ShardConstraints{Region: "us"}).ClusterWorkspace,
+			),
+			wantErr: true,
+		},
 		{
 			name: "adds default workspace type if missing",
 			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
@@ -226,6 +339,35 @@ func TestAdmit(t *testing.T) {
 			a:           createAttr(newWorkspace("root:org:ws:test").ClusterWorkspace),
 			expectedObj: newWorkspace("root:org:ws:test").withType("root:org:foo").ClusterWorkspace,
 		},
+		{
+			name: "adds default workspace type of a workspace policy if missing",
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:parent").withDefault("root:org:foo").ClusterWorkspaceType,
+				newType("root:org:foo").ClusterWorkspaceType,
+				newType("root:org:team").ClusterWorkspaceType,
+			},
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				newPolicy("root:org:defaults").withDefaultType("root:org:team").WorkspacePolicy,
+			},
+			clusterName: logicalcluster.New("root:org:ws"),
+			a:           createAttr(newWorkspace("root:org:ws:test").ClusterWorkspace),
+			expectedObj: newWorkspace("root:org:ws:test").withType("root:org:team").ClusterWorkspace,
+		},
+		{
+			name: "fails if type is missing and parent type has no default",
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:parent").ClusterWorkspaceType,
+			},
+			clusterName: logicalcluster.New("root:org:ws"),
+			a:           createAttr(newWorkspace("root:org:ws:test").ClusterWorkspace),
+			wantErr:     true,
+		},
 		{
 			name: "adds default workspace type if missing in root",
 			types: []*tenancyv1alpha1.ClusterWorkspaceType{
@@ -270,6 +412,7 @@ func TestAdmit(t *testing.T) {
 				Handler:         admission.NewHandler(admission.Create, admission.Update),
 				typeLister:      typeLister,
 				workspaceLister: fakeClusterWorkspaceLister(tt.workspaces),
+				listPolicies:    fakeListPolicies(tt.policies),
 				transitiveTypeResolver: transitiveTypeResolver{
 					getter: func(cluster logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
 						return typeLister.Get(clusters.ToClusterAwareKey(cluster, name))
@@ -296,6 +439,7 @@ func TestValidate(t *testing.T) {
 		name       string
 		types      []*tenancyv1alpha1.ClusterWorkspaceType
 		workspaces []*tenancyv1alpha1.ClusterWorkspace
+		policies   []*tenancyv1alpha1.WorkspacePolicy
 		attr       admission.Attributes
 		path       logicalcluster.Name
 
@@ -318,6 +462,52 @@ func TestValidate(t *testing.T) {
 			attr:          createAttr(newWorkspace("root:org:ws:test").withType("root:org:foo").ClusterWorkspace),
 			authzDecision: authorizer.DecisionAllow,
 		},
+		{
+			name: "passes create within the max child depth of the ancestors",
+			path: logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org").withType("root:organization").ClusterWorkspace,
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:organization").withMaxChildDepth(2).ClusterWorkspaceType,
+				newType("root:org:parent").withMaxChildDepth(1).ClusterWorkspaceType,
+				newType("root:org:foo").ClusterWorkspaceType,
+			},
+			attr:          createAttr(newWorkspace("root:org:ws:test").withType("root:org:foo").ClusterWorkspace),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "fails create beyond the max child depth of an ancestor",
+			path: logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org").withType("root:organization").ClusterWorkspace,
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:base").withMaxChildDepth(1).ClusterWorkspaceType,
+				newType("root:organization").extending("root:base").ClusterWorkspaceType,
+				newType("root:org:parent").ClusterWorkspaceType,
+				newType("root:org:foo").ClusterWorkspaceType,
+			},
+			attr:          createAttr(newWorkspace("root:org:ws:test").withType("root:org:foo").ClusterWorkspace),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
+		{
+			name: "fails create if parent type disallows all children",
+			path: logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:parent").disallowingChildren().ClusterWorkspaceType,
+				newType("root:org:foo").ClusterWorkspaceType,
+			},
+			attr:          createAttr(newWorkspace("root:org:ws:test").withType("root:org:foo").ClusterWorkspace),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
 		{
 			name: "passes create if unqualified type can be resolve locally",
 			path: logicalcluster.New("root:org:ws"),
@@ -530,6 +720,84 @@ func TestValidate(t *testing.T) {
 				}).ClusterWorkspace,
 			),
 		},
+		{
+			name: "validates only initializers of the lowest order on phase transition",
+			path: logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:parent").allowingChild("root:org:foo").ClusterWorkspaceType,
+				newType("root:org:networking").withInitializerOrder(0).ClusterWorkspaceType,
+				newType("root:org:foo").withInitializerOrder(1).extending("root:org:networking").ClusterWorkspaceType,
+			},
+			attr: updateAttr(
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"root:org:networking"},
+					Location:     tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:      "https://kcp.bigcorp.com/clusters/org:test",
+				}).ClusterWorkspace,
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withStatus(tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				}).ClusterWorkspace,
+			),
+		},
+		{
+			name: "passes create with parameters of an initializer of the type",
+			path: logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:parent").ClusterWorkspaceType,
+				newType("root:org:networking").withInitializer().ClusterWorkspaceType,
+				newType("root:org:foo").extending("root:org:networking").ClusterWorkspaceType,
+			},
+			attr:          createAttr(newWorkspace("root:org:ws:test").withType("root:org:foo").withInitializerParameters("root:org:networking").ClusterWorkspace),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "fails create with parameters of an initializer not of the type",
+			path: logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:parent").ClusterWorkspaceType,
+				newType("root:org:networking").withInitializer().ClusterWorkspaceType,
+				newType("root:org:foo").withInitializer().ClusterWorkspaceType,
+			},
+			attr:          createAttr(newWorkspace("root:org:ws:test").withType("root:org:foo").withInitializerParameters("root:org:networking").ClusterWorkspace),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
+		{
+			name: "fails create with duplicated initializer parameters",
+			path: logicalcluster.New("root:org:ws"),
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				newWorkspace("root:org:ws").withType("root:org:parent").ClusterWorkspace,
+			},
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:parent").ClusterWorkspaceType,
+				newType("root:org:foo").withInitializer().ClusterWorkspaceType,
+			},
+			attr:          createAttr(newWorkspace("root:org:ws:test").withType("root:org:foo").withInitializerParameters("root:org:foo", "root:org:foo").ClusterWorkspace),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
+		{
+			name: "fails if initializer parameters are changed",
+			path: logicalcluster.New("root:org:ws"),
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				newType("root:org:foo").withInitializer().ClusterWorkspaceType,
+			},
+			attr: updateAttr(
+				newWorkspace("root:org:ws:test").withType("root:org:foo").withInitializerParameters("root:org:foo").ClusterWorkspace,
+				newWorkspace("root:org:ws:test").withType("root:org:foo").ClusterWorkspace,
+			),
+			wantErr: true,
+		},
 		{
 			name:  "ignores different resources",
 			path:  logicalcluster.New("root:org:ws"),
@@ -560,6 +828,7 @@ func TestValidate(t *testing.T) {
 				Handler:         admission.NewHandler(admission.Create, admission.Update),
 				typeLister:      typeLister,
 				workspaceLister: fakeClusterWorkspaceLister(tt.workspaces),
+				listPolicies:    fakeListPolicies(tt.policies),
 				createAuthorizer: func(clusterName logicalcluster.Name, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
 					return &fakeAuthorizer{
 						tt.authzDecision,
@@ -829,6 +1098,19 @@ func TestValidateAllowedParents(t *testing.T) {
 	}
 }
 
+func TestValidateNoneAllowed(t *testing.T) {
+	parent := newType("root:b").disallowingChildren().ClusterWorkspaceType
+	child := newType("root:a").ClusterWorkspaceType
+	err := validateAllowedChildren([]*tenancyv1alpha1.ClusterWorkspaceType{parent}, []*tenancyv1alpha1.ClusterWorkspaceType{child}, "root:b", "root:a")
+	require.EqualError(t, err, "workspace type root:b cannot have any children")
+
+	parent = newType("root:b").ClusterWorkspaceType
+	child = newType("root:a").ClusterWorkspaceType
+	child.Spec.LimitAllowedParents = &tenancyv1alpha1.ClusterWorkspaceTypeSelector{None: true}
+	err = validateAllowedParents([]*tenancyv1alpha1.ClusterWorkspaceType{parent}, []*tenancyv1alpha1.ClusterWorkspaceType{child}, "root:b", "root:a")
+	require.EqualError(t, err, "workspace type root:a cannot be created in any workspace")
+}
+
 func TestValidateAllowedChildren(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -921,11 +1203,27 @@ func (b builder) disallowingChildren() builder {
 	return b
 }
 
+func (b builder) withMaxChildDepth(depth int32) builder {
+	b.ClusterWorkspaceType.Spec.MaxChildDepth = &depth
+	return b
+}
+
 func (b builder) withInitializer() builder {
 	b.ClusterWorkspaceType.Spec.Initializer = true
 	return b
 }
 
+func (b builder) withInitializerOrder(order int32) builder {
+	b.ClusterWorkspaceType.Spec.Initializer = true
+	b.ClusterWorkspaceType.Spec.InitializerOrder = order
+	return b
+}
+
+func (b builder) withShard(constraints tenancyv1alpha1.ShardConstraints) builder {
+	b.ClusterWorkspaceType.Spec.Shard = &constraints
+	return b
+}
+
 func (b builder) withAdditionalLabel(labels map[string]string) builder {
 	b.ClusterWorkspaceType.Spec.AdditionalWorkspaceLabels = labels
 	return b
@@ -961,7 +1259,59 @@ func (b wsBuilder) withStatus(status tenancyv1alpha1.ClusterWorkspaceStatus) wsB
 	return b
 }
 
+func (b wsBuilder) withInitializerParameters(initializers ...tenancyv1alpha1.ClusterWorkspaceInitializer) wsBuilder {
+	for _, initializer := range initializers {
+		b.Spec.InitializerParameters = append(b.Spec.InitializerParameters, tenancyv1alpha1.ClusterWorkspaceInitializerParameters{
+			Initializer: initializer,
+			Parameters:  runtime.RawExtension{Raw: []byte(`{"cidr":"10.0.0.0/16"}`)},
+		})
+	}
+	return b
+}
+
+func (b wsBuilder) withShard(constraints tenancyv1alpha1.ShardConstraints) wsBuilder {
+	b.Spec.Shard = &constraints
+	return b
+}
+
 func (b wsBuilder) withLabels(labels map[string]string) wsBuilder {
 	b.Labels = labels
 	return b
 }
+
+type policyBuilder struct {
+	*tenancyv1alpha1.WorkspacePolicy
+}
+
+func newPolicy(qualifiedName string) policyBuilder {
+	path, name := logicalcluster.New(qualifiedName).Split()
+	return policyBuilder{WorkspacePolicy: &tenancyv1alpha1.WorkspacePolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: path.String(),
+			},
+		},
+	}}
+}
+
+func (b policyBuilder) withDefaultType(qualifiedName string) policyBuilder {
+	path, name := logicalcluster.New(qualifiedName).Split()
+	b.Spec.DefaultType = &tenancyv1alpha1.ClusterWorkspaceTypeReference{
+		Path: path.String(),
+		Name: tenancyv1alpha1.ClusterWorkspaceTypeName(name),
+	}
+	return b
+}
+
+func fakeListPolicies(policies []*tenancyv1alpha1.WorkspacePolicy) func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+	return func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+		var ret []*tenancyv1alpha1.WorkspacePolicy
+		for _, p := range policies {
+			if logicalcluster.From(p) == clusterName {
+				ret = append(ret, p)
+			}
+		}
+		return ret, nil
+	}
+}
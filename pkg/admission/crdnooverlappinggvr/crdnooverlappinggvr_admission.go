@@ -24,19 +24,18 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/client-go/tools/cache"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
 )
 
 const (
-	PluginName  = "apis.kcp.dev/CRDNoOverlappingGVR"
-	byWorkspace = PluginName + "-byWorkspace"
+	PluginName = "apis.kcp.dev/CRDNoOverlappingGVR"
 )
 
 func Register(plugins *admission.Plugins) {
@@ -51,8 +50,7 @@ func Register(plugins *admission.Plugins) {
 type crdNoOverlappingGVRAdmission struct {
 	*admission.Handler
 
-	apiBindingIndexer          cache.Indexer
-	apiBindingIndexerInitError error
+	apiBindingIndexer cache.Indexer
 }
 
 // Ensure that the required admission interfaces are implemented.
@@ -60,21 +58,13 @@ var _ = admission.ValidationInterface(&crdNoOverlappingGVRAdmission{})
 var _ = admission.InitializationValidator(&crdNoOverlappingGVRAdmission{})
 
 func (p *crdNoOverlappingGVRAdmission) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
-	if err := informers.Apis().V1alpha1().APIBindings().Informer().AddIndexers(cache.Indexers{byWorkspace: indexByWorkspace}); err != nil {
-		p.apiBindingIndexerInitError = err
-		return
-	}
+	indexers.AddOrDie(informers.Apis().V1alpha1().APIBindings().Informer().GetIndexer(), indexers.ByLogicalCluster)
 
-	// just in case the plugin gets init multiple times in case of an error
-	p.apiBindingIndexerInitError = nil
 	p.SetReadyFunc(informers.Apis().V1alpha1().APIBindings().Informer().HasSynced)
 	p.apiBindingIndexer = informers.Apis().V1alpha1().APIBindings().Informer().GetIndexer()
 }
 
 func (p *crdNoOverlappingGVRAdmission) ValidateInitialization() error {
-	if p.apiBindingIndexerInitError != nil {
-		return fmt.Errorf(PluginName+" plugin failed to initialize %q APIBindings indexer, err = %v", byWorkspace, p.apiBindingIndexerInitError)
-	}
 	if p.apiBindingIndexer == nil {
 		return fmt.Errorf(PluginName + " plugin needs an APIBindings indexer")
 	}
@@ -118,23 +108,5 @@ func (p *crdNoOverlappingGVRAdmission) Validate(ctx context.Context, a admission
 }
 
 func (p *crdNoOverlappingGVRAdmission) listAPIBindingsFor(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
-	items, err := p.apiBindingIndexer.ByIndex(byWorkspace, clusterName.String())
-	if err != nil {
-		return nil, err
-	}
-	ret := make([]*apisv1alpha1.APIBinding, 0, len(items))
-	for _, item := range items {
-		ret = append(ret, item.(*apisv1alpha1.APIBinding))
-	}
-	return ret, nil
-}
-
-func indexByWorkspace(obj interface{}) ([]string, error) {
-	metaObj, ok := obj.(metav1.Object)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
-	}
-
-	lcluster := logicalcluster.From(metaObj)
-	return []string{lcluster.String()}, nil
+	return indexers.ByIndex[*apisv1alpha1.APIBinding](p.apiBindingIndexer, indexers.ByLogicalCluster, clusterName.String())
 }
@@ -32,6 +32,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 func TestValidate(t *testing.T) {
@@ -84,10 +85,7 @@ func TestValidate(t *testing.T) {
 	}
 	for _, scenario := range scenarios {
 		t.Run(scenario.name, func(t *testing.T) {
-			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
-			if err := indexer.AddIndexers(cache.Indexers{byWorkspace: indexByWorkspace}); err != nil {
-				t.Fatal(err)
-			}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers.Indexers(indexers.ByLogicalCluster))
 			for _, obj := range scenario.initialObjects {
 				if err := indexer.Add(obj); err != nil {
 					t.Error(err)
@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customsubresources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+const (
+	PluginName = "apis.kcp.dev/CustomSubresources"
+)
+
+type customSubresourceKeyType int
+
+const customSubresourceKey customSubresourceKeyType = iota
+
+// WithCustomSubresource returns a context that marks a request to a bound resource as a request to
+// the given custom subresource of it.
+func WithCustomSubresource(ctx context.Context, subresource string) context.Context {
+	return context.WithValue(ctx, customSubresourceKey, subresource)
+}
+
+// CustomSubresourceFrom returns the custom subresource a request is for, or an empty string.
+func CustomSubresourceFrom(ctx context.Context) string {
+	subresource, _ := ctx.Value(customSubresourceKey).(string)
+	return subresource
+}
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName, func(configFile io.Reader) (admission.Interface, error) {
+		return NewCustomSubresources(), nil
+	})
+}
+
+type customSubresources struct {
+	*admission.Handler
+
+	apiBindingsHasSynced cache.InformerSynced
+
+	listAPIBindings func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error)
+}
+
+var _ admission.MutationInterface = &customSubresources{}
+var _ admission.InitializationValidator = &customSubresources{}
+
+// NewCustomSubresources creates a mutating admission plugin that enforces the ownership of top-level
+// fields by the custom subresources of bound resources: requests to the resource cannot change the
+// owned fields, and requests to a custom subresource can only change the field it owns.
+func NewCustomSubresources() admission.MutationInterface {
+	p := &customSubresources{
+		Handler: admission.NewHandler(admission.Create, admission.Update),
+	}
+
+	p.SetReadyFunc(
+		func() bool {
+			return p.apiBindingsHasSynced()
+		},
+	)
+
+	return p
+}
+
+func (p *customSubresources) Admit(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) error {
+	// status and scale updates only change their own fields anyway
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	names, err := p.customSubresourceNames(clusterName, a.GetResource())
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	subresource := CustomSubresourceFrom(ctx)
+	if subresource != "" && !names.Has(subresource) {
+		return admission.NewForbidden(a, fmt.Errorf("unknown custom subresource %q", subresource))
+	}
+	if names.Len() == 0 {
+		return nil
+	}
+
+	switch a.GetOperation() {
+	case admission.Create:
+		if subresource != "" {
+			return admission.NewForbidden(a, fmt.Errorf("cannot create through custom subresource %q", subresource))
+		}
+		for _, name := range names.List() {
+			unstructured.RemoveNestedField(u.Object, name)
+		}
+	case admission.Update:
+		old, ok := a.GetOldObject().(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", a.GetOldObject())
+		}
+		if subresource == "" {
+			for _, name := range names.List() {
+				copyField(old, u, name)
+			}
+			return nil
+		}
+
+		// like for the status subresource, everything but the owned field is reset, while the resource
+		// version and the managed fields tracking the update of the owned field are kept.
+		updated := old.DeepCopy()
+		copyField(u, updated, subresource)
+		updated.SetResourceVersion(u.GetResourceVersion())
+		updated.SetManagedFields(u.GetManagedFields())
+		u.Object = updated.Object
+	}
+
+	return nil
+}
+
+// customSubresourceNames returns the names of the custom subresources of the given version of a resource
+// bound in the workspace.
+func (p *customSubresources) customSubresourceNames(clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (sets.String, error) {
+	apiBindings, err := p.listAPIBindings(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("error listing APIBindings: %w", err)
+	}
+
+	names := sets.NewString()
+	for _, apiBinding := range apiBindings {
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if boundResource.Group != gvr.Group || boundResource.Resource != gvr.Resource {
+				continue
+			}
+			for _, subresource := range boundResource.CustomSubresources {
+				if subresource.Version == gvr.Version {
+					names.Insert(subresource.Name)
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// copyField copies the top-level field from one object to the other, or removes it from the other
+// if it does not exist in the one.
+func copyField(from, to *unstructured.Unstructured, name string) {
+	if value, found := from.Object[name]; found {
+		to.Object[name] = runtime.DeepCopyJSONValue(value)
+	} else {
+		delete(to.Object, name)
+	}
+}
+
+// SetKcpInformers implements the WantsKcpInformers interface.
+func (p *customSubresources) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
+	apiBindingInformer := f.Apis().V1alpha1().APIBindings()
+	p.apiBindingsHasSynced = apiBindingInformer.Informer().HasSynced
+	p.listAPIBindings = func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+		return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+	}
+}
+
+func (p *customSubresources) ValidateInitialization() error {
+	if p.apiBindingsHasSynced == nil {
+		return errors.New("missing apiBindingsHasSynced")
+	}
+	if p.listAPIBindings == nil {
+		return errors.New("missing listAPIBindings")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customsubresources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func widget(resourceVersion string, fields map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kcp.dev/v1",
+		"kind":       "Widget",
+	}}
+	for k, v := range fields {
+		u.Object[k] = v
+	}
+	u.SetName("my-widget")
+	u.SetResourceVersion(resourceVersion)
+	return u
+}
+
+func attr(op admission.Operation, subresource string, obj, old runtime.Object) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		old,
+		schema.GroupVersionKind{Group: "kcp.dev", Version: "v1", Kind: "Widget"},
+		"",
+		"my-widget",
+		schema.GroupVersionResource{Group: "kcp.dev", Version: "v1", Resource: "widgets"},
+		subresource,
+		op,
+		nil,
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestAdmit(t *testing.T) {
+	spec := map[string]interface{}{"size": int64(1)}
+	newSpec := map[string]interface{}{"size": int64(2)}
+	approve := map[string]interface{}{"approver": "alice"}
+	newApprove := map[string]interface{}{"approver": "bob"}
+
+	tests := []struct {
+		name              string
+		attr              admission.Attributes
+		customSubresource string
+		want              *unstructured.Unstructured
+		wantErr           bool
+	}{
+		{
+			name: "create drops owned fields",
+			attr: attr(admission.Create, "", widget("", map[string]interface{}{"spec": spec, "approve": approve}), nil),
+			want: widget("", map[string]interface{}{"spec": spec}),
+		},
+		{
+			name: "update keeps owned fields",
+			attr: attr(admission.Update, "",
+				widget("2", map[string]interface{}{"spec": newSpec, "approve": newApprove}),
+				widget("1", map[string]interface{}{"spec": spec, "approve": approve}),
+			),
+			want: widget("2", map[string]interface{}{"spec": newSpec, "approve": approve}),
+		},
+		{
+			name: "update does not add owned fields",
+			attr: attr(admission.Update, "",
+				widget("2", map[string]interface{}{"spec": newSpec, "approve": newApprove}),
+				widget("1", map[string]interface{}{"spec": spec}),
+			),
+			want: widget("2", map[string]interface{}{"spec": newSpec}),
+		},
+		{
+			name: "update through custom subresource only changes owned field",
+			attr: attr(admission.Update, "",
+				widget("2", map[string]interface{}{"spec": newSpec, "approve": newApprove}),
+				widget("1", map[string]interface{}{"spec": spec, "approve": approve}),
+			),
+			customSubresource: "approve",
+			want:              widget("2", map[string]interface{}{"spec": spec, "approve": newApprove}),
+		},
+		{
+			name: "unknown custom subresource is forbidden",
+			attr: attr(admission.Update, "",
+				widget("2", map[string]interface{}{"spec": newSpec}),
+				widget("1", map[string]interface{}{"spec": spec}),
+			),
+			customSubresource: "reject",
+			wantErr:           true,
+		},
+		{
+			name: "status update is ignored",
+			attr: attr(admission.Update, "status",
+				widget("2", map[string]interface{}{"spec": newSpec, "approve": newApprove}),
+				widget("1", map[string]interface{}{"spec": spec, "approve": approve}),
+			),
+			want: widget("2", map[string]interface{}{"spec": newSpec, "approve": newApprove}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &customSubresources{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+					require.Equal(t, "root:org:ws", clusterName.String())
+					return []*apisv1alpha1.APIBinding{{
+						ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+						Status: apisv1alpha1.APIBindingStatus{
+							BoundResources: []apisv1alpha1.BoundAPIResource{{
+								Group:    "kcp.dev",
+								Resource: "widgets",
+								CustomSubresources: []apisv1alpha1.BoundCustomSubresource{
+									{Version: "v1", Name: "approve", Verbs: []string{"update"}},
+									{Version: "v2", Name: "reject", Verbs: []string{"update"}},
+								},
+							}},
+						},
+					}}, nil
+				},
+			}
+
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org:ws")})
+			if tt.customSubresource != "" {
+				ctx = WithCustomSubresource(ctx, tt.customSubresource)
+			}
+			err := p.Admit(ctx, tt.attr, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, tt.attr.GetObject())
+		})
+	}
+}
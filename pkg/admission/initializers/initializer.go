@@ -46,6 +46,30 @@ func (i *kcpInformersInitializer) Initialize(plugin admission.Interface) {
 	}
 }
 
+// NewCacheKcpInformersInitializer returns an admission plugin initializer that injects
+// the kcp shared informer factory of the cache server into admission plugins. Nothing
+// is injected if no cache server is configured, i.e. the factory is nil.
+func NewCacheKcpInformersInitializer(
+	cacheKcpInformers kcpinformers.SharedInformerFactory,
+) *cacheKcpInformersInitializer {
+	return &cacheKcpInformersInitializer{
+		cacheKcpInformers: cacheKcpInformers,
+	}
+}
+
+type cacheKcpInformersInitializer struct {
+	cacheKcpInformers kcpinformers.SharedInformerFactory
+}
+
+func (i *cacheKcpInformersInitializer) Initialize(plugin admission.Interface) {
+	if i.cacheKcpInformers == nil {
+		return
+	}
+	if wants, ok := plugin.(WantsCacheKcpInformers); ok {
+		wants.SetCacheKcpInformers(i.cacheKcpInformers)
+	}
+}
+
 // NewKubeClusterClientInitializer returns an admission plugin initializer that injects
 // a kube cluster client into admission plugins.
 func NewKubeClusterClientInitializer(
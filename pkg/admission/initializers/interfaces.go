@@ -29,6 +29,12 @@ type WantsKcpInformers interface {
 	SetKcpInformers(kcpinformers.SharedInformerFactory)
 }
 
+// WantsCacheKcpInformers interface should be implemented by admission plugins
+// that want to have a kcp informer factory for the cache server injected.
+type WantsCacheKcpInformers interface {
+	SetCacheKcpInformers(kcpinformers.SharedInformerFactory)
+}
+
 // WantsKubeClusterClient interface should be implemented by admission plugins
 // that want to have a kube cluster client injected.
 type WantsKubeClusterClient interface {
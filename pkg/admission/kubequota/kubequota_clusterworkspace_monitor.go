@@ -30,6 +30,7 @@ import (
 	"k8s.io/klog/v2"
 
 	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const clusterWorkspaceDeletionMonitorControllerName = "kcp-kubequota-cluster-workspace-deletion-monitor"
@@ -46,7 +47,7 @@ func newClusterWorkspaceDeletionMonitor(
 	stopFunc func(logicalcluster.Name),
 ) *clusterWorkspaceDeletionMonitor {
 	m := &clusterWorkspaceDeletionMonitor{
-		queue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkspaceDeletionMonitorControllerName),
+		queue:    kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), clusterWorkspaceDeletionMonitorControllerName),
 		stopFunc: stopFunc,
 	}
 
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	placementreconciler "github.com/kcp-dev/kcp/pkg/reconciler/workload/placement"
+)
+
+const (
+	PluginName = "scheduling.kcp.dev/Placement"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &placement{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// placement validates that the scoringExpression of Placements compiles and evaluates to a number.
+// Existing Placements can be updated without changing an invalid scoringExpression.
+type placement struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&placement{})
+
+func (o *placement) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if a.GetResource().GroupResource() != schedulingv1alpha1.Resource("placements") {
+		return nil
+	}
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	p, err := toPlacement(a.GetObject())
+	if err != nil {
+		return err
+	}
+	if p.Spec.ScoringExpression == "" {
+		return nil
+	}
+
+	if a.GetOperation() == admission.Update {
+		old, err := toPlacement(a.GetOldObject())
+		if err != nil {
+			return err
+		}
+		if old.Spec.ScoringExpression == p.Spec.ScoringExpression {
+			return nil
+		}
+	}
+
+	if _, err := placementreconciler.CompileScoringExpression(p.Spec.ScoringExpression); err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	return nil
+}
+
+func toPlacement(obj runtime.Object) (*schedulingv1alpha1.Placement, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	p := &schedulingv1alpha1.Placement{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, p); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to Placement: %w", err)
+	}
+	return p, nil
+}
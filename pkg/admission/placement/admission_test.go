@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+func newPlacement(expression string) *schedulingv1alpha1.Placement {
+	return &schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+		},
+		Spec: schedulingv1alpha1.PlacementSpec{
+			ScoringExpression: expression,
+		},
+	}
+}
+
+func placementAttr(obj, old *schedulingv1alpha1.Placement) admission.Attributes {
+	op, opts, oldObj := admission.Create, runtime.Object(&metav1.CreateOptions{}), runtime.Object(nil)
+	if old != nil {
+		op, opts, oldObj = admission.Update, &metav1.UpdateOptions{}, helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		oldObj,
+		schedulingv1alpha1.Kind("Placement").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		schedulingv1alpha1.Resource("placements").WithVersion("v1alpha1"),
+		"",
+		op,
+		opts,
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		attr    admission.Attributes
+		wantErr string
+	}{
+		"allows placements without scoring expression": {
+			attr: placementAttr(newPlacement(""), nil),
+		},
+		"allows valid scoring expressions": {
+			attr: placementAttr(newPlacement("-scores['cost'] + (labels['region'] == 'eu' ? 10.0 : 0.0)"), nil),
+		},
+		"forbids scoring expressions not compiling": {
+			attr:    placementAttr(newPlacement("scores["), nil),
+			wantErr: "invalid scoringExpression",
+		},
+		"forbids scoring expressions not evaluating to a number": {
+			attr:    placementAttr(newPlacement("name"), nil),
+			wantErr: "invalid scoringExpression: must evaluate to a number",
+		},
+		"forbids changing to an invalid scoring expression": {
+			attr:    placementAttr(newPlacement("name"), newPlacement("1")),
+			wantErr: "invalid scoringExpression: must evaluate to a number",
+		},
+		"allows updates keeping an invalid scoring expression": {
+			attr: placementAttr(newPlacement("name"), newPlacement("name")),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := &placement{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			err := o.Validate(context.Background(), tc.attr, nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
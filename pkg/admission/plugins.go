@@ -39,34 +39,48 @@ import (
 	"k8s.io/kubernetes/plugin/pkg/admission/storage/storageclass/setdefault"
 	"k8s.io/kubernetes/plugin/pkg/admission/storage/storageobjectinuseprotection"
 
+	"github.com/kcp-dev/kcp/pkg/admission/accessgrant"
 	"github.com/kcp-dev/kcp/pkg/admission/apibinding"
 	"github.com/kcp-dev/kcp/pkg/admission/apibindingfinalizer"
+	"github.com/kcp-dev/kcp/pkg/admission/apibindingquota"
+	"github.com/kcp-dev/kcp/pkg/admission/apiexport"
 	"github.com/kcp-dev/kcp/pkg/admission/apiresourceschema"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspace"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacefinalizer"
+	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacemove"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspaceshard"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacetype"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacetypeexists"
 	"github.com/kcp-dev/kcp/pkg/admission/crdnooverlappinggvr"
+	"github.com/kcp-dev/kcp/pkg/admission/customsubresources"
 	"github.com/kcp-dev/kcp/pkg/admission/kubequota"
 	kcpmutatingwebhook "github.com/kcp-dev/kcp/pkg/admission/mutatingwebhook"
 	workspacenamespacelifecycle "github.com/kcp-dev/kcp/pkg/admission/namespacelifecycle"
 	"github.com/kcp-dev/kcp/pkg/admission/permissionclaims"
+	"github.com/kcp-dev/kcp/pkg/admission/placement"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdannotations"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdgroups"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedmetadata"
+	"github.com/kcp-dev/kcp/pkg/admission/sharedsecret"
+	"github.com/kcp-dev/kcp/pkg/admission/validatingadmissionpolicy"
 	kcpvalidatingwebhook "github.com/kcp-dev/kcp/pkg/admission/validatingwebhook"
+	"github.com/kcp-dev/kcp/pkg/admission/workspaceauthenticationconfiguration"
+	"github.com/kcp-dev/kcp/pkg/admission/workspacemigration"
+	"github.com/kcp-dev/kcp/pkg/admission/workspacepolicy"
+	"github.com/kcp-dev/kcp/pkg/admission/workspacequota"
 )
 
 // AllOrderedPlugins is the list of all the plugins in order.
 var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	workspacenamespacelifecycle.PluginName,
 	apiresourceschema.PluginName,
+	apiexport.PluginName,
 	clusterworkspace.PluginName,
 	clusterworkspacefinalizer.PluginName,
 	clusterworkspaceshard.PluginName,
 	clusterworkspacetype.PluginName,
 	clusterworkspacetypeexists.PluginName,
+	clusterworkspacemove.PluginName,
 	apibinding.PluginName,
 	apibindingfinalizer.PluginName,
 	kcpvalidatingwebhook.PluginName,
@@ -76,6 +90,16 @@ var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	crdnooverlappinggvr.PluginName,
 	reservedmetadata.PluginName,
 	permissionclaims.PluginName,
+	customsubresources.PluginName,
+	validatingadmissionpolicy.PluginName,
+	apibindingquota.PluginName,
+	workspacequota.PluginName,
+	workspacepolicy.PluginName,
+	sharedsecret.PluginName,
+	accessgrant.PluginName,
+	workspacemigration.PluginName,
+	workspaceauthenticationconfiguration.PluginName,
+	placement.PluginName,
 	kubequota.PluginName,
 )
 
@@ -99,7 +123,9 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	clusterworkspaceshard.Register(plugins)
 	clusterworkspacetype.Register(plugins)
 	clusterworkspacetypeexists.Register(plugins)
+	clusterworkspacemove.Register(plugins)
 	apiresourceschema.Register(plugins)
+	apiexport.Register(plugins)
 	apibinding.Register(plugins)
 	apibindingfinalizer.Register(plugins)
 	workspacenamespacelifecycle.Register(plugins)
@@ -110,6 +136,16 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	crdnooverlappinggvr.Register(plugins)
 	reservedmetadata.Register(plugins)
 	permissionclaims.Register(plugins)
+	customsubresources.Register(plugins)
+	validatingadmissionpolicy.Register(plugins)
+	apibindingquota.Register(plugins)
+	workspacequota.Register(plugins)
+	workspacepolicy.Register(plugins)
+	sharedsecret.Register(plugins)
+	accessgrant.Register(plugins)
+	workspacemigration.Register(plugins)
+	workspaceauthenticationconfiguration.Register(plugins)
+	placement.Register(plugins)
 	kubequota.Register(plugins)
 }
 
@@ -126,7 +162,9 @@ var defaultOnPluginsInKcp = sets.NewString(
 	clusterworkspaceshard.PluginName,
 	clusterworkspacetype.PluginName,
 	clusterworkspacetypeexists.PluginName,
+	clusterworkspacemove.PluginName,
 	apiresourceschema.PluginName,
+	apiexport.PluginName,
 	apibinding.PluginName,
 	apibindingfinalizer.PluginName,
 	kcpvalidatingwebhook.PluginName,
@@ -134,6 +172,16 @@ var defaultOnPluginsInKcp = sets.NewString(
 	reservedcrdannotations.PluginName,
 	reservedcrdgroups.PluginName,
 	permissionclaims.PluginName,
+	customsubresources.PluginName,
+	validatingadmissionpolicy.PluginName,
+	apibindingquota.PluginName,
+	workspacequota.PluginName,
+	workspacepolicy.PluginName,
+	sharedsecret.PluginName,
+	accessgrant.PluginName,
+	workspacemigration.PluginName,
+	workspaceauthenticationconfiguration.PluginName,
+	placement.PluginName,
 	kubequota.PluginName,
 )
 
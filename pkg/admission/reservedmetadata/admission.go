@@ -43,6 +43,7 @@ var (
 		workloadv1alpha1.AnnotationSkipDefaultObjectCreation,
 		syncer.AdvancedSchedulingFeatureAnnotation,
 		tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey, // this is protected by clusterworkspace admission from non-system:admins
+		tenancyv1alpha1.ExperimentalAuthorAnnotationKey,                // protected by clusterworkspacetype and workspacepolicy admission
 	}
 	labelAllowList = []string{
 		apisv1alpha1.APIExportPermissionClaimLabelPrefix + "*", // protected by the permissionclaim admission plugin
@@ -0,0 +1,260 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedsecret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/SharedSecret"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &sharedSecret{
+				Handler:          admission.NewHandler(admission.Create, admission.Update, admission.Delete),
+				createAuthorizer: delegated.NewDelegatedAuthorizer,
+			}, nil
+		})
+}
+
+// sharedSecret validates SharedSecrets and protects the Secrets materialized from them:
+// - spec.secretRef is immutable,
+// - exactly one of workspace and apiExport is set for every consumer,
+// - the user must be allowed to get the shared Secret,
+// - the user must be allowed to create Secrets in the namespaces of consumer workspaces and APIExport workspaces,
+//   or to bind consumer APIExports if the Secret is materialized in the provider opt-in namespace,
+// - materialized Secrets can only be created, changed and deleted by system:masters.
+type sharedSecret struct {
+	*admission.Handler
+	deepSARClient kubernetesclient.ClusterInterface
+
+	createAuthorizer delegated.DelegatedAuthorizerFactory
+}
+
+// Ensure that the required admission interfaces are implemented.
+var (
+	_ = admission.ValidationInterface(&sharedSecret{})
+	_ = admission.InitializationValidator(&sharedSecret{})
+	_ = kcpinitializers.WantsDeepSARClient(&sharedSecret{})
+)
+
+func (o *sharedSecret) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	switch a.GetResource().GroupResource() {
+	case corev1.Resource("secrets"):
+		return o.validateSecret(a)
+	case tenancyv1alpha1.Resource("sharedsecrets"):
+	default:
+		return nil
+	}
+
+	if a.GetOperation() == admission.Delete || a.GetSubresource() != "" {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	ss, err := toSharedSecret(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	if a.GetOperation() == admission.Update {
+		old, err := toSharedSecret(a.GetOldObject())
+		if err != nil {
+			return err
+		}
+		if !equality.Semantic.DeepEqual(old.Spec.SecretRef, ss.Spec.SecretRef) {
+			return admission.NewForbidden(a, errors.New("spec.secretRef is immutable"))
+		}
+		if equality.Semantic.DeepEqual(old.Spec.Consumers, ss.Spec.Consumers) {
+			return nil
+		}
+	}
+
+	for i, consumer := range ss.Spec.Consumers {
+		if (consumer.Workspace == "") == (consumer.APIExport == nil) {
+			return admission.NewForbidden(a, fmt.Errorf("spec.consumers[%d]: exactly one of workspace and apiExport must be set", i))
+		}
+	}
+
+	if err := o.authorizeSecret(ctx, a, clusterName, ss); err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("spec.secretRef: %w", err))
+	}
+
+	for i, consumer := range ss.Spec.Consumers {
+		if err := o.authorizeConsumer(ctx, a, clusterName, ss, consumer); err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("spec.consumers[%d]: %w", i, err))
+		}
+	}
+
+	return nil
+}
+
+// authorizeSecret checks that the user can get the shared Secret, such that the Secret cannot be read through its
+// materialized copies by users without access to it.
+func (o *sharedSecret) authorizeSecret(ctx context.Context, a admission.Attributes, clusterName logicalcluster.Name, ss *tenancyv1alpha1.SharedSecret) error {
+	attr := authorizer.AttributesRecord{
+		User:            a.GetUserInfo(),
+		Verb:            "get",
+		APIVersion:      corev1.SchemeGroupVersion.Version,
+		Resource:        "secrets",
+		Namespace:       ss.Spec.SecretRef.Namespace,
+		Name:            ss.Spec.SecretRef.Name,
+		ResourceRequest: true,
+	}
+
+	authz, err := o.createAuthorizer(clusterName, o.deepSARClient)
+	if err != nil {
+		return fmt.Errorf("unable to determine access to workspace %s", clusterName)
+	}
+	if decision, _, err := authz.Authorize(ctx, attr); err != nil {
+		return fmt.Errorf("unable to determine access to workspace %s: %w", clusterName, err)
+	} else if decision != authorizer.DecisionAllow {
+		return fmt.Errorf("missing verb=%q permission on secrets %s/%s in %s", attr.Verb, attr.Namespace, attr.Name, clusterName)
+	}
+	return nil
+}
+
+// authorizeConsumer checks that the user can create Secrets in the namespace of the consumer workspace. For
+// APIExport consumers, it is enough to bind the APIExport if the Secret is materialized in the namespace
+// providers opt in to Secrets of their consumers with.
+func (o *sharedSecret) authorizeConsumer(ctx context.Context, a admission.Attributes, clusterName logicalcluster.Name, ss *tenancyv1alpha1.SharedSecret, consumer tenancyv1alpha1.SharedSecretConsumer) error {
+	workspace := logicalcluster.New(consumer.Workspace)
+	if consumer.APIExport != nil {
+		workspace = logicalcluster.New(consumer.APIExport.Path)
+	}
+	if workspace == clusterName {
+		// the Secret is shared within its own workspace
+		return nil
+	}
+	namespace := consumer.Namespace
+	if namespace == "" {
+		namespace = ss.Spec.SecretRef.Namespace
+	}
+
+	createErr := o.authorize(ctx, workspace, authorizer.AttributesRecord{
+		User:            a.GetUserInfo(),
+		Verb:            "create",
+		APIVersion:      corev1.SchemeGroupVersion.Version,
+		Resource:        "secrets",
+		Namespace:       namespace,
+		ResourceRequest: true,
+	})
+	if createErr == nil || consumer.APIExport == nil {
+		return createErr
+	}
+
+	if namespace != tenancyv1alpha1.SharedSecretProviderNamespace {
+		return fmt.Errorf("%w, or namespace must be %q to share with the provider of APIExport %s", createErr, tenancyv1alpha1.SharedSecretProviderNamespace, consumer.APIExport.Name)
+	}
+	return o.authorize(ctx, workspace, authorizer.AttributesRecord{
+		User:            a.GetUserInfo(),
+		Verb:            "bind",
+		APIGroup:        apisv1alpha1.SchemeGroupVersion.Group,
+		APIVersion:      apisv1alpha1.SchemeGroupVersion.Version,
+		Resource:        "apiexports",
+		Name:            consumer.APIExport.Name,
+		ResourceRequest: true,
+	})
+}
+
+// authorize checks the given attributes in the given workspace.
+func (o *sharedSecret) authorize(ctx context.Context, workspace logicalcluster.Name, attr authorizer.AttributesRecord) error {
+	authz, err := o.createAuthorizer(workspace, o.deepSARClient)
+	if err != nil {
+		return fmt.Errorf("unable to determine access to workspace %s", workspace)
+	}
+	if decision, _, err := authz.Authorize(ctx, attr); err != nil {
+		return fmt.Errorf("unable to determine access to workspace %s: %w", workspace, err)
+	} else if decision != authorizer.DecisionAllow {
+		return fmt.Errorf("missing verb=%q permission on %s in %s", attr.Verb, attr.Resource, workspace)
+	}
+	return nil
+}
+
+// validateSecret forbids users other than system:masters to create, change or delete materialized Secrets.
+func (o *sharedSecret) validateSecret(a admission.Attributes) error {
+	if sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		return nil
+	}
+
+	for _, obj := range []runtime.Object{a.GetObject(), a.GetOldObject()} {
+		if obj == nil {
+			continue
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+		if owner, found := accessor.GetAnnotations()[tenancyv1alpha1.SharedSecretAnnotationKey]; found {
+			return admission.NewForbidden(a, fmt.Errorf("Secret is materialized from SharedSecret %s and is read-only", owner))
+		}
+	}
+	return nil
+}
+
+func toSharedSecret(obj runtime.Object) (*tenancyv1alpha1.SharedSecret, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	ss := &tenancyv1alpha1.SharedSecret{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, ss); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to SharedSecret: %w", err)
+	}
+	return ss, nil
+}
+
+func (o *sharedSecret) ValidateInitialization() error {
+	if o.deepSARClient == nil {
+		return fmt.Errorf(PluginName + " plugin needs a deep SAR client")
+	}
+	return nil
+}
+
+func (o *sharedSecret) SetDeepSARClient(client kubernetesclient.ClusterInterface) {
+	o.deepSARClient = client
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedsecret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func sharedSecretAttr(obj, old *tenancyv1alpha1.SharedSecret) admission.Attributes {
+	op, opts, oldObj := admission.Create, runtime.Object(&metav1.CreateOptions{}), runtime.Object(nil)
+	if old != nil {
+		op, opts, oldObj = admission.Update, &metav1.UpdateOptions{}, helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		oldObj,
+		tenancyv1alpha1.Kind("SharedSecret").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("sharedsecrets").WithVersion("v1alpha1"),
+		"",
+		op,
+		opts,
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func secretAttr(obj, old *corev1.Secret, op admission.Operation, groups ...string) admission.Attributes {
+	var newObj, oldObj runtime.Object
+	if obj != nil {
+		newObj = obj
+	}
+	if old != nil {
+		oldObj = old
+	}
+	return admission.NewAttributesRecord(
+		newObj,
+		oldObj,
+		corev1.SchemeGroupVersion.WithKind("Secret"),
+		"default",
+		"db",
+		corev1.SchemeGroupVersion.WithResource("secrets"),
+		"",
+		op,
+		nil,
+		false,
+		&user.DefaultInfo{Groups: groups},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	newSharedSecret := func(secretName string, consumers ...tenancyv1alpha1.SharedSecretConsumer) *tenancyv1alpha1.SharedSecret {
+		return &tenancyv1alpha1.SharedSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "creds",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:producer"},
+			},
+			Spec: tenancyv1alpha1.SharedSecretSpec{
+				SecretRef: corev1.SecretReference{Namespace: "default", Name: secretName},
+				Consumers: consumers,
+			},
+		}
+	}
+	workspaceConsumer := tenancyv1alpha1.SharedSecretConsumer{Workspace: "root:org:consumer"}
+	exportConsumer := tenancyv1alpha1.SharedSecretConsumer{APIExport: &tenancyv1alpha1.SharedSecretAPIExportReference{Path: "root:org:provider", Name: "databases"}}
+	optInConsumer := tenancyv1alpha1.SharedSecretConsumer{APIExport: exportConsumer.APIExport, Namespace: tenancyv1alpha1.SharedSecretProviderNamespace}
+	materialized := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Name:        "db",
+		Annotations: map[string]string{tenancyv1alpha1.SharedSecretAnnotationKey: "root:org:producer|creds"},
+	}}
+	plain := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db"}}
+
+	tests := map[string]struct {
+		attr          admission.Attributes
+		authzDecision authorizer.Decision
+		denySecret    bool
+		denyCreate    bool
+		wantAuthz     []string
+		wantErr       string
+	}{
+		"allows consumers with permissions": {
+			attr:          sharedSecretAttr(newSharedSecret("db", workspaceConsumer, exportConsumer), nil),
+			authzDecision: authorizer.DecisionAllow,
+			wantAuthz:     []string{"root:org:producer get secrets default/db", "root:org:consumer create secrets default/", "root:org:provider create secrets default/"},
+		},
+		"forbids users without permission to get the Secret": {
+			attr:          sharedSecretAttr(newSharedSecret("db", workspaceConsumer), nil),
+			authzDecision: authorizer.DecisionAllow,
+			denySecret:    true,
+			wantErr:       `spec.secretRef: missing verb="get" permission on secrets default/db in root:org:producer`,
+		},
+		"forbids sharing within the workspace without permission to get the Secret": {
+			attr:          sharedSecretAttr(newSharedSecret("db", tenancyv1alpha1.SharedSecretConsumer{Workspace: "root:org:producer", Namespace: "other"}), nil),
+			authzDecision: authorizer.DecisionAllow,
+			denySecret:    true,
+			wantErr:       `missing verb="get" permission on secrets default/db`,
+		},
+		"forbids workspace consumers without permission": {
+			attr:          sharedSecretAttr(newSharedSecret("db", workspaceConsumer), nil),
+			authzDecision: authorizer.DecisionDeny,
+			wantErr:       `missing verb="create" permission on secrets in root:org:consumer`,
+		},
+		"forbids APIExport consumers without permission": {
+			attr:          sharedSecretAttr(newSharedSecret("db", exportConsumer), nil),
+			authzDecision: authorizer.DecisionDeny,
+			wantErr:       `missing verb="create" permission on secrets in root:org:provider, or namespace must be "kcp-shared-secrets"`,
+		},
+		"allows binders of the APIExport to share with the provider opt-in namespace": {
+			attr:          sharedSecretAttr(newSharedSecret("db", optInConsumer), nil),
+			authzDecision: authorizer.DecisionAllow,
+			denyCreate:    true,
+			wantAuthz:     []string{"root:org:producer get secrets default/db", "root:org:provider create secrets kcp-shared-secrets/", "root:org:provider bind apiexports /databases"},
+		},
+		"forbids binders of the APIExport to share with other namespaces of the provider": {
+			attr:          sharedSecretAttr(newSharedSecret("db", exportConsumer), nil),
+			authzDecision: authorizer.DecisionAllow,
+			denyCreate:    true,
+			wantErr:       `namespace must be "kcp-shared-secrets"`,
+		},
+		"forbids sharing with the provider opt-in namespace without permission to bind": {
+			attr:          sharedSecretAttr(newSharedSecret("db", optInConsumer), nil),
+			authzDecision: authorizer.DecisionDeny,
+			wantErr:       `missing verb="bind" permission on apiexports in root:org:provider`,
+		},
+		"does not authorize sharing within the workspace": {
+			attr:          sharedSecretAttr(newSharedSecret("db", tenancyv1alpha1.SharedSecretConsumer{Workspace: "root:org:producer", Namespace: "other"}), nil),
+			authzDecision: authorizer.DecisionDeny,
+		},
+		"forbids consumers with workspace and APIExport": {
+			attr: sharedSecretAttr(newSharedSecret("db", tenancyv1alpha1.SharedSecretConsumer{
+				Workspace: "root:org:consumer",
+				APIExport: exportConsumer.APIExport,
+			}), nil),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       "exactly one of workspace and apiExport must be set",
+		},
+		"forbids changing secretRef": {
+			attr:          sharedSecretAttr(newSharedSecret("other", workspaceConsumer), newSharedSecret("db", workspaceConsumer)),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       "spec.secretRef is immutable",
+		},
+		"does not authorize unchanged consumers": {
+			attr:          sharedSecretAttr(newSharedSecret("db", workspaceConsumer), newSharedSecret("db", workspaceConsumer)),
+			authzDecision: authorizer.DecisionDeny,
+		},
+		"authorizes added consumers": {
+			attr:          sharedSecretAttr(newSharedSecret("db", workspaceConsumer, exportConsumer), newSharedSecret("db", workspaceConsumer)),
+			authzDecision: authorizer.DecisionDeny,
+			wantErr:       "missing verb",
+		},
+		"allows plain Secrets": {
+			attr: secretAttr(plain, nil, admission.Create),
+		},
+		"forbids creating materialized Secrets": {
+			attr:    secretAttr(materialized, nil, admission.Create),
+			wantErr: "is read-only",
+		},
+		"forbids updating materialized Secrets": {
+			attr:    secretAttr(plain, materialized, admission.Update),
+			wantErr: "is read-only",
+		},
+		"forbids deleting materialized Secrets": {
+			attr:    secretAttr(nil, materialized, admission.Delete),
+			wantErr: "is read-only",
+		},
+		"allows system:masters to change materialized Secrets": {
+			attr: secretAttr(materialized, materialized, admission.Update, user.SystemPrivilegedGroup),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var authz []string
+			o := &sharedSecret{
+				Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete),
+				createAuthorizer: func(clusterName logicalcluster.Name, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
+					return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+						authz = append(authz, clusterName.String()+" "+a.GetVerb()+" "+a.GetResource()+" "+a.GetNamespace()+"/"+a.GetName())
+						if a.GetVerb() == "create" && tc.denyCreate {
+							return authorizer.DecisionDeny, "reason", nil
+						}
+						if a.GetVerb() == "get" {
+							if tc.denySecret {
+								return authorizer.DecisionDeny, "reason", nil
+							}
+							return authorizer.DecisionAllow, "reason", nil
+						}
+						return tc.authzDecision, "reason", nil
+					}), nil
+				},
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:producer")})
+			err := o.Validate(ctx, tc.attr, nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if tc.wantAuthz != nil {
+				require.Equal(t, tc.wantAuthz, authz)
+			}
+		})
+	}
+}
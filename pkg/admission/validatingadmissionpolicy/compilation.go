@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validatingadmissionpolicy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"google.golang.org/protobuf/proto"
+
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel/library"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	objectVarName    = "object"
+	oldObjectVarName = "oldObject"
+	paramsVarName    = "params"
+	requestVarName   = "request"
+
+	// perCallCostLimit limits the cost of evaluating a single expression, as for CRD validation rules.
+	perCallCostLimit = 1000000
+	// checkFrequency is the number of iterations after which the evaluation of an expression checks
+	// for cancellation of the request.
+	checkFrequency = 100
+)
+
+// newEnv returns the CEL environment of policy expressions, with the variables of a request and the
+// Kubernetes CEL libraries.
+func newEnv() (*cel.Env, error) {
+	opts := []cel.EnvOption{
+		cel.Declarations(
+			decls.NewVar(objectVarName, decls.Dyn),
+			decls.NewVar(oldObjectVarName, decls.Dyn),
+			decls.NewVar(paramsVarName, decls.Dyn),
+			decls.NewVar(requestVarName, decls.Dyn),
+		),
+	}
+	return cel.NewEnv(append(opts, library.ExtensionLibs...)...)
+}
+
+// compiledPolicy holds the programs of the validations of a policy, in the same order.
+type compiledPolicy struct {
+	resourceVersion string
+	programs        []cel.Program
+	err             error
+}
+
+// compile compiles the validations of a policy. Errors are returned for the first invalid expression.
+func compile(env *cel.Env, policy *tenancyv1alpha1.ValidatingAdmissionPolicy) ([]cel.Program, error) {
+	programs := make([]cel.Program, 0, len(policy.Spec.Validations))
+	for i, v := range policy.Spec.Validations {
+		ast, issues := env.Compile(v.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("spec.validations[%d].expression: compilation failed: %s", i, issues.String())
+		}
+		if !proto.Equal(ast.ResultType(), decls.Bool) && !proto.Equal(ast.ResultType(), decls.Dyn) {
+			return nil, fmt.Errorf("spec.validations[%d].expression: must evaluate to a bool", i)
+		}
+		prog, err := env.Program(ast,
+			cel.EvalOptions(cel.OptOptimize, cel.OptTrackCost),
+			cel.CostLimit(perCallCostLimit),
+			cel.OptimizeRegex(library.ExtensionLibRegexOptimizations...),
+			cel.InterruptCheckFrequency(checkFrequency),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("spec.validations[%d].expression: program instantiation failed: %w", i, err)
+		}
+		programs = append(programs, prog)
+	}
+	return programs, nil
+}
+
+// evaluate returns whether the program evaluates to true for the given variables.
+func evaluate(ctx context.Context, prog cel.Program, vars map[string]interface{}) (bool, error) {
+	out, _, err := prog.ContextEval(ctx, vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.(types.Bool)
+	if !ok {
+		return false, fmt.Errorf("expression evaluated to %s, not to a bool", out.Type().TypeName())
+	}
+	return bool(b), nil
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validatingadmissionpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/admission/plugin/webhook/generic"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/ValidatingAdmissionPolicy"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return NewValidatingAdmissionPolicy()
+		})
+}
+
+type validatingAdmissionPolicy struct {
+	*admission.Handler
+
+	env *cel.Env
+
+	policyIndexer     cache.Indexer
+	bindingIndexer    cache.Indexer
+	policiesHasSynced cache.InformerSynced
+	bindingsHasSynced cache.InformerSynced
+
+	lock     sync.Mutex
+	compiled map[string]*compiledPolicy
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&validatingAdmissionPolicy{})
+var _ = admission.InitializationValidator(&validatingAdmissionPolicy{})
+
+// NewValidatingAdmissionPolicy creates an admission plugin that enforces the ValidatingAdmissionPolicies
+// bound in the workspace of a request, and rejects policies with invalid expressions. Policies and their
+// bindings are not validated by policies, such that a broken policy can always be removed.
+func NewValidatingAdmissionPolicy() (admission.ValidationInterface, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, err
+	}
+	p := &validatingAdmissionPolicy{
+		Handler:  admission.NewHandler(admission.Create, admission.Update, admission.Delete, admission.Connect),
+		env:      env,
+		compiled: map[string]*compiledPolicy{},
+	}
+	p.SetReadyFunc(func() bool {
+		return p.policiesHasSynced() && p.bindingsHasSynced()
+	})
+	return p, nil
+}
+
+func (p *validatingAdmissionPolicy) Validate(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) error {
+	switch a.GetResource().GroupResource() {
+	case tenancyv1alpha1.Resource("validatingadmissionpolicies"):
+		return p.validatePolicy(a)
+	case tenancyv1alpha1.Resource("validatingadmissionpolicybindings"):
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if !p.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+	bindings, err := indexers.ByIndex[*tenancyv1alpha1.ValidatingAdmissionPolicyBinding](p.bindingIndexer, indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error getting ValidatingAdmissionPolicyBindings: %w", err))
+	}
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].Name < bindings[j].Name })
+
+	var vars map[string]interface{}
+	for _, binding := range bindings {
+		policy, err := p.getPolicy(clusterName, binding.Spec.PolicyName)
+		if err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("error getting ValidatingAdmissionPolicy %q: %w", binding.Spec.PolicyName, err))
+		}
+		if policy == nil || !matches(policy, a) {
+			continue
+		}
+
+		if vars == nil {
+			if vars, err = requestVars(a, o); err != nil {
+				return admission.NewForbidden(a, err)
+			}
+		}
+		if err := p.evaluate(ctx, clusterName, policy, binding, vars); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+
+	return nil
+}
+
+// evaluate evaluates the validations of the policy with the parameters of the binding, and returns
+// an error if one of them fails, or cannot be evaluated and the policy does not ignore failures.
+func (p *validatingAdmissionPolicy) evaluate(ctx context.Context, clusterName logicalcluster.Name, policy *tenancyv1alpha1.ValidatingAdmissionPolicy, binding *tenancyv1alpha1.ValidatingAdmissionPolicyBinding, vars map[string]interface{}) error {
+	failed := func(err error) error {
+		if policy.Spec.FailurePolicy == tenancyv1alpha1.AdmissionPolicyFailurePolicyIgnore {
+			return nil
+		}
+		return fmt.Errorf("ValidatingAdmissionPolicy %q with binding %q failed: %w", policy.Name, binding.Name, err)
+	}
+
+	programs, err := p.programs(clusterName, policy)
+	if err != nil {
+		return failed(err)
+	}
+	params, err := bindingParams(binding)
+	if err != nil {
+		return failed(err)
+	}
+
+	bindingVars := make(map[string]interface{}, len(vars)+1)
+	for k, v := range vars {
+		bindingVars[k] = v
+	}
+	bindingVars[paramsVarName] = params
+
+	for i, prog := range programs {
+		valid, err := evaluate(ctx, prog, bindingVars)
+		if err != nil {
+			if err := failed(fmt.Errorf("spec.validations[%d]: %w", i, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !valid {
+			message := policy.Spec.Validations[i].Message
+			if message == "" {
+				message = fmt.Sprintf("failed expression: %s", strings.TrimSpace(policy.Spec.Validations[i].Expression))
+			}
+			return fmt.Errorf("ValidatingAdmissionPolicy %q with binding %q denied request: %s", policy.Name, binding.Name, message)
+		}
+	}
+	return nil
+}
+
+// validatePolicy rejects policies with expressions that do not compile.
+func (p *validatingAdmissionPolicy) validatePolicy(a admission.Attributes) error {
+	if a.GetSubresource() != "" || (a.GetOperation() != admission.Create && a.GetOperation() != admission.Update) {
+		return nil
+	}
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	policy := &tenancyv1alpha1.ValidatingAdmissionPolicy{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, policy); err != nil {
+		return fmt.Errorf("failed to convert unstructured to ValidatingAdmissionPolicy: %w", err)
+	}
+	if _, err := compile(p.env, policy); err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	return nil
+}
+
+func (p *validatingAdmissionPolicy) getPolicy(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ValidatingAdmissionPolicy, error) {
+	obj, exists, err := p.policyIndexer.GetByKey(clusters.ToClusterAwareKey(clusterName, name))
+	if err != nil || !exists {
+		return nil, err
+	}
+	return obj.(*tenancyv1alpha1.ValidatingAdmissionPolicy), nil
+}
+
+// programs returns the compiled validations of the policy, compiling them once per resource version.
+func (p *validatingAdmissionPolicy) programs(clusterName logicalcluster.Name, policy *tenancyv1alpha1.ValidatingAdmissionPolicy) ([]cel.Program, error) {
+	key := clusters.ToClusterAwareKey(clusterName, policy.Name)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if c, found := p.compiled[key]; found && c.resourceVersion == policy.ResourceVersion {
+		return c.programs, c.err
+	}
+	programs, err := compile(p.env, policy)
+	p.compiled[key] = &compiledPolicy{resourceVersion: policy.ResourceVersion, programs: programs, err: err}
+	return programs, err
+}
+
+// matches returns whether one of the rules of the policy matches the operation and resource of the request.
+func matches(policy *tenancyv1alpha1.ValidatingAdmissionPolicy, a admission.Attributes) bool {
+	for _, rule := range policy.Spec.MatchResources {
+		if matchesAny(rule.Operations, string(a.GetOperation())) && matchesAny(rule.APIGroups, a.GetResource().Group) && matchesResource(rule.Resources, a.GetResource().Resource, a.GetSubresource()) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if p == "*" || p == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesResource matches resources as admission webhook rules do: "pods" only matches pods, "pods/*"
+// matches pods and all its subresources, and "*/status" the status subresource of all resources.
+func matchesResource(patterns []string, resource, subresource string) bool {
+	for _, p := range patterns {
+		parts := strings.SplitN(p, "/", 2)
+		res, sub := parts[0], ""
+		if len(parts) == 2 {
+			sub = parts[1]
+		}
+		if (res == "*" || res == resource) && (sub == "*" || sub == subresource) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestVars returns the object, oldObject and request variables of the request.
+func requestVars(a admission.Attributes, o admission.ObjectInterfaces) (map[string]interface{}, error) {
+	versioned, err := generic.NewVersionedAttributes(a, a.GetKind(), o)
+	if err != nil {
+		return nil, fmt.Errorf("error converting the object to %s: %w", a.GetKind(), err)
+	}
+	object, err := objectVar(versioned.VersionedObject)
+	if err != nil {
+		return nil, err
+	}
+	oldObject, err := objectVar(versioned.VersionedOldObject)
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]interface{}{
+		"operation":   string(a.GetOperation()),
+		"name":        a.GetName(),
+		"namespace":   a.GetNamespace(),
+		"subResource": a.GetSubresource(),
+		"dryRun":      a.IsDryRun(),
+		"resource": map[string]interface{}{
+			"group":    a.GetResource().Group,
+			"version":  a.GetResource().Version,
+			"resource": a.GetResource().Resource,
+		},
+	}
+	if info := a.GetUserInfo(); info != nil {
+		extra := make(map[string]interface{}, len(info.GetExtra()))
+		for k, v := range info.GetExtra() {
+			extra[k] = v
+		}
+		request["userInfo"] = map[string]interface{}{
+			"username": info.GetName(),
+			"uid":      info.GetUID(),
+			"groups":   info.GetGroups(),
+			"extra":    extra,
+		}
+	}
+
+	return map[string]interface{}{
+		objectVarName:    object,
+		oldObjectVarName: oldObject,
+		requestVarName:   request,
+	}, nil
+}
+
+func objectVar(obj runtime.Object) (interface{}, error) {
+	if obj == nil {
+		return types.NullValue, nil
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object, nil
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error converting %T to unstructured: %w", obj, err)
+	}
+	return u, nil
+}
+
+func bindingParams(binding *tenancyv1alpha1.ValidatingAdmissionPolicyBinding) (interface{}, error) {
+	if binding.Spec.Params == nil || len(binding.Spec.Params.Raw) == 0 {
+		return types.NullValue, nil
+	}
+	var params interface{}
+	if err := json.Unmarshal(binding.Spec.Params.Raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	return params, nil
+}
+
+// SetKcpInformers implements the WantsKcpInformers interface.
+func (p *validatingAdmissionPolicy) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
+	indexers.AddOrDie(f.Tenancy().V1alpha1().ValidatingAdmissionPolicyBindings().Informer().GetIndexer(), indexers.ByLogicalCluster)
+
+	p.policyIndexer = f.Tenancy().V1alpha1().ValidatingAdmissionPolicies().Informer().GetIndexer()
+	p.policiesHasSynced = f.Tenancy().V1alpha1().ValidatingAdmissionPolicies().Informer().HasSynced
+	p.bindingIndexer = f.Tenancy().V1alpha1().ValidatingAdmissionPolicyBindings().Informer().GetIndexer()
+	p.bindingsHasSynced = f.Tenancy().V1alpha1().ValidatingAdmissionPolicyBindings().Informer().HasSynced
+}
+
+func (p *validatingAdmissionPolicy) ValidateInitialization() error {
+	if p.policyIndexer == nil || p.bindingIndexer == nil {
+		return errors.New("missing ValidatingAdmissionPolicy informers")
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validatingadmissionpolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+func createAttr(obj *unstructured.Unstructured, resource, subresource string) admission.Attributes {
+	gvk := obj.GroupVersionKind()
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		gvk,
+		obj.GetNamespace(),
+		obj.GetName(),
+		gvk.GroupVersion().WithResource(resource),
+		subresource,
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+	)
+}
+
+func cowboy(name string, horses int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "wildwest.dev/v1alpha1",
+		"kind":       "Cowboy",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		"spec":       map[string]interface{}{"horses": horses},
+	}}
+}
+
+func policy(cluster, name string, failurePolicy tenancyv1alpha1.AdmissionPolicyFailurePolicy, expressions ...string) *tenancyv1alpha1.ValidatingAdmissionPolicy {
+	p := &tenancyv1alpha1.ValidatingAdmissionPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: "1",
+			Annotations:     map[string]string{logicalcluster.AnnotationKey: cluster},
+		},
+		Spec: tenancyv1alpha1.ValidatingAdmissionPolicySpec{
+			MatchResources: []tenancyv1alpha1.AdmissionPolicyRule{
+				{Operations: []string{"CREATE", "UPDATE"}, APIGroups: []string{"wildwest.dev"}, Resources: []string{"cowboys"}},
+			},
+			FailurePolicy: failurePolicy,
+		},
+	}
+	for _, e := range expressions {
+		p.Spec.Validations = append(p.Spec.Validations, tenancyv1alpha1.AdmissionPolicyValidation{Expression: e})
+	}
+	return p
+}
+
+func binding(cluster, name, policyName, params string) *tenancyv1alpha1.ValidatingAdmissionPolicyBinding {
+	b := &tenancyv1alpha1.ValidatingAdmissionPolicyBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+		},
+		Spec: tenancyv1alpha1.ValidatingAdmissionPolicyBindingSpec{PolicyName: policyName},
+	}
+	if params != "" {
+		b.Spec.Params = &runtime.RawExtension{Raw: []byte(params)}
+	}
+	return b
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		policies []*tenancyv1alpha1.ValidatingAdmissionPolicy
+		bindings []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding
+		attr     admission.Attributes
+		wantErr  string
+	}{
+		"no bindings": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:ws", "max-horses", "", "false")},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+		},
+		"valid object": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:ws", "max-horses", "", "object.spec.horses <= params.maxHorses")},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "max-horses", "max-horses", `{"maxHorses": 5}`)},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+		},
+		"invalid object": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:ws", "max-horses", "", "object.spec.horses <= params.maxHorses")},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "max-horses", "max-horses", `{"maxHorses": 2}`)},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+			wantErr:  `ValidatingAdmissionPolicy "max-horses" with binding "max-horses" denied request: failed expression: object.spec.horses <= params.maxHorses`,
+		},
+		"request attributes": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:ws", "no-alice", "", "request.userInfo.username != 'alice' || request.operation != 'CREATE'")},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "no-alice", "no-alice", "")},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+			wantErr:  `denied request`,
+		},
+		"binding of another workspace": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:other", "deny", "", "false")},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:other", "deny", "deny", "")},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+		},
+		"binding of a missing policy": {
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "deny", "deny", "")},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+		},
+		"subresources are not matched by the resource": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:ws", "deny", "", "false")},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "deny", "deny", "")},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", "status"),
+		},
+		"evaluation error fails": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:ws", "broken", "", "object.spec.missing > 1")},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "broken", "broken", "")},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+			wantErr:  `ValidatingAdmissionPolicy "broken" with binding "broken" failed: spec.validations[0]: no such key: missing`,
+		},
+		"evaluation error is ignored": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{policy("root:org:ws", "broken", tenancyv1alpha1.AdmissionPolicyFailurePolicyIgnore, "object.spec.missing > 1", "object.spec.horses < 10")},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "broken", "broken", "")},
+			attr:     createAttr(cowboy("billy", 3), "cowboys", ""),
+		},
+		"policies are not validated by policies": {
+			policies: []*tenancyv1alpha1.ValidatingAdmissionPolicy{func() *tenancyv1alpha1.ValidatingAdmissionPolicy {
+				p := policy("root:org:ws", "deny", "", "false")
+				p.Spec.MatchResources[0].APIGroups = []string{"*"}
+				p.Spec.MatchResources[0].Resources = []string{"*"}
+				return p
+			}()},
+			bindings: []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding{binding("root:org:ws", "deny", "deny", "")},
+			attr: createAttr(&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "tenancy.kcp.dev/v1alpha1",
+				"kind":       "ValidatingAdmissionPolicyBinding",
+				"metadata":   map[string]interface{}{"name": "other"},
+				"spec":       map[string]interface{}{"policyName": "deny"},
+			}}, "validatingadmissionpolicybindings", ""),
+		},
+		"invalid policy expression": {
+			attr: createAttr(&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "tenancy.kcp.dev/v1alpha1",
+				"kind":       "ValidatingAdmissionPolicy",
+				"metadata":   map[string]interface{}{"name": "broken"},
+				"spec": map[string]interface{}{
+					"validations": []interface{}{
+						map[string]interface{}{"expression": "object.spec.horses <"},
+					},
+				},
+			}}, "validatingadmissionpolicies", ""),
+			wantErr: "spec.validations[0].expression: compilation failed",
+		},
+		"non-bool policy expression": {
+			attr: createAttr(&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "tenancy.kcp.dev/v1alpha1",
+				"kind":       "ValidatingAdmissionPolicy",
+				"metadata":   map[string]interface{}{"name": "broken"},
+				"spec": map[string]interface{}{
+					"validations": []interface{}{
+						map[string]interface{}{"expression": "'horses'"},
+					},
+				},
+			}}, "validatingadmissionpolicies", ""),
+			wantErr: "spec.validations[0].expression: must evaluate to a bool",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kcpInformers := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+			plugin, err := NewValidatingAdmissionPolicy()
+			require.NoError(t, err)
+			p := plugin.(*validatingAdmissionPolicy)
+			p.SetKcpInformers(kcpInformers)
+			p.policiesHasSynced = func() bool { return true }
+			p.bindingsHasSynced = func() bool { return true }
+			require.NoError(t, p.ValidateInitialization())
+
+			for _, policy := range tc.policies {
+				require.NoError(t, p.policyIndexer.Add(policy))
+			}
+			for _, binding := range tc.bindings {
+				require.NoError(t, p.bindingIndexer.Add(binding))
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err = p.Validate(ctx, tc.attr, nil)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
@@ -22,12 +22,12 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/admission"
 	webhookconfiguration "k8s.io/apiserver/pkg/admission/configuration"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook/generic"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook/rules"
+	"k8s.io/apiserver/pkg/authentication/user"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
@@ -35,9 +35,14 @@ import (
 	"github.com/kcp-dev/kcp/pkg/admission/initializers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
-const byWorkspaceIndex = "webhookDispatcher-byWorkspace"
+const (
+	// ClusterNameUserInfoExtraKey is the user info extra key of admission reviews sent to webhooks of an
+	// APIExport workspace. It holds the logical cluster of the consumer workspace the request is for.
+	ClusterNameUserInfoExtraKey = "authentication.kcp.dev/cluster-name"
+)
 
 var _ initializers.WantsKcpInformers = &WebhookDispatcher{}
 
@@ -72,24 +77,33 @@ func (p *WebhookDispatcher) Dispatch(ctx context.Context, attr admission.Attribu
 	}
 
 	hooks := p.hookSource.Webhooks()
-	var whAccessor []webhook.WebhookAccessor
 
-	// Determine the type of request, is it api binding or not.
-	if workspace, isAPIBinding, err := p.getAPIBindingWorkspace(attr, lcluster); err != nil {
+	// Requests for resources bound through an APIBinding are first sent to the hooks of the APIExport workspace,
+	// which are told about the consumer workspace through the user info. These hooks are called directly from
+	// this shard with the requesting user's identity, not through the APIExport virtual workspace.
+	workspace, isAPIBinding, err := p.getAPIBindingWorkspace(attr, lcluster)
+	if err != nil {
 		return err
-	} else if isAPIBinding {
-		whAccessor = p.restrictToLogicalCluster(hooks, workspace)
+	}
+	if isAPIBinding {
 		klog.V(7).Infof("restricting call to api registration hooks in cluster: %v", workspace)
-	} else {
-		whAccessor = p.restrictToLogicalCluster(hooks, lcluster)
-		klog.V(7).Infof("restricting call to hooks in cluster: %v", lcluster)
+		if err := p.dispatcher.Dispatch(ctx, withClusterUserInfo(attr, lcluster), o, p.restrictToLogicalCluster(hooks, workspace)); err != nil {
+			return err
+		}
+		if workspace == lcluster {
+			return nil
+		}
 	}
 
-	return p.dispatcher.Dispatch(ctx, attr, o, whAccessor)
+	// Every request is sent to the hooks of its own workspace, such that workspace owners can enforce their own
+	// policies, also for bound resources, without affecting other workspaces. The cluster name extra is only
+	// set by kcp, never passed through from the requesting user, e.g. when impersonating.
+	klog.V(7).Infof("restricting call to hooks in cluster: %v", lcluster)
+	return p.dispatcher.Dispatch(ctx, withoutClusterUserInfo(attr), o, p.restrictToLogicalCluster(hooks, lcluster))
 }
 
 func (p *WebhookDispatcher) getAPIBindingWorkspace(attr admission.Attributes, clusterName logicalcluster.Name) (logicalcluster.Name, bool, error) {
-	objs, err := p.apiBindingsIndexer.ByIndex(byWorkspaceIndex, clusterName.String())
+	objs, err := p.apiBindingsIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return logicalcluster.New(""), false, err
 	}
@@ -122,22 +136,62 @@ func (p *WebhookDispatcher) restrictToLogicalCluster(hooks []webhook.WebhookAcce
 	return wh
 }
 
+// clusterAttributes passes the logical cluster of the request to webhooks of another logical cluster
+// through the user info of the admission review.
+type clusterAttributes struct {
+	admission.Attributes
+	userInfo user.Info
+}
+
+func withClusterUserInfo(attr admission.Attributes, clusterName logicalcluster.Name) admission.Attributes {
+	return withClusterNameExtra(attr, []string{clusterName.String()})
+}
+
+// withoutClusterUserInfo drops the cluster name extra from the user info, if the requesting user has one.
+func withoutClusterUserInfo(attr admission.Attributes) admission.Attributes {
+	if info := attr.GetUserInfo(); info == nil || info.GetExtra()[ClusterNameUserInfoExtraKey] == nil {
+		return attr
+	}
+	return withClusterNameExtra(attr, nil)
+}
+
+// withClusterNameExtra sets the cluster name extra of the user info to the given value, or drops it if nil.
+func withClusterNameExtra(attr admission.Attributes, value []string) admission.Attributes {
+	info := attr.GetUserInfo()
+	if info == nil {
+		info = &user.DefaultInfo{}
+	}
+	extra := make(map[string][]string, len(info.GetExtra())+1)
+	for k, v := range info.GetExtra() {
+		extra[k] = v
+	}
+	delete(extra, ClusterNameUserInfoExtraKey)
+	if value != nil {
+		extra[ClusterNameUserInfoExtraKey] = value
+	}
+
+	return &clusterAttributes{
+		Attributes: attr,
+		userInfo: &user.DefaultInfo{
+			Name:   info.GetName(),
+			UID:    info.GetUID(),
+			Groups: info.GetGroups(),
+			Extra:  extra,
+		},
+	}
+}
+
+func (a *clusterAttributes) GetUserInfo() user.Info {
+	return a.userInfo
+}
+
 func (p *WebhookDispatcher) SetHookSource(s generic.Source) {
 	p.hookSource = s
 }
 
 // SetKcpInformers implements the WantsExternalKcpInformerFactory interface.
 func (p *WebhookDispatcher) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
-	if _, found := f.Apis().V1alpha1().APIBindings().Informer().GetIndexer().GetIndexers()[byWorkspaceIndex]; !found {
-		if err := f.Apis().V1alpha1().APIBindings().Informer().AddIndexers(cache.Indexers{
-			byWorkspaceIndex: func(obj interface{}) ([]string, error) {
-				return []string{logicalcluster.From(obj.(metav1.Object)).String()}, nil
-			},
-		}); err != nil {
-			// nothing we can do here. But this should also never happen. We check for existence before.
-			klog.Errorf("failed to add indexer for APIBindings: %v", err)
-		}
-	}
+	indexers.AddOrDie(f.Apis().V1alpha1().APIBindings().Informer().GetIndexer(), indexers.ByLogicalCluster)
 	p.apiBindingsIndexer = f.Apis().V1alpha1().APIBindings().Informer().GetIndexer()
 	p.apiBindingsHasSynced = f.Apis().V1alpha1().APIBindings().Informer().HasSynced
 }
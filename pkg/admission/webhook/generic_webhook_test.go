@@ -33,11 +33,11 @@ import (
 	"k8s.io/apiserver/pkg/admission/plugin/webhook"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
-	"k8s.io/client-go/tools/cache"
 
 	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 func attr(gvk schema.GroupVersionKind, name, resource string, op admission.Operation) admission.Attributes {
@@ -59,23 +59,41 @@ func attr(gvk schema.GroupVersionKind, name, resource string, op admission.Opera
 	)
 }
 
+// expectedDispatch is a call of the dispatcher with the given hooks and the given cluster name in the user info.
+type expectedDispatch struct {
+	hooks       []webhook.WebhookAccessor
+	clusterName string
+}
+
 type validatingDispatcher struct {
-	hooks []webhook.WebhookAccessor
+	expected []expectedDispatch
+	calls    int
 }
 
 func (d *validatingDispatcher) Dispatch(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces, hooks []webhook.WebhookAccessor) error {
-	if len(hooks) != len(d.hooks) {
+	if d.calls >= len(d.expected) {
+		return fmt.Errorf("unexpected dispatch call %d", d.calls+1)
+	}
+	expected := d.expected[d.calls]
+	d.calls++
+
+	if got := a.GetUserInfo().GetExtra()[ClusterNameUserInfoExtraKey]; expected.clusterName == "" && len(got) > 0 {
+		return fmt.Errorf("unexpected cluster name %v in user info", got)
+	} else if expected.clusterName != "" && (len(got) != 1 || got[0] != expected.clusterName) {
+		return fmt.Errorf("expected cluster name %q in user info, got %v", expected.clusterName, got)
+	}
+	if len(hooks) != len(expected.hooks) {
 		return fmt.Errorf("invalid number of hooks sent to dispatcher")
 	}
 	uidMatches := map[string]*struct{}{}
 	for _, h := range hooks {
-		for _, expectedHook := range d.hooks {
+		for _, expectedHook := range expected.hooks {
 			if h.GetUID() == expectedHook.GetUID() {
 				uidMatches[h.GetUID()] = &struct{}{}
 			}
 		}
 	}
-	if len(uidMatches) != len(d.hooks) {
+	if len(uidMatches) != len(expected.hooks) {
 		return fmt.Errorf("hooks UID did not match expected")
 	}
 	return nil
@@ -99,7 +117,7 @@ func TestDispatch(t *testing.T) {
 		name                string
 		attr                admission.Attributes
 		cluster             string
-		expectedHooks       []webhook.WebhookAccessor
+		expectedDispatches  []expectedDispatch
 		hooksInSource       []webhook.WebhookAccessor
 		hookSourceNotSynced bool
 		apiBindings         []*v1alpha1.APIBinding
@@ -107,7 +125,7 @@ func TestDispatch(t *testing.T) {
 		wantErr             bool
 	}{
 		{
-			name: "call for APIBinding only calls hooks in api registration logical cluster",
+			name: "call for APIBinding calls hooks in api registration logical cluster, then hooks in logical cluster",
 			attr: attr(
 				schema.GroupVersionKind{Kind: "Cowboy", Group: "wildwest.dev", Version: "v1"},
 				"bound-resource",
@@ -115,8 +133,18 @@ func TestDispatch(t *testing.T) {
 				admission.Create,
 			),
 			cluster: "root:org:dest-cluster",
-			expectedHooks: []webhook.WebhookAccessor{
-				webhookconfiguration.WithCluster(logicalcluster.New("root:org:source-cluster"), webhook.NewValidatingWebhookAccessor("1", "api-registration-hook", nil)),
+			expectedDispatches: []expectedDispatch{
+				{
+					hooks: []webhook.WebhookAccessor{
+						webhookconfiguration.WithCluster(logicalcluster.New("root:org:source-cluster"), webhook.NewValidatingWebhookAccessor("1", "api-registration-hook", nil)),
+					},
+					clusterName: "root:org:dest-cluster",
+				},
+				{
+					hooks: []webhook.WebhookAccessor{
+						webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("2", "secrets", nil)),
+					},
+				},
 			},
 			hooksInSource: []webhook.WebhookAccessor{
 				webhookconfiguration.WithCluster(logicalcluster.New("root:org:source-cluster"), webhook.NewValidatingWebhookAccessor("1", "api-registration-hook", nil)),
@@ -146,6 +174,51 @@ func TestDispatch(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "call for APIBinding to APIExport in the same logical cluster calls hooks once",
+			attr: attr(
+				schema.GroupVersionKind{Kind: "Cowboy", Group: "wildwest.dev", Version: "v1"},
+				"bound-resource",
+				"cowboys",
+				admission.Create,
+			),
+			cluster: "root:org:dest-cluster",
+			expectedDispatches: []expectedDispatch{
+				{
+					hooks: []webhook.WebhookAccessor{
+						webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("2", "cowboy-hook", nil)),
+					},
+					clusterName: "root:org:dest-cluster",
+				},
+			},
+			hooksInSource: []webhook.WebhookAccessor{
+				webhookconfiguration.WithCluster(logicalcluster.New("root:org:source-cluster"), webhook.NewValidatingWebhookAccessor("1", "cowboy-hook", nil)),
+				webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("2", "cowboy-hook", nil)),
+			},
+			apiBindings: []*v1alpha1.APIBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "one",
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey: "root:org:dest-cluster",
+						},
+					},
+					Status: v1alpha1.APIBindingStatus{
+						BoundResources: []v1alpha1.BoundAPIResource{
+							{
+								Group:    "wildwest.dev",
+								Resource: "cowboys",
+							},
+						},
+						BoundAPIExport: &v1alpha1.ExportReference{
+							Workspace: &v1alpha1.WorkspaceExportReference{
+								Path: "root:org:dest-cluster",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "call for resource only calls hooks in logical cluster",
 			attr: attr(
@@ -155,8 +228,12 @@ func TestDispatch(t *testing.T) {
 				admission.Create,
 			),
 			cluster: "root:org:dest-cluster",
-			expectedHooks: []webhook.WebhookAccessor{
-				webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("3", "secrets", nil)),
+			expectedDispatches: []expectedDispatch{
+				{
+					hooks: []webhook.WebhookAccessor{
+						webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("3", "secrets", nil)),
+					},
+				},
 			},
 			hooksInSource: []webhook.WebhookAccessor{
 				webhookconfiguration.WithCluster(logicalcluster.New("root:org:source-cluster"), webhook.NewValidatingWebhookAccessor("1", "cowboy-hook", nil)),
@@ -164,6 +241,29 @@ func TestDispatch(t *testing.T) {
 				webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("3", "secrets", nil)),
 			},
 		},
+		{
+			name: "cluster name in the user info of the request is not passed to hooks in logical cluster",
+			attr: &clusterAttributes{
+				Attributes: attr(
+					schema.GroupVersionKind{Kind: "Cowboy", Group: "wildwest.dev", Version: "v1"},
+					"bound-resource",
+					"cowboys",
+					admission.Create,
+				),
+				userInfo: &user.DefaultInfo{Name: "impersonated", Extra: map[string][]string{ClusterNameUserInfoExtraKey: {"root:org:source-cluster"}}},
+			},
+			cluster: "root:org:dest-cluster",
+			expectedDispatches: []expectedDispatch{
+				{
+					hooks: []webhook.WebhookAccessor{
+						webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("3", "secrets", nil)),
+					},
+				},
+			},
+			hooksInSource: []webhook.WebhookAccessor{
+				webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("3", "secrets", nil)),
+			},
+		},
 		{
 			name: "API Bindings for other logical cluster call webhooks for dest cluster",
 			attr: attr(
@@ -173,8 +273,12 @@ func TestDispatch(t *testing.T) {
 				admission.Create,
 			),
 			cluster: "root:org:dest-cluster",
-			expectedHooks: []webhook.WebhookAccessor{
-				webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("3", "secrets", nil)),
+			expectedDispatches: []expectedDispatch{
+				{
+					hooks: []webhook.WebhookAccessor{
+						webhookconfiguration.WithCluster(logicalcluster.New("root:org:dest-cluster"), webhook.NewValidatingWebhookAccessor("3", "secrets", nil)),
+					},
+				},
 			},
 			hooksInSource: []webhook.WebhookAccessor{
 				webhookconfiguration.WithCluster(logicalcluster.New("root:org:source-cluster"), webhook.NewValidatingWebhookAccessor("1", "cowboy-hook", nil)),
@@ -261,18 +365,12 @@ func TestDispatch(t *testing.T) {
 
 			fakeClient := fake.NewSimpleClientset(toObjects(tc.apiBindings)...)
 			fakeInformerFactory := kcpinformers.NewSharedInformerFactory(fakeClient, time.Hour)
-			err := fakeInformerFactory.Apis().V1alpha1().APIBindings().Informer().AddIndexers(cache.Indexers{
-				byWorkspaceIndex: func(obj interface{}) ([]string, error) {
-					return []string{logicalcluster.From(obj.(metav1.Object)).String()}, nil
-				},
-			})
-			if err != nil {
-				t.Errorf("unable to add indexer to fake informer-%v", err)
-			}
+			indexers.AddOrDie(fakeInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer(), indexers.ByLogicalCluster)
 
+			dispatcher := &validatingDispatcher{expected: tc.expectedDispatches}
 			o := &WebhookDispatcher{
 				Handler:              admission.NewHandler(admission.Connect, admission.Create, admission.Delete, admission.Update),
-				dispatcher:           &validatingDispatcher{hooks: tc.expectedHooks},
+				dispatcher:           dispatcher,
 				hookSource:           &fakeHookSource{hooks: tc.hooksInSource, hasSynced: !tc.hookSourceNotSynced},
 				apiBindingsIndexer:   fakeInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer(),
 				apiBindingsHasSynced: tc.apiBindingsSynced,
@@ -294,6 +392,9 @@ func TestDispatch(t *testing.T) {
 			if err := o.Dispatch(ctx, tc.attr, nil); (err != nil) != tc.wantErr {
 				t.Fatalf("Dispatch() error = %v, wantErr %v", err, tc.wantErr)
 			}
+			if !tc.wantErr && dispatcher.calls != len(tc.expectedDispatches) {
+				t.Fatalf("Dispatch() called the dispatcher %d times, expected %d", dispatcher.calls, len(tc.expectedDispatches))
+			}
 		})
 	}
 }
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceauthenticationconfiguration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	certutil "k8s.io/client-go/util/cert"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceAuthenticationConfiguration"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &workspaceAuthenticationConfiguration{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// workspaceAuthenticationConfiguration validates WorkspaceAuthenticationConfigurations:
+// - issuer URLs are valid https URLs,
+// - username and groups prefixes are set, not "-", and do not start with "system:",
+// - certificate authority data holds PEM-encoded certificates.
+type workspaceAuthenticationConfiguration struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&workspaceAuthenticationConfiguration{})
+
+func (o *workspaceAuthenticationConfiguration) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspaceauthenticationconfigurations") {
+		return nil
+	}
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	config, err := toWorkspaceAuthenticationConfiguration(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	for i, issuer := range config.Spec.OIDC {
+		if err := validateIssuer(issuer); err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("spec.oidc[%d].%w", i, err))
+		}
+	}
+
+	return nil
+}
+
+func validateIssuer(issuer tenancyv1alpha1.OIDCIssuer) error {
+	u, err := url.Parse(issuer.IssuerURL)
+	if err != nil {
+		return fmt.Errorf("issuerURL: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("issuerURL: must be an https URL")
+	}
+	if issuer.UsernamePrefix == "" || issuer.UsernamePrefix == "-" {
+		return fmt.Errorf("usernamePrefix: must be set")
+	}
+	if strings.HasPrefix(issuer.UsernamePrefix, "system:") {
+		return fmt.Errorf("usernamePrefix: must not start with \"system:\"")
+	}
+	if issuer.GroupsClaim != "" && (issuer.GroupsPrefix == "" || issuer.GroupsPrefix == "-") {
+		return fmt.Errorf("groupsPrefix: must be set if groupsClaim is set")
+	}
+	if strings.HasPrefix(issuer.GroupsPrefix, "system:") {
+		return fmt.Errorf("groupsPrefix: must not start with \"system:\"")
+	}
+	if len(issuer.CertificateAuthorityData) > 0 {
+		if _, err := certutil.ParseCertsPEM(issuer.CertificateAuthorityData); err != nil {
+			return fmt.Errorf("certificateAuthorityData: %w", err)
+		}
+	}
+	return nil
+}
+
+func toWorkspaceAuthenticationConfiguration(obj runtime.Object) (*tenancyv1alpha1.WorkspaceAuthenticationConfiguration, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	config := &tenancyv1alpha1.WorkspaceAuthenticationConfiguration{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, config); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to WorkspaceAuthenticationConfiguration: %w", err)
+	}
+	return config, nil
+}
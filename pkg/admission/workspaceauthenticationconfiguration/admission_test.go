@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceauthenticationconfiguration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func configAttr(issuer tenancyv1alpha1.OIDCIssuer) admission.Attributes {
+	config := &tenancyv1alpha1.WorkspaceAuthenticationConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "corporate",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+		},
+		Spec: tenancyv1alpha1.WorkspaceAuthenticationConfigurationSpec{
+			OIDC: []tenancyv1alpha1.OIDCIssuer{issuer},
+		},
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(config),
+		nil,
+		tenancyv1alpha1.Kind("WorkspaceAuthenticationConfiguration").WithVersion("v1alpha1"),
+		"",
+		config.Name,
+		tenancyv1alpha1.Resource("workspaceauthenticationconfigurations").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		issuer  tenancyv1alpha1.OIDCIssuer
+		wantErr string
+	}{
+		"allows a valid issuer": {
+			issuer: tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp", UsernameClaim: "email", UsernamePrefix: "idp:", GroupsClaim: "groups", GroupsPrefix: "idp:"},
+		},
+		"forbids empty username prefixes": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp"},
+			wantErr: "spec.oidc[0].usernamePrefix: must be set",
+		},
+		"forbids disabled username prefixes": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp", UsernamePrefix: "-"},
+			wantErr: "spec.oidc[0].usernamePrefix: must be set",
+		},
+		"forbids empty groups prefixes with groups claim": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp", UsernamePrefix: "idp:", GroupsClaim: "groups"},
+			wantErr: "spec.oidc[0].groupsPrefix: must be set if groupsClaim is set",
+		},
+		"forbids disabled groups prefixes with groups claim": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp", UsernamePrefix: "idp:", GroupsClaim: "groups", GroupsPrefix: "-"},
+			wantErr: "spec.oidc[0].groupsPrefix: must be set if groupsClaim is set",
+		},
+		"forbids http issuers": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "http://idp.example.com", ClientID: "kcp"},
+			wantErr: "spec.oidc[0].issuerURL: must be an https URL",
+		},
+		"forbids system username prefixes": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp", UsernamePrefix: "system:"},
+			wantErr: `spec.oidc[0].usernamePrefix: must not start with "system:"`,
+		},
+		"forbids system groups prefixes": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp", UsernamePrefix: "idp:", GroupsPrefix: "system:kcp:"},
+			wantErr: `spec.oidc[0].groupsPrefix: must not start with "system:"`,
+		},
+		"forbids invalid certificate authority data": {
+			issuer:  tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://idp.example.com", ClientID: "kcp", UsernamePrefix: "idp:", CertificateAuthorityData: []byte("not a certificate")},
+			wantErr: "spec.oidc[0].certificateAuthorityData",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := &workspaceAuthenticationConfiguration{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			err := o.Validate(context.Background(), configAttr(tc.issuer), nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
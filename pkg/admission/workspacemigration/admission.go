@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacemigration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceMigration"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &workspaceMigration{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// workspaceMigration validates WorkspaceMigrations:
+// - only system:masters can create them, because they move the content of a workspace between shards,
+// - the spec is immutable.
+type workspaceMigration struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&workspaceMigration{})
+
+func (o *workspaceMigration) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspacemigrations") {
+		return nil
+	}
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	migration, err := toWorkspaceMigration(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	if a.GetOperation() == admission.Update {
+		old, err := toWorkspaceMigration(a.GetOldObject())
+		if err != nil {
+			return err
+		}
+		if !equality.Semantic.DeepEqual(old.Spec, migration.Spec) {
+			return admission.NewForbidden(a, errors.New("spec is immutable"))
+		}
+		return nil
+	}
+
+	if !sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		return admission.NewForbidden(a, errors.New("WorkspaceMigrations can only be created by system:masters"))
+	}
+
+	return nil
+}
+
+func toWorkspaceMigration(obj runtime.Object) (*tenancyv1alpha1.WorkspaceMigration, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	migration := &tenancyv1alpha1.WorkspaceMigration{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, migration); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to WorkspaceMigration: %w", err)
+	}
+	return migration, nil
+}
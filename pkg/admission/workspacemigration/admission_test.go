@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacemigration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func workspaceMigrationAttr(obj, old *tenancyv1alpha1.WorkspaceMigration, groups ...string) admission.Attributes {
+	op, opts, oldObj := admission.Create, runtime.Object(&metav1.CreateOptions{}), runtime.Object(nil)
+	if old != nil {
+		op, opts, oldObj = admission.Update, &metav1.UpdateOptions{}, helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		oldObj,
+		tenancyv1alpha1.Kind("WorkspaceMigration").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("workspacemigrations").WithVersion("v1alpha1"),
+		"",
+		op,
+		opts,
+		false,
+		&user.DefaultInfo{Groups: groups},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	newWorkspaceMigration := func(targetShard string) *tenancyv1alpha1.WorkspaceMigration {
+		return &tenancyv1alpha1.WorkspaceMigration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "team-to-beta",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+			},
+			Spec: tenancyv1alpha1.WorkspaceMigrationSpec{
+				Workspace:   "team",
+				TargetShard: targetShard,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		attr    admission.Attributes
+		wantErr string
+	}{
+		"allows system:masters to create": {
+			attr: workspaceMigrationAttr(newWorkspaceMigration("beta"), nil, user.SystemPrivilegedGroup),
+		},
+		"forbids others to create": {
+			attr:    workspaceMigrationAttr(newWorkspaceMigration("beta"), nil, "system:authenticated"),
+			wantErr: "WorkspaceMigrations can only be created by system:masters",
+		},
+		"forbids changing the spec": {
+			attr:    workspaceMigrationAttr(newWorkspaceMigration("gamma"), newWorkspaceMigration("beta"), user.SystemPrivilegedGroup),
+			wantErr: "spec is immutable",
+		},
+		"allows updates without spec changes": {
+			attr: workspaceMigrationAttr(newWorkspaceMigration("beta"), newWorkspaceMigration("beta")),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := &workspaceMigration{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			err := o.Validate(context.Background(), tc.attr, nil)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
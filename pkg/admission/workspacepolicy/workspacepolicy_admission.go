@@ -0,0 +1,288 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacepolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/author"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/workspacepolicy"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspacePolicy"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName, func(configFile io.Reader) (admission.Interface, error) {
+		return NewWorkspacePolicy(), nil
+	})
+}
+
+type workspacePolicy struct {
+	*admission.Handler
+
+	deepSARClient    kubernetesclient.ClusterInterface
+	createAuthorizer delegated.DelegatedAuthorizerFactory
+
+	workspacePoliciesHasSynced cache.InformerSynced
+
+	listPolicies func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error)
+}
+
+var _ admission.MutationInterface = &workspacePolicy{}
+var _ admission.ValidationInterface = &workspacePolicy{}
+var _ admission.InitializationValidator = &workspacePolicy{}
+var _ kcpinitializers.WantsDeepSARClient = &workspacePolicy{}
+
+// NewWorkspacePolicy creates an admission plugin that rejects ClusterWorkspaces of types not allowed
+// by the WorkspacePolicies of the ancestors of the workspace they are created in. It also records the
+// user changing the objects of a WorkspacePolicy as their author, who is impersonated when applying
+// them, rejects objects of disallowed kinds, and ensures that the user adding defaultAPIBindings is
+// allowed to bind their APIExports.
+func NewWorkspacePolicy() admission.Interface {
+	p := &workspacePolicy{
+		Handler:          admission.NewHandler(admission.Create, admission.Update),
+		createAuthorizer: delegated.NewDelegatedAuthorizer,
+	}
+
+	p.SetReadyFunc(
+		func() bool {
+			return p.workspacePoliciesHasSynced()
+		},
+	)
+
+	return p
+}
+
+// Admit records the user as the author of the objects of a WorkspacePolicy on create, and on updates
+// changing them.
+func (p *workspacePolicy) Admit(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspacepolicies") || a.GetSubresource() != "" {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	changed, err := objectsChanged(a)
+	if err != nil {
+		return err
+	}
+	return author.Admit(a, u, changed)
+}
+
+func (p *workspacePolicy) Validate(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) error {
+	if a.GetSubresource() != "" {
+		return nil
+	}
+	switch a.GetResource().GroupResource() {
+	case tenancyv1alpha1.Resource("workspacepolicies"):
+		return p.validatePolicy(ctx, a)
+	case tenancyv1alpha1.Resource("clusterworkspaces"):
+		if a.GetOperation() != admission.Create {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	cw := &tenancyv1alpha1.ClusterWorkspace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cw); err != nil {
+		return fmt.Errorf("failed to convert unstructured to ClusterWorkspace: %w", err)
+	}
+
+	if !p.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	policy, err := workspacepolicy.EffectivePolicy(p.listPolicies, clusterName)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error evaluating WorkspacePolicies: %w", err))
+	}
+	if !policy.AllowsType(cw.Spec.Type) {
+		return admission.NewForbidden(a, fmt.Errorf("ClusterWorkspaceType %s is not allowed in workspace %s by WorkspacePolicy", cw.Spec.Type, clusterName))
+	}
+
+	return nil
+}
+
+// validatePolicy rejects objects of disallowed kinds, ensures that the user changing the objects is
+// recorded as their author, and that the user adding defaultAPIBindings can bind their APIExports.
+// The APIBindings are created with the privileges of kcp, hence the APIBinding admission check of the
+// 'bind' verb is done here instead.
+func (p *workspacePolicy) validatePolicy(ctx context.Context, a admission.Attributes) error {
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	policy := &tenancyv1alpha1.WorkspacePolicy{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, policy); err != nil {
+		return fmt.Errorf("failed to convert unstructured to WorkspacePolicy: %w", err)
+	}
+
+	if errs := author.ValidateObjects(policy.Spec.Objects, field.NewPath("spec", "objects")); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+	changed, err := objectsChanged(a)
+	if err != nil {
+		return err
+	}
+	old, _ := a.GetOldObject().(*unstructured.Unstructured)
+	if err := author.Validate(a, u, old, changed); err != nil {
+		return err
+	}
+
+	existing := map[tenancyv1alpha1.DefaultAPIBinding]bool{}
+	if old != nil {
+		oldPolicy := &tenancyv1alpha1.WorkspacePolicy{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(old.Object, oldPolicy); err != nil {
+			return fmt.Errorf("failed to convert unstructured to WorkspacePolicy: %w", err)
+		}
+		for _, binding := range oldPolicy.Spec.DefaultAPIBindings {
+			existing[binding] = true
+		}
+	}
+	for _, binding := range policy.Spec.DefaultAPIBindings {
+		if existing[binding] {
+			continue
+		}
+		if err := p.checkAPIExportAccess(ctx, a.GetUserInfo(), logicalcluster.New(binding.Path), binding.ExportName); err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("unable to bind APIExport %s|%s by default: %w", binding.Path, binding.ExportName, err))
+		}
+	}
+	return nil
+}
+
+// checkAPIExportAccess checks that the user can use the 'bind' verb with the given APIExport.
+func (p *workspacePolicy) checkAPIExportAccess(ctx context.Context, user user.Info, apiExportClusterName logicalcluster.Name, apiExportName string) error {
+	logger := klog.FromContext(ctx)
+	authz, err := p.createAuthorizer(apiExportClusterName, p.deepSARClient)
+	if err != nil {
+		// Logging a more specific error for the operator
+		logger.Error(err, "error creating authorizer from delegating authorizer config")
+		// Returning a less specific error to the end user
+		return errors.New("unable to authorize request")
+	}
+
+	bindAttr := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "bind",
+		APIGroup:        apisv1alpha1.SchemeGroupVersion.Group,
+		APIVersion:      apisv1alpha1.SchemeGroupVersion.Version,
+		Resource:        "apiexports",
+		Name:            apiExportName,
+		ResourceRequest: true,
+	}
+	if decision, _, err := authz.Authorize(ctx, bindAttr); err != nil {
+		return fmt.Errorf("unable to determine access to apiexports: %w", err)
+	} else if decision != authorizer.DecisionAllow {
+		return errors.New("missing verb='bind' permission on apiexports")
+	}
+	return nil
+}
+
+// objectsChanged returns whether an update changes the objects of the WorkspacePolicy.
+func objectsChanged(a admission.Attributes) (bool, error) {
+	if a.GetOperation() != admission.Update {
+		return false, nil
+	}
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	old, ok := a.GetOldObject().(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("unexpected type %T", a.GetOldObject())
+	}
+	objs, _, err := unstructured.NestedSlice(u.Object, "spec", "objects")
+	if err != nil {
+		return false, err
+	}
+	oldObjs, _, err := unstructured.NestedSlice(old.Object, "spec", "objects")
+	if err != nil {
+		return false, err
+	}
+	return !equality.Semantic.DeepEqual(objs, oldObjs), nil
+}
+
+// SetKcpInformers implements the WantsKcpInformers interface.
+func (p *workspacePolicy) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
+	workspacePolicyInformer := f.Tenancy().V1alpha1().WorkspacePolicies()
+	indexers.AddIfNotPresentOrDie(
+		workspacePolicyInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+		},
+	)
+	p.workspacePoliciesHasSynced = workspacePolicyInformer.Informer().HasSynced
+	p.listPolicies = func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+		return indexers.ByIndex[*tenancyv1alpha1.WorkspacePolicy](workspacePolicyInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+	}
+}
+
+// SetDeepSARClient implements the WantsDeepSARClient interface.
+func (p *workspacePolicy) SetDeepSARClient(client kubernetesclient.ClusterInterface) {
+	p.deepSARClient = client
+}
+
+func (p *workspacePolicy) ValidateInitialization() error {
+	if p.deepSARClient == nil {
+		return errors.New("missing deepSARClient")
+	}
+	if p.workspacePoliciesHasSynced == nil {
+		return errors.New("missing workspacePoliciesHasSynced")
+	}
+	if p.listPolicies == nil {
+		return errors.New("missing listPolicies")
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacepolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/author"
+)
+
+func TestValidate(t *testing.T) {
+	universal := tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "universal", Path: "root"}
+	team := tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:org"}
+
+	policies := map[logicalcluster.Name][]*tenancyv1alpha1.WorkspacePolicy{
+		logicalcluster.New("root:org"): {
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "types"},
+				Spec:       tenancyv1alpha1.WorkspacePolicySpec{AllowedTypes: []tenancyv1alpha1.ClusterWorkspaceTypeReference{team}},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		clusterName logicalcluster.Name
+		resource    schema.GroupVersionResource
+		typeRef     tenancyv1alpha1.ClusterWorkspaceTypeReference
+
+		wantError bool
+	}{
+		"allowed type in descendant": {
+			clusterName: logicalcluster.New("root:org:ws"),
+			resource:    tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"),
+			typeRef:     team,
+		},
+		"disallowed type in descendant": {
+			clusterName: logicalcluster.New("root:org:ws"),
+			resource:    tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"),
+			typeRef:     universal,
+			wantError:   true,
+		},
+		"policy does not apply to its own workspace": {
+			clusterName: logicalcluster.New("root:org"),
+			resource:    tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"),
+			typeRef:     universal,
+		},
+		"other resources are ignored": {
+			clusterName: logicalcluster.New("root:org:ws"),
+			resource:    schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			typeRef:     universal,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := &workspacePolicy{
+				Handler: admission.NewHandler(admission.Create),
+				listPolicies: func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+					return policies[clusterName], nil
+				},
+			}
+
+			cw := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "child"},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: tc.typeRef},
+			}
+			raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cw)
+			require.NoError(t, err)
+			obj := &unstructured.Unstructured{Object: raw}
+
+			a := admission.NewAttributesRecord(obj, nil, tenancyv1alpha1.Kind("ClusterWorkspace").WithVersion("v1alpha1"), "", "child", tc.resource, "", admission.Create, &metav1.CreateOptions{}, false, &user.DefaultInfo{})
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: tc.clusterName})
+
+			err = p.Validate(ctx, a, nil)
+			if tc.wantError {
+				require.Error(t, err)
+				require.True(t, apierrors.IsForbidden(err))
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func policyAttr(policy, old *tenancyv1alpha1.WorkspacePolicy, info user.Info) admission.Attributes {
+	op := admission.Create
+	var oldObj runtime.Object
+	if old != nil {
+		op = admission.Update
+		oldObj = helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(policy),
+		oldObj,
+		tenancyv1alpha1.Kind("WorkspacePolicy").WithVersion("v1alpha1"),
+		"",
+		policy.Name,
+		tenancyv1alpha1.Resource("workspacepolicies").WithVersion("v1alpha1"),
+		"",
+		op,
+		&metav1.CreateOptions{},
+		false,
+		info,
+	)
+}
+
+func TestPolicyObjects(t *testing.T) {
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{user.AllAuthenticated}}
+	bob := &user.DefaultInfo{Name: "bob", Groups: []string{user.AllAuthenticated}}
+	admin := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+	aliceValue, err := author.AnnotationValue(alice)
+	require.NoError(t, err)
+
+	configMap := tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"default"}}`),
+	}}
+	secret := tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"token","namespace":"default"}}`),
+	}}
+	newPolicy := func(author string, objs ...tenancyv1alpha1.DefaultObject) *tenancyv1alpha1.WorkspacePolicy {
+		policy := &tenancyv1alpha1.WorkspacePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy", Annotations: map[string]string{}},
+			Spec:       tenancyv1alpha1.WorkspacePolicySpec{Objects: objs},
+		}
+		if author != "" {
+			policy.Annotations[tenancyv1alpha1.ExperimentalAuthorAnnotationKey] = author
+		}
+		return policy
+	}
+
+	tests := []struct {
+		name        string
+		policy, old *tenancyv1alpha1.WorkspacePolicy
+		user        user.Info
+		wantAuthor  string
+		wantErr     bool
+	}{
+		{
+			name:       "creator is recorded as author",
+			policy:     newPolicy("", configMap),
+			user:       alice,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:       "forged author is replaced",
+			policy:     newPolicy(`{"username":"admin","groups":["system:masters"]}`, configMap),
+			user:       alice,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:       "changing the objects makes the user the author",
+			policy:     newPolicy(aliceValue, configMap, configMap),
+			old:        newPolicy(aliceValue, configMap),
+			user:       bob,
+			wantAuthor: `{"username":"bob","groups":["system:authenticated"]}`,
+		},
+		{
+			name:       "other changes keep the author",
+			policy:     newPolicy(aliceValue, configMap),
+			old:        newPolicy(aliceValue, configMap),
+			user:       bob,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:       "system:masters keep the author",
+			policy:     newPolicy(aliceValue, configMap),
+			user:       admin,
+			wantAuthor: aliceValue,
+		},
+		{
+			name:    "secrets are rejected",
+			policy:  newPolicy("", secret),
+			user:    admin,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org")})
+			p := &workspacePolicy{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			a := policyAttr(tt.policy, tt.old, tt.user)
+			require.NoError(t, p.Admit(ctx, a, nil))
+			err := p.Validate(ctx, a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAuthor, a.GetObject().(*unstructured.Unstructured).GetAnnotations()[tenancyv1alpha1.ExperimentalAuthorAnnotationKey])
+		})
+	}
+
+	t.Run("changing the author without the objects is rejected", func(t *testing.T) {
+		ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org")})
+		p := &workspacePolicy{Handler: admission.NewHandler(admission.Create, admission.Update)}
+		err := p.Validate(ctx, policyAttr(newPolicy(`{"username":"admin","groups":["system:masters"]}`, configMap), newPolicy(aliceValue, configMap), bob), nil)
+		require.Error(t, err)
+	})
+}
+
+func TestDefaultAPIBindings(t *testing.T) {
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{user.AllAuthenticated}}
+	databases := tenancyv1alpha1.DefaultAPIBinding{Path: "root:org:services", ExportName: "databases"}
+	queues := tenancyv1alpha1.DefaultAPIBinding{Path: "root:org:services", ExportName: "queues"}
+	newPolicy := func(bindings ...tenancyv1alpha1.DefaultAPIBinding) *tenancyv1alpha1.WorkspacePolicy {
+		return &tenancyv1alpha1.WorkspacePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Spec:       tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: bindings},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		policy, old *tenancyv1alpha1.WorkspacePolicy
+		allowed     []string
+
+		wantChecked []string
+		wantErr     bool
+	}{
+		{
+			name:        "bindable APIExports are allowed",
+			policy:      newPolicy(databases),
+			allowed:     []string{"root:org:services|databases"},
+			wantChecked: []string{"root:org:services|databases"},
+		},
+		{
+			name:        "APIExports which cannot be bound are rejected",
+			policy:      newPolicy(databases),
+			wantChecked: []string{"root:org:services|databases"},
+			wantErr:     true,
+		},
+		{
+			name:        "only added APIExports are checked",
+			policy:      newPolicy(databases, queues),
+			old:         newPolicy(databases),
+			allowed:     []string{"root:org:services|queues"},
+			wantChecked: []string{"root:org:services|queues"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checked []string
+			p := &workspacePolicy{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: func(clusterName logicalcluster.Name, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
+					return authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+						require.Equal(t, "bind", attr.GetVerb())
+						require.Equal(t, "apiexports", attr.GetResource())
+						require.Equal(t, "alice", attr.GetUser().GetName())
+						key := clusterName.String() + "|" + attr.GetName()
+						checked = append(checked, key)
+						for _, allowed := range tt.allowed {
+							if allowed == key {
+								return authorizer.DecisionAllow, "", nil
+							}
+						}
+						return authorizer.DecisionNoOpinion, "", nil
+					}), nil
+				},
+			}
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org")})
+			a := policyAttr(tt.policy, tt.old, alice)
+			require.NoError(t, p.Admit(ctx, a, nil))
+			err := p.Validate(ctx, a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.True(t, apierrors.IsForbidden(err))
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantChecked, checked)
+		})
+	}
+}
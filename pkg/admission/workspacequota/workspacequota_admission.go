@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacequota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceQuota"
+
+	// maxChargeAttempts is the number of attempts to charge an object against the usage of a
+	// WorkspaceQuota on conflicts.
+	maxChargeAttempts = 5
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName, func(configFile io.Reader) (admission.Interface, error) {
+		return NewWorkspaceQuota(), nil
+	})
+}
+
+type workspaceQuota struct {
+	*admission.Handler
+
+	workspaceQuotasHasSynced cache.InformerSynced
+
+	getCachedWorkspaceQuota func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error)
+	getWorkspaceQuota       func(ctx context.Context, clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error)
+	updateWorkspaceQuota    func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error
+}
+
+var _ admission.ValidationInterface = &workspaceQuota{}
+var _ admission.InitializationValidator = &workspaceQuota{}
+
+// NewWorkspaceQuota creates an admission plugin that rejects objects beyond the WorkspaceQuota of
+// their workspace. Admitted objects are charged against the usage in the status of the
+// WorkspaceQuota, such that concurrent requests cannot exceed the limits.
+func NewWorkspaceQuota() admission.ValidationInterface {
+	p := &workspaceQuota{
+		Handler: admission.NewHandler(admission.Create, admission.Update),
+	}
+
+	p.SetReadyFunc(
+		func() bool {
+			return p.workspaceQuotasHasSynced()
+		},
+	)
+
+	return p
+}
+
+// charge is what a request adds to the usage of a workspace.
+type charge struct {
+	objects         bool
+	storageBytes    int64
+	childWorkspaces bool
+}
+
+func (q *workspaceQuota) Validate(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) error {
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return err
+	}
+	parent, hasParent := clusterName.Parent()
+	if !hasParent {
+		return nil
+	}
+
+	if !q.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	quota, err := q.getCachedWorkspaceQuota(parent, clusterName.Base())
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error getting WorkspaceQuota: %w", err))
+	}
+
+	c, err := chargeFor(a)
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+
+	gr := a.GetResource().GroupResource()
+	for attempt := 0; attempt < maxChargeAttempts; attempt++ {
+		quota = quota.DeepCopy()
+		charged, err := applyCharge(quota, gr.Group, gr.Resource, c)
+		if err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("exceeded WorkspaceQuota %s|%s: %w", parent, quota.Name, err))
+		}
+		if !charged || a.IsDryRun() {
+			return nil
+		}
+
+		err = q.updateWorkspaceQuota(ctx, parent, quota)
+		if err == nil {
+			return nil
+		}
+		if !apierrors.IsConflict(err) {
+			return admission.NewForbidden(a, fmt.Errorf("error charging WorkspaceQuota %s|%s: %w", parent, quota.Name, err))
+		}
+
+		quota, err = q.getWorkspaceQuota(ctx, parent, clusterName.Base())
+		if err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("error getting WorkspaceQuota %s|%s: %w", parent, clusterName.Base(), err))
+		}
+	}
+
+	return admission.NewForbidden(a, fmt.Errorf("error charging WorkspaceQuota %s|%s: too many conflicts", parent, clusterName.Base()))
+}
+
+// chargeFor returns what the request adds to the usage. Objects and child workspaces are only
+// charged on creation, storage by the growth of the object.
+func chargeFor(a admission.Attributes) (charge, error) {
+	size, err := objectSize(a.GetObject())
+	if err != nil {
+		return charge{}, err
+	}
+
+	if a.GetOperation() == admission.Update {
+		oldSize, err := objectSize(a.GetOldObject())
+		if err != nil {
+			return charge{}, err
+		}
+		return charge{storageBytes: size - oldSize}, nil
+	}
+
+	return charge{
+		objects:         true,
+		storageBytes:    size,
+		childWorkspaces: a.GetResource().GroupResource() == tenancyv1alpha1.Resource("clusterworkspaces"),
+	}, nil
+}
+
+// objectSize approximates the storage size of an object by the size of its JSON encoding.
+func objectSize(obj runtime.Object) (int64, error) {
+	if obj == nil {
+		return 0, nil
+	}
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine the size of the object: %w", err)
+	}
+	return int64(len(bs)), nil
+}
+
+// applyCharge adds the charge to the usage in the status of the quota, and returns whether the
+// usage changed. It fails if a limit would be exceeded.
+func applyCharge(quota *tenancyv1alpha1.WorkspaceQuota, group, resourceName string, c charge) (bool, error) {
+	charged := false
+
+	if maxObjects, found := quota.ObjectCountLimitFor(group, resourceName); found && c.objects {
+		count := objectCountFor(quota, group, resourceName)
+		if count.Count >= maxObjects {
+			return false, fmt.Errorf("%s limited to %d objects", schema.GroupResource{Group: group, Resource: resourceName}, maxObjects)
+		}
+		count.Count++
+		charged = true
+	}
+
+	if limit := quota.Spec.ChildWorkspaces; limit != nil && c.childWorkspaces {
+		var used int64
+		if quota.Status.Used.ChildWorkspaces != nil {
+			used = *quota.Status.Used.ChildWorkspaces
+		}
+		if used >= *limit {
+			return false, fmt.Errorf("limited to %d child workspaces", *limit)
+		}
+		used++
+		quota.Status.Used.ChildWorkspaces = &used
+		charged = true
+	}
+
+	if limit := quota.Spec.StorageBytes; limit != nil && c.storageBytes > 0 {
+		used := resource.NewQuantity(0, resource.BinarySI)
+		if quota.Status.Used.StorageBytes != nil {
+			used = quota.Status.Used.StorageBytes
+		}
+		if used.Value()+c.storageBytes > limit.Value() {
+			return false, fmt.Errorf("storage limited to %s", limit)
+		}
+		quota.Status.Used.StorageBytes = resource.NewQuantity(used.Value()+c.storageBytes, resource.BinarySI)
+		charged = true
+	}
+
+	return charged, nil
+}
+
+// objectCountFor returns the object count of the given resource in the status of the quota,
+// adding it if it does not exist yet.
+func objectCountFor(quota *tenancyv1alpha1.WorkspaceQuota, group, resourceName string) *tenancyv1alpha1.ObjectCount {
+	for i := range quota.Status.Used.ObjectCounts {
+		if count := &quota.Status.Used.ObjectCounts[i]; count.Group == group && count.Resource == resourceName {
+			return count
+		}
+	}
+	quota.Status.Used.ObjectCounts = append(quota.Status.Used.ObjectCounts, tenancyv1alpha1.ObjectCount{Group: group, Resource: resourceName})
+	return &quota.Status.Used.ObjectCounts[len(quota.Status.Used.ObjectCounts)-1]
+}
+
+// SetKcpInformers implements the WantsKcpInformers interface.
+func (q *workspaceQuota) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
+	workspaceQuotaInformer := f.Tenancy().V1alpha1().WorkspaceQuotas()
+	q.workspaceQuotasHasSynced = workspaceQuotaInformer.Informer().HasSynced
+	q.getCachedWorkspaceQuota = func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error) {
+		return workspaceQuotaInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+	}
+}
+
+// SetKcpClusterClient implements the WantsKcpClusterClient interface.
+func (q *workspaceQuota) SetKcpClusterClient(c kcpclient.ClusterInterface) {
+	q.getWorkspaceQuota = func(ctx context.Context, clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error) {
+		return c.Cluster(clusterName).TenancyV1alpha1().WorkspaceQuotas().Get(ctx, name, metav1.GetOptions{})
+	}
+	q.updateWorkspaceQuota = func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error {
+		_, err := c.Cluster(clusterName).TenancyV1alpha1().WorkspaceQuotas().UpdateStatus(ctx, quota, metav1.UpdateOptions{})
+		return err
+	}
+}
+
+func (q *workspaceQuota) ValidateInitialization() error {
+	if q.workspaceQuotasHasSynced == nil {
+		return errors.New("missing workspaceQuotasHasSynced")
+	}
+	if q.getCachedWorkspaceQuota == nil {
+		return errors.New("missing getCachedWorkspaceQuota")
+	}
+	if q.updateWorkspaceQuota == nil {
+		return errors.New("missing kcpClusterClient")
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacequota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestValidate(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	clusterWorkspaces := tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces")
+
+	newQuota := func(spec tenancyv1alpha1.WorkspaceQuotaSpec, used tenancyv1alpha1.WorkspaceQuotaUsage) *tenancyv1alpha1.WorkspaceQuota {
+		return &tenancyv1alpha1.WorkspaceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "ws"},
+			Spec:       spec,
+			Status:     tenancyv1alpha1.WorkspaceQuotaStatus{Used: used},
+		}
+	}
+	configMapLimit := func(maxObjects int64) []tenancyv1alpha1.ObjectCountLimit {
+		return []tenancyv1alpha1.ObjectCountLimit{{Resource: "configmaps", MaxObjects: maxObjects}}
+	}
+	configMapCount := func(count int64) []tenancyv1alpha1.ObjectCount {
+		return []tenancyv1alpha1.ObjectCount{{Resource: "configmaps", Count: count}}
+	}
+	int64Ptr := func(i int64) *int64 { return &i }
+	bytes := func(i int64) *resource.Quantity { return resource.NewQuantity(i, resource.BinarySI) }
+
+	newObj := func(data string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"data": data}}
+		obj.SetName("cm")
+		return obj
+	}
+	size := func(obj *unstructured.Unstructured) int64 {
+		s, err := objectSize(obj)
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := map[string]struct {
+		clusterName logicalcluster.Name
+		resource    schema.GroupVersionResource
+		operation   admission.Operation
+		obj, oldObj *unstructured.Unstructured
+		quota       *tenancyv1alpha1.WorkspaceQuota
+		conflicts   int
+		dryRun      bool
+
+		wantError bool
+		wantUsed  *tenancyv1alpha1.WorkspaceQuotaUsage
+	}{
+		"no quota": {
+			resource: configMaps,
+		},
+		"root workspace is not limited": {
+			clusterName: logicalcluster.New("root"),
+			resource:    configMaps,
+			quota:       newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(0)}, tenancyv1alpha1.WorkspaceQuotaUsage{}),
+		},
+		"resource without limit": {
+			resource: schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+			quota:    newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(0)}, tenancyv1alpha1.WorkspaceQuotaUsage{}),
+		},
+		"below object count limit": {
+			resource: configMaps,
+			quota:    newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(10)}, tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(9)}),
+			wantUsed: &tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(10)},
+		},
+		"object count limit without usage yet": {
+			resource: configMaps,
+			quota:    newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(10)}, tenancyv1alpha1.WorkspaceQuotaUsage{}),
+			wantUsed: &tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(1)},
+		},
+		"object count limit exceeded": {
+			resource:  configMaps,
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(10)}, tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(10)}),
+			wantError: true,
+		},
+		"updates are not charged for object counts": {
+			resource:  configMaps,
+			operation: admission.Update,
+			oldObj:    newObj("a"),
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(10)}, tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(10)}),
+		},
+		"child workspace limit": {
+			resource: clusterWorkspaces,
+			quota:    newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(2)}, tenancyv1alpha1.WorkspaceQuotaUsage{ChildWorkspaces: int64Ptr(1)}),
+			wantUsed: &tenancyv1alpha1.WorkspaceQuotaUsage{ChildWorkspaces: int64Ptr(2)},
+		},
+		"child workspace limit exceeded": {
+			resource:  clusterWorkspaces,
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(2)}, tenancyv1alpha1.WorkspaceQuotaUsage{ChildWorkspaces: int64Ptr(2)}),
+			wantError: true,
+		},
+		"storage is charged on creation": {
+			resource: configMaps,
+			obj:      newObj("a"),
+			quota:    newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{StorageBytes: bytes(1000)}, tenancyv1alpha1.WorkspaceQuotaUsage{StorageBytes: bytes(100)}),
+			wantUsed: &tenancyv1alpha1.WorkspaceQuotaUsage{StorageBytes: bytes(100 + size(newObj("a")))},
+		},
+		"storage limit exceeded": {
+			resource:  configMaps,
+			obj:       newObj("a"),
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{StorageBytes: bytes(1000)}, tenancyv1alpha1.WorkspaceQuotaUsage{StorageBytes: bytes(999)}),
+			wantError: true,
+		},
+		"storage growth is charged on update": {
+			resource:  configMaps,
+			operation: admission.Update,
+			obj:       newObj("abc"),
+			oldObj:    newObj("a"),
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{StorageBytes: bytes(1000)}, tenancyv1alpha1.WorkspaceQuotaUsage{StorageBytes: bytes(100)}),
+			wantUsed:  &tenancyv1alpha1.WorkspaceQuotaUsage{StorageBytes: bytes(102)},
+		},
+		"shrinking updates are not charged": {
+			resource:  configMaps,
+			operation: admission.Update,
+			obj:       newObj("a"),
+			oldObj:    newObj("abc"),
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{StorageBytes: bytes(1000)}, tenancyv1alpha1.WorkspaceQuotaUsage{StorageBytes: bytes(1000)}),
+		},
+		"dry run is not charged": {
+			resource: configMaps,
+			quota:    newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(10)}, tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(1)}),
+			dryRun:   true,
+		},
+		"conflicts are retried": {
+			resource:  configMaps,
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(10)}, tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(1)}),
+			conflicts: 2,
+			wantUsed:  &tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(2)},
+		},
+		"too many conflicts": {
+			resource:  configMaps,
+			quota:     newQuota(tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: configMapLimit(10)}, tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: configMapCount(1)}),
+			conflicts: maxChargeAttempts,
+			wantError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			clusterName := tc.clusterName
+			if clusterName.Empty() {
+				clusterName = logicalcluster.New("root:org:ws")
+			}
+			conflicts := tc.conflicts
+			var updated *tenancyv1alpha1.WorkspaceQuota
+			q := &workspaceQuota{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				getCachedWorkspaceQuota: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error) {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					require.Equal(t, "ws", name)
+					if tc.quota == nil {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("workspacequotas"), name)
+					}
+					return tc.quota, nil
+				},
+				getWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error) {
+					return tc.quota, nil
+				},
+				updateWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					if conflicts > 0 {
+						conflicts--
+						return apierrors.NewConflict(tenancyv1alpha1.Resource("workspacequotas"), quota.Name, nil)
+					}
+					updated = quota
+					return nil
+				},
+			}
+
+			operation := tc.operation
+			if operation == "" {
+				operation = admission.Create
+			}
+			obj := tc.obj
+			if obj == nil {
+				obj = newObj("")
+			}
+			var a admission.Attributes
+			if tc.oldObj != nil {
+				a = admission.NewAttributesRecord(obj, tc.oldObj, schema.GroupVersionKind{}, "default", "cm", tc.resource, "", operation, &metav1.UpdateOptions{}, tc.dryRun, &user.DefaultInfo{})
+			} else {
+				a = admission.NewAttributesRecord(obj, nil, schema.GroupVersionKind{}, "default", "cm", tc.resource, "", operation, &metav1.CreateOptions{}, tc.dryRun, &user.DefaultInfo{})
+			}
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: clusterName})
+
+			err := q.Validate(ctx, a, nil)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.wantUsed == nil {
+				require.Nil(t, updated)
+				return
+			}
+			require.NotNil(t, updated)
+			require.Equal(t, *tc.wantUsed, updated.Status.Used)
+			require.NotSame(t, tc.quota, updated, "the informer copy must not be mutated")
+		})
+	}
+}
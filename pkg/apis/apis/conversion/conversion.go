@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conversion converts CustomResourceDefinition manifests into APIResourceSchemas.
+package conversion
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/install"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+	codecs = serializer.NewCodecFactory(scheme)
+)
+
+func init() {
+	install.Install(scheme)
+}
+
+// DecodeCRDs decodes the CustomResourceDefinitions of a YAML or JSON stream with one or more documents.
+// Both apiextensions.k8s.io/v1 and v1beta1 are accepted. v1beta1 CRDs are defaulted and converted to v1,
+// i.e. top-level validation, subresources and printer columns are moved into each version.
+func DecodeCRDs(in io.Reader) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	d := kubeyaml.NewYAMLReader(bufio.NewReader(in))
+
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	for {
+		doc, err := d.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		// decode into the internal version, which both v1 and v1beta1 convert from and to
+		decoded, gvk, err := codecs.UniversalDecoder().Decode(doc, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		internal, ok := decoded.(*apiextensions.CustomResourceDefinition)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %s, expected a CustomResourceDefinition", gvk)
+		}
+
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := scheme.Convert(internal, crd, nil); err != nil {
+			return nil, fmt.Errorf("error converting CustomResourceDefinition %s: %w", internal.Name, err)
+		}
+		crd.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition"))
+
+		crds = append(crds, crd)
+	}
+
+	return crds, nil
+}
+
+// CRDToAPIResourceSchema converts a CustomResourceDefinition to an APIResourceSchema named <prefix>.<crd.Name>.
+// In addition to apisv1alpha1.CRDToAPIResourceSchema, it keeps the API approval annotation of protected groups,
+// and turns spec.preserveUnknownFields into x-kubernetes-preserve-unknown-fields of the version schemas.
+// Schemas including their defaults, subresources and printer columns are carried over unchanged.
+func CRDToAPIResourceSchema(crd *apiextensionsv1.CustomResourceDefinition, prefix string) (*apisv1alpha1.APIResourceSchema, error) {
+	if crd.Spec.PreserveUnknownFields {
+		crd = crd.DeepCopy()
+		for i := range crd.Spec.Versions {
+			if v := &crd.Spec.Versions[i]; v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+				preserve := true
+				v.Schema.OpenAPIV3Schema.XPreserveUnknownFields = &preserve
+			}
+		}
+	}
+
+	apiResourceSchema, err := apisv1alpha1.CRDToAPIResourceSchema(crd, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval, found := crd.Annotations[apiextensionsv1beta1.KubeAPIApprovedAnnotation]; found {
+		apiResourceSchema.Annotations = map[string]string{
+			apiextensionsv1beta1.KubeAPIApprovedAnnotation: approval,
+		}
+	}
+
+	return apiResourceSchema, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+var v1beta1CRD = `
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.k8s.io
+  annotations:
+    api-approved.kubernetes.io: "https://github.com/kubernetes/enhancements/pull/1111"
+spec:
+  group: example.k8s.io
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  preserveUnknownFields: false
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  - name: v2
+    served: true
+    storage: false
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            size:
+              type: integer
+              default: 3
+  subresources:
+    status: {}
+    scale:
+      specReplicasPath: .spec.size
+      statusReplicasPath: .status.size
+  additionalPrinterColumns:
+  - name: Size
+    type: integer
+    JSONPath: .spec.size
+`
+
+var v1CRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.io
+spec:
+  group: example.io
+  names:
+    kind: Gadget
+    plural: gadgets
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+`
+
+func TestDecodeCRDs(t *testing.T) {
+	crds, err := DecodeCRDs(strings.NewReader(v1beta1CRD + "\n---\n" + v1CRD))
+	require.NoError(t, err)
+	require.Len(t, crds, 2)
+
+	widgets := crds[0]
+	require.Equal(t, "widgets.example.k8s.io", widgets.Name)
+	require.Equal(t, "widget", widgets.Spec.Names.Singular, "expected defaulting")
+	require.Equal(t, "WidgetList", widgets.Spec.Names.ListKind, "expected defaulting")
+	require.Len(t, widgets.Spec.Versions, 2)
+	for _, v := range widgets.Spec.Versions {
+		require.NotNil(t, v.Schema, "version %s", v.Name)
+		require.Equal(t, `3`, string(v.Schema.OpenAPIV3Schema.Properties["spec"].Properties["size"].Default.Raw), "version %s", v.Name)
+		require.NotNil(t, v.Subresources, "version %s", v.Name)
+		require.NotNil(t, v.Subresources.Status, "version %s", v.Name)
+		require.NotNil(t, v.Subresources.Scale, "version %s", v.Name)
+		require.Equal(t, []apiextensionsv1.CustomResourceColumnDefinition{{Name: "Size", Type: "integer", JSONPath: ".spec.size"}}, v.AdditionalPrinterColumns, "version %s", v.Name)
+	}
+
+	require.Equal(t, "gadgets.example.io", crds[1].Name)
+
+	_, err = DecodeCRDs(strings.NewReader("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n"))
+	require.Error(t, err)
+}
+
+func TestCRDToAPIResourceSchema(t *testing.T) {
+	crds, err := DecodeCRDs(strings.NewReader(v1beta1CRD + "\n---\n" + v1CRD))
+	require.NoError(t, err)
+
+	widgets, err := CRDToAPIResourceSchema(crds[0], "today")
+	require.NoError(t, err)
+	require.Equal(t, "today.widgets.example.k8s.io", widgets.Name)
+	require.Equal(t, map[string]string{"api-approved.kubernetes.io": "https://github.com/kubernetes/enhancements/pull/1111"}, widgets.Annotations)
+	require.Len(t, widgets.Spec.Versions, 2)
+	for _, v := range widgets.Spec.Versions {
+		schema, err := v.GetSchema()
+		require.NoError(t, err)
+		require.Equal(t, `3`, string(schema.Properties["spec"].Properties["size"].Default.Raw), "version %s", v.Name)
+		require.Nil(t, schema.XPreserveUnknownFields, "version %s", v.Name)
+		require.NotNil(t, v.Subresources.Scale, "version %s", v.Name)
+		require.Len(t, v.AdditionalPrinterColumns, 1, "version %s", v.Name)
+	}
+
+	crds[1].Spec.PreserveUnknownFields = true
+	gadgets, err := CRDToAPIResourceSchema(crds[1], "today")
+	require.NoError(t, err)
+	require.Nil(t, gadgets.Annotations)
+	schema, err := gadgets.Spec.Versions[0].GetSchema()
+	require.NoError(t, err)
+	require.NotNil(t, schema.XPreserveUnknownFields)
+	require.True(t, *schema.XPreserveUnknownFields)
+	require.Nil(t, crds[1].Spec.Versions[0].Schema.OpenAPIV3Schema.XPreserveUnknownFields, "input CRD must not be mutated")
+}
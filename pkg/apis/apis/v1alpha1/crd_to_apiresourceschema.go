@@ -77,5 +77,22 @@ func CRDToAPIResourceSchema(crd *apiextensionsv1.CustomResourceDefinition, prefi
 		apiResourceSchema.Spec.Versions = append(apiResourceSchema.Spec.Versions, apiResourceVersion)
 	}
 
+	if conversion := crd.Spec.Conversion; conversion != nil && conversion.Strategy == apiextensionsv1.WebhookConverter {
+		if conversion.Webhook == nil || conversion.Webhook.ClientConfig == nil || conversion.Webhook.ClientConfig.URL == nil {
+			return nil, field.Invalid(field.NewPath("spec", "conversion", "webhook", "clientConfig"), conversion.Webhook, "only webhooks with url are supported")
+		}
+
+		apiResourceSchema.Spec.Conversion = &CustomResourceConversion{
+			Strategy: WebhookConverter,
+			Webhook: &WebhookConversion{
+				ClientConfig: WebhookClientConfig{
+					URL:      *conversion.Webhook.ClientConfig.URL,
+					CABundle: conversion.Webhook.ClientConfig.CABundle,
+				},
+				ConversionReviewVersions: conversion.Webhook.ConversionReviewVersions,
+			},
+		}
+	}
+
 	return apiResourceSchema, nil
 }
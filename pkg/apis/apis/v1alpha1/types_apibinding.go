@@ -26,6 +26,10 @@ const (
 	// InternalAPIBindingExportLabelKey is the label key on an APIBinding with the
 	// base62(sha224(<clusterName>:<exportName>)) as value to filter bindings by export.
 	InternalAPIBindingExportLabelKey = "internal.apis.kcp.dev/export"
+
+	// APIBindingClusterRoleLabelKey is the label key on ClusterRoles instantiated from the
+	// clusterRoleTemplates of an APIExport, with the name of the APIBinding as value.
+	APIBindingClusterRoleLabelKey = "apis.kcp.dev/apibinding"
 )
 
 // APIBinding enables a set of resources and their behaviour through an external
@@ -78,6 +82,81 @@ type APIBindingSpec struct {
 	//
 	// +optional
 	PermissionClaims []AcceptablePermissionClaim `json:"permissionClaims,omitempty"`
+
+	// upgradePolicy controls when the binding picks up changes of the latestResourceSchemas
+	// of the APIExport after the initial binding. If unset, the Automatic policy is used.
+	//
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+
+	// resources restricts the binding to the given resources of the APIExport. Other resources
+	// of the APIExport are not bound. If empty, all resources of the APIExport are bound.
+	//
+	// +optional
+	// +listType=atomic
+	Resources []GroupResource `json:"resources,omitempty"`
+
+	// quotas limit the number of objects of bound resources in this workspace. They can only
+	// tighten the defaultQuotas of the APIExport: the lower of both limits applies.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=resource
+	Quotas []ResourceQuota `json:"quotas,omitempty"`
+}
+
+// BindsResource returns whether the binding includes the given resource of its APIExport.
+func (in *APIBinding) BindsResource(group, resource string) bool {
+	if len(in.Spec.Resources) == 0 {
+		return true
+	}
+	for _, r := range in.Spec.Resources {
+		if r.Group == group && r.Resource == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// UpgradePolicyType is the type of an upgrade policy.
+type UpgradePolicyType string
+
+const (
+	// UpgradePolicyAutomatic picks up new resource schemas as soon as the APIExport publishes them.
+	UpgradePolicyAutomatic UpgradePolicyType = "Automatic"
+	// UpgradePolicyManual picks up new resource schemas only once their APIExport generation is approved.
+	UpgradePolicyManual UpgradePolicyType = "Manual"
+	// UpgradePolicyScheduled picks up new resource schemas after they have been available for a delay.
+	UpgradePolicyScheduled UpgradePolicyType = "Scheduled"
+)
+
+// UpgradePolicy controls when an APIBinding picks up new resource schemas of its APIExport.
+type UpgradePolicy struct {
+	// type is the type of the upgrade policy:
+	// - Automatic: new resource schemas are bound as soon as the APIExport publishes them.
+	// - Manual: new resource schemas are bound once approvedGeneration is at least the
+	//   generation of the APIExport.
+	// - Scheduled: new resource schemas are bound once the APIExport has not changed for
+	//   the given delay.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Automatic;Manual;Scheduled
+	Type UpgradePolicyType `json:"type"`
+
+	// approvedGeneration is the latest generation of the APIExport whose resource schemas may be
+	// bound with the Manual upgrade policy. Compare with status.availableExportGeneration.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ApprovedGeneration int64 `json:"approvedGeneration,omitempty"`
+
+	// delay is the duration the APIExport must not have changed before its new resource schemas
+	// are bound with the Scheduled upgrade policy.
+	//
+	// +optional
+	Delay *metav1.Duration `json:"delay,omitempty"`
 }
 
 // AcceptablePermissionClaim is a PermissionClaim that records if the user accepts or rejects it.
@@ -97,8 +176,36 @@ type AcceptablePermissionClaimState string
 const (
 	ClaimAccepted AcceptablePermissionClaimState = "Accepted"
 	ClaimRejected AcceptablePermissionClaimState = "Rejected"
+
+	// ClaimPending is the state of permission claims of the APIExport that are neither accepted
+	// nor rejected in spec.permissionClaims yet. It is only used in the status.
+	ClaimPending AcceptablePermissionClaimState = "Pending"
 )
 
+// PermissionClaimStatus records the acceptance state of a permission claim requested by the APIExport.
+type PermissionClaimStatus struct {
+	PermissionClaim `json:",inline"`
+
+	// state is Pending until the claim is accepted or rejected in spec.permissionClaims. The
+	// API service provider only gets access for accepted claims.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Pending;Accepted;Rejected
+	State AcceptablePermissionClaimState `json:"state"`
+}
+
+// PermissionClaimState returns the decision about the given permission claim of the APIExport in
+// spec.permissionClaims, or ClaimPending if there is none.
+func (in *APIBinding) PermissionClaimState(claim PermissionClaim) AcceptablePermissionClaimState {
+	for _, acceptable := range in.Spec.PermissionClaims {
+		if acceptable.GroupResource == claim.GroupResource && acceptable.IdentityHash == claim.IdentityHash {
+			return acceptable.State
+		}
+	}
+	return ClaimPending
+}
+
 // ExportReference describes a reference to an APIExport. Exactly one of the
 // fields must be set.
 type ExportReference struct {
@@ -108,6 +215,41 @@ type ExportReference struct {
 	//
 	// +optional
 	Workspace *WorkspaceExportReference `json:"workspace,omitempty"`
+
+	// identity is a reference to an APIExport by its identity hash alone. The APIExport
+	// is looked up across all shards, independent of the workspace it lives in. Hence,
+	// the APIBinding keeps working when the provider moves the APIExport to another
+	// workspace, as long as the identity is preserved. The creator of the APIBinding
+	// needs to have access to the resolved APIExport with the verb `bind` in order to
+	// bind to it.
+	//
+	// Exactly one of workspace and identity must be set.
+	//
+	// +optional
+	Identity *IdentityExportReference `json:"identity,omitempty"`
+}
+
+// IdentityExportReference describes an API and backing implementation by the identity of the APIExport.
+type IdentityExportReference struct {
+	// identityHash is the identity hash of the APIExport, as found in its status.identityHash.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	IdentityHash string `json:"identityHash"`
+}
+
+// ExportWorkspaceReference returns the workspace reference of the APIExport of the binding. For
+// references by identity, this is the APIExport the identity was resolved to, as recorded in
+// status.boundExport, or nil if it has not been resolved yet.
+func (in *APIBinding) ExportWorkspaceReference() *WorkspaceExportReference {
+	if in.Spec.Reference.Workspace != nil {
+		return in.Spec.Reference.Workspace
+	}
+	if in.Spec.Reference.Identity != nil && in.Status.BoundAPIExport != nil {
+		return in.Status.BoundAPIExport.Workspace
+	}
+	return nil
 }
 
 // WorkspaceExportReference describes an API and backing implementation that are provided by an actor in the
@@ -132,10 +274,86 @@ type WorkspaceExportReference struct {
 type APIBindingPhaseType string
 
 const (
+	// APIBindingPhasePending waits for the referenced APIExport to be found.
+	APIBindingPhasePending APIBindingPhaseType = "Pending"
+	// APIBindingPhaseIdentityVerification waits for the identity of the APIExport to be available.
+	APIBindingPhaseIdentityVerification APIBindingPhaseType = "IdentityVerification"
+	// APIBindingPhaseSchemasBinding waits for the resource schemas of the APIExport to be established.
+	APIBindingPhaseSchemasBinding APIBindingPhaseType = "SchemasBinding"
+	// APIBindingPhasePermissionClaimsPending means the APIs are bound, but permission claims of the
+	// APIExport are neither accepted nor rejected in spec.permissionClaims.
+	APIBindingPhasePermissionClaimsPending APIBindingPhaseType = "PermissionClaimsPending"
+	// APIBindingPhaseBound means the APIs are bound and all permission claims are decided.
+	APIBindingPhaseBound APIBindingPhaseType = "Bound"
+	// APIBindingPhaseFailed means the initial binding failed. The reason is reported in the
+	// conditions and in status.schemas.
+	APIBindingPhaseFailed APIBindingPhaseType = "Failed"
+
+	// APIBindingPhaseBinding is the phase of APIBindings that are being bound by older versions.
+	//
+	// Deprecated: use the more specific phases above.
 	APIBindingPhaseBinding APIBindingPhaseType = "Binding"
-	APIBindingPhaseBound   APIBindingPhaseType = "Bound"
 )
 
+// IsBound returns whether the APIs of the APIBinding are bound, independently of the
+// decisions about the permission claims of the APIExport.
+func (in *APIBinding) IsBound() bool {
+	return in.Status.Phase == APIBindingPhaseBound || in.Status.Phase == APIBindingPhasePermissionClaimsPending
+}
+
+// APIResourceSchemaPhaseType is the type of the phase of a resource schema of an APIBinding.
+type APIResourceSchemaPhaseType string
+
+const (
+	// APIResourceSchemaPhaseBinding waits for the resource schema to be established.
+	APIResourceSchemaPhaseBinding APIResourceSchemaPhaseType = "Binding"
+	// APIResourceSchemaPhaseBound means the resource schema is bound.
+	APIResourceSchemaPhaseBound APIResourceSchemaPhaseType = "Bound"
+	// APIResourceSchemaPhaseFailed means the resource schema cannot be bound.
+	APIResourceSchemaPhaseFailed APIResourceSchemaPhaseType = "Failed"
+)
+
+// APIResourceSchemaStatus is the binding state of a resource schema of the APIExport.
+type APIResourceSchemaStatus struct {
+	// name is the name of the APIResourceSchema.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// group is the group of the resource.
+	//
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// resource is the resource name. It is empty if the APIResourceSchema is not found.
+	//
+	// +optional
+	Resource string `json:"resource,omitempty"`
+
+	// phase is the phase of the resource schema:
+	// - Binding: the resource schema is waiting to be established.
+	// - Bound: the resource schema is bound.
+	// - Failed: the resource schema cannot be bound, see reason and message.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Binding;Bound;Failed
+	Phase APIResourceSchemaPhaseType `json:"phase"`
+
+	// reason is a machine-readable reason for a failure, e.g. NamingConflicts or
+	// APIResourceSchemaInvalid.
+	//
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// message is a human-readable description of a failure.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // APIBindingStatus records which schemas are bound.
 type APIBindingStatus struct {
 	// boundExport records the export this binding is bound to currently. It can
@@ -158,13 +376,29 @@ type APIBindingStatus struct {
 
 	// phase is the current phase of the APIBinding:
 	// - "": the APIBinding has just been created, waiting to be bound.
-	// - Binding: the APIBinding is being bound.
+	// - Pending: the referenced APIExport is not found yet.
+	// - IdentityVerification: the identity of the APIExport is not available yet.
+	// - SchemasBinding: the resource schemas of the APIExport are being bound, see status.schemas.
+	// - PermissionClaimsPending: the referenced APIs are available in the workspace, but some
+	//   permission claims of the APIExport are neither accepted nor rejected.
 	// - Bound: the APIBinding is bound and the referenced APIs are available in the workspace.
+	// - Failed: the initial binding failed, see the conditions and status.schemas.
+	//
+	// Once bound, the phase only changes between PermissionClaimsPending and Bound. Failures to pick
+	// up changes of the APIExport are reported in the conditions and status.schemas.
 	//
 	// +optional
-	// +kubebuilder:validation:Enum="";Binding;Bound
+	// +kubebuilder:validation:Enum="";Pending;IdentityVerification;SchemasBinding;PermissionClaimsPending;Bound;Failed;Binding
 	Phase APIBindingPhaseType `json:"phase,omitempty"`
 
+	// schemas reports the binding state of the resource schemas of the APIExport selected by
+	// the APIBinding.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Schemas []APIResourceSchemaStatus `json:"schemas,omitempty"`
+
 	// conditions is a list of conditions that apply to the APIBinding.
 	//
 	// +optional
@@ -181,6 +415,139 @@ type APIBindingStatus struct {
 	// the binding to grant.
 	// +optional
 	ExportPermissionClaims []PermissionClaim `json:"exportPermissionClaims,omitempty"`
+
+	// permissionClaims records the acceptance state of each permission claim requested by the
+	// APIExport. Claims are Pending until they are accepted or rejected in spec.permissionClaims.
+	//
+	// +optional
+	PermissionClaims []PermissionClaimStatus `json:"permissionClaims,omitempty"`
+
+	// availableExportGeneration is the generation of the APIExport whose latest resource schemas
+	// are available to be bound.
+	//
+	// +optional
+	AvailableExportGeneration int64 `json:"availableExportGeneration,omitempty"`
+
+	// boundExportGeneration is the generation of the APIExport whose latest resource schemas
+	// are bound. It lags behind availableExportGeneration while an upgrade is pending.
+	//
+	// +optional
+	BoundExportGeneration int64 `json:"boundExportGeneration,omitempty"`
+
+	// upgradeAvailableSince is the time when the currently available resource schemas, which
+	// differ from the bound ones, were first observed. It is unset if no upgrade is pending.
+	//
+	// +optional
+	UpgradeAvailableSince *metav1.Time `json:"upgradeAvailableSince,omitempty"`
+
+	// schemaCompatibility reports the changes of the available resource schemas that are
+	// incompatible with the bound ones. It is unset if no upgrade is pending.
+	//
+	// +optional
+	SchemaCompatibility *SchemaCompatibilityReport `json:"schemaCompatibility,omitempty"`
+
+	// quotaUsage reports the number of objects of the bound resources with a quota.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=resource
+	QuotaUsage []ResourceQuotaUsage `json:"quotaUsage,omitempty"`
+}
+
+// ResourceQuotaUsage is the number of objects of a bound resource with a quota.
+type ResourceQuotaUsage struct {
+	GroupResource `json:","`
+
+	// maxObjects is the quota in effect, i.e. the lower of the quotas of the APIExport
+	// and the APIBinding.
+	//
+	// +required
+	MaxObjects int64 `json:"maxObjects"`
+
+	// objectCount is the number of objects of the resource in the workspace.
+	//
+	// +required
+	ObjectCount int64 `json:"objectCount"`
+}
+
+// QuotaFor returns the quota of the given resource in the spec of the APIBinding, and whether
+// there is one.
+func (in *APIBinding) QuotaFor(group, resource string) (int64, bool) {
+	for _, q := range in.Spec.Quotas {
+		if q.Group == group && q.Resource == resource {
+			return q.MaxObjects, true
+		}
+	}
+	return 0, false
+}
+
+// SchemaCompatibilityReport lists the incompatible changes between the bound resource schemas
+// and the latest resource schemas of an APIExport.
+type SchemaCompatibilityReport struct {
+	// exportGeneration is the generation of the APIExport the report was computed for.
+	//
+	// +required
+	ExportGeneration int64 `json:"exportGeneration"`
+
+	// changes lists the incompatible changes. Upgrades with incompatible changes are not
+	// bound automatically, unless the apis.kcp.dev/allow-breaking-changes annotation of
+	// the APIBinding is set to "true".
+	//
+	// +optional
+	Changes []SchemaChange `json:"changes,omitempty"`
+}
+
+// SchemaChangeType is the kind of an incompatible schema change.
+type SchemaChangeType string
+
+const (
+	// SchemaChangeVersionRemoved is a served version that is not served anymore.
+	SchemaChangeVersionRemoved SchemaChangeType = "VersionRemoved"
+	// SchemaChangeFieldRemoved is a field that is not part of the schema anymore.
+	SchemaChangeFieldRemoved SchemaChangeType = "FieldRemoved"
+	// SchemaChangeTypeChanged is a field of another type.
+	SchemaChangeTypeChanged SchemaChangeType = "TypeChanged"
+	// SchemaChangeValidationTightened is a field with stricter validation, e.g. a new
+	// required field or a lower maximum.
+	SchemaChangeValidationTightened SchemaChangeType = "ValidationTightened"
+)
+
+// SchemaChange is an incompatible change of a resource schema.
+type SchemaChange struct {
+	// group is the group of the changed resource. Empty string for the core API group.
+	//
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// resource is the changed resource.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Resource string `json:"resource"`
+
+	// version is the changed version of the resource.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+
+	// type is the kind of the change.
+	//
+	// +required
+	// +kubebuilder:validation:Enum=VersionRemoved;FieldRemoved;TypeChanged;ValidationTightened
+	Type SchemaChangeType `json:"type"`
+
+	// path is the JSON path of the changed field, e.g. .spec.replicas. It is empty
+	// for changes of the whole version.
+	//
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// message is a human readable description of the change.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // These are valid conditions of APIBinding.
@@ -197,6 +564,10 @@ const (
 	// APIResourceSchemaInvalidReason is a reason for the InitialBindingCompleted and BindingUpToDate conditions when one of generated CRD is invalid.
 	APIResourceSchemaInvalidReason = "APIResourceSchemaInvalid"
 
+	// APIResourceSchemaNotFoundReason is a reason for a failed resource schema in status.schemas when the
+	// APIResourceSchema referenced by the APIExport does not exist.
+	APIResourceSchemaNotFoundReason = "APIResourceSchemaNotFound"
+
 	// InternalErrorReason is a reason used by multiple conditions that something went wrong.
 	InternalErrorReason = "InternalError"
 
@@ -215,6 +586,17 @@ const (
 	// has a naming conflict with other APIs.
 	NamingConflictsReason = "NamingConflicts"
 
+	// UpgradePendingReason is a reason for the BindingUpToDate condition that the APIExport publishes new
+	// resource schemas, but the upgrade policy of the APIBinding does not allow to bind them yet.
+	UpgradePendingReason = "UpgradePending"
+
+	// BreakingChangesReason is a reason for the BindingUpToDate condition that the available resource
+	// schemas have incompatible changes which are not bound without approval.
+	BreakingChangesReason = "BreakingChanges"
+	// ResourcesNotExportedReason is a reason for the BindingUpToDate condition that resources selected by the
+	// APIBinding are not exported by the APIExport.
+	ResourcesNotExportedReason = "ResourcesNotExported"
+
 	// BindingResourceDeleteSuccess is a condition for APIBinding that indicates the resources relating this binding are deleted
 	// successfully when the APIBinding is deleting
 	BindingResourceDeleteSuccess conditionsv1alpha1.ConditionType = "BindingResourceDeleteSuccess"
@@ -229,6 +611,47 @@ const (
 	// PermissionClaimsApplied is a condition for APIBinding that indicates that all the accepted permission claims
 	// have been applied.
 	PermissionClaimsApplied conditionsv1alpha1.ConditionType = "PermissionClaimsApplied"
+
+	// APIExportDeprecated is a condition for APIBinding that is true if the bound APIExport or any served version
+	// of its latest resource schemas is deprecated. It is absent otherwise.
+	APIExportDeprecated conditionsv1alpha1.ConditionType = "APIExportDeprecated"
+
+	// DeprecatedReason is a reason for the APIExportDeprecated condition that the APIExport is deprecated.
+	DeprecatedReason = "Deprecated"
+	// VersionsDeprecatedReason is a reason for the APIExportDeprecated condition that only some versions of the
+	// resource schemas of the APIExport are deprecated. Requests to those versions get the deprecation warning of
+	// the version from the bound CRD.
+	VersionsDeprecatedReason = "VersionsDeprecated"
+
+	// StorageVersionsMigrated is a condition for APIBinding that indicates whether the stored objects of all
+	// bound resources are stored in the current storage version. It is absent if no migration was ever needed.
+	StorageVersionsMigrated conditionsv1alpha1.ConditionType = "StorageVersionsMigrated"
+
+	// StorageVersionMigrationInProgressReason is a reason for the StorageVersionsMigrated condition that stored
+	// objects are being rewritten in the current storage version.
+	StorageVersionMigrationInProgressReason = "MigrationInProgress"
+	// StorageVersionMigrationFailedReason is a reason for the StorageVersionsMigrated condition that rewriting
+	// stored objects failed. The migration is retried.
+	StorageVersionMigrationFailedReason = "MigrationFailed"
+
+	// MaximalPermissionPolicyValid is a condition for APIBinding that indicates whether the maximal permission
+	// policy of the bound APIExport can be evaluated. While it is false, requests to the bound resources are
+	// denied. It is absent if the APIExport has no maximal permission policy.
+	MaximalPermissionPolicyValid conditionsv1alpha1.ConditionType = "MaximalPermissionPolicyValid"
+
+	// UnsupportedMaximalPermissionPolicyReason is a reason for the MaximalPermissionPolicyValid condition that the
+	// APIExport specifies a maximal permission policy this server does not know about.
+	UnsupportedMaximalPermissionPolicyReason = "UnsupportedMaximalPermissionPolicy"
+	// NoMaximalPermissionPolicyBindingsReason is a reason for the MaximalPermissionPolicyValid condition that the
+	// workspace of the APIExport has no RBAC bindings for users or groups with the
+	// MaximalPermissionPolicyRBACUserGroupPrefix.
+	NoMaximalPermissionPolicyBindingsReason = "NoMaximalPermissionPolicyBindings"
+)
+
+const (
+	// AnnotationAllowBreakingChangesKey is the annotation key of an APIBinding to allow upgrades to
+	// resource schemas with incompatible changes, if set to "true".
+	AnnotationAllowBreakingChangesKey = "apis.kcp.dev/allow-breaking-changes"
 )
 
 // These are annotations for bound CRDs
@@ -276,6 +699,74 @@ type BoundAPIResource struct {
 	// +optional
 	// +listType=set
 	StorageVersions []string `json:"storageVersions,omitempty"`
+
+	// storageVersionMigration reports the progress of rewriting the stored objects of the resource
+	// in the current storage version. It is absent if no migration is in progress.
+	//
+	// +optional
+	StorageVersionMigration *StorageVersionMigration `json:"storageVersionMigration,omitempty"`
+
+	// customSubresources lists the custom subresources of the bound API, beyond status and scale.
+	//
+	// +optional
+	CustomSubresources []BoundCustomSubresource `json:"customSubresources,omitempty"`
+}
+
+// BoundCustomSubresource is a custom subresource of a version of a bound API.
+type BoundCustomSubresource struct {
+	// version is the version of the bound API the subresource is served for.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+
+	// name is the name of the subresource, and of the top-level field owned by it.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// verbs are the verbs the subresource is served for.
+	//
+	// +required
+	// +listType=set
+	Verbs []string `json:"verbs"`
+}
+
+// CustomSubresource returns the custom subresource with the given name of the given version of
+// the bound API, or nil if there is none.
+func (r *BoundAPIResource) CustomSubresource(version, name string) *BoundCustomSubresource {
+	for i := range r.CustomSubresources {
+		if subresource := &r.CustomSubresources[i]; subresource.Version == version && subresource.Name == name {
+			return subresource
+		}
+	}
+	return nil
+}
+
+// StorageVersionMigration is the progress of migrating the stored objects of a bound resource
+// to another storage version.
+type StorageVersionMigration struct {
+	// targetVersion is the storage version objects are migrated to.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	TargetVersion string `json:"targetVersion"`
+
+	// migratedObjects is the number of objects that have been rewritten so far.
+	//
+	// +optional
+	MigratedObjects int64 `json:"migratedObjects,omitempty"`
+
+	// continue is the list continue token of the next chunk of objects to migrate.
+	//
+	// +optional
+	Continue string `json:"continue,omitempty"`
+
+	// startTime is the time the migration started.
+	//
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
 }
 
 // BoundAPIResourceSchema is a reference to an APIResourceSchema.
@@ -20,7 +20,10 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
@@ -41,6 +44,34 @@ const (
 const (
 	// SecretKeyAPIExportIdentity is the key in an identity secret for the identity of an APIExport.
 	SecretKeyAPIExportIdentity = "key"
+	// SecretKeyAPIExportSealedIdentity is the key in an identity secret for the identity of an APIExport
+	// when sealed by an encryption provider, e.g. an external KMS.
+	SecretKeyAPIExportSealedIdentity = "sealedKey"
+)
+
+// These are the reasons of the events recorded on an APIExport in its workspace to notify the
+// provider about consumers binding and unbinding the APIExport, and about objects of the bound
+// resources being created and deleted.
+const (
+	APIExportBoundEventReason           = "APIBindingBound"
+	APIExportUnboundEventReason         = "APIBindingUnbound"
+	APIExportInstanceCreatedEventReason = "InstanceCreated"
+	APIExportInstanceDeletedEventReason = "InstanceDeleted"
+)
+
+// These are the annotations of the events recorded on an APIExport.
+const (
+	// APIExportEventClusterAnnotationKey is the logical cluster of the APIBinding or object the event is about.
+	APIExportEventClusterAnnotationKey = "apis.kcp.dev/event-cluster"
+	// APIExportEventAPIBindingAnnotationKey is the name of the APIBinding the event is about, or the one
+	// binding the resource of the object.
+	APIExportEventAPIBindingAnnotationKey = "apis.kcp.dev/event-apibinding"
+	// APIExportEventResourceAnnotationKey is the group resource of the object the event is about.
+	APIExportEventResourceAnnotationKey = "apis.kcp.dev/event-resource"
+	// APIExportEventNamespaceAnnotationKey is the namespace of the object the event is about, if any.
+	APIExportEventNamespaceAnnotationKey = "apis.kcp.dev/event-namespace"
+	// APIExportEventNameAnnotationKey is the name of the object the event is about.
+	APIExportEventNameAnnotationKey = "apis.kcp.dev/event-name"
 )
 
 // APIExport registers an API and implementation to allow consumption by others
@@ -156,6 +187,104 @@ type APIExportSpec struct {
 	// +listMapKey=group
 	// +listMapKey=resource
 	PermissionClaims []PermissionClaim `json:"permissionClaims,omitempty"`
+
+	// deprecated marks the APIExport as deprecated. APIBindings to a deprecated APIExport
+	// report the deprecation in their APIExportDeprecated condition, and requests to the
+	// bound resources return a warning.
+	//
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// deprecationWarning overrides the default warning returned to clients and reported
+	// to APIBindings when the APIExport is deprecated.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	DeprecationWarning *string `json:"deprecationWarning,omitempty"`
+
+	// sunsetDate is the date after which a deprecated APIExport is planned to be removed.
+	//
+	// +optional
+	SunsetDate *metav1.Time `json:"sunsetDate,omitempty"`
+
+	// clusterRoleTemplates are ClusterRoles that are created in every workspace binding this
+	// APIExport, and deleted with the APIBinding. The ClusterRole of a template is named
+	// apis.kcp.dev:<apibinding-name>:<template-name>.
+	//
+	// Templates must only grant access to the exported resources and the permission claims. In a
+	// binding workspace, rules about resources that are not bound or whose claims are not accepted
+	// are dropped. Aggregation labels are not allowed, use roleAggregation instead.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ClusterRoleTemplates []ClusterRoleTemplate `json:"clusterRoleTemplates,omitempty"`
+
+	// roleAggregation aggregates the resources bound by an APIBinding into the default view, edit
+	// and admin ClusterRoles of the consumer workspace, such that their users get access to newly
+	// bound resources without role updates. The ClusterRoles carrying the aggregation labels are
+	// named apis.kcp.dev:<apibinding-name>:aggregate:<role>, and are deleted with the APIBinding.
+	//
+	// +optional
+	RoleAggregation *RoleAggregation `json:"roleAggregation,omitempty"`
+
+	// defaultQuotas limit the number of objects of the exported resources in each workspace
+	// binding this APIExport. APIBindings can tighten, but not raise them.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=resource
+	DefaultQuotas []ResourceQuota `json:"defaultQuotas,omitempty"`
+}
+
+// ResourceQuota limits the number of objects of a resource in a workspace.
+type ResourceQuota struct {
+	GroupResource `json:","`
+
+	// maxObjects is the maximal number of objects of the resource.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	MaxObjects int64 `json:"maxObjects"`
+}
+
+// ClusterRoleTemplate is a ClusterRole instantiated in the workspaces binding an APIExport.
+type ClusterRoleTemplate struct {
+	// name identifies the template within the APIExport.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Name string `json:"name"`
+
+	// labels are set on the ClusterRole. Aggregation labels like
+	// rbac.authorization.k8s.io/aggregate-to-edit are not allowed.
+	//
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// rules are the policy rules of the ClusterRole. They must only refer to the exported resources
+	// and the claimed resources of the APIExport, without wildcards and non-resource URLs.
+	//
+	// +optional
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// RoleAggregation selects the default ClusterRoles the bound resources are aggregated into.
+type RoleAggregation struct {
+	// view grants get, list and watch on the bound resources through the view ClusterRole,
+	// and through edit and admin which aggregate view.
+	//
+	// +optional
+	View bool `json:"view,omitempty"`
+
+	// edit grants all of view and create, update, patch, delete and deletecollection on the
+	// bound resources through the edit and admin ClusterRoles.
+	//
+	// +optional
+	Edit bool `json:"edit,omitempty"`
 }
 
 // Identity defines the identity of an APIExport, i.e. determines the etcd prefix
@@ -194,6 +323,60 @@ type PermissionClaim struct {
 	// Note that one must look this up for a particular KCP instance.
 	// +optional
 	IdentityHash string `json:"identityHash,omitempty"`
+
+	// verbs restricts the claim to the given verbs, e.g. get, list and watch for read-only
+	// access. If empty, all verbs are claimed.
+	//
+	// +optional
+	// +listType=set
+	Verbs []string `json:"verbs,omitempty"`
+
+	// resourceSelector restricts the claim to a subset of the objects of the resource.
+	// If unset, all objects are claimed.
+	//
+	// +optional
+	ResourceSelector *ResourceSelector `json:"resourceSelector,omitempty"`
+}
+
+// ResourceSelector selects a subset of the objects of a resource. Objects have to match
+// all of the given restrictions.
+type ResourceSelector struct {
+	// names restricts the claim to the objects with the given names.
+	//
+	// +optional
+	// +listType=set
+	Names []string `json:"names,omitempty"`
+
+	// labelSelector restricts the claim to the objects matching the label selector.
+	//
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// AllowsVerb returns whether the claim grants access for the given verb.
+func (p PermissionClaim) AllowsVerb(verb string) bool {
+	if len(p.Verbs) == 0 {
+		return true
+	}
+	for _, v := range p.Verbs {
+		if v == verb || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsName returns whether the claim grants access to the object with the given name.
+func (p PermissionClaim) AllowsName(name string) bool {
+	if p.ResourceSelector == nil || len(p.ResourceSelector.Names) == 0 {
+		return true
+	}
+	for _, n := range p.ResourceSelector.Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
 
 func (p PermissionClaim) String() string {
@@ -206,7 +389,9 @@ func (p PermissionClaim) String() string {
 func (p PermissionClaim) Equal(claim PermissionClaim) bool {
 	return p.Group == claim.Group &&
 		p.Resource == claim.Resource &&
-		p.IdentityHash == claim.IdentityHash
+		p.IdentityHash == claim.IdentityHash &&
+		sets.NewString(p.Verbs...).Equal(sets.NewString(claim.Verbs...)) &&
+		equality.Semantic.DeepEqual(p.ResourceSelector, claim.ResourceSelector)
 }
 
 // GroupResource identifies a resource.
@@ -240,9 +425,61 @@ type APIExportStatus struct {
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
 
-	// virtualWorkspaces contains all APIExport virtual workspace URLs.
+	// virtualWorkspaces contains the APIExport virtual workspace URLs of all shards, ordered
+	// by shard name. Every shard serves the objects of the consumers scheduled to it, hence
+	// controllers of the API provider have to watch all of them. Shards sharing a virtual
+	// workspace URL are listed once.
 	// +optional
 	VirtualWorkspaces []VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+
+	// usage is the consumption of this APIExport by APIBindings. It is
+	// recomputed periodically.
+	//
+	// +optional
+	Usage *APIExportUsage `json:"usage,omitempty"`
+}
+
+// APIExportUsage is the consumption of an APIExport by APIBindings.
+type APIExportUsage struct {
+	// bindingCount is the number of APIBindings bound to the APIExport.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	BindingCount int32 `json:"bindingCount"`
+
+	// consumers is the usage of the APIExport per APIBinding, ordered by workspace
+	// and name. At most 500 consumers are listed.
+	//
+	// +optional
+	Consumers []APIExportConsumer `json:"consumers,omitempty"`
+}
+
+// APIExportConsumer is the usage of an APIExport by one APIBinding.
+type APIExportConsumer struct {
+	// workspace is the logical cluster name of the consumer workspace.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Workspace string `json:"workspace"`
+
+	// apiBinding is the name of the APIBinding in the consumer workspace.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	APIBinding string `json:"apiBinding"`
+
+	// objectCount is the number of objects of the bound resources in the
+	// consumer workspace.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	ObjectCount int64 `json:"objectCount"`
+
+	// lastActivityTime is the last time an object of the bound resources was
+	// observed to be created, updated or deleted in the consumer workspace.
+	//
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
 }
 
 type VirtualWorkspace struct {
@@ -252,6 +489,11 @@ type VirtualWorkspace struct {
 	// +kubebuilder:format:URL
 	// +required
 	URL string `json:"url"`
+
+	// shard is the name of the ClusterWorkspaceShard serving this virtual workspace URL.
+	//
+	// +optional
+	Shard string `json:"shard,omitempty"`
 }
 
 // APIExportList is a list of APIExport resources
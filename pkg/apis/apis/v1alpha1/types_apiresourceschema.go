@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"encoding/json"
+	"fmt"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -67,14 +68,83 @@ type APIResourceSchemaSpec struct {
 
 	// versions is the API version of the defined custom resource.
 	//
-	// Note: the OpenAPI v3 schemas must be equal for all versions until CEL
-	//       version migration is supported.
+	// Note: the OpenAPI v3 schemas must be equal for all versions unless a conversion
+	//       webhook is configured.
 	//
 	// +required
 	// +listType=map
 	// +listMapKey=name
 	// +kubebuilder:validation:MinItems=1
 	Versions []APIResourceVersion `json:"versions"`
+
+	// conversion defines conversion settings between the versions of the defined custom resource.
+	// If it is not set, the None strategy is used.
+	//
+	// +optional
+	Conversion *CustomResourceConversion `json:"conversion,omitempty"`
+}
+
+// CustomResourceConversion describes how to convert different versions of a resource.
+type CustomResourceConversion struct {
+	// strategy specifies how custom resources are converted between versions. Allowed values are:
+	// - `None`: The converter only changes the apiVersion and does not touch any other field in the custom resource.
+	// - `Webhook`: kcp calls an external webhook to do the conversion. This requires webhook to be set.
+	//
+	// +required
+	// +kubebuilder:validation:Enum=None;Webhook
+	Strategy ConversionStrategyType `json:"strategy"`
+
+	// webhook describes how to call the conversion webhook. Required when `strategy` is set to `Webhook`.
+	//
+	// +optional
+	Webhook *WebhookConversion `json:"webhook,omitempty"`
+}
+
+// ConversionStrategyType describes different conversion types.
+type ConversionStrategyType string
+
+const (
+	// NoneConverter is a converter that only sets apiversion of the CR and leave everything else unchanged.
+	NoneConverter ConversionStrategyType = "None"
+	// WebhookConverter is a converter that calls to an external webhook to convert the CR.
+	WebhookConverter ConversionStrategyType = "Webhook"
+)
+
+// WebhookConversion describes how to call a conversion webhook.
+type WebhookConversion struct {
+	// clientConfig is the instructions for how to call the webhook.
+	//
+	// +required
+	ClientConfig WebhookClientConfig `json:"clientConfig"`
+
+	// conversionReviewVersions is an ordered list of preferred `ConversionReview`
+	// versions the webhook expects. kcp uses the first version in the list which it
+	// supports. If none of the versions specified in this list are supported by kcp,
+	// conversion fails for the custom resource.
+	//
+	// +required
+	// +listType=atomic
+	// +kubebuilder:validation:MinItems=1
+	ConversionReviewVersions []string `json:"conversionReviewVersions"`
+}
+
+// WebhookClientConfig contains the information to make a TLS connection with the webhook.
+// Unlike for CustomResourceDefinitions, services cannot be referenced because the webhook
+// does not run in the workspace of the resources.
+type WebhookClientConfig struct {
+	// url gives the location of the webhook, in standard URL form
+	// (`https://host:port/path`). The scheme must be "https". Query parameters and
+	// fragments are not allowed.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// caBundle is a PEM encoded CA bundle which will be used to validate the webhook's server certificate.
+	// If unspecified, system trust roots are used.
+	//
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
 }
 
 // APIResourceVersion describes one API version of a resource.
@@ -128,6 +198,40 @@ type APIResourceVersion struct {
 	// +listType=map
 	// +listMapKey=name
 	AdditionalPrinterColumns []apiextensionsv1.CustomResourceColumnDefinition `json:"additionalPrinterColumns,omitempty"`
+	// customSubresources specify subresources of this version of the defined custom resource
+	// beyond status and scale, e.g. to model imperative actions like `approve`. Each custom
+	// subresource owns the top-level field of the same name in the custom resource, like the
+	// status subresource owns `.status`: the field can only be changed through the subresource,
+	// and the subresource can only change that field.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	CustomSubresources []CustomSubresource `json:"customSubresources,omitempty"`
+}
+
+// CustomSubresource describes a custom subresource of a custom resource.
+type CustomSubresource struct {
+	// name is the name of the subresource. It is served at `.../<resource>/<object name>/<name>`,
+	// and it is the name of the top-level field of the custom resource owned by the subresource.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=^[a-z]([-a-z0-9]*[a-z0-9])?$
+	Name string `json:"name"`
+	// verbs are the verbs the subresource is served for. Supported verbs are get, update and patch.
+	//
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Verbs []string `json:"verbs"`
+	// schema describes the structural schema used for validation, pruning, and defaulting
+	// of the field owned by the subresource.
+	//
+	// +required
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +structType=atomic
+	Schema runtime.RawExtension `json:"schema"`
 }
 
 // APIResourceSchemaList is a list of APIResourceSchema resources
@@ -163,3 +267,19 @@ func (v *APIResourceVersion) SetSchema(schema *apiextensionsv1.JSONSchemaProps)
 	v.Schema.Raw = raw
 	return nil
 }
+
+// AddCustomSubresourceSchemas adds the schemas of the custom subresources of the version as
+// top-level properties to the given schema of the version.
+func (v *APIResourceVersion) AddCustomSubresourceSchemas(schema *apiextensionsv1.JSONSchemaProps) error {
+	for _, subresource := range v.CustomSubresources {
+		var props apiextensionsv1.JSONSchemaProps
+		if err := json.Unmarshal(subresource.Schema.Raw, &props); err != nil {
+			return fmt.Errorf("invalid schema of custom subresource %q: %w", subresource.Name, err)
+		}
+		if schema.Properties == nil {
+			schema.Properties = map[string]apiextensionsv1.JSONSchemaProps{}
+		}
+		schema.Properties[subresource.Name] = props
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// ObjectReference is a reference to an object, possibly in another workspace. Resources
+// provided by an APIExport are qualified by the identity hash of the APIExport, such that
+// the reference cannot be resolved against a different APIExport providing the same
+// group and resource.
+type ObjectReference struct {
+	// path is an absolute reference to the workspace of the object, e.g. root:org:ws. If it
+	// is unset, the workspace of the referencing object is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Path string `json:"path,omitempty"`
+
+	// identityHash is the identity of the APIExport providing the resource. It must be
+	// set if and only if the resource is provided by an APIExport.
+	//
+	// +optional
+	IdentityHash string `json:"identityHash,omitempty"`
+
+	// group is the API group of the object. Empty string for the core API group.
+	//
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// version is the API version of the object.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+
+	// resource is the resource of the object.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Resource string `json:"resource"`
+
+	// namespace is the namespace of the object. Empty for cluster-scoped objects.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// name is the name of the object.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
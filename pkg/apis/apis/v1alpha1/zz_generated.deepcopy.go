@@ -23,7 +23,9 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
@@ -97,6 +99,23 @@ func (in *APIBindingSpec) DeepCopyInto(out *APIBindingSpec) {
 	if in.PermissionClaims != nil {
 		in, out := &in.PermissionClaims, &out.PermissionClaims
 		*out = make([]AcceptablePermissionClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(UpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]GroupResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = make([]ResourceQuota, len(*in))
 		copy(*out, *in)
 	}
 	return
@@ -127,6 +146,11 @@ func (in *APIBindingStatus) DeepCopyInto(out *APIBindingStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Schemas != nil {
+		in, out := &in.Schemas, &out.Schemas
+		*out = make([]APIResourceSchemaStatus, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(conditionsv1alpha1.Conditions, len(*in))
@@ -137,11 +161,36 @@ func (in *APIBindingStatus) DeepCopyInto(out *APIBindingStatus) {
 	if in.AppliedPermissionClaims != nil {
 		in, out := &in.AppliedPermissionClaims, &out.AppliedPermissionClaims
 		*out = make([]PermissionClaim, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.ExportPermissionClaims != nil {
 		in, out := &in.ExportPermissionClaims, &out.ExportPermissionClaims
 		*out = make([]PermissionClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PermissionClaims != nil {
+		in, out := &in.PermissionClaims, &out.PermissionClaims
+		*out = make([]PermissionClaimStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UpgradeAvailableSince != nil {
+		in, out := &in.UpgradeAvailableSince, &out.UpgradeAvailableSince
+		*out = (*in).DeepCopy()
+	}
+	if in.SchemaCompatibility != nil {
+		in, out := &in.SchemaCompatibility, &out.SchemaCompatibility
+		*out = new(SchemaCompatibilityReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QuotaUsage != nil {
+		in, out := &in.QuotaUsage, &out.QuotaUsage
+		*out = make([]ResourceQuotaUsage, len(*in))
 		copy(*out, *in)
 	}
 	return
@@ -185,6 +234,26 @@ func (in *APIExport) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIExportConsumer) DeepCopyInto(out *APIExportConsumer) {
+	*out = *in
+	if in.LastActivityTime != nil {
+		in, out := &in.LastActivityTime, &out.LastActivityTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIExportConsumer.
+func (in *APIExportConsumer) DeepCopy() *APIExportConsumer {
+	if in == nil {
+		return nil
+	}
+	out := new(APIExportConsumer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIExportList) DeepCopyInto(out *APIExportList) {
 	*out = *in
@@ -239,6 +308,34 @@ func (in *APIExportSpec) DeepCopyInto(out *APIExportSpec) {
 	if in.PermissionClaims != nil {
 		in, out := &in.PermissionClaims, &out.PermissionClaims
 		*out = make([]PermissionClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeprecationWarning != nil {
+		in, out := &in.DeprecationWarning, &out.DeprecationWarning
+		*out = new(string)
+		**out = **in
+	}
+	if in.SunsetDate != nil {
+		in, out := &in.SunsetDate, &out.SunsetDate
+		*out = (*in).DeepCopy()
+	}
+	if in.ClusterRoleTemplates != nil {
+		in, out := &in.ClusterRoleTemplates, &out.ClusterRoleTemplates
+		*out = make([]ClusterRoleTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RoleAggregation != nil {
+		in, out := &in.RoleAggregation, &out.RoleAggregation
+		*out = new(RoleAggregation)
+		**out = **in
+	}
+	if in.DefaultQuotas != nil {
+		in, out := &in.DefaultQuotas, &out.DefaultQuotas
+		*out = make([]ResourceQuota, len(*in))
 		copy(*out, *in)
 	}
 	return
@@ -269,6 +366,11 @@ func (in *APIExportStatus) DeepCopyInto(out *APIExportStatus) {
 		*out = make([]VirtualWorkspace, len(*in))
 		copy(*out, *in)
 	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(APIExportUsage)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -282,6 +384,29 @@ func (in *APIExportStatus) DeepCopy() *APIExportStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIExportUsage) DeepCopyInto(out *APIExportUsage) {
+	*out = *in
+	if in.Consumers != nil {
+		in, out := &in.Consumers, &out.Consumers
+		*out = make([]APIExportConsumer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIExportUsage.
+func (in *APIExportUsage) DeepCopy() *APIExportUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(APIExportUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIResourceSchema) DeepCopyInto(out *APIResourceSchema) {
 	*out = *in
@@ -353,6 +478,11 @@ func (in *APIResourceSchemaSpec) DeepCopyInto(out *APIResourceSchemaSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Conversion != nil {
+		in, out := &in.Conversion, &out.Conversion
+		*out = new(CustomResourceConversion)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -366,6 +496,22 @@ func (in *APIResourceSchemaSpec) DeepCopy() *APIResourceSchemaSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIResourceSchemaStatus) DeepCopyInto(out *APIResourceSchemaStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIResourceSchemaStatus.
+func (in *APIResourceSchemaStatus) DeepCopy() *APIResourceSchemaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(APIResourceSchemaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIResourceVersion) DeepCopyInto(out *APIResourceVersion) {
 	*out = *in
@@ -381,6 +527,13 @@ func (in *APIResourceVersion) DeepCopyInto(out *APIResourceVersion) {
 		*out = make([]v1.CustomResourceColumnDefinition, len(*in))
 		copy(*out, *in)
 	}
+	if in.CustomSubresources != nil {
+		in, out := &in.CustomSubresources, &out.CustomSubresources
+		*out = make([]CustomSubresource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -397,7 +550,7 @@ func (in *APIResourceVersion) DeepCopy() *APIResourceVersion {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AcceptablePermissionClaim) DeepCopyInto(out *AcceptablePermissionClaim) {
 	*out = *in
-	out.PermissionClaim = in.PermissionClaim
+	in.PermissionClaim.DeepCopyInto(&out.PermissionClaim)
 	return
 }
 
@@ -420,6 +573,18 @@ func (in *BoundAPIResource) DeepCopyInto(out *BoundAPIResource) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.StorageVersionMigration != nil {
+		in, out := &in.StorageVersionMigration, &out.StorageVersionMigration
+		*out = new(StorageVersionMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CustomSubresources != nil {
+		in, out := &in.CustomSubresources, &out.CustomSubresources
+		*out = make([]BoundCustomSubresource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -449,6 +614,100 @@ func (in *BoundAPIResourceSchema) DeepCopy() *BoundAPIResourceSchema {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BoundCustomSubresource) DeepCopyInto(out *BoundCustomSubresource) {
+	*out = *in
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BoundCustomSubresource.
+func (in *BoundCustomSubresource) DeepCopy() *BoundCustomSubresource {
+	if in == nil {
+		return nil
+	}
+	out := new(BoundCustomSubresource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterRoleTemplate) DeepCopyInto(out *ClusterRoleTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]rbacv1.PolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRoleTemplate.
+func (in *ClusterRoleTemplate) DeepCopy() *ClusterRoleTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterRoleTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceConversion) DeepCopyInto(out *CustomResourceConversion) {
+	*out = *in
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookConversion)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceConversion.
+func (in *CustomResourceConversion) DeepCopy() *CustomResourceConversion {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceConversion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomSubresource) DeepCopyInto(out *CustomSubresource) {
+	*out = *in
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Schema.DeepCopyInto(&out.Schema)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomSubresource.
+func (in *CustomSubresource) DeepCopy() *CustomSubresource {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomSubresource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExportReference) DeepCopyInto(out *ExportReference) {
 	*out = *in
@@ -457,6 +716,11 @@ func (in *ExportReference) DeepCopyInto(out *ExportReference) {
 		*out = new(WorkspaceExportReference)
 		**out = **in
 	}
+	if in.Identity != nil {
+		in, out := &in.Identity, &out.Identity
+		*out = new(IdentityExportReference)
+		**out = **in
+	}
 	return
 }
 
@@ -507,6 +771,22 @@ func (in *Identity) DeepCopy() *Identity {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityExportReference) DeepCopyInto(out *IdentityExportReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityExportReference.
+func (in *IdentityExportReference) DeepCopy() *IdentityExportReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityExportReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalAPIExportPolicy) DeepCopyInto(out *LocalAPIExportPolicy) {
 	*out = *in
@@ -544,10 +824,36 @@ func (in *MaximalPermissionPolicy) DeepCopy() *MaximalPermissionPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectReference.
+func (in *ObjectReference) DeepCopy() *ObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PermissionClaim) DeepCopyInto(out *PermissionClaim) {
 	*out = *in
 	out.GroupResource = in.GroupResource
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceSelector != nil {
+		in, out := &in.ResourceSelector, &out.ResourceSelector
+		*out = new(ResourceSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -561,6 +867,177 @@ func (in *PermissionClaim) DeepCopy() *PermissionClaim {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PermissionClaimStatus) DeepCopyInto(out *PermissionClaimStatus) {
+	*out = *in
+	in.PermissionClaim.DeepCopyInto(&out.PermissionClaim)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PermissionClaimStatus.
+func (in *PermissionClaimStatus) DeepCopy() *PermissionClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PermissionClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceQuota) DeepCopyInto(out *ResourceQuota) {
+	*out = *in
+	out.GroupResource = in.GroupResource
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuota.
+func (in *ResourceQuota) DeepCopy() *ResourceQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceQuotaUsage) DeepCopyInto(out *ResourceQuotaUsage) {
+	*out = *in
+	out.GroupResource = in.GroupResource
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuotaUsage.
+func (in *ResourceQuotaUsage) DeepCopy() *ResourceQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceSelector) DeepCopyInto(out *ResourceSelector) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceSelector.
+func (in *ResourceSelector) DeepCopy() *ResourceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleAggregation) DeepCopyInto(out *RoleAggregation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleAggregation.
+func (in *RoleAggregation) DeepCopy() *RoleAggregation {
+	if in == nil {
+		return nil
+	}
+	out := new(RoleAggregation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaChange) DeepCopyInto(out *SchemaChange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaChange.
+func (in *SchemaChange) DeepCopy() *SchemaChange {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaCompatibilityReport) DeepCopyInto(out *SchemaCompatibilityReport) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]SchemaChange, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaCompatibilityReport.
+func (in *SchemaCompatibilityReport) DeepCopy() *SchemaCompatibilityReport {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaCompatibilityReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageVersionMigration) DeepCopyInto(out *StorageVersionMigration) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageVersionMigration.
+func (in *StorageVersionMigration) DeepCopy() *StorageVersionMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageVersionMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePolicy) DeepCopyInto(out *UpgradePolicy) {
+	*out = *in
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePolicy.
+func (in *UpgradePolicy) DeepCopy() *UpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualWorkspace) DeepCopyInto(out *VirtualWorkspace) {
 	*out = *in
@@ -577,6 +1054,49 @@ func (in *VirtualWorkspace) DeepCopy() *VirtualWorkspace {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookClientConfig) DeepCopyInto(out *WebhookClientConfig) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookClientConfig.
+func (in *WebhookClientConfig) DeepCopy() *WebhookClientConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookClientConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookConversion) DeepCopyInto(out *WebhookConversion) {
+	*out = *in
+	in.ClientConfig.DeepCopyInto(&out.ClientConfig)
+	if in.ConversionReviewVersions != nil {
+		in, out := &in.ConversionReviewVersions, &out.ConversionReviewVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookConversion.
+func (in *WebhookConversion) DeepCopy() *WebhookConversion {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookConversion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceExportReference) DeepCopyInto(out *WorkspaceExportReference) {
 	*out = *in
@@ -49,6 +49,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&LocationList{},
 		&Placement{},
 		&PlacementList{},
+		&PlacementPriority{},
+		&PlacementPriorityList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -27,6 +27,24 @@ const (
 
 	// PlacementAnnotationKey is the label key for the label holding a PlacementAnnotation struct.
 	PlacementAnnotationKey = "scheduling.kcp.dev/placement"
+
+	// SchedulingModeAnnotationKey is the annotation key on a Namespace or a ClusterWorkspace to set
+	// the SchedulingMode of the namespaces. The annotation on a Namespace takes precedence over the
+	// one on the ClusterWorkspace of the namespace.
+	SchedulingModeAnnotationKey = "scheduling.kcp.dev/mode"
+)
+
+// SchedulingMode defines whether namespaces are assigned to SyncTargets by the scheduler.
+type SchedulingMode string
+
+const (
+	// SchedulingModeAutomatic means that the scheduler assigns namespaces to the SyncTargets
+	// selected by the placements. This is the default.
+	SchedulingModeAutomatic SchedulingMode = "Automatic"
+
+	// SchedulingModeManual means that the scheduler never adds or removes state.workload.kcp.dev/<sync-target-key>
+	// labels of namespaces. Namespaces are bound to SyncTargets explicitly, e.g. with "kubectl kcp workload bind".
+	SchedulingModeManual SchedulingMode = "Manual"
 )
 
 // Location represents a set of instances of a scheduling resource type acting a target
@@ -85,6 +103,14 @@ type LocationSpec struct {
 	//
 	// +optional
 	InstanceSelector *metav1.LabelSelector `json:"instanceSelector,omitempty"`
+
+	// maxPlacements is the maximal number of placements that can select this location at the
+	// same time. If unset, the number of placements is not limited. When the limit is reached,
+	// placements of higher priority can preempt placements of lower priority.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxPlacements *int32 `json:"maxPlacements,omitempty"`
 }
 
 // GroupVersionResource unambiguously identifies a resource.
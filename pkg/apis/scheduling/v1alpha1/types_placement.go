@@ -82,6 +82,28 @@ type PlacementSpec struct {
 	// +optional
 	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
 	LocationWorkspace string `json:"locationWorkspace,omitempty"`
+
+	// priorityName is the name of a PlacementPriority in the location workspace. If it is not set,
+	// the PlacementPriority marked as globalDefault is used, or priority zero if there is none.
+	//
+	// +optional
+	PriorityName string `json:"priorityName,omitempty"`
+
+	// scoringExpression is a CEL expression evaluated for every valid SyncTarget of the selected
+	// location. The expression has access to
+	//
+	// - scores: a map from name to double, built from the score.workload.kcp.dev/<name> annotations
+	//   of the SyncTarget,
+	// - labels: the labels of the SyncTarget,
+	// - name: the name of the SyncTarget,
+	//
+	// and must evaluate to a number. The SyncTarget with the highest result is chosen, ties are broken
+	// by name. SyncTargets for which the expression fails to evaluate, e.g. because a score is missing
+	// or the evaluation is too expensive, are not considered. If it is not set, a valid SyncTarget is
+	// chosen randomly.
+	//
+	// +optional
+	ScoringExpression string `json:"scoringExpression,omitempty"`
 }
 
 type PlacementStatus struct {
@@ -95,11 +117,78 @@ type PlacementStatus struct {
 	// +optional
 	SelectedLocation *LocationReference `json:"selectedLocation,omitempty"`
 
+	// priority is the resolved priority value of the placement.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
 	// Current processing state of the Placement.
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+
+	// decisions is the history of the most recent scheduling decisions taken for this placement,
+	// ordered from oldest to newest. At most MaxPlacementDecisions entries are kept.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=10
+	Decisions []PlacementDecision `json:"decisions,omitempty"`
 }
 
+// MaxPlacementDecisions is the maximal number of decisions kept in the placement status.
+const MaxPlacementDecisions = 10
+
+// PlacementDecision records a single bind, unbind or retry decision of the placement controller.
+type PlacementDecision struct {
+	// type is the kind of decision taken.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Bind;Unbind;Retry
+	Type PlacementDecisionType `json:"type"`
+
+	// time is when the decision was taken.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Time metav1.Time `json:"time"`
+
+	// phase is the phase of the placement after the decision.
+	//
+	// +optional
+	Phase PlacementPhase `json:"phase,omitempty"`
+
+	// selectedLocation is the location selected by the placement after the decision.
+	//
+	// +optional
+	SelectedLocation *LocationReference `json:"selectedLocation,omitempty"`
+
+	// reason is a machine readable, CamelCase reason for the decision.
+	//
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// message is a human readable explanation of the decision.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PlacementDecisionType is the type of a placement decision.
+type PlacementDecisionType string
+
+const (
+	// PlacementDecisionBind is recorded when a location is selected for the placement, or when
+	// namespaces start using it.
+	PlacementDecisionBind PlacementDecisionType = "Bind"
+
+	// PlacementDecisionUnbind is recorded when the selected location is dropped, or when the last
+	// namespace stops using the placement.
+	PlacementDecisionUnbind PlacementDecisionType = "Unbind"
+
+	// PlacementDecisionRetry is recorded when no valid location could be selected and scheduling
+	// will be retried.
+	PlacementDecisionRetry PlacementDecisionType = "Retry"
+)
+
 // LocationReference describes a loaction that are provided in the specified Workspace.
 type LocationReference struct {
 	// path is an absolute reference to a workspace, e.g. root:org:ws. The workspace must
@@ -150,6 +239,22 @@ const (
 	// LocationNotMatchReason is a reason for PlacementReady condition that no matched location for
 	// this placement can be found.
 	LocationNotMatchReason = "LocationNoMatch"
+
+	// LocationCapacityExceededReason is a reason for PlacementReady condition that all matched
+	// locations have reached their maximal number of placements.
+	LocationCapacityExceededReason = "LocationCapacityExceeded"
+
+	// PreemptedReason is a reason for PlacementReady condition that the selected location has
+	// been taken away by a placement of higher priority.
+	PreemptedReason = "Preempted"
+
+	// ScoringExpressionValid is a condition type for placement representing whether the
+	// scoringExpression can be evaluated. It is not set if there is no scoringExpression.
+	ScoringExpressionValid conditionsv1alpha1.ConditionType = "ScoringExpressionValid"
+
+	// ScoringExpressionInvalidReason is a reason for ScoringExpressionValid condition that the
+	// scoringExpression does not compile, or does not evaluate to a number.
+	ScoringExpressionInvalidReason = "ScoringExpressionInvalid"
 )
 
 // PlacementList is a list of locations.
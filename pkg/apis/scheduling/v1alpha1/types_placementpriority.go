@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PlacementPriority defines a named priority for placements, similar to a PriorityClass for pods.
+//
+// PlacementPriorities live in the location workspace, next to the Locations they apply to, and are
+// referenced by name from spec.priorityName of a Placement. When a Location has no capacity left,
+// placements of higher priority are bound first and may preempt placements of lower priority.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Value",type=integer,JSONPath=`.spec.value`,description="Priority value"
+// +kubebuilder:printcolumn:name="Global-Default",type=boolean,JSONPath=`.spec.globalDefault`,description="Whether this is the default priority"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type PlacementPriority struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PlacementPrioritySpec `json:"spec,omitempty"`
+}
+
+// PlacementPrioritySpec holds the desired state of the PlacementPriority.
+type PlacementPrioritySpec struct {
+	// value is the priority of placements referencing this priority. The higher the value,
+	// the higher the priority.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Value int32 `json:"value"`
+
+	// globalDefault specifies whether this priority is used for placements without priorityName.
+	// Only one PlacementPriority in a workspace should be marked as default. If multiple are,
+	// the one with the highest value is used.
+	//
+	// +optional
+	GlobalDefault bool `json:"globalDefault,omitempty"`
+
+	// preemptionPolicy is the policy for preempting placements of lower priority.
+	//
+	// +optional
+	// +kubebuilder:default=PreemptLowerPriority
+	// +kubebuilder:validation:Enum=PreemptLowerPriority;Never
+	PreemptionPolicy PreemptionPolicy `json:"preemptionPolicy,omitempty"`
+
+	// description is a human-readable description of the priority.
+	//
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// PreemptionPolicy describes a policy for if/when to preempt a placement.
+type PreemptionPolicy string
+
+const (
+	// PreemptLowerPriority means that placements of this priority can preempt placements of lower priority.
+	PreemptLowerPriority PreemptionPolicy = "PreemptLowerPriority"
+
+	// PreemptNever means that placements of this priority never preempt other placements.
+	PreemptNever PreemptionPolicy = "Never"
+)
+
+// PlacementPriorityList is a list of placement priorities.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PlacementPriorityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PlacementPriority `json:"items"`
+}
@@ -158,6 +158,11 @@ func (in *LocationSpec) DeepCopyInto(out *LocationSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MaxPlacements != nil {
+		in, out := &in.MaxPlacements, &out.MaxPlacements
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -225,6 +230,28 @@ func (in *Placement) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementDecision) DeepCopyInto(out *PlacementDecision) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.SelectedLocation != nil {
+		in, out := &in.SelectedLocation, &out.SelectedLocation
+		*out = new(LocationReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementDecision.
+func (in *PlacementDecision) DeepCopy() *PlacementDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementList) DeepCopyInto(out *PlacementList) {
 	*out = *in
@@ -258,6 +285,82 @@ func (in *PlacementList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPriority) DeepCopyInto(out *PlacementPriority) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPriority.
+func (in *PlacementPriority) DeepCopy() *PlacementPriority {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPriority)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementPriority) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPriorityList) DeepCopyInto(out *PlacementPriorityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PlacementPriority, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPriorityList.
+func (in *PlacementPriorityList) DeepCopy() *PlacementPriorityList {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPriorityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementPriorityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPrioritySpec) DeepCopyInto(out *PlacementPrioritySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPrioritySpec.
+func (in *PlacementPrioritySpec) DeepCopy() *PlacementPrioritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPrioritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
 	*out = *in
@@ -302,6 +405,13 @@ func (in *PlacementStatus) DeepCopyInto(out *PlacementStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Decisions != nil {
+		in, out := &in.Decisions, &out.Decisions
+		*out = make([]PlacementDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
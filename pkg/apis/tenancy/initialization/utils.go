@@ -23,6 +23,7 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -61,6 +62,16 @@ func EnsureInitializerAbsent(initializer tenancyv1alpha1.ClusterWorkspaceInitial
 	return initializers
 }
 
+// ParametersFor returns the parameters passed to the given initializer in the spec of the ClusterWorkspace.
+func ParametersFor(initializer tenancyv1alpha1.ClusterWorkspaceInitializer, cw *tenancyv1alpha1.ClusterWorkspace) (runtime.RawExtension, bool) {
+	for _, p := range cw.Spec.InitializerParameters {
+		if p.Initializer == initializer {
+			return p.Parameters, true
+		}
+	}
+	return runtime.RawExtension{}, false
+}
+
 // InitializerForType determines the identifier for the implicit initializer associated with the ClusterWorkspaceType.
 func InitializerForType(cwt *tenancyv1alpha1.ClusterWorkspaceType) tenancyv1alpha1.ClusterWorkspaceInitializer {
 	return InitializerForReference(tenancyv1alpha1.ReferenceFor(cwt))
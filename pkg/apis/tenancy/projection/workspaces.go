@@ -27,6 +27,10 @@ func ProjectClusterWorkspaceToWorkspace(from *tenancyv1alpha1.ClusterWorkspace,
 	to.Status.URL = from.Status.BaseURL
 	to.Status.Phase = from.Status.Phase
 	to.Status.Initializers = from.Status.Initializers
+	if from.Status.Location != (tenancyv1alpha1.ClusterWorkspaceLocation{}) {
+		location := from.Status.Location
+		to.Status.Location = &location
+	}
 
 	to.Annotations = make(map[string]string, len(from.Annotations))
 	for k, v := range from.Annotations {
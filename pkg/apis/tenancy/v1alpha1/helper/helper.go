@@ -17,6 +17,7 @@ limitations under the License.
 package helper
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"regexp"
 
@@ -43,3 +44,9 @@ func QualifiedObjectName(obj metav1.Object) string {
 	}
 	return fmt.Sprintf("%s|%s", logicalcluster.From(obj), obj.GetName())
 }
+
+// TypeToLabel returns the label value of the ClusterWorkspaceTypeLabel for the given type reference. The
+// reference is hashed, as it is too long and contains characters that are invalid in label values.
+func TypeToLabel(ref v1alpha1.ClusterWorkspaceTypeReference) string {
+	return fmt.Sprintf("%x", sha256.Sum224([]byte(ref.String())))
+}
@@ -51,6 +51,26 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ClusterWorkspaceTypeList{},
 		&ClusterWorkspaceShard{},
 		&ClusterWorkspaceShardList{},
+		&WorkspaceQuota{},
+		&WorkspaceQuotaList{},
+		&WorkspacePolicy{},
+		&WorkspacePolicyList{},
+		&SharedSecret{},
+		&SharedSecretList{},
+		&AccessGrant{},
+		&AccessGrantList{},
+		&WorkspaceMigration{},
+		&WorkspaceMigrationList{},
+		&WorkspaceAuthenticationConfiguration{},
+		&WorkspaceAuthenticationConfigurationList{},
+		&ServiceAccountGrant{},
+		&ServiceAccountGrantList{},
+		&Team{},
+		&TeamList{},
+		&ValidatingAdmissionPolicy{},
+		&ValidatingAdmissionPolicyList{},
+		&ValidatingAdmissionPolicyBinding{},
+		&ValidatingAdmissionPolicyBindingList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -23,6 +23,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
@@ -75,6 +76,9 @@ var _ conditions.Setter = &ClusterWorkspace{}
 
 // ClusterWorkspaceSpec holds the desired state of the ClusterWorkspace.
 type ClusterWorkspaceSpec struct {
+	// readOnly blocks requests changing the content of the workspace, except by members of
+	// system:masters. It is set while the workspace is migrated to another shard or moved.
+	//
 	// +optional
 	ReadOnly bool `json:"readOnly,omitempty"`
 
@@ -95,12 +99,74 @@ type ClusterWorkspaceSpec struct {
 	// if the constraint is not fulfilled by the current location stored in the status,
 	// movement will be attempted.
 	//
-	// Either shard name or shard selector must be specified.
+	// All specified constraints must be fulfilled by the shard. The shard constraints of
+	// the type of the workspace are added on creation.
 	//
 	// If the no shard constraints are specified, an aribtrary shard is chosen.
 	//
 	// +optional
 	Shard *ShardConstraints `json:"shard,omitempty"`
+
+	// initializerParameters are parameters for the initializers of the type of this workspace,
+	// consumed by the initializing controllers. Every initializer must be the initializer of
+	// the type or of a type it extends. The parameters are validated at admission during creation,
+	// and are immutable after creation.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=initializer
+	InitializerParameters []ClusterWorkspaceInitializerParameters `json:"initializerParameters,omitempty"`
+
+	// moveTo requests to move this workspace to another parent workspace, to rename it, or both.
+	// The move is staged, i.e. a workspace is created at the target, the content of this workspace
+	// is copied into it, path-based references to this workspace are rewritten, and finally
+	// this workspace is deleted. Only ready workspaces without child workspaces can be moved.
+	// moveTo cannot be changed once set.
+	//
+	// +optional
+	MoveTo *ClusterWorkspaceMoveTarget `json:"moveTo,omitempty"`
+
+	// featureGates enables or disables workspace-scoped feature gates for this workspace.
+	// They override the feature gates of the workspace type and the process-wide
+	// --feature-gates of the shard. Only workspace-scoped features can be set.
+	//
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// ClusterWorkspaceMoveTarget is the target of a ClusterWorkspace move.
+type ClusterWorkspaceMoveTarget struct {
+	// parent is an absolute reference to the new parent workspace, e.g. root:org. If it is
+	// unset, the workspace is renamed in its current parent.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Parent string `json:"parent,omitempty"`
+
+	// name is the new name of the workspace. If it is unset, the name is kept.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern:="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name,omitempty"`
+}
+
+// ClusterWorkspaceInitializerParameters are the parameters of an initializer of a ClusterWorkspace.
+type ClusterWorkspaceInitializerParameters struct {
+	// initializer is the initializer consuming the parameters.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Initializer ClusterWorkspaceInitializer `json:"initializer"`
+
+	// parameters is a structured payload whose schema is defined by the initializing controller.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +structType=atomic
+	Parameters runtime.RawExtension `json:"parameters"`
 }
 
 type ShardConstraints struct {
@@ -114,8 +180,26 @@ type ShardConstraints struct {
 	//
 	// +optional
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// region requires a shard with the tenancy.kcp.dev/region label of the given value,
+	// e.g. to express data-residency requirements.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Region string `json:"region,omitempty"`
+
+	// sameAsParent requires the shard of the parent workspace. Workspaces in the root
+	// workspace are scheduled onto the root shard.
+	//
+	// +optional
+	SameAsParent bool `json:"sameAsParent,omitempty"`
 }
 
+// ShardRegionLabel is the label of ClusterWorkspaceShards with their region, matched by the
+// region shard constraint of ClusterWorkspaces.
+const ShardRegionLabel = "tenancy.kcp.dev/region"
+
 // ClusterWorkspaceTypeReference is a globally unique, fully qualified reference to a
 // cluster workspace type.
 type ClusterWorkspaceTypeReference struct {
@@ -180,6 +264,17 @@ type ClusterWorkspaceTypeSpec struct {
 	// +optional
 	Initializer bool `json:"initializer,omitempty"`
 
+	// initializerOrder orders the initializer of this ClusterWorkspaceType relative to the
+	// initializers of the types it extends, or is extended by. The initializers with the lowest
+	// order are added to a ClusterWorkspace when it starts initializing, and the initializers
+	// with the next higher order are only added once all of them have finished. Initializers
+	// with the same order run in parallel. This allows multi-step bootstrapping, e.g. of
+	// networking, then RBAC, then quotas.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	InitializerOrder int32 `json:"initializerOrder,omitempty"`
+
 	// extend is a list of other ClusterWorkspaceTypes whose initializers and limitAllowedChildren
 	// and limitAllowedParents this ClusterWorkspaceType is inheriting. By (transitively) extending
 	// another ClusterWorkspaceType, this ClusterWorkspaceType will be considered as that
@@ -200,6 +295,16 @@ type ClusterWorkspaceTypeSpec struct {
 	// +optional
 	AdditionalWorkspaceLabels map[string]string `json:"additionalWorkspaceLabels,omitempty"`
 
+	// defaultObjects are objects created in every workspace of this type, e.g. APIBindings,
+	// RBAC or placements. They are applied when the workspace starts initializing, and kept
+	// in sync afterwards, reverting changes to the fields set here. Objects annotated with
+	// bootstrap.kcp.dev/create-only are only created, but never updated. Extending this
+	// ClusterWorkspaceType does not inherit its defaultObjects.
+	//
+	// +optional
+	// +listType=atomic
+	DefaultObjects []DefaultObject `json:"defaultObjects,omitempty"`
+
 	// defaultChildWorkspaceType is the ClusterWorkspaceType that will be used
 	// by default if another, nested ClusterWorkspace is created in a workspace
 	// of this type. When this field is unset, the user must specify a type when
@@ -221,6 +326,29 @@ type ClusterWorkspaceTypeSpec struct {
 	//
 	// +optional
 	LimitAllowedParents *ClusterWorkspaceTypeSelector `json:"limitAllowedParents,omitempty"`
+
+	// maxChildDepth limits how deep ClusterWorkspaces can be nested below a workspace of
+	// this type. A value of 1 allows children, but no grandchildren. The lowest maxChildDepth
+	// of this type and the types it extends applies.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxChildDepth *int32 `json:"maxChildDepth,omitempty"`
+
+	// featureGates enables or disables workspace-scoped feature gates for workspaces of
+	// this type. They override the process-wide --feature-gates of the shard, and are
+	// overridden by the feature gates of the workspace itself. Feature gates are not
+	// inherited through extend.with.
+	//
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+
+	// shard constraints are added to the shard constraints of workspaces of this type on
+	// creation, e.g. to keep them in a region. A workspace cannot require another shard
+	// name or region than its type. Shard constraints are not inherited through extend.with.
+	//
+	// +optional
+	Shard *ShardConstraints `json:"shard,omitempty"`
 }
 
 // ClusterWorkspaceTypeSelector describes a set of types.
@@ -243,6 +371,14 @@ type ClusterWorkspaceTypeSelector struct {
 
 // ClusterWorkspaceTypeExtension defines how other ClusterWorkspaceTypes are
 // composed together to add functionality to the owning ClusterWorkspaceType.
+// DefaultObject is a Kubernetes object created in the workspaces of a ClusterWorkspaceType.
+//
+// +kubebuilder:validation:EmbeddedResource
+// +kubebuilder:pruning:PreserveUnknownFields
+type DefaultObject struct {
+	runtime.RawExtension `json:",inline"`
+}
+
 type ClusterWorkspaceTypeExtension struct {
 	// with are ClusterWorkspaceTypes whose initializers are added to the list
 	// for the owning type, and for whom the owning type becomes an alias, as long
@@ -334,6 +470,15 @@ const (
 
 const ExperimentalClusterWorkspaceOwnerAnnotationKey string = "experimental.tenancy.kcp.dev/owner"
 
+// ExperimentalAuthorAnnotationKey is set on ClusterWorkspaceTypes and WorkspacePolicies, holding the user
+// who last changed the objects they apply to other workspaces. The objects are applied impersonating
+// this user.
+const ExperimentalAuthorAnnotationKey string = "experimental.tenancy.kcp.dev/author"
+
+// ClusterWorkspaceMovedFromAnnotationKey is set on the target ClusterWorkspace of a move, holding the
+// path of the moved workspace.
+const ClusterWorkspaceMovedFromAnnotationKey string = "internal.tenancy.kcp.dev/moved-from"
+
 // ClusterWorkspaceStatus communicates the observed state of the ClusterWorkspace.
 type ClusterWorkspaceStatus struct {
 	// Phase of the workspace  (Scheduling / Initializing / Ready)
@@ -365,6 +510,12 @@ type ClusterWorkspaceStatus struct {
 	//
 	// +optional
 	Initializers []ClusterWorkspaceInitializer `json:"initializers,omitempty"`
+
+	// lastActivityTime is the time of the last write request of a user to the workspace,
+	// tracked with a granularity of a minute. Requests of kcp itself do not count.
+	//
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
 }
 
 // These are valid conditions of workspace.
@@ -402,6 +553,42 @@ const (
 	// WorkspaceInitializedAPIBindingNotBound reason in WorkspaceInitialized condition means that at least
 	// one APIBinding is not yet bound to the workspace.
 	WorkspaceInitializedAPIBindingNotBound = "APIBindingNotBound"
+
+	// WorkspaceDefaultObjectsApplied represents the status that the defaultObjects of the ClusterWorkspaceType
+	// are applied to the workspace.
+	WorkspaceDefaultObjectsApplied conditionsv1alpha1.ConditionType = "WorkspaceDefaultObjectsApplied"
+	// WorkspaceDefaultObjectsApplyFailed reason in WorkspaceDefaultObjectsApplied condition means that at least
+	// one default object could not be applied.
+	WorkspaceDefaultObjectsApplyFailed = "ApplyFailed"
+
+	// WorkspaceMoved represents the status of moving the workspace to the target of spec.moveTo.
+	WorkspaceMoved conditionsv1alpha1.ConditionType = "WorkspaceMoved"
+	// WorkspaceMovedTargetNotReady reason in WorkspaceMoved condition means that the target workspace
+	// is not yet ready to receive the content.
+	WorkspaceMovedTargetNotReady = "TargetNotReady"
+	// WorkspaceMovedTargetConflict reason in WorkspaceMoved condition means that the target workspace
+	// exists, but was not created by the move.
+	WorkspaceMovedTargetConflict = "TargetConflict"
+	// WorkspaceMovedCopyFailed reason in WorkspaceMoved condition means that at least one object could
+	// not be copied to the target workspace.
+	WorkspaceMovedCopyFailed = "CopyFailed"
+	// WorkspaceMovedReferenceUpdateFailed reason in WorkspaceMoved condition means that at least one
+	// reference to the workspace could not be updated.
+	WorkspaceMovedReferenceUpdateFailed = "ReferenceUpdateFailed"
+
+	// WorkspacePoliciesApplied represents the status that the WorkspacePolicies inherited from the
+	// ancestors are applied to the workspace.
+	WorkspacePoliciesApplied conditionsv1alpha1.ConditionType = "WorkspacePoliciesApplied"
+	// WorkspacePoliciesApplyFailed reason in WorkspacePoliciesApplied condition means that at least
+	// one inherited object or the inherited WorkspaceQuota could not be applied.
+	WorkspacePoliciesApplyFailed = "ApplyFailed"
+
+	// WorkspaceActive represents the status that the workspace had activity within the idle TTL of the
+	// workspace reaper.
+	WorkspaceActive conditionsv1alpha1.ConditionType = "WorkspaceActive"
+	// WorkspaceIdle reason in WorkspaceActive condition means that there was no activity in the workspace
+	// for longer than the idle TTL.
+	WorkspaceIdle = "Idle"
 )
 
 // ClusterWorkspaceLocation specifies workspace placement information, including current, desired (target), and
@@ -440,6 +627,7 @@ type ClusterWorkspaceList struct {
 // +kubebuilder:resource:scope=Cluster,categories=kcp
 // +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.baseURL`,description="Type URL to directly connect to the shard"
 // +kubebuilder:printcolumn:name="External URL",type=string,JSONPath=`.spec.externalURL`,description="The URL exposed in workspaces created on that shard"
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.spec.state`,description="The lifecycle state of the shard"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type ClusterWorkspaceShard struct {
 	metav1.TypeMeta `json:",inline"`
@@ -507,8 +695,48 @@ type ClusterWorkspaceShardSpec struct {
 	// +kubebuilder:validation:MinLength=1
 	// +optional
 	VirtualWorkspaceURL string `json:"virtualWorkspaceURL,omitempty"`
+
+	// state is the lifecycle state of the shard. Cordoned shards are not chosen for new
+	// workspaces, neither by scheduling nor as target of migrations. Draining shards are not
+	// chosen either, and their workspaces are migrated to other shards.
+	//
+	// +optional
+	// +kubebuilder:default=Active
+	State ClusterWorkspaceShardState `json:"state,omitempty"`
 }
 
+// ClusterWorkspaceShardState is the lifecycle state of a ClusterWorkspaceShard.
+//
+// +kubebuilder:validation:Enum=Active;Cordoned;Draining
+type ClusterWorkspaceShardState string
+
+const (
+	// ClusterWorkspaceShardStateActive means that workspaces are scheduled onto the shard.
+	ClusterWorkspaceShardStateActive ClusterWorkspaceShardState = "Active"
+	// ClusterWorkspaceShardStateCordoned means that no new workspaces are scheduled onto the
+	// shard. Existing workspaces stay.
+	ClusterWorkspaceShardStateCordoned ClusterWorkspaceShardState = "Cordoned"
+	// ClusterWorkspaceShardStateDraining means that no new workspaces are scheduled onto the
+	// shard, and that existing workspaces are migrated to other shards.
+	ClusterWorkspaceShardStateDraining ClusterWorkspaceShardState = "Draining"
+)
+
+// IsSchedulable returns whether new workspaces can be placed onto the shard. Shards without
+// state are active.
+func (in *ClusterWorkspaceShard) IsSchedulable() bool {
+	return in.Spec.State == "" || in.Spec.State == ClusterWorkspaceShardStateActive
+}
+
+const (
+	// ClusterWorkspaceShardAvailable represents whether the shard sends heartbeats. Shards
+	// without heartbeat for too long are considered down.
+	ClusterWorkspaceShardAvailable conditionsv1alpha1.ConditionType = "Available"
+
+	// ClusterWorkspaceShardHeartbeatMissingReason is a reason for the Available condition of
+	// a shard that has not sent a heartbeat for too long.
+	ClusterWorkspaceShardHeartbeatMissingReason = "HeartbeatMissing"
+)
+
 // ClusterWorkspaceShardStatus communicates the observed state of the ClusterWorkspaceShard.
 type ClusterWorkspaceShardStatus struct {
 	// Set of integer resources that workspaces can be scheduled into
@@ -518,6 +746,10 @@ type ClusterWorkspaceShardStatus struct {
 	// Current processing state of the ClusterWorkspaceShard.
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+
+	// lastHeartbeatTime is the last time the shard reported that it is alive.
+	// +optional
+	LastHeartbeatTime *metav1.Time `json:"lastHeartbeatTime,omitempty"`
 }
 
 // ClusterWorkspaceShardList is a list of workspace shards
@@ -538,6 +770,9 @@ const (
 	// and the set of labels with this prefix is enforced to match the set of initializers by a mutating admission
 	// webhook.
 	ClusterWorkspaceInitializerLabelPrefix = "initializer.internal.kcp.dev/"
+	// ClusterWorkspaceTypeLabel holds a hash of the ClusterWorkspace.Spec.Type reference, and is enforced
+	// to match by the ClusterWorkspace controller.
+	ClusterWorkspaceTypeLabel = "internal.kcp.dev/type"
 )
 
 const (
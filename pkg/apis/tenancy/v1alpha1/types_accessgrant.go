@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessGrant grants a role in its workspace to users and groups for a limited duration,
+// e.g. for break-glass access without permanent RBAC changes. The role is granted by the
+// workspace authorizers from the creation of the AccessGrant until it expires. Expired
+// AccessGrants are kept for auditing until they are deleted. The spec is immutable.
+//
+// The creator of the AccessGrant needs to be allowed to bind the referenced role.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Role",type=string,JSONPath=`.spec.roleRef.name`,description="Granted role"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="Whether the role is granted"
+// +kubebuilder:printcolumn:name="Expiration",type=date,JSONPath=`.status.expirationTime`,description="Time the grant expires"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type AccessGrant struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AccessGrantSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status AccessGrantStatus `json:"status,omitempty"`
+}
+
+// AccessGrantSpec holds the desired state of the AccessGrant.
+type AccessGrantSpec struct {
+	// subjects are the users and groups the role is granted to.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	Subjects []rbacv1.Subject `json:"subjects"`
+
+	// roleRef references the granted ClusterRole, or a Role in the given namespace.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	RoleRef rbacv1.RoleRef `json:"roleRef"`
+
+	// namespace restricts the grant to a namespace. If empty, the ClusterRole is granted
+	// cluster-wide. A Role can only be granted in its namespace.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// duration is how long the role is granted, starting at the creation of the AccessGrant.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Duration metav1.Duration `json:"duration"`
+
+	// expirationTime is the time the grant expires. It is set by admission on creation to the
+	// current time plus the duration, and it is kept when the AccessGrant is copied, e.g. when
+	// its workspace is moved.
+	//
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+
+	// reason is a human-readable justification of the grant, e.g. a reference to an incident.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Reason string `json:"reason"`
+}
+
+// AccessGrantPhase is the phase of an AccessGrant.
+//
+// +kubebuilder:validation:Enum=Active;Expired
+type AccessGrantPhase string
+
+const (
+	// AccessGrantPhaseActive means that the role is granted.
+	AccessGrantPhaseActive AccessGrantPhase = "Active"
+	// AccessGrantPhaseExpired means that the grant has expired and the role is not granted anymore.
+	AccessGrantPhaseExpired AccessGrantPhase = "Expired"
+)
+
+// AccessGrantStatus communicates the observed state of the AccessGrant.
+type AccessGrantStatus struct {
+	// phase is whether the role is currently granted.
+	//
+	// +optional
+	Phase AccessGrantPhase `json:"phase,omitempty"`
+
+	// expirationTime is the time the grant expires.
+	//
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+}
+
+// ExpirationTime returns the time the AccessGrant expires, i.e. spec.expirationTime, or for AccessGrants
+// created before it was set by admission, the creation time plus the duration.
+func (in *AccessGrant) ExpirationTime() metav1.Time {
+	if in.Spec.ExpirationTime != nil {
+		return *in.Spec.ExpirationTime
+	}
+	return metav1.NewTime(in.CreationTimestamp.Add(in.Spec.Duration.Duration))
+}
+
+// AccessGrantList is a list of access grants.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type AccessGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AccessGrant `json:"items"`
+}
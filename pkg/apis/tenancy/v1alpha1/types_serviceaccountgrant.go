@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceAccountGrant grants service accounts of other workspaces access to the workspace it lives
+// in. Without a grant, service accounts are only authorized in their own workspace.
+//
+// Granted service accounts are authorized with the username
+// "system:kcp:serviceaccount:<workspace>:<namespace>:<name>" and the system:authenticated and
+// system:kcp:clusterworkspace:access groups, such that RBAC in the workspace can bind them without
+// conflicting with its own service accounts.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ServiceAccountGrant struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ServiceAccountGrantSpec `json:"spec,omitempty"`
+}
+
+// ServiceAccountGrantSpec holds the service accounts granted access.
+type ServiceAccountGrantSpec struct {
+	// serviceAccounts are the granted service accounts.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	ServiceAccounts []ServiceAccountReference `json:"serviceAccounts"`
+}
+
+// ServiceAccountReference references service accounts in another workspace.
+type ServiceAccountReference struct {
+	// path is an absolute reference to the workspace of the service accounts, e.g. root:org:ws.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Path string `json:"path"`
+
+	// namespace is the namespace of the service accounts.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// name is the name of the service account. If empty, all service accounts in the namespace are
+	// granted access.
+	//
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// ServiceAccountGrantList is a list of service account grants.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ServiceAccountGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ServiceAccountGrant `json:"items"`
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+// SharedSecretAnnotationKey is set on Secrets materialized from a SharedSecret, with the
+// value <workspace>|<name> of the SharedSecret. Users cannot create, change or delete these
+// Secrets.
+const SharedSecretAnnotationKey = "tenancy.kcp.dev/shared-secret"
+
+// SharedSecretProviderNamespace is the namespace in which Secrets shared with the provider of an
+// APIExport are materialized when their creator can only bind the APIExport, but not create Secrets
+// in the workspace of the APIExport. Providers opt in to such Secrets by creating the namespace.
+const SharedSecretProviderNamespace = "kcp-shared-secrets"
+
+// SharedSecretFinalizer is set on SharedSecrets to remove the materialized Secrets on deletion.
+const SharedSecretFinalizer = "tenancy.kcp.dev/shared-secret"
+
+// SharedSecret exports a Secret of its workspace to consumer workspaces, where a controller
+// materializes read-only copies and keeps them in sync with the Secret.
+//
+// The creator of the SharedSecret needs to be able to create Secrets in the namespaces of the
+// consumer workspaces, or to bind the referenced APIExports.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.secretRef.namespace`,description="Namespace of the shared Secret"
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.spec.secretRef.name`,description="Name of the shared Secret"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SharedSecret struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SharedSecretSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status SharedSecretStatus `json:"status,omitempty"`
+}
+
+// SharedSecretSpec holds the desired state of the SharedSecret.
+type SharedSecretSpec struct {
+	// secretRef references the shared Secret in this workspace. It is immutable.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	SecretRef corev1.SecretReference `json:"secretRef"`
+
+	// consumers are the workspaces the Secret is materialized in, with the same name.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	Consumers []SharedSecretConsumer `json:"consumers"`
+}
+
+// SharedSecretConsumer is a workspace consuming a SharedSecret. Exactly one of workspace and
+// apiExport must be set.
+type SharedSecretConsumer struct {
+	// workspace is an absolute reference to the consuming workspace, e.g. root:org:ws.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Workspace string `json:"workspace,omitempty"`
+
+	// apiExport references an APIExport whose workspace consumes the Secret, i.e. the
+	// provider of an API bound in this workspace.
+	//
+	// +optional
+	APIExport *SharedSecretAPIExportReference `json:"apiExport,omitempty"`
+
+	// namespace is the namespace of the Secret in the consuming workspace. It must exist.
+	// Defaults to the namespace of the shared Secret.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SharedSecretAPIExportReference references an APIExport.
+type SharedSecretAPIExportReference struct {
+	// path is an absolute reference to the workspace of the APIExport, e.g. root:org:ws.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Path string `json:"path"`
+
+	// name is the name of the APIExport.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// SharedSecretStatus communicates the observed state of the SharedSecret.
+type SharedSecretStatus struct {
+	// conditions is a list of conditions that apply to the SharedSecret.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+
+	// materialized are the Secrets created in consumer workspaces. Secrets of removed
+	// consumers are deleted.
+	//
+	// +optional
+	// +listType=atomic
+	Materialized []SharedSecretTarget `json:"materialized,omitempty"`
+}
+
+// SharedSecretTarget is a Secret materialized in a consumer workspace.
+type SharedSecretTarget struct {
+	// workspace is the logical cluster of the consumer workspace.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Workspace string `json:"workspace"`
+
+	// namespace is the namespace of the materialized Secret.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+}
+
+// These are valid conditions of SharedSecret.
+const (
+	// SharedSecretMaterialized means that the Secret is materialized in all consumer workspaces.
+	SharedSecretMaterialized conditionsv1alpha1.ConditionType = "Materialized"
+
+	// SharedSecretNotFoundReason is a reason for the Materialized condition that the shared Secret
+	// does not exist.
+	SharedSecretNotFoundReason = "SecretNotFound"
+	// SharedSecretConsumerInvalidReason is a reason for the Materialized condition that a consumer
+	// workspace or APIExport cannot be resolved, or its namespace does not exist.
+	SharedSecretConsumerInvalidReason = "ConsumerInvalid"
+	// SharedSecretConflictReason is a reason for the Materialized condition that a Secret of the
+	// same name, not owned by the SharedSecret, exists in a consumer workspace.
+	SharedSecretConflictReason = "Conflict"
+	// SharedSecretMaterializationFailedReason is a reason for the Materialized condition that
+	// writing a Secret to a consumer workspace failed.
+	SharedSecretMaterializationFailedReason = "MaterializationFailed"
+)
+
+func (in *SharedSecret) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *SharedSecret) SetConditions(conditions conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+// SharedSecretList is a list of shared secrets.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SharedSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SharedSecret `json:"items"`
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Team is a group of users defined in an organization workspace. Members of a team are
+// authorized in the organization workspace and all its descendant workspaces with the
+// additional group "system:kcp:team:<name>", such that access can be granted to the team
+// with a single RoleBinding or ClusterRoleBinding instead of one per user.
+//
+// Teams are only resolved for users, not for service accounts, and only in the organization
+// they are defined in. Teams in other workspaces than organization workspaces have no effect.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type Team struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TeamSpec `json:"spec,omitempty"`
+}
+
+// TeamSpec holds the members of a team.
+type TeamSpec struct {
+	// users are the names of the users who are members of the team.
+	//
+	// +optional
+	// +listType=set
+	Users []string `json:"users,omitempty"`
+
+	// groups are the names of groups whose users are members of the team, e.g. groups
+	// of an OIDC issuer.
+	//
+	// +optional
+	// +listType=set
+	Groups []string `json:"groups,omitempty"`
+}
+
+// TeamList is a list of teams.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type TeamList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Team `json:"items"`
+}
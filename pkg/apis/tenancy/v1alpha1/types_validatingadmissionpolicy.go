@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ValidatingAdmissionPolicy describes CEL expressions validating the requests to the workspace it
+// lives in, without the need for an admission webhook. A policy is only enforced in its workspace,
+// through the ValidatingAdmissionPolicyBindings referencing it, each with its own parameters.
+//
+// Policies and bindings are ordinary objects of a workspace. Hence, they can be rolled out to many
+// workspaces as objects of a WorkspacePolicy or of a ClusterWorkspaceType.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Failure Policy",type=string,JSONPath=`.spec.failurePolicy`,description="What happens when an expression cannot be evaluated"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ValidatingAdmissionPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ValidatingAdmissionPolicySpec `json:"spec,omitempty"`
+}
+
+// AdmissionPolicyFailurePolicy defines how errors evaluating a policy are handled.
+//
+// +kubebuilder:validation:Enum=Fail;Ignore
+type AdmissionPolicyFailurePolicy string
+
+const (
+	// AdmissionPolicyFailurePolicyFail rejects requests for which an expression cannot be evaluated.
+	AdmissionPolicyFailurePolicyFail AdmissionPolicyFailurePolicy = "Fail"
+	// AdmissionPolicyFailurePolicyIgnore admits requests for which an expression cannot be evaluated.
+	AdmissionPolicyFailurePolicyIgnore AdmissionPolicyFailurePolicy = "Ignore"
+)
+
+// ValidatingAdmissionPolicySpec holds the matched requests and the expressions validating them.
+type ValidatingAdmissionPolicySpec struct {
+	// matchResources are the requests the policy validates. A request is validated if it matches
+	// one of the rules.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	MatchResources []AdmissionPolicyRule `json:"matchResources"`
+
+	// validations are CEL expressions which must all evaluate to true for a request to be admitted.
+	// The expressions can access:
+	//
+	// - object: the object of the request, or null for DELETE.
+	// - oldObject: the existing object for UPDATE and DELETE, or null otherwise.
+	// - params: the parameters of the binding, or null.
+	// - request: the attributes of the request, i.e. operation, name, namespace, resource,
+	//   subResource and userInfo.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=atomic
+	Validations []AdmissionPolicyValidation `json:"validations"`
+
+	// failurePolicy defines how errors evaluating an expression are handled, i.e. Fail to reject the
+	// request, or Ignore to admit it. Defaults to Fail.
+	//
+	// +optional
+	// +kubebuilder:default=Fail
+	FailurePolicy AdmissionPolicyFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// AdmissionPolicyRule matches requests by operation and resource.
+type AdmissionPolicyRule struct {
+	// operations are the matched operations, i.e. CREATE, UPDATE, DELETE or CONNECT, or * for all of them.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Operations []string `json:"operations"`
+
+	// apiGroups are the matched API groups, with "" for the core group, or * for all groups.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	APIGroups []string `json:"apiGroups"`
+
+	// resources are the matched resources, or * for all resources. Subresources are matched as
+	// <resource>/<subresource>, e.g. deployments/scale or */status.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Resources []string `json:"resources"`
+}
+
+// AdmissionPolicyValidation is a CEL expression validating a request.
+type AdmissionPolicyValidation struct {
+	// expression is a CEL expression evaluating to true if the request is valid.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Expression string `json:"expression"`
+
+	// message is returned to the client when the expression evaluates to false. Defaults to a message
+	// including the expression.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ValidatingAdmissionPolicyList is a list of validating admission policies.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ValidatingAdmissionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ValidatingAdmissionPolicy `json:"items"`
+}
+
+// ValidatingAdmissionPolicyBinding enforces a ValidatingAdmissionPolicy of the same workspace, with
+// the given parameters.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Policy",type=string,JSONPath=`.spec.policyName`,description="The enforced policy"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ValidatingAdmissionPolicyBinding struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ValidatingAdmissionPolicyBindingSpec `json:"spec,omitempty"`
+}
+
+// ValidatingAdmissionPolicyBindingSpec references the enforced policy and holds its parameters.
+type ValidatingAdmissionPolicyBindingSpec struct {
+	// policyName is the name of the ValidatingAdmissionPolicy in the same workspace. Bindings
+	// of missing policies have no effect.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	PolicyName string `json:"policyName"`
+
+	// params are the parameters of the policy, available to its expressions as params.
+	//
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +structType=atomic
+	Params *runtime.RawExtension `json:"params,omitempty"`
+}
+
+// ValidatingAdmissionPolicyBindingList is a list of validating admission policy bindings.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ValidatingAdmissionPolicyBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ValidatingAdmissionPolicyBinding `json:"items"`
+}
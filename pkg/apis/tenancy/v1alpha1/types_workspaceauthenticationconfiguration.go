@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspaceAuthenticationConfiguration configures additional OIDC issuers whose tokens
+// authenticate users in the workspace it lives in and in all its descendant workspaces, e.g.
+// to bring the identity provider of an organization without changing the server flags.
+// Tokens of these issuers are not valid in any other workspace.
+//
+// Usernames and groups are prefixed to keep them apart from the users known to the whole
+// server. Users and groups starting with "system:" are never authenticated by these issuers.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type WorkspaceAuthenticationConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceAuthenticationConfigurationSpec `json:"spec,omitempty"`
+}
+
+// WorkspaceAuthenticationConfigurationSpec holds the issuers trusted in the workspace subtree.
+type WorkspaceAuthenticationConfigurationSpec struct {
+	// oidc are the OpenID Connect issuers whose ID tokens are accepted as bearer tokens.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=issuerURL
+	OIDC []OIDCIssuer `json:"oidc"`
+}
+
+// OIDCIssuer configures an OpenID Connect issuer, with the same semantics as the --oidc-*
+// flags of kube-apiserver.
+type OIDCIssuer struct {
+	// issuerURL is the URL of the issuer. It must use the https scheme and match the "iss" claim
+	// of the tokens.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^https://"
+	IssuerURL string `json:"issuerURL"`
+
+	// clientID is the audience the tokens must be issued for.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// certificateAuthorityData holds PEM-encoded certificates of the authorities signing the
+	// serving certificate of the issuer. If empty, the system roots are used.
+	//
+	// +optional
+	CertificateAuthorityData []byte `json:"certificateAuthorityData,omitempty"`
+
+	// usernameClaim is the claim holding the username. Defaults to "sub".
+	//
+	// +optional
+	// +kubebuilder:default=sub
+	UsernameClaim string `json:"usernameClaim,omitempty"`
+
+	// usernamePrefix is prepended to usernames. It must be set, such that users of the issuer
+	// cannot be confused with users known to the whole server.
+	//
+	// +optional
+	UsernamePrefix string `json:"usernamePrefix,omitempty"`
+
+	// groupsClaim is the claim holding the groups. If empty, no groups are authenticated.
+	//
+	// +optional
+	GroupsClaim string `json:"groupsClaim,omitempty"`
+
+	// groupsPrefix is prepended to groups. It must be set if groupsClaim is set.
+	//
+	// +optional
+	GroupsPrefix string `json:"groupsPrefix,omitempty"`
+
+	// requiredClaims are claims which must be present in the tokens with the given values.
+	//
+	// +optional
+	RequiredClaims map[string]string `json:"requiredClaims,omitempty"`
+}
+
+// WorkspaceAuthenticationConfigurationList is a list of workspace authentication configurations.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceAuthenticationConfigurationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspaceAuthenticationConfiguration `json:"items"`
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+// WorkspaceMigration migrates the content of a ClusterWorkspace to another shard. It is created
+// in the parent workspace of the migrated workspace. The content is copied from the current shard
+// of the workspace to the target shard until a copy pass finds no changes, then the workspace is
+// made read-only for a final copy pass and switched to the target shard, and finally the content
+// is removed from the source shard. The workspace stays readable during the migration. The spec is
+// immutable.
+//
+// Only ready workspaces without child workspaces can be migrated.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Workspace",type=string,JSONPath=`.spec.workspace`,description="Migrated workspace"
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.status.sourceShard`,description="Shard the workspace is migrated from"
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.targetShard`,description="Shard the workspace is migrated to"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="Phase of the migration"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type WorkspaceMigration struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceMigrationSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status WorkspaceMigrationStatus `json:"status,omitempty"`
+}
+
+// WorkspaceMigrationSpec holds the desired state of the WorkspaceMigration.
+type WorkspaceMigrationSpec struct {
+	// workspace is the name of the migrated ClusterWorkspace in this workspace.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Workspace string `json:"workspace"`
+
+	// targetShard is the name of the ClusterWorkspaceShard the workspace is migrated to.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	TargetShard string `json:"targetShard"`
+}
+
+// WorkspaceMigrationPhase is the phase of a WorkspaceMigration.
+//
+// +kubebuilder:validation:Enum=Copying;Switched;Completed;Failed
+type WorkspaceMigrationPhase string
+
+const (
+	// WorkspaceMigrationPhaseCopying means that the content is being copied to the target shard,
+	// while the workspace is still served by the source shard.
+	WorkspaceMigrationPhaseCopying WorkspaceMigrationPhase = "Copying"
+	// WorkspaceMigrationPhaseSwitched means that the workspace is served by the target shard, and
+	// the content is being removed from the source shard.
+	WorkspaceMigrationPhaseSwitched WorkspaceMigrationPhase = "Switched"
+	// WorkspaceMigrationPhaseCompleted means that the workspace is migrated.
+	WorkspaceMigrationPhaseCompleted WorkspaceMigrationPhase = "Completed"
+	// WorkspaceMigrationPhaseFailed means that the migration cannot proceed. The workspace is
+	// still served by the source shard.
+	WorkspaceMigrationPhaseFailed WorkspaceMigrationPhase = "Failed"
+)
+
+// WorkspaceMigrationStatus communicates the observed state of the WorkspaceMigration.
+type WorkspaceMigrationStatus struct {
+	// phase is the phase of the migration.
+	//
+	// +optional
+	Phase WorkspaceMigrationPhase `json:"phase,omitempty"`
+
+	// sourceShard is the shard the workspace was on when the migration started.
+	//
+	// +optional
+	SourceShard string `json:"sourceShard,omitempty"`
+
+	// switchTime is the time the workspace was switched to the target shard.
+	//
+	// +optional
+	SwitchTime *metav1.Time `json:"switchTime,omitempty"`
+
+	// Current processing state of the WorkspaceMigration.
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+const (
+	// WorkspaceMigrationContentCopied represents the status of copying the content to the target shard.
+	WorkspaceMigrationContentCopied conditionsv1alpha1.ConditionType = "ContentCopied"
+	// WorkspaceMigrationCopyPending reason in ContentCopied condition means that the last copy pass
+	// found changes, and another pass is pending.
+	WorkspaceMigrationCopyPending = "CopyPending"
+	// WorkspaceMigrationCopyFailed reason in ContentCopied condition means that at least one object
+	// could not be copied.
+	WorkspaceMigrationCopyFailed = "CopyFailed"
+
+	// WorkspaceMigrationWritesBlocked represents whether the migration has made the workspace read-only
+	// for the final copy pass and the switch. Writes are unblocked after the switch, or when the
+	// migration fails.
+	WorkspaceMigrationWritesBlocked conditionsv1alpha1.ConditionType = "WritesBlocked"
+
+	// WorkspaceMigrationSourceRemoved represents the status of removing the content from the source shard.
+	WorkspaceMigrationSourceRemoved conditionsv1alpha1.ConditionType = "SourceRemoved"
+	// WorkspaceMigrationRemovalFailed reason in SourceRemoved condition means that the content of the
+	// source shard could not be removed.
+	WorkspaceMigrationRemovalFailed = "RemovalFailed"
+
+	// WorkspaceMigrationValid represents whether the migration can proceed.
+	WorkspaceMigrationValid conditionsv1alpha1.ConditionType = "Valid"
+	// WorkspaceMigrationWorkspaceNotFound reason in Valid condition means that the workspace does not exist.
+	WorkspaceMigrationWorkspaceNotFound = "WorkspaceNotFound"
+	// WorkspaceMigrationWorkspaceNotReady reason in Valid condition means that the workspace is not ready.
+	WorkspaceMigrationWorkspaceNotReady = "WorkspaceNotReady"
+	// WorkspaceMigrationWorkspaceHasChildren reason in Valid condition means that the workspace has child workspaces.
+	WorkspaceMigrationWorkspaceHasChildren = "WorkspaceHasChildren"
+	// WorkspaceMigrationShardNotFound reason in Valid condition means that the target shard does not
+	// exist, or that this shard has no credentials for it.
+	WorkspaceMigrationShardNotFound = "ShardNotFound"
+	// WorkspaceMigrationShardNotSchedulable reason in Valid condition means that the target shard is
+	// cordoned or draining.
+	WorkspaceMigrationShardNotSchedulable = "ShardNotSchedulable"
+	// WorkspaceMigrationWorkspaceMoved reason in Valid condition means that the workspace is already on
+	// the target shard, or has been moved to another shard by someone else during the migration.
+	WorkspaceMigrationWorkspaceMoved = "WorkspaceMoved"
+)
+
+func (in *WorkspaceMigration) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *WorkspaceMigration) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+// WorkspaceMigrationList is a list of workspace migrations.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspaceMigration `json:"items"`
+}
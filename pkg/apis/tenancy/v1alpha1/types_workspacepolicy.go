@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspacePolicyAnnotationKey is set on WorkspaceQuotas created from a WorkspacePolicy. Quotas
+// without it are local overrides and are left alone, unless the policy denies overrides.
+const WorkspacePolicyAnnotationKey = "tenancy.kcp.dev/workspace-policy"
+
+// SkipDefaultAPIBindingsAnnotationKey is set on ClusterWorkspaces to opt out of the defaultAPIBindings
+// of WorkspacePolicies. Its value is a comma-separated list of APIExport names, or "*" for all of them.
+// APIBindings of policies denying overrides cannot be opted out of.
+const SkipDefaultAPIBindingsAnnotationKey = "tenancy.kcp.dev/skip-default-apibindings"
+
+// WorkspacePolicy publishes policies which are inherited by all descendant workspaces of the
+// workspace it lives in, similar to hierarchical namespaces.
+//
+// The policies of all ancestors of a workspace are merged from the root down, and those of one
+// workspace by name. Later policies override the fields set by earlier ones, unless these deny
+// overrides.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Override",type=string,JSONPath=`.spec.overridePolicy`,description="Whether descendants can override the policy"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type WorkspacePolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspacePolicySpec `json:"spec,omitempty"`
+}
+
+// WorkspacePolicyOverridePolicy defines whether descendant workspaces can override a policy.
+type WorkspacePolicyOverridePolicy string
+
+const (
+	// WorkspacePolicyOverrideAllow lets policies of descendant workspaces override the fields set
+	// by the policy, and lets descendant workspaces keep their own WorkspaceQuotas.
+	WorkspacePolicyOverrideAllow WorkspacePolicyOverridePolicy = "Allow"
+	// WorkspacePolicyOverrideDeny enforces the fields set by the policy in all descendant workspaces.
+	WorkspacePolicyOverrideDeny WorkspacePolicyOverridePolicy = "Deny"
+)
+
+// WorkspacePolicySpec holds the policies inherited by descendant workspaces.
+type WorkspacePolicySpec struct {
+	// objects are created in every descendant workspace, e.g. RBAC or required APIBindings. They
+	// are applied once the workspace starts initializing, and kept in sync afterwards, reverting
+	// changes to the fields set here. Objects of later policies replace objects of the same kind,
+	// namespace and name.
+	//
+	// +optional
+	// +listType=atomic
+	Objects []DefaultObject `json:"objects,omitempty"`
+
+	// defaultAPIBindings are APIExports bound in every descendant workspace. The APIBindings are
+	// created once the workspace starts initializing, and recreated when they are deleted, unless
+	// the workspace opts out with the tenancy.kcp.dev/skip-default-apibindings annotation. They
+	// are not deleted when removed from the policy.
+	//
+	// +optional
+	// +listType=atomic
+	DefaultAPIBindings []DefaultAPIBinding `json:"defaultAPIBindings,omitempty"`
+
+	// workspaceQuota is the WorkspaceQuota of every descendant workspace, created in its parent.
+	//
+	// +optional
+	WorkspaceQuota *WorkspaceQuotaSpec `json:"workspaceQuota,omitempty"`
+
+	// allowedTypes are the only types of ClusterWorkspaces which can be created in descendant
+	// workspaces. An empty path matches types of any workspace.
+	//
+	// +optional
+	AllowedTypes []ClusterWorkspaceTypeReference `json:"allowedTypes,omitempty"`
+
+	// defaultType is the type of descendant ClusterWorkspaces created without a type. It takes
+	// precedence over the defaultChildWorkspaceType of the type of their parent, e.g. to define
+	// the default type of workspaces per organization.
+	//
+	// +optional
+	DefaultType *ClusterWorkspaceTypeReference `json:"defaultType,omitempty"`
+
+	// overridePolicy defines whether policies of descendant workspaces can override the fields
+	// set here. Defaults to Allow.
+	//
+	// +optional
+	// +kubebuilder:default=Allow
+	// +kubebuilder:validation:Enum=Allow;Deny
+	OverridePolicy WorkspacePolicyOverridePolicy `json:"overridePolicy,omitempty"`
+}
+
+// DefaultAPIBinding references an APIExport which is bound in descendant workspaces.
+type DefaultAPIBinding struct {
+	// path is an absolute reference to the workspace of the APIExport, e.g. root:org:services.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Path string `json:"path"`
+
+	// exportName is the name of the APIExport. It is also the name of the APIBinding.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ExportName string `json:"exportName"`
+}
+
+// WorkspacePolicyList is a list of workspace policies.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspacePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspacePolicy `json:"items"`
+}
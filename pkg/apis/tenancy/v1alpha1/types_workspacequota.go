@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspaceQuota limits the objects stored in a workspace.
+//
+// WorkspaceQuotas live in the parent workspace and apply to the ClusterWorkspace of the same
+// name, such that users of the limited workspace cannot change them. Admission charges new
+// objects against the usage in the status and rejects them beyond the limits. The usage is
+// recomputed periodically to account for deleted objects.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Storage",type=string,JSONPath=`.status.used.storageBytes`,description="Used storage of the workspace"
+// +kubebuilder:printcolumn:name="Children",type=integer,JSONPath=`.status.used.childWorkspaces`,description="Number of child workspaces"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type WorkspaceQuota struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceQuotaSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status WorkspaceQuotaStatus `json:"status,omitempty"`
+}
+
+// WorkspaceQuotaSpec holds the limits of a workspace.
+type WorkspaceQuotaSpec struct {
+	// objectCounts limit the number of objects per resource in the workspace.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=resource
+	ObjectCounts []ObjectCountLimit `json:"objectCounts,omitempty"`
+
+	// storageBytes limits the total size of the objects in the workspace, measured by
+	// the size of their JSON encoding.
+	//
+	// +optional
+	StorageBytes *resource.Quantity `json:"storageBytes,omitempty"`
+
+	// childWorkspaces limits the number of ClusterWorkspaces in the workspace.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ChildWorkspaces *int64 `json:"childWorkspaces,omitempty"`
+}
+
+// ObjectCountLimit is the maximal number of objects of a resource.
+type ObjectCountLimit struct {
+	// group is the API group of the resource. For the core group this is the empty string.
+	//
+	// +optional
+	// +kubebuilder:default=""
+	Group string `json:"group"`
+
+	// resource is the plural, lower-case name of the resource.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Resource string `json:"resource"`
+
+	// maxObjects is the maximal number of objects of the resource.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	MaxObjects int64 `json:"maxObjects"`
+}
+
+// WorkspaceQuotaStatus communicates the observed usage of the workspace.
+type WorkspaceQuotaStatus struct {
+	// used is the usage of the workspace for the limits in the spec.
+	//
+	// +optional
+	Used WorkspaceQuotaUsage `json:"used,omitempty"`
+}
+
+// WorkspaceQuotaUsage is the usage of a workspace.
+type WorkspaceQuotaUsage struct {
+	// objectCounts are the numbers of objects of the resources with a limit.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=resource
+	ObjectCounts []ObjectCount `json:"objectCounts,omitempty"`
+
+	// storageBytes is the total size of the objects in the workspace, if limited.
+	//
+	// +optional
+	StorageBytes *resource.Quantity `json:"storageBytes,omitempty"`
+
+	// childWorkspaces is the number of ClusterWorkspaces in the workspace, if limited.
+	//
+	// +optional
+	ChildWorkspaces *int64 `json:"childWorkspaces,omitempty"`
+}
+
+// ObjectCount is the number of objects of a resource.
+type ObjectCount struct {
+	// group is the API group of the resource. For the core group this is the empty string.
+	//
+	// +optional
+	// +kubebuilder:default=""
+	Group string `json:"group"`
+
+	// resource is the plural, lower-case name of the resource.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Resource string `json:"resource"`
+
+	// count is the number of objects of the resource.
+	//
+	// +required
+	Count int64 `json:"count"`
+}
+
+// ObjectCountLimitFor returns the limit of the number of objects of the given resource, and whether
+// there is one.
+func (in *WorkspaceQuota) ObjectCountLimitFor(group, resource string) (int64, bool) {
+	for _, limit := range in.Spec.ObjectCounts {
+		if limit.Group == group && limit.Resource == resource {
+			return limit.MaxObjects, true
+		}
+	}
+	return 0, false
+}
+
+// WorkspaceQuotaList is a list of workspace quotas.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspaceQuota `json:"items"`
+}
@@ -94,6 +94,11 @@ type WorkspaceStatus struct {
 	//
 	// +optional
 	Initializers []v1alpha1.ClusterWorkspaceInitializer `json:"initializers,omitempty"`
+
+	// location is the shard the workspace is placed on. This field is ALPHA.
+	//
+	// +optional
+	Location *v1alpha1.ClusterWorkspaceLocation `json:"location,omitempty"`
 }
 
 // WorkspaceList is a list of Workspaces
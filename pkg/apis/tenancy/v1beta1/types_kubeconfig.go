@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspaceKubeconfig is the request and the response of the kubeconfig subresource of a
+// Workspace. Creating it mints a kubeconfig for the workspace, or for a virtual workspace,
+// that authenticates with a short-lived token of a service account in the workspace.
+//
+// The requesting user must be allowed to create tokens for that service account in
+// the workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceKubeconfig struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec WorkspaceKubeconfigSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status WorkspaceKubeconfigStatus `json:"status,omitempty"`
+}
+
+// WorkspaceKubeconfigSpec describes the requested kubeconfig.
+type WorkspaceKubeconfigSpec struct {
+	// serviceAccountNamespace is the namespace of the service account in the workspace whose
+	// token is embedded. Defaults to "default".
+	//
+	// +optional
+	ServiceAccountNamespace string `json:"serviceAccountNamespace,omitempty"`
+
+	// serviceAccountName is the name of the service account in the workspace whose token is
+	// embedded. Defaults to "default".
+	//
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// expirationSeconds is the requested validity of the token. The token issuer may return
+	// a token with a different validity. Defaults to one hour.
+	//
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+
+	// server is the URL the kubeconfig points to, e.g. the URL of a virtual workspace
+	// serving this workspace. Defaults to the URL of the workspace.
+	//
+	// +optional
+	// +kubebuilder:format:uri
+	Server string `json:"server,omitempty"`
+}
+
+// WorkspaceKubeconfigStatus holds the minted kubeconfig.
+type WorkspaceKubeconfigStatus struct {
+	// kubeconfig is the serialized kubeconfig. It does not contain certificate authority data,
+	// which the client is expected to add from its own configuration.
+	Kubeconfig string `json:"kubeconfig"`
+
+	// expirationTimestamp is the time of expiration of the embedded token.
+	ExpirationTimestamp metav1.Time `json:"expirationTimestamp"`
+}
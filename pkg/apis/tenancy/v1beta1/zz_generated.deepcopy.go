@@ -56,6 +56,72 @@ func (in *Workspace) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceKubeconfig) DeepCopyInto(out *WorkspaceKubeconfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceKubeconfig.
+func (in *WorkspaceKubeconfig) DeepCopy() *WorkspaceKubeconfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceKubeconfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkspaceKubeconfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceKubeconfigSpec) DeepCopyInto(out *WorkspaceKubeconfigSpec) {
+	*out = *in
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceKubeconfigSpec.
+func (in *WorkspaceKubeconfigSpec) DeepCopy() *WorkspaceKubeconfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceKubeconfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceKubeconfigStatus) DeepCopyInto(out *WorkspaceKubeconfigStatus) {
+	*out = *in
+	in.ExpirationTimestamp.DeepCopyInto(&out.ExpirationTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceKubeconfigStatus.
+func (in *WorkspaceKubeconfigStatus) DeepCopy() *WorkspaceKubeconfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceKubeconfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceList) DeepCopyInto(out *WorkspaceList) {
 	*out = *in
@@ -121,6 +187,11 @@ func (in *WorkspaceStatus) DeepCopyInto(out *WorkspaceStatus) {
 		*out = make([]tenancyv1alpha1.ClusterWorkspaceInitializer, len(*in))
 		copy(*out, *in)
 	}
+	if in.Location != nil {
+		in, out := &in.Location, &out.Location
+		*out = new(tenancyv1alpha1.ClusterWorkspaceLocation)
+		**out = **in
+	}
 	return
 }
 
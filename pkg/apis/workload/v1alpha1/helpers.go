@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"crypto/sha256"
 	"math/big"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 )
@@ -31,6 +32,30 @@ func ToSyncTargetKey(syncTargetWorkspace logicalcluster.Name, syncTargetName str
 	return base62hash
 }
 
+// SyncerCertificateUserName returns the user name of the syncer of the given SyncTarget when
+// authenticated with a client certificate.
+func SyncerCertificateUserName(syncTargetWorkspace logicalcluster.Name, syncTargetName string) string {
+	return SyncerCertificateUserNamePrefix + syncTargetWorkspace.String() + ":" + syncTargetName
+}
+
+// ParseSyncerCertificateUserName returns the SyncTarget workspace and name of a user name returned
+// by SyncerCertificateUserName. It returns false if the user name is not one of a syncer.
+func ParseSyncerCertificateUserName(userName string) (logicalcluster.Name, string, bool) {
+	if !strings.HasPrefix(userName, SyncerCertificateUserNamePrefix) {
+		return logicalcluster.Name{}, "", false
+	}
+	rest := strings.TrimPrefix(userName, SyncerCertificateUserNamePrefix)
+	i := strings.LastIndex(rest, ":")
+	if i <= 0 || i == len(rest)-1 {
+		return logicalcluster.Name{}, "", false
+	}
+	syncTargetWorkspace := logicalcluster.New(rest[:i])
+	if !syncTargetWorkspace.IsValid() {
+		return logicalcluster.Name{}, "", false
+	}
+	return syncTargetWorkspace, rest[i+1:], true
+}
+
 func toBase62(hash [28]byte) string {
 	var i big.Int
 	i.SetBytes(hash[:])
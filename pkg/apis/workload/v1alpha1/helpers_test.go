@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+)
+
+func TestParseSyncerCertificateUserName(t *testing.T) {
+	userName := SyncerCertificateUserName(logicalcluster.New("root:org:ws"), "us-east1")
+	if userName != "system:kcp:syncer:root:org:ws:us-east1" {
+		t.Fatalf("unexpected user name %q", userName)
+	}
+
+	clusterName, syncTargetName, ok := ParseSyncerCertificateUserName(userName)
+	if !ok || clusterName != logicalcluster.New("root:org:ws") || syncTargetName != "us-east1" {
+		t.Fatalf("unexpected result %q, %q, %v", clusterName, syncTargetName, ok)
+	}
+
+	for _, userName := range []string{
+		"system:serviceaccount:default:us-east1",
+		"system:kcp:syncer:",
+		"system:kcp:syncer:us-east1",
+		"system:kcp:syncer:root:org:",
+		"system:kcp:syncer:Root:us-east1",
+	} {
+		if _, _, ok := ParseSyncerCertificateUserName(userName); ok {
+			t.Errorf("expected %q not to be parsed", userName)
+		}
+	}
+}
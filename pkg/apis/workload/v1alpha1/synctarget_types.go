@@ -80,6 +80,49 @@ type SyncTargetSpec struct {
 	// they are in the same physical cluster. Each key/value pair in the cells should be added and updated by service providers
 	// (i.e. a network provider updates one key/value, while the storage provider updates another.)
 	Cells map[string]string `json:"cells,omitempty"`
+
+	// ResourcePolicies restricts the verbs the syncer of this SyncTarget can use on the given resources
+	// through the syncer virtual workspace. Resources without a policy are ReadWrite.
+	// +optional
+	ResourcePolicies []ResourcePolicy `json:"resourcePolicies,omitempty"`
+}
+
+// ResourcePolicy defines the access of the syncer to a resource.
+type ResourcePolicy struct {
+	apisv1alpha1.GroupResource `json:","`
+
+	// access is the access of the syncer to the resource:
+	//
+	// - ReadOnly: the syncer can get, list and watch the resource.
+	// - StatusOnly: the syncer can get, list and watch the resource, and update its status.
+	// - ReadWrite: the syncer can get, list, watch and update the resource and its status.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=ReadOnly;StatusOnly;ReadWrite
+	Access ResourceAccess `json:"access"`
+}
+
+// ResourceAccess is the access of the syncer to a resource.
+type ResourceAccess string
+
+const (
+	// ResourceAccessReadOnly allows the syncer to get, list and watch a resource.
+	ResourceAccessReadOnly ResourceAccess = "ReadOnly"
+	// ResourceAccessStatusOnly allows the syncer to get, list and watch a resource, and to update its status.
+	ResourceAccessStatusOnly ResourceAccess = "StatusOnly"
+	// ResourceAccessReadWrite allows the syncer to get, list, watch and update a resource and its status.
+	ResourceAccessReadWrite ResourceAccess = "ReadWrite"
+)
+
+// ResourceAccess returns the access of the syncer to the given resource, ReadWrite if there is no policy for it.
+func (in *SyncTarget) ResourceAccess(group, resource string) ResourceAccess {
+	for _, policy := range in.Spec.ResourcePolicies {
+		if policy.Group == group && policy.Resource == resource {
+			return policy.Access
+		}
+	}
+	return ResourceAccessReadWrite
 }
 
 // SyncTargetStatus communicates the observed state of the SyncTarget (from the controller).
@@ -135,8 +178,55 @@ type ResourceToSync struct {
 	// +kubebuilder:default=Pending
 	// +optional
 	State ResourceCompatibleState `json:"state,omitempty"`
+
+	// incompatibilities lists why the versions of the resource cannot be synced, i.e. the fields of
+	// the schema that are missing or incompatible downstream. It is only set if the state is Incompatible.
+	// +optional
+	Incompatibilities []SchemaIncompatibility `json:"incompatibilities,omitempty"`
 }
 
+// SchemaIncompatibility is a difference of the downstream schema of a version of a synced resource
+// which makes it incompatible.
+type SchemaIncompatibility struct {
+	// version is the version of the resource.
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+
+	// type is the kind of the incompatibility.
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=VersionMissing;FieldMissing;TypeChanged;ValidationTightened;Unsupported
+	Type SchemaIncompatibilityType `json:"type"`
+
+	// path is the JSON path of the incompatible field, e.g. `.spec.replicas`, with `[*]` for array items
+	// and additional properties. It is empty if the version is missing downstream.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// message is a human readable description of the incompatibility.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SchemaIncompatibilityType is the kind of a SchemaIncompatibility.
+type SchemaIncompatibilityType string
+
+const (
+	// SchemaIncompatibilityVersionMissing means that the version is not served downstream.
+	SchemaIncompatibilityVersionMissing SchemaIncompatibilityType = "VersionMissing"
+	// SchemaIncompatibilityFieldMissing means that a field does not exist downstream.
+	SchemaIncompatibilityFieldMissing SchemaIncompatibilityType = "FieldMissing"
+	// SchemaIncompatibilityTypeChanged means that a field has another type downstream.
+	SchemaIncompatibilityTypeChanged SchemaIncompatibilityType = "TypeChanged"
+	// SchemaIncompatibilityValidationTightened means that a field has stricter validation downstream.
+	SchemaIncompatibilityValidationTightened SchemaIncompatibilityType = "ValidationTightened"
+	// SchemaIncompatibilityUnsupported means that the schemas differ in a way that cannot be checked
+	// for compatibility.
+	SchemaIncompatibilityUnsupported SchemaIncompatibilityType = "Unsupported"
+)
+
 type ResourceCompatibleState string
 
 const (
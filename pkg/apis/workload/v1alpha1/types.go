@@ -27,6 +27,10 @@ const (
 	// This includes the deletion process until the resource is deleted downstream and the
 	// syncer removes the state.workload.kcp.dev/<sync-target-name> label.
 	ResourceStateSync ResourceState = "Sync"
+	// ResourceStateUpsync is the state of a resource that originates from the sync target and
+	// is synced up by the syncer through the upsyncer virtual workspace. It is set by the
+	// syncer when creating the resource upstream, and the resource is owned by the sync target.
+	ResourceStateUpsync ResourceState = "Upsync"
 )
 
 const (
@@ -68,6 +72,7 @@ const (
 	//       controller will have to set the value to "Sync" after initializion in order to
 	//       start the sync process.
 	// - "Sync": the object is assigned and the syncer will start the sync process.
+	// - "Upsync": the object originates from the sync target and is synced up by the syncer.
 	//
 	// While being in "Sync" state, a deletion timestamp in deletion.internal.workload.kcp.dev/<sync-target-name>
 	// will signal the start of the deletion process of the object. During the deletion process
@@ -120,4 +125,27 @@ const (
 	// InternalSyncTargetKeyLabel is an internal label set on a SyncTarget resource that contains the full hash of the SyncTargetKey, generated with the ToSyncTargetKey(..)
 	// helper func, this label is used for reverse lookups of a syncTargetKey to SyncTarget.
 	InternalSyncTargetKeyLabel = "internal.workload.kcp.dev/key"
+
+	// SyncTargetScoreAnnotationPrefix is the prefix of annotations on a SyncTarget advertising numeric
+	// scores, e.g. score.workload.kcp.dev/cost: "12". The scores are available to the scoringExpression
+	// of a Placement under the annotation name without the prefix.
+	SyncTargetScoreAnnotationPrefix = "score.workload.kcp.dev/"
+
+	// SyncerClusterRoleLabelKey is the label key on the ClusterRole granting a syncer access to its
+	// SyncTarget, with the name of the SyncTarget as value. The rules of labelled ClusterRoles with
+	// the generated syncer name and a controller owner reference to the SyncTarget are regenerated
+	// whenever the synced resources of the SyncTarget change.
+	SyncerClusterRoleLabelKey = "workload.kcp.dev/syncer-synctarget"
+
+	// SyncerSignerName is the signerName of CertificateSigningRequests for syncer client certificates.
+	// The common name of the requested certificate must be the user name returned by
+	// SyncerCertificateUserName, and the single organizational unit the UID of the SyncTarget.
+	SyncerSignerName = "workload.kcp.dev/syncer"
+
+	// SyncerCertificateUserNamePrefix is the prefix of the user name of syncers authenticated with a
+	// client certificate, followed by the logical cluster and the name of the SyncTarget.
+	SyncerCertificateUserNamePrefix = "system:kcp:syncer:"
+
+	// SyncerCertificateGroup is the group of all syncers authenticated with a client certificate.
+	SyncerCertificateGroup = "system:kcp:syncers"
 )
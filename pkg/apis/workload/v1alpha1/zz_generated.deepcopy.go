@@ -30,6 +30,23 @@ import (
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourcePolicy) DeepCopyInto(out *ResourcePolicy) {
+	*out = *in
+	out.GroupResource = in.GroupResource
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcePolicy.
+func (in *ResourcePolicy) DeepCopy() *ResourcePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourcePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceToSync) DeepCopyInto(out *ResourceToSync) {
 	*out = *in
@@ -39,6 +56,11 @@ func (in *ResourceToSync) DeepCopyInto(out *ResourceToSync) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Incompatibilities != nil {
+		in, out := &in.Incompatibilities, &out.Incompatibilities
+		*out = make([]SchemaIncompatibility, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -52,6 +74,22 @@ func (in *ResourceToSync) DeepCopy() *ResourceToSync {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaIncompatibility) DeepCopyInto(out *SchemaIncompatibility) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaIncompatibility.
+func (in *SchemaIncompatibility) DeepCopy() *SchemaIncompatibility {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaIncompatibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncTarget) DeepCopyInto(out *SyncTarget) {
 	*out = *in
@@ -134,6 +172,11 @@ func (in *SyncTargetSpec) DeepCopyInto(out *SyncTargetSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ResourcePolicies != nil {
+		in, out := &in.ResourcePolicies, &out.ResourcePolicies
+		*out = make([]ResourcePolicy, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
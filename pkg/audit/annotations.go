@@ -14,22 +14,16 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package apiexport
+package audit
 
-import (
-	"fmt"
+const (
+	// WorkspaceAnnotationKey is the audit annotation holding the logical cluster of a request.
+	WorkspaceAnnotationKey = "tenancy.kcp.dev/workspace"
 
-	"github.com/kcp-dev/logicalcluster/v2"
+	// ShardAnnotationKey is the audit annotation holding the name of the shard serving a request.
+	ShardAnnotationKey = "tenancy.kcp.dev/shard"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	// VirtualWorkspaceOriginAnnotationKey is the audit annotation holding the name of the virtual workspace
+	// a request was forwarded from.
+	VirtualWorkspaceOriginAnnotationKey = "virtualworkspaces.kcp.dev/origin"
 )
-
-func indexByWorkspace(obj interface{}) ([]string, error) {
-	metaObj, ok := obj.(metav1.Object)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
-	}
-
-	lcluster := logicalcluster.From(metaObj)
-	return []string{lcluster.String()}, nil
-}
@@ -0,0 +1,274 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/util/webhook"
+	pluginbuffered "k8s.io/apiserver/plugin/pkg/audit/buffered"
+	pluginlog "k8s.io/apiserver/plugin/pkg/audit/log"
+	pluginwebhook "k8s.io/apiserver/plugin/pkg/audit/webhook"
+	"sigs.k8s.io/yaml"
+)
+
+// SinksConfig configures audit sinks receiving the audit events of selected workspaces, e.g. to give
+// every organization its own audit stream. Events are selected by their workspace annotation. Which
+// events are recorded at all, and at which level, is still decided by the audit policy of the shard.
+//
+// For example:
+//
+//	sinks:
+//	- name: acme
+//	  workspaces: ["root:acme", "root:acme:*"]
+//	  log:
+//	    path: /var/log/kcp/audit-acme.log
+//	    maxBackups: 10
+//	- name: globex
+//	  workspaces: ["root:globex", "root:globex:*"]
+//	  webhook:
+//	    kubeconfigFile: /etc/kcp/audit-globex.kubeconfig
+type SinksConfig struct {
+	Sinks []Sink `json:"sinks"`
+}
+
+// Sink is an audit log file or webhook receiving the events of the selected workspaces.
+type Sink struct {
+	// Name identifies the sink in logs and errors.
+	Name string `json:"name"`
+
+	// Workspaces selects the logical clusters whose events are sent to the sink. An entry ending
+	// in ":*" matches all descendants of the given logical cluster.
+	Workspaces []string `json:"workspaces"`
+
+	// Log writes the events to a log file. Exactly one of Log and Webhook must be set.
+	Log *LogSink `json:"log,omitempty"`
+	// Webhook sends the events to a webhook. Exactly one of Log and Webhook must be set.
+	Webhook *WebhookSink `json:"webhook,omitempty"`
+}
+
+// LogSink writes audit events to a rotated log file.
+type LogSink struct {
+	// Path is the log file.
+	Path string `json:"path"`
+	// Format is either "json" or "legacy". If empty, "json" is used.
+	Format string `json:"format,omitempty"`
+	// MaxAge is the maximum number of days to retain rotated log files.
+	MaxAge int `json:"maxAge,omitempty"`
+	// MaxBackups is the maximum number of rotated log files to retain.
+	MaxBackups int `json:"maxBackups,omitempty"`
+	// MaxSize is the maximum size in megabytes of the log file before it gets rotated.
+	MaxSize int `json:"maxSize,omitempty"`
+}
+
+// WebhookSink sends batches of audit events to a webhook.
+type WebhookSink struct {
+	// KubeconfigFile is a kubeconfig formatted file with the webhook configuration.
+	KubeconfigFile string `json:"kubeconfigFile"`
+	// InitialBackoff is the time to wait before retrying the first failed request. If zero, 10s are used.
+	InitialBackoff metav1.Duration `json:"initialBackoff,omitempty"`
+}
+
+// LoadSinksConfig reads a sinks configuration from the given file. If path is empty, nil is returned.
+func LoadSinksConfig(path string) (*SinksConfig, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit sinks config: %w", err)
+	}
+	config := &SinksConfig{}
+	if err := yaml.UnmarshalStrict(bs, config); err != nil {
+		return nil, fmt.Errorf("failed to parse audit sinks config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate checks that the sinks configuration is well-formed.
+func (c *SinksConfig) Validate() []error {
+	var errs []error
+	names := sets.NewString()
+	for i, s := range c.Sinks {
+		if s.Name == "" {
+			errs = append(errs, fmt.Errorf("sinks[%d]: name must be set", i))
+		} else if names.Has(s.Name) {
+			errs = append(errs, fmt.Errorf("sinks[%d]: duplicate name %q", i, s.Name))
+		}
+		names.Insert(s.Name)
+
+		if len(s.Workspaces) == 0 {
+			errs = append(errs, fmt.Errorf("sinks[%d]: workspaces must not be empty", i))
+		}
+		for j, ws := range s.Workspaces {
+			if !logicalcluster.New(strings.TrimSuffix(ws, ":*")).IsValid() {
+				errs = append(errs, fmt.Errorf("sinks[%d].workspaces[%d]: invalid workspace %q", i, j, ws))
+			}
+		}
+
+		switch {
+		case (s.Log == nil) == (s.Webhook == nil):
+			errs = append(errs, fmt.Errorf("sinks[%d]: exactly one of log and webhook must be set", i))
+		case s.Log != nil:
+			if s.Log.Path == "" {
+				errs = append(errs, fmt.Errorf("sinks[%d].log.path: must be set", i))
+			}
+			switch s.Log.Format {
+			case "", pluginlog.FormatJson, pluginlog.FormatLegacy:
+			default:
+				errs = append(errs, fmt.Errorf("sinks[%d].log.format: unknown format %q, must be %q or %q", i, s.Log.Format, pluginlog.FormatJson, pluginlog.FormatLegacy))
+			}
+			if s.Log.MaxAge < 0 || s.Log.MaxBackups < 0 || s.Log.MaxSize < 0 {
+				errs = append(errs, fmt.Errorf("sinks[%d].log: maxAge, maxBackups and maxSize must not be negative", i))
+			}
+		case s.Webhook != nil:
+			if s.Webhook.KubeconfigFile == "" {
+				errs = append(errs, fmt.Errorf("sinks[%d].webhook.kubeconfigFile: must be set", i))
+			}
+			if s.Webhook.InitialBackoff.Duration < 0 {
+				errs = append(errs, fmt.Errorf("sinks[%d].webhook.initialBackoff: must not be negative", i))
+			}
+		}
+	}
+	return errs
+}
+
+// matches returns whether events of the given logical cluster are sent to the sink.
+func (s *Sink) matches(clusterName logicalcluster.Name) bool {
+	for _, ws := range s.Workspaces {
+		if parent := strings.TrimSuffix(ws, ":*"); parent != ws {
+			if strings.HasPrefix(clusterName.String(), parent+":") {
+				return true
+			}
+		} else if clusterName.String() == ws {
+			return true
+		}
+	}
+	return false
+}
+
+// NewBackend creates an audit backend dispatching events to the configured sinks by their workspace annotation.
+// Events without workspace annotation are dropped.
+func (c *SinksConfig) NewBackend() (audit.Backend, error) {
+	b := &sinksBackend{}
+	for i := range c.Sinks {
+		s := &c.Sinks[i]
+		var backend audit.Backend
+		switch {
+		case s.Log != nil:
+			format := s.Log.Format
+			if format == "" {
+				format = pluginlog.FormatJson
+			}
+			backend = pluginlog.NewBackend(&lumberjack.Logger{
+				Filename:   s.Log.Path,
+				MaxAge:     s.Log.MaxAge,
+				MaxBackups: s.Log.MaxBackups,
+				MaxSize:    s.Log.MaxSize,
+			}, format, auditv1.SchemeGroupVersion)
+		case s.Webhook != nil:
+			initialBackoff := s.Webhook.InitialBackoff.Duration
+			if initialBackoff == 0 {
+				initialBackoff = pluginwebhook.DefaultInitialBackoffDelay
+			}
+			webhookBackend, err := pluginwebhook.NewBackend(s.Webhook.KubeconfigFile, auditv1.SchemeGroupVersion, webhook.DefaultRetryBackoffWithInitialDelay(initialBackoff), nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create webhook of audit sink %q: %w", s.Name, err)
+			}
+			backend = pluginbuffered.NewBackend(webhookBackend, webhookBatchConfig)
+		default:
+			return nil, fmt.Errorf("audit sink %q has neither log nor webhook", s.Name)
+		}
+		b.sinks = append(b.sinks, sinkBackend{Sink: s, backend: backend})
+	}
+	return b, nil
+}
+
+// webhookBatchConfig mirrors the defaults of the --audit-webhook-batch-* flags.
+var webhookBatchConfig = pluginbuffered.BatchConfig{
+	BufferSize:     10000,
+	MaxBatchSize:   400,
+	MaxBatchWait:   30 * time.Second,
+	ThrottleEnable: true,
+	ThrottleQPS:    10,
+	ThrottleBurst:  15,
+	AsyncDelegate:  true,
+}
+
+type sinkBackend struct {
+	*Sink
+	backend audit.Backend
+}
+
+type sinksBackend struct {
+	sinks []sinkBackend
+}
+
+var _ audit.Backend = &sinksBackend{}
+
+func (b *sinksBackend) ProcessEvents(events ...*auditinternal.Event) bool {
+	success := true
+	for _, s := range b.sinks {
+		var selected []*auditinternal.Event
+		for _, ev := range events {
+			clusterName, ok := ev.Annotations[WorkspaceAnnotationKey]
+			if !ok || !s.matches(logicalcluster.New(clusterName)) {
+				continue
+			}
+			selected = append(selected, ev)
+		}
+		if len(selected) > 0 {
+			success = s.backend.ProcessEvents(selected...) && success
+		}
+	}
+	return success
+}
+
+func (b *sinksBackend) Run(stopCh <-chan struct{}) error {
+	for _, s := range b.sinks {
+		if err := s.backend.Run(stopCh); err != nil {
+			return fmt.Errorf("failed to run audit sink %q: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+func (b *sinksBackend) Shutdown() {
+	for _, s := range b.sinks {
+		s.backend.Shutdown()
+	}
+}
+
+func (b *sinksBackend) String() string {
+	names := make([]string, 0, len(b.sinks))
+	for _, s := range b.sinks {
+		names = append(names, fmt.Sprintf("%s<%s>", s.Name, s.backend))
+	}
+	return fmt.Sprintf("workspace-sinks[%s]", strings.Join(names, ","))
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/types"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+)
+
+func TestLoadSinksConfig(t *testing.T) {
+	config, err := LoadSinksConfig("")
+	require.NoError(t, err)
+	require.Nil(t, config)
+
+	path := filepath.Join(t.TempDir(), "sinks.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+sinks:
+- name: acme
+  workspaces: ["root:acme", "root:acme:*"]
+  log:
+    path: /var/log/kcp/audit-acme.log
+`), 0600))
+	config, err = LoadSinksConfig(path)
+	require.NoError(t, err)
+	require.Empty(t, config.Validate())
+	require.Len(t, config.Sinks, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("sinks:\n- name: acme\n  organization: root:acme\n"), 0600))
+	_, err = LoadSinksConfig(path)
+	require.Error(t, err, "unknown fields must be rejected")
+}
+
+func TestValidateSinksConfig(t *testing.T) {
+	config := &SinksConfig{Sinks: []Sink{
+		{Name: "acme", Workspaces: []string{"root:Acme"}, Log: &LogSink{Path: "acme.log"}},
+		{Name: "acme", Workspaces: []string{"root:globex"}, Log: &LogSink{Path: "globex.log", Format: "yaml"}},
+		{Name: "initech", Workspaces: []string{"root:initech"}},
+		{Name: "umbrella", Workspaces: []string{"root:umbrella"}, Webhook: &WebhookSink{}},
+	}}
+	require.Len(t, config.Validate(), 5)
+}
+
+func TestSinksBackend(t *testing.T) {
+	acme, globex := &fakeBackend{}, &fakeBackend{}
+	b := &sinksBackend{sinks: []sinkBackend{
+		{Sink: &Sink{Name: "acme", Workspaces: []string{"root:acme", "root:acme:*"}}, backend: acme},
+		{Sink: &Sink{Name: "globex", Workspaces: []string{"root:globex:*"}}, backend: globex},
+	}}
+
+	event := func(clusterName string) *auditinternal.Event {
+		return &auditinternal.Event{AuditID: types.UID(clusterName), Annotations: map[string]string{WorkspaceAnnotationKey: clusterName}}
+	}
+	require.True(t, b.ProcessEvents(
+		event("root:acme"),
+		event("root:acme:team"),
+		event("root:acmecorp"),
+		event("root:globex"),
+		event("root:globex:team"),
+		&auditinternal.Event{AuditID: "none"},
+	))
+
+	require.Equal(t, []string{"root:acme", "root:acme:team"}, acme.ids)
+	require.Equal(t, []string{"root:globex:team"}, globex.ids)
+}
+
+type fakeBackend struct {
+	ids []string
+}
+
+var _ audit.Backend = &fakeBackend{}
+
+func (b *fakeBackend) ProcessEvents(events ...*auditinternal.Event) bool {
+	for _, ev := range events {
+		b.ids = append(b.ids, string(ev.AuditID))
+	}
+	return true
+}
+
+func (b *fakeBackend) Run(stopCh <-chan struct{}) error { return nil }
+func (b *fakeBackend) Shutdown()                        {}
+func (b *fakeBackend) String() string                   { return "fake" }
@@ -0,0 +1,194 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// BreakGlassUserNamePrefix prefixes the user names of break-glass accounts. It is followed by the
+	// name of the account.
+	BreakGlassUserNamePrefix = "system:kcp:break-glass:"
+
+	// BreakGlassReasonHeader must be set on every request of a break-glass account. It is recorded in
+	// the audit log.
+	BreakGlassReasonHeader = "X-Kcp-Break-Glass-Reason"
+
+	BreakGlassAuditPrefix  = "breakglass.authentication.kcp.dev/"
+	BreakGlassAuditAccount = BreakGlassAuditPrefix + "account"
+	BreakGlassAuditReason  = BreakGlassAuditPrefix + "reason"
+	BreakGlassAuditExpires = BreakGlassAuditPrefix + "expires"
+)
+
+// BreakGlassAccount is a named emergency identity with unrestricted access, as configured in the
+// break-glass accounts file.
+type BreakGlassAccount struct {
+	// Name of the account. The user name is BreakGlassUserNamePrefix followed by the name.
+	Name string `json:"name"`
+	// TokenHash is the hex encoded SHA-256 hash of the bearer token of the account.
+	TokenHash string `json:"tokenHash"`
+	// Expires is the time after which the account cannot be used anymore.
+	Expires time.Time `json:"expires"`
+}
+
+// BreakGlassAccounts authenticates break-glass accounts, and tells the authorizer which users have
+// been authenticated that way.
+type BreakGlassAccounts struct {
+	accounts []breakGlassAccount
+	now      func() time.Time
+}
+
+type breakGlassAccount struct {
+	BreakGlassAccount
+	tokenHash []byte
+}
+
+// breakGlassUser is the user of a request authenticated as break-glass account. Only the
+// authenticator creates values of this type, and they are passed unchanged through the request
+// context to the authorizer. Users created from user names, UIDs, groups and extras, e.g. by
+// impersonation, are never break-glass users.
+type breakGlassUser struct {
+	user.DefaultInfo
+	accounts *BreakGlassAccounts
+	account  *breakGlassAccount
+}
+
+// LoadBreakGlassAccounts reads the break-glass accounts file.
+func LoadBreakGlassAccounts(path string) (*BreakGlassAccounts, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read break-glass accounts file %q: %w", path, err)
+	}
+	var accounts []BreakGlassAccount
+	if err := yaml.UnmarshalStrict(data, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal break-glass accounts file %q: %w", path, err)
+	}
+	return NewBreakGlassAccounts(accounts)
+}
+
+// NewBreakGlassAccounts validates the given accounts. Every account needs a name, a token hash and
+// an expiry.
+func NewBreakGlassAccounts(accounts []BreakGlassAccount) (*BreakGlassAccounts, error) {
+	a := &BreakGlassAccounts{
+		now: time.Now,
+	}
+	names := map[string]bool{}
+	for i, account := range accounts {
+		if account.Name == "" {
+			return nil, fmt.Errorf("break-glass account %d: name is required", i)
+		}
+		if names[account.Name] {
+			return nil, fmt.Errorf("break-glass account %q: duplicate name", account.Name)
+		}
+		names[account.Name] = true
+		hash, err := hex.DecodeString(account.TokenHash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("break-glass account %q: tokenHash must be a hex encoded SHA-256 hash", account.Name)
+		}
+		if account.Expires.IsZero() {
+			return nil, fmt.Errorf("break-glass account %q: expires is required", account.Name)
+		}
+		if !account.Expires.After(a.now()) {
+			klog.Warningf("Break-glass account %q expired at %s", account.Name, account.Expires.Format(time.RFC3339))
+		}
+		a.accounts = append(a.accounts, breakGlassAccount{BreakGlassAccount: account, tokenHash: hash})
+	}
+	return a, nil
+}
+
+// Authenticator returns a bearer token authenticator for the break-glass accounts. Requests must
+// carry the BreakGlassReasonHeader. Account, reason and expiry are added as audit annotations.
+func (a *BreakGlassAccounts) Authenticator() authenticator.Request {
+	tokenAuthenticator := bearertoken.New(authenticator.TokenFunc(func(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+		account := a.lookup(token)
+		if account == nil {
+			return nil, false, nil
+		}
+		if !account.Expires.After(a.now()) {
+			return nil, false, fmt.Errorf("break-glass account %q expired at %s", account.Name, account.Expires.Format(time.RFC3339))
+		}
+		return &authenticator.Response{
+			User: &breakGlassUser{
+				DefaultInfo: user.DefaultInfo{
+					Name:   BreakGlassUserNamePrefix + account.Name,
+					Groups: []string{user.AllAuthenticated},
+				},
+				accounts: a,
+				account:  account,
+			},
+		}, true, nil
+	}))
+
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		resp, ok, err := tokenAuthenticator.AuthenticateRequest(req)
+		if !ok || err != nil {
+			return resp, ok, err
+		}
+		reason := req.Header.Get(BreakGlassReasonHeader)
+		if reason == "" {
+			return nil, false, fmt.Errorf("requests of break-glass accounts must give a reason in the %s header", BreakGlassReasonHeader)
+		}
+		req.Header.Del(BreakGlassReasonHeader)
+
+		account := resp.User.(*breakGlassUser).account
+		klog.Warningf("Break-glass account %q used for %s %s: %s", account.Name, req.Method, req.URL.Path, reason)
+		kaudit.AddAuditAnnotation(req.Context(), BreakGlassAuditAccount, account.Name)
+		kaudit.AddAuditAnnotation(req.Context(), BreakGlassAuditReason, reason)
+		kaudit.AddAuditAnnotation(req.Context(), BreakGlassAuditExpires, account.Expires.Format(time.RFC3339))
+		return resp, true, nil
+	})
+}
+
+// Authenticated returns the name of the break-glass account the given user has been authenticated
+// as by these accounts, if any, and whether that account is still valid. The user must be the one
+// returned by the authenticator: users with the same name, e.g. impersonated ones, are not
+// authenticated as break-glass account.
+func (a *BreakGlassAccounts) Authenticated(u user.Info) (string, bool) {
+	bu, ok := u.(*breakGlassUser)
+	if a == nil || !ok || bu.accounts != a {
+		return "", false
+	}
+	if !bu.account.Expires.After(a.now()) {
+		return "", false
+	}
+	return bu.account.Name, true
+}
+
+func (a *BreakGlassAccounts) lookup(token string) *breakGlassAccount {
+	hash := sha256.Sum256([]byte(token))
+	for i := range a.accounts {
+		if subtle.ConstantTimeCompare(hash[:], a.accounts[i].tokenHash) == 1 {
+			return &a.accounts[i]
+		}
+	}
+	return nil
+}
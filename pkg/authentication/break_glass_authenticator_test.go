@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestBreakGlassAccounts(t *testing.T) {
+	now := time.Now()
+	hash := func(token string) string {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
+
+	accounts, err := NewBreakGlassAccounts([]BreakGlassAccount{
+		{Name: "oncall", TokenHash: hash("valid"), Expires: now.Add(time.Hour)},
+		{Name: "expired", TokenHash: hash("expired"), Expires: now.Add(-time.Hour)},
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		token    string
+		reason   string
+		wantUser string
+		wantErr  bool
+	}{
+		"valid account with reason":    {token: "valid", reason: "etcd restore", wantUser: BreakGlassUserNamePrefix + "oncall"},
+		"valid account without reason": {token: "valid", wantErr: true},
+		"expired account":              {token: "expired", reason: "etcd restore", wantErr: true},
+		"unknown token":                {token: "unknown", reason: "etcd restore"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/clusters/root/api/v1/namespaces", nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.reason != "" {
+				req.Header.Set(BreakGlassReasonHeader, tt.reason)
+			}
+
+			resp, ok, err := accounts.Authenticator().AuthenticateRequest(req)
+			if tt.wantUser == "" {
+				require.False(t, ok)
+				if tt.wantErr {
+					require.Error(t, err)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, tt.wantUser, resp.User.GetName())
+
+			name, ok := accounts.Authenticated(resp.User)
+			require.True(t, ok)
+			require.Equal(t, "oncall", name)
+		})
+	}
+
+	t.Run("other authenticators cannot claim a break-glass identity", func(t *testing.T) {
+		_, ok := accounts.Authenticated(&user.DefaultInfo{Name: BreakGlassUserNamePrefix + "oncall", UID: "break-glass"})
+		require.False(t, ok)
+	})
+
+	t.Run("impersonation cannot claim a break-glass identity", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/clusters/root/api/v1/namespaces", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer valid")
+		req.Header.Set(BreakGlassReasonHeader, "etcd restore")
+		resp, ok, err := accounts.Authenticator().AuthenticateRequest(req)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// the impersonation filter creates a new user from the impersonation headers
+		impersonated := &user.DefaultInfo{
+			Name:   resp.User.GetName(),
+			UID:    resp.User.GetUID(),
+			Groups: resp.User.GetGroups(),
+			Extra:  resp.User.GetExtra(),
+		}
+		_, ok = accounts.Authenticated(impersonated)
+		require.False(t, ok)
+	})
+
+	t.Run("users of other break-glass accounts are not authenticated", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/clusters/root/api/v1/namespaces", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer valid")
+		req.Header.Set(BreakGlassReasonHeader, "etcd restore")
+		resp, ok, err := accounts.Authenticator().AuthenticateRequest(req)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		others, err := NewBreakGlassAccounts([]BreakGlassAccount{{Name: "oncall", TokenHash: hash("valid"), Expires: now.Add(time.Hour)}})
+		require.NoError(t, err)
+		_, ok = others.Authenticated(resp.User)
+		require.False(t, ok)
+	})
+
+	t.Run("accounts without expiry are rejected", func(t *testing.T) {
+		_, err := NewBreakGlassAccounts([]BreakGlassAccount{{Name: "forever", TokenHash: hash("forever")}})
+		require.Error(t, err)
+	})
+}
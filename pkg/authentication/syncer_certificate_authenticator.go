@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"crypto/x509"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	authenticatorunion "k8s.io/apiserver/pkg/authentication/request/union"
+	x509request "k8s.io/apiserver/pkg/authentication/request/x509"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/client-go/tools/clusters"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+// WithSyncerCertificateAuthentication returns a request authenticator which falls back to
+// authenticating syncers by client certificates signed by the given syncer client CA.
+func WithSyncerCertificateAuthentication(delegate authenticator.Request, clientCA dynamiccertificates.CAContentProvider, kcpInformers kcpinformers.SharedInformerFactory) authenticator.Request {
+	syncTargetLister := kcpInformers.Workload().V1alpha1().SyncTargets().Lister()
+	syncerAuthenticator := NewSyncerCertificateAuthenticator(clientCA, func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error) {
+		return syncTargetLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+	})
+	if delegate == nil {
+		return syncerAuthenticator
+	}
+	return authenticatorunion.New(delegate, syncerAuthenticator)
+}
+
+// NewSyncerCertificateAuthenticator returns a request authenticator for syncer client certificates
+// signed by the given client CA. A certificate authenticates the syncer only as long as the SyncTarget
+// it was issued for exists with the same UID, i.e. deleting the SyncTarget revokes its certificates.
+//
+// The syncer is authenticated as a member of its SyncTarget workspace, like a service account of
+// that workspace.
+func NewSyncerCertificateAuthenticator(clientCA dynamiccertificates.CAContentProvider, getSyncTarget func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error)) authenticator.Request {
+	return x509request.NewDynamic(clientCA.VerifyOptions, x509request.UserConversionFunc(func(chain []*x509.Certificate) (*authenticator.Response, bool, error) {
+		return syncerUser(chain[0], getSyncTarget)
+	}))
+}
+
+func syncerUser(cert *x509.Certificate, getSyncTarget func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error)) (*authenticator.Response, bool, error) {
+	clusterName, syncTargetName, ok := workloadv1alpha1.ParseSyncerCertificateUserName(cert.Subject.CommonName)
+	if !ok || len(cert.Subject.OrganizationalUnit) != 1 {
+		return nil, false, nil
+	}
+
+	syncTarget, err := getSyncTarget(clusterName, syncTargetName)
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if string(syncTarget.UID) != cert.Subject.OrganizationalUnit[0] {
+		// the SyncTarget has been recreated since the certificate was issued
+		return nil, false, nil
+	}
+
+	return &authenticator.Response{
+		User: &user.DefaultInfo{
+			Name:   cert.Subject.CommonName,
+			Groups: []string{workloadv1alpha1.SyncerCertificateGroup, user.AllAuthenticated},
+			Extra: map[string][]string{
+				authserviceaccount.ClusterNameKey: {clusterName.String()},
+			},
+		},
+	}, true, nil
+}
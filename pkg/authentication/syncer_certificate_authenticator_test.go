@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestSyncerUser(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{ObjectMeta: metav1.ObjectMeta{Name: "us-east1", UID: "uid-1"}}
+	getSyncTarget := func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error) {
+		if clusterName.String() == "root:org:ws" && name == "us-east1" {
+			return syncTarget, nil
+		}
+		if name == "broken" {
+			return nil, errors.New("boom")
+		}
+		return nil, apierrors.NewNotFound(workloadv1alpha1.Resource("synctargets"), name)
+	}
+	cert := func(commonName string, organizationalUnits ...string) *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{
+			CommonName:         commonName,
+			OrganizationalUnit: organizationalUnits,
+			Organization:       []string{"system:masters"},
+		}}
+	}
+
+	tests := map[string]struct {
+		cert     *x509.Certificate
+		wantUser user.Info
+		wantErr  bool
+	}{
+		"syncer of existing SyncTarget": {
+			cert: cert("system:kcp:syncer:root:org:ws:us-east1", "uid-1"),
+			wantUser: &user.DefaultInfo{
+				Name:   "system:kcp:syncer:root:org:ws:us-east1",
+				Groups: []string{workloadv1alpha1.SyncerCertificateGroup, user.AllAuthenticated},
+				Extra:  map[string][]string{authserviceaccount.ClusterNameKey: {"root:org:ws"}},
+			},
+		},
+		"recreated SyncTarget": {
+			cert: cert("system:kcp:syncer:root:org:ws:us-east1", "uid-0"),
+		},
+		"deleted SyncTarget": {
+			cert: cert("system:kcp:syncer:root:org:ws:us-west1", "uid-1"),
+		},
+		"missing UID": {
+			cert: cert("system:kcp:syncer:root:org:ws:us-east1"),
+		},
+		"no syncer": {
+			cert: cert("admin", "uid-1"),
+		},
+		"lister error": {
+			cert:    cert("system:kcp:syncer:root:org:ws:broken", "uid-1"),
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, ok, err := syncerUser(tc.cert, getSyncTarget)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.wantUser == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tc.wantUser, resp.User)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/group"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	authenticatorunion "k8s.io/apiserver/pkg/authentication/request/union"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/apiserver/plugin/pkg/authenticator/token/oidc"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+// WithWorkspaceAuthentication returns a request authenticator which falls back to the OIDC issuers
+// of WorkspaceAuthenticationConfigurations for bearer tokens not authenticated by the delegate.
+func WithWorkspaceAuthentication(delegate authenticator.Request, apiAudiences authenticator.Audiences, kcpInformers kcpinformers.SharedInformerFactory) authenticator.Request {
+	workspaceAuthenticator := group.NewAuthenticatedGroupAdder(bearertoken.New(authenticator.WrapAudienceAgnosticToken(apiAudiences, NewWorkspaceAuthenticator(kcpInformers))))
+	if delegate == nil {
+		return workspaceAuthenticator
+	}
+	return authenticatorunion.New(delegate, workspaceAuthenticator)
+}
+
+// NewWorkspaceAuthenticator returns a token authenticator validating tokens with the OIDC issuers
+// of the WorkspaceAuthenticationConfigurations in the request workspace and in its ancestors. Tokens
+// are not authenticated in any other workspace, and neither for wildcard requests.
+//
+// Usernames and groups are prefixed with the logical cluster of the configuration, such that a
+// configuration cannot authenticate the users of another one by choosing the same prefixes.
+//
+// Only configurations known to the informer, i.e. those on the local shard, are considered. Configurations
+// in ancestors on other shards are ignored.
+func NewWorkspaceAuthenticator(kcpInformers kcpinformers.SharedInformerFactory) authenticator.Token {
+	return newWorkspaceAuthenticator(kcpInformers.Tenancy().V1alpha1().WorkspaceAuthenticationConfigurations().Informer(), newOIDCAuthenticator)
+}
+
+// issuerAuthenticator authenticates the tokens of one issuer. It is closed when its
+// WorkspaceAuthenticationConfiguration changes.
+type issuerAuthenticator interface {
+	authenticator.Token
+	Close()
+}
+
+func newWorkspaceAuthenticator(informer cache.SharedIndexInformer, newIssuerAuthenticator func(logicalcluster.Name, tenancyv1alpha1.OIDCIssuer) (issuerAuthenticator, error)) *workspaceAuthenticator {
+	indexers.AddOrDie(informer.GetIndexer(), indexers.ByLogicalCluster)
+
+	a := &workspaceAuthenticator{
+		configIndexer:          informer.GetIndexer(),
+		newIssuerAuthenticator: newIssuerAuthenticator,
+		configs:                map[string]*configAuthenticators{},
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: a.forget,
+	})
+
+	return a
+}
+
+type workspaceAuthenticator struct {
+	configIndexer          cache.Indexer
+	newIssuerAuthenticator func(logicalcluster.Name, tenancyv1alpha1.OIDCIssuer) (issuerAuthenticator, error)
+
+	lock    sync.Mutex
+	configs map[string]*configAuthenticators
+}
+
+// configAuthenticators holds the issuer authenticators of a WorkspaceAuthenticationConfiguration
+// by issuer URL, for the resource version they were created for.
+type configAuthenticators struct {
+	resourceVersion string
+	issuers         map[string]issuerAuthenticator
+}
+
+func (c *configAuthenticators) close() {
+	for _, auth := range c.issuers {
+		auth.Close()
+	}
+}
+
+func (a *workspaceAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	cluster := genericapirequest.ClusterFrom(ctx)
+	if cluster == nil || cluster.Name.Empty() || cluster.Wildcard {
+		return nil, false, nil
+	}
+
+	var errs []error
+	for clusterName := cluster.Name; !clusterName.Empty(); clusterName, _ = clusterName.Parent() {
+		configs, err := indexers.ByIndex[*tenancyv1alpha1.WorkspaceAuthenticationConfiguration](a.configIndexer, indexers.ByLogicalCluster, clusterName.String())
+		if err != nil {
+			return nil, false, err
+		}
+		for _, config := range configs {
+			if config.DeletionTimestamp != nil {
+				continue
+			}
+			for _, issuer := range config.Spec.OIDC {
+				auth, err := a.issuerAuthenticator(config, issuer)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				resp, ok, err := auth.AuthenticateToken(ctx, token)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if !ok {
+					continue
+				}
+				if resp, ok := withoutSystemIdentities(resp); ok {
+					return resp, true, nil
+				}
+			}
+		}
+	}
+
+	return nil, false, utilerrors.NewAggregate(errs)
+}
+
+// issuerAuthenticator returns the authenticator of the given issuer of the
+// WorkspaceAuthenticationConfiguration, creating it on first use. The authenticators of earlier
+// resource versions of the configuration are closed.
+func (a *workspaceAuthenticator) issuerAuthenticator(config *tenancyv1alpha1.WorkspaceAuthenticationConfiguration, issuer tenancyv1alpha1.OIDCIssuer) (issuerAuthenticator, error) {
+	key, err := cache.MetaNamespaceKeyFunc(config)
+	if err != nil {
+		return nil, err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	c, ok := a.configs[key]
+	if ok && c.resourceVersion != config.ResourceVersion {
+		c.close()
+		ok = false
+	}
+	if !ok {
+		c = &configAuthenticators{
+			resourceVersion: config.ResourceVersion,
+			issuers:         map[string]issuerAuthenticator{},
+		}
+		a.configs[key] = c
+	}
+
+	if auth, ok := c.issuers[issuer.IssuerURL]; ok {
+		return auth, nil
+	}
+	auth, err := a.newIssuerAuthenticator(logicalcluster.From(config), issuer)
+	if err != nil {
+		return nil, err
+	}
+	c.issuers[issuer.IssuerURL] = auth
+	return auth, nil
+}
+
+// forget closes the authenticators of a deleted WorkspaceAuthenticationConfiguration.
+func (a *workspaceAuthenticator) forget(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Failed to get key of WorkspaceAuthenticationConfiguration: %v", err)
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if c, ok := a.configs[key]; ok {
+		c.close()
+		delete(a.configs, key)
+	}
+}
+
+// withoutSystemIdentities drops "system:" groups from the response. Responses for "system:" users
+// are not authenticated at all.
+func withoutSystemIdentities(resp *authenticator.Response) (*authenticator.Response, bool) {
+	if strings.HasPrefix(resp.User.GetName(), "system:") {
+		return nil, false
+	}
+
+	var groups []string
+	for _, g := range resp.User.GetGroups() {
+		if !strings.HasPrefix(g, "system:") {
+			groups = append(groups, g)
+		}
+	}
+
+	return &authenticator.Response{
+		Audiences: resp.Audiences,
+		User: &user.DefaultInfo{
+			Name:   resp.User.GetName(),
+			UID:    resp.User.GetUID(),
+			Groups: groups,
+			Extra:  resp.User.GetExtra(),
+		},
+	}, true
+}
+
+func newOIDCAuthenticator(clusterName logicalcluster.Name, issuer tenancyv1alpha1.OIDCIssuer) (issuerAuthenticator, error) {
+	opts := oidc.Options{
+		IssuerURL:      issuer.IssuerURL,
+		ClientID:       issuer.ClientID,
+		UsernameClaim:  issuer.UsernameClaim,
+		UsernamePrefix: usernamePrefix(clusterName, issuer),
+		GroupsClaim:    issuer.GroupsClaim,
+		GroupsPrefix:   groupsPrefix(clusterName, issuer),
+		RequiredClaims: issuer.RequiredClaims,
+	}
+	if opts.UsernameClaim == "" {
+		opts.UsernameClaim = "sub"
+	}
+	if len(issuer.CertificateAuthorityData) > 0 {
+		ca, err := dynamiccertificates.NewStaticCAContent(issuer.IssuerURL, issuer.CertificateAuthorityData)
+		if err != nil {
+			return nil, err
+		}
+		opts.CAContentProvider = ca
+	}
+	return oidc.New(opts)
+}
+
+// usernamePrefix returns the prefix of the usernames of the issuer of a configuration in the given logical
+// cluster: the logical cluster, followed by "/" and the usernamePrefix of the issuer. Logical cluster names
+// never contain "/", hence configurations in different logical clusters never share a prefix. Admission
+// requires the usernamePrefix to be set, but for configurations admitted before, it defaults to the issuer
+// URL followed by "#".
+func usernamePrefix(clusterName logicalcluster.Name, issuer tenancyv1alpha1.OIDCIssuer) string {
+	if issuer.UsernamePrefix == "" {
+		return clusterName.String() + "/" + issuer.IssuerURL + "#"
+	}
+	return clusterName.String() + "/" + issuer.UsernamePrefix
+}
+
+// groupsPrefix returns the prefix of the groups of the issuer like usernamePrefix.
+func groupsPrefix(clusterName logicalcluster.Name, issuer tenancyv1alpha1.OIDCIssuer) string {
+	if issuer.GroupsPrefix == "" {
+		return clusterName.String() + "/" + issuer.IssuerURL + "#"
+	}
+	return clusterName.String() + "/" + issuer.GroupsPrefix
+}
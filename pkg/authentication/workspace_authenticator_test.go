@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// fakeIssuer authenticates tokens of the form "<issuer URL>/<user>".
+type fakeIssuer struct {
+	clusterName logicalcluster.Name
+	issuer      tenancyv1alpha1.OIDCIssuer
+	closed      bool
+}
+
+func (f *fakeIssuer) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	prefix := f.issuer.IssuerURL + "/"
+	if len(token) <= len(prefix) || token[:len(prefix)] != prefix {
+		return nil, false, nil
+	}
+	name := token[len(prefix):]
+	return &authenticator.Response{User: &user.DefaultInfo{
+		Name:   usernamePrefix(f.clusterName, f.issuer) + name,
+		Groups: []string{groupsPrefix(f.clusterName, f.issuer) + "developers", "system:masters"},
+	}}, true, nil
+}
+
+func (f *fakeIssuer) Close() {
+	f.closed = true
+}
+
+func newConfig(clusterName, name, resourceVersion string, issuers ...tenancyv1alpha1.OIDCIssuer) *tenancyv1alpha1.WorkspaceAuthenticationConfiguration {
+	return &tenancyv1alpha1.WorkspaceAuthenticationConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: resourceVersion,
+			Annotations:     map[string]string{logicalcluster.AnnotationKey: clusterName},
+		},
+		Spec: tenancyv1alpha1.WorkspaceAuthenticationConfigurationSpec{
+			OIDC: issuers,
+		},
+	}
+}
+
+func TestWorkspaceAuthenticator(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &tenancyv1alpha1.WorkspaceAuthenticationConfiguration{}, 0, cache.Indexers{})
+	var created []*fakeIssuer
+	a := newWorkspaceAuthenticator(informer, func(clusterName logicalcluster.Name, issuer tenancyv1alpha1.OIDCIssuer) (issuerAuthenticator, error) {
+		f := &fakeIssuer{clusterName: clusterName, issuer: issuer}
+		created = append(created, f)
+		return f, nil
+	})
+
+	orgIssuer := tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://org.example.com", ClientID: "kcp", GroupsPrefix: "org:"}
+	teamIssuer := tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://team.example.com", ClientID: "kcp", UsernamePrefix: "team:", GroupsPrefix: "team:"}
+	require.NoError(t, informer.GetIndexer().Add(newConfig("root:org", "corporate", "1", orgIssuer)))
+	require.NoError(t, informer.GetIndexer().Add(newConfig("root:org:team", "team", "1", teamIssuer)))
+
+	tests := map[string]struct {
+		cluster   genericapirequest.Cluster
+		token     string
+		wantUser  string
+		wantGroup []string
+	}{
+		"authenticates in the workspace of the configuration": {
+			cluster:   genericapirequest.Cluster{Name: logicalcluster.New("root:org")},
+			token:     "https://org.example.com/alice",
+			wantUser:  "root:org/https://org.example.com#alice",
+			wantGroup: []string{"root:org/org:developers"},
+		},
+		"authenticates in descendant workspaces": {
+			cluster:   genericapirequest.Cluster{Name: logicalcluster.New("root:org:team:project")},
+			token:     "https://org.example.com/alice",
+			wantUser:  "root:org/https://org.example.com#alice",
+			wantGroup: []string{"root:org/org:developers"},
+		},
+		"authenticates with issuers of descendants in their subtree": {
+			cluster:   genericapirequest.Cluster{Name: logicalcluster.New("root:org:team")},
+			token:     "https://team.example.com/bob",
+			wantUser:  "root:org:team/team:bob",
+			wantGroup: []string{"root:org:team/team:developers"},
+		},
+		"does not authenticate with issuers of descendants in ancestors": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("root:org")},
+			token:   "https://team.example.com/bob",
+		},
+		"does not authenticate in other workspaces": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("root:other")},
+			token:   "https://org.example.com/alice",
+		},
+		"does not authenticate wildcard requests": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			token:   "https://org.example.com/alice",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := genericapirequest.WithCluster(context.Background(), tc.cluster)
+			resp, ok, err := a.AuthenticateToken(ctx, tc.token)
+			require.NoError(t, err)
+			if tc.wantUser == "" {
+				require.False(t, ok, "unexpectedly authenticated")
+				return
+			}
+			require.True(t, ok, "not authenticated")
+			require.Equal(t, tc.wantUser, resp.User.GetName())
+			require.Equal(t, tc.wantGroup, resp.User.GetGroups())
+		})
+	}
+
+	require.Len(t, created, 2, "authenticators should be reused")
+	old := created[0]
+	if old.issuer.IssuerURL != orgIssuer.IssuerURL {
+		old = created[1]
+	}
+
+	ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org")})
+	require.NoError(t, informer.GetIndexer().Update(newConfig("root:org", "corporate", "2", orgIssuer)))
+	_, ok, err := a.AuthenticateToken(ctx, "https://org.example.com/alice")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, created, 3, "authenticator should be recreated for the new resource version")
+	require.True(t, old.closed, "authenticator of the old resource version should be closed")
+
+	a.forget(newConfig("root:org", "corporate", "2", orgIssuer))
+	require.True(t, created[2].closed, "authenticator of the deleted configuration should be closed")
+}
+
+func TestWorkspaceAuthenticatorPrefixCollision(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &tenancyv1alpha1.WorkspaceAuthenticationConfiguration{}, 0, cache.Indexers{})
+	a := newWorkspaceAuthenticator(informer, func(clusterName logicalcluster.Name, issuer tenancyv1alpha1.OIDCIssuer) (issuerAuthenticator, error) {
+		return &fakeIssuer{clusterName: clusterName, issuer: issuer}, nil
+	})
+
+	// the ancestors choose prefixes such that their users would be the users of the team issuer
+	// if the prefixes were not qualified by the logical cluster.
+	teamIssuer := tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://team.example.com", ClientID: "kcp", UsernamePrefix: "team:", GroupsPrefix: "team:"}
+	orgIssuer := tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://org.example.com", ClientID: "kcp", UsernamePrefix: "team:", GroupsPrefix: "team:"}
+	rootIssuer := tenancyv1alpha1.OIDCIssuer{IssuerURL: "https://root.example.com", ClientID: "kcp", UsernamePrefix: "org:team/team:", GroupsPrefix: "org:team/team:"}
+	require.NoError(t, informer.GetIndexer().Add(newConfig("root:org:team", "team", "1", teamIssuer)))
+	require.NoError(t, informer.GetIndexer().Add(newConfig("root:org", "corporate", "1", orgIssuer)))
+	require.NoError(t, informer.GetIndexer().Add(newConfig("root", "global", "1", rootIssuer)))
+
+	ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org:team")})
+	for token, want := range map[string]string{
+		"https://team.example.com/bob": "root:org:team/team:",
+		"https://org.example.com/bob":  "root:org/team:",
+		"https://root.example.com/bob": "root/org:team/team:",
+	} {
+		resp, ok, err := a.AuthenticateToken(ctx, token)
+		require.NoError(t, err)
+		require.True(t, ok, "token %q not authenticated", token)
+		require.Equal(t, want+"bob", resp.User.GetName())
+	}
+}
+
+func TestWithoutSystemIdentities(t *testing.T) {
+	_, ok := withoutSystemIdentities(&authenticator.Response{User: &user.DefaultInfo{Name: "system:admin"}})
+	require.False(t, ok, "system users should not be authenticated")
+
+	resp, ok := withoutSystemIdentities(&authenticator.Response{User: &user.DefaultInfo{Name: "team:bob", Groups: []string{"team:developers", "system:masters"}}})
+	require.True(t, ok)
+	require.Equal(t, "team:bob", resp.User.GetName())
+	require.Equal(t, []string{"team:developers"}, resp.User.GetGroups())
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package author
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// disallowedKinds are the kinds of objects which cannot be applied on behalf of an author, because they
+// hold credentials, or intercept the requests to the workspace they are applied to.
+var disallowedKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "Secret"}: true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: true,
+}
+
+// Admit records the requesting user as the author of the given object on create, or on update if the
+// authored objects changed. system:masters keep the author of objects which have one, e.g. when copying
+// objects to another workspace.
+func Admit(a admission.Attributes, obj *unstructured.Unstructured, changed bool) error {
+	isSystemMaster := sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup)
+	if _, found := obj.GetAnnotations()[tenancyv1alpha1.ExperimentalAuthorAnnotationKey]; found && isSystemMaster {
+		return nil
+	}
+	if a.GetOperation() != admission.Create && !changed {
+		return nil
+	}
+
+	value, err := AnnotationValue(a.GetUserInfo())
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[tenancyv1alpha1.ExperimentalAuthorAnnotationKey] = value
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// Validate ensures that the author of the given object is the requesting user on create, or on update if
+// the authored objects changed, and that it is not changed otherwise. system:masters can set any author.
+func Validate(a admission.Attributes, obj, old *unstructured.Unstructured, changed bool) error {
+	if sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		return nil
+	}
+
+	got := obj.GetAnnotations()[tenancyv1alpha1.ExperimentalAuthorAnnotationKey]
+	if a.GetOperation() == admission.Create || changed {
+		expected, err := AnnotationValue(a.GetUserInfo())
+		if err != nil {
+			return admission.NewForbidden(a, err)
+		}
+		if got != expected {
+			return admission.NewForbidden(a, fmt.Errorf("expected annotation %s=%s", tenancyv1alpha1.ExperimentalAuthorAnnotationKey, expected))
+		}
+		return nil
+	}
+
+	if expected := old.GetAnnotations()[tenancyv1alpha1.ExperimentalAuthorAnnotationKey]; got != expected {
+		return admission.NewForbidden(a, fmt.Errorf("annotation %s cannot be changed", tenancyv1alpha1.ExperimentalAuthorAnnotationKey))
+	}
+	return nil
+}
+
+// ValidateObjects returns an error for each object of a disallowed kind, i.e. Secrets and webhook
+// configurations.
+func ValidateObjects(objs []tenancyv1alpha1.DefaultObject, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, raw := range objs {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(raw.Raw); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Index(i), string(raw.Raw), err.Error()))
+			continue
+		}
+		if gk := obj.GroupVersionKind().GroupKind(); disallowedKinds[gk] {
+			errs = append(errs, field.Forbidden(fldPath.Index(i).Child("kind"), fmt.Sprintf("%s cannot be applied to other workspaces", gk)))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package author
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+type authorContextKeyType int
+
+const authorContextKey authorContextKeyType = iota
+
+// AnnotationValue returns the value of the ExperimentalAuthorAnnotationKey annotation for the given user.
+func AnnotationValue(u user.Info) (string, error) {
+	info := &authenticationv1.UserInfo{
+		Username: u.GetName(),
+		UID:      u.GetUID(),
+		Groups:   u.GetGroups(),
+	}
+	if len(u.GetExtra()) > 0 {
+		info.Extra = map[string]authenticationv1.ExtraValue{}
+		for k, v := range u.GetExtra() {
+			info.Extra[k] = v
+		}
+	}
+	raw, err := json.Marshal(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal user info: %w", err)
+	}
+	return string(raw), nil
+}
+
+// From returns the author recorded in the ExperimentalAuthorAnnotationKey annotation of the given object,
+// or false if there is none.
+func From(obj metav1.Object) (user.Info, bool, error) {
+	raw, found := obj.GetAnnotations()[tenancyv1alpha1.ExperimentalAuthorAnnotationKey]
+	if !found {
+		return nil, false, nil
+	}
+	var info authenticationv1.UserInfo
+	if err := json.Unmarshal([]byte(raw), &info); err != nil {
+		return nil, false, fmt.Errorf("invalid %s annotation: %w", tenancyv1alpha1.ExperimentalAuthorAnnotationKey, err)
+	}
+	if info.Username == "" {
+		return nil, false, fmt.Errorf("invalid %s annotation: missing username", tenancyv1alpha1.ExperimentalAuthorAnnotationKey)
+	}
+	u := &user.DefaultInfo{
+		Name:   info.Username,
+		UID:    info.UID,
+		Groups: info.Groups,
+	}
+	if len(info.Extra) > 0 {
+		u.Extra = map[string][]string{}
+		for k, v := range info.Extra {
+			u.Extra[k] = v
+		}
+	}
+	return u, true, nil
+}
+
+// WithAuthor returns a context whose requests impersonate the given author when made with a config
+// wrapped by WithImpersonatedAuthor.
+func WithAuthor(ctx context.Context, author user.Info) context.Context {
+	return context.WithValue(ctx, authorContextKey, author)
+}
+
+// authorFrom returns the author set with WithAuthor.
+func authorFrom(ctx context.Context) (user.Info, bool) {
+	author, ok := ctx.Value(authorContextKey).(user.Info)
+	return author, ok
+}
+
+// WithImpersonatedAuthor returns a copy of the config whose requests impersonate the author of their context,
+// as set by WithAuthor. Requests without an author are rejected, such that objects are never applied with
+// the credentials of the config by mistake.
+func WithImpersonatedAuthor(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &impersonatingTransport{delegate: rt}
+	})
+	return config
+}
+
+// impersonatingTransport adds the impersonation headers of the author of the request context.
+type impersonatingTransport struct {
+	delegate http.RoundTripper
+}
+
+func (t *impersonatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	author, ok := authorFrom(req.Context())
+	if !ok {
+		return nil, fmt.Errorf("no author found in the context of the request to %s", req.URL.Path)
+	}
+	return transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+		UserName: author.GetName(),
+		UID:      author.GetUID(),
+		Groups:   author.GetGroups(),
+		Extra:    author.GetExtra(),
+	}, t.delegate).RoundTrip(req)
+}
+
+func (t *impersonatingTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.delegate
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package author
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestAnnotationRoundTrip(t *testing.T) {
+	alice := &user.DefaultInfo{Name: "alice", UID: "1234", Groups: []string{"team-a", user.AllAuthenticated}, Extra: map[string][]string{"scopes": {"cluster:root:org"}}}
+	value, err := AnnotationValue(alice)
+	require.NoError(t, err)
+
+	got, found, err := From(&metav1.ObjectMeta{Annotations: map[string]string{tenancyv1alpha1.ExperimentalAuthorAnnotationKey: value}})
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, alice, got)
+
+	_, found, err = From(&metav1.ObjectMeta{})
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, _, err = From(&metav1.ObjectMeta{Annotations: map[string]string{tenancyv1alpha1.ExperimentalAuthorAnnotationKey: `{"groups":["system:masters"]}`}})
+	require.Error(t, err, "authors without name must be rejected")
+}
+
+func TestWithImpersonatedAuthor(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		headers = req.Header.Clone()
+	}))
+	defer server.Close()
+
+	client, err := rest.HTTPClientFor(WithImpersonatedAuthor(&rest.Config{Host: server.URL, BearerToken: "loopback"}))
+	require.NoError(t, err)
+
+	alice := &user.DefaultInfo{Name: "alice", UID: "1234", Groups: []string{"team-a", user.AllAuthenticated}, Extra: map[string][]string{"scopes": {"cluster:root:org"}}}
+	req, err := http.NewRequestWithContext(WithAuthor(context.Background(), alice), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, []string{"alice"}, headers.Values("Impersonate-User"))
+	require.Equal(t, []string{"1234"}, headers.Values("Impersonate-Uid"))
+	require.Equal(t, []string{"team-a", user.AllAuthenticated}, headers.Values("Impersonate-Group"))
+	require.Equal(t, []string{"cluster:root:org"}, headers.Values("Impersonate-Extra-Scopes"))
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.Error(t, err, "requests without author must fail")
+}
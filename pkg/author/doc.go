@@ -14,22 +14,8 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package apiexport
-
-import (
-	"fmt"
-
-	"github.com/kcp-dev/logicalcluster/v2"
-
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-)
-
-func indexByWorkspace(obj interface{}) ([]string, error) {
-	metaObj, ok := obj.(metav1.Object)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
-	}
-
-	lcluster := logicalcluster.From(metaObj)
-	return []string{lcluster.String()}, nil
-}
+// Package author records the users who author objects which kcp applies to other workspaces on
+// their behalf, e.g. the defaultObjects of ClusterWorkspaceTypes or the objects of WorkspacePolicies,
+// and impersonates these users when applying the objects. This way, the objects cannot grant more
+// than their authors could grant themselves in the target workspaces.
+package author
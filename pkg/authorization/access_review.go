@@ -0,0 +1,359 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/genericcontrolplane"
+	"k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	rbacwrapper "github.com/kcp-dev/kcp/pkg/virtual/framework/wrappers/rbac"
+)
+
+// WorkspaceAccess is the level of access a subject has to a workspace.
+type WorkspaceAccess string
+
+const (
+	// WorkspaceAccessNone means that the subject cannot access the workspace at all.
+	WorkspaceAccessNone WorkspaceAccess = "None"
+	// WorkspaceAccessAccess means that the subject can access the workspace, limited by its RBAC.
+	WorkspaceAccessAccess WorkspaceAccess = "Access"
+	// WorkspaceAccessAdmin means that the subject is admin of the workspace.
+	WorkspaceAccessAdmin WorkspaceAccess = "Admin"
+)
+
+// AccessReview is the effective access of a subject in a workspace, as enforced by the kcp authorizers.
+type AccessReview struct {
+	// workspace is the reviewed workspace.
+	Workspace string `json:"workspace"`
+	// namespace is the namespace the rules are evaluated in. Empty means cluster-wide rules only.
+	Namespace string `json:"namespace,omitempty"`
+	// user is the name of the reviewed subject.
+	User string `json:"user"`
+	// groups are the groups of the reviewed subject.
+	Groups []string `json:"groups,omitempty"`
+
+	// workspaceAccess is the access granted by the top-level organization and the workspace content
+	// authorizers, i.e. through RBAC on workspaces/content in the root and parent workspaces.
+	WorkspaceAccess WorkspaceAccess `json:"workspaceAccess"`
+	// workspaceGroups are the groups added to the subject inside of the workspace because of its workspace access.
+	WorkspaceGroups []string `json:"workspaceGroups,omitempty"`
+
+	// resourceRules are the resource rules granted to the subject in the workspace, including the bootstrap policy
+	// and active AccessGrants.
+	ResourceRules []authorizationv1.ResourceRule `json:"resourceRules,omitempty"`
+	// nonResourceRules are the non-resource rules granted to the subject in the workspace, including the bootstrap
+	// policy and active AccessGrants.
+	NonResourceRules []authorizationv1.NonResourceRule `json:"nonResourceRules,omitempty"`
+
+	// maximalPermissionPolicies lists the bound resources whose access is further limited by the maximal
+	// permission policy of their APIExport.
+	MaximalPermissionPolicies []MaximalPermissionPolicyReview `json:"maximalPermissionPolicies,omitempty"`
+
+	// incomplete is true if the rules could not be fully evaluated.
+	Incomplete bool `json:"incomplete,omitempty"`
+	// evaluationError describes why the rules could not be fully evaluated.
+	EvaluationError string `json:"evaluationError,omitempty"`
+}
+
+// MaximalPermissionPolicyReview describes the maximal permission policy limiting the resources of an APIBinding.
+type MaximalPermissionPolicyReview struct {
+	// apiBinding is the name of the APIBinding in the reviewed workspace.
+	APIBinding string `json:"apiBinding"`
+	// apiExportPath is the workspace of the APIExport.
+	APIExportPath string `json:"apiExportPath"`
+	// apiExportName is the name of the APIExport.
+	APIExportName string `json:"apiExportName"`
+	// resources are the bound resources, as <resource>.<group>.
+	Resources []string `json:"resources"`
+	// resourceRules are the rules granted by the policy. The bound resources are only accessible within
+	// these rules, whatever the RBAC in the reviewed workspace grants. Empty if the policy cannot be
+	// evaluated, e.g. because the APIExport is missing, which denies all access to the bound resources.
+	ResourceRules []authorizationv1.ResourceRule `json:"resourceRules,omitempty"`
+}
+
+// AccessReviewer reviews the effective access of subjects in workspaces, combining the top-level organization,
+// workspace content, RBAC and APIBinding authorizers.
+type AccessReviewer struct {
+	workspaceAccessAuthorizer authorizer.Authorizer
+	bootstrapRuleResolver     authorizer.RuleResolver
+	accessGrants              *accessGrantAuthorizer
+
+	versionedInformers kubernetesinformers.SharedInformerFactory
+	apiBindingIndexer  cache.Indexer
+	apiExportIndexer   cache.Indexer
+}
+
+// NewAccessReviewer returns an AccessReviewer based on the given informers.
+func NewAccessReviewer(kubeInformers kubernetesinformers.SharedInformerFactory, kcpInformers kcpinformers.SharedInformerFactory) (*AccessReviewer, error) {
+	addByLogicalClusterIndexes(kcpInformers)
+
+	accessGrants, err := newAccessGrantAuthorizer(kubeInformers, kcpInformers)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceLister := kcpInformers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+	_, bootstrapRules := NewBootstrapPolicyAuthorizer(kubeInformers)
+
+	return &AccessReviewer{
+		workspaceAccessAuthorizer: NewTeamAuthorizer(kcpInformers,
+			NewTopLevelOrganizationAccessAuthorizer(kubeInformers, workspaceLister,
+				NewWorkspaceContentAuthorizer(kubeInformers, workspaceLister,
+					subjectRecordingAuthorizer{},
+				),
+			),
+		),
+		bootstrapRuleResolver: bootstrapRules,
+		accessGrants:          accessGrants,
+
+		versionedInformers: kubeInformers,
+		apiBindingIndexer:  kcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer(),
+		apiExportIndexer:   kcpInformers.Apis().V1alpha1().APIExports().Informer().GetIndexer(),
+	}, nil
+}
+
+type recordedSubjectKey struct{}
+
+// subjectRecordingAuthorizer records the subject it is called with, i.e. the subject after the workspace
+// access authorizers have added their groups.
+type subjectRecordingAuthorizer struct{}
+
+func (subjectRecordingAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	if recorded, ok := ctx.Value(recordedSubjectKey{}).(*user.Info); ok {
+		*recorded = attr.GetUser()
+	}
+	return authorizer.DecisionAllow, "", nil
+}
+
+// Review returns the effective access of the subject in the given workspace. The rules are evaluated in
+// the given namespace, or cluster-wide if empty.
+func (r *AccessReviewer) Review(ctx context.Context, clusterName logicalcluster.Name, namespace string, subject user.Info) (*AccessReview, error) {
+	review := &AccessReview{
+		Workspace: clusterName.String(),
+		Namespace: namespace,
+		User:      subject.GetName(),
+		Groups:    subject.GetGroups(),
+	}
+
+	if sets.NewString(subject.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		// privileged subjects skip authorization completely
+		review.WorkspaceAccess = WorkspaceAccessAdmin
+		review.ResourceRules = []authorizationv1.ResourceRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}}
+		review.NonResourceRules = []authorizationv1.NonResourceRule{{Verbs: []string{"*"}, NonResourceURLs: []string{"*"}}}
+		return review, nil
+	}
+
+	// Run the workspace access authorizers in a fresh context to not leak audit annotations into the review request.
+	var effective user.Info
+	reviewCtx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: clusterName})
+	reviewCtx = context.WithValue(reviewCtx, recordedSubjectKey{}, &effective)
+	dec, _, err := r.workspaceAccessAuthorizer.Authorize(reviewCtx, authorizer.AttributesRecord{
+		User:            subject,
+		Verb:            "get",
+		Path:            "/",
+		ResourceRequest: false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dec != authorizer.DecisionAllow || effective == nil {
+		review.WorkspaceAccess = WorkspaceAccessNone
+		return review, nil
+	}
+
+	workspaceGroups := sets.NewString(effective.GetGroups()...).Difference(sets.NewString(subject.GetGroups()...))
+	review.WorkspaceGroups = workspaceGroups.List()
+	if workspaceGroups.Has(bootstrap.SystemKcpClusterWorkspaceAdminGroup) {
+		review.WorkspaceAccess = WorkspaceAccessAdmin
+	} else {
+		review.WorkspaceAccess = WorkspaceAccessAccess
+	}
+
+	var errs []error
+	resourceRules, nonResourceRules, incomplete, err := r.workspaceRuleResolver(clusterName).RulesFor(effective, namespace)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	review.Incomplete = incomplete
+	bootstrapResourceRules, bootstrapNonResourceRules, incomplete, err := r.bootstrapRuleResolver.RulesFor(effective, namespace)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	review.Incomplete = review.Incomplete || incomplete
+	grantResourceRules, grantNonResourceRules, incomplete, err := r.accessGrants.rbacFor(clusterName).RulesFor(effective, namespace)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	review.Incomplete = review.Incomplete || incomplete
+	resourceRules = append(append(resourceRules, bootstrapResourceRules...), grantResourceRules...)
+	nonResourceRules = append(append(nonResourceRules, bootstrapNonResourceRules...), grantNonResourceRules...)
+	review.ResourceRules = convertResourceRules(resourceRules)
+	review.NonResourceRules = convertNonResourceRules(nonResourceRules)
+
+	policies, err := r.maximalPermissionPolicies(clusterName, namespace, effective)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	review.MaximalPermissionPolicies = policies
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		review.Incomplete = true
+		review.EvaluationError = err.Error()
+	}
+
+	return review, nil
+}
+
+// workspaceRuleResolver returns a rule resolver for the RBAC of the given workspace, with the same
+// role lookup as the LocalAuthorizer.
+func (r *AccessReviewer) workspaceRuleResolver(clusterName logicalcluster.Name) authorizer.RuleResolver {
+	filteredInformer := rbacwrapper.FilterInformers(clusterName, r.versionedInformers.Rbac().V1())
+	bootstrapInformer := rbacwrapper.FilterInformers(genericcontrolplane.LocalAdminCluster, r.versionedInformers.Rbac().V1())
+
+	mergedClusterRoleInformer := rbacwrapper.MergedClusterRoleInformer(filteredInformer.ClusterRoles(), bootstrapInformer.ClusterRoles())
+	mergedRoleInformer := rbacwrapper.MergedRoleInformer(filteredInformer.Roles(), bootstrapInformer.Roles())
+
+	return rbac.New(
+		&rbac.RoleGetter{Lister: mergedRoleInformer.Lister()},
+		&rbac.RoleBindingLister{Lister: filteredInformer.RoleBindings().Lister()},
+		&rbac.ClusterRoleGetter{Lister: mergedClusterRoleInformer.Lister()},
+		&rbac.ClusterRoleBindingLister{Lister: filteredInformer.ClusterRoleBindings().Lister()},
+	)
+}
+
+// maximalPermissionPolicies returns the maximal permission policies limiting the bound resources in the given
+// workspace, with the rules they grant to the subject, evaluated like in the APIBinding authorizer.
+func (r *AccessReviewer) maximalPermissionPolicies(clusterName logicalcluster.Name, namespace string, subject user.Info) ([]MaximalPermissionPolicyReview, error) {
+	objs, err := r.apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+
+	prefixed := &user.DefaultInfo{
+		Name:   apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix + subject.GetName(),
+		Groups: make([]string, 0, len(subject.GetGroups())),
+	}
+	for _, g := range subject.GetGroups() {
+		prefixed.Groups = append(prefixed.Groups, apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix+g)
+	}
+
+	var policies []MaximalPermissionPolicyReview
+	var errs []error
+	for _, obj := range objs {
+		binding := obj.(*apisv1alpha1.APIBinding)
+		if binding.Status.BoundAPIExport == nil || binding.Status.BoundAPIExport.Workspace == nil || len(binding.Status.BoundResources) == 0 {
+			continue
+		}
+		exportRef := binding.Status.BoundAPIExport.Workspace
+
+		policy := MaximalPermissionPolicyReview{
+			APIBinding:    binding.Name,
+			APIExportPath: exportRef.Path,
+			APIExportName: exportRef.ExportName,
+		}
+		for _, br := range binding.Status.BoundResources {
+			policy.Resources = append(policy.Resources, fmt.Sprintf("%s.%s", br.Resource, br.Group))
+		}
+
+		exportObjs, err := r.apiExportIndexer.ByIndex(indexers.ByLogicalCluster, exportRef.Path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var export *apisv1alpha1.APIExport
+		for _, obj := range exportObjs {
+			if e := obj.(*apisv1alpha1.APIExport); e.Name == exportRef.ExportName {
+				export = e
+				break
+			}
+		}
+		switch {
+		case export == nil:
+			// a missing export denies all access, like in the APIBinding authorizer
+			policies = append(policies, policy)
+			continue
+		case export.Spec.MaximalPermissionPolicy == nil:
+			continue
+		case export.Spec.MaximalPermissionPolicy.Local == nil:
+			// an unknown policy cannot be evaluated and denies all access
+			policies = append(policies, policy)
+			continue
+		}
+
+		exportInformer := rbacwrapper.FilterInformers(logicalcluster.From(export), r.versionedInformers.Rbac().V1())
+		exportRules := rbac.New(
+			&rbac.RoleGetter{Lister: exportInformer.Roles().Lister()},
+			&rbac.RoleBindingLister{Lister: exportInformer.RoleBindings().Lister()},
+			&rbac.ClusterRoleGetter{Lister: exportInformer.ClusterRoles().Lister()},
+			&rbac.ClusterRoleBindingLister{Lister: exportInformer.ClusterRoleBindings().Lister()},
+		)
+		resourceRules, _, _, err := exportRules.RulesFor(prefixed, namespace)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to evaluate maximal permission policy of APIExport %s|%s: %w", exportRef.Path, exportRef.ExportName, err))
+		}
+		policy.ResourceRules = convertResourceRules(resourceRules)
+		policies = append(policies, policy)
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].APIBinding < policies[j].APIBinding
+	})
+
+	return policies, utilerrors.NewAggregate(errs)
+}
+
+func convertResourceRules(rules []authorizer.ResourceRuleInfo) []authorizationv1.ResourceRule {
+	var ret []authorizationv1.ResourceRule
+	for _, rule := range rules {
+		ret = append(ret, authorizationv1.ResourceRule{
+			Verbs:         rule.GetVerbs(),
+			APIGroups:     rule.GetAPIGroups(),
+			Resources:     rule.GetResources(),
+			ResourceNames: rule.GetResourceNames(),
+		})
+	}
+	return ret
+}
+
+func convertNonResourceRules(rules []authorizer.NonResourceRuleInfo) []authorizationv1.NonResourceRule {
+	var ret []authorizationv1.NonResourceRule
+	for _, rule := range rules {
+		ret = append(ret, authorizationv1.NonResourceRule{
+			Verbs:           rule.GetVerbs(),
+			NonResourceURLs: rule.GetNonResourceURLs(),
+		})
+	}
+	return ret
+}
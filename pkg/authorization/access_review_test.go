@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/controller"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+func TestAccessReview(t *testing.T) {
+	meta := func(cluster, name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+			Name:        name,
+		}
+	}
+	clusterRole := func(cluster, name string, rule v1.PolicyRule) *v1.ClusterRole {
+		return &v1.ClusterRole{ObjectMeta: meta(cluster, name), Rules: []v1.PolicyRule{rule}}
+	}
+	clusterRoleBinding := func(cluster, role, userName string) *v1.ClusterRoleBinding {
+		return &v1.ClusterRoleBinding{
+			ObjectMeta: meta(cluster, role+"-"+userName),
+			Subjects:   []v1.Subject{{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: userName}},
+			RoleRef:    v1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: role},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		subject user.Info
+		policy  *apisv1alpha1.MaximalPermissionPolicy
+
+		wantAccess   WorkspaceAccess
+		wantGroups   []string
+		wantRules    []authorizationv1.ResourceRule
+		wantPolicies []MaximalPermissionPolicyReview
+	}{
+		{
+			name:       "user without workspace access",
+			subject:    newUser("user-unknown", "system:authenticated"),
+			wantAccess: WorkspaceAccessNone,
+		},
+		{
+			name:       "user with workspace access gets local rules",
+			subject:    newUser("user-access", "system:authenticated"),
+			wantAccess: WorkspaceAccessAccess,
+			wantGroups: []string{"system:kcp:clusterworkspace:access"},
+			wantRules: []authorizationv1.ResourceRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+			},
+		},
+		{
+			name:       "workspace admin",
+			subject:    newUser("user-admin", "system:authenticated"),
+			wantAccess: WorkspaceAccessAdmin,
+			wantGroups: []string{"system:kcp:clusterworkspace:access", "system:kcp:clusterworkspace:admin"},
+		},
+		{
+			name:       "privileged user",
+			subject:    newUser("admin", user.SystemPrivilegedGroup),
+			wantAccess: WorkspaceAccessAdmin,
+			wantRules: []authorizationv1.ResourceRule{
+				{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+			},
+		},
+		{
+			name:       "bound resources limited by local maximal permission policy",
+			subject:    newUser("user-access", "system:authenticated"),
+			policy:     &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+			wantAccess: WorkspaceAccessAccess,
+			wantGroups: []string{"system:kcp:clusterworkspace:access"},
+			wantRules: []authorizationv1.ResourceRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+			},
+			wantPolicies: []MaximalPermissionPolicyReview{{
+				APIBinding:    "widgets",
+				APIExportPath: "root:provider",
+				APIExportName: "widgets",
+				Resources:     []string{"widgets.example.kcp.dev"},
+				ResourceRules: []authorizationv1.ResourceRule{
+					{Verbs: []string{"get"}, APIGroups: []string{"example.kcp.dev"}, Resources: []string{"widgets"}},
+				},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeInformers := informers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+			kcpInformers := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+
+			reviewer, err := NewAccessReviewer(kubeInformers, kcpInformers)
+			require.NoError(t, err)
+
+			roles := kubeInformers.Rbac().V1().ClusterRoles().Informer().GetIndexer()
+			bindings := kubeInformers.Rbac().V1().ClusterRoleBindings().Informer().GetIndexer()
+			require.NoError(t, roles.Add(clusterRole("root", "org-access", v1.PolicyRule{Verbs: []string{"access"}, APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces/content"}, ResourceNames: []string{"org"}})))
+			require.NoError(t, roles.Add(clusterRole("root", "org-admin", v1.PolicyRule{Verbs: []string{"access", "admin"}, APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces/content"}, ResourceNames: []string{"org"}})))
+			require.NoError(t, roles.Add(clusterRole("root:org", "configmap-reader", v1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}})))
+			require.NoError(t, roles.Add(clusterRole("root:provider", "widgets-reader", v1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"example.kcp.dev"}, Resources: []string{"widgets"}})))
+			require.NoError(t, bindings.Add(clusterRoleBinding("root", "org-access", "user-access")))
+			require.NoError(t, bindings.Add(clusterRoleBinding("root", "org-admin", "user-admin")))
+			require.NoError(t, bindings.Add(clusterRoleBinding("root:org", "configmap-reader", "user-access")))
+			require.NoError(t, bindings.Add(clusterRoleBinding("root:provider", "widgets-reader", apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix+"user-access")))
+
+			require.NoError(t, kcpInformers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().GetIndexer().Add(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: meta("root", "org"),
+				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+			}))
+			require.NoError(t, kcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer().Add(&apisv1alpha1.APIBinding{
+				ObjectMeta: meta("root:org", "widgets"),
+				Status: apisv1alpha1.APIBindingStatus{
+					BoundAPIExport: &apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"},
+					},
+					BoundResources: []apisv1alpha1.BoundAPIResource{{Group: "example.kcp.dev", Resource: "widgets"}},
+				},
+			}))
+			require.NoError(t, kcpInformers.Apis().V1alpha1().APIExports().Informer().GetIndexer().Add(&apisv1alpha1.APIExport{
+				ObjectMeta: meta("root:provider", "widgets"),
+				Spec:       apisv1alpha1.APIExportSpec{MaximalPermissionPolicy: tt.policy},
+			}))
+
+			review, err := reviewer.Review(context.Background(), logicalcluster.New("root:org"), "", tt.subject)
+			require.NoError(t, err)
+			require.Empty(t, review.EvaluationError)
+			require.Equal(t, tt.wantAccess, review.WorkspaceAccess)
+			require.Equal(t, tt.wantGroups, review.WorkspaceGroups)
+			require.Equal(t, tt.wantRules, review.ResourceRules)
+			require.Equal(t, tt.wantPolicies, review.MaximalPermissionPolicies)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/genericcontrolplane"
+	"k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	rbacwrapper "github.com/kcp-dev/kcp/pkg/virtual/framework/wrappers/rbac"
+)
+
+const (
+	AccessGrantAuditPrefix   = "accessgrant.authorization.kcp.dev/"
+	AccessGrantAuditDecision = AccessGrantAuditPrefix + "decision"
+	AccessGrantAuditReason   = AccessGrantAuditPrefix + "reason"
+)
+
+// NewAccessGrantAuthorizer returns an authorizer that evaluates the roles granted by the active
+// AccessGrants of the request workspace, as if they were role bindings existing until the grants
+// expire.
+func NewAccessGrantAuthorizer(kubeInformers kubernetesinformers.SharedInformerFactory, kcpInformers kcpinformers.SharedInformerFactory) (authorizer.Authorizer, error) {
+	return newAccessGrantAuthorizer(kubeInformers, kcpInformers)
+}
+
+func newAccessGrantAuthorizer(kubeInformers kubernetesinformers.SharedInformerFactory, kcpInformers kcpinformers.SharedInformerFactory) (*accessGrantAuthorizer, error) {
+	indexers.AddOrDie(kcpInformers.Tenancy().V1alpha1().AccessGrants().Informer().GetIndexer(), indexers.ByLogicalCluster)
+
+	return &accessGrantAuthorizer{
+		versionedInformers: kubeInformers,
+		accessGrantIndexer: kcpInformers.Tenancy().V1alpha1().AccessGrants().Informer().GetIndexer(),
+		now:                time.Now,
+	}, nil
+}
+
+type accessGrantAuthorizer struct {
+	versionedInformers kubernetesinformers.SharedInformerFactory
+	accessGrantIndexer cache.Indexer
+	now                func() time.Time
+}
+
+func (a *accessGrantAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			AccessGrantAuditDecision, DecisionNoOpinion,
+			AccessGrantAuditReason, fmt.Sprintf("error getting cluster from request: %v", err),
+		)
+		return authorizer.DecisionNoOpinion, "", err
+	}
+	if cluster == nil || cluster.Name.Empty() {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	dec, reason, err := a.rbacFor(cluster.Name).Authorize(ctx, attr)
+
+	kaudit.AddAuditAnnotations(
+		ctx,
+		AccessGrantAuditDecision, decisionString(dec),
+		AccessGrantAuditReason, fmt.Sprintf("cluster %q access grants reason: %v", cluster.Name, reason),
+	)
+
+	return dec, reason, err
+}
+
+// rbacFor returns an RBAC authorizer for the active AccessGrants in the given workspace. Roles are
+// looked up like in the LocalAuthorizer.
+func (a *accessGrantAuthorizer) rbacFor(clusterName logicalcluster.Name) *rbac.RBACAuthorizer {
+	filteredInformer := rbacwrapper.FilterInformers(clusterName, a.versionedInformers.Rbac().V1())
+	bootstrapInformer := rbacwrapper.FilterInformers(genericcontrolplane.LocalAdminCluster, a.versionedInformers.Rbac().V1())
+
+	mergedClusterRoleInformer := rbacwrapper.MergedClusterRoleInformer(filteredInformer.ClusterRoles(), bootstrapInformer.ClusterRoles())
+	mergedRoleInformer := rbacwrapper.MergedRoleInformer(filteredInformer.Roles(), bootstrapInformer.Roles())
+
+	bindings := &accessGrantBindings{authorizer: a, clusterName: clusterName}
+	return rbac.New(
+		&rbac.RoleGetter{Lister: mergedRoleInformer.Lister()},
+		bindings,
+		&rbac.ClusterRoleGetter{Lister: mergedClusterRoleInformer.Lister()},
+		bindings,
+	)
+}
+
+// activeGrants returns the AccessGrants in the given workspace which have not expired yet.
+func (a *accessGrantAuthorizer) activeGrants(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.AccessGrant, error) {
+	objs, err := a.accessGrantIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	var grants []*tenancyv1alpha1.AccessGrant
+	for _, obj := range objs {
+		grant := obj.(*tenancyv1alpha1.AccessGrant)
+		if grant.DeletionTimestamp != nil {
+			continue
+		}
+		if expiration := grant.ExpirationTime(); !now.Before(expiration.Time) {
+			continue
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+// accessGrantBindings lists the active AccessGrants of a workspace as role bindings.
+type accessGrantBindings struct {
+	authorizer  *accessGrantAuthorizer
+	clusterName logicalcluster.Name
+}
+
+func (b *accessGrantBindings) ListRoleBindings(namespace string) ([]*rbacv1.RoleBinding, error) {
+	grants, err := b.authorizer.activeGrants(b.clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	var bindings []*rbacv1.RoleBinding
+	for _, grant := range grants {
+		if grant.Spec.Namespace == "" || grant.Spec.Namespace != namespace {
+			continue
+		}
+		bindings = append(bindings, &rbacv1.RoleBinding{
+			ObjectMeta: accessGrantBindingMeta(grant),
+			Subjects:   grant.Spec.Subjects,
+			RoleRef:    grant.Spec.RoleRef,
+		})
+	}
+	return bindings, nil
+}
+
+func (b *accessGrantBindings) ListClusterRoleBindings() ([]*rbacv1.ClusterRoleBinding, error) {
+	grants, err := b.authorizer.activeGrants(b.clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	var bindings []*rbacv1.ClusterRoleBinding
+	for _, grant := range grants {
+		if grant.Spec.Namespace != "" || grant.Spec.RoleRef.Kind != "ClusterRole" {
+			continue
+		}
+		bindings = append(bindings, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: accessGrantBindingMeta(grant),
+			Subjects:   grant.Spec.Subjects,
+			RoleRef:    grant.Spec.RoleRef,
+		})
+	}
+	return bindings, nil
+}
+
+// accessGrantBindingMeta names bindings after their AccessGrant, such that RBAC reasons reference the grant.
+func accessGrantBindingMeta(grant *tenancyv1alpha1.AccessGrant) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        "accessgrant:" + grant.Name,
+		Namespace:   grant.Spec.Namespace,
+		Annotations: grant.Annotations,
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+func TestAccessGrantAuthorizer(t *testing.T) {
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	meta := func(cluster, namespace, name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+			Namespace:   namespace,
+			Name:        name,
+		}
+	}
+	grant := func(name, kind, namespace string, age time.Duration) *tenancyv1alpha1.AccessGrant {
+		g := &tenancyv1alpha1.AccessGrant{
+			ObjectMeta: meta("root:org", "", name),
+			Spec: tenancyv1alpha1.AccessGrantSpec{
+				Subjects:  []v1.Subject{{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "user-oncall"}},
+				RoleRef:   v1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: kind, Name: "configmap-editor"},
+				Namespace: namespace,
+				Duration:  metav1.Duration{Duration: time.Hour},
+				Reason:    "incident",
+			},
+		}
+		g.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		return g
+	}
+
+	tests := []struct {
+		name      string
+		grant     *tenancyv1alpha1.AccessGrant
+		namespace string
+		wantDec   authorizer.Decision
+	}{
+		{
+			name:      "no grant",
+			namespace: "default",
+			wantDec:   authorizer.DecisionNoOpinion,
+		},
+		{
+			name:      "active cluster-wide grant",
+			grant:     grant("incident", "ClusterRole", "", 30*time.Minute),
+			namespace: "default",
+			wantDec:   authorizer.DecisionAllow,
+		},
+		{
+			name:      "expired grant",
+			grant:     grant("incident", "ClusterRole", "", 2*time.Hour),
+			namespace: "default",
+			wantDec:   authorizer.DecisionNoOpinion,
+		},
+		{
+			name:      "active namespaced Role grant in its namespace",
+			grant:     grant("incident", "Role", "default", 30*time.Minute),
+			namespace: "default",
+			wantDec:   authorizer.DecisionAllow,
+		},
+		{
+			name:      "active namespaced grant in another namespace",
+			grant:     grant("incident", "ClusterRole", "default", 30*time.Minute),
+			namespace: "other",
+			wantDec:   authorizer.DecisionNoOpinion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeInformers := informers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+			kcpInformers := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+
+			authz, err := newAccessGrantAuthorizer(kubeInformers, kcpInformers)
+			require.NoError(t, err)
+			authz.now = func() time.Time { return now }
+
+			rule := v1.PolicyRule{Verbs: []string{"update"}, APIGroups: []string{""}, Resources: []string{"configmaps"}}
+			require.NoError(t, kubeInformers.Rbac().V1().ClusterRoles().Informer().GetIndexer().Add(&v1.ClusterRole{ObjectMeta: meta("root:org", "", "configmap-editor"), Rules: []v1.PolicyRule{rule}}))
+			require.NoError(t, kubeInformers.Rbac().V1().Roles().Informer().GetIndexer().Add(&v1.Role{ObjectMeta: meta("root:org", "default", "configmap-editor"), Rules: []v1.PolicyRule{rule}}))
+			if tt.grant != nil {
+				require.NoError(t, kcpInformers.Tenancy().V1alpha1().AccessGrants().Informer().GetIndexer().Add(tt.grant))
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			dec, _, err := authz.Authorize(ctx, &authorizer.AttributesRecord{
+				User:            newUser("user-oncall", "system:authenticated"),
+				Verb:            "update",
+				Resource:        "configmaps",
+				Namespace:       tt.namespace,
+				Name:            "settings",
+				ResourceRequest: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantDec, dec)
+		})
+	}
+}
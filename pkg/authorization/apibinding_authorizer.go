@@ -22,7 +22,6 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kaudit "k8s.io/apiserver/pkg/audit"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
@@ -33,13 +32,10 @@ import (
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	rbacwrapper "github.com/kcp-dev/kcp/pkg/virtual/framework/wrappers/rbac"
 )
 
-const (
-	byWorkspaceIndex = "apiBindingAuthorizer-byWorkspace"
-)
-
 const (
 	APIBindingContentAuditPrefix   = "apibinding.authorization.kcp.dev/"
 	APIBindingContentAuditDecision = APIBindingContentAuditPrefix + "decision"
@@ -51,32 +47,7 @@ const (
 // exported resources workspace. If it is not allowed we will return NoDecision, if allowed we
 // will call the delegate authorizer.
 func NewAPIBindingAccessAuthorizer(kubeInformers kubernetesinformers.SharedInformerFactory, kcpInformers kcpinformers.SharedInformerFactory, delegate authorizer.Authorizer) (authorizer.Authorizer, error) {
-	if _, found := kcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer().GetIndexers()[byWorkspaceIndex]; !found {
-		err := kcpInformers.Apis().V1alpha1().APIBindings().Informer().AddIndexers(
-			cache.Indexers{
-				byWorkspaceIndex: func(obj interface{}) ([]string, error) {
-					return []string{logicalcluster.From(obj.(metav1.Object)).String()}, nil
-				},
-			},
-		)
-		if err != nil {
-			// nothing we can do here. But this should also never happen. We check for existence before.
-			return nil, fmt.Errorf("failed to add indexer for APIBindings: %w", err)
-		}
-	}
-	if _, found := kcpInformers.Apis().V1alpha1().APIExports().Informer().GetIndexer().GetIndexers()[byWorkspaceIndex]; !found {
-		err := kcpInformers.Apis().V1alpha1().APIExports().Informer().AddIndexers(
-			cache.Indexers{
-				byWorkspaceIndex: func(obj interface{}) ([]string, error) {
-					return []string{logicalcluster.From(obj.(metav1.Object)).String()}, nil
-				},
-			},
-		)
-		if err != nil {
-			// nothing we can do here. But this should also never happen. We check for existence before.
-			return nil, fmt.Errorf("failed to add indexer for APIExports: %w", err)
-		}
-	}
+	addByLogicalClusterIndexes(kcpInformers)
 
 	// Make sure informer knows what to watch
 	kubeInformers.Rbac().V1().Roles().Lister()
@@ -92,6 +63,12 @@ func NewAPIBindingAccessAuthorizer(kubeInformers kubernetesinformers.SharedInfor
 	}, nil
 }
 
+// addByLogicalClusterIndexes adds the ByLogicalCluster index to the APIBinding and APIExport informers, if not present yet.
+func addByLogicalClusterIndexes(kcpInformers kcpinformers.SharedInformerFactory) {
+	indexers.AddOrDie(kcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(kcpInformers.Apis().V1alpha1().APIExports().Informer().GetIndexer(), indexers.ByLogicalCluster)
+}
+
 type apiBindingAccessAuthorizer struct {
 	versionedInformers kubernetesinformers.SharedInformerFactory
 	apiBindingIndexer  cache.Indexer
@@ -168,13 +145,14 @@ func (a *apiBindingAccessAuthorizer) Authorize(ctx context.Context, attr authori
 		return a.delegate.Authorize(ctx, attr)
 	}
 
+	// An unknown policy cannot be evaluated, default to close
 	if apiExport.Spec.MaximalPermissionPolicy.Local == nil {
 		kaudit.AddAuditAnnotations(
 			ctx,
-			APIBindingContentAuditDecision, DecisionAllowed,
-			APIBindingContentAuditReason, fmt.Sprintf("no maximal local permission policy present in API export %q, path: %q, owning cluster: %q", apiExport.Name, path, logicalcluster.From(apiExport)),
+			APIBindingContentAuditDecision, DecisionNoOpinion,
+			APIBindingContentAuditReason, fmt.Sprintf("unsupported maximal permission policy present in API export %q, path: %q, owning cluster: %q", apiExport.Name, path, logicalcluster.From(apiExport)),
 		)
-		return a.delegate.Authorize(ctx, attr)
+		return authorizer.DecisionNoOpinion, apiBindingAccessDenied, nil
 	}
 
 	// If bound, create a rbac authorizer filtered to the cluster.
@@ -215,9 +193,9 @@ func (a *apiBindingAccessAuthorizer) Authorize(ctx context.Context, attr authori
 	return authorizer.DecisionNoOpinion, reason, nil
 }
 
-//TODO [shawn-hurley]: this should be a helper shared.
+// TODO [shawn-hurley]: this should be a helper shared.
 func (a *apiBindingAccessAuthorizer) getAPIBindingReference(attr authorizer.Attributes, clusterName logicalcluster.Name) (*apisv1alpha1.ExportReference, bool, error) {
-	objs, err := a.apiBindingIndexer.ByIndex(byWorkspaceIndex, clusterName.String())
+	objs, err := a.apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, false, err
 	}
@@ -236,7 +214,7 @@ func (a *apiBindingAccessAuthorizer) getAPIBindingReference(attr authorizer.Attr
 }
 
 func (a *apiBindingAccessAuthorizer) getAPIExport(exportRef *apisv1alpha1.ExportReference) (*apisv1alpha1.APIExport, bool, error) {
-	objs, err := a.apiExportIndexer.ByIndex(byWorkspaceIndex, exportRef.Workspace.Path)
+	objs, err := a.apiExportIndexer.ByIndex(indexers.ByLogicalCluster, exportRef.Workspace.Path)
 	if err != nil {
 		return nil, false, err
 	}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/controller"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+func TestAPIBindingAccessAuthorizer(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		resource       string
+		policy         *apisv1alpha1.MaximalPermissionPolicy
+		noExport       bool
+		requestingUser string
+		wantDecision   authorizer.Decision
+		wantDelegated  bool
+	}{
+		{
+			name:           "unbound resource is delegated",
+			resource:       "configmaps",
+			policy:         &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+			requestingUser: "user-1",
+			wantDecision:   authorizer.DecisionAllow,
+			wantDelegated:  true,
+		},
+		{
+			name:           "bound resource without policy is delegated",
+			resource:       "widgets",
+			requestingUser: "user-1",
+			wantDecision:   authorizer.DecisionAllow,
+			wantDelegated:  true,
+		},
+		{
+			name:           "bound resource allowed by local policy is delegated",
+			resource:       "widgets",
+			policy:         &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+			requestingUser: "user-1",
+			wantDecision:   authorizer.DecisionAllow,
+			wantDelegated:  true,
+		},
+		{
+			name:           "bound resource not allowed by local policy is denied",
+			resource:       "widgets",
+			policy:         &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+			requestingUser: "user-2",
+			wantDecision:   authorizer.DecisionNoOpinion,
+		},
+		{
+			name:           "bound resource with unsupported policy is denied",
+			resource:       "widgets",
+			policy:         &apisv1alpha1.MaximalPermissionPolicy{},
+			requestingUser: "user-1",
+			wantDecision:   authorizer.DecisionNoOpinion,
+		},
+		{
+			name:           "bound resource of missing export is denied",
+			resource:       "widgets",
+			noExport:       true,
+			requestingUser: "user-1",
+			wantDecision:   authorizer.DecisionNoOpinion,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeShareInformerFactory := informers.NewSharedInformerFactory(kubeClient, controller.NoResyncPeriodFunc())
+			kubeShareInformerFactory.Start(ctx.Done())
+
+			require.NoError(t, kubeShareInformerFactory.Rbac().V1().ClusterRoles().Informer().GetIndexer().Add(
+				&v1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey: "root:provider",
+						},
+						Name: "widgets-reader",
+					},
+					Rules: []v1.PolicyRule{
+						{
+							Verbs:     []string{"get"},
+							Resources: []string{"widgets"},
+							APIGroups: []string{"example.kcp.dev"},
+						},
+					},
+				},
+			))
+			require.NoError(t, kubeShareInformerFactory.Rbac().V1().ClusterRoleBindings().Informer().GetIndexer().Add(
+				&v1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey: "root:provider",
+						},
+						Name: "widgets-reader",
+					},
+					Subjects: []v1.Subject{
+						{
+							Kind:     "User",
+							APIGroup: "rbac.authorization.k8s.io",
+							Name:     apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix + "user-1",
+						},
+					},
+					RoleRef: v1.RoleRef{
+						APIGroup: "rbac.authorization.k8s.io",
+						Kind:     "ClusterRole",
+						Name:     "widgets-reader",
+					},
+				},
+			))
+
+			apiBindingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers.Indexers(indexers.ByLogicalCluster))
+			require.NoError(t, apiBindingIndexer.Add(&apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "root:consumer",
+					},
+					Name: "widgets",
+				},
+				Status: apisv1alpha1.APIBindingStatus{
+					BoundAPIExport: &apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{
+							Path:       "root:provider",
+							ExportName: "widgets",
+						},
+					},
+					BoundResources: []apisv1alpha1.BoundAPIResource{
+						{Group: "example.kcp.dev", Resource: "widgets"},
+					},
+				},
+			}))
+			apiExportIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers.Indexers(indexers.ByLogicalCluster))
+			if !tt.noExport {
+				require.NoError(t, apiExportIndexer.Add(&apisv1alpha1.APIExport{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey: "root:provider",
+						},
+						Name: "widgets",
+					},
+					Spec: apisv1alpha1.APIExportSpec{
+						MaximalPermissionPolicy: tt.policy,
+					},
+				}))
+			}
+
+			recordingAuthorizer := &recordingAuthorizer{decision: authorizer.DecisionAllow}
+			a := &apiBindingAccessAuthorizer{
+				versionedInformers: kubeShareInformerFactory,
+				apiBindingIndexer:  apiBindingIndexer,
+				apiExportIndexer:   apiExportIndexer,
+				delegate:           recordingAuthorizer,
+			}
+
+			ctx = request.WithCluster(ctx, request.Cluster{Name: logicalcluster.New("root:consumer")})
+			attr := authorizer.AttributesRecord{
+				User:            newUser(tt.requestingUser, "system:authenticated"),
+				Verb:            "get",
+				APIGroup:        "example.kcp.dev",
+				Resource:        tt.resource,
+				ResourceRequest: true,
+			}
+			if tt.resource == "configmaps" {
+				attr.APIGroup = ""
+			}
+
+			gotDecision, _, err := a.Authorize(ctx, attr)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantDecision, gotDecision)
+			require.Equal(t, tt.wantDelegated, recordingAuthorizer.recordedAttributes != nil)
+			if tt.wantDelegated {
+				require.Equal(t, tt.requestingUser, recordingAuthorizer.recordedAttributes.GetUser().GetName(), "delegate must see the unprefixed user")
+			}
+		})
+	}
+}
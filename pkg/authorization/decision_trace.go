@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// DecisionTracesPath is the path prefix of the debug endpoint serving the decision trace of a
+// request by its audit ID, i.e. /debug/authorization/traces/<audit-id>.
+const DecisionTracesPath = "/debug/authorization/traces/"
+
+// DecisionTrace is the sequence of decisions of the authorizer chain for one request.
+type DecisionTrace struct {
+	AuditID string    `json:"auditID"`
+	Time    time.Time `json:"time"`
+
+	// Authorizations are the authorization checks of the request, e.g. for impersonation
+	// and for the request itself.
+	Authorizations []TracedAuthorization `json:"authorizations"`
+}
+
+// TracedAuthorization is an authorization check of a request.
+type TracedAuthorization struct {
+	User            string   `json:"user"`
+	Groups          []string `json:"groups,omitempty"`
+	Cluster         string   `json:"cluster,omitempty"`
+	Verb            string   `json:"verb"`
+	APIGroup        string   `json:"apiGroup,omitempty"`
+	Resource        string   `json:"resource,omitempty"`
+	Subresource     string   `json:"subresource,omitempty"`
+	Namespace       string   `json:"namespace,omitempty"`
+	Name            string   `json:"name,omitempty"`
+	Path            string   `json:"path,omitempty"`
+	ResourceRequest bool     `json:"resourceRequest"`
+
+	// Decisions are the decisions of the authorizers in the order they were called. Depth is
+	// the nesting of an authorizer below the outermost authorizer of the chain.
+	Decisions []TracedDecision `json:"decisions"`
+}
+
+// TracedDecision is the decision of one authorizer of the chain.
+type TracedDecision struct {
+	Authorizer string `json:"authorizer"`
+	Depth      int    `json:"depth"`
+	Decision   string `json:"decision"`
+	Reason     string `json:"reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DecisionTracer keeps the authorizer decisions of the most recent requests. A nil tracer
+// records nothing.
+type DecisionTracer struct {
+	capacity int
+
+	lock   sync.Mutex
+	traces map[types.UID]*DecisionTrace
+	// order holds the audit IDs of the traces, oldest first.
+	order []types.UID
+}
+
+// NewDecisionTracer returns a tracer keeping the decisions of the given number of most recent
+// requests, or nil if capacity is not positive.
+func NewDecisionTracer(capacity int) *DecisionTracer {
+	if capacity <= 0 {
+		return nil
+	}
+	return &DecisionTracer{
+		capacity: capacity,
+		traces:   make(map[types.UID]*DecisionTrace, capacity),
+	}
+}
+
+// Trace wraps the authorizer such that its decisions are recorded under the given name. If the
+// tracer is nil, the authorizer is returned as is.
+func (t *DecisionTracer) Trace(name string, delegate authorizer.Authorizer) authorizer.Authorizer {
+	if t == nil {
+		return delegate
+	}
+	return &tracingAuthorizer{name: name, tracer: t, delegate: delegate}
+}
+
+// Get returns a copy of the decision trace of the request with the given audit ID.
+func (t *DecisionTracer) Get(auditID types.UID) (*DecisionTrace, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	trace, found := t.traces[auditID]
+	if !found {
+		return nil, false
+	}
+	copied := *trace
+	copied.Authorizations = make([]TracedAuthorization, len(trace.Authorizations))
+	for i, a := range trace.Authorizations {
+		copied.Authorizations[i] = a
+		copied.Authorizations[i].Decisions = append([]TracedDecision(nil), a.Decisions...)
+	}
+	return &copied, true
+}
+
+// ServeHTTP serves the decision trace of the request whose audit ID is the last path segment.
+func (t *DecisionTracer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	auditID := strings.TrimPrefix(req.URL.Path, DecisionTracesPath)
+	if auditID == "" || strings.Contains(auditID, "/") {
+		http.Error(w, "expected "+DecisionTracesPath+"<audit-id>", http.StatusBadRequest)
+		return
+	}
+	trace, found := t.Get(types.UID(auditID))
+	if !found {
+		http.Error(w, "no authorization decisions recorded for audit ID "+auditID, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trace) // nolint: errcheck
+}
+
+// begin records a new authorization check of the request, and returns its index.
+func (t *DecisionTracer) begin(ctx context.Context, auditID types.UID, attr authorizer.Attributes) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	trace, found := t.traces[auditID]
+	if !found {
+		if len(t.order) >= t.capacity {
+			delete(t.traces, t.order[0])
+			t.order = t.order[1:]
+		}
+		trace = &DecisionTrace{AuditID: string(auditID), Time: time.Now()}
+		t.traces[auditID] = trace
+		t.order = append(t.order, auditID)
+	}
+
+	a := TracedAuthorization{
+		Verb:            attr.GetVerb(),
+		APIGroup:        attr.GetAPIGroup(),
+		Resource:        attr.GetResource(),
+		Subresource:     attr.GetSubresource(),
+		Namespace:       attr.GetNamespace(),
+		Name:            attr.GetName(),
+		Path:            attr.GetPath(),
+		ResourceRequest: attr.IsResourceRequest(),
+	}
+	if u := attr.GetUser(); u != nil {
+		a.User = u.GetName()
+		a.Groups = u.GetGroups()
+	}
+	if cluster := genericapirequest.ClusterFrom(ctx); cluster != nil {
+		a.Cluster = cluster.Name.String()
+	}
+	trace.Authorizations = append(trace.Authorizations, a)
+	return len(trace.Authorizations) - 1
+}
+
+// reserve adds a placeholder for the decision of an authorizer, such that decisions are
+// ordered by the calls of the authorizers, not by their returns.
+func (t *DecisionTracer) reserve(auditID types.UID, authorization int, d TracedDecision) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	trace, found := t.traces[auditID]
+	if !found || authorization >= len(trace.Authorizations) {
+		// evicted meanwhile
+		return -1
+	}
+	a := &trace.Authorizations[authorization]
+	a.Decisions = append(a.Decisions, d)
+	return len(a.Decisions) - 1
+}
+
+func (t *DecisionTracer) finish(auditID types.UID, authorization, index int, decision authorizer.Decision, reason string, err error) {
+	if index < 0 {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	trace, found := t.traces[auditID]
+	if !found || authorization >= len(trace.Authorizations) {
+		return
+	}
+	d := &trace.Authorizations[authorization].Decisions[index]
+	d.Decision = decisionString(decision)
+	d.Reason = reason
+	if err != nil {
+		d.Error = err.Error()
+	}
+}
+
+type traceContextKeyType int
+
+const traceContextKey traceContextKeyType = iota
+
+// traceContext is the position of an authorizer call within the trace of a request.
+type traceContext struct {
+	authorization int
+	depth         int
+}
+
+type tracingAuthorizer struct {
+	name     string
+	tracer   *DecisionTracer
+	delegate authorizer.Authorizer
+}
+
+func (a *tracingAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	auditID, ok := genericapirequest.AuditIDFrom(ctx)
+	if !ok || auditID == "" {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	tc, nested := ctx.Value(traceContextKey).(traceContext)
+	if nested {
+		tc.depth++
+	} else {
+		tc = traceContext{authorization: a.tracer.begin(ctx, auditID, attr)}
+	}
+
+	index := a.tracer.reserve(auditID, tc.authorization, TracedDecision{Authorizer: a.name, Depth: tc.depth})
+	dec, reason, err := a.delegate.Authorize(context.WithValue(ctx, traceContextKey, tc), attr)
+	a.tracer.finish(auditID, tc.authorization, index, dec, reason, err)
+
+	return dec, reason, err
+}
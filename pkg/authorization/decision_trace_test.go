@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/authorization/union"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type delegatingAuthorizer struct {
+	decision authorizer.Decision
+	reason   string
+	err      error
+	delegate authorizer.Authorizer
+}
+
+func (a *delegatingAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	if a.delegate != nil {
+		if dec, _, _ := a.delegate.Authorize(ctx, attr); dec != authorizer.DecisionAllow {
+			return authorizer.DecisionNoOpinion, a.reason, a.err
+		}
+	}
+	return a.decision, a.reason, a.err
+}
+
+func TestDecisionTracer(t *testing.T) {
+	require.Nil(t, NewDecisionTracer(0))
+	var nilTracer *DecisionTracer
+	a := &delegatingAuthorizer{decision: authorizer.DecisionAllow}
+	require.Same(t, a, nilTracer.Trace("a", a))
+
+	tracer := NewDecisionTracer(2)
+	chain := tracer.Trace("chain", union.New(
+		tracer.Trace("privileged", &delegatingAuthorizer{decision: authorizer.DecisionNoOpinion}),
+		tracer.Trace("content", &delegatingAuthorizer{
+			decision: authorizer.DecisionAllow,
+			reason:   "content denied",
+			delegate: tracer.Trace("rbac", &delegatingAuthorizer{decision: authorizer.DecisionNoOpinion, reason: "no rule", err: errors.New("boom")}),
+		}),
+	))
+
+	attr := authorizer.AttributesRecord{
+		User:            &user.DefaultInfo{Name: "alice", Groups: []string{"team"}},
+		Verb:            "get",
+		APIGroup:        "apps",
+		Resource:        "deployments",
+		Namespace:       "default",
+		Name:            "web",
+		ResourceRequest: true,
+	}
+	authorize := func(auditID string) {
+		ctx := genericapirequest.WithAuditID(context.Background(), types.UID(auditID))
+		ctx = genericapirequest.WithCluster(ctx, genericapirequest.Cluster{Name: logicalcluster.New("root:org")})
+		dec, reason, err := chain.Authorize(ctx, attr)
+		require.Equal(t, authorizer.DecisionNoOpinion, dec)
+		require.Equal(t, "content denied", reason)
+		require.NoError(t, err)
+	}
+
+	authorize("")
+	authorize("one")
+	trace, found := tracer.Get("one")
+	require.True(t, found)
+	require.Len(t, trace.Authorizations, 1)
+	require.Equal(t, "alice", trace.Authorizations[0].User)
+	require.Equal(t, "root:org", trace.Authorizations[0].Cluster)
+	require.Equal(t, "deployments", trace.Authorizations[0].Resource)
+	require.Equal(t, []TracedDecision{
+		{Authorizer: "chain", Depth: 0, Decision: DecisionNoOpinion, Reason: "content denied"},
+		{Authorizer: "privileged", Depth: 1, Decision: DecisionNoOpinion},
+		{Authorizer: "content", Depth: 1, Decision: DecisionNoOpinion, Reason: "content denied"},
+		{Authorizer: "rbac", Depth: 2, Decision: DecisionNoOpinion, Reason: "no rule", Error: "boom"},
+	}, trace.Authorizations[0].Decisions)
+
+	authorize("one")
+	trace, _ = tracer.Get("one")
+	require.Len(t, trace.Authorizations, 2, "repeated authorizations of a request are appended")
+
+	authorize("two")
+	authorize("three")
+	_, found = tracer.Get("one")
+	require.False(t, found, "oldest trace is evicted")
+	_, found = tracer.Get("three")
+	require.True(t, found)
+
+	rec := httptest.NewRecorder()
+	tracer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DecisionTracesPath+"two", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var served DecisionTrace
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	require.Equal(t, "two", served.AuditID)
+
+	rec = httptest.NewRecorder()
+	tracer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DecisionTracesPath+"one", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
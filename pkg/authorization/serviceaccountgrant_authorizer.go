@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	kaudit "k8s.io/apiserver/pkg/audit"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+const (
+	ServiceAccountGrantAuditPrefix   = "serviceaccountgrant.authorization.kcp.dev/"
+	ServiceAccountGrantAuditDecision = ServiceAccountGrantAuditPrefix + "decision"
+	ServiceAccountGrantAuditReason   = ServiceAccountGrantAuditPrefix + "reason"
+
+	// CrossWorkspaceServiceAccountUsernamePrefix prefixes the usernames of service accounts authorized
+	// in another workspace through a ServiceAccountGrant. It is followed by
+	// "<workspace>:<namespace>:<name>" of the service account.
+	CrossWorkspaceServiceAccountUsernamePrefix = "system:kcp:serviceaccount:"
+)
+
+// NewServiceAccountGrantAuthorizer returns an authorizer that authorizes service accounts in other
+// workspaces than their own if a ServiceAccountGrant in the request workspace grants them access.
+// Granted service accounts are authorized by contentDelegate, which must be the workspace content
+// authorizer, as CrossWorkspaceServiceAccountUsernamePrefix followed by their workspace, namespace
+// and name. Every other request is authorized by delegate.
+func NewServiceAccountGrantAuthorizer(kcpInformers kcpinformers.SharedInformerFactory, delegate, contentDelegate authorizer.Authorizer) authorizer.Authorizer {
+	indexers.AddOrDie(kcpInformers.Tenancy().V1alpha1().ServiceAccountGrants().Informer().GetIndexer(), indexers.ByLogicalCluster)
+
+	return &serviceAccountGrantAuthorizer{
+		grantIndexer:    kcpInformers.Tenancy().V1alpha1().ServiceAccountGrants().Informer().GetIndexer(),
+		delegate:        delegate,
+		contentDelegate: contentDelegate,
+	}
+}
+
+type serviceAccountGrantAuthorizer struct {
+	grantIndexer cache.Indexer
+
+	delegate        authorizer.Authorizer
+	contentDelegate authorizer.Authorizer
+}
+
+type grantedServiceAccountKeyType int
+
+const grantedServiceAccountKey grantedServiceAccountKeyType = iota
+
+// withGrantedServiceAccount marks the request as made by a service account of another workspace, which
+// is granted access by a ServiceAccountGrant.
+func withGrantedServiceAccount(ctx context.Context) context.Context {
+	return context.WithValue(ctx, grantedServiceAccountKey, true)
+}
+
+// isGrantedServiceAccount returns whether the request is made by a service account of another workspace,
+// which is granted access by a ServiceAccountGrant.
+func isGrantedServiceAccount(ctx context.Context) bool {
+	granted, _ := ctx.Value(grantedServiceAccountKey).(bool)
+	return granted
+}
+
+// serviceAccount identifies a service account across workspaces.
+type serviceAccount struct {
+	clusterName logicalcluster.Name
+	namespace   string
+	name        string
+}
+
+func (a *serviceAccountGrantAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	cluster := genericapirequest.ClusterFrom(ctx)
+	if cluster == nil || cluster.Name.Empty() || cluster.Wildcard {
+		return a.delegate.Authorize(ctx, attr)
+	}
+	sa, ok := foreignServiceAccount(attr.GetUser(), cluster.Name)
+	if !ok {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	granted, err := a.granted(cluster.Name, sa)
+	if err != nil {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			ServiceAccountGrantAuditDecision, DecisionNoOpinion,
+			ServiceAccountGrantAuditReason, fmt.Sprintf("error getting service account grants: %v", err),
+		)
+		return authorizer.DecisionNoOpinion, WorkspaceAcccessNotPermittedReason, err
+	}
+	if !granted {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			ServiceAccountGrantAuditDecision, DecisionNoOpinion,
+			ServiceAccountGrantAuditReason, fmt.Sprintf("service account of workspace %q not granted access", sa.clusterName),
+		)
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	granteeAttr := deepCopyAttributes(attr)
+	granteeAttr.User = &user.DefaultInfo{
+		Name:   CrossWorkspaceServiceAccountUsername(sa.clusterName, sa.namespace, sa.name),
+		UID:    attr.GetUser().GetUID(),
+		Groups: []string{user.AllAuthenticated},
+		Extra:  attr.GetUser().GetExtra(),
+	}
+
+	kaudit.AddAuditAnnotations(
+		ctx,
+		ServiceAccountGrantAuditDecision, DecisionAllowed,
+		ServiceAccountGrantAuditReason, fmt.Sprintf("service account granted access as %q", granteeAttr.User.GetName()),
+	)
+
+	// the workspace content authorizer grants workspace access, unless the workspace is not ready or read-only
+	return a.contentDelegate.Authorize(withGrantedServiceAccount(ctx), granteeAttr)
+}
+
+// granted returns whether a ServiceAccountGrant in the given workspace grants access to the service account.
+func (a *serviceAccountGrantAuthorizer) granted(clusterName logicalcluster.Name, sa serviceAccount) (bool, error) {
+	grants, err := indexers.ByIndex[*tenancyv1alpha1.ServiceAccountGrant](a.grantIndexer, indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return false, err
+	}
+	for _, grant := range grants {
+		if grant.DeletionTimestamp != nil {
+			continue
+		}
+		for _, ref := range grant.Spec.ServiceAccounts {
+			if logicalcluster.New(ref.Path) == sa.clusterName && ref.Namespace == sa.namespace && (ref.Name == "" || ref.Name == sa.name) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// foreignServiceAccount returns the service account of the user if it is declared in another
+// workspace than the given one.
+func foreignServiceAccount(u user.Info, clusterName logicalcluster.Name) (serviceAccount, bool) {
+	subjectClusters := u.GetExtra()[authserviceaccount.ClusterNameKey]
+	if len(subjectClusters) != 1 || logicalcluster.New(subjectClusters[0]) == clusterName {
+		return serviceAccount{}, false
+	}
+	namespace, name, err := authserviceaccount.SplitUsername(u.GetName())
+	if err != nil {
+		return serviceAccount{}, false
+	}
+	return serviceAccount{
+		clusterName: logicalcluster.New(subjectClusters[0]),
+		namespace:   namespace,
+		name:        name,
+	}, true
+}
+
+// CrossWorkspaceServiceAccountUsername returns the username a service account is authorized as in
+// other workspaces than its own.
+func CrossWorkspaceServiceAccountUsername(clusterName logicalcluster.Name, namespace, name string) string {
+	return CrossWorkspaceServiceAccountUsernamePrefix + clusterName.String() + ":" + namespace + ":" + name
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+func TestServiceAccountGrantAuthorizer(t *testing.T) {
+	serviceAccount := func(cluster, namespace, name string) user.Info {
+		return &user.DefaultInfo{
+			Name:   authserviceaccount.MakeUsername(namespace, name),
+			Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"},
+			Extra:  map[string][]string{authserviceaccount.ClusterNameKey: {cluster}},
+		}
+	}
+	grant := &tenancyv1alpha1.ServiceAccountGrant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ci",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:target"},
+		},
+		Spec: tenancyv1alpha1.ServiceAccountGrantSpec{
+			ServiceAccounts: []tenancyv1alpha1.ServiceAccountReference{
+				{Path: "root:org:ci", Namespace: "default", Name: "builder"},
+				{Path: "root:other", Namespace: "deployers"},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		cluster       string
+		user          user.Info
+		wantDelegated string
+		wantUser      string
+	}{
+		"local service account is delegated": {
+			cluster:       "root:org:target",
+			user:          serviceAccount("root:org:target", "default", "builder"),
+			wantDelegated: "delegate",
+			wantUser:      "system:serviceaccount:default:builder",
+		},
+		"user is delegated": {
+			cluster:       "root:org:target",
+			user:          &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+			wantDelegated: "delegate",
+			wantUser:      "alice",
+		},
+		"service account without grant is delegated": {
+			cluster:       "root:org:target",
+			user:          serviceAccount("root:org:ci", "default", "other"),
+			wantDelegated: "delegate",
+			wantUser:      "system:serviceaccount:default:other",
+		},
+		"service account of other workspace with the same name is delegated": {
+			cluster:       "root:org:target",
+			user:          serviceAccount("root:org:elsewhere", "default", "builder"),
+			wantDelegated: "delegate",
+			wantUser:      "system:serviceaccount:default:builder",
+		},
+		"granted service account is authorized with its workspace in the username": {
+			cluster:       "root:org:target",
+			user:          serviceAccount("root:org:ci", "default", "builder"),
+			wantDelegated: "content",
+			wantUser:      "system:kcp:serviceaccount:root:org:ci:default:builder",
+		},
+		"service accounts of granted namespaces are authorized": {
+			cluster:       "root:org:target",
+			user:          serviceAccount("root:other", "deployers", "any"),
+			wantDelegated: "content",
+			wantUser:      "system:kcp:serviceaccount:root:other:deployers:any",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kcpInformers := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+			var delegated string
+			var delegatedUser user.Info
+			var delegatedGranted bool
+			recorder := func(name string) authorizer.Authorizer {
+				return authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+					delegated, delegatedUser, delegatedGranted = name, attr.GetUser(), isGrantedServiceAccount(ctx)
+					return authorizer.DecisionAllow, "", nil
+				})
+			}
+			authz := NewServiceAccountGrantAuthorizer(kcpInformers, recorder("delegate"), recorder("content"))
+
+			require.NoError(t, kcpInformers.Tenancy().V1alpha1().ServiceAccountGrants().Informer().GetIndexer().Add(grant))
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New(tc.cluster)})
+			dec, _, err := authz.Authorize(ctx, &authorizer.AttributesRecord{
+				User:            tc.user,
+				Verb:            "get",
+				Resource:        "configmaps",
+				Namespace:       "default",
+				Name:            "settings",
+				ResourceRequest: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantDelegated, delegated)
+			require.Equal(t, authorizer.DecisionAllow, dec)
+			require.Equal(t, tc.wantUser, delegatedUser.GetName())
+			require.Equal(t, tc.wantDelegated == "content", delegatedGranted, "only granted service accounts must be marked as granted")
+			if tc.wantDelegated == "content" {
+				require.Equal(t, []string{"system:authenticated"}, delegatedUser.GetGroups(), "workspace access must be added by the workspace content authorizer")
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	kaudit "k8s.io/apiserver/pkg/audit"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+const (
+	TeamAuditPrefix = "team.authorization.kcp.dev/"
+	TeamAuditGroups = TeamAuditPrefix + "groups"
+	TeamAuditReason = TeamAuditPrefix + "reason"
+
+	// TeamGroupPrefix prefixes the groups users are authorized with as members of a Team. It is
+	// followed by the name of the team.
+	TeamGroupPrefix = "system:kcp:team:"
+)
+
+// NewTeamAuthorizer returns an authorizer that adds the groups of the Teams a user is member of to
+// the user, and calls delegate. Teams are looked up in the organization workspace the request
+// workspace is nested in. Groups with TeamGroupPrefix that the user brings along are removed, such
+// that team membership cannot be claimed by an authenticator.
+func NewTeamAuthorizer(kcpInformers kcpinformers.SharedInformerFactory, delegate authorizer.Authorizer) authorizer.Authorizer {
+	indexers.AddOrDie(kcpInformers.Tenancy().V1alpha1().Teams().Informer().GetIndexer(), indexers.ByLogicalCluster)
+
+	return &teamAuthorizer{
+		teamIndexer: kcpInformers.Tenancy().V1alpha1().Teams().Informer().GetIndexer(),
+		delegate:    delegate,
+	}
+}
+
+type teamAuthorizer struct {
+	teamIndexer cache.Indexer
+	delegate    authorizer.Authorizer
+}
+
+func (a *teamAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	u := attr.GetUser()
+	if u == nil {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	groups := make([]string, 0, len(u.GetGroups()))
+	for _, g := range u.GetGroups() {
+		if !strings.HasPrefix(g, TeamGroupPrefix) {
+			groups = append(groups, g)
+		}
+	}
+
+	var teamGroups []string
+	cluster := genericapirequest.ClusterFrom(ctx)
+	if _, isServiceAccount := u.GetExtra()[authserviceaccount.ClusterNameKey]; !isServiceAccount && cluster != nil && !cluster.Wildcard {
+		if org, ok := topLevelOrg(cluster.Name); ok {
+			var err error
+			teamGroups, err = a.teamGroups(tenancyv1alpha1.RootCluster.Join(org), u.GetName(), groups)
+			if err != nil {
+				kaudit.AddAuditAnnotation(ctx, TeamAuditReason, fmt.Sprintf("error getting teams: %v", err))
+				return authorizer.DecisionNoOpinion, WorkspaceAcccessNotPermittedReason, err
+			}
+		}
+	}
+
+	if len(teamGroups) == 0 && len(groups) == len(u.GetGroups()) {
+		return a.delegate.Authorize(ctx, attr)
+	}
+	if len(teamGroups) > 0 {
+		kaudit.AddAuditAnnotation(ctx, TeamAuditGroups, strings.Join(teamGroups, ","))
+	}
+
+	teamAttr := deepCopyAttributes(attr)
+	teamAttr.User = &user.DefaultInfo{
+		Name:   u.GetName(),
+		UID:    u.GetUID(),
+		Groups: append(groups, teamGroups...),
+		Extra:  u.GetExtra(),
+	}
+	return a.delegate.Authorize(ctx, teamAttr)
+}
+
+// teamGroups returns the sorted groups of the teams in the given organization workspace that have
+// the given user or one of the given groups as members.
+func (a *teamAuthorizer) teamGroups(org logicalcluster.Name, userName string, groups []string) ([]string, error) {
+	teams, err := indexers.ByIndex[*tenancyv1alpha1.Team](a.teamIndexer, indexers.ByLogicalCluster, org.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var teamGroups []string
+	for _, team := range teams {
+		if team.DeletionTimestamp != nil {
+			continue
+		}
+		if isTeamMember(team, userName, groups) {
+			teamGroups = append(teamGroups, TeamGroupPrefix+team.Name)
+		}
+	}
+	sort.Strings(teamGroups)
+	return teamGroups, nil
+}
+
+func isTeamMember(team *tenancyv1alpha1.Team, userName string, groups []string) bool {
+	for _, u := range team.Spec.Users {
+		if u == userName {
+			return true
+		}
+	}
+	for _, tg := range team.Spec.Groups {
+		for _, g := range groups {
+			if tg == g {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+func TestTeamAuthorizer(t *testing.T) {
+	team := func(cluster, name string, spec tenancyv1alpha1.TeamSpec) *tenancyv1alpha1.Team {
+		return &tenancyv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+			},
+			Spec: spec,
+		}
+	}
+	teams := []*tenancyv1alpha1.Team{
+		team("root:org", "developers", tenancyv1alpha1.TeamSpec{Users: []string{"alice"}, Groups: []string{"oidc:devs"}}),
+		team("root:org", "operators", tenancyv1alpha1.TeamSpec{Users: []string{"alice", "bob"}}),
+		team("root:other", "developers", tenancyv1alpha1.TeamSpec{Users: []string{"bob"}}),
+		team("root:org:team", "nested", tenancyv1alpha1.TeamSpec{Users: []string{"alice"}}),
+	}
+
+	tests := map[string]struct {
+		cluster    string
+		user       user.Info
+		wantGroups []string
+	}{
+		"user gets the groups of its teams in the organization": {
+			cluster:    "root:org",
+			user:       &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+			wantGroups: []string{"system:authenticated", "system:kcp:team:developers", "system:kcp:team:operators"},
+		},
+		"teams apply to descendant workspaces, but teams in non-organization workspaces are ignored": {
+			cluster:    "root:org:team:deep",
+			user:       &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+			wantGroups: []string{"system:authenticated", "system:kcp:team:developers", "system:kcp:team:operators"},
+		},
+		"group members are team members": {
+			cluster:    "root:org:ws",
+			user:       &user.DefaultInfo{Name: "carol", Groups: []string{"oidc:devs", "system:authenticated"}},
+			wantGroups: []string{"oidc:devs", "system:authenticated", "system:kcp:team:developers"},
+		},
+		"teams of other organizations do not apply": {
+			cluster:    "root:other:ws",
+			user:       &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+			wantGroups: []string{"system:authenticated"},
+		},
+		"teams do not apply in root": {
+			cluster:    "root",
+			user:       &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+			wantGroups: []string{"system:authenticated"},
+		},
+		"team groups of the user are removed": {
+			cluster:    "root:org",
+			user:       &user.DefaultInfo{Name: "bob", Groups: []string{"system:authenticated", "system:kcp:team:developers"}},
+			wantGroups: []string{"system:authenticated", "system:kcp:team:operators"},
+		},
+		"teams do not apply to service accounts": {
+			cluster: "root:org",
+			user: &user.DefaultInfo{
+				Name:   "alice",
+				Groups: []string{"system:serviceaccounts", "system:authenticated"},
+				Extra:  map[string][]string{authserviceaccount.ClusterNameKey: {"root:org"}},
+			},
+			wantGroups: []string{"system:serviceaccounts", "system:authenticated"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kcpInformers := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+			var delegatedUser user.Info
+			authz := NewTeamAuthorizer(kcpInformers, authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+				delegatedUser = attr.GetUser()
+				return authorizer.DecisionAllow, "", nil
+			}))
+			for _, team := range teams {
+				require.NoError(t, kcpInformers.Tenancy().V1alpha1().Teams().Informer().GetIndexer().Add(team))
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New(tc.cluster)})
+			dec, _, err := authz.Authorize(ctx, &authorizer.AttributesRecord{
+				User:            tc.user,
+				Verb:            "get",
+				Resource:        "configmaps",
+				Namespace:       "default",
+				Name:            "settings",
+				ResourceRequest: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, authorizer.DecisionAllow, dec)
+			require.Equal(t, tc.user.GetName(), delegatedUser.GetName())
+			require.Equal(t, tc.wantGroups, delegatedUser.GetGroups())
+		})
+	}
+}
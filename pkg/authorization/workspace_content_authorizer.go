@@ -67,6 +67,9 @@ func NewWorkspaceContentAuthorizer(versionedInformers kubernetesinformers.Shared
 	}
 }
 
+// readOnlyVerbs are the verbs permitted in read-only workspaces.
+var readOnlyVerbs = sets.NewString("get", "list", "watch")
+
 type workspaceContentAuthorizer struct {
 	roleLister               rbaclisters.RoleLister
 	roleBindingLister        rbaclisters.RoleBindingLister
@@ -110,11 +113,12 @@ func (a *workspaceContentAuthorizer) Authorize(ctx context.Context, attr authori
 	isUser := len(subjectClusters) == 0
 	isServiceAccountFromRootCluster := subjectClusters[tenancyv1alpha1.RootCluster]
 	isServiceAccountFromCluster := subjectClusters[cluster.Name]
+	isGrantedServiceAccount := isGrantedServiceAccount(ctx)
 
 	if IsDeepSubjectAccessReviewFrom(ctx, attr) {
 		attr := deepCopyAttributes(attr)
 		// this is a deep SAR request, we have to skip the checks here and delegate to the subsequent authorizer.
-		if isAuthenticated && !isUser && !isServiceAccountFromCluster {
+		if isAuthenticated && !isUser && !isServiceAccountFromCluster && !isGrantedServiceAccount {
 			// service accounts from other workspaces might conflict with local service accounts by name.
 			// This could lead to unwanted side effects of unwanted applied permissions.
 			// Hence, these requests have to be anonymized.
@@ -134,7 +138,7 @@ func (a *workspaceContentAuthorizer) Authorize(ctx context.Context, attr authori
 	// Every authenticated user has access to the root workspace but not every service account.
 	// For root, only service accounts declared in root have access.
 	if cluster.Name == tenancyv1alpha1.RootCluster {
-		if isAuthenticated && (isUser || isServiceAccountFromRootCluster) {
+		if isAuthenticated && (isUser || isServiceAccountFromRootCluster || isGrantedServiceAccount) {
 			withGroups := deepCopyAttributes(attr)
 			withGroups.User.(*user.DefaultInfo).Groups = append(attr.GetUser().GetGroups(), bootstrap.SystemKcpClusterWorkspaceAccessGroup)
 
@@ -210,6 +214,17 @@ func (a *workspaceContentAuthorizer) Authorize(ctx context.Context, attr authori
 		return authorizer.DecisionNoOpinion, WorkspaceAcccessNotPermittedReason, nil
 	}
 
+	// read-only workspaces, e.g. while being migrated to another shard, only serve reads. Privileged
+	// subjects are allowed by an earlier authorizer.
+	if ws.Spec.ReadOnly && !readOnlyVerbs.Has(attr.GetVerb()) {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			WorkspaceContentAuditDecision, DecisionNoOpinion,
+			WorkspaceContentAuditReason, fmt.Sprintf("not permitted, clusterworkspace is read-only for verb %q", attr.GetVerb()),
+		)
+		return authorizer.DecisionNoOpinion, WorkspaceAcccessNotPermittedReason, nil
+	}
+
 	switch {
 	case isServiceAccountFromCluster:
 		// A service account declared in the requested workspace is authorized inside that workspace.
@@ -217,6 +232,11 @@ func (a *workspaceContentAuthorizer) Authorize(ctx context.Context, attr authori
 		// hence authorization against "admin" or "access" verbs in the parent is not possible either.
 		extraGroups.Insert(bootstrap.SystemKcpClusterWorkspaceAccessGroup)
 
+	case isGrantedServiceAccount:
+		// A service account of another workspace, granted access by a ServiceAccountGrant in the requested
+		// workspace. See NewServiceAccountGrantAuthorizer.
+		extraGroups.Insert(bootstrap.SystemKcpClusterWorkspaceAccessGroup)
+
 	case isUser:
 		verbToGroupMembership := map[string][]string{
 			"admin":  {bootstrap.SystemKcpClusterWorkspaceAccessGroup, bootstrap.SystemKcpClusterWorkspaceAdminGroup},
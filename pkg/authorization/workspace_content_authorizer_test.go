@@ -88,6 +88,8 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 		wantDecision          authorizer.Decision
 		wantUser              *user.DefaultInfo
 		deepSARHeader         bool
+		grantedServiceAccount bool
+		verb                  string
 	}{
 		{
 			testName: "requested cluster is not root",
@@ -128,6 +130,23 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 			requestingUser:     newUser("user-admin"),
 			wantUser:           newUser("user-admin", "system:kcp:clusterworkspace:access", "system:kcp:clusterworkspace:admin"),
 		},
+		{
+			testName: "permitted admin user can read read-only workspace",
+
+			requestedWorkspace: "root:readonly",
+			requestingUser:     newUser("user-admin"),
+			verb:               "list",
+			wantUser:           newUser("user-admin", "system:kcp:clusterworkspace:access", "system:kcp:clusterworkspace:admin"),
+		},
+		{
+			testName: "permitted admin user cannot write read-only workspace",
+
+			requestedWorkspace: "root:readonly",
+			requestingUser:     newUser("user-admin"),
+			verb:               "create",
+			wantDecision:       authorizer.DecisionNoOpinion,
+			wantReason:         "workspace access not permitted",
+		},
 		{
 			testName: "permitted access user is granted access",
 
@@ -150,6 +169,33 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 			requestingUser:     newServiceAccountWithCluster("sa", "root:ready"),
 			wantUser:           newServiceAccountWithCluster("sa", "root:ready", "system:kcp:clusterworkspace:access"),
 		},
+		{
+			testName: "granted service account of another workspace is granted access",
+
+			requestedWorkspace:    "root:ready",
+			requestingUser:        newServiceAccountWithCluster("system:kcp:serviceaccount:root:ci:default:sa", "root:ci", "system:authenticated"),
+			grantedServiceAccount: true,
+			wantUser:              newServiceAccountWithCluster("system:kcp:serviceaccount:root:ci:default:sa", "root:ci", "system:authenticated", "system:kcp:clusterworkspace:access"),
+		},
+		{
+			testName: "granted service account cannot write read-only workspace",
+
+			requestedWorkspace:    "root:readonly",
+			requestingUser:        newServiceAccountWithCluster("system:kcp:serviceaccount:root:ci:default:sa", "root:ci", "system:authenticated"),
+			grantedServiceAccount: true,
+			verb:                  "create",
+			wantDecision:          authorizer.DecisionNoOpinion,
+			wantReason:            "workspace access not permitted",
+		},
+		{
+			testName: "granted service account is denied on initializing workspace",
+
+			requestedWorkspace:    "root:initializing",
+			requestingUser:        newServiceAccountWithCluster("system:kcp:serviceaccount:root:ci:default:sa", "root:ci", "system:authenticated"),
+			grantedServiceAccount: true,
+			wantDecision:          authorizer.DecisionNoOpinion,
+			wantReason:            "workspace access not permitted",
+		},
 		{
 			testName: "authenticated user is granted access on root",
 
@@ -239,7 +285,7 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 						{
 							Verbs:         []string{"admin"},
 							Resources:     []string{"workspaces/content"},
-							ResourceNames: []string{"ready"},
+							ResourceNames: []string{"ready", "readonly"},
 							APIGroups:     []string{"tenancy.kcp.dev"},
 						},
 					},
@@ -400,6 +446,11 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: clusters.ToClusterAwareKey(logicalcluster.New("root"), "ready")},
 				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
 			}))
+			require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: clusters.ToClusterAwareKey(logicalcluster.New("root"), "readonly")},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{ReadOnly: true},
+				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+			}))
 			require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
 				ObjectMeta: metav1.ObjectMeta{Name: clusters.ToClusterAwareKey(logicalcluster.New("root"), "scheduling")},
 				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling},
@@ -423,10 +474,14 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 			ctx = request.WithCluster(ctx, requestedCluster)
 			attr := authorizer.AttributesRecord{
 				User: tt.requestingUser,
+				Verb: tt.verb,
 			}
 			if tt.deepSARHeader {
 				ctx = context.WithValue(ctx, deepSARKey, true)
 			}
+			if tt.grantedServiceAccount {
+				ctx = withGrantedServiceAccount(ctx)
+			}
 
 			gotDecision, gotReason, err := w.Authorize(ctx, attr)
 			gotErr := ""
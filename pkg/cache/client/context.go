@@ -37,5 +37,6 @@ func WithShardInContext(parent context.Context, shard shard.Name) context.Contex
 // ShardFromContext returns the value of the shard key on the ctx,
 // or an empty Name if there is no shard key.
 func ShardFromContext(ctx context.Context) shard.Name {
-	return ctx.Value(shardContextKey).(shard.Name)
+	name, _ := ctx.Value(shardContextKey).(shard.Name)
+	return name
 }
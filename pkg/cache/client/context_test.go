@@ -14,22 +14,18 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package location
+package client
 
 import (
-	"fmt"
+	"context"
+	"testing"
 
-	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/kcp-dev/kcp/pkg/cache/client/shard"
 )
 
-func indexByWorkspace(obj interface{}) ([]string, error) {
-	metaObj, ok := obj.(metav1.Object)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
-	}
-
-	lcluster := logicalcluster.From(metaObj)
-	return []string{lcluster.String()}, nil
+func TestShardFromContext(t *testing.T) {
+	require.Equal(t, shard.Name("amber"), ShardFromContext(WithShardInContext(context.Background(), "amber")))
+	require.Equal(t, shard.Name(""), ShardFromContext(context.Background()))
 }
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// SourceResourceVersionAnnotationKey is the annotation on copies in the cache server holding the resourceVersion
+// of the replicated object on its shard.
+const SourceResourceVersionAnnotationKey = "replication.cache.kcp.dev/source-resource-version"
+
+// SourceShardAnnotationKey is the annotation on copies in the cache server holding the name of the shard
+// of the replicated object.
+const SourceShardAnnotationKey = "replication.cache.kcp.dev/source-shard"
+
+// SealedIdentityAnnotationKey is the annotation on copies of APIExports in the cache server holding the base64
+// encoded sealed identity key of the APIExport, if identity secrets are replicated with IdentitySecretReplicationSealed.
+const SealedIdentityAnnotationKey = "replication.cache.kcp.dev/sealed-identity"
+
+// IdentitySecretReplication controls how much of the identity secrets of APIExports is replicated.
+type IdentitySecretReplication string
+
+const (
+	// IdentitySecretReplicationHash replicates only the identity hash, as part of the status of APIExports.
+	IdentitySecretReplicationHash IdentitySecretReplication = "Hash"
+	// IdentitySecretReplicationSealed additionally replicates identity keys sealed with the identity encryption
+	// provider of the shard, such that shards sharing the encryption provider can verify identity hashes. Plain
+	// identity keys are never replicated. With this policy, shards reject copies of root APIExports without a
+	// sealed identity key.
+	IdentitySecretReplicationSealed IdentitySecretReplication = "Sealed"
+)
+
+// Policy controls which objects are replicated into the cache server. Objects are replicated if they
+// match any of the resources of the policy.
+//
+// For example:
+//
+//	resources:
+//	- group: apis.kcp.dev
+//	  version: v1alpha1
+//	  resource: apiexports
+//	- group: scheduling.kcp.dev
+//	  version: v1alpha1
+//	  resource: locations
+//	  workspaces: ["root:compute:*"]
+//	  labelSelector:
+//	    matchExpressions:
+//	    - {key: example.kcp.dev/internal, operator: DoesNotExist}
+//	identitySecrets: Sealed
+type Policy struct {
+	// Resources are the resources to replicate.
+	Resources []Resource `json:"resources"`
+
+	// IdentitySecrets controls how much of the identity secrets of replicated APIExports is replicated,
+	// either "Hash" or "Sealed". If empty, only the identity hash is replicated.
+	IdentitySecrets IdentitySecretReplication `json:"identitySecrets,omitempty"`
+}
+
+// Resource selects the objects of a resource to replicate.
+type Resource struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+
+	// Workspaces restricts replication to the given logical clusters. An entry ending in ":*"
+	// matches all descendants of the given logical cluster. If empty, all logical clusters match.
+	Workspaces []string `json:"workspaces,omitempty"`
+
+	// LabelSelector restricts replication to objects with matching labels. If nil, all objects match.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// GroupVersionResource returns the GroupVersionResource of the resource.
+func (r Resource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// DefaultPolicy returns the policy used if none is configured, replicating the APIs exported by all workspaces,
+// and the ClusterWorkspaces the front-proxy routes requests by when started with --cache-kubeconfig.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Resources: []Resource{
+			{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiresourceschemas"},
+			{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"},
+			{Group: "tenancy.kcp.dev", Version: "v1alpha1", Resource: "clusterworkspaces"},
+		},
+	}
+}
+
+// Load reads a policy from the given file. If path is empty, the default policy is returned.
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replication policy: %w", err)
+	}
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(bs, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse replication policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// Validate checks that the policy is well-formed.
+func (p *Policy) Validate() []error {
+	var errs []error
+	seen := map[schema.GroupVersionResource]bool{}
+	for i, r := range p.Resources {
+		if r.Version == "" || r.Resource == "" {
+			errs = append(errs, fmt.Errorf("resources[%d]: version and resource must be set", i))
+		}
+		if seen[r.GroupVersionResource()] {
+			errs = append(errs, fmt.Errorf("resources[%d]: duplicate resource %s", i, r.GroupVersionResource()))
+		}
+		seen[r.GroupVersionResource()] = true
+
+		for j, ws := range r.Workspaces {
+			if !logicalcluster.New(strings.TrimSuffix(ws, ":*")).IsValid() {
+				errs = append(errs, fmt.Errorf("resources[%d].workspaces[%d]: invalid workspace %q", i, j, ws))
+			}
+		}
+		if _, err := metav1.LabelSelectorAsSelector(r.LabelSelector); err != nil {
+			errs = append(errs, fmt.Errorf("resources[%d].labelSelector: %w", i, err))
+		}
+	}
+
+	switch p.IdentitySecrets {
+	case "", IdentitySecretReplicationHash:
+	case IdentitySecretReplicationSealed:
+		if !seen[apiExportsGVR] {
+			errs = append(errs, fmt.Errorf("identitySecrets: %s requires %s to be replicated", p.IdentitySecrets, apiExportsGVR))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("identitySecrets: unknown value %q, must be %q or %q", p.IdentitySecrets, IdentitySecretReplicationHash, IdentitySecretReplicationSealed))
+	}
+	return errs
+}
+
+var apiExportsGVR = schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"}
+
+// ReplicatesSealedIdentities returns whether sealed identity keys are replicated with APIExports.
+func (p *Policy) ReplicatesSealedIdentities() bool {
+	return p.IdentitySecrets == IdentitySecretReplicationSealed
+}
+
+// GroupVersionResources returns the resources replicated by the policy.
+func (p *Policy) GroupVersionResources() []schema.GroupVersionResource {
+	gvrs := make([]schema.GroupVersionResource, 0, len(p.Resources))
+	for _, r := range p.Resources {
+		gvrs = append(gvrs, r.GroupVersionResource())
+	}
+	return gvrs
+}
+
+// Matches returns whether an object of the given resource, logical cluster and labels is to be replicated.
+func (p *Policy) Matches(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, objLabels map[string]string) (bool, error) {
+	for _, r := range p.Resources {
+		if r.GroupVersionResource() != gvr || !r.matchesWorkspace(clusterName) {
+			continue
+		}
+		if r.LabelSelector == nil {
+			return true, nil
+		}
+		selector, err := metav1.LabelSelectorAsSelector(r.LabelSelector)
+		if err != nil {
+			return false, err
+		}
+		if selector.Matches(labels.Set(objLabels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r Resource) matchesWorkspace(clusterName logicalcluster.Name) bool {
+	if len(r.Workspaces) == 0 {
+		return true
+	}
+	for _, ws := range r.Workspaces {
+		if parent := strings.TrimSuffix(ws, ":*"); parent != ws {
+			if strings.HasPrefix(clusterName.String(), parent+":") {
+				return true
+			}
+		} else if clusterName.String() == ws {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLoad(t *testing.T) {
+	policy, err := Load("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultPolicy(), policy)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+resources:
+- group: scheduling.kcp.dev
+  version: v1alpha1
+  resource: locations
+  workspaces: ["root:compute:*"]
+`), 0600))
+	policy, err = Load(path)
+	require.NoError(t, err)
+	require.Empty(t, policy.Validate())
+	require.Equal(t, []schema.GroupVersionResource{{Group: "scheduling.kcp.dev", Version: "v1alpha1", Resource: "locations"}}, policy.GroupVersionResources())
+
+	require.NoError(t, os.WriteFile(path, []byte("resources:\n- group: apis.kcp.dev\n  kind: APIExport\n"), 0600))
+	_, err = Load(path)
+	require.Error(t, err, "unknown fields must be rejected")
+}
+
+func TestValidate(t *testing.T) {
+	policy := &Policy{Resources: []Resource{
+		{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports", Workspaces: []string{"root:Org"}},
+		{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"},
+		{Group: "scheduling.kcp.dev", Resource: "locations"},
+	}}
+	require.Len(t, policy.Validate(), 3)
+
+	policy = &Policy{
+		Resources:       []Resource{{Group: "scheduling.kcp.dev", Version: "v1alpha1", Resource: "locations"}},
+		IdentitySecrets: IdentitySecretReplicationSealed,
+	}
+	require.Len(t, policy.Validate(), 1, "sealed identities require APIExports to be replicated")
+
+	policy = DefaultPolicy()
+	policy.IdentitySecrets = "Plain"
+	require.Len(t, policy.Validate(), 1)
+	policy.IdentitySecrets = IdentitySecretReplicationSealed
+	require.Empty(t, policy.Validate())
+}
+
+func TestMatches(t *testing.T) {
+	locations := schema.GroupVersionResource{Group: "scheduling.kcp.dev", Version: "v1alpha1", Resource: "locations"}
+	policy := &Policy{Resources: []Resource{
+		{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"},
+		{
+			Group:      "scheduling.kcp.dev",
+			Version:    "v1alpha1",
+			Resource:   "locations",
+			Workspaces: []string{"root:compute:*", "root:edge"},
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"replicate": "true"},
+			},
+		},
+	}}
+
+	tests := map[string]struct {
+		gvr     schema.GroupVersionResource
+		cluster string
+		labels  map[string]string
+		want    bool
+	}{
+		"resource without restrictions":   {gvr: schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"}, cluster: "root:org", want: true},
+		"resource not in policy":          {gvr: schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apibindings"}, cluster: "root:org"},
+		"descendant of workspace pattern": {gvr: locations, cluster: "root:compute:eu", labels: map[string]string{"replicate": "true"}, want: true},
+		"workspace pattern parent itself": {gvr: locations, cluster: "root:compute", labels: map[string]string{"replicate": "true"}},
+		"sibling with common prefix":      {gvr: locations, cluster: "root:computer:eu", labels: map[string]string{"replicate": "true"}},
+		"exact workspace":                 {gvr: locations, cluster: "root:edge", labels: map[string]string{"replicate": "true"}, want: true},
+		"label selector mismatch":         {gvr: locations, cluster: "root:edge"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := policy.Matches(tc.gvr, logicalcluster.New(tc.cluster), tc.labels)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	// ShardUserPrefix is the prefix of the user names of shards. A shard named "amber" authenticates as
+	// "system:kcp:shard:amber".
+	ShardUserPrefix = "system:kcp:shard:"
+)
+
+// ReadScope determines which replicated objects a shard can read.
+type ReadScope string
+
+const (
+	// ReadScopeAll allows shards to read the objects of all shards.
+	ReadScopeAll ReadScope = "all"
+	// ReadScopeOwn allows shards to read only the objects they replicated themselves.
+	ReadScopeOwn ReadScope = "own"
+)
+
+var readOnlyVerbs = sets.NewString("get", "list", "watch")
+
+// NewShardAuthorizer returns an authorizer that scopes the requests of shards to their own objects:
+//
+//   - shards can only write to the objects of their own shard, i.e. under /shards/<shard name>.
+//   - shards can read the objects of all shards, or only their own with ReadScopeOwn.
+//   - members of the given read-only groups can read the objects of all shards.
+//   - nobody but privileged users can write CustomResourceDefinitions, which are owned by the cache server.
+//
+// Other requests are given no opinion.
+func NewShardAuthorizer(readScope ReadScope, readOnlyGroups []string) authorizer.Authorizer {
+	return &shardAuthorizer{
+		readScope:      readScope,
+		readOnlyGroups: sets.NewString(readOnlyGroups...),
+	}
+}
+
+type shardAuthorizer struct {
+	readScope      ReadScope
+	readOnlyGroups sets.String
+}
+
+func (a *shardAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	u := attr.GetUser()
+	if u == nil {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+	shardName, isShard := ShardNameFromUser(u.GetName())
+	isReader := a.readOnlyGroups.HasAny(u.GetGroups()...)
+	if !isShard && !isReader {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+	readOnly := readOnlyVerbs.Has(attr.GetVerb())
+
+	if !attr.IsResourceRequest() {
+		// discovery, OpenAPI and version
+		if readOnly {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	if attr.GetAPIGroup() == apiextensionsv1.GroupName && !readOnly {
+		return authorizer.DecisionDeny, "CustomResourceDefinitions are owned by the cache server", nil
+	}
+
+	requestShard := request.ShardFrom(ctx)
+	if readOnly {
+		if isReader || a.readScope != ReadScopeOwn || requestShard.String() == shardName {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionDeny, fmt.Sprintf("shard %q can only read its own objects", shardName), nil
+	}
+
+	if isShard && requestShard.String() == shardName {
+		return authorizer.DecisionAllow, "", nil
+	}
+	if isShard {
+		return authorizer.DecisionDeny, fmt.Sprintf("shard %q can only write its own objects", shardName), nil
+	}
+	return authorizer.DecisionNoOpinion, "", nil
+}
+
+// ShardNameFromUser returns the shard name of a shard user, and whether the user is a shard.
+func ShardNameFromUser(userName string) (string, bool) {
+	if !strings.HasPrefix(userName, ShardUserPrefix) {
+		return "", false
+	}
+	shardName := strings.TrimPrefix(userName, ShardUserPrefix)
+	return shardName, shardName != "" && shardName != "*"
+}
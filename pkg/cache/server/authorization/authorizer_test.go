@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestShardAuthorizer(t *testing.T) {
+	for _, testCase := range []struct {
+		name      string
+		readScope ReadScope
+		user      user.Info
+		shard     request.Shard
+		verb      string
+		apiGroup  string
+		resource  bool
+
+		want authorizer.Decision
+	}{
+		{
+			name: "shards can write their own objects",
+			user: &user.DefaultInfo{Name: "system:kcp:shard:amber"}, shard: "amber", verb: "update", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionAllow,
+		},
+		{
+			name: "shards cannot write the objects of other shards",
+			user: &user.DefaultInfo{Name: "system:kcp:shard:amber"}, shard: "sapphire", verb: "update", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionDeny,
+		},
+		{
+			name: "shards cannot write across shards",
+			user: &user.DefaultInfo{Name: "system:kcp:shard:amber"}, shard: "*", verb: "deletecollection", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionDeny,
+		},
+		{
+			name: "shards can read the objects of all shards",
+			user: &user.DefaultInfo{Name: "system:kcp:shard:amber"}, shard: "*", verb: "watch", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionAllow,
+		},
+		{
+			name:      "shards can be restricted to read their own objects",
+			readScope: ReadScopeOwn,
+			user:      &user.DefaultInfo{Name: "system:kcp:shard:amber"}, shard: "*", verb: "list", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionDeny,
+		},
+		{
+			name:      "shards restricted to their own objects can read them",
+			readScope: ReadScopeOwn,
+			user:      &user.DefaultInfo{Name: "system:kcp:shard:amber"}, shard: "amber", verb: "list", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionAllow,
+		},
+		{
+			name: "shards cannot write CRDs",
+			user: &user.DefaultInfo{Name: "system:kcp:shard:amber"}, shard: "amber", verb: "create", apiGroup: "apiextensions.k8s.io", resource: true,
+			want: authorizer.DecisionDeny,
+		},
+		{
+			name: "shards can use discovery",
+			user: &user.DefaultInfo{Name: "system:kcp:shard:amber"}, verb: "get",
+			want: authorizer.DecisionAllow,
+		},
+		{
+			name:      "readers can read the objects of all shards",
+			readScope: ReadScopeOwn,
+			user:      &user.DefaultInfo{Name: "alice", Groups: []string{"readers"}}, shard: "*", verb: "list", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionAllow,
+		},
+		{
+			name: "readers cannot write",
+			user: &user.DefaultInfo{Name: "alice", Groups: []string{"readers"}}, shard: "amber", verb: "create", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionNoOpinion,
+		},
+		{
+			name: "other users are given no opinion",
+			user: &user.DefaultInfo{Name: "bob"}, shard: "*", verb: "list", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionNoOpinion,
+		},
+		{
+			name: "the wildcard shard user is no shard",
+			user: &user.DefaultInfo{Name: "system:kcp:shard:*"}, shard: "*", verb: "update", apiGroup: "apis.kcp.dev", resource: true,
+			want: authorizer.DecisionNoOpinion,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			readScope := testCase.readScope
+			if readScope == "" {
+				readScope = ReadScopeAll
+			}
+			a := NewShardAuthorizer(readScope, []string{"readers"})
+
+			ctx := request.WithShard(context.Background(), testCase.shard)
+			decision, _, err := a.Authorize(ctx, authorizer.AttributesRecord{
+				User:            testCase.user,
+				Verb:            testCase.verb,
+				APIGroup:        testCase.apiGroup,
+				Resource:        "apiexports",
+				ResourceRequest: testCase.resource,
+			})
+			require.NoError(t, err)
+			require.Equal(t, testCase.want, decision)
+		})
+	}
+}
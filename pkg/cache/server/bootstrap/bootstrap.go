@@ -25,6 +25,7 @@ import (
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
@@ -37,24 +38,40 @@ import (
 // We use the same name as the KCP for symmetry.
 var SystemCRDLogicalCluster = logicalcluster.New("system:system-crds")
 
-func Bootstrap(ctx context.Context, apiExtensionsClusterClient apiextensionsclient.ClusterInterface) error {
+// CRDsFor returns the CRDs serving the given resources in the cache server. Only kcp resources are known.
+func CRDsFor(resources []schema.GroupVersionResource) ([]*apiextensionsv1.CustomResourceDefinition, error) {
 	crds := []*apiextensionsv1.CustomResourceDefinition{}
-	for _, resource := range []string{"apiresourceschemas", "apiexports"} {
+	for _, resource := range resources {
 		crd := &apiextensionsv1.CustomResourceDefinition{}
-		if err := configcrds.Unmarshal(fmt.Sprintf("apis.kcp.dev_%s.yaml", resource), crd); err != nil {
-			panic(fmt.Errorf("failed to unmarshal %v resource: %w", resource, err))
+		if err := configcrds.Unmarshal(fmt.Sprintf("%s_%s.yaml", resource.Group, resource.Resource), crd); err != nil {
+			return nil, fmt.Errorf("no CustomResourceDefinition known for %s: %w", resource.GroupResource(), err)
 		}
+		served := false
 		for i := range crd.Spec.Versions {
 			v := &crd.Spec.Versions[i]
+			served = served || (v.Served && v.Name == resource.Version)
 			v.Schema = &apiextensionsv1.CustomResourceValidation{
 				OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
 					Type:                   "object",
 					XPreserveUnknownFields: pointer.BoolPtr(true),
 				},
 			} // wipe the schema, we don't need validation
+			v.Subresources = nil // objects are replicated as a whole, including their status
+		}
+		if !served {
+			return nil, fmt.Errorf("version %s of %s is not served", resource.Version, resource.GroupResource())
 		}
 		crds = append(crds, crd)
 	}
+	return crds, nil
+}
+
+// Bootstrap creates the CRDs serving the given resources, as replicated into the cache server.
+func Bootstrap(ctx context.Context, apiExtensionsClusterClient apiextensionsclient.ClusterInterface, resources []schema.GroupVersionResource) error {
+	crds, err := CRDsFor(resources)
+	if err != nil {
+		return err
+	}
 
 	logger := klog.FromContext(ctx)
 	return wait.PollInfiniteWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
+)
+
+func TestCRDsFor(t *testing.T) {
+	crds, err := CRDsFor(replication.DefaultPolicy().GroupVersionResources())
+	require.NoError(t, err)
+	require.Len(t, crds, 3)
+
+	crds, err = CRDsFor([]schema.GroupVersionResource{{Group: "scheduling.kcp.dev", Version: "v1alpha1", Resource: "locations"}})
+	require.NoError(t, err)
+	require.Equal(t, "locations.scheduling.kcp.dev", crds[0].Name)
+
+	_, err = CRDsFor([]schema.GroupVersionResource{{Group: "scheduling.kcp.dev", Version: "v1", Resource: "locations"}})
+	require.Error(t, err, "unserved versions must be rejected")
+
+	_, err = CRDsFor([]schema.GroupVersionResource{{Group: "example.kcp.dev", Version: "v1", Resource: "widgets"}})
+	require.Error(t, err, "unknown resources must be rejected")
+}
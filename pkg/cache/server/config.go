@@ -40,6 +40,7 @@ import (
 	"k8s.io/kubernetes/pkg/genericcontrolplane/clientutils"
 
 	cacheserveroptions "github.com/kcp-dev/kcp/pkg/cache/server/options"
+	"github.com/kcp-dev/kcp/pkg/cache/server/retention"
 	"github.com/kcp-dev/kcp/pkg/embeddedetcd"
 	kcpserver "github.com/kcp-dev/kcp/pkg/server"
 )
@@ -63,6 +64,8 @@ type completedConfig struct {
 }
 
 type ExtraConfig struct {
+	ShardActivity *retention.ShardActivity
+
 	ApiExtensionsClusterClient apiextensionsclient.ClusterInterface
 
 	ApiExtensionsSharedInformerFactory apiextensionsexternalversions.SharedInformerFactory
@@ -86,6 +89,9 @@ func (c *Config) Complete() (CompletedConfig, error) {
 func NewConfig(opts *cacheserveroptions.CompletedOptions) (*Config, error) {
 	c := &Config{
 		Options: opts,
+		ExtraConfig: ExtraConfig{
+			ShardActivity: retention.NewShardActivity(),
+		},
 	}
 	if opts.EmbeddedEtcd.Enabled {
 		var err error
@@ -115,21 +121,23 @@ func NewConfig(opts *cacheserveroptions.CompletedOptions) (*Config, error) {
 	if err := opts.SecureServing.ApplyTo(&serverConfig.Config.SecureServing, &serverConfig.Config.LoopbackClientConfig); err != nil {
 		return nil, err
 	}
-	if err := opts.Authentication.ApplyTo(&serverConfig.Config.Authentication, serverConfig.SecureServing, serverConfig.OpenAPIConfig); err != nil {
+	if err := opts.Authentication.ApplyTo(&serverConfig.Config.Authentication, serverConfig.SecureServing); err != nil {
 		return nil, err
 	}
 	if err := opts.Authorization.ApplyTo(&serverConfig.Config.Authorization); err != nil {
 		return nil, err
 	}
+	genericapiserver.AuthorizeClientBearerToken(serverConfig.LoopbackClientConfig, &serverConfig.Authentication, &serverConfig.Authorization)
 
 	if err := opts.APIEnablement.ApplyTo(&serverConfig.Config, apiextensionsapiserver.DefaultAPIResourceConfigSource(), apiextensionsapiserver.Scheme); err != nil {
 		return nil, err
 	}
 
 	serverConfig.Config.BuildHandlerChainFunc = func(apiHandler http.Handler, genericConfig *genericapiserver.Config) (secure http.Handler) {
+		apiHandler = retention.WithShardActivity(apiHandler, c.ShardActivity)
 		apiHandler = genericapiserver.DefaultBuildHandlerChainFromAuthz(apiHandler, genericConfig)
 		apiHandler = genericapiserver.DefaultBuildHandlerChainBeforeAuthz(apiHandler, genericConfig)
-		apiHandler = kcpserver.WithClusterAnnotation(apiHandler)
+		apiHandler = kcpserver.WithClusterAnnotation(apiHandler, "")
 		apiHandler = kcpserver.WithClusterScope(apiHandler)
 		apiHandler = kcpserver.WithAcceptHeader(apiHandler)
 		apiHandler = kcpserver.WithUserAgent(apiHandler)
@@ -178,7 +186,7 @@ func NewConfig(opts *cacheserveroptions.CompletedOptions) (*Config, error) {
 			ServiceResolver:       &unimplementedServiceResolver{},
 			MasterCount:           1,
 			AuthResolverWrapper:   webhook.NewDefaultAuthenticationInfoResolverWrapper(nil, nil, serverConfig.LoopbackClientConfig, nil),
-			ClusterAwareCRDLister: &crdLister{lister: c.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Lister()},
+			ClusterAwareCRDLister: newCRDLister(c.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer()),
 		},
 	}
 
@@ -19,34 +19,108 @@ package server
 import (
 	"context"
 
+	"github.com/kcp-dev/logicalcluster/v2"
+
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionslisters "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/kcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
 
 	"github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
-// crdLister is a CRD lister
+// crdLister is a CRD lister serving the CRDs of the logical cluster of a request.
+//
+// The system CRDs are served in every logical cluster and take priority over CRDs of the
+// logical cluster itself. Wildcard requests only see the system CRDs, because there is
+// no single schema for a resource across logical clusters otherwise.
+//
+// CRDs are always stored under the default shard (see WithShardScope), hence the same
+// schemas are served for all shards of a logical cluster.
 type crdLister struct {
-	lister apiextensionslisters.CustomResourceDefinitionLister
+	lister  apiextensionslisters.CustomResourceDefinitionLister
+	indexer cache.Indexer
 }
 
 var _ kcp.ClusterAwareCRDLister = &crdLister{}
 
-// List lists all CustomResourceDefinitions
+// newCRDLister returns a crdLister for the given informer, adding the indexers it needs.
+func newCRDLister(informer cache.SharedIndexInformer) *crdLister {
+	indexers.AddIfNotPresentOrDie(informer.GetIndexer(), cache.Indexers{
+		indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+	})
+	return &crdLister{
+		lister:  apiextensionslisters.NewCustomResourceDefinitionLister(informer.GetIndexer()),
+		indexer: informer.GetIndexer(),
+	}
+}
+
+// List lists the system CustomResourceDefinitions and those of the logical cluster retrieved from the context.
 func (c *crdLister) List(ctx context.Context, selector labels.Selector) ([]*apiextensionsv1.CustomResourceDefinition, error) {
-	// TODO: make it shard and cluster aware, for now just return what we have in the system ws
-	return c.lister.List(selector)
+	systemCRDs, err := indexers.ByIndex[*apiextensionsv1.CustomResourceDefinition](c.indexer, indexers.ByLogicalCluster, bootstrap.SystemCRDLogicalCluster.String())
+	if err != nil {
+		return nil, err
+	}
+
+	seen := sets.NewString()
+	var ret []*apiextensionsv1.CustomResourceDefinition
+	for _, crd := range systemCRDs {
+		seen.Insert(crd.Name)
+		if selector.Matches(labels.Set(crd.Labels)) {
+			ret = append(ret, crd)
+		}
+	}
+
+	clusterName, ok := clusterNameFrom(ctx)
+	if !ok {
+		return ret, nil
+	}
+	clusterCRDs, err := indexers.ByIndex[*apiextensionsv1.CustomResourceDefinition](c.indexer, indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, crd := range clusterCRDs {
+		// system CRDs take priority over CRDs of the logical cluster
+		if seen.Has(crd.Name) {
+			continue
+		}
+		if selector.Matches(labels.Set(crd.Labels)) {
+			ret = append(ret, crd)
+		}
+	}
+
+	return ret, nil
 }
 
 func (c *crdLister) Refresh(crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error) {
 	return crd, nil
 }
 
-// Get gets a CustomResourceDefinition
+// Get gets a system CustomResourceDefinition, or one of the logical cluster retrieved from the context.
 func (c *crdLister) Get(ctx context.Context, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
-	// TODO: make it shard and cluster aware, for now just return what we have in the system ws
-	return c.lister.Get(clusters.ToClusterAwareKey(bootstrap.SystemCRDLogicalCluster, name))
+	crd, err := c.lister.Get(clusters.ToClusterAwareKey(bootstrap.SystemCRDLogicalCluster, name))
+	if err == nil || !apierrors.IsNotFound(err) {
+		return crd, err
+	}
+
+	clusterName, ok := clusterNameFrom(ctx)
+	if !ok {
+		return nil, err
+	}
+	return c.lister.Get(clusters.ToClusterAwareKey(clusterName, name))
+}
+
+// clusterNameFrom returns the logical cluster of the request, if it is a single non-system one.
+func clusterNameFrom(ctx context.Context) (logicalcluster.Name, bool) {
+	cluster := request.ClusterFrom(ctx)
+	if cluster == nil || cluster.Name.Empty() || cluster.Wildcard || cluster.Name == bootstrap.SystemCRDLogicalCluster {
+		return logicalcluster.Name{}, false
+	}
+	return cluster.Name, true
 }
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apiextensionsexternalversions "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
+)
+
+func TestCRDLister(t *testing.T) {
+	newCRD := func(cluster logicalcluster.Name, name, version string) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: cluster.String()},
+				Labels:      map[string]string{"version": version},
+			},
+		}
+	}
+
+	informers := apiextensionsexternalversions.NewSharedInformerFactory(apiextensionsfake.NewSimpleClientset(), 0)
+	informer := informers.Apiextensions().V1().CustomResourceDefinitions().Informer()
+	lister := newCRDLister(informer)
+
+	org := logicalcluster.New("root:org")
+	for _, crd := range []*apiextensionsv1.CustomResourceDefinition{
+		newCRD(bootstrap.SystemCRDLogicalCluster, "apiexports.apis.kcp.dev", "system"),
+		newCRD(org, "apiexports.apis.kcp.dev", "org"),
+		newCRD(org, "widgets.example.kcp.dev", "org"),
+		newCRD(logicalcluster.New("root:other"), "gadgets.example.kcp.dev", "other"),
+	} {
+		require.NoError(t, informer.GetIndexer().Add(crd))
+	}
+
+	withCluster := func(cluster request.Cluster) context.Context {
+		return request.WithCluster(context.Background(), cluster)
+	}
+
+	tests := map[string]struct {
+		ctx      context.Context
+		wantList []string
+		wantGet  map[string]string
+	}{
+		"logical cluster sees system and own CRDs": {
+			ctx:      withCluster(request.Cluster{Name: org}),
+			wantList: []string{"apiexports.apis.kcp.dev/system", "widgets.example.kcp.dev/org"},
+			wantGet: map[string]string{
+				"apiexports.apis.kcp.dev": "system",
+				"widgets.example.kcp.dev": "org",
+				"gadgets.example.kcp.dev": "",
+			},
+		},
+		"wildcard sees system CRDs only": {
+			ctx:      withCluster(request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true}),
+			wantList: []string{"apiexports.apis.kcp.dev/system"},
+			wantGet: map[string]string{
+				"apiexports.apis.kcp.dev": "system",
+				"widgets.example.kcp.dev": "",
+			},
+		},
+		"no cluster sees system CRDs only": {
+			ctx:      context.Background(),
+			wantList: []string{"apiexports.apis.kcp.dev/system"},
+			wantGet: map[string]string{
+				"widgets.example.kcp.dev": "",
+			},
+		},
+		"shard does not change the view": {
+			ctx:      request.WithShard(withCluster(request.Cluster{Name: org}), "amber"),
+			wantList: []string{"apiexports.apis.kcp.dev/system", "widgets.example.kcp.dev/org"},
+			wantGet: map[string]string{
+				"widgets.example.kcp.dev": "org",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			crds, err := lister.List(tc.ctx, labels.Everything())
+			require.NoError(t, err)
+			var got []string
+			for _, crd := range crds {
+				got = append(got, crd.Name+"/"+crd.Labels["version"])
+			}
+			sort.Strings(got)
+			require.Equal(t, tc.wantList, got)
+
+			for crdName, wantVersion := range tc.wantGet {
+				crd, err := lister.Get(tc.ctx, crdName)
+				if wantVersion == "" {
+					require.True(t, apierrors.IsNotFound(err), "expected NotFound for %s, got %v", crdName, err)
+					continue
+				}
+				require.NoError(t, err)
+				require.Equal(t, wantVersion, crd.Labels["version"])
+			}
+		})
+	}
+}
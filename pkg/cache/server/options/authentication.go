@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/apiserver/pkg/authentication/request/union"
+	"k8s.io/apiserver/pkg/authentication/request/x509"
+	"k8s.io/apiserver/pkg/authentication/token/tokenfile"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericoptions "k8s.io/apiserver/pkg/server/options"
+)
+
+// AuthenticationOptions configures how shards and operators authenticate against the cache server.
+// Shards are expected to authenticate as "system:kcp:shard:<shard name>", either with a client
+// certificate with that common name, or with a token of that user.
+type AuthenticationOptions struct {
+	ClientCert genericoptions.ClientCertAuthenticationOptions
+
+	// TokenAuthFile is the path to a CSV file of static tokens in the format token,user,uid,"group1,group2".
+	TokenAuthFile string
+}
+
+// NewAuthenticationOptions returns authentication options with neither client certificates nor tokens enabled.
+func NewAuthenticationOptions() *AuthenticationOptions {
+	return &AuthenticationOptions{}
+}
+
+// Enabled returns whether any authentication method is configured.
+func (o *AuthenticationOptions) Enabled() bool {
+	return o != nil && (len(o.ClientCert.ClientCA) > 0 || len(o.TokenAuthFile) > 0)
+}
+
+func (o *AuthenticationOptions) AddFlags(fs *pflag.FlagSet) {
+	o.ClientCert.AddFlags(fs)
+	fs.StringVar(&o.TokenAuthFile, "token-auth-file", o.TokenAuthFile,
+		"If set, the file that will be used to secure the cache server via token authentication. "+
+			"Shards authenticate with tokens of user system:kcp:shard:<shard name>.")
+}
+
+func (o *AuthenticationOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+	var errs []error
+	if len(o.TokenAuthFile) > 0 {
+		if _, err := os.Stat(o.TokenAuthFile); err != nil {
+			errs = append(errs, fmt.Errorf("--token-auth-file: %w", err))
+		}
+	}
+	return errs
+}
+
+// ApplyTo sets up the client certificate and token authenticators. It does nothing if authentication is not
+// enabled.
+func (o *AuthenticationOptions) ApplyTo(authenticationInfo *genericapiserver.AuthenticationInfo, servingInfo *genericapiserver.SecureServingInfo) error {
+	if !o.Enabled() {
+		return nil
+	}
+
+	var authenticators []authenticator.Request
+	if len(o.ClientCert.ClientCA) > 0 {
+		clientCA, err := o.ClientCert.GetClientCAContentProvider()
+		if err != nil {
+			return fmt.Errorf("unable to load client CA file %q: %w", o.ClientCert.ClientCA, err)
+		}
+		if err := authenticationInfo.ApplyClientCert(clientCA, servingInfo); err != nil {
+			return fmt.Errorf("unable to assign client CA file: %w", err)
+		}
+		authenticators = append(authenticators, x509.NewDynamic(clientCA.VerifyOptions, x509.CommonNameUserConversion))
+	}
+	if len(o.TokenAuthFile) > 0 {
+		tokenAuthenticator, err := tokenfile.NewCSV(o.TokenAuthFile)
+		if err != nil {
+			return fmt.Errorf("unable to load token file %q: %w", o.TokenAuthFile, err)
+		}
+		authenticators = append(authenticators, bearertoken.New(tokenAuthenticator))
+	}
+	authenticationInfo.Authenticator = union.New(authenticators...)
+	return nil
+}
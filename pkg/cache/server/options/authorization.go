@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
+	"k8s.io/apiserver/pkg/authorization/path"
+	"k8s.io/apiserver/pkg/authorization/union"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/cache/server/authorization"
+)
+
+// AuthorizationOptions configures what authenticated shards and operators can do in the cache server.
+type AuthorizationOptions struct {
+	// ShardReadScope is either "all" or "own", see authorization.ReadScope.
+	ShardReadScope string
+	// ReadOnlyGroups are groups which can read the objects of all shards.
+	ReadOnlyGroups []string
+	// AlwaysAllowPaths are HTTP paths which are excluded from authorization.
+	AlwaysAllowPaths []string
+}
+
+func NewAuthorizationOptions() *AuthorizationOptions {
+	return &AuthorizationOptions{
+		ShardReadScope:   string(authorization.ReadScopeAll),
+		AlwaysAllowPaths: []string{"/healthz", "/readyz", "/livez"},
+	}
+}
+
+func (o *AuthorizationOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.ShardReadScope, "shard-read-scope", o.ShardReadScope,
+		"Which replicated objects shards can read: \"all\" for the objects of all shards, \"own\" for only the objects they replicated themselves.")
+	fs.StringSliceVar(&o.ReadOnlyGroups, "read-only-groups", o.ReadOnlyGroups,
+		"Groups whose members can read the objects of all shards.")
+	fs.StringSliceVar(&o.AlwaysAllowPaths, "authorization-always-allow-paths", o.AlwaysAllowPaths,
+		"A list of HTTP paths to skip during authorization.")
+}
+
+func (o *AuthorizationOptions) Validate() []error {
+	if o == nil {
+		return nil
+	}
+	switch authorization.ReadScope(o.ShardReadScope) {
+	case authorization.ReadScopeAll, authorization.ReadScopeOwn:
+		return nil
+	default:
+		return []error{fmt.Errorf("--shard-read-scope must be %q or %q", authorization.ReadScopeAll, authorization.ReadScopeOwn)}
+	}
+}
+
+// ApplyTo sets up an authorizer that allows system:masters everything, and scopes shards to their own objects.
+// It does nothing if the options are nil, i.e. authorization is disabled.
+func (o *AuthorizationOptions) ApplyTo(authorizationInfo *genericapiserver.AuthorizationInfo) error {
+	if o == nil {
+		return nil
+	}
+	pathAuthorizer, err := path.NewAuthorizer(o.AlwaysAllowPaths)
+	if err != nil {
+		return err
+	}
+	authorizationInfo.Authorizer = union.New(
+		pathAuthorizer,
+		authorizerfactory.NewPrivilegedGroups(user.SystemPrivilegedGroup),
+		authorization.NewShardAuthorizer(authorization.ReadScope(o.ShardReadScope), o.ReadOnlyGroups),
+	)
+	return nil
+}
@@ -17,12 +17,18 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/pflag"
 
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 	"k8s.io/apiserver/pkg/storage/storagebackend"
+	"k8s.io/klog/v2"
 	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
+	"github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
 	etcdoptions "github.com/kcp-dev/kcp/pkg/embeddedetcd/options"
 )
 
@@ -30,20 +36,42 @@ type Options struct {
 	ServerRunOptions *genericoptions.ServerRunOptions
 	Etcd             *genericoptions.EtcdOptions
 	SecureServing    *genericoptions.SecureServingOptionsWithLoopback
-	Authentication   *genericoptions.DelegatingAuthenticationOptions
-	Authorization    *genericoptions.DelegatingAuthorizationOptions
+	Authentication   *AuthenticationOptions
+	Authorization    *AuthorizationOptions
 	APIEnablement    *genericoptions.APIEnablementOptions
 	EmbeddedEtcd     etcdoptions.Options
+
+	// ReplicationPolicyFile is the path to the replication policy. If empty, the default policy is used.
+	ReplicationPolicyFile string
+
+	// InsecureAllowUnauthenticated allows running without any authentication, i.e. everybody can read and
+	// write the replicated objects of all shards.
+	InsecureAllowUnauthenticated bool
+
+	Retention RetentionOptions
+}
+
+// RetentionOptions bound the growth of the cache server.
+type RetentionOptions struct {
+	// ShardTTL is the duration after which the replicated objects of a shard which stopped watching the cache
+	// server are deleted. Zero disables the deletion.
+	ShardTTL time.Duration
+	// SweepInterval is the interval in which gone shards are looked for and storage metrics are updated.
+	SweepInterval time.Duration
 }
 
 type completedOptions struct {
 	ServerRunOptions *genericoptions.ServerRunOptions
 	Etcd             *genericoptions.EtcdOptions
 	SecureServing    *genericoptions.SecureServingOptionsWithLoopback
-	Authentication   *genericoptions.DelegatingAuthenticationOptions
-	Authorization    *genericoptions.DelegatingAuthorizationOptions
+	Authentication   *AuthenticationOptions
+	Authorization    *AuthorizationOptions
 	APIEnablement    *genericoptions.APIEnablementOptions
 	EmbeddedEtcd     etcdoptions.CompletedOptions
+
+	ReplicationPolicy            *replication.Policy
+	InsecureAllowUnauthenticated bool
+	Retention                    RetentionOptions
 }
 
 type CompletedOptions struct {
@@ -55,10 +83,27 @@ func (o *CompletedOptions) Validate() []error {
 	errors = append(errors, o.ServerRunOptions.Validate()...)
 	errors = append(errors, o.Etcd.Validate()...)
 	errors = append(errors, o.SecureServing.Validate()...)
+	switch {
+	case !o.Authentication.Enabled() && !o.InsecureAllowUnauthenticated:
+		errors = append(errors, fmt.Errorf("no authentication configured: set --client-ca-file or --token-auth-file, or --insecure-allow-unauthenticated to let everybody read and write the cache server"))
+	case o.Authentication.Enabled() && o.InsecureAllowUnauthenticated:
+		errors = append(errors, fmt.Errorf("--insecure-allow-unauthenticated must not be set together with --client-ca-file or --token-auth-file"))
+	}
 	errors = append(errors, o.Authentication.Validate()...)
 	errors = append(errors, o.Authorization.Validate()...)
 	errors = append(errors, o.APIEnablement.Validate()...)
 	errors = append(errors, o.EmbeddedEtcd.Validate()...)
+	if o.Retention.ShardTTL < 0 {
+		errors = append(errors, fmt.Errorf("--shard-ttl must not be negative"))
+	}
+	if o.Retention.SweepInterval <= 0 {
+		errors = append(errors, fmt.Errorf("--retention-sweep-interval must be positive"))
+	}
+	if policyErrs := o.ReplicationPolicy.Validate(); len(policyErrs) > 0 {
+		errors = append(errors, policyErrs...)
+	} else if _, err := bootstrap.CRDsFor(o.ReplicationPolicy.GroupVersionResources()); err != nil {
+		errors = append(errors, err)
+	}
 	return errors
 }
 
@@ -68,10 +113,13 @@ func NewOptions(rootDir string) *Options {
 		ServerRunOptions: genericoptions.NewServerRunOptions(),
 		Etcd:             genericoptions.NewEtcdOptions(storagebackend.NewDefaultConfig(kubeoptions.DefaultEtcdPathPrefix, nil)),
 		SecureServing:    genericoptions.NewSecureServingOptions().WithLoopback(),
-		Authentication:   genericoptions.NewDelegatingAuthenticationOptions(),
-		Authorization:    genericoptions.NewDelegatingAuthorizationOptions(),
+		Authentication:   NewAuthenticationOptions(),
+		Authorization:    NewAuthorizationOptions(),
 		APIEnablement:    genericoptions.NewAPIEnablementOptions(),
 		EmbeddedEtcd:     *etcdoptions.NewOptions(rootDir),
+		Retention: RetentionOptions{
+			SweepInterval: time.Minute,
+		},
 	}
 
 	o.ServerRunOptions.EnablePriorityAndFairness = false
@@ -91,14 +139,24 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 		o.EmbeddedEtcd.Enabled = true
 	}
 
-	// TODO: enable authN/Z stack
-	o.Authentication = nil
-	o.Authorization = nil
+	// without any way for shards to authenticate, the cache server is open to everybody. This must be asked for
+	// explicitly, which is checked in Validate.
+	if !o.Authentication.Enabled() && o.InsecureAllowUnauthenticated {
+		klog.Background().Info("WARNING: --insecure-allow-unauthenticated is set, authentication and authorization are disabled. " +
+			"Everybody can read and overwrite the objects replicated by all shards. Do not use this in production.")
+		o.Authentication = nil
+		o.Authorization = nil
+	}
 
 	if err := o.SecureServing.MaybeDefaultWithSelfSignedCerts("localhost", nil, nil); err != nil {
 		return nil, err
 	}
 
+	policy, err := replication.Load(o.ReplicationPolicyFile)
+	if err != nil {
+		return nil, err
+	}
+
 	return &CompletedOptions{&completedOptions{
 		ServerRunOptions: o.ServerRunOptions,
 		Etcd:             o.Etcd,
@@ -107,9 +165,29 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 		Authorization:    o.Authorization,
 		APIEnablement:    o.APIEnablement,
 		EmbeddedEtcd:     o.EmbeddedEtcd.Complete(o.Etcd),
+
+		ReplicationPolicy:            policy,
+		InsecureAllowUnauthenticated: o.InsecureAllowUnauthenticated,
+		Retention:                    o.Retention,
 	}}, nil
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	// TODO: figure out what flags needs to be exposed
+	o.SecureServing.AddFlags(fs)
+	o.Authentication.AddFlags(fs)
+	o.Authorization.AddFlags(fs)
+	o.EmbeddedEtcd.AddFlags(fs)
+	fs.DurationVar(&o.Etcd.StorageConfig.CompactionInterval, "etcd-compaction-interval", o.Etcd.StorageConfig.CompactionInterval,
+		"The interval of compaction requests. If 0, the compaction request from the cache server is disabled.")
+	fs.DurationVar(&o.Retention.ShardTTL, "shard-ttl", o.Retention.ShardTTL,
+		"Duration after which the replicated objects of a shard which stopped watching the cache server are deleted. If 0, they are kept forever.")
+	fs.DurationVar(&o.Retention.SweepInterval, "retention-sweep-interval", o.Retention.SweepInterval,
+		"Interval in which the replicated objects of gone shards are deleted and storage metrics are updated.")
+	fs.BoolVar(&o.InsecureAllowUnauthenticated, "insecure-allow-unauthenticated", o.InsecureAllowUnauthenticated,
+		"Run without authentication and authorization if neither --client-ca-file nor --token-auth-file is set. "+
+			"Everybody can then read and overwrite the objects replicated by all shards. Only meant for development.")
+	fs.StringVar(&o.ReplicationPolicyFile, "replication-policy-file", o.ReplicationPolicyFile,
+		"Path to a file with the policy of which resources, workspaces and labels are replicated into the cache server. "+
+			"If empty, APIExports, APIResourceSchemas and ClusterWorkspaces of all workspaces are replicated.")
 }
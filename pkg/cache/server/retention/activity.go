@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/cache/server/authorization"
+)
+
+// ShardActivity tracks which shards are alive. A shard is alive while it watches its own objects in the cache
+// server, which the replication controller of every shard does continuously.
+//
+// Activity is only kept in memory. After a restart of the cache server, every shard is considered to have
+// been seen at the time of the restart.
+type ShardActivity struct {
+	lock     sync.Mutex
+	started  time.Time
+	watching map[string]int
+	lastSeen map[string]time.Time
+
+	now func() time.Time
+}
+
+// NewShardActivity returns a new ShardActivity.
+func NewShardActivity() *ShardActivity {
+	return &ShardActivity{
+		started:  time.Now(),
+		watching: map[string]int{},
+		lastSeen: map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+func (a *ShardActivity) watchStarted(shard string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.watching[shard]++
+	a.lastSeen[shard] = a.now()
+}
+
+func (a *ShardActivity) watchEnded(shard string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.watching[shard]--
+	if a.watching[shard] <= 0 {
+		delete(a.watching, shard)
+	}
+	a.lastSeen[shard] = a.now()
+}
+
+// InactiveFor returns for how long the given shard has not been watching the cache server, or zero if it is
+// watching.
+func (a *ShardActivity) InactiveFor(shard string) time.Duration {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.watching[shard] > 0 {
+		return 0
+	}
+	since := a.started
+	if lastSeen, ok := a.lastSeen[shard]; ok && lastSeen.After(since) {
+		since = lastSeen
+	}
+	return a.now().Sub(since)
+}
+
+// WithShardActivity records the watches of shards on their own objects. When authentication is enabled, only
+// watches of the shard user of the watched shard are recorded.
+func WithShardActivity(handler http.Handler, activity *ShardActivity) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		requestInfo, ok := request.RequestInfoFrom(ctx)
+		shard := request.ShardFrom(ctx)
+		if !ok || !requestInfo.IsResourceRequest || requestInfo.Verb != "watch" || shard.Empty() || shard.Wildcard() {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if u, ok := request.UserFrom(ctx); ok {
+			if shardName, isShard := authorization.ShardNameFromUser(u.GetName()); !isShard || shardName != shard.String() {
+				handler.ServeHTTP(w, req)
+				return
+			}
+		}
+
+		activity.watchStarted(shard.String())
+		defer activity.watchEnded(shard.String())
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
+	cacheshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
+)
+
+// Collector periodically reports the storage usage of the replicated objects per shard, and deletes the
+// replicated objects of shards which have not been watching the cache server for longer than the TTL.
+//
+// Objects are attributed to shards by their replication.SourceShardAnnotationKey annotation. Objects without
+// it are never deleted.
+type Collector struct {
+	ttl      time.Duration
+	gvrs     []schema.GroupVersionResource
+	activity *ShardActivity
+
+	// reported holds the shards reported in the metrics per resource, to reset them once the shard is gone.
+	reported map[schema.GroupVersionResource]map[string]bool
+
+	listObjects  func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error)
+	deleteObject func(ctx context.Context, gvr schema.GroupVersionResource, shard string, obj *unstructured.Unstructured) error
+}
+
+// NewCollector returns a collector for the given resources. The client must be shard-aware, i.e. wrapped with
+// cacheclient.WithShardRoundTripper. A TTL of zero disables the deletion of objects.
+func NewCollector(client dynamic.ClusterInterface, gvrs []schema.GroupVersionResource, activity *ShardActivity, ttl time.Duration) *Collector {
+	return &Collector{
+		ttl:      ttl,
+		gvrs:     gvrs,
+		activity: activity,
+		reported: map[schema.GroupVersionResource]map[string]bool{},
+		listObjects: func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+			ctx = cacheclient.WithShardInContext(ctx, cacheshard.Wildcard)
+			list, err := client.Cluster(logicalcluster.Wildcard).Resource(gvr).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		},
+		deleteObject: func(ctx context.Context, gvr schema.GroupVersionResource, shard string, obj *unstructured.Unstructured) error {
+			ctx = cacheclient.WithShardInContext(ctx, cacheshard.New(shard))
+			uid := obj.GetUID()
+			return client.Cluster(logicalcluster.From(obj)).Resource(gvr).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{UID: &uid},
+			})
+		},
+	}
+}
+
+// Start runs the collector every interval until ctx is done.
+func (c *Collector) Start(ctx context.Context, interval time.Duration) {
+	logger := klog.FromContext(ctx).WithValues("component", "cache-server-retention")
+	ctx = klog.NewContext(ctx, logger)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.collect(ctx); err != nil {
+			logger.Error(err, "failed to collect replicated objects")
+		}
+	}, interval)
+}
+
+func (c *Collector) collect(ctx context.Context) error {
+	logger := klog.FromContext(ctx)
+
+	var errs []error
+	for _, gvr := range c.gvrs {
+		objs, err := c.listObjects(ctx, gvr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		counts, sizes := map[string]int{}, map[string]int{}
+		for i := range objs {
+			obj := &objs[i]
+			shard := obj.GetAnnotations()[replication.SourceShardAnnotationKey]
+			if shard != "" && c.ttl > 0 && c.activity.InactiveFor(shard) > c.ttl {
+				logger.V(2).Info("deleting replicated object of gone shard", "shard", shard, "resource", gvr.String(), "cluster", logicalcluster.From(obj), "namespace", obj.GetNamespace(), "name", obj.GetName())
+				if err := c.deleteObject(ctx, gvr, shard, obj); err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+					errs = append(errs, err)
+				} else {
+					collectedObjects.WithLabelValues(shard, gvr.GroupResource().String()).Inc()
+					continue
+				}
+			}
+
+			counts[shard]++
+			if bs, err := json.Marshal(obj.Object); err == nil {
+				sizes[shard] += len(bs)
+			}
+		}
+
+		c.report(gvr, counts, sizes)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// report sets the storage metrics of the resource, and resets those of shards without objects left.
+func (c *Collector) report(gvr schema.GroupVersionResource, counts, sizes map[string]int) {
+	resource := gvr.GroupResource().String()
+	reported := map[string]bool{}
+	for shard, count := range counts {
+		replicatedObjects.WithLabelValues(shard, resource).Set(float64(count))
+		replicatedBytes.WithLabelValues(shard, resource).Set(float64(sizes[shard]))
+		reported[shard] = true
+	}
+	for shard := range c.reported[gvr] {
+		if !reported[shard] {
+			replicatedObjects.WithLabelValues(shard, resource).Set(0)
+			replicatedBytes.WithLabelValues(shard, resource).Set(0)
+		}
+	}
+	c.reported[gvr] = reported
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
+)
+
+func TestShardActivity(t *testing.T) {
+	now := time.Unix(1000, 0)
+	a := NewShardActivity()
+	a.started = now
+	a.now = func() time.Time { return now }
+
+	now = now.Add(time.Minute)
+	require.Equal(t, time.Minute, a.InactiveFor("amber"), "unseen shards are inactive since the start")
+
+	a.watchStarted("amber")
+	a.watchStarted("amber")
+	now = now.Add(time.Hour)
+	require.Zero(t, a.InactiveFor("amber"), "watching shards are active")
+
+	a.watchEnded("amber")
+	now = now.Add(time.Hour)
+	require.Zero(t, a.InactiveFor("amber"), "shards with any watch are active")
+
+	a.watchEnded("amber")
+	now = now.Add(time.Minute)
+	require.Equal(t, time.Minute, a.InactiveFor("amber"), "shards are inactive since their last watch ended")
+}
+
+func TestCollect(t *testing.T) {
+	apiExports := schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"}
+	object := func(name, shard string) unstructured.Unstructured {
+		u := unstructured.Unstructured{}
+		u.SetName(name)
+		if shard != "" {
+			u.SetAnnotations(map[string]string{replication.SourceShardAnnotationKey: shard})
+		}
+		return u
+	}
+
+	for _, testCase := range []struct {
+		name string
+		ttl  time.Duration
+
+		wantDeleted []string
+	}{
+		{
+			name: "objects are kept without TTL",
+		},
+		{
+			name:        "objects of gone shards are deleted",
+			ttl:         time.Hour,
+			wantDeleted: []string{"sapphire/b", "sapphire/c"},
+		},
+		{
+			name: "objects of shards gone for less than the TTL are kept",
+			ttl:  3 * time.Hour,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			activity := NewShardActivity()
+			activity.started = now
+			activity.now = func() time.Time { return now }
+			activity.watchStarted("amber")
+			now = now.Add(2 * time.Hour)
+
+			var deleted []string
+			c := &Collector{
+				ttl:      testCase.ttl,
+				gvrs:     []schema.GroupVersionResource{apiExports},
+				activity: activity,
+				reported: map[schema.GroupVersionResource]map[string]bool{},
+				listObjects: func(ctx context.Context, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+					return []unstructured.Unstructured{object("a", "amber"), object("b", "sapphire"), object("c", "sapphire"), object("d", "")}, nil
+				},
+				deleteObject: func(ctx context.Context, gvr schema.GroupVersionResource, shard string, obj *unstructured.Unstructured) error {
+					deleted = append(deleted, shard+"/"+obj.GetName())
+					return nil
+				},
+			}
+
+			require.NoError(t, c.collect(context.Background()))
+			sort.Strings(deleted)
+			require.Equal(t, testCase.wantDeleted, deleted)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retention
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const subsystem = "cache_server"
+
+var (
+	replicatedObjects = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      subsystem,
+			Name:           "replicated_objects",
+			Help:           "Number of replicated objects stored in the cache server, partitioned by source shard and resource.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "resource"},
+	)
+
+	replicatedBytes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      subsystem,
+			Name:           "replicated_object_bytes",
+			Help:           "Size of the JSON serialization of the replicated objects stored in the cache server, partitioned by source shard and resource.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "resource"},
+	)
+
+	collectedObjects = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "collected_objects_total",
+			Help:           "Number of replicated objects deleted from the cache server because their source shard was gone, partitioned by source shard and resource.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "resource"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// Register registers the retention metrics in the legacy registry.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(replicatedObjects)
+		legacyregistry.MustRegister(replicatedBytes)
+		legacyregistry.MustRegister(collectedObjects)
+	})
+}
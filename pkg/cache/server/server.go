@@ -20,9 +20,13 @@ import (
 	"context"
 
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
 	"github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
+	"github.com/kcp-dev/kcp/pkg/cache/server/retention"
 	"github.com/kcp-dev/kcp/pkg/util"
 )
 
@@ -45,7 +49,7 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 	if err := server.GenericAPIServer.AddPostStartHook("bootstrap-cache-server", func(hookContext genericapiserver.PostStartHookContext) error {
 		logger = logger.WithValues("postStartHook", "bootstrap-cache-server")
-		if err = bootstrap.Bootstrap(klog.NewContext(util.GoContext(hookContext), logger), s.ApiExtensionsClusterClient); err != nil {
+		if err = bootstrap.Bootstrap(klog.NewContext(util.GoContext(hookContext), logger), s.ApiExtensionsClusterClient, s.Options.ReplicationPolicy.GroupVersionResources()); err != nil {
 			logger.Error(err, "failed creating the static CustomResourcesDefinitions")
 			// nolint:nilerr
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
@@ -68,5 +72,20 @@ func (s *Server) Run(ctx context.Context) error {
 	}); err != nil {
 		return err
 	}
+	retention.Register()
+	if err := server.GenericAPIServer.AddPostStartHook("cache-server-retention", func(hookContext genericapiserver.PostStartHookContext) error {
+		config := cacheclient.WithShardRoundTripper(rest.CopyConfig(hookContext.LoopbackClientConfig))
+		client, err := dynamic.NewClusterForConfig(config)
+		if err != nil {
+			return err
+		}
+		collector := retention.NewCollector(client, s.Options.ReplicationPolicy.GroupVersionResources(), s.ShardActivity, s.Options.Retention.ShardTTL)
+		logger := klog.FromContext(ctx).WithValues("postStartHook", "cache-server-retention")
+		go collector.Start(klog.NewContext(util.GoContext(hookContext), logger), s.Options.Retention.SweepInterval)
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	return server.GenericAPIServer.PrepareRun().Run(ctx.Done())
 }
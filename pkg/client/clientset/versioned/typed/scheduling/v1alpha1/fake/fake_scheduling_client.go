@@ -37,6 +37,10 @@ func (c *FakeSchedulingV1alpha1) Placements() v1alpha1.PlacementInterface {
 	return &FakePlacements{c}
 }
 
+func (c *FakeSchedulingV1alpha1) PlacementPriorities() v1alpha1.PlacementPriorityInterface {
+	return &FakePlacementPriorities{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeSchedulingV1alpha1) RESTClient() rest.Interface {
@@ -21,3 +21,5 @@ package v1alpha1
 type LocationExpansion interface{}
 
 type PlacementExpansion interface{}
+
+type PlacementPriorityExpansion interface{}
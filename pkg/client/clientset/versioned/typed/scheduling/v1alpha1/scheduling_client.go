@@ -33,6 +33,7 @@ type SchedulingV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	LocationsGetter
 	PlacementsGetter
+	PlacementPrioritiesGetter
 }
 
 // SchedulingV1alpha1Client is used to interact with features provided by the scheduling.kcp.dev group.
@@ -49,6 +50,10 @@ func (c *SchedulingV1alpha1Client) Placements() PlacementInterface {
 	return newPlacements(c)
 }
 
+func (c *SchedulingV1alpha1Client) PlacementPriorities() PlacementPriorityInterface {
+	return newPlacementPriorities(c)
+}
+
 // NewForConfig creates a new SchedulingV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
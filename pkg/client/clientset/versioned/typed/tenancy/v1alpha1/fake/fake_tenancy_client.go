@@ -29,6 +29,10 @@ type FakeTenancyV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeTenancyV1alpha1) AccessGrants() v1alpha1.AccessGrantInterface {
+	return &FakeAccessGrants{c}
+}
+
 func (c *FakeTenancyV1alpha1) ClusterWorkspaces() v1alpha1.ClusterWorkspaceInterface {
 	return &FakeClusterWorkspaces{c}
 }
@@ -41,6 +45,42 @@ func (c *FakeTenancyV1alpha1) ClusterWorkspaceTypes() v1alpha1.ClusterWorkspaceT
 	return &FakeClusterWorkspaceTypes{c}
 }
 
+func (c *FakeTenancyV1alpha1) ServiceAccountGrants() v1alpha1.ServiceAccountGrantInterface {
+	return &FakeServiceAccountGrants{c}
+}
+
+func (c *FakeTenancyV1alpha1) SharedSecrets() v1alpha1.SharedSecretInterface {
+	return &FakeSharedSecrets{c}
+}
+
+func (c *FakeTenancyV1alpha1) Teams() v1alpha1.TeamInterface {
+	return &FakeTeams{c}
+}
+
+func (c *FakeTenancyV1alpha1) ValidatingAdmissionPolicies() v1alpha1.ValidatingAdmissionPolicyInterface {
+	return &FakeValidatingAdmissionPolicies{c}
+}
+
+func (c *FakeTenancyV1alpha1) ValidatingAdmissionPolicyBindings() v1alpha1.ValidatingAdmissionPolicyBindingInterface {
+	return &FakeValidatingAdmissionPolicyBindings{c}
+}
+
+func (c *FakeTenancyV1alpha1) WorkspaceAuthenticationConfigurations() v1alpha1.WorkspaceAuthenticationConfigurationInterface {
+	return &FakeWorkspaceAuthenticationConfigurations{c}
+}
+
+func (c *FakeTenancyV1alpha1) WorkspaceMigrations() v1alpha1.WorkspaceMigrationInterface {
+	return &FakeWorkspaceMigrations{c}
+}
+
+func (c *FakeTenancyV1alpha1) WorkspacePolicies() v1alpha1.WorkspacePolicyInterface {
+	return &FakeWorkspacePolicies{c}
+}
+
+func (c *FakeTenancyV1alpha1) WorkspaceQuotas() v1alpha1.WorkspaceQuotaInterface {
+	return &FakeWorkspaceQuotas{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeTenancyV1alpha1) RESTClient() rest.Interface {
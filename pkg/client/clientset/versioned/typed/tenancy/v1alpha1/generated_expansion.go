@@ -18,8 +18,28 @@ limitations under the License.
 
 package v1alpha1
 
+type AccessGrantExpansion interface{}
+
 type ClusterWorkspaceExpansion interface{}
 
 type ClusterWorkspaceShardExpansion interface{}
 
 type ClusterWorkspaceTypeExpansion interface{}
+
+type ServiceAccountGrantExpansion interface{}
+
+type SharedSecretExpansion interface{}
+
+type TeamExpansion interface{}
+
+type ValidatingAdmissionPolicyExpansion interface{}
+
+type ValidatingAdmissionPolicyBindingExpansion interface{}
+
+type WorkspaceAuthenticationConfigurationExpansion interface{}
+
+type WorkspaceMigrationExpansion interface{}
+
+type WorkspacePolicyExpansion interface{}
+
+type WorkspaceQuotaExpansion interface{}
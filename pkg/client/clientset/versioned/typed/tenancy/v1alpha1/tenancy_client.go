@@ -31,9 +31,19 @@ import (
 
 type TenancyV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	AccessGrantsGetter
 	ClusterWorkspacesGetter
 	ClusterWorkspaceShardsGetter
 	ClusterWorkspaceTypesGetter
+	ServiceAccountGrantsGetter
+	SharedSecretsGetter
+	TeamsGetter
+	ValidatingAdmissionPoliciesGetter
+	ValidatingAdmissionPolicyBindingsGetter
+	WorkspaceAuthenticationConfigurationsGetter
+	WorkspaceMigrationsGetter
+	WorkspacePoliciesGetter
+	WorkspaceQuotasGetter
 }
 
 // TenancyV1alpha1Client is used to interact with features provided by the tenancy.kcp.dev group.
@@ -42,6 +52,10 @@ type TenancyV1alpha1Client struct {
 	cluster    v2.Name
 }
 
+func (c *TenancyV1alpha1Client) AccessGrants() AccessGrantInterface {
+	return newAccessGrants(c)
+}
+
 func (c *TenancyV1alpha1Client) ClusterWorkspaces() ClusterWorkspaceInterface {
 	return newClusterWorkspaces(c)
 }
@@ -54,6 +68,42 @@ func (c *TenancyV1alpha1Client) ClusterWorkspaceTypes() ClusterWorkspaceTypeInte
 	return newClusterWorkspaceTypes(c)
 }
 
+func (c *TenancyV1alpha1Client) ServiceAccountGrants() ServiceAccountGrantInterface {
+	return newServiceAccountGrants(c)
+}
+
+func (c *TenancyV1alpha1Client) SharedSecrets() SharedSecretInterface {
+	return newSharedSecrets(c)
+}
+
+func (c *TenancyV1alpha1Client) Teams() TeamInterface {
+	return newTeams(c)
+}
+
+func (c *TenancyV1alpha1Client) ValidatingAdmissionPolicies() ValidatingAdmissionPolicyInterface {
+	return newValidatingAdmissionPolicies(c)
+}
+
+func (c *TenancyV1alpha1Client) ValidatingAdmissionPolicyBindings() ValidatingAdmissionPolicyBindingInterface {
+	return newValidatingAdmissionPolicyBindings(c)
+}
+
+func (c *TenancyV1alpha1Client) WorkspaceAuthenticationConfigurations() WorkspaceAuthenticationConfigurationInterface {
+	return newWorkspaceAuthenticationConfigurations(c)
+}
+
+func (c *TenancyV1alpha1Client) WorkspaceMigrations() WorkspaceMigrationInterface {
+	return newWorkspaceMigrations(c)
+}
+
+func (c *TenancyV1alpha1Client) WorkspacePolicies() WorkspacePolicyInterface {
+	return newWorkspacePolicies(c)
+}
+
+func (c *TenancyV1alpha1Client) WorkspaceQuotas() WorkspaceQuotaInterface {
+	return newWorkspaceQuotas(c)
+}
+
 // NewForConfig creates a new TenancyV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
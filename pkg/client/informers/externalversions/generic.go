@@ -77,14 +77,36 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Scheduling().V1alpha1().Locations().Informer()}, nil
 	case schedulingv1alpha1.SchemeGroupVersion.WithResource("placements"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Scheduling().V1alpha1().Placements().Informer()}, nil
+	case schedulingv1alpha1.SchemeGroupVersion.WithResource("placementpriorities"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Scheduling().V1alpha1().PlacementPriorities().Informer()}, nil
 
 		// Group=tenancy.kcp.dev, Version=v1alpha1
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("accessgrants"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().AccessGrants().Informer()}, nil
 	case tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterWorkspaces().Informer()}, nil
 	case tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaceshards"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterWorkspaceShards().Informer()}, nil
 	case tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspacetypes"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterWorkspaceTypes().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("serviceaccountgrants"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ServiceAccountGrants().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("sharedsecrets"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().SharedSecrets().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("teams"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().Teams().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("validatingadmissionpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ValidatingAdmissionPolicies().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("validatingadmissionpolicybindings"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ValidatingAdmissionPolicyBindings().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("workspaceauthenticationconfigurations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().WorkspaceAuthenticationConfigurations().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("workspacemigrations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().WorkspaceMigrations().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("workspacepolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().WorkspacePolicies().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("workspacequotas"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().WorkspaceQuotas().Informer()}, nil
 
 		// Group=tenancy.kcp.dev, Version=v1beta1
 	case v1beta1.SchemeGroupVersion.WithResource("workspaces"):
@@ -28,6 +28,8 @@ type Interface interface {
 	Locations() LocationInformer
 	// Placements returns a PlacementInformer.
 	Placements() PlacementInformer
+	// PlacementPriorities returns a PlacementPriorityInformer.
+	PlacementPriorities() PlacementPriorityInformer
 }
 
 type version struct {
@@ -50,3 +52,8 @@ func (v *version) Locations() LocationInformer {
 func (v *version) Placements() PlacementInformer {
 	return &placementInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// PlacementPriorities returns a PlacementPriorityInformer.
+func (v *version) PlacementPriorities() PlacementPriorityInformer {
+	return &placementPriorityInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -24,12 +24,32 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// AccessGrants returns a AccessGrantInformer.
+	AccessGrants() AccessGrantInformer
 	// ClusterWorkspaces returns a ClusterWorkspaceInformer.
 	ClusterWorkspaces() ClusterWorkspaceInformer
 	// ClusterWorkspaceShards returns a ClusterWorkspaceShardInformer.
 	ClusterWorkspaceShards() ClusterWorkspaceShardInformer
 	// ClusterWorkspaceTypes returns a ClusterWorkspaceTypeInformer.
 	ClusterWorkspaceTypes() ClusterWorkspaceTypeInformer
+	// ServiceAccountGrants returns a ServiceAccountGrantInformer.
+	ServiceAccountGrants() ServiceAccountGrantInformer
+	// SharedSecrets returns a SharedSecretInformer.
+	SharedSecrets() SharedSecretInformer
+	// Teams returns a TeamInformer.
+	Teams() TeamInformer
+	// ValidatingAdmissionPolicies returns a ValidatingAdmissionPolicyInformer.
+	ValidatingAdmissionPolicies() ValidatingAdmissionPolicyInformer
+	// ValidatingAdmissionPolicyBindings returns a ValidatingAdmissionPolicyBindingInformer.
+	ValidatingAdmissionPolicyBindings() ValidatingAdmissionPolicyBindingInformer
+	// WorkspaceAuthenticationConfigurations returns a WorkspaceAuthenticationConfigurationInformer.
+	WorkspaceAuthenticationConfigurations() WorkspaceAuthenticationConfigurationInformer
+	// WorkspaceMigrations returns a WorkspaceMigrationInformer.
+	WorkspaceMigrations() WorkspaceMigrationInformer
+	// WorkspacePolicies returns a WorkspacePolicyInformer.
+	WorkspacePolicies() WorkspacePolicyInformer
+	// WorkspaceQuotas returns a WorkspaceQuotaInformer.
+	WorkspaceQuotas() WorkspaceQuotaInformer
 }
 
 type version struct {
@@ -43,6 +63,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// AccessGrants returns a AccessGrantInformer.
+func (v *version) AccessGrants() AccessGrantInformer {
+	return &accessGrantInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ClusterWorkspaces returns a ClusterWorkspaceInformer.
 func (v *version) ClusterWorkspaces() ClusterWorkspaceInformer {
 	return &clusterWorkspaceInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -57,3 +82,48 @@ func (v *version) ClusterWorkspaceShards() ClusterWorkspaceShardInformer {
 func (v *version) ClusterWorkspaceTypes() ClusterWorkspaceTypeInformer {
 	return &clusterWorkspaceTypeInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// ServiceAccountGrants returns a ServiceAccountGrantInformer.
+func (v *version) ServiceAccountGrants() ServiceAccountGrantInformer {
+	return &serviceAccountGrantInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// SharedSecrets returns a SharedSecretInformer.
+func (v *version) SharedSecrets() SharedSecretInformer {
+	return &sharedSecretInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// Teams returns a TeamInformer.
+func (v *version) Teams() TeamInformer {
+	return &teamInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ValidatingAdmissionPolicies returns a ValidatingAdmissionPolicyInformer.
+func (v *version) ValidatingAdmissionPolicies() ValidatingAdmissionPolicyInformer {
+	return &validatingAdmissionPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ValidatingAdmissionPolicyBindings returns a ValidatingAdmissionPolicyBindingInformer.
+func (v *version) ValidatingAdmissionPolicyBindings() ValidatingAdmissionPolicyBindingInformer {
+	return &validatingAdmissionPolicyBindingInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspaceAuthenticationConfigurations returns a WorkspaceAuthenticationConfigurationInformer.
+func (v *version) WorkspaceAuthenticationConfigurations() WorkspaceAuthenticationConfigurationInformer {
+	return &workspaceAuthenticationConfigurationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspaceMigrations returns a WorkspaceMigrationInformer.
+func (v *version) WorkspaceMigrations() WorkspaceMigrationInformer {
+	return &workspaceMigrationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspacePolicies returns a WorkspacePolicyInformer.
+func (v *version) WorkspacePolicies() WorkspacePolicyInformer {
+	return &workspacePolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspaceQuotas returns a WorkspaceQuotaInformer.
+func (v *version) WorkspaceQuotas() WorkspaceQuotaInformer {
+	return &workspaceQuotaInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
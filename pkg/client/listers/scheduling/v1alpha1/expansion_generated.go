@@ -25,3 +25,7 @@ type LocationListerExpansion interface{}
 // PlacementListerExpansion allows custom methods to be added to
 // PlacementLister.
 type PlacementListerExpansion interface{}
+
+// PlacementPriorityListerExpansion allows custom methods to be added to
+// PlacementPriorityLister.
+type PlacementPriorityListerExpansion interface{}
@@ -18,6 +18,10 @@ limitations under the License.
 
 package v1alpha1
 
+// AccessGrantListerExpansion allows custom methods to be added to
+// AccessGrantLister.
+type AccessGrantListerExpansion interface{}
+
 // ClusterWorkspaceListerExpansion allows custom methods to be added to
 // ClusterWorkspaceLister.
 type ClusterWorkspaceListerExpansion interface{}
@@ -29,3 +33,39 @@ type ClusterWorkspaceShardListerExpansion interface{}
 // ClusterWorkspaceTypeListerExpansion allows custom methods to be added to
 // ClusterWorkspaceTypeLister.
 type ClusterWorkspaceTypeListerExpansion interface{}
+
+// ServiceAccountGrantListerExpansion allows custom methods to be added to
+// ServiceAccountGrantLister.
+type ServiceAccountGrantListerExpansion interface{}
+
+// SharedSecretListerExpansion allows custom methods to be added to
+// SharedSecretLister.
+type SharedSecretListerExpansion interface{}
+
+// TeamListerExpansion allows custom methods to be added to
+// TeamLister.
+type TeamListerExpansion interface{}
+
+// ValidatingAdmissionPolicyListerExpansion allows custom methods to be added to
+// ValidatingAdmissionPolicyLister.
+type ValidatingAdmissionPolicyListerExpansion interface{}
+
+// ValidatingAdmissionPolicyBindingListerExpansion allows custom methods to be added to
+// ValidatingAdmissionPolicyBindingLister.
+type ValidatingAdmissionPolicyBindingListerExpansion interface{}
+
+// WorkspaceAuthenticationConfigurationListerExpansion allows custom methods to be added to
+// WorkspaceAuthenticationConfigurationLister.
+type WorkspaceAuthenticationConfigurationListerExpansion interface{}
+
+// WorkspaceMigrationListerExpansion allows custom methods to be added to
+// WorkspaceMigrationLister.
+type WorkspaceMigrationListerExpansion interface{}
+
+// WorkspacePolicyListerExpansion allows custom methods to be added to
+// WorkspacePolicyLister.
+type WorkspacePolicyListerExpansion interface{}
+
+// WorkspaceQuotaListerExpansion allows custom methods to be added to
+// WorkspaceQuotaLister.
+type WorkspaceQuotaListerExpansion interface{}
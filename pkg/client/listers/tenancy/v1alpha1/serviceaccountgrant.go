@@ -0,0 +1,69 @@
+/*
+Copyright The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	v1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// ServiceAccountGrantLister helps list ServiceAccountGrants.
+// All objects returned here must be treated as read-only.
+type ServiceAccountGrantLister interface {
+	// List lists all ServiceAccountGrants in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.ServiceAccountGrant, err error)
+	// Get retrieves the ServiceAccountGrant from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.ServiceAccountGrant, error)
+	ServiceAccountGrantListerExpansion
+}
+
+// serviceAccountGrantLister implements the ServiceAccountGrantLister interface.
+type serviceAccountGrantLister struct {
+	indexer cache.Indexer
+}
+
+// NewServiceAccountGrantLister returns a new ServiceAccountGrantLister.
+func NewServiceAccountGrantLister(indexer cache.Indexer) ServiceAccountGrantLister {
+	return &serviceAccountGrantLister{indexer: indexer}
+}
+
+// List lists all ServiceAccountGrants in the indexer.
+func (s *serviceAccountGrantLister) List(selector labels.Selector) (ret []*v1alpha1.ServiceAccountGrant, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ServiceAccountGrant))
+	})
+	return ret, err
+}
+
+// Get retrieves the ServiceAccountGrant from the index for a given name.
+func (s *serviceAccountGrantLister) Get(name string) (*v1alpha1.ServiceAccountGrant, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("serviceaccountgrant"), name)
+	}
+	return obj.(*v1alpha1.ServiceAccountGrant), nil
+}
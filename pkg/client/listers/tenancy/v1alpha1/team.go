@@ -0,0 +1,69 @@
+/*
+Copyright The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	v1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// TeamLister helps list Teams.
+// All objects returned here must be treated as read-only.
+type TeamLister interface {
+	// List lists all Teams in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.Team, err error)
+	// Get retrieves the Team from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.Team, error)
+	TeamListerExpansion
+}
+
+// teamLister implements the TeamLister interface.
+type teamLister struct {
+	indexer cache.Indexer
+}
+
+// NewTeamLister returns a new TeamLister.
+func NewTeamLister(indexer cache.Indexer) TeamLister {
+	return &teamLister{indexer: indexer}
+}
+
+// List lists all Teams in the indexer.
+func (s *teamLister) List(selector labels.Selector) (ret []*v1alpha1.Team, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.Team))
+	})
+	return ret, err
+}
+
+// Get retrieves the Team from the index for a given name.
+func (s *teamLister) Get(name string) (*v1alpha1.Team, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("team"), name)
+	}
+	return obj.(*v1alpha1.Team), nil
+}
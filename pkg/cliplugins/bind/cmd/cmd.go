@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/bind/plugin"
+)
+
+var (
+	bindAPIExportExample = `
+	# Bind the APIExport widgets of the workspace root:org:providers in the current workspace.
+	%[1]s bind apiexport root:org:providers:widgets
+
+	# Bind it without prompting, accepting the permission claim for configmaps and rejecting the one for secrets.
+	%[1]s bind apiexport root:org:providers:widgets --accept-permission-claim configmaps --reject-permission-claim secrets --interactive=false
+`
+)
+
+// New provides a command for binding APIs.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	opts := plugin.NewOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:              "bind",
+		Short:            "Binds APIs into the current workspace",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	opts.BindFlags(cmd)
+
+	var (
+		bindingName    string
+		acceptedClaims []string
+		rejectedClaims []string
+		interactive    = true
+		timeout        = 5 * time.Minute
+	)
+	apiExportCmd := &cobra.Command{
+		Use:          "apiexport <workspace_path:apiexport-name>",
+		Short:        "Bind to an APIExport",
+		Long:         "Creates an APIBinding for the APIExport in the current workspace, accepts or rejects the permission claims of the APIExport, and waits for the APIBinding to be bound.",
+		Example:      fmt.Sprintf(bindAPIExportExample, "kubectl kcp"),
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			config, err := plugin.NewConfig(opts)
+			if err != nil {
+				return err
+			}
+			return config.BindAPIExport(c.Context(), args[0], bindingName, acceptedClaims, rejectedClaims, interactive, timeout)
+		},
+	}
+	apiExportCmd.Flags().StringVar(&bindingName, "name", bindingName, "Name of the APIBinding. Defaults to the name of the APIExport.")
+	apiExportCmd.Flags().StringSliceVar(&acceptedClaims, "accept-permission-claim", acceptedClaims, "Permission claims of the APIExport to accept, as <resource>.<group>, or <resource> for the core group.")
+	apiExportCmd.Flags().StringSliceVar(&rejectedClaims, "reject-permission-claim", rejectedClaims, "Permission claims of the APIExport to reject, as <resource>.<group>, or <resource> for the core group.")
+	apiExportCmd.Flags().BoolVar(&interactive, "interactive", interactive, "Ask whether to accept the permission claims that are neither accepted nor rejected by flags. Otherwise they stay pending.")
+	apiExportCmd.Flags().DurationVar(&timeout, "timeout", timeout, "How long to wait for the APIBinding to be bound.")
+
+	cmd.AddCommand(apiExportCmd)
+	return cmd
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// pollInterval is the interval in which the APIBinding is checked while waiting.
+var pollInterval = time.Second
+
+// BindAPIExport binds the APIExport <path>:<name>, or <name> in the current workspace, in the current
+// workspace. Pending permission claims of the APIExport are accepted or rejected as given by their
+// <resource>.<group>, or else by asking the user if interactive. Then it waits for the APIBinding to
+// be bound.
+func (c *Config) BindAPIExport(ctx context.Context, exportRef, bindingName string, acceptedClaims, rejectedClaims []string, interactive bool, timeout time.Duration) error {
+	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+	kcpClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kcp client: %w", err)
+	}
+
+	return c.bindAPIExport(ctx, kcpClient, exportRef, bindingName, acceptedClaims, rejectedClaims, interactive, timeout)
+}
+
+func (c *Config) bindAPIExport(ctx context.Context, kcpClient kcpclient.Interface, exportRef, bindingName string, acceptedClaims, rejectedClaims []string, interactive bool, timeout time.Duration) error {
+	accepted, rejected := sets.NewString(acceptedClaims...), sets.NewString(rejectedClaims...)
+	if both := accepted.Intersection(rejected); both.Len() > 0 {
+		return fmt.Errorf("permission claims cannot be both accepted and rejected: %s", strings.Join(both.List(), ", "))
+	}
+
+	reference := apisv1alpha1.WorkspaceExportReference{ExportName: exportRef}
+	if i := strings.LastIndex(exportRef, ":"); i >= 0 {
+		reference = apisv1alpha1.WorkspaceExportReference{Path: exportRef[:i], ExportName: exportRef[i+1:]}
+	}
+	if reference.ExportName == "" {
+		return fmt.Errorf("invalid APIExport reference %q, expected <path>:<name> or <name>", exportRef)
+	}
+	if bindingName == "" {
+		bindingName = reference.ExportName
+	}
+
+	binding, err := kcpClient.ApisV1alpha1().APIBindings().Create(ctx, &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: bindingName},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{Workspace: &reference},
+		},
+	}, metav1.CreateOptions{})
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		binding, err = kcpClient.ApisV1alpha1().APIBindings().Get(ctx, bindingName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if existing := binding.Spec.Reference.Workspace; existing == nil || *existing != reference {
+			return fmt.Errorf("APIBinding %s already exists for another APIExport", bindingName)
+		}
+		fmt.Fprintf(c.Out, "apibinding %s already exists\n", bindingName) // nolint: errcheck
+	case err != nil:
+		return fmt.Errorf("failed to create APIBinding %s: %w", bindingName, err)
+	default:
+		fmt.Fprintf(c.Out, "apibinding %s created\n", bindingName) // nolint: errcheck
+	}
+
+	binding, err = c.waitForAPIBinding(ctx, kcpClient, bindingName, timeout, func(binding *apisv1alpha1.APIBinding) bool {
+		return binding.IsBound()
+	})
+	if err != nil {
+		return err
+	}
+
+	// decide the pending permission claims
+	var decisions []apisv1alpha1.AcceptablePermissionClaim
+	var pending []string
+	var in *bufio.Reader
+	if interactive {
+		in = bufio.NewReader(c.In)
+	}
+	for _, claim := range binding.Status.PermissionClaims {
+		if claim.State != apisv1alpha1.ClaimPending {
+			continue
+		}
+		name := helpers.PermissionClaimName(claim.PermissionClaim)
+		state := apisv1alpha1.ClaimPending
+		switch {
+		case accepted.Has(name):
+			state = apisv1alpha1.ClaimAccepted
+		case rejected.Has(name):
+			state = apisv1alpha1.ClaimRejected
+		case interactive:
+			accept, err := c.askForPermissionClaim(in, exportRef, claim.PermissionClaim)
+			if err != nil {
+				return err
+			}
+			state = apisv1alpha1.ClaimRejected
+			if accept {
+				state = apisv1alpha1.ClaimAccepted
+			}
+		}
+		accepted.Delete(name)
+		rejected.Delete(name)
+		if state == apisv1alpha1.ClaimPending {
+			pending = append(pending, name)
+			continue
+		}
+		decisions = append(decisions, apisv1alpha1.AcceptablePermissionClaim{PermissionClaim: claim.PermissionClaim, State: state})
+	}
+	if unknown := accepted.Union(rejected); unknown.Len() > 0 {
+		return fmt.Errorf("APIExport %s has no pending permission claims for %s", exportRef, strings.Join(unknown.List(), ", "))
+	}
+
+	if len(decisions) > 0 {
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			binding, err := kcpClient.ApisV1alpha1().APIBindings().Get(ctx, bindingName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			binding.Spec.PermissionClaims = append(binding.Spec.PermissionClaims, decisions...)
+			_, err = kcpClient.ApisV1alpha1().APIBindings().Update(ctx, binding, metav1.UpdateOptions{})
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to update the permission claims of APIBinding %s: %w", bindingName, err)
+		}
+		for _, decision := range decisions {
+			fmt.Fprintf(c.Out, "permission claim %s %s\n", helpers.PermissionClaimName(decision.PermissionClaim), strings.ToLower(string(decision.State))) // nolint: errcheck
+		}
+	}
+
+	if len(pending) > 0 {
+		fmt.Fprintf(c.Out, "apibinding %s is bound, but permission claims are pending: %s\n", bindingName, strings.Join(pending, ", ")) // nolint: errcheck
+		return nil
+	}
+
+	if _, err := c.waitForAPIBinding(ctx, kcpClient, bindingName, timeout, func(binding *apisv1alpha1.APIBinding) bool {
+		return binding.Status.Phase == apisv1alpha1.APIBindingPhaseBound
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Out, "apibinding %s is bound\n", bindingName) // nolint: errcheck
+	return nil
+}
+
+// waitForAPIBinding waits until done returns true for the APIBinding, and prints its phase whenever it
+// changes. It fails early if the binding failed.
+func (c *Config) waitForAPIBinding(ctx context.Context, kcpClient kcpclient.Interface, name string, timeout time.Duration, done func(binding *apisv1alpha1.APIBinding) bool) (*apisv1alpha1.APIBinding, error) {
+	var binding *apisv1alpha1.APIBinding
+	var lastPhase apisv1alpha1.APIBindingPhaseType
+	err := wait.PollImmediateWithContext(ctx, pollInterval, timeout, func(ctx context.Context) (bool, error) {
+		var err error
+		binding, err = kcpClient.ApisV1alpha1().APIBindings().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if done(binding) {
+			return true, nil
+		}
+		if binding.Status.Phase == apisv1alpha1.APIBindingPhaseFailed {
+			return false, fmt.Errorf("APIBinding %s failed to bind: %s", name, failureMessage(binding))
+		}
+		if phase := binding.Status.Phase; phase != lastPhase && phase != "" {
+			fmt.Fprintf(c.Out, "apibinding %s is %s\n", name, phase) // nolint: errcheck
+			lastPhase = phase
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return nil, fmt.Errorf("timed out waiting for APIBinding %s, it is %s", name, binding.Status.Phase)
+	}
+	return binding, err
+}
+
+func (c *Config) askForPermissionClaim(in *bufio.Reader, exportRef string, claim apisv1alpha1.PermissionClaim) (bool, error) {
+	verbs := "all verbs"
+	if len(claim.Verbs) > 0 {
+		verbs = strings.Join(claim.Verbs, ", ")
+	}
+	objects := "all objects"
+	if claim.ResourceSelector != nil {
+		objects = "selected objects"
+	}
+	fmt.Fprintf(c.Out, "APIExport %s claims access to %s (%s, %s). Accept? [y/N]: ", exportRef, helpers.PermissionClaimName(claim), verbs, objects) // nolint: errcheck
+
+	answer, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func failureMessage(binding *apisv1alpha1.APIBinding) string {
+	for _, c := range binding.Status.Conditions {
+		if c.Status == corev1.ConditionFalse && c.Message != "" {
+			return c.Message
+		}
+	}
+	return "unknown reason"
+}
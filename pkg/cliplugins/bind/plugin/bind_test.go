@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientgotesting "k8s.io/client-go/testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestBindAPIExport(t *testing.T) {
+	configmaps := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}
+	secrets := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}, Verbs: []string{"get"}}
+
+	tests := map[string]struct {
+		exportRef   string
+		accepted    []string
+		rejected    []string
+		interactive bool
+		input       string
+
+		wantReference *apisv1alpha1.WorkspaceExportReference
+		wantClaims    []apisv1alpha1.AcceptablePermissionClaim
+		wantOutput    string
+		wantErr       string
+	}{
+		"claims decided by flags": {
+			exportRef:     "root:org:providers:widgets",
+			accepted:      []string{"configmaps"},
+			rejected:      []string{"secrets"},
+			wantReference: &apisv1alpha1.WorkspaceExportReference{Path: "root:org:providers", ExportName: "widgets"},
+			wantClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimAccepted},
+				{PermissionClaim: secrets, State: apisv1alpha1.ClaimRejected},
+			},
+			wantOutput: "apibinding widgets is bound\n",
+		},
+		"claims decided interactively": {
+			exportRef:     "widgets",
+			accepted:      []string{"secrets"},
+			interactive:   true,
+			input:         "y\n",
+			wantReference: &apisv1alpha1.WorkspaceExportReference{ExportName: "widgets"},
+			wantClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimAccepted},
+				{PermissionClaim: secrets, State: apisv1alpha1.ClaimAccepted},
+			},
+			wantOutput: "apibinding widgets is bound\n",
+		},
+		"claims left pending": {
+			exportRef:     "root:org:providers:widgets",
+			accepted:      []string{"configmaps"},
+			wantReference: &apisv1alpha1.WorkspaceExportReference{Path: "root:org:providers", ExportName: "widgets"},
+			wantClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimAccepted},
+			},
+			wantOutput: "permission claims are pending: secrets\n",
+		},
+		"unknown claim": {
+			exportRef: "root:org:providers:widgets",
+			accepted:  []string{"deployments.apps"},
+			wantErr:   "no pending permission claims for deployments.apps",
+		},
+		"claim accepted and rejected": {
+			exportRef: "root:org:providers:widgets",
+			accepted:  []string{"secrets"},
+			rejected:  []string{"secrets"},
+			wantErr:   "cannot be both accepted and rejected: secrets",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := kcpfake.NewSimpleClientset()
+			// bind the APIs on creation, and update the phase on updates like the APIBinding controller
+			client.PrependReactor("create", "apibindings", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				binding := action.(clientgotesting.CreateAction).GetObject().(*apisv1alpha1.APIBinding)
+				binding.Status.Phase = apisv1alpha1.APIBindingPhasePermissionClaimsPending
+				binding.Status.PermissionClaims = []apisv1alpha1.PermissionClaimStatus{
+					{PermissionClaim: configmaps, State: apisv1alpha1.ClaimPending},
+					{PermissionClaim: secrets, State: apisv1alpha1.ClaimPending},
+				}
+				return false, nil, nil
+			})
+			client.PrependReactor("update", "apibindings", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				binding := action.(clientgotesting.UpdateAction).GetObject().(*apisv1alpha1.APIBinding)
+				binding.Status.Phase = apisv1alpha1.APIBindingPhaseBound
+				for i := range binding.Status.PermissionClaims {
+					binding.Status.PermissionClaims[i].State = binding.PermissionClaimState(binding.Status.PermissionClaims[i].PermissionClaim)
+					if binding.Status.PermissionClaims[i].State == apisv1alpha1.ClaimPending {
+						binding.Status.Phase = apisv1alpha1.APIBindingPhasePermissionClaimsPending
+					}
+				}
+				return false, nil, nil
+			})
+
+			out := &bytes.Buffer{}
+			c := &Config{IOStreams: genericclioptions.IOStreams{In: strings.NewReader(tt.input), Out: out, ErrOut: out}}
+			err := c.bindAPIExport(context.Background(), client, tt.exportRef, "", tt.accepted, tt.rejected, tt.interactive, time.Second)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, strings.HasSuffix(out.String(), tt.wantOutput), "unexpected output:\n%s", out.String())
+
+			binding, err := client.ApisV1alpha1().APIBindings().Get(context.Background(), "widgets", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantReference, binding.Spec.Reference.Workspace)
+			require.Equal(t, tt.wantClaims, binding.Spec.PermissionClaims)
+		})
+	}
+}
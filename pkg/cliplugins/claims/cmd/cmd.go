@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/claims/plugin"
+)
+
+var (
+	claimsExample = `
+	# List the permission claims of all APIBindings in the current workspace with their state.
+	%[1]s claims inspect
+
+	# List the permission claims of the APIBinding widgets.
+	%[1]s claims inspect widgets
+
+	# Accept the permission claims for configmaps and deployments.apps of the APIBinding widgets.
+	%[1]s claims accept widgets configmaps deployments.apps
+
+	# Reject the permission claim for secrets of the APIBinding widgets.
+	%[1]s claims reject widgets secrets
+`
+)
+
+// New provides a command for inspecting and deciding permission claims of APIBindings.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	opts := plugin.NewOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:              "claims",
+		Short:            "Inspects, accepts and rejects permission claims of APIBindings",
+		Example:          fmt.Sprintf(claimsExample, "kubectl kcp"),
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	opts.BindFlags(cmd)
+
+	inspectCmd := &cobra.Command{
+		Use:          "inspect [apibinding-name]",
+		Short:        "List permission claims and their state",
+		Long:         "Lists the permission claims of the APIExports bound by the given APIBinding, or by all APIBindings in the current workspace, and whether they are pending, accepted or rejected.",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			config, err := newConfig(opts)
+			if err != nil {
+				return err
+			}
+			var bindingName string
+			if len(args) > 0 {
+				bindingName = args[0]
+			}
+			return config.InspectPermissionClaims(c.Context(), bindingName)
+		},
+	}
+
+	acceptCmd := &cobra.Command{
+		Use:          "accept <apibinding-name> <claim>...",
+		Short:        "Accept permission claims",
+		Long:         "Accepts the given permission claims of an APIBinding, given as <resource>.<group>, or <resource> for the core group, optionally followed by :<identity-hash>.",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			config, err := newConfig(opts)
+			if err != nil {
+				return err
+			}
+			return config.AcceptPermissionClaims(c.Context(), args[0], args[1:])
+		},
+	}
+
+	rejectCmd := &cobra.Command{
+		Use:          "reject <apibinding-name> <claim>...",
+		Short:        "Reject permission claims",
+		Long:         "Rejects the given permission claims of an APIBinding, given as <resource>.<group>, or <resource> for the core group, optionally followed by :<identity-hash>. Rejecting an accepted claim revokes the access of the API service provider.",
+		SilenceUsage: true,
+		Args:         cobra.MinimumNArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			config, err := newConfig(opts)
+			if err != nil {
+				return err
+			}
+			return config.RejectPermissionClaims(c.Context(), args[0], args[1:])
+		},
+	}
+
+	cmd.AddCommand(inspectCmd, acceptCmd, rejectCmd)
+	return cmd
+}
+
+func newConfig(opts *plugin.Options) (*plugin.Config, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return plugin.NewConfig(opts)
+}
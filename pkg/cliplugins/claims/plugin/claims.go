@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// InspectPermissionClaims prints the permission claims of the given APIBinding, or of all APIBindings
+// in the current workspace, together with their acceptance state.
+func (c *Config) InspectPermissionClaims(ctx context.Context, bindingName string) error {
+	kcpClient, err := c.kcpClient()
+	if err != nil {
+		return err
+	}
+	return c.inspectPermissionClaims(ctx, kcpClient, bindingName)
+}
+
+// AcceptPermissionClaims accepts the given permission claims of the APIBinding.
+func (c *Config) AcceptPermissionClaims(ctx context.Context, bindingName string, claims []string) error {
+	kcpClient, err := c.kcpClient()
+	if err != nil {
+		return err
+	}
+	return c.decidePermissionClaims(ctx, kcpClient, bindingName, claims, apisv1alpha1.ClaimAccepted)
+}
+
+// RejectPermissionClaims rejects the given permission claims of the APIBinding.
+func (c *Config) RejectPermissionClaims(ctx context.Context, bindingName string, claims []string) error {
+	kcpClient, err := c.kcpClient()
+	if err != nil {
+		return err
+	}
+	return c.decidePermissionClaims(ctx, kcpClient, bindingName, claims, apisv1alpha1.ClaimRejected)
+}
+
+func (c *Config) kcpClient() (kcpclient.Interface, error) {
+	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	kcpClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kcp client: %w", err)
+	}
+	return kcpClient, nil
+}
+
+func (c *Config) inspectPermissionClaims(ctx context.Context, kcpClient kcpclient.Interface, bindingName string) error {
+	var bindings []apisv1alpha1.APIBinding
+	if bindingName != "" {
+		binding, err := kcpClient.ApisV1alpha1().APIBindings().Get(ctx, bindingName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		bindings = append(bindings, *binding)
+	} else {
+		list, err := kcpClient.ApisV1alpha1().APIBindings().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		bindings = list.Items
+		sort.Slice(bindings, func(i, j int) bool { return bindings[i].Name < bindings[j].Name })
+	}
+
+	w := printers.GetNewTabWriter(c.Out)
+	fmt.Fprintln(w, "APIBINDING\tAPIEXPORT\tCLAIM\tVERBS\tOBJECTS\tSTATE") // nolint: errcheck
+	found := false
+	for _, binding := range bindings {
+		for _, claim := range binding.Status.PermissionClaims {
+			found = true
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", binding.Name, exportName(&binding), claimName(binding.Status.PermissionClaims, claim.PermissionClaim), // nolint: errcheck
+				claimVerbs(claim.PermissionClaim), claimObjects(claim.PermissionClaim), claim.State)
+		}
+	}
+	if !found {
+		fmt.Fprintln(c.ErrOut, "No permission claims found.") // nolint: errcheck
+		return nil
+	}
+	return w.Flush()
+}
+
+// decidePermissionClaims records the state for the given permission claims in spec.permissionClaims
+// of the APIBinding, replacing earlier decisions. Only claims of the APIExport, i.e. in the status of
+// the APIBinding, can be decided.
+func (c *Config) decidePermissionClaims(ctx context.Context, kcpClient kcpclient.Interface, bindingName string, names []string, state apisv1alpha1.AcceptablePermissionClaimState) error {
+	var decided []apisv1alpha1.PermissionClaim
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		binding, err := kcpClient.ApisV1alpha1().APIBindings().Get(ctx, bindingName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		decided = nil
+		for _, name := range names {
+			claim, err := findPermissionClaim(binding, name)
+			if err != nil {
+				return err
+			}
+			decided = append(decided, claim)
+		}
+
+		for _, claim := range decided {
+			decision := apisv1alpha1.AcceptablePermissionClaim{PermissionClaim: claim, State: state}
+			replaced := false
+			for i, existing := range binding.Spec.PermissionClaims {
+				if existing.GroupResource == claim.GroupResource && existing.IdentityHash == claim.IdentityHash {
+					binding.Spec.PermissionClaims[i] = decision
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				binding.Spec.PermissionClaims = append(binding.Spec.PermissionClaims, decision)
+			}
+		}
+
+		_, err = kcpClient.ApisV1alpha1().APIBindings().Update(ctx, binding, metav1.UpdateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("APIBinding %s not found", bindingName)
+	} else if err != nil {
+		return fmt.Errorf("failed to update the permission claims of APIBinding %s: %w", bindingName, err)
+	}
+
+	for _, claim := range decided {
+		fmt.Fprintf(c.Out, "permission claim %s of apibinding %s %s\n", helpers.PermissionClaimName(claim), bindingName, strings.ToLower(string(state))) // nolint: errcheck
+	}
+	return nil
+}
+
+// findPermissionClaim returns the permission claim of the APIExport with the given <resource>.<group>,
+// optionally followed by :<identity-hash> to tell apart claims for resources of different APIExports.
+func findPermissionClaim(binding *apisv1alpha1.APIBinding, name string) (apisv1alpha1.PermissionClaim, error) {
+	name, identityHash, withIdentity := strings.Cut(name, ":")
+
+	var matches []apisv1alpha1.PermissionClaim
+	for _, claim := range binding.Status.PermissionClaims {
+		if helpers.PermissionClaimName(claim.PermissionClaim) != name {
+			continue
+		}
+		if withIdentity && claim.IdentityHash != identityHash {
+			continue
+		}
+		matches = append(matches, claim.PermissionClaim)
+	}
+
+	switch len(matches) {
+	case 0:
+		return apisv1alpha1.PermissionClaim{}, fmt.Errorf("APIBinding %s has no permission claim for %s", binding.Name, name)
+	case 1:
+		return matches[0], nil
+	default:
+		return apisv1alpha1.PermissionClaim{}, fmt.Errorf("APIBinding %s has several permission claims for %s, use <resource>.<group>:<identity-hash> to choose one", binding.Name, name)
+	}
+}
+
+// claimName returns the name of the claim as accepted by findPermissionClaim, with the identity
+// hash only if the name alone is ambiguous.
+func claimName(claims []apisv1alpha1.PermissionClaimStatus, claim apisv1alpha1.PermissionClaim) string {
+	name := helpers.PermissionClaimName(claim)
+	for _, other := range claims {
+		if other.IdentityHash != claim.IdentityHash && helpers.PermissionClaimName(other.PermissionClaim) == name {
+			return name + ":" + claim.IdentityHash
+		}
+	}
+	return name
+}
+
+func claimVerbs(claim apisv1alpha1.PermissionClaim) string {
+	if len(claim.Verbs) == 0 {
+		return "*"
+	}
+	return strings.Join(claim.Verbs, ",")
+}
+
+func claimObjects(claim apisv1alpha1.PermissionClaim) string {
+	selector := claim.ResourceSelector
+	if selector == nil {
+		return "*"
+	}
+	var restrictions []string
+	if len(selector.Names) > 0 {
+		restrictions = append(restrictions, "names="+strings.Join(selector.Names, ","))
+	}
+	if selector.LabelSelector != nil {
+		restrictions = append(restrictions, "labels="+metav1.FormatLabelSelector(selector.LabelSelector))
+	}
+	if len(restrictions) == 0 {
+		return "*"
+	}
+	return strings.Join(restrictions, " ")
+}
+
+func exportName(binding *apisv1alpha1.APIBinding) string {
+	if ref := binding.ExportWorkspaceReference(); ref != nil {
+		if ref.Path == "" {
+			return ref.ExportName
+		}
+		return ref.Path + ":" + ref.ExportName
+	}
+	return "<unknown>"
+}
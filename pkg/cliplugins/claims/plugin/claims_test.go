@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+var (
+	configmaps     = apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}
+	secrets        = apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}, Verbs: []string{"get", "list"}, ResourceSelector: &apisv1alpha1.ResourceSelector{Names: []string{"widgets"}}}
+	gadgets        = apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Group: "example.io", Resource: "gadgets"}, IdentityHash: "abc"}
+	otherGadgets   = apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Group: "example.io", Resource: "gadgets"}, IdentityHash: "def"}
+	widgetsBinding = &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:org:providers", ExportName: "widgets"}},
+			PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimAccepted},
+			},
+		},
+		Status: apisv1alpha1.APIBindingStatus{
+			PermissionClaims: []apisv1alpha1.PermissionClaimStatus{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimAccepted},
+				{PermissionClaim: secrets, State: apisv1alpha1.ClaimPending},
+				{PermissionClaim: gadgets, State: apisv1alpha1.ClaimPending},
+				{PermissionClaim: otherGadgets, State: apisv1alpha1.ClaimPending},
+			},
+		},
+	}
+)
+
+func TestInspectPermissionClaims(t *testing.T) {
+	client := kcpfake.NewSimpleClientset(widgetsBinding, &apisv1alpha1.APIBinding{ObjectMeta: metav1.ObjectMeta{Name: "empty"}})
+
+	out := &bytes.Buffer{}
+	c := &Config{IOStreams: genericclioptions.IOStreams{Out: out, ErrOut: out}}
+	require.NoError(t, c.inspectPermissionClaims(context.Background(), client, ""))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	require.Equal(t, []string{"widgets", "root:org:providers:widgets", "configmaps", "*", "*", "Accepted"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"widgets", "root:org:providers:widgets", "secrets", "get,list", "names=widgets", "Pending"}, strings.Fields(lines[2]))
+	require.Equal(t, "gadgets.example.io:abc", strings.Fields(lines[3])[2])
+
+	out.Reset()
+	require.NoError(t, c.inspectPermissionClaims(context.Background(), client, "empty"))
+	require.Equal(t, "No permission claims found.\n", out.String())
+}
+
+func TestDecidePermissionClaims(t *testing.T) {
+	tests := map[string]struct {
+		claims []string
+		state  apisv1alpha1.AcceptablePermissionClaimState
+
+		wantClaims []apisv1alpha1.AcceptablePermissionClaim
+		wantErr    string
+	}{
+		"accept a pending claim": {
+			claims: []string{"secrets"},
+			state:  apisv1alpha1.ClaimAccepted,
+			wantClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimAccepted},
+				{PermissionClaim: secrets, State: apisv1alpha1.ClaimAccepted},
+			},
+		},
+		"reject an accepted claim": {
+			claims: []string{"configmaps"},
+			state:  apisv1alpha1.ClaimRejected,
+			wantClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimRejected},
+			},
+		},
+		"claim chosen by identity": {
+			claims: []string{"gadgets.example.io:def"},
+			state:  apisv1alpha1.ClaimAccepted,
+			wantClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: configmaps, State: apisv1alpha1.ClaimAccepted},
+				{PermissionClaim: otherGadgets, State: apisv1alpha1.ClaimAccepted},
+			},
+		},
+		"ambiguous claim": {
+			claims:  []string{"gadgets.example.io"},
+			state:   apisv1alpha1.ClaimAccepted,
+			wantErr: "several permission claims for gadgets.example.io",
+		},
+		"unknown claim": {
+			claims:  []string{"secrets", "deployments.apps"},
+			state:   apisv1alpha1.ClaimAccepted,
+			wantErr: "no permission claim for deployments.apps",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := kcpfake.NewSimpleClientset(widgetsBinding.DeepCopy())
+
+			out := &bytes.Buffer{}
+			c := &Config{IOStreams: genericclioptions.IOStreams{Out: out, ErrOut: out}}
+			err := c.decidePermissionClaims(context.Background(), client, "widgets", tt.claims, tt.state)
+			binding, getErr := client.ApisV1alpha1().APIBindings().Get(context.Background(), "widgets", metav1.GetOptions{})
+			require.NoError(t, getErr)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				require.Equal(t, widgetsBinding.Spec.PermissionClaims, binding.Spec.PermissionClaims)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantClaims, binding.Spec.PermissionClaims)
+		})
+	}
+}
@@ -30,10 +30,13 @@ var (
 	crdExample = `
 	# Convert a CRD in a yaml file to an APIResourceSchema. For a CRD named widgets.example.io, and a prefix value of
 	# 'today', the new APIResourceSchema's name will be today.widgets.example.io.
-	%[1]s crd snapshot -f crd.yaml --prefix 2022-05-07 > api-resource-schema.yaml
+	%[1]s crd convert -f crd.yaml --prefix 2022-05-07 > api-resource-schema.yaml
 
 	# Convert a CRD from STDIN
-	kubectl get crd foo -o yaml | %[1]s crd snapshot -f - --prefix today > output.yaml
+	kubectl get crd foo -o yaml | %[1]s crd convert -f - --prefix today > output.yaml
+
+	# Convert all apiextensions.k8s.io/v1 and v1beta1 CRDs of a multi-document yaml file
+	%[1]s crd convert -f crds.yaml --prefix v1 > api-resource-schemas.yaml
 `
 )
 
@@ -49,25 +52,27 @@ func New(streams genericclioptions.IOStreams) *cobra.Command {
 		},
 	}
 
-	snapshotOptions := plugin.NewOptions(streams)
+	convertOptions := plugin.NewOptions(streams)
 
-	snapshotCommand := &cobra.Command{
-		Use:          "snapshot -f FILE --prefix PREFIX",
-		Short:        "Snapshot a CRD and convert it to an APIResourceSchema",
+	convertCommand := &cobra.Command{
+		Use:          "convert -f FILE --prefix PREFIX",
+		Aliases:      []string{"snapshot"},
+		Short:        "Convert CRDs to APIResourceSchemas",
+		Long:         "Convert CRDs to APIResourceSchemas, including all versions, schemas with defaults, subresources and printer columns. The resulting APIResourceSchemas are validated before they are written.",
 		Example:      fmt.Sprintf(crdExample, "kubectl kcp"),
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
-			if err := snapshotOptions.Validate(); err != nil {
+			if err := convertOptions.Validate(); err != nil {
 				return err
 			}
 
-			return plugin.NewCRDSnapshot(snapshotOptions).Execute()
+			return plugin.NewCRDConvert(convertOptions).Execute()
 		},
 	}
 
-	snapshotOptions.BindFlags(snapshotCommand)
+	convertOptions.BindFlags(convertCommand)
 
-	cmd.AddCommand(snapshotCommand)
+	cmd.AddCommand(convertCommand)
 
 	return cmd
 }
@@ -17,32 +17,31 @@ limitations under the License.
 package plugin
 
 import (
-	"bufio"
-	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"os"
 
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
-	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
 
+	"github.com/kcp-dev/kcp/pkg/admission/apiresourceschema"
+	"github.com/kcp-dev/kcp/pkg/apis/apis/conversion"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 )
 
-type CRDSnapshot struct {
+// CRDConvert converts CRD manifests into APIResourceSchemas.
+type CRDConvert struct {
 	options *Options
 }
 
-func NewCRDSnapshot(opts *Options) *CRDSnapshot {
-	return &CRDSnapshot{
+func NewCRDConvert(opts *Options) *CRDConvert {
+	return &CRDConvert{
 		options: opts,
 	}
 }
 
-func (c *CRDSnapshot) Execute() error {
+func (c *CRDConvert) Execute() error {
 	var in io.Reader
 
 	if c.options.Filename == "-" {
@@ -59,9 +58,6 @@ func (c *CRDSnapshot) Execute() error {
 	}
 
 	scheme := runtime.NewScheme()
-	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
-		return err
-	}
 	if err := apisv1alpha1.AddToScheme(scheme); err != nil {
 		return err
 	}
@@ -85,36 +81,27 @@ func (c *CRDSnapshot) Execute() error {
 
 	encoder := codecs.EncoderForVersion(info.Serializer, apisv1alpha1.SchemeGroupVersion)
 
-	d := kubeyaml.NewYAMLReader(bufio.NewReader(in))
-
-	for {
-		doc, err := d.Read()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		if len(bytes.TrimSpace(doc)) == 0 {
-			continue
-		}
+	crds, err := conversion.DecodeCRDs(in)
+	if err != nil {
+		return err
+	}
 
-		decoded, _, err := codecs.UniversalDecoder(apiextensionsv1.SchemeGroupVersion).Decode(doc, nil, nil)
+	// convert and validate everything before writing anything, in order to not produce partial output
+	apiResourceSchemas := make([]*apisv1alpha1.APIResourceSchema, 0, len(crds))
+	for _, crd := range crds {
+		apiResourceSchema, err := conversion.CRDToAPIResourceSchema(crd, c.options.Prefix)
 		if err != nil {
-			return err
+			return fmt.Errorf("error converting CRD %s: %w", crd.Name, err)
 		}
 
-		crd, ok := decoded.(*apiextensionsv1.CustomResourceDefinition)
-		if !ok {
-			return fmt.Errorf("unexpected type for CRD %T", decoded)
+		if errs := apiresourceschema.ValidateAPIResourceSchema(context.Background(), apiResourceSchema); len(errs) > 0 {
+			return fmt.Errorf("CRD %s converts to an invalid APIResourceSchema: %w", crd.Name, errs.ToAggregate())
 		}
 
-		apiResourceSchema, err := apisv1alpha1.CRDToAPIResourceSchema(crd, c.options.Prefix)
-		if err != nil {
-			return fmt.Errorf("error converting CRD: %w", err)
-		}
+		apiResourceSchemas = append(apiResourceSchemas, apiResourceSchema)
+	}
 
+	for _, apiResourceSchema := range apiResourceSchemas {
 		out, err := runtime.Encode(encoder, apiResourceSchema)
 		if err != nil {
 			return fmt.Errorf("error converting CRD to an APIResourceSchema: %w", err)
@@ -33,7 +33,7 @@ func TestExecute(t *testing.T) {
 	opts.Prefix = "testing"
 	opts.Filename = "-"
 
-	c := NewCRDSnapshot(opts)
+	c := NewCRDConvert(opts)
 
 	n, err := stdin.WriteString(multiCRDYaml)
 	require.NoError(t, err)
@@ -45,6 +45,36 @@ func TestExecute(t *testing.T) {
 	require.Empty(t, cmp.Diff(expectedYAML, strings.Trim(stdout.String(), "\n")))
 }
 
+func TestExecuteInvalid(t *testing.T) {
+	streams, stdin, stdout, _ := genericclioptions.NewTestIOStreams()
+
+	opts := NewOptions(streams)
+	opts.Prefix = "testing"
+	opts.Filename = "-"
+
+	_, err := stdin.WriteString(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.io
+spec:
+  group: example.io
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`)
+	require.NoError(t, err)
+
+	err = NewCRDConvert(opts).Execute()
+	require.ErrorContains(t, err, "schemas are required")
+	require.Empty(t, stdout.String(), "expected no partial output")
+}
+
 var multiCRDYaml = `
 apiVersion: apiextensions.k8s.io/v1
 kind: CustomResourceDefinition
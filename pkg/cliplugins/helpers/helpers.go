@@ -25,6 +25,7 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	virtualcommandoptions "github.com/kcp-dev/kcp/cmd/virtual-workspaces/options"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 )
 
@@ -51,3 +52,11 @@ func ParseClusterURL(host string) (*url.URL, logicalcluster.Name, error) {
 
 	return &ret, clusterName, nil
 }
+
+// PermissionClaimName returns <resource>.<group>, or <resource> for the core group.
+func PermissionClaimName(claim apisv1alpha1.PermissionClaim) string {
+	if claim.Group == "" {
+		return claim.Resource
+	}
+	return claim.Resource + "." + claim.Group
+}
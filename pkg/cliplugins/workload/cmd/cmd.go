@@ -17,12 +17,16 @@ limitations under the License.
 package cmd
 
 import (
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
@@ -38,6 +42,14 @@ var (
 
 	# Directly apply the manifest
 	%[1]s workload sync <sync-target-name> --syncer-image <kcp-syncer-image> -o - | KUBECONFIG=<pcluster-config> kubectl apply -f -
+
+	# Write a Helm chart with the syncer's resources, node selector and proxy settings as values.
+	%[1]s workload sync <sync-target-name> --syncer-image <kcp-syncer-image> --format helm -o syncer-chart --requests cpu=100m,memory=256Mi --node-selector kubernetes.io/os=linux --https-proxy http://proxy:3128
+	KUBECONFIG=<pcluster-config> helm install kcp-syncer ./syncer-chart
+
+	# Write a Kustomize overlay with the syncer's customizations as patches.
+	%[1]s workload sync <sync-target-name> --syncer-image <kcp-syncer-image> --format kustomize -o syncer --extra-ca-file proxy-ca.crt
+	KUBECONFIG=<pcluster-config> kubectl apply -k syncer
 `
 	cordonExample = `
 	# Mark a sync target as unschedulable.
@@ -50,6 +62,20 @@ var (
 	drainExample = `
 	# Start draining a sync target in preparation for maintenance.
 	%[1]s workload drain <sync-target-name>
+
+	# Drain a sync target and wait until no namespace of the given workspaces is synced to it anymore.
+	%[1]s workload drain <sync-target-name> --wait --workspace root:org:ws1 --workspace root:org:ws2
+`
+	bindExample = `
+	# Bind a namespace explicitly to a sync target in the current workspace.
+	%[1]s workload bind <namespace> <sync-target-name>
+
+	# Bind a namespace explicitly to a sync target in another workspace.
+	%[1]s workload bind <namespace> <sync-target-name> --sync-target-workspace root:compute
+`
+	unbindExample = `
+	# Remove a namespace from a sync target it has been bound to.
+	%[1]s workload unbind <namespace> <sync-target-name>
 `
 )
 
@@ -83,9 +109,13 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 		outputFile          string
 		downstreamNamespace string
 		featureGatesString  string
+		clientCertificates  bool
 		kcpNamespace                = "default"
 		qps                 float32 = 30
 		burst                       = 20
+		outputFormat                = plugin.OutputFormatYAML
+		extraCAFiles        []string
+		customizations      plugin.SyncerCustomizations
 	)
 
 	enableSyncerCmd := &cobra.Command{
@@ -124,6 +154,30 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 			if len(outputFile) == 0 {
 				return errors.New("a value must be specified for --output-file")
 			}
+			if !sets.NewString(plugin.OutputFormats...).Has(outputFormat) {
+				return fmt.Errorf("--format must be one of %s", strings.Join(plugin.OutputFormats, ", "))
+			}
+			if outputFormat != plugin.OutputFormatYAML && outputFile == "-" {
+				return fmt.Errorf("--format %s writes a directory, --output-file cannot be -", outputFormat)
+			}
+			for flag, quantities := range map[string]map[string]string{"--requests": customizations.Requests, "--limits": customizations.Limits} {
+				for name, quantity := range quantities {
+					if _, err := resource.ParseQuantity(quantity); err != nil {
+						return fmt.Errorf("invalid quantity %q for %s in %s: %w", quantity, name, flag, err)
+					}
+				}
+			}
+			customizations.ExtraCAData = nil
+			for _, file := range extraCAFiles {
+				bs, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if !x509.NewCertPool().AppendCertsFromPEM(bs) {
+					return fmt.Errorf("no PEM-encoded certificates found in --extra-ca-file %s", file)
+				}
+				customizations.ExtraCAData = append(customizations.ExtraCAData, bs...)
+			}
 
 			syncTargetName := args[0]
 			if len(syncTargetName)+len(plugin.SyncerIDPrefix)+8 > 254 {
@@ -134,6 +188,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 			return kubeconfig.Sync(
 				c.Context(),
+				outputFormat,
 				outputFile,
 				syncTargetName,
 				kcpNamespace,
@@ -144,6 +199,8 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 				qps,
 				burst,
 				featureGatesString,
+				clientCertificates,
+				customizations,
 			)
 		},
 	}
@@ -151,7 +208,15 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	enableSyncerCmd.Flags().StringVar(&syncerImage, "syncer-image", syncerImage, "The syncer image to use in the syncer's deployment YAML. Images are published at https://github.com/kcp-dev/kcp/pkgs/container/kcp%2Fsyncer.")
 	enableSyncerCmd.Flags().IntVar(&replicas, "replicas", replicas, "Number of replicas of the syncer deployment.")
 	enableSyncerCmd.Flags().StringVar(&kcpNamespace, "kcp-namespace", kcpNamespace, "The name of the kcp namespace to create a service account in.")
-	enableSyncerCmd.Flags().StringVarP(&outputFile, "output-file", "o", outputFile, "The manifest file to be created and applied to the physical cluster. Use - for stdout.")
+	enableSyncerCmd.Flags().StringVarP(&outputFile, "output-file", "o", outputFile, "The manifest file to be created and applied to the physical cluster. Use - for stdout. For the helm and kustomize formats, the directory to write to.")
+	enableSyncerCmd.Flags().StringVar(&outputFormat, "format", outputFormat, fmt.Sprintf("The output format, one of %s. With helm, a chart is written with the image, the replicas and the customizations of the syncer as values. With kustomize, an overlay is written that sets them on top of a base.", strings.Join(plugin.OutputFormats, ", ")))
+	enableSyncerCmd.Flags().StringToStringVar(&customizations.Requests, "requests", customizations.Requests, "Resource requests of the syncer container, e.g. cpu=100m,memory=256Mi.")
+	enableSyncerCmd.Flags().StringToStringVar(&customizations.Limits, "limits", customizations.Limits, "Resource limits of the syncer container, e.g. memory=512Mi.")
+	enableSyncerCmd.Flags().StringToStringVar(&customizations.NodeSelector, "node-selector", customizations.NodeSelector, "Node selector of the syncer pod, e.g. kubernetes.io/os=linux.")
+	enableSyncerCmd.Flags().StringVar(&customizations.HTTPProxy, "http-proxy", customizations.HTTPProxy, "HTTP_PROXY of the syncer container.")
+	enableSyncerCmd.Flags().StringVar(&customizations.HTTPSProxy, "https-proxy", customizations.HTTPSProxy, "HTTPS_PROXY of the syncer container, used to reach kcp.")
+	enableSyncerCmd.Flags().StringVar(&customizations.NoProxy, "no-proxy", customizations.NoProxy, "NO_PROXY of the syncer container.")
+	enableSyncerCmd.Flags().StringSliceVar(&extraCAFiles, "extra-ca-file", extraCAFiles, "Files with PEM-encoded ca certificates the syncer trusts for kcp in addition to the one of kcp, e.g. the one of a TLS intercepting proxy.")
 	enableSyncerCmd.Flags().StringVarP(&downstreamNamespace, "namespace", "n", downstreamNamespace, "The namespace to create the syncer in in the physical cluster. By default this is \"kcp-syncer-<synctarget-name>-<uid>\".")
 	enableSyncerCmd.Flags().Float32Var(&qps, "qps", qps, "QPS to use when talking to API servers.")
 	enableSyncerCmd.Flags().IntVar(&burst, "burst", burst, "Burst to use when talking to API servers.")
@@ -159,6 +224,9 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 		"A set of key=value pairs that describe feature gates for alpha/experimental features. "+
 			"Options are:\n"+strings.Join(kcpfeatures.KnownFeatures(), "\n")) // hide kube-only gates
 
+	enableSyncerCmd.Flags().BoolVar(&clientCertificates, "client-certificates", clientCertificates,
+		"Let the syncer authenticate to kcp with rotating client certificates. The service account token is then only used to request the first certificate.")
+
 	cmd.AddCommand(enableSyncerCmd)
 
 	// cordon
@@ -215,6 +283,10 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 	cmd.AddCommand(uncordonCmd)
 
+	var drainWait bool
+	var drainWorkspaces []string
+	drainTimeout := 10 * time.Minute
+
 	// drain
 	drainCmd := &cobra.Command{
 		Use:          "drain <sync-target-name>",
@@ -225,6 +297,11 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 			if err := opts.Validate(); err != nil {
 				return err
 			}
+			// The sync target does not know the workspaces of the namespaces synced to it: they are in the workspaces
+			// of the Placements selecting it, which can be anywhere.
+			if drainWait && len(drainWorkspaces) == 0 {
+				return errors.New("--wait requires --workspace with the workspaces of the placements selecting the sync target")
+			}
 			kubeconfig, err := plugin.NewConfig(opts)
 			if err != nil {
 				return err
@@ -236,11 +313,68 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 
 			syncTargetName := args[0]
 
-			return kubeconfig.Drain(c.Context(), syncTargetName)
+			return kubeconfig.Drain(c.Context(), syncTargetName, drainWait, drainWorkspaces, drainTimeout)
 		},
 	}
+	drainCmd.Flags().BoolVar(&drainWait, "wait", drainWait, "Wait until no namespace is synced to the sync target anymore.")
+	drainCmd.Flags().StringSliceVar(&drainWorkspaces, "workspace", drainWorkspaces, "Workspaces whose namespaces are waited for with --wait, i.e. the workspaces of the placements selecting the sync target. Required with --wait.")
+	drainCmd.Flags().DurationVar(&drainTimeout, "timeout", drainTimeout, "How long to wait with --wait.")
 
 	cmd.AddCommand(drainCmd)
 
+	var syncTargetWorkspace string
+
+	// bind
+	bindCmd := &cobra.Command{
+		Use:          "bind <namespace> <sync-target-name>",
+		Short:        "Bind a namespace explicitly to a sync target and disable automatic scheduling for it",
+		Example:      fmt.Sprintf(bindExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 2 {
+				return cmd.Help()
+			}
+
+			return kubeconfig.Bind(c.Context(), args[0], args[1], syncTargetWorkspace)
+		},
+	}
+	bindCmd.Flags().StringVar(&syncTargetWorkspace, "sync-target-workspace", syncTargetWorkspace, "The workspace of the sync target. By default this is the current workspace.")
+
+	cmd.AddCommand(bindCmd)
+
+	// unbind
+	unbindCmd := &cobra.Command{
+		Use:          "unbind <namespace> <sync-target-name>",
+		Short:        "Remove a namespace from a sync target it has been bound to explicitly",
+		Example:      fmt.Sprintf(unbindExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if len(args) != 2 {
+				return cmd.Help()
+			}
+
+			return kubeconfig.Unbind(c.Context(), args[0], args[1], syncTargetWorkspace)
+		},
+	}
+	unbindCmd.Flags().StringVar(&syncTargetWorkspace, "sync-target-workspace", syncTargetWorkspace, "The workspace of the sync target. By default this is the current workspace.")
+
+	cmd.AddCommand(unbindCmd)
+
 	return cmd, nil
 }
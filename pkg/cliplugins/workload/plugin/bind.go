@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// Bind binds the namespace explicitly to the sync target and switches the namespace to the Manual
+// scheduling mode, such that the scheduler does not change the binding anymore.
+func (c *Config) Bind(ctx context.Context, namespace, syncTargetName, syncTargetWorkspace string) error {
+	config, syncTargetKey, err := c.syncTargetKey(ctx, syncTargetName, syncTargetWorkspace)
+	if err != nil {
+		return err
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: string(workloadv1alpha1.ResourceStateSync),
+			},
+			"annotations": map[string]interface{}{
+				schedulingv1alpha1.SchedulingModeAnnotationKey:                                    string(schedulingv1alpha1.SchedulingModeManual),
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + syncTargetKey: nil,
+			},
+		},
+	}
+	if err := patchNamespace(ctx, config, namespace, patch); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "namespace %s bound to sync target %s\n", namespace, syncTargetName) // nolint: errcheck
+	return nil
+}
+
+// Unbind starts removing the namespace from the sync target. The namespace stays in the Manual
+// scheduling mode.
+func (c *Config) Unbind(ctx context.Context, namespace, syncTargetName, syncTargetWorkspace string) error {
+	config, syncTargetKey, err := c.syncTargetKey(ctx, syncTargetName, syncTargetWorkspace)
+	if err != nil {
+		return err
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				schedulingv1alpha1.SchedulingModeAnnotationKey:                                    string(schedulingv1alpha1.SchedulingModeManual),
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + syncTargetKey: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	}
+	if err := patchNamespace(ctx, config, namespace, patch); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "namespace %s unbinding from sync target %s\n", namespace, syncTargetName) // nolint: errcheck
+	return nil
+}
+
+// syncTargetKey returns the config of the current workspace and the key of the sync target, which lives
+// in the given workspace, or in the current workspace if empty.
+func (c *Config) syncTargetKey(ctx context.Context, syncTargetName, syncTargetWorkspace string) (*rest.Config, string, error) {
+	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	u, currentClusterName, err := helpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return nil, "", fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	syncTargetClusterName := currentClusterName
+	if syncTargetWorkspace != "" {
+		syncTargetClusterName = logicalcluster.New(syncTargetWorkspace)
+	}
+
+	syncTargetConfig := rest.CopyConfig(config)
+	u.Path = path.Join(u.Path, syncTargetClusterName.Path())
+	syncTargetConfig.Host = u.String()
+
+	kcpClient, err := kcpclient.NewForConfig(syncTargetConfig)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create kcp client: %w", err)
+	}
+	syncTarget, err := kcpClient.WorkloadV1alpha1().SyncTargets().Get(ctx, syncTargetName, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get SyncTarget %s in workspace %s: %w", syncTargetName, syncTargetClusterName, err)
+	}
+
+	return config, workloadv1alpha1.ToSyncTargetKey(syncTargetClusterName, syncTarget.Name), nil
+}
+
+func patchNamespace(ctx context.Context, config *rest.Config, namespace string, patch map[string]interface{}) error {
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if _, err := kubeClient.CoreV1().Namespaces().Patch(ctx, namespace, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to update Namespace %s: %w", namespace, err)
+	}
+	return nil
+}
@@ -19,7 +19,6 @@ package plugin
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
 	"embed"
 	"encoding/base64"
 	"encoding/json"
@@ -31,7 +30,7 @@ import (
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
-	"github.com/martinlindhe/base36"
+	"github.com/kcp-dev/logicalcluster/v2"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -44,11 +43,12 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
 
-	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
 //go:embed *.yaml
@@ -61,15 +61,18 @@ const (
 )
 
 // Sync prepares a kcp workspace for use with a syncer and outputs the
-// configuration required to deploy a syncer to the pcluster to stdout.
+// configuration required to deploy a syncer to the pcluster to stdout, or
+// writes it to outputFilePath in the given output format.
 func (c *Config) Sync(
 	ctx context.Context,
-	outputFilePath, syncTargetName, kcpNamespaceName, downstreamNamespace, image string,
+	outputFormat, outputFilePath, syncTargetName, kcpNamespaceName, downstreamNamespace, image string,
 	resourcesToSync []string,
 	replicas int,
 	qps float32,
 	burst int,
 	featureGatesString string,
+	clientCertificates bool,
+	customizations SyncerCustomizations,
 ) error {
 	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
 	if err != nil {
@@ -77,9 +80,17 @@ func (c *Config) Sync(
 	}
 
 	var outputFile *os.File
-	if outputFilePath == "-" {
+	switch {
+	case outputFormat != OutputFormatYAML:
+		if outputFilePath == "-" {
+			return fmt.Errorf("the %s output format cannot be written to stdout", outputFormat)
+		}
+		if err := os.MkdirAll(outputFilePath, 0755); err != nil {
+			return err
+		}
+	case outputFilePath == "-":
 		outputFile = os.Stdout
-	} else {
+	default:
 		outputFile, err = os.Create(outputFilePath)
 		if err != nil {
 			return err
@@ -109,20 +120,44 @@ func (c *Config) Sync(
 	serverURL := configURL.Scheme + "://" + configURL.Host
 
 	input := templateInput{
-		ServerURL:          serverURL,
-		CAData:             base64.StdEncoding.EncodeToString(config.CAData),
-		Token:              token,
-		KCPNamespace:       kcpNamespaceName,
-		Namespace:          downstreamNamespace,
-		LogicalCluster:     currentClusterName.String(),
-		SyncTarget:         syncTargetName,
-		SyncTargetUID:      syncTargetUID,
-		Image:              image,
-		Replicas:           replicas,
-		ResourcesToSync:    resourcesToSync,
-		QPS:                qps,
-		Burst:              burst,
-		FeatureGatesString: featureGatesString,
+		ServerURL:            serverURL,
+		CAData:               base64.StdEncoding.EncodeToString(config.CAData),
+		Token:                token,
+		KCPNamespace:         kcpNamespaceName,
+		Namespace:            downstreamNamespace,
+		LogicalCluster:       currentClusterName.String(),
+		SyncTarget:           syncTargetName,
+		SyncTargetUID:        syncTargetUID,
+		Image:                image,
+		Replicas:             replicas,
+		ResourcesToSync:      resourcesToSync,
+		QPS:                  qps,
+		Burst:                burst,
+		FeatureGatesString:   featureGatesString,
+		ClientCertificates:   clientCertificates,
+		SyncerCustomizations: customizations,
+	}
+
+	// the chart adds the extra ca certificates from its values on install
+	if outputFormat == OutputFormatHelm {
+		if err := writeSyncerChart(outputFilePath, input, syncerID); err != nil {
+			return err
+		}
+		// nolint: errcheck
+		c.ErrOut.Write([]byte(fmt.Sprintf("\nWrote Helm chart to %s for namespace %q. Use\n\n  KUBECONFIG=<pcluster-config> helm install %s %q\n\nto install it. "+
+			"Use\n\n  KUBECONFIG=<pcluster-config> kubectl get deployment -n %q %s\n\nto verify the syncer pod is running.\n", outputFilePath, downstreamNamespace, syncerID, outputFilePath, downstreamNamespace, syncerID)))
+		return nil
+	}
+
+	input.CAData = base64.StdEncoding.EncodeToString(appendPEM(config.CAData, customizations.ExtraCAData))
+	if outputFormat == OutputFormatKustomize {
+		if err := writeSyncerKustomization(outputFilePath, input, syncerID); err != nil {
+			return err
+		}
+		// nolint: errcheck
+		c.ErrOut.Write([]byte(fmt.Sprintf("\nWrote Kustomize overlay to %s for namespace %q. Use\n\n  KUBECONFIG=<pcluster-config> kubectl apply -k %q\n\nto apply it. "+
+			"Use\n\n  KUBECONFIG=<pcluster-config> kubectl get deployment -n %q %s\n\nto verify the syncer pod is running.\n", outputFilePath, downstreamNamespace, outputFilePath, downstreamNamespace, syncerID)))
+		return nil
 	}
 
 	resources, err := renderSyncerResources(input, syncerID)
@@ -139,14 +174,6 @@ func (c *Config) Sync(
 	return err
 }
 
-// getSyncerID returns a unique ID for a syncer derived from the name and its UID. It's
-// a valid DNS segment and can be used as namespace or object names.
-func getSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
-	syncerHash := sha256.Sum224([]byte(syncTarget.UID))
-	base36hash := strings.ToLower(base36.EncodeBytes(syncerHash[:]))
-	return fmt.Sprintf("kcp-syncer-%s-%s", syncTarget.Name, base36hash[:8])
-}
-
 // enableSyncerForWorkspace creates a sync target with the given name and creates a service
 // account for the syncer in the given namespace. The expectation is that the provided config is
 // for a logical cluster (workspace). Returns the token the syncer will use to connect to kcp.
@@ -189,13 +216,14 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		return "", "", "", fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	syncerID = getSyncerID(syncTarget)
+	syncerID = shared.GetSyncerID(syncTarget)
 
 	syncTargetOwnerReferences := []metav1.OwnerReference{{
 		APIVersion: workloadv1alpha1.SchemeGroupVersion.String(),
 		Kind:       "SyncTarget",
 		Name:       syncTarget.Name,
 		UID:        syncTarget.UID,
+		Controller: pointer.Bool(true),
 	}}
 	sa, err := kubeClient.CoreV1().ServiceAccounts(namespace).Get(ctx, syncerID, metav1.GetOptions{})
 
@@ -245,31 +273,14 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 	}
 
 	// Create a cluster role that provides the syncer the minimal permissions
-	// required by KCP to manage the sync target, and by the syncer virtual
-	// workspace to sync.
-	rules := []rbacv1.PolicyRule{
-		{
-			Verbs:         []string{"sync"},
-			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
-			ResourceNames: []string{syncTargetName},
-			Resources:     []string{"synctargets"},
-		},
-		{
-			Verbs:     []string{"get", "list", "watch"},
-			APIGroups: []string{workloadv1alpha1.SchemeGroupVersion.Group},
-			Resources: []string{"synctargets"},
-		},
-		{
-			Verbs:         []string{"update", "patch"},
-			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
-			ResourceNames: []string{syncTargetName},
-			Resources:     []string{"synctargets/status"},
-		},
-		{
-			Verbs:     []string{"get", "create", "update", "delete", "list", "watch"},
-			APIGroups: []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
-			Resources: []string{"apiresourceimports"},
-		},
+	// required by KCP to manage the sync target, and by the syncer and upsyncer
+	// virtual workspaces to sync. The rules only cover this single sync target
+	// and the APIResourceImports of its synced resources. They are regenerated
+	// by kcp when the synced resources change, identified by the name, the label
+	// and the controller owner reference to the sync target.
+	rules := shared.SyncerClusterRoleRules(syncTarget)
+	labels := map[string]string{
+		workloadv1alpha1.SyncerClusterRoleLabelKey: syncTargetName,
 	}
 
 	cr, err := kubeClient.RbacV1().ClusterRoles().Get(ctx,
@@ -277,10 +288,11 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		metav1.GetOptions{})
 	switch {
 	case errors.IsNotFound(err):
-		c.ErrOut.Write([]byte(fmt.Sprintf("Creating cluster role %q to give service account %q\n\n 1. write and sync access to the synctarget %q\n 2. write access to apiresourceimports.\n\n", syncerID, syncerID, syncerID))) // nolint: errcheck
+		c.ErrOut.Write([]byte(fmt.Sprintf("Creating cluster role %q to give service account %q\n\n 1. write and sync access to the synctarget %q\n 2. write access to the apiresourceimports of its synced resources.\n\n", syncerID, syncerID, syncerID))) // nolint: errcheck
 		if _, err = kubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:            syncerID,
+				Labels:          labels,
 				OwnerReferences: syncTargetOwnerReferences,
 			},
 			Rules: rules,
@@ -288,8 +300,17 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			return "", "", "", err
 		}
 	case err == nil:
+		newLabels := make(map[string]string, len(cr.Labels)+len(labels))
+		for k, v := range cr.Labels {
+			newLabels[k] = v
+		}
+		for k, v := range labels {
+			newLabels[k] = v
+		}
+
 		oldData, err := json.Marshal(rbacv1.ClusterRole{
 			ObjectMeta: metav1.ObjectMeta{
+				Labels:          cr.Labels,
 				OwnerReferences: cr.OwnerReferences,
 			},
 			Rules: cr.Rules,
@@ -302,6 +323,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			ObjectMeta: metav1.ObjectMeta{
 				UID:             cr.UID,
 				ResourceVersion: cr.ResourceVersion,
+				Labels:          newLabels,
 				OwnerReferences: mergeOwnerReference(cr.OwnerReferences, syncTargetOwnerReferences),
 			},
 			Rules: rules,
@@ -315,7 +337,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 			return "", "", "", fmt.Errorf("failed to create patch for ClusterRole %s|%s: %w", syncTargetName, syncerID, err)
 		}
 
-		c.ErrOut.Write([]byte(fmt.Sprintf("Updating cluster role %q with\n\n 1. write and sync access to the synctarget %q\n 2. write access to apiresourceimports.\n\n", syncerID, syncerID))) // nolint: errcheck
+		c.ErrOut.Write([]byte(fmt.Sprintf("Updating cluster role %q with\n\n 1. write and sync access to the synctarget %q\n 2. write access to the apiresourceimports of its synced resources.\n\n", syncerID, syncerID))) // nolint: errcheck
 		if _, err = kubeClient.RbacV1().ClusterRoles().Patch(ctx, cr.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
 			return "", "", "", fmt.Errorf("failed to patch ClusterRole %s|%s/%s: %w", syncTargetName, syncerID, namespace, err)
 		}
@@ -323,12 +345,19 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		return "", "", "", err
 	}
 
-	// Grant the service account the role created just above in the workspace
-	subjects := []rbacv1.Subject{{
-		Kind:      "ServiceAccount",
-		Name:      syncerID,
-		Namespace: namespace,
-	}}
+	// Grant the service account and the syncer certificate user the role created just above in the workspace
+	subjects := []rbacv1.Subject{
+		{
+			Kind:      "ServiceAccount",
+			Name:      syncerID,
+			Namespace: namespace,
+		},
+		{
+			Kind:     "User",
+			Name:     workloadv1alpha1.SyncerCertificateUserName(logicalcluster.From(syncTarget), syncTarget.Name),
+			APIGroup: "rbac.authorization.k8s.io",
+		},
+	}
 	roleRef := rbacv1.RoleRef{
 		Kind:     "ClusterRole",
 		Name:     syncerID,
@@ -347,7 +376,7 @@ func (c *Config) enableSyncerForWorkspace(ctx context.Context, config *rest.Conf
 		}
 	}
 
-	c.ErrOut.Write([]byte(fmt.Sprintf("Creating or updating cluster role binding %q to bind service account %q to cluster role %q.\n", syncerID, syncerID, syncerID))) // nolint: errcheck
+	c.ErrOut.Write([]byte(fmt.Sprintf("Creating or updating cluster role binding %q to bind service account %q and the syncer certificate user to cluster role %q.\n", syncerID, syncerID, syncerID))) // nolint: errcheck
 	if _, err = kubeClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            syncerID,
@@ -398,8 +427,9 @@ func mergeOwnerReference(ownerReferences, newOwnerReferences []metav1.OwnerRefer
 
 	for _, ownerReference := range newOwnerReferences {
 		found := false
-		for _, mergedOwnerReference := range merged {
+		for i, mergedOwnerReference := range merged {
 			if mergedOwnerReference.UID == ownerReference.UID {
+				merged[i] = ownerReference
 				found = true
 				break
 			}
@@ -451,6 +481,31 @@ type templateInput struct {
 	Burst int
 	// FeatureGatesString is the set of features gates.
 	FeatureGatesString string
+	// ClientCertificates makes the syncer authenticate to kcp with rotating client
+	// certificates requested with its service account token.
+	ClientCertificates bool
+
+	SyncerCustomizations
+}
+
+// SyncerCustomizations are the settings of the syncer deployment that platform teams
+// typically adapt to the pcluster. In the helm and kustomize output formats, they are
+// values of the chart and patches of the overlay respectively.
+type SyncerCustomizations struct {
+	// Requests are the resource requests of the syncer container, e.g. cpu=100m.
+	Requests map[string]string
+	// Limits are the resource limits of the syncer container, e.g. memory=512Mi.
+	Limits map[string]string
+	// NodeSelector restricts the nodes the syncer pod is scheduled to.
+	NodeSelector map[string]string
+	// HTTPProxy, HTTPSProxy and NoProxy are the proxy settings the syncer uses to
+	// reach kcp.
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+	// ExtraCAData holds PEM-encoded ca certificates the syncer trusts in addition
+	// to the one of kcp, e.g. the one of a TLS intercepting proxy.
+	ExtraCAData []byte
 }
 
 // templateArgs represents the full set of arguments required to render the resources
@@ -493,19 +548,6 @@ type templateArgs struct {
 // cluster role and role binding would be owned by the namespace to ensure cleanup on deletion
 // of the namespace.
 func renderSyncerResources(input templateInput, syncerID string) ([]byte, error) {
-	tmplArgs := templateArgs{
-		templateInput:           input,
-		LabelSafeLogicalCluster: strings.ReplaceAll(input.LogicalCluster, ":", "_"),
-		ServiceAccount:          syncerID,
-		ClusterRole:             syncerID,
-		ClusterRoleBinding:      syncerID,
-		GroupMappings:           getGroupMappings(input.ResourcesToSync),
-		Secret:                  syncerID,
-		SecretConfigKey:         SyncerSecretConfigKey,
-		Deployment:              syncerID,
-		DeploymentApp:           syncerID,
-	}
-
 	syncerTemplate, err := embeddedResources.ReadFile("syncer.yaml")
 	if err != nil {
 		return nil, err
@@ -515,13 +557,29 @@ func renderSyncerResources(input templateInput, syncerID string) ([]byte, error)
 		return nil, err
 	}
 	buffer := bytes.NewBuffer([]byte{})
-	err = tmpl.Execute(buffer, tmplArgs)
+	err = tmpl.Execute(buffer, newTemplateArgs(input, syncerID))
 	if err != nil {
 		return nil, err
 	}
 	return buffer.Bytes(), nil
 }
 
+// newTemplateArgs derives the arguments to render the syncer resources from the input.
+func newTemplateArgs(input templateInput, syncerID string) templateArgs {
+	return templateArgs{
+		templateInput:           input,
+		LabelSafeLogicalCluster: strings.ReplaceAll(input.LogicalCluster, ":", "_"),
+		ServiceAccount:          syncerID,
+		ClusterRole:             syncerID,
+		ClusterRoleBinding:      syncerID,
+		GroupMappings:           getGroupMappings(input.ResourcesToSync),
+		Secret:                  syncerID,
+		SecretConfigKey:         SyncerSecretConfigKey,
+		Deployment:              syncerID,
+		DeploymentApp:           syncerID,
+	}
+}
+
 // groupMapping associates an api group to the resources in that group.
 type groupMapping struct {
 	APIGroup  string
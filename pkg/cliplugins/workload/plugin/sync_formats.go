@@ -0,0 +1,295 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// OutputFormatYAML outputs the manifests to deploy the syncer.
+	OutputFormatYAML = "yaml"
+	// OutputFormatHelm outputs a Helm chart to deploy the syncer, with the SyncerCustomizations
+	// as values.
+	OutputFormatHelm = "helm"
+	// OutputFormatKustomize outputs a Kustomize overlay with the SyncerCustomizations as
+	// patches on top of a base with the manifests to deploy the syncer.
+	OutputFormatKustomize = "kustomize"
+
+	// kustomizeSyncerImage is the image name in the base of the Kustomize output that the
+	// overlay replaces with the syncer image.
+	kustomizeSyncerImage = "kcp-syncer"
+)
+
+// OutputFormats are the supported output formats of the sync command.
+var OutputFormats = []string{OutputFormatYAML, OutputFormatHelm, OutputFormatKustomize}
+
+// helmValues are the values of the syncer Helm chart.
+type helmValues struct {
+	Image        string            `json:"image"`
+	Replicas     int               `json:"replicas"`
+	Resources    helmResources     `json:"resources"`
+	NodeSelector map[string]string `json:"nodeSelector"`
+	Proxy        helmProxy         `json:"proxy"`
+	// ExtraCAs are PEM-encoded ca certificates the syncer trusts in addition to the one of kcp.
+	ExtraCAs string `json:"extraCAs"`
+}
+
+type helmResources struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+type helmProxy struct {
+	HTTPProxy  string `json:"httpProxy"`
+	HTTPSProxy string `json:"httpsProxy"`
+	NoProxy    string `json:"noProxy"`
+}
+
+type helmChart struct {
+	APIVersion  string `json:"apiVersion"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Version     string `json:"version"`
+	AppVersion  string `json:"appVersion,omitempty"`
+}
+
+// writeSyncerChart writes a Helm chart to deploy the syncer to the directory dir. The identity of
+// the syncer, i.e. its names and the kubeconfig for kcp, is fixed in the templates of the chart,
+// while the SyncerCustomizations, the image and the replicas become values.
+func writeSyncerChart(dir string, input templateInput, syncerID string) error {
+	values := helmValues{
+		Image:    input.Image,
+		Replicas: input.Replicas,
+		Resources: helmResources{
+			Requests: input.Requests,
+			Limits:   input.Limits,
+		},
+		NodeSelector: input.NodeSelector,
+		Proxy: helmProxy{
+			HTTPProxy:  input.HTTPProxy,
+			HTTPSProxy: input.HTTPSProxy,
+			NoProxy:    input.NoProxy,
+		},
+		ExtraCAs: string(input.ExtraCAData),
+	}
+	if values.NodeSelector == nil {
+		values.NodeSelector = map[string]string{}
+	}
+
+	_, tag, _ := splitImage(input.Image)
+	chart := helmChart{
+		APIVersion:  "v2",
+		Name:        "kcp-syncer",
+		Description: "Syncer for the sync target " + input.SyncTarget + " of the kcp workspace " + input.LogicalCluster,
+		Type:        "application",
+		Version:     "0.1.0",
+		AppVersion:  tag,
+	}
+
+	chartTemplate, err := embeddedResources.ReadFile("syncer-chart.yaml")
+	if err != nil {
+		return err
+	}
+	// the chart template is itself a Helm template, hence the install-time constants use other delimiters
+	tmpl, err := template.New("syncerChartTemplate").Delims("[[", "]]").Parse(string(chartTemplate))
+	if err != nil {
+		return err
+	}
+	templates := bytes.NewBuffer([]byte{})
+	if err := tmpl.Execute(templates, newTemplateArgs(input, syncerID)); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		return err
+	}
+	if err := writeYAML(filepath.Join(dir, "Chart.yaml"), chart); err != nil {
+		return err
+	}
+	if err := writeYAML(filepath.Join(dir, "values.yaml"), values); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "templates", "syncer.yaml"), templates.Bytes(), 0600)
+}
+
+type kustomization struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Resources  []string             `json:"resources"`
+	Images     []kustomizeImage     `json:"images,omitempty"`
+	Replicas   []kustomizeReplicas  `json:"replicas,omitempty"`
+	Patches    []kustomizePatchPath `json:"patches,omitempty"`
+}
+
+type kustomizeImage struct {
+	Name    string `json:"name"`
+	NewName string `json:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+type kustomizeReplicas struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type kustomizePatchPath struct {
+	Path string `json:"path"`
+}
+
+// writeSyncerKustomization writes a Kustomize overlay to deploy the syncer to the directory dir. The
+// base in dir/base contains the manifests to deploy the syncer, without the SyncerCustomizations and
+// with a placeholder image. The overlay sets the image and the replicas, and patches the syncer
+// deployment with the SyncerCustomizations. The extra ca certificates are part of the kubeconfig
+// in the base.
+func writeSyncerKustomization(dir string, input templateInput, syncerID string) error {
+	base := input
+	base.Image = kustomizeSyncerImage
+	base.SyncerCustomizations = SyncerCustomizations{}
+	resources, err := renderSyncerResources(base, syncerID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "base"), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "base", "syncer.yaml"), resources, 0600); err != nil {
+		return err
+	}
+	if err := writeYAML(filepath.Join(dir, "base", "kustomization.yaml"), kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{"syncer.yaml"},
+	}); err != nil {
+		return err
+	}
+
+	name, tag, digest := splitImage(input.Image)
+	overlay := kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{"base"},
+		Images:     []kustomizeImage{{Name: kustomizeSyncerImage, NewName: name, NewTag: tag, Digest: digest}},
+		Replicas:   []kustomizeReplicas{{Name: syncerID, Count: input.Replicas}},
+	}
+	if patch := syncerDeploymentPatch(input, syncerID); patch != nil {
+		if err := writeYAML(filepath.Join(dir, "syncer-patch.yaml"), patch); err != nil {
+			return err
+		}
+		overlay.Patches = []kustomizePatchPath{{Path: "syncer-patch.yaml"}}
+	}
+	return writeYAML(filepath.Join(dir, "kustomization.yaml"), overlay)
+}
+
+// syncerDeploymentPatch returns a strategic merge patch for the syncer deployment that applies the
+// SyncerCustomizations, or nil if there are none.
+func syncerDeploymentPatch(input templateInput, syncerID string) map[string]interface{} {
+	container := map[string]interface{}{"name": "kcp-syncer"}
+	if len(input.Requests) > 0 || len(input.Limits) > 0 {
+		resources := map[string]interface{}{}
+		if len(input.Requests) > 0 {
+			resources["requests"] = input.Requests
+		}
+		if len(input.Limits) > 0 {
+			resources["limits"] = input.Limits
+		}
+		container["resources"] = resources
+	}
+	if env := proxyEnv(input.SyncerCustomizations); len(env) > 0 {
+		container["env"] = env
+	}
+
+	podSpec := map[string]interface{}{}
+	if len(container) > 1 {
+		podSpec["containers"] = []interface{}{container}
+	}
+	if len(input.NodeSelector) > 0 {
+		podSpec["nodeSelector"] = input.NodeSelector
+	}
+	if len(podSpec) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      syncerID,
+			"namespace": input.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": podSpec,
+			},
+		},
+	}
+}
+
+func proxyEnv(customizations SyncerCustomizations) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, v := range []corev1.EnvVar{
+		{Name: "HTTP_PROXY", Value: customizations.HTTPProxy},
+		{Name: "HTTPS_PROXY", Value: customizations.HTTPSProxy},
+		{Name: "NO_PROXY", Value: customizations.NoProxy},
+	} {
+		if v.Value != "" {
+			env = append(env, v)
+		}
+	}
+	return env
+}
+
+// splitImage splits an image reference into its name, and its tag or digest.
+func splitImage(image string) (name, tag, digest string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], "", image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:], ""
+	}
+	return image, "", ""
+}
+
+// appendPEM appends the PEM-encoded certificates extra to the ones in certs.
+func appendPEM(certs, extra []byte) []byte {
+	if len(extra) == 0 {
+		return certs
+	}
+	ret := append([]byte{}, certs...)
+	if len(ret) > 0 && !bytes.HasSuffix(ret, []byte("\n")) {
+		ret = append(ret, '\n')
+	}
+	return append(ret, extra...)
+}
+
+func writeYAML(path string, obj interface{}) error {
+	bs, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bs, 0600)
+}
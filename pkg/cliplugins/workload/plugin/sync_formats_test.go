@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+var testSyncerInput = templateInput{
+	ServerURL:       "server-url",
+	Token:           "token",
+	CAData:          "Y2EtZGF0YQ==",
+	KCPNamespace:    "kcp-namespace",
+	Namespace:       "kcp-syncer-sync-target-name-34b23c4k",
+	LogicalCluster:  "root:default:foo",
+	SyncTarget:      "sync-target-name",
+	SyncTargetUID:   "sync-target-uid",
+	ResourcesToSync: []string{"resource1", "resource2"},
+	Image:           "ghcr.io/kcp-dev/kcp/syncer:v0.9.0",
+	Replicas:        1,
+	QPS:             123.4,
+	Burst:           456,
+	SyncerCustomizations: SyncerCustomizations{
+		Requests:     map[string]string{"memory": "256Mi", "cpu": "100m"},
+		NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+		HTTPSProxy:   "http://proxy:3128",
+		ExtraCAData:  []byte("extra-ca\n"),
+	},
+}
+
+func TestNewSyncerYAMLWithCustomizations(t *testing.T) {
+	actualYAML, err := renderSyncerResources(testSyncerInput, "kcp-syncer-sync-target-name-34b23c4k")
+	require.NoError(t, err)
+
+	require.Contains(t, string(actualYAML), `        image: ghcr.io/kcp-dev/kcp/syncer:v0.9.0
+        imagePullPolicy: IfNotPresent
+        resources:
+          requests:
+            cpu: "100m"
+            memory: "256Mi"
+        env:
+        - name: HTTPS_PROXY
+          value: "http://proxy:3128"
+        terminationMessagePolicy: FallbackToLogsOnError
+`)
+	require.Contains(t, string(actualYAML), `      serviceAccountName: kcp-syncer-sync-target-name-34b23c4k
+      nodeSelector:
+        "kubernetes.io/os": "linux"
+      volumes:
+`)
+}
+
+func TestWriteSyncerChart(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, writeSyncerChart(dir, testSyncerInput, "kcp-syncer-sync-target-name-34b23c4k"))
+
+	var chart helmChart
+	readYAML(t, filepath.Join(dir, "Chart.yaml"), &chart)
+	require.Equal(t, "kcp-syncer", chart.Name)
+	require.Equal(t, "v0.9.0", chart.AppVersion)
+
+	var values helmValues
+	readYAML(t, filepath.Join(dir, "values.yaml"), &values)
+	require.Equal(t, helmValues{
+		Image:        "ghcr.io/kcp-dev/kcp/syncer:v0.9.0",
+		Replicas:     1,
+		Resources:    helmResources{Requests: map[string]string{"memory": "256Mi", "cpu": "100m"}},
+		NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+		Proxy:        helmProxy{HTTPSProxy: "http://proxy:3128"},
+		ExtraCAs:     "extra-ca\n",
+	}, values)
+
+	templates, err := os.ReadFile(filepath.Join(dir, "templates", "syncer.yaml"))
+	require.NoError(t, err)
+	require.NotContains(t, string(templates), "[[")
+	require.Contains(t, string(templates), `        certificate-authority-data: {{ print (b64dec "Y2EtZGF0YQ==") .Values.extraCAs | b64enc }}`)
+	require.Contains(t, string(templates), `  replicas: {{ .Values.replicas }}`)
+	require.Contains(t, string(templates), `        image: {{ .Values.image | quote }}`)
+	require.Contains(t, string(templates), `        - --resources=resource2`)
+}
+
+func TestWriteSyncerKustomization(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, writeSyncerKustomization(dir, testSyncerInput, "kcp-syncer-sync-target-name-34b23c4k"))
+
+	base, err := os.ReadFile(filepath.Join(dir, "base", "syncer.yaml"))
+	require.NoError(t, err)
+	require.Contains(t, string(base), "        image: kcp-syncer\n")
+	require.NotContains(t, string(base), "        resources:\n")
+	require.NotContains(t, string(base), "nodeSelector:")
+
+	var overlay kustomization
+	readYAML(t, filepath.Join(dir, "kustomization.yaml"), &overlay)
+	require.Equal(t, kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  []string{"base"},
+		Images:     []kustomizeImage{{Name: "kcp-syncer", NewName: "ghcr.io/kcp-dev/kcp/syncer", NewTag: "v0.9.0"}},
+		Replicas:   []kustomizeReplicas{{Name: "kcp-syncer-sync-target-name-34b23c4k", Count: 1}},
+		Patches:    []kustomizePatchPath{{Path: "syncer-patch.yaml"}},
+	}, overlay)
+
+	patch, err := os.ReadFile(filepath.Join(dir, "syncer-patch.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kcp-syncer-sync-target-name-34b23c4k
+  namespace: kcp-syncer-sync-target-name-34b23c4k
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: HTTPS_PROXY
+          value: http://proxy:3128
+        name: kcp-syncer
+        resources:
+          requests:
+            cpu: 100m
+            memory: 256Mi
+      nodeSelector:
+        kubernetes.io/os: linux
+`, string(patch))
+}
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image, name, tag, digest string
+	}{
+		{image: "syncer", name: "syncer"},
+		{image: "syncer:v1", name: "syncer", tag: "v1"},
+		{image: "localhost:5000/kcp/syncer", name: "localhost:5000/kcp/syncer"},
+		{image: "localhost:5000/kcp/syncer:v1", name: "localhost:5000/kcp/syncer", tag: "v1"},
+		{image: "ghcr.io/kcp/syncer@sha256:abc", name: "ghcr.io/kcp/syncer", digest: "sha256:abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			name, tag, digest := splitImage(tt.image)
+			require.Equal(t, []string{tt.name, tt.tag, tt.digest}, []string{name, tag, digest})
+		})
+	}
+}
+
+func readYAML(t *testing.T, path string, obj interface{}) {
+	t.Helper()
+	bs, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, yaml.UnmarshalStrict(bs, obj))
+}
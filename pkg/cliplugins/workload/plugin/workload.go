@@ -19,14 +19,23 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/kcp-dev/logicalcluster/v2"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
 )
 
 // Cordon the sync target and mark it as unschedulable
@@ -112,8 +121,9 @@ func modifyCordon(ctx context.Context, config *rest.Config, syncTargetName strin
 	return nil
 }
 
-// Start draining the sync target and mark it as unschedulable
-func (c *Config) Drain(ctx context.Context, syncTargetName string) error {
+// Start draining the sync target and mark it as unschedulable. If waitUntilDrained is true, it waits until no
+// namespace in the given workspaces is synced to the sync target anymore.
+func (c *Config) Drain(ctx context.Context, syncTargetName string, waitUntilDrained bool, workspaces []string, timeout time.Duration) error {
 	config, err := clientcmd.NewDefaultClientConfig(*c.startingConfig, c.overrides).ClientConfig()
 	if err != nil {
 		return err
@@ -135,20 +145,84 @@ func (c *Config) Drain(ctx context.Context, syncTargetName string) error {
 	// See if there is nothing to do
 	if syncTarget.Spec.EvictAfter != nil && syncTarget.Spec.Unschedulable {
 		fmt.Println(syncTargetName, "already draining")
+	} else {
+		nowTime := time.Now().UTC()
+		var patchBytes = []byte(`[{"op":"replace","path":"/spec/unschedulable","value":true},{"op":"replace","path":"/spec/evictAfter","value":"` + nowTime.Format(time.RFC3339) + `"}]`)
+
+		_, err = kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{})
+
+		if err != nil {
+			return fmt.Errorf("failed to update SyncTarget %s: %w", syncTargetName, err)
+		}
+
+		fmt.Println(syncTargetName, "draining")
+	}
+
+	if !waitUntilDrained {
 		return nil
 	}
+	return c.waitForDrain(ctx, config, syncTargetName, workspaces, timeout)
+}
 
-	nowTime := time.Now().UTC()
-	var patchBytes = []byte(`[{"op":"replace","path":"/spec/unschedulable","value":true},{"op":"replace","path":"/spec/evictAfter","value":"` + nowTime.Format(time.RFC3339) + `"}]`)
+// waitForDrain waits until no namespace in the given workspaces is synced to the sync target in the
+// current workspace anymore, and prints the remaining namespaces whenever they change.
+func (c *Config) waitForDrain(ctx context.Context, config *rest.Config, syncTargetName string, workspaces []string, timeout time.Duration) error {
+	u, currentClusterName, err := helpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	if len(workspaces) == 0 {
+		return fmt.Errorf("no workspaces to wait for")
+	}
+	stateLabel := workloadv1alpha1.ClusterResourceStateLabelPrefix + workloadv1alpha1.ToSyncTargetKey(currentClusterName, syncTargetName)
+
+	var clients []namespaceLister
+	for _, ws := range workspaces {
+		wsConfig := rest.CopyConfig(config)
+		wsURL := *u
+		wsURL.Path = path.Join(u.Path, logicalcluster.New(ws).Path())
+		wsConfig.Host = wsURL.String()
+		kubeClient, err := kubernetes.NewForConfig(wsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create kubernetes client: %w", err)
+		}
+		clients = append(clients, namespaceLister{workspace: ws, client: kubeClient})
+	}
 
-	_, err = kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, syncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{})
+	return c.pollUntilDrained(ctx, clients, syncTargetName, stateLabel, time.Second, timeout)
+}
 
+// pollUntilDrained polls the namespaces with the given sync target state label until there is none left
+// in any of the given workspaces.
+func (c *Config) pollUntilDrained(ctx context.Context, clients []namespaceLister, syncTargetName, stateLabel string, interval, timeout time.Duration) error {
+	last := ""
+	err := wait.PollImmediateWithContext(ctx, interval, timeout, func(ctx context.Context) (bool, error) {
+		var remaining []string
+		for _, l := range clients {
+			namespaces, err := l.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: stateLabel})
+			if err != nil {
+				return false, fmt.Errorf("failed to list namespaces in workspace %s: %w", l.workspace, err)
+			}
+			for _, ns := range namespaces.Items {
+				remaining = append(remaining, l.workspace+"|"+ns.Name)
+			}
+		}
+		sort.Strings(remaining)
+		if current := strings.Join(remaining, ", "); current != last && len(remaining) > 0 {
+			fmt.Fprintf(c.Out, "waiting for %d namespaces to be drained from %s: %s\n", len(remaining), syncTargetName, current) // nolint: errcheck
+			last = current
+		}
+		return len(remaining) == 0, nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update SyncTarget %s: %w", syncTargetName, err)
+		return fmt.Errorf("failed to wait for SyncTarget %s to be drained: %w", syncTargetName, err)
 	}
 
-	fmt.Println(syncTargetName, "draining")
-
+	fmt.Fprintf(c.Out, "%s drained\n", syncTargetName) // nolint: errcheck
 	return nil
+}
 
+type namespaceLister struct {
+	workspace string
+	client    kubernetes.Interface
 }
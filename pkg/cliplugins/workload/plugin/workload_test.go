@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kubefakeclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestPollUntilDrained(t *testing.T) {
+	const stateLabel = "state.workload.kcp.dev/abc"
+
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	tests := map[string]struct {
+		objects   []runtime.Object
+		reactor   func() clienttesting.ReactionFunc
+		wantError string
+		wantOut   string
+	}{
+		"drained": {
+			objects: []runtime.Object{namespace("other", nil)},
+			wantOut: "cluster1 drained\n",
+		},
+		"drained after a while": {
+			reactor: func() clienttesting.ReactionFunc {
+				lists := 0
+				return func(action clienttesting.Action) (bool, runtime.Object, error) {
+					lists++
+					if lists > 1 {
+						return false, nil, nil
+					}
+					return true, &corev1.NamespaceList{Items: []corev1.Namespace{*namespace("ns1", map[string]string{stateLabel: "Sync"})}}, nil
+				}
+			},
+			wantOut: "waiting for 1 namespaces to be drained from cluster1: root:org:ws|ns1\ncluster1 drained\n",
+		},
+		"timeout": {
+			objects:   []runtime.Object{namespace("ns1", map[string]string{stateLabel: "Sync"})},
+			wantError: "failed to wait for SyncTarget cluster1 to be drained: timed out waiting for the condition",
+			wantOut:   "waiting for 1 namespaces to be drained from cluster1: root:org:ws|ns1\n",
+		},
+		"list error": {
+			reactor: func() clienttesting.ReactionFunc {
+				return func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("boom")
+				}
+			},
+			wantError: "failed to wait for SyncTarget cluster1 to be drained: failed to list namespaces in workspace root:org:ws: boom",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := kubefakeclient.NewSimpleClientset(tc.objects...)
+			if tc.reactor != nil {
+				client.PrependReactor("list", "namespaces", tc.reactor())
+			}
+
+			out := &bytes.Buffer{}
+			c := &Config{IOStreams: genericclioptions.IOStreams{Out: out}}
+			err := c.pollUntilDrained(context.Background(), []namespaceLister{{workspace: "root:org:ws", client: client}}, "cluster1", stateLabel, 10*time.Millisecond, 100*time.Millisecond)
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantOut, out.String())
+		})
+	}
+}
@@ -50,6 +50,13 @@ var (
 	# enter the previous workspace
 	%[1]s workspace -
 
+	# pick a workspace to enter interactively
+	%[1]s workspace use
+
+	# enter a workspace, remembering the current one on the workspace stack, and go back to it
+	%[1]s workspace push root:default:my-workspace
+	%[1]s workspace pop
+
 	# go to your home workspace 
 	%[1]s workspace
 
@@ -61,6 +68,12 @@ var (
 
 	# create a context with the current workspace, named context-name
 	%[1]s workspace create-context context-name
+
+	# export the content of the current workspace to an archive
+	%[1]s workspace export my-workspace.tar.gz
+
+	# import the content of an archive into the current workspace
+	%[1]s workspace import my-workspace.tar.gz
 `
 )
 
@@ -90,7 +103,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 	cmd := &cobra.Command{
 		Aliases:          []string{"ws", "workspaces"},
-		Use:              "workspace [create|create-context|use|current|<workspace>|..|.|-|~|<root:absolute:workspace>]",
+		Use:              "workspace [create|create-context|export|import|kubeconfig|tree|find|use|push|pop|stack|current|<workspace>|..|.|-|~|<root:absolute:workspace>]",
 		Short:            "Manages KCP workspaces",
 		Example:          fmt.Sprintf(workspaceExample, "kubectl kcp"),
 		SilenceUsage:     true,
@@ -100,17 +113,75 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	opts.BindFlags(cmd)
 
 	useCmd := &cobra.Command{
-		Use:          "use <workspace>|..|.|-|~|<root:absolute:workspace>",
-		Short:        "Uses the given workspace as the current workspace. Using - means previous workspace, .. means parent workspace, . mean current, ~ means home workspace",
+		Use:          "use [<workspace>|..|.|-|~|<root:absolute:workspace>]",
+		Short:        "Uses the given workspace as the current workspace. Using - means previous workspace, .. means parent workspace, . mean current, ~ means home workspace. Without workspace, a workspace can be picked interactively",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				return c.Help()
+				if err := opts.Validate(); err != nil {
+					return err
+				}
+				kubeconfig, err := plugin.NewKubeConfig(opts)
+				if err != nil {
+					return err
+				}
+				return kubeconfig.PickWorkspace(c.Context())
 			}
 			return useRunE(c, args)
 		},
 	}
 
+	pushCmd := &cobra.Command{
+		Use:          "push <workspace>|..|-|~|<root:absolute:workspace>",
+		Short:        "Pushes the current workspace onto the workspace stack and uses the given workspace",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			return kubeconfig.PushWorkspace(c.Context(), args[0])
+		},
+	}
+
+	popCmd := &cobra.Command{
+		Use:          "pop",
+		Short:        "Uses the workspace on top of the workspace stack and removes it from the stack",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			return kubeconfig.PopWorkspace(c.Context())
+		},
+	}
+
+	stackCmd := &cobra.Command{
+		Use:          "stack",
+		Short:        "Prints the current workspace and the workspace stack, the most recently pushed first",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			return kubeconfig.PrintWorkspaceStack()
+		},
+	}
+
 	currentCmd := &cobra.Command{
 		Use:          "current [--short]",
 		Short:        "Print the current workspace. Same as 'kubectl ws .'.",
@@ -189,6 +260,115 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 	createContextCmd.Flags().BoolVar(&overwriteContext, "overwrite", overwriteContext, "Overwrite the context if it already exists")
 
+	exportCmd := &cobra.Command{
+		Use:          "export <file>|-",
+		Short:        "Exports the content of the current workspace to an archive",
+		Example:      "kcp workspace export my-workspace.tar.gz",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			return kubeconfig.ExportWorkspace(cmd.Context(), args[0])
+		},
+	}
+
+	importTimeout := time.Minute
+	importCmd := &cobra.Command{
+		Use:          "import <file>|- [--timeout=<duration>]",
+		Short:        "Imports the content of an archive into the current workspace",
+		Example:      "kcp workspace import my-workspace.tar.gz",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			return kubeconfig.ImportWorkspace(cmd.Context(), args[0], importTimeout)
+		},
+	}
+	importCmd.Flags().DurationVar(&importTimeout, "timeout", importTimeout, "How long to wait for imported CRDs and APIBindings to serve their resources")
+
+	var kubeconfigServiceAccount, kubeconfigServer, kubeconfigOutput string
+	kubeconfigExpiration := time.Hour
+	kubeconfigCmd := &cobra.Command{
+		Use:          "kubeconfig <workspace> [--service-account=<namespace>/<name>] [--expiration=<duration>] [--server=<url>] [-o <file>]",
+		Short:        "Mints a time-limited kubeconfig for a child workspace with a service account token",
+		Example:      "kcp workspace kubeconfig my-workspace --service-account=default/ci --expiration=2h -o my-workspace.kubeconfig",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			return kubeconfig.MintKubeconfig(cmd.Context(), args[0], kubeconfigServiceAccount, kubeconfigExpiration, kubeconfigServer, kubeconfigOutput)
+		},
+	}
+	kubeconfigCmd.Flags().StringVar(&kubeconfigServiceAccount, "service-account", kubeconfigServiceAccount, "The service account in the workspace whose token is embedded, as <namespace>/<name>. Defaults to default/default.")
+	kubeconfigCmd.Flags().DurationVar(&kubeconfigExpiration, "expiration", kubeconfigExpiration, "How long the embedded token is valid. At least 10m.")
+	kubeconfigCmd.Flags().StringVar(&kubeconfigServer, "server", kubeconfigServer, "The URL the kubeconfig points to, e.g. of a virtual workspace. Defaults to the URL of the workspace.")
+	kubeconfigCmd.Flags().StringVarP(&kubeconfigOutput, "output-file", "o", "-", "The file to write the kubeconfig to. Use - for stdout.")
+
+	treeCmd := &cobra.Command{
+		Use:          "tree [<workspace>]",
+		Short:        "Prints the hierarchy of workspaces below the current or the given workspace, with type, phase and shard",
+		Example:      "kcp workspace tree root:org",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return kubeconfig.Tree(cmd.Context(), name)
+		},
+	}
+
+	var findType, findLabel string
+	findCmd := &cobra.Command{
+		Use:          "find [<workspace>] [--type=<type>] [--label=<selector>]",
+		Short:        "Finds the accessible workspaces below the current or the given workspace with the given type and labels",
+		Example:      "kcp workspace find root --type=root:universal --label=team=payments",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return kubeconfig.Find(cmd.Context(), name, findType, findLabel)
+		},
+	}
+	findCmd.Flags().StringVar(&findType, "type", findType, "The type of the workspaces, either a name like universal, or an absolute type like root:universal.")
+	findCmd.Flags().StringVarP(&findLabel, "label", "l", findLabel, "The label selector the workspaces must match, e.g. team=payments.")
+
 	deleteCmd := &cobra.Command{
 		Use:          "delete",
 		Short:        "Replaced with \"kubectl delete workspace <workspace-name>\"",
@@ -201,9 +381,17 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 
 	cmd.AddCommand(useCmd)
+	cmd.AddCommand(pushCmd)
+	cmd.AddCommand(popCmd)
+	cmd.AddCommand(stackCmd)
 	cmd.AddCommand(currentCmd)
 	cmd.AddCommand(createCmd)
 	cmd.AddCommand(createContextCmd)
+	cmd.AddCommand(exportCmd)
+	cmd.AddCommand(importCmd)
+	cmd.AddCommand(kubeconfigCmd)
+	cmd.AddCommand(treeCmd)
+	cmd.AddCommand(findCmd)
 	cmd.AddCommand(deleteCmd)
 	return cmd, nil
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+	"github.com/kcp-dev/kcp/pkg/workspacearchive"
+)
+
+// ExportWorkspace writes the content of the current workspace to an archive file, or to stdout
+// if the file name is "-".
+func (kc *KubeConfig) ExportWorkspace(ctx context.Context, fileName string) error {
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	resources, err := discoveryClient.ServerPreferredResources()
+	if err != nil {
+		// partial discovery is fatal because content would be missing in the archive
+		return fmt.Errorf("failed to discover resources of workspace %s: %w", currentClusterName, err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = kc.Out
+	if fileName != "-" {
+		f, err := os.Create(fileName)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+		out = f
+	}
+
+	if err := workspacearchive.Export(ctx, dynamicClient, resources, currentClusterName, out); err != nil {
+		return err
+	}
+	if fileName != "-" {
+		_, err = fmt.Fprintf(kc.Out, "Workspace %q exported to %s.\n", currentClusterName, fileName)
+	}
+	return err
+}
+
+// ImportWorkspace recreates the content of an archive file, or of stdin if the file name is "-",
+// in the current workspace.
+func (kc *KubeConfig) ImportWorkspace(ctx context.Context, fileName string, timeout time.Duration) error {
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = kc.In
+	if fileName != "-" {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+		in = f
+	}
+
+	manifest, err := workspacearchive.Import(ctx, dynamicClient, currentClusterName, in, timeout)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(kc.Out, "Workspace %q imported into %q.\n", manifest.Workspace, currentClusterName)
+	return err
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// MintKubeconfig requests a kubeconfig for the given child workspace of the current workspace from the
+// kubeconfig subresource of workspaces. It embeds a token of the given service account ("<namespace>/<name>",
+// or empty for default/default) valid for the given duration. The certificate authority of the current
+// cluster is added, and the result is written to the given file, or to stdout if the file name is "-".
+func (kc *KubeConfig) MintKubeconfig(ctx context.Context, workspaceName, serviceAccount string, expiration time.Duration, server, fileName string) error {
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	req := &tenancyv1beta1.WorkspaceKubeconfig{
+		Spec: tenancyv1beta1.WorkspaceKubeconfigSpec{
+			Server: server,
+		},
+	}
+	if serviceAccount != "" {
+		parts := strings.Split(serviceAccount, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("service account %q must be of the form <namespace>/<name>", serviceAccount)
+		}
+		req.Spec.ServiceAccountNamespace, req.Spec.ServiceAccountName = parts[0], parts[1]
+	}
+	if expiration > 0 {
+		expirationSeconds := int64(expiration / time.Second)
+		req.Spec.ExpirationSeconds = &expirationSeconds
+	}
+
+	result := &tenancyv1beta1.WorkspaceKubeconfig{}
+	if err := kc.clusterClient.Cluster(currentClusterName).TenancyV1beta1().RESTClient().Post().
+		Cluster(currentClusterName).
+		Resource("workspaces").
+		Name(workspaceName).
+		SubResource("kubeconfig").
+		Body(req).
+		Do(ctx).
+		Into(result); err != nil {
+		return err
+	}
+
+	minted, err := clientcmd.Load([]byte(result.Status.Kubeconfig))
+	if err != nil {
+		return fmt.Errorf("failed to parse minted kubeconfig: %w", err)
+	}
+	for _, cluster := range minted.Clusters {
+		cluster.CertificateAuthorityData = config.CAData
+		if len(config.CAData) == 0 && config.CAFile != "" {
+			if cluster.CertificateAuthorityData, err = ioutil.ReadFile(config.CAFile); err != nil {
+				return err
+			}
+		}
+		cluster.InsecureSkipTLSVerify = config.Insecure
+		cluster.TLSServerName = config.ServerName
+	}
+	data, err := clientcmd.Write(*minted)
+	if err != nil {
+		return err
+	}
+
+	if fileName == "-" {
+		_, err = kc.Out.Write(data)
+		return err
+	}
+	if err := ioutil.WriteFile(fileName, data, 0600); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(kc.Out, "Kubeconfig for workspace %q written to %s, valid until %s.\n", currentClusterName.Join(workspaceName), fileName, result.Status.ExpirationTimestamp.Format(time.RFC3339))
+	return err
+}
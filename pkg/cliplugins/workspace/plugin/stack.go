@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// kcpWorkspaceStackExtensionKey is the kubeconfig extension the workspace stack of push and pop
+// is stored in.
+const kcpWorkspaceStackExtensionKey string = "workspace.kcp.dev/stack"
+
+// workspaceStack is the content of the kcpWorkspaceStackExtensionKey kubeconfig extension.
+type workspaceStack struct {
+	// Workspaces are absolute workspace names, the most recently pushed last.
+	Workspaces []string `json:"workspaces"`
+}
+
+// PushWorkspace pushes the current workspace onto the workspace stack, and uses the given workspace.
+func (kc *KubeConfig) PushWorkspace(ctx context.Context, name string) error {
+	current, err := kc.currentWorkspaceName()
+	if err != nil {
+		return err
+	}
+	stack, err := readWorkspaceStack(kc.startingConfig)
+	if err != nil {
+		return err
+	}
+	stack.Workspaces = append(stack.Workspaces, current.String())
+	if err := writeWorkspaceStack(kc.startingConfig, stack); err != nil {
+		return err
+	}
+
+	return kc.UseWorkspace(ctx, name)
+}
+
+// PopWorkspace uses the workspace on top of the workspace stack, and removes it from the stack.
+func (kc *KubeConfig) PopWorkspace(ctx context.Context) error {
+	stack, err := readWorkspaceStack(kc.startingConfig)
+	if err != nil {
+		return err
+	}
+	if len(stack.Workspaces) == 0 {
+		return errors.New("workspace stack is empty")
+	}
+	top := stack.Workspaces[len(stack.Workspaces)-1]
+	stack.Workspaces = stack.Workspaces[:len(stack.Workspaces)-1]
+	if err := writeWorkspaceStack(kc.startingConfig, stack); err != nil {
+		return err
+	}
+
+	return kc.UseWorkspace(ctx, top)
+}
+
+// PrintWorkspaceStack prints the current workspace followed by the workspace stack, the most
+// recently pushed first.
+func (kc *KubeConfig) PrintWorkspaceStack() error {
+	current, err := kc.currentWorkspaceName()
+	if err != nil {
+		return err
+	}
+	stack, err := readWorkspaceStack(kc.startingConfig)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(kc.Out, current); err != nil {
+		return err
+	}
+	for i := len(stack.Workspaces) - 1; i >= 0; i-- {
+		if _, err := fmt.Fprintln(kc.Out, stack.Workspaces[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PickWorkspace lets the user pick a workspace to use among the child workspaces of the current
+// workspace, its parent, the home workspace and the workspaces on the stack. Input that is not a
+// number of the list filters the list by fuzzy matching.
+func (kc *KubeConfig) PickWorkspace(ctx context.Context) error {
+	current, err := kc.currentWorkspaceName()
+	if err != nil {
+		return err
+	}
+	children, err := kc.listChildWorkspaces(ctx, current)
+	if err != nil {
+		return err
+	}
+	stack, err := readWorkspaceStack(kc.startingConfig)
+	if err != nil {
+		return err
+	}
+
+	var candidates []string
+	for _, ws := range children {
+		if ws.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			candidates = append(candidates, current.Join(ws.Name).String())
+		}
+	}
+	sort.Strings(candidates)
+	if parent, hasParent := current.Parent(); hasParent {
+		candidates = append(candidates, parent.String())
+	}
+	for i := len(stack.Workspaces) - 1; i >= 0; i-- {
+		candidates = append(candidates, stack.Workspaces[i])
+	}
+	if _, found := kc.startingConfig.Contexts[kcpPreviousWorkspaceContextKey]; found {
+		candidates = append(candidates, "-")
+	}
+	candidates = append(candidates, "~")
+	candidates = uniqueStrings(candidates)
+
+	name, err := pickString(bufio.NewReader(kc.In), kc.Out, candidates)
+	if err != nil {
+		return err
+	}
+	return kc.UseWorkspace(ctx, name)
+}
+
+// pickString lists the candidates and reads the choice. A number picks the candidate, other input
+// filters the candidates by fuzzy matching. It returns if exactly one candidate is left.
+func pickString(in *bufio.Reader, out io.Writer, candidates []string) (string, error) {
+	for {
+		if len(candidates) == 0 {
+			return "", errors.New("no matching workspace")
+		}
+		if len(candidates) == 1 {
+			return candidates[0], nil
+		}
+		for i, c := range candidates {
+			fmt.Fprintf(out, "%3d) %s\n", i+1, c) // nolint: errcheck
+		}
+		fmt.Fprint(out, "Pick a workspace by number, or type to filter: ") // nolint: errcheck
+
+		line, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("no workspace picked: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if i, err := strconv.Atoi(line); err == nil && i >= 1 && i <= len(candidates) {
+			return candidates[i-1], nil
+		}
+
+		var filtered []string
+		for _, c := range candidates {
+			if fuzzyMatch(c, line) {
+				filtered = append(filtered, c)
+			}
+		}
+		candidates = filtered
+	}
+}
+
+// fuzzyMatch returns whether the characters of pattern appear in s in order, ignoring case.
+func fuzzyMatch(s, pattern string) bool {
+	s, pattern = strings.ToLower(s), strings.ToLower(pattern)
+	for _, r := range pattern {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
+
+func uniqueStrings(ss []string) []string {
+	seen := map[string]bool{}
+	var ret []string
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+func (kc *KubeConfig) currentWorkspaceName() (logicalcluster.Name, error) {
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return logicalcluster.Name{}, err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return logicalcluster.Name{}, fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	return currentClusterName, nil
+}
+
+func readWorkspaceStack(config *clientcmdapi.Config) (*workspaceStack, error) {
+	stack := &workspaceStack{}
+	ext, found := config.Extensions[kcpWorkspaceStackExtensionKey]
+	if !found {
+		return stack, nil
+	}
+	unknown, ok := ext.(*runtime.Unknown)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T of kubeconfig extension %q", ext, kcpWorkspaceStackExtensionKey)
+	}
+	if err := json.Unmarshal(unknown.Raw, stack); err != nil {
+		return nil, fmt.Errorf("failed to decode kubeconfig extension %q: %w", kcpWorkspaceStackExtensionKey, err)
+	}
+	return stack, nil
+}
+
+func writeWorkspaceStack(config *clientcmdapi.Config, stack *workspaceStack) error {
+	if len(stack.Workspaces) == 0 {
+		delete(config.Extensions, kcpWorkspaceStackExtensionKey)
+		return nil
+	}
+	raw, err := json.Marshal(stack)
+	if err != nil {
+		return err
+	}
+	if config.Extensions == nil {
+		config.Extensions = map[string]runtime.Object{}
+	}
+	config.Extensions[kcpWorkspaceStackExtensionKey] = &runtime.Unknown{Raw: raw, ContentType: runtime.ContentTypeJSON}
+	return nil
+}
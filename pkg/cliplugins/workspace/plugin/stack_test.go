@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestPushPopWorkspace(t *testing.T) {
+	workspace := func(cluster logicalcluster.Name, name string) runtime.Object {
+		return &tenancyv1beta1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       tenancyv1beta1.WorkspaceSpec{Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "universal", Path: "root"}},
+			Status: tenancyv1beta1.WorkspaceStatus{
+				Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				URL:   fmt.Sprintf("https://test%s", cluster.Join(name).Path()),
+			},
+		}
+	}
+	root, foo := tenancyv1alpha1.RootCluster, logicalcluster.New("root:foo")
+	clients := map[logicalcluster.Name]*tenancyfake.Clientset{
+		root: tenancyfake.NewSimpleClientset(workspace(root, "foo")),
+		foo:  tenancyfake.NewSimpleClientset(workspace(foo, "bar")),
+	}
+	clients[foo].Resources = []*metav1.APIResourceList{{GroupVersion: "tenancy.kcp.dev/v1beta1"}}
+
+	var got *clientcmdapi.Config
+	newKubeConfig := func(config *clientcmdapi.Config) *KubeConfig {
+		return &KubeConfig{
+			startingConfig: config.DeepCopy(),
+			currentContext: config.CurrentContext,
+			overrides:      &clientcmd.ConfigOverrides{},
+			clusterClient:  fakeTenancyClient{t: t, clients: clients},
+			modifyConfig: func(config *clientcmdapi.Config) error {
+				got = config
+				return nil
+			},
+			IOStreams: genericclioptions.NewTestIOStreamsDiscard(),
+		}
+	}
+
+	config := &clientcmdapi.Config{CurrentContext: "workspace.kcp.dev/current",
+		Contexts:  map[string]*clientcmdapi.Context{"workspace.kcp.dev/current": {Cluster: "workspace.kcp.dev/current", AuthInfo: "test"}},
+		Clusters:  map[string]*clientcmdapi.Cluster{"workspace.kcp.dev/current": {Server: "https://test/clusters/root:foo"}},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+	}
+
+	t.Log("Push the current workspace and enter bar")
+	require.NoError(t, newKubeConfig(config).PushWorkspace(context.Background(), "bar"))
+	require.Equal(t, "https://test/clusters/root:foo:bar", got.Clusters["workspace.kcp.dev/current"].Server)
+
+	t.Log("The stack survives writing and loading the kubeconfig")
+	file := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, clientcmd.WriteToFile(*got, file))
+	loaded, err := clientcmd.LoadFromFile(file)
+	require.NoError(t, err)
+	stack, err := readWorkspaceStack(loaded)
+	require.NoError(t, err)
+	require.Equal(t, []string{"root:foo"}, stack.Workspaces)
+
+	out := &bytes.Buffer{}
+	kc := newKubeConfig(loaded)
+	kc.Out = out
+	require.NoError(t, kc.PrintWorkspaceStack())
+	require.Equal(t, "root:foo:bar\nroot:foo\n", out.String())
+
+	t.Log("Pop back to foo")
+	require.NoError(t, newKubeConfig(loaded).PopWorkspace(context.Background()))
+	require.Equal(t, "https://test/clusters/root:foo", got.Clusters["workspace.kcp.dev/current"].Server)
+	require.NotContains(t, got.Extensions, kcpWorkspaceStackExtensionKey)
+
+	t.Log("Popping an empty stack fails")
+	require.Error(t, newKubeConfig(got).PopWorkspace(context.Background()))
+}
+
+func TestPickString(t *testing.T) {
+	candidates := []string{"root:foo:bar", "root:foo:baz", "root:foo:qux", "root"}
+
+	tests := map[string]struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		"by number":              {input: "2\n", want: "root:foo:baz"},
+		"by fuzzy filter":        {input: "fqx\n", want: "root:foo:qux"},
+		"filter, then by number": {input: "ba\n2\n", want: "root:foo:baz"},
+		"no match":               {input: "xyz\n", wantErr: true},
+		"no input":               {input: "", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := pickString(bufio.NewReader(strings.NewReader(tt.input)), &bytes.Buffer{}, candidates)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
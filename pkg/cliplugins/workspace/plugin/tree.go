@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// workspaceListLimit is the page size when listing workspaces.
+const workspaceListLimit = 100
+
+// Tree prints the hierarchy of workspaces below the given workspace, or below the current workspace
+// if empty, with their type, phase and shard.
+func (kc *KubeConfig) Tree(ctx context.Context, name string) error {
+	root, err := kc.resolveWorkspace(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(kc.Out, root); err != nil {
+		return err
+	}
+	return kc.printTree(ctx, root, "")
+}
+
+func (kc *KubeConfig) printTree(ctx context.Context, parent logicalcluster.Name, prefix string) error {
+	workspaces, err := kc.listChildWorkspaces(ctx, parent)
+	if err != nil {
+		return err
+	}
+	for i := range workspaces {
+		ws := &workspaces[i]
+		branch, indent := "├── ", "│   "
+		if i == len(workspaces)-1 {
+			branch, indent = "└── ", "    "
+		}
+		if _, err := fmt.Fprintf(kc.Out, "%s%s%s %s\n", prefix, branch, ws.Name, workspaceDetails(ws)); err != nil {
+			return err
+		}
+		if ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			continue
+		}
+		if err := kc.printTree(ctx, parent.Join(ws.Name), prefix+indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find prints the workspaces below the given workspace, or below the current workspace if empty,
+// which match the given type and label selector. The type is either a name like "universal", or
+// an absolute type like "root:universal".
+func (kc *KubeConfig) Find(ctx context.Context, name, workspaceType, labelSelector string) error {
+	root, err := kc.resolveWorkspace(name)
+	if err != nil {
+		return err
+	}
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector %q: %w", labelSelector, err)
+	}
+
+	return kc.find(ctx, root, func(ws *tenancyv1beta1.Workspace) bool {
+		if workspaceType != "" {
+			if strings.Contains(workspaceType, ":") {
+				if ws.Spec.Type.String() != workspaceType {
+					return false
+				}
+			} else if string(ws.Spec.Type.Name) != workspaceType {
+				return false
+			}
+		}
+		return selector.Matches(labels.Set(ws.Labels))
+	})
+}
+
+func (kc *KubeConfig) find(ctx context.Context, parent logicalcluster.Name, matches func(ws *tenancyv1beta1.Workspace) bool) error {
+	workspaces, err := kc.listChildWorkspaces(ctx, parent)
+	if err != nil {
+		return err
+	}
+	for i := range workspaces {
+		ws := &workspaces[i]
+		if matches(ws) {
+			if _, err := fmt.Fprintf(kc.Out, "%s %s\n", parent.Join(ws.Name), workspaceDetails(ws)); err != nil {
+				return err
+			}
+		}
+		if ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			continue
+		}
+		if err := kc.find(ctx, parent.Join(ws.Name), matches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listChildWorkspaces lists the workspaces in the given workspace page by page. Workspaces that
+// cannot have child workspaces, or whose children the user cannot see, have none.
+func (kc *KubeConfig) listChildWorkspaces(ctx context.Context, parent logicalcluster.Name) ([]tenancyv1beta1.Workspace, error) {
+	var workspaces []tenancyv1beta1.Workspace
+	opts := metav1.ListOptions{Limit: workspaceListLimit}
+	for {
+		list, err := kc.clusterClient.Cluster(parent).TenancyV1beta1().Workspaces().List(ctx, opts)
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workspaces in %s: %w", parent, err)
+		}
+		workspaces = append(workspaces, list.Items...)
+		if list.Continue == "" {
+			return workspaces, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+// resolveWorkspace returns the given absolute workspace, the given workspace relative to the current
+// workspace, or the current workspace if empty.
+func (kc *KubeConfig) resolveWorkspace(name string) (logicalcluster.Name, error) {
+	if name != "" && logicalcluster.New(name).HasPrefix(tenancyv1alpha1.RootCluster) {
+		return logicalcluster.New(name), nil
+	}
+
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return logicalcluster.Name{}, err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return logicalcluster.Name{}, fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+	if name == "" {
+		return currentClusterName, nil
+	}
+	return currentClusterName.Join(name), nil
+}
+
+func workspaceDetails(ws *tenancyv1beta1.Workspace) string {
+	details := []string{ws.Spec.Type.String(), string(ws.Status.Phase)}
+	if ws.Status.Location != nil && ws.Status.Location.Current != "" {
+		details = append(details, "shard "+ws.Status.Location.Current)
+	}
+	return "[" + strings.Join(details, ", ") + "]"
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestTreeAndFind(t *testing.T) {
+	workspace := func(name, typeName string, phase tenancyv1alpha1.ClusterWorkspacePhaseType, shard string, labels map[string]string) runtime.Object {
+		ws := &tenancyv1beta1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec:       tenancyv1beta1.WorkspaceSpec{Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root", Name: tenancyv1alpha1.ClusterWorkspaceTypeName(typeName)}},
+			Status:     tenancyv1beta1.WorkspaceStatus{Phase: phase},
+		}
+		if shard != "" {
+			ws.Status.Location = &tenancyv1alpha1.ClusterWorkspaceLocation{Current: shard}
+		}
+		return ws
+	}
+
+	newKubeConfig := func() (*KubeConfig, *bytes.Buffer) {
+		streams, _, out, _ := genericclioptions.NewTestIOStreams()
+		kc := &KubeConfig{
+			clusterClient: fakeTenancyClient{
+				t: t,
+				clients: map[logicalcluster.Name]*tenancyfake.Clientset{
+					logicalcluster.New("root:org"): tenancyfake.NewSimpleClientset(
+						workspace("apps", "universal", tenancyv1alpha1.ClusterWorkspacePhaseReady, "shard-1", map[string]string{"team": "payments"}),
+						workspace("new", "team", tenancyv1alpha1.ClusterWorkspacePhaseInitializing, "", nil),
+					),
+					logicalcluster.New("root:org:apps"): tenancyfake.NewSimpleClientset(
+						workspace("api", "universal", tenancyv1alpha1.ClusterWorkspacePhaseReady, "shard-2", map[string]string{"team": "payments"}),
+						workspace("web", "universal", tenancyv1alpha1.ClusterWorkspacePhaseReady, "shard-1", nil),
+					),
+					logicalcluster.New("root:org:apps:api"): tenancyfake.NewSimpleClientset(),
+					logicalcluster.New("root:org:apps:web"): tenancyfake.NewSimpleClientset(),
+				},
+			},
+			IOStreams: streams,
+		}
+		return kc, out
+	}
+
+	t.Run("tree", func(t *testing.T) {
+		kc, out := newKubeConfig()
+		require.NoError(t, kc.Tree(context.Background(), "root:org"))
+		require.Equal(t, `root:org
+├── apps [root:universal, Ready, shard shard-1]
+│   ├── api [root:universal, Ready, shard shard-2]
+│   └── web [root:universal, Ready, shard shard-1]
+└── new [root:team, Initializing]
+`, out.String())
+	})
+
+	tests := map[string]struct {
+		workspaceType string
+		labelSelector string
+		want          string
+	}{
+		"by type name": {
+			workspaceType: "team",
+			want:          "root:org:new [root:team, Initializing]\n",
+		},
+		"by absolute type and label": {
+			workspaceType: "root:universal",
+			labelSelector: "team=payments",
+			want:          "root:org:apps [root:universal, Ready, shard shard-1]\nroot:org:apps:api [root:universal, Ready, shard shard-2]\n",
+		},
+		"nothing matches": {
+			labelSelector: "team=unknown",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			kc, out := newKubeConfig()
+			require.NoError(t, kc.Find(context.Background(), "root:org", tt.workspaceType, tt.labelSelector))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/component-base/featuregate"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// workspaceScopedFeatures are the features that can be enabled or disabled per workspace
+// through the featureGates of a ClusterWorkspace or its ClusterWorkspaceType. All other
+// features are process-wide only.
+var workspaceScopedFeatures = map[featuregate.Feature]bool{
+	SyncerTunnel: true,
+}
+
+// IsWorkspaceScoped returns true if the given feature can be set per workspace.
+func IsWorkspaceScoped(feature featuregate.Feature) bool {
+	return workspaceScopedFeatures[feature]
+}
+
+// WorkspaceScopedFeatures returns the sorted names of the features that can be set per workspace.
+func WorkspaceScopedFeatures() []string {
+	var features []string
+	for k := range workspaceScopedFeatures {
+		features = append(features, string(k))
+	}
+	sort.Strings(features)
+	return features
+}
+
+// ValidateWorkspaceFeatureGates returns an error if the given feature gates of a ClusterWorkspace
+// or ClusterWorkspaceType contain a feature that is not workspace-scoped.
+func ValidateWorkspaceFeatureGates(gates map[string]bool) error {
+	var unknown []string
+	for k := range gates {
+		if !IsWorkspaceScoped(featuregate.Feature(k)) {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("%v are not workspace-scoped feature gates, supported are %v", unknown, WorkspaceScopedFeatures())
+}
+
+// WorkspaceFeatureGate resolves feature gates per workspace. The featureGates of the
+// ClusterWorkspace take precedence over those of its ClusterWorkspaceType, which take
+// precedence over the process-wide feature gates.
+type WorkspaceFeatureGate struct {
+	defaults featuregate.FeatureGate
+
+	getWorkspace func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error)
+	getType      func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspaceType, error)
+}
+
+// NewWorkspaceFeatureGate returns a WorkspaceFeatureGate falling back to the given process-wide
+// feature gates. The getters usually look up ClusterWorkspaces and ClusterWorkspaceTypes in informers.
+func NewWorkspaceFeatureGate(
+	defaults featuregate.FeatureGate,
+	getWorkspace func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error),
+	getType func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspaceType, error),
+) *WorkspaceFeatureGate {
+	return &WorkspaceFeatureGate{
+		defaults:     defaults,
+		getWorkspace: getWorkspace,
+		getType:      getType,
+	}
+}
+
+// Enabled returns true if the feature is enabled for the given logical cluster. Features that are
+// not workspace-scoped, the root workspace and workspaces not known to this shard use the
+// process-wide setting.
+func (g *WorkspaceFeatureGate) Enabled(clusterName logicalcluster.Name, feature featuregate.Feature) bool {
+	enabled := g.defaults.Enabled(feature)
+	if !IsWorkspaceScoped(feature) {
+		return enabled
+	}
+
+	parent, hasParent := clusterName.Parent()
+	if !hasParent {
+		return enabled
+	}
+	ws, err := g.getWorkspace(parent, clusterName.Base())
+	if err != nil {
+		return enabled
+	}
+	if v, found := ws.Spec.FeatureGates[string(feature)]; found {
+		return v
+	}
+
+	if ws.Spec.Type.Path == "" {
+		return enabled
+	}
+	cwt, err := g.getType(logicalcluster.New(ws.Spec.Type.Path), tenancyv1alpha1.ObjectName(ws.Spec.Type.Name))
+	if err != nil {
+		return enabled
+	}
+	if v, found := cwt.Spec.FeatureGates[string(feature)]; found {
+		return v
+	}
+
+	return enabled
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/featuregate"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestWorkspaceFeatureGateEnabled(t *testing.T) {
+	tests := map[string]struct {
+		defaults  map[featuregate.Feature]bool
+		workspace map[string]bool
+		typ       map[string]bool
+		noType    bool
+		cluster   string
+		feature   featuregate.Feature
+		want      bool
+	}{
+		"process-wide default": {
+			cluster: "root:org:ws",
+			feature: SyncerTunnel,
+			want:    false,
+		},
+		"process-wide enabled": {
+			defaults: map[featuregate.Feature]bool{SyncerTunnel: true},
+			cluster:  "root:org:ws",
+			feature:  SyncerTunnel,
+			want:     true,
+		},
+		"enabled by type": {
+			typ:     map[string]bool{string(SyncerTunnel): true},
+			cluster: "root:org:ws",
+			feature: SyncerTunnel,
+			want:    true,
+		},
+		"disabled by type": {
+			defaults: map[featuregate.Feature]bool{SyncerTunnel: true},
+			typ:      map[string]bool{string(SyncerTunnel): false},
+			cluster:  "root:org:ws",
+			feature:  SyncerTunnel,
+			want:     false,
+		},
+		"workspace overrides type": {
+			workspace: map[string]bool{string(SyncerTunnel): true},
+			typ:       map[string]bool{string(SyncerTunnel): false},
+			cluster:   "root:org:ws",
+			feature:   SyncerTunnel,
+			want:      true,
+		},
+		"missing type falls back to default": {
+			noType:  true,
+			cluster: "root:org:ws",
+			feature: SyncerTunnel,
+			want:    false,
+		},
+		"unknown workspace falls back to default": {
+			workspace: map[string]bool{string(SyncerTunnel): true},
+			cluster:   "root:org:other",
+			feature:   SyncerTunnel,
+			want:      false,
+		},
+		"root uses default": {
+			workspace: map[string]bool{string(SyncerTunnel): true},
+			cluster:   "root",
+			feature:   SyncerTunnel,
+			want:      false,
+		},
+		"not workspace-scoped": {
+			workspace: map[string]bool{string(LocationAPI): false},
+			cluster:   "root:org:ws",
+			feature:   LocationAPI,
+			want:      true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			defaults := featuregate.NewFeatureGate()
+			require.NoError(t, defaults.Add(map[featuregate.Feature]featuregate.FeatureSpec{
+				LocationAPI:  {Default: true, PreRelease: featuregate.Alpha},
+				SyncerTunnel: {Default: false, PreRelease: featuregate.Alpha},
+			}))
+			require.NoError(t, defaults.SetFromMap(toStringMap(tt.defaults)))
+
+			g := NewWorkspaceFeatureGate(defaults,
+				func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+					if clusterName != logicalcluster.New("root:org") || name != "ws" {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+					}
+					return &tenancyv1alpha1.ClusterWorkspace{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+							Type:         tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:org"},
+							FeatureGates: tt.workspace,
+						},
+					}, nil
+				},
+				func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
+					if tt.noType || clusterName != logicalcluster.New("root:org") || name != "team" {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspacetypes"), name)
+					}
+					return &tenancyv1alpha1.ClusterWorkspaceType{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{FeatureGates: tt.typ},
+					}, nil
+				},
+			)
+
+			require.Equal(t, tt.want, g.Enabled(logicalcluster.New(tt.cluster), tt.feature))
+		})
+	}
+}
+
+func TestValidateWorkspaceFeatureGates(t *testing.T) {
+	require.NoError(t, ValidateWorkspaceFeatureGates(nil))
+	require.NoError(t, ValidateWorkspaceFeatureGates(map[string]bool{string(SyncerTunnel): false}))
+	require.EqualError(t, ValidateWorkspaceFeatureGates(map[string]bool{string(SyncerTunnel): true, string(LocationAPI): true, "Foo": true}),
+		"[Foo KCPLocationAPI] are not workspace-scoped feature gates, supported are [KCPSyncerTunnel]")
+}
+
+func toStringMap(m map[featuregate.Feature]bool) map[string]bool {
+	ret := map[string]bool{}
+	for k, v := range m {
+		ret[string(k)] = v
+	}
+	return ret
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 )
@@ -52,3 +54,86 @@ func IndexAPIBindingByClusterAndAcceptedClaimedGroupResources(obj interface{}) (
 
 	return ret, nil
 }
+
+// IndexAPIBindingByAPIExport is an index function that indexes an APIBinding by the cluster-aware key
+// of the APIExport it references, or for references by identity, the APIExport it is bound to.
+func IndexAPIBindingByAPIExport(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not an APIBinding", obj)
+	}
+
+	workspaceRef := apiBinding.ExportWorkspaceReference()
+	if workspaceRef == nil {
+		return []string{}, nil
+	}
+
+	// an empty path references an APIExport in the workspace of the APIBinding
+	apiExportClusterName := logicalcluster.New(workspaceRef.Path)
+	if apiExportClusterName.Empty() {
+		apiExportClusterName = logicalcluster.From(apiBinding)
+	}
+
+	key := clusters.ToClusterAwareKey(apiExportClusterName, workspaceRef.ExportName)
+	return []string{key}, nil
+}
+
+// IndexAPIBindingByAPIExportIdentity is an index function that indexes an APIBinding by the identity hash of the
+// APIExport it references by identity, and by the identity hashes of its bound resources.
+func IndexAPIBindingByAPIExportIdentity(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not an APIBinding", obj)
+	}
+
+	identities := sets.NewString()
+	if apiBinding.Spec.Reference.Identity != nil {
+		identities.Insert(apiBinding.Spec.Reference.Identity.IdentityHash)
+	}
+	for _, r := range apiBinding.Status.BoundResources {
+		if r.Schema.IdentityHash != "" {
+			identities.Insert(r.Schema.IdentityHash)
+		}
+	}
+
+	return identities.List(), nil
+}
+
+// IndexAPIBindingByAPIExportWorkspace is an index function that indexes an APIBinding by the workspace path of
+// the APIExport it references, or for references by identity, the APIExport it is bound to.
+func IndexAPIBindingByAPIExportWorkspace(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not an APIBinding", obj)
+	}
+
+	workspaceRef := apiBinding.ExportWorkspaceReference()
+	if workspaceRef == nil {
+		return []string{}, nil
+	}
+
+	// an empty path references an APIExport in the workspace of the APIBinding
+	if workspaceRef.Path == "" {
+		return []string{logicalcluster.From(apiBinding).String()}, nil
+	}
+
+	return []string{workspaceRef.Path}, nil
+}
+
+// IndexAPIBindingByBoundCRD is an index function that indexes an APIBinding by the names of the bound CRDs backing
+// its bound resources. Bound CRDs are named after the UID of the APIResourceSchema they are created from.
+func IndexAPIBindingByBoundCRD(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not an APIBinding", obj)
+	}
+
+	names := sets.NewString()
+	for _, r := range apiBinding.Status.BoundResources {
+		if r.Schema.UID != "" {
+			names.Insert(r.Schema.UID)
+		}
+	}
+
+	return names.List(), nil
+}
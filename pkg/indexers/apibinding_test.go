@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clusters"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestIndexAPIBindingByAPIExport(t *testing.T) {
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not an APIBinding": {
+			obj:     "not an APIBinding",
+			want:    []string{},
+			wantErr: true,
+		},
+		"no workspace reference": {
+			obj:     &apisv1alpha1.APIBinding{},
+			want:    []string{},
+			wantErr: false,
+		},
+		"has a workspace reference": {
+			obj: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "root:default",
+					},
+					Name: "foo",
+				},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{
+							Path:       "root:workspace1",
+							ExportName: "export1",
+						},
+					},
+				},
+			},
+			want:    []string{clusters.ToClusterAwareKey(logicalcluster.New("root:workspace1"), "export1")},
+			wantErr: false,
+		},
+		"has a workspace reference without path": {
+			obj: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "root:default",
+					},
+					Name: "foo",
+				},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{
+							ExportName: "export1",
+						},
+					},
+				},
+			},
+			want:    []string{clusters.ToClusterAwareKey(logicalcluster.New("root:default"), "export1")},
+			wantErr: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexAPIBindingByAPIExport(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexAPIBindingByAPIExport() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexAPIBindingByAPIExport() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexAPIBindingByAPIExportIdentity(t *testing.T) {
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not an APIBinding": {
+			obj:     "not an APIBinding",
+			want:    []string{},
+			wantErr: true,
+		},
+		"no identity": {
+			obj:  &apisv1alpha1.APIBinding{},
+			want: []string{},
+		},
+		"identity reference and bound resources": {
+			obj: &apisv1alpha1.APIBinding{
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.ExportReference{
+						Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "id1"},
+					},
+				},
+				Status: apisv1alpha1.APIBindingStatus{
+					BoundResources: []apisv1alpha1.BoundAPIResource{
+						{Resource: "foos", Schema: apisv1alpha1.BoundAPIResourceSchema{IdentityHash: "id1"}},
+						{Resource: "bars", Schema: apisv1alpha1.BoundAPIResourceSchema{IdentityHash: "id2"}},
+					},
+				},
+			},
+			want: []string{"id1", "id2"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexAPIBindingByAPIExportIdentity(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexAPIBindingByAPIExportIdentity() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexAPIBindingByAPIExportIdentity() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexAPIBindingByAPIExportWorkspace(t *testing.T) {
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not an APIBinding": {
+			obj:     "not an APIBinding",
+			want:    []string{},
+			wantErr: true,
+		},
+		"unresolved identity reference": {
+			obj: &apisv1alpha1.APIBinding{
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.ExportReference{
+						Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "id1"},
+					},
+				},
+			},
+			want: []string{},
+		},
+		"workspace reference": {
+			obj: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "root:default",
+					},
+				},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:workspace1", ExportName: "export1"},
+					},
+				},
+			},
+			want: []string{"root:workspace1"},
+		},
+		"workspace reference without path": {
+			obj: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "root:default",
+					},
+				},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{ExportName: "export1"},
+					},
+				},
+			},
+			want: []string{"root:default"},
+		},
+		"resolved identity reference": {
+			obj: &apisv1alpha1.APIBinding{
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: apisv1alpha1.ExportReference{
+						Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "id1"},
+					},
+				},
+				Status: apisv1alpha1.APIBindingStatus{
+					BoundAPIExport: &apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "export1"},
+					},
+				},
+			},
+			want: []string{"root:provider"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexAPIBindingByAPIExportWorkspace(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexAPIBindingByAPIExportWorkspace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexAPIBindingByAPIExportWorkspace() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexAPIBindingByBoundCRD(t *testing.T) {
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not an APIBinding": {
+			obj:     "not an APIBinding",
+			want:    []string{},
+			wantErr: true,
+		},
+		"no bound resources": {
+			obj:  &apisv1alpha1.APIBinding{},
+			want: []string{},
+		},
+		"bound resources": {
+			obj: &apisv1alpha1.APIBinding{
+				Status: apisv1alpha1.APIBindingStatus{
+					BoundResources: []apisv1alpha1.BoundAPIResource{
+						{Resource: "foos", Schema: apisv1alpha1.BoundAPIResourceSchema{UID: "uid2"}},
+						{Resource: "bars", Schema: apisv1alpha1.BoundAPIResourceSchema{UID: "uid1"}},
+						{Resource: "bazs"},
+					},
+				},
+			},
+			want: []string{"uid1", "uid2"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexAPIBindingByBoundCRD(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexAPIBindingByBoundCRD() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexAPIBindingByBoundCRD() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -29,8 +29,11 @@ import (
 const (
 	// APIExportByIdentity is the indexer name for retrieving APIExports by identity hash.
 	APIExportByIdentity = "APIExportByIdentity"
-	// APIExportBySecret is the indexer name for retrieving APIExports by
+	// APIExportBySecret is the indexer name for retrieving APIExports by their identity secret.
 	APIExportBySecret = "APIExportSecret"
+	// APIExportByAPIResourceSchema is the indexer name for retrieving APIExports by the cluster-aware key of their
+	// latest APIResourceSchemas.
+	APIExportByAPIResourceSchema = "APIExportByAPIResourceSchema"
 )
 
 // IndexAPIExportByIdentity is an index function that indexes an APIExport by its identity hash.
@@ -64,6 +67,21 @@ func IndexAPIExportBySecret(obj interface{}) ([]string, error) {
 		return []string{}, nil
 	}
 
-	// TODO(ncdc): use future shared key func if we ever create one
-	return []string{ref.Namespace + "/" + clusters.ToClusterAwareKey(logicalcluster.From(apiExport), ref.Name)}, nil
+	return []string{NamespaceScopedKey(logicalcluster.From(apiExport), ref.Namespace, ref.Name)}, nil
+}
+
+// IndexAPIExportByAPIResourceSchema is an index function that indexes an APIExport by the cluster-aware keys of
+// its spec.latestResourceSchemas.
+func IndexAPIExportByAPIResourceSchema(obj interface{}) ([]string, error) {
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not an APIExport", obj)
+	}
+
+	ret := make([]string, len(apiExport.Spec.LatestResourceSchemas))
+	for i := range apiExport.Spec.LatestResourceSchemas {
+		ret[i] = clusters.ToClusterAwareKey(logicalcluster.From(apiExport), apiExport.Spec.LatestResourceSchemas[i])
+	}
+
+	return ret, nil
 }
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clusters"
+)
+
+const (
+	// ByUID is the name for the index that indexes an object by its logical cluster and UID.
+	ByUID = "byUID"
+	// ByOwnerUID is the name for the index that indexes an object by its logical cluster and the UIDs of its owners.
+	ByOwnerUID = "byOwnerUID"
+)
+
+// IndexByUID is an index function that indexes an object by its logical cluster and UID. Index values
+// are of the form <cluster name><separator><uid>.
+func IndexByUID(obj interface{}) ([]string, error) {
+	a, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{UIDKey(logicalcluster.From(a), a.GetUID())}, nil
+}
+
+// IndexByOwnerUID is an index function that indexes an object by its logical cluster and the UIDs of
+// its ownerReferences. Index values are of the form <cluster name><separator><owner uid>.
+func IndexByOwnerUID(obj interface{}) ([]string, error) {
+	a, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterName := logicalcluster.From(a)
+	refs := a.GetOwnerReferences()
+	keys := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		keys = append(keys, UIDKey(clusterName, ref.UID))
+	}
+	return keys, nil
+}
+
+// UIDKey returns the index value used by ByUID and ByOwnerUID for the given logical cluster and UID.
+func UIDKey(clusterName logicalcluster.Name, uid types.UID) string {
+	return clusters.ToClusterAwareKey(clusterName, string(uid))
+}
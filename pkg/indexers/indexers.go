@@ -41,6 +41,18 @@ const (
 	// APIBindingByClusterAndAcceptedClaimedGroupResources is the name for the index that indexes an APIBinding by its
 	// cluster name and accepted claimed group resources.
 	APIBindingByClusterAndAcceptedClaimedGroupResources = "byClusterAndAcceptedClaimedGroupResources"
+	// APIBindingByAPIExport is the name for the index that indexes an APIBinding by the cluster-aware key of the
+	// APIExport it references.
+	APIBindingByAPIExport = "byAPIExport"
+	// APIBindingByAPIExportIdentity is the name for the index that indexes an APIBinding by the identity hash of the
+	// APIExport it references or is bound to.
+	APIBindingByAPIExportIdentity = "byAPIExportIdentity"
+	// APIBindingByAPIExportWorkspace is the name for the index that indexes an APIBinding by the workspace path of the
+	// APIExport it references.
+	APIBindingByAPIExportWorkspace = "byAPIExportWorkspace"
+	// APIBindingByBoundCRD is the name for the index that indexes an APIBinding by the names of the bound CRDs
+	// backing its bound resources.
+	APIBindingByBoundCRD = "byBoundCRD"
 )
 
 // ClusterScoped returns cache.Indexers appropriate for cluster-scoped resources.
@@ -78,6 +90,13 @@ func IndexByLogicalClusterAndNamespace(obj interface{}) ([]string, error) {
 	return []string{clusters.ToClusterAwareKey(logicalcluster.From(a), a.GetNamespace())}, nil
 }
 
+// NamespaceScopedKey returns the cache key of a namespaced object in the given logical cluster, i.e.
+// <namespace>/<cluster name><separator><name>. Indexes of object references use it for their values, such that
+// the referencing objects can be looked up by the key of a referenced object in an informer event.
+func NamespaceScopedKey(clusterName logicalcluster.Name, namespace, name string) string {
+	return namespace + "/" + clusters.ToClusterAwareKey(clusterName, name)
+}
+
 // IndexBySyncerFinalizerKey indexes by syncer finalizer label keys.
 func IndexBySyncerFinalizerKey(obj interface{}) ([]string, error) {
 	metaObj, ok := obj.(metav1.Object)
@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/client-go/tools/clusters"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+const (
+	// PlacementBySelectedLocation is the indexer name for retrieving Placements by the cluster-aware key of the
+	// Location they selected.
+	PlacementBySelectedLocation = "PlacementBySelectedLocation"
+	// PlacementBySyncTargetKey is the indexer name for retrieving Placements by the key of the SyncTarget they are
+	// scheduled to.
+	PlacementBySyncTargetKey = "PlacementBySyncTargetKey"
+)
+
+// IndexPlacementBySelectedLocation is an index function that indexes a Placement by the cluster-aware key of the
+// Location it selected.
+func IndexPlacementBySelectedLocation(obj interface{}) ([]string, error) {
+	placement, ok := obj.(*schedulingv1alpha1.Placement)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a Placement, but is %T", obj)
+	}
+
+	if placement.Status.SelectedLocation == nil {
+		return []string{}, nil
+	}
+
+	return []string{clusters.ToClusterAwareKey(logicalcluster.New(placement.Status.SelectedLocation.Path), placement.Status.SelectedLocation.LocationName)}, nil
+}
+
+// IndexPlacementBySyncTargetKey is an index function that indexes a Placement by the key of the SyncTarget recorded
+// in its internal.workload.kcp.dev/synctarget annotation.
+func IndexPlacementBySyncTargetKey(obj interface{}) ([]string, error) {
+	placement, ok := obj.(*schedulingv1alpha1.Placement)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a Placement, but is %T", obj)
+	}
+
+	syncTargetKey, found := placement.Annotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey]
+	if !found || syncTargetKey == "" {
+		return []string{}, nil
+	}
+
+	return []string{syncTargetKey}, nil
+}
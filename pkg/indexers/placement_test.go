@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestIndexPlacementBySelectedLocation(t *testing.T) {
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not a Placement": {
+			obj:     "not a Placement",
+			want:    []string{},
+			wantErr: true,
+		},
+		"no selected location": {
+			obj:  &schedulingv1alpha1.Placement{},
+			want: []string{},
+		},
+		"selected location": {
+			obj: &schedulingv1alpha1.Placement{
+				Status: schedulingv1alpha1.PlacementStatus{
+					SelectedLocation: &schedulingv1alpha1.LocationReference{Path: "root:org:ws", LocationName: "us-east1"},
+				},
+			},
+			want: []string{"root:org:ws|us-east1"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexPlacementBySelectedLocation(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexPlacementBySelectedLocation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexPlacementBySelectedLocation() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexPlacementBySyncTargetKey(t *testing.T) {
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not a Placement": {
+			obj:     "not a Placement",
+			want:    []string{},
+			wantErr: true,
+		},
+		"not scheduled": {
+			obj:  &schedulingv1alpha1.Placement{},
+			want: []string{},
+		},
+		"scheduled": {
+			obj: &schedulingv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "abc123"},
+				},
+			},
+			want: []string{"abc123"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexPlacementBySyncTargetKey(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexPlacementBySyncTargetKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexPlacementBySyncTargetKey() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reference describes how objects referencing other objects are found when a referenced object changes: the index
+// IndexName of Indexer maps the referencing objects to index values, and ReferencedValues maps a referenced object
+// to the index values it is referenced by. Often, ReferencedValues is IndexByKey, or the index function of an index
+// of the referenced objects.
+type Reference struct {
+	Indexer          cache.Indexer
+	IndexName        string
+	ReferencedValues cache.IndexFunc
+}
+
+// Referencing returns the objects referencing the referenced object through any of the given references. Objects
+// referencing it multiple times are returned once. The referenced object may be a cache.DeletedFinalStateUnknown.
+func Referencing(referenced interface{}, refs ...Reference) ([]interface{}, error) {
+	if tombstone, ok := referenced.(cache.DeletedFinalStateUnknown); ok {
+		referenced = tombstone.Obj
+	}
+
+	seen := sets.NewString()
+	var ret []interface{}
+	for _, ref := range refs {
+		values, err := ref.ReferencedValues(referenced)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+
+			objs, err := ref.Indexer.ByIndex(ref.IndexName, value)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, obj := range objs {
+				key, err := cache.MetaNamespaceKeyFunc(obj)
+				if err != nil {
+					return nil, err
+				}
+				if seen.Has(key) {
+					continue
+				}
+				seen.Insert(key)
+				ret = append(ret, obj)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// IndexByKey is an index function that indexes an object by its cache key, e.g. for use as
+// Reference.ReferencedValues when referencing objects are indexed by the key of the object they reference.
+func IndexByKey(obj interface{}) ([]string, error) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return []string{key}, nil
+}
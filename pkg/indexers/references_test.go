@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestReferencing(t *testing.T) {
+	newBinding := func(name string, reference apisv1alpha1.ExportReference) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+				Name:        name,
+			},
+			Spec: apisv1alpha1.APIBindingSpec{Reference: reference},
+		}
+	}
+
+	bindingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, Indexers(APIBindingByAPIExport, APIBindingByAPIExportIdentity))
+	for _, binding := range []*apisv1alpha1.APIBinding{
+		newBinding("by-workspace", apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "export"}}),
+		newBinding("by-identity", apisv1alpha1.ExportReference{Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "hash"}}),
+		newBinding("other", apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "other"}}),
+	} {
+		require.NoError(t, bindingIndexer.Add(binding))
+	}
+
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+			Name:        "export",
+		},
+		Status: apisv1alpha1.APIExportStatus{IdentityHash: "hash"},
+	}
+	refs := []Reference{
+		{Indexer: bindingIndexer, IndexName: APIBindingByAPIExport, ReferencedValues: IndexByKey},
+		{Indexer: bindingIndexer, IndexName: APIBindingByAPIExportIdentity, ReferencedValues: IndexAPIExportByIdentity},
+		// referencing the same bindings again must not return them twice
+		{Indexer: bindingIndexer, IndexName: APIBindingByAPIExport, ReferencedValues: IndexByKey},
+	}
+
+	names := func(objs []interface{}) []string {
+		var ret []string
+		for _, obj := range objs {
+			ret = append(ret, obj.(*apisv1alpha1.APIBinding).Name)
+		}
+		return ret
+	}
+
+	referencing, err := Referencing(export, refs...)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"by-workspace", "by-identity"}, names(referencing))
+
+	referencing, err = Referencing(cache.DeletedFinalStateUnknown{Key: "root:provider|export", Obj: export}, refs...)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"by-workspace", "by-identity"}, names(referencing), "expected tombstones to be unwrapped")
+
+	export.Status.IdentityHash = ""
+	referencing, err = Referencing(export, refs...)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"by-workspace"}, names(referencing), "expected empty index values to be skipped")
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// registry maps the names of all shared indexes to their index functions. Every index name has exactly one
+// index function, such that informers shared between controllers never hold an index under the same name with
+// different key formats.
+var registry = cache.Indexers{
+	ByLogicalCluster:             IndexByLogicalCluster,
+	ByLogicalClusterAndNamespace: IndexByLogicalClusterAndNamespace,
+	BySyncerFinalizerKey:         IndexBySyncerFinalizerKey,
+	ByUID:                        IndexByUID,
+	ByOwnerUID:                   IndexByOwnerUID,
+
+	APIBindingByClusterAndAcceptedClaimedGroupResources: IndexAPIBindingByClusterAndAcceptedClaimedGroupResources,
+	APIBindingByAPIExport:                               IndexAPIBindingByAPIExport,
+	APIBindingByAPIExportIdentity:                       IndexAPIBindingByAPIExportIdentity,
+	APIBindingByAPIExportWorkspace:                      IndexAPIBindingByAPIExportWorkspace,
+	APIBindingByBoundCRD:                                IndexAPIBindingByBoundCRD,
+	APIExportByIdentity:                                 IndexAPIExportByIdentity,
+	CertificateSigningRequestsBySyncTargetKey:           IndexCertificateSigningRequestsBySyncTargetKey,
+	APIExportBySecret:                                   IndexAPIExportBySecret,
+	APIExportByAPIResourceSchema:                        IndexAPIExportByAPIResourceSchema,
+	NamespacesByClusterAndSyncTargetKey:                 IndexNamespacesByClusterAndSyncTargetKey,
+	PlacementBySelectedLocation:                         IndexPlacementBySelectedLocation,
+	PlacementBySyncTargetKey:                            IndexPlacementBySyncTargetKey,
+	SharedSecretBySecret:                                IndexSharedSecretBySecret,
+	SyncTargetsBySyncTargetKey:                          IndexSyncTargetsBySyncTargetKey,
+	SyncTargetsByExportIdentity:                         IndexSyncTargetsByExportIdentity,
+}
+
+// Indexers returns the registered index functions of the given index names. It panics if an index name is not
+// registered.
+func Indexers(names ...string) cache.Indexers {
+	ret := make(cache.Indexers, len(names))
+	for _, name := range names {
+		indexFunc, found := registry[name]
+		if !found {
+			panic(fmt.Errorf("unknown index %q", name))
+		}
+		ret[name] = indexFunc
+	}
+	return ret
+}
+
+// AddOrDie adds the registered indexes of the given names to indexer, unless they exist already. It panics if an
+// index name is not registered, or the indexes cannot be added.
+func AddOrDie(indexer cache.Indexer, names ...string) {
+	AddIfNotPresentOrDie(indexer, Indexers(names...))
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestAddOrDie(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		ByLogicalCluster: IndexByLogicalCluster,
+	})
+
+	AddOrDie(indexer, ByLogicalCluster, APIExportByIdentity)
+	require.Contains(t, indexer.GetIndexers(), ByLogicalCluster)
+	require.Contains(t, indexer.GetIndexers(), APIExportByIdentity)
+
+	// adding the same indexes again is a no-op
+	AddOrDie(indexer, APIExportByIdentity)
+	require.Len(t, indexer.GetIndexers(), 2)
+
+	require.Panics(t, func() { AddOrDie(indexer, "unknown") })
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// SharedSecretBySecret is the indexer name for retrieving SharedSecrets by the Secret they share.
+	SharedSecretBySecret = "SharedSecretBySecret"
+)
+
+// IndexSharedSecretBySecret is an index function that indexes a SharedSecret by its secret reference. Index values
+// are of the form <secret reference namespace>/<cluster name><separator><secret reference name> (cache keys).
+func IndexSharedSecretBySecret(obj interface{}) ([]string, error) {
+	sharedSecret, ok := obj.(*tenancyv1alpha1.SharedSecret)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not a SharedSecret", obj)
+	}
+
+	ref := sharedSecret.Spec.SecretRef
+	if ref.Namespace == "" || ref.Name == "" {
+		return []string{}, nil
+	}
+
+	return []string{NamespaceScopedKey(logicalcluster.From(sharedSecret), ref.Namespace, ref.Name)}, nil
+}
@@ -17,15 +17,32 @@ limitations under the License.
 package indexers
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
+
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 const (
 	SyncTargetsBySyncTargetKey = "SyncTargetsBySyncTargetKey"
+	// SyncTargetsByExportIdentity is the indexer name for retrieving SyncTargets by the identity hash of the
+	// APIExports they support.
+	SyncTargetsByExportIdentity = "SyncTargetsByExportIdentity"
+	// CertificateSigningRequestsBySyncTargetKey is the indexer name for retrieving the syncer
+	// CertificateSigningRequests by the key of the SyncTarget they request a certificate for.
+	CertificateSigningRequestsBySyncTargetKey = "CertificateSigningRequestsBySyncTargetKey"
+	// NamespacesByClusterAndSyncTargetKey is the indexer name for retrieving Namespaces by their logical cluster
+	// and the key of a SyncTarget they are scheduled to, joined by ClusterAndSyncTargetKey.
+	NamespacesByClusterAndSyncTargetKey = "NamespacesByClusterAndSyncTargetKey"
 )
 
 func IndexSyncTargetsBySyncTargetKey(obj interface{}) ([]string, error) {
@@ -36,3 +53,73 @@ func IndexSyncTargetsBySyncTargetKey(obj interface{}) ([]string, error) {
 
 	return []string{workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name)}, nil
 }
+
+// IndexSyncTargetsByExportIdentity is an index function that indexes a SyncTarget by the identity hashes of the
+// APIExports it references by identity in its supported exports.
+func IndexSyncTargetsByExportIdentity(obj interface{}) ([]string, error) {
+	syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a workloadv1alpha1.SyncTarget, but is %T", obj)
+	}
+
+	identities := sets.NewString()
+	for _, export := range syncTarget.Spec.SupportedAPIExports {
+		if export.Identity == nil || export.Identity.IdentityHash == "" {
+			continue
+		}
+		identities.Insert(export.Identity.IdentityHash)
+	}
+
+	return identities.List(), nil
+}
+
+// IndexCertificateSigningRequestsBySyncTargetKey is an index function that indexes a CertificateSigningRequest
+// of the syncer signer by the key of the SyncTarget named in the common name of the requested certificate.
+func IndexCertificateSigningRequestsBySyncTargetKey(obj interface{}) ([]string, error) {
+	csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a certificatesv1.CertificateSigningRequest, but is %T", obj)
+	}
+	if csr.Spec.SignerName != workloadv1alpha1.SyncerSignerName {
+		return []string{}, nil
+	}
+
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil {
+		return []string{}, nil
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return []string{}, nil
+	}
+	syncTargetWorkspace, syncTargetName, ok := workloadv1alpha1.ParseSyncerCertificateUserName(request.Subject.CommonName)
+	if !ok {
+		return []string{}, nil
+	}
+
+	return []string{workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName)}, nil
+}
+
+// IndexNamespacesByClusterAndSyncTargetKey is an index function that indexes a Namespace by its logical cluster
+// and the keys of the SyncTargets it has a state.workload.kcp.dev/<sync-target-key> label for.
+func IndexNamespacesByClusterAndSyncTargetKey(obj interface{}) ([]string, error) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a corev1.Namespace, but is %T", obj)
+	}
+
+	clusterName := logicalcluster.From(ns)
+	var keys []string
+	for label := range ns.Labels {
+		if syncTargetKey := strings.TrimPrefix(label, workloadv1alpha1.ClusterResourceStateLabelPrefix); syncTargetKey != label && syncTargetKey != "" {
+			keys = append(keys, ClusterAndSyncTargetKey(clusterName, syncTargetKey))
+		}
+	}
+	return keys, nil
+}
+
+// ClusterAndSyncTargetKey returns the key of the NamespacesByClusterAndSyncTargetKey index for the given logical
+// cluster and SyncTarget key.
+func ClusterAndSyncTargetKey(clusterName logicalcluster.Name, syncTargetKey string) string {
+	return clusters.ToClusterAwareKey(clusterName, syncTargetKey)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package indexers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"reflect"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestIndexSyncTargetsByExportIdentity(t *testing.T) {
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not a SyncTarget": {
+			obj:     "not a SyncTarget",
+			want:    []string{},
+			wantErr: true,
+		},
+		"no supported exports": {
+			obj: &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			},
+			want: []string{},
+		},
+		"workspace and identity references": {
+			obj: &workloadv1alpha1.SyncTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec: workloadv1alpha1.SyncTargetSpec{
+					SupportedAPIExports: []apisv1alpha1.ExportReference{
+						{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:org:ws", ExportName: "kubernetes"}},
+						{Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "hash2"}},
+						{Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "hash1"}},
+						{Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "hash2"}},
+					},
+				},
+			},
+			want: []string{"hash1", "hash2"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexSyncTargetsByExportIdentity(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexSyncTargetsByExportIdentity() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexSyncTargetsByExportIdentity() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexCertificateSigningRequestsBySyncTargetKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(commonName string) []byte {
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: commonName}}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	}
+
+	tests := map[string]struct {
+		obj     interface{}
+		want    []string
+		wantErr bool
+	}{
+		"not a CertificateSigningRequest": {
+			obj:     "not a CertificateSigningRequest",
+			want:    []string{},
+			wantErr: true,
+		},
+		"other signer": {
+			obj: &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{
+					SignerName: "kubernetes.io/kube-apiserver-client",
+					Request:    request("system:kcp:syncer:root:org:ws:us-east1"),
+				},
+			},
+			want: []string{},
+		},
+		"no syncer common name": {
+			obj: &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{
+					SignerName: workloadv1alpha1.SyncerSignerName,
+					Request:    request("admin"),
+				},
+			},
+			want: []string{},
+		},
+		"syncer request": {
+			obj: &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{
+					SignerName: workloadv1alpha1.SyncerSignerName,
+					Request:    request("system:kcp:syncer:root:org:ws:us-east1"),
+				},
+			},
+			want: []string{workloadv1alpha1.ToSyncTargetKey(logicalcluster.New("root:org:ws"), "us-east1")},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := IndexCertificateSigningRequestsBySyncTargetKey(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IndexCertificateSigningRequestsBySyncTargetKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndexCertificateSigningRequestsBySyncTargetKey() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexNamespacesByClusterAndSyncTargetKey(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+			Labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "abc": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix:         "invalid",
+				"other": "label",
+			},
+		},
+	}
+	got, err := IndexNamespacesByClusterAndSyncTargetKey(ns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{ClusterAndSyncTargetKey(logicalcluster.New("root:org:ws"), "abc")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
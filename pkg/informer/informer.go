@@ -42,6 +42,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	kcpindexers "github.com/kcp-dev/kcp/pkg/indexers"
 	metadataclient "github.com/kcp-dev/kcp/pkg/metadata"
 	"github.com/kcp-dev/kcp/pkg/projection"
 )
@@ -54,6 +55,15 @@ const (
 
 // DynamicDiscoverySharedInformerFactory is a SharedInformerFactory that
 // dynamically discovers new types and begins informing on them.
+//
+// The informers only watch PartialObjectMetadata, i.e. the cached objects are
+// *unstructured.Unstructured holding the apiVersion, kind and metadata of the
+// objects, but neither spec nor status. This keeps the memory footprint small
+// for big object types like ConfigMaps and Secrets, and makes the factory the
+// informer plumbing of choice for controllers that only need labels,
+// annotations, finalizers or owner references, e.g. the namespace and resource
+// schedulers and the garbage collector. Controllers that need the full objects
+// have to use typed or dynamic informers instead.
 type DynamicDiscoverySharedInformerFactory struct {
 	dynamicClient     dynamic.Interface
 	filterFunc        func(interface{}) bool
@@ -61,6 +71,12 @@ type DynamicDiscoverySharedInformerFactory struct {
 	crdIndexer        cache.Indexer
 	crdInformerSynced cache.InformerSynced
 
+	// boundCRDsCluster, apiBindingIndexer and apiBindingInformerSynced are only set when the factory informs on
+	// bound CRDs only while they are in use. See InformOnlyUsedBoundAPIs.
+	boundCRDsCluster         logicalcluster.Name
+	apiBindingIndexer        cache.Indexer
+	apiBindingInformerSynced cache.InformerSynced
+
 	// handlersLock protects multiple writers racing to update handlers.
 	handlersLock sync.Mutex
 	handlers     atomic.Value
@@ -120,45 +136,11 @@ func NewDynamicDiscoverySharedInformerFactory(
 	// metadata only. In this instance, version does not matter, because a wildcard partial metadata list request
 	// for CRs always serves all CRs for the group-resource, regardless of storage version.
 	if err := crdInformer.Informer().AddIndexers(cache.Indexers{
-		byGroupFirstFoundVersionResourceIndex: func(obj interface{}) ([]string, error) {
-			crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
-			if !ok {
-				return nil, fmt.Errorf("%T is not a CustomResourceDefinition", obj)
-			}
-
-			firstServedVersion := ""
-			for _, version := range crd.Spec.Versions {
-				if !version.Served {
-					continue
-				}
-				firstServedVersion = version.Name
-				break
-			}
-
-			if firstServedVersion == "" {
-				return []string{}, nil
-			}
-
-			group := crd.Spec.Group
-			resource := crd.Spec.Names.Plural
-
-			indexValue := fmt.Sprintf("%s/%s/%s", group, firstServedVersion, resource)
-			return []string{indexValue}, nil
-		},
+		byGroupFirstFoundVersionResourceIndex: indexCRDByGroupFirstFoundVersionResource,
 	}); err != nil {
 		return nil, err
 	}
 
-	// Any time a CRD event comes in, let StartWorker() know about it
-	notifyUpdateNeeded := func() {
-		select {
-		case f.updateCh <- struct{}{}:
-			klog.V(4).InfoS("Enqueued update notification for dynamic informer recalculation")
-		default:
-			klog.V(5).InfoS("Dropping update notification for dynamic informer recalculation because a notification is already pending")
-		}
-	}
-
 	crdIsEstablished := func(obj interface{}) bool {
 		crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
 		if !ok {
@@ -173,24 +155,61 @@ func NewDynamicDiscoverySharedInformerFactory(
 	crdInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if crdIsEstablished(obj) {
-				notifyUpdateNeeded()
+				f.notifyUpdateNeeded()
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			oldEstablished := crdIsEstablished(oldObj)
 			newEstablished := crdIsEstablished(newObj)
 			if newEstablished || oldEstablished != newEstablished {
-				notifyUpdateNeeded()
+				f.notifyUpdateNeeded()
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
-			notifyUpdateNeeded()
+			f.notifyUpdateNeeded()
 		},
 	})
 
 	return f, nil
 }
 
+// indexCRDByGroupFirstFoundVersionResource indexes a CRD by its group/firstServedVersion/resource.
+func indexCRDByGroupFirstFoundVersionResource(obj interface{}) ([]string, error) {
+	crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a CustomResourceDefinition", obj)
+	}
+
+	firstServedVersion := ""
+	for _, version := range crd.Spec.Versions {
+		if !version.Served {
+			continue
+		}
+		firstServedVersion = version.Name
+		break
+	}
+
+	if firstServedVersion == "" {
+		return []string{}, nil
+	}
+
+	group := crd.Spec.Group
+	resource := crd.Spec.Names.Plural
+
+	indexValue := fmt.Sprintf("%s/%s/%s", group, firstServedVersion, resource)
+	return []string{indexValue}, nil
+}
+
+// notifyUpdateNeeded lets StartWorker() know that informers might have to be added or removed.
+func (d *DynamicDiscoverySharedInformerFactory) notifyUpdateNeeded() {
+	select {
+	case d.updateCh <- struct{}{}:
+		klog.V(4).InfoS("Enqueued update notification for dynamic informer recalculation")
+	default:
+		klog.V(5).InfoS("Dropping update notification for dynamic informer recalculation because a notification is already pending")
+	}
+}
+
 // ForResource returns the GenericInformer for gvr, creating it if needed. The GenericInformer must be started
 // by calling Start on the DynamicDiscoverySharedInformerFactory before the GenericInformer can be used.
 func (d *DynamicDiscoverySharedInformerFactory) ForResource(gvr schema.GroupVersionResource) (kubernetesinformers.GenericInformer, error) {
@@ -223,11 +242,16 @@ func (d *DynamicDiscoverySharedInformerFactory) informerForResourceLockHeld(gvr
 	klog.V(2).Infof("Adding dynamic informer for %q", gvr)
 
 	// TODO(ncdc) remove NamespaceIndex when scoping is fully integrated
-	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	// The logical cluster indexes back the listers of ClusterListers.
+	indexers := cache.Indexers{
+		cache.NamespaceIndex:                     cache.MetaNamespaceIndexFunc,
+		kcpindexers.ByLogicalCluster:             kcpindexers.IndexByLogicalCluster,
+		kcpindexers.ByLogicalClusterAndNamespace: kcpindexers.IndexByLogicalClusterAndNamespace,
+	}
 
 	for k, v := range d.indexers {
-		if k == cache.NamespaceIndex {
-			// Don't allow overriding NamespaceIndex
+		if _, found := indexers[k]; found {
+			// Don't allow overriding the built-in indexes
 			continue
 		}
 
@@ -296,6 +320,36 @@ func (d *DynamicDiscoverySharedInformerFactory) Listers() (listers map[schema.Gr
 	return listers, notSynced
 }
 
+// ClusterListers returns a map of per-resource-type listers scoped to the given
+// logical cluster for all types that are known by this informer factory, and
+// that are synced. The listers are served from the logical cluster indexes of
+// the wildcard informers, i.e. they never return objects of other logical
+// clusters.
+//
+// If any informers aren't synced, their GVRs are returned so that they can be
+// checked and processed later.
+func (d *DynamicDiscoverySharedInformerFactory) ClusterListers(clusterName logicalcluster.Name) (listers map[schema.GroupVersionResource]cache.GenericLister, notSynced []schema.GroupVersionResource) {
+	listers = map[schema.GroupVersionResource]cache.GenericLister{}
+
+	d.informersLock.RLock()
+	defer d.informersLock.RUnlock()
+
+	for gvr, informer := range d.informers {
+		if !informer.Informer().HasSynced() {
+			notSynced = append(notSynced, gvr)
+			continue
+		}
+
+		listers[gvr] = &scopedLister{
+			clusterName: clusterName,
+			indexer:     informer.Informer().GetIndexer(),
+			resource:    gvr.GroupResource(),
+		}
+	}
+
+	return listers, notSynced
+}
+
 // GVREventHandler is an event handler that includes the GroupVersionResource
 // of the resource being handled.
 type GVREventHandler interface {
@@ -359,6 +413,11 @@ func (d *DynamicDiscoverySharedInformerFactory) StartWorker(ctx context.Context)
 		return
 	}
 
+	if d.apiBindingInformerSynced != nil && !cache.WaitForNamedCacheSync("kcp-ddsif-apibinding", ctx.Done(), d.apiBindingInformerSynced) {
+		klog.Errorf("APIBinding informer never synced")
+		return
+	}
+
 	// Now that the CRD informer has synced, do an initial update
 	d.updateInformers()
 
@@ -413,9 +472,8 @@ func builtInInformableTypes() map[schema.GroupVersionResource]struct{} {
 	return latest
 }
 
-func (d *DynamicDiscoverySharedInformerFactory) updateInformers() {
-	klog.V(5).InfoS("Determining dynamic informer additions and removals")
-
+// informableTypes returns the built-in types and the types served by established CRDs that are in use.
+func (d *DynamicDiscoverySharedInformerFactory) informableTypes() map[schema.GroupVersionResource]struct{} {
 	latest := builtInInformableTypes()
 
 	// Get the unique set of Group(Version)Resources (version doesn't matter because we're expecting a wildcard
@@ -436,9 +494,22 @@ func (d *DynamicDiscoverySharedInformerFactory) updateInformers() {
 			continue
 		}
 
+		if !d.inUse(s) {
+			klog.V(5).Infof("Skipping dynamic informer for %q because no APIBinding binds it", gvr)
+			continue
+		}
+
 		latest[gvr] = struct{}{}
 	}
 
+	return latest
+}
+
+func (d *DynamicDiscoverySharedInformerFactory) updateInformers() {
+	klog.V(5).InfoS("Determining dynamic informer additions and removals")
+
+	latest := d.informableTypes()
+
 	// Grab a read lock to compare against d.informers to see if we need to start or stop any informers
 	d.informersLock.RLock()
 	informersToAdd, informersToRemove := d.calculateInformersLockHeld(latest)
@@ -488,6 +559,13 @@ func (d *DynamicDiscoverySharedInformerFactory) updateInformers() {
 			close(stop)
 		}
 
+		// Release the cached objects right away, even if consumers still hold on to the informer or its listers.
+		if inf, ok := d.informers[gvr]; ok {
+			if err := inf.Informer().GetIndexer().Replace([]interface{}{}, ""); err != nil {
+				utilruntime.HandleError(fmt.Errorf("error releasing cache of dynamic informer for %q: %w", gvr, err))
+			}
+		}
+
 		klog.V(4).Infof("Removing dynamic informer from maps for %q", gvr)
 		delete(d.informers, gvr)
 		delete(d.informerStops, gvr)
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	kubernetesinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	kcpindexers "github.com/kcp-dev/kcp/pkg/indexers"
+	metadataclient "github.com/kcp-dev/kcp/pkg/metadata"
+)
+
+// MetadataSharedInformerFactory hands out informers across all logical clusters that only
+// watch PartialObjectMetadata, like the informers of the DynamicDiscoverySharedInformerFactory.
+// The cached objects are *unstructured.Unstructured holding the apiVersion, kind and metadata
+// of the objects, but neither spec nor status.
+//
+// Use it instead of a typed informer in controllers that only need labels, annotations,
+// finalizers or owner references of the objects. Informers are shared per resource, i.e.
+// all controllers asking for the same resource share one watch.
+type MetadataSharedInformerFactory struct {
+	client       dynamic.ClusterInterface
+	resyncPeriod time.Duration
+
+	lock             sync.Mutex
+	informers        map[schema.GroupVersionResource]kubernetesinformers.GenericInformer
+	startedInformers map[schema.GroupVersionResource]bool
+}
+
+// NewMetadataSharedInformerFactory returns a MetadataSharedInformerFactory for the given config.
+func NewMetadataSharedInformerFactory(cfg *rest.Config, resyncPeriod time.Duration) (*MetadataSharedInformerFactory, error) {
+	client, err := metadataclient.NewDynamicMetadataClusterClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetadataSharedInformerFactory{
+		client:           client,
+		resyncPeriod:     resyncPeriod,
+		informers:        map[schema.GroupVersionResource]kubernetesinformers.GenericInformer{},
+		startedInformers: map[schema.GroupVersionResource]bool{},
+	}, nil
+}
+
+// ForResource returns the shared metadata informer for gvr, creating it if necessary.
+// Informers created after Start are started by the next call to Start.
+func (f *MetadataSharedInformerFactory) ForResource(gvr schema.GroupVersionResource) kubernetesinformers.GenericInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if inf, found := f.informers[gvr]; found {
+		return inf
+	}
+
+	inf := dynamicinformer.NewFilteredDynamicInformer(
+		f.client.Cluster(logicalcluster.Wildcard),
+		gvr,
+		corev1.NamespaceAll,
+		f.resyncPeriod,
+		cache.Indexers{
+			cache.NamespaceIndex:                     cache.MetaNamespaceIndexFunc,
+			kcpindexers.ByLogicalCluster:             kcpindexers.IndexByLogicalCluster,
+			kcpindexers.ByLogicalClusterAndNamespace: kcpindexers.IndexByLogicalClusterAndNamespace,
+		},
+		nil,
+	)
+	f.informers[gvr] = inf
+
+	return inf
+}
+
+// Start starts all informers that have been requested and are not running yet.
+func (f *MetadataSharedInformerFactory) Start(stopCh <-chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for gvr, inf := range f.informers {
+		if !f.startedInformers[gvr] {
+			go inf.Informer().Run(stopCh)
+			f.startedInformers[gvr] = true
+		}
+	}
+}
+
+// WaitForCacheSync waits for the caches of all started informers to be synced.
+func (f *MetadataSharedInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool {
+	informers := func() map[schema.GroupVersionResource]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[schema.GroupVersionResource]cache.SharedIndexInformer{}
+		for gvr, inf := range f.informers {
+			if f.startedInformers[gvr] {
+				informers[gvr] = inf.Informer()
+			}
+		}
+		return informers
+	}()
+
+	res := map[schema.GroupVersionResource]bool{}
+	for gvr, inf := range informers {
+		res[gvr] = cache.WaitForCacheSync(stopCh, inf.HasSynced)
+	}
+	return res
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	kcpindexers "github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+func TestMetadataSharedInformerFactory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/clusters/*/api/v1/namespaces" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			if !strings.Contains(r.Header.Get("Accept"), "as=PartialObjectMetadata;") {
+				t.Errorf("unexpected watch Accept header %q", r.Header.Get("Accept"))
+			}
+			return
+		}
+		if !strings.Contains(r.Header.Get("Accept"), "as=PartialObjectMetadataList;") {
+			t.Errorf("unexpected list Accept header %q", r.Header.Get("Accept"))
+		}
+		_, _ = w.Write([]byte(`{
+  "apiVersion": "meta.k8s.io/v1",
+  "kind": "PartialObjectMetadataList",
+  "metadata": {"resourceVersion": "1"},
+  "items": [{
+    "apiVersion": "meta.k8s.io/v1",
+    "kind": "PartialObjectMetadata",
+    "metadata": {"name": "default", "labels": {"foo": "bar"}, "annotations": {"kcp.dev/cluster": "root:org"}}
+  }]
+}`))
+	}))
+	defer server.Close()
+
+	factory, err := NewMetadataSharedInformerFactory(&rest.Config{Host: server.URL}, 0)
+	require.NoError(t, err)
+
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	inf := factory.ForResource(gvr)
+	require.Same(t, inf, factory.ForResource(gvr), "expected the informer to be shared")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	require.Equal(t, map[schema.GroupVersionResource]bool{gvr: true}, factory.WaitForCacheSync(stopCh))
+
+	objs, err := inf.Informer().GetIndexer().ByIndex(kcpindexers.ByLogicalCluster, "root:org")
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+
+	ns, ok := objs[0].(*unstructured.Unstructured)
+	require.True(t, ok, "expected *unstructured.Unstructured, got %T", objs[0])
+	require.Equal(t, "default", ns.GetName())
+	require.Equal(t, map[string]string{"foo": "bar"}, ns.GetLabels())
+
+	_, err = inf.Lister().Get("root:org|default")
+	require.NoError(t, err)
+}
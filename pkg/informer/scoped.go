@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+// ScopedInformer is a view of a wildcard informer that only sees the objects of a single logical cluster.
+// Event handlers are only called for objects of that logical cluster, and lists are served from the
+// logical cluster indexes of the wildcard informer instead of filtering all objects.
+type ScopedInformer struct {
+	clusterName logicalcluster.Name
+	informer    cache.SharedIndexInformer
+}
+
+// NewScopedInformer returns a view of the given wildcard informer scoped to clusterName. It adds the
+// logical cluster indexes to the informer if they are missing.
+func NewScopedInformer(clusterName logicalcluster.Name, informer cache.SharedIndexInformer) *ScopedInformer {
+	indexers.AddOrDie(informer.GetIndexer(), indexers.ByLogicalCluster, indexers.ByLogicalClusterAndNamespace)
+
+	return &ScopedInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+// ClusterName returns the logical cluster the informer is scoped to.
+func (i *ScopedInformer) ClusterName() logicalcluster.Name {
+	return i.clusterName
+}
+
+// Informer returns the underlying wildcard informer.
+func (i *ScopedInformer) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+// HasSynced returns whether the underlying wildcard informer has synced.
+func (i *ScopedInformer) HasSynced() bool {
+	return i.informer.HasSynced()
+}
+
+// AddEventHandler adds a handler that is only called for objects of the logical cluster.
+func (i *ScopedInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	i.informer.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: i.inScope,
+		Handler:    handler,
+	})
+}
+
+// Lister returns a lister for the objects of the logical cluster. Errors for missing objects are reported
+// for the given resource.
+func (i *ScopedInformer) Lister(resource schema.GroupResource) cache.GenericLister {
+	return &scopedLister{
+		clusterName: i.clusterName,
+		indexer:     i.informer.GetIndexer(),
+		resource:    resource,
+	}
+}
+
+func (i *ScopedInformer) inScope(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	return logicalcluster.From(metaObj) == i.clusterName
+}
+
+var _ cache.GenericLister = &scopedLister{}
+var _ cache.GenericNamespaceLister = &scopedNamespaceLister{}
+
+type scopedLister struct {
+	clusterName logicalcluster.Name
+	indexer     cache.Indexer
+	resource    schema.GroupResource
+}
+
+func (l *scopedLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	return listByIndex(l.indexer, indexers.ByLogicalCluster, l.clusterName.String(), selector)
+}
+
+func (l *scopedLister) Get(name string) (runtime.Object, error) {
+	return getByKey(l.indexer, l.resource, clusters.ToClusterAwareKey(l.clusterName, name), name)
+}
+
+func (l *scopedLister) ByNamespace(namespace string) cache.GenericNamespaceLister {
+	return &scopedNamespaceLister{
+		clusterName: l.clusterName,
+		indexer:     l.indexer,
+		resource:    l.resource,
+		namespace:   namespace,
+	}
+}
+
+type scopedNamespaceLister struct {
+	clusterName logicalcluster.Name
+	indexer     cache.Indexer
+	resource    schema.GroupResource
+	namespace   string
+}
+
+func (l *scopedNamespaceLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	return listByIndex(l.indexer, indexers.ByLogicalClusterAndNamespace, clusters.ToClusterAwareKey(l.clusterName, l.namespace), selector)
+}
+
+func (l *scopedNamespaceLister) Get(name string) (runtime.Object, error) {
+	return getByKey(l.indexer, l.resource, indexers.NamespaceScopedKey(l.clusterName, l.namespace, name), name)
+}
+
+func listByIndex(indexer cache.Indexer, indexName, indexValue string, selector labels.Selector) ([]runtime.Object, error) {
+	objs, err := indexer.ByIndex(indexName, indexValue)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		metaObj, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if selector.Matches(labels.Set(metaObj.GetLabels())) {
+			ret = append(ret, obj.(runtime.Object))
+		}
+	}
+	return ret, nil
+}
+
+func getByKey(indexer cache.Indexer, resource schema.GroupResource, key, name string) (runtime.Object, error) {
+	obj, exists, err := indexer.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, apierrors.NewNotFound(resource, name)
+	}
+	return obj.(runtime.Object), nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func configMap(clusterName, namespace, name string, labels map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     map[string]string{logicalcluster.AnnotationKey: clusterName},
+			Namespace:       namespace,
+			Name:            name,
+			Labels:          labels,
+			ResourceVersion: "1",
+		},
+	}
+}
+
+func TestScopedInformer(t *testing.T) {
+	watcher := watch.NewFake()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.ConfigMapList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: []corev1.ConfigMap{
+				*configMap("root:org", "default", "a", map[string]string{"app": "foo"}),
+				*configMap("root:org", "other", "b", nil),
+				*configMap("root:other", "default", "a", map[string]string{"app": "foo"}),
+			}}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watcher, nil
+		},
+	}
+	wildcard := cache.NewSharedIndexInformer(lw, &corev1.ConfigMap{}, 0, cache.Indexers{})
+
+	scoped := NewScopedInformer(logicalcluster.New("root:org"), wildcard)
+
+	var lock sync.Mutex
+	var seen []string
+	scoped.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			lock.Lock()
+			defer lock.Unlock()
+			cm := obj.(*corev1.ConfigMap)
+			seen = append(seen, logicalcluster.From(cm).String()+"/"+cm.Namespace+"/"+cm.Name)
+		},
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go wildcard.Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, scoped.HasSynced))
+
+	watcher.Add(configMap("root:other", "default", "c", nil))
+	watcher.Add(configMap("root:org", "default", "c", nil))
+	err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(seen) == 3, nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"root:org/default/a", "root:org/other/b", "root:org/default/c"}, seen)
+
+	lister := scoped.Lister(corev1.Resource("configmaps"))
+
+	objs, err := lister.List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objs, 3)
+
+	objs, err = lister.List(labels.SelectorFromSet(labels.Set{"app": "foo"}))
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+	require.Equal(t, "root:org", logicalcluster.From(objs[0].(*corev1.ConfigMap)).String())
+
+	objs, err = lister.ByNamespace("default").List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+
+	obj, err := lister.ByNamespace("other").Get("b")
+	require.NoError(t, err)
+	require.Equal(t, "b", obj.(*corev1.ConfigMap).Name)
+
+	_, err = lister.ByNamespace("other").Get("a")
+	require.True(t, apierrors.IsNotFound(err), "expected NotFound, got %v", err)
+}
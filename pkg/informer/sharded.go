@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ShardedInformer watches a resource across logical clusters on multiple shards. It runs an informer per
+// shard, each with its own reflector, such that resource versions are tracked per shard: when a shard
+// restarts or becomes unreachable, only its watch is re-established and its objects are relisted, while
+// the events and caches of the other shards are unaffected. The objects of an unreachable shard stay in
+// the cache until its watch is back.
+//
+// The same object can be seen on two shards while its logical cluster is migrated between them. Handlers
+// are therefore told the shard of every event, and listers return the objects of all shards.
+type ShardedInformer struct {
+	shardNames []string
+	informers  map[string]cache.SharedIndexInformer
+}
+
+// NewShardedInformer returns an informer for the objects returned by the given list-watchers, keyed by
+// shard name. The list-watchers are expected to list and watch across logical clusters.
+func NewShardedInformer(
+	listWatchers map[string]cache.ListerWatcher,
+	exampleObject runtime.Object,
+	resyncPeriod time.Duration,
+	indexers cache.Indexers,
+) *ShardedInformer {
+	i := &ShardedInformer{
+		informers: make(map[string]cache.SharedIndexInformer, len(listWatchers)),
+	}
+	for shard, lw := range listWatchers {
+		i.shardNames = append(i.shardNames, shard)
+		i.informers[shard] = cache.NewSharedIndexInformer(lw, exampleObject, resyncPeriod, indexers)
+	}
+	sort.Strings(i.shardNames)
+	return i
+}
+
+// NewShardedDynamicInformer returns an informer for the given resource in all logical clusters on the
+// shards of the given dynamic clients, keyed by shard name.
+func NewShardedDynamicInformer(
+	clients map[string]dynamic.ClusterInterface,
+	gvr schema.GroupVersionResource,
+	resyncPeriod time.Duration,
+	indexers cache.Indexers,
+) *ShardedInformer {
+	listWatchers := make(map[string]cache.ListerWatcher, len(clients))
+	for shard, client := range clients {
+		resourceClient := client.Cluster(logicalcluster.Wildcard).Resource(gvr)
+		listWatchers[shard] = &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return resourceClient.List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return resourceClient.Watch(context.TODO(), options)
+			},
+		}
+	}
+	return NewShardedInformer(listWatchers, &unstructured.Unstructured{}, resyncPeriod, indexers)
+}
+
+// ShardEventHandler is an event handler that includes the shard of the object.
+type ShardEventHandler interface {
+	OnAdd(shard string, obj interface{})
+	OnUpdate(shard string, oldObj, newObj interface{})
+	OnDelete(shard string, obj interface{})
+}
+
+// ShardEventHandlerFuncs is a ShardEventHandler whose nil functions are skipped.
+type ShardEventHandlerFuncs struct {
+	AddFunc    func(shard string, obj interface{})
+	UpdateFunc func(shard string, oldObj, newObj interface{})
+	DeleteFunc func(shard string, obj interface{})
+}
+
+func (f ShardEventHandlerFuncs) OnAdd(shard string, obj interface{}) {
+	if f.AddFunc != nil {
+		f.AddFunc(shard, obj)
+	}
+}
+
+func (f ShardEventHandlerFuncs) OnUpdate(shard string, oldObj, newObj interface{}) {
+	if f.UpdateFunc != nil {
+		f.UpdateFunc(shard, oldObj, newObj)
+	}
+}
+
+func (f ShardEventHandlerFuncs) OnDelete(shard string, obj interface{}) {
+	if f.DeleteFunc != nil {
+		f.DeleteFunc(shard, obj)
+	}
+}
+
+// AddEventHandler adds a handler for the events of all shards. Events of one shard are delivered in
+// order, but there is no order between the events of different shards.
+func (i *ShardedInformer) AddEventHandler(handler ShardEventHandler) {
+	for _, shard := range i.shardNames {
+		shard := shard
+		i.informers[shard].AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { handler.OnAdd(shard, obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) { handler.OnUpdate(shard, oldObj, newObj) },
+			DeleteFunc: func(obj interface{}) { handler.OnDelete(shard, obj) },
+		})
+	}
+}
+
+// Run runs the informers of all shards until stopCh is closed. It blocks.
+func (i *ShardedInformer) Run(stopCh <-chan struct{}) {
+	for _, shard := range i.shardNames {
+		go i.informers[shard].Run(stopCh)
+	}
+	<-stopCh
+}
+
+// HasSynced returns whether the informers of all shards have synced once.
+func (i *ShardedInformer) HasSynced() bool {
+	for _, shard := range i.shardNames {
+		if !i.informers[shard].HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Shards returns the sorted names of the shards.
+func (i *ShardedInformer) Shards() []string {
+	return i.shardNames
+}
+
+// ShardInformer returns the informer of the given shard, or nil if the shard is unknown.
+func (i *ShardedInformer) ShardInformer(shard string) cache.SharedIndexInformer {
+	return i.informers[shard]
+}
+
+// List returns the objects of all shards.
+func (i *ShardedInformer) List() []interface{} {
+	var ret []interface{}
+	for _, shard := range i.shardNames {
+		ret = append(ret, i.informers[shard].GetIndexer().List()...)
+	}
+	return ret
+}
+
+// GetByKey returns the objects with the given key, keyed by the shards they are found on.
+func (i *ShardedInformer) GetByKey(key string) (map[string]interface{}, error) {
+	ret := map[string]interface{}{}
+	for _, shard := range i.shardNames {
+		obj, exists, err := i.informers[shard].GetIndexer().GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			ret[shard] = obj
+		}
+	}
+	return ret, nil
+}
+
+// ByIndex returns the objects of all shards whose index values include the given value.
+func (i *ShardedInformer) ByIndex(indexName, indexedValue string) ([]interface{}, error) {
+	var ret []interface{}
+	for _, shard := range i.shardNames {
+		objs, err := i.informers[shard].GetIndexer().ByIndex(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, objs...)
+	}
+	return ret, nil
+}
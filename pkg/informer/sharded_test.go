@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeShard struct {
+	lock    sync.Mutex
+	lists   int
+	watches chan *watch.FakeWatcher
+	objects []corev1.ConfigMap
+}
+
+func newFakeShard(names ...string) *fakeShard {
+	s := &fakeShard{watches: make(chan *watch.FakeWatcher, 10)}
+	for _, name := range names {
+		s.objects = append(s.objects, corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, ResourceVersion: "1"}})
+	}
+	return s
+}
+
+func (s *fakeShard) listWatch() cache.ListerWatcher {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			s.lists++
+			return &corev1.ConfigMapList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: s.objects}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w := watch.NewFake()
+			s.watches <- w
+			return w, nil
+		},
+	}
+}
+
+func (s *fakeShard) listCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lists
+}
+
+func TestShardedInformer(t *testing.T) {
+	amber, sapphire := newFakeShard("a"), newFakeShard("s", "both")
+	amber.objects = append(amber.objects, corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "both", ResourceVersion: "1"}})
+
+	informer := NewShardedInformer(map[string]cache.ListerWatcher{
+		"amber":    amber.listWatch(),
+		"sapphire": sapphire.listWatch(),
+	}, &corev1.ConfigMap{}, 0, cache.Indexers{})
+
+	type event struct {
+		shard, name string
+	}
+	events := make(chan event, 10)
+	informer.AddEventHandler(ShardEventHandlerFuncs{
+		AddFunc: func(shard string, obj interface{}) {
+			events <- event{shard, obj.(*corev1.ConfigMap).Name}
+		},
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	require.Eventually(t, informer.HasSynced, wait.ForeverTestTimeout, 10*time.Millisecond)
+
+	var got []event
+	for i := 0; i < 4; i++ {
+		got = append(got, <-events)
+	}
+	require.ElementsMatch(t, []event{{"amber", "a"}, {"amber", "both"}, {"sapphire", "s"}, {"sapphire", "both"}}, got)
+	require.Len(t, informer.List(), 4)
+
+	objs, err := informer.GetByKey("default/both")
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+
+	amberWatch, sapphireWatch := <-amber.watches, <-sapphire.watches
+
+	t.Log("Ending the watch of one shard only re-establishes the watch of that shard")
+	amberWatch.Stop()
+	amberWatch = <-amber.watches
+	amberWatch.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "new", ResourceVersion: "2"}})
+	require.Equal(t, event{"amber", "new"}, <-events)
+
+	sapphireWatch.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other", ResourceVersion: "2"}})
+	require.Equal(t, event{"sapphire", "other"}, <-events)
+	require.Empty(t, sapphire.watches)
+	require.Equal(t, 1, sapphire.listCount())
+	require.Len(t, informer.List(), 6)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	kcpindexers "github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+// InformOnlyUsedBoundAPIs makes the factory inform on the resources of bound CRDs, i.e. the CRDs in
+// boundCRDsCluster, only while at least one APIBinding binds them. The informer of a resource is stopped, and its
+// cache released, when the last APIBinding binding it goes away. CRDs in other logical clusters are informed on as
+// long as they are established.
+//
+// InformOnlyUsedBoundAPIs must be called before StartWorker and before apiBindingInformer is started.
+func (d *DynamicDiscoverySharedInformerFactory) InformOnlyUsedBoundAPIs(boundCRDsCluster logicalcluster.Name, apiBindingInformer apisinformers.APIBindingInformer) {
+	kcpindexers.AddOrDie(apiBindingInformer.Informer().GetIndexer(), kcpindexers.APIBindingByBoundCRD)
+
+	d.boundCRDsCluster = boundCRDsCluster
+	d.apiBindingIndexer = apiBindingInformer.Informer().GetIndexer()
+	d.apiBindingInformerSynced = apiBindingInformer.Informer().HasSynced
+
+	// When the bound resources of APIBindings change, send a notification that we might need to add/remove informers.
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			d.notifyUpdateNeeded()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldBinding, ok := oldObj.(*apisv1alpha1.APIBinding)
+			if !ok {
+				return
+			}
+			newBinding, ok := newObj.(*apisv1alpha1.APIBinding)
+			if !ok {
+				return
+			}
+			if !equality.Semantic.DeepEqual(oldBinding.Status.BoundResources, newBinding.Status.BoundResources) {
+				d.notifyUpdateNeeded()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			d.notifyUpdateNeeded()
+		},
+	})
+}
+
+// inUse returns whether any CRD serving the byGroupFirstFoundVersionResourceIndex value gvrIndexValue is in use. A
+// CRD outside of the bound CRDs cluster is always in use, a bound CRD only if at least one APIBinding binds it.
+func (d *DynamicDiscoverySharedInformerFactory) inUse(gvrIndexValue string) bool {
+	if d.apiBindingIndexer == nil {
+		return true
+	}
+
+	crds, err := d.crdIndexer.ByIndex(byGroupFirstFoundVersionResourceIndex, gvrIndexValue)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	for _, obj := range crds {
+		crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("%T is not a CustomResourceDefinition", obj))
+			continue
+		}
+
+		if logicalcluster.From(crd) != d.boundCRDsCluster {
+			return true
+		}
+
+		bindings, err := d.apiBindingIndexer.IndexKeys(kcpindexers.APIBindingByBoundCRD, crd.Name)
+		if err != nil {
+			utilruntime.HandleError(err)
+			return true
+		}
+		if len(bindings) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpindexers "github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+func newCRD(clusterName, name, group, resource string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{logicalcluster.AnnotationKey: clusterName},
+			Name:        name,
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: resource},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1", Served: true},
+			},
+		},
+	}
+}
+
+func newAPIBinding(clusterName, name string, boundCRDs ...string) *apisv1alpha1.APIBinding {
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{logicalcluster.AnnotationKey: clusterName},
+			Name:        name,
+		},
+	}
+	for _, crd := range boundCRDs {
+		binding.Status.BoundResources = append(binding.Status.BoundResources, apisv1alpha1.BoundAPIResource{
+			Schema: apisv1alpha1.BoundAPIResourceSchema{UID: crd},
+		})
+	}
+	return binding
+}
+
+func TestInformableTypes(t *testing.T) {
+	boundCRDsCluster := logicalcluster.New("system:bound-crds")
+
+	crdIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		byGroupFirstFoundVersionResourceIndex: indexCRDByGroupFirstFoundVersionResource,
+	})
+	for _, crd := range []*apiextensionsv1.CustomResourceDefinition{
+		newCRD("root:org:ws", "widgets.example.io", "example.io", "widgets"),
+		newCRD(boundCRDsCluster.String(), "uid-gadgets", "example.io", "gadgets"),
+		newCRD(boundCRDsCluster.String(), "uid-gizmos", "example.io", "gizmos"),
+		newCRD(boundCRDsCluster.String(), "uid-sprockets-1", "example.io", "sprockets"),
+		newCRD(boundCRDsCluster.String(), "uid-sprockets-2", "example.io", "sprockets"),
+	} {
+		require.NoError(t, crdIndexer.Add(crd))
+	}
+
+	apiBindingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, kcpindexers.Indexers(kcpindexers.APIBindingByBoundCRD))
+	require.NoError(t, apiBindingIndexer.Add(newAPIBinding("root:org:ws", "gadgets", "uid-gadgets")))
+	require.NoError(t, apiBindingIndexer.Add(newAPIBinding("root:org:other", "sprockets", "uid-sprockets-2")))
+
+	d := &DynamicDiscoverySharedInformerFactory{
+		crdIndexer: crdIndexer,
+	}
+	for _, resource := range []string{"widgets", "gadgets", "gizmos", "sprockets"} {
+		require.Contains(t, d.informableTypes(), gvrFor("example.io", "v1", resource), "expected all CRDs to be informable without APIBinding usage tracking")
+	}
+
+	d.boundCRDsCluster = boundCRDsCluster
+	d.apiBindingIndexer = apiBindingIndexer
+	latest := d.informableTypes()
+	require.Contains(t, latest, gvrFor("example.io", "v1", "widgets"), "expected CRD outside of the bound CRDs cluster to be informable")
+	require.Contains(t, latest, gvrFor("example.io", "v1", "gadgets"), "expected bound CRD with APIBinding to be informable")
+	require.Contains(t, latest, gvrFor("example.io", "v1", "sprockets"), "expected resource with one of two bound CRDs in use to be informable")
+	require.NotContains(t, latest, gvrFor("example.io", "v1", "gizmos"), "expected bound CRD without APIBinding not to be informable")
+	require.Contains(t, latest, gvrFor("", "v1", "configmaps"), "expected built-in types to be informable")
+
+	require.NoError(t, apiBindingIndexer.Delete(newAPIBinding("root:org:ws", "gadgets", "uid-gadgets")))
+	require.NotContains(t, d.informableTypes(), gvrFor("example.io", "v1", "gadgets"), "expected bound CRD not to be informable after its last APIBinding is gone")
+}
@@ -38,6 +38,7 @@ import (
 
 	envoycontrolplane "github.com/kcp-dev/kcp/pkg/localenvoy/controlplane"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/ingresssplitter"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const controllerName = "kcp-envoy-ingress-status-aggregator"
@@ -51,7 +52,7 @@ func NewController(
 	ecp *envoycontrolplane.EnvoyControlPlane, domain string) *Controller {
 
 	c := &Controller{
-		queue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		queue:  kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
 		client: kubeClient,
 		ecp:    ecp,
 		domain: domain,
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package objectreference encodes and validates apisv1alpha1.ObjectReferences,
+// i.e. references to objects in other workspaces, qualified by the identity of the APIExport
+// providing their resource.
+package objectreference
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+var (
+	pathRegExp         = regexp.MustCompile(`^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	identityHashRegExp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+)
+
+// String encodes the reference as
+//
+//	[<path>|]<resource>.<version>[.<group>][:<identity-hash>]/[<namespace>/]<name>
+//
+// e.g. root:org:ws|widgets.v1.example.io:f5e1.../default/foo. Parse is the inverse.
+func String(ref apisv1alpha1.ObjectReference) string {
+	var b strings.Builder
+	if ref.Path != "" {
+		b.WriteString(ref.Path)
+		b.WriteString("|")
+	}
+	b.WriteString(ref.Resource)
+	b.WriteString(".")
+	b.WriteString(ref.Version)
+	if ref.Group != "" {
+		b.WriteString(".")
+		b.WriteString(ref.Group)
+	}
+	if ref.IdentityHash != "" {
+		b.WriteString(":")
+		b.WriteString(ref.IdentityHash)
+	}
+	b.WriteString("/")
+	if ref.Namespace != "" {
+		b.WriteString(ref.Namespace)
+		b.WriteString("/")
+	}
+	b.WriteString(ref.Name)
+	return b.String()
+}
+
+// Parse decodes a reference encoded by String, and validates it.
+func Parse(s string) (apisv1alpha1.ObjectReference, error) {
+	var ref apisv1alpha1.ObjectReference
+
+	rest := s
+	if i := strings.Index(rest, "|"); i >= 0 {
+		ref.Path, rest = rest[:i], rest[i+1:]
+	}
+
+	parts := strings.Split(rest, "/")
+	switch len(parts) {
+	case 2:
+		ref.Name = parts[1]
+	case 3:
+		ref.Namespace, ref.Name = parts[1], parts[2]
+	default:
+		return apisv1alpha1.ObjectReference{}, fmt.Errorf("invalid object reference %q: expected [<path>|]<resource>.<version>[.<group>][:<identity-hash>]/[<namespace>/]<name>", s)
+	}
+
+	resource := parts[0]
+	if i := strings.Index(resource, ":"); i >= 0 {
+		resource, ref.IdentityHash = resource[:i], resource[i+1:]
+	}
+	comps := strings.SplitN(resource, ".", 3)
+	if len(comps) < 2 {
+		return apisv1alpha1.ObjectReference{}, fmt.Errorf("invalid object reference %q: resource %q must be of the form <resource>.<version>[.<group>]", s, resource)
+	}
+	ref.Resource, ref.Version = comps[0], comps[1]
+	if len(comps) == 3 {
+		ref.Group = comps[2]
+	}
+
+	if errs := Validate(ref, nil); len(errs) > 0 {
+		return apisv1alpha1.ObjectReference{}, fmt.Errorf("invalid object reference %q: %w", s, errs.ToAggregate())
+	}
+	return ref, nil
+}
+
+// Validate validates the fields of the reference.
+func Validate(ref apisv1alpha1.ObjectReference, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if ref.Path != "" && !pathRegExp.MatchString(ref.Path) {
+		errs = append(errs, field.Invalid(fldPath.Child("path"), ref.Path, "must be an absolute workspace path"))
+	}
+	if ref.IdentityHash != "" && !identityHashRegExp.MatchString(ref.IdentityHash) {
+		errs = append(errs, field.Invalid(fldPath.Child("identityHash"), ref.IdentityHash, "must be a hex encoded SHA-256 hash"))
+	}
+	if ref.Version == "" {
+		errs = append(errs, field.Required(fldPath.Child("version"), ""))
+	}
+	if ref.Resource == "" {
+		errs = append(errs, field.Required(fldPath.Child("resource"), ""))
+	}
+	if ref.Name == "" {
+		errs = append(errs, field.Required(fldPath.Child("name"), ""))
+	}
+
+	return errs
+}
+
+// ClusterName returns the logical cluster of the referenced object, falling back to the logical
+// cluster of the referencing object if the reference has no path.
+func ClusterName(ref apisv1alpha1.ObjectReference, from logicalcluster.Name) logicalcluster.Name {
+	if ref.Path == "" {
+		return from
+	}
+	return logicalcluster.New(ref.Path)
+}
+
+// GroupVersionResource returns the resource of the referenced object.
+func GroupVersionResource(ref apisv1alpha1.ObjectReference) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectreference
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+var identity = strings.Repeat("ab", 32)
+
+func TestStringAndParse(t *testing.T) {
+	tests := map[string]struct {
+		ref     apisv1alpha1.ObjectReference
+		encoded string
+	}{
+		"core resource in same workspace": {
+			ref:     apisv1alpha1.ObjectReference{Version: "v1", Resource: "configmaps", Namespace: "default", Name: "foo"},
+			encoded: "configmaps.v1/default/foo",
+		},
+		"cluster-scoped resource": {
+			ref:     apisv1alpha1.ObjectReference{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles", Name: "admin"},
+			encoded: "clusterroles.v1.rbac.authorization.k8s.io/admin",
+		},
+		"bound resource in another workspace": {
+			ref: apisv1alpha1.ObjectReference{
+				Path:         "root:org:ws",
+				IdentityHash: identity,
+				Group:        "example.io",
+				Version:      "v1alpha1",
+				Resource:     "widgets",
+				Namespace:    "default",
+				Name:         "foo",
+			},
+			encoded: "root:org:ws|widgets.v1alpha1.example.io:" + identity + "/default/foo",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.encoded, String(tc.ref))
+
+			parsed, err := Parse(tc.encoded)
+			require.NoError(t, err)
+			require.Equal(t, tc.ref, parsed)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"configmaps/default/foo",
+		"configmaps.v1",
+		"configmaps.v1/a/b/c",
+		"configmaps.v1/",
+		"org:ws|configmaps.v1/foo",
+		"widgets.v1.example.io:not-a-hash/foo",
+	} {
+		t.Run(s, func(t *testing.T) {
+			_, err := Parse(s)
+			require.Error(t, err)
+		})
+	}
+}
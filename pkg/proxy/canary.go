@@ -0,0 +1,290 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// CanaryResponseHeader is set to "true" on responses served by the canary backend of a shard.
+const CanaryResponseHeader = "X-Kcp-Canary"
+
+const (
+	// maxInflightMirrors bounds the number of mirrored requests in flight. Requests beyond
+	// are not mirrored.
+	maxInflightMirrors = 64
+	// mirrorTimeout bounds the duration of a mirrored request.
+	mirrorTimeout = 30 * time.Second
+)
+
+// CanaryMode is the way read traffic is sent to the canary backend of a shard.
+type CanaryMode string
+
+const (
+	// CanaryModeMirror sends a copy of the request to the canary backend after the shard served
+	// it. The response of the canary is discarded and compared to the one of the shard.
+	CanaryModeMirror CanaryMode = "Mirror"
+	// CanaryModeRoute sends the request to the canary backend instead of the shard.
+	CanaryModeRoute CanaryMode = "Route"
+)
+
+// ShardCanary describes a canary backend of a shard, e.g. a new build of the shard serving the
+// same data, and the share of read traffic of the shard's workspaces it receives.
+type ShardCanary struct {
+	// Shard is the name of the ClusterWorkspaceShard.
+	Shard string `json:"shard"`
+	// Workspaces selects the workspaces, including their descendants, whose traffic is sent to the
+	// canary. If empty, all workspaces of the shard are selected.
+	Workspaces []string `json:"workspaces,omitempty"`
+	// Backend is the URL of the canary. It is accessed with the transport of the /clusters/ mapping.
+	Backend string `json:"backend"`
+	// Mode is either Mirror or Route.
+	Mode CanaryMode `json:"mode"`
+	// Percentage is the share of get and list requests of the selected workspaces sent to the canary.
+	Percentage int `json:"percentage"`
+}
+
+// LoadShardCanaries reads and validates the canary configuration file.
+func LoadShardCanaries(path string) ([]ShardCanary, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shard canary file %q: %w", path, err)
+	}
+	var canaries []ShardCanary
+	if err := yaml.UnmarshalStrict(data, &canaries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shard canary file %q: %w", path, err)
+	}
+	for i, c := range canaries {
+		if c.Shard == "" {
+			return nil, fmt.Errorf("shard canary %d: shard is required", i)
+		}
+		if c.Mode != CanaryModeMirror && c.Mode != CanaryModeRoute {
+			return nil, fmt.Errorf("shard canary %d: mode must be %s or %s, got %q", i, CanaryModeMirror, CanaryModeRoute, c.Mode)
+		}
+		if c.Percentage < 0 || c.Percentage > 100 {
+			return nil, fmt.Errorf("shard canary %d: percentage must be between 0 and 100, got %d", i, c.Percentage)
+		}
+		if u, err := url.Parse(c.Backend); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("shard canary %d: invalid backend URL %q", i, c.Backend)
+		}
+		for _, ws := range c.Workspaces {
+			if !logicalcluster.New(ws).IsValid() || ws == logicalcluster.Wildcard.String() {
+				return nil, fmt.Errorf("shard canary %d: invalid workspace %q", i, ws)
+			}
+		}
+	}
+	return canaries, nil
+}
+
+type shardCanary struct {
+	ShardCanary
+	backend    *url.URL
+	workspaces []logicalcluster.Name
+}
+
+func (c *shardCanary) selects(clusterName logicalcluster.Name) bool {
+	if len(c.workspaces) == 0 {
+		return true
+	}
+	for _, ws := range c.workspaces {
+		if clusterName == ws || strings.HasPrefix(clusterName.String(), ws.String()+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// canaryProxy mirrors or routes read requests of selected workspaces to canary backends of their shards.
+type canaryProxy struct {
+	canaries map[string][]*shardCanary
+	mirror   *httputil.ReverseProxy
+	inflight chan struct{}
+	// random returns a number in [0,100). Replaced in tests.
+	random func() int
+}
+
+func newCanaryProxy(canaries []ShardCanary, transport http.RoundTripper) *canaryProxy {
+	p := &canaryProxy{
+		canaries: map[string][]*shardCanary{},
+		inflight: make(chan struct{}, maxInflightMirrors),
+		random:   func() int { return rand.Intn(100) },
+	}
+	for _, c := range canaries {
+		backend, _ := url.Parse(c.Backend) // validated when loading
+		sc := &shardCanary{ShardCanary: c, backend: backend}
+		for _, ws := range c.Workspaces {
+			sc.workspaces = append(sc.workspaces, logicalcluster.New(ws))
+		}
+		p.canaries[c.Shard] = append(p.canaries[c.Shard], sc)
+	}
+
+	p.mirror = newShardReverseProxy()
+	p.mirror.Transport = transport
+	p.mirror.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		klog.V(4).Infof("Mirrored request %q failed: %v", req.URL.Path, err)
+		w.(*mirrorResponseWriter).err = err
+	}
+	return p
+}
+
+// pick returns the canary the request is sent to, or nil. Only get and list requests are considered,
+// and the first canary of the shard selecting the workspace decides.
+func (p *canaryProxy) pick(req *http.Request, attributes authorizer.Attributes, shard string, clusterName logicalcluster.Name) *shardCanary {
+	if p == nil || !attributes.IsReadOnly() || httpstream.IsUpgradeRequest(req) {
+		return nil
+	}
+	if verb := attributes.GetVerb(); verb != "get" && verb != "list" {
+		return nil
+	}
+	for _, c := range p.canaries[shard] {
+		if !c.selects(clusterName) {
+			continue
+		}
+		if p.random() >= c.Percentage {
+			return nil
+		}
+		return c
+	}
+	return nil
+}
+
+// ServeHTTP serves the request with the shard proxy, according to the mode of the canary.
+func (p *canaryProxy) ServeHTTP(w http.ResponseWriter, req *http.Request, shardProxy http.Handler, shardURL *url.URL, canary *shardCanary) {
+	canaryRequests.WithLabelValues(canary.Shard, string(canary.Mode)).Inc()
+
+	if canary.Mode == CanaryModeRoute {
+		klog.V(4).Infof("Routing %q to canary %s of shard %q", req.URL.Path, canary.backend, canary.Shard)
+		w.Header().Set(CanaryResponseHeader, "true")
+		shardProxy.ServeHTTP(w, req.WithContext(WithShardURL(req.Context(), canary.backend)))
+		return
+	}
+
+	select {
+	case p.inflight <- struct{}{}:
+	default:
+		canaryMirrorResults.WithLabelValues(canary.Shard, "Skipped").Inc()
+		shardProxy.ServeHTTP(w, req.WithContext(WithShardURL(req.Context(), shardURL)))
+		return
+	}
+
+	primary := newHashingResponseWriter(w)
+	mirrorReq := req.Clone(context.Background())
+	mirrorReq.Body = http.NoBody
+	shardProxy.ServeHTTP(primary, req.WithContext(WithShardURL(req.Context(), shardURL)))
+
+	go func() {
+		defer func() { <-p.inflight }()
+
+		ctx, cancel := context.WithTimeout(WithShardURL(context.Background(), canary.backend), mirrorTimeout)
+		defer cancel()
+
+		mirror := &mirrorResponseWriter{header: http.Header{}, hashingResponseWriter: hashingResponseWriter{hash: sha256.New()}}
+		p.mirror.ServeHTTP(mirror, mirrorReq.WithContext(ctx))
+
+		result := compareMirrorResponse(primary, mirror)
+		klog.V(5).Infof("Mirrored %q to canary %s of shard %q: %s", req.URL.Path, canary.backend, canary.Shard, result)
+		canaryMirrorResults.WithLabelValues(canary.Shard, result).Inc()
+	}()
+}
+
+// compareMirrorResponse returns Match, StatusMismatch, BodyMismatch or Error.
+func compareMirrorResponse(primary *hashingResponseWriter, mirror *mirrorResponseWriter) string {
+	switch {
+	case mirror.err != nil:
+		return "Error"
+	case primary.status() != mirror.status():
+		return "StatusMismatch"
+	case !bytes.Equal(primary.hash.Sum(nil), mirror.hash.Sum(nil)):
+		return "BodyMismatch"
+	default:
+		return "Match"
+	}
+}
+
+// hashingResponseWriter passes the response on, recording the status code and a hash of the body.
+type hashingResponseWriter struct {
+	http.ResponseWriter
+	code int
+	hash hash.Hash
+}
+
+func newHashingResponseWriter(w http.ResponseWriter) *hashingResponseWriter {
+	return &hashingResponseWriter{ResponseWriter: w, hash: sha256.New()}
+}
+
+func (w *hashingResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	if w.ResponseWriter != nil {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *hashingResponseWriter) Write(data []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.hash.Write(data) // nolint: errcheck
+	if w.ResponseWriter != nil {
+		return w.ResponseWriter.Write(data)
+	}
+	return len(data), nil
+}
+
+func (w *hashingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *hashingResponseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// mirrorResponseWriter discards the response of a mirrored request, recording the status code, a
+// hash of the body and a transport error.
+type mirrorResponseWriter struct {
+	hashingResponseWriter
+	header http.Header
+	err    error
+}
+
+func (w *mirrorResponseWriter) Header() http.Header {
+	return w.header
+}
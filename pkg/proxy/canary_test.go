@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestLoadShardCanaries(t *testing.T) {
+	tests := map[string]struct {
+		config  string
+		wantErr bool
+	}{
+		"valid": {
+			config: `
+- shard: alpha
+  workspaces: ["root:org"]
+  backend: https://alpha-canary:6443
+  mode: Mirror
+  percentage: 10
+- shard: beta
+  backend: https://beta-canary:6443
+  mode: Route
+  percentage: 100
+`,
+		},
+		"unknown mode": {
+			config:  `[{shard: alpha, backend: "https://canary", mode: Shadow, percentage: 10}]`,
+			wantErr: true,
+		},
+		"percentage out of range": {
+			config:  `[{shard: alpha, backend: "https://canary", mode: Route, percentage: 101}]`,
+			wantErr: true,
+		},
+		"invalid backend": {
+			config:  `[{shard: alpha, backend: "canary", mode: Route, percentage: 10}]`,
+			wantErr: true,
+		},
+		"wildcard workspace": {
+			config:  `[{shard: alpha, workspaces: ["*"], backend: "https://canary", mode: Route, percentage: 10}]`,
+			wantErr: true,
+		},
+		"unknown field": {
+			config:  `[{shard: alpha, backend: "https://canary", mode: Route, percentage: 10, weight: 3}]`,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "canaries.yaml")
+			require.NoError(t, ioutil.WriteFile(path, []byte(tt.config), 0600))
+			_, err := LoadShardCanaries(path)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCanaryPick(t *testing.T) {
+	p := newCanaryProxy([]ShardCanary{
+		{Shard: "alpha", Workspaces: []string{"root:org"}, Backend: "https://canary", Mode: CanaryModeRoute, Percentage: 50},
+	}, http.DefaultTransport)
+
+	get := authorizer.AttributesRecord{Verb: "get", ResourceRequest: true}
+	watch := authorizer.AttributesRecord{Verb: "watch", ResourceRequest: true}
+	create := authorizer.AttributesRecord{Verb: "create", ResourceRequest: true}
+	req := httptest.NewRequest("GET", "/clusters/root:org/api/v1/namespaces", nil)
+
+	p.random = func() int { return 49 }
+	require.NotNil(t, p.pick(req, get, "alpha", logicalcluster.New("root:org")))
+	require.NotNil(t, p.pick(req, get, "alpha", logicalcluster.New("root:org:team")))
+	require.Nil(t, p.pick(req, get, "alpha", logicalcluster.New("root:organization")), "not a descendant")
+	require.Nil(t, p.pick(req, get, "beta", logicalcluster.New("root:org")), "other shard")
+	require.Nil(t, p.pick(req, watch, "alpha", logicalcluster.New("root:org")), "watches are not sent to canaries")
+	require.Nil(t, p.pick(req, create, "alpha", logicalcluster.New("root:org")), "writes are not sent to canaries")
+
+	p.random = func() int { return 50 }
+	require.Nil(t, p.pick(req, get, "alpha", logicalcluster.New("root:org")), "outside of the percentage")
+
+	var nilProxy *canaryProxy
+	require.Nil(t, nilProxy.pick(req, get, "alpha", logicalcluster.New("root:org")))
+}
+
+func TestCanaryServeHTTP(t *testing.T) {
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("shard")) // nolint: errcheck
+	}))
+	defer shard.Close()
+	canaryBody := "shard"
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(canaryBody)) // nolint: errcheck
+	}))
+	defer canary.Close()
+	shardURL, err := url.Parse(shard.URL)
+	require.NoError(t, err)
+
+	registerCanaryMetrics()
+	shardProxy := newShardReverseProxy()
+
+	serve := func(mode CanaryMode) *httptest.ResponseRecorder {
+		p := newCanaryProxy([]ShardCanary{{Shard: "alpha", Backend: canary.URL, Mode: mode, Percentage: 100}}, http.DefaultTransport)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest("GET", "/clusters/root:org/api", nil), shardProxy, shardURL, p.canaries["alpha"][0])
+		return w
+	}
+	waitForResult := func(result string, want float64) {
+		require.Eventually(t, func() bool {
+			got, err := testutil.GetCounterMetricValue(canaryMirrorResults.WithLabelValues("alpha", result))
+			return err == nil && got == want
+		}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	}
+
+	w := serve(CanaryModeMirror)
+	require.Equal(t, "shard", w.Body.String())
+	require.Empty(t, w.Header().Get(CanaryResponseHeader))
+	waitForResult("Match", 1)
+
+	canaryBody = "canary"
+	w = serve(CanaryModeMirror)
+	require.Equal(t, "shard", w.Body.String(), "the canary response is discarded")
+	waitForResult("BodyMismatch", 1)
+
+	w = serve(CanaryModeRoute)
+	require.Equal(t, "canary", w.Body.String())
+	require.Equal(t, "true", w.Header().Get(CanaryResponseHeader))
+}
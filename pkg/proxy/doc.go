@@ -19,6 +19,16 @@ limitations under the License.
 // headers. The proxy terminates client TLS and communicates with API servers
 // via mTLS. Traffic is routed based on paths.
 //
+// With --oidc-* flags or a TokenReview webhook configured with
+// --authentication-token-webhook-config-file, the proxy also authenticates
+// bearer tokens, and forwards the user, groups and extra of the token in the
+// same headers instead of the token. Tokens not recognized by the proxy, e.g.
+// service account tokens, are passed on to the backend as is. Paths given by
+// --authentication-excluded-paths are never authenticated by the proxy. The
+// headers can be changed per mapping with user_header, group_header and
+// extra_header_prefix, defaulting to X-Remote-User, X-Remote-Group and
+// X-Remote-Extra-. These headers are always dropped from client requests.
+//
 // An example configuration:
 //
 //  - path: /services/
@@ -31,5 +41,27 @@ limitations under the License.
 //    backend_server_ca: certs/kcp-ca-cert.pem
 //    proxy_client_cert: certs/proxy-client-cert.pem
 //    proxy_client_key: certs/proxy-client-key.pem
+//
+// Requests to the /clusters/ path are not routed to the backend of the mapping,
+// but to the shard of the workspace, as found in the workspace index. The index
+// follows workspaces when they move between shards. It is fed by watching the
+// ClusterWorkspaces on every shard, or, with --cache-kubeconfig, in the cache
+// server.
+//
+// With --shard-canary-file, a percentage of the get and list requests of
+// workspaces on a shard can be sent to a canary backend of that shard, e.g. a
+// new build serving the same data, to de-risk shard upgrades:
+//
+//  - shard: alpha
+//    workspaces: ["root:org"]
+//    backend: https://alpha-canary:6443
+//    mode: Mirror
+//    percentage: 10
+//
+// In Mirror mode, the request is repeated against the canary after the shard
+// served it. The canary response is discarded, and the comparison of status
+// codes and bodies is counted in kcp_front_proxy_canary_mirror_results_total.
+// In Route mode, the canary serves the request instead of the shard, marked
+// with the X-Kcp-Canary header.
 
 package proxy
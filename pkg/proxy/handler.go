@@ -17,6 +17,7 @@ limitations under the License.
 package proxy
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -28,12 +29,13 @@ import (
 	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	kcpauthorization "github.com/kcp-dev/kcp/pkg/authorization"
 	"github.com/kcp-dev/kcp/pkg/proxy/index"
 )
 
-func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
+func shardHandler(index index.Index, proxy http.Handler, staleReads *staleReadProxy, canaries *canaryProxy) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		var cs = strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 3)
 		if len(cs) != 3 || cs[0] != "clusters" {
@@ -56,17 +58,32 @@ func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
 			return
 		}
 
-		shardURLString, found := index.Lookup(clusterName)
+		result, found := index.Lookup(clusterName)
 		if !found {
 			klog.V(4).Infof("Unknown cluster %q", clusterName)
 			responsewriters.Forbidden(req.Context(), attributes, w, req, kcpauthorization.WorkspaceAcccessNotPermittedReason, kubernetesscheme.Codecs)
 			return
 		}
-		shardURL, err := url.Parse(shardURLString)
+		shardURL, err := url.Parse(result.URL)
 		if err != nil {
 			responsewriters.InternalError(w, req, err)
 			return
 		}
+		if result.State == tenancyv1alpha1.ClusterWorkspaceShardStateDraining {
+			// the workspace stays available, but clients are told that it is about to move
+			w.Header().Add("Warning", fmt.Sprintf("299 - %q", fmt.Sprintf("workspace %s is on draining shard %s and will be migrated to another shard", clusterName, result.Shard)))
+		}
+
+		if result.Unavailable && staleReads.serves(attributes) {
+			klog.V(4).Infof("Serving %q of unavailable shard %q from the cache server", req.URL.Path, result.Shard)
+			staleReads.ServeHTTP(w, req, result.Shard, clusterName)
+			return
+		}
+
+		if canary := canaries.pick(req, attributes, result.Shard, clusterName); canary != nil {
+			canaries.ServeHTTP(w, req, proxy, shardURL, canary)
+			return
+		}
 
 		klog.V(4).Infof("Redirecting %q to %s", req.URL.Path, shardURL)
 
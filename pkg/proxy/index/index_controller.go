@@ -26,15 +26,19 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
@@ -45,7 +49,19 @@ const (
 
 // Index implements a mapping from logical cluster to (shard) URL.
 type Index interface {
-	Lookup(logicalCluster logicalcluster.Name) (string, bool)
+	Lookup(logicalCluster logicalcluster.Name) (Result, bool)
+}
+
+// Result is the shard a logical cluster is served by.
+type Result struct {
+	// URL is the base URL of the shard.
+	URL string
+	// Shard is the name of the shard. It is empty for the root logical cluster.
+	Shard string
+	// State is the lifecycle state of the shard.
+	State tenancyv1alpha1.ClusterWorkspaceShardState
+	// Unavailable is true if the shard has stopped sending heartbeats.
+	Unavailable bool
 }
 
 type ClusterWorkspaceClientGetter func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (kcpclient.Interface, error)
@@ -55,7 +71,7 @@ func NewController(
 	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
 	clientGetter ClusterWorkspaceClientGetter,
 ) *Controller {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &Controller{
 		queue: queue,
@@ -71,85 +87,41 @@ func NewController(
 
 		workspaceShardNames: map[logicalcluster.Name]string{},
 		shardBaseURLs:       map[string]string{},
+		shardStates:         map[string]tenancyv1alpha1.ClusterWorkspaceShardState{},
+		unavailableShards:   sets.NewString(),
 	}
 
 	c.clusterWorkspaceHandler = cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			ws := obj.(*tenancyv1alpha1.ClusterWorkspace)
-			c.lock.RLock()
-			got := c.workspaceShardNames[logicalcluster.From(ws).Join(ws.Name)]
-			c.lock.RUnlock()
-
-			if expected := ws.Status.Location.Current; got != expected {
-				c.lock.Lock()
-				defer c.lock.Unlock()
-				c.workspaceShardNames[logicalcluster.From(ws).Join(ws.Name)] = expected
-			}
+			c.upsertClusterWorkspace(obj.(*tenancyv1alpha1.ClusterWorkspace))
 		},
 		UpdateFunc: func(old, obj interface{}) {
-			ws := obj.(*tenancyv1alpha1.ClusterWorkspace)
-
-			c.lock.RLock()
-			got := c.workspaceShardNames[logicalcluster.From(ws).Join(ws.Name)]
-			c.lock.RUnlock()
-
-			if expected := ws.Status.Location.Current; got != expected {
-				c.lock.Lock()
-				defer c.lock.Unlock()
-				c.workspaceShardNames[logicalcluster.From(ws).Join(ws.Name)] = expected
-			}
+			c.upsertClusterWorkspace(obj.(*tenancyv1alpha1.ClusterWorkspace))
 		},
 		DeleteFunc: func(obj interface{}) {
 			if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
 				obj = final.Obj
 			}
-			ws := obj.(*tenancyv1alpha1.ClusterWorkspace)
-
-			c.lock.Lock()
-			defer c.lock.Unlock()
-			delete(c.workspaceShardNames, logicalcluster.From(ws).Join(ws.Name))
+			c.deleteClusterWorkspace(obj.(*tenancyv1alpha1.ClusterWorkspace))
 		},
 	}
 
 	clusterWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
-			c.lock.RLock()
-			got := c.shardBaseURLs[shard.Name]
-			c.lock.RUnlock()
-
-			if expected := shard.Spec.BaseURL; got != expected {
-				c.lock.Lock()
-				defer c.lock.Unlock()
-				c.shardBaseURLs[shard.Name] = expected
-			}
-
+			c.upsertShard(shard)
 			c.enqueueShard(shard)
 		},
 		UpdateFunc: func(old, obj interface{}) {
-			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
-			c.lock.RLock()
-			got := c.shardBaseURLs[shard.Name]
-			c.lock.RUnlock()
-
-			if expected := shard.Spec.BaseURL; got != expected {
-				c.lock.Lock()
-				defer c.lock.Unlock()
-				c.shardBaseURLs[shard.Name] = expected
-			}
-
-			// don't updates. Not of interest.
+			c.upsertShard(obj.(*tenancyv1alpha1.ClusterWorkspaceShard))
+			// don't enqueue updates. Not of interest.
 		},
 		DeleteFunc: func(obj interface{}) {
 			if final, ok := obj.(cache.DeletedFinalStateUnknown); ok {
 				obj = final.Obj
 			}
 			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
-
-			c.lock.Lock()
-			defer c.lock.Unlock()
-			delete(c.shardBaseURLs, shard.Name)
-
+			c.deleteShard(shard)
 			c.enqueueShard(shard)
 		},
 	})
@@ -157,9 +129,24 @@ func NewController(
 	return c
 }
 
+// NewCacheController returns an index fed by the ClusterWorkspaces replicated into the cache server
+// from all shards. Instead of starting informers for every ClusterWorkspaceShard, a single informer
+// across shards is used. The cache server must replicate ClusterWorkspaces, as its default replication
+// policy does. ClusterWorkspaceShards are still watched on the root shard.
+func NewCacheController(
+	rootHost string,
+	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
+	clusterWorkspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+) *Controller {
+	c := NewController(rootHost, clusterWorkspaceShardInformer, nil)
+	clusterWorkspaceInformer.Informer().AddEventHandler(c.clusterWorkspaceHandler)
+	return c
+}
+
 // Controller watches ClusterWorkspaceShards on the root shard, and then starts informers
 // for every ClusterWorkspaceShard, watching the ClusterWorkspaces on them. It then
-// updates the workspace index, which maps logical clusters to shard URLs.
+// updates the workspace index, which maps logical clusters to shard URLs. If created
+// with NewCacheController, the ClusterWorkspaces are watched in the cache server instead.
 type Controller struct {
 	queue workqueue.RateLimitingInterface
 
@@ -178,6 +165,8 @@ type Controller struct {
 	lock                sync.RWMutex
 	workspaceShardNames map[logicalcluster.Name]string
 	shardBaseURLs       map[string]string
+	shardStates         map[string]tenancyv1alpha1.ClusterWorkspaceShardState
+	unavailableShards   sets.String
 }
 
 // Start the controller. It does not really do anything, but to keep the shape of a normal
@@ -203,6 +192,11 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 }
 
 func (c *Controller) enqueueShard(obj interface{}) {
+	if c.clientGetter == nil {
+		// ClusterWorkspaces are not watched per shard.
+		return
+	}
+
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
@@ -244,11 +238,17 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	shard, err := c.clusterWorkspaceShardLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
 	if err != nil {
 		if errors.IsNotFound(err) {
+			_, name := clusters.SplitClusterAwareKey(key)
+
 			c.shardInformersLock.Lock()
 			defer c.shardInformersLock.Unlock()
 
-			delete(c.shardClusterWorkspaceInformers, shard.Name)
-			delete(c.shardClusterWorkspaceStopCh, shard.Name)
+			// stop watching the ClusterWorkspaces of the removed shard
+			if stopCh, found := c.shardClusterWorkspaceStopCh[name]; found {
+				close(stopCh)
+			}
+			delete(c.shardClusterWorkspaceInformers, name)
+			delete(c.shardClusterWorkspaceStopCh, name)
 
 			return nil
 		}
@@ -278,9 +278,76 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	return nil
 }
 
-func (c *Controller) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
+// upsertClusterWorkspace points the logical cluster of the workspace to its current shard. When a workspace
+// is moved to another shard, requests are routed to the new shard as soon as the move is observed.
+func (c *Controller) upsertClusterWorkspace(ws *tenancyv1alpha1.ClusterWorkspace) {
+	clusterName := logicalcluster.From(ws).Join(ws.Name)
+
+	c.lock.RLock()
+	got, found := c.workspaceShardNames[clusterName]
+	c.lock.RUnlock()
+
+	expected := ws.Status.Location.Current
+	if expected == "" {
+		// not scheduled yet, or unscheduled
+		if found {
+			c.deleteClusterWorkspace(ws)
+		}
+		return
+	}
+	if got != expected {
+		if found {
+			klog.V(2).Infof("ClusterWorkspace %s moved from shard %q to %q", clusterName, got, expected)
+		}
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.workspaceShardNames[clusterName] = expected
+	}
+}
+
+func (c *Controller) deleteClusterWorkspace(ws *tenancyv1alpha1.ClusterWorkspace) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.workspaceShardNames, logicalcluster.From(ws).Join(ws.Name))
+}
+
+func (c *Controller) upsertShard(shard *tenancyv1alpha1.ClusterWorkspaceShard) {
+	c.lock.RLock()
+	gotURL, gotState, gotUnavailable := c.shardBaseURLs[shard.Name], c.shardStates[shard.Name], c.unavailableShards.Has(shard.Name)
+	c.lock.RUnlock()
+
+	expectedURL, expectedState := shard.Spec.BaseURL, shard.Spec.State
+	expectedUnavailable := conditions.IsFalse(shard, tenancyv1alpha1.ClusterWorkspaceShardAvailable)
+	if gotURL != expectedURL || gotState != expectedState || gotUnavailable != expectedUnavailable {
+		if gotUnavailable != expectedUnavailable {
+			klog.V(2).Infof("ClusterWorkspaceShard %q changed availability, unavailable: %v", shard.Name, expectedUnavailable)
+		}
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.shardBaseURLs[shard.Name] = expectedURL
+		c.shardStates[shard.Name] = expectedState
+		if expectedUnavailable {
+			c.unavailableShards.Insert(shard.Name)
+		} else {
+			c.unavailableShards.Delete(shard.Name)
+		}
+	}
+}
+
+func (c *Controller) deleteShard(shard *tenancyv1alpha1.ClusterWorkspaceShard) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.shardBaseURLs, shard.Name)
+	delete(c.shardStates, shard.Name)
+	c.unavailableShards.Delete(shard.Name)
+}
+
+// Lookup returns the shard serving the logical cluster. Workspaces are routed to their current shard
+// independently of its state, such that workspaces on cordoned and draining shards stay available
+// until they are migrated.
+func (c *Controller) Lookup(logicalCluster logicalcluster.Name) (Result, bool) {
 	if logicalCluster == tenancyv1alpha1.RootCluster {
-		return c.rootHost, true
+		return Result{URL: c.rootHost}, true
 	}
 
 	c.lock.RLock()
@@ -288,8 +355,11 @@ func (c *Controller) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
 
 	shardName, found := c.workspaceShardNames[logicalCluster]
 	if !found {
-		return "", false
+		return Result{}, false
 	}
 	url, found := c.shardBaseURLs[shardName]
-	return url, found
+	if !found {
+		return Result{}, false
+	}
+	return Result{URL: url, Shard: shardName, State: c.shardStates[shardName], Unavailable: c.unavailableShards.Has(shardName)}, true
 }
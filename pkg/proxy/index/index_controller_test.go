@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestLookup(t *testing.T) {
+	newShard := func(name, baseURL string) *tenancyv1alpha1.ClusterWorkspaceShard {
+		return &tenancyv1alpha1.ClusterWorkspaceShard{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{logicalcluster.AnnotationKey: "root"}},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: baseURL},
+		}
+	}
+	withState := func(state tenancyv1alpha1.ClusterWorkspaceShardState, shard *tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+		shard.Spec.State = state
+		return shard
+	}
+	unavailable := func(shard *tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+		conditions.MarkFalse(shard, tenancyv1alpha1.ClusterWorkspaceShardAvailable, tenancyv1alpha1.ClusterWorkspaceShardHeartbeatMissingReason, conditionsv1alpha1.ConditionSeverityError, "")
+		return shard
+	}
+	newWorkspace := func(shard string) *tenancyv1alpha1.ClusterWorkspace {
+		return &tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: "team", Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"}},
+			Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: shard}},
+		}
+	}
+	team := logicalcluster.New("root:org:team")
+
+	c := &Controller{
+		rootHost:            "https://root",
+		workspaceShardNames: map[logicalcluster.Name]string{},
+		shardBaseURLs:       map[string]string{},
+		shardStates:         map[string]tenancyv1alpha1.ClusterWorkspaceShardState{},
+		unavailableShards:   sets.NewString(),
+	}
+	c.upsertShard(newShard("amber", "https://amber"))
+	c.upsertShard(newShard("sapphire", "https://sapphire"))
+
+	result, found := c.Lookup(tenancyv1alpha1.RootCluster)
+	require.True(t, found)
+	require.Equal(t, "https://root", result.URL)
+
+	_, found = c.Lookup(team)
+	require.False(t, found, "unknown workspace")
+
+	c.upsertClusterWorkspace(newWorkspace(""))
+	_, found = c.Lookup(team)
+	require.False(t, found, "unscheduled workspace")
+
+	c.upsertClusterWorkspace(newWorkspace("amber"))
+	result, found = c.Lookup(team)
+	require.True(t, found)
+	require.Equal(t, Result{URL: "https://amber", Shard: "amber"}, result)
+
+	c.upsertClusterWorkspace(newWorkspace("sapphire"))
+	result, found = c.Lookup(team)
+	require.True(t, found, "moved workspace")
+	require.Equal(t, "https://sapphire", result.URL)
+
+	c.upsertShard(newShard("sapphire", "https://sapphire-new"))
+	result, found = c.Lookup(team)
+	require.True(t, found, "shard with changed base URL")
+	require.Equal(t, "https://sapphire-new", result.URL)
+
+	c.upsertShard(withState(tenancyv1alpha1.ClusterWorkspaceShardStateDraining, newShard("sapphire", "https://sapphire-new")))
+	result, found = c.Lookup(team)
+	require.True(t, found, "draining shard")
+	require.Equal(t, Result{URL: "https://sapphire-new", Shard: "sapphire", State: tenancyv1alpha1.ClusterWorkspaceShardStateDraining}, result)
+
+	c.upsertShard(unavailable(newShard("sapphire", "https://sapphire-new")))
+	result, found = c.Lookup(team)
+	require.True(t, found, "unavailable shard")
+	require.Equal(t, Result{URL: "https://sapphire-new", Shard: "sapphire", Unavailable: true}, result)
+
+	c.upsertShard(newShard("sapphire", "https://sapphire-new"))
+	result, found = c.Lookup(team)
+	require.True(t, found, "available shard")
+	require.False(t, result.Unavailable)
+
+	c.deleteShard(newShard("sapphire", "https://sapphire-new"))
+	_, found = c.Lookup(team)
+	require.False(t, found, "removed shard")
+
+	c.upsertShard(newShard("sapphire", "https://sapphire"))
+	c.deleteClusterWorkspace(newWorkspace("sapphire"))
+	_, found = c.Lookup(team)
+	require.False(t, found, "deleted workspace")
+}
@@ -23,6 +23,8 @@ import (
 	"net/http/httputil"
 	"net/url"
 
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 
@@ -34,16 +36,21 @@ import (
 // Each Path is registered with the DefaultServeMux with a handler that
 // delegates to the specified backend.
 type PathMapping struct {
-	Path            string `json:"path"`
-	Backend         string `json:"backend"`
-	BackendServerCA string `json:"backend_server_ca"`
-	ProxyClientCert string `json:"proxy_client_cert"`
-	ProxyClientKey  string `json:"proxy_client_key"`
-	UserHeader      string `json:"user_header,omitempty"`
-	GroupHeader     string `json:"group_header,omitempty"`
+	Path              string `json:"path"`
+	Backend           string `json:"backend"`
+	BackendServerCA   string `json:"backend_server_ca"`
+	ProxyClientCert   string `json:"proxy_client_cert"`
+	ProxyClientKey    string `json:"proxy_client_key"`
+	UserHeader        string `json:"user_header,omitempty"`
+	GroupHeader       string `json:"group_header,omitempty"`
+	ExtraHeaderPrefix string `json:"extra_header_prefix,omitempty"`
 }
 
-func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error) {
+// NewHandler returns the handler of the front-proxy. If a cache server config is given, read requests of
+// workspaces on unavailable shards are served from the cache server to the members of the stale read groups.
+// If a shard canary file is given, read requests of the selected workspaces are mirrored or routed to the
+// canary backends of their shards.
+func NewHandler(o *proxyoptions.Options, index index.Index, cacheConfig *rest.Config) (http.Handler, error) {
 	mappingData, err := ioutil.ReadFile(o.MappingFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read mapping file %q: %w", o.MappingFile, err)
@@ -54,6 +61,21 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 		return nil, fmt.Errorf("failed to unmarshal mapping file %q: %w", o.MappingFile, err)
 	}
 
+	var staleReads *staleReadProxy
+	if cacheConfig != nil && len(o.StaleReadGroups) > 0 {
+		if staleReads, err = newStaleReadProxy(cacheConfig, o.StaleReadGroups); err != nil {
+			return nil, err
+		}
+	}
+
+	var canaries []ShardCanary
+	if o.ShardCanaryFile != "" {
+		if canaries, err = LoadShardCanaries(o.ShardCanaryFile); err != nil {
+			return nil, err
+		}
+		registerCanaryMetrics()
+	}
+
 	mux := http.NewServeMux()
 
 	// TODO: implement proper readyz handler
@@ -68,6 +90,8 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 		w.WriteHeader(http.StatusOK)
 	}))
 
+	mux.Handle("/metrics", legacyregistry.Handler())
+
 	for _, m := range mapping {
 		klog.V(2).Infof("Adding mapping %v", m)
 
@@ -85,7 +109,11 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 		if m.Path == "/clusters/" {
 			clusterProxy := newShardReverseProxy()
 			clusterProxy.Transport = transport
-			handler = shardHandler(index, clusterProxy)
+			var canaryProxy *canaryProxy
+			if len(canaries) > 0 {
+				canaryProxy = newCanaryProxy(canaries, transport)
+			}
+			handler = shardHandler(index, clusterProxy, staleReads, canaryProxy)
 		} else {
 			// TODO: handle virtual workspace apiservers per shard
 			proxy := httputil.NewSingleHostReverseProxy(u)
@@ -95,14 +123,18 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 
 		userHeader := "X-Remote-User"
 		groupHeader := "X-Remote-Group"
+		extraHeaderPrefix := "X-Remote-Extra-"
 		if m.UserHeader != "" {
 			userHeader = m.UserHeader
 		}
 		if m.GroupHeader != "" {
 			groupHeader = m.GroupHeader
 		}
+		if m.ExtraHeaderPrefix != "" {
+			extraHeaderPrefix = m.ExtraHeaderPrefix
+		}
 
-		handler = WithProxyAuthHeaders(handler, userHeader, groupHeader)
+		handler = WithProxyAuthHeaders(handler, userHeader, groupHeader, extraHeaderPrefix)
 
 		mux.Handle(m.Path, handler)
 	}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const subsystem = "front_proxy"
+
+var (
+	canaryRequests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "canary_requests_total",
+			Help:           "Number of requests mirrored or routed to the canary backend of a shard, partitioned by shard and mode.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "mode"},
+	)
+
+	canaryMirrorResults = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "canary_mirror_results_total",
+			Help:           "Number of mirrored requests by the result of comparing the canary response to the shard response, partitioned by shard and result (Match, StatusMismatch, BodyMismatch, Error, Skipped).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"shard", "result"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// registerCanaryMetrics registers the canary metrics in the legacy registry.
+func registerCanaryMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(canaryRequests)
+		legacyregistry.MustRegister(canaryMirrorResults)
+	})
+}
@@ -24,6 +24,14 @@ import (
 
 type Options struct {
 	MappingFile string
+
+	// StaleReadGroups are the groups whose members are served read requests of workspaces on unavailable
+	// shards from the cache server.
+	StaleReadGroups []string
+
+	// ShardCanaryFile is the file with the canary backends of shards that read requests are mirrored
+	// or routed to.
+	ShardCanaryFile string
 }
 
 func NewOptions() *Options {
@@ -33,6 +41,10 @@ func NewOptions() *Options {
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.MappingFile, "mapping-file", o.MappingFile, "Config file mapping paths to backends")
+	fs.StringSliceVar(&o.StaleReadGroups, "stale-read-groups", o.StaleReadGroups, "Groups whose members are served read requests of workspaces on unavailable shards from the cache server, "+
+		"marked with the X-Kcp-Stale header. The cache server does not evaluate workspace permissions. Requires --cache-kubeconfig.")
+	fs.StringVar(&o.ShardCanaryFile, "shard-canary-file", o.ShardCanaryFile, "Config file listing canary backends of shards. A percentage of the get and list requests of "+
+		"the selected workspaces is mirrored to the canary, discarding its response and comparing it to the shard response, or routed to the canary instead of the shard.")
 }
 
 func (o *Options) Complete() error {
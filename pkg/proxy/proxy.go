@@ -25,6 +25,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/util/runtime"
 	userinfo "k8s.io/apiserver/pkg/authentication/user"
@@ -54,24 +55,43 @@ func newTransport(clientCert, clientKeyFile, caFile string) (*http.Transport, er
 	return transport, nil
 }
 
-// WithProxyAuthHeaders does client cert termination by extracting the user and groups and
-// passing them through access headers to the shard.
-func WithProxyAuthHeaders(delegate http.HandlerFunc, UserHeader, GroupHeader string) http.HandlerFunc {
+// WithProxyAuthHeaders does client cert and token termination by extracting the user, groups and
+// extra of the authenticated user and passing them through access headers to the shard. Access
+// headers sent by the client are dropped, such that they cannot be spoofed.
+func WithProxyAuthHeaders(delegate http.HandlerFunc, UserHeader, GroupHeader, ExtraHeaderPrefix string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		removeAuthHeaders(r.Header, UserHeader, GroupHeader, ExtraHeaderPrefix)
 		if u, ok := request.UserFrom(r.Context()); ok {
-			appendClientCertAuthHeaders(r.Header, u, UserHeader, GroupHeader)
+			appendClientCertAuthHeaders(r.Header, u, UserHeader, GroupHeader, ExtraHeaderPrefix)
 		}
 
 		delegate.ServeHTTP(w, r)
 	}
 }
 
-func appendClientCertAuthHeaders(header http.Header, user userinfo.Info, UserHeader, GroupHeader string) {
+func removeAuthHeaders(header http.Header, UserHeader, GroupHeader, ExtraHeaderPrefix string) {
+	header.Del(UserHeader)
+	header.Del(GroupHeader)
+	for k := range header {
+		if strings.HasPrefix(strings.ToLower(k), strings.ToLower(ExtraHeaderPrefix)) {
+			header.Del(k)
+		}
+	}
+}
+
+func appendClientCertAuthHeaders(header http.Header, user userinfo.Info, UserHeader, GroupHeader, ExtraHeaderPrefix string) {
 	header.Set(UserHeader, user.GetName())
 
 	for _, group := range user.GetGroups() {
 		header.Add(GroupHeader, group)
 	}
+
+	for k, vs := range user.GetExtra() {
+		// keys are escaped as expected by the request header authenticator of the shard
+		for _, v := range vs {
+			header.Add(ExtraHeaderPrefix+url.PathEscape(k), v)
+		}
+	}
 }
 
 func newShardReverseProxy() *httputil.ReverseProxy {
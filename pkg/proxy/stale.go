@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/rest"
+
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+)
+
+// StaleResponseHeader is set to "true" on responses served from the cache server instead of the shard
+// of the workspace. They can be outdated.
+const StaleResponseHeader = "X-Kcp-Stale"
+
+// staleReadProxy serves read requests for workspaces of unavailable shards from the objects replicated
+// into the cache server. The cache server does not evaluate the authorization of workspaces, hence only
+// members of the configured groups are served.
+type staleReadProxy struct {
+	groups sets.String
+	proxy  *httputil.ReverseProxy
+}
+
+func newStaleReadProxy(cacheConfig *rest.Config, groups []string) (*staleReadProxy, error) {
+	cacheURL, err := url.Parse(cacheConfig.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cache server URL %q: %w", cacheConfig.Host, err)
+	}
+	transport, err := rest.TransportFor(cacheConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache server transport: %w", err)
+	}
+
+	director := func(req *http.Request) {
+		req.URL.Scheme = cacheURL.Scheme
+		req.URL.Host = cacheURL.Host
+		req.URL.Path = strings.TrimSuffix(cacheURL.Path, "/") + req.URL.Path
+		req.URL.RawPath = ""
+		req.Host = ""
+
+		// the cache server is accessed with the credentials of the front-proxy
+		req.Header.Del("Authorization")
+		for name := range req.Header {
+			if strings.HasPrefix(name, "Impersonate-") || strings.HasPrefix(name, "X-Remote-") {
+				req.Header.Del(name)
+			}
+		}
+	}
+	return &staleReadProxy{
+		groups: sets.NewString(groups...),
+		proxy:  &httputil.ReverseProxy{Director: director, Transport: transport},
+	}, nil
+}
+
+// serves returns whether the request can be served from the cache server.
+func (p *staleReadProxy) serves(attributes authorizer.Attributes) bool {
+	if p == nil || !attributes.IsReadOnly() || attributes.GetUser() == nil {
+		return false
+	}
+	return p.groups.HasAny(attributes.GetUser().GetGroups()...)
+}
+
+// ServeHTTP serves a request of the given logical cluster on the given shard from the cache server. The
+// response is marked as stale.
+func (p *staleReadProxy) ServeHTTP(w http.ResponseWriter, req *http.Request, shard string, clusterName logicalcluster.Name) {
+	w.Header().Set(StaleResponseHeader, "true")
+	w.Header().Add("Warning", fmt.Sprintf("299 - %q", fmt.Sprintf("shard %s of workspace %s is unavailable, the response is served from the cache server and might be stale", shard, clusterName)))
+
+	req = req.Clone(req.Context())
+	req.URL.Path = clientshard.New(shard).Path() + req.URL.Path
+	req.URL.RawPath = ""
+	p.proxy.ServeHTTP(w, req)
+}
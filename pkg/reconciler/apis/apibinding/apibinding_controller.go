@@ -24,10 +24,12 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apihelpers"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apiextensionsinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,18 +37,22 @@ import (
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/permissionclaims"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
@@ -69,8 +75,10 @@ func NewController(
 	temporaryRemoteShardApiExportInformer apisinformers.APIExportInformer, /*TODO(p0lyn0mial): replace with multi-shard informers*/
 	temporaryRemoteShardApiResourceSchemaInformer apisinformers.APIResourceSchemaInformer, /*TODO(p0lyn0mial): replace with multi-shard informers*/
 	crdInformer apiextensionsinformers.CustomResourceDefinitionInformer,
+	clusterRoleBindingInformer rbacinformers.ClusterRoleBindingInformer,
+	roleBindingInformer rbacinformers.RoleBindingInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue:                queue,
@@ -107,6 +115,17 @@ func NewController(
 			}
 			return apiExport, err
 		},
+		getAPIExportsByIdentity: func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+			local, err := indexers.ByIndex[*apisv1alpha1.APIExport](apiExportInformer.Informer().GetIndexer(), indexers.APIExportByIdentity, identityHash)
+			if err != nil {
+				return nil, err
+			}
+			remote, err := indexers.ByIndex[*apisv1alpha1.APIExport](temporaryRemoteShardApiExportInformer.Informer().GetIndexer(), indexers.APIExportByIdentity, identityHash)
+			if err != nil {
+				return nil, err
+			}
+			return uniqueAPIExports(append(local, remote...)), nil
+		},
 		apiExportsIndexer:                     apiExportInformer.Informer().GetIndexer(),
 		temporaryRemoteShardApiExportsIndexer: temporaryRemoteShardApiExportInformer.Informer().GetIndexer(),
 
@@ -124,10 +143,42 @@ func NewController(
 		getCRD: func(clusterName logicalcluster.Name, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
 			return crdInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
 		},
-		crdIndexer:        crdInformer.Informer().GetIndexer(),
+		crdIndexer: crdInformer.Informer().GetIndexer(),
+
+		hasMaximalPermissionPolicyBindings: func(clusterName logicalcluster.Name) (bool, error) {
+			clusterRoleBindings, err := indexers.ByIndex[*rbacv1.ClusterRoleBinding](clusterRoleBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+			if err != nil {
+				return false, err
+			}
+			for _, binding := range clusterRoleBindings {
+				if hasMaximalPermissionPolicySubject(binding.Subjects) {
+					return true, nil
+				}
+			}
+			roleBindings, err := indexers.ByIndex[*rbacv1.RoleBinding](roleBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+			if err != nil {
+				return false, err
+			}
+			for _, binding := range roleBindings {
+				if hasMaximalPermissionPolicySubject(binding.Subjects) {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+
 		deletedCRDTracker: newLockedStringSet(),
+		now:               time.Now,
 		commit:            committer.NewCommitter[*APIBinding, *APIBindingSpec, *APIBindingStatus](kcpClusterClient.ApisV1alpha1().APIBindings()),
 	}
+	c.enqueueAfter = func(apiBinding *apisv1alpha1.APIBinding, duration time.Duration) {
+		key, err := cache.MetaNamespaceKeyFunc(apiBinding)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		queue.AddAfter(key, duration)
+	}
 
 	logger := logging.WithReconciler(klog.Background(), controllerName)
 	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -136,46 +187,31 @@ func NewController(
 		DeleteFunc: func(obj interface{}) { c.enqueueAPIBinding(obj, logger, "") },
 	})
 
-	if err := apiBindingInformer.Informer().AddIndexers(cache.Indexers{
-		indexAPIBindingsByWorkspaceExport: indexAPIBindingsByWorkspaceExportFunc,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingByAPIExport, indexers.APIBindingByAPIExportIdentity, indexers.APIBindingByAPIExportWorkspace)
 
-	crdInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: func(obj interface{}) bool {
-			crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
-			if !ok {
-				return false
+	informer.NewScopedInformer(ShadowWorkspaceName, crdInformer.Informer()).AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueCRD(obj, logger) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueCRD(obj, logger) },
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			meta, err := meta.Accessor(obj)
+			if err != nil {
+				runtime.HandleError(err)
+				return
 			}
 
-			return logicalcluster.From(crd) == ShadowWorkspaceName
-		},
-		Handler: cache.ResourceEventHandlerFuncs{
-			AddFunc:    func(obj interface{}) { c.enqueueCRD(obj, logger) },
-			UpdateFunc: func(_, obj interface{}) { c.enqueueCRD(obj, logger) },
-			DeleteFunc: func(obj interface{}) {
-				meta, err := meta.Accessor(obj)
-				if err != nil {
-					runtime.HandleError(err)
-					return
-				}
-
-				// If something deletes one of our bound CRDs, we need to keep track of it so when we're reconciling,
-				// we know we need to recreate it. This set is there to fight against stale informers still seeing
-				// the deleted CRD.
-				c.deletedCRDTracker.Add(meta.GetName())
+			// If something deletes one of our bound CRDs, we need to keep track of it so when we're reconciling,
+			// we know we need to recreate it. This set is there to fight against stale informers still seeing
+			// the deleted CRD.
+			c.deletedCRDTracker.Add(meta.GetName())
 
-				c.enqueueCRD(obj, logger)
-			},
+			c.enqueueCRD(obj, logger)
 		},
 	})
 
-	if err := crdInformer.Informer().AddIndexers(cache.Indexers{
-		indexByWorkspace: indexByWorkspaceFunc,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(crdInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	apiResourceSchemaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
@@ -199,16 +235,19 @@ func NewController(
 		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj, logger, "") },
 	})
 
-	if err := c.apiExportsIndexer.AddIndexers(cache.Indexers{
-		indexAPIExportsByAPIResourceSchema: indexAPIExportsByAPIResourceSchemasFunc,
-	}); err != nil {
-		return nil, fmt.Errorf("error add CRD indexes: %w", err)
-	}
-	if err := c.temporaryRemoteShardApiExportsIndexer.AddIndexers(cache.Indexers{
-		indexAPIExportsByAPIResourceSchema: indexAPIExportsByAPIResourceSchemasFunc,
-	}); err != nil {
-		return nil, fmt.Errorf("error adding ApiExport indexes for the root shard: %w", err)
-	}
+	clusterRoleBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueRBACBinding(obj, logger) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueRBACBinding(obj, logger) },
+		DeleteFunc: func(obj interface{}) { c.enqueueRBACBinding(obj, logger) },
+	})
+	roleBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueRBACBinding(obj, logger) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueRBACBinding(obj, logger) },
+		DeleteFunc: func(obj interface{}) { c.enqueueRBACBinding(obj, logger) },
+	})
+
+	indexers.AddOrDie(c.apiExportsIndexer, indexers.APIExportByIdentity, indexers.APIExportByAPIResourceSchema)
+	indexers.AddOrDie(c.temporaryRemoteShardApiExportsIndexer, indexers.APIExportByIdentity, indexers.APIExportByAPIResourceSchema)
 
 	return c, nil
 }
@@ -235,6 +274,7 @@ type controller struct {
 	apiBindingsIndexer cache.Indexer
 
 	getAPIExport                          func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
+	getAPIExportsByIdentity               func(identityHash string) ([]*apisv1alpha1.APIExport, error)
 	apiExportsIndexer                     cache.Indexer
 	temporaryRemoteShardApiExportsIndexer cache.Indexer
 
@@ -244,7 +284,11 @@ type controller struct {
 	getCRD     func(clusterName logicalcluster.Name, name string) (*apiextensionsv1.CustomResourceDefinition, error)
 	crdIndexer cache.Indexer
 
+	hasMaximalPermissionPolicyBindings func(clusterName logicalcluster.Name) (bool, error)
+
 	deletedCRDTracker *lockedStringSet
+	now               func() time.Time
+	enqueueAfter      func(*apisv1alpha1.APIBinding, time.Duration)
 	commit            CommitFunc
 }
 
@@ -260,22 +304,60 @@ func (c *controller) enqueueAPIBinding(obj interface{}, logger logr.Logger, logS
 	c.queue.Add(key)
 }
 
-// enqueueAPIExport enqueues maps an APIExport to APIBindings for enqueuing.
+// enqueueAPIExport maps an APIExport to APIBindings for enqueuing.
 func (c *controller) enqueueAPIExport(obj interface{}, logger logr.Logger, logSuffix string) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj))
+		return
+	}
+
+	bindings, err := indexers.Referencing(apiExport,
+		indexers.Reference{Indexer: c.apiBindingsIndexer, IndexName: indexers.APIBindingByAPIExport, ReferencedValues: indexers.IndexByKey},
+		indexers.Reference{Indexer: c.apiBindingsIndexer, IndexName: indexers.APIBindingByAPIExportIdentity, ReferencedValues: indexers.IndexAPIExportByIdentity},
+	)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
 
-	bindingsForExport, err := c.apiBindingsIndexer.ByIndex(indexAPIBindingsByWorkspaceExport, key)
+	for _, binding := range bindings {
+		c.enqueueAPIBinding(binding, logging.WithObject(logger, apiExport), fmt.Sprintf(" because of APIExport%s", logSuffix))
+	}
+}
+
+// enqueueRBACBinding maps a (Cluster)RoleBinding with maximal permission policy subjects to the APIBindings
+// of the APIExports of its workspace for enqueuing.
+func (c *controller) enqueueRBACBinding(obj interface{}, logger logr.Logger) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	var subjects []rbacv1.Subject
+	switch binding := obj.(type) {
+	case *rbacv1.ClusterRoleBinding:
+		subjects = binding.Subjects
+	case *rbacv1.RoleBinding:
+		subjects = binding.Subjects
+	default:
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a ClusterRoleBinding or RoleBinding, but is %T", obj))
+		return
+	}
+	if !hasMaximalPermissionPolicySubject(subjects) {
+		return
+	}
+
+	clusterName := logicalcluster.From(obj.(metav1.Object))
+	bindings, err := c.apiBindingsIndexer.ByIndex(indexers.APIBindingByAPIExportWorkspace, clusterName.String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
-
-	for _, binding := range bindingsForExport {
-		c.enqueueAPIBinding(binding, logging.WithObject(logger, obj.(*apisv1alpha1.APIExport)), fmt.Sprintf(" because of APIExport%s", logSuffix))
+	for _, binding := range bindings {
+		c.enqueueAPIBinding(binding, logger, " because of maximal permission policy RBAC")
 	}
 }
 
@@ -312,19 +394,26 @@ func (c *controller) enqueueCRD(obj interface{}, logger logr.Logger) {
 
 // enqueueAPIResourceSchema maps an APIResourceSchema to APIExports for enqueuing.
 func (c *controller) enqueueAPIResourceSchema(obj interface{}, logger logr.Logger, logSuffix string) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
-	if err != nil {
-		runtime.HandleError(err)
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	schema, ok := obj.(*apisv1alpha1.APIResourceSchema)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIResourceSchema, but is %T", obj))
 		return
 	}
 
-	apiExports, err := c.apiExportsIndexer.ByIndex(indexAPIExportsByAPIResourceSchema, key)
+	apiExports, err := indexers.Referencing(schema,
+		indexers.Reference{Indexer: c.apiExportsIndexer, IndexName: indexers.APIExportByAPIResourceSchema, ReferencedValues: indexers.IndexByKey},
+	)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
 	if len(apiExports) == 0 {
-		apiExports, err = c.temporaryRemoteShardApiExportsIndexer.ByIndex(indexAPIExportsByAPIResourceSchema, key)
+		apiExports, err = indexers.Referencing(schema,
+			indexers.Reference{Indexer: c.temporaryRemoteShardApiExportsIndexer, IndexName: indexers.APIExportByAPIResourceSchema, ReferencedValues: indexers.IndexByKey},
+		)
 		if err != nil {
 			runtime.HandleError(err)
 			return
@@ -332,7 +421,7 @@ func (c *controller) enqueueAPIResourceSchema(obj interface{}, logger logr.Logge
 	}
 
 	for _, export := range apiExports {
-		c.enqueueAPIExport(export, logging.WithObject(logger, obj.(*apisv1alpha1.APIResourceSchema)), fmt.Sprintf(" because of APIResourceSchema%s", logSuffix))
+		c.enqueueAPIExport(export, logging.WithObject(logger, schema), fmt.Sprintf(" because of APIResourceSchema%s", logSuffix))
 	}
 }
 
@@ -401,6 +490,12 @@ func (c *controller) process(ctx context.Context, key string) error {
 
 	reconcileErr := c.reconcile(ctx, obj)
 
+	// Metadata and status cannot be committed together. The export label of references by identity follows
+	// status.boundExport, hence it is updated once the status has settled.
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		updateIdentityExportLabel(obj)
+	}
+
 	// Regardless of whether reconcile returned an error or not, always try to patch status if needed. Return the
 	// reconciliation error at the end.
 
@@ -413,3 +508,21 @@ func (c *controller) process(ctx context.Context, key string) error {
 
 	return reconcileErr
 }
+
+// updateIdentityExportLabel sets the export label of an APIBinding referencing an APIExport by identity to the
+// APIExport the identity was resolved to, such that the APIExport virtual workspace finds the binding.
+func updateIdentityExportLabel(apiBinding *apisv1alpha1.APIBinding) {
+	workspaceRef := apiBinding.ExportWorkspaceReference()
+	if apiBinding.Spec.Reference.Identity == nil || workspaceRef == nil {
+		return
+	}
+
+	value := permissionclaims.ToAPIBindingExportLabelValue(logicalcluster.New(workspaceRef.Path), workspaceRef.ExportName)
+	if apiBinding.Labels[apisv1alpha1.InternalAPIBindingExportLabelKey] == value {
+		return
+	}
+	if apiBinding.Labels == nil {
+		apiBinding.Labels = make(map[string]string)
+	}
+	apiBinding.Labels[apisv1alpha1.InternalAPIBindingExportLabelKey] = value
+}
@@ -23,15 +23,19 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apihelpers"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
@@ -55,9 +59,13 @@ func (c *controller) reconcile(ctx context.Context, apiBinding *apisv1alpha1.API
 	switch apiBinding.Status.Phase {
 	case "":
 		return c.reconcileNew(ctx, apiBinding)
-	case apisv1alpha1.APIBindingPhaseBinding:
+	case apisv1alpha1.APIBindingPhasePending,
+		apisv1alpha1.APIBindingPhaseIdentityVerification,
+		apisv1alpha1.APIBindingPhaseSchemasBinding,
+		apisv1alpha1.APIBindingPhaseFailed,
+		apisv1alpha1.APIBindingPhaseBinding:
 		return kerrors.NewAggregate([]error{c.reconcileBinding(ctx, apiBinding)})
-	case apisv1alpha1.APIBindingPhaseBound:
+	case apisv1alpha1.APIBindingPhaseBound, apisv1alpha1.APIBindingPhasePermissionClaimsPending:
 		needsRebind, err := c.reconcileBound(ctx, apiBinding)
 		if err != nil {
 			return err
@@ -73,7 +81,7 @@ func (c *controller) reconcile(ctx context.Context, apiBinding *apisv1alpha1.API
 }
 
 func (c *controller) reconcileNew(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) error {
-	apiBinding.Status.Phase = apisv1alpha1.APIBindingPhaseBinding
+	apiBinding.Status.Phase = apisv1alpha1.APIBindingPhasePending
 
 	conditions.MarkFalse(
 		apiBinding,
@@ -88,9 +96,9 @@ func (c *controller) reconcileNew(ctx context.Context, apiBinding *apisv1alpha1.
 
 func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) error {
 	logger := klog.FromContext(ctx)
-	workspaceRef := apiBinding.Spec.Reference.Workspace
-	if workspaceRef == nil {
+	if apiBinding.Spec.Reference.Workspace == nil && apiBinding.Spec.Reference.Identity == nil {
 		// this should not happen because of OpenAPI
+		setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseFailed)
 		conditions.MarkFalse(
 			apiBinding,
 			apisv1alpha1.APIExportValid,
@@ -101,9 +109,10 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 		return nil
 	}
 
-	apiExportClusterName, err := getAPIExportClusterName(apiBinding)
-	if err != nil {
-		// this should not happen because of OpenAPI
+	apiExportClusterName, apiExport, err := c.resolveAPIExport(apiBinding)
+	var ambiguousErr *ambiguousIdentityError
+	if errors.As(err, &ambiguousErr) {
+		setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseFailed)
 		conditions.MarkFalse(
 			apiBinding,
 			apisv1alpha1.APIExportValid,
@@ -113,17 +122,15 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 		)
 		return nil
 	}
-
-	apiExport, err := c.getAPIExport(apiExportClusterName, workspaceRef.ExportName)
 	if apierrors.IsNotFound(err) {
+		setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhasePending)
 		conditions.MarkFalse(
 			apiBinding,
 			apisv1alpha1.APIExportValid,
 			apisv1alpha1.APIExportNotFoundReason,
 			conditionsv1alpha1.ConditionSeverityError,
-			"APIExport %s|%s not found",
-			apiExportClusterName,
-			workspaceRef.ExportName,
+			"APIExport %s not found",
+			exportReferenceString(apiBinding),
 		)
 		return nil
 	}
@@ -133,9 +140,8 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 			apisv1alpha1.APIExportValid,
 			apisv1alpha1.InternalErrorReason,
 			conditionsv1alpha1.ConditionSeverityError,
-			"Error getting APIExport %s|%s: %v",
-			apiExportClusterName,
-			workspaceRef.ExportName,
+			"Error getting APIExport %s: %v",
+			exportReferenceString(apiBinding),
 			err,
 		)
 		return err
@@ -143,6 +149,7 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 	logger = logging.WithObject(logger, apiExport)
 
 	if apiExport.Status.IdentityHash == "" {
+		setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseIdentityVerification)
 		conditions.MarkFalse(
 			apiBinding,
 			apisv1alpha1.APIExportValid,
@@ -150,12 +157,13 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 			conditionsv1alpha1.ConditionSeverityWarning,
 			"APIExport %s|%s is missing status.identityHash",
 			apiExportClusterName,
-			workspaceRef.ExportName,
+			apiExport.Name,
 		)
 		return nil
 	}
 
 	var needToWaitForRequeueWhenEstablished []string
+	var exportedSchemas, boundSchemas []*apisv1alpha1.APIResourceSchema
 
 	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
 		schema, err := c.getAPIResourceSchema(apiExportClusterName, schemaName)
@@ -172,17 +180,33 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 			)
 
 			if apierrors.IsNotFound(err) {
+				setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseFailed)
+				setSchemaStatus(apiBinding, apisv1alpha1.APIResourceSchemaStatus{
+					Name:    schemaName,
+					Phase:   apisv1alpha1.APIResourceSchemaPhaseFailed,
+					Reason:  apisv1alpha1.APIResourceSchemaNotFoundReason,
+					Message: fmt.Sprintf("APIResourceSchema %s|%s not found", apiExportClusterName, schemaName),
+				})
 				return nil
 			}
 
 			return err
 		}
 		logger = logging.WithObject(logger, schema)
+		exportedSchemas = append(exportedSchemas, schema)
+		if !apiBinding.BindsResource(schema.Spec.Group, schema.Spec.Names.Plural) {
+			logger.V(4).Info("skipping resource not selected by APIBinding")
+			continue
+		}
+		boundSchemas = append(boundSchemas, schema)
 
 		crd, err := generateCRD(schema)
 		if err != nil {
 			logger.Error(err, "error generating CRD")
 
+			setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseFailed)
+			setSchemaStatus(apiBinding, schemaStatus(schema, apisv1alpha1.APIResourceSchemaPhaseFailed, apisv1alpha1.APIResourceSchemaInvalidReason, err.Error()))
+
 			conditions.MarkFalse(
 				apiBinding,
 				apisv1alpha1.APIExportValid,
@@ -207,6 +231,8 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 		}
 
 		if err := checker.checkForConflicts(crd, apiBinding); err != nil {
+			setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseFailed)
+			setSchemaStatus(apiBinding, schemaStatus(schema, apisv1alpha1.APIResourceSchemaPhaseFailed, apisv1alpha1.NamingConflictsReason, err.Error()))
 			conditions.MarkFalse(
 				apiBinding,
 				apisv1alpha1.BindingUpToDate,
@@ -267,6 +293,8 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 					status := apierrors.APIStatus(nil)
 					// The error is guaranteed to implement APIStatus here
 					errors.As(err, &status)
+					setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseFailed)
+					setSchemaStatus(apiBinding, schemaStatus(schema, apisv1alpha1.APIResourceSchemaPhaseFailed, apisv1alpha1.APIResourceSchemaInvalidReason, fmt.Sprintf("%v", status.Status().Details.Causes)))
 					conditions.MarkFalse(
 						apiBinding,
 						apisv1alpha1.BindingUpToDate,
@@ -313,6 +341,7 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 
 			c.deletedCRDTracker.Remove(crd.Name)
 
+			setSchemaStatus(apiBinding, schemaStatus(schema, apisv1alpha1.APIResourceSchemaPhaseBinding, "", ""))
 			needToWaitForRequeueWhenEstablished = append(needToWaitForRequeueWhenEstablished, schemaName)
 			continue
 		} else {
@@ -323,27 +352,40 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 					return err
 				}
 				logger.V(4).Info("CRD is not established", "why", string(bs))
+				setSchemaStatus(apiBinding, schemaStatus(schema, apisv1alpha1.APIResourceSchemaPhaseBinding, "", ""))
 				needToWaitForRequeueWhenEstablished = append(needToWaitForRequeueWhenEstablished, schemaName)
 				continue
 			} else if apihelpers.IsCRDConditionTrue(existingCRD, apiextensionsv1.Terminating) {
 				logger.V(4).Info("CRD is terminating")
+				setSchemaStatus(apiBinding, schemaStatus(schema, apisv1alpha1.APIResourceSchemaPhaseBinding, "", ""))
 				needToWaitForRequeueWhenEstablished = append(needToWaitForRequeueWhenEstablished, schemaName)
 				continue
 			}
 		}
 
-		// Merge any current storage versions with new ones
-		storageVersions := sets.NewString()
-		if existingCRD != nil {
-			storageVersions.Insert(existingCRD.Status.StoredVersions...)
-		}
+		setSchemaStatus(apiBinding, schemaStatus(schema, apisv1alpha1.APIResourceSchemaPhaseBound, "", ""))
 
+		// Merge the versions objects of the resource might be stored in with the current storage version. A newly
+		// bound resource starts with the stored versions of the CRD. Afterwards, the versions are only dropped by
+		// the storage version migration controller once all objects are migrated, hence the stored versions of
+		// the CRD, which is shared with other bindings, must not be merged in again.
+		storageVersions := sets.NewString()
+		boundBefore := false
 		for _, b := range apiBinding.Status.BoundResources {
 			if b.Group == schema.Spec.Group && b.Resource == schema.Spec.Names.Plural {
 				storageVersions.Insert(b.StorageVersions...)
+				boundBefore = true
 				break
 			}
 		}
+		if !boundBefore && existingCRD != nil {
+			storageVersions.Insert(existingCRD.Status.StoredVersions...)
+		}
+		for _, version := range schema.Spec.Versions {
+			if version.Storage {
+				storageVersions.Insert(version.Name)
+			}
+		}
 
 		sortedStorageVersions := storageVersions.List()
 		sort.Strings(sortedStorageVersions)
@@ -356,7 +398,8 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 				UID:          string(schema.UID),
 				IdentityHash: apiExport.Status.IdentityHash,
 			},
-			StorageVersions: sortedStorageVersions,
+			StorageVersions:    sortedStorageVersions,
+			CustomSubresources: boundCustomSubresources(schema),
 		}
 		found := false
 		for i, r := range apiBinding.Status.BoundResources {
@@ -372,14 +415,40 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 	}
 
 	conditions.MarkTrue(apiBinding, apisv1alpha1.APIExportValid)
+	setAPIExportDeprecatedCondition(apiBinding, apiExportClusterName, apiExport, boundSchemas)
+	if err := c.setMaximalPermissionPolicyCondition(apiBinding, apiExportClusterName, apiExport); err != nil {
+		return err
+	}
 
-	apiBinding.Status.BoundAPIExport = &apiBinding.Spec.Reference
+	// Drop resources that are no longer selected by the APIBinding.
+	boundResources := apiBinding.Status.BoundResources[:0]
+	for _, r := range apiBinding.Status.BoundResources {
+		if apiBinding.BindsResource(r.Group, r.Resource) {
+			boundResources = append(boundResources, r)
+		}
+	}
+	apiBinding.Status.BoundResources = boundResources
+	pruneSchemaStatuses(apiBinding, boundSchemas)
+
+	if apiBinding.Spec.Reference.Identity != nil {
+		// Record the APIExport the identity was resolved to, such that it can see the objects in this workspace.
+		apiBinding.Status.BoundAPIExport = &apisv1alpha1.ExportReference{
+			Workspace: &apisv1alpha1.WorkspaceExportReference{
+				Path:       apiExportClusterName.String(),
+				ExportName: apiExport.Name,
+			},
+		}
+	} else {
+		apiBinding.Status.BoundAPIExport = &apiBinding.Spec.Reference
+	}
 
 	// Now that the Export is valid and is marked as such, we will add all the claims requested to the status.
 	apiBinding.Status.ExportPermissionClaims = apiExport.Spec.PermissionClaims
+	setPermissionClaimStatuses(apiBinding, apiExport)
 
 	if len(needToWaitForRequeueWhenEstablished) > 0 {
 		sort.Strings(needToWaitForRequeueWhenEstablished)
+		setInitialPhase(apiBinding, apisv1alpha1.APIBindingPhaseSchemasBinding)
 
 		conditions.MarkFalse(
 			apiBinding,
@@ -402,7 +471,12 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 	} else {
 		conditions.MarkTrue(apiBinding, apisv1alpha1.InitialBindingCompleted)
 		conditions.MarkTrue(apiBinding, apisv1alpha1.BindingUpToDate)
-		apiBinding.Status.Phase = apisv1alpha1.APIBindingPhaseBound
+		apiBinding.Status.Phase = boundPhase(apiBinding, apiExport)
+		apiBinding.Status.AvailableExportGeneration = apiExport.Generation
+		apiBinding.Status.BoundExportGeneration = apiExport.Generation
+		apiBinding.Status.UpgradeAvailableSince = nil
+		apiBinding.Status.SchemaCompatibility = nil
+		updateResourcesNotExportedCondition(apiBinding, apiExportClusterName, apiExport, exportedSchemas)
 	}
 
 	return nil
@@ -410,15 +484,14 @@ func (c *controller) reconcileBinding(ctx context.Context, apiBinding *apisv1alp
 
 func (c *controller) reconcileBound(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) (rebind bool, err error) {
 	logger := klog.FromContext(ctx)
-	apiExportClusterName, err := getAPIExportClusterName(apiBinding)
-	if err != nil {
+	if apiBinding.Spec.Reference.Workspace == nil && apiBinding.Spec.Reference.Identity == nil {
 		// Should never happen
 		conditions.MarkFalse(
 			apiBinding,
 			apisv1alpha1.APIExportValid,
 			apisv1alpha1.APIExportNotFoundReason,
 			conditionsv1alpha1.ConditionSeverityError,
-			err.Error(),
+			"APIBinding does not specify an APIExport",
 		)
 
 		return false, nil
@@ -429,17 +502,39 @@ func (c *controller) reconcileBound(ctx context.Context, apiBinding *apisv1alpha
 		return true, nil
 	}
 
-	apiExport, err := c.getAPIExport(apiExportClusterName, apiBinding.Spec.Reference.Workspace.ExportName)
+	apiExportClusterName, apiExport, err := c.resolveAPIExport(apiBinding)
+	var ambiguousErr *ambiguousIdentityError
+	if errors.As(err, &ambiguousErr) {
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.APIExportValid,
+			apisv1alpha1.APIExportInvalidReferenceReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			err.Error(),
+		)
+
+		// Return nil here so we don't retry. The APIBinding is requeued when the APIExports with the identity change.
+		return false, nil
+	}
 	if apierrors.IsNotFound(err) {
 		conditions.MarkFalse(
 			apiBinding,
 			apisv1alpha1.APIExportValid,
 			apisv1alpha1.APIExportNotFoundReason,
 			conditionsv1alpha1.ConditionSeverityWarning,
-			"APIExport %s|%s not found",
-			apiExportClusterName,
-			apiBinding.Spec.Reference.Workspace.ExportName,
+			"APIExport %s not found",
+			exportReferenceString(apiBinding),
 		)
+		if conditions.Has(apiBinding, apisv1alpha1.MaximalPermissionPolicyValid) {
+			conditions.MarkFalse(
+				apiBinding,
+				apisv1alpha1.MaximalPermissionPolicyValid,
+				apisv1alpha1.APIExportNotFoundReason,
+				conditionsv1alpha1.ConditionSeverityError,
+				"APIExport %s not found, requests to the bound resources are denied",
+				exportReferenceString(apiBinding),
+			)
+		}
 
 		// Return nil here so we don't retry. If/when there is an informer event for the correct APIExport, this
 		// APIBinding will automatically be requeued.
@@ -451,15 +546,22 @@ func (c *controller) reconcileBound(ctx context.Context, apiBinding *apisv1alpha
 			apisv1alpha1.APIExportValid,
 			apisv1alpha1.InternalErrorReason,
 			conditionsv1alpha1.ConditionSeverityWarning,
-			"Error getting APIExport %s|%s: %v",
-			apiExportClusterName,
-			apiBinding.Spec.Reference.Workspace.ExportName,
+			"Error getting APIExport %s: %v",
+			exportReferenceString(apiBinding),
 			err,
 		)
 
 		return false, err
 	}
 
+	if resolvedAPIExportChanged(apiBinding, apiExportClusterName, apiExport) {
+		logger.V(2).Info("APIBinding needs rebinding because its identity now resolves to a different APIExport")
+		return true, nil
+	}
+
+	apiBinding.Status.Phase = boundPhase(apiBinding, apiExport)
+	setPermissionClaimStatuses(apiBinding, apiExport)
+
 	var exportedSchemas []*apisv1alpha1.APIResourceSchema
 	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
 		apiResourceSchema, err := c.getAPIResourceSchema(apiExportClusterName, schemaName)
@@ -485,14 +587,353 @@ func (c *controller) reconcileBound(ctx context.Context, apiBinding *apisv1alpha
 		exportedSchemas = append(exportedSchemas, apiResourceSchema)
 	}
 
-	if apiExportLatestResourceSchemasChanged(apiBinding, exportedSchemas) {
+	var boundSchemas []*apisv1alpha1.APIResourceSchema
+	for _, schema := range exportedSchemas {
+		if apiBinding.BindsResource(schema.Spec.Group, schema.Spec.Names.Plural) {
+			boundSchemas = append(boundSchemas, schema)
+		}
+	}
+
+	setAPIExportDeprecatedCondition(apiBinding, apiExportClusterName, apiExport, boundSchemas)
+	if err := c.setMaximalPermissionPolicyCondition(apiBinding, apiExportClusterName, apiExport); err != nil {
+		return false, err
+	}
+
+	if apiExportLatestResourceSchemasChanged(apiBinding, boundSchemas) {
+		report, err := c.schemaCompatibilityReport(apiBinding, apiExport, boundSchemas)
+		if err != nil {
+			return false, err
+		}
+		apiBinding.Status.SchemaCompatibility = report
+
+		// the upgrade policy only applies to changes of the APIExport, not to changes of the selected resources.
+		if apiExport.Generation != apiBinding.Status.BoundExportGeneration && !c.upgradeAllowed(apiBinding, apiExport) {
+			logger.V(4).Info("APIBinding upgrade to the APIExport's latestResourceSchemas is pending", "generation", apiExport.Generation)
+			return false, nil
+		}
+		if len(report.Changes) > 0 && apiBinding.Annotations[apisv1alpha1.AnnotationAllowBreakingChangesKey] != "true" {
+			logger.V(2).Info("APIBinding upgrade to the APIExport's latestResourceSchemas is blocked by incompatible schema changes", "changes", len(report.Changes))
+			conditions.MarkFalse(
+				apiBinding,
+				apisv1alpha1.BindingUpToDate,
+				apisv1alpha1.BreakingChangesReason,
+				conditionsv1alpha1.ConditionSeverityWarning,
+				"APIExport generation %d has %d incompatible schema change(s), see status.schemaCompatibility. Set the annotation %s to \"true\" to bind them",
+				apiExport.Generation,
+				len(report.Changes),
+				apisv1alpha1.AnnotationAllowBreakingChangesKey,
+			)
+			return false, nil
+		}
 		logger.V(2).Info("APIBinding needs rebinding because the APIExport's latestResourceSchemas has changed")
 		return true, nil
 	}
 
+	apiBinding.Status.AvailableExportGeneration = apiExport.Generation
+	apiBinding.Status.BoundExportGeneration = apiExport.Generation
+	apiBinding.Status.UpgradeAvailableSince = nil
+	apiBinding.Status.SchemaCompatibility = nil
+	if cond := conditions.Get(apiBinding, apisv1alpha1.BindingUpToDate); cond != nil && (cond.Reason == apisv1alpha1.UpgradePendingReason || cond.Reason == apisv1alpha1.BreakingChangesReason) {
+		conditions.MarkTrue(apiBinding, apisv1alpha1.BindingUpToDate)
+	}
+	updateResourcesNotExportedCondition(apiBinding, apiExportClusterName, apiExport, exportedSchemas)
+
 	return false, nil
 }
 
+// setInitialPhase sets the phase of an APIBinding that is not bound yet. Once bound, the APIs stay
+// available and failures to pick up changes of the APIExport are only reported in the conditions and
+// the schema statuses.
+func setInitialPhase(apiBinding *apisv1alpha1.APIBinding, phase apisv1alpha1.APIBindingPhaseType) {
+	if apiBinding.IsBound() {
+		return
+	}
+	apiBinding.Status.Phase = phase
+}
+
+// boundPhase returns the phase of a bound APIBinding, depending on whether all permission claims
+// of the APIExport are accepted or rejected.
+func boundPhase(apiBinding *apisv1alpha1.APIBinding, apiExport *apisv1alpha1.APIExport) apisv1alpha1.APIBindingPhaseType {
+	for _, claim := range apiExport.Spec.PermissionClaims {
+		if apiBinding.PermissionClaimState(claim) == apisv1alpha1.ClaimPending {
+			return apisv1alpha1.APIBindingPhasePermissionClaimsPending
+		}
+	}
+	return apisv1alpha1.APIBindingPhaseBound
+}
+
+// setPermissionClaimStatuses records the acceptance state of each permission claim of the APIExport.
+func setPermissionClaimStatuses(apiBinding *apisv1alpha1.APIBinding, apiExport *apisv1alpha1.APIExport) {
+	var statuses []apisv1alpha1.PermissionClaimStatus
+	for _, claim := range apiExport.Spec.PermissionClaims {
+		statuses = append(statuses, apisv1alpha1.PermissionClaimStatus{
+			PermissionClaim: claim,
+			State:           apiBinding.PermissionClaimState(claim),
+		})
+	}
+	apiBinding.Status.PermissionClaims = statuses
+}
+
+func schemaStatus(schema *apisv1alpha1.APIResourceSchema, phase apisv1alpha1.APIResourceSchemaPhaseType, reason, message string) apisv1alpha1.APIResourceSchemaStatus {
+	return apisv1alpha1.APIResourceSchemaStatus{
+		Name:     schema.Name,
+		Group:    schema.Spec.Group,
+		Resource: schema.Spec.Names.Plural,
+		Phase:    phase,
+		Reason:   reason,
+		Message:  message,
+	}
+}
+
+// setSchemaStatus adds or replaces the status of a resource schema in the APIBinding.
+func setSchemaStatus(apiBinding *apisv1alpha1.APIBinding, status apisv1alpha1.APIResourceSchemaStatus) {
+	for i := range apiBinding.Status.Schemas {
+		if apiBinding.Status.Schemas[i].Name == status.Name {
+			apiBinding.Status.Schemas[i] = status
+			return
+		}
+	}
+	apiBinding.Status.Schemas = append(apiBinding.Status.Schemas, status)
+}
+
+// pruneSchemaStatuses drops the statuses of resource schemas that are no longer bound.
+func pruneSchemaStatuses(apiBinding *apisv1alpha1.APIBinding, boundSchemas []*apisv1alpha1.APIResourceSchema) {
+	names := sets.NewString()
+	for _, schema := range boundSchemas {
+		names.Insert(schema.Name)
+	}
+	statuses := apiBinding.Status.Schemas[:0]
+	for _, status := range apiBinding.Status.Schemas {
+		if names.Has(status.Name) {
+			statuses = append(statuses, status)
+		}
+	}
+	apiBinding.Status.Schemas = statuses
+}
+
+// updateResourcesNotExportedCondition marks the BindingUpToDate condition as false if resources selected by
+// the APIBinding are not exported by the APIExport, and resets it once they are.
+func updateResourcesNotExportedCondition(apiBinding *apisv1alpha1.APIBinding, apiExportClusterName logicalcluster.Name, apiExport *apisv1alpha1.APIExport, exportedSchemas []*apisv1alpha1.APIResourceSchema) {
+	exported := sets.NewString()
+	for _, schema := range exportedSchemas {
+		exported.Insert(schema.Spec.Names.Plural + "." + schema.Spec.Group)
+	}
+	var missing []string
+	for _, r := range apiBinding.Spec.Resources {
+		if gr := r.Resource + "." + r.Group; !exported.Has(gr) {
+			missing = append(missing, gr)
+		}
+	}
+
+	if len(missing) > 0 {
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.BindingUpToDate,
+			apisv1alpha1.ResourcesNotExportedReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"APIExport %s|%s does not export the resource(s) %s",
+			apiExportClusterName,
+			apiExport.Name,
+			strings.Join(missing, ", "),
+		)
+		return
+	}
+	if cond := conditions.Get(apiBinding, apisv1alpha1.BindingUpToDate); cond != nil && cond.Reason == apisv1alpha1.ResourcesNotExportedReason {
+		conditions.MarkTrue(apiBinding, apisv1alpha1.BindingUpToDate)
+	}
+}
+
+// upgradeAllowed returns whether the binding may pick up the changed latestResourceSchemas of the
+// APIExport according to its upgrade policy. If not, the BindingUpToDate condition reports the
+// pending upgrade.
+func (c *controller) upgradeAllowed(apiBinding *apisv1alpha1.APIBinding, apiExport *apisv1alpha1.APIExport) bool {
+	now := c.now()
+	if apiBinding.Status.UpgradeAvailableSince == nil || apiBinding.Status.AvailableExportGeneration != apiExport.Generation {
+		since := metav1.NewTime(now)
+		apiBinding.Status.UpgradeAvailableSince = &since
+	}
+	apiBinding.Status.AvailableExportGeneration = apiExport.Generation
+
+	policy := apiBinding.Spec.UpgradePolicy
+	if policy == nil {
+		return true
+	}
+
+	switch policy.Type {
+	case apisv1alpha1.UpgradePolicyManual:
+		if apiExport.Generation <= policy.ApprovedGeneration {
+			return true
+		}
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.BindingUpToDate,
+			apisv1alpha1.UpgradePendingReason,
+			conditionsv1alpha1.ConditionSeverityInfo,
+			"APIExport generation %d is available, but only generation %d is approved",
+			apiExport.Generation,
+			policy.ApprovedGeneration,
+		)
+		return false
+	case apisv1alpha1.UpgradePolicyScheduled:
+		var delay time.Duration
+		if policy.Delay != nil {
+			delay = policy.Delay.Duration
+		}
+		upgradeTime := apiBinding.Status.UpgradeAvailableSince.Add(delay)
+		if !now.Before(upgradeTime) {
+			return true
+		}
+		c.enqueueAfter(apiBinding, upgradeTime.Sub(now))
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.BindingUpToDate,
+			apisv1alpha1.UpgradePendingReason,
+			conditionsv1alpha1.ConditionSeverityInfo,
+			"APIExport generation %d is available and will be bound at %s",
+			apiExport.Generation,
+			upgradeTime.UTC().Format(time.RFC3339),
+		)
+		return false
+	default:
+		return true
+	}
+}
+
+// setAPIExportDeprecatedCondition sets the APIExportDeprecated condition if the APIExport or any served
+// version of the given schemas is deprecated, and removes it otherwise. The deprecation of the APIExport
+// supersedes the deprecation of single versions, such that the message is the warning for all requests
+// to the bound resources.
+func setAPIExportDeprecatedCondition(apiBinding *apisv1alpha1.APIBinding, apiExportClusterName logicalcluster.Name, apiExport *apisv1alpha1.APIExport, schemas []*apisv1alpha1.APIResourceSchema) {
+	if apiExport.Spec.Deprecated {
+		msg := fmt.Sprintf("APIExport %s|%s is deprecated", apiExportClusterName, apiExport.Name)
+		if apiExport.Spec.DeprecationWarning != nil {
+			msg = *apiExport.Spec.DeprecationWarning
+		}
+		if apiExport.Spec.SunsetDate != nil {
+			msg += fmt.Sprintf(" (sunset date %s)", apiExport.Spec.SunsetDate.UTC().Format(time.RFC3339))
+		}
+		conditions.Set(apiBinding, &conditionsv1alpha1.Condition{
+			Type:     apisv1alpha1.APIExportDeprecated,
+			Status:   corev1.ConditionTrue,
+			Severity: conditionsv1alpha1.ConditionSeverityWarning,
+			Reason:   apisv1alpha1.DeprecatedReason,
+			Message:  msg,
+		})
+		return
+	}
+
+	var messages []string
+	for _, schema := range schemas {
+		for _, version := range schema.Spec.Versions {
+			if !version.Served || !version.Deprecated {
+				continue
+			}
+			msg := fmt.Sprintf("%s/%s %s is deprecated", schema.Spec.Group, version.Name, schema.Spec.Names.Kind)
+			if version.DeprecationWarning != nil {
+				msg = *version.DeprecationWarning
+			}
+			messages = append(messages, msg)
+		}
+	}
+
+	if len(messages) == 0 {
+		conditions.Delete(apiBinding, apisv1alpha1.APIExportDeprecated)
+		return
+	}
+
+	conditions.Set(apiBinding, &conditionsv1alpha1.Condition{
+		Type:     apisv1alpha1.APIExportDeprecated,
+		Status:   corev1.ConditionTrue,
+		Severity: conditionsv1alpha1.ConditionSeverityWarning,
+		Reason:   apisv1alpha1.VersionsDeprecatedReason,
+		Message:  strings.Join(messages, "; "),
+	})
+}
+
+// setMaximalPermissionPolicyCondition sets the MaximalPermissionPolicyValid condition according to whether the
+// maximal permission policy of the APIExport can be evaluated by the authorizer, and removes it if the APIExport
+// has no maximal permission policy.
+func (c *controller) setMaximalPermissionPolicyCondition(apiBinding *apisv1alpha1.APIBinding, apiExportClusterName logicalcluster.Name, apiExport *apisv1alpha1.APIExport) error {
+	policy := apiExport.Spec.MaximalPermissionPolicy
+	if policy == nil {
+		conditions.Delete(apiBinding, apisv1alpha1.MaximalPermissionPolicyValid)
+		return nil
+	}
+
+	if policy.Local == nil {
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.MaximalPermissionPolicyValid,
+			apisv1alpha1.UnsupportedMaximalPermissionPolicyReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"APIExport %s|%s has an unsupported maximal permission policy, requests to the bound resources are denied",
+			apiExportClusterName,
+			apiExport.Name,
+		)
+		return nil
+	}
+
+	found, err := c.hasMaximalPermissionPolicyBindings(apiExportClusterName)
+	if err != nil {
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.MaximalPermissionPolicyValid,
+			apisv1alpha1.InternalErrorReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"Error looking up the maximal permission policy of APIExport %s|%s: %v",
+			apiExportClusterName,
+			apiExport.Name,
+			err,
+		)
+		return err
+	}
+	if !found {
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.MaximalPermissionPolicyValid,
+			apisv1alpha1.NoMaximalPermissionPolicyBindingsReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"Workspace %s has no RBAC bindings for %q prefixed users or groups, requests to the resources bound from APIExport %s are denied",
+			apiExportClusterName,
+			apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix,
+			apiExport.Name,
+		)
+		return nil
+	}
+
+	conditions.MarkTrue(apiBinding, apisv1alpha1.MaximalPermissionPolicyValid)
+	return nil
+}
+
+// hasMaximalPermissionPolicySubject returns whether any of the subjects is a user or group the
+// maximal permission policy authorizer checks.
+func hasMaximalPermissionPolicySubject(subjects []rbacv1.Subject) bool {
+	for _, subject := range subjects {
+		if subject.Kind != rbacv1.UserKind && subject.Kind != rbacv1.GroupKind {
+			continue
+		}
+		if strings.HasPrefix(subject.Name, apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// boundCustomSubresources returns the custom subresources of all versions of the schema.
+func boundCustomSubresources(schema *apisv1alpha1.APIResourceSchema) []apisv1alpha1.BoundCustomSubresource {
+	var subresources []apisv1alpha1.BoundCustomSubresource
+	for _, version := range schema.Spec.Versions {
+		for _, subresource := range version.CustomSubresources {
+			subresources = append(subresources, apisv1alpha1.BoundCustomSubresource{
+				Version: version.Name,
+				Name:    subresource.Name,
+				Verbs:   subresource.Verbs,
+			})
+		}
+	}
+	return subresources
+}
+
 func generateCRD(schema *apisv1alpha1.APIResourceSchema) (*apiextensionsv1.CustomResourceDefinition, error) {
 	crd := &apiextensionsv1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
@@ -535,11 +976,31 @@ func generateCRD(schema *apisv1alpha1.APIResourceSchema) (*apiextensionsv1.Custo
 		if err := json.Unmarshal(version.Schema.Raw, &validation.OpenAPIV3Schema); err != nil {
 			return nil, err
 		}
+		// custom subresources own the top-level fields of the same name
+		if err := version.AddCustomSubresourceSchemas(validation.OpenAPIV3Schema); err != nil {
+			return nil, err
+		}
 		crdVersion.Schema = &validation
 
 		crd.Spec.Versions = append(crd.Spec.Versions, crdVersion)
 	}
 
+	if conversion := schema.Spec.Conversion; conversion != nil {
+		crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+			Strategy: apiextensionsv1.ConversionStrategyType(conversion.Strategy),
+		}
+		if conversion.Webhook != nil {
+			url := conversion.Webhook.ClientConfig.URL
+			crd.Spec.Conversion.Webhook = &apiextensionsv1.WebhookConversion{
+				ClientConfig: &apiextensionsv1.WebhookClientConfig{
+					URL:      &url,
+					CABundle: conversion.Webhook.ClientConfig.CABundle,
+				},
+				ConversionReviewVersions: conversion.Webhook.ConversionReviewVersions,
+			}
+		}
+	}
+
 	return crd, nil
 }
 
@@ -552,8 +1013,90 @@ func getAPIExportClusterName(apiBinding *apisv1alpha1.APIBinding) (logicalcluste
 	return logicalcluster.New(apiBinding.Spec.Reference.Workspace.Path), nil
 }
 
+// ambiguousIdentityError is returned when an identity hash is shared by more than one APIExport, none of
+// which the APIBinding is bound to already.
+type ambiguousIdentityError struct {
+	identityHash string
+	apiExports   []string
+}
+
+func (e *ambiguousIdentityError) Error() string {
+	return fmt.Sprintf("identity hash %s is ambiguous, it is used by APIExports %s", e.identityHash, strings.Join(e.apiExports, ", "))
+}
+
+// resolveAPIExport returns the APIExport referenced by the APIBinding and the logical cluster it lives in.
+// A reference by identity is resolved through the identity hash index across shards. If the identity is
+// used by multiple APIExports, the APIExport the binding is bound to already wins.
+func (c *controller) resolveAPIExport(apiBinding *apisv1alpha1.APIBinding) (logicalcluster.Name, *apisv1alpha1.APIExport, error) {
+	identityRef := apiBinding.Spec.Reference.Identity
+	if identityRef == nil {
+		apiExportClusterName, err := getAPIExportClusterName(apiBinding)
+		if err != nil {
+			return logicalcluster.Name{}, nil, err
+		}
+		apiExport, err := c.getAPIExport(apiExportClusterName, apiBinding.Spec.Reference.Workspace.ExportName)
+		return apiExportClusterName, apiExport, err
+	}
+
+	apiExports, err := c.getAPIExportsByIdentity(identityRef.IdentityHash)
+	if err != nil {
+		return logicalcluster.Name{}, nil, err
+	}
+	switch len(apiExports) {
+	case 0:
+		return logicalcluster.Name{}, nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), identityRef.IdentityHash)
+	case 1:
+		return logicalcluster.From(apiExports[0]), apiExports[0], nil
+	}
+
+	names := make([]string, 0, len(apiExports))
+	for _, apiExport := range apiExports {
+		clusterName := logicalcluster.From(apiExport)
+		if bound := apiBinding.Status.BoundAPIExport; bound != nil && bound.Workspace != nil &&
+			bound.Workspace.Path == clusterName.String() && bound.Workspace.ExportName == apiExport.Name {
+			return clusterName, apiExport, nil
+		}
+		names = append(names, fmt.Sprintf("%s|%s", clusterName, apiExport.Name))
+	}
+	return logicalcluster.Name{}, nil, &ambiguousIdentityError{identityHash: identityRef.IdentityHash, apiExports: names}
+}
+
+// uniqueAPIExports returns the given APIExports without duplicates, sorted by logical cluster and name.
+func uniqueAPIExports(apiExports []*apisv1alpha1.APIExport) []*apisv1alpha1.APIExport {
+	seen := sets.NewString()
+	ret := make([]*apisv1alpha1.APIExport, 0, len(apiExports))
+	for _, apiExport := range apiExports {
+		key := clusters.ToClusterAwareKey(logicalcluster.From(apiExport), apiExport.Name)
+		if seen.Has(key) {
+			continue
+		}
+		seen.Insert(key)
+		ret = append(ret, apiExport)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return clusters.ToClusterAwareKey(logicalcluster.From(ret[i]), ret[i].Name) < clusters.ToClusterAwareKey(logicalcluster.From(ret[j]), ret[j].Name)
+	})
+	return ret
+}
+
+// exportReferenceString describes the APIExport reference of the APIBinding in condition messages.
+func exportReferenceString(apiBinding *apisv1alpha1.APIBinding) string {
+	if identityRef := apiBinding.Spec.Reference.Identity; identityRef != nil {
+		return fmt.Sprintf("with identity hash %s", identityRef.IdentityHash)
+	}
+	if workspaceRef := apiBinding.Spec.Reference.Workspace; workspaceRef != nil {
+		return fmt.Sprintf("%s|%s", logicalcluster.New(workspaceRef.Path), workspaceRef.ExportName)
+	}
+	return ""
+}
+
 func referencedAPIExportChanged(apiBinding *apisv1alpha1.APIBinding) bool {
-	// Can't happen because of OpenAPI, but just in case
+	if apiBinding.Status.BoundAPIExport == nil || apiBinding.Status.BoundAPIExport.Workspace == nil {
+		return true
+	}
+
+	// References by identity are compared after resolving them, see resolvedAPIExportChanged.
+	// Can't be nil otherwise because of OpenAPI, but just in case.
 	if apiBinding.Spec.Reference.Workspace == nil {
 		return false
 	}
@@ -561,6 +1104,17 @@ func referencedAPIExportChanged(apiBinding *apisv1alpha1.APIBinding) bool {
 	return *apiBinding.Spec.Reference.Workspace != *apiBinding.Status.BoundAPIExport.Workspace
 }
 
+// resolvedAPIExportChanged returns whether a reference by identity resolves to a different APIExport than
+// the bound one, e.g. because the provider moved the APIExport to another workspace.
+func resolvedAPIExportChanged(apiBinding *apisv1alpha1.APIBinding, apiExportClusterName logicalcluster.Name, apiExport *apisv1alpha1.APIExport) bool {
+	if apiBinding.Spec.Reference.Identity == nil {
+		return false
+	}
+
+	boundWorkspaceRef := apiBinding.Status.BoundAPIExport.Workspace
+	return boundWorkspaceRef.Path != apiExportClusterName.String() || boundWorkspaceRef.ExportName != apiExport.Name
+}
+
 func apiExportLatestResourceSchemasChanged(apiBinding *apisv1alpha1.APIBinding, exportedSchemas []*apisv1alpha1.APIResourceSchema) bool {
 	exportedSchemaUIDs := sets.NewString()
 	for _, exportedSchema := range exportedSchemas {
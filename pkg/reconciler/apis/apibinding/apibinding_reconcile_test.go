@@ -20,11 +20,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,6 +40,7 @@ import (
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 // requireConditionMatches looks for a condition matching c in g. Only fields that are set in c are compared (Type is
@@ -70,7 +73,7 @@ var (
 		WithName("my-binding").
 		WithWorkspaceReference("org:some-workspace", "some-export")
 
-	binding = unbound.DeepCopy().WithPhase(apisv1alpha1.APIBindingPhaseBinding)
+	binding = unbound.DeepCopy().WithPhase(apisv1alpha1.APIBindingPhasePending)
 
 	rebinding = binding.DeepCopy().
 			WithBoundAPIExport("org:some-workspace", "some-export").
@@ -175,7 +178,7 @@ func TestReconcileNew(t *testing.T) {
 
 	err := c.reconcile(context.Background(), apiBinding)
 	require.NoError(t, err)
-	require.Equal(t, apisv1alpha1.APIBindingPhaseBinding, apiBinding.Status.Phase)
+	require.Equal(t, apisv1alpha1.APIBindingPhasePending, apiBinding.Status.Phase)
 	requireConditionMatches(t, apiBinding, conditions.FalseCondition(conditionsv1alpha1.ReadyCondition, "", "", ""))
 }
 
@@ -203,55 +206,75 @@ func TestReconcileBinding(t *testing.T) {
 		wantInitialBindingComplete              bool
 		wantInitialBindingCompleteInternalError bool
 		wantInitialBindingCompleteSchemaInvalid bool
-		wantPhaseBound                          bool
+		wantPhase                               apisv1alpha1.APIBindingPhaseType
+		wantSchemas                             []apisv1alpha1.APIResourceSchemaStatus
+		wantPermissionClaims                    []apisv1alpha1.PermissionClaimStatus
 		wantBoundResources                      []apisv1alpha1.BoundAPIResource
 		wantNamingConflict                      bool
+		wantResourcesNotExported                bool
 		crdEstablished                          bool
 		crdStorageVerions                       []string
 	}{
 		"Update to nil workspace ref reports invalid APIExport": {
+			wantPhase:            apisv1alpha1.APIBindingPhaseFailed,
 			apiBinding:           binding.DeepCopy().WithoutWorkspaceReference().Build(),
 			wantInvalidReference: true,
 		},
 		"APIExport not found": {
+			wantPhase:             apisv1alpha1.APIBindingPhasePending,
 			apiBinding:            binding.Build(),
 			getAPIExportError:     apierrors.NewNotFound(apisv1alpha1.SchemeGroupVersion.WithResource("apiexports").GroupResource(), "some-export"),
 			wantAPIExportNotFound: true,
 		},
 		"APIExport get error - random error": {
+			wantPhase:                  apisv1alpha1.APIBindingPhasePending,
 			apiBinding:                 binding.Build(),
 			getAPIExportError:          errors.New("foo"),
 			wantAPIExportInternalError: true,
 			wantError:                  true,
 		},
 		"APIResourceSchema get error - not found": {
+			wantPhase: apisv1alpha1.APIBindingPhaseFailed,
+			wantSchemas: []apisv1alpha1.APIResourceSchemaStatus{
+				{
+					Name:    "today.widgets.kcp.dev",
+					Phase:   apisv1alpha1.APIResourceSchemaPhaseFailed,
+					Reason:  apisv1alpha1.APIResourceSchemaNotFoundReason,
+					Message: "APIResourceSchema org:some-workspace|today.widgets.kcp.dev not found",
+				},
+			},
 			apiBinding:                 binding.Build(),
 			getAPIResourceSchemaError:  apierrors.NewNotFound(schema.GroupResource{}, "foo"),
 			wantAPIExportInternalError: true,
 			wantError:                  false,
 		},
 		"APIResourceSchema get error - random error": {
+			wantPhase:                  apisv1alpha1.APIBindingPhasePending,
 			apiBinding:                 binding.Build(),
 			getAPIResourceSchemaError:  errors.New("foo"),
 			wantAPIExportInternalError: true,
 			wantError:                  true,
 		},
 		"APIExport doesn't have identity hash yet": {
+			wantPhase: apisv1alpha1.APIBindingPhaseIdentityVerification,
 			apiBinding: binding.DeepCopy().
 				WithWorkspaceReference("org:some-workspace", "no-identity-hash").Build(),
 			wantAPIExportValid: false,
 		},
 		"APIResourceSchema invalid": {
+			wantPhase:                  apisv1alpha1.APIBindingPhaseFailed,
 			apiBinding:                 invalidSchema.Build(),
 			wantAPIExportInternalError: true,
 		},
 		"CRD get error": {
+			wantPhase:                  apisv1alpha1.APIBindingPhasePending,
 			apiBinding:                 binding.Build(),
 			getCRDError:                errors.New("foo"),
 			wantAPIExportInternalError: true,
 			wantError:                  true,
 		},
 		"create CRD fails - invalid": {
+			wantPhase:                               apisv1alpha1.APIBindingPhaseFailed,
 			apiBinding:                              binding.Build(),
 			getCRDError:                             apierrors.NewNotFound(schema.GroupResource{}, ""),
 			wantCreateCRD:                           true,
@@ -260,6 +283,7 @@ func TestReconcileBinding(t *testing.T) {
 			wantError:                               false,
 		},
 		"create CRD fails - other error": {
+			wantPhase:                               apisv1alpha1.APIBindingPhasePending,
 			apiBinding:                              binding.Build(),
 			getCRDError:                             apierrors.NewNotFound(schema.GroupResource{}, ""),
 			wantCreateCRD:                           true,
@@ -268,6 +292,7 @@ func TestReconcileBinding(t *testing.T) {
 			wantError:                               true,
 		},
 		"create CRD - no other bindings": {
+			wantPhase:                 apisv1alpha1.APIBindingPhaseSchemasBinding,
 			apiBinding:                binding.Build(),
 			getCRDError:               apierrors.NewNotFound(schema.GroupResource{}, ""),
 			wantCreateCRD:             true,
@@ -276,7 +301,28 @@ func TestReconcileBinding(t *testing.T) {
 			wantBoundAPIExport:        true,
 			wantBoundResources:        nil, // not yet established
 		},
+		"create CRD - identity reference": {
+			wantPhase:                 apisv1alpha1.APIBindingPhaseSchemasBinding,
+			apiBinding:                binding.DeepCopy().WithIdentityReference("hash1").Build(),
+			getCRDError:               apierrors.NewNotFound(schema.GroupResource{}, ""),
+			wantCreateCRD:             true,
+			wantWaitingForEstablished: true,
+			wantAPIExportValid:        true,
+			wantBoundAPIExport:        true,
+			wantBoundResources:        nil, // not yet established
+		},
+		"identity reference not found": {
+			wantPhase:             apisv1alpha1.APIBindingPhasePending,
+			apiBinding:            binding.DeepCopy().WithIdentityReference("unknown").Build(),
+			wantAPIExportNotFound: true,
+		},
+		"identity reference is ambiguous": {
+			wantPhase:            apisv1alpha1.APIBindingPhaseFailed,
+			apiBinding:           binding.DeepCopy().WithIdentityReference("ambiguous").Build(),
+			wantInvalidReference: true,
+		},
 		"create CRD - other bindings - no conflicts": {
+			wantPhase:  apisv1alpha1.APIBindingPhaseSchemasBinding,
 			apiBinding: binding.Build(),
 			existingAPIBindings: []*apisv1alpha1.APIBinding{
 				bound.Build(),
@@ -289,6 +335,17 @@ func TestReconcileBinding(t *testing.T) {
 			wantBoundResources:        nil, // not yet established
 		},
 		"create CRD - other bindings - conflicts": {
+			wantPhase: apisv1alpha1.APIBindingPhaseFailed,
+			wantSchemas: []apisv1alpha1.APIResourceSchemaStatus{
+				{
+					Name:     "today.widgets.kcp.dev",
+					Group:    "kcp.dev",
+					Resource: "widgets",
+					Phase:    apisv1alpha1.APIResourceSchemaPhaseFailed,
+					Reason:   apisv1alpha1.NamingConflictsReason,
+					Message:  "naming conflict with a bound API conflicting, spec.names.plural=widgets is forbidden",
+				},
+			},
 			apiBinding: binding.Build(),
 			existingAPIBindings: []*apisv1alpha1.APIBinding{
 				conflicting.Build(),
@@ -297,6 +354,7 @@ func TestReconcileBinding(t *testing.T) {
 			wantNamingConflict: true,
 		},
 		"bind existing CRD - other bindings - conflicts": {
+			wantPhase:  apisv1alpha1.APIBindingPhaseFailed,
 			apiBinding: binding.Build(),
 			crdExists:  true,
 			existingAPIBindings: []*apisv1alpha1.APIBinding{
@@ -305,6 +363,10 @@ func TestReconcileBinding(t *testing.T) {
 			wantNamingConflict: true,
 		},
 		"CRD already exists but isn't established yet": {
+			wantPhase: apisv1alpha1.APIBindingPhaseSchemasBinding,
+			wantSchemas: []apisv1alpha1.APIResourceSchemaStatus{
+				{Name: "today.widgets.kcp.dev", Group: "kcp.dev", Resource: "widgets", Phase: apisv1alpha1.APIResourceSchemaPhaseBinding},
+			},
 			apiBinding:                binding.Build(),
 			getCRDError:               nil,
 			crdExists:                 true,
@@ -317,6 +379,10 @@ func TestReconcileBinding(t *testing.T) {
 			wantWaitingForEstablished: true,
 		},
 		"CRD already exists and is established": {
+			wantPhase: apisv1alpha1.APIBindingPhaseBound,
+			wantSchemas: []apisv1alpha1.APIResourceSchemaStatus{
+				{Name: "today.widgets.kcp.dev", Group: "kcp.dev", Resource: "widgets", Phase: apisv1alpha1.APIResourceSchemaPhaseBound},
+			},
 			apiBinding:         binding.Build(),
 			getCRDError:        nil,
 			crdExists:          true,
@@ -337,15 +403,96 @@ func TestReconcileBinding(t *testing.T) {
 					StorageVersions: []string{"v0", "v1"},
 				},
 			},
-			wantPhaseBound:             true,
 			wantInitialBindingComplete: true,
 		},
 		"Ensure merging storage versions works": {
-			apiBinding:         rebinding.Build(),
+			wantPhase: apisv1alpha1.APIBindingPhaseBound,
+			apiBinding: binding.DeepCopy().
+				WithBoundAPIExport("org:some-workspace", "some-export").
+				WithBoundResources(
+					new(boundAPIResourceBuilder).
+						WithGroupResource("kcp.dev", "widgets").
+						WithSchema("today.widgets.kcp.dev", "todaywidgetsuid").
+						WithStorageVersions("v0").
+						BoundAPIResource,
+				).
+				Build(),
 			getCRDError:        nil,
 			crdExists:          true,
 			crdEstablished:     true,
-			crdStorageVerions:  []string{"v2"},
+			crdStorageVerions:  []string{"v0", "v1", "v2"},
+			wantAPIExportValid: true,
+			wantReady:          true,
+			wantBoundAPIExport: true,
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:    "kcp.dev",
+					Resource: "widgets",
+					Schema: apisv1alpha1.BoundAPIResourceSchema{
+						Name:         "today.widgets.kcp.dev",
+						UID:          "todaywidgetsuid",
+						IdentityHash: "hash1",
+					},
+					StorageVersions: []string{"v0", "v1"},
+				},
+			},
+			wantInitialBindingComplete: true,
+		},
+		"storage versions dropped by a migration are not merged in again": {
+			wantPhase: apisv1alpha1.APIBindingPhaseBound,
+			apiBinding: rebinding.DeepCopy().
+				WithBoundResources(
+					new(boundAPIResourceBuilder).
+						WithGroupResource("kcp.dev", "widgets").
+						WithSchema("today.widgets.kcp.dev", "todaywidgetsuid").
+						WithStorageVersions("v1").
+						BoundAPIResource,
+				).
+				Build(),
+			crdExists:          true,
+			crdEstablished:     true,
+			crdStorageVerions:  []string{"v0", "v1"},
+			wantAPIExportValid: true,
+			wantReady:          true,
+			wantBoundAPIExport: true,
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:    "kcp.dev",
+					Resource: "widgets",
+					Schema: apisv1alpha1.BoundAPIResourceSchema{
+						Name:         "today.widgets.kcp.dev",
+						UID:          "todaywidgetsuid",
+						IdentityHash: "hash1",
+					},
+					StorageVersions: []string{"v1"},
+				},
+			},
+			wantInitialBindingComplete: true,
+		},
+		"resources not selected by the binding are unbound": {
+			wantPhase: apisv1alpha1.APIBindingPhaseBound,
+			apiBinding: rebinding.DeepCopy().
+				WithResources(apisv1alpha1.GroupResource{Group: "kcp.dev", Resource: "gadgets"}).
+				Build(),
+			wantAPIExportValid:         true,
+			wantReady:                  true,
+			wantBoundAPIExport:         true,
+			wantBoundResources:         nil,
+			wantInitialBindingComplete: true,
+			wantResourcesNotExported:   true,
+		},
+		"permission claims of the APIExport are pending": {
+			wantPhase:  apisv1alpha1.APIBindingPhasePermissionClaimsPending,
+			apiBinding: binding.DeepCopy().WithWorkspaceReference("org:some-workspace", "claims").Build(),
+			wantPermissionClaims: []apisv1alpha1.PermissionClaimStatus{
+				{
+					PermissionClaim: apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+					State:           apisv1alpha1.ClaimPending,
+				},
+			},
+			crdExists:          true,
+			crdEstablished:     true,
+			crdStorageVerions:  []string{"v1"},
 			wantAPIExportValid: true,
 			wantReady:          true,
 			wantBoundAPIExport: true,
@@ -358,10 +505,69 @@ func TestReconcileBinding(t *testing.T) {
 						UID:          "todaywidgetsuid",
 						IdentityHash: "hash1",
 					},
-					StorageVersions: []string{"v0", "v1", "v2"},
+					StorageVersions: []string{"v1"},
+				},
+			},
+			wantInitialBindingComplete: true,
+		},
+		"permission claims of the APIExport are decided": {
+			wantPhase: apisv1alpha1.APIBindingPhaseBound,
+			apiBinding: binding.DeepCopy().
+				WithWorkspaceReference("org:some-workspace", "claims").
+				WithPermissionClaims(apisv1alpha1.AcceptablePermissionClaim{
+					PermissionClaim: apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+					State:           apisv1alpha1.ClaimRejected,
+				}).
+				Build(),
+			wantPermissionClaims: []apisv1alpha1.PermissionClaimStatus{
+				{
+					PermissionClaim: apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+					State:           apisv1alpha1.ClaimRejected,
+				},
+			},
+			crdExists:          true,
+			crdEstablished:     true,
+			crdStorageVerions:  []string{"v1"},
+			wantAPIExportValid: true,
+			wantReady:          true,
+			wantBoundAPIExport: true,
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:    "kcp.dev",
+					Resource: "widgets",
+					Schema: apisv1alpha1.BoundAPIResourceSchema{
+						Name:         "today.widgets.kcp.dev",
+						UID:          "todaywidgetsuid",
+						IdentityHash: "hash1",
+					},
+					StorageVersions: []string{"v1"},
+				},
+			},
+			wantInitialBindingComplete: true,
+		},
+		"resources selected by the binding are bound": {
+			wantPhase: apisv1alpha1.APIBindingPhaseBound,
+			apiBinding: binding.DeepCopy().
+				WithResources(apisv1alpha1.GroupResource{Group: "kcp.dev", Resource: "widgets"}).
+				Build(),
+			crdExists:          true,
+			crdEstablished:     true,
+			crdStorageVerions:  []string{"v1"},
+			wantAPIExportValid: true,
+			wantReady:          true,
+			wantBoundAPIExport: true,
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:    "kcp.dev",
+					Resource: "widgets",
+					Schema: apisv1alpha1.BoundAPIResourceSchema{
+						Name:         "today.widgets.kcp.dev",
+						UID:          "todaywidgetsuid",
+						IdentityHash: "hash1",
+					},
+					StorageVersions: []string{"v1"},
 				},
 			},
-			wantPhaseBound:             true,
 			wantInitialBindingComplete: true,
 		},
 	}
@@ -407,6 +613,21 @@ func TestReconcileBinding(t *testing.T) {
 					},
 					Status: apisv1alpha1.APIExportStatus{IdentityHash: "hash3"},
 				},
+				"claims": {
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey: "some-export",
+						},
+						Name: "some-workspace",
+					},
+					Spec: apisv1alpha1.APIExportSpec{
+						LatestResourceSchemas: []string{"today.widgets.kcp.dev"},
+						PermissionClaims: []apisv1alpha1.PermissionClaim{
+							{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}},
+						},
+					},
+					Status: apisv1alpha1.APIExportStatus{IdentityHash: "hash1"},
+				},
 				"no-identity-hash": {
 					ObjectMeta: metav1.ObjectMeta{
 						Annotations: map[string]string{
@@ -450,6 +671,21 @@ func TestReconcileBinding(t *testing.T) {
 					require.Equal(t, "org:some-workspace", clusterName.String())
 					return apiExports[name], tc.getAPIExportError
 				},
+				getAPIExportsByIdentity: func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+					resolved := func(clusterName string) *apisv1alpha1.APIExport {
+						apiExport := apiExports["some-export"].DeepCopy()
+						apiExport.Name = "some-export"
+						apiExport.Annotations = map[string]string{logicalcluster.AnnotationKey: clusterName}
+						return apiExport
+					}
+					switch identityHash {
+					case "hash1":
+						return []*apisv1alpha1.APIExport{resolved("org:some-workspace")}, nil
+					case "ambiguous":
+						return []*apisv1alpha1.APIExport{resolved("org:some-workspace"), resolved("org:other-workspace")}, nil
+					}
+					return nil, nil
+				},
 				getAPIResourceSchema: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
 					if tc.getAPIResourceSchemaError != nil {
 						return nil, tc.getAPIResourceSchemaError
@@ -506,7 +742,7 @@ func TestReconcileBinding(t *testing.T) {
 				crdIndexer:        cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
 			}
 
-			if err := c.crdIndexer.AddIndexers(cache.Indexers{indexByWorkspace: indexByWorkspaceFunc}); err != nil {
+			if err := c.crdIndexer.AddIndexers(indexers.Indexers(indexers.ByLogicalCluster)); err != nil {
 				t.Fatal(err)
 			}
 
@@ -567,8 +803,14 @@ func TestReconcileBinding(t *testing.T) {
 			}
 
 			if tc.wantBoundAPIExport {
+				want := tc.apiBinding.Spec.Reference
+				if want.Identity != nil {
+					want = apisv1alpha1.ExportReference{
+						Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "org:some-workspace", ExportName: "some-export"},
+					}
+				}
 				require.NotNil(t, tc.apiBinding.Status.BoundAPIExport)
-				require.Equal(t, tc.apiBinding.Spec.Reference, *tc.apiBinding.Status.BoundAPIExport)
+				require.Equal(t, want, *tc.apiBinding.Status.BoundAPIExport)
 			} else {
 				require.Nil(t, tc.apiBinding.Status.BoundAPIExport)
 			}
@@ -577,10 +819,22 @@ func TestReconcileBinding(t *testing.T) {
 				requireConditionMatches(t, tc.apiBinding, conditions.TrueCondition(apisv1alpha1.InitialBindingCompleted))
 			}
 
-			if tc.wantPhaseBound {
-				require.Equal(t, apisv1alpha1.APIBindingPhaseBound, tc.apiBinding.Status.Phase)
-			} else {
-				require.Equal(t, apisv1alpha1.APIBindingPhaseBinding, tc.apiBinding.Status.Phase)
+			require.Equal(t, tc.wantPhase, tc.apiBinding.Status.Phase)
+			if tc.wantSchemas != nil {
+				require.Equal(t, tc.wantSchemas, tc.apiBinding.Status.Schemas)
+			}
+			if tc.wantPermissionClaims != nil {
+				require.Equal(t, tc.wantPermissionClaims, tc.apiBinding.Status.PermissionClaims)
+			}
+
+			if tc.wantResourcesNotExported {
+				requireConditionMatches(t, tc.apiBinding, &conditionsv1alpha1.Condition{
+					Type:     apisv1alpha1.BindingUpToDate,
+					Status:   corev1.ConditionFalse,
+					Severity: conditionsv1alpha1.ConditionSeverityWarning,
+					Reason:   apisv1alpha1.ResourcesNotExportedReason,
+					Message:  "gadgets.kcp.dev",
+				})
 			}
 
 			require.Len(t, tc.apiBinding.Status.BoundResources, len(tc.wantBoundResources), "unexpected bound resources")
@@ -642,6 +896,9 @@ func TestReconcileBound(t *testing.T) {
 		wantPhase             string
 		wantError             bool
 		wantAPIExportNotFound bool
+		wantUpgradePending    bool
+		wantBreakingChanges   bool
+		wantEnqueueAfter      time.Duration
 	}{
 		"rebinding when referenced export changes": {
 			apiBinding: bound.DeepCopy().
@@ -650,6 +907,13 @@ func TestReconcileBound(t *testing.T) {
 			wantRebinding: true,
 			wantPhase:     "Bound",
 		},
+		"rebinding when identity resolves to a moved export": {
+			apiBinding: bound.DeepCopy().
+				WithIdentityReference("moved").
+				Build(),
+			wantRebinding: true,
+			wantPhase:     "Bound",
+		},
 		"rebinding when export changes what it's exporting": {
 			apiBinding: bound.Build(),
 			apiExport: &apisv1alpha1.APIExport{
@@ -698,6 +962,101 @@ func TestReconcileBound(t *testing.T) {
 			wantRebinding: true,
 			wantPhase:     "Bound",
 		},
+		"manual upgrade policy holds back unapproved export generation": {
+			apiBinding: bound.DeepCopy().
+				WithUpgradePolicy(&apisv1alpha1.UpgradePolicy{Type: apisv1alpha1.UpgradePolicyManual, ApprovedGeneration: 1}).
+				Build(),
+			apiExport:          newExportWithGeneration(2, "someresources", "otherresources"),
+			apiResourceSchemas: newerSchemas,
+			wantRebinding:      false,
+			wantPhase:          "Bound",
+			wantUpgradePending: true,
+		},
+		"manual upgrade policy rebinds approved export generation": {
+			apiBinding: bound.DeepCopy().
+				WithUpgradePolicy(&apisv1alpha1.UpgradePolicy{Type: apisv1alpha1.UpgradePolicyManual, ApprovedGeneration: 2}).
+				Build(),
+			apiExport:          newExportWithGeneration(2, "someresources", "otherresources"),
+			apiResourceSchemas: newerSchemas,
+			wantRebinding:      true,
+			wantPhase:          "Bound",
+		},
+		"scheduled upgrade policy holds back new export generation until the delay passed": {
+			apiBinding: bound.DeepCopy().
+				WithUpgradePolicy(&apisv1alpha1.UpgradePolicy{Type: apisv1alpha1.UpgradePolicyScheduled, Delay: &metav1.Duration{Duration: time.Hour}}).
+				WithUpgradeAvailableSince(2, now.Add(-time.Minute)).
+				Build(),
+			apiExport:          newExportWithGeneration(2, "someresources", "otherresources"),
+			apiResourceSchemas: newerSchemas,
+			wantRebinding:      false,
+			wantPhase:          "Bound",
+			wantUpgradePending: true,
+			wantEnqueueAfter:   59 * time.Minute,
+		},
+		"scheduled upgrade policy restarts the delay when the export changes again": {
+			apiBinding: bound.DeepCopy().
+				WithUpgradePolicy(&apisv1alpha1.UpgradePolicy{Type: apisv1alpha1.UpgradePolicyScheduled, Delay: &metav1.Duration{Duration: time.Hour}}).
+				WithUpgradeAvailableSince(2, now.Add(-2*time.Hour)).
+				Build(),
+			apiExport:          newExportWithGeneration(3, "someresources", "otherresources"),
+			apiResourceSchemas: newerSchemas,
+			wantRebinding:      false,
+			wantPhase:          "Bound",
+			wantUpgradePending: true,
+			wantEnqueueAfter:   time.Hour,
+		},
+		"scheduled upgrade policy rebinds after the delay passed": {
+			apiBinding: bound.DeepCopy().
+				WithUpgradePolicy(&apisv1alpha1.UpgradePolicy{Type: apisv1alpha1.UpgradePolicyScheduled, Delay: &metav1.Duration{Duration: time.Hour}}).
+				WithUpgradeAvailableSince(2, now.Add(-2*time.Hour)).
+				Build(),
+			apiExport:          newExportWithGeneration(2, "someresources", "otherresources"),
+			apiResourceSchemas: newerSchemas,
+			wantRebinding:      true,
+			wantPhase:          "Bound",
+		},
+		"rebinding when selected resources change regardless of upgrade policy": {
+			apiBinding: bound.DeepCopy().
+				WithUpgradePolicy(&apisv1alpha1.UpgradePolicy{Type: apisv1alpha1.UpgradePolicyManual}).
+				WithResources(apisv1alpha1.GroupResource{Group: "mygroup", Resource: "someresources"}).
+				Build(),
+			apiExport: newExportWithGeneration(0, "someresources", "otherresources"),
+			apiResourceSchemas: map[string]*apisv1alpha1.APIResourceSchema{
+				"someresources": {
+					ObjectMeta: metav1.ObjectMeta{Name: "someresources", UID: "uid1"},
+					Spec: apisv1alpha1.APIResourceSchemaSpec{
+						Group: "mygroup",
+						Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "someresources"},
+					},
+				},
+				"otherresources": {
+					ObjectMeta: metav1.ObjectMeta{Name: "otherresources", UID: "uid2"},
+					Spec: apisv1alpha1.APIResourceSchemaSpec{
+						Group: "anothergroup",
+						Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "otherresources"},
+					},
+				},
+			},
+			wantRebinding: true,
+			wantPhase:     "Bound",
+		},
+		"incompatible schema changes block the upgrade": {
+			apiBinding:          bound.Build(),
+			apiExport:           newExportWithGeneration(2, "someresources", "otherresources"),
+			apiResourceSchemas:  incompatibleSchemas,
+			wantRebinding:       false,
+			wantPhase:           "Bound",
+			wantBreakingChanges: true,
+		},
+		"incompatible schema changes are bound with the allow-breaking-changes annotation": {
+			apiBinding: bound.DeepCopy().
+				WithAnnotation(apisv1alpha1.AnnotationAllowBreakingChangesKey, "true").
+				Build(),
+			apiExport:          newExportWithGeneration(2, "someresources", "otherresources"),
+			apiResourceSchemas: incompatibleSchemas,
+			wantRebinding:      true,
+			wantPhase:          "Bound",
+		},
 		"APIExportValid warning condition set when error getting previously bound APIExport": {
 			apiBinding:            bound.Build(),
 			getAPIExportError:     apierrors.NewNotFound(schema.GroupResource{}, "foo"),
@@ -708,12 +1067,22 @@ func TestReconcileBound(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
+			var enqueuedAfter time.Duration
 			c := &controller{
 				getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
 					require.Equal(t, "org:some-workspace", clusterName.String())
 					require.Equal(t, "some-export", name)
 					return tc.apiExport, tc.getAPIExportError
 				},
+				getAPIExportsByIdentity: func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+					require.Equal(t, "moved", identityHash)
+					return []*apisv1alpha1.APIExport{{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "some-export",
+							Annotations: map[string]string{logicalcluster.AnnotationKey: "org:new-workspace"},
+						},
+					}}, nil
+				},
 				getAPIResourceSchema: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
 					require.Equal(t, "org:some-workspace", clusterName.String())
 					return tc.apiResourceSchemas[name], nil
@@ -722,6 +1091,19 @@ func TestReconcileBound(t *testing.T) {
 					// TODO: Add tests for  reconcile permisonclaims, this to is to prevent a nil panic in reconciling permission claims.
 					return []*apisv1alpha1.APIBinding{}, nil
 				},
+				getCRD: func(clusterName logicalcluster.Name, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+					require.Equal(t, ShadowWorkspaceName, clusterName)
+					if crd, found := boundCRDs[name]; found {
+						return crd, nil
+					}
+					return nil, apierrors.NewNotFound(apiextensionsv1.Resource("customresourcedefinitions"), name)
+				},
+				now: func() time.Time {
+					return now
+				},
+				enqueueAfter: func(_ *apisv1alpha1.APIBinding, duration time.Duration) {
+					enqueuedAfter = duration
+				},
 			}
 
 			rebind, err := c.reconcileBound(context.Background(), tc.apiBinding)
@@ -742,10 +1124,321 @@ func TestReconcileBound(t *testing.T) {
 					Reason:   apisv1alpha1.APIExportNotFoundReason,
 				})
 			}
+
+			require.Equal(t, tc.wantEnqueueAfter, enqueuedAfter)
+			if tc.wantUpgradePending {
+				requireConditionMatches(t, tc.apiBinding, &conditionsv1alpha1.Condition{
+					Type:     apisv1alpha1.BindingUpToDate,
+					Status:   corev1.ConditionFalse,
+					Severity: conditionsv1alpha1.ConditionSeverityInfo,
+					Reason:   apisv1alpha1.UpgradePendingReason,
+				})
+				require.Equal(t, tc.apiExport.Generation, tc.apiBinding.Status.AvailableExportGeneration)
+				require.NotNil(t, tc.apiBinding.Status.UpgradeAvailableSince)
+			}
+			if tc.wantBreakingChanges {
+				requireConditionMatches(t, tc.apiBinding, &conditionsv1alpha1.Condition{
+					Type:     apisv1alpha1.BindingUpToDate,
+					Status:   corev1.ConditionFalse,
+					Severity: conditionsv1alpha1.ConditionSeverityWarning,
+					Reason:   apisv1alpha1.BreakingChangesReason,
+				})
+				require.NotNil(t, tc.apiBinding.Status.SchemaCompatibility)
+				require.NotEmpty(t, tc.apiBinding.Status.SchemaCompatibility.Changes)
+			}
 		})
 	}
 }
 
+var (
+	now = time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	newerSchemas = map[string]*apisv1alpha1.APIResourceSchema{
+		"someresources": {
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "someresources",
+				UID:  "uid1",
+			},
+		},
+		"otherresources": {
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "otherresources",
+				UID:  "newuid",
+			},
+		},
+	}
+)
+
+var (
+	// boundCRDs are the bound CRDs of the bound fixture by schema UID.
+	boundCRDs = map[string]*apiextensionsv1.CustomResourceDefinition{
+		"uid2": {
+			ObjectMeta: metav1.ObjectMeta{Name: "uid2"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: "anothergroup",
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{
+						Name:   "v1",
+						Served: true,
+						Schema: &apiextensionsv1.CustomResourceValidation{
+							OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+								Type: "object",
+								Properties: map[string]apiextensionsv1.JSONSchemaProps{
+									"spec": {
+										Type: "object",
+										Properties: map[string]apiextensionsv1.JSONSchemaProps{
+											"replicas": {Type: "integer"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// incompatibleSchemas remove the replicas field of otherresources bound by the bound fixture.
+	incompatibleSchemas = map[string]*apisv1alpha1.APIResourceSchema{
+		"someresources": {
+			ObjectMeta: metav1.ObjectMeta{Name: "someresources", UID: "uid1"},
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group: "mygroup",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "someresources"},
+			},
+		},
+		"otherresources": {
+			ObjectMeta: metav1.ObjectMeta{Name: "otherresources", UID: "newuid"},
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group: "anothergroup",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "otherresources"},
+				Versions: []apisv1alpha1.APIResourceVersion{
+					{
+						Name:   "v1",
+						Served: true,
+						Schema: runtime.RawExtension{
+							Raw: []byte(`{"type":"object","properties":{"spec":{"type":"object"}}}`),
+						},
+					},
+				},
+			},
+		},
+	}
+)
+
+func newExportWithGeneration(generation int64, latestResourceSchemas ...string) *apisv1alpha1.APIExport {
+	return &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Generation: generation,
+		},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: latestResourceSchemas,
+		},
+	}
+}
+
+func TestSetAPIExportDeprecatedCondition(t *testing.T) {
+	sunset := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	schemaWithVersions := func(versions ...apisv1alpha1.APIResourceVersion) *apisv1alpha1.APIResourceSchema {
+		return &apisv1alpha1.APIResourceSchema{
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group:    "kcp.dev",
+				Names:    apiextensionsv1.CustomResourceDefinitionNames{Kind: "Widget"},
+				Versions: versions,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		apiBinding  *apisv1alpha1.APIBinding
+		apiExport   *apisv1alpha1.APIExport
+		schemas     []*apisv1alpha1.APIResourceSchema
+		wantReason  string
+		wantMessage string
+	}{
+		"nothing deprecated": {
+			apiBinding: bound.Build(),
+			apiExport:  &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "some-export"}},
+			schemas:    []*apisv1alpha1.APIResourceSchema{schemaWithVersions(apisv1alpha1.APIResourceVersion{Name: "v1", Served: true})},
+		},
+		"deprecated export with sunset date": {
+			apiBinding: bound.Build(),
+			apiExport: &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-export"},
+				Spec:       apisv1alpha1.APIExportSpec{Deprecated: true, SunsetDate: &sunset},
+			},
+			wantReason:  apisv1alpha1.DeprecatedReason,
+			wantMessage: "APIExport org:some-workspace|some-export is deprecated (sunset date 2023-01-01T00:00:00Z)",
+		},
+		"deprecated export with custom warning": {
+			apiBinding: bound.Build(),
+			apiExport: &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-export"},
+				Spec:       apisv1alpha1.APIExportSpec{Deprecated: true, DeprecationWarning: pointer.String("use other-export instead")},
+			},
+			wantReason:  apisv1alpha1.DeprecatedReason,
+			wantMessage: "use other-export instead",
+		},
+		"deprecated served schema version": {
+			apiBinding: bound.Build(),
+			apiExport:  &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "some-export"}},
+			schemas: []*apisv1alpha1.APIResourceSchema{schemaWithVersions(
+				apisv1alpha1.APIResourceVersion{Name: "v1alpha1", Served: true, Deprecated: true},
+				apisv1alpha1.APIResourceVersion{Name: "v1alpha2", Served: false, Deprecated: true},
+				apisv1alpha1.APIResourceVersion{Name: "v1", Served: true},
+			)},
+			wantReason:  apisv1alpha1.VersionsDeprecatedReason,
+			wantMessage: "kcp.dev/v1alpha1 Widget is deprecated",
+		},
+		"deprecated export supersedes deprecated versions": {
+			apiBinding: bound.Build(),
+			apiExport: &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-export"},
+				Spec:       apisv1alpha1.APIExportSpec{Deprecated: true},
+			},
+			schemas: []*apisv1alpha1.APIResourceSchema{schemaWithVersions(
+				apisv1alpha1.APIResourceVersion{Name: "v1alpha1", Served: true, Deprecated: true},
+				apisv1alpha1.APIResourceVersion{Name: "v1", Served: true},
+			)},
+			wantReason:  apisv1alpha1.DeprecatedReason,
+			wantMessage: "APIExport org:some-workspace|some-export is deprecated",
+		},
+		"condition removed when no longer deprecated": {
+			apiBinding: func() *apisv1alpha1.APIBinding {
+				b := bound.Build()
+				conditions.MarkTrue(b, apisv1alpha1.APIExportDeprecated)
+				return b
+			}(),
+			apiExport: &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{Name: "some-export"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			setAPIExportDeprecatedCondition(tc.apiBinding, logicalcluster.New("org:some-workspace"), tc.apiExport, tc.schemas)
+
+			if tc.wantReason == "" {
+				require.Nil(t, conditions.Get(tc.apiBinding, apisv1alpha1.APIExportDeprecated))
+				return
+			}
+			requireConditionMatches(t, tc.apiBinding, &conditionsv1alpha1.Condition{
+				Type:     apisv1alpha1.APIExportDeprecated,
+				Status:   corev1.ConditionTrue,
+				Severity: conditionsv1alpha1.ConditionSeverityWarning,
+				Reason:   tc.wantReason,
+				Message:  tc.wantMessage,
+			})
+		})
+	}
+}
+
+func TestSetMaximalPermissionPolicyCondition(t *testing.T) {
+	withPolicy := func(policy *apisv1alpha1.MaximalPermissionPolicy) *apisv1alpha1.APIExport {
+		return &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-export"},
+			Spec:       apisv1alpha1.APIExportSpec{MaximalPermissionPolicy: policy},
+		}
+	}
+	localPolicy := &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}}
+
+	tests := map[string]struct {
+		apiBinding        *apisv1alpha1.APIBinding
+		apiExport         *apisv1alpha1.APIExport
+		hasPolicyBindings bool
+		lookupError       error
+		wantCondition     *conditionsv1alpha1.Condition
+		wantError         bool
+	}{
+		"no policy": {
+			apiBinding: bound.Build(),
+			apiExport:  withPolicy(nil),
+		},
+		"condition removed when the policy is dropped": {
+			apiBinding: func() *apisv1alpha1.APIBinding {
+				b := bound.Build()
+				conditions.MarkTrue(b, apisv1alpha1.MaximalPermissionPolicyValid)
+				return b
+			}(),
+			apiExport: withPolicy(nil),
+		},
+		"local policy with policy bindings": {
+			apiBinding:        bound.Build(),
+			apiExport:         withPolicy(localPolicy),
+			hasPolicyBindings: true,
+			wantCondition: &conditionsv1alpha1.Condition{
+				Type:   apisv1alpha1.MaximalPermissionPolicyValid,
+				Status: corev1.ConditionTrue,
+			},
+		},
+		"local policy without policy bindings": {
+			apiBinding: bound.Build(),
+			apiExport:  withPolicy(localPolicy),
+			wantCondition: &conditionsv1alpha1.Condition{
+				Type:     apisv1alpha1.MaximalPermissionPolicyValid,
+				Status:   corev1.ConditionFalse,
+				Severity: conditionsv1alpha1.ConditionSeverityWarning,
+				Reason:   apisv1alpha1.NoMaximalPermissionPolicyBindingsReason,
+				Message:  `Workspace org:some-workspace has no RBAC bindings for "apis.kcp.dev:binding:" prefixed users or groups, requests to the resources bound from APIExport some-export are denied`,
+			},
+		},
+		"unsupported policy": {
+			apiBinding: bound.Build(),
+			apiExport:  withPolicy(&apisv1alpha1.MaximalPermissionPolicy{}),
+			wantCondition: &conditionsv1alpha1.Condition{
+				Type:     apisv1alpha1.MaximalPermissionPolicyValid,
+				Status:   corev1.ConditionFalse,
+				Severity: conditionsv1alpha1.ConditionSeverityError,
+				Reason:   apisv1alpha1.UnsupportedMaximalPermissionPolicyReason,
+			},
+		},
+		"policy lookup error": {
+			apiBinding:  bound.Build(),
+			apiExport:   withPolicy(localPolicy),
+			lookupError: errors.New("boom"),
+			wantCondition: &conditionsv1alpha1.Condition{
+				Type:     apisv1alpha1.MaximalPermissionPolicyValid,
+				Status:   corev1.ConditionFalse,
+				Severity: conditionsv1alpha1.ConditionSeverityError,
+				Reason:   apisv1alpha1.InternalErrorReason,
+			},
+			wantError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &controller{
+				hasMaximalPermissionPolicyBindings: func(clusterName logicalcluster.Name) (bool, error) {
+					require.Equal(t, "org:some-workspace", clusterName.String())
+					return tc.hasPolicyBindings, tc.lookupError
+				},
+			}
+
+			err := c.setMaximalPermissionPolicyCondition(tc.apiBinding, logicalcluster.New("org:some-workspace"), tc.apiExport)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.wantCondition == nil {
+				require.Nil(t, conditions.Get(tc.apiBinding, apisv1alpha1.MaximalPermissionPolicyValid))
+				return
+			}
+			requireConditionMatches(t, tc.apiBinding, tc.wantCondition)
+		})
+	}
+}
+
+func TestHasMaximalPermissionPolicySubject(t *testing.T) {
+	require.False(t, hasMaximalPermissionPolicySubject(nil))
+	require.False(t, hasMaximalPermissionPolicySubject([]rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "user-1"}}))
+	require.False(t, hasMaximalPermissionPolicySubject([]rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "apis.kcp.dev:binding:sa", Namespace: "default"}}))
+	require.True(t, hasMaximalPermissionPolicySubject([]rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "user-1"}, {Kind: rbacv1.UserKind, Name: "apis.kcp.dev:binding:user-1"}}))
+	require.True(t, hasMaximalPermissionPolicySubject([]rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "apis.kcp.dev:binding:system:authenticated"}}))
+}
+
 func TestCRDFromAPIResourceSchema(t *testing.T) {
 	tests := map[string]struct {
 		schema  *apisv1alpha1.APIResourceSchema
@@ -931,6 +1624,248 @@ func TestCRDFromAPIResourceSchema(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		"webhook conversion": {
+			schema: &apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "my-cluster",
+					},
+					Name: "my-name",
+					UID:  types.UID("my-uuid"),
+				},
+				Spec: apisv1alpha1.APIResourceSchemaSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{
+						Plural:   "widgets",
+						Singular: "widget",
+						Kind:     "Widget",
+						ListKind: "WidgetList",
+					},
+					Scope: apiextensionsv1.ClusterScoped,
+					Versions: []apisv1alpha1.APIResourceVersion{
+						{
+							Name:    "v1",
+							Served:  true,
+							Storage: true,
+							Schema: runtime.RawExtension{
+								Raw: []byte(`{"type":"object"}`),
+							},
+						},
+					},
+					Conversion: &apisv1alpha1.CustomResourceConversion{
+						Strategy: apisv1alpha1.WebhookConverter,
+						Webhook: &apisv1alpha1.WebhookConversion{
+							ClientConfig: apisv1alpha1.WebhookClientConfig{
+								URL:      "https://converter.example.com/convert",
+								CABundle: []byte("ca"),
+							},
+							ConversionReviewVersions: []string{"v1"},
+						},
+					},
+				},
+			},
+			want: &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-uuid",
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey:            ShadowWorkspaceName.String(),
+						apisv1alpha1.AnnotationBoundCRDKey:      "",
+						apisv1alpha1.AnnotationSchemaClusterKey: "my-cluster",
+						apisv1alpha1.AnnotationSchemaNameKey:    "my-name",
+					},
+				},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{
+						Plural:   "widgets",
+						Singular: "widget",
+						Kind:     "Widget",
+						ListKind: "WidgetList",
+					},
+					Scope: apiextensionsv1.ClusterScoped,
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+						{
+							Name:    "v1",
+							Served:  true,
+							Storage: true,
+							Schema: &apiextensionsv1.CustomResourceValidation{
+								OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+									Type: "object",
+								},
+							},
+							Subresources: &apiextensionsv1.CustomResourceSubresources{},
+						},
+					},
+					Conversion: &apiextensionsv1.CustomResourceConversion{
+						Strategy: apiextensionsv1.WebhookConverter,
+						Webhook: &apiextensionsv1.WebhookConversion{
+							ClientConfig: &apiextensionsv1.WebhookClientConfig{
+								URL:      pointer.StringPtr("https://converter.example.com/convert"),
+								CABundle: []byte("ca"),
+							},
+							ConversionReviewVersions: []string{"v1"},
+						},
+					},
+				},
+			},
+		},
+		"validation rules and defaults": {
+			schema: &apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "my-cluster",
+					},
+					Name: "my-name",
+					UID:  types.UID("my-uuid"),
+				},
+				Spec: apisv1alpha1.APIResourceSchemaSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{
+						Plural:   "widgets",
+						Singular: "widget",
+						Kind:     "Widget",
+						ListKind: "WidgetList",
+					},
+					Scope: apiextensionsv1.ClusterScoped,
+					Versions: []apisv1alpha1.APIResourceVersion{
+						{
+							Name:    "v1",
+							Served:  true,
+							Storage: true,
+							Schema: runtime.RawExtension{
+								Raw: []byte(`{"type":"object","x-kubernetes-validations":[{"rule":"self.replicas <= 10","message":"too many replicas"}],"properties":{"replicas":{"type":"integer","default":1}}}`),
+							},
+						},
+					},
+				},
+			},
+			want: &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-uuid",
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey:            ShadowWorkspaceName.String(),
+						apisv1alpha1.AnnotationBoundCRDKey:      "",
+						apisv1alpha1.AnnotationSchemaClusterKey: "my-cluster",
+						apisv1alpha1.AnnotationSchemaNameKey:    "my-name",
+					},
+				},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{
+						Plural:   "widgets",
+						Singular: "widget",
+						Kind:     "Widget",
+						ListKind: "WidgetList",
+					},
+					Scope: apiextensionsv1.ClusterScoped,
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+						{
+							Name:    "v1",
+							Served:  true,
+							Storage: true,
+							Schema: &apiextensionsv1.CustomResourceValidation{
+								OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+									Type: "object",
+									XValidations: apiextensionsv1.ValidationRules{
+										{Rule: "self.replicas <= 10", Message: "too many replicas"},
+									},
+									Properties: map[string]apiextensionsv1.JSONSchemaProps{
+										"replicas": {
+											Type:    "integer",
+											Default: &apiextensionsv1.JSON{Raw: []byte(`1`)},
+										},
+									},
+								},
+							},
+							Subresources: &apiextensionsv1.CustomResourceSubresources{},
+						},
+					},
+				},
+			},
+		},
+		"custom subresources": {
+			schema: &apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "my-cluster",
+					},
+					Name: "my-name",
+					UID:  types.UID("my-uuid"),
+				},
+				Spec: apisv1alpha1.APIResourceSchemaSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{
+						Plural:   "widgets",
+						Singular: "widget",
+						Kind:     "Widget",
+						ListKind: "WidgetList",
+					},
+					Scope: apiextensionsv1.ClusterScoped,
+					Versions: []apisv1alpha1.APIResourceVersion{
+						{
+							Name:    "v1",
+							Served:  true,
+							Storage: true,
+							Schema: runtime.RawExtension{
+								Raw: []byte(`{"type":"object","properties":{"spec":{"type":"object"}}}`),
+							},
+							CustomSubresources: []apisv1alpha1.CustomSubresource{
+								{
+									Name:  "approve",
+									Verbs: []string{"update"},
+									Schema: runtime.RawExtension{
+										Raw: []byte(`{"type":"object","properties":{"approver":{"type":"string"}}}`),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-uuid",
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey:            ShadowWorkspaceName.String(),
+						apisv1alpha1.AnnotationBoundCRDKey:      "",
+						apisv1alpha1.AnnotationSchemaClusterKey: "my-cluster",
+						apisv1alpha1.AnnotationSchemaNameKey:    "my-name",
+					},
+				},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{
+						Plural:   "widgets",
+						Singular: "widget",
+						Kind:     "Widget",
+						ListKind: "WidgetList",
+					},
+					Scope: apiextensionsv1.ClusterScoped,
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+						{
+							Name:    "v1",
+							Served:  true,
+							Storage: true,
+							Schema: &apiextensionsv1.CustomResourceValidation{
+								OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]apiextensionsv1.JSONSchemaProps{
+										"spec": {Type: "object"},
+										"approve": {
+											Type: "object",
+											Properties: map[string]apiextensionsv1.JSONSchemaProps{
+												"approver": {Type: "string"},
+											},
+										},
+									},
+								},
+							},
+							Subresources: &apiextensionsv1.CustomResourceSubresources{},
+						},
+					},
+				},
+			},
+		},
 		"error when schema is invalid": {
 			schema: &apisv1alpha1.APIResourceSchema{
 				Spec: apisv1alpha1.APIResourceSchemaSpec{
@@ -991,6 +1926,14 @@ func (b *bindingBuilder) WithClusterName(clusterName string) *bindingBuilder {
 	return b
 }
 
+func (b *bindingBuilder) WithAnnotation(key, value string) *bindingBuilder {
+	if b.Annotations == nil {
+		b.Annotations = make(map[string]string)
+	}
+	b.Annotations[key] = value
+	return b
+}
+
 func (b *bindingBuilder) WithName(name string) *bindingBuilder {
 	b.Name = name
 	return b
@@ -1009,6 +1952,14 @@ func (b *bindingBuilder) WithWorkspaceReference(path, exportName string) *bindin
 	return b
 }
 
+func (b *bindingBuilder) WithIdentityReference(identityHash string) *bindingBuilder {
+	b.Spec.Reference.Workspace = nil
+	b.Spec.Reference.Identity = &apisv1alpha1.IdentityExportReference{
+		IdentityHash: identityHash,
+	}
+	return b
+}
+
 func (b *bindingBuilder) WithPhase(phase apisv1alpha1.APIBindingPhaseType) *bindingBuilder {
 	b.Status.Phase = phase
 	return b
@@ -1024,6 +1975,27 @@ func (b *bindingBuilder) WithBoundAPIExport(path, exportName string) *bindingBui
 	return b
 }
 
+func (b *bindingBuilder) WithUpgradePolicy(policy *apisv1alpha1.UpgradePolicy) *bindingBuilder {
+	b.Spec.UpgradePolicy = policy
+	return b
+}
+
+func (b *bindingBuilder) WithPermissionClaims(claims ...apisv1alpha1.AcceptablePermissionClaim) *bindingBuilder {
+	b.Spec.PermissionClaims = claims
+	return b
+}
+
+func (b *bindingBuilder) WithResources(resources ...apisv1alpha1.GroupResource) *bindingBuilder {
+	b.Spec.Resources = resources
+	return b
+}
+
+func (b *bindingBuilder) WithUpgradeAvailableSince(generation int64, since time.Time) *bindingBuilder {
+	b.Status.AvailableExportGeneration = generation
+	b.Status.UpgradeAvailableSince = &metav1.Time{Time: since}
+	return b
+}
+
 func (b *bindingBuilder) WithBoundResources(boundResources ...apisv1alpha1.BoundAPIResource) *bindingBuilder {
 	b.Status.BoundResources = boundResources
 	return b
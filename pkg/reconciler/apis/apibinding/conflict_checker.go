@@ -27,6 +27,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 // byUID implements sort.Interface based on the UID field of CustomResourceDefinition
@@ -62,12 +63,14 @@ func (ncc *conflictChecker) getBoundCRDs(apiBindingToExclude *apisv1alpha1.APIBi
 			continue
 		}
 
-		apiExportClusterName, err := getAPIExportClusterName(apiBinding)
-		if err != nil {
-			return err
+		workspaceRef := apiBinding.ExportWorkspaceReference()
+		if workspaceRef == nil {
+			// not resolved yet, hence nothing is bound
+			continue
 		}
 
-		apiExport, err := ncc.getAPIExport(apiExportClusterName, apiBinding.Spec.Reference.Workspace.ExportName)
+		apiExportClusterName := logicalcluster.New(workspaceRef.Path)
+		apiExport, err := ncc.getAPIExport(apiExportClusterName, workspaceRef.ExportName)
 		if err != nil {
 			return err
 		}
@@ -118,7 +121,7 @@ func (ncc *conflictChecker) checkForConflicts(crd *apiextensionsv1.CustomResourc
 
 func (ncc *conflictChecker) gvrConflict(crd *apiextensionsv1.CustomResourceDefinition, apiBinding *apisv1alpha1.APIBinding) error {
 	bindingClusterName := logicalcluster.From(apiBinding)
-	rawBindingClusterCRDs, err := ncc.crdIndexer.ByIndex(indexByWorkspace, bindingClusterName.String())
+	rawBindingClusterCRDs, err := ncc.crdIndexer.ByIndex(indexers.ByLogicalCluster, bindingClusterName.String())
 	if err != nil {
 		return err
 	}
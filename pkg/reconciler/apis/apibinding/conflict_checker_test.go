@@ -30,6 +30,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 func TestNameConflictCheckerGetBoundCRDs(t *testing.T) {
@@ -273,7 +274,7 @@ func TestGVRConflict(t *testing.T) {
 	for _, scenario := range scenarios {
 		t.Run(scenario.name, func(t *testing.T) {
 			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
-			if err := indexer.AddIndexers(cache.Indexers{indexByWorkspace: indexByWorkspaceFunc}); err != nil {
+			if err := indexer.AddIndexers(indexers.Indexers(indexers.ByLogicalCluster)); err != nil {
 				t.Fatal(err)
 			}
 			for _, obj := range scenario.initialCRDs {
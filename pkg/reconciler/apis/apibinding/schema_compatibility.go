@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibinding
+
+import (
+	"fmt"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/schemacompat"
+)
+
+// schemaCompatibilityReport compares the bound CRDs of the APIBinding with the given schemas of the
+// APIExport, and returns the incompatible changes. Resources that are not bound yet are skipped.
+func (c *controller) schemaCompatibilityReport(apiBinding *apisv1alpha1.APIBinding, apiExport *apisv1alpha1.APIExport, schemas []*apisv1alpha1.APIResourceSchema) (*apisv1alpha1.SchemaCompatibilityReport, error) {
+	report := &apisv1alpha1.SchemaCompatibilityReport{
+		ExportGeneration: apiExport.Generation,
+	}
+
+	for _, schema := range schemas {
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if boundResource.Group != schema.Spec.Group || boundResource.Resource != schema.Spec.Names.Plural {
+				continue
+			}
+			if boundResource.Schema.UID == string(schema.UID) {
+				continue
+			}
+
+			crd, err := c.getCRD(ShadowWorkspaceName, boundResource.Schema.UID)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			changes, err := schemaChanges(crd, schema)
+			if err != nil {
+				return nil, err
+			}
+			report.Changes = append(report.Changes, changes...)
+		}
+	}
+
+	return report, nil
+}
+
+// schemaChangeTypes maps the types of the schema diff to the types of the compatibility report.
+var schemaChangeTypes = map[schemacompat.ChangeType]apisv1alpha1.SchemaChangeType{
+	schemacompat.FieldMissing:        apisv1alpha1.SchemaChangeFieldRemoved,
+	schemacompat.TypeChanged:         apisv1alpha1.SchemaChangeTypeChanged,
+	schemacompat.ValidationTightened: apisv1alpha1.SchemaChangeValidationTightened,
+}
+
+// schemaChanges returns the incompatible changes of the served versions of the bound CRD in the schema.
+func schemaChanges(crd *apiextensionsv1.CustomResourceDefinition, schema *apisv1alpha1.APIResourceSchema) ([]apisv1alpha1.SchemaChange, error) {
+	var changes []apisv1alpha1.SchemaChange
+
+	newVersions := map[string]*apisv1alpha1.APIResourceVersion{}
+	for i := range schema.Spec.Versions {
+		if v := &schema.Spec.Versions[i]; v.Served {
+			newVersions[v.Name] = v
+		}
+	}
+
+	for _, oldVersion := range crd.Spec.Versions {
+		if !oldVersion.Served {
+			continue
+		}
+
+		add := func(changeType apisv1alpha1.SchemaChangeType, path, message string) {
+			changes = append(changes, apisv1alpha1.SchemaChange{
+				Group:    schema.Spec.Group,
+				Resource: schema.Spec.Names.Plural,
+				Version:  oldVersion.Name,
+				Type:     changeType,
+				Path:     path,
+				Message:  message,
+			})
+		}
+
+		newVersion, found := newVersions[oldVersion.Name]
+		if !found {
+			add(apisv1alpha1.SchemaChangeVersionRemoved, "", fmt.Sprintf("version %s is not served anymore", oldVersion.Name))
+			continue
+		}
+
+		newProps, err := newVersion.GetSchema()
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema of version %s of APIResourceSchema %s: %w", newVersion.Name, schema.Name, err)
+		}
+		var oldProps *apiextensionsv1.JSONSchemaProps
+		if oldVersion.Schema != nil {
+			oldProps = oldVersion.Schema.OpenAPIV3Schema
+		}
+		for _, change := range schemacompat.Diff(oldProps, newProps) {
+			add(schemaChangeTypes[change.Type], change.Path, change.Message)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Version != changes[j].Version {
+			return changes[i].Version < changes[j].Version
+		}
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibinding
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestSchemaChanges(t *testing.T) {
+	widgetSpec := func(props map[string]apiextensionsv1.JSONSchemaProps, required ...string) *apiextensionsv1.JSONSchemaProps {
+		return &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"spec": {Type: "object", Properties: props, Required: required},
+			},
+		}
+	}
+	oldSpec := widgetSpec(map[string]apiextensionsv1.JSONSchemaProps{
+		"replicas": {Type: "integer", Maximum: pointer.Float64(10)},
+		"name":     {Type: "string", MaxLength: pointer.Int64(63)},
+		"mode":     {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"a"`)}, {Raw: []byte(`"b"`)}}},
+		"tags":     {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+	})
+
+	tests := map[string]struct {
+		oldVersions []string
+		newVersions []string
+		newSpec     *apiextensionsv1.JSONSchemaProps
+		want        []apisv1alpha1.SchemaChange
+	}{
+		"unchanged": {
+			newSpec: oldSpec,
+		},
+		"compatible changes": {
+			newSpec: widgetSpec(map[string]apiextensionsv1.JSONSchemaProps{
+				"replicas": {Type: "integer", Maximum: pointer.Float64(20)},
+				"name":     {Type: "string"},
+				"mode":     {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"a"`)}, {Raw: []byte(`"b"`)}, {Raw: []byte(`"c"`)}}},
+				"tags":     {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+				"paused":   {Type: "boolean"},
+			}),
+		},
+		"incompatible changes": {
+			newSpec: widgetSpec(map[string]apiextensionsv1.JSONSchemaProps{
+				"replicas": {Type: "string"},
+				"name":     {Type: "string", MaxLength: pointer.Int64(10)},
+				"mode":     {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"a"`)}}},
+				"tags":     {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string", Pattern: "^[a-z]+$"}}},
+				"paused":   {Type: "boolean"},
+			}, "paused"),
+			want: []apisv1alpha1.SchemaChange{
+				{Type: apisv1alpha1.SchemaChangeValidationTightened, Path: ".spec.mode", Message: `enum does not allow "b" anymore`},
+				{Type: apisv1alpha1.SchemaChangeValidationTightened, Path: ".spec.name", Message: "maxLength lowered from 63 to 10"},
+				{Type: apisv1alpha1.SchemaChangeValidationTightened, Path: ".spec.paused", Message: "field is required now"},
+				{Type: apisv1alpha1.SchemaChangeTypeChanged, Path: ".spec.replicas", Message: `type changed from "integer" to "string"`},
+				{Type: apisv1alpha1.SchemaChangeValidationTightened, Path: ".spec.tags[*]", Message: `pattern changed from "" to "^[a-z]+$"`},
+			},
+		},
+		"removed field": {
+			newSpec: widgetSpec(map[string]apiextensionsv1.JSONSchemaProps{
+				"replicas": {Type: "integer", Maximum: pointer.Float64(10)},
+				"name":     {Type: "string", MaxLength: pointer.Int64(63)},
+				"mode":     {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"a"`)}, {Raw: []byte(`"b"`)}}},
+			}),
+			want: []apisv1alpha1.SchemaChange{
+				{Type: apisv1alpha1.SchemaChangeFieldRemoved, Path: ".spec.tags", Message: "field was removed"},
+			},
+		},
+		"removed version": {
+			oldVersions: []string{"v1", "v2"},
+			newVersions: []string{"v2"},
+			newSpec:     oldSpec,
+			want: []apisv1alpha1.SchemaChange{
+				{Type: apisv1alpha1.SchemaChangeVersionRemoved, Version: "v1", Message: "version v1 is not served anymore"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.oldVersions == nil {
+				tc.oldVersions = []string{"v1"}
+			}
+			if tc.newVersions == nil {
+				tc.newVersions = []string{"v1"}
+			}
+
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			for _, v := range tc.oldVersions {
+				crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{
+					Name:   v,
+					Served: true,
+					Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: oldSpec},
+				})
+			}
+
+			raw, err := json.Marshal(tc.newSpec)
+			require.NoError(t, err)
+			schema := &apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{Name: "today.widgets.example.io"},
+				Spec: apisv1alpha1.APIResourceSchemaSpec{
+					Group: "example.io",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets"},
+				},
+			}
+			for _, v := range tc.newVersions {
+				schema.Spec.Versions = append(schema.Spec.Versions, apisv1alpha1.APIResourceVersion{
+					Name:   v,
+					Served: true,
+					Schema: runtime.RawExtension{Raw: raw},
+				})
+			}
+
+			for i := range tc.want {
+				tc.want[i].Group = "example.io"
+				tc.want[i].Resource = "widgets"
+				if tc.want[i].Version == "" {
+					tc.want[i].Version = "v1"
+				}
+			}
+
+			got, err := schemaChanges(crd, schema)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
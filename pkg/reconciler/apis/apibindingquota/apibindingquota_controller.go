@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingquota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const controllerName = "kcp-apibinding-quota"
+
+// NewController returns a new controller that counts the objects of bound resources with a quota
+// into the quotaUsage of the APIBindings. Admission charges new objects against the quota usage, and
+// this controller recounts it from the informers of the bound resources when objects are deleted.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	ddsif *informer.DynamicDiscoverySharedInformerFactory,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	apiExportInformer apisinformers.APIExportInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:            queue,
+		apiBindingLister: apiBindingInformer.Lister(),
+		getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+			return apiExportInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		countObjects: func(clusterName logicalcluster.Name, gr schema.GroupResource) (int64, bool, error) {
+			listers, _ := ddsif.ClusterListers(clusterName)
+			for gvr, lister := range listers {
+				if gvr.GroupResource() == gr {
+					objs, err := lister.List(labels.Everything())
+					return int64(len(objs)), true, err
+				}
+			}
+			// not informed or not synced yet
+			return 0, false, nil
+		},
+		listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+		},
+		listAPIBindingsForAPIExport: func(apiExport *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error) {
+			key := clusters.ToClusterAwareKey(logicalcluster.From(apiExport), apiExport.Name)
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingByAPIExport, key)
+		},
+		commit: committer.NewCommitter[*APIBinding, *APIBindingSpec, *APIBindingStatus](kcpClusterClient.ApisV1alpha1().APIBindings()),
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		apiBindingInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.ByLogicalCluster:      indexers.IndexByLogicalCluster,
+			indexers.APIBindingByAPIExport: indexers.IndexAPIBindingByAPIExport,
+		},
+	)
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAPIBinding(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueAPIBinding(newObj)
+		},
+	})
+
+	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueAPIExport(newObj)
+		},
+	})
+
+	// Updates do not change the number of objects.
+	ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.enqueueForObject(gvr, obj)
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.enqueueForObject(gvr, obj)
+		},
+	})
+
+	return c, nil
+}
+
+type APIBinding = apisv1alpha1.APIBinding
+type APIBindingSpec = apisv1alpha1.APIBindingSpec
+type APIBindingStatus = apisv1alpha1.APIBindingStatus
+type Resource = committer.Resource[*APIBindingSpec, *APIBindingStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// controller reconciles the quota usage of APIBindings in their status.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	apiBindingLister apislisters.APIBindingLister
+
+	getAPIExport                func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
+	countObjects                func(clusterName logicalcluster.Name, gr schema.GroupResource) (count int64, synced bool, err error)
+	listAPIBindings             func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error)
+	listAPIBindingsForAPIExport func(apiExport *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error)
+
+	commit CommitFunc
+}
+
+// enqueueAPIBinding enqueues an APIBinding.
+func (c *controller) enqueueAPIBinding(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing APIBinding")
+	c.queue.Add(key)
+}
+
+// enqueueAPIExport enqueues the APIBindings of an APIExport.
+func (c *controller) enqueueAPIExport(obj interface{}) {
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj))
+		return
+	}
+
+	apiBindings, err := c.listAPIBindingsForAPIExport(apiExport)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, apiBinding := range apiBindings {
+		c.enqueueAPIBinding(apiBinding)
+	}
+}
+
+// enqueueForObject enqueues the APIBindings counting objects of the resource of the given added or
+// deleted object in their quota usage.
+func (c *controller) enqueueForObject(gvr schema.GroupVersionResource, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	apiBindings, err := c.listAPIBindings(logicalcluster.From(metaObj))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, apiBinding := range apiBindings {
+		for _, usage := range apiBinding.Status.QuotaUsage {
+			if usage.Group == gvr.Group && usage.Resource == gvr.Resource {
+				c.enqueueAPIBinding(apiBinding)
+				break
+			}
+		}
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.apiBindingLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	if err := c.reconcile(ctx, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	// If the object being reconciled changed as a result, update it.
+	oldResource := &Resource{ObjectMeta: old.ObjectMeta, Spec: &old.Spec, Status: &old.Status}
+	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
+	if err := c.commit(ctx, oldResource, newResource); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingquota
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func (c *controller) reconcile(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) error {
+	logger := klog.FromContext(ctx)
+
+	workspaceRef := apiBinding.ExportWorkspaceReference()
+	if !apiBinding.IsBound() || workspaceRef == nil {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(apiBinding)
+	apiExportClusterName := logicalcluster.New(workspaceRef.Path)
+	if apiExportClusterName.Empty() {
+		apiExportClusterName = clusterName
+	}
+	apiExport, err := c.getAPIExport(apiExportClusterName, workspaceRef.ExportName)
+	if errors.IsNotFound(err) {
+		// keep the last known usage until the APIExport shows up again
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	previous := map[apisv1alpha1.GroupResource]apisv1alpha1.ResourceQuotaUsage{}
+	for _, usage := range apiBinding.Status.QuotaUsage {
+		previous[usage.GroupResource] = usage
+	}
+
+	var usages []apisv1alpha1.ResourceQuotaUsage
+	var errs []error
+	for _, boundResource := range apiBinding.Status.BoundResources {
+		maxObjects, found := EffectiveQuota(apiExport, apiBinding, boundResource.Group, boundResource.Resource)
+		if !found {
+			continue
+		}
+		gr := apisv1alpha1.GroupResource{Group: boundResource.Group, Resource: boundResource.Resource}
+		usage := apisv1alpha1.ResourceQuotaUsage{
+			GroupResource: gr,
+			MaxObjects:    maxObjects,
+		}
+
+		groupResource := schema.GroupResource{Group: gr.Group, Resource: gr.Resource}
+		count, synced, err := c.countObjects(clusterName, groupResource)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("error counting %s in %s: %w", groupResource, clusterName, err))
+			// keep the last known count
+			usage.ObjectCount = previous[gr].ObjectCount
+		case !synced:
+			// keep the last known count, the objects are enqueued once informed
+			logger.V(4).Info("bound resource not informed yet", "resource", groupResource)
+			usage.ObjectCount = previous[gr].ObjectCount
+		default:
+			usage.ObjectCount = count
+		}
+
+		usages = append(usages, usage)
+	}
+
+	apiBinding.Status.QuotaUsage = usages
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// EffectiveQuota returns the quota of the given resource bound by the APIBinding, i.e. the lower of the
+// default quota of the APIExport and the quota of the APIBinding, and whether there is one.
+func EffectiveQuota(apiExport *apisv1alpha1.APIExport, apiBinding *apisv1alpha1.APIBinding, group, resource string) (int64, bool) {
+	maxObjects, found := apiBinding.QuotaFor(group, resource)
+	for _, q := range apiExport.Spec.DefaultQuotas {
+		if q.Group != group || q.Resource != resource {
+			continue
+		}
+		if !found || q.MaxObjects < maxObjects {
+			maxObjects, found = q.MaxObjects, true
+		}
+	}
+	return maxObjects, found
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingquota
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	cowboys := apisv1alpha1.GroupResource{Group: "wildwest.dev", Resource: "cowboys"}
+	sheriffs := apisv1alpha1.GroupResource{Group: "wildwest.dev", Resource: "sheriffs"}
+
+	apiExport := func(quotas ...apisv1alpha1.ResourceQuota) *apisv1alpha1.APIExport {
+		return &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "wildwest"},
+			Spec:       apisv1alpha1.APIExportSpec{DefaultQuotas: quotas},
+		}
+	}
+	apiBinding := func(quotas ...apisv1alpha1.ResourceQuota) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "wildwest",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+			},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference: apisv1alpha1.ExportReference{
+					Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "wildwest"},
+				},
+				Quotas: quotas,
+			},
+			Status: apisv1alpha1.APIBindingStatus{
+				Phase: apisv1alpha1.APIBindingPhaseBound,
+				BoundResources: []apisv1alpha1.BoundAPIResource{
+					{Group: cowboys.Group, Resource: cowboys.Resource, StorageVersions: []string{"v1"}},
+					{Group: sheriffs.Group, Resource: sheriffs.Resource, StorageVersions: []string{"v1"}},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		apiExport  *apisv1alpha1.APIExport
+		apiBinding *apisv1alpha1.APIBinding
+		listError  error
+		notSynced  bool
+		want       []apisv1alpha1.ResourceQuotaUsage
+		wantError  bool
+	}{
+		"no quotas": {
+			apiExport:  apiExport(),
+			apiBinding: apiBinding(),
+		},
+		"default quota of the APIExport": {
+			apiExport:  apiExport(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 500}),
+			apiBinding: apiBinding(),
+			want:       []apisv1alpha1.ResourceQuotaUsage{{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 3}},
+		},
+		"APIBinding tightens the default quota": {
+			apiExport:  apiExport(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 500}),
+			apiBinding: apiBinding(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 10}),
+			want:       []apisv1alpha1.ResourceQuotaUsage{{GroupResource: cowboys, MaxObjects: 10, ObjectCount: 3}},
+		},
+		"APIBinding cannot raise the default quota": {
+			apiExport:  apiExport(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 500}),
+			apiBinding: apiBinding(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 1000}),
+			want:       []apisv1alpha1.ResourceQuotaUsage{{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 3}},
+		},
+		"APIBinding quota without default quota": {
+			apiExport:  apiExport(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 500}),
+			apiBinding: apiBinding(apisv1alpha1.ResourceQuota{GroupResource: sheriffs, MaxObjects: 1}),
+			want: []apisv1alpha1.ResourceQuotaUsage{
+				{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 3},
+				{GroupResource: sheriffs, MaxObjects: 1, ObjectCount: 3},
+			},
+		},
+		"list error keeps the last count": {
+			apiExport: apiExport(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 500}),
+			apiBinding: func() *apisv1alpha1.APIBinding {
+				b := apiBinding()
+				b.Status.QuotaUsage = []apisv1alpha1.ResourceQuotaUsage{{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 42}}
+				return b
+			}(),
+			listError: errors.New("boom"),
+			want:      []apisv1alpha1.ResourceQuotaUsage{{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 42}},
+			wantError: true,
+		},
+		"resource not informed yet keeps the last count": {
+			apiExport: apiExport(apisv1alpha1.ResourceQuota{GroupResource: cowboys, MaxObjects: 500}),
+			apiBinding: func() *apisv1alpha1.APIBinding {
+				b := apiBinding()
+				b.Status.QuotaUsage = []apisv1alpha1.ResourceQuotaUsage{{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 42}}
+				return b
+			}(),
+			notSynced: true,
+			want:      []apisv1alpha1.ResourceQuotaUsage{{GroupResource: cowboys, MaxObjects: 500, ObjectCount: 42}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &controller{
+				getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+					require.Equal(t, "root:provider", clusterName.String())
+					return tc.apiExport, nil
+				},
+				countObjects: func(clusterName logicalcluster.Name, gr schema.GroupResource) (int64, bool, error) {
+					require.Equal(t, "root:consumer", clusterName.String())
+					if tc.listError != nil {
+						return 0, true, tc.listError
+					}
+					return 3, !tc.notSynced, nil
+				},
+			}
+
+			err := c.reconcile(context.Background(), tc.apiBinding)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.want, tc.apiBinding.Status.QuotaUsage)
+		})
+	}
+}
+
+func TestEnqueueForObject(t *testing.T) {
+	apiBinding := func(name string, usage ...apisv1alpha1.ResourceQuotaUsage) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+			},
+			Status: apisv1alpha1.APIBindingStatus{QuotaUsage: usage},
+		}
+	}
+	cowboys := apisv1alpha1.GroupResource{Group: "wildwest.dev", Resource: "cowboys"}
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test")
+	defer queue.ShutDown()
+	c := &controller{
+		queue: queue,
+		listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+			require.Equal(t, "root:consumer", clusterName.String())
+			return []*apisv1alpha1.APIBinding{
+				apiBinding("wildwest", apisv1alpha1.ResourceQuotaUsage{GroupResource: cowboys, MaxObjects: 1, ObjectCount: 1}),
+				apiBinding("other"),
+			}, nil
+		},
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetName("lucky-luke")
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:consumer"})
+	c.enqueueForObject(schema.GroupVersionResource{Group: "wildwest.dev", Version: "v1", Resource: "cowboys"}, cache.DeletedFinalStateUnknown{Obj: obj})
+
+	require.Equal(t, 1, queue.Len())
+	key, _ := queue.Get()
+	require.Equal(t, "root:consumer|wildwest", key)
+}
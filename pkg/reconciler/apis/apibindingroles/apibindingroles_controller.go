@@ -0,0 +1,264 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingroles
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-apibinding-roles"
+)
+
+// NewController returns a new controller that instantiates the clusterRoleTemplates of APIExports
+// as ClusterRoles in the workspaces binding them, and deletes the ClusterRoles when the APIBinding
+// is deleted.
+func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	apiExportInformer apisinformers.APIExportInformer,
+	clusterRoleInformer rbacinformers.ClusterRoleInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue: queue,
+		getAPIBinding: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error) {
+			return apiBindingInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+			return apiExportInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		listClusterRoles: func(clusterName logicalcluster.Name, apiBindingName string) ([]*rbacv1.ClusterRole, error) {
+			clusterRoles, err := indexers.ByIndex[*rbacv1.ClusterRole](clusterRoleInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+			if err != nil {
+				return nil, err
+			}
+			var ret []*rbacv1.ClusterRole
+			for _, clusterRole := range clusterRoles {
+				if clusterRole.Labels[apisv1alpha1.APIBindingClusterRoleLabelKey] == apiBindingName {
+					ret = append(ret, clusterRole)
+				}
+			}
+			return ret, nil
+		},
+		createClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error {
+			_, err := kubeClusterClient.Cluster(clusterName).RbacV1().ClusterRoles().Create(ctx, clusterRole, metav1.CreateOptions{})
+			return err
+		},
+		updateClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error {
+			_, err := kubeClusterClient.Cluster(clusterName).RbacV1().ClusterRoles().Update(ctx, clusterRole, metav1.UpdateOptions{})
+			return err
+		},
+		deleteClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+			return kubeClusterClient.Cluster(clusterName).RbacV1().ClusterRoles().Delete(ctx, name, metav1.DeleteOptions{})
+		},
+		listAPIBindingsForAPIExport: func(apiExport *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error) {
+			key := clusters.ToClusterAwareKey(logicalcluster.From(apiExport), apiExport.Name)
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingByAPIExport, key)
+		},
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		apiBindingInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.APIBindingByAPIExport: indexers.IndexAPIBindingByAPIExport,
+		},
+	)
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAPIBinding(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueAPIBinding(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueAPIBinding(obj)
+		},
+	})
+
+	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAPIExport(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueAPIExport(newObj)
+		},
+	})
+
+	clusterRoleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			clusterRole, ok := obj.(*rbacv1.ClusterRole)
+			if !ok {
+				return false
+			}
+			_, found := clusterRole.Labels[apisv1alpha1.APIBindingClusterRoleLabelKey]
+			return found
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, newObj interface{}) {
+				c.enqueueClusterRole(newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				c.enqueueClusterRole(obj)
+			},
+		},
+	})
+
+	return c, nil
+}
+
+// controller instantiates the clusterRoleTemplates of APIExports in the workspaces binding them.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	getAPIBinding func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error)
+	getAPIExport  func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
+
+	listClusterRoles  func(clusterName logicalcluster.Name, apiBindingName string) ([]*rbacv1.ClusterRole, error)
+	createClusterRole func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error
+	updateClusterRole func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error
+	deleteClusterRole func(ctx context.Context, clusterName logicalcluster.Name, name string) error
+
+	listAPIBindingsForAPIExport func(apiExport *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error)
+}
+
+// enqueueAPIBinding enqueues an APIBinding.
+func (c *controller) enqueueAPIBinding(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing APIBinding")
+	c.queue.Add(key)
+}
+
+// enqueueAPIExport enqueues the APIBindings of an APIExport.
+func (c *controller) enqueueAPIExport(obj interface{}) {
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj))
+		return
+	}
+
+	apiBindings, err := c.listAPIBindingsForAPIExport(apiExport)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, apiBinding := range apiBindings {
+		c.enqueueAPIBinding(apiBinding)
+	}
+}
+
+// enqueueClusterRole enqueues the APIBinding a ClusterRole was instantiated for.
+func (c *controller) enqueueClusterRole(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	clusterRole, ok := obj.(*rbacv1.ClusterRole)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a ClusterRole, but is %T", obj))
+		return
+	}
+
+	key := clusters.ToClusterAwareKey(logicalcluster.From(clusterRole), clusterRole.Labels[apisv1alpha1.APIBindingClusterRoleLabelKey])
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing APIBinding because of ClusterRole", "clusterRole", clusterRole.Name)
+	c.queue.Add(key)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	clusterName, name := clusters.SplitClusterAwareKey(key)
+	return c.reconcile(ctx, clusterName, name)
+}
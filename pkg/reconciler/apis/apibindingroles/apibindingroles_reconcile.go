@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingroles
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// reconcile creates, updates and deletes the ClusterRoles of the APIBinding with the given name,
+// such that they match the clusterRoleTemplates and the roleAggregation of the bound APIExport. If
+// the APIBinding is gone or being deleted, all its ClusterRoles are deleted.
+func (c *controller) reconcile(ctx context.Context, clusterName logicalcluster.Name, apiBindingName string) error {
+	logger := klog.FromContext(ctx)
+
+	apiBinding, err := c.getAPIBinding(clusterName, apiBindingName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	var desired []*rbacv1.ClusterRole
+	if apiBinding != nil && apiBinding.DeletionTimestamp.IsZero() {
+		workspaceRef := apiBinding.ExportWorkspaceReference()
+		if !apiBinding.IsBound() || workspaceRef == nil {
+			// keep the ClusterRoles as they are until the APIBinding is bound
+			return nil
+		}
+
+		apiExportClusterName := logicalcluster.New(workspaceRef.Path)
+		if apiExportClusterName.Empty() {
+			apiExportClusterName = clusterName
+		}
+		apiExport, err := c.getAPIExport(apiExportClusterName, workspaceRef.ExportName)
+		if apierrors.IsNotFound(err) {
+			// the APIBinding is requeued when the APIExport shows up
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		desired = clusterRolesForAPIExport(apiBinding, apiExport)
+		desired = append(desired, aggregatedClusterRoles(apiBinding, apiExport)...)
+	}
+
+	existing, err := c.listClusterRoles(clusterName, apiBindingName)
+	if err != nil {
+		return err
+	}
+	existingByName := make(map[string]*rbacv1.ClusterRole, len(existing))
+	for _, clusterRole := range existing {
+		existingByName[clusterRole.Name] = clusterRole
+	}
+
+	var errs []error
+	for _, clusterRole := range desired {
+		old, found := existingByName[clusterRole.Name]
+		delete(existingByName, clusterRole.Name)
+
+		if !found {
+			logger.V(2).Info("creating ClusterRole", "clusterRole", clusterRole.Name)
+			if err := c.createClusterRole(ctx, clusterName, clusterRole); err != nil {
+				errs = append(errs, fmt.Errorf("failed to create ClusterRole %s|%s: %w", clusterName, clusterRole.Name, err))
+			}
+			continue
+		}
+
+		if equality.Semantic.DeepEqual(old.Labels, clusterRole.Labels) && equality.Semantic.DeepEqual(old.Rules, clusterRole.Rules) {
+			continue
+		}
+		updated := old.DeepCopy()
+		updated.Labels = clusterRole.Labels
+		updated.Rules = clusterRole.Rules
+		logger.V(2).Info("updating ClusterRole", "clusterRole", clusterRole.Name)
+		if err := c.updateClusterRole(ctx, clusterName, updated); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update ClusterRole %s|%s: %w", clusterName, clusterRole.Name, err))
+		}
+	}
+
+	for name := range existingByName {
+		logger.V(2).Info("deleting ClusterRole", "clusterRole", name)
+		if err := c.deleteClusterRole(ctx, clusterName, name); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete ClusterRole %s|%s: %w", clusterName, name, err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// clusterRolesForAPIExport returns the ClusterRoles instantiated from the clusterRoleTemplates of the
+// APIExport for the APIBinding. The templates are not trusted: aggregation labels are dropped, and so
+// are rules about anything but the bound resources and the accepted permission claims. Otherwise the
+// API service provider could grant itself or the users of the consumer workspace arbitrary permissions.
+func clusterRolesForAPIExport(apiBinding *apisv1alpha1.APIBinding, apiExport *apisv1alpha1.APIExport) []*rbacv1.ClusterRole {
+	allowed := sets.NewString()
+	for _, r := range apiBinding.Status.BoundResources {
+		allowed.Insert(schema.GroupResource{Group: r.Group, Resource: r.Resource}.String())
+	}
+	for _, claim := range apiBinding.Spec.PermissionClaims {
+		if claim.State == apisv1alpha1.ClaimAccepted {
+			allowed.Insert(schema.GroupResource{Group: claim.Group, Resource: claim.Resource}.String())
+		}
+	}
+
+	clusterRoles := make([]*rbacv1.ClusterRole, 0, len(apiExport.Spec.ClusterRoleTemplates))
+	for _, template := range apiExport.Spec.ClusterRoleTemplates {
+		labels := make(map[string]string, len(template.Labels)+1)
+		for k, v := range template.Labels {
+			if IsAggregationLabel(k) {
+				continue
+			}
+			labels[k] = v
+		}
+		labels[apisv1alpha1.APIBindingClusterRoleLabelKey] = apiBinding.Name
+
+		var rules []rbacv1.PolicyRule
+		for _, rule := range template.Rules {
+			if RuleCoveredBy(rule, allowed) {
+				rules = append(rules, rule)
+			}
+		}
+
+		clusterRoles = append(clusterRoles, &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   ClusterRoleName(apiBinding.Name, template.Name),
+				Labels: labels,
+			},
+			Rules: rules,
+		})
+	}
+	return clusterRoles
+}
+
+// IsAggregationLabel returns whether the label key aggregates a ClusterRole into the default
+// ClusterRoles, e.g. rbac.authorization.k8s.io/aggregate-to-admin.
+func IsAggregationLabel(key string) bool {
+	return strings.HasPrefix(key, aggregateToLabelKeyPrefix)
+}
+
+// RuleCoveredBy returns whether the rule only grants access to the given group resources, in the
+// format of schema.GroupResource.String(). Rules with wildcards and non-resource URLs are never
+// covered. Subresources are covered by their resource.
+func RuleCoveredBy(rule rbacv1.PolicyRule, groupResources sets.String) bool {
+	if len(rule.NonResourceURLs) > 0 || len(rule.APIGroups) == 0 || len(rule.Resources) == 0 {
+		return false
+	}
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			resource = strings.SplitN(resource, "/", 2)[0]
+			if !groupResources.Has(schema.GroupResource{Group: group, Resource: resource}.String()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// aggregatedClusterRoles returns the ClusterRoles aggregating the resources bound by the APIBinding
+// into the default view, edit and admin ClusterRoles, as selected by the roleAggregation of the
+// APIExport.
+func aggregatedClusterRoles(apiBinding *apisv1alpha1.APIBinding, apiExport *apisv1alpha1.APIExport) []*rbacv1.ClusterRole {
+	aggregation := apiExport.Spec.RoleAggregation
+	if aggregation == nil || len(apiBinding.Status.BoundResources) == 0 {
+		return nil
+	}
+
+	resourcesByGroup := map[string][]string{}
+	var groups []string
+	for _, r := range apiBinding.Status.BoundResources {
+		if _, found := resourcesByGroup[r.Group]; !found {
+			groups = append(groups, r.Group)
+		}
+		resourcesByGroup[r.Group] = append(resourcesByGroup[r.Group], r.Resource)
+	}
+	sort.Strings(groups)
+	rules := func(verbs ...string) []rbacv1.PolicyRule {
+		rules := make([]rbacv1.PolicyRule, 0, len(groups))
+		for _, g := range groups {
+			resources := append([]string(nil), resourcesByGroup[g]...)
+			sort.Strings(resources)
+			rules = append(rules, rbacv1.PolicyRule{APIGroups: []string{g}, Resources: resources, Verbs: verbs})
+		}
+		return rules
+	}
+
+	var clusterRoles []*rbacv1.ClusterRole
+	if aggregation.View {
+		clusterRoles = append(clusterRoles, &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ClusterRoleName(apiBinding.Name, "aggregate:view"),
+				Labels: map[string]string{
+					apisv1alpha1.APIBindingClusterRoleLabelKey: apiBinding.Name,
+					aggregateToViewLabelKey:                    "true",
+				},
+			},
+			Rules: rules("get", "list", "watch"),
+		})
+	}
+	if aggregation.Edit {
+		clusterRoles = append(clusterRoles, &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ClusterRoleName(apiBinding.Name, "aggregate:edit"),
+				Labels: map[string]string{
+					apisv1alpha1.APIBindingClusterRoleLabelKey: apiBinding.Name,
+					aggregateToEditLabelKey:                    "true",
+					aggregateToAdminLabelKey:                   "true",
+				},
+			},
+			Rules: rules("get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"),
+		})
+	}
+	return clusterRoles
+}
+
+const (
+	aggregateToLabelKeyPrefix = "rbac.authorization.k8s.io/aggregate-to-"
+	aggregateToViewLabelKey   = aggregateToLabelKeyPrefix + "view"
+	aggregateToEditLabelKey   = aggregateToLabelKeyPrefix + "edit"
+	aggregateToAdminLabelKey  = aggregateToLabelKeyPrefix + "admin"
+)
+
+// ClusterRoleName returns the name of the ClusterRole instantiated from the clusterRoleTemplate with the
+// given name for the APIBinding with the given name.
+func ClusterRoleName(apiBindingName, templateName string) string {
+	return fmt.Sprintf("apis.kcp.dev:%s:%s", apiBindingName, templateName)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibindingroles
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	viewRules := []rbacv1.PolicyRule{{APIGroups: []string{"example.io"}, Resources: []string{"widgets"}, Verbs: []string{"get", "list", "watch"}}}
+	editRules := []rbacv1.PolicyRule{{APIGroups: []string{"example.io"}, Resources: []string{"widgets"}, Verbs: []string{"*"}}}
+
+	apiExport := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			ClusterRoleTemplates: []apisv1alpha1.ClusterRoleTemplate{
+				{Name: "view", Labels: map[string]string{"example.io/role": "view"}, Rules: viewRules},
+				{Name: "edit", Labels: map[string]string{"example.io/role": "edit"}, Rules: editRules},
+			},
+		},
+	}
+	apiBinding := func(phase apisv1alpha1.APIBindingPhaseType) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference: apisv1alpha1.ExportReference{
+					Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "widgets"},
+				},
+			},
+			Status: apisv1alpha1.APIBindingStatus{
+				Phase:          phase,
+				BoundResources: []apisv1alpha1.BoundAPIResource{{Group: "example.io", Resource: "widgets"}},
+			},
+		}
+	}
+	clusterRole := func(name string, labels map[string]string, rules []rbacv1.PolicyRule) *rbacv1.ClusterRole {
+		allLabels := map[string]string{apisv1alpha1.APIBindingClusterRoleLabelKey: "widgets"}
+		for k, v := range labels {
+			allLabels[k] = v
+		}
+		return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: allLabels}, Rules: rules}
+	}
+	view := clusterRole("apis.kcp.dev:widgets:view", map[string]string{"example.io/role": "view"}, viewRules)
+	edit := clusterRole("apis.kcp.dev:widgets:edit", map[string]string{"example.io/role": "edit"}, editRules)
+
+	deleting := apiBinding(apisv1alpha1.APIBindingPhaseBound)
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+
+	tests := map[string]struct {
+		apiBinding *apisv1alpha1.APIBinding
+		existing   []*rbacv1.ClusterRole
+
+		wantCreated []string
+		wantUpdated []string
+		wantDeleted []string
+	}{
+		"bound APIBinding gets ClusterRoles": {
+			apiBinding:  apiBinding(apisv1alpha1.APIBindingPhaseBound),
+			wantCreated: []string{"apis.kcp.dev:widgets:edit", "apis.kcp.dev:widgets:view"},
+		},
+		"binding APIBinding gets no ClusterRoles yet": {
+			apiBinding: apiBinding(apisv1alpha1.APIBindingPhaseBinding),
+		},
+		"up-to-date ClusterRoles are kept": {
+			apiBinding: apiBinding(apisv1alpha1.APIBindingPhaseBound),
+			existing:   []*rbacv1.ClusterRole{view, edit},
+		},
+		"changed ClusterRoles are updated, stale ones deleted": {
+			apiBinding: apiBinding(apisv1alpha1.APIBindingPhaseBound),
+			existing: []*rbacv1.ClusterRole{
+				view,
+				clusterRole("apis.kcp.dev:widgets:edit", nil, viewRules),
+				clusterRole("apis.kcp.dev:widgets:admin", nil, editRules),
+			},
+			wantUpdated: []string{"apis.kcp.dev:widgets:edit"},
+			wantDeleted: []string{"apis.kcp.dev:widgets:admin"},
+		},
+		"ClusterRoles of deleted APIBinding are deleted": {
+			existing:    []*rbacv1.ClusterRole{view, edit},
+			wantDeleted: []string{"apis.kcp.dev:widgets:edit", "apis.kcp.dev:widgets:view"},
+		},
+		"ClusterRoles of deleting APIBinding are deleted": {
+			apiBinding:  deleting,
+			existing:    []*rbacv1.ClusterRole{view, edit},
+			wantDeleted: []string{"apis.kcp.dev:widgets:edit", "apis.kcp.dev:widgets:view"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var created, updated, deleted []string
+			c := &controller{
+				getAPIBinding: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error) {
+					require.Equal(t, "root:consumer", clusterName.String())
+					if tc.apiBinding == nil {
+						return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apibindings"), name)
+					}
+					return tc.apiBinding, nil
+				},
+				getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+					require.Equal(t, "root:provider", clusterName.String())
+					return apiExport, nil
+				},
+				listClusterRoles: func(clusterName logicalcluster.Name, apiBindingName string) ([]*rbacv1.ClusterRole, error) {
+					return tc.existing, nil
+				},
+				createClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error {
+					require.Equal(t, "widgets", clusterRole.Labels[apisv1alpha1.APIBindingClusterRoleLabelKey])
+					created = append(created, clusterRole.Name)
+					return nil
+				},
+				updateClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error {
+					updated = append(updated, clusterRole.Name)
+					return nil
+				},
+				deleteClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+					deleted = append(deleted, name)
+					return nil
+				},
+			}
+
+			err := c.reconcile(context.Background(), logicalcluster.New("root:consumer"), "widgets")
+			require.NoError(t, err)
+
+			sort.Strings(created)
+			sort.Strings(deleted)
+			require.Equal(t, tc.wantCreated, created)
+			require.Equal(t, tc.wantUpdated, updated)
+			require.Equal(t, tc.wantDeleted, deleted)
+		})
+	}
+}
+
+func TestClusterRolesForAPIExportEscalation(t *testing.T) {
+	apiBinding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIBindingSpec{
+			PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{
+				{PermissionClaim: apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}, State: apisv1alpha1.ClaimAccepted},
+				{PermissionClaim: apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}}, State: apisv1alpha1.ClaimRejected},
+			},
+		},
+		Status: apisv1alpha1.APIBindingStatus{
+			BoundResources: []apisv1alpha1.BoundAPIResource{{Group: "example.io", Resource: "widgets"}},
+		},
+	}
+	apiExport := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			ClusterRoleTemplates: []apisv1alpha1.ClusterRoleTemplate{{
+				Name: "admin",
+				Labels: map[string]string{
+					"rbac.authorization.k8s.io/aggregate-to-admin": "true",
+					"example.io/role": "admin",
+				},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{"example.io"}, Resources: []string{"widgets", "widgets/status"}, Verbs: []string{"*"}},
+					{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+					{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+					{APIGroups: []string{"", "example.io"}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+					{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+					{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, Verbs: []string{"escalate", "bind"}},
+					{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+				},
+			}},
+		},
+	}
+
+	clusterRoles := clusterRolesForAPIExport(apiBinding, apiExport)
+	require.Len(t, clusterRoles, 1)
+	require.Equal(t, map[string]string{
+		apisv1alpha1.APIBindingClusterRoleLabelKey: "widgets",
+		"example.io/role":                          "admin",
+	}, clusterRoles[0].Labels, "aggregation labels must be dropped")
+	require.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{"example.io"}, Resources: []string{"widgets", "widgets/status"}, Verbs: []string{"*"}},
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+	}, clusterRoles[0].Rules, "rules beyond the bound resources and the accepted claims must be dropped")
+}
+
+func TestAggregatedClusterRoles(t *testing.T) {
+	apiBinding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Status: apisv1alpha1.APIBindingStatus{
+			BoundResources: []apisv1alpha1.BoundAPIResource{
+				{Group: "example.io", Resource: "widgets"},
+				{Group: "", Resource: "gadgets"},
+				{Group: "example.io", Resource: "gizmos"},
+			},
+		},
+	}
+	apiExport := func(aggregation *apisv1alpha1.RoleAggregation) *apisv1alpha1.APIExport {
+		return &apisv1alpha1.APIExport{Spec: apisv1alpha1.APIExportSpec{RoleAggregation: aggregation}}
+	}
+
+	require.Empty(t, aggregatedClusterRoles(apiBinding, apiExport(nil)))
+	require.Empty(t, aggregatedClusterRoles(&apisv1alpha1.APIBinding{}, apiExport(&apisv1alpha1.RoleAggregation{View: true, Edit: true})))
+
+	clusterRoles := aggregatedClusterRoles(apiBinding, apiExport(&apisv1alpha1.RoleAggregation{View: true, Edit: true}))
+	require.Len(t, clusterRoles, 2)
+
+	view, edit := clusterRoles[0], clusterRoles[1]
+	require.Equal(t, "apis.kcp.dev:widgets:aggregate:view", view.Name)
+	require.Equal(t, map[string]string{
+		apisv1alpha1.APIBindingClusterRoleLabelKey:    "widgets",
+		"rbac.authorization.k8s.io/aggregate-to-view": "true",
+	}, view.Labels)
+	require.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"gadgets"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{"example.io"}, Resources: []string{"gizmos", "widgets"}, Verbs: []string{"get", "list", "watch"}},
+	}, view.Rules)
+
+	require.Equal(t, "apis.kcp.dev:widgets:aggregate:edit", edit.Name)
+	require.Equal(t, "true", edit.Labels["rbac.authorization.k8s.io/aggregate-to-edit"])
+	require.Equal(t, "true", edit.Labels["rbac.authorization.k8s.io/aggregate-to-admin"])
+	require.Equal(t, []string{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}, edit.Rules[0].Verbs)
+
+	clusterRoles = aggregatedClusterRoles(apiBinding, apiExport(&apisv1alpha1.RoleAggregation{View: true}))
+	require.Len(t, clusterRoles, 1)
+	require.Equal(t, "apis.kcp.dev:widgets:aggregate:view", clusterRoles[0].Name)
+}
@@ -25,11 +25,13 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	kubernetesinformers "k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	corelisters "k8s.io/client-go/listers/core/v1"
@@ -47,6 +49,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
@@ -55,16 +58,18 @@ const (
 	DefaultIdentitySecretNamespace = "kcp-system"
 )
 
-// NewController returns a new controller for APIExports.
+// NewController returns a new controller for APIExports. namespaceInformer is expected to be a
+// metadata-only informer, see informer.MetadataSharedInformerFactory.
 func NewController(
 	kcpClusterClient kcpclient.Interface,
 	apiExportInformer apisinformers.APIExportInformer,
 	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
 	kubeClusterClient kubernetesclient.Interface,
-	namespaceInformer coreinformers.NamespaceInformer,
+	namespaceInformer kubernetesinformers.GenericInformer,
 	secretInformer coreinformers.SecretInformer,
+	identityProvider IdentityProvider,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue:             queue,
@@ -72,8 +77,12 @@ func NewController(
 		apiExportLister:   apiExportInformer.Lister(),
 		apiExportIndexer:  apiExportInformer.Informer().GetIndexer(),
 		kubeClusterClient: kubeClusterClient,
-		getNamespace: func(clusterName logicalcluster.Name, name string) (*corev1.Namespace, error) {
-			return namespaceInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		getNamespace: func(clusterName logicalcluster.Name, name string) (metav1.Object, error) {
+			obj, err := namespaceInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+			if err != nil {
+				return nil, err
+			}
+			return meta.Accessor(obj)
 		},
 		createNamespace: func(ctx context.Context, clusterName logicalcluster.Name, ns *corev1.Namespace) error {
 			_, err := kubeClusterClient.CoreV1().Namespaces().Create(logicalcluster.WithCluster(ctx, clusterName), ns, metav1.CreateOptions{})
@@ -85,6 +94,11 @@ func NewController(
 			_, err := kubeClusterClient.CoreV1().Secrets(secret.Namespace).Create(logicalcluster.WithCluster(ctx, clusterName), secret, metav1.CreateOptions{})
 			return err
 		},
+		updateSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+			_, err := kubeClusterClient.CoreV1().Secrets(secret.Namespace).Update(logicalcluster.WithCluster(ctx, clusterName), secret, metav1.UpdateOptions{})
+			return err
+		},
+		identityProvider: identityProvider,
 		listClusterWorkspaceShards: func() ([]*tenancyv1alpha1.ClusterWorkspaceShard, error) {
 			return clusterWorkspaceShardInformer.Lister().List(labels.Everything())
 		},
@@ -158,7 +172,7 @@ type controller struct {
 
 	kubeClusterClient kubernetesclient.Interface
 
-	getNamespace    func(clusterName logicalcluster.Name, name string) (*corev1.Namespace, error)
+	getNamespace    func(clusterName logicalcluster.Name, name string) (metav1.Object, error)
 	createNamespace func(ctx context.Context, clusterName logicalcluster.Name, ns *corev1.Namespace) error
 
 	secretLister    corelisters.SecretLister
@@ -166,6 +180,9 @@ type controller struct {
 
 	getSecret    func(ctx context.Context, clusterName logicalcluster.Name, ns, name string) (*corev1.Secret, error)
 	createSecret func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error
+	updateSecret func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error
+
+	identityProvider IdentityProvider
 
 	listClusterWorkspaceShards func() ([]*tenancyv1alpha1.ClusterWorkspaceShard, error)
 	commit                     CommitFunc
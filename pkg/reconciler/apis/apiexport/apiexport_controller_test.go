@@ -17,6 +17,7 @@ limitations under the License.
 package apiexport
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"errors"
@@ -29,6 +30,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage/value"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -47,10 +49,14 @@ func TestReconcile(t *testing.T) {
 		apiExportHasSomeOtherHash            bool
 		hasPreexistingVerifyFailure          bool
 		listClusterWorkspaceShardsError      error
+		sealedIdentityProvider               bool
+		secretSealedWithRotatedKey           bool
+		secretHasPlainKey                    bool
 
 		wantGenerationFailed          bool
 		wantError                     bool
 		wantCreateSecretCalled        bool
+		wantUpdateSecretCalled        bool
 		wantUnsetIdentity             bool
 		wantDefaultSecretRef          bool
 		wantStatusHashSet             bool
@@ -112,6 +118,41 @@ func TestReconcile(t *testing.T) {
 
 			wantVirtualWorkspaceURLsReady: true,
 		},
+		"sealed identity is verified": {
+			secretRefSet:           true,
+			secretExists:           true,
+			sealedIdentityProvider: true,
+
+			wantStatusHashSet: true,
+			wantIdentityValid: true,
+
+			wantVirtualWorkspaceURLsReady: true,
+		},
+		"sealed identity is resealed after key rotation without changing the hash": {
+			secretRefSet:               true,
+			secretExists:               true,
+			apiExportHasExpectedHash:   true,
+			sealedIdentityProvider:     true,
+			secretSealedWithRotatedKey: true,
+
+			wantStatusHashSet:      true,
+			wantIdentityValid:      true,
+			wantUpdateSecretCalled: true,
+
+			wantVirtualWorkspaceURLsReady: true,
+		},
+		"plain identity secret is accepted by the sealed identity provider": {
+			secretRefSet:             true,
+			secretExists:             true,
+			apiExportHasExpectedHash: true,
+			sealedIdentityProvider:   true,
+			secretHasPlainKey:        true,
+
+			wantStatusHashSet: true,
+			wantIdentityValid: true,
+
+			wantVirtualWorkspaceURLsReady: true,
+		},
 		"error listing clusterworkspaceshards": {
 			secretRefSet: true,
 			secretExists: true,
@@ -129,13 +170,19 @@ func TestReconcile(t *testing.T) {
 
 		t.Run(name, func(t *testing.T) {
 			createSecretCalled := false
+			var updatedSecret *corev1.Secret
 
 			expectedKey := "abc"
 			expectedHash := fmt.Sprintf("%x", sha256.Sum256([]byte(expectedKey)))
 			someOtherKey := "def"
 
+			identityProvider := NewPlainIdentityProvider()
+			if tc.sealedIdentityProvider {
+				identityProvider = NewSealedIdentityProvider(prefixTransformer{})
+			}
+
 			c := &controller{
-				getNamespace: func(clusterName logicalcluster.Name, name string) (*corev1.Namespace, error) {
+				getNamespace: func(clusterName logicalcluster.Name, name string) (metav1.Object, error) {
 					return &corev1.Namespace{}, nil
 				},
 				createNamespace: func(ctx context.Context, clusterName logicalcluster.Name, ns *corev1.Namespace) error {
@@ -147,7 +194,13 @@ func TestReconcile(t *testing.T) {
 						secret := &corev1.Secret{
 							Data: map[string][]byte{},
 						}
-						if !tc.keyMissing {
+						if !tc.keyMissing && tc.sealedIdentityProvider && !tc.secretHasPlainKey {
+							prefix := "current:"
+							if tc.secretSealedWithRotatedKey {
+								prefix = "rotated:"
+							}
+							secret.Data[apisv1alpha1.SecretKeyAPIExportSealedIdentity] = []byte(prefix + expectedKey)
+						} else if !tc.keyMissing {
 							if tc.secretHashDoesntMatchAPIExportStatus {
 								secret.Data[apisv1alpha1.SecretKeyAPIExportIdentity] = []byte(someOtherKey)
 							} else {
@@ -163,12 +216,29 @@ func TestReconcile(t *testing.T) {
 					createSecretCalled = true
 					return tc.createSecretError
 				},
+				updateSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+					updatedSecret = secret
+					return nil
+				},
+				identityProvider: identityProvider,
 				listClusterWorkspaceShards: func() ([]*tenancyv1alpha1.ClusterWorkspaceShard, error) {
 					if tc.listClusterWorkspaceShardsError != nil {
 						return nil, tc.listClusterWorkspaceShardsError
 					}
 
 					return []*tenancyv1alpha1.ClusterWorkspaceShard{
+						{
+							ObjectMeta: metav1.ObjectMeta{
+								Annotations: map[string]string{
+									logicalcluster.AnnotationKey: "root:org:ws",
+								},
+								Name: "shard3",
+							},
+							Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+								ExternalURL:         "https://server-3.kcp.dev/",
+								VirtualWorkspaceURL: "https://server-1.kcp.dev/",
+							},
+						},
 						{
 							ObjectMeta: metav1.ObjectMeta{
 								Annotations: map[string]string{
@@ -177,7 +247,8 @@ func TestReconcile(t *testing.T) {
 								Name: "shard1",
 							},
 							Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-								ExternalURL: "https://server-1.kcp.dev/",
+								ExternalURL:         "https://server-1.kcp.dev/",
+								VirtualWorkspaceURL: "https://server-1.kcp.dev/",
 							},
 						},
 						{
@@ -188,7 +259,8 @@ func TestReconcile(t *testing.T) {
 								Name: "shard2",
 							},
 							Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
-								ExternalURL: "https://server-2.kcp.dev/",
+								ExternalURL:         "https://server-2.kcp.dev/",
+								VirtualWorkspaceURL: "https://server-2.kcp.dev/",
 							},
 						},
 					}, nil
@@ -232,6 +304,10 @@ func TestReconcile(t *testing.T) {
 			}
 
 			require.Equal(t, tc.wantCreateSecretCalled, createSecretCalled, "expected to try to create secret")
+			require.Equal(t, tc.wantUpdateSecretCalled, updatedSecret != nil, "expected to try to update secret")
+			if updatedSecret != nil {
+				require.Equal(t, "current:"+expectedKey, string(updatedSecret.Data[apisv1alpha1.SecretKeyAPIExportSealedIdentity]))
+			}
 
 			if !tc.wantUnsetIdentity {
 				if tc.wantDefaultSecretRef {
@@ -288,11 +364,32 @@ func TestReconcile(t *testing.T) {
 
 			if tc.wantVirtualWorkspaceURLsReady {
 				requireConditionMatches(t, apiExport, conditions.TrueCondition(apisv1alpha1.APIExportVirtualWorkspaceURLsReady))
+				require.Equal(t, []apisv1alpha1.VirtualWorkspace{
+					{URL: "https://server-1.kcp.dev/services/apiexport/root:org:ws/" + apiExport.Name, Shard: "shard1"},
+					{URL: "https://server-2.kcp.dev/services/apiexport/root:org:ws/" + apiExport.Name, Shard: "shard2"},
+				}, apiExport.Status.VirtualWorkspaces)
 			}
 		})
 	}
 }
 
+// prefixTransformer seals data with a "current:" prefix and reports data with a "rotated:" prefix as stale.
+type prefixTransformer struct{}
+
+func (prefixTransformer) TransformFromStorage(_ context.Context, data []byte, _ value.Context) ([]byte, bool, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("current:")):
+		return bytes.TrimPrefix(data, []byte("current:")), false, nil
+	case bytes.HasPrefix(data, []byte("rotated:")):
+		return bytes.TrimPrefix(data, []byte("rotated:")), true, nil
+	}
+	return nil, false, errors.New("unknown prefix")
+}
+
+func (prefixTransformer) TransformToStorage(_ context.Context, data []byte, _ value.Context) ([]byte, error) {
+	return append([]byte("current:"), data...), nil
+}
+
 // requireConditionMatches looks for a condition matching c in g. Only fields that are set in c are compared (Type is
 // required, though). If c.Message is set, the test performed is contains rather than an exact match.
 func requireConditionMatches(t *testing.T, g conditions.Getter, c *conditionsv1alpha1.Condition) {
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"sort"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
@@ -127,7 +128,7 @@ func (c *controller) ensureSecretNamespaceExists(ctx context.Context, clusterNam
 }
 
 func (c *controller) createIdentitySecret(ctx context.Context, clusterName logicalcluster.Name, apiExportName string) error {
-	secret, err := GenerateIdentitySecret(ctx, c.secretNamespace, apiExportName)
+	secret, err := GenerateIdentitySecret(ctx, c.identityProvider, c.secretNamespace, apiExportName)
 	if err != nil {
 		return err
 	}
@@ -149,7 +150,7 @@ func (c *controller) updateOrVerifyIdentitySecretHash(ctx context.Context, clust
 		return err
 	}
 
-	hash, err := IdentityHash(secret)
+	hash, stale, err := IdentityHash(ctx, c.identityProvider, secret)
 	if err != nil {
 		return err
 	}
@@ -164,9 +165,28 @@ func (c *controller) updateOrVerifyIdentitySecretHash(ctx context.Context, clust
 
 	conditions.MarkTrue(apiExport, apisv1alpha1.APIExportIdentityValid)
 
+	if stale {
+		// The key material was sealed with a rotated key. Seal it again, the identity hash does not change.
+		if err := c.resealIdentitySecret(ctx, clusterName, secret); err != nil {
+			klog.FromContext(ctx).Error(err, "error resealing identity secret")
+		}
+	}
+
 	return nil
 }
 
+func (c *controller) resealIdentitySecret(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+	resealed, err := ResealIdentitySecret(ctx, c.identityProvider, secret)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), secret)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(2).Info("resealing identity secret")
+	return c.updateSecret(ctx, clusterName, resealed)
+}
+
 func (c *controller) updateVirtualWorkspaceURLs(ctx context.Context, apiExport *apisv1alpha1.APIExport) error {
 	logger := klog.FromContext(ctx)
 	clusterWorkspaceShards, err := c.listClusterWorkspaceShards()
@@ -174,7 +194,12 @@ func (c *controller) updateVirtualWorkspaceURLs(ctx context.Context, apiExport *
 		return fmt.Errorf("error listing ClusterWorkspaceShards: %w", err)
 	}
 
-	desiredURLs := sets.NewString()
+	sort.Slice(clusterWorkspaceShards, func(i, j int) bool {
+		return clusterWorkspaceShards[i].Name < clusterWorkspaceShards[j].Name
+	})
+
+	var virtualWorkspaces []apisv1alpha1.VirtualWorkspace
+	seenURLs := sets.NewString()
 	for _, clusterWorkspaceShard := range clusterWorkspaceShards {
 		logger = logging.WithObject(logger, clusterWorkspaceShard)
 		if clusterWorkspaceShard.Spec.VirtualWorkspaceURL == "" {
@@ -200,17 +225,19 @@ func (c *controller) updateVirtualWorkspaceURLs(ctx context.Context, apiExport *
 			apiExport.Name,
 		)
 
-		desiredURLs.Insert(u.String())
-	}
-
-	apiExport.Status.VirtualWorkspaces = nil
+		if seenURLs.Has(u.String()) {
+			continue
+		}
+		seenURLs.Insert(u.String())
 
-	for _, u := range desiredURLs.List() {
-		apiExport.Status.VirtualWorkspaces = append(apiExport.Status.VirtualWorkspaces, apisv1alpha1.VirtualWorkspace{
-			URL: u,
+		virtualWorkspaces = append(virtualWorkspaces, apisv1alpha1.VirtualWorkspace{
+			URL:   u.String(),
+			Shard: clusterWorkspaceShard.Name,
 		})
 	}
 
+	apiExport.Status.VirtualWorkspaces = virtualWorkspaces
+
 	conditions.MarkTrue(apiExport, apisv1alpha1.APIExportVirtualWorkspaceURLsReady)
 
 	return nil
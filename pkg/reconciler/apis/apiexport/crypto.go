@@ -24,13 +24,81 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage/value"
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/crypto"
 )
 
-func GenerateIdentitySecret(ctx context.Context, ns string, apiExportName string) (*corev1.Secret, error) {
+// IdentityProvider stores and retrieves the private key material of APIExport identities in identity secrets.
+type IdentityProvider interface {
+	// Seal returns the secret data holding the given identity key.
+	Seal(ctx context.Context, key []byte) (map[string][]byte, error)
+	// Unseal returns the identity key held by the given secret. If stale is true, the
+	// key material was sealed with a rotated key and should be sealed again.
+	Unseal(ctx context.Context, secret *corev1.Secret) (key []byte, stale bool, err error)
+}
+
+// NewPlainIdentityProvider returns an IdentityProvider storing identity keys as plain secret data.
+func NewPlainIdentityProvider() IdentityProvider {
+	return plainIdentityProvider{}
+}
+
+type plainIdentityProvider struct{}
+
+func (plainIdentityProvider) Seal(_ context.Context, key []byte) (map[string][]byte, error) {
+	return map[string][]byte{apisv1alpha1.SecretKeyAPIExportIdentity: key}, nil
+}
+
+func (plainIdentityProvider) Unseal(_ context.Context, secret *corev1.Secret) ([]byte, bool, error) {
+	key := secret.Data[apisv1alpha1.SecretKeyAPIExportIdentity]
+	if len(key) == 0 {
+		return nil, false, fmt.Errorf("secret is missing data.%s", apisv1alpha1.SecretKeyAPIExportIdentity)
+	}
+	return key, false, nil
+}
+
+// sealedIdentityContext is the authenticated data of sealed identity keys. It is not bound to
+// the secret, so that an identity can be shared by copying its secret.
+var sealedIdentityContext = value.DefaultContext(apisv1alpha1.SecretKeyAPIExportSealedIdentity)
+
+// NewSealedIdentityProvider returns an IdentityProvider that seals identity keys with the given
+// transformer, e.g. an envelope transformer backed by an external KMS. Secrets holding a plain
+// identity key are still accepted, in order to support identity secrets created by users.
+func NewSealedIdentityProvider(transformer value.Transformer) IdentityProvider {
+	return &sealedIdentityProvider{transformer: transformer}
+}
+
+type sealedIdentityProvider struct {
+	transformer value.Transformer
+}
+
+func (p *sealedIdentityProvider) Seal(ctx context.Context, key []byte) (map[string][]byte, error) {
+	sealed, err := p.transformer.TransformToStorage(ctx, key, sealedIdentityContext)
+	if err != nil {
+		return nil, fmt.Errorf("error sealing identity key: %w", err)
+	}
+	return map[string][]byte{apisv1alpha1.SecretKeyAPIExportSealedIdentity: sealed}, nil
+}
+
+func (p *sealedIdentityProvider) Unseal(ctx context.Context, secret *corev1.Secret) ([]byte, bool, error) {
+	sealed, found := secret.Data[apisv1alpha1.SecretKeyAPIExportSealedIdentity]
+	if !found {
+		return plainIdentityProvider{}.Unseal(ctx, secret)
+	}
+
+	key, stale, err := p.transformer.TransformFromStorage(ctx, sealed, sealedIdentityContext)
+	if err != nil {
+		return nil, false, fmt.Errorf("error unsealing data.%s: %w", apisv1alpha1.SecretKeyAPIExportSealedIdentity, err)
+	}
+	if len(key) == 0 {
+		return nil, false, fmt.Errorf("secret has an empty data.%s", apisv1alpha1.SecretKeyAPIExportSealedIdentity)
+	}
+	return key, stale, nil
+}
+
+func GenerateIdentitySecret(ctx context.Context, provider IdentityProvider, ns string, apiExportName string) (*corev1.Secret, error) {
 	logger := klog.FromContext(ctx)
 	start := time.Now()
 	key := crypto.Random256BitsString()
@@ -38,27 +106,71 @@ func GenerateIdentitySecret(ctx context.Context, ns string, apiExportName string
 		logger.Info("identity key generation took a long time", "duration", dur)
 	}
 
+	data, err := provider.Seal(ctx, []byte(key))
+	if err != nil {
+		return nil, err
+	}
+
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:   ns,
 			Name:        apiExportName,
 			Annotations: map[string]string{},
 		},
-		StringData: map[string]string{
-			apisv1alpha1.SecretKeyAPIExportIdentity: key,
-		},
+		Data: data,
 	}
 
 	return secret, nil
 }
 
-func IdentityHash(secret *corev1.Secret) (string, error) {
-	key := secret.Data[apisv1alpha1.SecretKeyAPIExportIdentity]
-	if len(key) == 0 {
-		return "", fmt.Errorf("secret is missing data.%s", apisv1alpha1.SecretKeyAPIExportIdentity)
+// IdentityHash returns the identity hash derived from the identity key held by the given secret,
+// and whether the secret should be sealed again because its key material is stale.
+func IdentityHash(ctx context.Context, provider IdentityProvider, secret *corev1.Secret) (string, bool, error) {
+	key, stale, err := provider.Unseal(ctx, secret)
+	if err != nil {
+		return "", false, err
 	}
 
 	hashBytes := sha256.Sum256(key)
 	hash := fmt.Sprintf("%x", hashBytes)
-	return hash, nil
+	return hash, stale, nil
+}
+
+// VerifySealedIdentity checks that a sealed identity key, as replicated from another shard, derives the given
+// identity hash. The provider must be able to unseal the key, i.e. the shards must share the identity encryption
+// provider configuration.
+func VerifySealedIdentity(ctx context.Context, provider IdentityProvider, sealed []byte, identityHash string) error {
+	hash, _, err := IdentityHash(ctx, provider, &corev1.Secret{Data: map[string][]byte{apisv1alpha1.SecretKeyAPIExportSealedIdentity: sealed}})
+	if err != nil {
+		return err
+	}
+	if hash != identityHash {
+		return fmt.Errorf("identity hash %s does not match the sealed identity key", identityHash)
+	}
+	return nil
+}
+
+// ResealIdentitySecret returns a copy of the given secret with its identity key sealed again,
+// keeping the identity hash unchanged.
+func ResealIdentitySecret(ctx context.Context, provider IdentityProvider, secret *corev1.Secret) (*corev1.Secret, error) {
+	key, _, err := provider.Unseal(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := provider.Seal(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	secret = secret.DeepCopy()
+	delete(secret.Data, apisv1alpha1.SecretKeyAPIExportIdentity)
+	delete(secret.Data, apisv1alpha1.SecretKeyAPIExportSealedIdentity)
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for k, v := range data {
+		secret.Data[k] = v
+	}
+	return secret, nil
 }
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/server/options/encryptionconfig"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// identityEncryptionResource is the resource in the encryption provider configuration whose
+// providers seal APIExport identity keys.
+var identityEncryptionResource = schema.GroupResource{Group: apisv1alpha1.SchemeGroupVersion.Group, Resource: "apiexports"}
+
+// DefaultOptions are the default options for the apiexport controller.
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+// BindOptions binds the apiexport controller options to the flag set.
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.IdentityEncryptionProviderConfig, "apiexport-identity-encryption-provider-config", o.IdentityEncryptionProviderConfig,
+		"The file containing the encryption provider configuration used to seal APIExport identity keys, e.g. with an external KMS. "+
+			"The providers of the apiexports.apis.kcp.dev resource are used, the first one seals, the others only unseal and trigger resealing on key rotation. "+
+			"If empty, identity keys are stored as plain secret data.")
+	return o
+}
+
+// Options are the options for the apiexport controller.
+type Options struct {
+	IdentityEncryptionProviderConfig string
+}
+
+func (o *Options) Validate() error {
+	if o.IdentityEncryptionProviderConfig == "" {
+		return nil
+	}
+	if _, err := os.Stat(o.IdentityEncryptionProviderConfig); err != nil {
+		return fmt.Errorf("--apiexport-identity-encryption-provider-config: %w", err)
+	}
+	return nil
+}
+
+// IdentityProvider returns the IdentityProvider configured by the options.
+func (o *Options) IdentityProvider() (IdentityProvider, error) {
+	if o.IdentityEncryptionProviderConfig == "" {
+		return NewPlainIdentityProvider(), nil
+	}
+
+	transformers, err := encryptionconfig.GetTransformerOverrides(o.IdentityEncryptionProviderConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error loading APIExport identity encryption provider config: %w", err)
+	}
+	transformer, found := transformers[identityEncryptionResource]
+	if !found {
+		return nil, fmt.Errorf("APIExport identity encryption provider config %q has no providers for %s", o.IdentityEncryptionProviderConfig, identityEncryptionResource)
+	}
+
+	return NewSealedIdentityProvider(transformer), nil
+}
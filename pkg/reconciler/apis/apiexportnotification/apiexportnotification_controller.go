@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportnotification
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+)
+
+const (
+	controllerName = "kcp-apiexport-notification"
+)
+
+// NewController returns a new controller that notifies providers about the lifecycle of their APIExports
+// in consumer workspaces. It records events on the APIExport in the provider workspace when an APIBinding
+// binds or unbinds it, and when objects of its resources are created or deleted. Providers watch these
+// events with a field selector on the involved APIExport instead of watching all consumer workspaces.
+//
+// Notifications are best-effort: changes that happen while the controller is down are not reported.
+func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	dynamicDiscoverySharedInformerFactory *informer.DynamicDiscoverySharedInformerFactory,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	apiExportInformer apisinformers.APIExportInformer,
+) (*controller, error) {
+	// Every notification is about a different consumer or object. Neither aggregate nor rate-limit them
+	// per APIExport like events of the same reason usually are.
+	eventBroadcaster := record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+		KeyFunc:     notificationKey,
+		SpamKeyFunc: notificationSpamKey,
+	})
+
+	c := &controller{
+		kubeClusterClient: kubeClusterClient,
+		eventBroadcaster:  eventBroadcaster,
+		eventRecorder:     events.NewClusterAwareRecorder(eventBroadcaster.NewRecorder(kcpscheme.Scheme, corev1.EventSource{Component: controllerName})),
+		startTime:         time.Now(),
+		getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+			return apiExportInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+		},
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		apiBindingInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+		},
+	)
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.apiBindingUpdated(oldObj, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.apiBindingDeleted(obj)
+		},
+	})
+
+	dynamicDiscoverySharedInformerFactory.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.instanceCreated(gvr, obj)
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.instanceDeleted(gvr, obj)
+		},
+	})
+
+	return c, nil
+}
+
+// controller records notification events on APIExports.
+type controller struct {
+	kubeClusterClient kubernetesclient.ClusterInterface
+
+	eventBroadcaster record.EventBroadcaster
+	eventRecorder    record.EventRecorder
+
+	// startTime is used to skip objects that existed before the controller started, and that are
+	// only seen as added because the informers list them initially.
+	startTime time.Time
+
+	getAPIExport    func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
+	listAPIBindings func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context) {
+	defer runtime.HandleCrash()
+
+	c.eventBroadcaster.StartRecordingToSink(events.NewClusterAwareSink(c.kubeClusterClient))
+	defer c.eventBroadcaster.Shutdown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	<-ctx.Done()
+}
+
+// notificationKey aggregates events by their message in addition to their reason, such that
+// notifications about different objects are never combined.
+func notificationKey(event *corev1.Event) (string, string) {
+	key, _ := record.EventAggregatorByReasonFunc(event)
+	return strings.Join([]string{key, event.Message}, ""), event.Message
+}
+
+// notificationSpamKey rate-limits events per message, such that notifications about different
+// objects do not count against each other.
+func notificationSpamKey(event *corev1.Event) string {
+	key, _ := record.EventAggregatorByReasonFunc(event)
+	return strings.Join([]string{key, event.Message}, "")
+}
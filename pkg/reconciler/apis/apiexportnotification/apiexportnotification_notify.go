@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportnotification
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+// apiBindingUpdated notifies about an APIBinding that became bound.
+func (c *controller) apiBindingUpdated(oldObj, newObj interface{}) {
+	oldBinding, ok := oldObj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIBinding, but is %T", oldObj))
+		return
+	}
+	newBinding, ok := newObj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIBinding, but is %T", newObj))
+		return
+	}
+
+	if oldBinding.IsBound() || !newBinding.IsBound() {
+		return
+	}
+
+	apiExport := c.apiExportFor(newBinding)
+	if apiExport == nil {
+		return
+	}
+
+	clusterName := logicalcluster.From(newBinding)
+	c.eventRecorder.AnnotatedEventf(apiExport, map[string]string{
+		apisv1alpha1.APIExportEventClusterAnnotationKey:    clusterName.String(),
+		apisv1alpha1.APIExportEventAPIBindingAnnotationKey: newBinding.Name,
+	}, corev1.EventTypeNormal, apisv1alpha1.APIExportBoundEventReason, "APIBinding %s in workspace %s bound the APIExport", newBinding.Name, clusterName)
+}
+
+// apiBindingDeleted notifies about a bound APIBinding that was deleted.
+func (c *controller) apiBindingDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj))
+		return
+	}
+
+	if !apiBinding.IsBound() {
+		return
+	}
+
+	apiExport := c.apiExportFor(apiBinding)
+	if apiExport == nil {
+		return
+	}
+
+	clusterName := logicalcluster.From(apiBinding)
+	c.eventRecorder.AnnotatedEventf(apiExport, map[string]string{
+		apisv1alpha1.APIExportEventClusterAnnotationKey:    clusterName.String(),
+		apisv1alpha1.APIExportEventAPIBindingAnnotationKey: apiBinding.Name,
+	}, corev1.EventTypeNormal, apisv1alpha1.APIExportUnboundEventReason, "APIBinding %s in workspace %s unbound the APIExport", apiBinding.Name, clusterName)
+}
+
+// instanceCreated notifies about an object of a bound resource that was created.
+func (c *controller) instanceCreated(gvr schema.GroupVersionResource, obj interface{}) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	if object.GetCreationTimestamp().Time.Before(c.startTime) {
+		// listed initially by the informer
+		return
+	}
+
+	c.notifyInstance(gvr, object, apisv1alpha1.APIExportInstanceCreatedEventReason, "created")
+}
+
+// instanceDeleted notifies about an object of a bound resource that was deleted.
+func (c *controller) instanceDeleted(gvr schema.GroupVersionResource, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	c.notifyInstance(gvr, object, apisv1alpha1.APIExportInstanceDeletedEventReason, "deleted")
+}
+
+func (c *controller) notifyInstance(gvr schema.GroupVersionResource, object metav1.Object, reason, verb string) {
+	clusterName := logicalcluster.From(object)
+	apiBindings, err := c.listAPIBindings(clusterName)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	apiBinding := boundBy(apiBindings, gvr.GroupResource())
+	if apiBinding == nil {
+		return
+	}
+	apiExport := c.apiExportFor(apiBinding)
+	if apiExport == nil {
+		return
+	}
+
+	name := object.GetName()
+	if ns := object.GetNamespace(); ns != "" {
+		name = ns + "/" + name
+	}
+	c.eventRecorder.AnnotatedEventf(apiExport, map[string]string{
+		apisv1alpha1.APIExportEventClusterAnnotationKey:    clusterName.String(),
+		apisv1alpha1.APIExportEventAPIBindingAnnotationKey: apiBinding.Name,
+		apisv1alpha1.APIExportEventResourceAnnotationKey:   gvr.GroupResource().String(),
+		apisv1alpha1.APIExportEventNamespaceAnnotationKey:  object.GetNamespace(),
+		apisv1alpha1.APIExportEventNameAnnotationKey:       object.GetName(),
+	}, corev1.EventTypeNormal, reason, "%s %s %s in workspace %s", gvr.GroupResource(), name, verb, clusterName)
+}
+
+// apiExportFor returns the APIExport referenced by the APIBinding, or nil if it is not known to this shard.
+func (c *controller) apiExportFor(apiBinding *apisv1alpha1.APIBinding) *apisv1alpha1.APIExport {
+	workspaceRef := apiBinding.ExportWorkspaceReference()
+	if workspaceRef == nil {
+		return nil
+	}
+
+	apiExportClusterName := logicalcluster.New(workspaceRef.Path)
+	if apiExportClusterName.Empty() {
+		apiExportClusterName = logicalcluster.From(apiBinding)
+	}
+	apiExport, err := c.getAPIExport(apiExportClusterName, workspaceRef.ExportName)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			runtime.HandleError(err)
+		}
+		logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), apiBinding)
+		logger.V(4).Info("APIExport not found, skipping notification", "apiExportCluster", apiExportClusterName, "apiExportName", workspaceRef.ExportName)
+		return nil
+	}
+	return apiExport
+}
+
+// boundBy returns the APIBinding binding the given resource, or nil.
+func boundBy(apiBindings []*apisv1alpha1.APIBinding, gr schema.GroupResource) *apisv1alpha1.APIBinding {
+	for _, apiBinding := range apiBindings {
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if boundResource.Group == gr.Group && boundResource.Resource == gr.Resource {
+				return apiBinding
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportnotification
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+type event struct {
+	object      string
+	annotations map[string]string
+	reason      string
+	message     string
+}
+
+type fakeRecorder struct {
+	events []event
+}
+
+func (r *fakeRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, "%s", message)
+}
+
+func (r *fakeRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, messageFmt, args...)
+}
+
+func (r *fakeRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.events = append(r.events, event{
+		object:      logicalcluster.From(object.(metav1.Object)).String() + "|" + object.(metav1.Object).GetName(),
+		annotations: annotations,
+		reason:      reason,
+		message:     fmt.Sprintf(messageFmt, args...),
+	})
+}
+
+func newController(recorder *fakeRecorder, startTime time.Time) *controller {
+	return &controller{
+		eventRecorder: recorder,
+		startTime:     startTime,
+		getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+			if clusterName.String() != "root:provider" || name != "wildwest" {
+				return nil, errors.NewNotFound(apisv1alpha1.Resource("apiexports"), name)
+			}
+			return &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "wildwest",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+				},
+			}, nil
+		},
+		listAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+			if clusterName.String() != "root:consumer" {
+				return nil, nil
+			}
+			return []*apisv1alpha1.APIBinding{newAPIBinding("wildwest", apisv1alpha1.APIBindingPhaseBound)}, nil
+		},
+	}
+}
+
+func newAPIBinding(exportName string, phase apisv1alpha1.APIBindingPhaseType) *apisv1alpha1.APIBinding {
+	return &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-binding",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+		},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: exportName},
+			},
+		},
+		Status: apisv1alpha1.APIBindingStatus{
+			Phase: phase,
+			BoundResources: []apisv1alpha1.BoundAPIResource{
+				{Group: "wildwest.dev", Resource: "cowboys"},
+			},
+		},
+	}
+}
+
+func TestAPIBindingNotifications(t *testing.T) {
+	bindingAnnotations := map[string]string{
+		apisv1alpha1.APIExportEventClusterAnnotationKey:    "root:consumer",
+		apisv1alpha1.APIExportEventAPIBindingAnnotationKey: "my-binding",
+	}
+
+	tests := map[string]struct {
+		notify func(c *controller)
+		want   []event
+	}{
+		"binding becomes bound": {
+			notify: func(c *controller) {
+				c.apiBindingUpdated(newAPIBinding("wildwest", apisv1alpha1.APIBindingPhaseBinding), newAPIBinding("wildwest", apisv1alpha1.APIBindingPhaseBound))
+			},
+			want: []event{{
+				object:      "root:provider|wildwest",
+				annotations: bindingAnnotations,
+				reason:      apisv1alpha1.APIExportBoundEventReason,
+				message:     "APIBinding my-binding in workspace root:consumer bound the APIExport",
+			}},
+		},
+		"bound binding is updated": {
+			notify: func(c *controller) {
+				c.apiBindingUpdated(newAPIBinding("wildwest", apisv1alpha1.APIBindingPhaseBound), newAPIBinding("wildwest", apisv1alpha1.APIBindingPhaseBound))
+			},
+		},
+		"binding of unknown export becomes bound": {
+			notify: func(c *controller) {
+				c.apiBindingUpdated(newAPIBinding("unknown", apisv1alpha1.APIBindingPhaseBinding), newAPIBinding("unknown", apisv1alpha1.APIBindingPhaseBound))
+			},
+		},
+		"bound binding is deleted": {
+			notify: func(c *controller) {
+				c.apiBindingDeleted(cache.DeletedFinalStateUnknown{Obj: newAPIBinding("wildwest", apisv1alpha1.APIBindingPhaseBound)})
+			},
+			want: []event{{
+				object:      "root:provider|wildwest",
+				annotations: bindingAnnotations,
+				reason:      apisv1alpha1.APIExportUnboundEventReason,
+				message:     "APIBinding my-binding in workspace root:consumer unbound the APIExport",
+			}},
+		},
+		"binding is deleted before being bound": {
+			notify: func(c *controller) {
+				c.apiBindingDeleted(newAPIBinding("wildwest", apisv1alpha1.APIBindingPhaseBinding))
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			recorder := &fakeRecorder{}
+			tc.notify(newController(recorder, time.Now()))
+			require.Equal(t, tc.want, recorder.events)
+		})
+	}
+}
+
+func TestInstanceNotifications(t *testing.T) {
+	startTime := time.Now()
+	cowboys := schema.GroupVersionResource{Group: "wildwest.dev", Version: "v1", Resource: "cowboys"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	newObject := func(clusterName string, created time.Time) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetNamespace("default")
+		obj.SetName("billy")
+		obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: clusterName})
+		obj.SetCreationTimestamp(metav1.NewTime(created))
+		return obj
+	}
+	instanceAnnotations := map[string]string{
+		apisv1alpha1.APIExportEventClusterAnnotationKey:    "root:consumer",
+		apisv1alpha1.APIExportEventAPIBindingAnnotationKey: "my-binding",
+		apisv1alpha1.APIExportEventResourceAnnotationKey:   "cowboys.wildwest.dev",
+		apisv1alpha1.APIExportEventNamespaceAnnotationKey:  "default",
+		apisv1alpha1.APIExportEventNameAnnotationKey:       "billy",
+	}
+
+	tests := map[string]struct {
+		notify func(c *controller)
+		want   []event
+	}{
+		"instance is created": {
+			notify: func(c *controller) {
+				c.instanceCreated(cowboys, newObject("root:consumer", startTime.Add(time.Minute)))
+			},
+			want: []event{{
+				object:      "root:provider|wildwest",
+				annotations: instanceAnnotations,
+				reason:      apisv1alpha1.APIExportInstanceCreatedEventReason,
+				message:     "cowboys.wildwest.dev default/billy created in workspace root:consumer",
+			}},
+		},
+		"instance created before the controller started": {
+			notify: func(c *controller) {
+				c.instanceCreated(cowboys, newObject("root:consumer", startTime.Add(-time.Minute)))
+			},
+		},
+		"instance is deleted": {
+			notify: func(c *controller) {
+				c.instanceDeleted(cowboys, cache.DeletedFinalStateUnknown{Obj: newObject("root:consumer", startTime.Add(-time.Minute))})
+			},
+			want: []event{{
+				object:      "root:provider|wildwest",
+				annotations: instanceAnnotations,
+				reason:      apisv1alpha1.APIExportInstanceDeletedEventReason,
+				message:     "cowboys.wildwest.dev default/billy deleted in workspace root:consumer",
+			}},
+		},
+		"resource is not bound": {
+			notify: func(c *controller) {
+				c.instanceCreated(configMaps, newObject("root:consumer", startTime.Add(time.Minute)))
+			},
+		},
+		"resource is not bound in the workspace": {
+			notify: func(c *controller) {
+				c.instanceDeleted(cowboys, newObject("root:other", startTime.Add(time.Minute)))
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			recorder := &fakeRecorder{}
+			tc.notify(newController(recorder, startTime))
+			require.Equal(t, tc.want, recorder.events)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportusage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-apiexport-usage"
+
+	// resyncPeriod is the period after which the usage of an APIExport is recomputed, in order
+	// to pick up changes of objects of the bound resources.
+	resyncPeriod = 5 * time.Minute
+)
+
+// NewController returns a new controller that aggregates the consumption of APIExports by APIBindings
+// into the status of the APIExports.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	metadataClient metadata.Interface,
+	apiExportInformer apisinformers.APIExportInformer,
+	apiBindingInformer apisinformers.APIBindingInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:           queue,
+		apiExportLister: apiExportInformer.Lister(),
+		listAPIBindingsForAPIExport: func(apiExport *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error) {
+			key := clusters.ToClusterAwareKey(logicalcluster.From(apiExport), apiExport.Name)
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingByAPIExport, key)
+		},
+		listObjectMetadata: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*metav1.PartialObjectMetadataList, error) {
+			return metadataClient.Resource(gvr).Namespace(metav1.NamespaceAll).List(logicalcluster.WithCluster(ctx, clusterName), metav1.ListOptions{})
+		},
+		now:    time.Now,
+		commit: committer.NewCommitter[*APIExport, *APIExportSpec, *APIExportStatus](kcpClusterClient.ApisV1alpha1().APIExports()),
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		apiBindingInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.APIBindingByAPIExport: indexers.IndexAPIBindingByAPIExport,
+		},
+	)
+
+	// Status updates of APIExports do not change their usage, only new APIExports are queued here.
+	// Existing ones are requeued periodically.
+	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAPIExport(obj)
+		},
+	})
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAPIBinding(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueAPIBinding(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueAPIBinding(obj)
+		},
+	})
+
+	return c, nil
+}
+
+type APIExport = apisv1alpha1.APIExport
+type APIExportSpec = apisv1alpha1.APIExportSpec
+type APIExportStatus = apisv1alpha1.APIExportStatus
+type Resource = committer.Resource[*APIExportSpec, *APIExportStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// controller reconciles the usage of APIExports in their status.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	apiExportLister apislisters.APIExportLister
+
+	listAPIBindingsForAPIExport func(apiExport *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error)
+	listObjectMetadata          func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*metav1.PartialObjectMetadataList, error)
+
+	now    func() time.Time
+	commit CommitFunc
+}
+
+// enqueueAPIExport enqueues an APIExport.
+func (c *controller) enqueueAPIExport(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing APIExport")
+	c.queue.Add(key)
+}
+
+// enqueueAPIBinding enqueues the APIExport referenced by an APIBinding.
+func (c *controller) enqueueAPIBinding(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj))
+		return
+	}
+
+	keys, err := indexers.IndexAPIBindingByAPIExport(apiBinding)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), apiBinding)
+	for _, key := range keys {
+		logging.WithQueueKey(logger, key).V(4).Info("queueing APIExport because of APIBinding")
+		c.queue.Add(key)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.apiExportLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	if err := c.reconcile(ctx, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	// If the object being reconciled changed as a result, update it.
+	oldResource := &Resource{ObjectMeta: old.ObjectMeta, Spec: &old.Spec, Status: &old.Status}
+	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
+	if err := c.commit(ctx, oldResource, newResource); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		// objects of the bound resources are not watched, recompute the usage periodically
+		c.queue.AddAfter(key, resyncPeriod)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportusage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+// maxConsumers is the maximal number of consumers listed in the usage of an APIExport, in order
+// to bound the size of the APIExport.
+const maxConsumers = 500
+
+func (c *controller) reconcile(ctx context.Context, apiExport *apisv1alpha1.APIExport) error {
+	apiBindings, err := c.listAPIBindingsForAPIExport(apiExport)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(apiBindings, func(i, j int) bool {
+		if ci, cj := logicalcluster.From(apiBindings[i]), logicalcluster.From(apiBindings[j]); ci != cj {
+			return ci.String() < cj.String()
+		}
+		return apiBindings[i].Name < apiBindings[j].Name
+	})
+
+	previous := map[consumerKey]*apisv1alpha1.APIExportConsumer{}
+	if apiExport.Status.Usage != nil {
+		for i := range apiExport.Status.Usage.Consumers {
+			consumer := &apiExport.Status.Usage.Consumers[i]
+			previous[consumerKey{workspace: consumer.Workspace, apiBinding: consumer.APIBinding}] = consumer
+		}
+	}
+
+	usage := &apisv1alpha1.APIExportUsage{
+		BindingCount: int32(len(apiBindings)),
+	}
+
+	var errs []error
+	for _, apiBinding := range apiBindings {
+		if len(usage.Consumers) == maxConsumers {
+			break
+		}
+
+		consumer, err := c.consumerUsage(ctx, apiBinding)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if prev, found := previous[consumerKey{workspace: consumer.Workspace, apiBinding: consumer.APIBinding}]; found {
+			if err != nil {
+				// keep the last known usage if the objects cannot be listed
+				consumer = *prev
+			} else if prev.ObjectCount != consumer.ObjectCount && !laterThan(consumer.LastActivityTime, prev.LastActivityTime) {
+				// objects were deleted, which is not visible in the remaining objects
+				now := metav1.NewTime(c.now())
+				consumer.LastActivityTime = &now
+			} else if laterThan(prev.LastActivityTime, consumer.LastActivityTime) {
+				consumer.LastActivityTime = prev.LastActivityTime
+			}
+		}
+
+		usage.Consumers = append(usage.Consumers, consumer)
+	}
+
+	apiExport.Status.Usage = usage
+
+	return utilerrors.NewAggregate(errs)
+}
+
+type consumerKey struct {
+	workspace  string
+	apiBinding string
+}
+
+// consumerUsage counts the objects of the bound resources of the given APIBinding, and returns the
+// last time one of them was created or updated.
+func (c *controller) consumerUsage(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) (apisv1alpha1.APIExportConsumer, error) {
+	clusterName := logicalcluster.From(apiBinding)
+	consumer := apisv1alpha1.APIExportConsumer{
+		Workspace:  clusterName.String(),
+		APIBinding: apiBinding.Name,
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), apiBinding)
+
+	for _, boundResource := range apiBinding.Status.BoundResources {
+		if len(boundResource.StorageVersions) == 0 {
+			continue
+		}
+
+		// the latest storage version is served by the bound CRD
+		gvr := schema.GroupVersionResource{
+			Group:    boundResource.Group,
+			Version:  boundResource.StorageVersions[len(boundResource.StorageVersions)-1],
+			Resource: boundResource.Resource,
+		}
+
+		list, err := c.listObjectMetadata(ctx, clusterName, gvr)
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("bound resource not served yet", "resource", gvr)
+			continue
+		}
+		if err != nil {
+			return consumer, fmt.Errorf("error listing %s in %s: %w", gvr, clusterName, err)
+		}
+
+		consumer.ObjectCount += int64(len(list.Items))
+		for i := range list.Items {
+			if t := lastActivityTime(&list.Items[i].ObjectMeta); laterThan(t, consumer.LastActivityTime) {
+				consumer.LastActivityTime = t
+			}
+		}
+	}
+
+	return consumer, nil
+}
+
+// lastActivityTime returns the latest of the creation, deletion and managed fields timestamps.
+func lastActivityTime(obj *metav1.ObjectMeta) *metav1.Time {
+	latest := obj.CreationTimestamp.DeepCopy()
+	if laterThan(obj.DeletionTimestamp, latest) {
+		latest = obj.DeletionTimestamp.DeepCopy()
+	}
+	for i := range obj.ManagedFields {
+		if laterThan(obj.ManagedFields[i].Time, latest) {
+			latest = obj.ManagedFields[i].Time.DeepCopy()
+		}
+	}
+	if latest.IsZero() {
+		return nil
+	}
+	return latest
+}
+
+func laterThan(a, b *metav1.Time) bool {
+	if a == nil || a.IsZero() {
+		return false
+	}
+	if b == nil || b.IsZero() {
+		return true
+	}
+	return b.Before(a)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportusage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-2 * time.Hour))
+	updated := metav1.NewTime(now.Add(-time.Hour))
+	earlier := metav1.NewTime(now.Add(-30 * time.Minute))
+	nowTime := metav1.NewTime(now)
+
+	binding := func(cluster, name string, resources ...string) *apisv1alpha1.APIBinding {
+		b := &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+			},
+		}
+		for _, r := range resources {
+			b.Status.BoundResources = append(b.Status.BoundResources, apisv1alpha1.BoundAPIResource{
+				Group:           "example.io",
+				Resource:        r,
+				StorageVersions: []string{"v1alpha1", "v1"},
+			})
+		}
+		return b
+	}
+
+	object := func(creation metav1.Time, managed ...metav1.Time) metav1.PartialObjectMetadata {
+		obj := metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: creation}}
+		for i := range managed {
+			obj.ManagedFields = append(obj.ManagedFields, metav1.ManagedFieldsEntry{Time: &managed[i]})
+		}
+		return obj
+	}
+
+	tests := map[string]struct {
+		apiBindings []*apisv1alpha1.APIBinding
+		objects     map[string][]metav1.PartialObjectMetadata
+		listErrors  map[string]error
+		previous    *apisv1alpha1.APIExportUsage
+
+		wantUsage *apisv1alpha1.APIExportUsage
+		wantError bool
+	}{
+		"no bindings": {
+			wantUsage: &apisv1alpha1.APIExportUsage{},
+		},
+		"objects are counted per consumer": {
+			apiBindings: []*apisv1alpha1.APIBinding{
+				binding("root:org:b", "binding", "widgets", "gadgets"),
+				binding("root:org:a", "binding", "widgets"),
+			},
+			objects: map[string][]metav1.PartialObjectMetadata{
+				"root:org:a|widgets": {object(created), object(created, updated)},
+				"root:org:b|widgets": {object(created)},
+				"root:org:b|gadgets": {object(created, earlier)},
+			},
+			wantUsage: &apisv1alpha1.APIExportUsage{
+				BindingCount: 2,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 2, LastActivityTime: &updated},
+					{Workspace: "root:org:b", APIBinding: "binding", ObjectCount: 2, LastActivityTime: &earlier},
+				},
+			},
+		},
+		"resources not served yet are skipped": {
+			apiBindings: []*apisv1alpha1.APIBinding{
+				binding("root:org:a", "binding", "widgets", "gadgets"),
+			},
+			objects: map[string][]metav1.PartialObjectMetadata{
+				"root:org:a|widgets": {object(created)},
+			},
+			wantUsage: &apisv1alpha1.APIExportUsage{
+				BindingCount: 1,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 1, LastActivityTime: &created},
+				},
+			},
+		},
+		"deleted objects are recorded as activity": {
+			apiBindings: []*apisv1alpha1.APIBinding{
+				binding("root:org:a", "binding", "widgets"),
+			},
+			objects: map[string][]metav1.PartialObjectMetadata{
+				"root:org:a|widgets": {object(created)},
+			},
+			previous: &apisv1alpha1.APIExportUsage{
+				BindingCount: 1,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 2, LastActivityTime: &updated},
+				},
+			},
+			wantUsage: &apisv1alpha1.APIExportUsage{
+				BindingCount: 1,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 1, LastActivityTime: &nowTime},
+				},
+			},
+		},
+		"last activity does not go back in time": {
+			apiBindings: []*apisv1alpha1.APIBinding{
+				binding("root:org:a", "binding", "widgets"),
+			},
+			objects: map[string][]metav1.PartialObjectMetadata{
+				"root:org:a|widgets": {object(created)},
+			},
+			previous: &apisv1alpha1.APIExportUsage{
+				BindingCount: 1,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 1, LastActivityTime: &updated},
+				},
+			},
+			wantUsage: &apisv1alpha1.APIExportUsage{
+				BindingCount: 1,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 1, LastActivityTime: &updated},
+				},
+			},
+		},
+		"last known usage is kept on list errors": {
+			apiBindings: []*apisv1alpha1.APIBinding{
+				binding("root:org:a", "binding", "widgets"),
+				binding("root:org:b", "binding", "widgets"),
+			},
+			objects: map[string][]metav1.PartialObjectMetadata{
+				"root:org:b|widgets": {object(created)},
+			},
+			listErrors: map[string]error{
+				"root:org:a|widgets": errors.New("boom"),
+			},
+			previous: &apisv1alpha1.APIExportUsage{
+				BindingCount: 1,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 5, LastActivityTime: &updated},
+				},
+			},
+			wantUsage: &apisv1alpha1.APIExportUsage{
+				BindingCount: 2,
+				Consumers: []apisv1alpha1.APIExportConsumer{
+					{Workspace: "root:org:a", APIBinding: "binding", ObjectCount: 5, LastActivityTime: &updated},
+					{Workspace: "root:org:b", APIBinding: "binding", ObjectCount: 1, LastActivityTime: &created},
+				},
+			},
+			wantError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &controller{
+				listAPIBindingsForAPIExport: func(apiExport *apisv1alpha1.APIExport) ([]*apisv1alpha1.APIBinding, error) {
+					return tc.apiBindings, nil
+				},
+				listObjectMetadata: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*metav1.PartialObjectMetadataList, error) {
+					require.Equal(t, "v1", gvr.Version, "expected the latest storage version")
+					key := clusterName.String() + "|" + gvr.Resource
+					if err := tc.listErrors[key]; err != nil {
+						return nil, err
+					}
+					items, found := tc.objects[key]
+					if !found {
+						return nil, apierrors.NewNotFound(gvr.GroupResource(), "")
+					}
+					return &metav1.PartialObjectMetadataList{Items: items}, nil
+				},
+				now: func() time.Time { return now },
+			}
+
+			apiExport := &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "export",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:provider"},
+				},
+				Status: apisv1alpha1.APIExportStatus{
+					Usage: tc.previous,
+				},
+			}
+
+			err := c.reconcile(context.Background(), apiExport)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantUsage, apiExport.Status.Usage)
+		})
+	}
+}
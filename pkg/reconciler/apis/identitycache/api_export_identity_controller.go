@@ -35,7 +35,10 @@ import (
 	configshard "github.com/kcp-dev/kcp/config/shard"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
@@ -52,34 +55,33 @@ const (
 //
 // The config map is meant to be used by clients/informers to inject the identities
 // for the given GRs when making requests to the server.
+//
+// The APIExports are either watched on the root shard, or replicated from it through
+// the cache server. Replicated APIExports carrying a sealed identity key are only
+// accepted if the key derives their identity hash. If requireSealedIdentity is set,
+// APIExports without a sealed identity key are not accepted at all.
 func NewApiExportIdentityProviderController(
 	kubeClusterClient kubernetesclient.ClusterInterface,
 	remoteShardApiExportInformer apisinformers.APIExportInformer,
 	configMapInformer coreinformers.ConfigMapInformer,
+	identityProvider apiexport.IdentityProvider,
+	requireSealedIdentity bool,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
 
 	c := &controller{
 		queue:                        queue,
 		kubeClient:                   kubeClusterClient.Cluster(configshard.SystemShardCluster),
 		configMapLister:              configMapInformer.Lister(),
 		remoteShardApiExportsIndexer: remoteShardApiExportInformer.Informer().GetIndexer(),
+		identityProvider:             identityProvider,
+		requireSealedIdentity:        requireSealedIdentity,
 	}
 
-	remoteShardApiExportInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: func(obj interface{}) bool {
-			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
-			if err != nil {
-				return false
-			}
-			clusterName, _ := clusters.SplitClusterAwareKey(key)
-			return clusterName == tenancyv1alpha1.RootCluster
-		},
-		Handler: cache.ResourceEventHandlerFuncs{
-			AddFunc:    func(obj interface{}) { c.queue.Add(workKey) },
-			UpdateFunc: func(old, new interface{}) { c.queue.Add(workKey) },
-			DeleteFunc: func(obj interface{}) { c.queue.Add(workKey) },
-		},
+	informer.NewScopedInformer(tenancyv1alpha1.RootCluster, remoteShardApiExportInformer.Informer()).AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.queue.Add(workKey) },
+		UpdateFunc: func(old, new interface{}) { c.queue.Add(workKey) },
+		DeleteFunc: func(obj interface{}) { c.queue.Add(workKey) },
 	})
 
 	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
@@ -156,4 +158,6 @@ type controller struct {
 	kubeClient                   kubernetesclient.Interface
 	configMapLister              corelisters.ConfigMapLister
 	remoteShardApiExportsIndexer cache.Indexer
+	identityProvider             apiexport.IdentityProvider
+	requireSealedIdentity        bool
 }
@@ -18,6 +18,8 @@ package identitycache
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -28,7 +30,9 @@ import (
 	configshard "github.com/kcp-dev/kcp/config/shard"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
 	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
 )
 
 func (c *controller) reconcile(ctx context.Context) error {
@@ -48,6 +52,9 @@ func (c *controller) reconcile(ctx context.Context) error {
 		if apiExport.Status.IdentityHash == "" {
 			return nil // we cannot do anything here, we will get notified when an identity is assigned.
 		}
+		if err := c.verifySealedIdentity(ctx, apiExport); err != nil {
+			return err
+		}
 		requiredApiExportIdentitiesConfigMap.Data[apiExport.Name] = apiExport.Status.IdentityHash
 	}
 
@@ -67,3 +74,23 @@ func (c *controller) reconcile(ctx context.Context) error {
 	}
 	return nil
 }
+
+// verifySealedIdentity checks the identity hash of an APIExport replicated with its sealed identity key.
+// If sealed identity keys are required, copies without one are rejected.
+func (c *controller) verifySealedIdentity(ctx context.Context, apiExport *apisv1alpha1.APIExport) error {
+	encoded, found := apiExport.Annotations[replication.SealedIdentityAnnotationKey]
+	if !found {
+		if c.requireSealedIdentity {
+			return fmt.Errorf("APIExport %s has no sealed identity", apiExport.Name)
+		}
+		return nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("failed to decode the sealed identity of APIExport %s: %w", apiExport.Name, err)
+	}
+	if err := apiexport.VerifySealedIdentity(ctx, c.identityProvider, sealed, apiExport.Status.IdentityHash); err != nil {
+		return fmt.Errorf("failed to verify the identity of APIExport %s: %w", apiExport.Name, err)
+	}
+	return nil
+}
@@ -18,6 +18,8 @@ package identitycache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"testing"
 
@@ -28,13 +30,16 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/storage/value"
 	"k8s.io/client-go/kubernetes/fake"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	clientgotesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
 	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
 )
 
 func TestReconcile(t *testing.T) {
@@ -43,6 +48,8 @@ func TestReconcile(t *testing.T) {
 		initialApiExports []runtime.Object
 		initialConfigMap  []runtime.Object
 		validateFunc      func(ts *testing.T, actions []clientgotesting.Action)
+		requireSealed     bool
+		wantErr           bool
 	}{
 		{
 			name: "scenario 1: happy path, cm doesn't exist",
@@ -131,11 +138,55 @@ func TestReconcile(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "scenario 4: replicated sealed identity verified",
+			initialApiExports: []runtime.Object{
+				newReplicatedAPIExport("export-1", "key-1", "key-1"),
+			},
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action) {
+				for _, action := range actions {
+					if action.Matches("create", "configmaps") {
+						configMap := action.(clientgotesting.CreateAction).GetObject().(*corev1.ConfigMap)
+						if configMap.Data["export-1"] != fmt.Sprintf("%x", sha256.Sum256([]byte("key-1"))) {
+							ts.Errorf("unexpected identity %q", configMap.Data["export-1"])
+						}
+						return
+					}
+				}
+				ts.Errorf("the config map wasn't created")
+			},
+		},
+		{
+			name: "scenario 5: replicated sealed identity not matching its hash",
+			initialApiExports: []runtime.Object{
+				newReplicatedAPIExport("export-1", "key-1", "other-key"),
+			},
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action) {
+				if len(actions) != 0 {
+					t.Fatal("didn't expect any changes to the configmap")
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "scenario 6: replicated identity without sealed key while sealed keys are required",
+			initialApiExports: []runtime.Object{
+				newReplicatedAPIExport("export-1", "key-1", "key-1"),
+				newAPIExport("export-2"),
+			},
+			requireSealed: true,
+			validateFunc: func(ts *testing.T, actions []clientgotesting.Action) {
+				if len(actions) != 0 {
+					t.Fatal("didn't expect any changes to the configmap")
+				}
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, scenario := range scenarios {
 		t.Run(scenario.name, func(t *testing.T) {
-			target := &controller{}
+			target := &controller{identityProvider: apiexport.NewSealedIdentityProvider(value.IdentityTransformer), requireSealedIdentity: scenario.requireSealed}
 			fakeKubeClient := fake.NewSimpleClientset(scenario.initialConfigMap...)
 			target.kubeClient = fakeKubeClient
 			target.remoteShardApiExportsIndexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexers.ByLogicalCluster: indexers.IndexByLogicalCluster})
@@ -152,8 +203,8 @@ func TestReconcile(t *testing.T) {
 			}
 			target.configMapLister = corelisters.NewConfigMapLister(configMapIndexer)
 
-			if err := target.reconcile(context.TODO()); err != nil {
-				t.Fatal(err)
+			if err := target.reconcile(context.TODO()); (err != nil) != scenario.wantErr {
+				t.Fatalf("unexpected error: %v", err)
 			}
 			if scenario.validateFunc != nil {
 				scenario.validateFunc(t, fakeKubeClient.Actions())
@@ -176,6 +227,15 @@ func newAPIExport(name string) *apisv1alpha1.APIExport {
 	}
 }
 
+// newReplicatedAPIExport returns an APIExport as replicated through the cache server, with the hash of the given
+// key and the given sealed key. Sealing is the identity transformation in these tests.
+func newReplicatedAPIExport(name, key, sealedKey string) *apisv1alpha1.APIExport {
+	apiExport := newAPIExport(name)
+	apiExport.Annotations[replication.SealedIdentityAnnotationKey] = base64.StdEncoding.EncodeToString([]byte(sealedKey))
+	apiExport.Status.IdentityHash = fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+	return apiExport
+}
+
 func newEmptyRequiredConfigmap() *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,249 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageversionmigration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiextensionsinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-storage-version-migration"
+
+	// migrationChunkSize is the number of objects of a bound resource rewritten per reconciliation.
+	migrationChunkSize = 500
+
+	byBoundSchemaUID = controllerName + "-byBoundSchemaUID"
+)
+
+// NewController returns a new controller that rewrites the stored objects of resources bound by
+// APIBindings in the current storage version of their bound CRD, and reports the progress in the
+// status of the APIBindings. Once all objects are migrated, the old versions are dropped from the
+// storageVersions of the bound resource, and from the storedVersions of the bound CRD when no other
+// APIBinding of the CRD has objects stored in them either.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	crdClusterClient apiextensionsclient.Interface,
+	dynamicClusterClient dynamic.Interface,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	crdInformer apiextensionsinformers.CustomResourceDefinitionInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:            queue,
+		apiBindingLister: apiBindingInformer.Lister(),
+		listAPIBindingsBySchemaUID: func(uid string) ([]*apisv1alpha1.APIBinding, error) {
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), byBoundSchemaUID, uid)
+		},
+		getCRD: func(clusterName logicalcluster.Name, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+			return crdInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		updateCRDStatus: func(ctx context.Context, clusterName logicalcluster.Name, crd *apiextensionsv1.CustomResourceDefinition) error {
+			_, err := crdClusterClient.ApiextensionsV1().CustomResourceDefinitions().UpdateStatus(logicalcluster.WithCluster(ctx, clusterName), crd, metav1.UpdateOptions{})
+			return err
+		},
+		listObjects: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, continueToken string) (*unstructured.UnstructuredList, error) {
+			return dynamicClusterClient.Resource(gvr).Namespace(metav1.NamespaceAll).List(logicalcluster.WithCluster(ctx, clusterName), metav1.ListOptions{
+				Limit:    migrationChunkSize,
+				Continue: continueToken,
+			})
+		},
+		updateObject: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+			_, err := dynamicClusterClient.Resource(gvr).Namespace(obj.GetNamespace()).Update(logicalcluster.WithCluster(ctx, clusterName), obj, metav1.UpdateOptions{})
+			return err
+		},
+		now:    time.Now,
+		commit: committer.NewCommitter[*APIBinding, *APIBindingSpec, *APIBindingStatus](kcpClusterClient.ApisV1alpha1().APIBindings()),
+	}
+
+	indexers.AddIfNotPresentOrDie(apiBindingInformer.Informer().GetIndexer(), cache.Indexers{
+		byBoundSchemaUID: indexByBoundSchemaUID,
+	})
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueAPIBinding(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueAPIBinding(newObj)
+		},
+	})
+
+	return c, nil
+}
+
+type APIBinding = apisv1alpha1.APIBinding
+type APIBindingSpec = apisv1alpha1.APIBindingSpec
+type APIBindingStatus = apisv1alpha1.APIBindingStatus
+type Resource = committer.Resource[*APIBindingSpec, *APIBindingStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// controller migrates the stored objects of bound resources to their current storage version.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	apiBindingLister           apislisters.APIBindingLister
+	listAPIBindingsBySchemaUID func(uid string) ([]*apisv1alpha1.APIBinding, error)
+
+	getCRD          func(clusterName logicalcluster.Name, name string) (*apiextensionsv1.CustomResourceDefinition, error)
+	updateCRDStatus func(ctx context.Context, clusterName logicalcluster.Name, crd *apiextensionsv1.CustomResourceDefinition) error
+	listObjects     func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, continueToken string) (*unstructured.UnstructuredList, error)
+	updateObject    func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error
+
+	now    func() time.Time
+	commit CommitFunc
+}
+
+// indexByBoundSchemaUID indexes APIBindings by the UIDs of the APIResourceSchemas of their bound resources,
+// which are the names of the bound CRDs.
+func indexByBoundSchemaUID(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj)
+	}
+
+	uids := make([]string, 0, len(apiBinding.Status.BoundResources))
+	for _, r := range apiBinding.Status.BoundResources {
+		uids = append(uids, r.Schema.UID)
+	}
+	return uids, nil
+}
+
+// enqueueAPIBinding enqueues an APIBinding.
+func (c *controller) enqueueAPIBinding(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing APIBinding")
+	c.queue.Add(key)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.apiBindingLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	requeue, err := c.reconcile(ctx, obj)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// If the object being reconciled changed as a result, update it.
+	oldResource := &Resource{ObjectMeta: old.ObjectMeta, Spec: &old.Spec, Status: &old.Status}
+	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
+	if err := c.commit(ctx, oldResource, newResource); err != nil {
+		errs = append(errs, err)
+	}
+
+	if requeue && len(errs) == 0 {
+		// continue with the next chunk of objects
+		c.queue.Add(key)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
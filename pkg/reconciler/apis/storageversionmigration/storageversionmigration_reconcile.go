@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageversionmigration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
+)
+
+// reconcile migrates the next chunk of stored objects of every bound resource that has objects
+// stored in other versions than the current storage version. It returns whether more objects
+// are left to be migrated.
+func (c *controller) reconcile(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) (bool, error) {
+	if !apiBinding.IsBound() {
+		return false, nil
+	}
+
+	clusterName := logicalcluster.From(apiBinding)
+
+	var inProgress, failed []string
+	var errs []error
+	migrated := false
+	for i := range apiBinding.Status.BoundResources {
+		boundResource := &apiBinding.Status.BoundResources[i]
+		groupResource := schema.GroupResource{Group: boundResource.Group, Resource: boundResource.Resource}
+
+		storageVersion, err := c.storageVersion(boundResource)
+		if err != nil {
+			errs = append(errs, err)
+			failed = append(failed, groupResource.String())
+			continue
+		}
+
+		if needsMigration(boundResource, storageVersion) {
+			migrated = true
+
+			done, err := c.migrate(ctx, clusterName, boundResource, storageVersion)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error migrating %s to version %s: %w", groupResource, storageVersion, err))
+				failed = append(failed, groupResource.String())
+				continue
+			}
+			if !done {
+				inProgress = append(inProgress, groupResource.String())
+				continue
+			}
+		}
+		boundResource.StorageVersionMigration = nil
+
+		if err := c.trimStoredVersions(ctx, apiBinding, boundResource, storageVersion); err != nil {
+			errs = append(errs, fmt.Errorf("error trimming the stored versions of %s to version %s: %w", groupResource, storageVersion, err))
+			failed = append(failed, groupResource.String())
+		}
+	}
+
+	switch {
+	case len(errs) > 0:
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.StorageVersionsMigrated,
+			apisv1alpha1.StorageVersionMigrationFailedReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"Failed to migrate stored objects of %s: %v",
+			strings.Join(failed, ", "),
+			utilerrors.NewAggregate(errs),
+		)
+	case len(inProgress) > 0:
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.StorageVersionsMigrated,
+			apisv1alpha1.StorageVersionMigrationInProgressReason,
+			conditionsv1alpha1.ConditionSeverityInfo,
+			"Migrating stored objects of %s to the current storage version",
+			strings.Join(inProgress, ", "),
+		)
+	case migrated || conditions.Has(apiBinding, apisv1alpha1.StorageVersionsMigrated):
+		conditions.MarkTrue(apiBinding, apisv1alpha1.StorageVersionsMigrated)
+	}
+
+	return len(inProgress) > 0, utilerrors.NewAggregate(errs)
+}
+
+// storageVersion returns the current storage version of the bound CRD of the given resource.
+func (c *controller) storageVersion(boundResource *apisv1alpha1.BoundAPIResource) (string, error) {
+	crd, err := c.getCRD(apibinding.ShadowWorkspaceName, boundResource.Schema.UID)
+	if err != nil {
+		return "", fmt.Errorf("error getting bound CRD %s|%s: %w", apibinding.ShadowWorkspaceName, boundResource.Schema.UID, err)
+	}
+
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name, nil
+		}
+	}
+	return "", fmt.Errorf("bound CRD %s|%s has no storage version", apibinding.ShadowWorkspaceName, crd.Name)
+}
+
+// trimStoredVersions drops all versions but the storage version from the storedVersions of the bound CRD
+// of the given resource, once no APIBinding of the CRD has objects left that might be stored in them. The
+// given APIBinding is expected to be migrated already, the others are taken from the informer.
+func (c *controller) trimStoredVersions(ctx context.Context, apiBinding *apisv1alpha1.APIBinding, boundResource *apisv1alpha1.BoundAPIResource, storageVersion string) error {
+	crd, err := c.getCRD(apibinding.ShadowWorkspaceName, boundResource.Schema.UID)
+	if err != nil {
+		return err
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		return nil
+	}
+
+	others, err := c.listAPIBindingsBySchemaUID(boundResource.Schema.UID)
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		if logicalcluster.From(other) == logicalcluster.From(apiBinding) && other.Name == apiBinding.Name {
+			continue
+		}
+		for i := range other.Status.BoundResources {
+			r := &other.Status.BoundResources[i]
+			if r.Schema.UID == boundResource.Schema.UID && needsMigration(r, storageVersion) {
+				return nil // trimmed once the other binding is migrated
+			}
+		}
+	}
+
+	logger := klog.FromContext(ctx).WithValues("crd", crd.Name, "storedVersions", crd.Status.StoredVersions, "storageVersion", storageVersion)
+	logger.V(2).Info("trimming stored versions of bound CRD")
+
+	crd = crd.DeepCopy()
+	crd.Status.StoredVersions = []string{storageVersion}
+	return c.updateCRDStatus(ctx, apibinding.ShadowWorkspaceName, crd)
+}
+
+// needsMigration returns whether objects of the bound resource might be stored in another version
+// than the given storage version.
+func needsMigration(boundResource *apisv1alpha1.BoundAPIResource, storageVersion string) bool {
+	for _, version := range boundResource.StorageVersions {
+		if version != storageVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// migrate rewrites the next chunk of stored objects of the bound resource in the given storage version. Writing
+// an object unchanged makes the apiserver encode it in the storage version, objects that are already stored in that
+// version are not written at all. It returns whether all objects have been migrated, in which case the other
+// storage versions are dropped from the bound resource.
+func (c *controller) migrate(ctx context.Context, clusterName logicalcluster.Name, boundResource *apisv1alpha1.BoundAPIResource, storageVersion string) (bool, error) {
+	logger := klog.FromContext(ctx).WithValues("group", boundResource.Group, "resource", boundResource.Resource, "storageVersion", storageVersion)
+
+	migration := boundResource.StorageVersionMigration
+	if migration == nil || migration.TargetVersion != storageVersion {
+		now := metav1.NewTime(c.now())
+		migration = &apisv1alpha1.StorageVersionMigration{
+			TargetVersion: storageVersion,
+			StartTime:     &now,
+		}
+		boundResource.StorageVersionMigration = migration
+		logger.V(2).Info("starting storage version migration", "storageVersions", boundResource.StorageVersions)
+	}
+
+	gvr := schema.GroupVersionResource{Group: boundResource.Group, Version: storageVersion, Resource: boundResource.Resource}
+	list, err := c.listObjects(ctx, clusterName, gvr, migration.Continue)
+	if errors.IsResourceExpired(err) {
+		// the continue token expired, start over
+		logger.V(2).Info("restarting storage version migration because the continue token expired")
+		migration.Continue = ""
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for i := range list.Items {
+		obj := &list.Items[i]
+		err := c.updateObject(ctx, clusterName, gvr, obj)
+		switch {
+		case errors.IsNotFound(err):
+			// deleted in the meantime, nothing left to migrate
+			continue
+		case errors.IsConflict(err):
+			// written in the meantime, which migrated it as well
+		case err != nil:
+			return false, err
+		}
+		migration.MigratedObjects++
+	}
+
+	migration.Continue = list.GetContinue()
+	if migration.Continue != "" {
+		logger.V(4).Info("migrated chunk of stored objects", "migratedObjects", migration.MigratedObjects)
+		return false, nil
+	}
+
+	logger.V(2).Info("finished storage version migration", "migratedObjects", migration.MigratedObjects)
+	boundResource.StorageVersions = []string{storageVersion}
+	boundResource.StorageVersionMigration = nil
+	return true, nil
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageversionmigration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	started := metav1.NewTime(now.Add(-time.Hour))
+	nowTime := metav1.NewTime(now)
+
+	binding := func(storageVersions []string, migration *apisv1alpha1.StorageVersionMigration) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "widgets",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+			},
+			Status: apisv1alpha1.APIBindingStatus{
+				Phase: apisv1alpha1.APIBindingPhaseBound,
+				BoundResources: []apisv1alpha1.BoundAPIResource{
+					{
+						Group:                   "example.io",
+						Resource:                "widgets",
+						Schema:                  apisv1alpha1.BoundAPIResourceSchema{Name: "v2.widgets.example.io", UID: "uid-1"},
+						StorageVersions:         storageVersions,
+						StorageVersionMigration: migration,
+					},
+				},
+			},
+		}
+	}
+
+	otherBinding := func(storageVersions []string) *apisv1alpha1.APIBinding {
+		b := binding(storageVersions, nil)
+		b.Annotations[logicalcluster.AnnotationKey] = "root:other"
+		return b
+	}
+
+	object := func(ns, name string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{}
+		obj.SetNamespace(ns)
+		obj.SetName(name)
+		return obj
+	}
+
+	tests := map[string]struct {
+		apiBinding   *apisv1alpha1.APIBinding
+		pages        map[string]*unstructured.UnstructuredList
+		listError    error
+		updateErrors map[string]error
+
+		crdStoredVersions []string
+		otherAPIBindings  []*apisv1alpha1.APIBinding
+
+		wantRequeue         bool
+		wantError           bool
+		wantUpdated         []string
+		wantStorageVersions []string
+		wantMigration       *apisv1alpha1.StorageVersionMigration
+		wantConditionStatus corev1.ConditionStatus
+		wantConditionReason string
+		wantStoredVersions  []string
+	}{
+		"nothing to migrate": {
+			apiBinding:          binding([]string{"v2"}, nil),
+			crdStoredVersions:   []string{"v2"},
+			wantStorageVersions: []string{"v2"},
+		},
+		"migration of a single chunk finishes and trims the stored versions of the CRD": {
+			apiBinding: binding([]string{"v1", "v2"}, nil),
+			pages: map[string]*unstructured.UnstructuredList{
+				"": {Items: []unstructured.Unstructured{object("default", "a"), object("kube-system", "b")}},
+			},
+			crdStoredVersions:   []string{"v1", "v2"},
+			otherAPIBindings:    []*apisv1alpha1.APIBinding{otherBinding([]string{"v2"})},
+			wantUpdated:         []string{"default/a", "kube-system/b"},
+			wantStorageVersions: []string{"v2"},
+			wantConditionStatus: corev1.ConditionTrue,
+			wantStoredVersions:  []string{"v2"},
+		},
+		"stored versions of the CRD are kept while other bindings are not migrated": {
+			apiBinding: binding([]string{"v1", "v2"}, nil),
+			pages: map[string]*unstructured.UnstructuredList{
+				"": {Items: []unstructured.Unstructured{object("default", "a")}},
+			},
+			crdStoredVersions:   []string{"v1", "v2"},
+			otherAPIBindings:    []*apisv1alpha1.APIBinding{otherBinding([]string{"v1", "v2"})},
+			wantUpdated:         []string{"default/a"},
+			wantStorageVersions: []string{"v2"},
+			wantConditionStatus: corev1.ConditionTrue,
+		},
+		"already migrated binding trims the stored versions of the CRD": {
+			apiBinding:          binding([]string{"v2"}, nil),
+			crdStoredVersions:   []string{"v1", "v2"},
+			otherAPIBindings:    []*apisv1alpha1.APIBinding{binding([]string{"v1", "v2"}, nil)},
+			wantStorageVersions: []string{"v2"},
+			wantStoredVersions:  []string{"v2"},
+		},
+		"migration continues with the next chunk": {
+			apiBinding: binding([]string{"v1", "v2"}, &apisv1alpha1.StorageVersionMigration{TargetVersion: "v2", MigratedObjects: 500, Continue: "chunk-2", StartTime: &started}),
+			pages: map[string]*unstructured.UnstructuredList{
+				"chunk-2": func() *unstructured.UnstructuredList {
+					l := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{object("default", "c")}}
+					l.SetContinue("chunk-3")
+					return l
+				}(),
+			},
+			wantRequeue:         true,
+			wantUpdated:         []string{"default/c"},
+			wantStorageVersions: []string{"v1", "v2"},
+			wantMigration:       &apisv1alpha1.StorageVersionMigration{TargetVersion: "v2", MigratedObjects: 501, Continue: "chunk-3", StartTime: &started},
+			wantConditionStatus: corev1.ConditionFalse,
+			wantConditionReason: apisv1alpha1.StorageVersionMigrationInProgressReason,
+		},
+		"migration restarts when the storage version changes again": {
+			apiBinding: binding([]string{"v1", "v2"}, &apisv1alpha1.StorageVersionMigration{TargetVersion: "v1", MigratedObjects: 500, Continue: "chunk-2", StartTime: &started}),
+			pages: map[string]*unstructured.UnstructuredList{
+				"": func() *unstructured.UnstructuredList {
+					l := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{object("default", "a")}}
+					l.SetContinue("chunk-2")
+					return l
+				}(),
+			},
+			wantRequeue:         true,
+			wantUpdated:         []string{"default/a"},
+			wantStorageVersions: []string{"v1", "v2"},
+			wantMigration:       &apisv1alpha1.StorageVersionMigration{TargetVersion: "v2", MigratedObjects: 1, Continue: "chunk-2", StartTime: &nowTime},
+			wantConditionStatus: corev1.ConditionFalse,
+			wantConditionReason: apisv1alpha1.StorageVersionMigrationInProgressReason,
+		},
+		"deleted and concurrently written objects are skipped": {
+			apiBinding: binding([]string{"v1", "v2"}, nil),
+			pages: map[string]*unstructured.UnstructuredList{
+				"": {Items: []unstructured.Unstructured{object("default", "a"), object("default", "b")}},
+			},
+			updateErrors: map[string]error{
+				"default/a": apierrors.NewNotFound(schema.GroupResource{}, "a"),
+				"default/b": apierrors.NewConflict(schema.GroupResource{}, "b", errors.New("conflict")),
+			},
+			crdStoredVersions:   []string{"v2"},
+			wantUpdated:         []string{"default/a", "default/b"},
+			wantStorageVersions: []string{"v2"},
+			wantConditionStatus: corev1.ConditionTrue,
+		},
+		"expired continue token restarts the migration": {
+			apiBinding:          binding([]string{"v1", "v2"}, &apisv1alpha1.StorageVersionMigration{TargetVersion: "v2", MigratedObjects: 500, Continue: "chunk-2", StartTime: &started}),
+			listError:           apierrors.NewResourceExpired("expired"),
+			wantRequeue:         true,
+			wantStorageVersions: []string{"v1", "v2"},
+			wantMigration:       &apisv1alpha1.StorageVersionMigration{TargetVersion: "v2", MigratedObjects: 500, StartTime: &started},
+			wantConditionStatus: corev1.ConditionFalse,
+			wantConditionReason: apisv1alpha1.StorageVersionMigrationInProgressReason,
+		},
+		"update error keeps the progress": {
+			apiBinding: binding([]string{"v1", "v2"}, &apisv1alpha1.StorageVersionMigration{TargetVersion: "v2", MigratedObjects: 500, Continue: "chunk-2", StartTime: &started}),
+			pages: map[string]*unstructured.UnstructuredList{
+				"chunk-2": {Items: []unstructured.Unstructured{object("default", "c")}},
+			},
+			updateErrors: map[string]error{
+				"default/c": apierrors.NewInternalError(errors.New("boom")),
+			},
+			wantError:           true,
+			wantUpdated:         []string{"default/c"},
+			wantStorageVersions: []string{"v1", "v2"},
+			wantMigration:       &apisv1alpha1.StorageVersionMigration{TargetVersion: "v2", MigratedObjects: 500, Continue: "chunk-2", StartTime: &started},
+			wantConditionStatus: corev1.ConditionFalse,
+			wantConditionReason: apisv1alpha1.StorageVersionMigrationFailedReason,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var updated, storedVersions []string
+			c := &controller{
+				listAPIBindingsBySchemaUID: func(uid string) ([]*apisv1alpha1.APIBinding, error) {
+					require.Equal(t, "uid-1", uid)
+					return append([]*apisv1alpha1.APIBinding{tc.apiBinding}, tc.otherAPIBindings...), nil
+				},
+				updateCRDStatus: func(ctx context.Context, clusterName logicalcluster.Name, crd *apiextensionsv1.CustomResourceDefinition) error {
+					require.Equal(t, "system:bound-crds", clusterName.String())
+					storedVersions = crd.Status.StoredVersions
+					return nil
+				},
+				getCRD: func(clusterName logicalcluster.Name, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+					require.Equal(t, "system:bound-crds", clusterName.String())
+					require.Equal(t, "uid-1", name)
+					return &apiextensionsv1.CustomResourceDefinition{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+							Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+								{Name: "v1"},
+								{Name: "v2", Storage: true},
+							},
+						},
+						Status: apiextensionsv1.CustomResourceDefinitionStatus{
+							StoredVersions: tc.crdStoredVersions,
+						},
+					}, nil
+				},
+				listObjects: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, continueToken string) (*unstructured.UnstructuredList, error) {
+					require.Equal(t, "root:consumer", clusterName.String())
+					require.Equal(t, schema.GroupVersionResource{Group: "example.io", Version: "v2", Resource: "widgets"}, gvr)
+					if tc.listError != nil {
+						return nil, tc.listError
+					}
+					page, found := tc.pages[continueToken]
+					require.True(t, found, "unexpected continue token %q", continueToken)
+					return page, nil
+				},
+				updateObject: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+					key := obj.GetNamespace() + "/" + obj.GetName()
+					updated = append(updated, key)
+					return tc.updateErrors[key]
+				},
+				now: func() time.Time {
+					return now
+				},
+			}
+
+			requeue, err := c.reconcile(context.Background(), tc.apiBinding)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.wantRequeue, requeue)
+			require.Equal(t, tc.wantUpdated, updated)
+			require.Equal(t, tc.wantStorageVersions, tc.apiBinding.Status.BoundResources[0].StorageVersions)
+			require.Equal(t, tc.wantMigration, tc.apiBinding.Status.BoundResources[0].StorageVersionMigration)
+			require.Equal(t, tc.wantStoredVersions, storedVersions)
+
+			cond := conditions.Get(tc.apiBinding, apisv1alpha1.StorageVersionsMigrated)
+			if tc.wantConditionStatus == "" {
+				require.Nil(t, cond)
+				return
+			}
+			require.NotNil(t, cond)
+			require.Equal(t, tc.wantConditionStatus, cond.Status)
+			require.Equal(t, tc.wantConditionReason, cond.Reason)
+		})
+	}
+}
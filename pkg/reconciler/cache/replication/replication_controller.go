@@ -0,0 +1,288 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-cache-replication"
+
+	byIdentitySecret = "byIdentitySecret"
+)
+
+var apiExportsGVR = apisv1alpha1.SchemeGroupVersion.WithResource("apiexports")
+
+// NewController returns a new controller that replicates the objects selected by the policy from this shard
+// into the cache server.
+//
+// Replication is driven by watches on both sides, without periodic resyncs: an object is only written to
+// the cache server when it changed locally, or when its copy in the cache server diverged. Copies record the
+// resourceVersion of the replicated object, and updates are made against the resourceVersion of the copy, such
+// that conflicting writes are retried with the latest state.
+//
+// If the policy replicates sealed identities, APIExports are replicated again when their identity secret changes.
+//
+// The given cache server client must target the shard of this controller.
+func NewController(
+	shardName string,
+	policy *replication.Policy,
+	localDynamicClusterClient dynamic.ClusterInterface,
+	cacheDynamicClusterClient dynamic.ClusterInterface,
+	secretInformer coreinformers.SecretInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:          queue,
+		shardName:      shardName,
+		policy:         policy,
+		localInformers: map[schema.GroupVersionResource]cache.SharedIndexInformer{},
+		cacheInformers: map[schema.GroupVersionResource]cache.SharedIndexInformer{},
+	}
+
+	for _, gvr := range policy.GroupVersionResources() {
+		gvr := gvr
+		localInformer := dynamicinformer.NewFilteredDynamicInformer(localDynamicClusterClient.Cluster(logicalcluster.Wildcard), gvr, metav1.NamespaceAll, 0, cache.Indexers{}, nil)
+		cacheInformer := dynamicinformer.NewFilteredDynamicInformer(cacheDynamicClusterClient.Cluster(logicalcluster.Wildcard), gvr, metav1.NamespaceAll, 0, cache.Indexers{}, nil)
+		c.localInformers[gvr] = localInformer.Informer()
+		c.cacheInformers[gvr] = cacheInformer.Informer()
+
+		for _, informer := range []cache.SharedIndexInformer{localInformer.Informer(), cacheInformer.Informer()} {
+			informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					c.enqueue(gvr, obj)
+				},
+				UpdateFunc: func(_, newObj interface{}) {
+					c.enqueue(gvr, newObj)
+				},
+				DeleteFunc: func(obj interface{}) {
+					c.enqueue(gvr, obj)
+				},
+			})
+		}
+	}
+
+	if localAPIExportInformer, found := c.localInformers[apiExportsGVR]; found && policy.ReplicatesSealedIdentities() {
+		if err := localAPIExportInformer.AddIndexers(cache.Indexers{byIdentitySecret: indexByIdentitySecret}); err != nil {
+			return nil, err
+		}
+		secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueueIdentitySecret(obj) },
+			UpdateFunc: func(_, newObj interface{}) { c.enqueueIdentitySecret(newObj) },
+			DeleteFunc: func(obj interface{}) { c.enqueueIdentitySecret(obj) },
+		})
+		c.getIdentitySecret = func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error) {
+			return secretInformer.Lister().Secrets(namespace).Get(clusters.ToClusterAwareKey(clusterName, name))
+		}
+	}
+
+	c.getLocalObject = func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+		return getFromIndexer(c.localInformers[gvr].GetIndexer(), gvr, key)
+	}
+	c.getCachedObject = func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+		return getFromIndexer(c.cacheInformers[gvr].GetIndexer(), gvr, key)
+	}
+	c.createCachedObject = func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error {
+		_, err := cacheDynamicClusterClient.Cluster(clusterName).Resource(gvr).Namespace(obj.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	c.updateCachedObject = func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error {
+		_, err := cacheDynamicClusterClient.Cluster(clusterName).Resource(gvr).Namespace(obj.GetNamespace()).Update(ctx, obj, metav1.UpdateOptions{})
+		return err
+	}
+	c.deleteCachedObject = func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error {
+		uid, resourceVersion := obj.GetUID(), obj.GetResourceVersion()
+		return cacheDynamicClusterClient.Cluster(clusterName).Resource(gvr).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion},
+		})
+	}
+
+	return c, nil
+}
+
+// queueKey identifies an object of a replicated resource by its cluster-aware key.
+type queueKey struct {
+	gvr schema.GroupVersionResource
+	key string
+}
+
+// controller replicates objects into the cache server.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	shardName      string
+	policy         *replication.Policy
+	localInformers map[schema.GroupVersionResource]cache.SharedIndexInformer
+	cacheInformers map[schema.GroupVersionResource]cache.SharedIndexInformer
+
+	getIdentitySecret  func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error)
+	getLocalObject     func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error)
+	getCachedObject    func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error)
+	createCachedObject func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error
+	updateCachedObject func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error
+	deleteCachedObject func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error
+}
+
+func (c *controller) enqueue(gvr schema.GroupVersionResource, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key).WithValues("resource", gvr.String())
+	logger.V(4).Info("queueing object")
+	c.queue.Add(queueKey{gvr: gvr, key: key})
+}
+
+// enqueueIdentitySecret enqueues the APIExports whose identity is held by the given secret.
+func (c *controller) enqueueIdentitySecret(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	apiExports, err := c.localInformers[apiExportsGVR].GetIndexer().ByIndex(byIdentitySecret, key)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, apiExport := range apiExports {
+		c.enqueue(apiExportsGVR, apiExport)
+	}
+}
+
+// Start starts the informers and the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	var synced []cache.InformerSynced
+	for gvr := range c.localInformers {
+		go c.localInformers[gvr].Run(ctx.Done())
+		go c.cacheInformers[gvr].Run(ctx.Done())
+		synced = append(synced, c.localInformers[gvr].HasSynced, c.cacheInformers[gvr].HasSynced)
+	}
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), synced...) {
+		return
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(queueKey)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key.key).WithValues("resource", key.gvr.String())
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.reconcile(ctx, key.gvr, key.key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q of %s, err: %w", controllerName, key.key, key.gvr, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// getFromIndexer returns the object with the given key, or nil if it does not exist.
+func getFromIndexer(indexer cache.Indexer, gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+	obj, exists, err := indexer.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, errors.NewInternalError(fmt.Errorf("unexpected type %T for %s", obj, gvr))
+	}
+	return u, nil
+}
+
+// indexByIdentitySecret indexes APIExports by the key of their identity secret, as used by secret informers.
+func indexByIdentitySecret(obj interface{}) ([]string, error) {
+	apiExport, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("obj is supposed to be an Unstructured, but is %T", obj)
+	}
+	namespace, name := identitySecretRef(apiExport)
+	if namespace == "" || name == "" {
+		return nil, nil
+	}
+	return []string{indexers.NamespaceScopedKey(logicalcluster.From(apiExport), namespace, name)}, nil
+}
+
+// identitySecretRef returns the namespace and name of the identity secret of an APIExport, if set.
+func identitySecretRef(apiExport *unstructured.Unstructured) (string, string) {
+	namespace, _, _ := unstructured.NestedString(apiExport.Object, "spec", "identity", "secretRef", "namespace")
+	name, _, _ := unstructured.NestedString(apiExport.Object, "spec", "identity", "secretRef", "name")
+	return namespace, name
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
+)
+
+// reconcile makes the copy of the object with the given key in the cache server match the local object.
+func (c *controller) reconcile(ctx context.Context, gvr schema.GroupVersionResource, key string) error {
+	logger := klog.FromContext(ctx)
+
+	local, err := c.getLocalObject(gvr, key)
+	if err != nil {
+		return err
+	}
+	cached, err := c.getCachedObject(gvr, key)
+	if err != nil {
+		return err
+	}
+
+	_, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	clusterName, _ := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	replicate := false
+	if local != nil && local.GetDeletionTimestamp() == nil {
+		if replicate, err = c.policy.Matches(gvr, clusterName, local.GetLabels()); err != nil {
+			return err
+		}
+	}
+
+	if !replicate {
+		if cached == nil {
+			return nil
+		}
+		logger.V(2).Info("deleting object from cache server")
+		if err := c.deleteCachedObject(ctx, gvr, clusterName, cached); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	desired := replicatedCopy(local, c.shardName)
+	if gvr == apiExportsGVR && c.policy.ReplicatesSealedIdentities() {
+		if err := c.addSealedIdentity(clusterName, desired); err != nil {
+			return err
+		}
+	}
+
+	if cached != nil && upToDate(cached, desired) {
+		return nil
+	}
+
+	if cached == nil {
+		logger.V(2).Info("creating object in cache server")
+		return c.createCachedObject(ctx, gvr, clusterName, desired)
+	}
+
+	// update against the resourceVersion of the copy, a conflict means the copy changed since we observed it,
+	// and the object is reconciled again with the copy observed through the watch.
+	desired.SetUID(cached.GetUID())
+	desired.SetResourceVersion(cached.GetResourceVersion())
+	logger.V(2).Info("updating object in cache server")
+	return c.updateCachedObject(ctx, gvr, clusterName, desired)
+}
+
+// replicatedCopy returns the copy of a local object to be stored in the cache server. Metadata owned by
+// the local server is dropped, and the resourceVersion and shard are recorded in annotations.
+func replicatedCopy(local *unstructured.Unstructured, shardName string) *unstructured.Unstructured {
+	obj := local.DeepCopy()
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetOwnerReferences(nil)
+	obj.SetSelfLink("")
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, logicalcluster.AnnotationKey)
+	delete(annotations, replication.SealedIdentityAnnotationKey)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[replication.SourceResourceVersionAnnotationKey] = local.GetResourceVersion()
+	annotations[replication.SourceShardAnnotationKey] = shardName
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
+
+// upToDate returns whether a copy in the cache server matches the desired copy, as far as recorded in the
+// replication annotations.
+func upToDate(cached, desired *unstructured.Unstructured) bool {
+	for _, key := range []string{
+		replication.SourceResourceVersionAnnotationKey,
+		replication.SourceShardAnnotationKey,
+		replication.SealedIdentityAnnotationKey,
+	} {
+		if cached.GetAnnotations()[key] != desired.GetAnnotations()[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// addSealedIdentity annotates the copy of an APIExport with the sealed identity key held by its identity
+// secret. Plain identity keys are never replicated.
+func (c *controller) addSealedIdentity(clusterName logicalcluster.Name, apiExport *unstructured.Unstructured) error {
+	namespace, name := identitySecretRef(apiExport)
+	if namespace == "" || name == "" {
+		return nil // the identity is not set up yet, we will get notified when it is
+	}
+	secret, err := c.getIdentitySecret(clusterName, namespace, name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sealed, found := secret.Data[apisv1alpha1.SecretKeyAPIExportSealedIdentity]
+	if !found {
+		return nil
+	}
+
+	annotations := apiExport.GetAnnotations()
+	annotations[replication.SealedIdentityAnnotationKey] = base64.StdEncoding.EncodeToString(sealed)
+	apiExport.SetAnnotations(annotations)
+	return nil
+}
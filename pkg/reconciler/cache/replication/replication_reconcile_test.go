@@ -0,0 +1,262 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
+)
+
+func TestReconcile(t *testing.T) {
+	apiExports := schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"}
+	newAPIExport := func(uid, resourceVersion string, labels map[string]string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("apis.kcp.dev/v1alpha1")
+		u.SetKind("APIExport")
+		u.SetName("widgets")
+		u.SetUID(types.UID(uid))
+		u.SetResourceVersion(resourceVersion)
+		u.SetLabels(labels)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	local := func(resourceVersion string, labels map[string]string) *unstructured.Unstructured {
+		return newAPIExport("local-uid", resourceVersion, labels, map[string]string{logicalcluster.AnnotationKey: "root:org"})
+	}
+	cached := func(uid, resourceVersion, sourceResourceVersion string) *unstructured.Unstructured {
+		return newAPIExport(uid, resourceVersion, nil, map[string]string{
+			replication.SourceResourceVersionAnnotationKey: sourceResourceVersion,
+			replication.SourceShardAnnotationKey:           "amber",
+		})
+	}
+	deleting := local("10", nil)
+	now := metav1.Now()
+	deleting.SetDeletionTimestamp(&now)
+
+	tests := map[string]struct {
+		local     *unstructured.Unstructured
+		cached    *unstructured.Unstructured
+		updateErr error
+
+		wantCreated *unstructured.Unstructured
+		wantUpdated *unstructured.Unstructured
+		wantDeleted *unstructured.Unstructured
+		wantErr     bool
+	}{
+		"creates missing copy": {
+			local:       local("10", nil),
+			wantCreated: cached("", "", "10"),
+		},
+		"skips up-to-date copy": {
+			local:  local("10", nil),
+			cached: cached("cache-uid", "3", "10"),
+		},
+		"updates copy without source shard": {
+			local:       local("10", nil),
+			cached:      newAPIExport("cache-uid", "3", nil, map[string]string{replication.SourceResourceVersionAnnotationKey: "10"}),
+			wantUpdated: cached("cache-uid", "3", "10"),
+		},
+		"updates outdated copy against its resourceVersion": {
+			local:       local("11", nil),
+			cached:      cached("cache-uid", "3", "10"),
+			wantUpdated: cached("cache-uid", "3", "11"),
+		},
+		"returns conflicts to be retried": {
+			local:       local("11", nil),
+			cached:      cached("cache-uid", "3", "10"),
+			updateErr:   errors.NewConflict(apiExports.GroupResource(), "widgets", nil),
+			wantUpdated: cached("cache-uid", "3", "11"),
+			wantErr:     true,
+		},
+		"deletes copy of deleted object": {
+			cached:      cached("cache-uid", "3", "10"),
+			wantDeleted: cached("cache-uid", "3", "10"),
+		},
+		"deletes copy of deleting object": {
+			local:       deleting,
+			cached:      cached("cache-uid", "3", "10"),
+			wantDeleted: cached("cache-uid", "3", "10"),
+		},
+		"deletes copy of object not selected anymore": {
+			local:       local("11", map[string]string{"internal": "true"}),
+			cached:      cached("cache-uid", "3", "10"),
+			wantDeleted: cached("cache-uid", "3", "10"),
+		},
+		"ignores unselected objects without copy": {
+			local: local("11", map[string]string{"internal": "true"}),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var created, updated, deleted *unstructured.Unstructured
+			c := &controller{
+				shardName: "amber",
+				policy: &replication.Policy{Resources: []replication.Resource{{
+					Group:         "apis.kcp.dev",
+					Version:       "v1alpha1",
+					Resource:      "apiexports",
+					LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "internal", Operator: metav1.LabelSelectorOpDoesNotExist}}},
+				}}},
+				getLocalObject: func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+					return tc.local, nil
+				},
+				getCachedObject: func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+					return tc.cached, nil
+				},
+				createCachedObject: func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error {
+					require.Equal(t, "root:org", clusterName.String())
+					created = obj
+					return nil
+				},
+				updateCachedObject: func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error {
+					require.Equal(t, "root:org", clusterName.String())
+					updated = obj
+					return tc.updateErr
+				},
+				deleteCachedObject: func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error {
+					require.Equal(t, "root:org", clusterName.String())
+					deleted = obj
+					return nil
+				},
+			}
+
+			err := c.reconcile(context.Background(), apiExports, "root:org|widgets")
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.wantCreated, created)
+			require.Equal(t, tc.wantUpdated, updated)
+			require.Equal(t, tc.wantDeleted, deleted)
+		})
+	}
+}
+
+func TestReconcileSealedIdentity(t *testing.T) {
+	sealed := base64.StdEncoding.EncodeToString([]byte("sealed"))
+	local := func() *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"identity": map[string]interface{}{
+					"secretRef": map[string]interface{}{"namespace": "kcp-system", "name": "widgets"},
+				},
+			},
+		}}
+		u.SetName("widgets")
+		u.SetResourceVersion("10")
+		u.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org"})
+		return u
+	}
+	cached := func(sealedIdentity string) *unstructured.Unstructured {
+		u := replicatedCopy(local(), "amber")
+		u.SetUID("cache-uid")
+		u.SetResourceVersion("3")
+		if sealedIdentity != "" {
+			annotations := u.GetAnnotations()
+			annotations[replication.SealedIdentityAnnotationKey] = sealedIdentity
+			u.SetAnnotations(annotations)
+		}
+		return u
+	}
+
+	tests := map[string]struct {
+		secret *corev1.Secret
+		cached *unstructured.Unstructured
+
+		wantSealedIdentity string
+		wantWrite          bool
+	}{
+		"replicates sealed identity": {
+			secret:             &corev1.Secret{Data: map[string][]byte{apisv1alpha1.SecretKeyAPIExportSealedIdentity: []byte("sealed")}},
+			wantSealedIdentity: sealed,
+			wantWrite:          true,
+		},
+		"never replicates plain identity": {
+			secret:    &corev1.Secret{Data: map[string][]byte{apisv1alpha1.SecretKeyAPIExportIdentity: []byte("plain")}},
+			wantWrite: true,
+		},
+		"replicates without missing identity secret": {
+			wantWrite: true,
+		},
+		"skips copy with up-to-date sealed identity": {
+			secret: &corev1.Secret{Data: map[string][]byte{apisv1alpha1.SecretKeyAPIExportSealedIdentity: []byte("sealed")}},
+			cached: cached(sealed),
+		},
+		"updates copy of resealed identity": {
+			secret:             &corev1.Secret{Data: map[string][]byte{apisv1alpha1.SecretKeyAPIExportSealedIdentity: []byte("sealed")}},
+			cached:             cached(base64.StdEncoding.EncodeToString([]byte("rotated"))),
+			wantSealedIdentity: sealed,
+			wantWrite:          true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var written *unstructured.Unstructured
+			write := func(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, obj *unstructured.Unstructured) error {
+				written = obj
+				return nil
+			}
+			c := &controller{
+				shardName: "amber",
+				policy:    &replication.Policy{Resources: replication.DefaultPolicy().Resources, IdentitySecrets: replication.IdentitySecretReplicationSealed},
+				getIdentitySecret: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error) {
+					require.Equal(t, "root:org", clusterName.String())
+					require.Equal(t, "kcp-system", namespace)
+					require.Equal(t, "widgets", name)
+					if tc.secret == nil {
+						return nil, errors.NewNotFound(corev1.Resource("secrets"), name)
+					}
+					return tc.secret, nil
+				},
+				getLocalObject: func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+					return local(), nil
+				},
+				getCachedObject: func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+					return tc.cached, nil
+				},
+				createCachedObject: write,
+				updateCachedObject: write,
+			}
+
+			err := c.reconcile(context.Background(), apiExportsGVR, "root:org|widgets")
+			require.NoError(t, err)
+
+			if !tc.wantWrite {
+				require.Nil(t, written)
+				return
+			}
+			require.NotNil(t, written)
+			require.Equal(t, tc.wantSealedIdentity, written.GetAnnotations()[replication.SealedIdentityAnnotationKey])
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// NewClusterAwareSink returns an event sink writing events into the logical cluster recorded
+// in the kcp.dev/cluster annotation of the event. Events without that annotation are rejected.
+func NewClusterAwareSink(kubeClusterClient kubernetesclient.ClusterInterface) record.EventSink {
+	return &clusterAwareSink{kubeClusterClient: kubeClusterClient}
+}
+
+type clusterAwareSink struct {
+	kubeClusterClient kubernetesclient.ClusterInterface
+}
+
+func (s *clusterAwareSink) events(event *corev1.Event) (kubernetesclient.Interface, error) {
+	clusterName := logicalcluster.From(event)
+	if clusterName.Empty() {
+		return nil, fmt.Errorf("event %s/%s has no %s annotation", event.Namespace, event.Name, logicalcluster.AnnotationKey)
+	}
+	return s.kubeClusterClient.Cluster(clusterName), nil
+}
+
+func (s *clusterAwareSink) Create(event *corev1.Event) (*corev1.Event, error) {
+	client, err := s.events(event)
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().Events(event.Namespace).CreateWithEventNamespace(event)
+}
+
+func (s *clusterAwareSink) Update(event *corev1.Event) (*corev1.Event, error) {
+	client, err := s.events(event)
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().Events(event.Namespace).UpdateWithEventNamespace(event)
+}
+
+func (s *clusterAwareSink) Patch(event *corev1.Event, data []byte) (*corev1.Event, error) {
+	client, err := s.events(event)
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().Events(event.Namespace).PatchWithEventNamespace(event, data)
+}
+
+// NewClusterAwareRecorder wraps the given recorder such that every event carries the logical
+// cluster of the involved object, for NewClusterAwareSink to route it into the right workspace.
+func NewClusterAwareRecorder(recorder record.EventRecorder) record.EventRecorder {
+	return &clusterAwareRecorder{delegate: recorder}
+}
+
+type clusterAwareRecorder struct {
+	delegate record.EventRecorder
+}
+
+func (r *clusterAwareRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, "%s", message)
+}
+
+func (r *clusterAwareRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, messageFmt, args...)
+}
+
+func (r *clusterAwareRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return
+	}
+	withCluster := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		withCluster[k] = v
+	}
+	withCluster[logicalcluster.AnnotationKey] = logicalcluster.From(accessor).String()
+	r.delegate.AnnotatedEventf(object, withCluster, eventtype, reason, messageFmt, args...)
+}
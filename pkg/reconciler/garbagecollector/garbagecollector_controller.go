@@ -0,0 +1,373 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const controllerName = "kcp-garbage-collector"
+
+// NewController returns a new controller that deletes objects whose owners, as given by their ownerReferences
+// within the same logical cluster, are gone. It also handles the orphan and foregroundDeletion finalizers of
+// objects that are being deleted.
+func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	dynamicClusterClient dynamic.Interface,
+	ddsif *informer.DynamicDiscoverySharedInformerFactory,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	eventBroadcaster := record.NewBroadcaster()
+
+	c := &controller{
+		queue:             queue,
+		kubeClusterClient: kubeClusterClient,
+		eventBroadcaster:  eventBroadcaster,
+		eventRecorder:     events.NewClusterAwareRecorder(eventBroadcaster.NewRecorder(kcpscheme.Scheme, corev1.EventSource{Component: controllerName})),
+		getObject: func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error) {
+			inf, err := ddsif.ForResource(gvr)
+			if err != nil {
+				return nil, err
+			}
+			obj, exists, err := inf.Informer().GetIndexer().GetByKey(key)
+			if err != nil || !exists {
+				return nil, err
+			}
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object type %T", obj)
+			}
+			return u, nil
+		},
+		listByIndex: func(indexName, indexValue string) (map[schema.GroupVersionResource][]*unstructured.Unstructured, bool, error) {
+			return listByIndex(ddsif, indexName, indexValue)
+		},
+		resourceFor: func(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+			return resourceFor(ddsif, gvk)
+		},
+		getLiveObject: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+			return dynamicClusterClient.Resource(gvr).Namespace(namespace).Get(logicalcluster.WithCluster(ctx, clusterName), name, metav1.GetOptions{})
+		},
+		updateOwnerReferences: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, refs []metav1.OwnerReference) error {
+			return patchMetadata(ctx, dynamicClusterClient, clusterName, gvr, obj, "ownerReferences", refs)
+		},
+		removeFinalizer: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, finalizer string) error {
+			var finalizers []string
+			for _, f := range obj.GetFinalizers() {
+				if f != finalizer {
+					finalizers = append(finalizers, f)
+				}
+			}
+			return patchMetadata(ctx, dynamicClusterClient, clusterName, gvr, obj, "finalizers", finalizers)
+		},
+		deleteObject: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, policy metav1.DeletionPropagation) error {
+			uid := obj.GetUID()
+			err := dynamicClusterClient.Resource(gvr).Namespace(obj.GetNamespace()).Delete(logicalcluster.WithCluster(ctx, clusterName), obj.GetName(), metav1.DeleteOptions{
+				Preconditions:     &metav1.Preconditions{UID: &uid},
+				PropagationPolicy: &policy,
+			})
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+	}
+
+	ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.enqueueIfRelevant(gvr, obj)
+		},
+		UpdateFunc: func(gvr schema.GroupVersionResource, oldObj, newObj interface{}) {
+			c.enqueueIfRelevant(gvr, newObj)
+			c.enqueueDeletingOwners(oldObj)
+		},
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) {
+			c.enqueueDependents(obj)
+			c.enqueueDeletingOwners(obj)
+		},
+	})
+
+	return c, nil
+}
+
+// controller is the workspace-aware garbage collector. As the kube garbage collector does not run per logical
+// cluster, this controller takes over its duties: it deletes dependents whose owners are gone, and it orphans
+// or deletes the dependents of owners that are deleted with the orphan or foreground propagation policy.
+//
+// ownerReferences are only honored within a logical cluster. Owners are looked up in the dynamic discovery
+// informers first, and are confirmed to be gone with a live lookup before any dependent is deleted.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kubeClusterClient kubernetesclient.ClusterInterface
+	eventBroadcaster  record.EventBroadcaster
+	eventRecorder     record.EventRecorder
+
+	// getObject returns the cached object of the given resource with the given key, or nil if it does not exist.
+	getObject func(gvr schema.GroupVersionResource, key string) (*unstructured.Unstructured, error)
+	// listByIndex returns the cached objects of all resources matching the given index value. synced is
+	// false if not all informers have synced yet, i.e. if the result might be incomplete.
+	listByIndex func(indexName, indexValue string) (objs map[schema.GroupVersionResource][]*unstructured.Unstructured, synced bool, err error)
+	// resourceFor returns the resource of the given kind, and whether it is namespaced.
+	resourceFor func(gvk schema.GroupVersionKind) (gvr schema.GroupVersionResource, namespaced bool, err error)
+
+	getLiveObject         func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error)
+	updateOwnerReferences func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, refs []metav1.OwnerReference) error
+	removeFinalizer       func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, finalizer string) error
+	deleteObject          func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, policy metav1.DeletionPropagation) error
+}
+
+// enqueueIfRelevant enqueues objects which have owners, or which wait for their dependents to be orphaned or deleted.
+func (c *controller) enqueueIfRelevant(gvr schema.GroupVersionResource, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	if len(u.GetOwnerReferences()) == 0 && !waitingForDependents(u) {
+		return
+	}
+	c.enqueue(gvr, u)
+}
+
+// enqueueDependents enqueues the dependents of the given object, e.g. after it got deleted.
+func (c *controller) enqueueDependents(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+
+	dependents, _, err := c.listByIndex(indexers.ByOwnerUID, indexers.UIDKey(logicalcluster.From(u), u.GetUID()))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for gvr, objs := range dependents {
+		for _, dependent := range objs {
+			c.enqueue(gvr, dependent)
+		}
+	}
+}
+
+// enqueueDeletingOwners enqueues the owners of the given object which wait for their dependents to be deleted,
+// such that they notice when their dependents are gone or do not block them anymore.
+func (c *controller) enqueueDeletingOwners(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+
+	for _, ref := range u.GetOwnerReferences() {
+		owners, _, err := c.listByIndex(indexers.ByUID, indexers.UIDKey(logicalcluster.From(u), ref.UID))
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		for gvr, objs := range owners {
+			for _, owner := range objs {
+				if owner.GetDeletionTimestamp() != nil {
+					c.enqueue(gvr, owner)
+				}
+			}
+		}
+	}
+}
+
+func (c *controller) enqueue(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	queueKey := strings.Join([]string{gvr.Resource, gvr.Version, gvr.Group}, ".") + "::" + key
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), queueKey)
+	logger.V(4).Info("queueing object")
+	c.queue.Add(queueKey)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	c.eventBroadcaster.StartRecordingToSink(events.NewClusterAwareSink(c.kubeClusterClient))
+	defer c.eventBroadcaster.Shutdown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// key is gvr::KEY
+func (c *controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+	parts := strings.SplitN(key, "::", 2)
+	if len(parts) != 2 {
+		logger.Info("error parsing key; dropping")
+		return nil
+	}
+	gvr, _ := schema.ParseResourceArg(parts[0])
+	if gvr == nil {
+		logger.Info("error parsing GVR; dropping")
+		return nil
+	}
+
+	obj, err := c.getObject(*gvr, parts[1])
+	if err != nil {
+		return err
+	}
+	if obj == nil {
+		return nil // object deleted before we handled it
+	}
+	obj = obj.DeepCopy()
+
+	logger = logging.WithObject(logger, obj).WithValues("gvr", gvr.String())
+	ctx = klog.NewContext(ctx, logger)
+
+	return c.reconcile(ctx, *gvr, obj)
+}
+
+// listByIndex returns the objects matching the given index value in the synced informers of ddsif.
+func listByIndex(ddsif *informer.DynamicDiscoverySharedInformerFactory, indexName, indexValue string) (map[schema.GroupVersionResource][]*unstructured.Unstructured, bool, error) {
+	listers, notSynced := ddsif.Listers()
+	ret := map[schema.GroupVersionResource][]*unstructured.Unstructured{}
+	for gvr := range listers {
+		inf, err := ddsif.ForResource(gvr)
+		if err != nil {
+			return nil, false, err
+		}
+		objs, err := inf.Informer().GetIndexer().ByIndex(indexName, indexValue)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, obj := range objs {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				ret[gvr] = append(ret[gvr], u)
+			}
+		}
+	}
+	return ret, len(notSynced) == 0, nil
+}
+
+// resourceFor maps the given kind to its resource using the discovery data of ddsif.
+func resourceFor(ddsif *informer.DynamicDiscoverySharedInformerFactory, gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+	discoveryData, err := ddsif.DiscoveryData()
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+	for _, resources := range discoveryData {
+		if resources.GroupVersion != gvk.GroupVersion().String() {
+			continue
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+				return gvk.GroupVersion().WithResource(resource.Name), resource.Namespaced, nil
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, false, fmt.Errorf("no resource found for %s", gvk)
+}
+
+// patchMetadata sets the given metadata field of obj with a merge patch, which fails if obj has been
+// recreated or changed in the meantime.
+func patchMetadata(ctx context.Context, client dynamic.Interface, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, field string, value interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"uid":             obj.GetUID(),
+			"resourceVersion": obj.GetResourceVersion(),
+			field:             value,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Resource(gvr).Namespace(obj.GetNamespace()).Patch(logicalcluster.WithCluster(ctx, clusterName), obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+// errInvalidOwnerNamespace is returned by getOwner for namespaced owners of cluster-scoped objects, which
+// cannot be resolved.
+var errInvalidOwnerNamespace = errors.New("cluster-scoped objects cannot be owned by namespaced objects")
+
+func (c *controller) reconcile(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	clusterName := logicalcluster.From(obj)
+
+	if obj.GetDeletionTimestamp() != nil {
+		finalizers := sets.NewString(obj.GetFinalizers()...)
+		switch {
+		case finalizers.Has(metav1.FinalizerOrphanDependents):
+			return c.orphanDependents(ctx, clusterName, gvr, obj)
+		case finalizers.Has(metav1.FinalizerDeleteDependents):
+			return c.deleteDependents(ctx, clusterName, gvr, obj)
+		}
+		return nil
+	}
+
+	if len(obj.GetOwnerReferences()) == 0 {
+		return nil
+	}
+	return c.collect(ctx, clusterName, gvr, obj)
+}
+
+// collect deletes obj if all of its owners are gone or wait for their dependents to be deleted. If some of
+// its owners still exist, it only removes the references to the others. References that cannot be resolved
+// are kept, like references to existing owners, and reported with an event.
+func (c *controller) collect(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	logger := klog.FromContext(ctx)
+
+	var solid []metav1.OwnerReference
+	var dangling, waiting, blocking int
+	for _, ref := range obj.GetOwnerReferences() {
+		owner, err := c.getOwner(ctx, clusterName, obj.GetNamespace(), ref)
+		if errors.Is(err, errInvalidOwnerNamespace) {
+			c.eventRecorder.Eventf(obj, corev1.EventTypeWarning, "OwnerRefInvalidNamespace",
+				"ownerRef [%s/%s, name: %s, uid: %s] cannot be resolved: %v", ref.APIVersion, ref.Kind, ref.Name, ref.UID, err)
+			solid = append(solid, ref)
+			continue
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case owner == nil:
+			dangling++
+		case owner.GetDeletionTimestamp() != nil && sets.NewString(owner.GetFinalizers()...).Has(metav1.FinalizerDeleteDependents):
+			waiting++
+			if ref.BlockOwnerDeletion != nil && *ref.BlockOwnerDeletion {
+				blocking++
+			}
+		default:
+			solid = append(solid, ref)
+		}
+	}
+
+	switch {
+	case dangling == 0 && waiting == 0:
+		return nil
+	case len(solid) > 0:
+		logger.V(2).Info("removing references to deleted owners", "dangling", dangling, "waiting", waiting)
+		return c.updateOwnerReferences(ctx, clusterName, gvr, obj, solid)
+	case blocking > 0:
+		// delete our own dependents first, because an owner waits for us
+		logger.V(2).Info("deleting object in foreground, because its owners are being deleted")
+		return c.deleteObject(ctx, clusterName, gvr, obj, metav1.DeletePropagationForeground)
+	default:
+		logger.V(2).Info("deleting object, because its owners are gone")
+		return c.deleteObject(ctx, clusterName, gvr, obj, metav1.DeletePropagationBackground)
+	}
+}
+
+// getOwner returns the owner referenced by ref, or nil if it does not exist. Owners missing in the informers
+// are looked up live, to not delete dependents of owners that the informers have not seen yet. It returns
+// errInvalidOwnerNamespace for namespaced owners of cluster-scoped objects.
+func (c *controller) getOwner(ctx context.Context, clusterName logicalcluster.Name, namespace string, ref metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	cached, _, err := c.listByIndex(indexers.ByUID, indexers.UIDKey(clusterName, ref.UID))
+	if err != nil {
+		return nil, err
+	}
+	for _, objs := range cached {
+		if len(objs) > 0 {
+			return objs[0], nil
+		}
+	}
+
+	gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	gvr, namespaced, err := c.resourceFor(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if !namespaced {
+		namespace = ""
+	} else if namespace == "" {
+		return nil, errInvalidOwnerNamespace
+	}
+
+	owner, err := c.getLiveObject(ctx, clusterName, gvr, namespace, ref.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if owner.GetUID() != ref.UID {
+		// the owner has been recreated
+		return nil, nil
+	}
+	return owner, nil
+}
+
+// listDependents returns the dependents of obj. It fails if not all informers have synced, as dependents
+// might be missing then.
+func (c *controller) listDependents(clusterName logicalcluster.Name, obj *unstructured.Unstructured) (map[schema.GroupVersionResource][]*unstructured.Unstructured, error) {
+	dependents, synced, err := c.listByIndex(indexers.ByOwnerUID, indexers.UIDKey(clusterName, obj.GetUID()))
+	if err != nil {
+		return nil, err
+	}
+	if !synced {
+		return nil, fmt.Errorf("informers not synced, cannot determine all dependents")
+	}
+	return dependents, nil
+}
+
+// orphanDependents removes the references to obj from its dependents, and then the orphan finalizer from obj.
+func (c *controller) orphanDependents(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	dependents, err := c.listDependents(clusterName, obj)
+	if err != nil {
+		return err
+	}
+
+	for dependentGVR, objs := range dependents {
+		for _, dependent := range objs {
+			var refs []metav1.OwnerReference
+			for _, ref := range dependent.GetOwnerReferences() {
+				if ref.UID != obj.GetUID() {
+					refs = append(refs, ref)
+				}
+			}
+			if err := c.updateOwnerReferences(ctx, clusterName, dependentGVR, dependent, refs); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	klog.FromContext(ctx).V(2).Info("orphaned dependents", "count", count(dependents))
+	return c.removeFinalizer(ctx, clusterName, gvr, obj, metav1.FinalizerOrphanDependents)
+}
+
+// deleteDependents deletes the dependents of obj, and removes the foregroundDeletion finalizer from obj once
+// no dependent blocks its deletion anymore.
+func (c *controller) deleteDependents(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	dependents, err := c.listDependents(clusterName, obj)
+	if err != nil {
+		return err
+	}
+
+	var blocking int
+	for dependentGVR, objs := range dependents {
+		for _, dependent := range objs {
+			if dependent.GetDeletionTimestamp() == nil {
+				// collecting the dependent deletes it, or removes the reference to obj if it has other owners
+				if err := c.collect(ctx, clusterName, dependentGVR, dependent.DeepCopy()); err != nil && !apierrors.IsNotFound(err) {
+					return err
+				}
+			}
+			for _, ref := range dependent.GetOwnerReferences() {
+				if ref.UID == obj.GetUID() && ref.BlockOwnerDeletion != nil && *ref.BlockOwnerDeletion {
+					blocking++
+				}
+			}
+		}
+	}
+
+	if blocking > 0 {
+		// we are requeued when the blocking dependents are deleted or updated
+		klog.FromContext(ctx).V(2).Info("waiting for dependents to be deleted", "blocking", blocking)
+		return nil
+	}
+	return c.removeFinalizer(ctx, clusterName, gvr, obj, metav1.FinalizerDeleteDependents)
+}
+
+// waitingForDependents returns whether obj is being deleted and waits for its dependents to be orphaned or deleted.
+func waitingForDependents(obj *unstructured.Unstructured) bool {
+	if obj.GetDeletionTimestamp() == nil {
+		return false
+	}
+	return sets.NewString(obj.GetFinalizers()...).HasAny(metav1.FinalizerOrphanDependents, metav1.FinalizerDeleteDependents)
+}
+
+func count(objs map[schema.GroupVersionResource][]*unstructured.Unstructured) int {
+	var n int
+	for _, l := range objs {
+		n += len(l)
+	}
+	return n
+}
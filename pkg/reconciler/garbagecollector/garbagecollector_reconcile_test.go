@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+var configMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func TestReconcile(t *testing.T) {
+	now := metav1.Now()
+	object := func(name string, finalizers []string, owners ...string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("default")
+		u.SetName(name)
+		u.SetUID(types.UID(name))
+		u.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org"})
+		if len(finalizers) > 0 {
+			u.SetFinalizers(finalizers)
+			u.SetDeletionTimestamp(&now)
+		}
+		var refs []metav1.OwnerReference
+		for _, owner := range owners {
+			ref := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: strings.TrimSuffix(owner, "!"), UID: types.UID(strings.TrimSuffix(owner, "!"))}
+			if strings.HasSuffix(owner, "!") {
+				ref.BlockOwnerDeletion = pointer.Bool(true)
+			}
+			refs = append(refs, ref)
+		}
+		u.SetOwnerReferences(refs)
+		return u
+	}
+	clusterScoped := func(u *unstructured.Unstructured) *unstructured.Unstructured {
+		u.SetNamespace("")
+		return u
+	}
+	orphan := []string{metav1.FinalizerOrphanDependents}
+	foreground := []string{metav1.FinalizerDeleteDependents}
+
+	tests := map[string]struct {
+		obj      *unstructured.Unstructured
+		cached   []*unstructured.Unstructured
+		live     []*unstructured.Unstructured
+		unsynced bool
+
+		wantActions []string
+		wantEvents  []string
+		wantErr     bool
+	}{
+		"keeps dependent of existing owner": {
+			obj:    object("child", nil, "parent"),
+			cached: []*unstructured.Unstructured{object("parent", nil)},
+		},
+		"keeps dependent of owner not yet in the informers": {
+			obj:  object("child", nil, "parent"),
+			live: []*unstructured.Unstructured{object("parent", nil)},
+		},
+		"deletes dependent of deleted owner": {
+			obj:         object("child", nil, "parent"),
+			wantActions: []string{"delete child Background"},
+		},
+		"deletes dependent of recreated owner": {
+			obj: object("child", nil, "parent"),
+			live: []*unstructured.Unstructured{func() *unstructured.Unstructured {
+				u := object("parent", nil)
+				u.SetUID("other")
+				return u
+			}()},
+			wantActions: []string{"delete child Background"},
+		},
+		"removes reference to deleted owner if other owners exist": {
+			obj:         object("child", nil, "parent", "other"),
+			cached:      []*unstructured.Unstructured{object("other", nil)},
+			wantActions: []string{"ownerReferences child [other]"},
+		},
+		"keeps cluster-scoped dependent of namespaced owner": {
+			obj:        clusterScoped(object("child", nil, "parent")),
+			wantEvents: []string{"Warning OwnerRefInvalidNamespace ownerRef [v1/ConfigMap, name: parent, uid: parent] cannot be resolved: cluster-scoped objects cannot be owned by namespaced objects"},
+		},
+		"deletes blocking dependent of owner deleted in foreground in foreground": {
+			obj:         object("child", nil, "parent!"),
+			cached:      []*unstructured.Unstructured{object("parent", foreground)},
+			wantActions: []string{"delete child Foreground"},
+		},
+		"orphans dependents": {
+			obj:         object("parent", orphan),
+			cached:      []*unstructured.Unstructured{object("child", nil, "parent", "other")},
+			wantActions: []string{"ownerReferences child [other]", "finalizer parent orphan"},
+		},
+		"does not orphan dependents if informers are not synced": {
+			obj:      object("parent", orphan),
+			unsynced: true,
+			wantErr:  true,
+		},
+		"deletes dependents in foreground": {
+			obj:         object("parent", foreground),
+			cached:      []*unstructured.Unstructured{object("child", nil, "parent!")},
+			wantActions: []string{"delete child Foreground"},
+		},
+		"removes foreground finalizer when dependents are not blocking": {
+			obj:         object("parent", foreground),
+			cached:      []*unstructured.Unstructured{object("child", nil, "parent")},
+			wantActions: []string{"delete child Background", "finalizer parent foregroundDeletion"},
+		},
+		"waits for blocking dependents being deleted": {
+			obj:    object("parent", foreground),
+			cached: []*unstructured.Unstructured{object("child", foreground, "parent!")},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cached := append([]*unstructured.Unstructured{tc.obj}, tc.cached...)
+			var actions []string
+			recorder := record.NewFakeRecorder(10)
+			c := &controller{
+				eventRecorder: recorder,
+				listByIndex: func(indexName, indexValue string) (map[schema.GroupVersionResource][]*unstructured.Unstructured, bool, error) {
+					ret := map[schema.GroupVersionResource][]*unstructured.Unstructured{}
+					for _, obj := range cached {
+						var values []string
+						var err error
+						switch indexName {
+						case indexers.ByUID:
+							values, err = indexers.IndexByUID(obj)
+						case indexers.ByOwnerUID:
+							values, err = indexers.IndexByOwnerUID(obj)
+						}
+						require.NoError(t, err)
+						for _, v := range values {
+							if v == indexValue {
+								ret[configMaps] = append(ret[configMaps], obj)
+							}
+						}
+					}
+					return ret, !tc.unsynced, nil
+				},
+				resourceFor: func(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+					require.Equal(t, "ConfigMap", gvk.Kind)
+					return configMaps, true, nil
+				},
+				getLiveObject: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+					for _, obj := range tc.live {
+						if obj.GetName() == name {
+							return obj, nil
+						}
+					}
+					return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+				},
+				updateOwnerReferences: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, refs []metav1.OwnerReference) error {
+					var names []string
+					for _, ref := range refs {
+						names = append(names, ref.Name)
+					}
+					actions = append(actions, fmt.Sprintf("ownerReferences %s %v", obj.GetName(), names))
+					return nil
+				},
+				removeFinalizer: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, finalizer string) error {
+					actions = append(actions, fmt.Sprintf("finalizer %s %s", obj.GetName(), finalizer))
+					return nil
+				},
+				deleteObject: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, policy metav1.DeletionPropagation) error {
+					actions = append(actions, fmt.Sprintf("delete %s %s", obj.GetName(), policy))
+					return nil
+				},
+			}
+
+			err := c.reconcile(context.Background(), configMaps, tc.obj)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			sort.Strings(actions)
+			sort.Strings(tc.wantActions)
+			require.Equal(t, tc.wantActions, actions)
+			close(recorder.Events)
+			var events []string
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			require.Equal(t, tc.wantEvents, events)
+		})
+	}
+}
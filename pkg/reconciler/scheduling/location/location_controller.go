@@ -43,12 +43,13 @@ import (
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	controllerName = "kcp-scheduling-location-status"
-	byWorkspace    = controllerName + "-byWorkspace" // will go away with scoping
 )
 
 // NewController returns a new controller reconciling location status.
@@ -57,7 +58,7 @@ func NewController(
 	locationInformer schedulinginformers.LocationInformer,
 	syncTargetInformer workloadinformers.SyncTargetInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue: queue,
@@ -72,17 +73,8 @@ func NewController(
 		syncTargetIndexer: syncTargetInformer.Informer().GetIndexer(),
 	}
 
-	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
-
-	if err := locationInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(syncTargetInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(locationInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	locationInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueueLocation(obj) },
@@ -151,7 +143,7 @@ func (c *controller) enqueueSyncTarget(obj interface{}) {
 		return
 	}
 	lcluster, _ := clusters.SplitClusterAwareKey(key)
-	domains, err := c.locationIndexer.ByIndex(byWorkspace, lcluster.String())
+	domains, err := c.locationIndexer.ByIndex(indexers.ByLogicalCluster, lcluster.String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -34,35 +34,46 @@ import (
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
-	coreinformers "k8s.io/client-go/informers/core/v1"
-	corelisters "k8s.io/client-go/listers/core/v1"
+	kubernetesinformers "k8s.io/client-go/informers"
+	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
 	schedulinginformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/scheduling/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/events"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	controllerName      = "kcp-scheduling-placement"
-	byWorkspace         = controllerName + "-byWorkspace" // will go away with scoping
 	byLocationWorkspace = controllerName + "-byLoactionWorkspace"
 )
 
 // NewController returns a new controller placing namespaces onto locations by create
-// a placement annotation..
+// a placement annotation. Every scheduling decision is recorded in the placement status
+// and emitted as an event into the workspace of the placement. namespaceInformer is expected to be
+// a metadata-only informer, see informer.MetadataSharedInformerFactory.
 func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
 	kcpClusterClient kcpclient.Interface,
-	namespaceInformer coreinformers.NamespaceInformer,
+	namespaceInformer kubernetesinformers.GenericInformer,
 	locationInformer schedulinginformers.LocationInformer,
 	placementInformer schedulinginformers.PlacementInformer,
+	placementPriorityInformer schedulinginformers.PlacementPriorityInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	eventBroadcaster := record.NewBroadcaster()
 
 	c := &controller{
 		queue: queue,
@@ -70,9 +81,12 @@ func NewController(
 			key := clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name)
 			queue.AddAfter(key, duration)
 		},
-		kcpClusterClient: kcpClusterClient,
+		kubeClusterClient: kubeClusterClient,
+		kcpClusterClient:  kcpClusterClient,
+
+		eventBroadcaster: eventBroadcaster,
+		eventRecorder:    events.NewClusterAwareRecorder(eventBroadcaster.NewRecorder(kcpscheme.Scheme, corev1.EventSource{Component: controllerName})),
 
-		namespaceLister:  namespaceInformer.Lister(),
 		namespaceIndexer: namespaceInformer.Informer().GetIndexer(),
 
 		locationLister:  locationInformer.Lister(),
@@ -80,36 +94,31 @@ func NewController(
 
 		placementLister:  placementInformer.Lister(),
 		placementIndexer: placementInformer.Informer().GetIndexer(),
-	}
 
-	if err := locationInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
+		placementPriorityIndexer: placementPriorityInformer.Informer().GetIndexer(),
 	}
 
+	indexers.AddOrDie(locationInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(placementInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, indexers.PlacementBySelectedLocation)
+
 	if err := placementInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace:         indexByWorkspace,
 		byLocationWorkspace: indexByLocationWorkspace,
 	}); err != nil {
 		return nil, err
 	}
 
-	if err := namespaceInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(placementPriorityInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(namespaceInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	// namespaceBlocklist holds a set of namespaces that should never be synced from kcp to physical clusters.
 	var namespaceBlocklist = sets.NewString("kube-system", "kube-public", "kube-node-lease")
 	namespaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
 			switch ns := obj.(type) {
-			case *corev1.Namespace:
-				return !namespaceBlocklist.Has(ns.Name)
 			case cache.DeletedFinalStateUnknown:
 				return true
+			case metav1.Object:
+				return !namespaceBlocklist.Has(ns.GetName())
 			default:
 				return false
 			}
@@ -117,10 +126,10 @@ func NewController(
 		Handler: cache.ResourceEventHandlerFuncs{
 			AddFunc: c.enqueueNamespace,
 			UpdateFunc: func(old, obj interface{}) {
-				oldNs := old.(*corev1.Namespace)
-				newNs := obj.(*corev1.Namespace)
+				oldNs := old.(metav1.Object)
+				newNs := obj.(metav1.Object)
 
-				if !reflect.DeepEqual(oldNs.Annotations, newNs.Annotations) {
+				if !reflect.DeepEqual(oldNs.GetAnnotations(), newNs.GetAnnotations()) {
 					c.enqueueNamespace(obj)
 				}
 			},
@@ -143,9 +152,38 @@ func NewController(
 	)
 
 	placementInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    c.enqueuePlacement,
-		UpdateFunc: func(_, obj interface{}) { c.enqueuePlacement(obj) },
-		DeleteFunc: c.enqueuePlacement,
+		AddFunc: c.enqueuePlacement,
+		UpdateFunc: func(old, obj interface{}) {
+			c.enqueuePlacement(obj)
+
+			oldPlacement := old.(*schedulingv1alpha1.Placement)
+			newPlacement := obj.(*schedulingv1alpha1.Placement)
+			if oldPlacement.Status.SelectedLocation != nil && !reflect.DeepEqual(oldPlacement.Status.SelectedLocation, newPlacement.Status.SelectedLocation) {
+				c.enqueuePendingPlacements(logicalcluster.New(oldPlacement.Status.SelectedLocation.Path), "released Location")
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueuePlacement(obj)
+
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if placement, ok := obj.(*schedulingv1alpha1.Placement); ok && placement.Status.SelectedLocation != nil {
+				c.enqueuePendingPlacements(logicalcluster.New(placement.Status.SelectedLocation.Path), "released Location")
+			}
+		},
+	})
+
+	placementPriorityInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueuePlacementPriority,
+		UpdateFunc: func(old, obj interface{}) {
+			oldPriority := old.(*schedulingv1alpha1.PlacementPriority)
+			newPriority := obj.(*schedulingv1alpha1.PlacementPriority)
+			if !reflect.DeepEqual(oldPriority.Spec, newPriority.Spec) {
+				c.enqueuePlacementPriority(obj)
+			}
+		},
+		DeleteFunc: c.enqueuePlacementPriority,
 	})
 
 	return c, nil
@@ -156,9 +194,12 @@ type controller struct {
 	queue        workqueue.RateLimitingInterface
 	enqueueAfter func(*corev1.Namespace, time.Duration)
 
-	kcpClusterClient kcpclient.Interface
+	kubeClusterClient kubernetesclient.ClusterInterface
+	kcpClusterClient  kcpclient.Interface
+
+	eventBroadcaster record.EventBroadcaster
+	eventRecorder    record.EventRecorder
 
-	namespaceLister  corelisters.NamespaceLister
 	namespaceIndexer cache.Indexer
 
 	locationLister  schedulinglisters.LocationLister
@@ -166,6 +207,8 @@ type controller struct {
 
 	placementLister  schedulinglisters.PlacementLister
 	placementIndexer cache.Indexer
+
+	placementPriorityIndexer cache.Indexer
 }
 
 func (c *controller) enqueuePlacement(obj interface{}) {
@@ -190,7 +233,7 @@ func (c *controller) enqueueNamespace(obj interface{}) {
 	}
 	clusterName, _ := clusters.SplitClusterAwareKey(key)
 
-	placements, err := c.placementIndexer.ByIndex(byWorkspace, clusterName.String())
+	placements, err := c.placementIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -229,11 +272,60 @@ func (c *controller) enqueueLocation(obj interface{}) {
 	}
 }
 
+// enqueuePlacementPriority enqueues all placements using the location workspace of the placement priority.
+func (c *controller) enqueuePlacementPriority(obj interface{}) {
+	logger := logging.WithReconciler(klog.Background(), controllerName)
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName, _ := clusters.SplitClusterAwareKey(key)
+
+	placements, err := c.placementIndexer.ByIndex(byLocationWorkspace, clusterName.String())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, obj := range placements {
+		placement := obj.(*schedulingv1alpha1.Placement)
+		priorityKey := key
+		key := clusters.ToClusterAwareKey(logicalcluster.From(placement), placement.Name)
+		logging.WithQueueKey(logger, key).V(2).Info("queueing Placement because PlacementPriority changed", "PlacementPriority", priorityKey)
+		c.queue.Add(key)
+	}
+}
+
+// enqueuePendingPlacements enqueues the pending placements of the location workspace, e.g. when
+// capacity on one of its locations has been released.
+func (c *controller) enqueuePendingPlacements(locationWorkspace logicalcluster.Name, reason string) {
+	logger := logging.WithReconciler(klog.Background(), controllerName)
+	placements, err := c.placementIndexer.ByIndex(byLocationWorkspace, locationWorkspace.String())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	for _, obj := range placements {
+		placement := obj.(*schedulingv1alpha1.Placement)
+		if placement.Status.Phase != schedulingv1alpha1.PlacementPending {
+			continue
+		}
+		key := clusters.ToClusterAwareKey(logicalcluster.From(placement), placement.Name)
+		logging.WithQueueKey(logger, key).V(2).Info("queueing pending Placement", "reason", reason)
+		c.queue.Add(key)
+	}
+}
+
 // Start starts the controller, which stops when ctx.Done() is closed.
 func (c *controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
 	defer c.queue.ShutDown()
 
+	c.eventBroadcaster.StartRecordingToSink(events.NewClusterAwareSink(c.kubeClusterClient))
+	defer c.eventBroadcaster.Shutdown()
+
 	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
 	ctx = klog.NewContext(ctx, logger)
 	logger.Info("Starting controller")
@@ -278,12 +370,10 @@ func (c *controller) processNextWorkItem(ctx context.Context) bool {
 
 func (c *controller) process(ctx context.Context, key string) error {
 	logger := klog.FromContext(ctx)
-	_, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
-	if err != nil {
+	if _, _, err := cache.SplitMetaNamespaceKey(key); err != nil {
 		logger.Error(err, "invalid key")
 		return nil
 	}
-	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
 
 	obj, err := c.placementLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
 	if err != nil {
@@ -300,34 +390,76 @@ func (c *controller) process(ctx context.Context, key string) error {
 
 	reconcileErr := c.reconcile(ctx, obj)
 
+	if decision := decide(old, obj, reconcileErr); decision != nil {
+		logger.V(2).Info("recording placement decision", "type", decision.Type, "reason", decision.Reason)
+		recordDecision(obj, *decision, metav1.Now())
+		c.eventRecorder.Event(obj, eventType(decision), decision.Reason, decision.Message)
+	}
+
 	// If the object being reconciled changed as a result, update it.
-	if !equality.Semantic.DeepEqual(old.Status, obj.Status) {
-		oldData, err := json.Marshal(schedulingv1alpha1.Placement{
-			Status: old.Status,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to Marshal old data for placement %s|%s: %w", clusterName, name, err)
-		}
+	if err := c.patchStatus(ctx, old, obj); err != nil {
+		return err
+	}
 
-		newData, err := json.Marshal(schedulingv1alpha1.Placement{
-			ObjectMeta: metav1.ObjectMeta{
-				UID:             old.UID,
-				ResourceVersion: old.ResourceVersion,
-			}, // to ensure they appear in the patch as preconditions
-			Status: obj.Status,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to Marshal new data for LocationDomain %s|%s: %w", clusterName, name, err)
-		}
+	return reconcileErr
+}
 
-		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
-		if err != nil {
-			return fmt.Errorf("failed to create patch for LocationDomain %s|%s: %w", clusterName, name, err)
-		}
-		logger.V(2).Info("patching placement", "patch", string(patchBytes))
-		_, uerr := c.kcpClusterClient.SchedulingV1alpha1().Placements().Patch(logicalcluster.WithCluster(ctx, clusterName), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
-		return uerr
+func (c *controller) patchStatus(ctx context.Context, old, obj *schedulingv1alpha1.Placement) error {
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
 	}
 
-	return reconcileErr
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(old)
+	name := old.Name
+
+	oldData, err := json.Marshal(schedulingv1alpha1.Placement{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for placement %s|%s: %w", clusterName, name, err)
+	}
+
+	newData, err := json.Marshal(schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for LocationDomain %s|%s: %w", clusterName, name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for LocationDomain %s|%s: %w", clusterName, name, err)
+	}
+	logger.V(2).Info("patching placement", "patch", string(patchBytes))
+	_, err = c.kcpClusterClient.SchedulingV1alpha1().Placements().Patch(logicalcluster.WithCluster(ctx, clusterName), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// preemptPlacement drops the selected location of the victim in favour of the preemptor.
+func (c *controller) preemptPlacement(ctx context.Context, victim, preemptor *schedulingv1alpha1.Placement) error {
+	logger := klog.FromContext(ctx)
+	logger.V(2).Info("preempting placement", "victim", clusters.ToClusterAwareKey(logicalcluster.From(victim), victim.Name))
+
+	preempted := victim.DeepCopy()
+	preempted.Status.Phase = schedulingv1alpha1.PlacementPending
+	preempted.Status.SelectedLocation = nil
+	conditions.MarkFalse(
+		preempted,
+		schedulingv1alpha1.PlacementReady,
+		schedulingv1alpha1.PreemptedReason,
+		conditionsv1alpha1.ConditionSeverityWarning,
+		"Preempted by placement %s in workspace %s with priority %d", preemptor.Name, logicalcluster.From(preemptor), preemptor.Status.Priority)
+
+	decision := decide(victim, preempted, nil)
+	recordDecision(preempted, *decision, metav1.Now())
+	if err := c.patchStatus(ctx, victim, preempted); err != nil {
+		return err
+	}
+	c.eventRecorder.Event(preempted, eventType(decision), decision.Reason, decision.Message)
+	return nil
 }
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+const (
+	// LocationSelectedReason is the decision reason when a location is selected for a placement.
+	LocationSelectedReason = "LocationSelected"
+	// LocationDeselectedReason is the decision reason when the selected location of a placement is dropped.
+	LocationDeselectedReason = "LocationDeselected"
+	// NamespacesBoundReason is the decision reason when the first namespace starts using a placement.
+	NamespacesBoundReason = "NamespacesBound"
+	// NamespacesUnboundReason is the decision reason when the last namespace stops using a placement.
+	NamespacesUnboundReason = "NamespacesUnbound"
+	// ReconcileErrorReason is the decision reason when reconciling a placement failed and will be retried.
+	ReconcileErrorReason = "ReconcileError"
+)
+
+// decide returns the scheduling decision that led from old to placement, or nil if no
+// decision worth recording has been taken. The time of the decision is not set.
+func decide(old, placement *schedulingv1alpha1.Placement, reconcileErr error) *schedulingv1alpha1.PlacementDecision {
+	decision := &schedulingv1alpha1.PlacementDecision{
+		Phase:            placement.Status.Phase,
+		SelectedLocation: placement.Status.SelectedLocation,
+	}
+
+	switch {
+	case reconcileErr != nil:
+		decision.Type = schedulingv1alpha1.PlacementDecisionRetry
+		decision.Reason = ReconcileErrorReason
+		decision.Message = reconcileErr.Error()
+	case placement.Status.SelectedLocation != nil && !equality.Semantic.DeepEqual(old.Status.SelectedLocation, placement.Status.SelectedLocation):
+		decision.Type = schedulingv1alpha1.PlacementDecisionBind
+		decision.Reason = LocationSelectedReason
+		decision.Message = fmt.Sprintf("Selected location %s in workspace %s", placement.Status.SelectedLocation.LocationName, placement.Status.SelectedLocation.Path)
+	case old.Status.SelectedLocation != nil && placement.Status.SelectedLocation == nil:
+		decision.Type = schedulingv1alpha1.PlacementDecisionUnbind
+		decision.Reason = LocationDeselectedReason
+		decision.Message = fmt.Sprintf("Location %s in workspace %s is not selected anymore", old.Status.SelectedLocation.LocationName, old.Status.SelectedLocation.Path)
+		if c := conditions.Get(placement, schedulingv1alpha1.PlacementReady); c != nil && c.Status == corev1.ConditionFalse {
+			decision.Message += ": " + c.Message
+			if c.Reason == schedulingv1alpha1.PreemptedReason {
+				decision.Reason = schedulingv1alpha1.PreemptedReason
+			}
+		}
+	case old.Status.Phase != schedulingv1alpha1.PlacementBound && placement.Status.Phase == schedulingv1alpha1.PlacementBound:
+		decision.Type = schedulingv1alpha1.PlacementDecisionBind
+		decision.Reason = NamespacesBoundReason
+		decision.Message = "At least one namespace uses the placement"
+	case old.Status.Phase == schedulingv1alpha1.PlacementBound && placement.Status.Phase == schedulingv1alpha1.PlacementUnbound:
+		decision.Type = schedulingv1alpha1.PlacementDecisionUnbind
+		decision.Reason = NamespacesUnboundReason
+		decision.Message = "No namespace uses the placement anymore"
+	default:
+		c := conditions.Get(placement, schedulingv1alpha1.PlacementReady)
+		if placement.Status.Phase != schedulingv1alpha1.PlacementPending || c == nil || c.Status != corev1.ConditionFalse {
+			return nil
+		}
+		if oldCondition := conditions.Get(old, schedulingv1alpha1.PlacementReady); oldCondition != nil && oldCondition.Status == c.Status && oldCondition.Reason == c.Reason && oldCondition.Message == c.Message {
+			return nil
+		}
+		decision.Type = schedulingv1alpha1.PlacementDecisionRetry
+		decision.Reason = c.Reason
+		decision.Message = c.Message
+	}
+
+	return decision
+}
+
+// recordDecision appends the decision to the history in the placement status, dropping the
+// oldest entries beyond MaxPlacementDecisions. A decision equal to the latest recorded one,
+// apart from its time, is not recorded again.
+func recordDecision(placement *schedulingv1alpha1.Placement, decision schedulingv1alpha1.PlacementDecision, now metav1.Time) {
+	if n := len(placement.Status.Decisions); n > 0 {
+		last := placement.Status.Decisions[n-1].DeepCopy()
+		last.Time = decision.Time
+		if equality.Semantic.DeepEqual(*last, decision) {
+			return
+		}
+	}
+
+	decision.Time = now
+	placement.Status.Decisions = append(placement.Status.Decisions, decision)
+	if n := len(placement.Status.Decisions); n > schedulingv1alpha1.MaxPlacementDecisions {
+		placement.Status.Decisions = placement.Status.Decisions[n-schedulingv1alpha1.MaxPlacementDecisions:]
+	}
+}
+
+// eventType returns the event type to emit for a decision.
+func eventType(decision *schedulingv1alpha1.PlacementDecision) string {
+	if decision.Type == schedulingv1alpha1.PlacementDecisionRetry {
+		return corev1.EventTypeWarning
+	}
+	return corev1.EventTypeNormal
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+func TestDecide(t *testing.T) {
+	aws := &schedulingv1alpha1.LocationReference{Path: "root:org", LocationName: "aws"}
+	gcp := &schedulingv1alpha1.LocationReference{Path: "root:org", LocationName: "gcp"}
+	notReady := func(reason, message string) conditionsv1alpha1.Conditions {
+		return conditionsv1alpha1.Conditions{{
+			Type:    schedulingv1alpha1.PlacementReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  reason,
+			Message: message,
+		}}
+	}
+
+	testCases := []struct {
+		name         string
+		old          schedulingv1alpha1.PlacementStatus
+		new          schedulingv1alpha1.PlacementStatus
+		reconcileErr error
+
+		wantType   schedulingv1alpha1.PlacementDecisionType
+		wantReason string
+	}{
+		{
+			name: "no change",
+			old:  schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			new:  schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+		},
+		{
+			name:       "location selected",
+			old:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementPending},
+			new:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			wantType:   schedulingv1alpha1.PlacementDecisionBind,
+			wantReason: LocationSelectedReason,
+		},
+		{
+			name:       "location changed",
+			old:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			new:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: gcp},
+			wantType:   schedulingv1alpha1.PlacementDecisionBind,
+			wantReason: LocationSelectedReason,
+		},
+		{
+			name:       "location deselected",
+			old:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			new:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementPending, Conditions: notReady(schedulingv1alpha1.LocationNotMatchReason, "No valid location is found")},
+			wantType:   schedulingv1alpha1.PlacementDecisionUnbind,
+			wantReason: LocationDeselectedReason,
+		},
+		{
+			name:       "location preempted",
+			old:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementBound, SelectedLocation: aws},
+			new:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementPending, Conditions: notReady(schedulingv1alpha1.PreemptedReason, "Preempted by placement high")},
+			wantType:   schedulingv1alpha1.PlacementDecisionUnbind,
+			wantReason: schedulingv1alpha1.PreemptedReason,
+		},
+		{
+			name:       "namespaces bound",
+			old:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			new:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementBound, SelectedLocation: aws},
+			wantType:   schedulingv1alpha1.PlacementDecisionBind,
+			wantReason: NamespacesBoundReason,
+		},
+		{
+			name:       "namespaces unbound",
+			old:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementBound, SelectedLocation: aws},
+			new:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			wantType:   schedulingv1alpha1.PlacementDecisionUnbind,
+			wantReason: NamespacesUnboundReason,
+		},
+		{
+			name:       "no location found",
+			old:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementPending},
+			new:        schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementPending, Conditions: notReady(schedulingv1alpha1.LocationNotMatchReason, "No valid location is found")},
+			wantType:   schedulingv1alpha1.PlacementDecisionRetry,
+			wantReason: schedulingv1alpha1.LocationNotMatchReason,
+		},
+		{
+			name: "still no location found",
+			old:  schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementPending, Conditions: notReady(schedulingv1alpha1.LocationNotMatchReason, "No valid location is found")},
+			new:  schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementPending, Conditions: notReady(schedulingv1alpha1.LocationNotMatchReason, "No valid location is found")},
+		},
+		{
+			name:         "reconcile error",
+			old:          schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			new:          schedulingv1alpha1.PlacementStatus{Phase: schedulingv1alpha1.PlacementUnbound, SelectedLocation: aws},
+			reconcileErr: fmt.Errorf("list location fails"),
+			wantType:     schedulingv1alpha1.PlacementDecisionRetry,
+			wantReason:   ReconcileErrorReason,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			old := &schedulingv1alpha1.Placement{Status: testCase.old}
+			updated := &schedulingv1alpha1.Placement{Status: testCase.new}
+
+			decision := decide(old, updated, testCase.reconcileErr)
+			if testCase.wantType == "" {
+				require.Nil(t, decision)
+				return
+			}
+			require.NotNil(t, decision)
+			require.Equal(t, testCase.wantType, decision.Type)
+			require.Equal(t, testCase.wantReason, decision.Reason)
+			require.Equal(t, testCase.new.Phase, decision.Phase)
+			require.Equal(t, testCase.new.SelectedLocation, decision.SelectedLocation)
+		})
+	}
+}
+
+func TestRecordDecision(t *testing.T) {
+	placement := &schedulingv1alpha1.Placement{}
+	start := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < schedulingv1alpha1.MaxPlacementDecisions+3; i++ {
+		recordDecision(placement, schedulingv1alpha1.PlacementDecision{
+			Type:    schedulingv1alpha1.PlacementDecisionRetry,
+			Reason:  ReconcileErrorReason,
+			Message: fmt.Sprintf("attempt %d", i),
+		}, metav1.NewTime(start.Add(time.Duration(i)*time.Minute)))
+	}
+	require.Len(t, placement.Status.Decisions, schedulingv1alpha1.MaxPlacementDecisions)
+	require.Equal(t, "attempt 3", placement.Status.Decisions[0].Message)
+	require.Equal(t, fmt.Sprintf("attempt %d", schedulingv1alpha1.MaxPlacementDecisions+2), placement.Status.Decisions[schedulingv1alpha1.MaxPlacementDecisions-1].Message)
+
+	last := placement.Status.Decisions[schedulingv1alpha1.MaxPlacementDecisions-1]
+	repeated := last
+	repeated.Time = metav1.Time{}
+	recordDecision(placement, repeated, metav1.NewTime(start.Add(time.Hour)))
+	require.Len(t, placement.Status.Decisions, schedulingv1alpha1.MaxPlacementDecisions)
+	require.Equal(t, last.Time, placement.Status.Decisions[schedulingv1alpha1.MaxPlacementDecisions-1].Time, "repeated decision must not be recorded again")
+}
@@ -21,10 +21,14 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clusters"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 type reconcileStatus int
@@ -41,7 +45,11 @@ type reconciler interface {
 func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) error {
 	reconcilers := []reconciler{
 		&placementReconciler{
-			listLocations: c.listLocations,
+			listLocations:                   c.listLocations,
+			getPlacementPriority:            c.getPlacementPriority,
+			listPlacementPriorities:         c.listPlacementPriorities,
+			listPlacementsSelectingLocation: c.listPlacementsSelectingLocation,
+			preemptPlacement:                c.preemptPlacement,
 		},
 		&placementNamespaceReconciler{
 			listNamespacesWithAnnotation: c.listNamespacesWithAnnotation,
@@ -66,7 +74,7 @@ func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha
 }
 
 func (c *controller) listLocations(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error) {
-	items, err := c.locationIndexer.ByIndex(byWorkspace, clusterName.String())
+	items, err := c.locationIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
@@ -77,15 +85,53 @@ func (c *controller) listLocations(clusterName logicalcluster.Name) ([]*scheduli
 	return ret, nil
 }
 
-func (c *controller) listNamespacesWithAnnotation(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
-	items, err := c.namespaceIndexer.ByIndex(byWorkspace, clusterName.String())
+func (c *controller) getPlacementPriority(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.PlacementPriority, error) {
+	obj, exists, err := c.placementPriorityIndexer.GetByKey(clusters.ToClusterAwareKey(clusterName, name))
 	if err != nil {
 		return nil, err
 	}
-	ret := make([]*corev1.Namespace, 0, len(items))
+	if !exists {
+		return nil, errors.NewNotFound(schedulingv1alpha1.Resource("placementpriorities"), name)
+	}
+	return obj.(*schedulingv1alpha1.PlacementPriority), nil
+}
+
+func (c *controller) listPlacementPriorities(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.PlacementPriority, error) {
+	items, err := c.placementPriorityIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*schedulingv1alpha1.PlacementPriority, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*schedulingv1alpha1.PlacementPriority))
+	}
+	return ret, nil
+}
+
+func (c *controller) listPlacementsSelectingLocation(locationWorkspace logicalcluster.Name, locationName string) ([]*schedulingv1alpha1.Placement, error) {
+	items, err := c.placementIndexer.ByIndex(indexers.PlacementBySelectedLocation, clusters.ToClusterAwareKey(locationWorkspace, locationName))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*schedulingv1alpha1.Placement, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.(*schedulingv1alpha1.Placement))
+	}
+	return ret, nil
+}
+
+func (c *controller) listNamespacesWithAnnotation(clusterName logicalcluster.Name) ([]metav1.Object, error) {
+	items, err := c.namespaceIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]metav1.Object, 0, len(items))
 	for _, item := range items {
-		ns := item.(*corev1.Namespace)
-		_, foundPlacement := ns.Annotations[schedulingv1alpha1.PlacementAnnotationKey]
+		ns, err := meta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		_, foundPlacement := ns.GetAnnotations()[schedulingv1alpha1.PlacementAnnotationKey]
 		if foundPlacement {
 			ret = append(ret, ns)
 		}
@@ -21,7 +21,6 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
@@ -32,7 +31,7 @@ import (
 // placementNamespaceReconciler checkes the namespaces bound to this placement and set the phase.
 // If there are at least one namespace bound to this placement, the placement is in bound state.
 type placementNamespaceReconciler struct {
-	listNamespacesWithAnnotation func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error)
+	listNamespacesWithAnnotation func(clusterName logicalcluster.Name) ([]metav1.Object, error)
 }
 
 func (r *placementNamespaceReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
@@ -60,7 +59,7 @@ func (r *placementNamespaceReconciler) reconcile(ctx context.Context, placement
 	return reconcileStatusContinue, placement, err
 }
 
-func (r *placementNamespaceReconciler) selectNamespaces(placement *schedulingv1alpha1.Placement) ([]*corev1.Namespace, error) {
+func (r *placementNamespaceReconciler) selectNamespaces(placement *schedulingv1alpha1.Placement) ([]metav1.Object, error) {
 	clusterName := logicalcluster.From(placement)
 	nss, err := r.listNamespacesWithAnnotation(clusterName)
 
@@ -73,10 +72,10 @@ func (r *placementNamespaceReconciler) selectNamespaces(placement *schedulingv1a
 		return nil, err
 	}
 
-	candidates := []*corev1.Namespace{}
+	candidates := []metav1.Object{}
 	var errs []error
 	for _, ns := range nss {
-		if !selector.Matches(labels.Set(ns.Labels)) {
+		if !selector.Matches(labels.Set(ns.GetLabels())) {
 			continue
 		}
 
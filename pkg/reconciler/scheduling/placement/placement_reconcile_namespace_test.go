@@ -105,11 +105,11 @@ func TestPlacementPhase(t *testing.T) {
 					SelectedLocation: testCase.selectedLocation,
 				},
 			}
-			listNamespacesWithAnnotation := func(clusterName logicalcluster.Name) ([]*corev1.Namespace, error) {
+			listNamespacesWithAnnotation := func(clusterName logicalcluster.Name) ([]metav1.Object, error) {
 				if testCase.ns == nil {
-					return []*corev1.Namespace{}, nil
+					return []metav1.Object{}, nil
 				}
-				return []*corev1.Namespace{testCase.ns}, nil
+				return []metav1.Object{testCase.ns}, nil
 			}
 
 			reconciler := &placementNamespaceReconciler{listNamespacesWithAnnotation: listNamespacesWithAnnotation}
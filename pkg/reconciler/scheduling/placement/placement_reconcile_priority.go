@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"sort"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/client-go/tools/clusters"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+)
+
+// PlacementPriorityNotFoundReason is a reason for PlacementReady condition that the referenced
+// PlacementPriority does not exist in the location workspace.
+const PlacementPriorityNotFoundReason = "PlacementPriorityNotFound"
+
+// resolvePriority returns the priority value and preemption policy of the placement. The priority
+// is looked up by name in the location workspace, or defaults to the PlacementPriority marked as
+// globalDefault with the highest value. Without any default, the priority is zero.
+func (r *placementReconciler) resolvePriority(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name) (int32, schedulingv1alpha1.PreemptionPolicy, error) {
+	if name := placement.Spec.PriorityName; name != "" {
+		priority, err := r.getPlacementPriority(locationWorkspace, name)
+		if err != nil {
+			return 0, "", err
+		}
+		return priority.Spec.Value, preemptionPolicy(priority), nil
+	}
+
+	priorities, err := r.listPlacementPriorities(locationWorkspace)
+	if err != nil {
+		return 0, "", err
+	}
+	var found *schedulingv1alpha1.PlacementPriority
+	for _, priority := range priorities {
+		if priority.Spec.GlobalDefault && (found == nil || priority.Spec.Value > found.Spec.Value) {
+			found = priority
+		}
+	}
+	if found == nil {
+		return 0, schedulingv1alpha1.PreemptLowerPriority, nil
+	}
+	return found.Spec.Value, preemptionPolicy(found), nil
+}
+
+func preemptionPolicy(priority *schedulingv1alpha1.PlacementPriority) schedulingv1alpha1.PreemptionPolicy {
+	if priority.Spec.PreemptionPolicy == "" {
+		return schedulingv1alpha1.PreemptLowerPriority
+	}
+	return priority.Spec.PreemptionPolicy
+}
+
+// otherPlacements returns the placements other than the given one that have selected the location.
+func (r *placementReconciler) otherPlacements(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name, locationName string) ([]*schedulingv1alpha1.Placement, error) {
+	placements, err := r.listPlacementsSelectingLocation(locationWorkspace, locationName)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*schedulingv1alpha1.Placement, 0, len(placements))
+	for _, p := range placements {
+		if p.Name == placement.Name && logicalcluster.From(p) == logicalcluster.From(placement) {
+			continue
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+// hasCapacity returns whether the location can accept the placement without preempting others.
+func (r *placementReconciler) hasCapacity(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name, location *schedulingv1alpha1.Location) (bool, error) {
+	if location.Spec.MaxPlacements == nil {
+		return true, nil
+	}
+	others, err := r.otherPlacements(placement, locationWorkspace, location.Name)
+	if err != nil {
+		return false, err
+	}
+	return len(others) < int(*location.Spec.MaxPlacements), nil
+}
+
+// mustYield returns whether the placement has to give up its selected location because the
+// location has more placements than allowed and the placement is not among those with precedence.
+func (r *placementReconciler) mustYield(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name, location *schedulingv1alpha1.Location) (bool, error) {
+	if location.Spec.MaxPlacements == nil {
+		return false, nil
+	}
+	others, err := r.otherPlacements(placement, locationWorkspace, location.Name)
+	if err != nil {
+		return false, err
+	}
+	if len(others) < int(*location.Spec.MaxPlacements) {
+		return false, nil
+	}
+	preceding := 0
+	for _, other := range others {
+		if hasPrecedence(other, placement) {
+			preceding++
+		}
+	}
+	return preceding >= int(*location.Spec.MaxPlacements), nil
+}
+
+// findPreemption returns a location of the given ones that can be freed for the placement by
+// preempting placements of lower priority, together with those victims. Among the possible
+// locations, the one requiring the victims of the lowest priority is chosen.
+func (r *placementReconciler) findPreemption(placement *schedulingv1alpha1.Placement, policy schedulingv1alpha1.PreemptionPolicy, locationWorkspace logicalcluster.Name, locations map[string]*schedulingv1alpha1.Location) (*schedulingv1alpha1.Location, []*schedulingv1alpha1.Placement, error) {
+	if policy == schedulingv1alpha1.PreemptNever {
+		return nil, nil, nil
+	}
+
+	names := make([]string, 0, len(locations))
+	for name := range locations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var chosen *schedulingv1alpha1.Location
+	var chosenVictims []*schedulingv1alpha1.Placement
+	for _, name := range names {
+		location := locations[name]
+		if location.Spec.MaxPlacements == nil {
+			continue
+		}
+		others, err := r.otherPlacements(placement, locationWorkspace, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		needed := len(others) - int(*location.Spec.MaxPlacements) + 1
+		if needed <= 0 || needed > len(others) {
+			continue
+		}
+
+		// lowest precedence first
+		sort.Slice(others, func(i, j int) bool { return hasPrecedence(others[j], others[i]) })
+		victims := others[:needed]
+		if victims[needed-1].Status.Priority >= placement.Status.Priority {
+			continue
+		}
+
+		if chosen == nil ||
+			victims[needed-1].Status.Priority < chosenVictims[len(chosenVictims)-1].Status.Priority ||
+			(victims[needed-1].Status.Priority == chosenVictims[len(chosenVictims)-1].Status.Priority && len(victims) < len(chosenVictims)) {
+			chosen = location
+			chosenVictims = victims
+		}
+	}
+
+	return chosen, chosenVictims, nil
+}
+
+// hasPrecedence returns whether placement a takes precedence over placement b for a location with
+// limited capacity: higher priority first, then older placements, then ordered by workspace and name.
+func hasPrecedence(a, b *schedulingv1alpha1.Placement) bool {
+	if a.Status.Priority != b.Status.Priority {
+		return a.Status.Priority > b.Status.Priority
+	}
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return clusters.ToClusterAwareKey(logicalcluster.From(a), a.Name) < clusters.ToClusterAwareKey(logicalcluster.From(b), b.Name)
+}
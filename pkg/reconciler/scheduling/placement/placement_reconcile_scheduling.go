@@ -22,6 +22,7 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/kube-openapi/pkg/util/sets"
@@ -34,7 +35,11 @@ import (
 // placementReconciler watches namespaces within a cluster workspace and assigns those to location from
 // the location domain of the cluster workspace.
 type placementReconciler struct {
-	listLocations func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error)
+	listLocations                   func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error)
+	getPlacementPriority            func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.PlacementPriority, error)
+	listPlacementPriorities         func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.PlacementPriority, error)
+	listPlacementsSelectingLocation func(locationWorkspace logicalcluster.Name, locationName string) ([]*schedulingv1alpha1.Placement, error)
+	preemptPlacement                func(ctx context.Context, victim, preemptor *schedulingv1alpha1.Placement) error
 }
 
 func (r *placementReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
@@ -46,11 +51,26 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 		locationWorkspace = logicalcluster.From(placement)
 	}
 
-	validLocationNames, err := r.validLocationNames(placement, locationWorkspace)
+	priority, policy, err := r.resolvePriority(placement, locationWorkspace)
+	if errors.IsNotFound(err) {
+		conditions.MarkFalse(
+			placement,
+			schedulingv1alpha1.PlacementReady,
+			PlacementPriorityNotFoundReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"PlacementPriority %s is not found in workspace %s", placement.Spec.PriorityName, locationWorkspace)
+		return reconcileStatusContinue, placement, nil
+	} else if err != nil {
+		return reconcileStatusContinue, placement, err
+	}
+	placement.Status.Priority = priority
+
+	validLocations, err := r.validLocations(placement, locationWorkspace)
 	if err != nil {
 		conditions.MarkFalse(placement, schedulingv1alpha1.PlacementReady, schedulingv1alpha1.LocationNotFoundReason, conditionsv1alpha1.ConditionSeverityError, err.Error())
 		return reconcileStatusContinue, placement, err
 	}
+	validLocationNames := sets.StringKeySet(validLocations)
 
 	switch placement.Status.Phase {
 	case schedulingv1alpha1.PlacementBound:
@@ -67,13 +87,24 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 			return reconcileStatusContinue, placement, nil
 		}
 
+		// a bound placement gives up its location if placements of higher precedence exceed the capacity.
+		if yielded, err := r.yieldIfOverCapacity(placement, locationWorkspace, validLocations); err != nil || yielded {
+			return reconcileStatusContinue, placement, err
+		}
+
 		conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
 		return reconcileStatusContinue, placement, nil
 	case schedulingv1alpha1.PlacementUnbound:
 		if isValidLocationSelected(placement, locationWorkspace, validLocationNames) {
-			// if the selected location is valid, keep it.
-			conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
-			return reconcileStatusContinue, placement, nil
+			yielded, err := r.yieldIfOverCapacity(placement, locationWorkspace, validLocations)
+			if err != nil {
+				return reconcileStatusContinue, placement, err
+			}
+			if !yielded {
+				// if the selected location is valid, keep it.
+				conditions.MarkTrue(placement, schedulingv1alpha1.PlacementReady)
+				return reconcileStatusContinue, placement, nil
+			}
 		}
 	}
 
@@ -91,8 +122,38 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 	}
 
 	candidates := make([]string, 0, validLocationNames.Len())
-	for loc := range validLocationNames {
-		candidates = append(candidates, loc)
+	for _, loc := range validLocations {
+		free, err := r.hasCapacity(placement, locationWorkspace, loc)
+		if err != nil {
+			return reconcileStatusContinue, placement, err
+		}
+		if free {
+			candidates = append(candidates, loc.Name)
+		}
+	}
+
+	if len(candidates) == 0 {
+		location, victims, err := r.findPreemption(placement, policy, locationWorkspace, validLocations)
+		if err != nil {
+			return reconcileStatusContinue, placement, err
+		}
+		if location == nil {
+			placement.Status.Phase = schedulingv1alpha1.PlacementPending
+			placement.Status.SelectedLocation = nil
+			conditions.MarkFalse(
+				placement,
+				schedulingv1alpha1.PlacementReady,
+				schedulingv1alpha1.LocationCapacityExceededReason,
+				conditionsv1alpha1.ConditionSeverityError,
+				"All %d valid locations have reached their maximal number of placements", validLocationNames.Len())
+			return reconcileStatusContinue, placement, nil
+		}
+		for _, victim := range victims {
+			if err := r.preemptPlacement(ctx, victim, placement); err != nil {
+				return reconcileStatusContinue, placement, err
+			}
+		}
+		candidates = append(candidates, location.Name)
 	}
 
 	// TODO(qiujian16): two placements could select the same location. We should
@@ -108,8 +169,28 @@ func (r *placementReconciler) reconcile(ctx context.Context, placement *scheduli
 	return reconcileStatusContinue, placement, nil
 }
 
-func (r *placementReconciler) validLocationNames(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name) (sets.String, error) {
-	selectedLocations := sets.NewString()
+// yieldIfOverCapacity drops the selected location of the placement if the location has more
+// placements than allowed and the placement is not among those with precedence.
+func (r *placementReconciler) yieldIfOverCapacity(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name, validLocations map[string]*schedulingv1alpha1.Location) (bool, error) {
+	location := validLocations[placement.Status.SelectedLocation.LocationName]
+	yield, err := r.mustYield(placement, locationWorkspace, location)
+	if err != nil || !yield {
+		return false, err
+	}
+
+	placement.Status.Phase = schedulingv1alpha1.PlacementPending
+	placement.Status.SelectedLocation = nil
+	conditions.MarkFalse(
+		placement,
+		schedulingv1alpha1.PlacementReady,
+		schedulingv1alpha1.PreemptedReason,
+		conditionsv1alpha1.ConditionSeverityWarning,
+		"Location %s has reached its maximal number of placements with higher precedence", location.Name)
+	return true, nil
+}
+
+func (r *placementReconciler) validLocations(placement *schedulingv1alpha1.Placement, locationWorkspace logicalcluster.Name) (map[string]*schedulingv1alpha1.Location, error) {
+	selectedLocations := map[string]*schedulingv1alpha1.Location{}
 
 	locations, err := r.listLocations(locationWorkspace)
 	if err != nil {
@@ -129,7 +210,7 @@ func (r *placementReconciler) validLocationNames(placement *schedulingv1alpha1.P
 			}
 
 			if selector.Matches(labels.Set(loc.Labels)) {
-				selectedLocations.Insert(loc.Name)
+				selectedLocations[loc.Name] = loc
 			}
 		}
 	}
@@ -20,11 +20,13 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
@@ -205,7 +207,12 @@ func TestPlacementScheduling(t *testing.T) {
 				return testCase.locations, testCase.listLocationsError
 			}
 
-			reconciler := &placementReconciler{listLocations: listLoaction}
+			reconciler := &placementReconciler{
+				listLocations:                   listLoaction,
+				getPlacementPriority:            getNoPlacementPriority,
+				listPlacementPriorities:         listNoPlacementPriorities,
+				listPlacementsSelectingLocation: listNoPlacements,
+			}
 			_, updated, err := reconciler.reconcile(context.TODO(), testPlacement)
 
 			if testCase.wantError {
@@ -224,6 +231,205 @@ func TestPlacementScheduling(t *testing.T) {
 	}
 }
 
+func TestPlacementPreemption(t *testing.T) {
+	now := metav1.Now()
+	older := metav1.NewTime(now.Add(-time.Hour))
+	newPlacement := func(name string, priority int32, created metav1.Time, selected string) *schedulingv1alpha1.Placement {
+		placement := &schedulingv1alpha1.Placement{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: created,
+			},
+			Status: schedulingv1alpha1.PlacementStatus{
+				Phase:    schedulingv1alpha1.PlacementPending,
+				Priority: priority,
+			},
+		}
+		if selected != "" {
+			placement.Status.Phase = schedulingv1alpha1.PlacementBound
+			placement.Status.SelectedLocation = &schedulingv1alpha1.LocationReference{LocationName: selected}
+		}
+		return placement
+	}
+	newPriority := func(name string, value int32, globalDefault bool, policy schedulingv1alpha1.PreemptionPolicy) *schedulingv1alpha1.PlacementPriority {
+		return &schedulingv1alpha1.PlacementPriority{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: schedulingv1alpha1.PlacementPrioritySpec{
+				Value:            value,
+				GlobalDefault:    globalDefault,
+				PreemptionPolicy: policy,
+			},
+		}
+	}
+	capacity := func(location *schedulingv1alpha1.Location, max int32) *schedulingv1alpha1.Location {
+		location.Spec.MaxPlacements = &max
+		return location
+	}
+
+	testCases := []struct {
+		name         string
+		priorityName string
+		phase        schedulingv1alpha1.PlacementPhase
+		selected     string
+		locations    []*schedulingv1alpha1.Location
+		priorities   []*schedulingv1alpha1.PlacementPriority
+		placements   []*schedulingv1alpha1.Placement
+
+		wantPhase    schedulingv1alpha1.PlacementPhase
+		wantLocation string
+		wantReason   string
+		wantPriority int32
+		wantVictims  []string
+	}{
+		{
+			name:         "free location is selected",
+			phase:        schedulingv1alpha1.PlacementPending,
+			locations:    []*schedulingv1alpha1.Location{capacity(newLocation("aws", nil), 1), capacity(newLocation("gcp", nil), 1)},
+			placements:   []*schedulingv1alpha1.Placement{newPlacement("other", 0, older, "aws")},
+			wantPhase:    schedulingv1alpha1.PlacementUnbound,
+			wantLocation: "gcp",
+		},
+		{
+			name:       "no capacity left without priority",
+			phase:      schedulingv1alpha1.PlacementPending,
+			locations:  []*schedulingv1alpha1.Location{capacity(newLocation("aws", nil), 1)},
+			placements: []*schedulingv1alpha1.Placement{newPlacement("other", 0, older, "aws")},
+			wantPhase:  schedulingv1alpha1.PlacementPending,
+			wantReason: schedulingv1alpha1.LocationCapacityExceededReason,
+		},
+		{
+			name:         "higher priority preempts lower priority",
+			priorityName: "high",
+			phase:        schedulingv1alpha1.PlacementPending,
+			locations:    []*schedulingv1alpha1.Location{capacity(newLocation("aws", nil), 1)},
+			priorities:   []*schedulingv1alpha1.PlacementPriority{newPriority("high", 100, false, "")},
+			placements:   []*schedulingv1alpha1.Placement{newPlacement("other", 0, older, "aws")},
+			wantPhase:    schedulingv1alpha1.PlacementUnbound,
+			wantLocation: "aws",
+			wantPriority: 100,
+			wantVictims:  []string{"other"},
+		},
+		{
+			name:         "preemption policy never",
+			priorityName: "high",
+			phase:        schedulingv1alpha1.PlacementPending,
+			locations:    []*schedulingv1alpha1.Location{capacity(newLocation("aws", nil), 1)},
+			priorities:   []*schedulingv1alpha1.PlacementPriority{newPriority("high", 100, false, schedulingv1alpha1.PreemptNever)},
+			placements:   []*schedulingv1alpha1.Placement{newPlacement("other", 0, older, "aws")},
+			wantPhase:    schedulingv1alpha1.PlacementPending,
+			wantReason:   schedulingv1alpha1.LocationCapacityExceededReason,
+			wantPriority: 100,
+		},
+		{
+			name:         "global default priority is used",
+			phase:        schedulingv1alpha1.PlacementPending,
+			locations:    []*schedulingv1alpha1.Location{capacity(newLocation("aws", nil), 1)},
+			priorities:   []*schedulingv1alpha1.PlacementPriority{newPriority("low", 10, true, ""), newPriority("high", 100, false, "")},
+			placements:   []*schedulingv1alpha1.Placement{newPlacement("other", 0, older, "aws")},
+			wantPhase:    schedulingv1alpha1.PlacementUnbound,
+			wantLocation: "aws",
+			wantPriority: 10,
+			wantVictims:  []string{"other"},
+		},
+		{
+			name:         "unknown priority",
+			priorityName: "unknown",
+			phase:        schedulingv1alpha1.PlacementPending,
+			locations:    []*schedulingv1alpha1.Location{newLocation("aws", nil)},
+			wantPhase:    schedulingv1alpha1.PlacementPending,
+			wantReason:   PlacementPriorityNotFoundReason,
+		},
+		{
+			name:       "bound placement yields to older placement over capacity",
+			phase:      schedulingv1alpha1.PlacementBound,
+			selected:   "aws",
+			locations:  []*schedulingv1alpha1.Location{capacity(newLocation("aws", nil), 1)},
+			placements: []*schedulingv1alpha1.Placement{newPlacement("other", 0, older, "aws")},
+			wantPhase:  schedulingv1alpha1.PlacementPending,
+			wantReason: schedulingv1alpha1.PreemptedReason,
+		},
+		{
+			name:         "bound placement keeps location against lower priority",
+			priorityName: "high",
+			phase:        schedulingv1alpha1.PlacementBound,
+			selected:     "aws",
+			locations:    []*schedulingv1alpha1.Location{capacity(newLocation("aws", nil), 1)},
+			priorities:   []*schedulingv1alpha1.PlacementPriority{newPriority("high", 100, false, "")},
+			placements:   []*schedulingv1alpha1.Placement{newPlacement("other", 0, older, "aws")},
+			wantPhase:    schedulingv1alpha1.PlacementBound,
+			wantLocation: "aws",
+			wantPriority: 100,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testPlacement := newPlacement("test-placement", 0, now, testCase.selected)
+			testPlacement.Spec.PriorityName = testCase.priorityName
+			testPlacement.Spec.LocationSelectors = []metav1.LabelSelector{{}}
+			testPlacement.Status.Phase = testCase.phase
+
+			var victims []string
+			reconciler := &placementReconciler{
+				listLocations: func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Location, error) {
+					return testCase.locations, nil
+				},
+				getPlacementPriority: func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.PlacementPriority, error) {
+					for _, priority := range testCase.priorities {
+						if priority.Name == name {
+							return priority, nil
+						}
+					}
+					return getNoPlacementPriority(clusterName, name)
+				},
+				listPlacementPriorities: func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.PlacementPriority, error) {
+					return testCase.priorities, nil
+				},
+				listPlacementsSelectingLocation: func(locationWorkspace logicalcluster.Name, locationName string) ([]*schedulingv1alpha1.Placement, error) {
+					var ret []*schedulingv1alpha1.Placement
+					for _, placement := range append(testCase.placements, testPlacement) {
+						if placement.Status.SelectedLocation != nil && placement.Status.SelectedLocation.LocationName == locationName {
+							ret = append(ret, placement)
+						}
+					}
+					return ret, nil
+				},
+				preemptPlacement: func(ctx context.Context, victim, preemptor *schedulingv1alpha1.Placement) error {
+					victims = append(victims, victim.Name)
+					return nil
+				},
+			}
+			_, updated, err := reconciler.reconcile(context.TODO(), testPlacement)
+			require.NoError(t, err)
+
+			require.Equal(t, testCase.wantPhase, updated.Status.Phase)
+			require.Equal(t, testCase.wantPriority, updated.Status.Priority)
+			require.Equal(t, testCase.wantVictims, victims)
+			if testCase.wantLocation == "" {
+				require.Nil(t, updated.Status.SelectedLocation)
+			} else {
+				require.NotNil(t, updated.Status.SelectedLocation)
+				require.Equal(t, testCase.wantLocation, updated.Status.SelectedLocation.LocationName)
+			}
+			c := conditions.Get(updated, schedulingv1alpha1.PlacementReady)
+			require.NotNil(t, c)
+			require.Equal(t, testCase.wantReason, c.Reason)
+		})
+	}
+}
+
+func getNoPlacementPriority(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.PlacementPriority, error) {
+	return nil, errors.NewNotFound(schedulingv1alpha1.Resource("placementpriorities"), name)
+}
+
+func listNoPlacementPriorities(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.PlacementPriority, error) {
+	return nil, nil
+}
+
+func listNoPlacements(locationWorkspace logicalcluster.Name, locationName string) ([]*schedulingv1alpha1.Placement, error) {
+	return nil, nil
+}
+
 func newLocation(name string, labels map[string]string) *schedulingv1alpha1.Location {
 	return &schedulingv1alpha1.Location{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessgrant
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// reconcile sets the expiration time and phase of the AccessGrant. It returns the duration after
+// which the AccessGrant expires, or zero if it has expired already.
+func (c *controller) reconcile(ctx context.Context, grant *tenancyv1alpha1.AccessGrant) time.Duration {
+	expiration := grant.ExpirationTime()
+	grant.Status.ExpirationTime = &expiration
+
+	if remaining := expiration.Sub(c.now()); remaining > 0 {
+		grant.Status.Phase = tenancyv1alpha1.AccessGrantPhaseActive
+		return remaining
+	}
+
+	if grant.Status.Phase != tenancyv1alpha1.AccessGrantPhaseExpired {
+		klog.FromContext(ctx).V(2).Info("access grant expired", "expirationTime", expiration)
+	}
+	grant.Status.Phase = tenancyv1alpha1.AccessGrantPhaseExpired
+	return 0
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessgrant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	created := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		now            time.Time
+		expirationTime *metav1.Time
+		phase          tenancyv1alpha1.AccessGrantPhase
+
+		wantPhase        tenancyv1alpha1.AccessGrantPhase
+		wantRequeueAfter time.Duration
+	}{
+		"new grant is active until it expires": {
+			now:              created.Add(time.Minute),
+			wantPhase:        tenancyv1alpha1.AccessGrantPhaseActive,
+			wantRequeueAfter: 59 * time.Minute,
+		},
+		"grant expires after its duration": {
+			now:       created.Add(time.Hour),
+			phase:     tenancyv1alpha1.AccessGrantPhaseActive,
+			wantPhase: tenancyv1alpha1.AccessGrantPhaseExpired,
+		},
+		"grant expires at its expiration time": {
+			now:            created.Add(time.Minute),
+			expirationTime: &metav1.Time{Time: created},
+			phase:          tenancyv1alpha1.AccessGrantPhaseActive,
+			wantPhase:      tenancyv1alpha1.AccessGrantPhaseExpired,
+		},
+		"expired grant stays expired": {
+			now:       created.Add(2 * time.Hour),
+			phase:     tenancyv1alpha1.AccessGrantPhaseExpired,
+			wantPhase: tenancyv1alpha1.AccessGrantPhaseExpired,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			grant := &tenancyv1alpha1.AccessGrant{
+				ObjectMeta: metav1.ObjectMeta{Name: "break-glass", CreationTimestamp: metav1.NewTime(created)},
+				Spec:       tenancyv1alpha1.AccessGrantSpec{Duration: metav1.Duration{Duration: time.Hour}, ExpirationTime: tc.expirationTime},
+				Status:     tenancyv1alpha1.AccessGrantStatus{Phase: tc.phase},
+			}
+			c := &controller{now: func() time.Time { return tc.now }}
+
+			requeueAfter := c.reconcile(context.Background(), grant)
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+			require.Equal(t, tc.wantPhase, grant.Status.Phase)
+			require.NotNil(t, grant.Status.ExpirationTime)
+			wantExpiration := created.Add(time.Hour)
+			if tc.expirationTime != nil {
+				wantExpiration = tc.expirationTime.Time
+			}
+			require.Equal(t, wantExpiration, grant.Status.ExpirationTime.Time)
+		})
+	}
+}
@@ -44,7 +44,9 @@ import (
 	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
@@ -52,15 +54,17 @@ const (
 )
 
 func NewController(
+	shardName string,
 	kcpClusterClient kcpclient.Interface,
 	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
 	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
 	apiBindingsInformer apisinformers.APIBindingInformer,
 ) (*Controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &Controller{
 		queue:                        queue,
+		shardName:                    shardName,
 		kcpClusterClient:             kcpClusterClient,
 		workspaceIndexer:             workspaceInformer.Informer().GetIndexer(),
 		workspaceLister:              workspaceInformer.Lister(),
@@ -78,11 +82,7 @@ func NewController(
 		return nil, fmt.Errorf("failed to add indexer for ClusterWorkspace: %w", err)
 	}
 
-	if err := c.apiBindingIndexer.AddIndexers(map[string]cache.IndexFunc{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, fmt.Errorf("failed to add indexer for APIBinding: %w", err)
-	}
+	indexers.AddOrDie(c.apiBindingIndexer, indexers.ByLogicalCluster)
 
 	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
@@ -109,6 +109,8 @@ func NewController(
 type Controller struct {
 	queue workqueue.RateLimitingInterface
 
+	shardName string
+
 	kcpClusterClient kcpclient.Interface
 	workspaceIndexer cache.Indexer
 	workspaceLister  tenancylisters.ClusterWorkspaceLister
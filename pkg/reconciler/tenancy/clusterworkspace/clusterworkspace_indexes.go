@@ -19,10 +19,6 @@ package clusterworkspace
 import (
 	"fmt"
 
-	"github.com/kcp-dev/logicalcluster/v2"
-
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 )
@@ -31,7 +27,6 @@ const (
 	byCurrentShard = "byCurrentShard"
 	unschedulable  = "unschedulable"
 	byPhase        = "byPhase"
-	byWorkspace    = controllerName + "byWorkspace" // will go away with scoping
 )
 
 func indexByCurrentShard(obj interface{}) ([]string, error) {
@@ -55,13 +50,3 @@ func indexByPhase(obj interface{}) ([]string, error) {
 
 	return []string{string(workspace.Status.Phase)}, nil
 }
-
-func indexByWorkspace(obj interface{}) ([]string, error) {
-	metaObj, ok := obj.(metav1.Object)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
-	}
-
-	lcluster := logicalcluster.From(metaObj)
-	return []string{lcluster.String()}, nil
-}
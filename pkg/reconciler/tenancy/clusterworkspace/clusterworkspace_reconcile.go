@@ -27,6 +27,7 @@ import (
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 type reconcileStatus int
@@ -44,6 +45,7 @@ func (c *Controller) reconcile(ctx context.Context, ws *tenancyv1alpha1.ClusterW
 	reconcilers := []reconciler{
 		&metaDataReconciler{},
 		&schedulingReconciler{
+			shardName: c.shardName,
 			getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
 				return c.clusterWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
 			},
@@ -55,7 +57,7 @@ func (c *Controller) reconcile(ctx context.Context, ws *tenancyv1alpha1.ClusterW
 				return c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaceShards().Get(rootCtx, name, options)
 			},
 			getAPIBindings: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
-				objs, err := c.apiBindingIndexer.ByIndex(byWorkspace, clusterName.String())
+				objs, err := c.apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 				if err != nil {
 					return nil, err
 				}
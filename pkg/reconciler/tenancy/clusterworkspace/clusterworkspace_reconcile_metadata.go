@@ -27,6 +27,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 )
 
 type metaDataReconciler struct {
@@ -35,14 +36,21 @@ type metaDataReconciler struct {
 func (r *metaDataReconciler) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (reconcileStatus, error) {
 	logger := klog.FromContext(ctx)
 	changed := false
+	if workspace.Labels == nil {
+		workspace.Labels = map[string]string{}
+	}
 	if got, expected := workspace.Labels[tenancyv1alpha1.ClusterWorkspacePhaseLabel], string(workspace.Status.Phase); got != expected {
-		if workspace.Labels == nil {
-			workspace.Labels = map[string]string{}
-		}
 		workspace.Labels[tenancyv1alpha1.ClusterWorkspacePhaseLabel] = expected
 		changed = true
 	}
 
+	if workspace.Spec.Type.Name != "" {
+		if got, expected := workspace.Labels[tenancyv1alpha1.ClusterWorkspaceTypeLabel], helper.TypeToLabel(workspace.Spec.Type); got != expected {
+			workspace.Labels[tenancyv1alpha1.ClusterWorkspaceTypeLabel] = expected
+			changed = true
+		}
+	}
+
 	initializerKeys := sets.NewString()
 	for _, initializer := range workspace.Status.Initializers {
 		key, value := initialization.InitializerToLabel(initializer)
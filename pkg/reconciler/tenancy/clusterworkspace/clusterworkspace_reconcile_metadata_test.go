@@ -135,6 +135,43 @@ func TestReconcileMetadata(t *testing.T) {
 			},
 			wantStatus: reconcileStatusContinue,
 		},
+		{
+			name: "adds type label",
+			input: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"internal.kcp.dev/phase": "Ready",
+					},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:org"},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				},
+			},
+			expected: metav1.ObjectMeta{
+				Labels: map[string]string{
+					"internal.kcp.dev/phase": "Ready",
+					"internal.kcp.dev/type":  "6100421780d772942ad72b083c325411846f9c15f259415a1daee192",
+				},
+			},
+			wantStatus: reconcileStatusStopAndRequeue,
+		},
+		{
+			name: "adds type label to a workspace without labels",
+			input: &tenancyv1alpha1.ClusterWorkspace{
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:org"},
+				},
+			},
+			expected: metav1.ObjectMeta{
+				Labels: map[string]string{
+					"internal.kcp.dev/type": "6100421780d772942ad72b083c325411846f9c15f259415a1daee192",
+				},
+			},
+			wantStatus: reconcileStatusStopAndRequeue,
+		},
 		{
 			name: "removes everything but owner username when ready",
 			input: &tenancyv1alpha1.ClusterWorkspace{
@@ -27,6 +27,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/klog/v2"
@@ -38,6 +39,10 @@ import (
 )
 
 type schedulingReconciler struct {
+	// shardName is the name of this shard, i.e. of the shard of the parent workspaces of the
+	// scheduled ClusterWorkspaces.
+	shardName string
+
 	getShard   func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error)
 	listShards func(selector labels.Selector) ([]*tenancyv1alpha1.ClusterWorkspaceShard, error)
 }
@@ -64,24 +69,21 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 		}
 
 		if workspace.Status.Location.Current == "" {
-			selector := labels.Everything()
+			matches, err := r.shardMatcher(workspace.Spec.Shard)
+			if err != nil {
+				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "spec.shard is invalid: %v", err)
+				return reconcileStatusContinue, nil // don't retry, cannot do anything useful
+			}
+
 			var shards []*tenancyv1alpha1.ClusterWorkspaceShard
 			if workspace.Spec.Shard != nil {
-				if workspace.Spec.Shard.Selector != nil {
-					var err error
-					selector, err = metav1.LabelSelectorAsSelector(workspace.Spec.Shard.Selector)
-					if err != nil {
-						conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "spec.location.selector is invalid: %v", err)
-						return reconcileStatusContinue, nil // don't retry, cannot do anything useful
-					}
-				}
 				if shardName := workspace.Spec.Shard.Name; shardName != "" {
 					shard, err := r.getShard(workspace.Spec.Shard.Name)
 					if err != nil && !apierrors.IsNotFound(err) {
 						return reconcileStatusStopAndRequeue, err
 					}
 					if apierrors.IsNotFound(err) {
-						conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "shard %q specified in spec.location.name does not exist: %v", shardName, err)
+						conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "shard %q specified in spec.shard.name does not exist: %v", shardName, err)
 						return reconcileStatusContinue, nil // retry is automatic when new shards show up
 					}
 					shards = []*tenancyv1alpha1.ClusterWorkspaceShard{shard}
@@ -90,7 +92,7 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 
 			if len(shards) == 0 {
 				var err error
-				shards, err = r.listShards(selector)
+				shards, err = r.listShards(labels.Everything())
 				if err != nil {
 					return reconcileStatusStopAndRequeue, err
 				}
@@ -101,7 +103,7 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				// until then we need to assign ws to the root shard otherwise all e2e test will break
 				//
 				// note if there are no shards just let it run, at the end, we set a proper condition.
-				if len(shards) > 0 && (workspace.Spec.Shard == nil || workspace.Spec.Shard.Selector == nil) {
+				if len(shards) > 0 && !selectsShards(workspace.Spec.Shard) {
 					// trim the list to contain only the "root" shard so that we always schedule onto it
 					for _, shard := range shards {
 						if shard.Name == "root" {
@@ -119,11 +121,29 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				}
 			}
 
+			// all constraints must be fulfilled
+			matching := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(shards))
+			for _, shard := range shards {
+				if matches(shard) {
+					matching = append(matching, shard)
+				}
+			}
+			shards = matching
+
 			validShards := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(shards))
 			invalidShards := map[string]struct {
 				reason, message string
 			}{}
 			for _, shard := range shards {
+				if !shard.IsSchedulable() {
+					invalidShards[shard.Name] = struct {
+						reason, message string
+					}{
+						reason:  tenancyv1alpha1.WorkspaceReasonUnschedulable,
+						message: fmt.Sprintf("shard is %s", shard.Spec.State),
+					}
+					continue
+				}
 				if valid, reason, message := isValidShard(shard); valid {
 					validShards = append(validShards, shard)
 				} else {
@@ -200,19 +220,12 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 		}
 
 		if workspace.Spec.Shard != nil && shard != nil {
-			needsRescheduling := false
-			if workspace.Spec.Shard.Selector != nil {
-				var err error
-				selector, err := metav1.LabelSelectorAsSelector(workspace.Spec.Shard.Selector)
-				if err != nil {
-					conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "spec.location.shardSelector is invalid: %v", err)
-					return reconcileStatusContinue, nil // don't retry, cannot do anything useful
-				}
-				needsRescheduling = !selector.Matches(labels.Set(shard.Labels))
-			} else if shardName := workspace.Spec.Shard.Name; shardName != "" && shardName != workspace.Status.Location.Current {
-				needsRescheduling = true
+			matches, err := r.shardMatcher(workspace.Spec.Shard)
+			if err != nil {
+				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "spec.shard is invalid: %v", err)
+				return reconcileStatusContinue, nil // don't retry, cannot do anything useful
 			}
-			if needsRescheduling {
+			if !matches(shard) {
 				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnreschedulable, conditionsv1alpha1.ConditionSeverityError, "Needs rescheduling, but movement is not supported yet")
 			} else {
 				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
@@ -225,6 +238,45 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 	return reconcileStatusContinue, nil
 }
 
+// shardMatcher returns a function checking whether a shard fulfills all the given shard constraints.
+func (r *schedulingReconciler) shardMatcher(constraints *tenancyv1alpha1.ShardConstraints) (func(shard *tenancyv1alpha1.ClusterWorkspaceShard) bool, error) {
+	if constraints == nil {
+		return func(*tenancyv1alpha1.ClusterWorkspaceShard) bool { return true }, nil
+	}
+
+	selector := labels.Everything()
+	if constraints.Selector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(constraints.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+	if constraints.Region != "" {
+		requirement, err := labels.NewRequirement(tenancyv1alpha1.ShardRegionLabel, selection.Equals, []string{constraints.Region})
+		if err != nil {
+			return nil, fmt.Errorf("invalid region: %w", err)
+		}
+		selector = selector.Add(*requirement)
+	}
+
+	return func(shard *tenancyv1alpha1.ClusterWorkspaceShard) bool {
+		if constraints.Name != "" && shard.Name != constraints.Name {
+			return false
+		}
+		// ClusterWorkspaces are scheduled by the shard of their parent workspace
+		if constraints.SameAsParent && shard.Name != r.shardName {
+			return false
+		}
+		return selector.Matches(labels.Set(shard.Labels))
+	}, nil
+}
+
+// selectsShards returns true if the constraints select shards by other means than by name.
+func selectsShards(constraints *tenancyv1alpha1.ShardConstraints) bool {
+	return constraints != nil && (constraints.Selector != nil || constraints.Region != "" || constraints.SameAsParent)
+}
+
 func isValidShard(shard *tenancyv1alpha1.ClusterWorkspaceShard) (valid bool, reason, message string) {
 	return true, "", ""
 }
@@ -213,6 +213,69 @@ func TestSchedulingReconciler(t *testing.T) {
 			),
 			wantStatus: reconcileStatusContinue,
 		},
+		{
+			name: "cordoned and draining shards are skipped",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"a": "1"}},
+				}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withState(tenancyv1alpha1.ClusterWorkspaceShardStateCordoned, withLabels(map[string]string{"a": "1"}, withURLs("https://root", "https://front-proxy", shard("root")))),
+				withState(tenancyv1alpha1.ClusterWorkspaceShardStateDraining, withLabels(map[string]string{"a": "1"}, withURLs("https://bar", "https://front-proxy", shard("bar")))),
+				withLabels(map[string]string{"a": "1"}, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("foo", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name:      "cordoned root shard, not scheduled",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withState(tenancyv1alpha1.ClusterWorkspaceShardStateCordoned, withURLs("https://root", "https://front-proxy", shard("root"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnschedulable,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "ready workspace on cordoned shard stays",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace", workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withState(tenancyv1alpha1.ClusterWorkspaceShardStateCordoned, withURLs("https://root", "https://front-proxy", shard("root"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace", workspace())),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
 		{
 			name: "invalid spec shard selector",
 			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
@@ -236,10 +299,98 @@ func TestSchedulingReconciler(t *testing.T) {
 			),
 			wantStatus: reconcileStatusContinue,
 		},
+		{
+			name: "spec shard region",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Region: "eu"}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{tenancyv1alpha1.ShardRegionLabel: "us"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withLabels(map[string]string{tenancyv1alpha1.ShardRegionLabel: "eu"}, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("foo", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Region: "eu"}, workspace()))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard same as parent",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{SameAsParent: true}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+				withURLs("https://foo", "https://front-proxy", shard("foo")),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("foo", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{SameAsParent: true}, workspace()))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard name not in region",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Name: "root", Region: "eu"}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{tenancyv1alpha1.ShardRegionLabel: "us"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withLabels(map[string]string{tenancyv1alpha1.ShardRegionLabel: "eu"}, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Name: "root", Region: "eu"}, workspace())),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnschedulable,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "ready workspace outside of region",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Region: "eu"}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{tenancyv1alpha1.ShardRegionLabel: "us"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Region: "eu"}, workspace()))),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnreschedulable,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &schedulingReconciler{
+				shardName: "foo",
 				getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
 					for _, shard := range tt.shards {
 						if shard.Name == name {
@@ -328,3 +479,8 @@ func withLabels(labels map[string]string, shard *tenancyv1alpha1.ClusterWorkspac
 	shard.Labels = labels
 	return shard
 }
+
+func withState(state tenancyv1alpha1.ClusterWorkspaceShardState, shard *tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+	shard.Spec.State = state
+	return shard
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemove
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-clusterworkspace-move"
+)
+
+// NewController returns a new controller moving ClusterWorkspaces with spec.moveTo set to their target.
+func NewController(
+	shardName string,
+	kcpClusterClient kcpclient.Interface,
+	dynamicClusterClient dynamic.Interface,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	syncTargetInformer workloadinformers.SyncTargetInformer,
+	discoverResources func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error),
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	workspaceLister := workspaceInformer.Lister()
+	c := &controller{
+		queue:            queue,
+		shardName:        shardName,
+		kcpClusterClient: kcpClusterClient,
+		workspaceLister:  workspaceLister,
+		syncTargetLister: syncTargetInformer.Lister(),
+		listAPIBindingsReferencing: func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error) {
+			return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingByAPIExportWorkspace, clusterName.String())
+		},
+		getWorkspace: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+			return workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		createWorkspace: func(ctx context.Context, clusterName logicalcluster.Name, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+			_, err := kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Create(logicalcluster.WithCluster(ctx, clusterName), workspace, metav1.CreateOptions{})
+			return err
+		},
+		deleteWorkspace: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+			return kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Delete(logicalcluster.WithCluster(ctx, clusterName), name, metav1.DeleteOptions{})
+		},
+		setReadOnly: func(ctx context.Context, clusterName logicalcluster.Name, name string, readOnly bool) error {
+			return SetReadOnly(ctx, kcpClusterClient, clusterName, name, readOnly)
+		},
+		copyContent: func(ctx context.Context, from, to logicalcluster.Name) error {
+			return copyContent(ctx, dynamicClusterClient, discoverResources, from, to)
+		},
+		syncChecks: []cache.InformerSynced{
+			workspaceInformer.Informer().HasSynced,
+			apiBindingInformer.Informer().HasSynced,
+			syncTargetInformer.Informer().HasSynced,
+		},
+	}
+	c.updateReferences = c.updateReferencesOnShard
+
+	indexers.AddOrDie(apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingByAPIExportWorkspace)
+
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	})
+
+	return c, nil
+}
+
+// controller moves ClusterWorkspaces with spec.moveTo set in stages: it creates the read-only target
+// workspace on the same shard, blocks writes to the source and copies the content over once the
+// target is ready, updates path references to the moved workspace, unblocks writes to the target,
+// and finally deletes the source workspace.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	shardName        string
+	kcpClusterClient kcpclient.Interface
+
+	workspaceLister  tenancylisters.ClusterWorkspaceLister
+	syncTargetLister workloadlisters.SyncTargetLister
+
+	listAPIBindingsReferencing func(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIBinding, error)
+
+	getWorkspace     func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error)
+	createWorkspace  func(ctx context.Context, clusterName logicalcluster.Name, workspace *tenancyv1alpha1.ClusterWorkspace) error
+	deleteWorkspace  func(ctx context.Context, clusterName logicalcluster.Name, name string) error
+	setReadOnly      func(ctx context.Context, clusterName logicalcluster.Name, name string, readOnly bool) error
+	copyContent      func(ctx context.Context, from, to logicalcluster.Name) error
+	updateReferences func(ctx context.Context, from, to logicalcluster.Name) error
+
+	syncChecks []cache.InformerSynced
+}
+
+// enqueue queues workspaces being moved, and the source workspace of a move target.
+func (c *controller) enqueue(obj interface{}) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+	logger := logging.WithReconciler(klog.Background(), controllerName)
+
+	if workspace.Spec.MoveTo != nil {
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		logging.WithQueueKey(logger, key).V(2).Info("queueing ClusterWorkspace")
+		c.queue.Add(key)
+	}
+
+	if movedFrom, ok := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMovedFromAnnotationKey]; ok {
+		parent, name := logicalcluster.New(movedFrom).Split()
+		if parent.Empty() {
+			return
+		}
+		key := clusters.ToClusterAwareKey(parent, name)
+		logging.WithQueueKey(logger, key).V(2).Info("queueing ClusterWorkspace because its move target changed")
+		c.queue.Add(key)
+	}
+}
+
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), c.syncChecks...) {
+		logger.Error(nil, "Failed to wait for caches to sync")
+		return
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.workspaceLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	if err := c.reconcile(ctx, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Regardless of whether reconcile returned an error or not, always try to patch status if needed. Return the
+	// reconciliation error at the end.
+	if err := c.patchStatusIfNeeded(ctx, old, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *controller) patchStatusIfNeeded(ctx context.Context, old, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(old)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+	_, err = c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// SetReadOnly sets spec.readOnly of the ClusterWorkspace, which blocks or unblocks writes to its content.
+func SetReadOnly(ctx context.Context, kcpClusterClient kcpclient.Interface, clusterName logicalcluster.Name, name string, readOnly bool) error {
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"readOnly": readOnly,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, name, err)
+	}
+	_, err = kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	return err
+}
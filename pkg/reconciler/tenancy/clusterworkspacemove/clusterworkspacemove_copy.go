@@ -0,0 +1,251 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemove
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// skippedResources are not copied into the move target, because they are virtual, recreated by
+// controllers, or only of historic interest.
+var skippedResources = sets.NewString(
+	"events",
+	"events.events.k8s.io",
+	"workspaces.tenancy.kcp.dev",
+	"clusterworkspaces.tenancy.kcp.dev",
+)
+
+// copyPriorities defines which resources are copied before others, such that the resources of
+// the target workspace are served when their objects are copied.
+var copyPriorities = map[schema.GroupResource]int{
+	{Resource: "namespaces"}: 0,
+	{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}:  1,
+	{Group: apisv1alpha1.SchemeGroupVersion.Group, Resource: "apibindings"}: 1,
+}
+
+const defaultCopyPriority = 2
+
+var (
+	apiBindingsResource  = apisv1alpha1.SchemeGroupVersion.WithResource("apibindings").GroupResource()
+	syncTargetsResource  = workloadv1alpha1.SchemeGroupVersion.WithResource("synctargets").GroupResource()
+	accessGrantsResource = tenancyv1alpha1.Resource("accessgrants")
+)
+
+// copyContent copies all objects of one workspace to another. Objects which exist already in the
+// target are left untouched, such that the copy can be retried.
+func copyContent(
+	ctx context.Context,
+	client dynamic.Interface,
+	discoverResources func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error),
+	from, to logicalcluster.Name,
+) error {
+	resources, err := discoverResources(from)
+	if err != nil {
+		// unlike on deletion, partial discovery is fatal because content would be lost
+		return fmt.Errorf("failed to discover resources of workspace %s: %w", from, err)
+	}
+
+	gvrs, err := CopyOrder(CopyableResources(resources))
+	if err != nil {
+		return err
+	}
+
+	// copy in priority order, failing early such that dependent objects are not tried before their
+	// resources are served.
+	for _, gvr := range gvrs {
+		list, err := client.Resource(gvr).List(logicalcluster.WithCluster(ctx, from), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list %s in workspace %s: %w", gvr.GroupResource(), from, err)
+		}
+
+		var errs []error
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if SkipObject(gvr.GroupResource(), obj) {
+				continue
+			}
+			if err := PrepareForCopy(gvr.GroupResource(), obj, from, to); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			_, err := client.Resource(gvr).Namespace(obj.GetNamespace()).Create(logicalcluster.WithCluster(ctx, to), obj, metav1.CreateOptions{})
+			if err != nil && !errors.IsAlreadyExists(err) {
+				errs = append(errs, fmt.Errorf("failed to copy %s %s/%s: %w", gvr.GroupResource(), obj.GetNamespace(), obj.GetName(), err))
+			}
+		}
+		if err := utilerrors.NewAggregate(errs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopyableResources returns the resources whose objects are copied, i.e. those which can be listed
+// and created, and are not virtual or recreated by controllers.
+func CopyableResources(resources []*metav1.APIResourceList) []*metav1.APIResourceList {
+	return discovery.FilteredBy(discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			return false
+		}
+		return discovery.SupportsAllVerbs{Verbs: []string{"list", "create"}}.Match(groupVersion, r) &&
+			!skippedResources.Has(schema.GroupResource{Group: gv.Group, Resource: r.Name}.String())
+	}), resources)
+}
+
+// CopyOrder returns the resources of the given resource lists in the order they must be copied.
+func CopyOrder(resources []*metav1.APIResourceList) ([]schema.GroupVersionResource, error) {
+	var gvrs []schema.GroupVersionResource
+	for _, list := range resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range list.APIResources {
+			gvrs = append(gvrs, gv.WithResource(r.Name))
+		}
+	}
+
+	priority := func(gvr schema.GroupVersionResource) int {
+		if p, ok := copyPriorities[gvr.GroupResource()]; ok {
+			return p
+		}
+		return defaultCopyPriority
+	}
+	sort.SliceStable(gvrs, func(i, j int) bool {
+		if pi, pj := priority(gvrs[i]), priority(gvrs[j]); pi != pj {
+			return pi < pj
+		}
+		return gvrs[i].GroupResource().String() < gvrs[j].GroupResource().String()
+	})
+
+	return gvrs, nil
+}
+
+// SkipObject returns whether the object is not copied, because it is being deleted or is recreated
+// by controllers in the target.
+func SkipObject(gr schema.GroupResource, obj *unstructured.Unstructured) bool {
+	if obj.GetDeletionTimestamp() != nil {
+		return true
+	}
+	if gr == corev1.Resource("secrets") {
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType == string(corev1.SecretTypeServiceAccountToken)
+	}
+	return false
+}
+
+// PrepareForCopy clears the server-populated metadata of the object, and rewrites path references
+// to the moved workspace. Owner references are dropped because the UIDs change. AccessGrants keep
+// their absolute expiration time, such that they do not start over in the target.
+func PrepareForCopy(gr schema.GroupResource, obj *unstructured.Unstructured, from, to logicalcluster.Name) error {
+	if gr == accessGrantsResource {
+		grant := &tenancyv1alpha1.AccessGrant{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, grant); err != nil {
+			return fmt.Errorf("failed to convert AccessGrant %s: %w", obj.GetName(), err)
+		}
+		if grant.Spec.ExpirationTime == nil {
+			expiration := grant.ExpirationTime()
+			if err := unstructured.SetNestedField(obj.Object, expiration.UTC().Format(time.RFC3339), "spec", "expirationTime"); err != nil {
+				return err
+			}
+		}
+	}
+
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetOwnerReferences(nil)
+	obj.SetSelfLink("")
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, logicalcluster.AnnotationKey)
+		obj.SetAnnotations(annotations)
+	}
+
+	switch gr {
+	case apiBindingsResource:
+		binding := &apisv1alpha1.APIBinding{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, binding); err != nil {
+			return fmt.Errorf("failed to convert APIBinding %s: %w", obj.GetName(), err)
+		}
+		if rewriteAPIBindingReference(binding, from, to) {
+			return unstructured.SetNestedField(obj.Object, binding.Spec.Reference.Workspace.Path, "spec", "reference", "workspace", "path")
+		}
+	case syncTargetsResource:
+		syncTarget := &workloadv1alpha1.SyncTarget{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, syncTarget); err != nil {
+			return fmt.Errorf("failed to convert SyncTarget %s: %w", obj.GetName(), err)
+		}
+		if rewriteSyncTargetReferences(syncTarget, from, to) {
+			exports, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&workloadv1alpha1.SyncTargetSpec{SupportedAPIExports: syncTarget.Spec.SupportedAPIExports})
+			if err != nil {
+				return err
+			}
+			return unstructured.SetNestedField(obj.Object, exports["supportedAPIExports"], "spec", "supportedAPIExports")
+		}
+	}
+
+	return nil
+}
+
+// rewriteAPIBindingReference rewrites the APIExport reference of the binding from one workspace to
+// another, and returns whether it changed.
+func rewriteAPIBindingReference(binding *apisv1alpha1.APIBinding, from, to logicalcluster.Name) bool {
+	if binding.Spec.Reference.Workspace == nil || binding.Spec.Reference.Workspace.Path != from.String() {
+		return false
+	}
+	binding.Spec.Reference.Workspace.Path = to.String()
+	return true
+}
+
+// rewriteSyncTargetReferences rewrites the supported APIExport references of the SyncTarget from
+// one workspace to another, and returns whether any changed.
+func rewriteSyncTargetReferences(syncTarget *workloadv1alpha1.SyncTarget, from, to logicalcluster.Name) bool {
+	changed := false
+	for i := range syncTarget.Spec.SupportedAPIExports {
+		ref := syncTarget.Spec.SupportedAPIExports[i].Workspace
+		if ref == nil || ref.Path != from.String() {
+			continue
+		}
+		ref.Path = to.String()
+		changed = true
+	}
+	return changed
+}
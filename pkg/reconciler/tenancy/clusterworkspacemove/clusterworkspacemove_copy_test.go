@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemove
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCopyOrder(t *testing.T) {
+	gvrs, err := CopyOrder([]*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "configmaps"}, {Name: "namespaces"}}},
+		{GroupVersion: "apis.kcp.dev/v1alpha1", APIResources: []metav1.APIResource{{Name: "apiexports"}, {Name: "apibindings"}}},
+		{GroupVersion: "apiextensions.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "customresourcedefinitions"}}},
+	})
+	require.NoError(t, err)
+
+	var got []string
+	for _, gvr := range gvrs {
+		got = append(got, gvr.GroupResource().String())
+	}
+	require.Equal(t, []string{
+		"namespaces",
+		"apibindings.apis.kcp.dev",
+		"customresourcedefinitions.apiextensions.k8s.io",
+		"apiexports.apis.kcp.dev",
+		"configmaps",
+	}, got)
+}
+
+func TestSkipObject(t *testing.T) {
+	secret := func(secretType corev1.SecretType) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "s"},
+			"type":       string(secretType),
+		}}
+	}
+	deleting := secret(corev1.SecretTypeOpaque)
+	now := metav1.Now()
+	deleting.SetDeletionTimestamp(&now)
+
+	require.False(t, SkipObject(corev1.Resource("secrets"), secret(corev1.SecretTypeOpaque)))
+	require.True(t, SkipObject(corev1.Resource("secrets"), secret(corev1.SecretTypeServiceAccountToken)))
+	require.True(t, SkipObject(corev1.Resource("secrets"), deleting))
+	require.False(t, SkipObject(corev1.Resource("configmaps"), &unstructured.Unstructured{Object: map[string]interface{}{}}))
+}
+
+func TestPrepareForCopy(t *testing.T) {
+	from := logicalcluster.New("root:org:ws")
+	to := logicalcluster.New("root:team:renamed")
+
+	tests := []struct {
+		name string
+		gr   schema.GroupResource
+		obj  map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "server-populated metadata is cleared",
+			gr:   corev1.Resource("configmaps"),
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":              "cm",
+					"namespace":         "default",
+					"uid":               "abc",
+					"resourceVersion":   "42",
+					"creationTimestamp": "2022-01-01T00:00:00Z",
+					"annotations":       map[string]interface{}{logicalcluster.AnnotationKey: "root:org:ws", "keep": "me"},
+					"ownerReferences":   []interface{}{map[string]interface{}{"name": "owner"}},
+				},
+				"data": map[string]interface{}{"a": "b"},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":        "cm",
+					"namespace":   "default",
+					"annotations": map[string]interface{}{"keep": "me"},
+				},
+				"data": map[string]interface{}{"a": "b"},
+			},
+		},
+		{
+			name: "APIBinding references to the moved workspace are rewritten",
+			gr:   apiBindingsResource,
+			obj: map[string]interface{}{
+				"apiVersion": "apis.kcp.dev/v1alpha1",
+				"kind":       "APIBinding",
+				"metadata":   map[string]interface{}{"name": "b"},
+				"spec": map[string]interface{}{
+					"reference": map[string]interface{}{"workspace": map[string]interface{}{"path": "root:org:ws", "exportName": "e"}},
+				},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "apis.kcp.dev/v1alpha1",
+				"kind":       "APIBinding",
+				"metadata":   map[string]interface{}{"name": "b"},
+				"spec": map[string]interface{}{
+					"reference": map[string]interface{}{"workspace": map[string]interface{}{"path": "root:team:renamed", "exportName": "e"}},
+				},
+			},
+		},
+		{
+			name: "other APIBinding references are kept",
+			gr:   apiBindingsResource,
+			obj: map[string]interface{}{
+				"apiVersion": "apis.kcp.dev/v1alpha1",
+				"kind":       "APIBinding",
+				"metadata":   map[string]interface{}{"name": "b"},
+				"spec": map[string]interface{}{
+					"reference": map[string]interface{}{"workspace": map[string]interface{}{"path": "root:org:ws2", "exportName": "e"}},
+				},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "apis.kcp.dev/v1alpha1",
+				"kind":       "APIBinding",
+				"metadata":   map[string]interface{}{"name": "b"},
+				"spec": map[string]interface{}{
+					"reference": map[string]interface{}{"workspace": map[string]interface{}{"path": "root:org:ws2", "exportName": "e"}},
+				},
+			},
+		},
+		{
+			name: "SyncTarget references to the moved workspace are rewritten",
+			gr:   syncTargetsResource,
+			obj: map[string]interface{}{
+				"apiVersion": "workload.kcp.dev/v1alpha1",
+				"kind":       "SyncTarget",
+				"metadata":   map[string]interface{}{"name": "st"},
+				"spec": map[string]interface{}{
+					"supportedAPIExports": []interface{}{
+						map[string]interface{}{"workspace": map[string]interface{}{"path": "root:org:ws", "exportName": "kubernetes"}},
+						map[string]interface{}{"workspace": map[string]interface{}{"path": "root:compute", "exportName": "kubernetes"}},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "workload.kcp.dev/v1alpha1",
+				"kind":       "SyncTarget",
+				"metadata":   map[string]interface{}{"name": "st"},
+				"spec": map[string]interface{}{
+					"supportedAPIExports": []interface{}{
+						map[string]interface{}{"workspace": map[string]interface{}{"path": "root:team:renamed", "exportName": "kubernetes"}},
+						map[string]interface{}{"workspace": map[string]interface{}{"path": "root:compute", "exportName": "kubernetes"}},
+					},
+				},
+			},
+		},
+		{
+			name: "AccessGrants keep their expiration time",
+			gr:   accessGrantsResource,
+			obj: map[string]interface{}{
+				"apiVersion": "tenancy.kcp.dev/v1alpha1",
+				"kind":       "AccessGrant",
+				"metadata":   map[string]interface{}{"name": "g", "creationTimestamp": "2022-01-01T00:00:00Z"},
+				"spec":       map[string]interface{}{"duration": "1h0m0s", "expirationTime": "2022-01-01T00:30:00Z"},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "tenancy.kcp.dev/v1alpha1",
+				"kind":       "AccessGrant",
+				"metadata":   map[string]interface{}{"name": "g"},
+				"spec":       map[string]interface{}{"duration": "1h0m0s", "expirationTime": "2022-01-01T00:30:00Z"},
+			},
+		},
+		{
+			name: "AccessGrants without expiration time expire at their creation time plus duration",
+			gr:   accessGrantsResource,
+			obj: map[string]interface{}{
+				"apiVersion": "tenancy.kcp.dev/v1alpha1",
+				"kind":       "AccessGrant",
+				"metadata":   map[string]interface{}{"name": "g", "creationTimestamp": "2022-01-01T00:00:00Z"},
+				"spec":       map[string]interface{}{"duration": "1h0m0s"},
+			},
+			want: map[string]interface{}{
+				"apiVersion": "tenancy.kcp.dev/v1alpha1",
+				"kind":       "AccessGrant",
+				"metadata":   map[string]interface{}{"name": "g"},
+				"spec":       map[string]interface{}{"duration": "1h0m0s", "expirationTime": "2022-01-01T01:00:00Z"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: tt.obj}
+			require.NoError(t, PrepareForCopy(tt.gr, obj, from, to))
+			require.Equal(t, tt.want, obj.Object)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemove
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// reconcile advances the move of the workspace by one stage, and reflects the progress in the
+// WorkspaceMoved condition. Every stage is idempotent, such that an interrupted move continues
+// where it stopped.
+func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := klog.FromContext(ctx)
+	if workspace.Spec.MoveTo == nil || !workspace.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	// both are defaulted by admission
+	if workspace.Spec.MoveTo.Parent == "" || workspace.Spec.MoveTo.Name == "" {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedTargetConflict, conditionsv1alpha1.ConditionSeverityError,
+			"spec.moveTo.parent and spec.moveTo.name must be set")
+		return nil
+	}
+
+	source := logicalcluster.From(workspace).Join(workspace.Name)
+	targetParent := logicalcluster.New(workspace.Spec.MoveTo.Parent)
+	target := targetParent.Join(workspace.Spec.MoveTo.Name)
+
+	if workspace.Status.Location.Current != c.shardName {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedCopyFailed, conditionsv1alpha1.ConditionSeverityError,
+			"Workspace content is on shard %q, but moves are only supported on shard %q of the parent workspace", workspace.Status.Location.Current, c.shardName)
+		return nil
+	}
+
+	// content is copied and references are updated, remove the source
+	if conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceMoved) {
+		logger.Info("deleting moved workspace", "target", target)
+		if err := c.deleteWorkspace(ctx, logicalcluster.From(workspace), workspace.Name); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	targetWorkspace, err := c.getWorkspace(targetParent, workspace.Spec.MoveTo.Name)
+	if errors.IsNotFound(err) {
+		logger.Info("creating move target", "target", target)
+		if err := c.createWorkspace(ctx, targetParent, newMoveTarget(workspace)); err != nil && !errors.IsAlreadyExists(err) {
+			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedTargetNotReady, conditionsv1alpha1.ConditionSeverityError,
+				"Failed to create workspace %s: %v", target, err)
+			return err
+		}
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedTargetNotReady, conditionsv1alpha1.ConditionSeverityInfo,
+			"Waiting for workspace %s to become ready", target)
+		return nil // wait for the target to show up in the informer
+	} else if err != nil {
+		return err
+	}
+
+	if targetWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMovedFromAnnotationKey] != source.String() {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedTargetConflict, conditionsv1alpha1.ConditionSeverityError,
+			"Workspace %s already exists and is not the target of this move", target)
+		return nil
+	}
+	if targetWorkspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedTargetNotReady, conditionsv1alpha1.ConditionSeverityInfo,
+			"Waiting for workspace %s to become ready", target)
+		return nil
+	}
+
+	if !workspace.Spec.ReadOnly {
+		// block writes before the copy, such that no write to the source is lost
+		logger.Info("blocking writes to moved workspace", "target", target)
+		return c.setReadOnly(ctx, logicalcluster.From(workspace), workspace.Name, true) // the update requeues the workspace
+	}
+
+	logger.V(2).Info("copying workspace content", "target", target)
+	if err := c.copyContent(ctx, source, target); err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedCopyFailed, conditionsv1alpha1.ConditionSeverityError,
+			"Failed to copy content to workspace %s: %v", target, err)
+		return err
+	}
+
+	logger.V(2).Info("updating references to the moved workspace", "target", target)
+	if err := c.updateReferences(ctx, source, target); err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMoved, tenancyv1alpha1.WorkspaceMovedReferenceUpdateFailed, conditionsv1alpha1.ConditionSeverityError,
+			"Failed to update references to workspace %s: %v", source, err)
+		return err
+	}
+
+	if targetWorkspace.Spec.ReadOnly {
+		logger.Info("unblocking writes to move target", "target", target)
+		if err := c.setReadOnly(ctx, targetParent, workspace.Spec.MoveTo.Name, false); err != nil {
+			return err
+		}
+	}
+
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceMoved)
+	return nil
+}
+
+// newMoveTarget returns the ClusterWorkspace replacing the given workspace at its spec.moveTo. It
+// is scheduled onto the shard of the given workspace, such that its content can be copied locally,
+// and is read-only until the move finished.
+func newMoveTarget(workspace *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	target := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   workspace.Spec.MoveTo.Name,
+			Labels: labels.Merge(nil, workspace.Labels),
+			Annotations: map[string]string{
+				tenancyv1alpha1.ClusterWorkspaceMovedFromAnnotationKey: logicalcluster.From(workspace).Join(workspace.Name).String(),
+			},
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			ReadOnly:              true,
+			Type:                  workspace.Spec.Type,
+			InitializerParameters: workspace.Spec.InitializerParameters,
+			Shard: &tenancyv1alpha1.ShardConstraints{
+				Name: workspace.Status.Location.Current,
+			},
+		},
+	}
+	if owner, ok := workspace.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey]; ok {
+		target.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey] = owner
+	}
+	return target
+}
+
+// updateReferencesOnShard rewrites the path references of APIBindings and SyncTargets on this shard
+// from one workspace to another. References from other shards are not updated.
+func (c *controller) updateReferencesOnShard(ctx context.Context, from, to logicalcluster.Name) error {
+	var errs []error
+
+	bindings, err := c.listAPIBindingsReferencing(from)
+	if err != nil {
+		return err
+	}
+	for _, binding := range bindings {
+		clusterName := logicalcluster.From(binding)
+		if clusterName == from {
+			continue // deleted together with the moved workspace
+		}
+		binding = binding.DeepCopy()
+		if !rewriteAPIBindingReference(binding, from, to) {
+			continue
+		}
+		if _, err := c.kcpClusterClient.ApisV1alpha1().APIBindings().Update(logicalcluster.WithCluster(ctx, clusterName), binding, metav1.UpdateOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update APIBinding %s|%s: %w", clusterName, binding.Name, err))
+		}
+	}
+
+	syncTargets, err := c.syncTargetLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, syncTarget := range syncTargets {
+		clusterName := logicalcluster.From(syncTarget)
+		if clusterName == from {
+			continue // deleted together with the moved workspace
+		}
+		syncTarget = syncTarget.DeepCopy()
+		if !rewriteSyncTargetReferences(syncTarget, from, to) {
+			continue
+		}
+		if _, err := c.kcpClusterClient.WorkloadV1alpha1().SyncTargets().Update(logicalcluster.WithCluster(ctx, clusterName), syncTarget, metav1.UpdateOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update SyncTarget %s|%s: %w", clusterName, syncTarget.Name, err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	target := func(phase tenancyv1alpha1.ClusterWorkspacePhaseType, movedFrom string) *tenancyv1alpha1.ClusterWorkspace {
+		return &tenancyv1alpha1.ClusterWorkspace{
+			Spec: tenancyv1alpha1.ClusterWorkspaceSpec{ReadOnly: true},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "renamed",
+				Annotations: map[string]string{
+					logicalcluster.AnnotationKey:                           "root:team",
+					tenancyv1alpha1.ClusterWorkspaceMovedFromAnnotationKey: movedFrom,
+				},
+			},
+			Status: tenancyv1alpha1.ClusterWorkspaceStatus{Phase: phase},
+		}
+	}
+
+	for _, testCase := range []struct {
+		name          string
+		noMove        bool
+		emptyMoveTo   bool
+		shard         string
+		moved         bool
+		writable      bool
+		target        *tenancyv1alpha1.ClusterWorkspace
+		copyErr       error
+		referencesErr error
+
+		wantCreated    bool
+		wantCopied     bool
+		wantReferences bool
+		wantDeleted    bool
+		wantReadOnly   []string
+		wantErr        bool
+		wantReason     string
+		wantCondition  corev1.ConditionStatus
+	}{
+		{
+			name:   "workspaces without moveTo are skipped",
+			noMove: true,
+		},
+		{
+			name:          "moves without target are not started",
+			emptyMoveTo:   true,
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMovedTargetConflict,
+		},
+		{
+			name:          "workspaces on other shards are not moved",
+			shard:         "other",
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMovedCopyFailed,
+		},
+		{
+			name:          "target is created",
+			wantCreated:   true,
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMovedTargetNotReady,
+		},
+		{
+			name:          "waits for the target to be ready",
+			target:        target(tenancyv1alpha1.ClusterWorkspacePhaseInitializing, "root:org:ws"),
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMovedTargetNotReady,
+		},
+		{
+			name:          "foreign targets are a conflict",
+			target:        target(tenancyv1alpha1.ClusterWorkspacePhaseReady, "root:org:other"),
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMovedTargetConflict,
+		},
+		{
+			name:         "writes are blocked before the copy",
+			writable:     true,
+			target:       target(tenancyv1alpha1.ClusterWorkspacePhaseReady, "root:org:ws"),
+			wantReadOnly: []string{"root:org|ws=true"},
+		},
+		{
+			name:           "content is copied, references are updated and the target is unblocked",
+			target:         target(tenancyv1alpha1.ClusterWorkspacePhaseReady, "root:org:ws"),
+			wantCopied:     true,
+			wantReferences: true,
+			wantReadOnly:   []string{"root:team|renamed=false"},
+			wantCondition:  corev1.ConditionTrue,
+		},
+		{
+			name:          "copy errors are reported",
+			target:        target(tenancyv1alpha1.ClusterWorkspacePhaseReady, "root:org:ws"),
+			copyErr:       errors.New("boom"),
+			wantCopied:    true,
+			wantErr:       true,
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMovedCopyFailed,
+		},
+		{
+			name:           "reference update errors are reported",
+			target:         target(tenancyv1alpha1.ClusterWorkspacePhaseReady, "root:org:ws"),
+			referencesErr:  errors.New("boom"),
+			wantCopied:     true,
+			wantReferences: true,
+			wantErr:        true,
+			wantCondition:  corev1.ConditionFalse,
+			wantReason:     tenancyv1alpha1.WorkspaceMovedReferenceUpdateFailed,
+		},
+		{
+			name:          "moved workspaces are deleted",
+			moved:         true,
+			wantDeleted:   true,
+			wantCondition: corev1.ConditionTrue,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var created, copied, references, deleted bool
+			var readOnly []string
+			c := &controller{
+				shardName: "root",
+				getWorkspace: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+					require.Equal(t, logicalcluster.New("root:team"), clusterName)
+					require.Equal(t, "renamed", name)
+					if testCase.target == nil {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+					}
+					return testCase.target, nil
+				},
+				createWorkspace: func(ctx context.Context, clusterName logicalcluster.Name, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+					require.Equal(t, logicalcluster.New("root:team"), clusterName)
+					require.Equal(t, "renamed", workspace.Name)
+					require.Equal(t, "root:org:ws", workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMovedFromAnnotationKey])
+					require.Equal(t, "root", workspace.Spec.Shard.Name)
+					require.True(t, workspace.Spec.ReadOnly)
+					created = true
+					return nil
+				},
+				deleteWorkspace: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					require.Equal(t, "ws", name)
+					deleted = true
+					return nil
+				},
+				setReadOnly: func(ctx context.Context, clusterName logicalcluster.Name, name string, value bool) error {
+					readOnly = append(readOnly, fmt.Sprintf("%s|%s=%t", clusterName, name, value))
+					return nil
+				},
+				copyContent: func(ctx context.Context, from, to logicalcluster.Name) error {
+					require.Equal(t, logicalcluster.New("root:org:ws"), from)
+					require.Equal(t, logicalcluster.New("root:team:renamed"), to)
+					copied = true
+					return testCase.copyErr
+				},
+				updateReferences: func(ctx context.Context, from, to logicalcluster.Name) error {
+					require.Equal(t, logicalcluster.New("root:org:ws"), from)
+					require.Equal(t, logicalcluster.New("root:team:renamed"), to)
+					references = true
+					return testCase.referencesErr
+				},
+			}
+
+			shard := testCase.shard
+			if shard == "" {
+				shard = "root"
+			}
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "ws",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					ReadOnly: !testCase.writable,
+					MoveTo:   &tenancyv1alpha1.ClusterWorkspaceMoveTarget{Parent: "root:team", Name: "renamed"},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: shard},
+				},
+			}
+			if testCase.emptyMoveTo {
+				workspace.Spec.MoveTo = &tenancyv1alpha1.ClusterWorkspaceMoveTarget{}
+			}
+			if testCase.noMove {
+				workspace.Spec.MoveTo = nil
+			}
+			if testCase.moved {
+				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceMoved)
+			}
+
+			err := c.reconcile(context.Background(), workspace)
+			if testCase.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, testCase.wantCreated, created, "created")
+			require.Equal(t, testCase.wantCopied, copied, "copied")
+			require.Equal(t, testCase.wantReferences, references, "references")
+			require.Equal(t, testCase.wantDeleted, deleted, "deleted")
+			require.Equal(t, testCase.wantReadOnly, readOnly, "readOnly")
+
+			condition := conditions.Get(workspace, tenancyv1alpha1.WorkspaceMoved)
+			if testCase.wantCondition == "" {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, testCase.wantCondition, condition.Status)
+			require.Equal(t, testCase.wantReason, condition.Reason)
+			if condition.Status == corev1.ConditionFalse && testCase.wantReason != tenancyv1alpha1.WorkspaceMovedTargetNotReady {
+				require.Equal(t, conditionsv1alpha1.ConditionSeverityError, condition.Severity)
+			}
+		})
+	}
+}
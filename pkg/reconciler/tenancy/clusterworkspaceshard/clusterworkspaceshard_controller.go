@@ -37,10 +37,13 @@ import (
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
@@ -51,13 +54,14 @@ func NewController(
 	rootKcpClient kcpclient.Interface,
 	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
 ) (*Controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &Controller{
 		queue:                        queue,
 		kcpClient:                    rootKcpClient,
 		clusterWorkspaceShardIndexer: clusterWorkspaceShardInformer.Informer().GetIndexer(),
 		clusterWorkspaceShardLister:  clusterWorkspaceShardInformer.Lister(),
+		now:                          time.Now,
 	}
 
 	clusterWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -69,7 +73,8 @@ func NewController(
 }
 
 // Controller watches WorkspaceShards and Secrets in order to make sure every ClusterWorkspaceShard
-// has its URL exposed when a valid kubeconfig is connected to it.
+// has its URL exposed when a valid kubeconfig is connected to it. It also aggregates the heartbeats
+// of the shards into their Available condition.
 type Controller struct {
 	queue workqueue.RateLimitingInterface
 
@@ -77,6 +82,8 @@ type Controller struct {
 
 	clusterWorkspaceShardIndexer cache.Indexer
 	clusterWorkspaceShardLister  tenancylisters.ClusterWorkspaceShardLister
+
+	now func() time.Time
 }
 
 func (c *Controller) enqueue(obj interface{}) {
@@ -198,5 +205,43 @@ func (c *Controller) process(ctx context.Context, key string) error {
 }
 
 func (c *Controller) reconcile(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error {
+	requeueAfter, err := c.reconcileHeartbeat(ctx, workspaceShard)
+	if err != nil {
+		return err
+	}
+	if requeueAfter > 0 {
+		key, err := cache.MetaNamespaceKeyFunc(workspaceShard)
+		if err != nil {
+			return err
+		}
+		c.queue.AddAfter(key, requeueAfter)
+	}
 	return nil
 }
+
+// reconcileHeartbeat sets the Available condition from the last heartbeat of the shard. Shards which
+// never sent a heartbeat are left alone. It returns when the heartbeat times out.
+func (c *Controller) reconcileHeartbeat(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) (time.Duration, error) {
+	if workspaceShard.Status.LastHeartbeatTime == nil {
+		return 0, nil
+	}
+
+	age := c.now().Sub(workspaceShard.Status.LastHeartbeatTime.Time)
+	if age > heartbeatTimeout {
+		if !conditions.IsFalse(workspaceShard, tenancyv1alpha1.ClusterWorkspaceShardAvailable) {
+			klog.FromContext(ctx).Info("shard stopped sending heartbeats", "lastHeartbeatTime", workspaceShard.Status.LastHeartbeatTime.Time)
+		}
+		conditions.MarkFalse(
+			workspaceShard,
+			tenancyv1alpha1.ClusterWorkspaceShardAvailable,
+			tenancyv1alpha1.ClusterWorkspaceShardHeartbeatMissingReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"No heartbeat since %s",
+			workspaceShard.Status.LastHeartbeatTime.UTC().Format(time.RFC3339),
+		)
+		return 0, nil
+	}
+
+	conditions.MarkTrue(workspaceShard, tenancyv1alpha1.ClusterWorkspaceShardAvailable)
+	return heartbeatTimeout - age + time.Second, nil
+}
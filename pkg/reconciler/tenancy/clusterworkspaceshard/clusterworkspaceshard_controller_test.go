@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcileHeartbeat(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		lastHeartbeat    *time.Time
+		wantCondition    bool
+		wantAvailable    bool
+		wantRequeueAfter time.Duration
+	}{
+		"no heartbeat yet": {},
+		"recent heartbeat": {
+			lastHeartbeat:    timePtr(now.Add(-10 * time.Second)),
+			wantCondition:    true,
+			wantAvailable:    true,
+			wantRequeueAfter: heartbeatTimeout - 10*time.Second + time.Second,
+		},
+		"missing heartbeat": {
+			lastHeartbeat: timePtr(now.Add(-heartbeatTimeout - time.Second)),
+			wantCondition: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{now: func() time.Time { return now }}
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{ObjectMeta: metav1.ObjectMeta{Name: "amber"}}
+			if tt.lastHeartbeat != nil {
+				shard.Status.LastHeartbeatTime = &metav1.Time{Time: *tt.lastHeartbeat}
+			}
+
+			requeueAfter, err := c.reconcileHeartbeat(context.Background(), shard)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantRequeueAfter, requeueAfter)
+
+			require.Equal(t, tt.wantCondition, conditions.Has(shard, tenancyv1alpha1.ClusterWorkspaceShardAvailable))
+			if tt.wantCondition {
+				require.Equal(t, tt.wantAvailable, conditions.IsTrue(shard, tenancyv1alpha1.ClusterWorkspaceShardAvailable))
+			}
+			if tt.wantCondition && !tt.wantAvailable {
+				require.Equal(t, tenancyv1alpha1.ClusterWorkspaceShardHeartbeatMissingReason, conditions.GetReason(shard, tenancyv1alpha1.ClusterWorkspaceShardAvailable))
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
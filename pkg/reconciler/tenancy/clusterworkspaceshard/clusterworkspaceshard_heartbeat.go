@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+const (
+	// HeartbeatControllerName is the name of the heartbeat loop of every shard.
+	HeartbeatControllerName = "kcp-clusterworkspaceshard-heartbeat"
+
+	// HeartbeatInterval is the interval in which shards send heartbeats.
+	HeartbeatInterval = 15 * time.Second
+
+	// heartbeatTimeout is the duration without heartbeat after which a shard is considered down.
+	heartbeatTimeout = 4 * HeartbeatInterval
+)
+
+// Heartbeat periodically records in the ClusterWorkspaceShard of this shard on the root shard that
+// the shard is alive.
+type Heartbeat struct {
+	shardName  string
+	rootClient kcpclient.ClusterInterface
+}
+
+// NewHeartbeat returns a heartbeat for the given shard, using the given client of the root shard.
+func NewHeartbeat(shardName string, rootClient kcpclient.ClusterInterface) *Heartbeat {
+	return &Heartbeat{
+		shardName:  shardName,
+		rootClient: rootClient,
+	}
+}
+
+// Start sends heartbeats until ctx is done.
+func (h *Heartbeat) Start(ctx context.Context) {
+	logger := klog.FromContext(ctx).WithValues("component", HeartbeatControllerName, "ClusterWorkspaceShard", h.shardName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting heartbeat")
+	defer logger.Info("Shutting down heartbeat")
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := h.beat(ctx); err != nil {
+			logger.Error(err, "failed to send heartbeat")
+		}
+	}, HeartbeatInterval)
+}
+
+func (h *Heartbeat) beat(ctx context.Context) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"lastHeartbeatTime": metav1.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = h.rootClient.Cluster(tenancyv1alpha1.RootCluster).TenancyV1alpha1().ClusterWorkspaceShards().Patch(ctx, h.shardName, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultobjects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/author"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-clusterworkspace-default-objects"
+
+	// createOnlyAnnotationKey marks default objects which are created, but never updated. It matches the
+	// annotation of the bootstrap helpers.
+	createOnlyAnnotationKey = "bootstrap.kcp.dev/create-only"
+
+	// driftResyncPeriod is the period after which the default objects of a workspace are applied again,
+	// reverting changes made to them in the workspace.
+	driftResyncPeriod = 10 * time.Minute
+)
+
+// NewController returns a new controller applying the defaultObjects of ClusterWorkspaceTypes to the
+// ClusterWorkspaces of these types. The dynamic client must impersonate the author of the request
+// context, see author.WithImpersonatedAuthor.
+func NewController(
+	dynamicClusterClient dynamic.Interface,
+	kcpClusterClient kcpclient.Interface,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	workspaceTypeInformer tenancyinformers.ClusterWorkspaceTypeInformer,
+	newRESTMapper func(clusterName logicalcluster.Name) (meta.RESTMapper, error),
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	workspaceTypeLister := workspaceTypeInformer.Lister()
+	c := &controller{
+		queue:            queue,
+		kcpClusterClient: kcpClusterClient,
+		workspaceLister:  workspaceInformer.Lister(),
+		getClusterWorkspaceType: func(reference tenancyv1alpha1.ClusterWorkspaceTypeReference) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
+			return workspaceTypeLister.Get(clusters.ToClusterAwareKey(logicalcluster.New(reference.Path), tenancyv1alpha1.ObjectName(reference.Name)))
+		},
+		applyObjects: func(ctx context.Context, clusterName logicalcluster.Name, objAuthor user.Info, objs []*unstructured.Unstructured) error {
+			mapper, err := newRESTMapper(clusterName)
+			if err != nil {
+				return err
+			}
+			return ApplyObjects(author.WithAuthor(logicalcluster.WithCluster(ctx, clusterName), objAuthor), dynamicClusterClient, mapper, controllerName, objs)
+		},
+		syncChecks: []cache.InformerSynced{
+			workspaceInformer.Informer().HasSynced,
+			workspaceTypeInformer.Informer().HasSynced,
+		},
+	}
+
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	})
+
+	workspaceTypeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspacesOfType(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspacesOfType(obj) },
+	})
+
+	return c, nil
+}
+
+// controller watches ClusterWorkspaces in initializing and ready phase, and applies the defaultObjects
+// of their ClusterWorkspaceType to them. The objects are applied again periodically to revert drift.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.Interface
+
+	workspaceLister         tenancylisters.ClusterWorkspaceLister
+	getClusterWorkspaceType func(reference tenancyv1alpha1.ClusterWorkspaceTypeReference) (*tenancyv1alpha1.ClusterWorkspaceType, error)
+	applyObjects            func(ctx context.Context, clusterName logicalcluster.Name, author user.Info, objs []*unstructured.Unstructured) error
+
+	syncChecks []cache.InformerSynced
+}
+
+func (c *controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace")
+	c.queue.Add(key)
+}
+
+func (c *controller) enqueueWorkspacesOfType(obj interface{}) {
+	cwt, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceType)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+
+	workspaces, err := c.workspaceLister.List(labels.SelectorFromSet(labels.Set{
+		tenancyv1alpha1.ClusterWorkspaceTypeLabel: helper.TypeToLabel(tenancyv1alpha1.ReferenceFor(cwt)),
+	}))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), cwt)
+	for _, workspace := range workspaces {
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		logging.WithQueueKey(logger, key).V(2).Info("queueing ClusterWorkspace because ClusterWorkspaceType changed")
+		c.queue.Add(key)
+	}
+}
+
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), c.syncChecks...) {
+		logger.Error(nil, "Failed to wait for caches to sync")
+		return
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	resync, err := c.process(ctx, key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	if resync {
+		// apply again later to revert drift
+		c.queue.AddAfter(key, driftResyncPeriod)
+	}
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) (bool, error) {
+	obj, err := c.workspaceLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil // object deleted before we handled it
+		}
+		return false, err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	resync, err := c.reconcile(ctx, obj)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Regardless of whether reconcile returned an error or not, always try to patch status if needed. Return the
+	// reconciliation error at the end.
+	if err := c.patchStatusIfNeeded(ctx, old, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	return resync, utilerrors.NewAggregate(errs)
+}
+
+func (c *controller) patchStatusIfNeeded(ctx context.Context, old, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(old)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+	_, err = c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// ApplyObjects applies the objects with server-side apply as the given field manager, forcing conflicts such
+// that changes made by others to the fields of the objects are reverted. Objects with the create-only annotation
+// are only created.
+func ApplyObjects(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, fieldManager string, objs []*unstructured.Unstructured) error {
+	var errs []error
+	for _, obj := range objs {
+		if err := applyObject(ctx, client, mapper, fieldManager, obj); err != nil {
+			errs = append(errs, fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func applyObject(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, fieldManager string, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if resettable, ok := mapper.(meta.ResettableRESTMapper); ok && meta.IsNoMatchError(err) {
+		// the mapper is cached, and might predate the API, e.g. when bound by an earlier object
+		resettable.Reset()
+		mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return err
+	}
+	namespace := obj.GetNamespace()
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		namespace = ""
+	}
+	resourceClient := client.Resource(mapping.Resource).Namespace(namespace)
+
+	if _, createOnly := obj.GetAnnotations()[createOnlyAnnotationKey]; createOnly {
+		_, err := resourceClient.Create(ctx, obj, metav1.CreateOptions{FieldManager: fieldManager})
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = resourceClient.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: fieldManager, Force: pointer.Bool(true)})
+	return err
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultobjects
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/author"
+)
+
+// reconcile applies the defaultObjects of the type of the workspace, and reflects the result in the
+// WorkspaceDefaultObjectsApplied condition. It returns whether the objects must be applied again later
+// to revert drift.
+func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (bool, error) {
+	logger := klog.FromContext(ctx)
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return false, nil
+	}
+
+	cwt, err := c.getClusterWorkspaceType(workspace.Spec.Type)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil // type deleted, or not known on this shard
+		}
+		return false, err
+	}
+	if len(cwt.Spec.DefaultObjects) == 0 {
+		return false, nil
+	}
+
+	// the objects are applied impersonating their author, such that they cannot grant more than the author could
+	objAuthor, found, err := author.From(cwt)
+	if err == nil && !found {
+		err = fmt.Errorf("missing %s annotation", tenancyv1alpha1.ExperimentalAuthorAnnotationKey)
+	}
+	if err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceDefaultObjectsApplied, tenancyv1alpha1.WorkspaceDefaultObjectsApplyFailed, conditionsv1alpha1.ConditionSeverityError,
+			"Invalid author of the defaultObjects of ClusterWorkspaceType %s: %v", workspace.Spec.Type, err)
+		return true, nil // wait for the type to be fixed
+	}
+
+	objs := make([]*unstructured.Unstructured, 0, len(cwt.Spec.DefaultObjects))
+	for i, obj := range cwt.Spec.DefaultObjects {
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(obj.Raw); err != nil {
+			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceDefaultObjectsApplied, tenancyv1alpha1.WorkspaceDefaultObjectsApplyFailed, conditionsv1alpha1.ConditionSeverityError,
+				"Invalid defaultObjects[%d] of ClusterWorkspaceType %s: %v", i, workspace.Spec.Type, err)
+			return true, nil // wait for the type to be fixed
+		}
+		objs = append(objs, u)
+	}
+
+	wsClusterName := logicalcluster.From(workspace).Join(workspace.Name)
+	logger.V(4).Info("applying default objects", "logicalCluster", wsClusterName, "count", len(objs), "author", objAuthor.GetName())
+	if err := c.applyObjects(ctx, wsClusterName, objAuthor, objs); err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceDefaultObjectsApplied, tenancyv1alpha1.WorkspaceDefaultObjectsApplyFailed, conditionsv1alpha1.ConditionSeverityError,
+			"Failed to apply default objects: %v", err)
+		return false, fmt.Errorf("failed to apply default objects to workspace %s: %w", wsClusterName, err)
+	}
+
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceDefaultObjectsApplied)
+	return true, nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultobjects
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	binding := tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"apis.kcp.dev/v1alpha1","kind":"APIBinding","metadata":{"name":"kubernetes"}}`),
+	}}
+
+	for _, testCase := range []struct {
+		name            string
+		phase           tenancyv1alpha1.ClusterWorkspacePhaseType
+		objects         []tenancyv1alpha1.DefaultObject
+		typeMissing     bool
+		authorMissing   bool
+		applyErr        error
+		wantApplied     []string
+		wantResync      bool
+		wantErr         bool
+		wantCondition   corev1.ConditionStatus
+		wantNoCondition bool
+	}{
+		{
+			name:            "scheduling workspaces are skipped",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+			objects:         []tenancyv1alpha1.DefaultObject{binding},
+			wantNoCondition: true,
+		},
+		{
+			name:            "types without default objects are skipped",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			wantNoCondition: true,
+		},
+		{
+			name:            "missing types are skipped",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			typeMissing:     true,
+			wantNoCondition: true,
+		},
+		{
+			name:          "objects are applied to initializing workspaces",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			objects:       []tenancyv1alpha1.DefaultObject{binding},
+			wantApplied:   []string{"APIBinding/kubernetes"},
+			wantResync:    true,
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:          "objects are applied to ready workspaces",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			objects:       []tenancyv1alpha1.DefaultObject{binding},
+			wantApplied:   []string{"APIBinding/kubernetes"},
+			wantResync:    true,
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:          "apply errors are reported",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			objects:       []tenancyv1alpha1.DefaultObject{binding},
+			applyErr:      errors.New("boom"),
+			wantApplied:   []string{"APIBinding/kubernetes"},
+			wantErr:       true,
+			wantCondition: corev1.ConditionFalse,
+		},
+		{
+			name:          "objects without author are not applied",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			objects:       []tenancyv1alpha1.DefaultObject{binding},
+			authorMissing: true,
+			wantResync:    true,
+			wantCondition: corev1.ConditionFalse,
+		},
+		{
+			name:          "invalid objects are reported",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			objects:       []tenancyv1alpha1.DefaultObject{{RawExtension: runtime.RawExtension{Raw: []byte(`[]`)}}},
+			wantResync:    true,
+			wantCondition: corev1.ConditionFalse,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var applied []string
+			c := &controller{
+				getClusterWorkspaceType: func(reference tenancyv1alpha1.ClusterWorkspaceTypeReference) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
+					require.Equal(t, tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root:org", Name: "team"}, reference)
+					if testCase.typeMissing {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspacetypes"), "team")
+					}
+					cwt := &tenancyv1alpha1.ClusterWorkspaceType{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "team",
+							Annotations: map[string]string{
+								logicalcluster.AnnotationKey:                    "root:org",
+								tenancyv1alpha1.ExperimentalAuthorAnnotationKey: `{"username":"alice","groups":["system:authenticated"]}`,
+							},
+						},
+						Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{DefaultObjects: testCase.objects},
+					}
+					if testCase.authorMissing {
+						delete(cwt.Annotations, tenancyv1alpha1.ExperimentalAuthorAnnotationKey)
+					}
+					return cwt, nil
+				},
+				applyObjects: func(ctx context.Context, clusterName logicalcluster.Name, author user.Info, objs []*unstructured.Unstructured) error {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					require.Equal(t, &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}}, author)
+					for _, obj := range objs {
+						applied = append(applied, obj.GetKind()+"/"+obj.GetName())
+					}
+					return testCase.applyErr
+				},
+			}
+
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "ws",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root:org", Name: "team"},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{Phase: testCase.phase},
+			}
+
+			resync, err := c.reconcile(context.Background(), workspace)
+			if testCase.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, testCase.wantResync, resync)
+			require.Equal(t, testCase.wantApplied, applied)
+
+			condition := conditions.Get(workspace, tenancyv1alpha1.WorkspaceDefaultObjectsApplied)
+			if testCase.wantNoCondition {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, testCase.wantCondition, condition.Status)
+		})
+	}
+}
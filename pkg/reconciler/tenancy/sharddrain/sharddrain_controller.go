@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharddrain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-shard-drain"
+)
+
+// NewController returns a new controller which creates WorkspaceMigrations for the ClusterWorkspaces on
+// draining shards. Only the given shards are chosen as migration targets, i.e. those the migration
+// controller of this shard can reach.
+func NewController(
+	shardName string,
+	reachableShards sets.String,
+	kcpClusterClient kcpclient.Interface,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	shardInformer tenancyinformers.ClusterWorkspaceShardInformer,
+	migrationInformer tenancyinformers.WorkspaceMigrationInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	shardLister := shardInformer.Lister()
+	migrationLister := migrationInformer.Lister()
+	c := &controller{
+		queue:           queue,
+		shardName:       shardName,
+		reachableShards: reachableShards,
+		workspaceLister: workspaceInformer.Lister(),
+		getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+			return shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
+		},
+		listShards: func() ([]*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+			return shardLister.List(labels.Everything())
+		},
+		listMigrations: func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspaceMigration, error) {
+			migrations, err := migrationLister.List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			var ret []*tenancyv1alpha1.WorkspaceMigration
+			for _, migration := range migrations {
+				if logicalcluster.From(migration) == clusterName {
+					ret = append(ret, migration)
+				}
+			}
+			return ret, nil
+		},
+		createMigration: func(ctx context.Context, clusterName logicalcluster.Name, migration *tenancyv1alpha1.WorkspaceMigration) error {
+			_, err := kcpClusterClient.TenancyV1alpha1().WorkspaceMigrations().Create(logicalcluster.WithCluster(ctx, clusterName), migration, metav1.CreateOptions{})
+			return err
+		},
+		syncChecks: []cache.InformerSynced{
+			workspaceInformer.Informer().HasSynced,
+			shardInformer.Informer().HasSynced,
+			migrationInformer.Informer().HasSynced,
+		},
+	}
+
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	})
+	shardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueShard(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueShard(obj) },
+	})
+
+	return c, nil
+}
+
+// controller migrates the ClusterWorkspaces of draining shards away by creating a WorkspaceMigration
+// per workspace in its parent workspace. It acts on the ClusterWorkspaces of this shard, i.e. those
+// whose parent workspace lives here.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	shardName       string
+	reachableShards sets.String
+
+	workspaceLister tenancylisters.ClusterWorkspaceLister
+
+	getShard        func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	listShards      func() ([]*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	listMigrations  func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspaceMigration, error)
+	createMigration func(ctx context.Context, clusterName logicalcluster.Name, migration *tenancyv1alpha1.WorkspaceMigration) error
+
+	syncChecks []cache.InformerSynced
+}
+
+// enqueue enqueues a ClusterWorkspace.
+func (c *controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing ClusterWorkspace")
+	c.queue.Add(key)
+}
+
+// enqueueShard enqueues the ClusterWorkspaces on a draining shard.
+func (c *controller) enqueueShard(obj interface{}) {
+	shard, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+	if shard.Spec.State != tenancyv1alpha1.ClusterWorkspaceShardStateDraining {
+		return
+	}
+
+	workspaces, err := c.workspaceLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, workspace := range workspaces {
+		if workspace.Status.Location.Current == shard.Name {
+			c.enqueue(workspace)
+		}
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), c.syncChecks...) {
+		logger.Error(nil, "Failed to wait for caches to sync")
+		return
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	workspace, err := c.workspaceLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), workspace)
+	ctx = klog.NewContext(ctx, logger)
+
+	return c.reconcile(ctx, workspace)
+}
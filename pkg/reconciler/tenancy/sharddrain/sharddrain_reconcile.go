@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharddrain
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// reconcile creates a WorkspaceMigration for the workspace if it is ready, writable and on a draining
+// shard, unless a migration of the workspace exists already. Failed drain migrations are not retried, but
+// left for the operator to resolve.
+func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := klog.FromContext(ctx)
+
+	current := workspace.Status.Location.Current
+	if current == "" || workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady || !workspace.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	// drain migrations rely on blocking writes for the final copy pass. Workspaces whose writes are
+	// blocked already, or which are moved, are drained once the block is lifted or the move finished.
+	if workspace.Spec.ReadOnly || workspace.Spec.MoveTo != nil {
+		return nil
+	}
+	shard, err := c.getShard(current)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if shard.Spec.State != tenancyv1alpha1.ClusterWorkspaceShardStateDraining {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(workspace)
+	migrations, err := c.listMigrations(clusterName)
+	if err != nil {
+		return err
+	}
+	name := migrationName(current, workspace.Name)
+	for _, migration := range migrations {
+		if migration.Name == name {
+			return nil
+		}
+		if migration.Spec.Workspace != workspace.Name {
+			continue
+		}
+		if migration.Status.Phase != tenancyv1alpha1.WorkspaceMigrationPhaseCompleted && migration.Status.Phase != tenancyv1alpha1.WorkspaceMigrationPhaseFailed {
+			return nil // another migration is in progress
+		}
+	}
+
+	target, err := c.chooseTarget(workspace)
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		logger.Info("no shard to migrate workspace of draining shard to", "ClusterWorkspaceShard", current)
+		return nil // retried on shard changes
+	}
+
+	logger.Info("migrating workspace off draining shard", "ClusterWorkspaceShard", current, "target", target)
+	migration := &tenancyv1alpha1.WorkspaceMigration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: tenancyv1alpha1.WorkspaceMigrationSpec{
+			Workspace:   workspace.Name,
+			TargetShard: target,
+		},
+	}
+	if err := c.createMigration(ctx, clusterName, migration); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// chooseTarget returns a random schedulable and reachable shard fulfilling the shard constraints of
+// the workspace, or an empty string if there is none.
+func (c *controller) chooseTarget(workspace *tenancyv1alpha1.ClusterWorkspace) (string, error) {
+	shards, err := c.listShards()
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []string
+	for _, shard := range shards {
+		if shard.Name == workspace.Status.Location.Current || !shard.IsSchedulable() || !c.reachableShards.Has(shard.Name) {
+			continue
+		}
+		matches, err := c.matchesConstraints(workspace.Spec.Shard, shard)
+		if err != nil {
+			return "", err
+		}
+		if matches {
+			candidates = append(candidates, shard.Name)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", nil
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// matchesConstraints returns whether the shard fulfills all the given shard constraints.
+func (c *controller) matchesConstraints(constraints *tenancyv1alpha1.ShardConstraints, shard *tenancyv1alpha1.ClusterWorkspaceShard) (bool, error) {
+	if constraints == nil {
+		return true, nil
+	}
+	if constraints.Name != "" && shard.Name != constraints.Name {
+		return false, nil
+	}
+	// ClusterWorkspaces are scheduled by the shard of their parent workspace
+	if constraints.SameAsParent && shard.Name != c.shardName {
+		return false, nil
+	}
+	if constraints.Region != "" && shard.Labels[tenancyv1alpha1.ShardRegionLabel] != constraints.Region {
+		return false, nil
+	}
+	if constraints.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(constraints.Selector)
+		if err != nil {
+			return false, nil // invalid selectors match nothing
+		}
+		return selector.Matches(labels.Set(shard.Labels)), nil
+	}
+	return true, nil
+}
+
+// migrationName returns the name of the WorkspaceMigration draining the workspace from the shard.
+func migrationName(shard, workspace string) string {
+	return fmt.Sprintf("drain-%s-%s", shard, workspace)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharddrain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	shard := func(name string, state tenancyv1alpha1.ClusterWorkspaceShardState, labels map[string]string) *tenancyv1alpha1.ClusterWorkspaceShard {
+		return &tenancyv1alpha1.ClusterWorkspaceShard{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{State: state},
+		}
+	}
+	migration := func(name, workspace string, phase tenancyv1alpha1.WorkspaceMigrationPhase) *tenancyv1alpha1.WorkspaceMigration {
+		return &tenancyv1alpha1.WorkspaceMigration{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       tenancyv1alpha1.WorkspaceMigrationSpec{Workspace: workspace},
+			Status:     tenancyv1alpha1.WorkspaceMigrationStatus{Phase: phase},
+		}
+	}
+
+	for _, testCase := range []struct {
+		name           string
+		workspacePhase tenancyv1alpha1.ClusterWorkspacePhaseType
+		constraints    *tenancyv1alpha1.ShardConstraints
+		readOnly       bool
+		moveTo         *tenancyv1alpha1.ClusterWorkspaceMoveTarget
+		shards         []*tenancyv1alpha1.ClusterWorkspaceShard
+		migrations     []*tenancyv1alpha1.WorkspaceMigration
+
+		wantTarget string
+	}{
+		{
+			name: "workspaces on active shards are not migrated",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateActive, nil),
+				shard("sapphire", "", nil),
+			},
+		},
+		{
+			name: "workspaces on cordoned shards are not migrated",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateCordoned, nil),
+				shard("sapphire", "", nil),
+			},
+		},
+		{
+			name: "workspaces on draining shards are migrated to schedulable reachable shards",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("ruby", tenancyv1alpha1.ClusterWorkspaceShardStateCordoned, nil),
+				shard("emerald", "", nil),
+				shard("sapphire", "", nil),
+			},
+			wantTarget: "sapphire",
+		},
+		{
+			name:           "not ready workspaces are not migrated",
+			workspacePhase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("sapphire", "", nil),
+			},
+		},
+		{
+			name:     "read-only workspaces are not migrated",
+			readOnly: true,
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("sapphire", "", nil),
+			},
+		},
+		{
+			name:   "moved workspaces are not migrated",
+			moveTo: &tenancyv1alpha1.ClusterWorkspaceMoveTarget{Parent: "root:other", Name: "team"},
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("sapphire", "", nil),
+			},
+		},
+		{
+			name:        "shard constraints are honored",
+			constraints: &tenancyv1alpha1.ShardConstraints{Region: "eu"},
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("sapphire", "", map[string]string{tenancyv1alpha1.ShardRegionLabel: "us"}),
+				shard("ruby", "", map[string]string{tenancyv1alpha1.ShardRegionLabel: "eu"}),
+			},
+			wantTarget: "ruby",
+		},
+		{
+			name: "existing drain migrations are not recreated",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("sapphire", "", nil),
+			},
+			migrations: []*tenancyv1alpha1.WorkspaceMigration{
+				migration("drain-amber-team", "team", tenancyv1alpha1.WorkspaceMigrationPhaseFailed),
+			},
+		},
+		{
+			name: "migrations in progress are waited for",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("sapphire", "", nil),
+			},
+			migrations: []*tenancyv1alpha1.WorkspaceMigration{
+				migration("manual", "team", tenancyv1alpha1.WorkspaceMigrationPhaseCopying),
+			},
+		},
+		{
+			name: "finished migrations of other shards are ignored",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				shard("amber", tenancyv1alpha1.ClusterWorkspaceShardStateDraining, nil),
+				shard("sapphire", "", nil),
+			},
+			migrations: []*tenancyv1alpha1.WorkspaceMigration{
+				migration("drain-ruby-team", "team", tenancyv1alpha1.WorkspaceMigrationPhaseCompleted),
+			},
+			wantTarget: "sapphire",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var created *tenancyv1alpha1.WorkspaceMigration
+			c := &controller{
+				shardName:       "amber",
+				reachableShards: sets.NewString("amber", "ruby", "sapphire"),
+				getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+					for _, shard := range testCase.shards {
+						if shard.Name == name {
+							return shard, nil
+						}
+					}
+					return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaceshards"), name)
+				},
+				listShards: func() ([]*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+					return testCase.shards, nil
+				},
+				listMigrations: func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspaceMigration, error) {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					return testCase.migrations, nil
+				},
+				createMigration: func(ctx context.Context, clusterName logicalcluster.Name, migration *tenancyv1alpha1.WorkspaceMigration) error {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					created = migration
+					return nil
+				},
+			}
+
+			workspacePhase := testCase.workspacePhase
+			if workspacePhase == "" {
+				workspacePhase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+			}
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "team",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{Shard: testCase.constraints, ReadOnly: testCase.readOnly, MoveTo: testCase.moveTo},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    workspacePhase,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "amber"},
+				},
+			}
+
+			err := c.reconcile(context.Background(), workspace)
+			require.NoError(t, err)
+			if testCase.wantTarget == "" {
+				require.Nil(t, created)
+				return
+			}
+			require.NotNil(t, created)
+			require.Equal(t, "drain-amber-team", created.Name)
+			require.Equal(t, "team", created.Spec.Workspace)
+			require.Equal(t, testCase.wantTarget, created.Spec.TargetShard)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedsecret
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubernetesinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-shared-secret"
+
+	// retryPeriod is the period after which a SharedSecret that is not materialized in all consumer
+	// workspaces is reconciled again, e.g. to pick up created namespaces.
+	retryPeriod = time.Minute
+)
+
+// NewController returns a new controller that materializes the Secrets shared by SharedSecrets in
+// the consumer workspaces, and keeps them in sync. namespaceInformer is expected to be a metadata-only
+// informer, see informer.MetadataSharedInformerFactory.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	kubeClusterClient kubernetesclient.Interface,
+	sharedSecretInformer tenancyinformers.SharedSecretInformer,
+	secretInformer coreinformers.SecretInformer,
+	namespaceInformer kubernetesinformers.GenericInformer,
+	apiExportInformer apisinformers.APIExportInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:               queue,
+		sharedSecretLister:  sharedSecretInformer.Lister(),
+		sharedSecretIndexer: sharedSecretInformer.Informer().GetIndexer(),
+		getSecret: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error) {
+			return secretInformer.Lister().Secrets(namespace).Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		createSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+			_, err := kubeClusterClient.CoreV1().Secrets(secret.Namespace).Create(logicalcluster.WithCluster(ctx, clusterName), secret, metav1.CreateOptions{})
+			return err
+		},
+		updateSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+			_, err := kubeClusterClient.CoreV1().Secrets(secret.Namespace).Update(logicalcluster.WithCluster(ctx, clusterName), secret, metav1.UpdateOptions{})
+			return err
+		},
+		deleteSecret: func(ctx context.Context, clusterName logicalcluster.Name, namespace, name string) error {
+			return kubeClusterClient.CoreV1().Secrets(namespace).Delete(logicalcluster.WithCluster(ctx, clusterName), name, metav1.DeleteOptions{})
+		},
+		getNamespace: func(clusterName logicalcluster.Name, name string) (metav1.Object, error) {
+			obj, err := namespaceInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+			if err != nil {
+				return nil, err
+			}
+			return meta.Accessor(obj)
+		},
+		getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+			return apiExportInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		commit: committer.NewCommitter[*SharedSecret, *SharedSecretSpec, *SharedSecretStatus](kcpClusterClient.TenancyV1alpha1().SharedSecrets()),
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		sharedSecretInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.SharedSecretBySecret: indexers.IndexSharedSecretBySecret,
+		},
+	)
+
+	sharedSecretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueSharedSecret(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueSharedSecret(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueSharedSecret(obj)
+		},
+	})
+
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueSecret(obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			c.enqueueSecret(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueSecret(obj)
+		},
+	})
+
+	return c, nil
+}
+
+type SharedSecret = tenancyv1alpha1.SharedSecret
+type SharedSecretSpec = tenancyv1alpha1.SharedSecretSpec
+type SharedSecretStatus = tenancyv1alpha1.SharedSecretStatus
+type Resource = committer.Resource[*SharedSecretSpec, *SharedSecretStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// controller reconciles SharedSecrets. It materializes the shared Secret in the consumer workspaces,
+// and deletes it from workspaces which are no consumers anymore.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	sharedSecretLister  tenancylisters.SharedSecretLister
+	sharedSecretIndexer cache.Indexer
+
+	getSecret    func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error)
+	createSecret func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error
+	updateSecret func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error
+	deleteSecret func(ctx context.Context, clusterName logicalcluster.Name, namespace, name string) error
+	getNamespace func(clusterName logicalcluster.Name, name string) (metav1.Object, error)
+	getAPIExport func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
+
+	commit CommitFunc
+}
+
+// enqueueSharedSecret enqueues a SharedSecret.
+func (c *controller) enqueueSharedSecret(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing SharedSecret")
+	c.queue.Add(key)
+}
+
+// enqueueSecret enqueues the SharedSecrets sharing the given Secret, or the SharedSecret the
+// given Secret is materialized from.
+func (c *controller) enqueueSecret(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+
+	secretKey, err := cache.MetaNamespaceKeyFunc(secret)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	keys, err := c.sharedSecretIndexer.IndexKeys(indexers.SharedSecretBySecret, secretKey)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	if key, found := secret.Annotations[tenancyv1alpha1.SharedSecretAnnotationKey]; found {
+		keys = append(keys, key)
+	}
+
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), secret)
+	for _, key := range keys {
+		logging.WithQueueKey(logger, key).V(2).Info("queueing SharedSecret because of Secret")
+		c.queue.Add(key)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.sharedSecretLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	requeue, err := c.reconcile(ctx, obj)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// If the object being reconciled changed as a result, update it.
+	oldResource := &Resource{ObjectMeta: old.ObjectMeta, Spec: &old.Spec, Status: &old.Status}
+	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
+	if err := c.commit(ctx, oldResource, newResource); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 && requeue {
+		c.queue.AddAfter(key, retryPeriod)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
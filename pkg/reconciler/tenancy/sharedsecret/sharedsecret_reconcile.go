@@ -0,0 +1,244 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedsecret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// reconcile materializes the shared Secret in the consumer workspaces, and deletes it from former
+// consumers. It returns true if the SharedSecret should be reconciled again later.
+func (c *controller) reconcile(ctx context.Context, sharedSecret *tenancyv1alpha1.SharedSecret) (bool, error) {
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(sharedSecret)
+	owner := clusters.ToClusterAwareKey(clusterName, sharedSecret.Name)
+	ref := sharedSecret.Spec.SecretRef
+
+	finalizers := sets.NewString(sharedSecret.Finalizers...)
+	if !sharedSecret.DeletionTimestamp.IsZero() {
+		if !finalizers.Has(tenancyv1alpha1.SharedSecretFinalizer) {
+			return false, nil
+		}
+		for _, target := range sharedSecret.Status.Materialized {
+			if err := c.deleteMaterialized(ctx, owner, target, ref.Name); err != nil {
+				return false, err
+			}
+		}
+		logger.V(2).Info("removing finalizer")
+		sharedSecret.Finalizers = finalizers.Delete(tenancyv1alpha1.SharedSecretFinalizer).List()
+		return false, nil
+	}
+	if !finalizers.Has(tenancyv1alpha1.SharedSecretFinalizer) {
+		logger.V(2).Info("adding finalizer")
+		sharedSecret.Finalizers = finalizers.Insert(tenancyv1alpha1.SharedSecretFinalizer).List()
+		return false, nil
+	}
+
+	source, err := c.getSecret(clusterName, ref.Namespace, ref.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	var desired []tenancyv1alpha1.SharedSecretTarget
+	var invalid []string
+	if source != nil {
+		desired, invalid = c.resolveConsumers(clusterName, sharedSecret)
+	}
+
+	// delete the Secret from former consumers, or from all if the shared Secret is gone
+	var materialized []tenancyv1alpha1.SharedSecretTarget
+	var errs []error
+	for _, target := range sharedSecret.Status.Materialized {
+		if containsTarget(desired, target) {
+			continue
+		}
+		if err := c.deleteMaterialized(ctx, owner, target, ref.Name); err != nil {
+			errs = append(errs, err)
+			materialized = append(materialized, target)
+		}
+	}
+
+	if source == nil {
+		sharedSecret.Status.Materialized = materialized
+		conditions.MarkFalse(sharedSecret, tenancyv1alpha1.SharedSecretMaterialized, tenancyv1alpha1.SharedSecretNotFoundReason, conditionsv1alpha1.ConditionSeverityError,
+			"Secret %s/%s not found", ref.Namespace, ref.Name)
+		return false, utilerrors.NewAggregate(errs)
+	}
+
+	var conflicts, failed []string
+	for _, target := range desired {
+		conflict, err := c.materialize(ctx, owner, source, target)
+		switch {
+		case conflict:
+			conflicts = append(conflicts, fmt.Sprintf("%s|%s/%s", target.Workspace, target.Namespace, source.Name))
+		case err != nil:
+			logger.Error(err, "failed to materialize Secret", "workspace", target.Workspace, "namespace", target.Namespace)
+			failed = append(failed, fmt.Sprintf("%s|%s/%s: %v", target.Workspace, target.Namespace, source.Name, err))
+			// it might have been created before
+			if containsTarget(sharedSecret.Status.Materialized, target) {
+				materialized = append(materialized, target)
+			}
+		default:
+			materialized = append(materialized, target)
+		}
+	}
+	sharedSecret.Status.Materialized = materialized
+
+	switch {
+	case len(failed) > 0:
+		conditions.MarkFalse(sharedSecret, tenancyv1alpha1.SharedSecretMaterialized, tenancyv1alpha1.SharedSecretMaterializationFailedReason, conditionsv1alpha1.ConditionSeverityError,
+			"Failed to materialize Secret: %s", strings.Join(failed, "; "))
+		errs = append(errs, fmt.Errorf("failed to materialize Secret in %d consumer workspaces", len(failed)))
+	case len(conflicts) > 0:
+		conditions.MarkFalse(sharedSecret, tenancyv1alpha1.SharedSecretMaterialized, tenancyv1alpha1.SharedSecretConflictReason, conditionsv1alpha1.ConditionSeverityError,
+			"Secrets not owned by this SharedSecret exist: %s", strings.Join(conflicts, ", "))
+	case len(invalid) > 0:
+		conditions.MarkFalse(sharedSecret, tenancyv1alpha1.SharedSecretMaterialized, tenancyv1alpha1.SharedSecretConsumerInvalidReason, conditionsv1alpha1.ConditionSeverityError,
+			"%s", strings.Join(invalid, "; "))
+	default:
+		conditions.MarkTrue(sharedSecret, tenancyv1alpha1.SharedSecretMaterialized)
+	}
+
+	return len(conflicts) > 0 || len(invalid) > 0, utilerrors.NewAggregate(errs)
+}
+
+// resolveConsumers returns the targets of the consumers of the SharedSecret, and messages for the
+// consumers which cannot be resolved. Consumer namespaces must exist on this shard.
+func (c *controller) resolveConsumers(clusterName logicalcluster.Name, sharedSecret *tenancyv1alpha1.SharedSecret) ([]tenancyv1alpha1.SharedSecretTarget, []string) {
+	var targets []tenancyv1alpha1.SharedSecretTarget
+	var invalid []string
+	for i, consumer := range sharedSecret.Spec.Consumers {
+		var workspace logicalcluster.Name
+		switch {
+		case consumer.Workspace != "":
+			workspace = logicalcluster.New(consumer.Workspace)
+		case consumer.APIExport != nil:
+			export, err := c.getAPIExport(logicalcluster.New(consumer.APIExport.Path), consumer.APIExport.Name)
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("consumers[%d]: APIExport %s|%s not found", i, consumer.APIExport.Path, consumer.APIExport.Name))
+				continue
+			}
+			workspace = logicalcluster.From(export)
+		default:
+			invalid = append(invalid, fmt.Sprintf("consumers[%d]: neither workspace nor apiExport is set", i))
+			continue
+		}
+
+		namespace := consumer.Namespace
+		if namespace == "" {
+			namespace = sharedSecret.Spec.SecretRef.Namespace
+		}
+		if workspace == clusterName && namespace == sharedSecret.Spec.SecretRef.Namespace {
+			invalid = append(invalid, fmt.Sprintf("consumers[%d]: cannot share the Secret with its own namespace", i))
+			continue
+		}
+		if _, err := c.getNamespace(workspace, namespace); err != nil {
+			invalid = append(invalid, fmt.Sprintf("consumers[%d]: namespace %s not found in workspace %s", i, namespace, workspace))
+			continue
+		}
+
+		target := tenancyv1alpha1.SharedSecretTarget{Workspace: workspace.String(), Namespace: namespace}
+		if !containsTarget(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	return targets, invalid
+}
+
+// materialize creates or updates the copy of the source Secret in the target. It returns true if
+// a Secret not owned by the SharedSecret is in the way.
+func (c *controller) materialize(ctx context.Context, owner string, source *corev1.Secret, target tenancyv1alpha1.SharedSecretTarget) (bool, error) {
+	workspace := logicalcluster.New(target.Workspace)
+	existing, err := c.getSecret(workspace, target.Namespace, source.Name)
+	if errors.IsNotFound(err) {
+		return false, c.createSecret(ctx, workspace, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: target.Namespace,
+				Name:      source.Name,
+				Annotations: map[string]string{
+					tenancyv1alpha1.SharedSecretAnnotationKey: owner,
+				},
+			},
+			Type: source.Type,
+			Data: source.Data,
+		})
+	} else if err != nil {
+		return false, err
+	}
+
+	if existing.Annotations[tenancyv1alpha1.SharedSecretAnnotationKey] != owner {
+		return true, nil
+	}
+	if existing.Type == source.Type && equality.Semantic.DeepEqual(existing.Data, source.Data) {
+		return false, nil
+	}
+	if existing.Type != source.Type {
+		// the type of a Secret is immutable
+		if err := c.deleteSecret(ctx, workspace, target.Namespace, source.Name); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		return false, fmt.Errorf("recreating Secret with changed type")
+	}
+
+	updated := existing.DeepCopy()
+	updated.Data = source.Data
+	return false, c.updateSecret(ctx, workspace, updated)
+}
+
+// deleteMaterialized deletes the copy of the shared Secret in the target, if it is owned by the SharedSecret.
+func (c *controller) deleteMaterialized(ctx context.Context, owner string, target tenancyv1alpha1.SharedSecretTarget, name string) error {
+	workspace := logicalcluster.New(target.Workspace)
+	existing, err := c.getSecret(workspace, target.Namespace, name)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if existing.Annotations[tenancyv1alpha1.SharedSecretAnnotationKey] != owner {
+		return nil
+	}
+	if err := c.deleteSecret(ctx, workspace, target.Namespace, name); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func containsTarget(targets []tenancyv1alpha1.SharedSecretTarget, target tenancyv1alpha1.SharedSecretTarget) bool {
+	for _, t := range targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
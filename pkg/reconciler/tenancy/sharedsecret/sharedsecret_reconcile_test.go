@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedsecret
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+const owner = "root:org:producer|creds"
+
+func TestReconcile(t *testing.T) {
+	now := metav1.Now()
+	secret := func(clusterName, namespace, annotation, data string) *corev1.Secret {
+		s := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace,
+				Name:        "db",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: clusterName},
+			},
+			Data: map[string][]byte{"password": []byte(data)},
+		}
+		if annotation != "" {
+			s.Annotations[tenancyv1alpha1.SharedSecretAnnotationKey] = annotation
+		}
+		return s
+	}
+	source := secret("root:org:producer", "default", "", "secret")
+	target := func(workspace, namespace string) tenancyv1alpha1.SharedSecretTarget {
+		return tenancyv1alpha1.SharedSecretTarget{Workspace: workspace, Namespace: namespace}
+	}
+
+	tests := map[string]struct {
+		noFinalizer  bool
+		deleting     bool
+		consumers    []tenancyv1alpha1.SharedSecretConsumer
+		materialized []tenancyv1alpha1.SharedSecretTarget
+		secrets      []*corev1.Secret
+
+		wantSecrets      []string
+		wantMaterialized []tenancyv1alpha1.SharedSecretTarget
+		wantReason       string
+		wantRequeue      bool
+		wantFinalizer    bool
+	}{
+		"adds finalizer": {
+			noFinalizer:   true,
+			consumers:     []tenancyv1alpha1.SharedSecretConsumer{{Workspace: "root:org:consumer"}},
+			secrets:       []*corev1.Secret{source},
+			wantSecrets:   []string{"root:org:producer|default/db=secret"},
+			wantFinalizer: true,
+		},
+		"materializes in workspaces and APIExport workspaces": {
+			consumers: []tenancyv1alpha1.SharedSecretConsumer{
+				{Workspace: "root:org:consumer", Namespace: "other"},
+				{APIExport: &tenancyv1alpha1.SharedSecretAPIExportReference{Path: "root:org:provider", Name: "databases"}},
+			},
+			secrets: []*corev1.Secret{source},
+			wantSecrets: []string{
+				"root:org:consumer|other/db=secret@" + owner,
+				"root:org:producer|default/db=secret",
+				"root:org:provider|default/db=secret@" + owner,
+			},
+			wantMaterialized: []tenancyv1alpha1.SharedSecretTarget{target("root:org:consumer", "other"), target("root:org:provider", "default")},
+			wantFinalizer:    true,
+		},
+		"updates changed data": {
+			consumers:        []tenancyv1alpha1.SharedSecretConsumer{{Workspace: "root:org:consumer"}},
+			materialized:     []tenancyv1alpha1.SharedSecretTarget{target("root:org:consumer", "default")},
+			secrets:          []*corev1.Secret{source, secret("root:org:consumer", "default", owner, "old")},
+			wantSecrets:      []string{"root:org:consumer|default/db=secret@" + owner, "root:org:producer|default/db=secret"},
+			wantMaterialized: []tenancyv1alpha1.SharedSecretTarget{target("root:org:consumer", "default")},
+			wantFinalizer:    true,
+		},
+		"does not overwrite foreign secrets": {
+			consumers:     []tenancyv1alpha1.SharedSecretConsumer{{Workspace: "root:org:consumer"}},
+			secrets:       []*corev1.Secret{source, secret("root:org:consumer", "default", "", "mine")},
+			wantSecrets:   []string{"root:org:consumer|default/db=mine", "root:org:producer|default/db=secret"},
+			wantReason:    tenancyv1alpha1.SharedSecretConflictReason,
+			wantRequeue:   true,
+			wantFinalizer: true,
+		},
+		"missing namespace and APIExport": {
+			consumers: []tenancyv1alpha1.SharedSecretConsumer{
+				{Workspace: "root:org:consumer", Namespace: "missing"},
+				{APIExport: &tenancyv1alpha1.SharedSecretAPIExportReference{Path: "root:org:provider", Name: "missing"}},
+				{Workspace: "root:org:producer"},
+			},
+			secrets:       []*corev1.Secret{source},
+			wantSecrets:   []string{"root:org:producer|default/db=secret"},
+			wantReason:    tenancyv1alpha1.SharedSecretConsumerInvalidReason,
+			wantRequeue:   true,
+			wantFinalizer: true,
+		},
+		"deletes from removed consumers": {
+			consumers:        []tenancyv1alpha1.SharedSecretConsumer{{Workspace: "root:org:consumer"}},
+			materialized:     []tenancyv1alpha1.SharedSecretTarget{target("root:org:consumer", "default"), target("root:org:provider", "default")},
+			secrets:          []*corev1.Secret{source, secret("root:org:consumer", "default", owner, "secret"), secret("root:org:provider", "default", owner, "secret")},
+			wantSecrets:      []string{"root:org:consumer|default/db=secret@" + owner, "root:org:producer|default/db=secret"},
+			wantMaterialized: []tenancyv1alpha1.SharedSecretTarget{target("root:org:consumer", "default")},
+			wantFinalizer:    true,
+		},
+		"deletes everywhere when the secret is gone": {
+			consumers:     []tenancyv1alpha1.SharedSecretConsumer{{Workspace: "root:org:consumer"}},
+			materialized:  []tenancyv1alpha1.SharedSecretTarget{target("root:org:consumer", "default")},
+			secrets:       []*corev1.Secret{secret("root:org:consumer", "default", owner, "secret")},
+			wantReason:    tenancyv1alpha1.SharedSecretNotFoundReason,
+			wantFinalizer: true,
+		},
+		"deletes everywhere on deletion": {
+			deleting:     true,
+			consumers:    []tenancyv1alpha1.SharedSecretConsumer{{Workspace: "root:org:consumer"}},
+			materialized: []tenancyv1alpha1.SharedSecretTarget{target("root:org:consumer", "default")},
+			secrets:      []*corev1.Secret{source, secret("root:org:consumer", "default", owner, "secret")},
+			wantSecrets:  []string{"root:org:producer|default/db=secret"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			secrets := map[string]*corev1.Secret{}
+			key := func(clusterName logicalcluster.Name, namespace, name string) string {
+				return clusterName.String() + "|" + namespace + "/" + name
+			}
+			for _, s := range tc.secrets {
+				secrets[key(logicalcluster.From(s), s.Namespace, s.Name)] = s
+			}
+
+			c := &controller{
+				getSecret: func(clusterName logicalcluster.Name, namespace, name string) (*corev1.Secret, error) {
+					if s, found := secrets[key(clusterName, namespace, name)]; found {
+						return s, nil
+					}
+					return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+				},
+				createSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+					secrets[key(clusterName, secret.Namespace, secret.Name)] = secret
+					return nil
+				},
+				updateSecret: func(ctx context.Context, clusterName logicalcluster.Name, secret *corev1.Secret) error {
+					secrets[key(clusterName, secret.Namespace, secret.Name)] = secret
+					return nil
+				},
+				deleteSecret: func(ctx context.Context, clusterName logicalcluster.Name, namespace, name string) error {
+					delete(secrets, key(clusterName, namespace, name))
+					return nil
+				},
+				getNamespace: func(clusterName logicalcluster.Name, name string) (metav1.Object, error) {
+					if name == "missing" {
+						return nil, apierrors.NewNotFound(corev1.Resource("namespaces"), name)
+					}
+					return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+				},
+				getAPIExport: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+					if name == "missing" {
+						return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), name)
+					}
+					return &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{
+						Name:        name,
+						Annotations: map[string]string{logicalcluster.AnnotationKey: clusterName.String()},
+					}}, nil
+				},
+			}
+
+			sharedSecret := &tenancyv1alpha1.SharedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "creds",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:producer"},
+				},
+				Spec: tenancyv1alpha1.SharedSecretSpec{
+					SecretRef: corev1.SecretReference{Namespace: "default", Name: "db"},
+					Consumers: tc.consumers,
+				},
+				Status: tenancyv1alpha1.SharedSecretStatus{Materialized: tc.materialized},
+			}
+			if !tc.noFinalizer {
+				sharedSecret.Finalizers = []string{tenancyv1alpha1.SharedSecretFinalizer}
+			}
+			if tc.deleting {
+				sharedSecret.DeletionTimestamp = &now
+			}
+
+			requeue, err := c.reconcile(context.Background(), sharedSecret)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRequeue, requeue)
+			require.Equal(t, tc.wantFinalizer, len(sharedSecret.Finalizers) > 0)
+
+			var got []string
+			for k, s := range secrets {
+				v := k + "=" + string(s.Data["password"])
+				if a := s.Annotations[tenancyv1alpha1.SharedSecretAnnotationKey]; a != "" {
+					v += "@" + a
+				}
+				got = append(got, v)
+			}
+			sort.Strings(got)
+			require.Equal(t, tc.wantSecrets, got)
+
+			if tc.noFinalizer || tc.deleting {
+				return
+			}
+			require.Equal(t, tc.wantMaterialized, sharedSecret.Status.Materialized)
+			if tc.wantReason == "" {
+				require.True(t, conditions.IsTrue(sharedSecret, tenancyv1alpha1.SharedSecretMaterialized))
+			} else {
+				require.Equal(t, tc.wantReason, conditions.GetReason(sharedSecret, tenancyv1alpha1.SharedSecretMaterialized))
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceactivity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	controllerName = "kcp-workspace-activity"
+
+	// flushPeriod is the period in which the recorded activity is written to the ClusterWorkspaces.
+	flushPeriod = 30 * time.Second
+
+	// granularity is the minimal difference to the lastActivityTime of a ClusterWorkspace for
+	// its status to be updated, i.e. a workspace is updated at most once per granularity.
+	granularity = time.Minute
+)
+
+// NewController returns a new controller persisting the activity recorded in the tracker as
+// lastActivityTime of the ClusterWorkspaces.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	tracker *Tracker,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+) (*controller, error) {
+	workspaceLister := workspaceInformer.Lister()
+	return &controller{
+		tracker: tracker,
+		getWorkspace: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+			return workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		patchLastActivityTime: func(ctx context.Context, clusterName logicalcluster.Name, name string, at time.Time) error {
+			patch, err := json.Marshal(map[string]interface{}{
+				"status": map[string]interface{}{
+					"lastActivityTime": metav1.NewTime(at),
+				},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+			return err
+		},
+		syncChecks: []cache.InformerSynced{
+			workspaceInformer.Informer().HasSynced,
+		},
+	}, nil
+}
+
+// controller periodically drains the activity tracker and writes the last activity of each workspace
+// to the status of its ClusterWorkspace. ClusterWorkspaces not known to this shard are skipped.
+type controller struct {
+	tracker *Tracker
+
+	getWorkspace          func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error)
+	patchLastActivityTime func(ctx context.Context, clusterName logicalcluster.Name, name string, at time.Time) error
+
+	syncChecks []cache.InformerSynced
+}
+
+func (c *controller) Start(ctx context.Context) {
+	defer runtime.HandleCrash()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), c.syncChecks...) {
+		logger.Error(nil, "Failed to wait for caches to sync")
+		return
+	}
+
+	wait.UntilWithContext(ctx, c.flush, flushPeriod)
+}
+
+func (c *controller) flush(ctx context.Context) {
+	for clusterName, at := range c.tracker.Drain() {
+		if err := c.persist(ctx, clusterName, at); err != nil {
+			runtime.HandleError(fmt.Errorf("%q controller failed to update last activity of workspace %s: %w", controllerName, clusterName, err))
+			// retry with the next flush
+			c.tracker.Record(clusterName, at)
+		}
+	}
+}
+
+// persist updates the lastActivityTime of the ClusterWorkspace of the given workspace, unless it
+// is within the granularity already.
+func (c *controller) persist(ctx context.Context, clusterName logicalcluster.Name, at time.Time) error {
+	parent, hasParent := clusterName.Parent()
+	if !hasParent {
+		return nil // the root workspace has no ClusterWorkspace
+	}
+
+	workspace, err := c.getWorkspace(parent, clusterName.Base())
+	if errors.IsNotFound(err) {
+		return nil // deleted, or on another shard where the reaper ignores the workspace
+	} else if err != nil {
+		return err
+	}
+	if last := workspace.Status.LastActivityTime; last != nil && at.Sub(last.Time) < granularity {
+		return nil
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), workspace)
+	logger.V(4).Info("updating last activity time", "lastActivityTime", at)
+	return c.patchLastActivityTime(ctx, parent, workspace.Name, at)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceactivity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.Record(logicalcluster.New("root:org:ws"), now)
+	tracker.Record(logicalcluster.New("root:org:ws"), now.Add(-time.Minute))
+	tracker.Record(logicalcluster.New("root:org"), now.Add(time.Second))
+
+	require.Equal(t, map[logicalcluster.Name]time.Time{
+		logicalcluster.New("root:org:ws"): now,
+		logicalcluster.New("root:org"):    now.Add(time.Second),
+	}, tracker.Drain())
+	require.Empty(t, tracker.Drain())
+}
+
+func TestFlush(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	lastActivity := metav1.NewTime(now.Add(-30 * time.Second))
+
+	tests := map[string]struct {
+		clusterName  logicalcluster.Name
+		workspace    *tenancyv1alpha1.ClusterWorkspace
+		patchErr     error
+		wantPatched  bool
+		wantRecorded bool
+	}{
+		"root is skipped": {
+			clusterName: logicalcluster.New("root"),
+		},
+		"unknown workspace is skipped": {
+			clusterName: logicalcluster.New("root:org:ws"),
+		},
+		"first activity is persisted": {
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "ws"}},
+			wantPatched: true,
+		},
+		"activity within granularity is skipped": {
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "ws"},
+				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{LastActivityTime: &lastActivity},
+			},
+		},
+		"failed patch is retried": {
+			clusterName:  logicalcluster.New("root:org:ws"),
+			workspace:    &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "ws"}},
+			patchErr:     errors.New("boom"),
+			wantPatched:  true,
+			wantRecorded: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			patched := false
+			c := &controller{
+				tracker: NewTracker(),
+				getWorkspace: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+					if tc.workspace == nil {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+					}
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					require.Equal(t, "ws", name)
+					return tc.workspace, nil
+				},
+				patchLastActivityTime: func(ctx context.Context, clusterName logicalcluster.Name, name string, at time.Time) error {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					require.Equal(t, now, at)
+					patched = true
+					return tc.patchErr
+				},
+			}
+
+			c.tracker.Record(tc.clusterName, now)
+			c.flush(context.Background())
+			require.Equal(t, tc.wantPatched, patched)
+			require.Equal(t, tc.wantRecorded, len(c.tracker.Drain()) > 0)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceactivity
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+)
+
+// Tracker aggregates the time of the last activity per workspace in memory. It is cheap to call
+// on every request, and drained periodically to persist the activity.
+type Tracker struct {
+	lock     sync.Mutex
+	activity map[logicalcluster.Name]time.Time
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{activity: map[logicalcluster.Name]time.Time{}}
+}
+
+// Record records activity in the given workspace at the given time. Earlier times than those
+// recorded already are ignored.
+func (t *Tracker) Record(clusterName logicalcluster.Name, at time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if last, found := t.activity[clusterName]; !found || at.After(last) {
+		t.activity[clusterName] = at
+	}
+}
+
+// Drain returns the last activity of all workspaces recorded since the previous call.
+func (t *Tracker) Drain() map[logicalcluster.Name]time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	activity := t.activity
+	t.activity = map[logicalcluster.Name]time.Time{}
+	return activity
+}
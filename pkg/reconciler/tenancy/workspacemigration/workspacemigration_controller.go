@@ -0,0 +1,316 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacemigration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemove"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-workspace-migration"
+
+	// copyPassInterval is the time between two copy passes of a migration, as long as a pass finds changes.
+	copyPassInterval = 10 * time.Second
+)
+
+// NewController returns a new controller migrating ClusterWorkspaces between shards as requested by
+// WorkspaceMigrations. The shard configs hold a multi-cluster config for every shard the content can
+// be migrated from or to, including this one, keyed by shard name.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	shardConfigs map[string]*rest.Config,
+	migrationInformer tenancyinformers.WorkspaceMigrationInformer,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	shardInformer tenancyinformers.ClusterWorkspaceShardInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	shards := map[string]*shardClient{}
+	for name, config := range shardConfigs {
+		client, err := newShardClient(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for shard %q: %w", name, err)
+		}
+		shards[name] = client
+	}
+
+	workspaceLister := workspaceInformer.Lister()
+	shardLister := shardInformer.Lister()
+	c := &controller{
+		queue:           queue,
+		migrationLister: migrationInformer.Lister(),
+		getWorkspace: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+			return workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+			return shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
+		},
+		hasShardClient: func(name string) bool {
+			_, found := shards[name]
+			return found
+		},
+		hasChildWorkspaces: func(ctx context.Context, shard string, clusterName logicalcluster.Name) (bool, error) {
+			return shards[shard].hasChildWorkspaces(ctx, clusterName)
+		},
+		copyContent: func(ctx context.Context, from, to string, clusterName logicalcluster.Name) (int, error) {
+			return copyContent(ctx, shards[from], shards[to], clusterName)
+		},
+		removeContent: func(ctx context.Context, shard string, clusterName logicalcluster.Name) error {
+			return shards[shard].removeContent(ctx, clusterName)
+		},
+		switchShard: func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, location tenancyv1alpha1.ClusterWorkspaceLocation, baseURL string) error {
+			return switchShard(ctx, kcpClusterClient, workspace, location, baseURL)
+		},
+		setReadOnly: func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, readOnly bool) error {
+			return clusterworkspacemove.SetReadOnly(ctx, kcpClusterClient, logicalcluster.From(workspace), workspace.Name, readOnly)
+		},
+		now:    time.Now,
+		commit: committer.NewCommitter[*WorkspaceMigration, *WorkspaceMigrationSpec, *WorkspaceMigrationStatus](kcpClusterClient.TenancyV1alpha1().WorkspaceMigrations()),
+		syncChecks: []cache.InformerSynced{
+			migrationInformer.Informer().HasSynced,
+			workspaceInformer.Informer().HasSynced,
+			shardInformer.Informer().HasSynced,
+		},
+	}
+
+	migrationInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	})
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspace(obj) },
+	})
+
+	return c, nil
+}
+
+type WorkspaceMigration = tenancyv1alpha1.WorkspaceMigration
+type WorkspaceMigrationSpec = tenancyv1alpha1.WorkspaceMigrationSpec
+type WorkspaceMigrationStatus = tenancyv1alpha1.WorkspaceMigrationStatus
+type Resource = committer.Resource[*WorkspaceMigrationSpec, *WorkspaceMigrationStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// controller migrates the content of ClusterWorkspaces between shards in phases: it copies the content
+// from the source to the target shard in passes until a pass finds no changes, makes the workspace
+// read-only for a final pass, switches the location of the workspace to the target shard and lifts the
+// write block, and finally removes the content from the source shard.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	migrationLister tenancylisters.WorkspaceMigrationLister
+
+	getWorkspace       func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error)
+	getShard           func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	hasShardClient     func(name string) bool
+	hasChildWorkspaces func(ctx context.Context, shard string, clusterName logicalcluster.Name) (bool, error)
+	copyContent        func(ctx context.Context, from, to string, clusterName logicalcluster.Name) (int, error)
+	removeContent      func(ctx context.Context, shard string, clusterName logicalcluster.Name) error
+	switchShard        func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, location tenancyv1alpha1.ClusterWorkspaceLocation, baseURL string) error
+	setReadOnly        func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, readOnly bool) error
+	now                func() time.Time
+
+	commit CommitFunc
+
+	syncChecks []cache.InformerSynced
+}
+
+// enqueue enqueues a WorkspaceMigration.
+func (c *controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing WorkspaceMigration")
+	c.queue.Add(key)
+}
+
+// enqueueWorkspace enqueues the unfinished WorkspaceMigrations of a ClusterWorkspace, such that
+// migrations waiting for the workspace, or affected by changes to its location, are reconciled.
+func (c *controller) enqueueWorkspace(obj interface{}) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+
+	migrations, err := c.migrationLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName := logicalcluster.From(workspace)
+	for _, migration := range migrations {
+		if logicalcluster.From(migration) != clusterName || migration.Spec.Workspace != workspace.Name {
+			continue
+		}
+		if migration.Status.Phase == tenancyv1alpha1.WorkspaceMigrationPhaseCompleted || migration.Status.Phase == tenancyv1alpha1.WorkspaceMigrationPhaseFailed {
+			continue
+		}
+		c.enqueue(migration)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), c.syncChecks...) {
+		logger.Error(nil, "Failed to wait for caches to sync")
+		return
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.migrationLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	requeueAfter, err := c.reconcile(ctx, obj)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Regardless of whether reconcile returned an error or not, always try to update status if needed.
+	oldResource := &Resource{ObjectMeta: old.ObjectMeta, Spec: &old.Spec, Status: &old.Status}
+	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
+	if err := c.commit(ctx, oldResource, newResource); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 && requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// switchShard points the workspace to the target location with a single status patch, preconditioned
+// on the observed resource version. The front-proxy index follows the location of the workspace.
+func switchShard(ctx context.Context, kcpClusterClient kcpclient.Interface, workspace *tenancyv1alpha1.ClusterWorkspace, location tenancyv1alpha1.ClusterWorkspaceLocation, baseURL string) error {
+	clusterName := logicalcluster.From(workspace)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: workspace.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, workspace.Name, err)
+	}
+
+	status := *workspace.Status.DeepCopy()
+	status.Location = location
+	status.BaseURL = baseURL
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             workspace.UID,
+			ResourceVersion: workspace.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, workspace.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, workspace.Name, err)
+	}
+	_, err = kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), workspace.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacemigration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemove"
+)
+
+var clusterWorkspacesResource = tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces")
+
+// shardClient accesses the logical clusters of one shard.
+type shardClient struct {
+	config *rest.Config
+	client dynamic.Interface
+}
+
+func newShardClient(config *rest.Config) (*shardClient, error) {
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &shardClient{config: config, client: client}, nil
+}
+
+// copyableResources returns the resources of the logical cluster on the shard whose objects are
+// migrated, in the order they must be copied.
+func (s *shardClient) copyableResources(clusterName logicalcluster.Name) ([]schema.GroupVersionResource, error) {
+	config := rest.CopyConfig(s.config)
+	config.Host += clusterName.Path()
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := discoveryClient.ServerPreferredResources()
+	if err != nil {
+		// partial discovery is fatal because content would be lost
+		return nil, fmt.Errorf("failed to discover resources of workspace %s: %w", clusterName, err)
+	}
+	return clusterworkspacemove.CopyOrder(clusterworkspacemove.CopyableResources(resources))
+}
+
+// list returns the objects of the resource in the logical cluster on the shard which are migrated,
+// prepared for comparison and copying.
+func (s *shardClient) list(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name) (map[string]*unstructured.Unstructured, error) {
+	list, err := s.client.Resource(gvr).List(logicalcluster.WithCluster(ctx, clusterName), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s in workspace %s: %w", gvr.GroupResource(), clusterName, err)
+	}
+
+	objs := make(map[string]*unstructured.Unstructured, len(list.Items))
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if skipObject(gvr.GroupResource(), obj) {
+			continue
+		}
+		resourceVersion := obj.GetResourceVersion()
+		if err := clusterworkspacemove.PrepareForCopy(gvr.GroupResource(), obj, clusterName, clusterName); err != nil {
+			return nil, err
+		}
+		unstructured.RemoveNestedField(obj.Object, "status")
+		obj.SetResourceVersion(resourceVersion)
+		objs[obj.GetNamespace()+"/"+obj.GetName()] = obj
+	}
+	return objs, nil
+}
+
+// hasChildWorkspaces returns whether the logical cluster on the shard has ClusterWorkspaces.
+func (s *shardClient) hasChildWorkspaces(ctx context.Context, clusterName logicalcluster.Name) (bool, error) {
+	list, err := s.client.Resource(clusterWorkspacesResource).List(logicalcluster.WithCluster(ctx, clusterName), metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	return len(list.Items) > 0, nil
+}
+
+// removeContent deletes the migrated objects of the logical cluster on the shard. Namespaced objects
+// are removed together with their namespaces.
+func (s *shardClient) removeContent(ctx context.Context, clusterName logicalcluster.Name) error {
+	gvrs, err := s.copyableResources(clusterName)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := len(gvrs) - 1; i >= 0; i-- {
+		gvr := gvrs[i]
+		objs, err := s.list(ctx, gvr, clusterName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, obj := range objs {
+			if obj.GetNamespace() != "" {
+				continue // deleted with the namespace
+			}
+			err := s.client.Resource(gvr).Delete(logicalcluster.WithCluster(ctx, clusterName), obj.GetName(), metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", gvr.GroupResource(), obj.GetName(), err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// copyContent makes the migrated objects of the logical cluster on the target shard equal to those on
+// the source shard: missing objects are created, changed objects updated, and superfluous objects
+// deleted. It returns the number of changes, such that a pass without changes tells that the target
+// has caught up with the source.
+func copyContent(ctx context.Context, from, to *shardClient, clusterName logicalcluster.Name) (int, error) {
+	gvrs, err := from.copyableResources(clusterName)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := 0
+	// copy in priority order, failing early such that dependent objects are not tried before their
+	// resources are served.
+	for _, gvr := range gvrs {
+		sourceObjs, err := from.list(ctx, gvr, clusterName)
+		if err != nil {
+			return changes, err
+		}
+		targetObjs, err := to.list(ctx, gvr, clusterName)
+		if err != nil {
+			return changes, err
+		}
+
+		var errs []error
+		for key, obj := range sourceObjs {
+			client := to.client.Resource(gvr).Namespace(obj.GetNamespace())
+			existing, found := targetObjs[key]
+			switch {
+			case !found:
+				obj.SetResourceVersion("")
+				if _, err := client.Create(logicalcluster.WithCluster(ctx, clusterName), obj, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+					errs = append(errs, fmt.Errorf("failed to copy %s %s: %w", gvr.GroupResource(), key, err))
+					continue
+				}
+				changes++
+			case !equalContent(obj, existing):
+				obj.SetResourceVersion(existing.GetResourceVersion())
+				if _, err := client.Update(logicalcluster.WithCluster(ctx, clusterName), obj, metav1.UpdateOptions{}); err != nil {
+					errs = append(errs, fmt.Errorf("failed to update %s %s: %w", gvr.GroupResource(), key, err))
+					continue
+				}
+				changes++
+			}
+		}
+		for key, obj := range targetObjs {
+			if _, found := sourceObjs[key]; found {
+				continue
+			}
+			err := to.client.Resource(gvr).Namespace(obj.GetNamespace()).Delete(logicalcluster.WithCluster(ctx, clusterName), obj.GetName(), metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", gvr.GroupResource(), key, err))
+				continue
+			}
+			changes++
+		}
+		if err := utilerrors.NewAggregate(errs); err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// skipObject returns whether the object is not migrated. On top of the objects skipped by moves,
+// objects maintained by every shard itself are skipped, as they differ between shards.
+func skipObject(gr schema.GroupResource, obj *unstructured.Unstructured) bool {
+	if clusterworkspacemove.SkipObject(gr, obj) {
+		return true
+	}
+	return gr == corev1.Resource("configmaps") && obj.GetName() == "kube-root-ca.crt"
+}
+
+// equalContent returns whether two objects prepared by list have the same content, ignoring their
+// resource versions.
+func equalContent(a, b *unstructured.Unstructured) bool {
+	a, b = a.DeepCopy(), b.DeepCopy()
+	a.SetResourceVersion("")
+	b.SetResourceVersion("")
+	return equality.Semantic.DeepEqual(a.Object, b.Object)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacemigration
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// reconcile advances the migration by one phase, and returns when the migration should be reconciled
+// again. Every phase is idempotent, such that an interrupted migration continues where it stopped.
+func (c *controller) reconcile(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration) (time.Duration, error) {
+	logger := klog.FromContext(ctx)
+
+	finished := migration.Status.Phase == tenancyv1alpha1.WorkspaceMigrationPhaseCompleted || migration.Status.Phase == tenancyv1alpha1.WorkspaceMigrationPhaseFailed
+	if finished && !conditions.IsTrue(migration, tenancyv1alpha1.WorkspaceMigrationWritesBlocked) {
+		return 0, nil
+	}
+
+	parent := logicalcluster.From(migration)
+	clusterName := parent.Join(migration.Spec.Workspace)
+	target := migration.Spec.TargetShard
+
+	workspace, err := c.getWorkspace(parent, migration.Spec.Workspace)
+	if errors.IsNotFound(err) {
+		conditions.Delete(migration, tenancyv1alpha1.WorkspaceMigrationWritesBlocked) // nothing left to unblock
+		if !finished {
+			fail(migration, tenancyv1alpha1.WorkspaceMigrationWorkspaceNotFound, "Workspace %s does not exist", clusterName)
+		}
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	switch migration.Status.Phase {
+	case tenancyv1alpha1.WorkspaceMigrationPhaseCompleted, tenancyv1alpha1.WorkspaceMigrationPhaseFailed:
+		// the migration failed after blocking writes
+		return 0, c.unblockWrites(ctx, migration, workspace)
+
+	case "":
+		if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, tenancyv1alpha1.WorkspaceMigrationWorkspaceNotReady, conditionsv1alpha1.ConditionSeverityInfo,
+				"Waiting for workspace %s to become ready", clusterName)
+			return 0, nil // wait for the workspace to be updated
+		}
+		if workspace.Status.Location.Current == target {
+			fail(migration, tenancyv1alpha1.WorkspaceMigrationWorkspaceMoved, "Workspace %s is already on shard %q", clusterName, target)
+			return 0, nil
+		}
+		shard, err := c.getShard(target)
+		if errors.IsNotFound(err) || (err == nil && !c.hasShardClient(target)) {
+			fail(migration, tenancyv1alpha1.WorkspaceMigrationShardNotFound, "Shard %q does not exist or is not reachable", target)
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if !shard.IsSchedulable() {
+			fail(migration, tenancyv1alpha1.WorkspaceMigrationShardNotSchedulable, "Shard %q is %s", target, shard.Spec.State)
+			return 0, nil
+		}
+		source := workspace.Status.Location.Current
+		if !c.hasShardClient(source) {
+			fail(migration, tenancyv1alpha1.WorkspaceMigrationShardNotFound, "Shard %q of workspace %s is not reachable", source, clusterName)
+			return 0, nil
+		}
+		if hasChildren, err := c.hasChildWorkspaces(ctx, source, clusterName); err != nil {
+			return 0, err
+		} else if hasChildren {
+			fail(migration, tenancyv1alpha1.WorkspaceMigrationWorkspaceHasChildren, "Workspace %s has child workspaces", clusterName)
+			return 0, nil
+		}
+
+		logger.Info("starting migration", "source", source, "target", target)
+		conditions.MarkTrue(migration, tenancyv1alpha1.WorkspaceMigrationValid)
+		migration.Status.SourceShard = source
+		migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCopying
+		return 0, nil // the status update triggers the first copy pass
+
+	case tenancyv1alpha1.WorkspaceMigrationPhaseCopying:
+		source := migration.Status.SourceShard
+		if workspace.Status.Location.Current != source {
+			fail(migration, tenancyv1alpha1.WorkspaceMigrationWorkspaceMoved, "Workspace %s was moved to shard %q during the migration", clusterName, workspace.Status.Location.Current)
+			return 0, nil
+		}
+
+		changes, err := c.copyContent(ctx, source, target, clusterName)
+		if err != nil {
+			conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationContentCopied, tenancyv1alpha1.WorkspaceMigrationCopyFailed, conditionsv1alpha1.ConditionSeverityError,
+				"Failed to copy content to shard %q: %v", target, err)
+			return 0, err
+		}
+		if changes > 0 {
+			logger.V(2).Info("copy pass found changes", "changes", changes)
+			conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationContentCopied, tenancyv1alpha1.WorkspaceMigrationCopyPending, conditionsv1alpha1.ConditionSeverityInfo,
+				"Last copy pass changed %d objects", changes)
+			return copyPassInterval, nil
+		}
+		if !workspace.Spec.ReadOnly {
+			// block writes, such that a final pass catches up with every write before the switch.
+			// The condition is set first, such that the block is lifted even if the status update
+			// fails after the workspace has been updated.
+			logger.Info("blocking writes to workspace for the final copy pass")
+			conditions.MarkTrue(migration, tenancyv1alpha1.WorkspaceMigrationWritesBlocked)
+			conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationContentCopied, tenancyv1alpha1.WorkspaceMigrationCopyPending, conditionsv1alpha1.ConditionSeverityInfo,
+				"Waiting for the final copy pass")
+			if err := c.setReadOnly(ctx, workspace, true); err != nil {
+				return 0, err
+			}
+			// give in-flight writes time to finish before the final pass
+			return copyPassInterval, nil
+		}
+		conditions.MarkTrue(migration, tenancyv1alpha1.WorkspaceMigrationContentCopied)
+
+		shard, err := c.getShard(target)
+		if err != nil {
+			return 0, err
+		}
+		baseURL, err := workspaceBaseURL(shard, clusterName)
+		if err != nil {
+			return 0, err
+		}
+		logger.Info("switching workspace to target shard", "source", source, "target", target)
+		location := tenancyv1alpha1.ClusterWorkspaceLocation{Current: target}
+		if err := c.switchShard(ctx, workspace, location, baseURL); err != nil {
+			return 0, err
+		}
+		now := metav1.NewTime(c.now())
+		migration.Status.SwitchTime = &now
+		migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseSwitched
+		return 0, nil
+
+	case tenancyv1alpha1.WorkspaceMigrationPhaseSwitched:
+		if err := c.unblockWrites(ctx, migration, workspace); err != nil {
+			return 0, err
+		}
+		source := migration.Status.SourceShard
+		logger.V(2).Info("removing content from source shard", "source", source)
+		if err := c.removeContent(ctx, source, clusterName); err != nil {
+			conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationSourceRemoved, tenancyv1alpha1.WorkspaceMigrationRemovalFailed, conditionsv1alpha1.ConditionSeverityError,
+				"Failed to remove content from shard %q: %v", source, err)
+			return 0, err
+		}
+		conditions.MarkTrue(migration, tenancyv1alpha1.WorkspaceMigrationSourceRemoved)
+		migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCompleted
+		logger.Info("migration completed", "source", source, "target", target)
+	}
+
+	return 0, nil
+}
+
+// unblockWrites lifts the write block of the workspace if the migration has set it.
+func (c *controller) unblockWrites(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if !conditions.IsTrue(migration, tenancyv1alpha1.WorkspaceMigrationWritesBlocked) {
+		return nil
+	}
+	if workspace.Spec.ReadOnly {
+		klog.FromContext(ctx).Info("unblocking writes to workspace")
+		if err := c.setReadOnly(ctx, workspace, false); err != nil {
+			return err
+		}
+	}
+	conditions.Delete(migration, tenancyv1alpha1.WorkspaceMigrationWritesBlocked)
+	return nil
+}
+
+// fail marks the migration as failed with the given reason in the Valid condition.
+func fail(migration *tenancyv1alpha1.WorkspaceMigration, reason, messageFormat string, messageArgs ...interface{}) {
+	conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, reason, conditionsv1alpha1.ConditionSeverityError, messageFormat, messageArgs...)
+	migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseFailed
+}
+
+// workspaceBaseURL returns the base URL of the logical cluster on the given shard.
+func workspaceBaseURL(shard *tenancyv1alpha1.ClusterWorkspaceShard, clusterName logicalcluster.Name) (string, error) {
+	u, err := url.Parse(shard.Spec.ExternalURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, clusterName.Path())
+	return u.String(), nil
+}
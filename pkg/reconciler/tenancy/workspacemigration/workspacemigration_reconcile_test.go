@@ -0,0 +1,364 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacemigration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, testCase := range []struct {
+		name           string
+		phase          tenancyv1alpha1.WorkspaceMigrationPhase
+		noWorkspace    bool
+		workspacePhase tenancyv1alpha1.ClusterWorkspacePhaseType
+		currentShard   string
+		noTargetShard  bool
+		targetState    tenancyv1alpha1.ClusterWorkspaceShardState
+		hasChildren    bool
+		changes        int
+		copyErr        error
+		removeErr      error
+		readOnly       bool
+		writesBlocked  bool
+
+		wantPhase        tenancyv1alpha1.WorkspaceMigrationPhase
+		wantCopied       bool
+		wantSwitched     bool
+		wantRemoved      bool
+		wantRequeue      bool
+		wantErr          bool
+		wantCondition    conditionsv1alpha1.ConditionType
+		wantStatus       corev1.ConditionStatus
+		wantReason       string
+		wantSourceShard  string
+		wantSwitchedTime bool
+		wantReadOnly     *bool
+	}{
+		{
+			name:          "missing workspaces fail the migration",
+			noWorkspace:   true,
+			wantPhase:     tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantCondition: tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:    corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMigrationWorkspaceNotFound,
+		},
+		{
+			name:           "waits for the workspace to be ready",
+			workspacePhase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			wantCondition:  tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:     corev1.ConditionFalse,
+			wantReason:     tenancyv1alpha1.WorkspaceMigrationWorkspaceNotReady,
+		},
+		{
+			name:          "workspaces on the target shard fail the migration",
+			currentShard:  "sapphire",
+			wantPhase:     tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantCondition: tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:    corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMigrationWorkspaceMoved,
+		},
+		{
+			name:          "unknown target shards fail the migration",
+			noTargetShard: true,
+			wantPhase:     tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantCondition: tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:    corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMigrationShardNotFound,
+		},
+		{
+			name:          "cordoned target shards fail the migration",
+			targetState:   tenancyv1alpha1.ClusterWorkspaceShardStateCordoned,
+			wantPhase:     tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantCondition: tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:    corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMigrationShardNotSchedulable,
+		},
+		{
+			name:          "workspaces with children fail the migration",
+			hasChildren:   true,
+			wantPhase:     tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantCondition: tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:    corev1.ConditionFalse,
+			wantReason:    tenancyv1alpha1.WorkspaceMigrationWorkspaceHasChildren,
+		},
+		{
+			name:            "valid migrations start copying",
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:      corev1.ConditionTrue,
+			wantSourceShard: "amber",
+		},
+		{
+			name:            "copy passes with changes are repeated",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			changes:         3,
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantCopied:      true,
+			wantRequeue:     true,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationContentCopied,
+			wantStatus:      corev1.ConditionFalse,
+			wantReason:      tenancyv1alpha1.WorkspaceMigrationCopyPending,
+			wantSourceShard: "amber",
+		},
+		{
+			name:            "copy errors are reported",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			copyErr:         errors.New("boom"),
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantCopied:      true,
+			wantErr:         true,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationContentCopied,
+			wantStatus:      corev1.ConditionFalse,
+			wantReason:      tenancyv1alpha1.WorkspaceMigrationCopyFailed,
+			wantSourceShard: "amber",
+		},
+		{
+			name:            "writes are blocked after a pass without changes",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantCopied:      true,
+			wantRequeue:     true,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationWritesBlocked,
+			wantStatus:      corev1.ConditionTrue,
+			wantSourceShard: "amber",
+			wantReadOnly:    pointer.Bool(true),
+		},
+		{
+			name:             "workspace is switched after a pass without changes while writes are blocked",
+			phase:            tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			readOnly:         true,
+			writesBlocked:    true,
+			wantPhase:        tenancyv1alpha1.WorkspaceMigrationPhaseSwitched,
+			wantCopied:       true,
+			wantSwitched:     true,
+			wantCondition:    tenancyv1alpha1.WorkspaceMigrationContentCopied,
+			wantStatus:       corev1.ConditionTrue,
+			wantSourceShard:  "amber",
+			wantSwitchedTime: true,
+		},
+		{
+			name:            "workspaces moved by someone else fail the migration",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			currentShard:    "emerald",
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationValid,
+			wantStatus:      corev1.ConditionFalse,
+			wantReason:      tenancyv1alpha1.WorkspaceMigrationWorkspaceMoved,
+			wantSourceShard: "amber",
+		},
+		{
+			name:            "source content is removed after the switch",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseSwitched,
+			currentShard:    "sapphire",
+			readOnly:        true,
+			writesBlocked:   true,
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseCompleted,
+			wantRemoved:     true,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationSourceRemoved,
+			wantStatus:      corev1.ConditionTrue,
+			wantSourceShard: "amber",
+			wantReadOnly:    pointer.Bool(false),
+		},
+		{
+			name:            "removal errors are reported",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseSwitched,
+			currentShard:    "sapphire",
+			removeErr:       errors.New("boom"),
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseSwitched,
+			wantRemoved:     true,
+			wantErr:         true,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationSourceRemoved,
+			wantStatus:      corev1.ConditionFalse,
+			wantReason:      tenancyv1alpha1.WorkspaceMigrationRemovalFailed,
+			wantSourceShard: "amber",
+		},
+		{
+			name:            "failed migrations unblock writes",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			readOnly:        true,
+			writesBlocked:   true,
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantSourceShard: "amber",
+			wantReadOnly:    pointer.Bool(false),
+		},
+		{
+			name:            "failed migrations of deleted workspaces drop the write block",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			noWorkspace:     true,
+			writesBlocked:   true,
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseFailed,
+			wantSourceShard: "amber",
+		},
+		{
+			name:            "workspaces made read-only by someone else stay read-only",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseSwitched,
+			currentShard:    "sapphire",
+			readOnly:        true,
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseCompleted,
+			wantRemoved:     true,
+			wantCondition:   tenancyv1alpha1.WorkspaceMigrationSourceRemoved,
+			wantStatus:      corev1.ConditionTrue,
+			wantSourceShard: "amber",
+		},
+		{
+			name:            "completed migrations are left alone",
+			phase:           tenancyv1alpha1.WorkspaceMigrationPhaseCompleted,
+			noWorkspace:     true,
+			wantPhase:       tenancyv1alpha1.WorkspaceMigrationPhaseCompleted,
+			wantSourceShard: "amber",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			currentShard := testCase.currentShard
+			if currentShard == "" {
+				currentShard = "amber"
+			}
+			workspacePhase := testCase.workspacePhase
+			if workspacePhase == "" {
+				workspacePhase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+			}
+
+			var copied, switched, removed bool
+			var readOnly *bool
+			c := &controller{
+				getWorkspace: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					require.Equal(t, "team", name)
+					if testCase.noWorkspace {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+					}
+					return &tenancyv1alpha1.ClusterWorkspace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "team",
+							Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+						},
+						Spec: tenancyv1alpha1.ClusterWorkspaceSpec{ReadOnly: testCase.readOnly},
+						Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+							Phase:    workspacePhase,
+							Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: currentShard},
+						},
+					}, nil
+				},
+				getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+					if name != "sapphire" || testCase.noTargetShard {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaceshards"), name)
+					}
+					return &tenancyv1alpha1.ClusterWorkspaceShard{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{ExternalURL: "https://sapphire:6443", State: testCase.targetState},
+					}, nil
+				},
+				hasShardClient: func(name string) bool {
+					return name == "amber" || name == "sapphire"
+				},
+				hasChildWorkspaces: func(ctx context.Context, shard string, clusterName logicalcluster.Name) (bool, error) {
+					require.Equal(t, "amber", shard)
+					require.Equal(t, logicalcluster.New("root:org:team"), clusterName)
+					return testCase.hasChildren, nil
+				},
+				copyContent: func(ctx context.Context, from, to string, clusterName logicalcluster.Name) (int, error) {
+					require.Equal(t, "amber", from)
+					require.Equal(t, "sapphire", to)
+					require.Equal(t, logicalcluster.New("root:org:team"), clusterName)
+					copied = true
+					return testCase.changes, testCase.copyErr
+				},
+				removeContent: func(ctx context.Context, shard string, clusterName logicalcluster.Name) error {
+					require.Equal(t, "amber", shard)
+					require.Equal(t, logicalcluster.New("root:org:team"), clusterName)
+					removed = true
+					return testCase.removeErr
+				},
+				switchShard: func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, location tenancyv1alpha1.ClusterWorkspaceLocation, baseURL string) error {
+					require.Equal(t, "team", workspace.Name)
+					require.Equal(t, "sapphire", location.Current)
+					require.Equal(t, "https://sapphire:6443/clusters/root:org:team", baseURL)
+					switched = true
+					return nil
+				},
+				setReadOnly: func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, value bool) error {
+					require.Equal(t, "team", workspace.Name)
+					readOnly = &value
+					return nil
+				},
+				now: func() time.Time { return now },
+			}
+
+			migration := &tenancyv1alpha1.WorkspaceMigration{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "team-to-sapphire",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Spec: tenancyv1alpha1.WorkspaceMigrationSpec{Workspace: "team", TargetShard: "sapphire"},
+				Status: tenancyv1alpha1.WorkspaceMigrationStatus{
+					Phase: testCase.phase,
+				},
+			}
+			if testCase.phase != "" {
+				migration.Status.SourceShard = "amber"
+			}
+			if testCase.writesBlocked {
+				conditions.MarkTrue(migration, tenancyv1alpha1.WorkspaceMigrationWritesBlocked)
+			}
+
+			requeueAfter, err := c.reconcile(context.Background(), migration)
+			if testCase.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, testCase.wantRequeue, requeueAfter > 0, "requeue")
+			require.Equal(t, testCase.wantCopied, copied, "copied")
+			require.Equal(t, testCase.wantSwitched, switched, "switched")
+			require.Equal(t, testCase.wantRemoved, removed, "removed")
+			require.Equal(t, testCase.wantReadOnly, readOnly, "readOnly")
+			require.Equal(t, testCase.wantPhase, migration.Status.Phase)
+			require.Equal(t, testCase.wantSourceShard, migration.Status.SourceShard)
+			if testCase.wantSwitchedTime {
+				require.NotNil(t, migration.Status.SwitchTime)
+				require.Equal(t, now, migration.Status.SwitchTime.Time)
+			}
+
+			if testCase.wantCondition == "" {
+				require.Empty(t, migration.Status.Conditions)
+				return
+			}
+			condition := conditions.Get(migration, testCase.wantCondition)
+			require.NotNil(t, condition)
+			require.Equal(t, testCase.wantStatus, condition.Status)
+			require.Equal(t, testCase.wantReason, condition.Reason)
+		})
+	}
+}
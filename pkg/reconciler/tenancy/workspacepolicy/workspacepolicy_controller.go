@@ -0,0 +1,394 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/author"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/defaultobjects"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-workspace-policy"
+
+	// driftResyncPeriod is the period after which the policy objects of a workspace are applied again,
+	// reverting changes made to them in the workspace.
+	driftResyncPeriod = 10 * time.Minute
+)
+
+// NewController returns a new controller applying the WorkspacePolicies of the ancestors of
+// ClusterWorkspaces to them. The dynamic client must impersonate the author of the request
+// context, see author.WithImpersonatedAuthor.
+func NewController(
+	dynamicClusterClient dynamic.Interface,
+	kcpClusterClient kcpclient.Interface,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	workspacePolicyInformer tenancyinformers.WorkspacePolicyInformer,
+	workspaceQuotaInformer tenancyinformers.WorkspaceQuotaInformer,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	newRESTMapper func(clusterName logicalcluster.Name) (meta.RESTMapper, error),
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	workspaceQuotaLister := workspaceQuotaInformer.Lister()
+	apiBindingLister := apiBindingInformer.Lister()
+	c := &controller{
+		queue:            queue,
+		kcpClusterClient: kcpClusterClient,
+		workspaceLister:  workspaceInformer.Lister(),
+		listPolicies: func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+			return indexers.ByIndex[*tenancyv1alpha1.WorkspacePolicy](workspacePolicyInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+		},
+		getWorkspaceQuota: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error) {
+			return workspaceQuotaLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		createWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error {
+			_, err := kcpClusterClient.TenancyV1alpha1().WorkspaceQuotas().Create(logicalcluster.WithCluster(ctx, clusterName), quota, metav1.CreateOptions{})
+			return err
+		},
+		updateWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error {
+			_, err := kcpClusterClient.TenancyV1alpha1().WorkspaceQuotas().Update(logicalcluster.WithCluster(ctx, clusterName), quota, metav1.UpdateOptions{})
+			return err
+		},
+		deleteWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+			return kcpClusterClient.TenancyV1alpha1().WorkspaceQuotas().Delete(logicalcluster.WithCluster(ctx, clusterName), name, metav1.DeleteOptions{})
+		},
+		getAPIBinding: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error) {
+			return apiBindingLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		createAPIBinding: func(ctx context.Context, clusterName logicalcluster.Name, binding *apisv1alpha1.APIBinding) error {
+			_, err := kcpClusterClient.ApisV1alpha1().APIBindings().Create(logicalcluster.WithCluster(ctx, clusterName), binding, metav1.CreateOptions{})
+			return err
+		},
+		applyObjects: func(ctx context.Context, clusterName logicalcluster.Name, objAuthor user.Info, objs []*unstructured.Unstructured) error {
+			mapper, err := newRESTMapper(clusterName)
+			if err != nil {
+				return err
+			}
+			return defaultobjects.ApplyObjects(author.WithAuthor(logicalcluster.WithCluster(ctx, clusterName), objAuthor), dynamicClusterClient, mapper, controllerName, objs)
+		},
+		syncChecks: []cache.InformerSynced{
+			workspaceInformer.Informer().HasSynced,
+			workspacePolicyInformer.Informer().HasSynced,
+			workspaceQuotaInformer.Informer().HasSynced,
+			apiBindingInformer.Informer().HasSynced,
+		},
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		workspacePolicyInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+		},
+	)
+
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	})
+
+	workspacePolicyInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueDescendants(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueDescendants(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueDescendants(obj) },
+	})
+
+	workspaceQuotaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldQuota, ok := oldObj.(*tenancyv1alpha1.WorkspaceQuota)
+			if !ok {
+				return
+			}
+			newQuota, ok := newObj.(*tenancyv1alpha1.WorkspaceQuota)
+			if !ok {
+				return
+			}
+			// status updates are charges by admission, only spec changes can drift from the policy
+			if oldQuota.Generation != newQuota.Generation {
+				c.enqueueLimitedWorkspace(newObj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueueLimitedWorkspace(obj) },
+	})
+
+	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) { c.enqueueBindingWorkspace(obj) },
+	})
+
+	return c, nil
+}
+
+// controller watches ClusterWorkspaces in initializing and ready phase, and applies the WorkspacePolicies
+// of their ancestors to them: the policy objects and default APIBindings are created inside the workspace,
+// and the policy WorkspaceQuota is created in the parent. The objects are applied again periodically to
+// revert drift, and default APIBindings are recreated when deleted.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.Interface
+
+	workspaceLister      tenancylisters.ClusterWorkspaceLister
+	listPolicies         func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error)
+	getWorkspaceQuota    func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error)
+	createWorkspaceQuota func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error
+	updateWorkspaceQuota func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error
+	deleteWorkspaceQuota func(ctx context.Context, clusterName logicalcluster.Name, name string) error
+	getAPIBinding        func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error)
+	createAPIBinding     func(ctx context.Context, clusterName logicalcluster.Name, binding *apisv1alpha1.APIBinding) error
+	applyObjects         func(ctx context.Context, clusterName logicalcluster.Name, author user.Info, objs []*unstructured.Unstructured) error
+
+	syncChecks []cache.InformerSynced
+}
+
+func (c *controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace")
+	c.queue.Add(key)
+}
+
+// enqueueDescendants enqueues all ClusterWorkspaces below the workspace of a WorkspacePolicy.
+func (c *controller) enqueueDescendants(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	policy, ok := obj.(*tenancyv1alpha1.WorkspacePolicy)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+
+	workspaces, err := c.workspaceLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	policyClusterName := logicalcluster.From(policy)
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), policy)
+	for _, workspace := range workspaces {
+		if !isDescendantOrSelf(logicalcluster.From(workspace), policyClusterName) {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		logging.WithQueueKey(logger, key).V(2).Info("queueing ClusterWorkspace because WorkspacePolicy changed")
+		c.queue.Add(key)
+	}
+}
+
+// enqueueLimitedWorkspace enqueues the ClusterWorkspace limited by a WorkspaceQuota.
+func (c *controller) enqueueLimitedWorkspace(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	quota, ok := obj.(*tenancyv1alpha1.WorkspaceQuota)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+
+	key := clusters.ToClusterAwareKey(logicalcluster.From(quota), quota.Name)
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace because WorkspaceQuota changed")
+	c.queue.Add(key)
+}
+
+// enqueueBindingWorkspace enqueues the ClusterWorkspace of a deleted APIBinding created from a policy,
+// such that it is bound again.
+func (c *controller) enqueueBindingWorkspace(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	binding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+	if _, found := binding.Annotations[tenancyv1alpha1.WorkspacePolicyAnnotationKey]; !found {
+		return
+	}
+
+	parent, name := logicalcluster.From(binding).Split()
+	key := clusters.ToClusterAwareKey(parent, name)
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace because APIBinding got deleted")
+	c.queue.Add(key)
+}
+
+// isDescendantOrSelf returns whether the logical cluster is the given ancestor or below it.
+func isDescendantOrSelf(clusterName, ancestor logicalcluster.Name) bool {
+	return clusterName == ancestor || strings.HasPrefix(clusterName.String(), ancestor.String()+":")
+}
+
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), c.syncChecks...) {
+		logger.Error(nil, "Failed to wait for caches to sync")
+		return
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	resync, err := c.process(ctx, key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	if resync {
+		// apply again later to revert drift
+		c.queue.AddAfter(key, driftResyncPeriod)
+	}
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) (bool, error) {
+	obj, err := c.workspaceLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil // object deleted before we handled it
+		}
+		return false, err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	resync, err := c.reconcile(ctx, obj)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Regardless of whether reconcile returned an error or not, always try to patch status if needed. Return the
+	// reconciliation error at the end.
+	if err := c.patchStatusIfNeeded(ctx, old, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	return resync, utilerrors.NewAggregate(errs)
+}
+
+func (c *controller) patchStatusIfNeeded(ctx context.Context, old, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(old)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+	_, err = c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
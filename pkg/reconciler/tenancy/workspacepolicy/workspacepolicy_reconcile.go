@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacepolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/author"
+	"github.com/kcp-dev/kcp/pkg/workspacepolicy"
+)
+
+// reconcile applies the merged WorkspacePolicies of the ancestors of the workspace, and reflects the
+// result in the WorkspacePoliciesApplied condition. It returns whether the policy objects must be
+// applied again later to revert drift.
+func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (bool, error) {
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return false, nil
+	}
+
+	wsClusterName := logicalcluster.From(workspace).Join(workspace.Name)
+	policy, err := workspacepolicy.EffectivePolicy(c.listPolicies, wsClusterName)
+	if err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspacePoliciesApplied, tenancyv1alpha1.WorkspacePoliciesApplyFailed, conditionsv1alpha1.ConditionSeverityError,
+			"Invalid WorkspacePolicy: %v", err)
+		return true, nil // wait for the policy to be fixed
+	}
+
+	var errs []error
+	if err := c.reconcileObjects(ctx, wsClusterName, policy); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.reconcileAPIBindings(ctx, workspace, policy); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.reconcileWorkspaceQuota(ctx, workspace, policy); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspacePoliciesApplied, tenancyv1alpha1.WorkspacePoliciesApplyFailed, conditionsv1alpha1.ConditionSeverityError,
+			"Failed to apply policies: %v", err)
+		return false, fmt.Errorf("failed to apply policies to workspace %s: %w", wsClusterName, err)
+	}
+
+	if len(policy.Objects) == 0 && len(policy.APIBindings) == 0 && policy.WorkspaceQuota == nil {
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspacePoliciesApplied)
+		return false, nil
+	}
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspacePoliciesApplied)
+	return len(policy.Objects) > 0, nil
+}
+
+// reconcileObjects applies the objects of the policy in order, impersonating the author of the
+// WorkspacePolicy each comes from, such that they cannot grant more than the author could.
+func (c *controller) reconcileObjects(ctx context.Context, wsClusterName logicalcluster.Name, policy *workspacepolicy.Policy) error {
+	logger := klog.FromContext(ctx)
+
+	for start := 0; start < len(policy.Objects); {
+		// consecutive objects of the same policy are applied together
+		source := policy.Objects[start].Policy
+		end := start + 1
+		for end < len(policy.Objects) && policy.Objects[end].Policy == source {
+			end++
+		}
+
+		objAuthor, found, err := author.From(source)
+		if err == nil && !found {
+			err = fmt.Errorf("missing %s annotation", tenancyv1alpha1.ExperimentalAuthorAnnotationKey)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid author of WorkspacePolicy %s|%s: %w", logicalcluster.From(source), source.Name, err)
+		}
+
+		objs := make([]*unstructured.Unstructured, 0, end-start)
+		for _, obj := range policy.Objects[start:end] {
+			objs = append(objs, obj.Unstructured)
+		}
+		logger.V(4).Info("applying policy objects", "logicalCluster", wsClusterName, "count", len(objs), "policy", source.Name, "author", objAuthor.GetName())
+		if err := c.applyObjects(ctx, wsClusterName, objAuthor, objs); err != nil {
+			return fmt.Errorf("failed to apply objects of WorkspacePolicy %s|%s: %w", logicalcluster.From(source), source.Name, err)
+		}
+		start = end
+	}
+	return nil
+}
+
+// reconcileAPIBindings creates the default APIBindings of the policy in the workspace, unless the workspace
+// opts out of them. Existing APIBindings of the same name are left alone.
+func (c *controller) reconcileAPIBindings(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, policy *workspacepolicy.Policy) error {
+	logger := klog.FromContext(ctx)
+	wsClusterName := logicalcluster.From(workspace).Join(workspace.Name)
+
+	skipped := sets.NewString()
+	if value, found := workspace.Annotations[tenancyv1alpha1.SkipDefaultAPIBindingsAnnotationKey]; found {
+		for _, name := range strings.Split(value, ",") {
+			skipped.Insert(strings.TrimSpace(name))
+		}
+	}
+
+	var errs []error
+	for _, binding := range policy.APIBindings {
+		if !binding.Enforced && (skipped.Has("*") || skipped.Has(binding.ExportName)) {
+			continue
+		}
+
+		if _, err := c.getAPIBinding(wsClusterName, binding.ExportName); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			errs = append(errs, err)
+			continue
+		}
+
+		logger.V(2).Info("creating default APIBinding from policy", "path", binding.Path, "exportName", binding.ExportName)
+		apiBinding := &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        binding.ExportName,
+				Annotations: map[string]string{tenancyv1alpha1.WorkspacePolicyAnnotationKey: "true"},
+			},
+			Spec: apisv1alpha1.APIBindingSpec{
+				Reference: apisv1alpha1.ExportReference{
+					Workspace: &apisv1alpha1.WorkspaceExportReference{
+						Path:       binding.Path,
+						ExportName: binding.ExportName,
+					},
+				},
+			},
+		}
+		if err := c.createAPIBinding(ctx, wsClusterName, apiBinding); err != nil && !errors.IsAlreadyExists(err) {
+			errs = append(errs, fmt.Errorf("failed to create APIBinding %s|%s: %w", wsClusterName, binding.ExportName, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// reconcileWorkspaceQuota makes the WorkspaceQuota of the workspace in its parent match the policy.
+// WorkspaceQuotas not created from a policy are local overrides, and are only replaced if the policy
+// is enforced.
+func (c *controller) reconcileWorkspaceQuota(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, policy *workspacepolicy.Policy) error {
+	logger := klog.FromContext(ctx)
+	parent := logicalcluster.From(workspace)
+
+	quota, err := c.getWorkspaceQuota(parent, workspace.Name)
+	if errors.IsNotFound(err) {
+		quota = nil
+	} else if err != nil {
+		return err
+	}
+	managed := false
+	if quota != nil {
+		_, managed = quota.Annotations[tenancyv1alpha1.WorkspacePolicyAnnotationKey]
+	}
+
+	switch {
+	case policy.WorkspaceQuota == nil:
+		if quota == nil || !managed {
+			return nil
+		}
+		logger.V(2).Info("deleting WorkspaceQuota no longer required by policy")
+		if err := c.deleteWorkspaceQuota(ctx, parent, workspace.Name); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete WorkspaceQuota %s|%s: %w", parent, workspace.Name, err)
+		}
+		return nil
+
+	case quota == nil:
+		logger.V(2).Info("creating WorkspaceQuota from policy")
+		quota = &tenancyv1alpha1.WorkspaceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        workspace.Name,
+				Annotations: map[string]string{tenancyv1alpha1.WorkspacePolicyAnnotationKey: "true"},
+			},
+			Spec: *policy.WorkspaceQuota,
+		}
+		if err := c.createWorkspaceQuota(ctx, parent, quota); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create WorkspaceQuota %s|%s: %w", parent, workspace.Name, err)
+		}
+		return nil
+
+	case !managed && !policy.WorkspaceQuotaEnforced:
+		return nil // local override
+
+	case managed && equality.Semantic.DeepEqual(quota.Spec, *policy.WorkspaceQuota):
+		return nil
+	}
+
+	logger.V(2).Info("updating WorkspaceQuota from policy")
+	quota = quota.DeepCopy()
+	if quota.Annotations == nil {
+		quota.Annotations = map[string]string{}
+	}
+	quota.Annotations[tenancyv1alpha1.WorkspacePolicyAnnotationKey] = "true"
+	quota.Spec = *policy.WorkspaceQuota
+	if err := c.updateWorkspaceQuota(ctx, parent, quota); err != nil {
+		return fmt.Errorf("failed to update WorkspaceQuota %s|%s: %w", parent, workspace.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacepolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/workspacepolicy"
+)
+
+func TestReconcile(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+	binding := tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+		Raw: []byte(`{"apiVersion":"apis.kcp.dev/v1alpha1","kind":"APIBinding","metadata":{"name":"kubernetes"}}`),
+	}}
+	quotaSpec := &tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(5)}
+	managedQuota := &tenancyv1alpha1.WorkspaceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws", Annotations: map[string]string{tenancyv1alpha1.WorkspacePolicyAnnotationKey: "true"}},
+		Spec:       tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(10)},
+	}
+	defaultBindings := []tenancyv1alpha1.DefaultAPIBinding{
+		{Path: "root:org:services", ExportName: "databases"},
+		{Path: "root:org:services", ExportName: "queues"},
+	}
+	localQuota := &tenancyv1alpha1.WorkspaceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "ws"},
+		Spec:       tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(10)},
+	}
+
+	for _, testCase := range []struct {
+		name     string
+		phase    tenancyv1alpha1.ClusterWorkspacePhaseType
+		spec     tenancyv1alpha1.WorkspacePolicySpec
+		quota    *tenancyv1alpha1.WorkspaceQuota
+		applyErr error
+		noAuthor bool
+		skip     string
+		bindings []string
+
+		wantApplied     []string
+		wantQuotaAction string
+		wantBindings    []string
+		wantResync      bool
+		wantErr         bool
+		wantCondition   corev1.ConditionStatus
+		wantNoCondition bool
+	}{
+		{
+			name:            "scheduling workspaces are skipped",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+			spec:            tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{binding}},
+			wantNoCondition: true,
+		},
+		{
+			name:            "workspaces without policies are skipped",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			quota:           localQuota,
+			wantNoCondition: true,
+		},
+		{
+			name:          "objects are applied",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{binding}},
+			wantApplied:   []string{"APIBinding/kubernetes"},
+			wantResync:    true,
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:          "apply errors are reported",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{binding}},
+			applyErr:      errors.New("boom"),
+			wantApplied:   []string{"APIBinding/kubernetes"},
+			wantErr:       true,
+			wantCondition: corev1.ConditionFalse,
+		},
+		{
+			name:          "objects without author are not applied",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{binding}},
+			noAuthor:      true,
+			wantErr:       true,
+			wantCondition: corev1.ConditionFalse,
+		},
+		{
+			name:          "invalid policies are reported",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{{RawExtension: runtime.RawExtension{Raw: []byte(`[]`)}}}},
+			wantResync:    true,
+			wantCondition: corev1.ConditionFalse,
+		},
+		{
+			name:            "missing quota is created",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:            tenancyv1alpha1.WorkspacePolicySpec{WorkspaceQuota: quotaSpec},
+			wantQuotaAction: "create",
+			wantCondition:   corev1.ConditionTrue,
+		},
+		{
+			name:            "managed quota is updated",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:            tenancyv1alpha1.WorkspacePolicySpec{WorkspaceQuota: quotaSpec},
+			quota:           managedQuota,
+			wantQuotaAction: "update",
+			wantCondition:   corev1.ConditionTrue,
+		},
+		{
+			name:          "local quota overrides the policy",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{WorkspaceQuota: quotaSpec},
+			quota:         localQuota,
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:            "local quota is replaced by an enforced policy",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:            tenancyv1alpha1.WorkspacePolicySpec{WorkspaceQuota: quotaSpec, OverridePolicy: tenancyv1alpha1.WorkspacePolicyOverrideDeny},
+			quota:           localQuota,
+			wantQuotaAction: "update",
+			wantCondition:   corev1.ConditionTrue,
+		},
+		{
+			name:          "default APIBindings are created",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: defaultBindings},
+			bindings:      []string{"queues"},
+			wantBindings:  []string{"root:org:services:databases"},
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:          "workspaces opt out of default APIBindings",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: defaultBindings},
+			skip:          "databases",
+			wantBindings:  []string{"root:org:services:queues"},
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:          "workspaces opt out of all default APIBindings",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: defaultBindings},
+			skip:          "*",
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:          "workspaces cannot opt out of enforced default APIBindings",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: defaultBindings, OverridePolicy: tenancyv1alpha1.WorkspacePolicyOverrideDeny},
+			skip:          "*",
+			wantBindings:  []string{"root:org:services:databases", "root:org:services:queues"},
+			wantCondition: corev1.ConditionTrue,
+		},
+		{
+			name:            "managed quota is deleted without policy",
+			phase:           tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			quota:           managedQuota,
+			wantQuotaAction: "delete",
+			wantNoCondition: true,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var applied, bindings []string
+			var quotaAction string
+			c := &controller{
+				listPolicies: func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+					if clusterName != logicalcluster.New("root:org") {
+						return nil, nil
+					}
+					policy := &tenancyv1alpha1.WorkspacePolicy{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "policy",
+							Annotations: map[string]string{
+								logicalcluster.AnnotationKey:                    "root:org",
+								tenancyv1alpha1.ExperimentalAuthorAnnotationKey: `{"username":"alice","groups":["system:authenticated"]}`,
+							},
+						},
+						Spec: testCase.spec,
+					}
+					if testCase.noAuthor {
+						delete(policy.Annotations, tenancyv1alpha1.ExperimentalAuthorAnnotationKey)
+					}
+					return []*tenancyv1alpha1.WorkspacePolicy{policy}, nil
+				},
+				getWorkspaceQuota: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.WorkspaceQuota, error) {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					require.Equal(t, "ws", name)
+					if testCase.quota == nil {
+						return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("workspacequotas"), name)
+					}
+					return testCase.quota, nil
+				},
+				createWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error {
+					require.Equal(t, *quotaSpec, quota.Spec)
+					require.Contains(t, quota.Annotations, tenancyv1alpha1.WorkspacePolicyAnnotationKey)
+					quotaAction = "create"
+					return nil
+				},
+				updateWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, quota *tenancyv1alpha1.WorkspaceQuota) error {
+					require.Equal(t, *quotaSpec, quota.Spec)
+					require.Contains(t, quota.Annotations, tenancyv1alpha1.WorkspacePolicyAnnotationKey)
+					quotaAction = "update"
+					return nil
+				},
+				deleteWorkspaceQuota: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+					quotaAction = "delete"
+					return nil
+				},
+				getAPIBinding: func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIBinding, error) {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					for _, existing := range testCase.bindings {
+						if existing == name {
+							return &apisv1alpha1.APIBinding{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+						}
+					}
+					return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apibindings"), name)
+				},
+				createAPIBinding: func(ctx context.Context, clusterName logicalcluster.Name, binding *apisv1alpha1.APIBinding) error {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					require.Contains(t, binding.Annotations, tenancyv1alpha1.WorkspacePolicyAnnotationKey)
+					require.Equal(t, binding.Name, binding.Spec.Reference.Workspace.ExportName)
+					bindings = append(bindings, binding.Spec.Reference.Workspace.Path+":"+binding.Name)
+					return nil
+				},
+				applyObjects: func(ctx context.Context, clusterName logicalcluster.Name, author user.Info, objs []*unstructured.Unstructured) error {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					require.Equal(t, &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}}, author)
+					for _, obj := range objs {
+						applied = append(applied, obj.GetKind()+"/"+obj.GetName())
+					}
+					return testCase.applyErr
+				},
+			}
+
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "ws",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{Phase: testCase.phase},
+			}
+			if testCase.skip != "" {
+				workspace.Annotations[tenancyv1alpha1.SkipDefaultAPIBindingsAnnotationKey] = testCase.skip
+			}
+
+			resync, err := c.reconcile(context.Background(), workspace)
+			if testCase.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, testCase.wantResync, resync)
+			require.Equal(t, testCase.wantApplied, applied)
+			require.Equal(t, testCase.wantQuotaAction, quotaAction)
+			require.Equal(t, testCase.wantBindings, bindings)
+
+			condition := conditions.Get(workspace, tenancyv1alpha1.WorkspacePoliciesApplied)
+			if testCase.wantNoCondition {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, testCase.wantCondition, condition.Status)
+		})
+	}
+}
+
+func TestReconcileObjects(t *testing.T) {
+	object := func(name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetName(name)
+		return obj
+	}
+	policy := func(name, username string) *tenancyv1alpha1.WorkspacePolicy {
+		return &tenancyv1alpha1.WorkspacePolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Annotations: map[string]string{
+					logicalcluster.AnnotationKey:                    "root:org",
+					tenancyv1alpha1.ExperimentalAuthorAnnotationKey: fmt.Sprintf(`{"username":%q}`, username),
+				},
+			},
+		}
+	}
+	a, b := policy("a", "alice"), policy("b", "bob")
+
+	var applied []string
+	c := &controller{
+		applyObjects: func(ctx context.Context, clusterName logicalcluster.Name, author user.Info, objs []*unstructured.Unstructured) error {
+			for _, obj := range objs {
+				applied = append(applied, author.GetName()+"/"+obj.GetName())
+			}
+			applied = append(applied, "|")
+			return nil
+		},
+	}
+	err := c.reconcileObjects(context.Background(), logicalcluster.New("root:org:ws"), &workspacepolicy.Policy{
+		Objects: []workspacepolicy.Object{
+			{Unstructured: object("x"), Policy: a},
+			{Unstructured: object("y"), Policy: a},
+			{Unstructured: object("z"), Policy: b},
+			{Unstructured: object("w"), Policy: a},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice/x", "alice/y", "|", "bob/z", "|", "alice/w", "|"}, applied)
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacequota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/committer"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-workspace-quota"
+
+	// resyncPeriod is the period after which the usage of a WorkspaceQuota is recomputed, in order
+	// to pick up deleted objects.
+	resyncPeriod = time.Minute
+)
+
+// NewController returns a new controller that computes the usage of the limited workspaces into
+// the status of their WorkspaceQuotas. Admission charges new objects against the usage, and this
+// controller corrects it for deleted and shrunk objects.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	metadataClient metadata.Interface,
+	dynamicClusterClient dynamic.Interface,
+	workspaceQuotaInformer tenancyinformers.WorkspaceQuotaInformer,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	discoverResources func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error),
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:                queue,
+		workspaceQuotaLister: workspaceQuotaInformer.Lister(),
+		discoverResources:    discoverResources,
+		listObjectMetadata: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*metav1.PartialObjectMetadataList, error) {
+			return metadataClient.Resource(gvr).Namespace(metav1.NamespaceAll).List(logicalcluster.WithCluster(ctx, clusterName), metav1.ListOptions{})
+		},
+		listObjects: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+			return dynamicClusterClient.Resource(gvr).Namespace(metav1.NamespaceAll).List(logicalcluster.WithCluster(ctx, clusterName), metav1.ListOptions{})
+		},
+		listWorkspaces: func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.ClusterWorkspace, error) {
+			return indexers.ByIndex[*tenancyv1alpha1.ClusterWorkspace](workspaceInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+		},
+		commit: committer.NewCommitter[*WorkspaceQuota, *WorkspaceQuotaSpec, *WorkspaceQuotaStatus](kcpClusterClient.TenancyV1alpha1().WorkspaceQuotas()),
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		workspaceInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+		},
+	)
+
+	workspaceQuotaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueue(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldQuota, ok := oldObj.(*tenancyv1alpha1.WorkspaceQuota)
+			if !ok {
+				return
+			}
+			newQuota, ok := newObj.(*tenancyv1alpha1.WorkspaceQuota)
+			if !ok {
+				return
+			}
+			// status updates are mostly charges by admission, the usage is recomputed periodically
+			if oldQuota.Generation != newQuota.Generation {
+				c.enqueue(newObj)
+			}
+		},
+	})
+
+	return c, nil
+}
+
+type WorkspaceQuota = tenancyv1alpha1.WorkspaceQuota
+type WorkspaceQuotaSpec = tenancyv1alpha1.WorkspaceQuotaSpec
+type WorkspaceQuotaStatus = tenancyv1alpha1.WorkspaceQuotaStatus
+type Resource = committer.Resource[*WorkspaceQuotaSpec, *WorkspaceQuotaStatus]
+type CommitFunc = func(context.Context, *Resource, *Resource) error
+
+// controller reconciles the usage of WorkspaceQuotas in their status.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	workspaceQuotaLister tenancylisters.WorkspaceQuotaLister
+
+	discoverResources  func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error)
+	listObjectMetadata func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*metav1.PartialObjectMetadataList, error)
+	listObjects        func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error)
+	listWorkspaces     func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.ClusterWorkspace, error)
+
+	commit CommitFunc
+}
+
+// enqueue enqueues a WorkspaceQuota.
+func (c *controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing WorkspaceQuota")
+	c.queue.Add(key)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.workspaceQuotaLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	if err := c.reconcile(ctx, obj); err != nil {
+		errs = append(errs, err)
+	}
+
+	// If the object being reconciled changed as a result, update it.
+	oldResource := &Resource{ObjectMeta: old.ObjectMeta, Spec: &old.Spec, Status: &old.Status}
+	newResource := &Resource{ObjectMeta: obj.ObjectMeta, Spec: &obj.Spec, Status: &obj.Status}
+	if err := c.commit(ctx, oldResource, newResource); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		// the objects of the workspace are not watched, recompute the usage periodically
+		c.queue.AddAfter(key, resyncPeriod)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacequota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// unmeasuredResources are not counted into the storage usage, because they are virtual or not
+// created by users of the workspace.
+var unmeasuredResources = sets.NewString(
+	"events",
+	"events.events.k8s.io",
+	"workspaces.tenancy.kcp.dev",
+)
+
+func (c *controller) reconcile(ctx context.Context, quota *tenancyv1alpha1.WorkspaceQuota) error {
+	logger := klog.FromContext(ctx)
+
+	clusterName := logicalcluster.From(quota).Join(quota.Name)
+	previous := quota.Status.Used
+	used := tenancyv1alpha1.WorkspaceQuotaUsage{}
+	var errs []error
+
+	var resources []*metav1.APIResourceList
+	if len(quota.Spec.ObjectCounts) > 0 || quota.Spec.StorageBytes != nil {
+		var err error
+		resources, err = c.discoverResources(clusterName)
+		if err != nil && len(resources) == 0 {
+			// keep the last known usage, e.g. until the workspace is initialized
+			return fmt.Errorf("failed to discover resources of workspace %s: %w", clusterName, err)
+		}
+	}
+
+	for _, limit := range quota.Spec.ObjectCounts {
+		count := tenancyv1alpha1.ObjectCount{Group: limit.Group, Resource: limit.Resource}
+		gvr, found := preferredVersion(resources, limit.Group, limit.Resource)
+		if found {
+			list, err := c.listObjectMetadata(ctx, clusterName, gvr)
+			switch {
+			case errors.IsNotFound(err):
+				logger.V(4).Info("resource not served", "resource", gvr)
+			case err != nil:
+				errs = append(errs, fmt.Errorf("error listing %s in %s: %w", gvr, clusterName, err))
+				// keep the last known count
+				count.Count = previousCount(previous, limit.Group, limit.Resource)
+			default:
+				count.Count = int64(len(list.Items))
+			}
+		}
+		used.ObjectCounts = append(used.ObjectCounts, count)
+	}
+
+	if quota.Spec.StorageBytes != nil {
+		size, err := c.measureStorage(ctx, clusterName, resources)
+		if err != nil {
+			errs = append(errs, err)
+			used.StorageBytes = previous.StorageBytes
+		} else {
+			used.StorageBytes = resource.NewQuantity(size, resource.BinarySI)
+		}
+	}
+
+	if quota.Spec.ChildWorkspaces != nil {
+		workspaces, err := c.listWorkspaces(clusterName)
+		if err != nil {
+			errs = append(errs, err)
+			used.ChildWorkspaces = previous.ChildWorkspaces
+		} else {
+			count := int64(len(workspaces))
+			used.ChildWorkspaces = &count
+		}
+	}
+
+	quota.Status.Used = used
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// measureStorage returns the total size of the JSON encoding of the objects in the workspace.
+func (c *controller) measureStorage(ctx context.Context, clusterName logicalcluster.Name, resources []*metav1.APIResourceList) (int64, error) {
+	var total int64
+	for _, list := range discovery.FilteredBy(discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			return false
+		}
+		return discovery.SupportsAllVerbs{Verbs: []string{"list"}}.Match(groupVersion, r) &&
+			!unmeasuredResources.Has(schema.GroupResource{Group: gv.Group, Resource: r.Name}.String())
+	}), resources) {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range list.APIResources {
+			objs, err := c.listObjects(ctx, clusterName, gv.WithResource(r.Name))
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return 0, fmt.Errorf("error listing %s in %s: %w", gv.WithResource(r.Name), clusterName, err)
+			}
+			for i := range objs.Items {
+				bs, err := json.Marshal(objs.Items[i].Object)
+				if err != nil {
+					return 0, err
+				}
+				total += int64(len(bs))
+			}
+		}
+	}
+	return total, nil
+}
+
+// preferredVersion returns the resource with the given group and name among the discovered ones.
+func preferredVersion(resources []*metav1.APIResourceList, group, resourceName string) (schema.GroupVersionResource, bool) {
+	for _, list := range resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil || gv.Group != group {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if r.Name == resourceName {
+				return gv.WithResource(r.Name), true
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+func previousCount(used tenancyv1alpha1.WorkspaceQuotaUsage, group, resourceName string) int64 {
+	for _, count := range used.ObjectCounts {
+		if count.Group == group && count.Resource == resourceName {
+			return count.Count
+		}
+	}
+	return 0
+}
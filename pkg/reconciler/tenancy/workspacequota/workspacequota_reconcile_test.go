@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacequota
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+	configMap := unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+	configMapSize := int64(len(`{"apiVersion":"v1","kind":"ConfigMap"}`))
+
+	resources := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Verbs: metav1.Verbs{"list", "create"}},
+			{Name: "events", Verbs: metav1.Verbs{"list", "create"}},
+		}},
+		{GroupVersion: "wildwest.dev/v1", APIResources: []metav1.APIResource{
+			{Name: "cowboys", Verbs: metav1.Verbs{"list", "create"}},
+		}},
+	}
+
+	tests := map[string]struct {
+		spec        tenancyv1alpha1.WorkspaceQuotaSpec
+		used        tenancyv1alpha1.WorkspaceQuotaUsage
+		listErr     error
+		discoverErr error
+
+		wantUsed  tenancyv1alpha1.WorkspaceQuotaUsage
+		wantError bool
+	}{
+		"no limits": {
+			used: tenancyv1alpha1.WorkspaceQuotaUsage{ChildWorkspaces: int64Ptr(3)},
+		},
+		"object counts": {
+			spec: tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: []tenancyv1alpha1.ObjectCountLimit{
+				{Resource: "configmaps", MaxObjects: 10},
+				{Group: "wildwest.dev", Resource: "cowboys", MaxObjects: 10},
+				{Group: "unknown.dev", Resource: "things", MaxObjects: 10},
+			}},
+			wantUsed: tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: []tenancyv1alpha1.ObjectCount{
+				{Resource: "configmaps", Count: 2},
+				{Group: "wildwest.dev", Resource: "cowboys", Count: 2},
+				{Group: "unknown.dev", Resource: "things"},
+			}},
+		},
+		"list errors keep the last known count": {
+			spec:      tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: []tenancyv1alpha1.ObjectCountLimit{{Resource: "configmaps", MaxObjects: 10}}},
+			used:      tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: []tenancyv1alpha1.ObjectCount{{Resource: "configmaps", Count: 7}}},
+			listErr:   errors.New("boom"),
+			wantUsed:  tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: []tenancyv1alpha1.ObjectCount{{Resource: "configmaps", Count: 7}}},
+			wantError: true,
+		},
+		"discovery errors keep the last known usage": {
+			spec:        tenancyv1alpha1.WorkspaceQuotaSpec{ObjectCounts: []tenancyv1alpha1.ObjectCountLimit{{Resource: "configmaps", MaxObjects: 10}}},
+			used:        tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: []tenancyv1alpha1.ObjectCount{{Resource: "configmaps", Count: 7}}},
+			discoverErr: errors.New("boom"),
+			wantUsed:    tenancyv1alpha1.WorkspaceQuotaUsage{ObjectCounts: []tenancyv1alpha1.ObjectCount{{Resource: "configmaps", Count: 7}}},
+			wantError:   true,
+		},
+		"storage skips events": {
+			spec:     tenancyv1alpha1.WorkspaceQuotaSpec{StorageBytes: resource.NewQuantity(1000, resource.BinarySI)},
+			wantUsed: tenancyv1alpha1.WorkspaceQuotaUsage{StorageBytes: resource.NewQuantity(4*configMapSize, resource.BinarySI)},
+		},
+		"child workspaces": {
+			spec:     tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(10)},
+			used:     tenancyv1alpha1.WorkspaceQuotaUsage{ChildWorkspaces: int64Ptr(5)},
+			wantUsed: tenancyv1alpha1.WorkspaceQuotaUsage{ChildWorkspaces: int64Ptr(3)},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &controller{
+				discoverResources: func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error) {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					if tc.discoverErr != nil {
+						return nil, tc.discoverErr
+					}
+					return resources, nil
+				},
+				listObjectMetadata: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*metav1.PartialObjectMetadataList, error) {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					if tc.listErr != nil {
+						return nil, tc.listErr
+					}
+					if gvr.Resource == "things" {
+						return nil, apierrors.NewNotFound(gvr.GroupResource(), "")
+					}
+					return &metav1.PartialObjectMetadataList{Items: make([]metav1.PartialObjectMetadata, 2)}, nil
+				},
+				listObjects: func(ctx context.Context, clusterName logicalcluster.Name, gvr schema.GroupVersionResource) (*unstructured.UnstructuredList, error) {
+					require.NotEqual(t, "events", gvr.Resource)
+					return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{configMap, configMap}}, nil
+				},
+				listWorkspaces: func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.ClusterWorkspace, error) {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					return make([]*tenancyv1alpha1.ClusterWorkspace, 3), nil
+				},
+			}
+
+			quota := &tenancyv1alpha1.WorkspaceQuota{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "ws",
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Spec:   tc.spec,
+				Status: tenancyv1alpha1.WorkspaceQuotaStatus{Used: tc.used},
+			}
+			err := c.reconcile(context.Background(), quota)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantUsed, quota.Status.Used)
+		})
+	}
+}
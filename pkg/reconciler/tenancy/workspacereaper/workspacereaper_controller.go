@@ -0,0 +1,273 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacereaper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-workspace-reaper"
+
+	// ExemptAnnotationKey on a ClusterWorkspace with value "true" exempts the workspace from reaping.
+	ExemptAnnotationKey = "tenancy.kcp.dev/idle-reaping-exempt"
+)
+
+// NewController returns a new controller reaping ClusterWorkspaces without activity for longer than
+// the idle TTL of the options.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	shardName string,
+	options Options,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:            queue,
+		kcpClusterClient: kcpClusterClient,
+		workspaceLister:  workspaceInformer.Lister(),
+		hasChildren: func(clusterName logicalcluster.Name) (bool, error) {
+			children, err := workspaceInformer.Informer().GetIndexer().ByIndex(indexers.ByLogicalCluster, clusterName.String())
+			return len(children) > 0, err
+		},
+		deleteWorkspace: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+			return kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Delete(logicalcluster.WithCluster(ctx, clusterName), name, metav1.DeleteOptions{})
+		},
+		now:       time.Now,
+		shardName: shardName,
+		idleTTL:   options.IdleTTL,
+		action:    options.Action,
+		syncChecks: []cache.InformerSynced{
+			workspaceInformer.Informer().HasSynced,
+		},
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		workspaceInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			indexers.ByLogicalCluster: indexers.IndexByLogicalCluster,
+		},
+	)
+
+	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueParent(obj) },
+	})
+
+	return c, nil
+}
+
+// controller watches ready ClusterWorkspaces, and marks those without activity for longer than the
+// idle TTL with a false WorkspaceActive condition, and deletes them if configured so. The activity
+// of a workspace is its lastActivityTime, or its creation if there was none. Workspaces with child
+// workspaces are never idle. Workspaces scheduled to other shards are ignored, because
+// their activity is recorded there and cannot be persisted in the ClusterWorkspace on this shard.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.Interface
+
+	workspaceLister tenancylisters.ClusterWorkspaceLister
+	hasChildren     func(clusterName logicalcluster.Name) (bool, error)
+	deleteWorkspace func(ctx context.Context, clusterName logicalcluster.Name, name string) error
+	now             func() time.Time
+
+	shardName string
+	idleTTL   time.Duration
+	action    Action
+
+	syncChecks []cache.InformerSynced
+}
+
+func (c *controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace")
+	c.queue.Add(key)
+}
+
+// enqueueParent enqueues the ClusterWorkspace of the workspace a deleted ClusterWorkspace was in,
+// as it might have become idle.
+func (c *controller) enqueueParent(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected type %T", obj))
+		return
+	}
+
+	parent := logicalcluster.From(workspace)
+	grandparent, hasGrandparent := parent.Parent()
+	if !hasGrandparent {
+		return
+	}
+	key := clusters.ToClusterAwareKey(grandparent, parent.Base())
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace because child workspace was deleted")
+	c.queue.Add(key)
+}
+
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	if !cache.WaitForNamedCacheSync(controllerName, ctx.Done(), c.syncChecks...) {
+		logger.Error(nil, "Failed to wait for caches to sync")
+		return
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	requeueAfter, err := c.process(ctx, key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	if requeueAfter > 0 {
+		// check again when the workspace would become idle
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) (time.Duration, error) {
+	obj, err := c.workspaceLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return 0, nil // object deleted before we handled it
+		}
+		return 0, err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	logger := logging.WithObject(klog.FromContext(ctx), obj)
+	ctx = klog.NewContext(ctx, logger)
+
+	var errs []error
+	requeueAfter, err := c.reconcile(ctx, obj)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Regardless of whether reconcile returned an error or not, always try to patch status if needed. Return the
+	// reconciliation error at the end.
+	if err := c.patchStatusIfNeeded(ctx, old, obj); err != nil && !errors.IsNotFound(err) {
+		errs = append(errs, err)
+	}
+
+	return requeueAfter, utilerrors.NewAggregate(errs)
+}
+
+func (c *controller) patchStatusIfNeeded(ctx context.Context, old, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(old)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, old.Name, err)
+	}
+	_, err = c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Patch(logicalcluster.WithCluster(ctx, clusterName), obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacereaper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Action is what the reaper does with idle workspaces.
+type Action string
+
+const (
+	// ActionFlag marks idle workspaces with a false WorkspaceActive condition.
+	ActionFlag Action = "Flag"
+	// ActionDelete marks idle workspaces like ActionFlag, and deletes them.
+	ActionDelete Action = "Delete"
+)
+
+// DefaultOptions are the default options for the workspace reaper controller.
+func DefaultOptions() *Options {
+	return &Options{
+		Action: ActionFlag,
+	}
+}
+
+// BindOptions binds the workspace reaper controller options to the flag set.
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.DurationVar(&o.IdleTTL, "workspace-idle-ttl", o.IdleTTL, "Duration without user activity after which a workspace is idle, and reaped according to --workspace-idle-action. Workspaces with child workspaces are never idle. If zero, workspaces are not reaped.")
+	fs.StringVar((*string)(&o.Action), "workspace-idle-action", string(o.Action), fmt.Sprintf("What to do with idle workspaces: %q marks them with a false WorkspaceActive condition, %q deletes them in addition.", ActionFlag, ActionDelete))
+	return o
+}
+
+// Options are the options for the workspace reaper controller.
+type Options struct {
+	IdleTTL time.Duration
+	Action  Action
+}
+
+func (o *Options) Validate() error {
+	if o.IdleTTL < 0 {
+		return fmt.Errorf("--workspace-idle-ttl must be >=0 (%s)", o.IdleTTL)
+	}
+	if o.Action != ActionFlag && o.Action != ActionDelete {
+		return fmt.Errorf("--workspace-idle-action must be %q or %q, got %q", ActionFlag, ActionDelete, o.Action)
+	}
+	return nil
+}
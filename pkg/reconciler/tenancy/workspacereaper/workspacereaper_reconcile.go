@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacereaper
+
+import (
+	"context"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// reconcile updates the WorkspaceActive condition of the workspace, and deletes it if it is idle and
+// the action is ActionDelete. It returns after which duration the workspace becomes idle, if active.
+func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	if workspace.DeletionTimestamp != nil || workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return 0, nil
+	}
+	if workspace.Annotations[ExemptAnnotationKey] == "true" {
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceActive)
+		return 0, nil
+	}
+	if workspace.Status.Location.Current != c.shardName {
+		// the activity of the workspace is recorded on its shard, but cannot be persisted here
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceActive)
+		return 0, nil
+	}
+
+	clusterName := logicalcluster.From(workspace)
+	hasChildren, err := c.hasChildren(clusterName.Join(workspace.Name))
+	if err != nil {
+		return 0, err
+	}
+	if hasChildren {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceActive)
+		return 0, nil // re-queued when the last child is deleted
+	}
+
+	lastActivity := workspace.CreationTimestamp.Time
+	if t := workspace.Status.LastActivityTime; t != nil && t.After(lastActivity) {
+		lastActivity = t.Time
+	}
+	if idle := c.now().Sub(lastActivity); idle < c.idleTTL {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceActive)
+		return c.idleTTL - idle, nil
+	}
+
+	conditions.MarkFalse(
+		workspace,
+		tenancyv1alpha1.WorkspaceActive,
+		tenancyv1alpha1.WorkspaceIdle,
+		conditionsv1alpha1.ConditionSeverityWarning,
+		"No activity since %s, longer than the idle TTL of %s.", lastActivity.UTC().Format(time.RFC3339), c.idleTTL,
+	)
+	if c.action != ActionDelete {
+		return 0, nil
+	}
+
+	logger := klog.FromContext(ctx)
+	logger.Info("deleting idle workspace", "lastActivity", lastActivity)
+	return 0, c.deleteWorkspace(ctx, clusterName, workspace.Name)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacereaper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-48 * time.Hour))
+	recent := metav1.NewTime(now.Add(-time.Hour))
+
+	tests := map[string]struct {
+		phase        tenancyv1alpha1.ClusterWorkspacePhaseType
+		annotations  map[string]string
+		lastActivity *metav1.Time
+		hasChildren  bool
+		shard        string
+		action       Action
+
+		wantCondition    corev1.ConditionStatus
+		wantRequeueAfter time.Duration
+		wantDeleted      bool
+	}{
+		"not ready workspaces are ignored": {
+			phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+		},
+		"exempt workspaces are ignored": {
+			phase:       tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			annotations: map[string]string{ExemptAnnotationKey: "true"},
+			action:      ActionDelete,
+		},
+		"workspaces on other shards are ignored": {
+			phase:  tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			shard:  "other",
+			action: ActionDelete,
+		},
+		"workspaces with children are active": {
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			hasChildren:   true,
+			action:        ActionDelete,
+			wantCondition: corev1.ConditionTrue,
+		},
+		"recent activity is active": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			lastActivity:     &recent,
+			wantCondition:    corev1.ConditionTrue,
+			wantRequeueAfter: 23 * time.Hour,
+		},
+		"idle workspaces are flagged": {
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			action:        ActionFlag,
+			wantCondition: corev1.ConditionFalse,
+		},
+		"idle workspaces are deleted": {
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			action:        ActionDelete,
+			wantCondition: corev1.ConditionFalse,
+			wantDeleted:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			deleted := false
+			c := &controller{
+				hasChildren: func(clusterName logicalcluster.Name) (bool, error) {
+					require.Equal(t, logicalcluster.New("root:org:ws"), clusterName)
+					return tc.hasChildren, nil
+				},
+				deleteWorkspace: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+					require.Equal(t, logicalcluster.New("root:org"), clusterName)
+					require.Equal(t, "ws", name)
+					deleted = true
+					return nil
+				},
+				now:       func() time.Time { return now },
+				shardName: "root",
+				idleTTL:   24 * time.Hour,
+				action:    tc.action,
+			}
+			shard := "root"
+			if tc.shard != "" {
+				shard = tc.shard
+			}
+
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "ws",
+					CreationTimestamp: created,
+					Annotations:       map[string]string{logicalcluster.AnnotationKey: "root:org"},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:            tc.phase,
+					LastActivityTime: tc.lastActivity,
+					Location:         tenancyv1alpha1.ClusterWorkspaceLocation{Current: shard},
+				},
+			}
+			for k, v := range tc.annotations {
+				workspace.Annotations[k] = v
+			}
+
+			requeueAfter, err := c.reconcile(context.Background(), workspace)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+			require.Equal(t, tc.wantDeleted, deleted)
+
+			if tc.wantCondition == "" {
+				require.Nil(t, conditions.Get(workspace, tenancyv1alpha1.WorkspaceActive))
+			} else {
+				require.Equal(t, tc.wantCondition, conditions.Get(workspace, tenancyv1alpha1.WorkspaceActive).Status)
+			}
+		})
+	}
+}
@@ -38,12 +38,13 @@ import (
 	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
 	apiresourcelisters "github.com/kcp-dev/kcp/pkg/client/listers/apiresource/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	controllerName = "kcp-workload-apiexport"
-	byWorkspace    = controllerName + "-byWorkspace" // will go away with scoping
 
 	// TemporaryComputeServiceExportName is a temporary singleton name of compute service exports.
 	TemporaryComputeServiceExportName = "kubernetes"
@@ -56,7 +57,7 @@ func NewController(
 	apiResourceSchemaInformer apisinformers.APIResourceSchemaInformer,
 	negotiatedAPIResourceInformer apiresourceinformer.NegotiatedAPIResourceInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue: queue,
@@ -73,23 +74,9 @@ func NewController(
 		negotiatedAPIResourceIndexer: negotiatedAPIResourceInformer.Informer().GetIndexer(),
 	}
 
-	if err := c.apiResourceSchemaIndexer.AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
-
-	if err := negotiatedAPIResourceInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
-
-	if err := apiExportInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(apiResourceSchemaInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(negotiatedAPIResourceInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(apiExportInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	apiExportInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
@@ -35,6 +35,7 @@ import (
 
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 )
 
@@ -227,7 +228,7 @@ func (c *controller) reconcile(ctx context.Context, export *apisv1alpha1.APIExpo
 }
 
 func (c *controller) listNegotiatedAPIResources(clusterName logicalcluster.Name) ([]*apiresourcev1alpha1.NegotiatedAPIResource, error) {
-	objs, err := c.negotiatedAPIResourceIndexer.ByIndex(byWorkspace, clusterName.String())
+	objs, err := c.negotiatedAPIResourceIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
@@ -239,7 +240,7 @@ func (c *controller) listNegotiatedAPIResources(clusterName logicalcluster.Name)
 }
 
 func (c *controller) listAPIResourceSchemas(clusterName logicalcluster.Name) ([]*apisv1alpha1.APIResourceSchema, error) {
-	objs, err := c.apiResourceSchemaIndexer.ByIndex(byWorkspace, clusterName.String())
+	objs, err := c.apiResourceSchemaIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
@@ -45,15 +45,15 @@ import (
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	reconcilerapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	controllerName = "kcp-workload-apiexport-create"
 
-	byWorkspace = controllerName + "-byWorkspace" // will go away with scoping
-
 	DefaultLocationName = "default"
 )
 
@@ -65,7 +65,7 @@ func NewController(
 	apiBindingInformer apisinformers.APIBindingInformer,
 	locationInformer schedulinginformers.LocationInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue: queue,
@@ -88,17 +88,8 @@ func NewController(
 		locationLister: locationInformer.Lister(),
 	}
 
-	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
-
-	if err := apiBindingInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(syncTargetInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(apiBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	apiExportInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
@@ -234,7 +225,7 @@ func (c *controller) process(ctx context.Context, key string) error {
 	logger := klog.FromContext(ctx)
 	clusterName := logicalcluster.New(key)
 
-	syncTargets, err := c.syncTargetIndexer.ByIndex(byWorkspace, clusterName.String())
+	syncTargets, err := c.syncTargetIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		logger.Error(err, "failed to list clusters for workspace")
 		return err
@@ -298,7 +289,7 @@ func (c *controller) process(ctx context.Context, key string) error {
 	}
 
 	// check that binding exists, and create it if not
-	bindings, err := c.apiBindingIndexer.ByIndex(byWorkspace, clusterName.String())
+	bindings, err := c.apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		logger.Error(err, "failed to list APIBindings")
 		return err
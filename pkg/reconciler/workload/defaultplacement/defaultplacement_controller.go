@@ -42,15 +42,15 @@ import (
 	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
 	schedulinginformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/scheduling/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	reconcilerapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	controllerName = "kcp-workload-default-placement"
 
-	byWorkspace = controllerName + "-byWorkspace" // will go away with scoping
-
 	// DefaultPlacementName is the name of the default placement
 	DefaultPlacementName = "default"
 )
@@ -61,7 +61,7 @@ func NewController(
 	apiBindingInformer apisinformers.APIBindingInformer,
 	placementInformer schedulinginformers.PlacementInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue: queue,
@@ -73,11 +73,7 @@ func NewController(
 		placementLister: placementInformer.Lister(),
 	}
 
-	if err := apiBindingInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(apiBindingInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
@@ -186,7 +182,7 @@ func (c *controller) process(ctx context.Context, key string) error {
 	clusterName := logicalcluster.New(key)
 
 	// check that binding exists, and create it if not
-	bindings, err := c.apiBindingIndexer.ByIndex(byWorkspace, clusterName.String())
+	bindings, err := c.apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		logger.Error(err, "failed to list APIBindings for ClusterWorkspace")
 		return err
@@ -42,14 +42,18 @@ import (
 	"k8s.io/kube-openapi/pkg/util/sets"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	schedulinginformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/scheduling/v1alpha1"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	controllerName      = "kcp-namespace-scheduling-placement"
-	byWorkspace         = controllerName + "-byWorkspace" // will go away with scoping
 	byLocationWorkspace = controllerName + "-byLocationWorkspace"
 )
 
@@ -59,8 +63,9 @@ func NewController(
 	kubeClusterClient kubernetesclient.Interface,
 	namespaceInformer coreinformers.NamespaceInformer,
 	placementInformer schedulinginformers.PlacementInformer,
+	clusterWorkspaceInformer tenancyinformers.ClusterWorkspaceInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue: queue,
@@ -76,16 +81,14 @@ func NewController(
 
 		placmentLister:   placementInformer.Lister(),
 		placementIndexer: placementInformer.Informer().GetIndexer(),
-	}
 
-	if err := namespaceInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorksapce,
-	}); err != nil {
-		return nil, err
+		clusterWorkspaceLister: clusterWorkspaceInformer.Lister(),
 	}
 
+	indexers.AddOrDie(namespaceInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(placementInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+
 	if err := placementInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace:         indexByWorksapce,
 		byLocationWorkspace: indexByLoactionWorkspace,
 	}); err != nil {
 		return nil, err
@@ -117,6 +120,15 @@ func NewController(
 		DeleteFunc: func(obj interface{}) { c.enqueuePlacement(obj) },
 	})
 
+	clusterWorkspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if _, found := obj.(*tenancyv1alpha1.ClusterWorkspace).Annotations[schedulingv1alpha1.SchedulingModeAnnotationKey]; found {
+				c.enqueueClusterWorkspace(obj)
+			}
+		},
+		UpdateFunc: c.updateClusterWorkspace,
+	})
+
 	return c, nil
 }
 
@@ -132,6 +144,8 @@ type controller struct {
 
 	placmentLister   schedulinglisters.PlacementLister
 	placementIndexer cache.Indexer
+
+	clusterWorkspaceLister tenancylisters.ClusterWorkspaceLister
 }
 
 func (c *controller) enqueueNamespace(obj interface{}) {
@@ -154,7 +168,7 @@ func (c *controller) enqueuePlacement(obj interface{}) {
 	}
 	clusterName, _ := clusters.SplitClusterAwareKey(key)
 
-	nss, err := c.namespaceIndexer.ByIndex(byWorkspace, clusterName.String())
+	nss, err := c.namespaceIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -170,6 +184,39 @@ func (c *controller) enqueuePlacement(obj interface{}) {
 	}
 }
 
+// updateClusterWorkspace enqueues all namespaces of the workspace if its scheduling mode changes,
+// including when the annotation is added or removed.
+func (c *controller) updateClusterWorkspace(old, obj interface{}) {
+	oldWorkspace := old.(*tenancyv1alpha1.ClusterWorkspace)
+	newWorkspace := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if oldWorkspace.Annotations[schedulingv1alpha1.SchedulingModeAnnotationKey] != newWorkspace.Annotations[schedulingv1alpha1.SchedulingModeAnnotationKey] {
+		c.enqueueClusterWorkspace(obj)
+	}
+}
+
+// enqueueClusterWorkspace enqueues all namespaces of the workspace, e.g. when its scheduling mode changes.
+func (c *controller) enqueueClusterWorkspace(obj interface{}) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		return
+	}
+	clusterName := logicalcluster.From(workspace).Join(workspace.Name)
+
+	nss, err := c.namespaceIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), workspace)
+	for _, o := range nss {
+		ns := o.(*corev1.Namespace)
+		nskey := clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name)
+		logging.WithQueueKey(logger, nskey).V(2).Info("queueing Namespace because of ClusterWorkspace")
+		c.queue.Add(nskey)
+	}
+}
+
 // Start starts the controller, which stops when ctx.Done() is closed.
 func (c *controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
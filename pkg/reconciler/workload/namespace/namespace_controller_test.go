@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+func TestUpdateClusterWorkspace(t *testing.T) {
+	workspace := func(mode string) *tenancyv1alpha1.ClusterWorkspace {
+		annotations := map[string]string{logicalcluster.AnnotationKey: "root:org"}
+		if mode != "" {
+			annotations[schedulingv1alpha1.SchedulingModeAnnotationKey] = mode
+		}
+		return &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "ws", Annotations: annotations}}
+	}
+	namespace := func(cluster string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Annotations: map[string]string{logicalcluster.AnnotationKey: cluster}}}
+	}
+	manual := string(schedulingv1alpha1.SchedulingModeManual)
+
+	tests := map[string]struct {
+		old, new *tenancyv1alpha1.ClusterWorkspace
+		wantKeys int
+	}{
+		"annotation added":   {old: workspace(""), new: workspace(manual), wantKeys: 1},
+		"annotation removed": {old: workspace(manual), new: workspace(""), wantKeys: 1},
+		"annotation kept":    {old: workspace(manual), new: workspace(manual)},
+		"no annotation":      {old: workspace(""), new: workspace("")},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexers.ByLogicalCluster: indexers.IndexByLogicalCluster})
+			require.NoError(t, indexer.Add(namespace("root:org:ws")))
+			require.NoError(t, indexer.Add(namespace("root:org:other")))
+
+			c := &controller{
+				queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test"),
+				namespaceIndexer: indexer,
+			}
+			c.updateClusterWorkspace(tt.old, tt.new)
+			require.Equal(t, tt.wantKeys, c.queue.Len())
+		})
+	}
+}
@@ -23,9 +23,12 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	utilserrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clusters"
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 type reconcileStatus int
@@ -46,13 +49,15 @@ func (c *controller) reconcile(ctx context.Context, ns *corev1.Namespace) error
 			patchNamespace: c.patchNamespace,
 		},
 		&placementSchedulingReconciler{
-			listPlacement:  c.listPlacement,
-			enqueueAfter:   c.enqueueAfter,
-			patchNamespace: c.patchNamespace,
-			now:            time.Now,
+			listPlacement:     c.listPlacement,
+			getSchedulingMode: c.getSchedulingMode,
+			enqueueAfter:      c.enqueueAfter,
+			patchNamespace:    c.patchNamespace,
+			now:               time.Now,
 		},
 		&statusConditionReconciler{
-			patchNamespace: c.patchNamespace,
+			getSchedulingMode: c.getSchedulingMode,
+			patchNamespace:    c.patchNamespace,
 		},
 	}
 
@@ -74,7 +79,7 @@ func (c *controller) reconcile(ctx context.Context, ns *corev1.Namespace) error
 }
 
 func (c *controller) listPlacement(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error) {
-	items, err := c.placementIndexer.ByIndex(byWorkspace, clusterName.String())
+	items, err := c.placementIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
@@ -84,3 +89,26 @@ func (c *controller) listPlacement(clusterName logicalcluster.Name) ([]*scheduli
 	}
 	return ret, nil
 }
+
+// getSchedulingMode returns the scheduling mode of the namespace, given by the scheduling.kcp.dev/mode
+// annotation on the namespace or else on the ClusterWorkspace of the namespace.
+func (c *controller) getSchedulingMode(ns *corev1.Namespace) (schedulingv1alpha1.SchedulingMode, error) {
+	if mode, found := ns.Annotations[schedulingv1alpha1.SchedulingModeAnnotationKey]; found {
+		return schedulingv1alpha1.SchedulingMode(mode), nil
+	}
+
+	parent, name := logicalcluster.From(ns).Split()
+	if parent.Empty() {
+		return schedulingv1alpha1.SchedulingModeAutomatic, nil
+	}
+	workspace, err := c.clusterWorkspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+	if errors.IsNotFound(err) {
+		return schedulingv1alpha1.SchedulingModeAutomatic, nil
+	} else if err != nil {
+		return "", err
+	}
+	if mode, found := workspace.Annotations[schedulingv1alpha1.SchedulingModeAnnotationKey]; found {
+		return schedulingv1alpha1.SchedulingMode(mode), nil
+	}
+	return schedulingv1alpha1.SchedulingModeAutomatic, nil
+}
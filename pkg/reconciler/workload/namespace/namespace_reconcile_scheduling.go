@@ -41,7 +41,8 @@ const removingGracePeriod = 5 * time.Second
 // selected synctarget stored in the internal.workload.kcp.dev/synctarget annotation
 // on each placement.
 type placementSchedulingReconciler struct {
-	listPlacement func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
+	listPlacement     func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
+	getSchedulingMode func(ns *corev1.Namespace) (schedulingv1alpha1.SchedulingMode, error)
 
 	patchNamespace func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error)
 
@@ -66,6 +67,12 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 		validPlacements = filterValidPlacements(ns, placements)
 	}
 
+	mode, err := r.getSchedulingMode(ns)
+	if err != nil {
+		return reconcileStatusStop, ns, err
+	}
+	manual := mode == schedulingv1alpha1.SchedulingModeManual
+
 	// 1. pick all synctargets in all bound placements
 	scheduledSyncTargets := sets.NewString()
 	for _, placement := range validPlacements {
@@ -84,6 +91,10 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 	expectedLabels := map[string]interface{}{}      // nil means to remove the key
 
 	for syncTarget := range synced {
+		if manual {
+			// in manual mode, synctargets are only marked as removing explicitly.
+			continue
+		}
 		if !scheduledSyncTargets.Has(syncTarget) {
 			// it is no longer a synced synctarget, mark it as removing.
 			now := r.now().UTC().Format(time.RFC3339)
@@ -109,6 +120,10 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 
 	// 5. if a scheduled synctarget is not in synced and removing, add it in to the label
 	for scheduledSyncTarget := range scheduledSyncTargets {
+		if manual {
+			// in manual mode, synctargets are only added explicitly.
+			continue
+		}
 		if synced.Has(scheduledSyncTarget) {
 			continue
 		}
@@ -170,6 +170,56 @@ func TestScheduling(t *testing.T) {
 			},
 			expectedLabels: map[string]string{},
 		},
+		{
+			name: "no synctarget is scheduled in manual mode",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:      "",
+				schedulingv1alpha1.SchedulingModeAnnotationKey: string(schedulingv1alpha1.SchedulingModeManual),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster"),
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:      "",
+				schedulingv1alpha1.SchedulingModeAnnotationKey: string(schedulingv1alpha1.SchedulingModeManual),
+			},
+		},
+		{
+			name: "manually bound synctarget is kept in manual mode",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:      "",
+				schedulingv1alpha1.SchedulingModeAnnotationKey: string(schedulingv1alpha1.SchedulingModeManual),
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster-2"),
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:      "",
+				schedulingv1alpha1.SchedulingModeAnnotationKey: string(schedulingv1alpha1.SchedulingModeManual),
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "manually unbound synctarget is removed after grace period in manual mode",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                                                    "",
+				schedulingv1alpha1.SchedulingModeAnnotationKey:                                                               string(schedulingv1alpha1.SchedulingModeManual),
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": time.Now().Add(-1 * (removingGracePeriod + 1)).UTC().Format(time.RFC3339),
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster"),
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:      "",
+				schedulingv1alpha1.SchedulingModeAnnotationKey: string(schedulingv1alpha1.SchedulingModeManual),
+			},
+			expectedLabels: map[string]string{},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -191,10 +241,11 @@ func TestScheduling(t *testing.T) {
 
 			var patched bool
 			reconciler := &placementSchedulingReconciler{
-				listPlacement:  listPlacement,
-				patchNamespace: patchNamespaceFunc(&patched, ns),
-				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
-				now:            func() time.Time { return now },
+				listPlacement:     listPlacement,
+				getSchedulingMode: namespaceSchedulingMode,
+				patchNamespace:    patchNamespaceFunc(&patched, ns),
+				enqueueAfter:      func(*corev1.Namespace, time.Duration) {},
+				now:               func() time.Time { return now },
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), ns)
@@ -324,10 +375,11 @@ func TestMultiplePlacements(t *testing.T) {
 
 			var patched bool
 			reconciler := &placementSchedulingReconciler{
-				listPlacement:  listPlacement,
-				patchNamespace: patchNamespaceFunc(&patched, ns),
-				enqueueAfter:   func(*corev1.Namespace, time.Duration) {},
-				now:            func() time.Time { return now },
+				listPlacement:     listPlacement,
+				getSchedulingMode: namespaceSchedulingMode,
+				patchNamespace:    patchNamespaceFunc(&patched, ns),
+				enqueueAfter:      func(*corev1.Namespace, time.Duration) {},
+				now:               func() time.Time { return now },
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), ns)
@@ -362,3 +414,10 @@ func newPlacement(name, location, synctarget string) *schedulingv1alpha1.Placeme
 
 	return placement
 }
+
+func namespaceSchedulingMode(ns *corev1.Namespace) (schedulingv1alpha1.SchedulingMode, error) {
+	if mode, found := ns.Annotations[schedulingv1alpha1.SchedulingModeAnnotationKey]; found {
+		return schedulingv1alpha1.SchedulingMode(mode), nil
+	}
+	return schedulingv1alpha1.SchedulingModeAutomatic, nil
+}
@@ -43,8 +43,8 @@ const (
 	// lack of ready clusters being available.
 	NamespaceReasonUnschedulable = "Unschedulable"
 	// NamespaceReasonSchedulingDisabled reason in NamespaceScheduled Namespace Condition
-	// means that the automated scheduling for this namespace is disabled, e.g., when it's
-	// labelled with ScheduleDisabledLabel.
+	// means that the automated scheduling for this namespace is disabled, e.g., when its
+	// scheduling mode is Manual.
 	NamespaceReasonSchedulingDisabled = "SchedulingDisabled"
 	// NamespaceReasonPlacementInvalid reason in NamespaceScheduled Namespace Condition
 	// means the placement annotation has invalid value.
@@ -53,6 +53,8 @@ const (
 
 // statusReconciler updates conditions on the namespace.
 type statusConditionReconciler struct {
+	getSchedulingMode func(ns *corev1.Namespace) (schedulingv1alpha1.SchedulingMode, error)
+
 	patchNamespace func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error)
 }
 
@@ -60,7 +62,11 @@ type statusConditionReconciler struct {
 // namespace's scheduled state.
 func (r *statusConditionReconciler) reconcile(ctx context.Context, ns *corev1.Namespace) (reconcileStatus, *corev1.Namespace, error) {
 	logger := klog.FromContext(ctx)
-	updatedNs := setScheduledCondition(ns)
+	mode, err := r.getSchedulingMode(ns)
+	if err != nil {
+		return reconcileStatusStop, ns, err
+	}
+	updatedNs := setScheduledCondition(ns, mode)
 
 	if equality.Semantic.DeepEqual(ns.Status, updatedNs.Status) {
 		return reconcileStatusContinue, ns, nil
@@ -143,10 +149,22 @@ func (ca *NamespaceConditionsAdapter) SetConditions(conditions conditionsv1alpha
 	ca.Status.Conditions = nsConditions
 }
 
-func setScheduledCondition(ns *corev1.Namespace) *corev1.Namespace {
+func setScheduledCondition(ns *corev1.Namespace, mode schedulingv1alpha1.SchedulingMode) *corev1.Namespace {
 	updatedNs := ns.DeepCopy()
 	conditionsAdapter := &NamespaceConditionsAdapter{updatedNs}
 
+	if mode == schedulingv1alpha1.SchedulingModeManual {
+		synced, _ := syncedRemovingCluster(ns)
+		if len(synced) == 0 {
+			conditions.MarkFalse(conditionsAdapter, NamespaceScheduled, NamespaceReasonSchedulingDisabled,
+				conditionsv1alpha1.ConditionSeverityNone, // NamespaceCondition doesn't support severity
+				"Scheduling mode is Manual and no sync target is bound")
+			return updatedNs
+		}
+		conditions.MarkTrue(conditionsAdapter, NamespaceScheduled)
+		return updatedNs
+	}
+
 	_, found := ns.Annotations[schedulingv1alpha1.PlacementAnnotationKey]
 	if !found {
 		conditions.MarkFalse(conditionsAdapter, NamespaceScheduled, NamespaceReasonUnschedulable,
@@ -34,6 +34,7 @@ func TestSetScheduledCondition(t *testing.T) {
 	testCases := map[string]struct {
 		labels      map[string]string
 		annotations map[string]string
+		mode        schedulingv1alpha1.SchedulingMode
 		scheduled   bool
 		reason      conditionsapi.ConditionType
 	}{
@@ -55,6 +56,17 @@ func TestSetScheduledCondition(t *testing.T) {
 			},
 			reason: NamespaceReasonUnschedulable,
 		},
+		"manual mode without clusters": {
+			mode:   schedulingv1alpha1.SchedulingModeManual,
+			reason: NamespaceReasonSchedulingDisabled,
+		},
+		"manual mode with bound cluster": {
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+			},
+			mode:      schedulingv1alpha1.SchedulingModeManual,
+			scheduled: true,
+		},
 	}
 	for testName, testCase := range testCases {
 		t.Run(testName, func(t *testing.T) {
@@ -64,7 +76,11 @@ func TestSetScheduledCondition(t *testing.T) {
 					Annotations: testCase.annotations,
 				},
 			}
-			updatedNs := setScheduledCondition(ns)
+			mode := testCase.mode
+			if mode == "" {
+				mode = schedulingv1alpha1.SchedulingModeAutomatic
+			}
+			updatedNs := setScheduledCondition(ns, mode)
 
 			if !testCase.scheduled && testCase.reason == "" {
 				c := conditions.Get(&NamespaceConditionsAdapter{updatedNs}, NamespaceScheduled)
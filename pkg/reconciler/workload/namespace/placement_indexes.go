@@ -19,23 +19,9 @@ package namespace
 import (
 	"fmt"
 
-	"github.com/kcp-dev/logicalcluster/v2"
-
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 )
 
-func indexByWorksapce(obj interface{}) ([]string, error) {
-	metaObj, ok := obj.(metav1.Object)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
-	}
-
-	lcluster := logicalcluster.From(metaObj)
-	return []string{lcluster.String()}, nil
-}
-
 func indexByLoactionWorkspace(obj interface{}) ([]string, error) {
 	placement, ok := obj.(*schedulingv1alpha1.Placement)
 	if !ok {
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/kcp-dev/logicalcluster/v2"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
@@ -39,13 +40,14 @@ import (
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	schedulinglisters "github.com/kcp-dev/kcp/pkg/client/listers/scheduling/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	locationreconciler "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
-	controllerName      = "kcp-workload-placement"
-	byWorkspace         = controllerName + "-byWorkspace" // will go away with scoping
-	byLocationWorkspace = controllerName + "-byLocationWorkspace"
+	controllerName = "kcp-workload-placement"
 )
 
 // NewController returns a new controller starting the process of selecting synctarget for a placement
@@ -55,7 +57,7 @@ func NewController(
 	syncTargetInformer workloadinformers.SyncTargetInformer,
 	placementInformer schedulinginformers.PlacementInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &controller{
 		queue: queue,
@@ -72,24 +74,9 @@ func NewController(
 		placementIndexer: placementInformer.Informer().GetIndexer(),
 	}
 
-	if err := locationInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
-
-	if err := syncTargetInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace: indexByWorkspace,
-	}); err != nil {
-		return nil, err
-	}
-
-	if err := placementInformer.Informer().AddIndexers(cache.Indexers{
-		byWorkspace:         indexByWorkspace,
-		byLocationWorkspace: indexByLocationWorkspace,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(locationInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(syncTargetInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(placementInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, indexers.PlacementBySelectedLocation, indexers.PlacementBySyncTargetKey)
 
 	locationInformer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
@@ -160,22 +147,26 @@ type controller struct {
 	placementIndexer cache.Indexer
 }
 
-// enqueueLocation finds placement ref to this location at first, and then namespaces bound to this placement.
+// enqueueLocation enqueues the placements that selected this location.
 func (c *controller) enqueueLocation(obj interface{}) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
-	if err != nil {
-		runtime.HandleError(err)
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	location, ok := obj.(*schedulingv1alpha1.Location)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a Location, but is %T", obj))
 		return
 	}
-	clusterName, _ := clusters.SplitClusterAwareKey(key)
 
-	placements, err := c.placementIndexer.ByIndex(byLocationWorkspace, clusterName.String())
+	placements, err := indexers.Referencing(location,
+		indexers.Reference{Indexer: c.placementIndexer, IndexName: indexers.PlacementBySelectedLocation, ReferencedValues: indexers.IndexByKey},
+	)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
 
-	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), obj.(*schedulingv1alpha1.Location))
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), location)
 	for _, placement := range placements {
 		c.enqueuePlacement(placement, logger, " because of Location")
 	}
@@ -192,26 +183,61 @@ func (c *controller) enqueuePlacement(obj interface{}, logger logr.Logger, logSu
 	c.queue.Add(key)
 }
 
+// enqueueSyncTarget enqueues the placements scheduled to this sync target, and the placements that selected a
+// location the sync target is in.
 func (c *controller) enqueueSyncTarget(obj interface{}) {
-	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
-	if err != nil {
-		runtime.HandleError(err)
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a SyncTarget, but is %T", obj))
 		return
 	}
-	clusterName, _ := clusters.SplitClusterAwareKey(key)
 
-	placements, err := c.placementIndexer.ByIndex(byLocationWorkspace, clusterName.String())
+	placements, err := indexers.Referencing(syncTarget,
+		indexers.Reference{Indexer: c.placementIndexer, IndexName: indexers.PlacementBySyncTargetKey, ReferencedValues: indexers.IndexSyncTargetsBySyncTargetKey},
+		indexers.Reference{Indexer: c.placementIndexer, IndexName: indexers.PlacementBySelectedLocation, ReferencedValues: c.locationKeysSelecting},
+	)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
 
-	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), obj.(*workloadv1alpha1.SyncTarget))
+	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), syncTarget)
 	for _, placement := range placements {
 		c.enqueuePlacement(placement, logger, " because of SyncTarget")
 	}
 }
 
+// locationKeysSelecting returns the keys of the locations in the workspace of the given sync target that select it.
+func (c *controller) locationKeysSelecting(obj interface{}) ([]string, error) {
+	syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a SyncTarget, but is %T", obj)
+	}
+	clusterName := logicalcluster.From(syncTarget)
+
+	locations, err := indexers.ByIndex[*schedulingv1alpha1.Location](c.locationIndexer, indexers.ByLogicalCluster, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, location := range locations {
+		syncTargets, err := locationreconciler.LocationSyncTargets([]*workloadv1alpha1.SyncTarget{syncTarget}, location)
+		if err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		if len(syncTargets) > 0 {
+			keys = append(keys, clusters.ToClusterAwareKey(clusterName, location.Name))
+		}
+	}
+
+	return keys, nil
+}
+
 // Start starts the controller, which stops when ctx.Done() is closed.
 func (c *controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
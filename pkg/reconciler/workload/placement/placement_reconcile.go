@@ -29,6 +29,7 @@ import (
 
 	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 type reconcileStatus int
@@ -44,6 +45,9 @@ type reconciler interface {
 
 func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) error {
 	reconcilers := []reconciler{
+		&placementScoringReconciler{
+			patchPlacement: c.patchPlacement,
+		},
 		&placementSchedulingReconciler{
 			listSyncTarget: c.listSyncTarget,
 			getLocation:    c.getLocation,
@@ -69,7 +73,7 @@ func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha
 }
 
 func (c *controller) listSyncTarget(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error) {
-	items, err := c.syncTargetIndexer.ByIndex(byWorkspace, clusterName.String())
+	items, err := c.syncTargetIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
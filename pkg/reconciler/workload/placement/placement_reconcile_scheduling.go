@@ -44,6 +44,7 @@ type placementSchedulingReconciler struct {
 }
 
 func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
+	logger := klog.FromContext(ctx)
 	clusterName := logicalcluster.From(placement)
 
 	// 1. get current scheduled
@@ -74,10 +75,28 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement
 		}
 	}
 
-	// 3. randomly select one as the scheduled cluster
+	// 3. select the best scored one if a scoring expression is given, otherwise randomly select one as the scheduled cluster
 	// TODO(qiujian16): we currently schedule each in each location independently. It cannot guarantee 1 cluster is scheduled per location
 	// when the same synctargets are in multiple locations, we need to rethink whether we need a better algorithm or we need location
 	// to be exclusive.
+	if len(syncTargets) > 0 && len(placement.Spec.ScoringExpression) > 0 {
+		program, err := CompileScoringExpression(placement.Spec.ScoringExpression)
+		if err != nil {
+			// reported by the ScoringExpressionValid condition, retrying does not help until the placement is changed
+			return reconcileStatusContinue, placement, nil
+		}
+		syncTarget := bestScoredSyncTarget(program, syncTargets)
+		if syncTarget == nil {
+			logger.V(2).Info("scoringExpression cannot be evaluated for any valid SyncTarget")
+			if foundScheduled {
+				expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = nil
+				updated, err := r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations)
+				return reconcileStatusContinue, updated, err
+			}
+			return reconcileStatusContinue, placement, nil
+		}
+		syncTargets = []*workloadv1alpha1.SyncTarget{syncTarget}
+	}
 	if len(syncTargets) > 0 {
 		scheduledSyncTarget := syncTargets[rand.Intn(len(syncTargets))]
 		expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = workloadv1alpha1.ToSyncTargetKey(syncTargetClusterName, scheduledSyncTarget.Name)
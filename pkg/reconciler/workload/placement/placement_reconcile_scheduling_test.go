@@ -93,6 +93,51 @@ func TestSchedulingReconcile(t *testing.T) {
 				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
 			},
 		},
+		{
+			name:      "schedule best scored synctarget",
+			placement: withScoringExpression(newPlacement("test", "test-location", ""), "-scores['cost']"),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withScores(newSyncTarget("c1", true), map[string]string{"cost": "12"}),
+				withScores(newSyncTarget("c2", true), map[string]string{"cost": "3"}),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+		},
+		{
+			name:      "skip synctarget without score",
+			placement: withScoringExpression(newPlacement("test", "test-location", ""), "scores['cost']"),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c1", true),
+				withScores(newSyncTarget("c2", true), map[string]string{"cost": "3"}),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+			},
+		},
+		{
+			name:      "equal scores are ordered by name",
+			placement: withScoringExpression(newPlacement("test", "test-location", ""), "1"),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTarget("c2", true),
+				newSyncTarget("c1", true),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa",
+			},
+		},
+		{
+			name:        "invalid scoring expression",
+			placement:   withScoringExpression(newPlacement("test", "test-location", ""), "name"),
+			location:    newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{newSyncTarget("c1", true)},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -160,6 +205,11 @@ func newPlacement(name, location, synctarget string) *schedulingv1alpha1.Placeme
 	return placement
 }
 
+func withScoringExpression(placement *schedulingv1alpha1.Placement, expression string) *schedulingv1alpha1.Placement {
+	placement.Spec.ScoringExpression = expression
+	return placement
+}
+
 func newLocation(name string) *schedulingv1alpha1.Location {
 	return &schedulingv1alpha1.Location{
 		ObjectMeta: metav1.ObjectMeta{
@@ -184,3 +234,13 @@ func newSyncTarget(name string, ready bool) *workloadv1alpha1.SyncTarget {
 
 	return syncTarget
 }
+
+func withScores(syncTarget *workloadv1alpha1.SyncTarget, scores map[string]string) *workloadv1alpha1.SyncTarget {
+	if syncTarget.Annotations == nil {
+		syncTarget.Annotations = map[string]string{}
+	}
+	for name, value := range scores {
+		syncTarget.Annotations[workloadv1alpha1.SyncTargetScoreAnnotationPrefix+name] = value
+	}
+	return syncTarget
+}
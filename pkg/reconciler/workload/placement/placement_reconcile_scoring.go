@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// placementScoringReconciler reports in the ScoringExpressionValid condition whether the
+// scoringExpression of a placement compiles.
+type placementScoringReconciler struct {
+	patchPlacement func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*schedulingv1alpha1.Placement, error)
+}
+
+func (r *placementScoringReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
+	updated := placement.DeepCopy()
+	if placement.Spec.ScoringExpression == "" {
+		conditions.Delete(updated, schedulingv1alpha1.ScoringExpressionValid)
+	} else if _, err := CompileScoringExpression(placement.Spec.ScoringExpression); err != nil {
+		conditions.MarkFalse(updated, schedulingv1alpha1.ScoringExpressionValid, schedulingv1alpha1.ScoringExpressionInvalidReason, conditionsv1alpha1.ConditionSeverityError, err.Error())
+	} else {
+		conditions.MarkTrue(updated, schedulingv1alpha1.ScoringExpressionValid)
+	}
+
+	if equality.Semantic.DeepEqual(placement.Status, updated.Status) {
+		return reconcileStatusContinue, placement, nil
+	}
+
+	patched, err := r.patchPlacementStatus(ctx, placement, updated)
+	if err != nil {
+		return reconcileStatusStop, placement, err
+	}
+	return reconcileStatusContinue, patched, nil
+}
+
+func (r *placementScoringReconciler) patchPlacementStatus(ctx context.Context, old, obj *schedulingv1alpha1.Placement) (*schedulingv1alpha1.Placement, error) {
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(old)
+
+	oldData, err := json.Marshal(schedulingv1alpha1.Placement{
+		Status: old.Status,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to Marshal old data for placement %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	newData, err := json.Marshal(schedulingv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to Marshal new data for placement %s|%s: %w", clusterName, old.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create patch for placement %s|%s: %w", clusterName, old.Name, err)
+	}
+	logger.WithValues("patch", string(patchBytes)).V(3).Info("patching Placement to update ScoringExpressionValid condition")
+	return r.patchPlacement(ctx, clusterName, old.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+}
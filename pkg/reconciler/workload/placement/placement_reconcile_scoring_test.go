@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+func TestScoringReconcile(t *testing.T) {
+	withCondition := func(placement *schedulingv1alpha1.Placement, valid bool) *schedulingv1alpha1.Placement {
+		if valid {
+			conditions.MarkTrue(placement, schedulingv1alpha1.ScoringExpressionValid)
+		} else {
+			conditions.MarkFalse(placement, schedulingv1alpha1.ScoringExpressionValid, schedulingv1alpha1.ScoringExpressionInvalidReason, conditionsv1alpha1.ConditionSeverityError, "invalid")
+		}
+		return placement
+	}
+
+	tests := map[string]struct {
+		placement *schedulingv1alpha1.Placement
+
+		wantPatch  bool
+		wantStatus corev1.ConditionStatus
+		wantReason string
+	}{
+		"no scoring expression": {
+			placement: newPlacement("test", "test-location", ""),
+		},
+		"removes condition without scoring expression": {
+			placement: withCondition(newPlacement("test", "test-location", ""), false),
+			wantPatch: true,
+		},
+		"valid scoring expression": {
+			placement:  withScoringExpression(newPlacement("test", "test-location", ""), "scores['cost']"),
+			wantPatch:  true,
+			wantStatus: corev1.ConditionTrue,
+		},
+		"valid scoring expression already reported": {
+			placement:  withCondition(withScoringExpression(newPlacement("test", "test-location", ""), "scores['cost']"), true),
+			wantStatus: corev1.ConditionTrue,
+		},
+		"scoring expression not evaluating to a number": {
+			placement:  withScoringExpression(newPlacement("test", "test-location", ""), "name"),
+			wantPatch:  true,
+			wantStatus: corev1.ConditionFalse,
+			wantReason: schedulingv1alpha1.ScoringExpressionInvalidReason,
+		},
+		"scoring expression not compiling": {
+			placement:  withCondition(withScoringExpression(newPlacement("test", "test-location", ""), "scores["), true),
+			wantPatch:  true,
+			wantStatus: corev1.ConditionFalse,
+			wantReason: schedulingv1alpha1.ScoringExpressionInvalidReason,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var patched bool
+			r := &placementScoringReconciler{
+				patchPlacement: func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*schedulingv1alpha1.Placement, error) {
+					patched = true
+					require.Equal(t, []string{"status"}, subresources)
+					oldData, err := json.Marshal(tc.placement)
+					require.NoError(t, err)
+					updatedData, err := jsonpatch.MergePatch(oldData, data)
+					require.NoError(t, err)
+					var updated schedulingv1alpha1.Placement
+					require.NoError(t, json.Unmarshal(updatedData, &updated))
+					return &updated, nil
+				},
+			}
+
+			status, updated, err := r.reconcile(context.Background(), tc.placement)
+			require.NoError(t, err)
+			require.Equal(t, reconcileStatusContinue, status)
+			require.Equal(t, tc.wantPatch, patched)
+
+			cond := conditions.Get(updated, schedulingv1alpha1.ScoringExpressionValid)
+			if tc.wantStatus == "" {
+				require.Nil(t, cond)
+				return
+			}
+			require.NotNil(t, cond)
+			require.Equal(t, tc.wantStatus, cond.Status)
+			require.Equal(t, tc.wantReason, cond.Reason)
+		})
+	}
+}
+
+func TestScoringCostLimit(t *testing.T) {
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = fmt.Sprintf("%d", i)
+	}
+	list := "[" + strings.Join(items, ",") + "]"
+
+	program, err := CompileScoringExpression(fmt.Sprintf("size(%s.filter(x, %s.exists(y, y == -1)))", list, list))
+	require.NoError(t, err)
+
+	_, err = score(program, newSyncTarget("c1", true))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cost limit exceeded")
+}
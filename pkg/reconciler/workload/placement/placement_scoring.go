@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// scoringCostLimit is the maximal runtime cost of evaluating the scoringExpression of a placement
+// for one SyncTarget, as for the CEL validation rules of CRDs.
+const scoringCostLimit uint64 = 1000000
+
+// scoringEnv is the CEL environment of the scoringExpression of a placement.
+var scoringEnv = func() *cel.Env {
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewVar("scores", decls.NewMapType(decls.String, decls.Double)),
+		decls.NewVar("labels", decls.NewMapType(decls.String, decls.String)),
+		decls.NewVar("name", decls.String),
+	))
+	if err != nil {
+		panic(err)
+	}
+	return env
+}()
+
+// CompileScoringExpression compiles the scoring expression of a placement, checking that it
+// evaluates to a number. The evaluation of the program is limited by scoringCostLimit.
+func CompileScoringExpression(expression string) (cel.Program, error) {
+	ast, issues := scoringEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid scoringExpression: %w", issues.Err())
+	}
+
+	resultType := ast.ResultType()
+	numeric := false
+	for _, t := range []*exprpb.Type{decls.Double, decls.Int, decls.Uint, decls.Dyn} {
+		if proto.Equal(resultType, t) {
+			numeric = true
+			break
+		}
+	}
+	if !numeric {
+		return nil, fmt.Errorf("invalid scoringExpression: must evaluate to a number")
+	}
+
+	return scoringEnv.Program(ast, cel.CostLimit(scoringCostLimit))
+}
+
+// syncTargetScores returns the scores advertised by the score.workload.kcp.dev/<name> annotations
+// of the SyncTarget. Annotations with values that are not numbers are ignored.
+func syncTargetScores(syncTarget *workloadv1alpha1.SyncTarget) map[string]float64 {
+	scores := map[string]float64{}
+	for k, v := range syncTarget.Annotations {
+		if !strings.HasPrefix(k, workloadv1alpha1.SyncTargetScoreAnnotationPrefix) {
+			continue
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			continue
+		}
+		scores[strings.TrimPrefix(k, workloadv1alpha1.SyncTargetScoreAnnotationPrefix)] = value
+	}
+	return scores
+}
+
+// score evaluates the scoring program for the SyncTarget.
+func score(program cel.Program, syncTarget *workloadv1alpha1.SyncTarget) (float64, error) {
+	labels := syncTarget.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	out, _, err := program.Eval(map[string]interface{}{
+		"scores": syncTargetScores(syncTarget),
+		"labels": labels,
+		"name":   syncTarget.Name,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := out.(type) {
+	case types.Double:
+		return float64(v), nil
+	case types.Int:
+		return float64(v), nil
+	case types.Uint:
+		return float64(v), nil
+	default:
+		return 0, fmt.Errorf("scoringExpression evaluated to %s, not a number", out.Type().TypeName())
+	}
+}
+
+// bestScoredSyncTarget returns the SyncTarget with the highest score, ties broken by name, or nil if
+// the expression cannot be evaluated for any of the SyncTargets.
+func bestScoredSyncTarget(program cel.Program, syncTargets []*workloadv1alpha1.SyncTarget) *workloadv1alpha1.SyncTarget {
+	sorted := make([]*workloadv1alpha1.SyncTarget, len(syncTargets))
+	copy(sorted, syncTargets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var best *workloadv1alpha1.SyncTarget
+	var bestScore float64
+	for _, syncTarget := range sorted {
+		s, err := score(program, syncTarget)
+		if err != nil {
+			continue
+		}
+		if best == nil || s > bestScore {
+			best = syncTarget
+			bestScore = s
+		}
+	}
+	return best
+}
@@ -25,8 +25,8 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -35,8 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
-	coreinformers "k8s.io/client-go/informers/core/v1"
-	corelisters "k8s.io/client-go/listers/core/v1"
+	kubernetesinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/client-go/util/workqueue"
@@ -49,19 +48,28 @@ import (
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	syncershared "github.com/kcp-dev/kcp/pkg/syncer/shared"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const controllerName = "kcp-workload-resource-scheduler"
 
 // NewController returns a new Controller which schedules resources in scheduled namespaces.
+// The controller only looks at the labels and annotations of namespaces, hence namespaceInformer
+// is expected to be a metadata-only informer, see informer.MetadataSharedInformerFactory.
 func NewController(
 	dynamicClusterClient dynamic.Interface,
 	ddsif *informer.DynamicDiscoverySharedInformerFactory,
 	syncTargetInformer workloadinformers.SyncTargetInformer,
-	namespaceInformer coreinformers.NamespaceInformer,
+	namespaceInformer kubernetesinformers.GenericInformer,
 ) (*Controller, error) {
-	resourceQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-resource")
-	gvrQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-gvr")
+	// Every object of every informed resource passes through this queue, so back off from new adds during storms,
+	// e.g. when a namespace with many objects gets rescheduled.
+	resourceQueue := kcpworkqueue.NewNamedRateLimitingQueueWithBackpressure(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-resource", kcpworkqueue.Backpressure{
+		Threshold: 10000,
+		BaseDelay: time.Second,
+		MaxDelay:  time.Minute,
+	})
+	gvrQueue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-gvr")
 
 	c := &Controller{
 		resourceQueue: resourceQueue,
@@ -82,10 +90,18 @@ func NewController(
 		Handler: cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) { c.enqueueNamespace(obj) },
 			UpdateFunc: func(old, obj interface{}) {
-				oldNS := old.(*corev1.Namespace)
-				newNS := obj.(*corev1.Namespace)
-				if !reflect.DeepEqual(scheduleStateLabels(oldNS.Labels), scheduleStateLabels(newNS.Labels)) ||
-					!reflect.DeepEqual(scheduleStateAnnotations(oldNS.Annotations), scheduleStateAnnotations(newNS.Annotations)) {
+				oldNS, err := meta.Accessor(old)
+				if err != nil {
+					runtime.HandleError(err)
+					return
+				}
+				newNS, err := meta.Accessor(obj)
+				if err != nil {
+					runtime.HandleError(err)
+					return
+				}
+				if !reflect.DeepEqual(scheduleStateLabels(oldNS.GetLabels()), scheduleStateLabels(newNS.GetLabels())) ||
+					!reflect.DeepEqual(scheduleStateAnnotations(oldNS.GetAnnotations()), scheduleStateAnnotations(newNS.GetAnnotations())) {
 					c.enqueueNamespace(obj)
 				}
 
@@ -135,7 +151,7 @@ type Controller struct {
 
 	dynClusterClient dynamic.Interface
 
-	namespaceLister corelisters.NamespaceLister
+	namespaceLister cache.GenericLister
 
 	syncTargetLister  workloadlisters.SyncTargetLister
 	syncTargetIndexer cache.Indexer
@@ -181,13 +197,26 @@ func (c *Controller) enqueueGVR(gvr schema.GroupVersionResource) {
 	c.gvrQueue.Add(queueKey)
 }
 
+// getNamespace returns the partial object metadata of the namespace with the given key.
+func (c *Controller) getNamespace(key string) (*unstructured.Unstructured, error) {
+	obj, err := c.namespaceLister.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("expected *unstructured.Unstructured for namespace %q, got %T", key, obj)
+	}
+	return ns, nil
+}
+
 func (c *Controller) enqueueNamespace(obj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
 		return
 	}
-	ns, err := c.namespaceLister.Get(key)
+	ns, err := c.getNamespace(key)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Namespace was deleted
@@ -321,21 +350,21 @@ var namespaceBlocklist = sets.NewString("kube-system", "kube-public", "kube-node
 
 // enqueueResourcesForNamespace adds the resources contained by the given
 // namespace to the queue if there scheduling label differs from the namespace's.
-func (c *Controller) enqueueResourcesForNamespace(ns *corev1.Namespace) error {
+func (c *Controller) enqueueResourcesForNamespace(ns *unstructured.Unstructured) error {
 	logger := logging.WithObject(logging.WithReconciler(klog.Background(), controllerName), ns).WithValues("operation", "enqueueResourcesForNamespace")
 	clusterName := logicalcluster.From(ns)
 
-	nsLocations, nsDeleting := locations(ns.Annotations, ns.Labels, true)
+	nsLocations, nsDeleting := locations(ns.GetAnnotations(), ns.GetLabels(), true)
 	logger = logger.WithValues("nsLocations", nsLocations.List())
 
 	logger.V(4).Info("getting listers")
-	listers, notSynced := c.ddsif.Listers()
+	listers, notSynced := c.ddsif.ClusterListers(clusterName)
 	var errs []error
 	for gvr, lister := range listers {
 		logger = logger.WithValues("gvr", gvr.String())
-		objs, err := lister.ByNamespace(ns.Name).List(labels.Everything())
+		objs, err := lister.ByNamespace(ns.GetName()).List(labels.Everything())
 		if err != nil {
-			errs = append(errs, fmt.Errorf("error listing %q in %s|%s: %w", gvr, clusterName, ns.Name, err))
+			errs = append(errs, fmt.Errorf("error listing %q in %s|%s: %w", gvr, clusterName, ns.GetName(), err))
 			continue
 		}
 
@@ -345,11 +374,6 @@ func (c *Controller) enqueueResourcesForNamespace(ns *corev1.Namespace) error {
 		for _, obj := range objs {
 			u := obj.(*unstructured.Unstructured)
 
-			// TODO(ncdc): remove this when we have namespaced listers that only return for the scoped cluster (https://github.com/kcp-dev/kcp/issues/685).
-			if logicalcluster.From(u) != clusterName {
-				continue
-			}
-
 			objLocations, objDeleting := locations(u.GetAnnotations(), u.GetLabels(), false)
 			logger := logging.WithObject(logger, u).WithValues("gvk", gvr.GroupVersion().WithKind(u.GetKind()))
 			if !objLocations.Equal(nsLocations) || !objDeleting.Equal(nsDeleting) {
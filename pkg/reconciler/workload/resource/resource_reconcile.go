@@ -26,7 +26,6 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -63,7 +62,7 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 	// Align the resource's assigned cluster with the namespace's assigned
 	// cluster.
 	// First, get the namespace object (from the cached lister).
-	ns, err := c.namespaceLister.Get(clusters.ToClusterAwareKey(lclusterName, obj.GetNamespace()))
+	ns, err := c.getNamespace(clusters.ToClusterAwareKey(lclusterName, obj.GetNamespace()))
 	if apierrors.IsNotFound(err) {
 		// Namespace was deleted; this resource will eventually get deleted too, so ignore
 		return nil
@@ -140,7 +139,7 @@ func (c *Controller) reconcileResource(ctx context.Context, lclusterName logical
 	}
 
 	logger.WithValues("patch", string(patchBytes)).V(2).Info("patching resource")
-	if _, err := c.dynClusterClient.Resource(*gvr).Namespace(ns.Name).
+	if _, err := c.dynClusterClient.Resource(*gvr).Namespace(ns.GetName()).
 		Patch(logicalcluster.WithCluster(ctx, lclusterName), obj.GetName(), types.MergePatchType, patchBytes, metav1.PatchOptions{}); err != nil {
 		return err
 	}
@@ -164,8 +163,8 @@ func propagateDeletionTimestamp(logger logr.Logger, obj metav1.Object, annotatio
 }
 
 // computePlacement computes the patch against annotations and labels. Nil means to remove the key.
-func computePlacement(ns *corev1.Namespace, obj metav1.Object) (annotationPatch map[string]interface{}, labelPatch map[string]interface{}) {
-	nsLocations, nsDeleting := locations(ns.Annotations, ns.Labels, true)
+func computePlacement(ns metav1.Object, obj metav1.Object) (annotationPatch map[string]interface{}, labelPatch map[string]interface{}) {
+	nsLocations, nsDeleting := locations(ns.GetAnnotations(), ns.GetLabels(), true)
 	objLocations, objDeleting := locations(obj.GetAnnotations(), obj.GetLabels(), false)
 	if objLocations.Equal(nsLocations) && objDeleting.Equal(nsDeleting) {
 		// already correctly assigned.
@@ -199,7 +198,7 @@ func computePlacement(ns *corev1.Namespace, obj metav1.Object) (annotationPatch
 		}
 	}
 	for _, loc := range nsLocations.Intersection(nsLocations).List() {
-		if nsTimestamp, found := ns.Annotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc]; found && validRFC3339(nsTimestamp) {
+		if nsTimestamp, found := ns.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc]; found && validRFC3339(nsTimestamp) {
 			objTimestamp, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc]
 			if !found || !validRFC3339(objTimestamp) {
 				annotationPatch[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+loc] = nsTimestamp
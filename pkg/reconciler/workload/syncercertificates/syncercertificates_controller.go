@@ -0,0 +1,258 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncercertificates
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certificatesinformers "k8s.io/client-go/informers/certificates/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/controller/certificates/authority"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-syncer-certificates"
+)
+
+// NewController returns a new controller that approves and signs the CertificateSigningRequests of
+// syncers for client certificates, and deletes them when their SyncTarget is deleted.
+//
+// A request is approved if its requester is allowed to sync the SyncTarget named in the requested
+// certificate. Certificates are signed by the given certificate authority, and are valid for at
+// most the given duration.
+func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	csrInformer certificatesinformers.CertificateSigningRequestInformer,
+	syncTargetInformer workloadinformers.SyncTargetInformer,
+	ca *authority.CertificateAuthority,
+	duration time.Duration,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:    queue,
+		duration: duration,
+		now:      time.Now,
+		getCSR: func(clusterName logicalcluster.Name, name string) (*certificatesv1.CertificateSigningRequest, error) {
+			return csrInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		getSyncTarget: func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error) {
+			return syncTargetInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		listCSRsForSyncTarget: func(clusterName logicalcluster.Name, syncTargetName string) ([]*certificatesv1.CertificateSigningRequest, error) {
+			key := workloadv1alpha1.ToSyncTargetKey(clusterName, syncTargetName)
+			return indexers.ByIndex[*certificatesv1.CertificateSigningRequest](csrInformer.Informer().GetIndexer(), indexers.CertificateSigningRequestsBySyncTargetKey, key)
+		},
+		canSync: func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest, syncTargetName string) (bool, error) {
+			extra := make(map[string]authorizationv1.ExtraValue, len(csr.Spec.Extra))
+			for k, v := range csr.Spec.Extra {
+				extra[k] = authorizationv1.ExtraValue(v)
+			}
+			sar, err := kubeClusterClient.Cluster(clusterName).AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					User:   csr.Spec.Username,
+					UID:    csr.Spec.UID,
+					Groups: csr.Spec.Groups,
+					Extra:  extra,
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Verb:     "sync",
+						Group:    workloadv1alpha1.SchemeGroupVersion.Group,
+						Version:  workloadv1alpha1.SchemeGroupVersion.Version,
+						Resource: "synctargets",
+						Name:     syncTargetName,
+					},
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return false, err
+			}
+			return sar.Status.Allowed, nil
+		},
+		sign: func(request []byte, usages []certificatesv1.KeyUsage, ttl time.Duration) ([]byte, error) {
+			return ca.Sign(request, authority.PermissiveSigningPolicy{
+				TTL:      ttl,
+				Usages:   usages,
+				Backdate: 5 * time.Minute,
+				Short:    8 * time.Hour,
+			})
+		},
+		updateApproval: func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest) error {
+			_, err := kubeClusterClient.Cluster(clusterName).CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+			return err
+		},
+		updateStatus: func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest) error {
+			_, err := kubeClusterClient.Cluster(clusterName).CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, csr, metav1.UpdateOptions{})
+			return err
+		},
+		deleteCSR: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+			return kubeClusterClient.Cluster(clusterName).CertificatesV1().CertificateSigningRequests().Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	}
+
+	indexers.AddOrDie(csrInformer.Informer().GetIndexer(), indexers.CertificateSigningRequestsBySyncTargetKey)
+
+	csrInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
+			return ok && csr.Spec.SignerName == workloadv1alpha1.SyncerSignerName
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.enqueueCSR(obj)
+			},
+			UpdateFunc: func(_, newObj interface{}) {
+				c.enqueueCSR(newObj)
+			},
+		},
+	})
+
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueSyncTarget(obj)
+		},
+	})
+
+	return c, nil
+}
+
+// controller approves and signs syncer CertificateSigningRequests.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	duration time.Duration
+	now      func() time.Time
+
+	getCSR                func(clusterName logicalcluster.Name, name string) (*certificatesv1.CertificateSigningRequest, error)
+	getSyncTarget         func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error)
+	listCSRsForSyncTarget func(clusterName logicalcluster.Name, syncTargetName string) ([]*certificatesv1.CertificateSigningRequest, error)
+
+	canSync func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest, syncTargetName string) (bool, error)
+	sign    func(request []byte, usages []certificatesv1.KeyUsage, ttl time.Duration) ([]byte, error)
+
+	updateApproval func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest) error
+	updateStatus   func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest) error
+	deleteCSR      func(ctx context.Context, clusterName logicalcluster.Name, name string) error
+}
+
+// enqueueCSR enqueues a CertificateSigningRequest.
+func (c *controller) enqueueCSR(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing CertificateSigningRequest")
+	c.queue.Add(key)
+}
+
+// enqueueSyncTarget enqueues the CertificateSigningRequests of a deleted SyncTarget.
+func (c *controller) enqueueSyncTarget(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	syncTarget, ok := obj.(*workloadv1alpha1.SyncTarget)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a SyncTarget, but is %T", obj))
+		return
+	}
+
+	csrs, err := c.listCSRsForSyncTarget(logicalcluster.From(syncTarget), syncTarget.Name)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, csr := range csrs {
+		c.enqueueCSR(csr)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	requeueAfter, err := c.process(ctx, key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) (time.Duration, error) {
+	clusterName, name := clusters.SplitClusterAwareKey(key)
+	return c.reconcile(ctx, clusterName, name)
+}
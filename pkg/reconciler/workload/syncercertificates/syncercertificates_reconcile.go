@@ -0,0 +1,211 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncercertificates
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+var allowedUsages = sets.NewString(
+	string(certificatesv1.UsageDigitalSignature),
+	string(certificatesv1.UsageKeyEncipherment),
+	string(certificatesv1.UsageClientAuth),
+)
+
+// reconcile approves and signs the syncer CertificateSigningRequest with the given name, and deletes
+// it when its SyncTarget is gone or its certificate has expired. It returns after which duration the
+// request has to be reconciled again, if at all.
+func (c *controller) reconcile(ctx context.Context, clusterName logicalcluster.Name, name string) (time.Duration, error) {
+	logger := klog.FromContext(ctx)
+
+	csr, err := c.getCSR(clusterName, name)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if csr.Spec.SignerName != workloadv1alpha1.SyncerSignerName {
+		return 0, nil
+	}
+
+	request, syncTargetName, err := validateRequest(clusterName, csr)
+	if err != nil {
+		if isApproved(csr) || hasCondition(csr, certificatesv1.CertificateDenied) || hasCondition(csr, certificatesv1.CertificateFailed) {
+			return 0, nil
+		}
+		logger.V(2).Info("denying invalid syncer CertificateSigningRequest", "reason", err.Error())
+		denied := csr.DeepCopy()
+		denied.Status.Conditions = append(denied.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:           certificatesv1.CertificateDenied,
+			Status:         corev1.ConditionTrue,
+			Reason:         "InvalidRequest",
+			Message:        err.Error(),
+			LastUpdateTime: metav1.NewTime(c.now()),
+		})
+		return 0, c.updateApproval(ctx, clusterName, denied)
+	}
+
+	syncTarget, err := c.getSyncTarget(clusterName, syncTargetName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return 0, err
+	}
+	if syncTarget == nil || string(syncTarget.UID) != request.Subject.OrganizationalUnit[0] {
+		logger.V(2).Info("deleting syncer CertificateSigningRequest of deleted SyncTarget", "syncTarget", syncTargetName)
+		if err := c.deleteCSR(ctx, clusterName, name); err != nil && !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	if len(csr.Status.Certificate) > 0 {
+		cert, err := parseCertificate(csr.Status.Certificate)
+		if err != nil {
+			// retrying does not help with an unparsable certificate
+			return 0, nil // nolint:nilerr
+		}
+		if now := c.now(); now.Before(cert.NotAfter) {
+			return cert.NotAfter.Sub(now), nil
+		}
+		logger.V(2).Info("deleting syncer CertificateSigningRequest with expired certificate")
+		if err := c.deleteCSR(ctx, clusterName, name); err != nil && !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	if hasCondition(csr, certificatesv1.CertificateDenied) || hasCondition(csr, certificatesv1.CertificateFailed) {
+		return 0, nil
+	}
+
+	if !isApproved(csr) {
+		allowed, err := c.canSync(ctx, clusterName, csr, syncTargetName)
+		if err != nil {
+			return 0, err
+		}
+		if !allowed {
+			// leave the decision to other approvers
+			return 0, nil
+		}
+		logger.V(2).Info("approving syncer CertificateSigningRequest", "syncTarget", syncTargetName)
+		approved := csr.DeepCopy()
+		approved.Status.Conditions = append(approved.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:           certificatesv1.CertificateApproved,
+			Status:         corev1.ConditionTrue,
+			Reason:         "AutoApproved",
+			Message:        "Auto approving syncer client certificate of a requester allowed to sync the SyncTarget.",
+			LastUpdateTime: metav1.NewTime(c.now()),
+		})
+		return 0, c.updateApproval(ctx, clusterName, approved)
+	}
+
+	ttl := c.duration
+	if csr.Spec.ExpirationSeconds != nil {
+		if requested := time.Duration(*csr.Spec.ExpirationSeconds) * time.Second; requested < ttl {
+			ttl = requested
+		}
+	}
+	der, err := c.sign(request.Raw, csr.Spec.Usages, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.V(2).Info("signing syncer CertificateSigningRequest", "syncTarget", syncTargetName)
+	signed := csr.DeepCopy()
+	signed.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return 0, c.updateStatus(ctx, clusterName, signed)
+}
+
+// validateRequest returns the parsed certificate request and the name of the SyncTarget of a syncer
+// CertificateSigningRequest in the given logical cluster, or an error why it must not be signed.
+func validateRequest(clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest) (*x509.CertificateRequest, string, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, "", errors.New("request does not contain a PEM encoded certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse certificate request: %w", err)
+	}
+
+	syncTargetWorkspace, syncTargetName, ok := workloadv1alpha1.ParseSyncerCertificateUserName(request.Subject.CommonName)
+	if !ok {
+		return nil, "", fmt.Errorf("common name must be of the form %s<workspace>:<synctarget>", workloadv1alpha1.SyncerCertificateUserNamePrefix)
+	}
+	if syncTargetWorkspace != clusterName {
+		return nil, "", fmt.Errorf("certificate must be requested in workspace %s of the SyncTarget", syncTargetWorkspace)
+	}
+	if len(request.Subject.OrganizationalUnit) != 1 {
+		return nil, "", errors.New("organizational unit must be the UID of the SyncTarget")
+	}
+	if len(request.Subject.Organization) > 1 || (len(request.Subject.Organization) == 1 && request.Subject.Organization[0] != workloadv1alpha1.SyncerCertificateGroup) {
+		return nil, "", fmt.Errorf("organization must be empty or %s", workloadv1alpha1.SyncerCertificateGroup)
+	}
+	if len(request.DNSNames) > 0 || len(request.EmailAddresses) > 0 || len(request.IPAddresses) > 0 || len(request.URIs) > 0 {
+		return nil, "", errors.New("subject alternative names are not allowed")
+	}
+
+	usages := sets.NewString()
+	for _, usage := range csr.Spec.Usages {
+		usages.Insert(string(usage))
+	}
+	if !usages.Has(string(certificatesv1.UsageClientAuth)) {
+		return nil, "", errors.New("usages must include client auth")
+	}
+	if disallowed := usages.Difference(allowedUsages); disallowed.Len() > 0 {
+		return nil, "", fmt.Errorf("usages %v are not allowed", disallowed.List())
+	}
+
+	return request, syncTargetName, nil
+}
+
+func parseCertificate(pemBytes []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func isApproved(csr *certificatesv1.CertificateSigningRequest) bool {
+	return hasCondition(csr, certificatesv1.CertificateApproved)
+}
+
+func hasCondition(csr *certificatesv1.CertificateSigningRequest, conditionType certificatesv1.RequestConditionType) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == conditionType && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
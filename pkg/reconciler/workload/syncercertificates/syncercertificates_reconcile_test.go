@@ -0,0 +1,234 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncercertificates
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	syncTarget := &workloadv1alpha1.SyncTarget{ObjectMeta: metav1.ObjectMeta{Name: "us-east1", UID: "uid-1"}}
+
+	request := func(subject pkix.Name) []byte {
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: subject}, key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	}
+	certificate := func(notAfter time.Time) []byte {
+		template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: now.Add(-time.Hour), NotAfter: notAfter}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+	syncerSubject := pkix.Name{
+		CommonName:         "system:kcp:syncer:root:org:ws:us-east1",
+		OrganizationalUnit: []string{"uid-1"},
+		Organization:       []string{workloadv1alpha1.SyncerCertificateGroup},
+	}
+	csr := func(subject pkix.Name, conditions ...certificatesv1.RequestConditionType) *certificatesv1.CertificateSigningRequest {
+		csr := &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "csr-abc"},
+			Spec: certificatesv1.CertificateSigningRequestSpec{
+				Request:    request(subject),
+				SignerName: workloadv1alpha1.SyncerSignerName,
+				Usages:     []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageClientAuth},
+				Username:   "system:serviceaccount:default:kcp-syncer-us-east1-1234abcd",
+			},
+		}
+		for _, c := range conditions {
+			csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{Type: c, Status: corev1.ConditionTrue})
+		}
+		return csr
+	}
+	withSubject := func(f func(*pkix.Name)) pkix.Name {
+		subject := syncerSubject
+		f(&subject)
+		return subject
+	}
+	issued := func(notAfter time.Time) *certificatesv1.CertificateSigningRequest {
+		csr := csr(syncerSubject, certificatesv1.CertificateApproved)
+		csr.Status.Certificate = certificate(notAfter)
+		return csr
+	}
+	otherSigner := csr(syncerSubject)
+	otherSigner.Spec.SignerName = "kubernetes.io/kube-apiserver-client"
+	serverUsage := csr(syncerSubject)
+	serverUsage.Spec.Usages = append(serverUsage.Spec.Usages, certificatesv1.UsageServerAuth)
+	shortLived := csr(syncerSubject, certificatesv1.CertificateApproved)
+	shortLived.Spec.ExpirationSeconds = func(i int32) *int32 { return &i }(3600)
+
+	tests := map[string]struct {
+		csr        *certificatesv1.CertificateSigningRequest
+		syncTarget *workloadv1alpha1.SyncTarget
+		canSync    bool
+
+		wantCondition    certificatesv1.RequestConditionType
+		wantSignedTTL    time.Duration
+		wantDeleted      bool
+		wantRequeueAfter time.Duration
+	}{
+		"other signer is ignored": {
+			csr:        otherSigner,
+			syncTarget: syncTarget,
+			canSync:    true,
+		},
+		"request of a requester allowed to sync is approved": {
+			csr:           csr(syncerSubject),
+			syncTarget:    syncTarget,
+			canSync:       true,
+			wantCondition: certificatesv1.CertificateApproved,
+		},
+		"request of a requester not allowed to sync is left alone": {
+			csr:        csr(syncerSubject),
+			syncTarget: syncTarget,
+		},
+		"approved request is signed": {
+			csr:           csr(syncerSubject, certificatesv1.CertificateApproved),
+			syncTarget:    syncTarget,
+			wantSignedTTL: 24 * time.Hour,
+		},
+		"approved request is signed with shorter requested expiration": {
+			csr:           shortLived,
+			syncTarget:    syncTarget,
+			wantSignedTTL: time.Hour,
+		},
+		"denied request is left alone": {
+			csr:        csr(syncerSubject, certificatesv1.CertificateDenied),
+			syncTarget: syncTarget,
+			canSync:    true,
+		},
+		"request for a SyncTarget in another workspace is denied": {
+			csr:           csr(withSubject(func(s *pkix.Name) { s.CommonName = "system:kcp:syncer:root:org:other:us-east1" })),
+			syncTarget:    syncTarget,
+			canSync:       true,
+			wantCondition: certificatesv1.CertificateDenied,
+		},
+		"request for other groups is denied": {
+			csr:           csr(withSubject(func(s *pkix.Name) { s.Organization = []string{"system:masters"} })),
+			syncTarget:    syncTarget,
+			canSync:       true,
+			wantCondition: certificatesv1.CertificateDenied,
+		},
+		"request for server usage is denied": {
+			csr:           serverUsage,
+			syncTarget:    syncTarget,
+			canSync:       true,
+			wantCondition: certificatesv1.CertificateDenied,
+		},
+		"request of a deleted SyncTarget is deleted": {
+			csr:         csr(syncerSubject),
+			canSync:     true,
+			wantDeleted: true,
+		},
+		"request of a recreated SyncTarget is deleted": {
+			csr:         issued(now.Add(time.Hour)),
+			syncTarget:  &workloadv1alpha1.SyncTarget{ObjectMeta: metav1.ObjectMeta{Name: "us-east1", UID: "uid-2"}},
+			wantDeleted: true,
+		},
+		"issued request is requeued until the certificate expires": {
+			csr:              issued(now.Add(time.Hour)),
+			syncTarget:       syncTarget,
+			wantRequeueAfter: time.Hour,
+		},
+		"issued request with expired certificate is deleted": {
+			csr:         issued(now.Add(-time.Minute)),
+			syncTarget:  syncTarget,
+			wantDeleted: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var approval *certificatesv1.CertificateSigningRequest
+			var signedTTL time.Duration
+			var signed, deleted bool
+			c := &controller{
+				duration: 24 * time.Hour,
+				now:      func() time.Time { return now },
+				getCSR: func(clusterName logicalcluster.Name, name string) (*certificatesv1.CertificateSigningRequest, error) {
+					require.Equal(t, "root:org:ws", clusterName.String())
+					return tc.csr, nil
+				},
+				getSyncTarget: func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error) {
+					require.Equal(t, "us-east1", name)
+					if tc.syncTarget == nil {
+						return nil, apierrors.NewNotFound(workloadv1alpha1.Resource("synctargets"), name)
+					}
+					return tc.syncTarget, nil
+				},
+				canSync: func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest, syncTargetName string) (bool, error) {
+					require.Equal(t, "us-east1", syncTargetName)
+					return tc.canSync, nil
+				},
+				sign: func(request []byte, usages []certificatesv1.KeyUsage, ttl time.Duration) ([]byte, error) {
+					signedTTL = ttl
+					return []byte("signed"), nil
+				},
+				updateApproval: func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest) error {
+					approval = csr
+					return nil
+				},
+				updateStatus: func(ctx context.Context, clusterName logicalcluster.Name, csr *certificatesv1.CertificateSigningRequest) error {
+					require.Contains(t, string(csr.Status.Certificate), "BEGIN CERTIFICATE")
+					signed = true
+					return nil
+				},
+				deleteCSR: func(ctx context.Context, clusterName logicalcluster.Name, name string) error {
+					deleted = true
+					return nil
+				},
+			}
+
+			requeueAfter, err := c.reconcile(context.Background(), logicalcluster.New("root:org:ws"), "csr-abc")
+			require.NoError(t, err)
+
+			if tc.wantCondition != "" {
+				require.NotNil(t, approval)
+				require.True(t, hasCondition(approval, tc.wantCondition))
+			} else {
+				require.Nil(t, approval)
+			}
+			require.Equal(t, tc.wantSignedTTL != 0, signed)
+			require.Equal(t, tc.wantSignedTTL, signedTTL)
+			require.Equal(t, tc.wantDeleted, deleted)
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+		})
+	}
+}
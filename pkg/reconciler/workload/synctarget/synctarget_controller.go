@@ -42,6 +42,7 @@ import (
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const controllerName = "kcp-synctarget-controller"
@@ -53,7 +54,7 @@ func NewController(
 ) *Controller {
 
 	c := &Controller{
-		queue:                workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		queue:                kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
 		kcpClusterClient:     kcpClusterClient,
 		syncTargetIndexer:    syncTargetInformer.Informer().GetIndexer(),
 		workspaceShardLister: workspaceShardInformer.Lister(),
@@ -21,29 +21,12 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/clusters"
 
-	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	reconcilerapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 )
 
-// indexAPIExportsByAPIResourceSchemasFunc is an index function that maps an APIExport to its spec.latestResourceSchemas.
-func indexAPIExportsByAPIResourceSchemas(obj interface{}) ([]string, error) {
-	apiExport, ok := obj.(*apisv1alpha1.APIExport)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj)
-	}
-
-	ret := make([]string, len(apiExport.Spec.LatestResourceSchemas))
-	for i := range apiExport.Spec.LatestResourceSchemas {
-		ret[i] = clusters.ToClusterAwareKey(logicalcluster.From(apiExport), apiExport.Spec.LatestResourceSchemas[i])
-	}
-
-	return ret, nil
-}
-
 func indexSyncTargetsByExports(obj interface{}) ([]string, error) {
 	synctarget, ok := obj.(*workloadv1alpha1.SyncTarget)
 	if !ok {
@@ -53,6 +36,8 @@ func indexSyncTargetsByExports(obj interface{}) ([]string, error) {
 	return getExportKeys(synctarget), nil
 }
 
+// getExportKeys returns the cluster-aware keys of the APIExports a SyncTarget references by workspace. Without
+// supported exports, the kubernetes export in the SyncTarget's workspace is used.
 func getExportKeys(synctarget *workloadv1alpha1.SyncTarget) []string {
 	lcluster := logicalcluster.From(synctarget)
 	if len(synctarget.Spec.SupportedAPIExports) == 0 {
@@ -74,12 +59,15 @@ func getExportKeys(synctarget *workloadv1alpha1.SyncTarget) []string {
 	return keys
 }
 
-func indexByWorksapce(obj interface{}) ([]string, error) {
-	metaObj, ok := obj.(metav1.Object)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
+// getExportIdentities returns the identity hashes of the APIExports a SyncTarget references by identity.
+func getExportIdentities(synctarget *workloadv1alpha1.SyncTarget) []string {
+	var identities []string
+	for _, export := range synctarget.Spec.SupportedAPIExports {
+		if export.Identity == nil || len(export.Identity.IdentityHash) == 0 {
+			continue
+		}
+		identities = append(identities, export.Identity.IdentityHash)
 	}
 
-	lcluster := logicalcluster.From(metaObj)
-	return []string{lcluster.String()}, nil
+	return identities
 }
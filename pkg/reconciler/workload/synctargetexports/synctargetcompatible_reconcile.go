@@ -25,8 +25,6 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/validation/field"
-	"k8s.io/client-go/tools/clusters"
 
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
@@ -34,33 +32,28 @@ import (
 	"github.com/kcp-dev/kcp/pkg/schemacompat"
 )
 
+// maxIncompatibilitiesPerResource limits the incompatibilities published for a synced resource, to
+// keep the SyncTarget small.
+const maxIncompatibilitiesPerResource = 20
+
 // apiCompatibleReconciler sets state for each synced resource based on resource schema and apiimports.
 // TODO(qiujian06) this should be done in syncer when resource schema(or crd) is exposed by syncer virtual workspace.
 type apiCompatibleReconciler struct {
-	getAPIExport           func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
-	getResourceSchema      func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error)
-	listAPIResourceImports func(clusterName logicalcluster.Name) ([]*apiresourcev1alpha1.APIResourceImport, error)
+	getAPIExport            func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
+	getAPIExportsByIdentity func(identityHash string) ([]*apisv1alpha1.APIExport, error)
+	getResourceSchema       func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error)
+	listAPIResourceImports  func(clusterName logicalcluster.Name) ([]*apiresourcev1alpha1.APIResourceImport, error)
 }
 
 func (e *apiCompatibleReconciler) reconcile(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
-	exportKeys := getExportKeys(syncTarget)
+	exports, errs := getSupportedAPIExports(syncTarget, e.getAPIExport, e.getAPIExportsByIdentity)
 
-	var errs []error
 	schemaMap := map[schema.GroupVersionResource]*apiextensionsv1.JSONSchemaProps{}
 
 	// Get json schema from all related resource schemas
-	for _, exportKey := range exportKeys {
-		exportCluster, name := clusters.SplitClusterAwareKey(exportKey)
-		export, err := e.getAPIExport(exportCluster, name)
-		if apierrors.IsNotFound(err) {
-			continue
-		}
-		if err != nil {
-			errs = append(errs, err)
-		}
-
-		for _, schemaName := range export.Spec.LatestResourceSchemas {
-			resourceSchema, err := e.getResourceSchema(exportCluster, schemaName)
+	for _, supported := range exports {
+		for _, schemaName := range supported.export.Spec.LatestResourceSchemas {
+			resourceSchema, err := e.getResourceSchema(supported.clusterName, schemaName)
 			if apierrors.IsNotFound(err) {
 				continue
 			}
@@ -104,6 +97,7 @@ func (e *apiCompatibleReconciler) reconcile(ctx context.Context, syncTarget *wor
 	}
 
 	for i, syncedRsesource := range syncTarget.Status.SyncedResources {
+		var incompatibilities []workloadv1alpha1.SchemaIncompatibility
 		for _, v := range syncedRsesource.Versions {
 			gvr := schema.GroupVersionResource{Group: syncedRsesource.Group, Resource: syncedRsesource.Resource, Version: v}
 			upstreamSchema, ok := schemaMap[gvr]
@@ -115,13 +109,24 @@ func (e *apiCompatibleReconciler) reconcile(ctx context.Context, syncTarget *wor
 			downStreamSchema, ok := apiImportMap[gvr]
 			if !ok {
 				syncTarget.Status.SyncedResources[i].State = workloadv1alpha1.ResourceSchemaIncomptibleState
+				incompatibilities = append(incompatibilities, workloadv1alpha1.SchemaIncompatibility{
+					Version: v,
+					Type:    workloadv1alpha1.SchemaIncompatibilityVersionMissing,
+					Message: "version is not served downstream",
+				})
 				continue
 			}
 
-			_, err := schemacompat.EnsureStructuralSchemaCompatibility(
-				field.NewPath(gvr.String()), upstreamSchema, downStreamSchema, false)
-			if err != nil {
+			if changes := schemacompat.CheckCompatibility(upstreamSchema, downStreamSchema); len(changes) > 0 {
 				syncTarget.Status.SyncedResources[i].State = workloadv1alpha1.ResourceSchemaIncomptibleState
+				for _, change := range changes {
+					incompatibilities = append(incompatibilities, workloadv1alpha1.SchemaIncompatibility{
+						Version: v,
+						Type:    workloadv1alpha1.SchemaIncompatibilityType(change.Type),
+						Path:    change.Path,
+						Message: change.Message,
+					})
+				}
 				continue
 			}
 
@@ -129,6 +134,14 @@ func (e *apiCompatibleReconciler) reconcile(ctx context.Context, syncTarget *wor
 			syncTarget.Status.SyncedResources[i].State = workloadv1alpha1.ResourceSchemaAcceptedState
 			break
 		}
+
+		if syncTarget.Status.SyncedResources[i].State != workloadv1alpha1.ResourceSchemaIncomptibleState {
+			incompatibilities = nil
+		}
+		if len(incompatibilities) > maxIncompatibilitiesPerResource {
+			incompatibilities = incompatibilities[:maxIncompatibilitiesPerResource]
+		}
+		syncTarget.Status.SyncedResources[i].Incompatibilities = incompatibilities
 	}
 
 	return syncTarget, errors.NewAggregate(errs)
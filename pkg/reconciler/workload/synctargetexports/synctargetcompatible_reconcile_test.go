@@ -81,7 +81,11 @@ func TestSyncTargetCompatibleReconcile(t *testing.T) {
 				}),
 			},
 			wantSyncedResources: []workloadv1alpha1.ResourceToSync{
-				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaIncomptibleState},
+				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaIncomptibleState,
+					Incompatibilities: []workloadv1alpha1.SchemaIncompatibility{
+						{Version: "v1", Type: workloadv1alpha1.SchemaIncompatibilityVersionMissing, Message: "version is not served downstream"},
+					},
+				},
 			},
 		},
 		{
@@ -91,7 +95,11 @@ func TestSyncTargetCompatibleReconcile(t *testing.T) {
 					Workspace: &apisv1alpha1.WorkspaceExportReference{ExportName: "kubernetes"},
 				}},
 				[]workloadv1alpha1.ResourceToSync{
-					{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaPendingState},
+					{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaIncomptibleState,
+						Incompatibilities: []workloadv1alpha1.SchemaIncompatibility{
+							{Version: "v1", Type: workloadv1alpha1.SchemaIncompatibilityVersionMissing, Message: "version is not served downstream"},
+						},
+					},
 				},
 			),
 			export: newAPIExport("kubernetes", []string{"apps.v1.deployment"}, ""),
@@ -135,7 +143,42 @@ func TestSyncTargetCompatibleReconcile(t *testing.T) {
 				newAPIResourceImport("apps.v1.deployment", "apps", "deployments", "v1", `{"type":"string"}`),
 			},
 			wantSyncedResources: []workloadv1alpha1.ResourceToSync{
-				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaIncomptibleState},
+				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaIncomptibleState,
+					Incompatibilities: []workloadv1alpha1.SchemaIncompatibility{
+						{Version: "v1", Type: workloadv1alpha1.SchemaIncompatibilityTypeChanged, Path: ".", Message: `type changed from "integer" to "string"`},
+					},
+				},
+			},
+		},
+		{
+			name: "APIResourceImport missing fields of APIResourceSchema",
+			syncTarget: newSyncTarget([]apisv1alpha1.ExportReference{
+				{
+					Workspace: &apisv1alpha1.WorkspaceExportReference{ExportName: "kubernetes"},
+				}},
+				[]workloadv1alpha1.ResourceToSync{
+					{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaPendingState},
+				},
+			),
+			export: newAPIExport("kubernetes", []string{"apps.v1.deployment"}, ""),
+			schemas: []*apisv1alpha1.APIResourceSchema{
+				newResourceSchema("apps.v1.deployment", "apps", "deployments", []apisv1alpha1.APIResourceVersion{
+					{
+						Name:   "v1",
+						Served: true,
+						Schema: runtime.RawExtension{Raw: []byte(`{"type":"object","properties":{"spec":{"type":"object","properties":{"replicas":{"type":"integer"},"paused":{"type":"boolean"}}}}}`)},
+					},
+				}),
+			},
+			apiResourceImport: []*apiresourcev1alpha1.APIResourceImport{
+				newAPIResourceImport("apps.v1.deployment", "apps", "deployments", "v1", `{"type":"object","properties":{"spec":{"type":"object","properties":{"replicas":{"type":"integer"}}}}}`),
+			},
+			wantSyncedResources: []workloadv1alpha1.ResourceToSync{
+				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaIncomptibleState,
+					Incompatibilities: []workloadv1alpha1.SchemaIncompatibility{
+						{Version: "v1", Type: workloadv1alpha1.SchemaIncompatibilityFieldMissing, Path: ".spec.paused", Message: "field was removed"},
+					},
+				},
 			},
 		},
 		{
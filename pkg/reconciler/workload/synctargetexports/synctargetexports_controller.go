@@ -46,14 +46,16 @@ import (
 	apiresourcelisters "github.com/kcp-dev/kcp/pkg/client/listers/apiresource/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	controllerName = "kcp-synctarget-export-controller"
 
-	indexSyncTargetsByExport           = controllerName + "ByExport"
-	indexAPIExportsByAPIResourceSchema = controllerName + "ByAPIResourceSchema"
-	indexByWorkspace                   = controllerName + "ByWorkspace" // will go away with scoping
+	// indexSyncTargetsByExport indexes SyncTargets by the APIExports they reference by workspace. It is not part of
+	// the shared indexers because it defaults to the workload kubernetes APIExport.
+	indexSyncTargetsByExport = controllerName + "ByExport"
 )
 
 // NewController returns a controller which update syncedResource in status based on supportedExports in spec
@@ -67,7 +69,7 @@ func NewController(
 ) (*Controller, error) {
 
 	c := &Controller{
-		queue:                workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		queue:                kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
 		kcpClusterClient:     kcpClusterClient,
 		syncTargetIndexer:    syncTargetInformer.Informer().GetIndexer(),
 		syncTargetLister:     syncTargetInformer.Lister(),
@@ -84,17 +86,11 @@ func NewController(
 		return nil, err
 	}
 
-	if err := apiExportInformer.Informer().AddIndexers(cache.Indexers{
-		indexAPIExportsByAPIResourceSchema: indexAPIExportsByAPIResourceSchemas,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(syncTargetInformer.Informer().GetIndexer(), indexers.SyncTargetsByExportIdentity)
 
-	if err := apiResourceImportInformer.Informer().AddIndexers(cache.Indexers{
-		indexByWorkspace: indexByWorksapce,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(apiExportInformer.Informer().GetIndexer(), indexers.APIExportByAPIResourceSchema, indexers.APIExportByIdentity)
+
+	indexers.AddOrDie(apiResourceImportInformer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	// Watch for events related to SyncTargets
 	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -179,6 +175,8 @@ func (c *Controller) enqueueAPIResourceImport(obj interface{}) {
 	c.queue.Add(key)
 }
 
+// enqueueAPIExport maps an APIExport to the SyncTargets supporting it, either by workspace or by identity, for
+// enqueuing.
 func (c *Controller) enqueueAPIExport(obj interface{}, logSuffix string) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
@@ -186,7 +184,10 @@ func (c *Controller) enqueueAPIExport(obj interface{}, logSuffix string) {
 		return
 	}
 
-	synctargets, err := c.syncTargetIndexer.ByIndex(indexSyncTargetsByExport, key)
+	synctargets, err := indexers.Referencing(obj,
+		indexers.Reference{Indexer: c.syncTargetIndexer, IndexName: indexSyncTargetsByExport, ReferencedValues: indexers.IndexByKey},
+		indexers.Reference{Indexer: c.syncTargetIndexer, IndexName: indexers.SyncTargetsByExportIdentity, ReferencedValues: indexers.IndexAPIExportByIdentity},
+	)
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -205,7 +206,9 @@ func (c *Controller) enqueueAPIResourceSchema(obj interface{}) {
 		return
 	}
 
-	apiExports, err := c.apiExportsIndexer.ByIndex(indexAPIExportsByAPIResourceSchema, key)
+	apiExports, err := indexers.Referencing(obj,
+		indexers.Reference{Indexer: c.apiExportsIndexer, IndexName: indexers.APIExportByAPIResourceSchema, ReferencedValues: indexers.IndexByKey},
+	)
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -272,8 +275,9 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	currentSyncTarget := syncTarget.DeepCopy()
 
 	exportReconciler := &exportReconciler{
-		getAPIExport:      c.getAPIExport,
-		getResourceSchema: c.getResourceSchema,
+		getAPIExport:            c.getAPIExport,
+		getAPIExportsByIdentity: c.getAPIExportsByIdentity,
+		getResourceSchema:       c.getResourceSchema,
 	}
 	currentSyncTarget, err = exportReconciler.reconcile(ctx, currentSyncTarget)
 	if err != nil {
@@ -281,9 +285,10 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	}
 
 	apiCompatibleReconciler := &apiCompatibleReconciler{
-		getAPIExport:           c.getAPIExport,
-		getResourceSchema:      c.getResourceSchema,
-		listAPIResourceImports: c.listAPIResourceImports,
+		getAPIExport:            c.getAPIExport,
+		getAPIExportsByIdentity: c.getAPIExportsByIdentity,
+		getResourceSchema:       c.getResourceSchema,
+		listAPIResourceImports:  c.listAPIResourceImports,
 	}
 	currentSyncTarget, err = apiCompatibleReconciler.reconcile(ctx, currentSyncTarget)
 	if err != nil {
@@ -341,13 +346,17 @@ func (c *Controller) getAPIExport(clusterName logicalcluster.Name, name string)
 	return c.apiExportLister.Get(key)
 }
 
+func (c *Controller) getAPIExportsByIdentity(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+	return indexers.ByIndex[*apisv1alpha1.APIExport](c.apiExportsIndexer, indexers.APIExportByIdentity, identityHash)
+}
+
 func (c *Controller) getResourceSchema(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
 	key := clusters.ToClusterAwareKey(clusterName, name)
 	return c.resourceSchemaLister.Get(key)
 }
 
 func (c *Controller) listAPIResourceImports(clusterName logicalcluster.Name) ([]*apiresourcev1alpha1.APIResourceImport, error) {
-	items, err := c.apiImportIndexer.ByIndex(indexByWorkspace, clusterName.String())
+	items, err := c.apiImportIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
@@ -33,27 +33,19 @@ import (
 
 // exportReconciler updates syncedResource in SyncTarget status based on supporteAPIExports.
 type exportReconciler struct {
-	getAPIExport      func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
-	getResourceSchema func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error)
+	getAPIExport            func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error)
+	getAPIExportsByIdentity func(identityHash string) ([]*apisv1alpha1.APIExport, error)
+	getResourceSchema       func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error)
 }
 
 func (e *exportReconciler) reconcile(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, error) {
-	exportKeys := getExportKeys(syncTarget)
+	exports, errs := getSupportedAPIExports(syncTarget, e.getAPIExport, e.getAPIExportsByIdentity)
 
-	var errs []error
 	var syncedResources []workloadv1alpha1.ResourceToSync
-	for _, exportKey := range exportKeys {
-		exportCluster, name := clusters.SplitClusterAwareKey(exportKey)
-		export, err := e.getAPIExport(exportCluster, name)
-		if apierrors.IsNotFound(err) {
-			continue
-		}
-		if err != nil {
-			errs = append(errs, err)
-		}
-
+	for _, supported := range exports {
+		export := supported.export
 		for _, schema := range export.Spec.LatestResourceSchemas {
-			syncedResource, err := e.convertSchemaToSyncedResource(exportCluster, schema, export.Status.IdentityHash)
+			syncedResource, err := e.convertSchemaToSyncedResource(supported.clusterName, schema, export.Status.IdentityHash)
 			if err != nil {
 				klog.Warningf("cannot get schema: %v", err)
 				continue
@@ -112,3 +104,45 @@ func (e *exportReconciler) convertSchemaToSyncedResource(cluterName logicalclust
 
 	return syncedResource, nil
 }
+
+// supportedAPIExport is an APIExport supported by a SyncTarget, together with the workspace it lives in.
+type supportedAPIExport struct {
+	clusterName logicalcluster.Name
+	export      *apisv1alpha1.APIExport
+}
+
+// getSupportedAPIExports resolves the APIExports a SyncTarget supports, referenced either by workspace or by
+// identity. APIExports that do not exist are skipped.
+func getSupportedAPIExports(
+	syncTarget *workloadv1alpha1.SyncTarget,
+	getAPIExport func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error),
+	getAPIExportsByIdentity func(identityHash string) ([]*apisv1alpha1.APIExport, error),
+) ([]supportedAPIExport, []error) {
+	var errs []error
+	var exports []supportedAPIExport
+	for _, exportKey := range getExportKeys(syncTarget) {
+		exportCluster, name := clusters.SplitClusterAwareKey(exportKey)
+		export, err := getAPIExport(exportCluster, name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		exports = append(exports, supportedAPIExport{clusterName: exportCluster, export: export})
+	}
+
+	for _, identityHash := range getExportIdentities(syncTarget) {
+		identityExports, err := getAPIExportsByIdentity(identityHash)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, export := range identityExports {
+			exports = append(exports, supportedAPIExport{clusterName: logicalcluster.From(export), export: export})
+		}
+	}
+
+	return exports, errs
+}
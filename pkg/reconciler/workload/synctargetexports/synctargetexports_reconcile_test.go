@@ -117,6 +117,22 @@ func TestSyncTargetExportReconcile(t *testing.T) {
 				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1", "v1beta1"}},
 			},
 		},
+		{
+			name: "export referenced by identity",
+			syncTarget: newSyncTarget([]apisv1alpha1.ExportReference{
+				{
+					Identity: &apisv1alpha1.IdentityExportReference{IdentityHash: "abc"},
+				}},
+				nil,
+			),
+			export: newAPIExport("kubernetes", []string{"v1.service"}, "abc"),
+			schemas: []*apisv1alpha1.APIResourceSchema{
+				newResourceSchema("v1.service", "", "services", []apisv1alpha1.APIResourceVersion{{Name: "v1", Served: true}}),
+			},
+			wantSyncedResources: []workloadv1alpha1.ResourceToSync{
+				{GroupResource: apisv1alpha1.GroupResource{Group: "", Resource: "services"}, Versions: []string{"v1"}, IdentityHash: "abc"},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -127,6 +143,12 @@ func TestSyncTargetExportReconcile(t *testing.T) {
 				}
 				return tc.export, nil
 			}
+			getAPIExportsByIdentity := func(identityHash string) ([]*apisv1alpha1.APIExport, error) {
+				if tc.export == nil || tc.export.Status.IdentityHash != identityHash {
+					return nil, nil
+				}
+				return []*apisv1alpha1.APIExport{tc.export}, nil
+			}
 			getResourceSchema := func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
 				for _, schema := range tc.schemas {
 					if schema.Name == name {
@@ -138,8 +160,9 @@ func TestSyncTargetExportReconcile(t *testing.T) {
 			}
 
 			reconciler := &exportReconciler{
-				getAPIExport:      getAPIExport,
-				getResourceSchema: getResourceSchema,
+				getAPIExport:            getAPIExport,
+				getAPIExportsByIdentity: getAPIExportsByIdentity,
+				getResourceSchema:       getResourceSchema,
 			}
 
 			updated, err := reconciler.reconcile(context.TODO(), tc.syncTarget)
@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctargetrbac
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
+)
+
+const (
+	controllerName = "kcp-synctarget-rbac"
+)
+
+// NewController returns a new controller that regenerates the rules of the ClusterRoles granting
+// syncers access to their SyncTarget, such that they only cover that SyncTarget and the
+// APIResourceImports of its current synced resources.
+func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	syncTargetInformer workloadinformers.SyncTargetInformer,
+	clusterRoleInformer rbacinformers.ClusterRoleInformer,
+) (*controller, error) {
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue: queue,
+		getSyncTarget: func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error) {
+			return syncTargetInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		listClusterRoles: func(clusterName logicalcluster.Name, syncTargetName string) ([]*rbacv1.ClusterRole, error) {
+			clusterRoles, err := indexers.ByIndex[*rbacv1.ClusterRole](clusterRoleInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, clusterName.String())
+			if err != nil {
+				return nil, err
+			}
+			var ret []*rbacv1.ClusterRole
+			for _, clusterRole := range clusterRoles {
+				if clusterRole.Labels[workloadv1alpha1.SyncerClusterRoleLabelKey] == syncTargetName {
+					ret = append(ret, clusterRole)
+				}
+			}
+			return ret, nil
+		},
+		updateClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error {
+			_, err := kubeClusterClient.Cluster(clusterName).RbacV1().ClusterRoles().Update(ctx, clusterRole, metav1.UpdateOptions{})
+			return err
+		},
+	}
+
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueSyncTarget(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSyncTarget, ok := oldObj.(*workloadv1alpha1.SyncTarget)
+			if !ok {
+				return
+			}
+			newSyncTarget, ok := newObj.(*workloadv1alpha1.SyncTarget)
+			if !ok {
+				return
+			}
+			if equality.Semantic.DeepEqual(oldSyncTarget.Status.SyncedResources, newSyncTarget.Status.SyncedResources) {
+				return
+			}
+			c.enqueueSyncTarget(newObj)
+		},
+	})
+
+	clusterRoleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			clusterRole, ok := obj.(*rbacv1.ClusterRole)
+			if !ok {
+				return false
+			}
+			_, found := clusterRole.Labels[workloadv1alpha1.SyncerClusterRoleLabelKey]
+			return found
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.enqueueClusterRole(obj)
+			},
+			UpdateFunc: func(_, newObj interface{}) {
+				c.enqueueClusterRole(newObj)
+			},
+		},
+	})
+
+	return c, nil
+}
+
+// controller regenerates the rules of the syncer ClusterRoles of SyncTargets.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	getSyncTarget func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error)
+
+	listClusterRoles  func(clusterName logicalcluster.Name, syncTargetName string) ([]*rbacv1.ClusterRole, error)
+	updateClusterRole func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error
+}
+
+// enqueueSyncTarget enqueues a SyncTarget.
+func (c *controller) enqueueSyncTarget(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing SyncTarget")
+	c.queue.Add(key)
+}
+
+// enqueueClusterRole enqueues the SyncTarget a syncer ClusterRole grants access to.
+func (c *controller) enqueueClusterRole(obj interface{}) {
+	clusterRole, ok := obj.(*rbacv1.ClusterRole)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a ClusterRole, but is %T", obj))
+		return
+	}
+
+	key := clusters.ToClusterAwareKey(logicalcluster.From(clusterRole), clusterRole.Labels[workloadv1alpha1.SyncerClusterRoleLabelKey])
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(4).Info("queueing SyncTarget because of ClusterRole", "clusterRole", clusterRole.Name)
+	c.queue.Add(key)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(4).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	clusterName, name := clusters.SplitClusterAwareKey(key)
+	return c.reconcile(ctx, clusterName, name)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctargetrbac
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+// reconcile updates the rules of the syncer ClusterRole of the SyncTarget with the given name to
+// match its current synced resources. Only the ClusterRole with the generated syncer name and a
+// controller owner reference to this very SyncTarget is touched, so that a role merely carrying the
+// label cannot be escalated. ClusterRoles of deleted SyncTargets are left to the garbage collector
+// via their owner references.
+func (c *controller) reconcile(ctx context.Context, clusterName logicalcluster.Name, syncTargetName string) error {
+	logger := klog.FromContext(ctx)
+
+	syncTarget, err := c.getSyncTarget(clusterName, syncTargetName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !syncTarget.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	clusterRoles, err := c.listClusterRoles(clusterName, syncTargetName)
+	if err != nil {
+		return err
+	}
+
+	rules := shared.SyncerClusterRoleRules(syncTarget)
+
+	var errs []error
+	for _, clusterRole := range clusterRoles {
+		if !isSyncerClusterRole(clusterRole, syncTarget) {
+			continue
+		}
+		if equality.Semantic.DeepEqual(clusterRole.Rules, rules) {
+			continue
+		}
+		updated := clusterRole.DeepCopy()
+		updated.Rules = rules
+		logger.V(2).Info("updating syncer ClusterRole", "clusterRole", clusterRole.Name)
+		if err := c.updateClusterRole(ctx, clusterName, updated); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update ClusterRole %s|%s: %w", clusterName, clusterRole.Name, err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// isSyncerClusterRole returns whether the given ClusterRole is the one created for the syncer of
+// the given SyncTarget, i.e. it has the generated syncer name and is controlled by the SyncTarget.
+func isSyncerClusterRole(clusterRole *rbacv1.ClusterRole, syncTarget *workloadv1alpha1.SyncTarget) bool {
+	if clusterRole.Name != shared.GetSyncerID(syncTarget) {
+		return false
+	}
+	owner := metav1.GetControllerOfNoCopy(clusterRole)
+	if owner == nil {
+		return false
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return false
+	}
+	return gv.Group == workloadv1alpha1.SchemeGroupVersion.Group &&
+		owner.Kind == "SyncTarget" &&
+		owner.Name == syncTarget.Name &&
+		owner.UID == syncTarget.UID
+}
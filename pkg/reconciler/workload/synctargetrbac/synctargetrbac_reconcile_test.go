@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package synctargetrbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+)
+
+func TestReconcile(t *testing.T) {
+	syncTarget := func(resources ...workloadv1alpha1.ResourceToSync) *workloadv1alpha1.SyncTarget {
+		return &workloadv1alpha1.SyncTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: "us-east1", UID: "uid-1"},
+			Status:     workloadv1alpha1.SyncTargetStatus{SyncedResources: resources},
+		}
+	}
+	deployments := workloadv1alpha1.ResourceToSync{
+		GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"},
+		Versions:      []string{"v1"},
+		State:         workloadv1alpha1.ResourceSchemaAcceptedState,
+	}
+	syncerID := shared.GetSyncerID(syncTarget())
+	clusterRole := func(rules []rbacv1.PolicyRule) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   syncerID,
+				Labels: map[string]string{workloadv1alpha1.SyncerClusterRoleLabelKey: "us-east1"},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: workloadv1alpha1.SchemeGroupVersion.String(),
+					Kind:       "SyncTarget",
+					Name:       "us-east1",
+					UID:        "uid-1",
+					Controller: pointer.Bool(true),
+				}},
+			},
+			Rules: rules,
+		}
+	}
+	withName := func(name string, clusterRole *rbacv1.ClusterRole) *rbacv1.ClusterRole {
+		clusterRole.Name = name
+		return clusterRole
+	}
+	withOwner := func(mutate func(ref *metav1.OwnerReference), clusterRole *rbacv1.ClusterRole) *rbacv1.ClusterRole {
+		mutate(&clusterRole.OwnerReferences[0])
+		return clusterRole
+	}
+
+	deleting := syncTarget(deployments)
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+
+	tests := map[string]struct {
+		syncTarget *workloadv1alpha1.SyncTarget
+		existing   []*rbacv1.ClusterRole
+
+		wantUpdated []string
+		wantRules   []rbacv1.PolicyRule
+	}{
+		"new synced resources are added to the rules": {
+			syncTarget:  syncTarget(deployments),
+			existing:    []*rbacv1.ClusterRole{clusterRole(shared.SyncerClusterRoleRules(syncTarget()))},
+			wantUpdated: []string{syncerID},
+			wantRules:   shared.SyncerClusterRoleRules(syncTarget(deployments)),
+		},
+		"removed synced resources are removed from the rules": {
+			syncTarget:  syncTarget(),
+			existing:    []*rbacv1.ClusterRole{clusterRole(shared.SyncerClusterRoleRules(syncTarget(deployments)))},
+			wantUpdated: []string{syncerID},
+			wantRules:   shared.SyncerClusterRoleRules(syncTarget()),
+		},
+		"up-to-date ClusterRoles are kept": {
+			syncTarget: syncTarget(deployments),
+			existing:   []*rbacv1.ClusterRole{clusterRole(shared.SyncerClusterRoleRules(syncTarget(deployments)))},
+		},
+		"labelled ClusterRoles with another name are ignored": {
+			syncTarget: syncTarget(deployments),
+			existing:   []*rbacv1.ClusterRole{withName("escalate-me", clusterRole(nil))},
+		},
+		"labelled ClusterRoles without controller owner reference are ignored": {
+			syncTarget: syncTarget(deployments),
+			existing: []*rbacv1.ClusterRole{withOwner(func(ref *metav1.OwnerReference) {
+				ref.Controller = nil
+			}, clusterRole(nil))},
+		},
+		"labelled ClusterRoles controlled by a SyncTarget with another UID are ignored": {
+			syncTarget: syncTarget(deployments),
+			existing: []*rbacv1.ClusterRole{withOwner(func(ref *metav1.OwnerReference) {
+				ref.UID = "uid-2"
+			}, clusterRole(nil))},
+		},
+		"labelled ClusterRoles controlled by another kind are ignored": {
+			syncTarget: syncTarget(deployments),
+			existing: []*rbacv1.ClusterRole{withOwner(func(ref *metav1.OwnerReference) {
+				ref.Kind = "Location"
+			}, clusterRole(nil))},
+		},
+		"ClusterRoles of deleted SyncTarget are left to the garbage collector": {
+			existing: []*rbacv1.ClusterRole{clusterRole(nil)},
+		},
+		"ClusterRoles of deleting SyncTarget are kept": {
+			syncTarget: deleting,
+			existing:   []*rbacv1.ClusterRole{clusterRole(nil)},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var updated []string
+			var updatedRules []rbacv1.PolicyRule
+			c := &controller{
+				getSyncTarget: func(clusterName logicalcluster.Name, name string) (*workloadv1alpha1.SyncTarget, error) {
+					require.Equal(t, "root:org:ws", clusterName.String())
+					if tc.syncTarget == nil {
+						return nil, apierrors.NewNotFound(workloadv1alpha1.Resource("synctargets"), name)
+					}
+					return tc.syncTarget, nil
+				},
+				listClusterRoles: func(clusterName logicalcluster.Name, syncTargetName string) ([]*rbacv1.ClusterRole, error) {
+					require.Equal(t, "us-east1", syncTargetName)
+					return tc.existing, nil
+				},
+				updateClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, clusterRole *rbacv1.ClusterRole) error {
+					updated = append(updated, clusterRole.Name)
+					updatedRules = clusterRole.Rules
+					return nil
+				},
+			}
+
+			err := c.reconcile(context.Background(), logicalcluster.New("root:org:ws"), "us-east1")
+			require.NoError(t, err)
+
+			require.Equal(t, tc.wantUpdated, updated)
+			require.Equal(t, tc.wantRules, updatedRules)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemacompat
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/multierr"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ChangeType is the kind of an incompatible difference between two schemas.
+type ChangeType string
+
+const (
+	// FieldMissing is a field of the existing schema that the new schema does not have.
+	FieldMissing ChangeType = "FieldMissing"
+	// TypeChanged is a field of another type in the new schema.
+	TypeChanged ChangeType = "TypeChanged"
+	// ValidationTightened is a field with stricter validation in the new schema, e.g. a new
+	// required field, a lower maximum or fewer enum values.
+	ValidationTightened ChangeType = "ValidationTightened"
+	// Unsupported is a difference the schema negotiation cannot reconcile, as reported by
+	// EnsureStructuralSchemaCompatibility.
+	Unsupported ChangeType = "Unsupported"
+)
+
+// Change is an incompatible difference between two schemas.
+type Change struct {
+	Type ChangeType
+	// Path is the JSON path of the field relative to the schema root, e.g. `.spec.replicas`, with
+	// `[*]` for array items and additional properties. It is `.` for the root.
+	Path    string
+	Message string
+}
+
+// Diff returns the changes of the new schema that reject or drop objects accepted by the existing
+// schema, ordered by path. Changes that only accept more objects are compatible and not returned.
+func Diff(existing, new *apiextensionsv1.JSONSchemaProps) []Change {
+	var changes []Change
+	diffSchemaProps("", existing, new, func(changeType ChangeType, path, format string, args ...interface{}) {
+		changes = append(changes, Change{Type: changeType, Path: path, Message: fmt.Sprintf(format, args...)})
+	})
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Path != changes[j].Path {
+			return changes[i].Path < changes[j].Path
+		}
+		return changes[i].Message < changes[j].Message
+	})
+
+	return changes
+}
+
+// CheckCompatibility returns why objects of the upstream schema cannot be stored with the downstream
+// schema, or nil if they can. Compatibility is decided by EnsureStructuralSchemaCompatibility. The changes
+// are those found by Diff, or the unsupported differences reported by EnsureStructuralSchemaCompatibility
+// if Diff does not find any.
+func CheckCompatibility(upstream, downstream *apiextensionsv1.JSONSchemaProps) []Change {
+	_, err := EnsureStructuralSchemaCompatibility(field.NewPath("schema"), upstream, downstream, false)
+	if err == nil {
+		return nil
+	}
+
+	if changes := Diff(upstream, downstream); len(changes) > 0 {
+		return changes
+	}
+
+	var changes []Change
+	for _, err := range multierr.Errors(err) {
+		if fieldErr, ok := err.(*field.Error); ok {
+			changes = append(changes, Change{Type: Unsupported, Path: jsonPath(fieldErr.Field), Message: fieldErr.Detail})
+		} else {
+			changes = append(changes, Change{Type: Unsupported, Path: ".", Message: err.Error()})
+		}
+	}
+	return changes
+}
+
+var structuralPathElementRE = regexp.MustCompile(`properties\[([^\]]*)\]|(?:Items|additionalProperties)\.`)
+
+// jsonPath turns the field path of an error of EnsureStructuralSchemaCompatibility into the JSON path
+// of the affected field. Path elements naming schema attributes, like `type`, are dropped.
+func jsonPath(fieldPath string) string {
+	var path strings.Builder
+	for _, match := range structuralPathElementRE.FindAllStringSubmatch(fieldPath, -1) {
+		if match[1] != "" {
+			path.WriteString("." + match[1])
+		} else {
+			path.WriteString("[*]")
+		}
+	}
+	if path.Len() == 0 {
+		return "."
+	}
+	return path.String()
+}
+
+// diffSchemaProps reports the changes of newProps that reject or drop objects accepted by oldProps.
+func diffSchemaProps(path string, oldProps, newProps *apiextensionsv1.JSONSchemaProps, add func(changeType ChangeType, path, format string, args ...interface{})) {
+	if oldProps == nil || newProps == nil {
+		return
+	}
+	fieldPath := path
+	if fieldPath == "" {
+		fieldPath = "."
+	}
+
+	if oldProps.Type != newProps.Type && newProps.Type != "" {
+		add(TypeChanged, fieldPath, "type changed from %q to %q", oldProps.Type, newProps.Type)
+		return
+	}
+
+	if oldProps.Nullable && !newProps.Nullable {
+		add(ValidationTightened, fieldPath, "null is not allowed anymore")
+	}
+	if newProps.Pattern != "" && newProps.Pattern != oldProps.Pattern {
+		add(ValidationTightened, fieldPath, "pattern changed from %q to %q", oldProps.Pattern, newProps.Pattern)
+	}
+	if newProps.Format != "" && newProps.Format != oldProps.Format {
+		add(ValidationTightened, fieldPath, "format changed from %q to %q", oldProps.Format, newProps.Format)
+	}
+	if len(newProps.Enum) > 0 {
+		newEnum := sets.NewString()
+		for _, v := range newProps.Enum {
+			newEnum.Insert(string(v.Raw))
+		}
+		var removed []string
+		if len(oldProps.Enum) == 0 {
+			removed = append(removed, "any value")
+		}
+		for _, v := range oldProps.Enum {
+			if !newEnum.Has(string(v.Raw)) {
+				removed = append(removed, string(v.Raw))
+			}
+		}
+		if len(removed) > 0 {
+			add(ValidationTightened, fieldPath, "enum does not allow %s anymore", strings.Join(removed, ", "))
+		}
+	}
+	diffMaximum(fieldPath, "maximum", oldProps.Maximum, newProps.Maximum, add)
+	diffMinimum(fieldPath, "minimum", oldProps.Minimum, newProps.Minimum, add)
+	diffMaximum(fieldPath, "maxLength", int64ToFloat(oldProps.MaxLength), int64ToFloat(newProps.MaxLength), add)
+	diffMinimum(fieldPath, "minLength", int64ToFloat(oldProps.MinLength), int64ToFloat(newProps.MinLength), add)
+	diffMaximum(fieldPath, "maxItems", int64ToFloat(oldProps.MaxItems), int64ToFloat(newProps.MaxItems), add)
+	diffMinimum(fieldPath, "minItems", int64ToFloat(oldProps.MinItems), int64ToFloat(newProps.MinItems), add)
+	diffMaximum(fieldPath, "maxProperties", int64ToFloat(oldProps.MaxProperties), int64ToFloat(newProps.MaxProperties), add)
+	diffMinimum(fieldPath, "minProperties", int64ToFloat(oldProps.MinProperties), int64ToFloat(newProps.MinProperties), add)
+
+	oldRequired := sets.NewString(oldProps.Required...)
+	for _, name := range newProps.Required {
+		if !oldRequired.Has(name) {
+			add(ValidationTightened, path+"."+name, "field is required now")
+		}
+	}
+
+	preservesUnknownFields := newProps.XPreserveUnknownFields != nil && *newProps.XPreserveUnknownFields
+	for name := range oldProps.Properties {
+		oldProp := oldProps.Properties[name]
+		newProp, found := newProps.Properties[name]
+		if !found {
+			if !preservesUnknownFields {
+				add(FieldMissing, path+"."+name, "field was removed")
+			}
+			continue
+		}
+		diffSchemaProps(path+"."+name, &oldProp, &newProp, add)
+	}
+
+	if oldProps.Items != nil && newProps.Items != nil {
+		diffSchemaProps(path+"[*]", oldProps.Items.Schema, newProps.Items.Schema, add)
+	}
+	if oldProps.AdditionalProperties != nil && newProps.AdditionalProperties != nil {
+		if oldProps.AdditionalProperties.Allows && !newProps.AdditionalProperties.Allows {
+			add(ValidationTightened, fieldPath, "additional properties are not allowed anymore")
+		}
+		diffSchemaProps(path+"[*]", oldProps.AdditionalProperties.Schema, newProps.AdditionalProperties.Schema, add)
+	}
+}
+
+func diffMaximum(path, name string, oldMax, newMax *float64, add func(changeType ChangeType, path, format string, args ...interface{})) {
+	if newMax == nil || (oldMax != nil && *oldMax <= *newMax) {
+		return
+	}
+	add(ValidationTightened, path, "%s lowered from %s to %v", name, formatLimit(oldMax), *newMax)
+}
+
+func diffMinimum(path, name string, oldMin, newMin *float64, add func(changeType ChangeType, path, format string, args ...interface{})) {
+	if newMin == nil || (oldMin != nil && *oldMin >= *newMin) {
+		return
+	}
+	add(ValidationTightened, path, "%s raised from %s to %v", name, formatLimit(oldMin), *newMin)
+}
+
+func formatLimit(limit *float64) string {
+	if limit == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("%v", *limit)
+}
+
+func int64ToFloat(i *int64) *float64 {
+	if i == nil {
+		return nil
+	}
+	f := float64(*i)
+	return &f
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schemacompat
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestDiff(t *testing.T) {
+	for _, c := range []struct {
+		desc          string
+		existing, new *apiextensionsv1.JSONSchemaProps
+		want          []Change
+	}{{
+		desc: "new has more properties",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"existing": {Type: "string"},
+			},
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"existing": {Type: "string"},
+				"new":      {Type: "integer"},
+			},
+		},
+	}, {
+		desc: "new has fewer properties",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"spec": {
+					Type: "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{
+						"replicas": {Type: "integer"},
+						"paused":   {Type: "boolean"},
+					},
+				},
+			},
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"spec": {
+					Type: "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{
+						"replicas": {Type: "integer"},
+					},
+				},
+			},
+		},
+		want: []Change{
+			{Type: FieldMissing, Path: ".spec.paused", Message: "field was removed"},
+		},
+	}, {
+		desc: "new has fewer properties, but preserves unknown fields",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"existing": {Type: "string"},
+			},
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type:                   "object",
+			XPreserveUnknownFields: pointer.Bool(true),
+		},
+	}, {
+		desc: "type changed in items",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "integer"}},
+		},
+		want: []Change{
+			{Type: TypeChanged, Path: "[*]", Message: `type changed from "string" to "integer"`},
+		},
+	}, {
+		desc: "validation tightened",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"name":  {Type: "string", MaxLength: pointer.Int64(63)},
+				"count": {Type: "integer"},
+			},
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type:     "object",
+			Required: []string{"count"},
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"name":  {Type: "string", MaxLength: pointer.Int64(10)},
+				"count": {Type: "integer", Minimum: pointer.Float64(1)},
+			},
+		},
+		want: []Change{
+			{Type: ValidationTightened, Path: ".count", Message: "field is required now"},
+			{Type: ValidationTightened, Path: ".count", Message: "minimum raised from unset to 1"},
+			{Type: ValidationTightened, Path: ".name", Message: "maxLength lowered from 63 to 10"},
+		},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			got := Diff(c.existing, c.new)
+			if d := cmp.Diff(c.want, got); d != "" {
+				t.Errorf("unexpected changes (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	for _, c := range []struct {
+		desc                 string
+		upstream, downstream *apiextensionsv1.JSONSchemaProps
+		want                 []Change
+	}{{
+		desc: "compatible",
+		upstream: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"existing": {Type: "string"},
+			},
+		},
+		downstream: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"existing": {Type: "string"},
+				"new":      {Type: "integer"},
+			},
+		},
+	}, {
+		desc: "field missing downstream",
+		upstream: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"existing": {Type: "string"},
+				"new":      {Type: "integer"},
+			},
+		},
+		downstream: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"existing": {Type: "string"},
+			},
+		},
+		want: []Change{
+			{Type: FieldMissing, Path: ".new", Message: "field was removed"},
+		},
+	}, {
+		desc: "unsupported change",
+		upstream: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"tags": {
+					Type:      "array",
+					Items:     &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+					XListType: pointer.String("atomic"),
+				},
+			},
+		},
+		downstream: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"tags": {
+					Type:      "array",
+					Items:     &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+					XListType: pointer.String("set"),
+				},
+			},
+		},
+		want: []Change{
+			{Type: Unsupported, Path: ".tags", Message: "x-kubernetes-list-type value has been changed in an incompatible way"},
+		},
+	}} {
+		t.Run(c.desc, func(t *testing.T) {
+			got := CheckCompatibility(c.upstream, c.downstream)
+			if d := cmp.Diff(c.want, got); d != "" {
+				t.Errorf("unexpected changes (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	for fieldPath, want := range map[string]string{
+		"schema":                       ".",
+		"schema.type":                  ".",
+		"schema.properties[spec].type": ".spec",
+		"schema.properties[spec].properties[containers].Items.properties[name]": ".spec.containers[*].name",
+		"schema.properties[labels].additionalProperties.type":                   ".labels[*]",
+		"schema.properties[labels].additionalProperties":                        ".labels",
+	} {
+		if got := jsonPath(fieldPath); got != want {
+			t.Errorf("jsonPath(%q) = %q, want %q", fieldPath, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/authorization"
+)
+
+// AccessReviewPath is the path, relative to a workspace, serving the effective access of a subject in that workspace.
+const AccessReviewPath = "/access-review"
+
+// WithAccessReview serves GET requests to AccessReviewPath with the effective access of a subject in the
+// request workspace, as computed by the given reviewer. By default, the requesting user is reviewed, which
+// requires some access to the workspace. Other subjects can be reviewed via the user and group query
+// parameters by users allowed to create subjectaccessreviews in the workspace. The namespace query parameter
+// selects the namespace the rules are evaluated in.
+//
+// This filter must run after authentication and before authorization.
+func WithAccessReview(apiHandler http.Handler, authz authorizer.Authorizer, reviewer *authorization.AccessReviewer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != AccessReviewPath {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		ctx := req.Context()
+		if req.Method != http.MethodGet {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewMethodNotSupported(schema.GroupResource{Resource: "access-review"}, req.Method),
+				errorCodecs, schema.GroupVersion{}, w, req,
+			)
+			return
+		}
+		cluster := request.ClusterFrom(ctx)
+		if cluster == nil || cluster.Name.Empty() || cluster.Wildcard {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewBadRequest("access reviews require a workspace"),
+				errorCodecs, schema.GroupVersion{}, w, req,
+			)
+			return
+		}
+		requester, ok := request.UserFrom(ctx)
+		if !ok {
+			responsewriters.InternalError(w, req, errors.New("no user in AccessReview filter"))
+			return
+		}
+
+		query := req.URL.Query()
+		subject := requester
+		isSelfReview := query.Get("user") == "" && len(query["group"]) == 0
+		if !isSelfReview {
+			// reviewing others reveals their permissions, hence it is restricted like subject access reviews
+			attr := authorizer.AttributesRecord{
+				User:            requester,
+				Verb:            "create",
+				APIGroup:        authorizationv1.SchemeGroupVersion.Group,
+				APIVersion:      authorizationv1.SchemeGroupVersion.Version,
+				Resource:        "subjectaccessreviews",
+				ResourceRequest: true,
+			}
+			dec, reason, err := authz.Authorize(ctx, attr)
+			if err != nil {
+				responsewriters.InternalError(w, req, err)
+				return
+			}
+			if dec != authorizer.DecisionAllow {
+				responsewriters.Forbidden(ctx, attr, w, req, reason, errorCodecs)
+				return
+			}
+			subject = &user.DefaultInfo{
+				Name:   query.Get("user"),
+				Groups: query["group"],
+			}
+		}
+
+		review, err := reviewer.Review(ctx, cluster.Name, query.Get("namespace"), subject)
+		if err != nil {
+			responsewriters.InternalError(w, req, err)
+			return
+		}
+		if isSelfReview && review.WorkspaceAccess == authorization.WorkspaceAccessNone {
+			// do not reveal anything about workspaces the requester cannot access
+			attr := authorizer.AttributesRecord{
+				User: requester,
+				Verb: "get",
+				Path: AccessReviewPath,
+			}
+			responsewriters.Forbidden(ctx, attr, w, req, authorization.WorkspaceAcccessNotPermittedReason, errorCodecs)
+			return
+		}
+
+		responsewriters.WriteRawJSON(http.StatusOK, review, w)
+	}
+}
@@ -45,6 +45,7 @@ import (
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
 )
@@ -87,7 +88,7 @@ func (c *apiBindingAwareCRDLister) List(ctx context.Context, selector labels.Sel
 	var ret []*apiextensionsv1.CustomResourceDefinition
 
 	// Priority 1: add system CRDs. These take priority over CRDs from APIBindings and CRDs from the local workspace.
-	systemCRDObjs, err := c.crdIndexer.ByIndex(byWorkspace, SystemCRDLogicalCluster.String())
+	systemCRDObjs, err := c.crdIndexer.ByIndex(indexers.ByLogicalCluster, SystemCRDLogicalCluster.String())
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving kcp system CRDs: %w", err)
 	}
@@ -97,7 +98,7 @@ func (c *apiBindingAwareCRDLister) List(ctx context.Context, selector labels.Sel
 		seen.Insert(crdName(crd))
 	}
 
-	objs, err := c.apiBindingIndexer.ByIndex(byWorkspace, clusterName.String())
+	objs, err := c.apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
@@ -144,7 +145,7 @@ func (c *apiBindingAwareCRDLister) List(ctx context.Context, selector labels.Sel
 	}
 
 	// TODO use scoping lister when available
-	objs, err = c.crdIndexer.ByIndex(byWorkspace, clusterName.String())
+	objs, err = c.crdIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
@@ -332,7 +333,7 @@ func makePartialMetadataCRD(crd *apiextensionsv1.CustomResourceDefinition) {
 
 // getForIdentityWildcard handles finding the right CRD for an incoming wildcard request with identity, such as
 //
-//   /clusters/*/apis/$group/$version/$resource:$identity.
+//	/clusters/*/apis/$group/$version/$resource:$identity.
 func (c *apiBindingAwareCRDLister) getForIdentityWildcard(name, identity string) (*apiextensionsv1.CustomResourceDefinition, error) {
 	group, resource := crdNameToGroupResource(name)
 
@@ -407,7 +408,7 @@ func (c *apiBindingAwareCRDLister) get(clusterName logicalcluster.Name, name str
 	// Priority 1: see if it comes from any APIBindings
 	group, resource := crdNameToGroupResource(name)
 
-	objs, err := c.apiBindingIndexer.ByIndex(byWorkspace, clusterName.String())
+	objs, err := c.apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		return nil, err
 	}
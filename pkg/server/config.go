@@ -30,6 +30,7 @@ import (
 	apiextensionsexternalversions "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/audit"
 	"k8s.io/apiserver/pkg/endpoints/filters"
 	"k8s.io/apiserver/pkg/quota/v1/generic"
 	genericapiserver "k8s.io/apiserver/pkg/server"
@@ -42,6 +43,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/kubernetes/pkg/controller/certificates/authority"
 	"k8s.io/kubernetes/pkg/genericcontrolplane"
 	"k8s.io/kubernetes/pkg/genericcontrolplane/aggregator"
 	"k8s.io/kubernetes/pkg/genericcontrolplane/apis"
@@ -49,18 +51,24 @@ import (
 
 	kcpadmissioninitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpaudit "github.com/kcp-dev/kcp/pkg/audit"
+	"github.com/kcp-dev/kcp/pkg/authentication"
 	"github.com/kcp-dev/kcp/pkg/authorization"
+	cacheshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/embeddedetcd"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceactivity"
 	boostrap "github.com/kcp-dev/kcp/pkg/server/bootstrap"
 	kcpserveroptions "github.com/kcp-dev/kcp/pkg/server/options"
 	"github.com/kcp-dev/kcp/pkg/server/options/batteries"
 	"github.com/kcp-dev/kcp/pkg/server/requestinfo"
 	"github.com/kcp-dev/kcp/pkg/tunneler"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 type Config struct {
@@ -86,6 +94,10 @@ type ExtraConfig struct {
 	// authentication
 	kcpAdminToken, shardAdminToken, userToken string
 	shardAdminTokenHash                       []byte
+	syncerCertificateAuthority                *authority.CertificateAuthority
+
+	// authorization
+	authorizationTracer *authorization.DecisionTracer
 
 	// clients
 	DynamicClusterClient       dynamic.ClusterInterface
@@ -98,12 +110,19 @@ type ExtraConfig struct {
 	// misc
 	preHandlerChainMux   *handlerChainMuxes
 	quotaAdmissionStopCh chan struct{}
+	activityTracker      *workspaceactivity.Tracker
+	workspaceFeatureGate *kcpfeatures.WorkspaceFeatureGate
 
 	// informers
 	KcpSharedInformerFactory              kcpinformers.SharedInformerFactory
 	KubeSharedInformerFactory             kubernetesinformers.SharedInformerFactory
 	ApiExtensionsSharedInformerFactory    apiextensionsexternalversions.SharedInformerFactory
 	DynamicDiscoverySharedInformerFactory *informer.DynamicDiscoverySharedInformerFactory
+	MetadataSharedInformerFactory         *informer.MetadataSharedInformerFactory
+
+	// CacheKcpSharedInformerFactory brings kcp objects replicated by all shards from the cache server.
+	// It is nil if no cache server is configured.
+	CacheKcpSharedInformerFactory kcpinformers.SharedInformerFactory
 
 	// TODO(p0lyn0mial):  get rid of TemporaryRootShardKcpSharedInformerFactory, in the future
 	//                    we should have multi-shard aware informers
@@ -157,6 +176,8 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		go http.ListenAndServe(opts.Extra.ProfilerAddress, nil)
 	}
 
+	kcpworkqueue.SetFullLogicalClusterLabels(opts.Extra.FullLogicalClusterMetrics)
+
 	if opts.EmbeddedEtcd.Enabled {
 		var err error
 		c.EmbeddedEtcd, err = embeddedetcd.NewConfig(opts.EmbeddedEtcd, opts.GenericControlPlane.Etcd.EnableWatchCache)
@@ -185,6 +206,10 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		kubernetesinformers.WithExtraClusterScopedIndexers(indexers.ClusterScoped()),
 		kubernetesinformers.WithExtraNamespaceScopedIndexers(indexers.NamespaceScoped()),
 	)
+	c.MetadataSharedInformerFactory, err = informer.NewMetadataSharedInformerFactory(c.GenericConfig.LoopbackClientConfig, resyncPeriod)
+	if err != nil {
+		return nil, err
+	}
 
 	// Setup kcp * informers, but those will need the identities for the APIExports used to make the APIs available.
 	// The identities are not known before we can get them from the APIExports via the loopback client or from the root shard in case this is a non-root shard,
@@ -232,6 +257,21 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		kcpinformers.WithExtraClusterScopedIndexers(indexers.ClusterScoped()),
 		kcpinformers.WithExtraNamespaceScopedIndexers(indexers.NamespaceScoped()),
 	)
+	if len(c.Options.Extra.CacheServerKubeconfigFile) > 0 {
+		cacheConfig, err := newCacheServerConfig(c.Options.Extra.CacheServerKubeconfigFile, "kcp-cache-informers", cacheshard.Wildcard)
+		if err != nil {
+			return nil, err
+		}
+		cacheKcpClusterClient, err := kcpclient.NewClusterForConfig(cacheConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.CacheKcpSharedInformerFactory = kcpinformers.NewSharedInformerFactoryWithOptions(
+			cacheKcpClusterClient.Cluster(logicalcluster.Wildcard),
+			resyncPeriod,
+			kcpinformers.WithExtraClusterScopedIndexers(indexers.ClusterScoped()),
+		)
+	}
 	c.DeepSARClient, err = kubernetesclient.NewClusterForConfig(authorization.WithDeepSARConfig(rest.CopyConfig(c.GenericConfig.LoopbackClientConfig)))
 	if err != nil {
 		return nil, err
@@ -255,21 +295,44 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		return nil, err
 	}
 
-	if err := opts.Authorization.ApplyTo(c.GenericConfig, c.KubeSharedInformerFactory, c.KcpSharedInformerFactory); err != nil {
+	breakGlassAccounts, err := opts.AdminAuthentication.BreakGlassAccounts()
+	if err != nil {
+		return nil, err
+	}
+	c.authorizationTracer, err = opts.Authorization.ApplyTo(c.GenericConfig, c.KubeSharedInformerFactory, c.KcpSharedInformerFactory, breakGlassAccounts)
+	if err != nil {
 		return nil, err
 	}
 	var userToken string
-	c.kcpAdminToken, c.shardAdminToken, userToken, c.shardAdminTokenHash, err = opts.AdminAuthentication.ApplyTo(c.GenericConfig)
+	c.kcpAdminToken, c.shardAdminToken, userToken, c.shardAdminTokenHash, err = opts.AdminAuthentication.ApplyTo(c.GenericConfig, breakGlassAccounts)
 	if err != nil {
 		return nil, err
 	}
 	if sets.NewString(opts.Extra.BatteriesIncluded...).Has(batteries.User) {
 		c.userToken = userToken
 	}
+	c.GenericConfig.Authentication.Authenticator = authentication.WithWorkspaceAuthentication(c.GenericConfig.Authentication.Authenticator, c.GenericConfig.Authentication.APIAudiences, c.KcpSharedInformerFactory)
+	c.syncerCertificateAuthority, err = opts.SyncerCertificates.ApplyTo(c.GenericConfig, c.KcpSharedInformerFactory)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := opts.GenericControlPlane.Audit.ApplyTo(c.GenericConfig); err != nil {
 		return nil, err
 	}
+	if sinksConfig, err := kcpaudit.LoadSinksConfig(opts.Extra.AuditWorkspaceSinksFile); err != nil {
+		return nil, err
+	} else if sinksConfig != nil && len(sinksConfig.Sinks) > 0 {
+		sinksBackend, err := sinksConfig.NewBackend()
+		if err != nil {
+			return nil, err
+		}
+		if c.GenericConfig.AuditBackend != nil {
+			c.GenericConfig.AuditBackend = audit.Union(c.GenericConfig.AuditBackend, sinksBackend)
+		} else {
+			c.GenericConfig.AuditBackend = sinksBackend
+		}
+	}
 
 	var shardVirtualWorkspaceURL *url.URL
 	if !opts.Virtual.Enabled && opts.Extra.ShardVirtualWorkspaceURL != "" {
@@ -283,7 +346,26 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 	// is called multiple times, but only one of the handler chain will actually be used. Hence, we wrap it
 	// to give handlers below one mux.Handle func to call.
 	c.preHandlerChainMux = &handlerChainMuxes{}
+	c.activityTracker = workspaceactivity.NewTracker()
+	workspaceLister := c.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+	typeLister := c.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes().Lister()
+	c.workspaceFeatureGate = kcpfeatures.NewWorkspaceFeatureGate(
+		kcpfeatures.DefaultFeatureGate,
+		func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+			return workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
+			return typeLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+	)
+	accessReviewer, err := authorization.NewAccessReviewer(c.KubeSharedInformerFactory, c.KcpSharedInformerFactory)
+	if err != nil {
+		return nil, err
+	}
 	c.GenericConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, genericConfig *genericapiserver.Config) (secure http.Handler) {
+		apiHandler = WithCustomSubresources(apiHandler, c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer())
+		apiHandler = WithActivityTracking(apiHandler, c.activityTracker)
+		apiHandler = WithAPIBindingDeprecationWarning(apiHandler, c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer())
 		apiHandler = WithWildcardListWatchGuard(apiHandler)
 		apiHandler = WithWildcardIdentity(apiHandler)
 		apiHandler = authorization.WithDeepSubjectAccessReview(apiHandler)
@@ -305,6 +387,8 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 				opts.HomeWorkspaces.BucketSize,
 			)
 		}
+		apiHandler = WithAccessReview(apiHandler, genericConfig.Authorization.Authorizer, accessReviewer)
+		apiHandler = WithVirtualWorkspaceOriginAnnotation(apiHandler)
 
 		apiHandler = genericapiserver.DefaultBuildHandlerChainBeforeAuthz(apiHandler, genericConfig)
 
@@ -318,11 +402,11 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		*c.preHandlerChainMux = append(*c.preHandlerChainMux, mux)
 		apiHandler = mux
 
-		if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.SyncerTunnel) {
-			apiHandler = tunneler.WithSyncerTunnel(apiHandler)
-		}
+		apiHandler = tunneler.WithSyncerTunnel(apiHandler, func(clusterName logicalcluster.Name) bool {
+			return c.workspaceFeatureGate.Enabled(clusterName, kcpfeatures.SyncerTunnel)
+		})
 		apiHandler = WithWorkspaceProjection(apiHandler, shardVirtualWorkspaceURL)
-		apiHandler = WithClusterAnnotation(apiHandler)
+		apiHandler = WithClusterAnnotation(apiHandler, opts.Extra.ShardName)
 		apiHandler = WithAuditAnnotation(apiHandler) // Must run before any audit annotation is made
 		apiHandler = WithClusterScope(apiHandler)
 		apiHandler = WithInClusterServiceAccountRequestRewrite(apiHandler)
@@ -341,6 +425,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 
 	admissionPluginInitializers := []admission.PluginInitializer{
 		kcpadmissioninitializers.NewKcpInformersInitializer(c.KcpSharedInformerFactory),
+		kcpadmissioninitializers.NewCacheKcpInformersInitializer(c.CacheKcpSharedInformerFactory),
 		kcpadmissioninitializers.NewKubeClusterClientInitializer(c.KubeClusterClient),
 		kcpadmissioninitializers.NewKcpClusterClientInitializer(c.KcpClusterClient),
 		kcpadmissioninitializers.NewDeepSARClientInitializer(c.DeepSARClient),
@@ -381,12 +466,11 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		return nil, fmt.Errorf("configure api extensions: %w", err)
 	}
 
-	c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer().AddIndexers(cache.Indexers{byWorkspace: indexByWorkspace})                                                     // nolint: errcheck
-	c.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().GetIndexer().AddIndexers(cache.Indexers{byWorkspace: indexByWorkspace})                          // nolint: errcheck
-	c.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().GetIndexer().AddIndexers(cache.Indexers{byGroupResourceName: indexCRDByGroupResourceName})       // nolint: errcheck
-	c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer().AddIndexers(cache.Indexers{byWorkspace: indexByWorkspace})                                                     // nolint: errcheck
-	c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer().AddIndexers(cache.Indexers{byIdentityGroupResource: indexAPIBindingByIdentityGroupResource})                   // nolint: errcheck
-	c.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets().Informer().GetIndexer().AddIndexers(cache.Indexers{indexers.SyncTargetsBySyncTargetKey: indexers.IndexSyncTargetsBySyncTargetKey}) // nolint: errcheck
+	indexers.AddOrDie(c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddOrDie(c.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().GetIndexer(), indexers.ByLogicalCluster)
+	indexers.AddIfNotPresentOrDie(c.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().GetIndexer(), cache.Indexers{byGroupResourceName: indexCRDByGroupResourceName})
+	indexers.AddIfNotPresentOrDie(c.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer(), cache.Indexers{byIdentityGroupResource: indexAPIBindingByIdentityGroupResource})
+	indexers.AddOrDie(c.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets().Informer().GetIndexer(), indexers.SyncTargetsBySyncTargetKey)
 
 	c.ApiExtensions.ExtraConfig.ClusterAwareCRDLister = &apiBindingAwareCRDLister{
 		kcpClusterClient:  c.KcpClusterClient,
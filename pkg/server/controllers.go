@@ -30,15 +30,20 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
 	"k8s.io/klog/v2"
@@ -52,22 +57,45 @@ import (
 	confighomeroot "github.com/kcp-dev/kcp/config/homeroot"
 	configuniversal "github.com/kcp-dev/kcp/config/universal"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/author"
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
+	cacheshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+	cachereplication "github.com/kcp-dev/kcp/pkg/cache/replication"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingdeletion"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingquota"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibindingroles"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexportnotification"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexportusage"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/identitycache"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/permissionclaimlabel"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/storageversionmigration"
+	"github.com/kcp-dev/kcp/pkg/reconciler/cache/replication"
+	"github.com/kcp-dev/kcp/pkg/reconciler/garbagecollector"
 	"github.com/kcp-dev/kcp/pkg/reconciler/kubequota"
 	schedulinglocationstatus "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
 	schedulingplacement "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/placement"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/accessgrant"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/bootstrap"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspace"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacedeletion"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemove"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspaceshard"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacetype"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/defaultobjects"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/sharddrain"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/sharedsecret"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceactivity"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacemigration"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacepolicy"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacequota"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacereaper"
 	workloadsapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 	workloadsapiexportcreate "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexportcreate"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/defaultplacement"
@@ -75,11 +103,22 @@ import (
 	workloadnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	workloadplacement "github.com/kcp-dev/kcp/pkg/reconciler/workload/placement"
 	workloadresource "github.com/kcp-dev/kcp/pkg/reconciler/workload/resource"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/syncercertificates"
 	synctargetcontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/synctarget"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/synctargetexports"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/synctargetrbac"
 	"github.com/kcp-dev/kcp/pkg/util"
 )
 
+const (
+	// restMapperCacheSize is the number of logical clusters whose RESTMappers are cached by controllers
+	// applying objects to workspaces.
+	restMapperCacheSize = 1000
+	// restMapperCacheTTL is the time after which cached RESTMappers are dropped, such that new APIs are
+	// discovered.
+	restMapperCacheTTL = 10 * time.Minute
+)
+
 func postStartHookName(controllerName string) string {
 	return fmt.Sprintf("kcp-start-%s", controllerName)
 }
@@ -335,20 +374,26 @@ func (s *Server) installWorkspaceDeletionController(ctx context.Context, config
 	})
 }
 
-func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *rest.Config, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
-	controllerName := "kcp-workload-resource-scheduler"
+func (s *Server) installWorkspaceDefaultObjectsController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-default-objects"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
-	dynamicClusterClient, err := dynamic.NewForConfig(config)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	// default objects are applied impersonating the author of their ClusterWorkspaceType
+	dynamicClusterClient, err := dynamic.NewForConfig(author.WithImpersonatedAuthor(config))
 	if err != nil {
 		return err
 	}
 
-	resourceScheduler, err := workloadresource.NewController(
+	workspaceDefaultObjectsController, err := defaultobjects.NewController(
 		dynamicClusterClient,
-		s.DynamicDiscoverySharedInformerFactory,
-		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
-		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		newCachedRESTMapperFunc(config),
 	)
 	if err != nil {
 		return err
@@ -362,70 +407,109 @@ func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *r
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go resourceScheduler.Start(ctx, 2)
+		go workspaceDefaultObjectsController.Start(ctx, 2)
 		return nil
 	})
 }
 
-func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Config) error {
-	controllerName := "kcp-workspace-scheduler"
+func (s *Server) installWorkspaceMoveController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-move"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
-
 	kcpClusterClient, err := kcpclient.NewForConfig(config)
 	if err != nil {
 		return err
 	}
-
-	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
-	if err != nil {
-		return err
-	}
-
 	dynamicClusterClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return err
 	}
+	discoverResourcesFn := func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error) {
+		logicalClusterConfig := rest.CopyConfig(config)
+		logicalClusterConfig.Host += clusterName.Path()
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(logicalClusterConfig)
+		if err != nil {
+			return nil, err
+		}
+		return discoveryClient.ServerPreferredResources()
+	}
 
-	workspaceController, err := clusterworkspace.NewController(
+	workspaceMoveController, err := clusterworkspacemove.NewController(
+		s.Options.Extra.ShardName,
 		kcpClusterClient,
+		dynamicClusterClient,
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
-		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		discoverResourcesFn,
 	)
 	if err != nil {
 		return err
 	}
 
-	var workspaceShardController *clusterworkspaceshard.Controller
-	if s.Options.Extra.ShardName == tenancyv1alpha1.RootShard {
-		workspaceShardController, err = clusterworkspaceshard.NewController(
-			kcpClusterClient,
-			s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
-		)
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceMoveController.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installWorkspaceMigrationController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-migration"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// the shard kubeconfig has a context for every peer shard, named after the shard
+	kubeconfig, err := clientcmd.LoadFromFile(s.Options.Extra.ShardKubeconfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the shard kubeconfig %q: %w", s.Options.Extra.ShardKubeconfigFile, err)
+	}
+	shardConfigs := map[string]*rest.Config{
+		s.Options.Extra.ShardName: config,
+	}
+	for name := range kubeconfig.Contexts {
+		if name == s.Options.Extra.ShardName {
+			continue
+		}
+		shardConfig, err := clientcmd.NewNonInteractiveClientConfig(*kubeconfig, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to load the context %q of the shard kubeconfig %q: %w", name, s.Options.Extra.ShardKubeconfigFile, err)
 		}
+		shardConfigs[name] = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(shardConfig), controllerName)
 	}
 
-	workspaceTypeController, err := clusterworkspacetype.NewController(
+	workspaceMigrationController, err := workspacemigration.NewController(
 		kcpClusterClient,
-		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		shardConfigs,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceMigrations(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
 	)
 	if err != nil {
 		return err
 	}
 
-	universalController, err := bootstrap.NewController(
-		config,
-		dynamicClusterClient,
-		crdClusterClient,
+	reachableShards := sets.NewString()
+	for name := range shardConfigs {
+		reachableShards.Insert(name)
+	}
+	shardDrainController, err := sharddrain.NewController(
+		s.Options.Extra.ShardName,
+		reachableShards,
 		kcpClusterClient,
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
-		tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root", Name: "universal"},
-		configuniversal.Bootstrap,
-		sets.NewString(s.Options.Extra.BatteriesIncluded...),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceMigrations(),
 	)
 	if err != nil {
 		return err
@@ -439,60 +523,85 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go workspaceController.Start(ctx, 2)
-		if workspaceShardController != nil {
-			go workspaceShardController.Start(ctx, 2)
-		}
-		go workspaceTypeController.Start(ctx, 2)
-		go universalController.Start(ctx, 2)
-
+		go workspaceMigrationController.Start(ctx, 2)
+		go shardDrainController.Start(ctx, 2)
 		return nil
 	})
 }
 
-func (s *Server) installHomeWorkspaces(ctx context.Context, config *rest.Config) error {
-	controllerName := "kcp-home-workspaces"
+func (s *Server) installWorkspaceQuotaController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-quota"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
-
 	kcpClusterClient, err := kcpclient.NewForConfig(config)
 	if err != nil {
 		return err
 	}
-
-	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
+	metadataClient, err := metadata.NewForConfig(config)
 	if err != nil {
 		return err
 	}
-
 	dynamicClusterClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return err
 	}
+	discoverResourcesFn := func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error) {
+		logicalClusterConfig := rest.CopyConfig(config)
+		logicalClusterConfig.Host += clusterName.Path()
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(logicalClusterConfig)
+		if err != nil {
+			return nil, err
+		}
+		return discoveryClient.ServerPreferredResources()
+	}
 
-	homerootController, err := bootstrap.NewController(
-		config,
-		dynamicClusterClient,
-		crdClusterClient,
+	workspaceQuotaController, err := workspacequota.NewController(
 		kcpClusterClient,
+		metadataClient,
+		dynamicClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceQuotas(),
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
-		tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root", Name: "homeroot"},
-		confighomeroot.Bootstrap,
-		sets.NewString(s.Options.Extra.BatteriesIncluded...),
+		discoverResourcesFn,
 	)
 	if err != nil {
 		return err
 	}
 
-	homebucketController, err := bootstrap.NewController(
-		config,
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceQuotaController.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installWorkspacePolicyController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-policy"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	// policy objects are applied impersonating the author of their WorkspacePolicy
+	dynamicClusterClient, err := dynamic.NewForConfig(author.WithImpersonatedAuthor(config))
+	if err != nil {
+		return err
+	}
+
+	workspacePolicyController, err := workspacepolicy.NewController(
 		dynamicClusterClient,
-		crdClusterClient,
 		kcpClusterClient,
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
-		tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root", Name: "homebucket"},
-		confighomebucket.Bootstrap,
-		sets.NewString(s.Options.Extra.BatteriesIncluded...),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspacePolicies(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceQuotas(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		newCachedRESTMapperFunc(config),
 	)
 	if err != nil {
 		return err
@@ -506,73 +615,682 @@ func (s *Server) installHomeWorkspaces(ctx context.Context, config *rest.Config)
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go homerootController.Start(ctx, 2)
-		go homebucketController.Start(ctx, 2)
+		go workspacePolicyController.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installSharedSecretController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-shared-secret"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	kubeClusterClient, err := kubernetesclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 
+	sharedSecretController, err := sharedsecret.NewController(
+		kcpClusterClient,
+		kubeClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().SharedSecrets(),
+		s.KubeSharedInformerFactory.Core().V1().Secrets(),
+		s.MetadataSharedInformerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("namespaces")),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go sharedSecretController.Start(ctx, 2)
 		return nil
 	})
 }
 
-func (s *Server) installApiResourceController(ctx context.Context, config *rest.Config) error {
-	controllerName := "kcp-api-resource-controller"
+func (s *Server) installAccessGrantController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-access-grant"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	accessGrantController, err := accessgrant.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().AccessGrants(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go accessGrantController.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installCacheReplicationController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-cache-replication"
+	config = rest.AddUserAgent(rest.CopyConfig(config), controllerName)
+	localDynamicClusterClient, err := dynamic.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	cacheConfig, err := s.cacheServerConfig(controllerName, cacheshard.New(s.Options.Extra.ShardName))
+	if err != nil {
+		return err
+	}
+	cacheDynamicClusterClient, err := dynamic.NewClusterForConfig(cacheConfig)
+	if err != nil {
+		return err
+	}
+
+	policy, err := cachereplication.Load(s.Options.Extra.CacheReplicationPolicyFile)
+	if err != nil {
+		return err
+	}
+
+	c, err := replication.NewController(s.Options.Extra.ShardName, policy, localDynamicClusterClient, cacheDynamicClusterClient, s.KubeSharedInformerFactory.Core().V1().Secrets())
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+		return nil
+	})
+}
+
+// cacheServerConfig returns a rest.Config for the cache server, targeting the given shard by default.
+func (s *Server) cacheServerConfig(userAgent string, shard cacheshard.Name) (*rest.Config, error) {
+	return newCacheServerConfig(s.Options.Extra.CacheServerKubeconfigFile, userAgent, shard)
+}
+
+func newCacheServerConfig(kubeconfigFile, userAgent string, shard cacheshard.Name) (*rest.Config, error) {
+	cacheConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigFile}, nil).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for the cache server, err: %w", kubeconfigFile, err)
+	}
+	cacheConfig = rest.AddUserAgent(cacheConfig, userAgent)
+	cacheConfig = cacheclient.WithShardRoundTripper(cacheConfig)
+	cacheConfig = cacheclient.WithDefaultShardRoundTripper(cacheConfig, shard)
+	return cacheConfig, nil
+}
+
+func (s *Server) installWorkspaceActivityController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-activity"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	workspaceActivityController, err := workspaceactivity.NewController(
+		kcpClusterClient,
+		s.activityTracker,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceActivityController.Start(ctx)
+		return nil
+	})
+}
+
+func (s *Server) installWorkspaceReaperController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-reaper"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	workspaceReaperController, err := workspacereaper.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.Options.Extra.ShardName,
+		s.Options.Controllers.WorkspaceReaper,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceReaperController.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *rest.Config, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
+	controllerName := "kcp-workload-resource-scheduler"
+	config = rest.AddUserAgent(rest.CopyConfig(config), controllerName)
+	dynamicClusterClient, err := dynamic.NewForConfig(kcpclienthelper.SetMultiClusterRoundTripper(rest.CopyConfig(config)))
+	if err != nil {
+		return err
+	}
+
+	resourceScheduler, err := workloadresource.NewController(
+		dynamicClusterClient,
+		s.DynamicDiscoverySharedInformerFactory,
+		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		s.MetadataSharedInformerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("namespaces")),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go resourceScheduler.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installGarbageCollector(ctx context.Context, config *rest.Config, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
+	controllerName := "kcp-garbage-collector"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicClusterClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := garbagecollector.NewController(
+		kubeClusterClient,
+		dynamicClusterClient,
+		ddsif,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-scheduler"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	dynamicClusterClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	workspaceController, err := clusterworkspace.NewController(
+		s.Options.Extra.ShardName,
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+	)
+	if err != nil {
+		return err
+	}
+
+	// every shard sends heartbeats to its ClusterWorkspaceShard on the root shard
+	rootKcpClusterClient := s.KcpClusterClient
+	if len(s.Options.Extra.RootShardKubeconfigFile) > 0 {
+		rootKcpClusterClient = s.RootShardKcpClusterClient
+	}
+	heartbeat := clusterworkspaceshard.NewHeartbeat(s.Options.Extra.ShardName, rootKcpClusterClient)
+
+	var workspaceShardController *clusterworkspaceshard.Controller
+	if s.Options.Extra.ShardName == tenancyv1alpha1.RootShard {
+		workspaceShardController, err = clusterworkspaceshard.NewController(
+			kcpClusterClient,
+			s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	workspaceTypeController, err := clusterworkspacetype.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+	)
+	if err != nil {
+		return err
+	}
+
+	universalController, err := bootstrap.NewController(
+		config,
+		dynamicClusterClient,
+		crdClusterClient,
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root", Name: "universal"},
+		configuniversal.Bootstrap,
+		sets.NewString(s.Options.Extra.BatteriesIncluded...),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceController.Start(ctx, 2)
+		if workspaceShardController != nil {
+			go workspaceShardController.Start(ctx, 2)
+		}
+		go heartbeat.Start(ctx)
+		go workspaceTypeController.Start(ctx, 2)
+		go universalController.Start(ctx, 2)
+
+		return nil
+	})
+}
+
+func (s *Server) installHomeWorkspaces(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-home-workspaces"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	dynamicClusterClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	homerootController, err := bootstrap.NewController(
+		config,
+		dynamicClusterClient,
+		crdClusterClient,
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root", Name: "homeroot"},
+		confighomeroot.Bootstrap,
+		sets.NewString(s.Options.Extra.BatteriesIncluded...),
+	)
+	if err != nil {
+		return err
+	}
+
+	homebucketController, err := bootstrap.NewController(
+		config,
+		dynamicClusterClient,
+		crdClusterClient,
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		tenancyv1alpha1.ClusterWorkspaceTypeReference{Path: "root", Name: "homebucket"},
+		confighomebucket.Bootstrap,
+		sets.NewString(s.Options.Extra.BatteriesIncluded...),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go homerootController.Start(ctx, 2)
+		go homebucketController.Start(ctx, 2)
+
+		return nil
+	})
+}
+
+func (s *Server) installApiResourceController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-api-resource-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := apiresource.NewController(
+		crdClusterClient,
+		kcpClusterClient,
+		s.Options.Controllers.ApiResource.AutoPublishAPIs,
+		s.KcpSharedInformerFactory.Apiresource().V1alpha1().NegotiatedAPIResources(),
+		s.KcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
+		s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, s.Options.Controllers.ApiResource.NumThreads)
+
+		return nil
+	})
+}
+
+func (s *Server) installSyncTargetHeartbeatController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-synctarget-heartbeat-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := heartbeat.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		s.KcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
+		s.Options.Controllers.SyncTargetHeartbeat.HeartbeatThreshold,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx)
+
+		return nil
+	})
+}
+
+func (s *Server) installAPIBindingController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
+	controllerName := "kcp-apibinding-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicClusterClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := apibinding.NewController(
+		crdClusterClient,
+		kcpClusterClient,
+		dynamicClusterClient,
+		ddsif,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
+		s.TemporaryRootShardKcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.TemporaryRootShardKcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
+		s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		s.KubeSharedInformerFactory.Rbac().V1().ClusterRoleBindings(),
+		s.KubeSharedInformerFactory.Rbac().V1().RoleBindings(),
+	)
+	if err != nil {
+		return err
+	}
+
+	permissionClaimLabelController, err := permissionclaimlabel.NewController(
+		kcpClusterClient,
+		dynamicClusterClient,
+		ddsif,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+	)
+	if err != nil {
+		return err
+	}
+
+	permissionClaimLabelResourceController, err := permissionclaimlabel.NewResourceController(
+		kcpClusterClient,
+		dynamicClusterClient,
+		ddsif,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		// do custom wait logic here because APIExports+APIBindings are special as system CRDs,
+		// and the controllers must run as soon as these two informers are up in order to bootstrap
+		// the rest of the system. Everything else in the kcp clientset is APIBinding based.
+		if err := wait.PollImmediateInfiniteWithContext(util.GoContext(hookContext), time.Millisecond*100, func(ctx context.Context) (bool, error) {
+			crdsSynced := s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().HasSynced()
+			exportsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports().Informer().HasSynced()
+			bindingsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().HasSynced()
+			return crdsSynced && exportsSynced && bindingsSynced, nil
+		}); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+		go permissionClaimLabelController.Start(util.GoContext(hookContext), 5)
+		go permissionClaimLabelResourceController.Start(util.GoContext(hookContext), 2)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	apibindingDeletionController := apibindingdeletion.NewController(
+		metadataClient,
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+	)
+
+	controllerName = "apibinding-deletion-controller"
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go apibindingDeletionController.Start(util.GoContext(hookContext), 10)
+
+		return nil
+	})
+}
+
+func (s *Server) installAPIExportController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-apiexport-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 
-	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
+	kubeClusterClient, err := kubernetesclient.NewForConfig(config)
 	if err != nil {
 		return err
 	}
-	kcpClusterClient, err := kcpclient.NewForConfig(config)
+
+	identityProvider, err := s.Options.Controllers.APIExport.IdentityProvider()
 	if err != nil {
 		return err
 	}
 
-	c, err := apiresource.NewController(
-		crdClusterClient,
+	c, err := apiexport.NewController(
 		kcpClusterClient,
-		s.Options.Controllers.ApiResource.AutoPublishAPIs,
-		s.KcpSharedInformerFactory.Apiresource().V1alpha1().NegotiatedAPIResources(),
-		s.KcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
-		s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		kubeClusterClient,
+		s.MetadataSharedInformerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("namespaces")),
+		s.KubeSharedInformerFactory.Core().V1().Secrets(),
+		identityProvider,
 	)
 	if err != nil {
 		return err
 	}
 
-	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
 		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
-		if err := s.waitForSync(hookContext.StopCh); err != nil {
+		// do custom wait logic here because APIExports+APIBindings are special as system CRDs,
+		// and the controllers must run as soon as these two informers are up in order to bootstrap
+		// the rest of the system. Everything else in the kcp clientset is APIBinding based.
+		if err := wait.PollImmediateInfiniteWithContext(util.GoContext(hookContext), time.Millisecond*100, func(ctx context.Context) (bool, error) {
+			crdsSynced := s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().HasSynced()
+			exportsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports().Informer().HasSynced()
+			bindingsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().HasSynced()
+			return crdsSynced && exportsSynced && bindingsSynced, nil
+		}); err != nil {
 			logger.Error(err, "failed to finish post-start-hook")
 			// nolint:nilerr
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(ctx, s.Options.Controllers.ApiResource.NumThreads)
+		go c.Start(util.GoContext(hookContext), 2)
 
 		return nil
 	})
 }
 
-func (s *Server) installSyncTargetHeartbeatController(ctx context.Context, config *rest.Config) error {
-	controllerName := "kcp-synctarget-heartbeat-controller"
+func (s *Server) installAPIExportUsageController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-apiexport-usage-controller"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
 	kcpClusterClient, err := kcpclient.NewForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	c, err := heartbeat.NewController(
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := apiexportusage.NewController(
 		kcpClusterClient,
-		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
-		s.KcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
-		s.Options.Controllers.SyncTargetHeartbeat.HeartbeatThreshold,
+		metadataClient,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
 	)
 	if err != nil {
 		return err
 	}
 
-	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
 		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
 		if err := s.waitForSync(hookContext.StopCh); err != nil {
 			logger.Error(err, "failed to finish post-start-hook")
@@ -580,14 +1298,14 @@ func (s *Server) installSyncTargetHeartbeatController(ctx context.Context, confi
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(ctx)
+		go c.Start(util.GoContext(hookContext), 2)
 
 		return nil
 	})
 }
 
-func (s *Server) installAPIBindingController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
-	controllerName := "kcp-apibinding-controller"
+func (s *Server) installStorageVersionMigrationController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-storage-version-migration-controller"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
 
@@ -595,90 +1313,96 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 	if err != nil {
 		return err
 	}
-	dynamicClusterClient, err := dynamic.NewForConfig(config)
-	if err != nil {
-		return err
-	}
 
 	crdClusterClient, err := apiextensionsclient.NewForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	metadataClient, err := metadata.NewForConfig(config)
+	dynamicClusterClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	c, err := apibinding.NewController(
-		crdClusterClient,
+	c, err := storageversionmigration.NewController(
 		kcpClusterClient,
+		crdClusterClient,
 		dynamicClusterClient,
-		ddsif,
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
-		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
-		s.KcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
-		s.TemporaryRootShardKcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
-		s.TemporaryRootShardKcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
 		s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
 	)
 	if err != nil {
 		return err
 	}
 
-	permissionClaimLabelController, err := permissionclaimlabel.NewController(
-		kcpClusterClient,
-		dynamicClusterClient,
-		ddsif,
-		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
-		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
-	)
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+
+		return nil
+	})
+}
+
+func (s *Server) installAPIBindingRolesController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-apibinding-roles-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	permissionClaimLabelResourceController, err := permissionclaimlabel.NewResourceController(
-		kcpClusterClient,
-		dynamicClusterClient,
-		ddsif,
+	c, err := apibindingroles.NewController(
+		kubeClusterClient,
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.KubeSharedInformerFactory.Rbac().V1().ClusterRoles(),
 	)
 	if err != nil {
 		return err
 	}
 
-	if err := server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
 		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
-		// do custom wait logic here because APIExports+APIBindings are special as system CRDs,
-		// and the controllers must run as soon as these two informers are up in order to bootstrap
-		// the rest of the system. Everything else in the kcp clientset is APIBinding based.
-		if err := wait.PollImmediateInfiniteWithContext(util.GoContext(hookContext), time.Millisecond*100, func(ctx context.Context) (bool, error) {
-			crdsSynced := s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().HasSynced()
-			exportsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports().Informer().HasSynced()
-			bindingsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().HasSynced()
-			return crdsSynced && exportsSynced && bindingsSynced, nil
-		}); err != nil {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
 			logger.Error(err, "failed to finish post-start-hook")
 			// nolint:nilerr
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
 		go c.Start(util.GoContext(hookContext), 2)
-		go permissionClaimLabelController.Start(util.GoContext(hookContext), 5)
-		go permissionClaimLabelResourceController.Start(util.GoContext(hookContext), 2)
 
 		return nil
-	}); err != nil {
+	})
+}
+
+func (s *Server) installAPIBindingQuotaController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-apibinding-quota-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
 		return err
 	}
 
-	apibindingDeletionController := apibindingdeletion.NewController(
-		metadataClient,
+	c, err := apibindingquota.NewController(
 		kcpClusterClient,
+		s.DynamicDiscoverySharedInformerFactory,
 		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
 	)
+	if err != nil {
+		return err
+	}
 
-	controllerName = "apibinding-deletion-controller"
 	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
 		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
 		if err := s.waitForSync(hookContext.StopCh); err != nil {
@@ -687,34 +1411,27 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go apibindingDeletionController.Start(util.GoContext(hookContext), 10)
+		go c.Start(util.GoContext(hookContext), 2)
 
 		return nil
 	})
 }
 
-func (s *Server) installAPIExportController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
-	controllerName := "kcp-apiexport-controller"
+func (s *Server) installAPIExportNotificationController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
+	controllerName := "kcp-apiexport-notification-controller"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
 
-	kcpClusterClient, err := kcpclient.NewForConfig(config)
-	if err != nil {
-		return err
-	}
-
-	kubeClusterClient, err := kubernetesclient.NewForConfig(config)
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	c, err := apiexport.NewController(
-		kcpClusterClient,
-		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
-		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+	c, err := apiexportnotification.NewController(
 		kubeClusterClient,
-		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
-		s.KubeSharedInformerFactory.Core().V1().Secrets(),
+		ddsif,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
 	)
 	if err != nil {
 		return err
@@ -722,21 +1439,13 @@ func (s *Server) installAPIExportController(ctx context.Context, config *rest.Co
 
 	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
 		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
-		// do custom wait logic here because APIExports+APIBindings are special as system CRDs,
-		// and the controllers must run as soon as these two informers are up in order to bootstrap
-		// the rest of the system. Everything else in the kcp clientset is APIBinding based.
-		if err := wait.PollImmediateInfiniteWithContext(util.GoContext(hookContext), time.Millisecond*100, func(ctx context.Context) (bool, error) {
-			crdsSynced := s.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer().HasSynced()
-			exportsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIExports().Informer().HasSynced()
-			bindingsSynced := s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer().HasSynced()
-			return crdsSynced && exportsSynced && bindingsSynced, nil
-		}); err != nil {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
 			logger.Error(err, "failed to finish post-start-hook")
 			// nolint:nilerr
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go c.Start(util.GoContext(hookContext), 2)
+		go c.Start(util.GoContext(hookContext))
 
 		return nil
 	})
@@ -816,10 +1525,13 @@ func (s *Server) installWorkloadNamespaceScheduler(ctx context.Context, config *
 		return err
 	}
 
+	// The controller reconciles the status conditions of namespaces, hence it cannot use a
+	// metadata-only informer like the scheduling controllers.
 	c, err := workloadnamespace.NewController(
 		kubeClusterClient,
 		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
 		s.KcpSharedInformerFactory.Scheduling().V1alpha1().Placements(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
 	)
 	if err != nil {
 		return err
@@ -880,16 +1592,22 @@ func (s *Server) installSchedulingPlacementController(ctx context.Context, confi
 	controllerName := "kcp-scheduling-placement-controller"
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
 	kcpClusterClient, err := kcpclient.NewForConfig(config)
 	if err != nil {
 		return err
 	}
 
 	c, err := schedulingplacement.NewController(
+		kubeClusterClient,
 		kcpClusterClient,
-		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
+		s.MetadataSharedInformerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("namespaces")),
 		s.KcpSharedInformerFactory.Scheduling().V1alpha1().Locations(),
 		s.KcpSharedInformerFactory.Scheduling().V1alpha1().Placements(),
+		s.KcpSharedInformerFactory.Scheduling().V1alpha1().PlacementPriorities(),
 	)
 	if err != nil {
 		return err
@@ -1009,6 +1727,74 @@ func (s *Server) installWorkloadsSyncTargetExportController(ctx context.Context,
 	})
 }
 
+func (s *Server) installSyncTargetRBACController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-synctarget-rbac-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := synctargetrbac.NewController(
+		kubeClusterClient,
+		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		s.KubeSharedInformerFactory.Rbac().V1().ClusterRoles(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+
+		return nil
+	})
+}
+
+func (s *Server) installSyncerCertificatesController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-syncer-certificates-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := syncercertificates.NewController(
+		kubeClusterClient,
+		s.KubeSharedInformerFactory.Certificates().V1().CertificateSigningRequests(),
+		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		s.syncerCertificateAuthority,
+		s.Options.SyncerCertificates.Duration,
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+
+		return nil
+	})
+}
+
 func (s *Server) installSyncTargetController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
 	controllerName := "kcp-synctarget-controller"
 	config = rest.CopyConfig(config)
@@ -1112,7 +1898,37 @@ func (s *Server) installApiExportIdentityController(ctx context.Context, config
 	if err != nil {
 		return err
 	}
-	c, err := identitycache.NewApiExportIdentityProviderController(kubeClusterClient, s.TemporaryRootShardKcpSharedInformerFactory.Apis().V1alpha1().APIExports(), s.KubeSharedInformerFactory.Core().V1().ConfigMaps())
+	identityProvider, err := s.Options.Controllers.APIExport.IdentityProvider()
+	if err != nil {
+		return err
+	}
+
+	// with a cache server, the identities of the root APIExports are taken from their copies replicated
+	// by the root shard instead of watching the root shard. Copies from other shards are never trusted.
+	rootShardApiExportInformerFactory := s.TemporaryRootShardKcpSharedInformerFactory
+	requireSealedIdentity := false
+	if len(s.Options.Extra.CacheServerKubeconfigFile) > 0 {
+		cacheConfig, err := s.cacheServerConfig(identitycache.ControllerName, cacheshard.New(tenancyv1alpha1.RootShard))
+		if err != nil {
+			return err
+		}
+		policy, err := cachereplication.Load(s.Options.Extra.CacheReplicationPolicyFile)
+		if err != nil {
+			return err
+		}
+		requireSealedIdentity = policy.ReplicatesSealedIdentities()
+		cacheKcpClusterClient, err := kcpclient.NewClusterForConfig(cacheConfig)
+		if err != nil {
+			return err
+		}
+		rootShardApiExportInformerFactory = kcpinformers.NewSharedInformerFactoryWithOptions(
+			cacheKcpClusterClient.Cluster(logicalcluster.Wildcard),
+			resyncPeriod,
+			kcpinformers.WithExtraClusterScopedIndexers(indexers.ClusterScoped()),
+		)
+	}
+
+	c, err := identitycache.NewApiExportIdentityProviderController(kubeClusterClient, rootShardApiExportInformerFactory.Apis().V1alpha1().APIExports(), s.KubeSharedInformerFactory.Core().V1().ConfigMaps(), identityProvider, requireSealedIdentity)
 	if err != nil {
 		return err
 	}
@@ -1124,11 +1940,38 @@ func (s *Server) installApiExportIdentityController(ctx context.Context, config
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
+		if rootShardApiExportInformerFactory != s.TemporaryRootShardKcpSharedInformerFactory {
+			rootShardApiExportInformerFactory.Start(hookContext.StopCh)
+			rootShardApiExportInformerFactory.WaitForCacheSync(hookContext.StopCh)
+		}
+
 		go c.Start(util.GoContext(hookContext), 1)
 		return nil
 	})
 }
 
+// newCachedRESTMapperFunc returns a func returning a discovery based RESTMapper for a logical cluster. The
+// mappers are cached for a while, such that discovery is not done on every reconcile. Mappers missing a kind
+// must be reset by the caller.
+func newCachedRESTMapperFunc(config *rest.Config) func(clusterName logicalcluster.Name) (meta.RESTMapper, error) {
+	mappers := utilcache.NewLRUExpireCache(restMapperCacheSize)
+	return func(clusterName logicalcluster.Name) (meta.RESTMapper, error) {
+		if mapper, found := mappers.Get(clusterName); found {
+			return mapper.(meta.RESTMapper), nil
+		}
+
+		logicalClusterConfig := rest.CopyConfig(config)
+		logicalClusterConfig.Host += clusterName.Path()
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(logicalClusterConfig)
+		if err != nil {
+			return nil, err
+		}
+		mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+		mappers.Add(clusterName, mapper, restMapperCacheTTL)
+		return mapper, nil
+	}
+}
+
 func (s *Server) waitForSync(stop <-chan struct{}) error {
 	// Wait for shared informer factories to by synced.
 	// factory. Otherwise, informer list calls may go into backoff (before the CRDs are ready) and
@@ -27,11 +27,13 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	"github.com/kcp-dev/logicalcluster/v2"
 	jwt2 "gopkg.in/square/go-jose.v2/jwt"
 
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsapiserver "k8s.io/apiextensions-apiserver/pkg/apiserver"
 	"k8s.io/apiextensions-apiserver/pkg/kcp"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -44,15 +46,24 @@ import (
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/sets"
 	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
 	apiserverdiscovery "k8s.io/apiserver/pkg/endpoints/discovery"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/genericcontrolplane"
 	"k8s.io/kubernetes/pkg/genericcontrolplane/aggregator"
 
+	"github.com/kcp-dev/kcp/pkg/admission/customsubresources"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpaudit "github.com/kcp-dev/kcp/pkg/audit"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceactivity"
 )
 
 var (
@@ -60,6 +71,8 @@ var (
 
 	errorScheme = runtime.NewScheme()
 	errorCodecs = serializer.NewCodecFactory(errorScheme)
+
+	writeVerbs = sets.NewString("create", "update", "patch", "delete", "deletecollection")
 )
 
 func init() {
@@ -69,8 +82,7 @@ func init() {
 }
 
 const (
-	passthroughHeader   = "X-Kcp-Api-V1-Discovery-Passthrough"
-	workspaceAnnotation = "tenancy.kcp.dev/workspace"
+	passthroughHeader = "X-Kcp-Api-V1-Discovery-Passthrough"
 )
 
 type (
@@ -184,19 +196,49 @@ func WithAuditAnnotation(handler http.Handler) http.HandlerFunc {
 	})
 }
 
-// WithClusterAnnotation adds the cluster name into the annotation of an audit
-// event. Needs initialized annotations.
-func WithClusterAnnotation(handler http.Handler) http.HandlerFunc {
+// WithClusterAnnotation adds the cluster name and, if not empty, the shard name into the annotation
+// of an audit event. Needs initialized annotations.
+func WithClusterAnnotation(handler http.Handler, shardName string) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		cluster := request.ClusterFrom(req.Context())
 		if cluster != nil {
-			kaudit.AddAuditAnnotation(req.Context(), workspaceAnnotation, cluster.Name.String())
+			kaudit.AddAuditAnnotation(req.Context(), kcpaudit.WorkspaceAnnotationKey, cluster.Name.String())
+		}
+		if shardName != "" {
+			kaudit.AddAuditAnnotation(req.Context(), kcpaudit.ShardAnnotationKey, shardName)
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// WithVirtualWorkspaceOriginAnnotation adds the virtual workspace a request was forwarded from into the
+// annotation of an audit event. Virtual workspace clients are recognized by their user agent, which is only
+// trusted if the authenticated user, i.e. the virtual workspace server, is privileged. Must run after
+// authentication.
+func WithVirtualWorkspaceOriginAnnotation(handler http.Handler) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// the audit event holds the authenticated user, not the impersonated one.
+		if ev := kaudit.AuditEventFrom(req.Context()); ev != nil && sets.NewString(ev.User.Groups...).Has(user.SystemPrivilegedGroup) {
+			if origin := virtualWorkspaceOrigin(req.UserAgent()); origin != "" {
+				kaudit.AddAuditAnnotation(req.Context(), kcpaudit.VirtualWorkspaceOriginAnnotationKey, origin)
+			}
 		}
 
 		handler.ServeHTTP(w, req)
 	})
 }
 
+// virtualWorkspaceOrigin returns the name of the virtual workspace from the user agent of its clients,
+// which end in "/<name>-virtual-workspace", or an empty string.
+func virtualWorkspaceOrigin(userAgent string) string {
+	component := userAgent[strings.LastIndex(userAgent, "/")+1:]
+	if name := strings.TrimSuffix(component, "-virtual-workspace"); name != component && name != "" {
+		return name
+	}
+	return ""
+}
+
 // WithWorkspaceProjection maps the personal virtual workspace "workspaces" resource into the cluster
 // workspace URL space. This means you can do `kubectl get workspaces` from an org workspace.
 func WithWorkspaceProjection(apiHandler http.Handler, shardVirtualWorkspaceURL *url.URL) http.HandlerFunc {
@@ -272,6 +314,125 @@ func WithWildcardListWatchGuard(apiHandler http.Handler) http.HandlerFunc {
 	}
 }
 
+// WithAPIBindingDeprecationWarning adds a warning to resource requests for resources bound by an APIBinding
+// whose APIExport is deprecated, as reported by the APIExportDeprecated condition of the APIBinding.
+// Deprecated versions of otherwise not deprecated APIExports are warned about by the CRD handler, only
+// for requests to those versions.
+func WithAPIBindingDeprecationWarning(apiHandler http.Handler, apiBindingIndexer cache.Indexer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		requestInfo, ok := request.RequestInfoFrom(req.Context())
+		if cluster == nil || cluster.Wildcard || !ok || !requestInfo.IsResourceRequest {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		objs, err := apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, cluster.Name.String())
+		if err != nil {
+			klog.FromContext(req.Context()).WithValues("operation", "WithAPIBindingDeprecationWarning", "cluster", cluster.Name).Error(err, "unable to list APIBindings")
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		for _, obj := range objs {
+			apiBinding := obj.(*apisv1alpha1.APIBinding)
+			cond := conditions.Get(apiBinding, apisv1alpha1.APIExportDeprecated)
+			if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != apisv1alpha1.DeprecatedReason {
+				continue
+			}
+			for _, boundResource := range apiBinding.Status.BoundResources {
+				if boundResource.Group == requestInfo.APIGroup && boundResource.Resource == requestInfo.Resource {
+					warning.AddWarning(req.Context(), "", cond.Message)
+					break
+				}
+			}
+		}
+
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
+// WithActivityTracking records write requests of users to a workspace in the tracker, to be persisted
+// as lastActivityTime of its ClusterWorkspace. Requests of privileged users, i.e. of kcp itself and
+// its controllers, are not user activity.
+func WithActivityTracking(apiHandler http.Handler, tracker *workspaceactivity.Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		requestInfo, ok := request.RequestInfoFrom(req.Context())
+		if cluster == nil || cluster.Wildcard || cluster.Name.Empty() || !ok || !requestInfo.IsResourceRequest || !writeVerbs.Has(requestInfo.Verb) {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		if u, ok := request.UserFrom(req.Context()); ok && !sets.NewString(u.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+			tracker.Record(cluster.Name, time.Now())
+		}
+
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
+// WithCustomSubresources serves the custom subresources of resources bound by an APIBinding, which the
+// CRD handler does not know about. Authorized requests to a custom subresource are rewritten into
+// requests to the resource itself, and marked such that the CustomSubresources admission plugin
+// restricts them to the field owned by the custom subresource.
+func WithCustomSubresources(apiHandler http.Handler, apiBindingIndexer cache.Indexer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		requestInfo, ok := request.RequestInfoFrom(req.Context())
+		if cluster == nil || cluster.Wildcard || !ok || !requestInfo.IsResourceRequest || requestInfo.Name == "" {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		switch requestInfo.Subresource {
+		case "", "status", "scale":
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		objs, err := apiBindingIndexer.ByIndex(indexers.ByLogicalCluster, cluster.Name.String())
+		if err != nil {
+			klog.FromContext(req.Context()).WithValues("operation", "WithCustomSubresources", "cluster", cluster.Name).Error(err, "unable to list APIBindings")
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		var subresource *apisv1alpha1.BoundCustomSubresource
+		for _, obj := range objs {
+			apiBinding := obj.(*apisv1alpha1.APIBinding)
+			for i := range apiBinding.Status.BoundResources {
+				boundResource := &apiBinding.Status.BoundResources[i]
+				if boundResource.Group == requestInfo.APIGroup && boundResource.Resource == requestInfo.Resource {
+					subresource = boundResource.CustomSubresource(requestInfo.APIVersion, requestInfo.Subresource)
+				}
+			}
+		}
+		if subresource == nil {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		if !sets.NewString(subresource.Verbs...).Has(requestInfo.Verb) {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewMethodNotSupported(schema.GroupResource{Group: requestInfo.APIGroup, Resource: requestInfo.Resource + "/" + requestInfo.Subresource}, requestInfo.Verb),
+				errorCodecs, schema.GroupVersion{Group: requestInfo.APIGroup, Version: requestInfo.APIVersion},
+				w, req)
+			return
+		}
+
+		resourceRequestInfo := *requestInfo
+		resourceRequestInfo.Subresource = ""
+		resourceRequestInfo.Parts = requestInfo.Parts[:len(requestInfo.Parts)-1]
+		ctx := request.WithRequestInfo(req.Context(), &resourceRequestInfo)
+		ctx = customsubresources.WithCustomSubresource(ctx, requestInfo.Subresource)
+
+		req = req.WithContext(ctx)
+		resourceURL := *req.URL
+		resourceURL.Path = strings.TrimSuffix(resourceURL.Path, "/"+requestInfo.Subresource)
+		req.URL = &resourceURL
+
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
 // WithInClusterServiceAccountRequestRewrite adds the /clusters/<clusterName> prefix to the request path if the request comes
 // from an InCluster service account requests (InCluster clients don't support prefixes).
 func WithInClusterServiceAccountRequestRewrite(handler http.Handler) http.Handler {
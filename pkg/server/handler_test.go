@@ -17,21 +17,36 @@ limitations under the License.
 package server
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/kcp/pkg/admission/customsubresources"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceactivity"
 )
 
 func TestClusterWorkspaceNamePattern(t *testing.T) {
@@ -97,6 +112,27 @@ func TestReCluster(t *testing.T) {
 	}
 }
 
+func TestVirtualWorkspaceOrigin(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"", ""},
+		{"kubectl/v1.24.0 (linux/amd64) kubernetes/4a3c7e3", ""},
+		{"kcp/v1.24.3 (linux/amd64) kubernetes/4a3c7e3/apiexport-virtual-workspace", "apiexport"},
+		{"kcp/v1.24.3 (linux/amd64) kubernetes/4a3c7e3/initializingworkspaces-virtual-workspace", "initializingworkspaces"},
+		{"kcp/v1.24.3 (linux/amd64) kubernetes/4a3c7e3/-virtual-workspace", ""},
+		{"apiexport-virtual-workspace", "apiexport"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.userAgent, func(t *testing.T) {
+			if got := virtualWorkspaceOrigin(tt.userAgent); got != tt.want {
+				t.Errorf("virtualWorkspaceOrigin(%q) = %q, want %q", tt.userAgent, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestProcessResourceIdentity(t *testing.T) {
 	tests := map[string]struct {
 		path             string
@@ -223,3 +259,260 @@ func TestProcessResourceIdentity(t *testing.T) {
 		})
 	}
 }
+
+type recordedWarnings []string
+
+func (r *recordedWarnings) AddWarning(agent, text string) {
+	*r = append(*r, text)
+}
+
+func TestWithAPIBindingDeprecationWarning(t *testing.T) {
+	binding := func(name string, deprecatedReason string, group, resource string) *apisv1alpha1.APIBinding {
+		b := &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+			},
+			Status: apisv1alpha1.APIBindingStatus{
+				BoundResources: []apisv1alpha1.BoundAPIResource{{Group: group, Resource: resource}},
+			},
+		}
+		if deprecatedReason != "" {
+			conditions.Set(b, &conditionsv1alpha1.Condition{
+				Type:    apisv1alpha1.APIExportDeprecated,
+				Status:  corev1.ConditionTrue,
+				Reason:  deprecatedReason,
+				Message: name + " is deprecated",
+			})
+		}
+		return b
+	}
+
+	tests := map[string]struct {
+		cluster      request.Cluster
+		requestInfo  *request.RequestInfo
+		wantWarnings []string
+	}{
+		"deprecated bound resource": {
+			cluster:      request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo:  &request.RequestInfo{IsResourceRequest: true, APIGroup: "kcp.dev", Resource: "widgets", Verb: "get"},
+			wantWarnings: []string{"widgets-binding is deprecated"},
+		},
+		"bound resource with deprecated versions": {
+			cluster:     request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo: &request.RequestInfo{IsResourceRequest: true, APIGroup: "kcp.dev", APIVersion: "v2", Resource: "sprockets", Verb: "get"},
+		},
+		"not deprecated bound resource": {
+			cluster:     request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo: &request.RequestInfo{IsResourceRequest: true, APIGroup: "kcp.dev", Resource: "gadgets", Verb: "get"},
+		},
+		"other workspace": {
+			cluster:     request.Cluster{Name: logicalcluster.New("root:org:other")},
+			requestInfo: &request.RequestInfo{IsResourceRequest: true, APIGroup: "kcp.dev", Resource: "widgets", Verb: "get"},
+		},
+		"wildcard request": {
+			cluster:     request.Cluster{Wildcard: true},
+			requestInfo: &request.RequestInfo{IsResourceRequest: true, APIGroup: "kcp.dev", Resource: "widgets", Verb: "list"},
+		},
+		"non-resource request": {
+			cluster:     request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo: &request.RequestInfo{Path: "/apis/kcp.dev"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers.Indexers(indexers.ByLogicalCluster))
+			require.NoError(t, indexer.Add(binding("widgets-binding", apisv1alpha1.DeprecatedReason, "kcp.dev", "widgets")))
+			require.NoError(t, indexer.Add(binding("gadgets-binding", "", "kcp.dev", "gadgets")))
+			require.NoError(t, indexer.Add(binding("sprockets-binding", apisv1alpha1.VersionsDeprecatedReason, "kcp.dev", "sprockets")))
+
+			var warnings recordedWarnings
+			ctx := request.WithCluster(context.Background(), tc.cluster)
+			ctx = request.WithRequestInfo(ctx, tc.requestInfo)
+			ctx = warning.WithWarningRecorder(ctx, &warnings)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+			require.NoError(t, err)
+
+			called := false
+			handler := WithAPIBindingDeprecationWarning(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				called = true
+			}), indexer)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.True(t, called)
+			require.Equal(t, tc.wantWarnings, []string(warnings))
+		})
+	}
+}
+
+func TestWithActivityTracking(t *testing.T) {
+	ws := logicalcluster.New("root:org:ws")
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{user.AllAuthenticated}}
+	loopback := &user.DefaultInfo{Name: user.APIServerUser, Groups: []string{user.SystemPrivilegedGroup}}
+
+	tests := map[string]struct {
+		cluster      request.Cluster
+		requestInfo  *request.RequestInfo
+		user         user.Info
+		wantRecorded bool
+	}{
+		"write of a user": {
+			cluster:      request.Cluster{Name: ws},
+			requestInfo:  &request.RequestInfo{IsResourceRequest: true, Resource: "configmaps", Verb: "create"},
+			user:         alice,
+			wantRecorded: true,
+		},
+		"read of a user": {
+			cluster:     request.Cluster{Name: ws},
+			requestInfo: &request.RequestInfo{IsResourceRequest: true, Resource: "configmaps", Verb: "list"},
+			user:        alice,
+		},
+		"write of kcp itself": {
+			cluster:     request.Cluster{Name: ws},
+			requestInfo: &request.RequestInfo{IsResourceRequest: true, Resource: "configmaps", Verb: "update"},
+			user:        loopback,
+		},
+		"wildcard request": {
+			cluster:     request.Cluster{Wildcard: true},
+			requestInfo: &request.RequestInfo{IsResourceRequest: true, Resource: "configmaps", Verb: "list"},
+			user:        alice,
+		},
+		"non-resource request": {
+			cluster:     request.Cluster{Name: ws},
+			requestInfo: &request.RequestInfo{Path: "/healthz", Verb: "get"},
+			user:        alice,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := workspaceactivity.NewTracker()
+
+			ctx := request.WithCluster(context.Background(), tc.cluster)
+			ctx = request.WithRequestInfo(ctx, tc.requestInfo)
+			ctx = request.WithUser(ctx, tc.user)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+			require.NoError(t, err)
+
+			served := false
+			WithActivityTracking(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { served = true }), tracker).ServeHTTP(httptest.NewRecorder(), req)
+			require.True(t, served)
+
+			_, recorded := tracker.Drain()[ws]
+			require.Equal(t, tc.wantRecorded, recorded)
+		})
+	}
+}
+
+func TestWithCustomSubresources(t *testing.T) {
+	binding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "widgets-binding",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+		},
+		Status: apisv1alpha1.APIBindingStatus{
+			BoundResources: []apisv1alpha1.BoundAPIResource{{
+				Group:    "kcp.dev",
+				Resource: "widgets",
+				CustomSubresources: []apisv1alpha1.BoundCustomSubresource{
+					{Version: "v1", Name: "approve", Verbs: []string{"get", "update"}},
+				},
+			}},
+		},
+	}
+	requestInfo := func(verb, subresource string) *request.RequestInfo {
+		ri := &request.RequestInfo{
+			IsResourceRequest: true,
+			Verb:              verb,
+			APIPrefix:         "apis",
+			APIGroup:          "kcp.dev",
+			APIVersion:        "v1",
+			Resource:          "widgets",
+			Name:              "my-widget",
+			Subresource:       subresource,
+			Parts:             []string{"widgets", "my-widget"},
+		}
+		if subresource != "" {
+			ri.Parts = append(ri.Parts, subresource)
+		}
+		return ri
+	}
+
+	tests := map[string]struct {
+		cluster               request.Cluster
+		requestInfo           *request.RequestInfo
+		wantCalled            bool
+		wantSubresource       string
+		wantCustomSubresource string
+		wantPath              string
+		wantCode              int
+	}{
+		"custom subresource": {
+			cluster:               request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo:           requestInfo("update", "approve"),
+			wantCalled:            true,
+			wantCustomSubresource: "approve",
+			wantPath:              "/apis/kcp.dev/v1/widgets/my-widget",
+		},
+		"custom subresource with unsupported verb": {
+			cluster:     request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo: requestInfo("patch", "approve"),
+			wantCode:    http.StatusMethodNotAllowed,
+		},
+		"unknown subresource": {
+			cluster:         request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo:     requestInfo("update", "reject"),
+			wantCalled:      true,
+			wantSubresource: "reject",
+			wantPath:        "/apis/kcp.dev/v1/widgets/my-widget/reject",
+		},
+		"status subresource": {
+			cluster:         request.Cluster{Name: logicalcluster.New("root:org:ws")},
+			requestInfo:     requestInfo("update", "status"),
+			wantCalled:      true,
+			wantSubresource: "status",
+			wantPath:        "/apis/kcp.dev/v1/widgets/my-widget/status",
+		},
+		"other workspace": {
+			cluster:         request.Cluster{Name: logicalcluster.New("root:org:other")},
+			requestInfo:     requestInfo("update", "approve"),
+			wantCalled:      true,
+			wantSubresource: "approve",
+			wantPath:        "/apis/kcp.dev/v1/widgets/my-widget/approve",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, indexers.Indexers(indexers.ByLogicalCluster))
+			require.NoError(t, indexer.Add(binding))
+
+			ctx := request.WithCluster(context.Background(), tc.cluster)
+			ctx = request.WithRequestInfo(ctx, tc.requestInfo)
+			path := "/apis/kcp.dev/v1/widgets/my-widget"
+			if tc.requestInfo.Subresource != "" {
+				path += "/" + tc.requestInfo.Subresource
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPut, path, nil)
+			require.NoError(t, err)
+
+			called := false
+			handler := WithCustomSubresources(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				called = true
+				requestInfo, ok := request.RequestInfoFrom(req.Context())
+				require.True(t, ok)
+				require.Equal(t, tc.wantSubresource, requestInfo.Subresource)
+				require.Equal(t, tc.wantCustomSubresource, customsubresources.CustomSubresourceFrom(req.Context()))
+				require.Equal(t, tc.wantPath, req.URL.Path)
+			}), indexer)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantCalled, called)
+			if tc.wantCode != 0 {
+				require.Equal(t, tc.wantCode, rec.Code)
+			}
+		})
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
+	"github.com/kcp-dev/kcp/pkg/authentication"
 	"github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 )
 
@@ -56,6 +57,13 @@ type AdminAuthentication struct {
 
 	// TODO: move into Secret in-cluster, maybe by using an "in-cluster" string as value
 	ShardAdminTokenHashFilePath string
+
+	// DisableLocalAdmin disables the shard-admin, kcp-admin and user tokens, and the admin kubeconfig.
+	// Emergency access is then only possible through break-glass accounts.
+	DisableLocalAdmin bool
+
+	// BreakGlassAccountsFile is a file with named break-glass accounts with unrestricted access.
+	BreakGlassAccountsFile string
 }
 
 func NewAdminAuthentication(rootDir string) *AdminAuthentication {
@@ -75,6 +83,11 @@ func (s *AdminAuthentication) Validate() []error {
 	if s.ShardAdminTokenHashFilePath == "" && s.KubeConfigPath != "" {
 		errs = append(errs, fmt.Errorf("--admin-kubeconfig requires --admin-token-hash-file-path"))
 	}
+	if s.BreakGlassAccountsFile != "" {
+		if _, err := authentication.LoadBreakGlassAccounts(s.BreakGlassAccountsFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
 	return errs
 }
@@ -88,12 +101,35 @@ func (s *AdminAuthentication) AddFlags(fs *pflag.FlagSet) {
 		"Path to which the administrative kubeconfig should be written at startup. If this is relative, it is relative to --root-directory.")
 	fs.StringVar(&s.ShardAdminTokenHashFilePath, "authentication-admin-token-path", s.ShardAdminTokenHashFilePath,
 		"Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.")
+	fs.BoolVar(&s.DisableLocalAdmin, "disable-local-admin", s.DisableLocalAdmin,
+		"Disable the shard-admin, kcp-admin and user tokens, and do not write the administrative kubeconfig. "+
+			"Use --break-glass-accounts-file for emergency access.")
+	fs.StringVar(&s.BreakGlassAccountsFile, "break-glass-accounts-file", s.BreakGlassAccountsFile,
+		"Path to a YAML file with a list of named break-glass accounts (name, tokenHash as hex encoded SHA-256, expires) "+
+			"with unrestricted access. Requests of these accounts must set the "+authentication.BreakGlassReasonHeader+" header, "+
+			"which is recorded in the audit log. Requires audit logging.")
+}
+
+// BreakGlassAccounts loads the configured break-glass accounts. It returns nil if there are none.
+func (s *AdminAuthentication) BreakGlassAccounts() (*authentication.BreakGlassAccounts, error) {
+	if s.BreakGlassAccountsFile == "" {
+		return nil, nil
+	}
+	return authentication.LoadBreakGlassAccounts(s.BreakGlassAccountsFile)
 }
 
 // ApplyTo returns a new volatile kcp admin token.
 // It also returns a new shard admin token and its hash if the configured shard admin hash file is not present.
 // If the shard admin hash file is present only the shard admin hash is returned and the returned shard admin token is empty.
-func (s *AdminAuthentication) ApplyTo(config *genericapiserver.Config) (volatileKcpAdminToken, shardAdminToken, volatileUserToken string, shardAdminTokenHash []byte, err error) {
+// If the local admin is disabled, no tokens are returned. Break-glass accounts are authenticated if given.
+func (s *AdminAuthentication) ApplyTo(config *genericapiserver.Config, breakGlassAccounts *authentication.BreakGlassAccounts) (volatileKcpAdminToken, shardAdminToken, volatileUserToken string, shardAdminTokenHash []byte, err error) {
+	if breakGlassAccounts != nil {
+		config.Authentication.Authenticator = authenticatorunion.New(breakGlassAccounts.Authenticator(), config.Authentication.Authenticator)
+	}
+	if s.DisableLocalAdmin {
+		return "", "", "", nil, nil
+	}
+
 	// try to load existing token to reuse
 	shardAdminTokenHash, err = ioutil.ReadFile(s.ShardAdminTokenHashFilePath)
 	if os.IsNotExist(err) {
@@ -153,6 +189,10 @@ func (s *AdminAuthentication) ApplyTo(config *genericapiserver.Config) (volatile
 }
 
 func (s *AdminAuthentication) WriteKubeConfig(config genericapiserver.CompletedConfig, kcpAdminToken, shardAdminToken, userToken string, shardAdminTokenHash []byte) error {
+	if s.DisableLocalAdmin {
+		return nil
+	}
+
 	externalCACert, _ := config.SecureServing.Cert.CurrentCertKeyContent()
 	externalKubeConfigHost := fmt.Sprintf("https://%s", config.ExternalAddress)
 
@@ -17,6 +17,8 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -27,6 +29,7 @@ import (
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	kubernetesinformers "k8s.io/client-go/informers"
 
+	"github.com/kcp-dev/kcp/pkg/authentication"
 	"github.com/kcp-dev/kcp/pkg/authorization"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 )
@@ -38,6 +41,10 @@ type Authorization struct {
 
 	// AlwaysAllowGroups are groups which are allowed to take any actions.  In kube, this is system:masters.
 	AlwaysAllowGroups []string
+
+	// DecisionTraces is the number of most recent requests whose authorizer decisions are kept
+	// for the decision trace debug endpoint. Zero disables tracing.
+	DecisionTraces int
 }
 
 func NewAuthorization() *Authorization {
@@ -68,6 +75,10 @@ func (s *Authorization) Validate() []error {
 
 	allErrors := []error{}
 
+	if s.DecisionTraces < 0 {
+		allErrors = append(allErrors, fmt.Errorf("--authorization-decision-traces must not be negative"))
+	}
+
 	return allErrors
 }
 
@@ -79,48 +90,72 @@ func (s *Authorization) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&s.AlwaysAllowPaths, "authorization-always-allow-paths", s.AlwaysAllowPaths,
 		"A list of HTTP paths to skip during authorization, i.e. these are authorized without "+
 			"contacting the 'core' kubernetes server.")
+	fs.IntVar(&s.DecisionTraces, "authorization-decision-traces", s.DecisionTraces,
+		"Number of most recent requests whose authorizer decisions are kept, to be served by audit ID "+
+			"under "+authorization.DecisionTracesPath+"<audit-id> for debugging denied requests. Zero disables tracing.")
 }
 
-func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubernetesinformers.SharedInformerFactory, kcpinformer kcpinformers.SharedInformerFactory) error {
+// ApplyTo sets up the authorizer chain. If decision tracing is enabled, the returned tracer records the
+// decisions of the authorizers of the chain. Break-glass accounts are allowed everything if given.
+func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubernetesinformers.SharedInformerFactory, kcpinformer kcpinformers.SharedInformerFactory, breakGlassAccounts *authentication.BreakGlassAccounts) (*authorization.DecisionTracer, error) {
 	var authorizers []authorizer.Authorizer
+	tracer := authorization.NewDecisionTracer(s.DecisionTraces)
 
 	workspaceLister := kcpinformer.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
 
 	// group authorizer
 	if len(s.AlwaysAllowGroups) > 0 {
-		authorizers = append(authorizers, authorizerfactory.NewPrivilegedGroups(s.AlwaysAllowGroups...))
+		authorizers = append(authorizers, tracer.Trace("privileged-groups", authorizerfactory.NewPrivilegedGroups(s.AlwaysAllowGroups...)))
+	}
+
+	// break-glass authorizer
+	if breakGlassAccounts != nil {
+		authorizers = append(authorizers, tracer.Trace("break-glass", authorization.NewBreakGlassAuthorizer(breakGlassAccounts)))
 	}
 
 	// path authorizer
 	if len(s.AlwaysAllowPaths) > 0 {
 		a, err := path.NewAuthorizer(s.AlwaysAllowPaths)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		authorizers = append(authorizers, a)
+		authorizers = append(authorizers, tracer.Trace("always-allow-paths", a))
 	}
 
 	// kcp authorizers
 	bootstrapAuth, bootstrapRules := authorization.NewBootstrapPolicyAuthorizer(informer)
 	localAuth, localResolver := authorization.NewLocalAuthorizer(informer)
+	accessGrantAuth, err := authorization.NewAccessGrantAuthorizer(informer, kcpinformer)
+	if err != nil {
+		return nil, err
+	}
 	apiBindingAuth, err := authorization.NewAPIBindingAccessAuthorizer(informer, kcpinformer,
-		union.New(bootstrapAuth, localAuth),
+		union.New(
+			tracer.Trace("bootstrap-policy", bootstrapAuth),
+			tracer.Trace("local-policy", localAuth),
+			tracer.Trace("accessgrant", accessGrantAuth),
+		),
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	contentAuth := tracer.Trace("system-crd", authorization.NewSystemCRDAuthorizer(
+		tracer.Trace("maximal-permission-policy", apiBindingAuth),
+	))
+	workspaceContentAuth := tracer.Trace("workspace-content", authorization.NewWorkspaceContentAuthorizer(informer, workspaceLister, contentAuth))
 	authorizers = append(authorizers,
-		authorization.NewTopLevelOrganizationAccessAuthorizer(informer, workspaceLister,
-			authorization.NewWorkspaceContentAuthorizer(informer, workspaceLister,
-				authorization.NewSystemCRDAuthorizer(
-					apiBindingAuth,
-				),
-			),
-		),
+		tracer.Trace("serviceaccountgrant", authorization.NewServiceAccountGrantAuthorizer(kcpinformer,
+			tracer.Trace("team", authorization.NewTeamAuthorizer(kcpinformer,
+				tracer.Trace("toplevel-organization", authorization.NewTopLevelOrganizationAccessAuthorizer(informer, workspaceLister,
+					workspaceContentAuth,
+				)),
+			)),
+			workspaceContentAuth,
+		)),
 	)
 
 	config.RuleResolver = union.NewRuleResolvers(bootstrapRules, localResolver)
-	config.Authorization.Authorizer = union.New(authorizers...)
-	return nil
+	config.Authorization.Authorizer = tracer.Trace("chain", union.New(authorizers...))
+	return tracer, nil
 }
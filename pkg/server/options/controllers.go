@@ -29,7 +29,9 @@ import (
 	"k8s.io/klog/v2"
 	kcmoptions "k8s.io/kubernetes/cmd/kube-controller-manager/app/options"
 
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacereaper"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
 )
 
@@ -37,12 +39,16 @@ type Controllers struct {
 	EnableAll           bool
 	IndividuallyEnabled []string
 	ApiResource         ApiResourceController
+	APIExport           APIExportController
 	SyncTargetHeartbeat SyncTargetHeartbeatController
+	WorkspaceReaper     WorkspaceReaperController
 	SAController        kcmoptions.SAControllerOptions
 }
 
 type ApiResourceController = apiresource.Options
+type APIExportController = apiexport.Options
 type SyncTargetHeartbeatController = heartbeat.Options
+type WorkspaceReaperController = workspacereaper.Options
 
 var kcmDefaults *kcmoptions.KubeControllerManagerOptions
 
@@ -60,7 +66,9 @@ func NewControllers() *Controllers {
 		EnableAll: true,
 
 		ApiResource:         *apiresource.DefaultOptions(),
+		APIExport:           *apiexport.DefaultOptions(),
 		SyncTargetHeartbeat: *heartbeat.DefaultOptions(),
+		WorkspaceReaper:     *workspacereaper.DefaultOptions(),
 		SAController:        *kcmDefaults.SAController,
 	}
 }
@@ -72,7 +80,9 @@ func (c *Controllers) AddFlags(fs *pflag.FlagSet) {
 	fs.MarkHidden("unsupported-run-individual-controllers") //nolint:errcheck
 
 	apiresource.BindOptions(&c.ApiResource, fs)
+	apiexport.BindOptions(&c.APIExport, fs)
 	heartbeat.BindOptions(&c.SyncTargetHeartbeat, fs)
+	workspacereaper.BindOptions(&c.WorkspaceReaper, fs)
 
 	c.SAController.AddFlags(fs)
 }
@@ -109,9 +119,15 @@ func (c *Controllers) Validate() []error {
 	if err := c.ApiResource.Validate(); err != nil {
 		errs = append(errs, err)
 	}
+	if err := c.APIExport.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 	if err := c.SyncTargetHeartbeat.Validate(); err != nil {
 		errs = append(errs, err)
 	}
+	if err := c.WorkspaceReaper.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 	if saErrs := c.SAController.Validate(); saErrs != nil {
 		errs = append(errs, saErrs...)
 	}
@@ -99,10 +99,18 @@ var (
 
 		// KCP Authorization flags
 		"authorization-always-allow-paths", // A list of HTTP paths to skip during authorization, i.e. these are authorized without contacting the 'core' kubernetes server.
+		"authorization-decision-traces",    // Number of most recent requests whose authorizer decisions are kept, to be served by audit ID for debugging denied requests.
 
 		// KCP Admin Authentication flags
 		"authentication-admin-token-path", // Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.
 		"kubeconfig-path",                 // Path to which the administrative kubeconfig should be written at startup.
+		"disable-local-admin",             // Disable the shard-admin, kcp-admin and user tokens, and do not write the administrative kubeconfig.
+		"break-glass-accounts-file",       // Path to a YAML file with a list of named break-glass accounts with unrestricted access.
+
+		// KCP Syncer Certificates flags
+		"syncer-client-ca-file",              // Path to the CA certificate signing syncer client certificates, requested with CertificateSigningRequests of signer workload.kcp.dev/syncer. If set, syncers authenticate with these certificates. It must differ from --client-ca-file, and must not be trusted by the front-proxy.
+		"syncer-client-ca-key-file",          // Path to the private key of --syncer-client-ca-file.
+		"syncer-client-certificate-duration", // Maximum duration of syncer client certificates. Syncers rotate their certificates before they expire.
 
 		// Kubernetes ServiceAccount Token Controller
 		"concurrent-serviceaccount-token-syncs", // The number of service account token objects that are allowed to sync concurrently. Larger number = more responsive token generation, but more CPU (and network) load
@@ -128,16 +136,20 @@ var (
 		"tracing-config-file", // File with apiserver tracing configuration.
 
 		// KCP flags
-		"profiler-address",            // [Address]:port to bind the profiler to
-		"root-directory",              // Root directory.
-		"shard-base-url",              // Base URL to this kcp shard. Defaults to external address.
-		"shard-external-url",          // URL used by outside clients to talk to this kcp shard. Defaults to external address.
-		"shard-virtual-workspace-url", // An external URL address of a virtual workspace server associated with this shard. Defaults to shard's base address.
-		"shard-name",                  // A name of this kcp shard.
-		"shard-kubeconfig-file",       // Kubeconfig holding admin(!) credentials to peer kcp shards.
-		"root-shard-kubeconfig-file",  // Kubeconfig holding admin(!) credentials to the root kcp shard.
-		"experimental-bind-free-port", // Bind to a free port. --secure-bind-port must be 0. Use the admin.kubeconfig to extract the chosen port.
-		"batteries-included",          // A list of batteries included (= default objects that might be unwanted in production, but very helpful in trying out kcp or development).
+		"profiler-address",                       // [Address]:port to bind the profiler to
+		"root-directory",                         // Root directory.
+		"shard-base-url",                         // Base URL to this kcp shard. Defaults to external address.
+		"shard-external-url",                     // URL used by outside clients to talk to this kcp shard. Defaults to external address.
+		"shard-virtual-workspace-url",            // An external URL address of a virtual workspace server associated with this shard. Defaults to shard's base address.
+		"shard-name",                             // A name of this kcp shard.
+		"shard-kubeconfig-file",                  // Kubeconfig holding admin(!) credentials to peer kcp shards.
+		"root-shard-kubeconfig-file",             // Kubeconfig holding admin(!) credentials to the root kcp shard.
+		"cache-server-kubeconfig-file",           // Kubeconfig for the cache server. If set, the objects selected by the replication policy are replicated into the cache server. It must authenticate as system:kcp:shard:<shard name>.
+		"cache-replication-policy-file",          // Path to a file with the policy of which resources, workspaces and labels are replicated into the cache server.
+		"audit-workspace-sinks-file",             // Path to a file configuring audit log files and webhooks that receive the audit events of selected workspaces.
+		"experimental-bind-free-port",            // Bind to a free port. --secure-bind-port must be 0. Use the admin.kubeconfig to extract the chosen port.
+		"batteries-included",                     // A list of batteries included (= default objects that might be unwanted in production, but very helpful in trying out kcp or development).
+		"workqueue-metrics-full-logical-cluster", // Partition the workqueue metrics by the full logical cluster name of the items instead of by their organization.
 
 		// secure serving flags
 		"bind-address",                     // The IP address on which to listen for the --secure-port port. The associated interface(s) must be reachable by the rest of the cluster, and by CLI/web clients. If blank or an unspecified address (0.0.0.0 or ::), all interfaces will be used.
@@ -170,12 +182,22 @@ var (
 		"home-workspaces-root-prefix",            // Logical cluster name of the workspace that will contains home workspaces for all workspaces.
 
 		// KCP Controllers flags
-		"auto-publish-apis",                      // If true, the APIs imported from physical clusters will be published automatically as CRDs
-		"apiresource-controller-threads",         // Number of threads to use for the apiresource controller.
-		"run-controllers",                        // Run the controllers in-process
-		"run-virtual-workspaces",                 // Run the virtual workspaces apiservers in-process
-		"unsupported-run-individual-controllers", // Run individual controllers in-process. The controller names can change at any time.
-		"sync-target-heartbeat-threshold",        // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"auto-publish-apis",                             // If true, the APIs imported from physical clusters will be published automatically as CRDs
+		"apiresource-controller-threads",                // Number of threads to use for the apiresource controller.
+		"apiexport-identity-encryption-provider-config", // The file containing the encryption provider configuration used to seal APIExport identity keys, e.g. with an external KMS.
+		"run-controllers",                               // Run the controllers in-process
+		"run-virtual-workspaces",                        // Run the virtual workspaces apiservers in-process
+		"unsupported-run-individual-controllers",        // Run individual controllers in-process. The controller names can change at any time.
+		"sync-target-heartbeat-threshold",               // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"workspace-idle-ttl",                            // Duration without user activity after which a workspace is idle, and reaped according to --workspace-idle-action.
+		"workspace-idle-action",                         // What to do with idle workspaces: "Flag" marks them with a false WorkspaceActive condition, "Delete" deletes them in addition.
+
+		// KCP Virtual Workspaces flags
+		"virtual-workspaces-apiexport-shard-kubeconfig-file", // Kubeconfig with a context for every peer kcp shard, named after the shard. If set, the APIExport virtual workspace serves the objects of consumers on all shards.
+		"virtual-workspaces-apiexport-shard-name",            // The name of the kcp shard the APIExport virtual workspace runs next to. Required with --virtual-workspaces-apiexport-shard-kubeconfig-file.
+		"virtual-workspaces-rate-limit-qps",                  // Maximum sustained requests per second of every user to a virtual workspace. Zero disables the rate limiting.
+		"virtual-workspaces-rate-limit-burst",                // Maximum burst of requests of every user to a virtual workspace above --virtual-workspaces-rate-limit-qps.
+		"virtual-workspaces-rate-limit-overrides",            // Rate limits of specific virtual workspaces in the format <qps>:<burst>, by virtual workspace name.
 
 		// generic flags
 		"cors-allowed-origins",                 // List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching. If this list is empty CORS will not be enabled.
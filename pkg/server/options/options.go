@@ -35,6 +35,8 @@ import (
 	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
 	kcpadmission "github.com/kcp-dev/kcp/pkg/admission"
+	kcpaudit "github.com/kcp-dev/kcp/pkg/audit"
+	"github.com/kcp-dev/kcp/pkg/cache/replication"
 	etcdoptions "github.com/kcp-dev/kcp/pkg/embeddedetcd/options"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/server/options/batteries"
@@ -46,6 +48,7 @@ type Options struct {
 	Controllers         Controllers
 	Authorization       Authorization
 	AdminAuthentication AdminAuthentication
+	SyncerCertificates  SyncerCertificates
 	Virtual             Virtual
 	HomeWorkspaces      HomeWorkspaces
 
@@ -53,16 +56,20 @@ type Options struct {
 }
 
 type ExtraOptions struct {
-	RootDirectory            string
-	ProfilerAddress          string
-	ShardKubeconfigFile      string
-	RootShardKubeconfigFile  string
-	ShardBaseURL             string
-	ShardExternalURL         string
-	ShardName                string
-	ShardVirtualWorkspaceURL string
-	DiscoveryPollInterval    time.Duration
-	ExperimentalBindFreePort bool
+	RootDirectory              string
+	ProfilerAddress            string
+	ShardKubeconfigFile        string
+	RootShardKubeconfigFile    string
+	CacheServerKubeconfigFile  string
+	CacheReplicationPolicyFile string
+	AuditWorkspaceSinksFile    string
+	ShardBaseURL               string
+	ShardExternalURL           string
+	ShardName                  string
+	ShardVirtualWorkspaceURL   string
+	DiscoveryPollInterval      time.Duration
+	ExperimentalBindFreePort   bool
+	FullLogicalClusterMetrics  bool
 
 	BatteriesIncluded []string
 }
@@ -73,6 +80,7 @@ type completedOptions struct {
 	Controllers         Controllers
 	Authorization       Authorization
 	AdminAuthentication AdminAuthentication
+	SyncerCertificates  SyncerCertificates
 	Virtual             Virtual
 	HomeWorkspaces      HomeWorkspaces
 
@@ -93,6 +101,7 @@ func NewOptions(rootDir string) *Options {
 		Controllers:         *NewControllers(),
 		Authorization:       *NewAuthorization(),
 		AdminAuthentication: *NewAdminAuthentication(rootDir),
+		SyncerCertificates:  *NewSyncerCertificates(),
 		Virtual:             *NewVirtual(),
 		HomeWorkspaces:      *NewHomeWorkspaces(),
 
@@ -158,18 +167,23 @@ func (o *Options) rawFlags() cliflag.NamedFlagSets {
 	o.Controllers.AddFlags(fss.FlagSet("KCP Controllers"))
 	o.Authorization.AddFlags(fss.FlagSet("KCP Authorization"))
 	o.AdminAuthentication.AddFlags(fss.FlagSet("KCP Authentication"))
+	o.SyncerCertificates.AddFlags(fss.FlagSet("KCP Authentication"))
 	o.Virtual.AddFlags(fss.FlagSet("KCP Virtual Workspaces"))
 	o.HomeWorkspaces.AddFlags(fss.FlagSet("KCP Home Workspaces"))
 
 	fs := fss.FlagSet("KCP")
 	fs.StringVar(&o.Extra.ProfilerAddress, "profiler-address", o.Extra.ProfilerAddress, "[Address]:port to bind the profiler to")
-	fs.StringVar(&o.Extra.ShardKubeconfigFile, "shard-kubeconfig-file", o.Extra.ShardKubeconfigFile, "Kubeconfig holding admin(!) credentials to peer kcp shards.")
+	fs.StringVar(&o.Extra.ShardKubeconfigFile, "shard-kubeconfig-file", o.Extra.ShardKubeconfigFile, "Kubeconfig holding admin(!) credentials to peer kcp shards, with a context for every peer shard named after the shard. If set, workspaces can be migrated between shards with WorkspaceMigrations.")
 	fs.StringVar(&o.Extra.RootShardKubeconfigFile, "root-shard-kubeconfig-file", o.Extra.RootShardKubeconfigFile, "Kubeconfig holding admin(!) credentials to the root kcp shard.")
+	fs.StringVar(&o.Extra.CacheServerKubeconfigFile, "cache-server-kubeconfig-file", o.Extra.CacheServerKubeconfigFile, "Kubeconfig for the cache server. If set, the objects selected by the replication policy are replicated into the cache server. It must authenticate as system:kcp:shard:<shard name>.")
+	fs.StringVar(&o.Extra.CacheReplicationPolicyFile, "cache-replication-policy-file", o.Extra.CacheReplicationPolicyFile, "Path to a file with the policy of which resources, workspaces and labels are replicated into the cache server. If empty, APIExports and APIResourceSchemas of all workspaces are replicated.")
+	fs.StringVar(&o.Extra.AuditWorkspaceSinksFile, "audit-workspace-sinks-file", o.Extra.AuditWorkspaceSinksFile, "Path to a file configuring audit log files and webhooks that receive the audit events of selected workspaces, e.g. one per organization. Requires --audit-policy-file.")
 	fs.StringVar(&o.Extra.ShardBaseURL, "shard-base-url", o.Extra.ShardBaseURL, "Base URL to this kcp shard. Defaults to external address.")
 	fs.StringVar(&o.Extra.ShardExternalURL, "shard-external-url", o.Extra.ShardExternalURL, "URL used by outside clients to talk to this kcp shard. Defaults to external address.")
 	fs.StringVar(&o.Extra.ShardName, "shard-name", o.Extra.ShardName, "A name of this kcp shard. Defaults to the \"root\" name.")
 	fs.StringVar(&o.Extra.ShardVirtualWorkspaceURL, "shard-virtual-workspace-url", o.Extra.ShardVirtualWorkspaceURL, "An external URL address of a virtual workspace server associated with this shard. Defaults to shard's base address.")
 	fs.StringVar(&o.Extra.RootDirectory, "root-directory", o.Extra.RootDirectory, "Root directory.")
+	fs.BoolVar(&o.Extra.FullLogicalClusterMetrics, "workqueue-metrics-full-logical-cluster", o.Extra.FullLogicalClusterMetrics, "Partition the workqueue metrics by the full logical cluster name of the items instead of by their organization. The number of time series grows with the number of workspaces, hence only enable this for debugging.")
 
 	fs.BoolVar(&o.Extra.ExperimentalBindFreePort, "experimental-bind-free-port", o.Extra.ExperimentalBindFreePort, "Bind to a free port. --secure-port must be 0. Use the admin.kubeconfig to extract the chosen port.")
 	fs.MarkHidden("experimental-bind-free-port") // nolint:errcheck
@@ -201,9 +215,36 @@ func (o *CompletedOptions) Validate() []error {
 	errs = append(errs, o.EmbeddedEtcd.Validate()...)
 	errs = append(errs, o.Authorization.Validate()...)
 	errs = append(errs, o.AdminAuthentication.Validate()...)
+	errs = append(errs, o.SyncerCertificates.Validate(o.GenericControlPlane.Authentication.ClientCert.ClientCA)...)
 	errs = append(errs, o.Virtual.Validate()...)
 	errs = append(errs, o.HomeWorkspaces.Validate()...)
 
+	if len(o.Extra.CacheReplicationPolicyFile) > 0 && len(o.Extra.CacheServerKubeconfigFile) == 0 {
+		errs = append(errs, fmt.Errorf("--cache-replication-policy-file requires --cache-server-kubeconfig-file"))
+	}
+	if policy, err := replication.Load(o.Extra.CacheReplicationPolicyFile); err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, policy.Validate()...)
+	}
+
+	if len(o.AdminAuthentication.BreakGlassAccountsFile) > 0 && (len(o.GenericControlPlane.Audit.PolicyFile) == 0 ||
+		(len(o.GenericControlPlane.Audit.LogOptions.Path) == 0 && len(o.GenericControlPlane.Audit.WebhookOptions.ConfigFile) == 0)) {
+		errs = append(errs, fmt.Errorf("--break-glass-accounts-file requires --audit-policy-file and one of --audit-log-path or --audit-webhook-config-file"))
+	}
+	if o.AdminAuthentication.DisableLocalAdmin && sets.NewString(o.Extra.BatteriesIncluded...).Has(batteries.User) {
+		errs = append(errs, fmt.Errorf("--disable-local-admin cannot be used with the %q battery", batteries.User))
+	}
+
+	if len(o.Extra.AuditWorkspaceSinksFile) > 0 && len(o.GenericControlPlane.Audit.PolicyFile) == 0 {
+		errs = append(errs, fmt.Errorf("--audit-workspace-sinks-file requires --audit-policy-file"))
+	}
+	if sinksConfig, err := kcpaudit.LoadSinksConfig(o.Extra.AuditWorkspaceSinksFile); err != nil {
+		errs = append(errs, err)
+	} else if sinksConfig != nil {
+		errs = append(errs, sinksConfig.Validate()...)
+	}
+
 	differential := false
 	for i, b := range o.Extra.BatteriesIncluded {
 		if strings.HasPrefix(b, "+") || strings.HasPrefix(b, "-") {
@@ -332,6 +373,7 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 			Controllers:         o.Controllers,
 			Authorization:       o.Authorization,
 			AdminAuthentication: o.AdminAuthentication,
+			SyncerCertificates:  o.SyncerCertificates,
 			Virtual:             o.Virtual,
 			HomeWorkspaces:      o.HomeWorkspaces,
 			Extra:               o.Extra,
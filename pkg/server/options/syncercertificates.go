@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"crypto"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/kubernetes/pkg/controller/certificates/authority"
+
+	"github.com/kcp-dev/kcp/pkg/authentication"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+type SyncerCertificates struct {
+	ClientCAFile    string
+	ClientCAKeyFile string
+
+	Duration time.Duration
+}
+
+func NewSyncerCertificates() *SyncerCertificates {
+	return &SyncerCertificates{
+		Duration: 24 * time.Hour,
+	}
+}
+
+func (s *SyncerCertificates) AddFlags(fs *pflag.FlagSet) {
+	if s == nil {
+		return
+	}
+
+	fs.StringVar(&s.ClientCAFile, "syncer-client-ca-file", s.ClientCAFile,
+		"Path to the CA certificate signing syncer client certificates, requested with CertificateSigningRequests of signer workload.kcp.dev/syncer. If set, syncers authenticate with these certificates. It must differ from --client-ca-file, and must not be trusted by the front-proxy.")
+	fs.StringVar(&s.ClientCAKeyFile, "syncer-client-ca-key-file", s.ClientCAKeyFile,
+		"Path to the private key of --syncer-client-ca-file.")
+	fs.DurationVar(&s.Duration, "syncer-client-certificate-duration", s.Duration,
+		"Maximum duration of syncer client certificates. Syncers rotate their certificates before they expire.")
+}
+
+// Validate checks the options. clientCAFile is the value of --client-ca-file, which must not be the same CA
+// as the syncer client CA: certificates signed by the latter are only valid while their sync target exists,
+// which the generic client certificate authenticator does not check.
+func (s *SyncerCertificates) Validate(clientCAFile string) []error {
+	if s == nil {
+		return nil
+	}
+
+	var errs []error
+
+	if (s.ClientCAFile == "") != (s.ClientCAKeyFile == "") {
+		errs = append(errs, fmt.Errorf("--syncer-client-ca-file and --syncer-client-ca-key-file must be set together"))
+	}
+	if s.ClientCAFile != "" && clientCAFile != "" && sharesCertificate(s.ClientCAFile, clientCAFile) {
+		errs = append(errs, fmt.Errorf("--syncer-client-ca-file must not contain a CA of --client-ca-file"))
+	}
+	if s.Duration < 10*time.Minute {
+		errs = append(errs, fmt.Errorf("--syncer-client-certificate-duration must be at least 10m"))
+	}
+
+	return errs
+}
+
+// sharesCertificate returns whether the given PEM files are the same file or have a certificate in common.
+// Files which cannot be read or parsed are reported by ApplyTo.
+func sharesCertificate(fileA, fileB string) bool {
+	if filepath.Clean(fileA) == filepath.Clean(fileB) {
+		return true
+	}
+	certsA, err := certutil.CertsFromFile(fileA)
+	if err != nil {
+		return false
+	}
+	certsB, err := certutil.CertsFromFile(fileB)
+	if err != nil {
+		return false
+	}
+	for _, a := range certsA {
+		for _, b := range certsB {
+			if a.Equal(b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Enabled returns whether syncer client certificates are issued and authenticated.
+func (s *SyncerCertificates) Enabled() bool {
+	return s != nil && s.ClientCAFile != ""
+}
+
+// ApplyTo adds the syncer client CA to the client CAs of the server and authenticates syncers by
+// their client certificates. It returns the certificate authority signing syncer client
+// certificates, or nil if syncer client certificates are disabled.
+func (s *SyncerCertificates) ApplyTo(config *genericapiserver.Config, kcpInformers kcpinformers.SharedInformerFactory) (*authority.CertificateAuthority, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+
+	certPEM, err := os.ReadFile(s.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --syncer-client-ca-file: %w", err)
+	}
+	certs, err := certutil.ParseCertsPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --syncer-client-ca-file: %w", err)
+	}
+	keyPEM, err := os.ReadFile(s.ClientCAKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --syncer-client-ca-key-file: %w", err)
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --syncer-client-ca-key-file: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("--syncer-client-ca-key-file does not contain a signing key")
+	}
+
+	clientCA, err := dynamiccertificates.NewStaticCAContent("syncer-client-ca", certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if config.SecureServing != nil {
+		if config.SecureServing.ClientCA != nil {
+			config.SecureServing.ClientCA = dynamiccertificates.NewUnionCAContentProvider(config.SecureServing.ClientCA, clientCA)
+		} else {
+			config.SecureServing.ClientCA = clientCA
+		}
+	}
+	config.Authentication.Authenticator = authentication.WithSyncerCertificateAuthentication(config.Authentication.Authenticator, clientCA, kcpInformers)
+
+	return &authority.CertificateAuthority{
+		RawCert:     certPEM,
+		RawKey:      keyPEM,
+		Certificate: certs[0],
+		PrivateKey:  signer,
+	}, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func TestSyncerCertificatesValidate(t *testing.T) {
+	dir := t.TempDir()
+	writeCA := func(name string) string {
+		certPEM, _, err := certutil.GenerateSelfSignedCertKey(name, nil, nil)
+		require.NoError(t, err)
+		path := filepath.Join(dir, name+".crt")
+		require.NoError(t, os.WriteFile(path, certPEM, 0600))
+		return path
+	}
+	syncerCA := writeCA("syncer-ca")
+	clientCA := writeCA("client-ca")
+
+	bundle, err := os.ReadFile(clientCA)
+	require.NoError(t, err)
+	syncerPEM, err := os.ReadFile(syncerCA)
+	require.NoError(t, err)
+	clientBundle := filepath.Join(dir, "client-bundle.crt")
+	require.NoError(t, os.WriteFile(clientBundle, append(bundle, syncerPEM...), 0600))
+	syncerCopy := filepath.Join(dir, "syncer-ca-copy.crt")
+	require.NoError(t, os.WriteFile(syncerCopy, syncerPEM, 0600))
+
+	tests := []struct {
+		name         string
+		clientCAFile string
+		wantErr      bool
+	}{
+		{name: "no client ca"},
+		{name: "different client ca", clientCAFile: clientCA},
+		{name: "same file", clientCAFile: syncerCA, wantErr: true},
+		{name: "same ca in another file", clientCAFile: syncerCopy, wantErr: true},
+		{name: "client ca bundle containing the syncer ca", clientCAFile: clientBundle, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSyncerCertificates()
+			s.ClientCAFile = syncerCA
+			s.ClientCAKeyFile = filepath.Join(dir, "syncer-ca.key")
+
+			errs := s.Validate(tt.clientCAFile)
+			if tt.wantErr {
+				require.Len(t, errs, 1)
+			} else {
+				require.Empty(t, errs)
+			}
+		})
+	}
+}
@@ -41,11 +41,13 @@ import (
 	configshard "github.com/kcp-dev/kcp/config/shard"
 	systemcrds "github.com/kcp-dev/kcp/config/system-crds"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization"
 	bootstrappolicy "github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
 	"github.com/kcp-dev/kcp/pkg/util"
 )
 
@@ -83,6 +85,11 @@ func NewServer(c CompletedConfig) (*Server, error) {
 		),
 	)
 
+	if c.authorizationTracer != nil {
+		// served behind authentication and authorization, i.e. by default only to system:masters
+		s.MiniAggregator.GenericAPIServer.Handler.NonGoRestfulMux.HandlePrefix(authorization.DecisionTracesPath, c.authorizationTracer)
+	}
+
 	s.DynamicDiscoverySharedInformerFactory, err = informer.NewDynamicDiscoverySharedInformerFactory(
 		s.MiniAggregator.GenericAPIServer.LoopbackClientConfig,
 		func(obj interface{}) bool { return true },
@@ -91,6 +98,8 @@ func NewServer(c CompletedConfig) (*Server, error) {
 			indexers.NamespaceScoped(),
 			cache.Indexers{
 				indexers.BySyncerFinalizerKey: indexers.IndexBySyncerFinalizerKey,
+				indexers.ByUID:                indexers.IndexByUID,
+				indexers.ByOwnerUID:           indexers.IndexByOwnerUID,
 			},
 		),
 	)
@@ -98,6 +107,13 @@ func NewServer(c CompletedConfig) (*Server, error) {
 		return nil, err
 	}
 
+	// Only inform on bound CRDs while APIBindings bind them. Bound CRDs outlive their APIBindings, and informing on
+	// all of them would cost memory for APIs nobody uses anymore.
+	s.DynamicDiscoverySharedInformerFactory.InformOnlyUsedBoundAPIs(
+		apibinding.ShadowWorkspaceName,
+		s.KcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+	)
+
 	return s, nil
 }
 
@@ -115,9 +131,11 @@ func (s *Server) Run(ctx context.Context) error {
 		logger := logger.WithValues("postStartHook", hookName)
 		s.KubeSharedInformerFactory.Start(hookContext.StopCh)
 		s.ApiExtensionsSharedInformerFactory.Start(hookContext.StopCh)
+		s.MetadataSharedInformerFactory.Start(hookContext.StopCh)
 
 		s.KubeSharedInformerFactory.WaitForCacheSync(hookContext.StopCh)
 		s.ApiExtensionsSharedInformerFactory.WaitForCacheSync(hookContext.StopCh)
+		s.MetadataSharedInformerFactory.WaitForCacheSync(hookContext.StopCh)
 
 		select {
 		case <-hookContext.StopCh:
@@ -292,6 +310,10 @@ func (s *Server) Run(ctx context.Context) error {
 
 		s.KcpSharedInformerFactory.Start(hookContext.StopCh)
 		s.KcpSharedInformerFactory.WaitForCacheSync(hookContext.StopCh)
+		if s.CacheKcpSharedInformerFactory != nil {
+			// not waited for: the cache server being unavailable must not block the shard from starting.
+			s.CacheKcpSharedInformerFactory.Start(hookContext.StopCh)
+		}
 
 		select {
 		case <-hookContext.StopCh:
@@ -394,6 +416,14 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installWorkloadsSyncTargetExportController(ctx, controllerConfig, delegationChainHead); err != nil {
 			return err
 		}
+		if err := s.installSyncTargetRBACController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+		if s.syncerCertificateAuthority != nil {
+			if err := s.installSyncerCertificatesController(ctx, controllerConfig, delegationChainHead); err != nil {
+				return err
+			}
+		}
 	}
 
 	if s.Options.Controllers.EnableAll || enabled.Has("workspace-scheduler") {
@@ -403,6 +433,37 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installWorkspaceDeletionController(ctx, controllerConfig); err != nil {
 			return err
 		}
+		if err := s.installWorkspaceDefaultObjectsController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if err := s.installWorkspaceMoveController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if len(s.Options.Extra.ShardKubeconfigFile) > 0 {
+			if err := s.installWorkspaceMigrationController(ctx, controllerConfig); err != nil {
+				return err
+			}
+		}
+		if err := s.installWorkspaceQuotaController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if err := s.installWorkspacePolicyController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if err := s.installSharedSecretController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if err := s.installAccessGrantController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if err := s.installWorkspaceActivityController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if s.Options.Controllers.WorkspaceReaper.IdleTTL > 0 {
+			if err := s.installWorkspaceReaperController(ctx, controllerConfig); err != nil {
+				return err
+			}
+		}
 	}
 
 	if s.Options.HomeWorkspaces.Enabled {
@@ -429,6 +490,36 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	if s.Options.Controllers.EnableAll || enabled.Has("apiexport-usage") {
+		if err := s.installAPIExportUsageController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.EnableAll || enabled.Has("storage-version-migration") {
+		if err := s.installStorageVersionMigrationController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.EnableAll || enabled.Has("apibinding-roles") {
+		if err := s.installAPIBindingRolesController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.EnableAll || enabled.Has("apibinding-quota") {
+		if err := s.installAPIBindingQuotaController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Controllers.EnableAll || enabled.Has("apiexport-notification") {
+		if err := s.installAPIExportNotificationController(ctx, controllerConfig, delegationChainHead, s.DynamicDiscoverySharedInformerFactory); err != nil {
+			return err
+		}
+	}
+
 	if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.LocationAPI) {
 		if s.Options.Controllers.EnableAll || enabled.Has("scheduling") {
 			if err := s.installWorkloadNamespaceScheduler(ctx, controllerConfig, delegationChainHead); err != nil {
@@ -461,6 +552,18 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	if s.Options.Controllers.EnableAll || enabled.Has("garbage-collector") {
+		if err := s.installGarbageCollector(ctx, controllerConfig, s.DynamicDiscoverySharedInformerFactory); err != nil {
+			return err
+		}
+	}
+
+	if len(s.Options.Extra.CacheServerKubeconfigFile) > 0 && (s.Options.Controllers.EnableAll || enabled.Has("cache-replication")) {
+		if err := s.installCacheReplicationController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
 	if s.Options.Virtual.Enabled {
 		if err := s.installVirtualWorkspaces(ctx, controllerConfig, delegationChainHead, s.GenericConfig.Authentication, s.GenericConfig.ExternalAddress, s.preHandlerChainMux); err != nil {
 			return err
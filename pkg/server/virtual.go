@@ -85,6 +85,7 @@ func (s *Server) installVirtualWorkspaces(
 		return err
 	}
 	rootAPIServerConfig.GenericConfig.ExternalAddress = externalAddress
+	rootAPIServerConfig.ExtraConfig.RateLimiter = s.Options.Virtual.VirtualWorkspaces.RateLimiting.Limiter()
 
 	completedRootAPIServerConfig := rootAPIServerConfig.Complete()
 
@@ -26,6 +26,8 @@ import (
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -40,6 +42,7 @@ import (
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/crdpuller"
 	clusterctl "github.com/kcp-dev/kcp/pkg/reconciler/workload/basecontroller"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
 var clusterKind = reflect.TypeOf(workloadv1alpha1.SyncTarget{}).Name()
@@ -71,7 +74,11 @@ func NewAPIImporter(
 
 	kcpClient := kcpClusterClient.Cluster(logicalClusterName)
 	kcpInformerFactory := kcpinformers.NewSharedInformerFactoryWithOptions(kcpClient, resyncPeriod)
-	clusterIndexer := kcpInformerFactory.Workload().V1alpha1().SyncTargets().Informer().GetIndexer()
+	// the syncer is only allowed to list and watch its own SyncTarget
+	syncTargetInformerFactory := kcpinformers.NewSharedInformerFactoryWithOptions(kcpClient, resyncPeriod, kcpinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", location).String()
+	}))
+	clusterIndexer := syncTargetInformerFactory.Workload().V1alpha1().SyncTargets().Informer().GetIndexer()
 	importIndexer := kcpInformerFactory.Apiresource().V1alpha1().APIResourceImports().Informer().GetIndexer()
 
 	indexers := map[string]cache.IndexFunc{
@@ -105,11 +112,12 @@ func NewAPIImporter(
 	}
 
 	return &APIImporter{
-		kcpInformerFactory:       kcpInformerFactory,
-		kcpClusterClient:         kcpClusterClient,
-		resourcesToSync:          resourcesToSync,
-		apiresourceImportIndexer: importIndexer,
-		clusterIndexer:           clusterIndexer,
+		kcpInformerFactory:        kcpInformerFactory,
+		syncTargetInformerFactory: syncTargetInformerFactory,
+		kcpClusterClient:          kcpClusterClient,
+		resourcesToSync:           resourcesToSync,
+		apiresourceImportIndexer:  importIndexer,
+		clusterIndexer:            clusterIndexer,
 
 		location:           location,
 		logicalClusterName: logicalClusterName,
@@ -118,11 +126,12 @@ func NewAPIImporter(
 }
 
 type APIImporter struct {
-	kcpInformerFactory       kcpinformers.SharedInformerFactory
-	kcpClusterClient         *kcpclient.Cluster
-	resourcesToSync          []string
-	apiresourceImportIndexer cache.Indexer
-	clusterIndexer           cache.Indexer
+	kcpInformerFactory        kcpinformers.SharedInformerFactory
+	syncTargetInformerFactory kcpinformers.SharedInformerFactory
+	kcpClusterClient          *kcpclient.Cluster
+	resourcesToSync           []string
+	apiresourceImportIndexer  cache.Indexer
+	clusterIndexer            cache.Indexer
 
 	location           string
 	logicalClusterName logicalcluster.Name
@@ -134,7 +143,9 @@ func (i *APIImporter) Start(ctx context.Context, pollInterval time.Duration) {
 	defer runtime.HandleCrash()
 
 	i.kcpInformerFactory.Start(ctx.Done())
+	i.syncTargetInformerFactory.Start(ctx.Done())
 	i.kcpInformerFactory.WaitForCacheSync(ctx.Done())
+	i.syncTargetInformerFactory.WaitForCacheSync(ctx.Done())
 
 	klog.Infof("Starting API Importer for location %s in cluster %s", i.location, i.logicalClusterName)
 
@@ -207,12 +218,7 @@ func (i *APIImporter) ImportAPIs(ctx context.Context) {
 				continue
 			}
 		} else {
-			apiResourceImportName := gvr.Resource + "." + i.location + "." + gvr.Version + "."
-			if gvr.Group == "" {
-				apiResourceImportName = apiResourceImportName + "core"
-			} else {
-				apiResourceImportName = apiResourceImportName + gvr.Group
-			}
+			apiResourceImportName := shared.GetAPIResourceImportName(schema.GroupVersionResource(gvr), i.location)
 
 			clusterKey, err := cache.MetaNamespaceKeyFunc(&metav1.PartialObjectMetadata{
 				ObjectMeta: metav1.ObjectMeta{
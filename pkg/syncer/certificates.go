@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/certificate"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/klog/v2"
+	kubeletcertificate "k8s.io/kubernetes/pkg/kubelet/certificate"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// EnableClientCertificates switches the given upstream config to client certificate authentication.
+// A certificate for the SyncTarget is requested through a CertificateSigningRequest in the SyncTarget
+// workspace, using the credentials of the config for the first request and the current certificate
+// afterwards. The certificate is stored in certDir and rotated before it expires. Once a certificate
+// is available, the bearer token of the config is dropped.
+func EnableClientCertificates(ctx context.Context, upstreamConfig *rest.Config, certDir string, syncTargetWorkspace logicalcluster.Name, syncTargetName, syncTargetUID string) error {
+	store, err := certificate.NewFileStore("kcp-syncer-client", certDir, certDir, "", "")
+	if err != nil {
+		return fmt.Errorf("failed to initialize certificate store in %q: %w", certDir, err)
+	}
+
+	bootstrapConfig := rest.CopyConfig(upstreamConfig)
+	manager, err := certificate.NewManager(&certificate.Config{
+		ClientsetFn: func(current *tls.Certificate) (kubernetesclient.Interface, error) {
+			config := rest.CopyConfig(bootstrapConfig)
+			if current != nil {
+				if err := withClientCertificate(config, current); err != nil {
+					return nil, err
+				}
+			}
+			kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
+			if err != nil {
+				return nil, err
+			}
+			return kubeClusterClient.Cluster(syncTargetWorkspace), nil
+		},
+		Template: &x509.CertificateRequest{
+			Subject: pkix.Name{
+				CommonName:         workloadv1alpha1.SyncerCertificateUserName(syncTargetWorkspace, syncTargetName),
+				Organization:       []string{workloadv1alpha1.SyncerCertificateGroup},
+				OrganizationalUnit: []string{syncTargetUID},
+			},
+		},
+		SignerName: workloadv1alpha1.SyncerSignerName,
+		Usages: []certificatesv1.KeyUsage{
+			certificatesv1.UsageDigitalSignature,
+			certificatesv1.UsageKeyEncipherment,
+			certificatesv1.UsageClientAuth,
+		},
+		CertificateStore: store,
+		Name:             "syncer client",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize client certificate manager: %w", err)
+	}
+
+	upstreamConfig.BearerToken = ""
+	upstreamConfig.BearerTokenFile = ""
+	upstreamConfig.Username = ""
+	upstreamConfig.Password = ""
+	if _, err := kubeletcertificate.UpdateTransport(ctx.Done(), upstreamConfig, manager, 0); err != nil {
+		return fmt.Errorf("failed to configure client certificate rotation: %w", err)
+	}
+
+	manager.Start()
+	go func() {
+		<-ctx.Done()
+		manager.Stop()
+	}()
+
+	klog.Infof("Waiting for a client certificate for SyncTarget %s|%s", syncTargetWorkspace, syncTargetName)
+	return wait.PollImmediateUntilWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+		return manager.Current() != nil, nil
+	})
+}
+
+// withClientCertificate replaces the credentials of the config with the given certificate.
+func withClientCertificate(config *rest.Config, cert *tls.Certificate) error {
+	if len(cert.Certificate) == 0 || cert.PrivateKey == nil {
+		return fmt.Errorf("incomplete client certificate")
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(cert.PrivateKey)
+	if err != nil {
+		return err
+	}
+	var certPEM []byte
+	for _, der := range cert.Certificate {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	config.BearerToken = ""
+	config.BearerTokenFile = ""
+	config.Username = ""
+	config.Password = ""
+	config.CertFile = ""
+	config.KeyFile = ""
+	config.CertData = certPEM
+	config.KeyData = keyPEM
+	return nil
+}
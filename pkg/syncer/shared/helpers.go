@@ -17,8 +17,12 @@ limitations under the License.
 package shared
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"strings"
 
+	"github.com/martinlindhe/base36"
+
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
@@ -34,3 +38,11 @@ func DeprecatedGetAssignedSyncTarget(labels map[string]string) string {
 	}
 	return ""
 }
+
+// GetSyncerID returns a unique ID for a syncer derived from the name and its UID. It's
+// a valid DNS segment and can be used as namespace or object names.
+func GetSyncerID(syncTarget *workloadv1alpha1.SyncTarget) string {
+	syncerHash := sha256.Sum224([]byte(syncTarget.UID))
+	base36hash := strings.ToLower(base36.EncodeBytes(syncerHash[:]))
+	return fmt.Sprintf("kcp-syncer-%s-%s", syncTarget.Name, base36hash[:8])
+}
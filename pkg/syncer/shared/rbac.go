@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"sort"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// GetAPIResourceImportName returns the name of the APIResourceImport the syncer of the given
+// SyncTarget creates for the given resource.
+func GetAPIResourceImportName(gvr schema.GroupVersionResource, syncTargetName string) string {
+	group := gvr.Group
+	if group == "" {
+		group = "core"
+	}
+	return gvr.Resource + "." + syncTargetName + "." + gvr.Version + "." + group
+}
+
+// SyncerClusterRoleRules returns the rules of the ClusterRole bound to the service account of the
+// syncer of the given SyncTarget. They grant access to that single SyncTarget and to the
+// APIResourceImports of its accepted synced resources only. APIResourceImports of resources not
+// accepted yet can still be created, and so can CertificateSigningRequests for syncer client
+// certificates.
+func SyncerClusterRoleRules(syncTarget *workloadv1alpha1.SyncTarget) []rbacv1.PolicyRule {
+	rules := []rbacv1.PolicyRule{
+		{
+			Verbs:         []string{"sync", "upsync", "get", "list", "watch"},
+			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			ResourceNames: []string{syncTarget.Name},
+			Resources:     []string{"synctargets"},
+		},
+		{
+			Verbs:         []string{"get", "update", "patch"},
+			APIGroups:     []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			ResourceNames: []string{syncTarget.Name},
+			Resources:     []string{"synctargets/status"},
+		},
+		{
+			// create, list and watch cannot be restricted to resource names.
+			Verbs:     []string{"create", "list", "watch"},
+			APIGroups: []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"apiresourceimports"},
+		},
+		{
+			// to request and rotate syncer client certificates.
+			Verbs:     []string{"create", "get", "list", "watch"},
+			APIGroups: []string{certificatesv1.SchemeGroupVersion.Group},
+			Resources: []string{"certificatesigningrequests"},
+		},
+	}
+
+	var importNames []string
+	for _, r := range syncTarget.Status.SyncedResources {
+		if r.State != workloadv1alpha1.ResourceSchemaAcceptedState {
+			continue
+		}
+		for _, v := range r.Versions {
+			importNames = append(importNames, GetAPIResourceImportName(schema.GroupVersionResource{Group: r.Group, Version: v, Resource: r.Resource}, syncTarget.Name))
+		}
+	}
+	if len(importNames) > 0 {
+		sort.Strings(importNames)
+		rules = append(rules, rbacv1.PolicyRule{
+			Verbs:         []string{"get", "update", "delete"},
+			APIGroups:     []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
+			ResourceNames: importNames,
+			Resources:     []string{"apiresourceimports"},
+		})
+	}
+
+	return rules
+}
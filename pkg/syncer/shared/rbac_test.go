@@ -0,0 +1,54 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestSyncerClusterRoleRules(t *testing.T) {
+	syncTarget := &workloadv1alpha1.SyncTarget{ObjectMeta: metav1.ObjectMeta{Name: "us-east1"}}
+
+	rules := SyncerClusterRoleRules(syncTarget)
+	require.Len(t, rules, 4, "no apiresourceimports can be updated without synced resources")
+	for _, rule := range rules[:2] {
+		require.Equal(t, []string{"us-east1"}, rule.ResourceNames)
+	}
+
+	syncTarget.Status.SyncedResources = []workloadv1alpha1.ResourceToSync{
+		{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaAcceptedState},
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "services"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaAcceptedState},
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaPendingState},
+		{GroupResource: apisv1alpha1.GroupResource{Resource: "secrets"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaIncomptibleState},
+	}
+	rules = SyncerClusterRoleRules(syncTarget)
+	require.Len(t, rules, 5)
+	require.Equal(t, rbacv1.PolicyRule{
+		Verbs:         []string{"get", "update", "delete"},
+		APIGroups:     []string{"apiresource.kcp.dev"},
+		ResourceNames: []string{"deployments.us-east1.v1.apps", "services.us-east1.v1.core"},
+		Resources:     []string{"apiresourceimports"},
+	}, rules[4])
+}
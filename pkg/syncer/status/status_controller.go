@@ -34,6 +34,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
@@ -60,7 +61,7 @@ func NewStatusSyncer(gvrs []schema.GroupVersionResource, syncTargetWorkspace log
 	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID) (*Controller, error) {
 
 	c := &Controller{
-		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		queue: kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
 
 		upstreamClient:            upstreamClient,
 		downstreamClient:          downstreamClient,
@@ -26,6 +26,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
 )
 
 func setup(t *testing.T) (*http.Client, string, func()) {
@@ -38,7 +40,7 @@ func setup(t *testing.T) (*http.Client, string, func()) {
 
 	// public server
 	mux := http.NewServeMux()
-	apiHandler := WithSyncerTunnel(mux)
+	apiHandler := WithSyncerTunnel(mux, func(logicalcluster.Name) bool { return true })
 	publicServer := httptest.NewUnstartedServer(apiHandler)
 	publicServer.EnableHTTP2 = true
 	publicServer.StartTLS()
@@ -135,7 +137,7 @@ func Test_integration_listener_reconnect(t *testing.T) {
 
 	// public server
 	mux := http.NewServeMux()
-	apiHandler := WithSyncerTunnel(mux)
+	apiHandler := WithSyncerTunnel(mux, func(logicalcluster.Name) bool { return true })
 	publicServer := httptest.NewUnstartedServer(apiHandler)
 	publicServer.EnableHTTP2 = true
 	publicServer.StartTLS()
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/aojea/rwconn"
+	"github.com/kcp-dev/logicalcluster/v2"
 
 	"k8s.io/klog/v2"
 
@@ -113,7 +114,9 @@ func SyncerTunnelURL(host, ws, target string) (string, error) {
 //
 // https://host/services/syncer-tunnels/clusters/<ws>/apis/workload.kcp.dev/v1alpha1/synctargets/<name>/connect establish reverse connections and queue them so it can be consumed by the dialer
 // https://host/services/syncer-tunnels/clusters/<ws>/apis/workload.kcp.dev/v1alpha1/synctargets/<name>/proxy/{path} proxies the {path} through the reverse connection identified by the cluster and syncer name
-func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
+//
+// Requests for workspaces for which enabled returns false fall through to the apiHandler.
+func WithSyncerTunnel(apiHandler http.Handler, enabled func(clusterName logicalcluster.Name) bool) http.HandlerFunc {
 	pool := newTunnelPool()
 	return func(w http.ResponseWriter, r *http.Request) {
 		// fall through, syncer tunnels URL start by /services/tunnels
@@ -125,6 +128,10 @@ func WithSyncerTunnel(apiHandler http.Handler) http.HandlerFunc {
 		// route the request
 		p := strings.TrimPrefix(r.URL.Path, defaultTunnelPathPrefix)
 		path := strings.Split(strings.Trim(p, "/"), "/")
+		if !enabled(logicalcluster.New(path[0])) {
+			apiHandler.ServeHTTP(w, r)
+			return
+		}
 		if len(path) < 7 {
 			http.Error(w, "invalid path", http.StatusInternalServerError)
 			return
@@ -28,27 +28,33 @@ import (
 
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/dynamic"
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/server/requestinfo"
 	"github.com/kcp-dev/kcp/pkg/virtual/apiexport/controllers/apireconciler"
 	"github.com/kcp-dev/kcp/pkg/virtual/apiexport/schemas"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
 	virtualdynamic "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/impersonation"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/metrics"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
 )
 
@@ -67,6 +73,15 @@ func BuildVirtualWorkspace(
 
 	readyCh := make(chan struct{})
 
+	apiBindingInformer := wildcardKcpInformers.Apis().V1alpha1().APIBindings()
+	indexers.AddIfNotPresentOrDie(apiBindingInformer.Informer().GetIndexer(), cache.Indexers{
+		indexers.APIBindingByAPIExport: indexers.IndexAPIBindingByAPIExport,
+	})
+
+	listAPIBindings := func(apiExportClusterName logicalcluster.Name, apiExportName string) ([]*apisv1alpha1.APIBinding, error) {
+		return indexers.ByIndex[*apisv1alpha1.APIBinding](apiBindingInformer.Informer().GetIndexer(), indexers.APIBindingByAPIExport, clusters.ToClusterAwareKey(apiExportClusterName, apiExportName))
+	}
+
 	apiBindingsName := VirtualWorkspaceName + "-apibindings"
 	apiBindings := &virtualdynamic.DynamicVirtualWorkspace{
 		RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, ctx context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
@@ -83,7 +98,7 @@ func BuildVirtualWorkspace(
 			completedContext = dynamiccontext.WithAPIDomainKey(completedContext, apiDomain)
 			return true, prefixToStrip, completedContext
 		}),
-		Authorizer: newAuthorizer(kubeClusterClient),
+		Authorizer: newImpersonationAuthorizer(newAuthorizer(kubeClusterClient), listAPIBindings, newWorkspaceUserChecker(kubeClusterClient)),
 		ReadyChecker: framework.ReadyFunc(func() error {
 			select {
 			case <-readyCh:
@@ -129,14 +144,28 @@ func BuildVirtualWorkspace(
 				kcpClusterClient,
 				wildcardKcpInformers.Apis().V1alpha1().APIResourceSchemas(),
 				wildcardKcpInformers.Apis().V1alpha1().APIExports(),
-				func(apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, identityHash string, optionalLabelRequirements labels.Requirements) (apidefinition.APIDefinition, error) {
+				func(apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, identityHash string, optionalLabelRequirements, createLabelRequirements labels.Requirements, optionalNames sets.String) (apidefinition.APIDefinition, error) {
 					ctx, cancelFn := context.WithCancel(context.Background())
 
-					var wrapper forwardingregistry.StorageWrapper = nil
+					var wrappers []forwardingregistry.StorageWrapper
 					if len(optionalLabelRequirements) > 0 {
-						wrapper = forwardingregistry.WithLabelSelector(func(_ context.Context) labels.Requirements {
+						wrappers = append(wrappers, forwardingregistry.WithLabelSelectors(func(_ context.Context) labels.Requirements {
 							return optionalLabelRequirements
-						})
+						}, func(_ context.Context) labels.Requirements {
+							return createLabelRequirements
+						}))
+					}
+					if optionalNames != nil {
+						wrappers = append(wrappers, forwardingregistry.WithNames(optionalNames))
+					}
+					var wrapper forwardingregistry.StorageWrapper = nil
+					if len(wrappers) > 0 {
+						wrapper = func(resource schema.GroupResource, storage *forwardingregistry.StoreFuncs) *forwardingregistry.StoreFuncs {
+							for _, w := range wrappers {
+								storage = w(resource, storage)
+							}
+							return storage
+						}
 					}
 
 					storageBuilder := provideDelegatingRestStorage(ctx, dynamicClusterClient, identityHash, wrapper)
@@ -161,7 +190,9 @@ func BuildVirtualWorkspace(
 				for name, informer := range map[string]cache.SharedIndexInformer{
 					"apiresourceschemas": wildcardKcpInformers.Apis().V1alpha1().APIResourceSchemas().Informer(),
 					"apiexports":         wildcardKcpInformers.Apis().V1alpha1().APIExports().Informer(),
+					"apibindings":        apiBindingInformer.Informer(),
 				} {
+					informer.AddEventHandler(metrics.InformerLagHandler(VirtualWorkspaceName, name))
 					if !cache.WaitForNamedCacheSync(name, hookContext.StopCh, informer.HasSynced) {
 						klog.Errorf("informer not synced")
 						return nil
@@ -176,11 +207,24 @@ func BuildVirtualWorkspace(
 
 			return apiReconciler, nil
 		},
-		Authorizer: newAuthorizer(kubeClusterClient),
+		Authorizer: newImpersonationAuthorizer(
+			newClaimScopeAuthorizer(
+				newAuthorizer(kubeClusterClient),
+				func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+					return wildcardKcpInformers.Apis().V1alpha1().APIExports().Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+				},
+				func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+					return wildcardKcpInformers.Apis().V1alpha1().APIResourceSchemas().Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+				},
+				listAPIBindings,
+			),
+			listAPIBindings,
+			newWorkspaceUserChecker(kubeClusterClient),
+		),
 	}
 
 	return []rootapiserver.NamedVirtualWorkspace{
-		{Name: VirtualWorkspaceName, VirtualWorkspace: boundOrClaimedWorkspaceContent}, // this must come first because a claim will show all bindings, not only those for the export
+		{Name: VirtualWorkspaceName, URLTemplate: rootPathPrefix + "{apiExportWorkspace}/{apiExportName}/clusters/{cluster}", VirtualWorkspace: boundOrClaimedWorkspaceContent}, // this must come first because a claim will show all bindings, not only those for the export
 		{Name: apiBindingsName, VirtualWorkspace: apiBindings},
 	}, nil
 }
@@ -304,10 +348,21 @@ func newAuthorizer(client kubernetesclient.ClusterInterface) authorizer.Authoriz
 			return authorizer.DecisionNoOpinion, "error", err
 		}
 
+		// impersonating requests are authorized against the service provider, not the impersonated consumer user.
+		// The impersonation itself is authorized before, with the service provider as user.
+		requester := attr.GetUser()
+		if virtualcontext.ImpersonationFrom(ctx) && attr.GetVerb() != "impersonate" {
+			impersonator, ok := impersonation.ImpersonatorFrom(requester)
+			if !ok {
+				return authorizer.DecisionNoOpinion, "unable to determine impersonator", fmt.Errorf("access not permitted")
+			}
+			requester = impersonator
+		}
+
 		SARAttributes := authorizer.AttributesRecord{
 			APIGroup:        apisv1alpha1.SchemeGroupVersion.Group,
 			APIVersion:      apisv1alpha1.SchemeGroupVersion.Version,
-			User:            attr.GetUser(),
+			User:            requester,
 			Verb:            attr.GetVerb(),
 			Name:            apiExportName,
 			Resource:        "apiexports",
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+// newClaimScopeAuthorizer denies requests to claimed resources that are outside of the verbs and
+// object names of the permission claim, or that target a workspace which has not accepted the claim,
+// and delegates all other requests. Label selectors of claims are enforced by the storage for every
+// verb, and so is the acceptance of claims for wildcard requests.
+func newClaimScopeAuthorizer(
+	delegate authorizer.Authorizer,
+	getAPIExport func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error),
+	getAPIResourceSchema func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error),
+	listAPIBindings func(apiExportClusterName logicalcluster.Name, apiExportName string) ([]*apisv1alpha1.APIBinding, error),
+) authorizer.AuthorizerFunc {
+	return func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		if !attr.IsResourceRequest() {
+			return delegate.Authorize(ctx, attr)
+		}
+
+		parts := strings.Split(string(dynamiccontext.APIDomainKeyFrom(ctx)), "/")
+		if len(parts) < 2 {
+			return authorizer.DecisionNoOpinion, "unable to determine api export", fmt.Errorf("access not permitted")
+		}
+		apiExportClusterName := logicalcluster.New(parts[0])
+
+		apiExport, err := getAPIExport(apiExportClusterName, parts[1])
+		if apierrors.IsNotFound(err) {
+			return delegate.Authorize(ctx, attr)
+		} else if err != nil {
+			return authorizer.DecisionNoOpinion, "error", err
+		}
+
+		claim, err := claimFor(apiExport, attr.GetAPIGroup(), attr.GetResource(), func(name string) (*apisv1alpha1.APIResourceSchema, error) {
+			return getAPIResourceSchema(apiExportClusterName, name)
+		})
+		if err != nil {
+			return authorizer.DecisionNoOpinion, "error", err
+		}
+		if claim != nil {
+			if allowed, reason := claimAllows(claim, attr); !allowed {
+				return authorizer.DecisionDeny, reason, nil
+			}
+
+			if cluster := genericapirequest.ClusterFrom(ctx); cluster != nil && !cluster.Wildcard {
+				apiBindings, err := listAPIBindings(apiExportClusterName, parts[1])
+				if err != nil {
+					return authorizer.DecisionNoOpinion, "error", err
+				}
+				if !claimAccepted(apiBindings, cluster.Name, *claim) {
+					return authorizer.DecisionDeny, fmt.Sprintf("permission claim for %s is not accepted in workspace %s", claim, cluster.Name), nil
+				}
+			}
+		}
+
+		return delegate.Authorize(ctx, attr)
+	}
+}
+
+// claimFor returns the permission claim of the APIExport for the given resource, or nil if the
+// resource is not claimed or exported by the APIExport itself.
+func claimFor(apiExport *apisv1alpha1.APIExport, group, resource string, getAPIResourceSchema func(name string) (*apisv1alpha1.APIResourceSchema, error)) (*apisv1alpha1.PermissionClaim, error) {
+	var claim *apisv1alpha1.PermissionClaim
+	for i := range apiExport.Spec.PermissionClaims {
+		if pc := &apiExport.Spec.PermissionClaims[i]; pc.Group == group && pc.Resource == resource {
+			claim = pc
+			break
+		}
+	}
+	if claim == nil {
+		return nil, nil
+	}
+
+	// exported resources have priority over claimed resources
+	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
+		schema, err := getAPIResourceSchema(schemaName)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if schema.Spec.Group == group && schema.Spec.Names.Plural == resource {
+			return nil, nil
+		}
+	}
+
+	return claim, nil
+}
+
+// claimAccepted returns whether an APIBinding in the given workspace accepted the claim.
+func claimAccepted(apiBindings []*apisv1alpha1.APIBinding, clusterName logicalcluster.Name, claim apisv1alpha1.PermissionClaim) bool {
+	for _, apiBinding := range apiBindings {
+		if logicalcluster.From(apiBinding) != clusterName {
+			continue
+		}
+		if apiBinding.PermissionClaimState(claim) == apisv1alpha1.ClaimAccepted {
+			return true
+		}
+	}
+	return false
+}
+
+// claimAllows returns whether the request is within the verbs and object names of the claim, and
+// a reason if not. Requests without object name are only allowed for list and watch if the claim
+// is restricted to names, because the storage filters their results.
+func claimAllows(claim *apisv1alpha1.PermissionClaim, attr authorizer.Attributes) (bool, string) {
+	if !claim.AllowsVerb(attr.GetVerb()) {
+		return false, fmt.Sprintf("permission claim for %s does not include verb %q", claim, attr.GetVerb())
+	}
+
+	if claim.ResourceSelector == nil || len(claim.ResourceSelector.Names) == 0 {
+		return true, ""
+	}
+	if name := attr.GetName(); name != "" {
+		if !claim.AllowsName(name) {
+			return false, fmt.Sprintf("permission claim for %s does not include object %q", claim, name)
+		}
+		return true, ""
+	}
+	switch attr.GetVerb() {
+	case "list", "watch":
+		return true, ""
+	default:
+		return false, fmt.Sprintf("permission claim for %s is restricted to named objects", claim)
+	}
+}
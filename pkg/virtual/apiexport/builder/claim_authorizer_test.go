@@ -0,0 +1,242 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+func TestClaimScopeAuthorizer(t *testing.T) {
+	claim := func(verbs []string, names ...string) apisv1alpha1.PermissionClaim {
+		pc := apisv1alpha1.PermissionClaim{
+			GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"},
+			Verbs:         verbs,
+		}
+		if len(names) > 0 {
+			pc.ResourceSelector = &apisv1alpha1.ResourceSelector{Names: names}
+		}
+		return pc
+	}
+
+	tests := []struct {
+		name         string
+		claims       []apisv1alpha1.PermissionClaim
+		exported     bool
+		verb         string
+		resource     string
+		objectName   string
+		cluster      *genericapirequest.Cluster
+		claimState   apisv1alpha1.AcceptablePermissionClaimState
+		wantDecision authorizer.Decision
+	}{
+		{
+			name:         "unrestricted claim delegates",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil)},
+			verb:         "delete",
+			resource:     "configmaps",
+			objectName:   "foo",
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "claimed verb delegates",
+			claims:       []apisv1alpha1.PermissionClaim{claim([]string{"get", "list", "watch"})},
+			verb:         "get",
+			resource:     "configmaps",
+			objectName:   "foo",
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "unclaimed verb is denied",
+			claims:       []apisv1alpha1.PermissionClaim{claim([]string{"get", "list", "watch"})},
+			verb:         "update",
+			resource:     "configmaps",
+			objectName:   "foo",
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "claimed name delegates",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil, "foo")},
+			verb:         "update",
+			resource:     "configmaps",
+			objectName:   "foo",
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "unclaimed name is denied",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil, "foo")},
+			verb:         "get",
+			resource:     "configmaps",
+			objectName:   "bar",
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "list of claim restricted to names delegates",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil, "foo")},
+			verb:         "list",
+			resource:     "configmaps",
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "create of claim restricted to names is denied",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil, "foo")},
+			verb:         "create",
+			resource:     "configmaps",
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "exported resource is not restricted by claim",
+			claims:       []apisv1alpha1.PermissionClaim{claim([]string{"get"})},
+			exported:     true,
+			verb:         "update",
+			resource:     "configmaps",
+			objectName:   "foo",
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "unclaimed resource delegates",
+			claims:       []apisv1alpha1.PermissionClaim{claim([]string{"get"})},
+			verb:         "update",
+			resource:     "secrets",
+			objectName:   "foo",
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "accepted claim in workspace delegates",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil)},
+			verb:         "create",
+			resource:     "configmaps",
+			cluster:      &genericapirequest.Cluster{Name: logicalcluster.New("root:org:consumer")},
+			claimState:   apisv1alpha1.ClaimAccepted,
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "pending claim in workspace is denied",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil)},
+			verb:         "create",
+			resource:     "configmaps",
+			cluster:      &genericapirequest.Cluster{Name: logicalcluster.New("root:org:consumer")},
+			claimState:   apisv1alpha1.ClaimPending,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "rejected claim in workspace is denied",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil)},
+			verb:         "get",
+			resource:     "configmaps",
+			objectName:   "foo",
+			cluster:      &genericapirequest.Cluster{Name: logicalcluster.New("root:org:consumer")},
+			claimState:   apisv1alpha1.ClaimRejected,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "claim in workspace without binding is denied",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil)},
+			verb:         "get",
+			resource:     "configmaps",
+			objectName:   "foo",
+			cluster:      &genericapirequest.Cluster{Name: logicalcluster.New("root:org:other")},
+			claimState:   apisv1alpha1.ClaimAccepted,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "wildcard request is left to the storage",
+			claims:       []apisv1alpha1.PermissionClaim{claim(nil)},
+			verb:         "list",
+			resource:     "configmaps",
+			cluster:      &genericapirequest.Cluster{Wildcard: true},
+			claimState:   apisv1alpha1.ClaimRejected,
+			wantDecision: authorizer.DecisionAllow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiExport := &apisv1alpha1.APIExport{
+				Spec: apisv1alpha1.APIExportSpec{
+					LatestResourceSchemas: []string{"today.configmaps.core"},
+					PermissionClaims:      tt.claims,
+				},
+			}
+			getAPIExport := func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIExport, error) {
+				require.Equal(t, "root:org:ws", clusterName.String())
+				require.Equal(t, "my-export", name)
+				return apiExport, nil
+			}
+			getAPIResourceSchema := func(clusterName logicalcluster.Name, name string) (*apisv1alpha1.APIResourceSchema, error) {
+				if !tt.exported {
+					return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiresourceschemas"), name)
+				}
+				return &apisv1alpha1.APIResourceSchema{
+					ObjectMeta: metav1.ObjectMeta{Name: name},
+					Spec: apisv1alpha1.APIResourceSchemaSpec{
+						Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "configmaps"},
+					},
+				}, nil
+			}
+			listAPIBindings := func(apiExportClusterName logicalcluster.Name, apiExportName string) ([]*apisv1alpha1.APIBinding, error) {
+				require.Equal(t, "root:org:ws", apiExportClusterName.String())
+				require.Equal(t, "my-export", apiExportName)
+				apiBinding := &apisv1alpha1.APIBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "my-binding",
+						Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:consumer"},
+					},
+				}
+				if tt.claimState != apisv1alpha1.ClaimPending {
+					for _, pc := range tt.claims {
+						apiBinding.Spec.PermissionClaims = append(apiBinding.Spec.PermissionClaims, apisv1alpha1.AcceptablePermissionClaim{
+							PermissionClaim: pc,
+							State:           tt.claimState,
+						})
+					}
+				}
+				return []*apisv1alpha1.APIBinding{apiBinding}, nil
+			}
+			delegate := authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+				return authorizer.DecisionAllow, "", nil
+			})
+
+			authz := newClaimScopeAuthorizer(delegate, getAPIExport, getAPIResourceSchema, listAPIBindings)
+			ctx := dynamiccontext.WithAPIDomainKey(context.Background(), "root:org:ws/my-export")
+			if tt.cluster != nil {
+				ctx = genericapirequest.WithCluster(ctx, *tt.cluster)
+			}
+			decision, _, err := authz.Authorize(ctx, authorizer.AttributesRecord{
+				User:            &user.DefaultInfo{Name: "user"},
+				Verb:            tt.verb,
+				Resource:        tt.resource,
+				Name:            tt.objectName,
+				ResourceRequest: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantDecision, decision)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/impersonation"
+)
+
+// impersonatableResources are the resources of the impersonation requests which can be authorized. Service
+// accounts, UIDs and user extras, e.g. scopes, cannot be impersonated, and groups only as system:authenticated.
+var impersonatableResources = sets.NewString("users", "groups")
+
+// newImpersonationAuthorizer scopes impersonation to the users of the consumers of the APIExport: impersonation
+// is only allowed in a logical cluster with an APIBinding bound to the APIExport, only for users of that
+// logical cluster, and not for system users. Groups other than system:authenticated, UIDs and extras cannot be
+// impersonated, such that the service provider cannot widen the permissions of the users. Within this scope, the delegate decides, i.e. the
+// service provider needs the impersonate verb on the content of its APIExport. The user extras recording the
+// service provider as impersonator are always allowed. All other requests are delegated.
+func newImpersonationAuthorizer(
+	delegate authorizer.Authorizer,
+	listAPIBindings func(apiExportClusterName logicalcluster.Name, apiExportName string) ([]*apisv1alpha1.APIBinding, error),
+	isWorkspaceUser func(ctx context.Context, clusterName logicalcluster.Name, userName string) (bool, error),
+) authorizer.AuthorizerFunc {
+	return func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		if attr.GetVerb() != "impersonate" {
+			return delegate.Authorize(ctx, attr)
+		}
+
+		if attr.GetAPIGroup() == "" && attr.GetResource() == "userextras" && impersonation.IsImpersonatorExtra(attr.GetUser(), attr.GetSubresource(), attr.GetName()) {
+			return authorizer.DecisionAllow, "", nil
+		}
+		if attr.GetAPIGroup() != "" || !impersonatableResources.Has(attr.GetResource()) {
+			return authorizer.DecisionDeny, fmt.Sprintf("impersonating %s is not allowed", attr.GetResource()), nil
+		}
+		if attr.GetResource() == "groups" && attr.GetName() != user.AllAuthenticated {
+			return authorizer.DecisionDeny, fmt.Sprintf("impersonating the group %q is not allowed", attr.GetName()), nil
+		}
+		if name := attr.GetName(); attr.GetResource() == "users" && strings.HasPrefix(name, "system:") {
+			return authorizer.DecisionDeny, fmt.Sprintf("impersonating the system identity %q is not allowed", name), nil
+		}
+
+		cluster := genericapirequest.ClusterFrom(ctx)
+		if cluster == nil || cluster.Wildcard || cluster.Name.Empty() {
+			return authorizer.DecisionDeny, "impersonation is only allowed in the workspace of a consumer", nil
+		}
+
+		parts := strings.Split(string(dynamiccontext.APIDomainKeyFrom(ctx)), "/")
+		if len(parts) < 2 {
+			return authorizer.DecisionNoOpinion, "unable to determine api export", fmt.Errorf("access not permitted")
+		}
+		apiBindings, err := listAPIBindings(logicalcluster.New(parts[0]), parts[1])
+		if err != nil {
+			return authorizer.DecisionNoOpinion, "error", err
+		}
+		bound := false
+		for _, apiBinding := range apiBindings {
+			if logicalcluster.From(apiBinding) == cluster.Name && apiBinding.IsBound() {
+				bound = true
+				break
+			}
+		}
+		if !bound {
+			return authorizer.DecisionDeny, fmt.Sprintf("workspace %s is not bound to the api export", cluster.Name), nil
+		}
+
+		if attr.GetResource() == "users" {
+			ok, err := isWorkspaceUser(ctx, cluster.Name, attr.GetName())
+			if err != nil {
+				return authorizer.DecisionNoOpinion, "error", err
+			}
+			if !ok {
+				return authorizer.DecisionDeny, fmt.Sprintf("user %q has no access to workspace %s", attr.GetName(), cluster.Name), nil
+			}
+		}
+
+		return delegate.Authorize(ctx, attr)
+	}
+}
+
+// newWorkspaceUserChecker returns a function telling whether a user has access to the workspace of the given
+// logical cluster. Listing workspaces is granted to every user with access or admin permissions for the
+// workspace by the bootstrap policy, and the workspace content authorizer denies everything else.
+func newWorkspaceUserChecker(client kubernetesclient.ClusterInterface) func(ctx context.Context, clusterName logicalcluster.Name, userName string) (bool, error) {
+	return func(ctx context.Context, clusterName logicalcluster.Name, userName string) (bool, error) {
+		authz, err := delegated.NewDelegatedAuthorizer(clusterName, client)
+		if err != nil {
+			return false, err
+		}
+		dec, _, err := authz.Authorize(ctx, authorizer.AttributesRecord{
+			User:            &user.DefaultInfo{Name: userName, Groups: []string{user.AllAuthenticated}},
+			Verb:            "list",
+			APIGroup:        tenancyv1beta1.SchemeGroupVersion.Group,
+			APIVersion:      tenancyv1beta1.SchemeGroupVersion.Version,
+			Resource:        "workspaces",
+			ResourceRequest: true,
+		})
+		if err != nil {
+			return false, err
+		}
+		return dec == authorizer.DecisionAllow, nil
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/impersonation"
+)
+
+func TestImpersonationAuthorizer(t *testing.T) {
+	consumer := &genericapirequest.Cluster{Name: logicalcluster.New("root:org:consumer")}
+
+	tests := []struct {
+		name         string
+		verb         string
+		resource     string
+		subresource  string
+		objectName   string
+		cluster      *genericapirequest.Cluster
+		phase        apisv1alpha1.APIBindingPhaseType
+		wantDecision authorizer.Decision
+	}{
+		{
+			name:         "other verbs are delegated",
+			verb:         "get",
+			resource:     "configmaps",
+			cluster:      &genericapirequest.Cluster{Wildcard: true},
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "user of a bound consumer is delegated",
+			verb:         "impersonate",
+			resource:     "users",
+			objectName:   "alice",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "user without access to the consumer workspace is denied",
+			verb:         "impersonate",
+			resource:     "users",
+			objectName:   "mallory",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "impersonator name of the requester is allowed",
+			verb:         "impersonate",
+			resource:     "userextras",
+			subresource:  impersonation.ImpersonatorNameExtraKey,
+			objectName:   "provider",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "impersonator group of the requester is allowed",
+			verb:         "impersonate",
+			resource:     "userextras",
+			subresource:  impersonation.ImpersonatorGroupsExtraKey,
+			objectName:   "providers",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "impersonator name of another user is denied",
+			verb:         "impersonate",
+			resource:     "userextras",
+			subresource:  impersonation.ImpersonatorNameExtraKey,
+			objectName:   "admin",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "user of a consumer with pending claims is delegated",
+			verb:         "impersonate",
+			resource:     "users",
+			objectName:   "alice",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhasePermissionClaimsPending,
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "group is denied",
+			verb:         "impersonate",
+			resource:     "groups",
+			objectName:   "team-a",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "uid is denied",
+			verb:         "impersonate",
+			resource:     "uids",
+			objectName:   "1234",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "authenticated group is delegated",
+			verb:         "impersonate",
+			resource:     "groups",
+			objectName:   user.AllAuthenticated,
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:         "unbound consumer is denied",
+			verb:         "impersonate",
+			resource:     "users",
+			objectName:   "alice",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBinding,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "workspace without binding is denied",
+			verb:         "impersonate",
+			resource:     "users",
+			objectName:   "alice",
+			cluster:      &genericapirequest.Cluster{Name: logicalcluster.New("root:org:other")},
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "wildcard request is denied",
+			verb:         "impersonate",
+			resource:     "users",
+			objectName:   "alice",
+			cluster:      &genericapirequest.Cluster{Wildcard: true},
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "system user is denied",
+			verb:         "impersonate",
+			resource:     "users",
+			objectName:   "system:admin",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "system group is denied",
+			verb:         "impersonate",
+			resource:     "groups",
+			objectName:   user.SystemPrivilegedGroup,
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "service account is denied",
+			verb:         "impersonate",
+			resource:     "serviceaccounts",
+			objectName:   "default",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:         "user extra is denied",
+			verb:         "impersonate",
+			resource:     "userextras",
+			objectName:   "cluster:root:org:consumer",
+			cluster:      consumer,
+			phase:        apisv1alpha1.APIBindingPhaseBound,
+			wantDecision: authorizer.DecisionDeny,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listAPIBindings := func(apiExportClusterName logicalcluster.Name, apiExportName string) ([]*apisv1alpha1.APIBinding, error) {
+				require.Equal(t, "root:org:ws", apiExportClusterName.String())
+				require.Equal(t, "my-export", apiExportName)
+				return []*apisv1alpha1.APIBinding{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "my-binding",
+						Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:consumer"},
+					},
+					Status: apisv1alpha1.APIBindingStatus{Phase: tt.phase},
+				}}, nil
+			}
+			delegate := authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+				return authorizer.DecisionAllow, "", nil
+			})
+
+			isWorkspaceUser := func(ctx context.Context, clusterName logicalcluster.Name, userName string) (bool, error) {
+				require.Equal(t, "root:org:consumer", clusterName.String())
+				return userName == "alice", nil
+			}
+
+			authz := newImpersonationAuthorizer(delegate, listAPIBindings, isWorkspaceUser)
+			ctx := dynamiccontext.WithAPIDomainKey(context.Background(), "root:org:ws/my-export")
+			if tt.cluster != nil {
+				ctx = genericapirequest.WithCluster(ctx, *tt.cluster)
+			}
+			decision, _, err := authz.Authorize(ctx, authorizer.AttributesRecord{
+				User:            &user.DefaultInfo{Name: "provider", Groups: []string{"providers"}},
+				Verb:            tt.verb,
+				Resource:        tt.resource,
+				Subresource:     tt.subresource,
+				Name:            tt.objectName,
+				ResourceRequest: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantDecision, decision)
+		})
+	}
+}
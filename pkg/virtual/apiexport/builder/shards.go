@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/dynamic"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	dynamicextension "github.com/kcp-dev/kcp/pkg/virtual/framework/client/dynamic"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+const (
+	clusterLocationCacheSize = 10000
+	clusterLocationCacheTTL  = 10 * time.Minute
+)
+
+// NewAPIBindingClusterLocator returns a locator finding the shard of a logical cluster by its APIBindings,
+// which every consumer of an APIExport has. Logical clusters without APIBindings are located on the local
+// shard, just like without peer shards.
+//
+// Note that the permission claims of consumers on peer shards are not accepted, as the claim authorizer only
+// knows the APIBindings of the local shard.
+func NewAPIBindingClusterLocator(shards map[string]dynamic.ClusterInterface, localShard string) dynamicextension.ClusterLocator {
+	// probe the local shard first, as most consumers are usually co-located with the APIExport.
+	names := make([]string, 0, len(shards))
+	for name := range shards {
+		if name != localShard {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{localShard}, names...)
+
+	// logical clusters do not move between shards, but they are deleted.
+	locations := cache.NewLRUExpireCache(clusterLocationCacheSize)
+
+	return func(ctx context.Context, cluster logicalcluster.Name) (string, error) {
+		if shard, found := locations.Get(cluster); found {
+			return shard.(string), nil
+		}
+
+		// the locator looks up the shard on behalf of the virtual workspace, not of an impersonated user.
+		ctx = virtualcontext.WithoutImpersonation(ctx)
+		for _, shard := range names {
+			apiBindings, err := shards[shard].Cluster(cluster).Resource(apisv1alpha1.SchemeGroupVersion.WithResource("apibindings")).List(ctx, metav1.ListOptions{Limit: 1})
+			if err != nil {
+				return "", err
+			}
+			if len(apiBindings.Items) > 0 {
+				locations.Add(cluster, shard, clusterLocationCacheTTL)
+				return shard, nil
+			}
+		}
+
+		return localShard, nil
+	}
+}
@@ -27,6 +27,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
@@ -41,14 +42,14 @@ import (
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
 	ControllerName = "kcp-virtual-apiexport-api-reconciler"
-	byWorkspace    = ControllerName + "-byWorkspace" // will go away with scoping
 )
 
-type CreateAPIDefinitionFunc func(apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, identityHash string, additionalLabelRequirements labels.Requirements) (apidefinition.APIDefinition, error)
+type CreateAPIDefinitionFunc func(apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, identityHash string, additionalLabelRequirements, createLabelRequirements labels.Requirements, optionalNames sets.String) (apidefinition.APIDefinition, error)
 
 // NewAPIReconciler returns a new controller which reconciles APIResourceImport resources
 // and delegates the corresponding SyncTargetAPI management to the given SyncTargetAPIManager.
@@ -58,7 +59,7 @@ func NewAPIReconciler(
 	apiExportInformer apisinformers.APIExportInformer,
 	createAPIDefinition CreateAPIDefinitionFunc,
 ) (*APIReconciler, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
 
 	c := &APIReconciler{
 		kcpClusterClient: kcpClusterClient,
@@ -76,13 +77,7 @@ func NewAPIReconciler(
 		apiSets: map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet{},
 	}
 
-	indexers.AddIfNotPresentOrDie(
-		apiExportInformer.Informer().GetIndexer(),
-		cache.Indexers{
-			byWorkspace:                  indexByWorkspace,
-			indexers.APIExportByIdentity: indexers.IndexAPIExportByIdentity,
-		},
-	)
+	indexers.AddOrDie(apiExportInformer.Informer().GetIndexer(), indexers.ByLogicalCluster, indexers.APIExportByIdentity)
 
 	logger := logging.WithReconciler(klog.Background(), ControllerName)
 
@@ -137,7 +132,7 @@ func (c *APIReconciler) enqueueAPIResourceSchema(obj interface{}, logger logr.Lo
 	}
 
 	clusterName, name := clusters.SplitClusterAwareKey(key)
-	exports, err := c.apiExportIndexer.ByIndex(byWorkspace, clusterName.String())
+	exports, err := c.apiExportIndexer.ByIndex(indexers.ByLogicalCluster, clusterName.String())
 	if err != nil {
 		runtime.HandleError(err)
 		return
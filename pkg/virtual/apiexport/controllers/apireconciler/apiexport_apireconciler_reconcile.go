@@ -33,11 +33,13 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 	"k8s.io/kube-openapi/pkg/common"
@@ -180,10 +182,12 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.A
 				Resource: apiResourceSchema.Spec.Names.Plural,
 			}
 
+			claim := claims[gvr.GroupResource()]
+
 			oldDef, found := oldSet[gvr]
 			if found {
 				oldDef := oldDef.(apiResourceSchemaApiDefinition)
-				if oldDef.UID == apiResourceSchema.UID && oldDef.IdentityHash == apiExport.Status.IdentityHash {
+				if oldDef.UID == apiResourceSchema.UID && oldDef.IdentityHash == apiExport.Status.IdentityHash && claimsEqual(oldDef.Claim, claim) {
 					// this is the same schema and identity as before. no need to update.
 					newSet[gvr] = oldDef
 					preservedGVR = append(preservedGVR, gvrString(gvr))
@@ -191,8 +195,12 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.A
 				}
 			}
 
-			var labelReqs labels.Requirements
-			if c := claims[gvr.GroupResource()]; c != nil {
+			// labelReqs select the claimed objects, createLabelReqs must be matched by created objects. The
+			// latter do not include the claim label, as it is added by the permissionclaimlabel controller
+			// after creation.
+			var labelReqs, createLabelReqs labels.Requirements
+			var names sets.String
+			if c := claim; c != nil {
 				key, label, err := permissionclaims.ToLabelKeyAndValue(clusterName, apiExport.Name, *c)
 				if err != nil {
 					return fmt.Errorf(fmt.Sprintf("failed to convert permission claim %v to label key and value: %v", c, err))
@@ -207,10 +215,26 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.A
 					return fmt.Errorf(fmt.Sprintf("failed to create label requirement for permission claim %v: %v", c, err))
 				}
 				labelReqs = labels.Requirements{*req}
+
+				if selector := c.ResourceSelector; selector != nil {
+					if selector.LabelSelector != nil {
+						sel, err := metav1.LabelSelectorAsSelector(selector.LabelSelector)
+						if err != nil {
+							logger.Error(err, "invalid label selector of permission claim", "claim", c)
+							continue
+						}
+						reqs, _ := sel.Requirements()
+						labelReqs = append(labelReqs, reqs...)
+						createLabelReqs = append(createLabelReqs, reqs...)
+					}
+					if len(selector.Names) > 0 {
+						names = sets.NewString(selector.Names...)
+					}
+				}
 			}
 
-			logger.Info("creating API definition", "gvr", gvr, "labels", labelReqs)
-			apiDefinition, err := c.createAPIDefinition(apiResourceSchema, version.Name, identities[gvr.GroupResource()], labelReqs)
+			logger.Info("creating API definition", "gvr", gvr, "labels", labelReqs, "names", names.List())
+			apiDefinition, err := c.createAPIDefinition(apiResourceSchema, version.Name, identities[gvr.GroupResource()], labelReqs, createLabelReqs, names)
 			if err != nil {
 				// TODO(ncdc): would be nice to expose some sort of user-visible error
 				logger.Error(err, "error creating api definition", "gvr", gvr)
@@ -221,6 +245,7 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiExport *apisv1alpha1.A
 				APIDefinition: apiDefinition,
 				UID:           apiResourceSchema.UID,
 				IdentityHash:  apiExport.Status.IdentityHash,
+				Claim:         claim.DeepCopy(),
 			}
 			newGVRs = append(newGVRs, gvrString(gvr))
 		}
@@ -249,6 +274,14 @@ type apiResourceSchemaApiDefinition struct {
 
 	UID          types.UID
 	IdentityHash string
+	Claim        *apisv1alpha1.PermissionClaim
+}
+
+func claimsEqual(a, b *apisv1alpha1.PermissionClaim) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
 func gvrString(gvr schema.GroupVersionResource) string {
@@ -17,21 +17,31 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
 	"path"
 
 	"github.com/spf13/pflag"
 
+	kubernetesdynamic "k8s.io/client-go/dynamic"
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/virtual/apiexport/builder"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/client/dynamic"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/impersonation"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
 )
 
-type APIExport struct{}
+type APIExport struct {
+	// ShardKubeconfigFile is a kubeconfig with a context for every peer shard, named after the shard.
+	// If set, the virtual workspace serves the objects of consumers on all shards.
+	ShardKubeconfigFile string
+	// ShardName is the name of the shard the virtual workspace runs next to.
+	ShardName string
+}
 
 func New() *APIExport {
 	return &APIExport{}
@@ -41,6 +51,11 @@ func (o *APIExport) AddFlags(flags *pflag.FlagSet, prefix string) {
 	if o == nil {
 		return
 	}
+
+	flags.StringVar(&o.ShardKubeconfigFile, prefix+"apiexport-shard-kubeconfig-file", o.ShardKubeconfigFile,
+		"Kubeconfig with a context for every peer kcp shard, named after the shard. If set, the APIExport virtual workspace serves the objects of consumers on all shards.")
+	flags.StringVar(&o.ShardName, prefix+"apiexport-shard-name", o.ShardName,
+		"The name of the kcp shard the APIExport virtual workspace runs next to. Required with --"+prefix+"apiexport-shard-kubeconfig-file.")
 }
 
 func (o *APIExport) Validate(flagPrefix string) []error {
@@ -49,6 +64,10 @@ func (o *APIExport) Validate(flagPrefix string) []error {
 	}
 	errs := []error{}
 
+	if o.ShardKubeconfigFile != "" && o.ShardName == "" {
+		errs = append(errs, fmt.Errorf("--%sapiexport-shard-name is required with --%sapiexport-shard-kubeconfig-file", flagPrefix, flagPrefix))
+	}
+
 	return errs
 }
 
@@ -66,10 +85,44 @@ func (o *APIExport) NewVirtualWorkspaces(
 	if err != nil {
 		return nil, err
 	}
-	dynamicClusterClient, err := dynamic.NewClusterForConfig(config)
+	// impersonating requests of service providers are forwarded as the impersonated consumer user.
+	dynamicClusterClient, err := dynamic.NewClusterForConfig(impersonation.WithImpersonatedUser(config))
 	if err != nil {
 		return nil, err
 	}
 
-	return builder.BuildVirtualWorkspace(path.Join(rootPathPrefix, builder.VirtualWorkspaceName), kubeClusterClient, dynamicClusterClient, kcpClusterClient, wildcardKcpInformers)
+	var contentClusterClient kubernetesdynamic.ClusterInterface = dynamicClusterClient
+	if o.ShardKubeconfigFile != "" {
+		shardClusterClients, err := o.newShardDynamicClusterClients(dynamicClusterClient)
+		if err != nil {
+			return nil, err
+		}
+		contentClusterClient = dynamic.NewShardedCluster(shardClusterClients, builder.NewAPIBindingClusterLocator(shardClusterClients, o.ShardName))
+	}
+
+	return builder.BuildVirtualWorkspace(path.Join(rootPathPrefix, builder.VirtualWorkspaceName), kubeClusterClient, contentClusterClient, kcpClusterClient, wildcardKcpInformers)
+}
+
+// newShardDynamicClusterClients returns the dynamic clients of the peer shards in the shard kubeconfig
+// and of the local shard.
+func (o *APIExport) newShardDynamicClusterClients(local *dynamic.Cluster) (map[string]kubernetesdynamic.ClusterInterface, error) {
+	kubeconfig, err := clientcmd.LoadFromFile(o.ShardKubeconfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the shard kubeconfig %q: %w", o.ShardKubeconfigFile, err)
+	}
+
+	clients := map[string]kubernetesdynamic.ClusterInterface{o.ShardName: local}
+	for name := range kubeconfig.Contexts {
+		if name == o.ShardName {
+			return nil, fmt.Errorf("the shard kubeconfig %q must not have a context for the local shard %q", o.ShardKubeconfigFile, name)
+		}
+		config, err := clientcmd.NewNonInteractiveClientConfig(*kubeconfig, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the context %q of the shard kubeconfig %q: %w", name, o.ShardKubeconfigFile, err)
+		}
+		if clients[name], err = dynamic.NewClusterForConfig(impersonation.WithImpersonatedUser(rest.AddUserAgent(config, "apiexport-virtual-workspace"))); err != nil {
+			return nil, err
+		}
+	}
+	return clients, nil
 }
@@ -23,6 +23,7 @@ import (
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 
 	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/metrics"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
 )
 
@@ -46,7 +47,11 @@ func (a *virtualWorkspaceAuthorizer) Authorize(ctx context.Context, attrs author
 
 	for _, vw := range a.virtualWorkspaces {
 		if vw.Name == virtualWorkspaceName {
-			return vw.VirtualWorkspace.Authorize(ctx, attrs)
+			decision, reason, err := vw.VirtualWorkspace.Authorize(ctx, attrs)
+			if decision != authorizer.DecisionAllow {
+				metrics.RecordAuthorizationRejection(virtualWorkspaceName)
+			}
+			return decision, reason, err
 		}
 	}
 
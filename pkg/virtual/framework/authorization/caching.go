@@ -0,0 +1,249 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/genericcontrolplane"
+
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+const (
+	DefaultAllowCacheTTL   = 5 * time.Minute
+	DefaultDenyCacheTTL    = 30 * time.Second
+	DefaultCacheMaxEntries = 10000
+)
+
+// CachingAuthorizer memoizes the decisions of a delegate authorizer per user, request attributes, logical
+// cluster and API domain of the request, such that chatty clients of a virtual workspace do not cause a
+// SubjectAccessReview for every request. Errors of the delegate are not cached.
+//
+// Decisions can be invalidated per logical cluster whose policies they depend on, as returned by the
+// ClusterFunc of the authorizer.
+type CachingAuthorizer struct {
+	delegate   authorizer.Authorizer
+	clusterFor ClusterFunc
+
+	allowTTL time.Duration
+	denyTTL  time.Duration
+
+	decisions *utilcache.LRUExpireCache
+	// generation is part of the cache keys, such that incrementing it invalidates all cached decisions at once.
+	generation uint64
+
+	// clusterGenerations are part of the cache keys too, such that changing the generation of a logical cluster
+	// invalidates all cached decisions depending on its policies. The generations are drawn from
+	// lastClusterGeneration, such that they are never reused.
+	lock                  sync.RWMutex
+	clusterGenerations    map[logicalcluster.Name]uint64
+	lastClusterGeneration uint64
+}
+
+// ClusterFunc returns the logical cluster whose policies the decision for a request depends on.
+type ClusterFunc func(ctx context.Context) logicalcluster.Name
+
+// RequestCluster is a ClusterFunc returning the logical cluster of the request.
+func RequestCluster(ctx context.Context) logicalcluster.Name {
+	if cluster := genericapirequest.ClusterFrom(ctx); cluster != nil {
+		return cluster.Name
+	}
+	return logicalcluster.Name{}
+}
+
+var _ authorizer.Authorizer = (*CachingAuthorizer)(nil)
+
+// NewCachingAuthorizer returns an authorizer caching the allowed decisions of the delegate for allowTTL, and the
+// others for denyTTL, with at most maxEntries decisions cached. The decisions of the delegate depend on the
+// policies of the logical cluster returned by clusterFor.
+func NewCachingAuthorizer(delegate authorizer.Authorizer, clusterFor ClusterFunc, allowTTL, denyTTL time.Duration, maxEntries int) *CachingAuthorizer {
+	return &CachingAuthorizer{
+		delegate:           delegate,
+		clusterFor:         clusterFor,
+		allowTTL:           allowTTL,
+		denyTTL:            denyTTL,
+		decisions:          utilcache.NewLRUExpireCache(maxEntries),
+		clusterGenerations: map[logicalcluster.Name]uint64{},
+	}
+}
+
+type decisionKey struct {
+	generation        uint64
+	clusterGeneration uint64
+
+	cluster      string
+	apiDomainKey dynamiccontext.APIDomainKey
+
+	user   string
+	uid    string
+	groups string
+	extra  string
+
+	verb            string
+	apiGroup        string
+	apiVersion      string
+	resource        string
+	subresource     string
+	namespace       string
+	name            string
+	path            string
+	resourceRequest bool
+}
+
+type cachedDecision struct {
+	decision authorizer.Decision
+	reason   string
+}
+
+func (a *CachingAuthorizer) Authorize(ctx context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+	key := a.keyFor(ctx, attrs)
+	if cached, found := a.decisions.Get(key); found {
+		decision := cached.(cachedDecision)
+		return decision.decision, decision.reason, nil
+	}
+
+	decision, reason, err := a.delegate.Authorize(ctx, attrs)
+	if err != nil {
+		return decision, reason, err
+	}
+
+	ttl := a.denyTTL
+	if decision == authorizer.DecisionAllow {
+		ttl = a.allowTTL
+	}
+	if ttl > 0 {
+		a.decisions.Add(key, cachedDecision{decision: decision, reason: reason}, ttl)
+	}
+	return decision, reason, nil
+}
+
+// Invalidate drops all cached decisions.
+func (a *CachingAuthorizer) Invalidate() {
+	atomic.AddUint64(&a.generation, 1)
+}
+
+// InvalidateCluster drops the cached decisions depending on the policies of the given logical cluster.
+func (a *CachingAuthorizer) InvalidateCluster(clusterName logicalcluster.Name) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.lastClusterGeneration++
+	a.clusterGenerations[clusterName] = a.lastClusterGeneration
+}
+
+// invalidateClusterOf drops the cached decisions depending on the policies of the logical cluster of obj. Changes
+// in the bootstrap policies of the local admin cluster drop all cached decisions.
+func (a *CachingAuthorizer) invalidateClusterOf(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		a.Invalidate()
+		return
+	}
+	clusterName := logicalcluster.From(accessor)
+	if clusterName == genericcontrolplane.LocalAdminCluster {
+		a.Invalidate()
+		return
+	}
+	a.InvalidateCluster(clusterName)
+}
+
+// InvalidateOnRBACChanges drops the cached decisions of a logical cluster whenever a role, role binding, cluster
+// role or cluster role binding of it changes.
+func (a *CachingAuthorizer) InvalidateOnRBACChanges(rbacInformers rbacinformers.Interface) {
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { a.invalidateClusterOf(obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) { a.invalidateClusterOf(newObj) },
+		DeleteFunc: func(obj interface{}) { a.invalidateClusterOf(obj) },
+	}
+	rbacInformers.Roles().Informer().AddEventHandler(handler)
+	rbacInformers.RoleBindings().Informer().AddEventHandler(handler)
+	rbacInformers.ClusterRoles().Informer().AddEventHandler(handler)
+	rbacInformers.ClusterRoleBindings().Informer().AddEventHandler(handler)
+}
+
+// InvalidateOnDeletion drops the cached decisions of a logical cluster whenever an object of the given informer
+// is deleted in it, e.g. the object the decisions are about.
+func (a *CachingAuthorizer) InvalidateOnDeletion(informer cache.SharedInformer) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) { a.invalidateClusterOf(obj) },
+	})
+}
+
+func (a *CachingAuthorizer) keyFor(ctx context.Context, attrs authorizer.Attributes) decisionKey {
+	a.lock.RLock()
+	clusterGeneration := a.clusterGenerations[a.clusterFor(ctx)]
+	a.lock.RUnlock()
+
+	key := decisionKey{
+		generation:        atomic.LoadUint64(&a.generation),
+		clusterGeneration: clusterGeneration,
+		apiDomainKey:      dynamiccontext.APIDomainKeyFrom(ctx),
+
+		verb:            attrs.GetVerb(),
+		apiGroup:        attrs.GetAPIGroup(),
+		apiVersion:      attrs.GetAPIVersion(),
+		resource:        attrs.GetResource(),
+		subresource:     attrs.GetSubresource(),
+		namespace:       attrs.GetNamespace(),
+		name:            attrs.GetName(),
+		path:            attrs.GetPath(),
+		resourceRequest: attrs.IsResourceRequest(),
+	}
+	if cluster := genericapirequest.ClusterFrom(ctx); cluster != nil {
+		key.cluster = cluster.Name.String()
+		if cluster.Wildcard {
+			key.cluster = "*"
+		}
+	}
+	if user := attrs.GetUser(); user != nil {
+		key.user = user.GetName()
+		key.uid = user.GetUID()
+		key.groups = strings.Join(user.GetGroups(), "\x00")
+
+		extra := user.GetExtra()
+		extraKeys := make([]string, 0, len(extra))
+		for k := range extra {
+			extraKeys = append(extraKeys, k)
+		}
+		sort.Strings(extraKeys)
+		var b strings.Builder
+		for _, k := range extraKeys {
+			b.WriteString(k)
+			b.WriteString("=")
+			b.WriteString(strings.Join(extra[k], "\x00"))
+			b.WriteString("\x01")
+		}
+		key.extra = b.String()
+	}
+	return key
+}
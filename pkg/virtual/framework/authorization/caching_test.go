@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/genericcontrolplane"
+
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+func TestCachingAuthorizer(t *testing.T) {
+	calls := 0
+	decision := authorizer.DecisionAllow
+	var delegateErr error
+	authz := NewCachingAuthorizer(authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		calls++
+		return decision, "", delegateErr
+	}), RequestCluster, time.Minute, time.Minute, 100)
+
+	ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org:ws")})
+	ctx = dynamiccontext.WithAPIDomainKey(ctx, "root:org:ws|target")
+	attrs := authorizer.AttributesRecord{
+		User:            &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+		Verb:            "list",
+		Resource:        "pods",
+		APIVersion:      "v1",
+		ResourceRequest: true,
+	}
+
+	got, _, err := authz.Authorize(ctx, attrs)
+	require.NoError(t, err)
+	require.Equal(t, authorizer.DecisionAllow, got)
+	require.Equal(t, 1, calls)
+
+	_, _, err = authz.Authorize(ctx, attrs)
+	require.NoError(t, err)
+	require.Equal(t, 1, calls, "decision should be cached")
+
+	otherUser := attrs
+	otherUser.User = &user.DefaultInfo{Name: "bob"}
+	_, _, err = authz.Authorize(ctx, otherUser)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls, "decisions are per user")
+
+	otherVerb := attrs
+	otherVerb.Verb = "watch"
+	_, _, err = authz.Authorize(ctx, otherVerb)
+	require.NoError(t, err)
+	require.Equal(t, 3, calls, "decisions are per verb")
+
+	_, _, err = authz.Authorize(dynamiccontext.WithAPIDomainKey(ctx, "root:org:ws|other"), attrs)
+	require.NoError(t, err)
+	require.Equal(t, 4, calls, "decisions are per API domain")
+
+	authz.Invalidate()
+	decision = authorizer.DecisionDeny
+	got, _, err = authz.Authorize(ctx, attrs)
+	require.NoError(t, err)
+	require.Equal(t, authorizer.DecisionDeny, got, "invalidation should drop cached decisions")
+	require.Equal(t, 5, calls)
+
+	authz.Invalidate()
+	delegateErr = errors.New("failed")
+	_, _, err = authz.Authorize(ctx, attrs)
+	require.Error(t, err)
+	_, _, err = authz.Authorize(ctx, attrs)
+	require.Error(t, err)
+	require.Equal(t, 7, calls, "errors should not be cached")
+}
+
+func TestCachingAuthorizerClusterInvalidation(t *testing.T) {
+	calls := map[string]int{}
+	authz := NewCachingAuthorizer(authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		calls[genericapirequest.ClusterFrom(ctx).Name.String()]++
+		return authorizer.DecisionAllow, "", nil
+	}), RequestCluster, time.Minute, time.Minute, 100)
+
+	attrs := authorizer.AttributesRecord{
+		User:            &user.DefaultInfo{Name: "alice"},
+		Verb:            "list",
+		Resource:        "pods",
+		APIVersion:      "v1",
+		ResourceRequest: true,
+	}
+	authorize := func(cluster string) {
+		ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New(cluster)})
+		_, _, err := authz.Authorize(ctx, attrs)
+		require.NoError(t, err)
+	}
+	roleBinding := func(cluster string) *rbacv1.RoleBinding {
+		return &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name:        "binding",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+		}}
+	}
+
+	authorize("root:org:one")
+	authorize("root:org:two")
+	require.Equal(t, map[string]int{"root:org:one": 1, "root:org:two": 1}, calls)
+
+	authz.InvalidateCluster(logicalcluster.New("root:org:one"))
+	authorize("root:org:one")
+	authorize("root:org:two")
+	require.Equal(t, map[string]int{"root:org:one": 2, "root:org:two": 1}, calls, "only decisions of the invalidated cluster should be dropped")
+
+	authz.invalidateClusterOf(cache.DeletedFinalStateUnknown{Obj: roleBinding("root:org:two")})
+	authorize("root:org:one")
+	authorize("root:org:two")
+	require.Equal(t, map[string]int{"root:org:one": 2, "root:org:two": 2}, calls, "RBAC changes should drop the decisions of their cluster")
+
+	authz.invalidateClusterOf(roleBinding(genericcontrolplane.LocalAdminCluster.String()))
+	authorize("root:org:one")
+	authorize("root:org:two")
+	require.Equal(t, map[string]int{"root:org:one": 3, "root:org:two": 3}, calls, "bootstrap policy changes should drop all decisions")
+}
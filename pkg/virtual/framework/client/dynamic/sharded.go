@@ -0,0 +1,662 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// ClusterLocator returns the name of the shard hosting the given logical cluster.
+type ClusterLocator func(ctx context.Context, cluster logicalcluster.Name) (shard string, err error)
+
+// NewShardedCluster returns a dynamic cluster client serving wildcard requests from all the given
+// shards, and requests to a logical cluster from the shard returned by locate.
+//
+// The resource versions of the returned objects and lists are opaque and hold the resource versions
+// of the shards they come from. They are translated back to the resource version of the shard in
+// requests. Resource versions in patches are not translated.
+func NewShardedCluster(shards map[string]dynamic.ClusterInterface, locate ClusterLocator) dynamic.ClusterInterface {
+	names := make([]string, 0, len(shards))
+	for name := range shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return &shardedCluster{
+		shards:     shards,
+		shardNames: names,
+		locate:     locate,
+	}
+}
+
+type shardedCluster struct {
+	shards     map[string]dynamic.ClusterInterface
+	shardNames []string
+	locate     ClusterLocator
+}
+
+func (c *shardedCluster) Cluster(name logicalcluster.Name) dynamic.Interface {
+	return &shardedClient{shardedCluster: c, cluster: name}
+}
+
+type shardedClient struct {
+	*shardedCluster
+	cluster logicalcluster.Name
+}
+
+var _ dynamic.Interface = &shardedClient{}
+
+func (c *shardedClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &shardedResourceClient{shardedClient: c, resource: resource}
+}
+
+type shardedResourceClient struct {
+	*shardedClient
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+var _ ResourceInterface = &shardedResourceClient{}
+
+func (c *shardedResourceClient) Namespace(ns string) dynamic.ResourceInterface {
+	ret := *c
+	ret.namespace = ns
+	return &ret
+}
+
+// shardClient returns the client of the resource on the given shard.
+func (c *shardedResourceClient) shardClient(shard string) dynamic.ResourceInterface {
+	client := c.shards[shard].Cluster(c.cluster).Resource(c.resource)
+	if c.namespace != "" {
+		return client.Namespace(c.namespace)
+	}
+	return client
+}
+
+// locatedClient returns the shard hosting the logical cluster of the request, and the client of the
+// resource on it.
+func (c *shardedResourceClient) locatedClient(ctx context.Context) (string, dynamic.ResourceInterface, error) {
+	if c.cluster == logicalcluster.Wildcard {
+		return "", nil, apierrors.NewBadRequest(fmt.Sprintf("%s cannot be accessed by name across logical clusters", c.resource.GroupResource()))
+	}
+	shard, err := c.locate(ctx, c.cluster)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, found := c.shards[shard]; !found {
+		return "", nil, fmt.Errorf("logical cluster %q is located on unknown shard %q", c.cluster, shard)
+	}
+	return shard, c.shardClient(shard), nil
+}
+
+func (c *shardedResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	shard, client, err := c.locatedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if obj, err = c.toShardObject(obj, shard); err != nil {
+		return nil, err
+	}
+	return fromShard(shard)(client.Create(ctx, obj, options, subresources...))
+}
+
+func (c *shardedResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	shard, client, err := c.locatedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if obj, err = c.toShardObject(obj, shard); err != nil {
+		return nil, err
+	}
+	return fromShard(shard)(client.Update(ctx, obj, options, subresources...))
+}
+
+func (c *shardedResourceClient) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	shard, client, err := c.locatedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if obj, err = c.toShardObject(obj, shard); err != nil {
+		return nil, err
+	}
+	return fromShard(shard)(client.UpdateStatus(ctx, obj, options))
+}
+
+func (c *shardedResourceClient) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	shard, client, err := c.locatedClient(ctx)
+	if err != nil {
+		return err
+	}
+	if options, err = c.toShardDeleteOptions(options, name, shard); err != nil {
+		return err
+	}
+	return client.Delete(ctx, name, options, subresources...)
+}
+
+func (c *shardedResourceClient) DeleteCollection(ctx context.Context, options metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	_, err := c.deleteCollection(ctx, listOptions, func(shard string, client dynamic.ResourceInterface, listOptions metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		return nil, client.DeleteCollection(ctx, options, listOptions)
+	})
+	return err
+}
+
+func (c *shardedResourceClient) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	shard, client, err := c.locatedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if options.ResourceVersion, err = c.toShardResourceVersion(options.ResourceVersion, shard); err != nil {
+		return nil, err
+	}
+	return fromShard(shard)(client.Get(ctx, name, options, subresources...))
+}
+
+func (c *shardedResourceClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	shard, client, err := c.locatedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromShard(shard)(client.Patch(ctx, name, pt, data, options, subresources...))
+}
+
+func (c *shardedResourceClient) DeleteWithResult(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) (*unstructured.Unstructured, int, error) {
+	shard, client, err := c.locatedClient(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	deleter, ok := client.(ResourceDeleterInterface)
+	if !ok {
+		return nil, 0, fmt.Errorf("dynamic client of shard %q does not implement ResourceDeleterInterface", shard)
+	}
+	if options, err = c.toShardDeleteOptions(options, name, shard); err != nil {
+		return nil, 0, err
+	}
+	obj, status, err := deleter.DeleteWithResult(ctx, name, options, subresources...)
+	if err != nil {
+		return nil, status, err
+	}
+	if obj.GetKind() != "Status" {
+		setShardResourceVersion(obj, shard)
+	}
+	return obj, status, nil
+}
+
+func (c *shardedResourceClient) DeleteCollectionWithResult(ctx context.Context, options metav1.DeleteOptions, listOptions metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return c.deleteCollection(ctx, listOptions, func(shard string, client dynamic.ResourceInterface, listOptions metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		deleter, ok := client.(ResourceDeleterInterface)
+		if !ok {
+			return nil, fmt.Errorf("dynamic client of shard %q does not implement ResourceDeleterInterface", shard)
+		}
+		return deleter.DeleteCollectionWithResult(ctx, options, listOptions)
+	})
+}
+
+// deleteCollection deletes the collection on the shard hosting the logical cluster of the request,
+// or on all shards for wildcard requests, and merges the results.
+func (c *shardedResourceClient) deleteCollection(ctx context.Context, listOptions metav1.ListOptions, deleteCollection func(shard string, client dynamic.ResourceInterface, listOptions metav1.ListOptions) (*unstructured.UnstructuredList, error)) (*unstructured.UnstructuredList, error) {
+	rvs, err := c.parseResourceVersions(listOptions.ResourceVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := c.shardNames
+	if c.cluster != logicalcluster.Wildcard {
+		shard, _, err := c.locatedClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		shards = []string{shard}
+	}
+
+	lists, err := c.forEachShard(shards, func(shard string) (*unstructured.UnstructuredList, error) {
+		return deleteCollection(shard, c.shardClient(shard), rvs.listOptionsFor(listOptions, shard))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeShardLists(shards, lists), nil
+}
+
+func (c *shardedResourceClient) List(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if c.cluster != logicalcluster.Wildcard {
+		shard, client, err := c.locatedClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rvs, err := c.parseResourceVersions(options.ResourceVersion)
+		if err != nil {
+			return nil, err
+		}
+		// the continue token of a single shard is passed through unchanged.
+		list, err := client.List(ctx, rvs.listOptionsFor(options, shard))
+		if err != nil {
+			return nil, err
+		}
+		return mergeShardLists([]string{shard}, map[string]*unstructured.UnstructuredList{shard: list}), nil
+	}
+
+	if options.Limit > 0 || options.Continue != "" {
+		return c.listPage(ctx, options)
+	}
+
+	rvs, err := c.parseResourceVersions(options.ResourceVersion)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := c.forEachShard(c.shardNames, func(shard string) (*unstructured.UnstructuredList, error) {
+		return c.shardClient(shard).List(ctx, rvs.listOptionsFor(options, shard))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeShardLists(c.shardNames, lists), nil
+}
+
+// shardedContinueToken is the continue token of wildcard lists. The shards are listed one after
+// the other, at the resource versions taken when listing the first page.
+type shardedContinueToken struct {
+	ResourceVersions shardResourceVersions `json:"rvs"`
+	Shard            string                `json:"shard"`
+	Continue         string                `json:"continue,omitempty"`
+}
+
+// listPage returns one page of a wildcard list, holding objects of a single shard.
+func (c *shardedResourceClient) listPage(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	var token shardedContinueToken
+	var list *unstructured.UnstructuredList
+
+	if options.Continue == "" {
+		// take a snapshot of all shards, reading the first page of the first shard only.
+		rvs, err := c.parseResourceVersions(options.ResourceVersion)
+		if err != nil {
+			return nil, err
+		}
+		lists, err := c.forEachShard(c.shardNames, func(shard string) (*unstructured.UnstructuredList, error) {
+			shardOptions := rvs.listOptionsFor(options, shard)
+			if shard != c.shardNames[0] {
+				shardOptions.Limit = 1
+			}
+			return c.shardClient(shard).List(ctx, shardOptions)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		token.ResourceVersions = shardResourceVersions{}
+		for shard, list := range lists {
+			token.ResourceVersions[shard] = list.GetResourceVersion()
+		}
+		token.Shard = c.shardNames[0]
+		list = lists[token.Shard]
+	} else {
+		if err := decodeOpaque(options.Continue, &token); err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid continue token: %v", err))
+		}
+		if _, found := c.shards[token.Shard]; !found {
+			return nil, apierrors.NewResourceExpired(fmt.Sprintf("continue token refers to unknown shard %q", token.Shard))
+		}
+
+		shardOptions := options
+		shardOptions.Continue = token.Continue
+		if token.Continue == "" {
+			shardOptions.ResourceVersion = token.ResourceVersions[token.Shard]
+			shardOptions.ResourceVersionMatch = metav1.ResourceVersionMatchExact
+		}
+		var err error
+		if list, err = c.shardClient(token.Shard).List(ctx, shardOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range list.Items {
+		setShardResourceVersion(&list.Items[i], token.Shard)
+	}
+	list.SetResourceVersion(token.ResourceVersions.String())
+	list.SetRemainingItemCount(nil)
+
+	next := token
+	next.Continue = list.GetContinue()
+	if next.Continue == "" {
+		next.Shard = ""
+		for i, shard := range c.shardNames {
+			if shard == token.Shard && i+1 < len(c.shardNames) {
+				next.Shard = c.shardNames[i+1]
+			}
+		}
+	}
+	list.SetContinue("")
+	if next.Shard != "" {
+		list.SetContinue(encodeOpaque(next))
+	}
+
+	return list, nil
+}
+
+func (c *shardedResourceClient) Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	rvs, err := c.parseResourceVersions(options.ResourceVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := c.shardNames
+	if c.cluster != logicalcluster.Wildcard {
+		shard, _, err := c.locatedClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		shards = []string{shard}
+	}
+
+	w := &shardedWatch{
+		result:   make(chan watch.Event),
+		stopCh:   make(chan struct{}),
+		watches:  make(map[string]watch.Interface, len(shards)),
+		position: shardResourceVersions{},
+	}
+	for _, shard := range shards {
+		shardOptions := rvs.listOptionsFor(options, shard)
+		shardWatch, err := c.shardClient(shard).Watch(ctx, shardOptions)
+		if err != nil {
+			w.Stop()
+			return nil, err
+		}
+		w.watches[shard] = shardWatch
+		if rv := shardOptions.ResourceVersion; rv != "" && rv != "0" {
+			w.position[shard] = rv
+		}
+	}
+	w.run()
+
+	return w, nil
+}
+
+// shardedWatch merges the watches of multiple shards. The resource version of every event is the
+// position in the watches of all shards, so that a client can resume watching from it.
+type shardedWatch struct {
+	result   chan watch.Event
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	watches  map[string]watch.Interface
+
+	lock     sync.Mutex
+	position shardResourceVersions
+}
+
+func (w *shardedWatch) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		for _, shardWatch := range w.watches {
+			shardWatch.Stop()
+		}
+	})
+}
+
+func (w *shardedWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *shardedWatch) run() {
+	var wg sync.WaitGroup
+	done := make(chan struct{}, len(w.watches))
+	for shard, shardWatch := range w.watches {
+		wg.Add(1)
+		go func(shard string, shardWatch watch.Interface) {
+			defer wg.Done()
+			defer func() { done <- struct{}{} }()
+
+			for event := range shardWatch.ResultChan() {
+				if !w.send(shard, event) {
+					return
+				}
+				if event.Type == watch.Error {
+					return
+				}
+			}
+		}(shard, shardWatch)
+	}
+
+	go func() {
+		// the client resumes from the last position when any of the shard watches ends.
+		select {
+		case <-done:
+		case <-w.stopCh:
+		}
+		w.Stop()
+		wg.Wait()
+		close(w.result)
+	}()
+}
+
+// send sends the event of the given shard with the new position as resource version, and returns
+// false if the watch is stopped.
+func (w *shardedWatch) send(shard string, event watch.Event) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if obj, ok := event.Object.(*unstructured.Unstructured); ok && event.Type != watch.Error {
+		w.position[shard] = obj.GetResourceVersion()
+		obj = obj.DeepCopy()
+		obj.SetResourceVersion(w.position.String())
+		event.Object = obj
+	}
+
+	select {
+	case w.result <- event:
+		return true
+	case <-w.stopCh:
+		return false
+	}
+}
+
+// forEachShard calls fn for the given shards in parallel.
+func (c *shardedResourceClient) forEachShard(shards []string, fn func(shard string) (*unstructured.UnstructuredList, error)) (map[string]*unstructured.UnstructuredList, error) {
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+	lists := make(map[string]*unstructured.UnstructuredList, len(shards))
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard string) {
+			defer wg.Done()
+			list, err := fn(shard)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			lists[shard] = list
+		}(shard)
+	}
+	wg.Wait()
+
+	if len(errs) == 1 {
+		// keep the API status of the error
+		return nil, errs[0]
+	}
+	return lists, utilerrors.NewAggregate(errs)
+}
+
+// mergeShardLists concatenates the lists of the given shards, in order.
+func mergeShardLists(shards []string, lists map[string]*unstructured.UnstructuredList) *unstructured.UnstructuredList {
+	merged := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	rvs := shardResourceVersions{}
+	for _, shard := range shards {
+		list := lists[shard]
+		if list == nil {
+			continue
+		}
+		if len(merged.Object) == 0 {
+			merged.Object = list.Object
+		}
+		for i := range list.Items {
+			setShardResourceVersion(&list.Items[i], shard)
+		}
+		merged.Items = append(merged.Items, list.Items...)
+		if rv := list.GetResourceVersion(); rv != "" {
+			rvs[shard] = rv
+		}
+	}
+	if len(shards) > 1 {
+		merged.SetContinue("")
+		merged.SetRemainingItemCount(nil)
+	}
+	merged.SetResourceVersion(rvs.String())
+	return merged
+}
+
+// toShardObject returns a copy of the object with the resource version of the given shard.
+func (c *shardedResourceClient) toShardObject(obj *unstructured.Unstructured, shard string) (*unstructured.Unstructured, error) {
+	rv := obj.GetResourceVersion()
+	if rv == "" {
+		return obj, nil
+	}
+	rvs, err := c.parseResourceVersions(rv)
+	if err != nil {
+		return nil, err
+	}
+	shardRV, found := rvs[shard]
+	if !found {
+		return nil, apierrors.NewConflict(c.resource.GroupResource(), obj.GetName(), fmt.Errorf("resource version %q does not belong to shard %q", rv, shard))
+	}
+	obj = obj.DeepCopy()
+	obj.SetResourceVersion(shardRV)
+	return obj, nil
+}
+
+// toShardDeleteOptions returns the delete options with the resource version precondition of the given shard.
+func (c *shardedResourceClient) toShardDeleteOptions(options metav1.DeleteOptions, name, shard string) (metav1.DeleteOptions, error) {
+	if options.Preconditions == nil || options.Preconditions.ResourceVersion == nil || *options.Preconditions.ResourceVersion == "" {
+		return options, nil
+	}
+	rv := *options.Preconditions.ResourceVersion
+	rvs, err := c.parseResourceVersions(rv)
+	if err != nil {
+		return options, err
+	}
+	shardRV, found := rvs[shard]
+	if !found {
+		return options, apierrors.NewConflict(c.resource.GroupResource(), name, fmt.Errorf("resource version %q does not belong to shard %q", rv, shard))
+	}
+	preconditions := *options.Preconditions
+	preconditions.ResourceVersion = &shardRV
+	options.Preconditions = &preconditions
+	return options, nil
+}
+
+// toShardResourceVersion returns the resource version of the given shard for reads.
+func (c *shardedResourceClient) toShardResourceVersion(rv, shard string) (string, error) {
+	rvs, err := c.parseResourceVersions(rv)
+	if err != nil {
+		return "", err
+	}
+	return rvs.listOptionsFor(metav1.ListOptions{ResourceVersion: rv}, shard).ResourceVersion, nil
+}
+
+func (c *shardedResourceClient) parseResourceVersions(rv string) (shardResourceVersions, error) {
+	rvs, err := parseShardResourceVersions(rv)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version %q for %s: %v", rv, c.resource.GroupResource(), err))
+	}
+	return rvs, nil
+}
+
+// fromShard returns a function setting the resource version of an object returned by the given shard.
+func fromShard(shard string) func(obj *unstructured.Unstructured, err error) (*unstructured.Unstructured, error) {
+	return func(obj *unstructured.Unstructured, err error) (*unstructured.Unstructured, error) {
+		if err != nil {
+			return nil, err
+		}
+		setShardResourceVersion(obj, shard)
+		return obj, nil
+	}
+}
+
+func setShardResourceVersion(obj *unstructured.Unstructured, shard string) {
+	if rv := obj.GetResourceVersion(); rv != "" {
+		obj.SetResourceVersion(shardResourceVersions{shard: rv}.String())
+	}
+}
+
+// shardResourceVersions are the resource versions of multiple shards. A nil value stands for the
+// resource versions "" and "0", which are passed to all shards as is.
+type shardResourceVersions map[string]string
+
+func parseShardResourceVersions(rv string) (shardResourceVersions, error) {
+	if rv == "" || rv == "0" {
+		return nil, nil
+	}
+	rvs := shardResourceVersions{}
+	if err := decodeOpaque(rv, &rvs); err != nil {
+		return nil, err
+	}
+	return rvs, nil
+}
+
+// String returns the opaque resource version.
+func (rvs shardResourceVersions) String() string {
+	if len(rvs) == 0 {
+		return ""
+	}
+	return encodeOpaque(rvs)
+}
+
+// listOptionsFor returns the list options with the resource version of the given shard. Shards
+// without a resource version are read at the most recent one.
+func (rvs shardResourceVersions) listOptionsFor(options metav1.ListOptions, shard string) metav1.ListOptions {
+	if rvs == nil {
+		return options
+	}
+	if rv, found := rvs[shard]; found {
+		options.ResourceVersion = rv
+		return options
+	}
+	options.ResourceVersion = ""
+	options.ResourceVersionMatch = ""
+	return options
+}
+
+func encodeOpaque(v interface{}) string {
+	// marshalling maps and structs of strings does not fail
+	bs, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(bs)
+}
+
+func decodeOpaque(s string, v interface{}) error {
+	bs, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, v)
+}
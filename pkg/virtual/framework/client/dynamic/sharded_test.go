@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+var widgets = schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets"}
+
+// fakeShard serves the objects of one shard at a fixed resource version.
+type fakeShard struct {
+	resourceVersion string
+	objects         []*unstructured.Unstructured
+	watcher         *watch.FakeWatcher
+
+	listOptions   []metav1.ListOptions
+	watchOptions  []metav1.ListOptions
+	updateVersion string
+}
+
+func (s *fakeShard) Cluster(name logicalcluster.Name) dynamic.Interface {
+	return &fakeShardClient{fakeShard: s}
+}
+
+type fakeShardClient struct {
+	dynamic.NamespaceableResourceInterface
+	*fakeShard
+}
+
+func (c *fakeShardClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return c
+}
+
+func (c *fakeShardClient) List(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	c.listOptions = append(c.listOptions, options)
+
+	start := 0
+	if options.Continue != "" {
+		start, _ = strconv.Atoi(options.Continue)
+	}
+	end := len(c.objects)
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	if options.Limit > 0 && start+int(options.Limit) < end {
+		end = start + int(options.Limit)
+		list.SetContinue(strconv.Itoa(end))
+	}
+	for _, obj := range c.objects[start:end] {
+		list.Items = append(list.Items, *obj.DeepCopy())
+	}
+	list.SetResourceVersion(c.resourceVersion)
+	return list, nil
+}
+
+func (c *fakeShardClient) Watch(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+	c.watchOptions = append(c.watchOptions, options)
+	return c.watcher, nil
+}
+
+func (c *fakeShardClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	c.updateVersion = obj.GetResourceVersion()
+	obj = obj.DeepCopy()
+	obj.SetResourceVersion(c.resourceVersion)
+	return obj, nil
+}
+
+func newWidget(name, resourceVersion string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("example.io/v1")
+	obj.SetKind("Widget")
+	obj.SetName(name)
+	obj.SetResourceVersion(resourceVersion)
+	return obj
+}
+
+func names(items []unstructured.Unstructured) []string {
+	var ret []string
+	for _, item := range items {
+		ret = append(ret, item.GetName())
+	}
+	return ret
+}
+
+func newShards() (alpha, beta *fakeShard, client dynamic.ClusterInterface) {
+	alpha = &fakeShard{resourceVersion: "10", objects: []*unstructured.Unstructured{newWidget("a1", "1"), newWidget("a2", "2"), newWidget("a3", "3")}, watcher: watch.NewFakeWithChanSize(1, false)}
+	beta = &fakeShard{resourceVersion: "20", objects: []*unstructured.Unstructured{newWidget("b1", "4")}, watcher: watch.NewFakeWithChanSize(1, false)}
+	client = NewShardedCluster(map[string]dynamic.ClusterInterface{"alpha": alpha, "beta": beta}, func(ctx context.Context, cluster logicalcluster.Name) (string, error) {
+		return "beta", nil
+	})
+	return alpha, beta, client
+}
+
+func TestShardedList(t *testing.T) {
+	alpha, beta, client := newShards()
+	ctx := context.Background()
+	wildcard := client.Cluster(logicalcluster.Wildcard).Resource(widgets)
+	rv := shardResourceVersions{"alpha": "10", "beta": "20"}.String()
+
+	list, err := wildcard.List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a1", "a2", "a3", "b1"}, names(list.Items))
+	require.Equal(t, rv, list.GetResourceVersion())
+	require.Equal(t, shardResourceVersions{"beta": "4"}.String(), list.Items[3].GetResourceVersion())
+
+	_, err = wildcard.List(ctx, metav1.ListOptions{ResourceVersion: rv, ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan})
+	require.NoError(t, err)
+	require.Equal(t, "10", alpha.listOptions[1].ResourceVersion)
+	require.Equal(t, "20", beta.listOptions[1].ResourceVersion)
+
+	_, err = wildcard.List(ctx, metav1.ListOptions{ResourceVersion: "invalid"})
+	require.True(t, apierrors.IsBadRequest(err), "unexpected error: %v", err)
+}
+
+func TestShardedListPages(t *testing.T) {
+	_, beta, client := newShards()
+	ctx := context.Background()
+	wildcard := client.Cluster(logicalcluster.Wildcard).Resource(widgets)
+	rv := shardResourceVersions{"alpha": "10", "beta": "20"}.String()
+
+	var pages [][]string
+	options := metav1.ListOptions{Limit: 2}
+	for {
+		list, err := wildcard.List(ctx, options)
+		require.NoError(t, err)
+		require.Equal(t, rv, list.GetResourceVersion())
+		pages = append(pages, names(list.Items))
+		if list.GetContinue() == "" {
+			break
+		}
+		options.Continue = list.GetContinue()
+	}
+	require.Equal(t, [][]string{{"a1", "a2"}, {"a3"}, {"b1"}}, pages)
+
+	// the first page only takes the resource version of beta, which is then read at it.
+	require.Equal(t, int64(1), beta.listOptions[0].Limit)
+	require.Equal(t, "20", beta.listOptions[1].ResourceVersion)
+	require.Equal(t, metav1.ResourceVersionMatchExact, beta.listOptions[1].ResourceVersionMatch)
+}
+
+func TestShardedWatch(t *testing.T) {
+	alpha, beta, client := newShards()
+	ctx := context.Background()
+	wildcard := client.Cluster(logicalcluster.Wildcard).Resource(widgets)
+
+	w, err := wildcard.Watch(ctx, metav1.ListOptions{ResourceVersion: shardResourceVersions{"alpha": "10", "beta": "20"}.String()})
+	require.NoError(t, err)
+	require.Equal(t, "10", alpha.watchOptions[0].ResourceVersion)
+	require.Equal(t, "20", beta.watchOptions[0].ResourceVersion)
+
+	alpha.watcher.Add(newWidget("a4", "11"))
+	event := <-w.ResultChan()
+	require.Equal(t, watch.Added, event.Type)
+	require.Equal(t, shardResourceVersions{"alpha": "11", "beta": "20"}.String(), event.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	beta.watcher.Modify(newWidget("b1", "21"))
+	event = <-w.ResultChan()
+	require.Equal(t, watch.Modified, event.Type)
+	require.Equal(t, shardResourceVersions{"alpha": "11", "beta": "21"}.String(), event.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	// the watch ends with the watch of any shard.
+	beta.watcher.Stop()
+	_, ok := <-w.ResultChan()
+	require.False(t, ok)
+	require.True(t, alpha.watcher.IsStopped())
+}
+
+func TestShardedUpdate(t *testing.T) {
+	_, beta, client := newShards()
+	ctx := context.Background()
+	widgetsClient := client.Cluster(logicalcluster.New("root:org:ws")).Resource(widgets)
+
+	updated, err := widgetsClient.Update(ctx, newWidget("b1", shardResourceVersions{"alpha": "11", "beta": "21"}.String()), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "21", beta.updateVersion)
+	require.Equal(t, shardResourceVersions{"beta": "20"}.String(), updated.GetResourceVersion())
+
+	_, err = widgetsClient.Update(ctx, newWidget("b1", shardResourceVersions{"alpha": "11"}.String()), metav1.UpdateOptions{})
+	require.True(t, apierrors.IsConflict(err), "unexpected error: %v", err)
+
+	_, err = client.Cluster(logicalcluster.Wildcard).Resource(widgets).Update(ctx, newWidget("b1", ""), metav1.UpdateOptions{})
+	require.True(t, apierrors.IsBadRequest(err), "unexpected error: %v", err)
+}
@@ -36,3 +36,26 @@ func VirtualWorkspaceNameFrom(ctx context.Context) (string, bool) {
 	wcn, hasVirtualWorkspaceName := ctx.Value(virtualWorkspaceNameKey).(string)
 	return wcn, hasVirtualWorkspaceName
 }
+
+type impersonationKeyType string
+
+// impersonationKey is a context key that records whether a request impersonates a user.
+const impersonationKey impersonationKeyType = "Impersonation"
+
+// WithImpersonation marks the context of a request impersonating a user. Once the impersonation is authorized,
+// the user of the context is the impersonated user, and virtual workspaces may act as this user.
+func WithImpersonation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, impersonationKey, true)
+}
+
+// WithoutImpersonation removes the impersonation mark from the context, e.g. for requests a virtual workspace
+// makes on its own behalf while serving an impersonating request.
+func WithoutImpersonation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, impersonationKey, false)
+}
+
+// ImpersonationFrom returns whether the context is marked as impersonating a user.
+func ImpersonationFrom(ctx context.Context) bool {
+	impersonation, _ := ctx.Value(impersonationKey).(bool)
+	return impersonation
+}
@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strings"
 
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -112,18 +113,28 @@ func (r *versionDiscoveryHandler) ServeHTTP(w http.ResponseWriter, req *http.Req
 			StorageVersionHash: storageVersionHash,
 		})
 
-		for i := range apiResourceSchema.Spec.Versions {
-			if v := apiResourceSchema.Spec.Versions[i]; v.Subresources.Status != nil {
-				apiResourcesForDiscovery = append(apiResourcesForDiscovery, metav1.APIResource{
-					Name:       apiResourceSchema.Spec.Names.Plural + "/status",
-					Namespaced: apiResourceSchema.Spec.Scope == apiextensionsv1.NamespaceScoped,
-					Kind:       apiResourceSchema.Spec.Names.Kind,
-					Verbs:      supportedVerbs(apiDef.GetSubResourceStorage("status")),
-				})
-			}
+		apiResourceVersion, found := findAPIResourceVersion(apiResourceSchema, gvr.Version)
+		if !found {
+			continue
+		}
+		if statusStorage := apiDef.GetSubResourceStorage("status"); apiResourceVersion.Subresources.Status != nil && statusStorage != nil {
+			apiResourcesForDiscovery = append(apiResourcesForDiscovery, metav1.APIResource{
+				Name:       apiResourceSchema.Spec.Names.Plural + "/status",
+				Namespaced: apiResourceSchema.Spec.Scope == apiextensionsv1.NamespaceScoped,
+				Kind:       apiResourceSchema.Spec.Names.Kind,
+				Verbs:      supportedVerbs(statusStorage),
+			})
+		}
+		if scaleStorage := apiDef.GetSubResourceStorage("scale"); apiResourceVersion.Subresources.Scale != nil && scaleStorage != nil {
+			apiResourcesForDiscovery = append(apiResourcesForDiscovery, metav1.APIResource{
+				Group:      autoscalingv1.GroupName,
+				Version:    "v1",
+				Kind:       "Scale",
+				Name:       apiResourceSchema.Spec.Names.Plural + "/scale",
+				Namespaced: apiResourceSchema.Spec.Scope == apiextensionsv1.NamespaceScoped,
+				Verbs:      supportedVerbs(scaleStorage),
+			})
 		}
-
-		// TODO(david): Add scale sub-resource ???
 	}
 
 	resourceListerFunc := discovery.APIResourceListerFunc(func() []metav1.APIResource {
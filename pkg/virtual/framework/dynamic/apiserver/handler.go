@@ -211,8 +211,12 @@ func (r *resourceHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	switch {
 	case subresource == "status" && subresources.Status != nil:
 		handlerFunc = r.serveStatus(w, req, requestInfo, apiDef, supportedTypes)
+	case subresource == "scale" && subresources.Scale != nil:
+		handlerFunc = r.serveScale(w, req, requestInfo, apiDef, supportedTypes)
 	case len(subresource) == 0:
 		handlerFunc = r.serveResource(w, req, requestInfo, apiDef, supportedTypes)
+	case isConnecter(apiDef.GetSubResourceStorage(subresource)):
+		handlerFunc = r.serveConnect(w, req, requestInfo, apiDef)
 	default:
 		responsewriters.ErrorNegotiated(
 			apierrors.NewNotFound(schema.GroupResource{Group: requestInfo.APIGroup, Resource: requestInfo.Resource}, requestInfo.Name),
@@ -304,3 +308,59 @@ func (r *resourceHandler) serveStatus(w http.ResponseWriter, req *http.Request,
 	)
 	return nil
 }
+
+// serveConnect serves connect subresources like exec, attach, portforward or proxy, including upgrade requests,
+// by streaming them to the connecter of the subresource.
+func (r *resourceHandler) serveConnect(w http.ResponseWriter, req *http.Request, requestInfo *apirequest.RequestInfo, apiDef apidefinition.APIDefinition) http.HandlerFunc {
+	requestScope := apiDef.GetSubResourceRequestScope(requestInfo.Subresource)
+	connecter := apiDef.GetSubResourceStorage(requestInfo.Subresource).(rest.Connecter)
+
+	for _, method := range connecter.ConnectMethods() {
+		if method == req.Method {
+			return handlers.ConnectResource(connecter, requestScope, r.admission, requestInfo.Subresource, true)
+		}
+	}
+	responsewriters.ErrorNegotiated(
+		apierrors.NewMethodNotSupported(schema.GroupResource{Group: requestInfo.APIGroup, Resource: requestInfo.Resource + "/" + requestInfo.Subresource}, requestInfo.Verb),
+		codecs, schema.GroupVersion{Group: requestInfo.APIGroup, Version: requestInfo.APIVersion}, w, req,
+	)
+	return nil
+}
+
+func isConnecter(storage rest.Storage) bool {
+	_, ok := storage.(rest.Connecter)
+	return ok
+}
+
+func (r *resourceHandler) serveScale(w http.ResponseWriter, req *http.Request, requestInfo *apirequest.RequestInfo, apiDef apidefinition.APIDefinition, supportedTypes []string) http.HandlerFunc {
+	requestScope := apiDef.GetSubResourceRequestScope("scale")
+	storage := apiDef.GetSubResourceStorage("scale")
+
+	// the scale subresource does not support server-side apply
+	var scaleSupportedTypes []string
+	for _, t := range supportedTypes {
+		if t != string(types.ApplyPatchType) {
+			scaleSupportedTypes = append(scaleSupportedTypes, t)
+		}
+	}
+
+	switch requestInfo.Verb {
+	case "get":
+		if storage, isAble := storage.(rest.Getter); isAble {
+			return handlers.GetResource(storage, requestScope)
+		}
+	case "update":
+		if storage, isAble := storage.(rest.Updater); isAble {
+			return handlers.UpdateResource(storage, requestScope, r.admission)
+		}
+	case "patch":
+		if storage, isAble := storage.(rest.Patcher); isAble {
+			return handlers.PatchResource(storage, requestScope, r.admission, scaleSupportedTypes)
+		}
+	}
+	responsewriters.ErrorNegotiated(
+		apierrors.NewMethodNotSupported(schema.GroupResource{Group: requestInfo.APIGroup, Resource: requestInfo.Resource}, requestInfo.Verb),
+		codecs, schema.GroupVersion{Group: requestInfo.APIGroup, Version: requestInfo.APIVersion}, w, req,
+	)
+	return nil
+}
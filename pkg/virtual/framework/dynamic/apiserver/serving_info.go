@@ -36,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apiserver/pkg/endpoints/handlers"
 	"k8s.io/apiserver/pkg/endpoints/handlers/fieldmanager"
@@ -44,6 +45,8 @@ import (
 	"k8s.io/apiserver/pkg/registry/rest"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	utilopenapi "k8s.io/apiserver/pkg/util/openapi"
+	"k8s.io/client-go/scale"
+	"k8s.io/client-go/scale/scheme/autoscalingv1"
 	"k8s.io/klog/v2"
 	"k8s.io/kube-openapi/pkg/validation/spec"
 	"k8s.io/kube-openapi/pkg/validation/strfmt"
@@ -74,6 +77,12 @@ func CreateServingInfoFor(genericConfig genericapiserver.CompletedConfig, apiRes
 	if err != nil {
 		return nil, err
 	}
+	if openapiSchema != nil {
+		// custom subresources own the top-level fields of the same name, like in the bound CRD
+		if err := apiResourceVersion.AddCustomSubresourceSchemas(openapiSchema); err != nil {
+			return nil, err
+		}
+	}
 	if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(openapiSchema, internalSchema, nil); err != nil {
 		return nil, fmt.Errorf("failed converting CRD validation to internal version: %w", err)
 	}
@@ -292,13 +301,58 @@ func CreateServingInfoFor(genericConfig genericapiserver.CompletedConfig, apiRes
 		}
 	}
 
+	var scaleScope handlers.RequestScope
+	scaleStorage, scaleEnabled := subresourceStorages["scale"]
+	if scaleEnabled && apiResourceVersion.Subresources.Scale != nil {
+		equivalentResourceRegistry.RegisterKindFor(gvr, "scale", autoscalingv1.SchemeGroupVersion.WithKind("Scale"))
+
+		// shallow copy, like for CRDs, but without server-side apply
+		scaleScope = *requestScope
+		scaleConverter := scale.NewScaleConverter()
+		scaleScope.Subresource = "scale"
+		scaleScope.Serializer = serializer.NewCodecFactory(scaleConverter.Scheme())
+		scaleScope.Kind = autoscalingv1.SchemeGroupVersion.WithKind("Scale")
+		scaleScope.Namer = handlers.ContextBasedNaming{
+			Namer:         meta.NewAccessor(),
+			ClusterScoped: clusterScoped,
+		}
+		scaleScope.TableConvertor, _ = tableconvertor.New(nil)
+		scaleScope.FieldManager = nil
+	} else {
+		scaleStorage = nil
+	}
+
+	// the other subresources are connect subresources like exec, attach, portforward or proxy. Their requests are
+	// streamed to the connecter, hence they have no schema.
+	connectStorages := map[string]rest.Storage{}
+	connectScopes := map[string]*handlers.RequestScope{}
+	for subresource, connectStorage := range subresourceStorages {
+		if subresource == "status" || subresource == "scale" {
+			continue
+		}
+		if _, isConnecter := connectStorage.(rest.Connecter); !isConnecter {
+			return nil, fmt.Errorf("storage for resource %q subresource %q should be a connecter", gvk.String(), subresource)
+		}
+
+		// shallow copy
+		connectScope := *requestScope
+		connectScope.Subresource = subresource
+		connectScope.FieldManager = nil
+		connectStorages[subresource] = connectStorage
+		connectScopes[subresource] = &connectScope
+	}
+
 	ret := &servingInfo{
-		apiResourceSchema:  apiResourceSchema,
-		storage:            storage,
-		statusStorage:      statusStorage,
-		requestScope:       requestScope,
-		statusRequestScope: &statusScope,
-		logicalClusterName: logicalcluster.From(apiResourceSchema),
+		apiResourceSchema:    apiResourceSchema,
+		storage:              storage,
+		statusStorage:        statusStorage,
+		scaleStorage:         scaleStorage,
+		connectStorages:      connectStorages,
+		requestScope:         requestScope,
+		statusRequestScope:   &statusScope,
+		scaleRequestScope:    &scaleScope,
+		connectRequestScopes: connectScopes,
+		logicalClusterName:   logicalcluster.From(apiResourceSchema),
 	}
 
 	return ret, nil
@@ -309,11 +363,15 @@ type servingInfo struct {
 	logicalClusterName logicalcluster.Name
 	apiResourceSchema  *apisv1alpha1.APIResourceSchema
 
-	storage       rest.Storage
-	statusStorage rest.Storage
+	storage         rest.Storage
+	statusStorage   rest.Storage
+	scaleStorage    rest.Storage
+	connectStorages map[string]rest.Storage
 
-	requestScope       *handlers.RequestScope
-	statusRequestScope *handlers.RequestScope
+	requestScope         *handlers.RequestScope
+	statusRequestScope   *handlers.RequestScope
+	scaleRequestScope    *handlers.RequestScope
+	connectRequestScopes map[string]*handlers.RequestScope
 }
 
 // Implement APIDefinition interface
@@ -328,19 +386,25 @@ func (apiDef *servingInfo) GetStorage() rest.Storage {
 	return apiDef.storage
 }
 func (apiDef *servingInfo) GetSubResourceStorage(subresource string) rest.Storage {
-	if subresource == "status" {
+	switch subresource {
+	case "status":
 		return apiDef.statusStorage
+	case "scale":
+		return apiDef.scaleStorage
 	}
-	return nil
+	return apiDef.connectStorages[subresource]
 }
 func (apiDef *servingInfo) GetRequestScope() *handlers.RequestScope {
 	return apiDef.requestScope
 }
 func (apiDef *servingInfo) GetSubResourceRequestScope(subresource string) *handlers.RequestScope {
-	if subresource == "status" {
+	switch subresource {
+	case "status":
 		return apiDef.statusRequestScope
+	case "scale":
+		return apiDef.scaleRequestScope
 	}
-	return nil
+	return apiDef.connectRequestScopes[subresource]
 }
 func (apiDef *servingInfo) TearDown() {
 }
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forwardingregistry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/proxy"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	clientrest "k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// ConnectStorage is the storage of a connect subresource like exec, attach, portforward or proxy. It streams the
+// requests, including upgraded connections, to the same subresource of the backing server, with the credentials
+// of the virtual workspace.
+type ConnectStorage struct {
+	FactoryFunc
+	DestroyerFunc
+
+	location         *url.URL
+	transport        http.RoundTripper
+	upgradeTransport proxy.UpgradeRequestRoundTripper
+	methods          []string
+}
+
+var _ rest.Connecter = &ConnectStorage{}
+
+// NewConnectStorage returns a connect storage forwarding to the server of the given config. The methods are the
+// HTTP methods accepted by the subresource, e.g. GET and POST for exec.
+func NewConnectStorage(config *clientrest.Config, methods []string) (*ConnectStorage, error) {
+	hasCA := len(config.CAFile) != 0 || len(config.CAData) != 0
+	hasCert := len(config.CertFile) != 0 || len(config.CertData) != 0
+	location, _, err := clientrest.DefaultServerURL(config.Host, "", schema.GroupVersion{}, hasCA || hasCert || config.Insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	transportConfig, err := config.TransportConfig()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := transport.TLSConfigFor(transportConfig)
+	if err != nil {
+		return nil, err
+	}
+	// upgraded connections are dialed directly, hence the authentication is added to the request up-front.
+	connectionTransport := utilnet.SetTransportDefaults(&http.Transport{TLSClientConfig: tlsConfig, Proxy: config.Proxy})
+	requestTransport, err := transport.HTTPWrappersForConfig(transportConfig, proxy.MirrorRequest)
+	if err != nil {
+		return nil, err
+	}
+	roundTripper, err := transport.HTTPWrappersForConfig(transportConfig, connectionTransport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConnectStorage{
+		FactoryFunc: func() runtime.Object {
+			return &unstructured.Unstructured{}
+		},
+		DestroyerFunc: func() {},
+
+		location:         location,
+		transport:        roundTripper,
+		upgradeTransport: proxy.NewUpgradeRequestRoundTripper(connectionTransport, requestTransport),
+		methods:          methods,
+	}, nil
+}
+
+// ConnectMethods returns the HTTP methods accepted by the subresource.
+func (s *ConnectStorage) ConnectMethods() []string {
+	return s.methods
+}
+
+// NewConnectOptions returns no options: the query of the request is forwarded as is to the backing server.
+func (s *ConnectStorage) NewConnectOptions() (runtime.Object, bool, string) {
+	return nil, false, ""
+}
+
+// Connect returns a handler streaming the request to the subresource of the object in the logical cluster of the
+// request.
+func (s *ConnectStorage) Connect(ctx context.Context, name string, _ runtime.Object, responder rest.Responder) (http.Handler, error) {
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.Wildcard {
+		return nil, apierrors.NewBadRequest("connecting to an object requires a logical cluster")
+	}
+	requestInfo, ok := genericapirequest.RequestInfoFrom(ctx)
+	if !ok {
+		return nil, apierrors.NewInternalError(fmt.Errorf("no request info found for connecting to %q", name))
+	}
+
+	location := *s.location
+	location.Path = path.Join(location.Path, cluster.Name.Path(), backendPath(requestInfo))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		location := location
+		location.RawQuery = req.URL.RawQuery
+
+		handler := proxy.NewUpgradeAwareHandler(&location, s.transport, false, false, proxy.NewErrorResponder(responder))
+		handler.UpgradeTransport = s.upgradeTransport
+		handler.UseLocationHost = true
+
+		// the backing server must not see the credentials of the client, but those of the virtual workspace.
+		req = req.Clone(req.Context())
+		req.Header.Del("Authorization")
+		for key := range req.Header {
+			if strings.HasPrefix(key, "Impersonate-") {
+				req.Header.Del(key)
+			}
+		}
+
+		handler.ServeHTTP(w, req)
+	}), nil
+}
+
+// backendPath returns the path of the request below the logical cluster, e.g.
+// `/api/v1/namespaces/default/pods/foo/exec`.
+func backendPath(requestInfo *genericapirequest.RequestInfo) string {
+	segments := []string{"/", requestInfo.APIPrefix, requestInfo.APIGroup, requestInfo.APIVersion}
+	if requestInfo.Namespace != "" {
+		segments = append(segments, "namespaces", requestInfo.Namespace)
+	}
+	segments = append(segments, requestInfo.Parts...)
+	return path.Join(segments...)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forwardingregistry
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	clientrest "k8s.io/client-go/rest"
+)
+
+type errorResponder struct {
+	t *testing.T
+}
+
+func (r errorResponder) Object(statusCode int, obj runtime.Object) {
+	r.t.Errorf("unexpected object with status code %d: %v", statusCode, obj)
+}
+
+func (r errorResponder) Error(err error) {
+	r.t.Errorf("unexpected error: %v", err)
+}
+
+func TestBackendPath(t *testing.T) {
+	tests := map[string]struct {
+		requestInfo *genericapirequest.RequestInfo
+		want        string
+	}{
+		"core namespaced subresource": {
+			requestInfo: &genericapirequest.RequestInfo{APIPrefix: "api", APIVersion: "v1", Namespace: "default", Parts: []string{"pods", "foo", "exec"}},
+			want:        "/api/v1/namespaces/default/pods/foo/exec",
+		},
+		"proxy with sub-path": {
+			requestInfo: &genericapirequest.RequestInfo{APIPrefix: "api", APIVersion: "v1", Namespace: "default", Parts: []string{"services", "foo", "proxy", "healthz", "ready"}},
+			want:        "/api/v1/namespaces/default/services/foo/proxy/healthz/ready",
+		},
+		"cluster-scoped resource of a group": {
+			requestInfo: &genericapirequest.RequestInfo{APIPrefix: "apis", APIGroup: "example.io", APIVersion: "v1", Parts: []string{"widgets", "foo", "attach"}},
+			want:        "/apis/example.io/v1/widgets/foo/attach",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.want, backendPath(tt.requestInfo))
+		})
+	}
+}
+
+func TestConnectStorage(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/clusters/root:org/api/v1/namespaces/default/pods/foo/exec", req.URL.Path)
+		require.Equal(t, "command=ls", req.URL.RawQuery)
+		require.Equal(t, "Bearer virtual-workspace", req.Header.Get("Authorization"))
+		require.Empty(t, req.Header.Get("Impersonate-User"))
+
+		if req.Header.Get("Upgrade") == "" {
+			_, _ = w.Write([]byte("not upgraded"))
+			return
+		}
+		conn, bufrw, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		_, _ = bufrw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		require.NoError(t, bufrw.Flush())
+		// echo the stream
+		line, err := bufrw.ReadString('\n')
+		require.NoError(t, err)
+		_, _ = bufrw.WriteString(line)
+		require.NoError(t, bufrw.Flush())
+	}))
+	defer backend.Close()
+
+	storage, err := NewConnectStorage(&clientrest.Config{Host: backend.URL, BearerToken: "virtual-workspace"}, []string{"GET", "POST"})
+	require.NoError(t, err)
+
+	frontend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := genericapirequest.WithCluster(req.Context(), genericapirequest.Cluster{Name: logicalcluster.New("root:org")})
+		ctx = genericapirequest.WithRequestInfo(ctx, &genericapirequest.RequestInfo{
+			IsResourceRequest: true,
+			APIPrefix:         "api",
+			APIVersion:        "v1",
+			Namespace:         "default",
+			Resource:          "pods",
+			Name:              "foo",
+			Subresource:       "exec",
+			Parts:             []string{"pods", "foo", "exec"},
+		})
+		handler, err := storage.Connect(ctx, "foo", nil, errorResponder{t: t})
+		require.NoError(t, err)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+	}))
+	defer frontend.Close()
+
+	t.Run("plain request", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, frontend.URL+"/api/v1/namespaces/default/pods/foo/exec?command=ls", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer client")
+		req.Header.Set("Impersonate-User", "admin")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "not upgraded", string(body))
+	})
+
+	t.Run("upgrade request", func(t *testing.T) {
+		conn, err := net.Dial("tcp", frontend.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("GET /api/v1/namespaces/default/pods/foo/exec?command=ls HTTP/1.1\r\nHost: frontend\r\nAuthorization: Bearer client\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n"))
+		require.NoError(t, err)
+		reader := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(reader, nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		_, err = conn.Write([]byte("ping\n"))
+		require.NoError(t, err)
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "ping\n", line)
+	})
+
+	t.Run("wildcard cluster", func(t *testing.T) {
+		ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.Wildcard, Wildcard: true})
+		_, err := storage.Connect(ctx, "foo", nil, errorResponder{t: t})
+		require.Error(t, err)
+	})
+}
@@ -19,6 +19,9 @@ package forwardingregistry_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -46,6 +49,7 @@ import (
 	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
+	restclient "k8s.io/client-go/rest"
 	kubernetestesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/util/retry"
 
@@ -345,6 +349,69 @@ func updateReactor(fakeClient *fake.FakeDynamicClient) kubernetestesting.Reactio
 	}
 }
 
+func TestWildcardListPagination(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion":"mygroup.example.com/v1beta1","kind":"NoxuList","metadata":{"resourceVersion":"10","continue":"next-page","remainingItemCount":1},"items":[{"apiVersion":"mygroup.example.com/v1beta1","kind":"Noxu","metadata":{"name":"foo","namespace":"default"}}]}`))
+	}))
+	defer server.Close()
+
+	clusterClient, err := dynamic.NewClusterForConfig(&restclient.Config{Host: server.URL})
+	require.NoError(t, err)
+	storage, _ := newStorage(t, clusterClient, "", nil)
+	ctx := request.WithNamespace(context.Background(), "default")
+	ctx = request.WithCluster(ctx, request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true})
+	lister := storage.(rest.Lister)
+
+	result, err := lister.List(ctx, &internalversion.ListOptions{Limit: 1, ResourceVersion: "0"})
+	require.NoError(t, err)
+	require.Len(t, result.(*unstructured.UnstructuredList).Items, 1)
+	require.Equal(t, "next-page", result.(*unstructured.UnstructuredList).GetContinue())
+	require.Equal(t, "1", queries[0].Get("limit"))
+	require.Empty(t, queries[0].Get("resourceVersion"), "first page should not be served from the watch cache")
+
+	_, err = lister.List(ctx, &internalversion.ListOptions{Limit: 1, Continue: "next-page"})
+	require.NoError(t, err)
+	require.Equal(t, "1", queries[1].Get("limit"))
+	require.Equal(t, "next-page", queries[1].Get("continue"))
+
+	_, err = lister.List(ctx, &internalversion.ListOptions{ResourceVersion: "0"})
+	require.NoError(t, err)
+	require.Equal(t, "0", queries[2].Get("resourceVersion"), "unpaginated lists can be served from the watch cache")
+}
+
+func TestWatchBookmarks(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query = req.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type":"BOOKMARK","object":{"apiVersion":"mygroup.example.com/v1beta1","kind":"Noxu","metadata":{"resourceVersion":"12"}}}` + "\n"))
+		w.(http.Flusher).Flush()
+	}))
+	defer server.Close()
+
+	clusterClient, err := dynamic.NewClusterForConfig(&restclient.Config{Host: server.URL})
+	require.NoError(t, err)
+	storage, _ := newStorage(t, clusterClient, "", nil)
+	ctx := request.WithNamespace(context.Background(), "default")
+	ctx = request.WithCluster(ctx, request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true})
+
+	watcher, err := storage.(rest.Watcher).Watch(ctx, &internalversion.ListOptions{Watch: true, AllowWatchBookmarks: true, ResourceVersion: "10"})
+	require.NoError(t, err)
+	defer watcher.Stop()
+	require.Equal(t, "true", query.Get("allowWatchBookmarks"))
+
+	select {
+	case event := <-watcher.ResultChan():
+		require.Equal(t, watch.Bookmark, event.Type)
+		require.Equal(t, "12", event.Object.(*unstructured.Unstructured).GetResourceVersion())
+	case <-time.After(wait.ForeverTestTimeout):
+		require.Fail(t, "Bookmark event not received")
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	resource := createResource("default", "foo")
 	resource.SetGeneration(1)
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forwardingregistry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+// NewScaleStorage returns the proto-functions of the scale subresource on top of the storage of the resource,
+// in the same way as for CRDs: the replicas and label selector are read from and written to the paths of the
+// scale specification.
+func NewScaleStorage(resource schema.GroupResource, storage *StoreFuncs, scale *apiextensionsv1.CustomResourceSubresourceScale) *StoreFuncs {
+	labelSelectorPath := ""
+	if scale.LabelSelectorPath != nil {
+		labelSelectorPath = *scale.LabelSelectorPath
+	}
+
+	s := &StoreFuncs{}
+	s.FactoryFunc = func() runtime.Object {
+		return &autoscalingv1.Scale{}
+	}
+	s.DestroyerFunc = func() {}
+	s.GetterFunc = func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+		obj, err := storage.Get(ctx, name, options)
+		if err != nil {
+			return nil, err
+		}
+		scaleObj, replicasFound, err := scaleFromObject(obj.(*unstructured.Unstructured), scale.SpecReplicasPath, scale.StatusReplicasPath, labelSelectorPath)
+		if err != nil {
+			return nil, err
+		}
+		if !replicasFound {
+			return nil, apierrors.NewInternalError(fmt.Errorf("the spec replicas field %q does not exist", scale.SpecReplicasPath))
+		}
+		return scaleObj, nil
+	}
+	s.UpdaterFunc = func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+		obj, _, err := storage.Update(ctx, name, &scaleUpdatedObjectInfo{
+			resource:           resource,
+			name:               name,
+			reqObjInfo:         objInfo,
+			specReplicasPath:   scale.SpecReplicasPath,
+			statusReplicasPath: scale.StatusReplicasPath,
+			labelSelectorPath:  labelSelectorPath,
+		}, nil, nil, false, options)
+		if err != nil {
+			return nil, false, err
+		}
+
+		newScale, _, err := scaleFromObject(obj.(*unstructured.Unstructured), scale.SpecReplicasPath, scale.StatusReplicasPath, labelSelectorPath)
+		if err != nil {
+			return nil, false, apierrors.NewBadRequest(err.Error())
+		}
+		return newScale, false, nil
+	}
+	return s
+}
+
+// splitReplicasPath splits the path per period, ignoring the leading period.
+func splitReplicasPath(replicasPath string) []string {
+	return strings.Split(strings.TrimPrefix(replicasPath, "."), ".")
+}
+
+// scaleFromObject returns the scale of an object, and whether the spec replicas were found.
+func scaleFromObject(obj *unstructured.Unstructured, specReplicasPath, statusReplicasPath, labelSelectorPath string) (*autoscalingv1.Scale, bool, error) {
+	specReplicas, foundSpecReplicas, err := unstructured.NestedInt64(obj.UnstructuredContent(), splitReplicasPath(specReplicasPath)...)
+	if err != nil {
+		return nil, false, err
+	}
+	statusReplicas, _, err := unstructured.NestedInt64(obj.UnstructuredContent(), splitReplicasPath(statusReplicasPath)...)
+	if err != nil {
+		return nil, false, err
+	}
+	var labelSelector string
+	if len(labelSelectorPath) > 0 {
+		labelSelector, _, err = unstructured.NestedString(obj.UnstructuredContent(), splitReplicasPath(labelSelectorPath)...)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return &autoscalingv1.Scale{
+		// populate apiVersion and kind so conversion recognizes we are already in the desired GVK
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "autoscaling/v1",
+			Kind:       "Scale",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              obj.GetName(),
+			Namespace:         obj.GetNamespace(),
+			UID:               obj.GetUID(),
+			ResourceVersion:   obj.GetResourceVersion(),
+			CreationTimestamp: obj.GetCreationTimestamp(),
+		},
+		Spec: autoscalingv1.ScaleSpec{
+			Replicas: int32(specReplicas),
+		},
+		Status: autoscalingv1.ScaleStatus{
+			Replicas: int32(statusReplicas),
+			Selector: labelSelector,
+		},
+	}, foundSpecReplicas, nil
+}
+
+// scaleUpdatedObjectInfo computes the updated object from the updated scale of the request.
+type scaleUpdatedObjectInfo struct {
+	resource           schema.GroupResource
+	name               string
+	reqObjInfo         rest.UpdatedObjectInfo
+	specReplicasPath   string
+	statusReplicasPath string
+	labelSelectorPath  string
+}
+
+func (i *scaleUpdatedObjectInfo) Preconditions() *metav1.Preconditions {
+	return i.reqObjInfo.Preconditions()
+}
+
+func (i *scaleUpdatedObjectInfo) UpdatedObject(ctx context.Context, oldObj runtime.Object) (runtime.Object, error) {
+	if oldObj == nil {
+		// scale updates never create the object
+		return nil, apierrors.NewNotFound(i.resource, i.name)
+	}
+	obj := oldObj.DeepCopyObject().(*unstructured.Unstructured)
+
+	const invalidSpecReplicas = -2147483648 // smallest int32
+	oldScale, replicasFound, err := scaleFromObject(obj, i.specReplicasPath, i.statusReplicasPath, i.labelSelectorPath)
+	if err != nil {
+		return nil, err
+	}
+	if !replicasFound {
+		oldScale.Spec.Replicas = invalidSpecReplicas // signal that this was not set before
+	}
+
+	updated, err := i.reqObjInfo.UpdatedObject(ctx, oldScale)
+	if err != nil {
+		return nil, err
+	}
+	scale, ok := updated.(*autoscalingv1.Scale)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("wrong object passed to Scale update: %T", updated))
+	}
+	if scale.Spec.Replicas == invalidSpecReplicas {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("the spec replicas field %q cannot be empty", i.specReplicasPath))
+	}
+
+	if err := unstructured.SetNestedField(obj.Object, int64(scale.Spec.Replicas), splitReplicasPath(i.specReplicasPath)...); err != nil {
+		return nil, err
+	}
+	if len(scale.ResourceVersion) != 0 {
+		// the client provided a resourceVersion precondition
+		obj.SetResourceVersion(scale.ResourceVersion)
+	}
+	return obj, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forwardingregistry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/utils/pointer"
+)
+
+func TestScaleStorage(t *testing.T) {
+	resource := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	stored := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "foo",
+			"resourceVersion": "1",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+		},
+		"status": map[string]interface{}{
+			"replicas":      int64(1),
+			"labelSelector": "app=foo",
+		},
+	}}
+
+	storage := NewScaleStorage(resource, &StoreFuncs{
+		GetterFunc: func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			if name != stored.GetName() {
+				return nil, apierrors.NewNotFound(resource, name)
+			}
+			return stored.DeepCopy(), nil
+		},
+		UpdaterFunc: func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+			var old runtime.Object
+			if name == stored.GetName() {
+				old = stored.DeepCopy()
+			}
+			obj, err := objInfo.UpdatedObject(ctx, old)
+			if err != nil {
+				return nil, false, err
+			}
+			stored = obj.(*unstructured.Unstructured)
+			return stored.DeepCopy(), false, nil
+		},
+	}, &apiextensionsv1.CustomResourceSubresourceScale{
+		SpecReplicasPath:   ".spec.replicas",
+		StatusReplicasPath: ".status.replicas",
+		LabelSelectorPath:  pointer.String(".status.labelSelector"),
+	})
+	ctx := context.Background()
+
+	obj, err := storage.Get(ctx, "foo", &metav1.GetOptions{})
+	require.NoError(t, err)
+	scale := obj.(*autoscalingv1.Scale)
+	require.Equal(t, int32(1), scale.Spec.Replicas)
+	require.Equal(t, int32(1), scale.Status.Replicas)
+	require.Equal(t, "app=foo", scale.Status.Selector)
+
+	scale.Spec.Replicas = 3
+	obj, _, err = storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(scale), nil, nil, false, &metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), obj.(*autoscalingv1.Scale).Spec.Replicas)
+	replicas, _, err := unstructured.NestedInt64(stored.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), replicas)
+
+	_, _, err = storage.Update(ctx, "bar", rest.DefaultUpdatedObjectInfo(scale), nil, nil, false, &metav1.UpdateOptions{})
+	require.True(t, apierrors.IsNotFound(err), "unexpected error: %v", err)
+}
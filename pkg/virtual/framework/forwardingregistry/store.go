@@ -163,6 +163,12 @@ func DefaultDynamicDelegatedStoreFuncs(
 		if err := metainternalversion.Convert_internalversion_ListOptions_To_v1_ListOptions(options, &v1ListOptions, nil); err != nil {
 			return nil, err
 		}
+		if v1ListOptions.Limit > 0 && v1ListOptions.Continue == "" && v1ListOptions.ResourceVersion == "0" && v1ListOptions.ResourceVersionMatch == "" {
+			// Lists at resourceVersion 0 are served from the watch cache, which ignores the limit and returns all
+			// objects in one response. For wildcard lists over a whole shard this is too big, so read the first
+			// page from storage instead, and continue with the token of the response.
+			v1ListOptions.ResourceVersion = ""
+		}
 
 		delegate, err := client(ctx)
 		if err != nil {
@@ -21,12 +21,16 @@ import (
 	"fmt"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/rest"
 )
 
 func WithStaticLabelSelector(labelSelector labels.Requirements) StorageWrapper {
@@ -35,16 +39,54 @@ func WithStaticLabelSelector(labelSelector labels.Requirements) StorageWrapper {
 	})
 }
 
+// WithLabelSelector restricts the storage to objects matching the label requirements: lists, watches and
+// collection deletes are restricted by a label selector, objects outside of the selector are not found by
+// gets, updates and deletes, and created or updated objects must match the selector.
 func WithLabelSelector(labelSelectorFrom func(ctx context.Context) labels.Requirements) StorageWrapper {
+	return WithLabelSelectors(labelSelectorFrom, labelSelectorFrom)
+}
+
+// WithLabelSelectors is like WithLabelSelector, but created objects only have to match the create label
+// requirements, e.g. because the other labels of the selector are added by a controller after creation.
+func WithLabelSelectors(labelSelectorFrom, createLabelSelectorFrom func(ctx context.Context) labels.Requirements) StorageWrapper {
 	return func(resource schema.GroupResource, storage *StoreFuncs) *StoreFuncs {
-		delegateLister := storage.ListerFunc
-		storage.ListerFunc = func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+		matchesRequirements := func(obj runtime.Object, requirements labels.Requirements) (bool, error) {
+			metaObj, err := meta.Accessor(obj)
+			if err != nil {
+				return false, fmt.Errorf("expected a metav1.Object, got %T", obj)
+			}
+			return labels.Everything().Add(requirements...).Matches(labels.Set(metaObj.GetLabels())), nil
+		}
+		matches := func(ctx context.Context, obj runtime.Object) (bool, error) {
+			return matchesRequirements(obj, labelSelectorFrom(ctx))
+		}
+		admitRequirements := func(obj runtime.Object, requirements labels.Requirements) error {
+			if ok, err := matchesRequirements(obj, requirements); err != nil {
+				return err
+			} else if !ok {
+				metaObj, _ := meta.Accessor(obj)
+				return errors.NewForbidden(resource, metaObj.GetName(), fmt.Errorf("labels must match %s", labels.Everything().Add(requirements...)))
+			}
+			return nil
+		}
+		admit := func(ctx context.Context, obj runtime.Object) error {
+			return admitRequirements(obj, labelSelectorFrom(ctx))
+		}
+		withSelector := func(ctx context.Context, options *internalversion.ListOptions) *internalversion.ListOptions {
+			if options == nil {
+				options = &internalversion.ListOptions{}
+			}
 			selector := options.LabelSelector
 			if selector == nil {
 				selector = labels.Everything()
 			}
 			options.LabelSelector = selector.Add(labelSelectorFrom(ctx)...)
-			return delegateLister.List(ctx, options)
+			return options
+		}
+
+		delegateLister := storage.ListerFunc
+		storage.ListerFunc = func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			return delegateLister.List(ctx, withSelector(ctx, options))
 		}
 
 		delegateGetter := storage.GetterFunc
@@ -53,28 +95,240 @@ func WithLabelSelector(labelSelectorFrom func(ctx context.Context) labels.Requir
 			if err != nil {
 				return obj, err
 			}
+			if ok, err := matches(ctx, obj); err != nil {
+				return nil, err
+			} else if !ok {
+				return nil, errors.NewNotFound(resource, name)
+			}
+			return obj, err
+		}
+
+		delegateWatcher := storage.WatcherFunc
+		storage.WatcherFunc = func(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+			return delegateWatcher.Watch(ctx, withSelector(ctx, options))
+		}
 
-			metaObj, ok := obj.(metav1.Object)
-			if !ok {
-				return nil, fmt.Errorf("expected a metav1.Object, got %T", obj)
+		if delegateCreater := storage.CreaterFunc; delegateCreater != nil {
+			storage.CreaterFunc = func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+				if err := admitRequirements(obj, createLabelSelectorFrom(ctx)); err != nil {
+					return nil, err
+				}
+				return delegateCreater.Create(ctx, obj, createValidation, options)
 			}
-			if !labels.Everything().Add(labelSelectorFrom(ctx)...).Matches(labels.Set(metaObj.GetLabels())) {
-				return nil, errors.NewNotFound(resource, name)
+		}
+
+		if delegateUpdater := storage.UpdaterFunc; delegateUpdater != nil {
+			storage.UpdaterFunc = func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+				scoped := &scopedObjectInfo{UpdatedObjectInfo: objInfo, check: func(oldObj, obj runtime.Object) error {
+					if oldObj != nil {
+						if ok, err := matches(ctx, oldObj); err != nil {
+							return err
+						} else if !ok {
+							return errors.NewNotFound(resource, name)
+						}
+					}
+					return admit(ctx, obj)
+				}}
+				return delegateUpdater.Update(ctx, name, scoped, createValidation, updateValidation, forceAllowCreate, options)
 			}
+		}
 
-			return obj, err
+		if delegateDeleter := storage.GracefulDeleterFunc; delegateDeleter != nil {
+			storage.GracefulDeleterFunc = func(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+				obj, err := storage.GetterFunc(ctx, name, &metav1.GetOptions{})
+				if err != nil {
+					return nil, false, err
+				}
+				// make sure the object is not changed to match the selector in between
+				options, err = withObjectPreconditions(options, obj)
+				if err != nil {
+					return nil, false, err
+				}
+				return delegateDeleter.Delete(ctx, name, deleteValidation, options)
+			}
+		}
+
+		if delegateCollectionDeleter := storage.CollectionDeleterFunc; delegateCollectionDeleter != nil {
+			storage.CollectionDeleterFunc = func(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+				return delegateCollectionDeleter.DeleteCollection(ctx, deleteValidation, options, withSelector(ctx, listOptions))
+			}
+		}
+
+		return storage
+	}
+}
+
+// WithNames restricts the storage to objects with the given names: other objects are not found by gets,
+// updates and deletes, and cannot be created. With a single name, lists, watches and collection deletes are
+// restricted by a field selector on the name. With several names, lists and watches are filtered after the
+// fact, hence lists are not paginated and continue tokens are rejected, and collection deletes are rejected.
+func WithNames(names sets.String) StorageWrapper {
+	return func(resource schema.GroupResource, storage *StoreFuncs) *StoreFuncs {
+		delegateLister := storage.ListerFunc
+		storage.ListerFunc = func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			if names.Len() != 1 && options != nil && (options.Limit > 0 || options.Continue != "") {
+				if options.Continue != "" {
+					return nil, errors.NewBadRequest(fmt.Sprintf("continue tokens are not supported for %s restricted to names %v", resource, names.List()))
+				}
+				// a page could be filtered to nothing, hence return all objects in a single response
+				options = options.DeepCopy()
+				options.Limit = 0
+			}
+			obj, err := delegateLister.List(ctx, withNameFieldSelector(options, names))
+			if err != nil {
+				return obj, err
+			}
+
+			items, err := meta.ExtractList(obj)
+			if err != nil {
+				return nil, err
+			}
+			filtered := make([]runtime.Object, 0, len(items))
+			for _, item := range items {
+				metaObj, err := meta.Accessor(item)
+				if err != nil {
+					return nil, err
+				}
+				if names.Has(metaObj.GetName()) {
+					filtered = append(filtered, item)
+				}
+			}
+			if err := meta.SetList(obj, filtered); err != nil {
+				return nil, err
+			}
+
+			return obj, nil
+		}
+
+		delegateGetter := storage.GetterFunc
+		storage.GetterFunc = func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			if !names.Has(name) {
+				return nil, errors.NewNotFound(resource, name)
+			}
+			return delegateGetter.Get(ctx, name, options)
 		}
 
 		delegateWatcher := storage.WatcherFunc
 		storage.WatcherFunc = func(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
-			selector := options.LabelSelector
-			if selector == nil {
-				selector = labels.Everything()
+			w, err := delegateWatcher.Watch(ctx, withNameFieldSelector(options, names))
+			if err != nil {
+				return w, err
+			}
+
+			return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+				if in.Type == watch.Bookmark || in.Type == watch.Error {
+					return in, true
+				}
+				metaObj, err := meta.Accessor(in.Object)
+				if err != nil {
+					return in, false
+				}
+				return in, names.Has(metaObj.GetName())
+			}), nil
+		}
+
+		if delegateCreater := storage.CreaterFunc; delegateCreater != nil {
+			storage.CreaterFunc = func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+				metaObj, err := meta.Accessor(obj)
+				if err != nil {
+					return nil, err
+				}
+				if !names.Has(metaObj.GetName()) {
+					return nil, errors.NewForbidden(resource, metaObj.GetName(), fmt.Errorf("name must be one of %v", names.List()))
+				}
+				return delegateCreater.Create(ctx, obj, createValidation, options)
+			}
+		}
+
+		if delegateUpdater := storage.UpdaterFunc; delegateUpdater != nil {
+			storage.UpdaterFunc = func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+				if !names.Has(name) {
+					return nil, false, errors.NewNotFound(resource, name)
+				}
+				return delegateUpdater.Update(ctx, name, objInfo, createValidation, updateValidation, forceAllowCreate, options)
+			}
+		}
+
+		if delegateDeleter := storage.GracefulDeleterFunc; delegateDeleter != nil {
+			storage.GracefulDeleterFunc = func(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+				if !names.Has(name) {
+					return nil, false, errors.NewNotFound(resource, name)
+				}
+				return delegateDeleter.Delete(ctx, name, deleteValidation, options)
+			}
+		}
+
+		if delegateCollectionDeleter := storage.CollectionDeleterFunc; delegateCollectionDeleter != nil {
+			storage.CollectionDeleterFunc = func(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+				if names.Len() != 1 {
+					return nil, errors.NewMethodNotSupported(resource, "deletecollection")
+				}
+				if listOptions == nil {
+					listOptions = &internalversion.ListOptions{}
+				}
+				return delegateCollectionDeleter.DeleteCollection(ctx, deleteValidation, options, withNameFieldSelector(listOptions, names))
 			}
-			options.LabelSelector = selector.Add(labelSelectorFrom(ctx)...)
-			return delegateWatcher.Watch(ctx, options)
 		}
 
 		return storage
 	}
 }
+
+// scopedObjectInfo checks the old and the updated object before the updated object is stored.
+type scopedObjectInfo struct {
+	rest.UpdatedObjectInfo
+	check func(oldObj, obj runtime.Object) error
+}
+
+func (i *scopedObjectInfo) UpdatedObject(ctx context.Context, oldObj runtime.Object) (runtime.Object, error) {
+	obj, err := i.UpdatedObjectInfo.UpdatedObject(ctx, oldObj)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.check(oldObj, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// withObjectPreconditions returns a copy of the delete options with preconditions on the UID and the resource
+// version of the object, unless preconditions are given already.
+func withObjectPreconditions(options *metav1.DeleteOptions, obj runtime.Object) (*metav1.DeleteOptions, error) {
+	if options != nil && options.Preconditions != nil {
+		return options, nil
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	uid, resourceVersion := metaObj.GetUID(), metaObj.GetResourceVersion()
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	} else {
+		options = options.DeepCopy()
+	}
+	options.Preconditions = &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion}
+	return options, nil
+}
+
+// withNameFieldSelector returns a copy of the list options with a field selector on the name if there is
+// only one name, such that the delegate does not return any other objects in the first place.
+func withNameFieldSelector(options *internalversion.ListOptions, names sets.String) *internalversion.ListOptions {
+	if names.Len() != 1 || options == nil {
+		return options
+	}
+	name := names.List()[0]
+	nameSelector := fields.OneTermEqualSelector("metadata.name", name)
+
+	if options.FieldSelector == nil || options.FieldSelector.Empty() {
+		options = options.DeepCopy()
+		options.FieldSelector = nameSelector
+		return options
+	}
+	if _, found := options.FieldSelector.RequiresExactMatch("metadata.name"); found {
+		return options
+	}
+	options = options.DeepCopy()
+	options.FieldSelector = fields.AndSelectors(options.FieldSelector, nameSelector)
+	return options
+}
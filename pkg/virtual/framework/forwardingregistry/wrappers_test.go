@@ -0,0 +1,296 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forwardingregistry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/utils/pointer"
+)
+
+func TestWithNamesFieldSelector(t *testing.T) {
+	tests := []struct {
+		name          string
+		names         sets.String
+		fieldSelector fields.Selector
+		want          string
+	}{
+		{
+			name:  "single name without field selector",
+			names: sets.NewString("foo"),
+			want:  "metadata.name=foo",
+		},
+		{
+			name:          "single name with other field selector",
+			names:         sets.NewString("foo"),
+			fieldSelector: fields.OneTermEqualSelector("metadata.namespace", "default"),
+			want:          "metadata.namespace=default,metadata.name=foo",
+		},
+		{
+			name:          "single name with name field selector",
+			names:         sets.NewString("foo"),
+			fieldSelector: fields.OneTermEqualSelector("metadata.name", "bar"),
+			want:          "metadata.name=bar",
+		},
+		{
+			name:          "multiple names",
+			names:         sets.NewString("foo", "bar"),
+			fieldSelector: fields.OneTermEqualSelector("metadata.namespace", "default"),
+			want:          "metadata.namespace=default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listed, watched string
+			storage := WithNames(tt.names)(schema.GroupResource{Resource: "widgets"}, &StoreFuncs{
+				ListerFunc: func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+					listed = options.FieldSelector.String()
+					return &unstructured.UnstructuredList{}, nil
+				},
+				WatcherFunc: func(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+					watched = options.FieldSelector.String()
+					return watch.NewEmptyWatch(), nil
+				},
+			})
+
+			options := &internalversion.ListOptions{FieldSelector: tt.fieldSelector}
+			_, err := storage.List(context.Background(), options)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, listed)
+
+			_, err = storage.Watch(context.Background(), options)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, watched)
+
+			require.Equal(t, tt.fieldSelector, options.FieldSelector, "options of the request must not be changed")
+		})
+	}
+}
+
+func TestWithLabelSelectorScope(t *testing.T) {
+	newObj := func(name string, labels map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetName(name)
+		obj.SetUID(types.UID(name + "-uid"))
+		obj.SetResourceVersion("42")
+		obj.SetLabels(labels)
+		return obj
+	}
+	objs := map[string]*unstructured.Unstructured{
+		"in":  newObj("in", map[string]string{"scope": "in"}),
+		"out": newObj("out", map[string]string{"scope": "out"}),
+	}
+	requirements, _ := labels.SelectorFromSet(labels.Set{"scope": "in"}).Requirements()
+
+	var created, updated, deleted bool
+	var deleteOptions *metav1.DeleteOptions
+	var collectionSelector string
+	storage := WithStaticLabelSelector(requirements)(schema.GroupResource{Resource: "widgets"}, &StoreFuncs{
+		GetterFunc: func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			return objs[name].DeepCopy(), nil
+		},
+		CreaterFunc: func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+			created = true
+			return obj, nil
+		},
+		UpdaterFunc: func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+			obj, err := objInfo.UpdatedObject(ctx, objs[name].DeepCopy())
+			if err != nil {
+				return nil, false, err
+			}
+			updated = true
+			return obj, false, nil
+		},
+		GracefulDeleterFunc: func(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+			deleted = true
+			deleteOptions = options
+			return nil, true, nil
+		},
+		CollectionDeleterFunc: func(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+			collectionSelector = listOptions.LabelSelector.String()
+			return &unstructured.UnstructuredList{}, nil
+		},
+	})
+	ctx := context.Background()
+	updateTo := func(obj *unstructured.Unstructured) rest.UpdatedObjectInfo {
+		return rest.DefaultUpdatedObjectInfo(obj)
+	}
+
+	_, err := storage.Create(ctx, newObj("new", map[string]string{"scope": "out"}), nil, &metav1.CreateOptions{})
+	require.True(t, errors.IsForbidden(err), "creating objects outside of the selector must be forbidden, got %v", err)
+	require.False(t, created)
+	_, err = storage.Create(ctx, newObj("new", map[string]string{"scope": "in"}), nil, &metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.True(t, created)
+
+	_, _, err = storage.Update(ctx, "out", updateTo(newObj("out", map[string]string{"scope": "in"})), nil, nil, false, &metav1.UpdateOptions{})
+	require.True(t, errors.IsNotFound(err), "updating objects outside of the selector must not find them, got %v", err)
+	_, _, err = storage.Update(ctx, "in", updateTo(newObj("in", map[string]string{"scope": "out"})), nil, nil, false, &metav1.UpdateOptions{})
+	require.True(t, errors.IsForbidden(err), "updating objects out of the selector must be forbidden, got %v", err)
+	require.False(t, updated)
+	_, _, err = storage.Update(ctx, "in", updateTo(newObj("in", map[string]string{"scope": "in", "other": "label"})), nil, nil, false, &metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	_, _, err = storage.Delete(ctx, "out", nil, &metav1.DeleteOptions{})
+	require.True(t, errors.IsNotFound(err), "deleting objects outside of the selector must not find them, got %v", err)
+	require.False(t, deleted)
+	_, _, err = storage.Delete(ctx, "in", nil, &metav1.DeleteOptions{})
+	require.NoError(t, err)
+	require.True(t, deleted)
+	require.Equal(t, &metav1.Preconditions{UID: pointerUID("in-uid"), ResourceVersion: pointer.String("42")}, deleteOptions.Preconditions)
+
+	_, err = storage.DeleteCollection(ctx, nil, &metav1.DeleteOptions{}, &internalversion.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "scope=in", collectionSelector)
+}
+
+func TestWithLabelSelectorsCreate(t *testing.T) {
+	newObj := func(labels map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetName("new")
+		obj.SetLabels(labels)
+		return obj
+	}
+	requirements, _ := labels.SelectorFromSet(labels.Set{"scope": "in", "claimed": "true"}).Requirements()
+	createRequirements, _ := labels.SelectorFromSet(labels.Set{"scope": "in"}).Requirements()
+
+	var created bool
+	storage := WithLabelSelectors(func(ctx context.Context) labels.Requirements {
+		return requirements
+	}, func(ctx context.Context) labels.Requirements {
+		return createRequirements
+	})(schema.GroupResource{Resource: "widgets"}, &StoreFuncs{
+		GetterFunc: func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			return newObj(map[string]string{"scope": "in"}), nil
+		},
+		CreaterFunc: func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+			created = true
+			return obj, nil
+		},
+	})
+	ctx := context.Background()
+
+	_, err := storage.Create(ctx, newObj(map[string]string{"scope": "out"}), nil, &metav1.CreateOptions{})
+	require.True(t, errors.IsForbidden(err), "creating objects outside of the create selector must be forbidden, got %v", err)
+	require.False(t, created)
+	_, err = storage.Create(ctx, newObj(map[string]string{"scope": "in"}), nil, &metav1.CreateOptions{})
+	require.NoError(t, err, "creating objects without the labels added after creation must be allowed")
+	require.True(t, created)
+
+	_, err = storage.Get(ctx, "new", &metav1.GetOptions{})
+	require.True(t, errors.IsNotFound(err), "objects outside of the selector must not be found until the labels are added, got %v", err)
+}
+
+func TestWithNamesScope(t *testing.T) {
+	newObj := func(name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetName(name)
+		return obj
+	}
+
+	var listOptions *internalversion.ListOptions
+	var created, updated, deleted, collectionDeleted bool
+	delegate := func() *StoreFuncs {
+		return &StoreFuncs{
+			ListerFunc: func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+				listOptions = options
+				return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*newObj("foo"), *newObj("bar"), *newObj("baz")}}, nil
+			},
+			CreaterFunc: func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+				created = true
+				return obj, nil
+			},
+			UpdaterFunc: func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+				updated = true
+				return nil, false, nil
+			},
+			GracefulDeleterFunc: func(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+				deleted = true
+				return nil, true, nil
+			},
+			CollectionDeleterFunc: func(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+				collectionDeleted = true
+				return &unstructured.UnstructuredList{}, nil
+			},
+		}
+	}
+	resource := schema.GroupResource{Resource: "widgets"}
+	ctx := context.Background()
+
+	storage := WithNames(sets.NewString("foo", "bar"))(resource, delegate())
+
+	_, err := storage.Create(ctx, newObj("baz"), nil, &metav1.CreateOptions{})
+	require.True(t, errors.IsForbidden(err), "creating other objects must be forbidden, got %v", err)
+	require.False(t, created)
+	_, err = storage.Create(ctx, newObj("foo"), nil, &metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.True(t, created)
+
+	_, _, err = storage.Update(ctx, "baz", rest.DefaultUpdatedObjectInfo(newObj("baz")), nil, nil, false, &metav1.UpdateOptions{})
+	require.True(t, errors.IsNotFound(err), "updating other objects must not find them, got %v", err)
+	require.False(t, updated)
+	_, _, err = storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(newObj("foo")), nil, nil, false, &metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	_, _, err = storage.Delete(ctx, "baz", nil, &metav1.DeleteOptions{})
+	require.True(t, errors.IsNotFound(err), "deleting other objects must not find them, got %v", err)
+	require.False(t, deleted)
+	_, _, err = storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
+	require.NoError(t, err)
+	require.True(t, deleted)
+
+	_, err = storage.DeleteCollection(ctx, nil, &metav1.DeleteOptions{}, &internalversion.ListOptions{})
+	require.True(t, errors.IsMethodNotSupported(err), "deleting collections of several names must be rejected, got %v", err)
+	require.False(t, collectionDeleted)
+
+	list, err := storage.List(ctx, &internalversion.ListOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), listOptions.Limit, "lists of several names must not be paginated")
+	require.Len(t, list.(*unstructured.UnstructuredList).Items, 2)
+	_, err = storage.List(ctx, &internalversion.ListOptions{Continue: "token"})
+	require.True(t, errors.IsBadRequest(err), "continue tokens must be rejected, got %v", err)
+
+	storage = WithNames(sets.NewString("foo"))(resource, delegate())
+	_, err = storage.List(ctx, &internalversion.ListOptions{Limit: 2, Continue: "token"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), listOptions.Limit, "lists of a single name are paginated")
+	require.Equal(t, "token", listOptions.Continue)
+	_, err = storage.DeleteCollection(ctx, nil, &metav1.DeleteOptions{}, &internalversion.ListOptions{})
+	require.NoError(t, err)
+	require.True(t, collectionDeleted)
+}
+
+func pointerUID(uid types.UID) *types.UID {
+	return &uid
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package impersonation
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+const (
+	// ImpersonatorNameExtraKey is the user extra of an impersonated user holding the name of the
+	// user impersonating it, i.e. of the original requester.
+	ImpersonatorNameExtraKey = impersonatorExtraKeyPrefix + "name"
+	// ImpersonatorGroupsExtraKey is the user extra of an impersonated user holding the groups of the
+	// user impersonating it, i.e. of the original requester.
+	ImpersonatorGroupsExtraKey = impersonatorExtraKeyPrefix + "groups"
+
+	impersonatorExtraKeyPrefix = "impersonator.virtual.kcp.dev/"
+)
+
+// WithImpersonator wraps the authenticator of a virtual workspace such that the authenticated user of
+// an impersonating request is recorded in the user extras of the impersonated user, see ImpersonatorFrom.
+// Impersonator extras set by the client are always dropped, hence they cannot be forged. Still, the
+// impersonation filter asks for the permission to impersonate the extras, which must be granted if the
+// extras match the requester, see IsImpersonatorExtra.
+func WithImpersonator(delegate authenticator.Request) authenticator.Request {
+	return authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		for headerName := range req.Header {
+			if strings.HasPrefix(strings.ToLower(headerName), strings.ToLower(transport.ImpersonateUserExtraHeaderPrefix+url.PathEscape(impersonatorExtraKeyPrefix))) {
+				req.Header.Del(headerName)
+			}
+		}
+
+		resp, ok, err := delegate.AuthenticateRequest(req)
+		if err != nil || !ok || req.Header.Get(transport.ImpersonateUserHeader) == "" {
+			return resp, ok, err
+		}
+
+		req.Header.Set(transport.ImpersonateUserExtraHeaderPrefix+url.PathEscape(ImpersonatorNameExtraKey), resp.User.GetName())
+		for _, group := range resp.User.GetGroups() {
+			req.Header.Add(transport.ImpersonateUserExtraHeaderPrefix+url.PathEscape(ImpersonatorGroupsExtraKey), group)
+		}
+		return resp, ok, err
+	})
+}
+
+// IsImpersonatorExtra returns whether the given user extra, as authorized with the impersonate verb
+// on the userextras resource, records the requester as the impersonator.
+func IsImpersonatorExtra(requester user.Info, key, value string) bool {
+	switch key {
+	case ImpersonatorNameExtraKey:
+		return value == requester.GetName()
+	case ImpersonatorGroupsExtraKey:
+		for _, group := range requester.GetGroups() {
+			if group == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ImpersonatorFrom returns the user impersonating the given user, as recorded in its user extras by
+// WithImpersonator. The result must only be trusted for requests marked as impersonating by the virtual
+// workspace framework.
+func ImpersonatorFrom(u user.Info) (user.Info, bool) {
+	names := u.GetExtra()[ImpersonatorNameExtraKey]
+	if len(names) != 1 || names[0] == "" {
+		return nil, false
+	}
+	return &user.DefaultInfo{
+		Name:   names[0],
+		Groups: u.GetExtra()[ImpersonatorGroupsExtraKey],
+	}, true
+}
+
+// WithImpersonatedUser returns a copy of the config whose requests impersonate the user of the request context
+// if the virtual workspace request impersonates a user. The other requests are made with the credentials of the
+// config. This way, the writes of the impersonated user are audited as such in the backing logical clusters.
+func WithImpersonatedUser(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &impersonatingTransport{delegate: rt}
+	})
+	return config
+}
+
+// impersonatingTransport adds the impersonation headers of the user of the request context to the requests
+// with a context marked as impersonating.
+type impersonatingTransport struct {
+	delegate http.RoundTripper
+}
+
+func (t *impersonatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !virtualcontext.ImpersonationFrom(ctx) {
+		return t.delegate.RoundTrip(req)
+	}
+
+	user, ok := genericapirequest.UserFrom(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no user found in the context of an impersonating request to %s", req.URL.Path)
+	}
+	return transport.NewImpersonatingRoundTripper(transport.ImpersonationConfig{
+		UserName: user.GetName(),
+		UID:      user.GetUID(),
+		Groups:   user.GetGroups(),
+		Extra:    user.GetExtra(),
+	}, t.delegate).RoundTrip(req)
+}
+
+func (t *impersonatingTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.delegate
+}
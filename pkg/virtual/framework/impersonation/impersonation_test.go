@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package impersonation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+func TestWithImpersonatedUser(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		headers = req.Header.Clone()
+	}))
+	defer server.Close()
+
+	client, err := rest.HTTPClientFor(WithImpersonatedUser(&rest.Config{Host: server.URL, BearerToken: "virtual-workspace"}))
+	require.NoError(t, err)
+
+	consumer := &user.DefaultInfo{Name: "alice", UID: "1234", Groups: []string{"team-a", user.AllAuthenticated}, Extra: map[string][]string{"scopes": {"cluster:root:consumer"}}}
+	tests := map[string]struct {
+		ctx         context.Context
+		wantHeaders http.Header
+	}{
+		"not impersonating": {
+			ctx: genericapirequest.WithUser(context.Background(), consumer),
+			wantHeaders: http.Header{
+				"Authorization": {"Bearer virtual-workspace"},
+			},
+		},
+		"impersonating": {
+			ctx: virtualcontext.WithImpersonation(genericapirequest.WithUser(context.Background(), consumer)),
+			wantHeaders: http.Header{
+				"Authorization":            {"Bearer virtual-workspace"},
+				"Impersonate-User":         {"alice"},
+				"Impersonate-Uid":          {"1234"},
+				"Impersonate-Group":        {"team-a", user.AllAuthenticated},
+				"Impersonate-Extra-Scopes": {"cluster:root:consumer"},
+			},
+		},
+		"impersonation removed": {
+			ctx: virtualcontext.WithoutImpersonation(virtualcontext.WithImpersonation(genericapirequest.WithUser(context.Background(), consumer))),
+			wantHeaders: http.Header{
+				"Authorization": {"Bearer virtual-workspace"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			for key, want := range tt.wantHeaders {
+				require.Equal(t, want, headers.Values(key), "header %s", key)
+			}
+			if _, impersonating := tt.wantHeaders["Impersonate-User"]; !impersonating {
+				require.Empty(t, headers.Get("Impersonate-User"))
+			}
+		})
+	}
+
+	req, err := http.NewRequestWithContext(virtualcontext.WithImpersonation(context.Background()), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.Error(t, err, "impersonating requests without user must fail")
+}
+
+func TestWithImpersonator(t *testing.T) {
+	provider := &user.DefaultInfo{Name: "provider", Groups: []string{"providers", user.AllAuthenticated}}
+	authn := WithImpersonator(authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		return &authenticator.Response{User: provider}, true, nil
+	}))
+	authz := authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		if attr.GetResource() == "userextras" && !IsImpersonatorExtra(attr.GetUser(), attr.GetSubresource(), attr.GetName()) {
+			return authorizer.DecisionDeny, "", nil
+		}
+		return authorizer.DecisionAllow, "", nil
+	})
+
+	var got user.Info
+	handler := genericapifilters.WithAuthentication(
+		genericapifilters.WithImpersonation(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			got, _ = genericapirequest.UserFrom(req.Context())
+		}), authz, serializer.NewCodecFactory(runtime.NewScheme())),
+		authn,
+		http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusUnauthorized) }),
+		nil,
+	)
+
+	tests := map[string]struct {
+		headers          http.Header
+		wantUser         string
+		wantImpersonator *user.DefaultInfo
+	}{
+		"not impersonating": {
+			wantUser: "provider",
+		},
+		"impersonating": {
+			headers:          http.Header{"Impersonate-User": {"alice"}},
+			wantUser:         "alice",
+			wantImpersonator: &user.DefaultInfo{Name: "provider", Groups: []string{"providers", user.AllAuthenticated}},
+		},
+		"forged impersonator": {
+			headers: http.Header{
+				"Impersonate-User": {"alice"},
+				"Impersonate-Extra-Impersonator.virtual.kcp.dev%2fname":   {"admin"},
+				"Impersonate-Extra-Impersonator.virtual.kcp.dev%2fgroups": {"system:masters"},
+			},
+			wantUser:         "alice",
+			wantImpersonator: &user.DefaultInfo{Name: "provider", Groups: []string{"providers", user.AllAuthenticated}},
+		},
+		"forged impersonator without impersonation": {
+			headers: http.Header{
+				"Impersonate-Extra-Impersonator.virtual.kcp.dev%2fname": {"admin"},
+			},
+			wantUser: "provider",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces", nil)
+			for key, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.NotNil(t, got, "request was rejected")
+			require.Equal(t, tt.wantUser, got.GetName())
+			impersonator, ok := ImpersonatorFrom(got)
+			if tt.wantImpersonator == nil {
+				require.False(t, ok, "unexpected impersonator %v", impersonator)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tt.wantImpersonator, impersonator)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const subsystem = "virtual_workspace"
+
+var (
+	requestCounter = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "requests_total",
+			Help:           "Number of requests served by a virtual workspace, partitioned by virtual workspace, verb and HTTP response code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "verb", "code"},
+	)
+
+	requestLatencies = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subsystem,
+			Name:           "request_duration_seconds",
+			Help:           "Latency of the requests served by a virtual workspace, partitioned by virtual workspace and verb. Long-running requests are not observed.",
+			Buckets:        []float64{0.005, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "verb"},
+	)
+
+	authorizationRejections = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "authorization_rejections_total",
+			Help:           "Number of requests a virtual workspace did not authorize, partitioned by virtual workspace.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"virtual_workspace"},
+	)
+
+	rateLimitedRequests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "rate_limited_requests_total",
+			Help:           "Number of requests a virtual workspace rejected because the user exceeded its rate limit, partitioned by virtual workspace.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"virtual_workspace"},
+	)
+
+	informerLag = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subsystem,
+			Name:           "informer_lag_seconds",
+			Help:           "Time between the last write of an object and the update event of the informer of a virtual workspace, partitioned by virtual workspace and informer. The write times have a precision of one second.",
+			Buckets:        []float64{1, 2, 5, 10, 30, 60, 120, 300},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"virtual_workspace", "informer"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// Register registers the virtual workspace metrics in the legacy registry.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(requestCounter)
+		legacyregistry.MustRegister(requestLatencies)
+		legacyregistry.MustRegister(authorizationRejections)
+		legacyregistry.MustRegister(rateLimitedRequests)
+		legacyregistry.MustRegister(informerLag)
+	})
+}
+
+// RecordRequest records a request served by the given virtual workspace. The latency of long-running
+// requests is not recorded.
+func RecordRequest(virtualWorkspace, verb string, code int, longRunning bool, elapsed time.Duration) {
+	requestCounter.WithLabelValues(virtualWorkspace, verb, strconv.Itoa(code)).Inc()
+	if !longRunning {
+		requestLatencies.WithLabelValues(virtualWorkspace, verb).Observe(elapsed.Seconds())
+	}
+}
+
+// RecordAuthorizationRejection records a request which the given virtual workspace did not authorize.
+func RecordAuthorizationRejection(virtualWorkspace string) {
+	authorizationRejections.WithLabelValues(virtualWorkspace).Inc()
+}
+
+// RecordRateLimited records a request which the given virtual workspace rejected because of the rate limit.
+func RecordRateLimited(virtualWorkspace string) {
+	rateLimitedRequests.WithLabelValues(virtualWorkspace).Inc()
+}
+
+// InformerLagHandler returns an event handler observing the lag of the given informer of a virtual workspace.
+// Only updates are observed, as the objects of the initial list were written long before.
+func InformerLagHandler(virtualWorkspace, informer string) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			written, ok := lastWriteTime(newObj)
+			if !ok {
+				return
+			}
+			informerLag.WithLabelValues(virtualWorkspace, informer).Observe(time.Since(written).Seconds())
+		},
+	}
+}
+
+// lastWriteTime returns the most recent time of the managed fields of the object, if any.
+func lastWriteTime(obj interface{}) (time.Time, bool) {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return time.Time{}, false
+	}
+	var last time.Time
+	for _, entry := range metaObj.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(last) {
+			last = entry.Time.Time
+		}
+	}
+	return last, !last.IsZero()
+}
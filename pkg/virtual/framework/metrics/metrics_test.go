@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestRecordRequest(t *testing.T) {
+	Register()
+	requestCounter.Reset()
+
+	RecordRequest("syncer", "list", 200, false, time.Second)
+	RecordRequest("syncer", "watch", 200, true, time.Minute)
+	RecordRequest("apiexport", "get", 403, false, time.Millisecond)
+
+	require.NoError(t, testutil.CollectAndCompare(requestCounter, strings.NewReader(`
+# HELP virtual_workspace_requests_total [ALPHA] Number of requests served by a virtual workspace, partitioned by virtual workspace, verb and HTTP response code.
+# TYPE virtual_workspace_requests_total counter
+virtual_workspace_requests_total{code="200",verb="list",virtual_workspace="syncer"} 1
+virtual_workspace_requests_total{code="200",verb="watch",virtual_workspace="syncer"} 1
+virtual_workspace_requests_total{code="403",verb="get",virtual_workspace="apiexport"} 1
+`), "virtual_workspace_requests_total"))
+}
+
+func TestLastWriteTime(t *testing.T) {
+	older := metav1.NewTime(time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC))
+	newer := metav1.NewTime(time.Date(2022, 9, 1, 11, 0, 0, 0, time.UTC))
+
+	written, ok := lastWriteTime(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{
+		{Manager: "a", Time: &newer},
+		{Manager: "b", Time: &older},
+		{Manager: "c"},
+	}}})
+	require.True(t, ok)
+	require.Equal(t, newer.Time, written)
+
+	_, ok = lastWriteTime(&corev1.ConfigMap{})
+	require.False(t, ok, "objects without managed fields have no write time")
+
+	_, ok = lastWriteTime("not an object")
+	require.False(t, ok)
+}
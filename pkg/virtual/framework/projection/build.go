@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualdynamic "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+)
+
+var (
+	readOnlyVerbs = sets.NewString("get", "list", "watch")
+	mutatingVerbs = sets.NewString("create", "update", "patch", "delete", "deletecollection")
+)
+
+// BuildVirtualWorkspace builds the projection virtual workspace declared by the config, served below the
+// given root path prefix.
+func BuildVirtualWorkspace(rootPathPrefix string, config *Config) (rootapiserver.NamedVirtualWorkspace, error) {
+	if err := config.validate(); err != nil {
+		return rootapiserver.NamedVirtualWorkspace{}, err
+	}
+
+	rootPathPrefix = path.Join(rootPathPrefix, config.Name) + "/"
+	apiDomainKey := dynamiccontext.APIDomainKey(config.Name)
+
+	verbs := make(map[schema.GroupResource]sets.String, len(config.Resources))
+	for _, resource := range config.Resources {
+		verbs[groupResource(resource)] = resource.Verbs
+	}
+
+	virtualWorkspace := &virtualdynamic.DynamicVirtualWorkspace{
+		RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, ctx context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+			cluster, prefixToStrip, ok := digestUrl(urlPath, rootPathPrefix)
+			if !ok {
+				return false, "", ctx
+			}
+
+			completedContext = genericapirequest.WithCluster(ctx, cluster)
+			completedContext = dynamiccontext.WithAPIDomainKey(completedContext, apiDomainKey)
+			return true, prefixToStrip, completedContext
+		}),
+		Authorizer: newAuthorizer(config.Name, verbs, config.Authorizer),
+		ReadyChecker: framework.ReadyFunc(func() error {
+			return nil
+		}),
+		BootstrapAPISetManagement: func(mainConfig genericapiserver.CompletedConfig) (apidefinition.APIDefinitionSetGetter, error) {
+			apis := make(apidefinition.APIDefinitionSet, len(config.Resources))
+			for _, resource := range config.Resources {
+				apiDefinition, err := apiserver.CreateServingInfoFor(
+					mainConfig,
+					resource.Schema,
+					resource.Version,
+					provideRestStorage(context.Background(), config.DynamicClusterClient, resource),
+				)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create serving info for %s: %w", groupResource(resource), err)
+				}
+				apis[groupResource(resource).WithVersion(resource.Version)] = apiDefinition
+			}
+
+			return &apiSetRetriever{apiDomainKey: apiDomainKey, apis: apis}, nil
+		},
+	}
+
+	return rootapiserver.NamedVirtualWorkspace{
+		Name:             config.Name,
+		URLTemplate:      rootPathPrefix + "clusters/{cluster}",
+		VirtualWorkspace: virtualWorkspace,
+	}, nil
+}
+
+func (c *Config) validate() error {
+	if c.Name == "" {
+		return errors.New("projection virtual workspace name is required")
+	}
+	if c.DynamicClusterClient == nil {
+		return fmt.Errorf("dynamic client of the %s virtual workspace is required", c.Name)
+	}
+	if c.Authorizer == nil {
+		return fmt.Errorf("authorizer of the %s virtual workspace is required", c.Name)
+	}
+
+	seen := sets.NewString()
+	for i, resource := range c.Resources {
+		if resource.Schema == nil {
+			return fmt.Errorf("schema of resource %d of the %s virtual workspace is required", i, c.Name)
+		}
+		gr := groupResource(resource)
+		if seen.Has(gr.String()) {
+			return fmt.Errorf("resource %s is projected more than once in the %s virtual workspace", gr, c.Name)
+		}
+		seen.Insert(gr.String())
+
+		found := false
+		for _, version := range resource.Schema.Spec.Versions {
+			found = found || version.Name == resource.Version
+		}
+		if !found {
+			return fmt.Errorf("version %q of resource %s is not in its schema", resource.Version, gr)
+		}
+		if resource.Verbs.Len() == 0 {
+			return fmt.Errorf("resource %s of the %s virtual workspace has no verbs", gr, c.Name)
+		}
+		if unknown := resource.Verbs.Difference(readOnlyVerbs).Difference(mutatingVerbs); unknown.Len() > 0 {
+			return fmt.Errorf("resource %s of the %s virtual workspace has unknown verbs %v", gr, c.Name, unknown.List())
+		}
+		if len(resource.Fields) > 0 && resource.Verbs.HasAny(mutatingVerbs.UnsortedList()...) {
+			return fmt.Errorf("resource %s of the %s virtual workspace cannot project fields and allow the verbs %v", gr, c.Name, resource.Verbs.Intersection(mutatingVerbs).List())
+		}
+		if resource.LabelSelector != nil {
+			if _, selectable := resource.LabelSelector.Requirements(); !selectable {
+				return fmt.Errorf("resource %s of the %s virtual workspace has a label selector %q which cannot be expressed as requirements", gr, c.Name, resource.LabelSelector)
+			}
+		}
+		for _, field := range resource.Fields {
+			if field == "" || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+				return fmt.Errorf("resource %s of the %s virtual workspace has an invalid field %q", gr, c.Name, field)
+			}
+		}
+	}
+
+	return nil
+}
+
+func groupResource(resource Resource) schema.GroupResource {
+	return schema.GroupResource{Group: resource.Schema.Spec.Group, Resource: resource.Schema.Spec.Names.Plural}
+}
+
+// newAuthorizer returns an authorizer denying resource requests with verbs which are not allowed for the resource,
+// and delegating all the other requests.
+func newAuthorizer(name string, verbs map[schema.GroupResource]sets.String, delegate authorizer.Authorizer) authorizer.AuthorizerFunc {
+	return func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		if attr.IsResourceRequest() {
+			gr := schema.GroupResource{Group: attr.GetAPIGroup(), Resource: attr.GetResource()}
+			if allowed, found := verbs[gr]; found && (attr.GetSubresource() != "" || !allowed.Has(attr.GetVerb())) {
+				return authorizer.DecisionDeny, fmt.Sprintf("%s of %s is not allowed in the %s virtual workspace", attr.GetVerb(), gr, name), nil
+			}
+		}
+		return delegate.Authorize(ctx, attr)
+	}
+}
+
+// digestUrl accepts URL paths of the form `<root path prefix>clusters/<cluster>/...` and returns the cluster and
+// the prefix to strip.
+func digestUrl(urlPath, rootPathPrefix string) (cluster genericapirequest.Cluster, logicalPath string, accepted bool) {
+	if !strings.HasPrefix(urlPath, rootPathPrefix+"clusters/") {
+		return genericapirequest.Cluster{}, "", false
+	}
+	withoutClustersPrefix := strings.TrimPrefix(urlPath, rootPathPrefix+"clusters/")
+
+	parts := strings.SplitN(withoutClustersPrefix, "/", 2)
+	if parts[0] == "" {
+		return genericapirequest.Cluster{}, "", false
+	}
+	clusterName := logicalcluster.New(parts[0])
+	realPath := "/"
+	if len(parts) > 1 {
+		realPath += parts[1]
+	}
+
+	return genericapirequest.Cluster{Name: clusterName, Wildcard: clusterName == logicalcluster.Wildcard}, strings.TrimSuffix(urlPath, realPath), true
+}
+
+// apiSetRetriever serves the fixed APIs of the virtual workspace.
+type apiSetRetriever struct {
+	apiDomainKey dynamiccontext.APIDomainKey
+	apis         apidefinition.APIDefinitionSet
+}
+
+var _ apidefinition.APIDefinitionSetGetter = &apiSetRetriever{}
+
+func (a *apiSetRetriever) GetAPIDefinitionSet(ctx context.Context, key dynamiccontext.APIDomainKey) (apis apidefinition.APIDefinitionSet, apisExist bool, err error) {
+	if key != a.apiDomainKey {
+		return nil, false, nil
+	}
+	return a.apis, len(a.apis) > 0, nil
+}
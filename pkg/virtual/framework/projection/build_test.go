@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/dynamic"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+type fakeClusterClient struct{}
+
+func (fakeClusterClient) Cluster(name logicalcluster.Name) dynamic.Interface {
+	return nil
+}
+
+func newWidgetSchema() *apisv1alpha1.APIResourceSchema {
+	return &apisv1alpha1.APIResourceSchema{
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Group:    "example.io",
+			Names:    apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Kind: "Widget"},
+			Versions: []apisv1alpha1.APIResourceVersion{{Name: "v1", Served: true, Storage: true}},
+		},
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		verbs   sets.String
+		version string
+		labels  labels.Selector
+		wantErr bool
+	}{
+		{name: "read-only projection", fields: []string{"spec.replicas"}, verbs: sets.NewString("get", "list", "watch"), version: "v1"},
+		{name: "mutable resource", verbs: sets.NewString("get", "update"), version: "v1"},
+		{name: "mutable projection", fields: []string{"spec.replicas"}, verbs: sets.NewString("get", "update"), version: "v1", wantErr: true},
+		{name: "unknown verb", verbs: sets.NewString("get", "escalate"), version: "v1", wantErr: true},
+		{name: "no verbs", version: "v1", wantErr: true},
+		{name: "invalid field", fields: []string{"spec..replicas"}, verbs: sets.NewString("get"), version: "v1", wantErr: true},
+		{name: "unknown version", verbs: sets.NewString("get"), version: "v2", wantErr: true},
+		{name: "label selector", verbs: sets.NewString("get"), version: "v1", labels: labels.SelectorFromSet(labels.Set{"app": "widget"})},
+		{name: "unselectable label selector", verbs: sets.NewString("get"), version: "v1", labels: labels.Nothing(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Name:                 "widgets",
+				DynamicClusterClient: fakeClusterClient{},
+				Authorizer:           authorizer.AuthorizerFunc(allow),
+				Resources: []Resource{{
+					Schema:        newWidgetSchema(),
+					Version:       tt.version,
+					LabelSelector: tt.labels,
+					Fields:        tt.fields,
+					Verbs:         tt.verbs,
+				}},
+			}
+			err := config.validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizer(t *testing.T) {
+	widgets := schema.GroupResource{Group: "example.io", Resource: "widgets"}
+	authz := newAuthorizer("widgets", map[schema.GroupResource]sets.String{widgets: sets.NewString("get", "list")}, authorizer.AuthorizerFunc(allow))
+
+	tests := []struct {
+		name  string
+		attrs authorizer.AttributesRecord
+		want  authorizer.Decision
+	}{
+		{name: "allowed verb", attrs: authorizer.AttributesRecord{ResourceRequest: true, APIGroup: "example.io", Resource: "widgets", Verb: "list"}, want: authorizer.DecisionAllow},
+		{name: "denied verb", attrs: authorizer.AttributesRecord{ResourceRequest: true, APIGroup: "example.io", Resource: "widgets", Verb: "delete"}, want: authorizer.DecisionDeny},
+		{name: "subresource", attrs: authorizer.AttributesRecord{ResourceRequest: true, APIGroup: "example.io", Resource: "widgets", Subresource: "status", Verb: "get"}, want: authorizer.DecisionDeny},
+		{name: "discovery", attrs: authorizer.AttributesRecord{Path: "/apis", Verb: "get"}, want: authorizer.DecisionAllow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attrs.User = &user.DefaultInfo{Name: "user"}
+			decision, _, err := authz.Authorize(context.Background(), tt.attrs)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, decision)
+		})
+	}
+}
+
+func allow(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	return authorizer.DecisionAllow, "", nil
+}
@@ -0,0 +1,24 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package projection builds virtual workspaces serving a filtered view of existing resources, declared
+// by a Config instead of a hand-written DynamicVirtualWorkspace.
+//
+// Every projected resource is restricted by an optional label selector, an optional list of fields kept
+// in the returned objects, and the verbs allowed on it. The projected resources are forwarded to the
+// logical clusters through the forwarding registry, and the verbs are enforced by the authorizer of the
+// virtual workspace before the configured authorizer is asked.
+package projection
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projection
+
+import (
+	"context"
+	"strings"
+
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/registry/customresource"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+// provideRestStorage returns the storage of the projected resource. Resources with only read-only verbs get a
+// read-only storage, the others a storage with all verbs, which are then restricted by the authorizer.
+func provideRestStorage(ctx context.Context, clusterClient dynamic.ClusterInterface, resource Resource) apiserver.RestProviderFunc {
+	var wrappers []registry.StorageWrapper
+	if resource.LabelSelector != nil {
+		// the selector is validated to be selectable in Config.validate.
+		requirements, _ := resource.LabelSelector.Requirements()
+		wrappers = append(wrappers, registry.WithStaticLabelSelector(requirements))
+	}
+	if len(resource.Fields) > 0 {
+		wrappers = append(wrappers, withFieldProjection(resource.Fields))
+	}
+	wrapper := registry.StorageWrapper(func(resource schema.GroupResource, storage *registry.StoreFuncs) *registry.StoreFuncs {
+		for _, wrap := range wrappers {
+			storage = wrap(resource, storage)
+		}
+		return storage
+	})
+	readOnly := readOnlyVerbs.IsSuperset(resource.Verbs)
+
+	return func(gvr schema.GroupVersionResource, kind schema.GroupVersionKind, listKind schema.GroupVersionKind, typer runtime.ObjectTyper, tableConvertor rest.TableConvertor, namespaceScoped bool, schemaValidator *validate.SchemaValidator, subresourcesSchemaValidator map[string]*validate.SchemaValidator, structuralSchema *structuralschema.Structural) (mainStorage rest.Storage, subresourceStorages map[string]rest.Storage) {
+		strategy := customresource.NewStrategy(
+			typer,
+			namespaceScoped,
+			kind,
+			schemaValidator,
+			nil, // no status here
+			map[string]*structuralschema.Structural{gvr.Version: structuralSchema},
+			nil, // no status here
+			nil, // no scale here
+		)
+
+		storage, _ := registry.NewStorage(
+			ctx,
+			gvr,
+			resource.IdentityHash,
+			kind,
+			listKind,
+			strategy,
+			resource.Schema.Spec.Names.Categories,
+			tableConvertor,
+			nil,
+			clusterClient,
+			nil,
+			wrapper,
+		)
+
+		if !readOnly {
+			return storage, nil // no subresources
+		}
+
+		// only expose GET+LIST+WATCH
+		return &struct {
+			registry.FactoryFunc
+			registry.ListFactoryFunc
+			registry.DestroyerFunc
+
+			registry.GetterFunc
+			registry.ListerFunc
+			registry.WatcherFunc
+
+			registry.TableConvertorFunc
+			registry.CategoriesProviderFunc
+			registry.ResetFieldsStrategyFunc
+		}{
+			FactoryFunc:     storage.FactoryFunc,
+			ListFactoryFunc: storage.ListFactoryFunc,
+			DestroyerFunc:   storage.DestroyerFunc,
+
+			GetterFunc:  storage.GetterFunc,
+			ListerFunc:  storage.ListerFunc,
+			WatcherFunc: storage.WatcherFunc,
+
+			TableConvertorFunc:      storage.TableConvertorFunc,
+			CategoriesProviderFunc:  storage.CategoriesProviderFunc,
+			ResetFieldsStrategyFunc: storage.ResetFieldsStrategyFunc,
+		}, nil // no subresources
+	}
+}
+
+// withFieldProjection restricts the objects returned by get, list and watch requests to the given fields, in
+// addition to apiVersion, kind and metadata.
+func withFieldProjection(fields []string) registry.StorageWrapper {
+	paths := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		paths = append(paths, strings.Split(field, "."))
+	}
+
+	return func(resource schema.GroupResource, storage *registry.StoreFuncs) *registry.StoreFuncs {
+		delegateGetter := storage.GetterFunc
+		storage.GetterFunc = func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			obj, err := delegateGetter.Get(ctx, name, options)
+			if err != nil {
+				return obj, err
+			}
+			return projectFields(obj, paths), nil
+		}
+
+		delegateLister := storage.ListerFunc
+		storage.ListerFunc = func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			obj, err := delegateLister.List(ctx, options)
+			if err != nil {
+				return obj, err
+			}
+
+			items, err := meta.ExtractList(obj)
+			if err != nil {
+				return nil, err
+			}
+			for i := range items {
+				items[i] = projectFields(items[i], paths)
+			}
+			if err := meta.SetList(obj, items); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		}
+
+		delegateWatcher := storage.WatcherFunc
+		storage.WatcherFunc = func(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+			w, err := delegateWatcher.Watch(ctx, options)
+			if err != nil {
+				return w, err
+			}
+
+			return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+				if in.Type != watch.Error {
+					in.Object = projectFields(in.Object, paths)
+				}
+				return in, true
+			}), nil
+		}
+
+		return storage
+	}
+}
+
+// projectFields returns a copy of an unstructured object with only apiVersion, kind, metadata and the given
+// fields. Other objects are returned as is.
+func projectFields(obj runtime.Object, paths [][]string) runtime.Object {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj
+	}
+
+	projected := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for _, key := range []string{"apiVersion", "kind", "metadata"} {
+		if value, found := u.Object[key]; found {
+			projected.Object[key] = runtime.DeepCopyJSONValue(value)
+		}
+	}
+	for _, path := range paths {
+		value, found, err := unstructured.NestedFieldNoCopy(u.Object, path...)
+		if err != nil || !found {
+			continue
+		}
+		// the parents of the field are maps, hence setting it does not fail.
+		_ = unstructured.SetNestedField(projected.Object, runtime.DeepCopyJSONValue(value), path...)
+	}
+	return projected
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+func newWidget() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.io/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "foo"},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"secret":   "s3cr3t",
+		},
+		"status": map[string]interface{}{"ready": true},
+	}}
+}
+
+func TestWithFieldProjection(t *testing.T) {
+	want := map[string]interface{}{
+		"apiVersion": "example.io/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "foo"},
+		"spec":       map[string]interface{}{"replicas": int64(3)},
+		"status":     map[string]interface{}{"ready": true},
+	}
+
+	watcher := watch.NewFakeWithChanSize(1, false)
+	storage := withFieldProjection([]string{"spec.replicas", "status", "spec.missing"})(schema.GroupResource{Resource: "widgets"}, &registry.StoreFuncs{
+		GetterFunc: func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			return newWidget(), nil
+		},
+		ListerFunc: func(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+			return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*newWidget()}}, nil
+		},
+		WatcherFunc: func(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+			return watcher, nil
+		},
+	})
+
+	obj, err := storage.Get(context.Background(), "foo", &metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, want, obj.(*unstructured.Unstructured).Object)
+
+	list, err := storage.List(context.Background(), &internalversion.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.(*unstructured.UnstructuredList).Items, 1)
+	require.Equal(t, want, list.(*unstructured.UnstructuredList).Items[0].Object)
+
+	w, err := storage.Watch(context.Background(), &internalversion.ListOptions{})
+	require.NoError(t, err)
+	watcher.Add(newWidget())
+	event := <-w.ResultChan()
+	require.Equal(t, want, event.Object.(*unstructured.Unstructured).Object)
+	w.Stop()
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projection
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/dynamic"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// Config declares a projection virtual workspace, i.e. a view of existing resources of the logical clusters,
+// served at `<root path prefix>/<name>/clusters/<cluster>`.
+type Config struct {
+	// Name is the name of the virtual workspace, and the URL path segment it is served at.
+	Name string
+
+	// DynamicClusterClient is used to access the projected resources.
+	DynamicClusterClient dynamic.ClusterInterface
+
+	// Authorizer authorizes the requests which are allowed by the verbs of the resources.
+	Authorizer authorizer.Authorizer
+
+	// Resources are the projected resources.
+	Resources []Resource
+}
+
+// Resource declares how a resource is projected into the virtual workspace. Subresources are not served.
+type Resource struct {
+	// Schema is the schema of the resource. The group and names of the projected resource are the ones of
+	// the schema.
+	Schema *apisv1alpha1.APIResourceSchema
+
+	// Version is the served version of the schema.
+	Version string
+
+	// IdentityHash is the identity of the APIExport of the resource, if any.
+	IdentityHash string
+
+	// LabelSelector restricts the projected objects to the ones matching it. If nil, all objects are projected.
+	// It must be expressible as requirements, i.e. labels.Nothing() is rejected.
+	LabelSelector labels.Selector
+
+	// Fields are the paths of the fields kept in the projected objects, e.g. `spec.replicas`. The apiVersion,
+	// kind and metadata are always kept. If empty, objects are projected as a whole.
+	//
+	// Fields can only be set for resources which are not mutated, as updates would drop the other fields.
+	Fields []string
+
+	// Verbs are the allowed verbs, e.g. `get`, `list` and `watch` for a read-only projection.
+	Verbs sets.String
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiting
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// idleBucketTimeout is the time after which the bucket of a user who sent no request is dropped.
+	idleBucketTimeout = 10 * time.Minute
+	// cleanupInterval is the minimal time between two scans for idle buckets.
+	cleanupInterval = time.Minute
+)
+
+// Limit is the token bucket of the requests of every user of a virtual workspace. A QPS of zero
+// disables the rate limiting.
+type Limit struct {
+	QPS   float32 `json:"qps"`
+	Burst int     `json:"burst"`
+}
+
+// Enabled returns whether the limit restricts requests.
+func (l Limit) Enabled() bool {
+	return l.QPS > 0
+}
+
+// Limiter rate-limits the requests per user and virtual workspace, such that a single misbehaving
+// client, e.g. a syncer in a hot loop, cannot starve the other clients of the virtual workspace server.
+// Users of the privileged group are exempt.
+type Limiter struct {
+	defaultLimit Limit
+	overrides    map[string]Limit
+	clock        clock.PassiveClock
+
+	lock        sync.Mutex
+	buckets     map[bucketKey]*bucket
+	lastCleanup time.Time
+}
+
+type bucketKey struct {
+	virtualWorkspace string
+	user             string
+}
+
+type bucket struct {
+	limiter  flowcontrol.PassiveRateLimiter
+	lastUsed time.Time
+	rejected int64
+}
+
+// NewLimiter returns a limiter applying the default limit to all virtual workspaces, except those
+// with an override.
+func NewLimiter(defaultLimit Limit, overrides map[string]Limit) *Limiter {
+	return newLimiterWithClock(defaultLimit, overrides, clock.RealClock{})
+}
+
+func newLimiterWithClock(defaultLimit Limit, overrides map[string]Limit, clock clock.PassiveClock) *Limiter {
+	return &Limiter{
+		defaultLimit: defaultLimit,
+		overrides:    overrides,
+		clock:        clock,
+		buckets:      map[bucketKey]*bucket{},
+		lastCleanup:  clock.Now(),
+	}
+}
+
+// LimitFor returns the limit of the given virtual workspace.
+func (l *Limiter) LimitFor(virtualWorkspace string) Limit {
+	if limit, found := l.overrides[virtualWorkspace]; found {
+		return limit
+	}
+	return l.defaultLimit
+}
+
+// Allow takes a token from the bucket of the user in the given virtual workspace, and returns
+// false if there is none left.
+func (l *Limiter) Allow(virtualWorkspace string, u user.Info) bool {
+	limit := l.LimitFor(virtualWorkspace)
+	if !limit.Enabled() || sets.NewString(u.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	if now.Sub(l.lastCleanup) > cleanupInterval {
+		for key, b := range l.buckets {
+			if now.Sub(b.lastUsed) > idleBucketTimeout {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	key := bucketKey{virtualWorkspace: virtualWorkspace, user: u.GetName()}
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{limiter: flowcontrol.NewTokenBucketPassiveRateLimiterWithClock(limit.QPS, limit.Burst, l.clock)}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+
+	if !b.limiter.TryAccept() {
+		b.rejected++
+		return false
+	}
+	return true
+}
+
+// Status is the configuration and state of the rate limiting of the virtual workspaces.
+type Status struct {
+	Default   Limit            `json:"default"`
+	Overrides map[string]Limit `json:"overrides,omitempty"`
+	// Throttled are the users with rejected requests, per virtual workspace, since their
+	// bucket was created.
+	Throttled []ThrottledUser `json:"throttled"`
+}
+
+// ThrottledUser is a user with rejected requests to a virtual workspace.
+type ThrottledUser struct {
+	VirtualWorkspace string `json:"virtualWorkspace"`
+	User             string `json:"user"`
+	Rejected         int64  `json:"rejected"`
+}
+
+// Status returns the configuration of the limiter and the throttled users, sorted by virtual
+// workspace and user.
+func (l *Limiter) Status() *Status {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	status := &Status{
+		Default:   l.defaultLimit,
+		Overrides: l.overrides,
+		Throttled: []ThrottledUser{},
+	}
+	for key, b := range l.buckets {
+		if b.rejected == 0 {
+			continue
+		}
+		status.Throttled = append(status.Throttled, ThrottledUser{
+			VirtualWorkspace: key.virtualWorkspace,
+			User:             key.user,
+			Rejected:         b.rejected,
+		})
+	}
+	sort.Slice(status.Throttled, func(i, j int) bool {
+		if status.Throttled[i].VirtualWorkspace != status.Throttled[j].VirtualWorkspace {
+			return status.Throttled[i].VirtualWorkspace < status.Throttled[j].VirtualWorkspace
+		}
+		return status.Throttled[i].User < status.Throttled[j].User
+	})
+	return status
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimiting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestLimiter(t *testing.T) {
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	limiter := newLimiterWithClock(Limit{QPS: 1, Burst: 2}, map[string]Limit{"unlimited": {}, "strict": {QPS: 1, Burst: 1}}, clock)
+
+	syncer := &user.DefaultInfo{Name: "syncer"}
+	controller := &user.DefaultInfo{Name: "controller"}
+	admin := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+
+	require.True(t, limiter.Allow("syncer", syncer))
+	require.True(t, limiter.Allow("syncer", syncer))
+	require.False(t, limiter.Allow("syncer", syncer), "burst must be exhausted")
+	require.False(t, limiter.Allow("syncer", syncer), "burst must be exhausted")
+
+	require.True(t, limiter.Allow("syncer", controller), "other users must not be throttled")
+	require.True(t, limiter.Allow("strict", syncer), "other virtual workspaces must not be throttled")
+	require.False(t, limiter.Allow("strict", syncer), "override must apply")
+	for i := 0; i < 10; i++ {
+		require.True(t, limiter.Allow("unlimited", syncer), "disabled limit must not throttle")
+		require.True(t, limiter.Allow("syncer", admin), "privileged users must not be throttled")
+	}
+
+	clock.SetTime(clock.Now().Add(time.Second))
+	require.True(t, limiter.Allow("syncer", syncer), "tokens must be refilled")
+
+	require.Equal(t, &Status{
+		Default:   Limit{QPS: 1, Burst: 2},
+		Overrides: map[string]Limit{"unlimited": {}, "strict": {QPS: 1, Burst: 1}},
+		Throttled: []ThrottledUser{
+			{VirtualWorkspace: "strict", User: "syncer", Rejected: 1},
+			{VirtualWorkspace: "syncer", User: "syncer", Rejected: 2},
+		},
+	}, limiter.Status())
+
+	clock.SetTime(clock.Now().Add(idleBucketTimeout + time.Second))
+	require.True(t, limiter.Allow("syncer", controller))
+	require.Empty(t, limiter.Status().Throttled, "idle buckets must be dropped")
+}
@@ -17,10 +17,12 @@ limitations under the License.
 package rootapiserver
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,15 +30,33 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kaudit "k8s.io/apiserver/pkg/audit"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/endpoints/responsewriter"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericfilters "k8s.io/apiserver/pkg/server/filters"
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/apiserver/pkg/warning"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
 	componentbaseversion "k8s.io/component-base/version"
 
 	"github.com/kcp-dev/kcp/pkg/virtual/framework"
 	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/impersonation"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/metrics"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/ratelimiting"
+)
+
+const (
+	// virtualWorkspaceAnnotation is the audit annotation holding the name of the virtual workspace serving a request.
+	virtualWorkspaceAnnotation = "virtualworkspaces.kcp.dev/name"
+	// apiDomainKeyAnnotation is the audit annotation holding the API domain key of a request, e.g. the sync target
+	// key in the syncer virtual workspace.
+	apiDomainKeyAnnotation = "virtualworkspaces.kcp.dev/api-domain-key"
 )
 
 var (
@@ -57,10 +77,17 @@ type RootAPIExtraConfig struct {
 	informerStart func(stopCh <-chan struct{})
 
 	VirtualWorkspaces []NamedVirtualWorkspace
+
+	// RateLimiter limits the requests of every user to a virtual workspace. It is optional.
+	RateLimiter *ratelimiting.Limiter
 }
 
 type NamedVirtualWorkspace struct {
 	Name string
+	// URLTemplate is the URL path of the virtual workspace with the path parameters in braces, e.g.
+	// `/services/initializingworkspaces/{initializer}/clusters/{cluster}`. Virtual workspaces with
+	// a URL template are listed in the index of the virtual workspaces.
+	URLTemplate string
 	framework.VirtualWorkspace
 }
 
@@ -161,9 +188,23 @@ func asHealthChecks(workspaces []NamedVirtualWorkspace) []healthz.HealthChecker
 
 func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.DelegationTarget) func(http.Handler, *genericapiserver.Config) http.Handler {
 	return func(apiHandler http.Handler, genericConfig *genericapiserver.Config) http.Handler {
+		// record the impersonator in the user extras of impersonating requests, such that virtual
+		// workspaces can authorize them against the original requester.
+		chainConfig := *c.GenericConfig.Config
+		if chainConfig.Authentication.Authenticator != nil {
+			chainConfig.Authentication.Authenticator = impersonation.WithImpersonator(chainConfig.Authentication.Authenticator)
+		}
 		delegateAfterDefaultHandlerChain := genericapiserver.DefaultBuildHandlerChain(
 			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-				if _, virtualWorkspaceNameExists := virtualcontext.VirtualWorkspaceNameFrom(req.Context()); virtualWorkspaceNameExists {
+				if virtualWorkspaceName, virtualWorkspaceNameExists := virtualcontext.VirtualWorkspaceNameFrom(req.Context()); virtualWorkspaceNameExists {
+					if !c.allow(virtualWorkspaceName, req) {
+						metrics.RecordRateLimited(virtualWorkspaceName)
+						responsewriters.ErrorNegotiated(
+							apierrors.NewTooManyRequests(fmt.Sprintf("too many requests to virtual workspace %q, please try again later", virtualWorkspaceName), 1),
+							errorCodecs, schema.GroupVersion{},
+							w, req)
+						return
+					}
 					delegatedHandler := delegateAPIServer.UnprotectedHandler()
 					if delegatedHandler != nil {
 						delegatedHandler.ServeHTTP(w, req)
@@ -171,7 +212,7 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 					return
 				}
 				apiHandler.ServeHTTP(w, req)
-			}), c.GenericConfig.Config)
+			}), &chainConfig)
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			requestContext := req.Context()
 			// detect old kubectl plugins and inject warning headers
@@ -197,11 +238,92 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 					break
 				}
 			}
-			delegateAfterDefaultHandlerChain.ServeHTTP(w, req)
+
+			virtualWorkspaceName, found := virtualcontext.VirtualWorkspaceNameFrom(req.Context())
+			if !found {
+				delegateAfterDefaultHandlerChain.ServeHTTP(w, req)
+				return
+			}
+			req = req.WithContext(withAuditAnnotations(req.Context(), virtualWorkspaceName))
+			if req.Header.Get(transport.ImpersonateUserHeader) != "" {
+				// the impersonation filter of the handler chain rejects the request if the
+				// virtual workspace does not authorize the impersonation.
+				req = req.WithContext(virtualcontext.WithImpersonation(req.Context()))
+			}
+
+			verb, longRunning := strings.ToLower(req.Method), false
+			if requestInfo, err := c.GenericConfig.RequestInfoResolver.NewRequestInfo(req); err == nil {
+				verb = requestInfo.Verb
+				longRunning = c.GenericConfig.LongRunningFunc != nil && c.GenericConfig.LongRunningFunc(req, requestInfo)
+			}
+			recorder := &statusRecorder{ResponseWriter: w}
+			startTime := time.Now()
+			defer func() {
+				metrics.RecordRequest(virtualWorkspaceName, verb, recorder.Status(), longRunning, time.Since(startTime))
+			}()
+
+			delegateAfterDefaultHandlerChain.ServeHTTP(responsewriter.WrapForHTTP1Or2(recorder), req)
 		})
 	}
 }
 
+// allow returns whether the authenticated user of the request is within the rate limit of the virtual workspace.
+func (c completedConfig) allow(virtualWorkspaceName string, req *http.Request) bool {
+	if c.ExtraConfig.RateLimiter == nil {
+		return true
+	}
+	u, ok := genericapirequest.UserFrom(req.Context())
+	if !ok {
+		return true
+	}
+	return c.ExtraConfig.RateLimiter.Allow(virtualWorkspaceName, u)
+}
+
+// withAuditAnnotations initializes the audit annotations of the request, such that they are kept by the
+// default handler chain, and annotates the request with the virtual workspace serving it.
+func withAuditAnnotations(ctx context.Context, virtualWorkspaceName string) context.Context {
+	ctx = kaudit.WithAuditAnnotations(ctx)
+	kaudit.AddAuditAnnotation(ctx, virtualWorkspaceAnnotation, virtualWorkspaceName)
+	if apiDomainKey := dynamiccontext.APIDomainKeyFrom(ctx); apiDomainKey != "" {
+		kaudit.AddAuditAnnotation(ctx, apiDomainKeyAnnotation, string(apiDomainKey))
+	}
+	return ctx
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+var _ responsewriter.UserProvidedDecorator = &statusRecorder{}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Status returns the status code of the response, or 200 if nothing has been written, e.g. for a hijacked connection.
+func (r *statusRecorder) Status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
+
 func NewRootAPIConfig(recommendedConfig *genericapiserver.RecommendedConfig, informerStarts []InformerStart, virtualWorkspaces []NamedVirtualWorkspace) (*RootAPIConfig, error) {
 	// TODO: genericConfig.ExternalAddress = ... allow a command line flag or it to be overridden by a top-level multiroot apiServer
 
@@ -214,6 +336,15 @@ func NewRootAPIConfig(recommendedConfig *genericapiserver.RecommendedConfig, inf
 		Host: "loopback-config-not-wired-for-now",
 	}
 
+	// like in kube-apiserver, connect subresources are long-running, such that the streams of upgraded
+	// connections are not cut by the request timeout.
+	recommendedConfig.Config.LongRunningFunc = genericfilters.BasicLongRunningRequestCheck(
+		sets.NewString("watch", "proxy"),
+		sets.NewString("attach", "exec", "proxy", "log", "portforward"),
+	)
+
+	metrics.Register()
+
 	ret := &RootAPIConfig{
 		GenericConfig: recommendedConfig,
 		ExtraConfig: RootAPIExtraConfig{
@@ -42,6 +42,10 @@ type filteredInterface struct {
 	informers   tenancyinformers.Interface
 }
 
+func (i *filteredInterface) AccessGrants() tenancyinformers.AccessGrantInformer {
+	return FilterAccessGrantInformer(i.clusterName, i.informers.AccessGrants())
+}
+
 func (i *filteredInterface) ClusterWorkspaceTypes() tenancyinformers.ClusterWorkspaceTypeInformer {
 	return FilterClusterWorkspaceTypeInformer(i.clusterName, i.informers.ClusterWorkspaceTypes())
 }
@@ -54,6 +58,93 @@ func (i *filteredInterface) ClusterWorkspaceShards() tenancyinformers.ClusterWor
 	return FilterWorkspaceShardInformer(i.clusterName, i.informers.ClusterWorkspaceShards())
 }
 
+func (i *filteredInterface) ServiceAccountGrants() tenancyinformers.ServiceAccountGrantInformer {
+	return FilterServiceAccountGrantInformer(i.clusterName, i.informers.ServiceAccountGrants())
+}
+
+func (i *filteredInterface) SharedSecrets() tenancyinformers.SharedSecretInformer {
+	return FilterSharedSecretInformer(i.clusterName, i.informers.SharedSecrets())
+}
+
+func (i *filteredInterface) Teams() tenancyinformers.TeamInformer {
+	return FilterTeamInformer(i.clusterName, i.informers.Teams())
+}
+
+func (i *filteredInterface) ValidatingAdmissionPolicies() tenancyinformers.ValidatingAdmissionPolicyInformer {
+	return FilterValidatingAdmissionPolicyInformer(i.clusterName, i.informers.ValidatingAdmissionPolicies())
+}
+
+func (i *filteredInterface) ValidatingAdmissionPolicyBindings() tenancyinformers.ValidatingAdmissionPolicyBindingInformer {
+	return FilterValidatingAdmissionPolicyBindingInformer(i.clusterName, i.informers.ValidatingAdmissionPolicyBindings())
+}
+
+func (i *filteredInterface) WorkspaceAuthenticationConfigurations() tenancyinformers.WorkspaceAuthenticationConfigurationInformer {
+	return FilterWorkspaceAuthenticationConfigurationInformer(i.clusterName, i.informers.WorkspaceAuthenticationConfigurations())
+}
+
+func (i *filteredInterface) WorkspaceMigrations() tenancyinformers.WorkspaceMigrationInformer {
+	return FilterWorkspaceMigrationInformer(i.clusterName, i.informers.WorkspaceMigrations())
+}
+
+func (i *filteredInterface) WorkspacePolicies() tenancyinformers.WorkspacePolicyInformer {
+	return FilterWorkspacePolicyInformer(i.clusterName, i.informers.WorkspacePolicies())
+}
+
+func (i *filteredInterface) WorkspaceQuotas() tenancyinformers.WorkspaceQuotaInformer {
+	return FilterWorkspaceQuotaInformer(i.clusterName, i.informers.WorkspaceQuotas())
+}
+
+func FilterAccessGrantInformer(clusterName logicalcluster.Name, informer tenancyinformers.AccessGrantInformer) tenancyinformers.AccessGrantInformer {
+	return &filteredAccessGrantInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.AccessGrantInformer = (*filteredAccessGrantInformer)(nil)
+var _ tenancylisters.AccessGrantLister = (*filteredAccessGrantLister)(nil)
+
+type filteredAccessGrantInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.AccessGrantInformer
+}
+
+type filteredAccessGrantLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.AccessGrantLister
+}
+
+func (i *filteredAccessGrantInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredAccessGrantInformer) Lister() tenancylisters.AccessGrantLister {
+	return &filteredAccessGrantLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredAccessGrantLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.AccessGrant, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredAccessGrantLister) Get(name string) (*tenancyv1alpha1.AccessGrant, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
 func FilterClusterWorkspaceTypeInformer(clusterName logicalcluster.Name, informer tenancyinformers.ClusterWorkspaceTypeInformer) tenancyinformers.ClusterWorkspaceTypeInformer {
 	return &filteredClusterWorkspaceTypeInformer{
 		clusterName: clusterName,
@@ -206,3 +297,462 @@ func (l *filteredWorkspaceShardLister) Get(name string) (*tenancyv1alpha1.Cluste
 	}
 	return l.lister.Get(name)
 }
+
+func FilterServiceAccountGrantInformer(clusterName logicalcluster.Name, informer tenancyinformers.ServiceAccountGrantInformer) tenancyinformers.ServiceAccountGrantInformer {
+	return &filteredServiceAccountGrantInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.ServiceAccountGrantInformer = (*filteredServiceAccountGrantInformer)(nil)
+var _ tenancylisters.ServiceAccountGrantLister = (*filteredServiceAccountGrantLister)(nil)
+
+type filteredServiceAccountGrantInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.ServiceAccountGrantInformer
+}
+
+type filteredServiceAccountGrantLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.ServiceAccountGrantLister
+}
+
+func (i *filteredServiceAccountGrantInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredServiceAccountGrantInformer) Lister() tenancylisters.ServiceAccountGrantLister {
+	return &filteredServiceAccountGrantLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredServiceAccountGrantLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.ServiceAccountGrant, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredServiceAccountGrantLister) Get(name string) (*tenancyv1alpha1.ServiceAccountGrant, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterSharedSecretInformer(clusterName logicalcluster.Name, informer tenancyinformers.SharedSecretInformer) tenancyinformers.SharedSecretInformer {
+	return &filteredSharedSecretInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.SharedSecretInformer = (*filteredSharedSecretInformer)(nil)
+var _ tenancylisters.SharedSecretLister = (*filteredSharedSecretLister)(nil)
+
+type filteredSharedSecretInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.SharedSecretInformer
+}
+
+type filteredSharedSecretLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.SharedSecretLister
+}
+
+func (i *filteredSharedSecretInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredSharedSecretInformer) Lister() tenancylisters.SharedSecretLister {
+	return &filteredSharedSecretLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredSharedSecretLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.SharedSecret, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredSharedSecretLister) Get(name string) (*tenancyv1alpha1.SharedSecret, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterTeamInformer(clusterName logicalcluster.Name, informer tenancyinformers.TeamInformer) tenancyinformers.TeamInformer {
+	return &filteredTeamInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.TeamInformer = (*filteredTeamInformer)(nil)
+var _ tenancylisters.TeamLister = (*filteredTeamLister)(nil)
+
+type filteredTeamInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.TeamInformer
+}
+
+type filteredTeamLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.TeamLister
+}
+
+func (i *filteredTeamInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredTeamInformer) Lister() tenancylisters.TeamLister {
+	return &filteredTeamLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredTeamLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.Team, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredTeamLister) Get(name string) (*tenancyv1alpha1.Team, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterValidatingAdmissionPolicyInformer(clusterName logicalcluster.Name, informer tenancyinformers.ValidatingAdmissionPolicyInformer) tenancyinformers.ValidatingAdmissionPolicyInformer {
+	return &filteredValidatingAdmissionPolicyInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.ValidatingAdmissionPolicyInformer = (*filteredValidatingAdmissionPolicyInformer)(nil)
+var _ tenancylisters.ValidatingAdmissionPolicyLister = (*filteredValidatingAdmissionPolicyLister)(nil)
+
+type filteredValidatingAdmissionPolicyInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.ValidatingAdmissionPolicyInformer
+}
+
+type filteredValidatingAdmissionPolicyLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.ValidatingAdmissionPolicyLister
+}
+
+func (i *filteredValidatingAdmissionPolicyInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredValidatingAdmissionPolicyInformer) Lister() tenancylisters.ValidatingAdmissionPolicyLister {
+	return &filteredValidatingAdmissionPolicyLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredValidatingAdmissionPolicyLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.ValidatingAdmissionPolicy, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredValidatingAdmissionPolicyLister) Get(name string) (*tenancyv1alpha1.ValidatingAdmissionPolicy, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterValidatingAdmissionPolicyBindingInformer(clusterName logicalcluster.Name, informer tenancyinformers.ValidatingAdmissionPolicyBindingInformer) tenancyinformers.ValidatingAdmissionPolicyBindingInformer {
+	return &filteredValidatingAdmissionPolicyBindingInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.ValidatingAdmissionPolicyBindingInformer = (*filteredValidatingAdmissionPolicyBindingInformer)(nil)
+var _ tenancylisters.ValidatingAdmissionPolicyBindingLister = (*filteredValidatingAdmissionPolicyBindingLister)(nil)
+
+type filteredValidatingAdmissionPolicyBindingInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.ValidatingAdmissionPolicyBindingInformer
+}
+
+type filteredValidatingAdmissionPolicyBindingLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.ValidatingAdmissionPolicyBindingLister
+}
+
+func (i *filteredValidatingAdmissionPolicyBindingInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredValidatingAdmissionPolicyBindingInformer) Lister() tenancylisters.ValidatingAdmissionPolicyBindingLister {
+	return &filteredValidatingAdmissionPolicyBindingLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredValidatingAdmissionPolicyBindingLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.ValidatingAdmissionPolicyBinding, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredValidatingAdmissionPolicyBindingLister) Get(name string) (*tenancyv1alpha1.ValidatingAdmissionPolicyBinding, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterWorkspaceAuthenticationConfigurationInformer(clusterName logicalcluster.Name, informer tenancyinformers.WorkspaceAuthenticationConfigurationInformer) tenancyinformers.WorkspaceAuthenticationConfigurationInformer {
+	return &filteredWorkspaceAuthenticationConfigurationInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspaceAuthenticationConfigurationInformer = (*filteredWorkspaceAuthenticationConfigurationInformer)(nil)
+var _ tenancylisters.WorkspaceAuthenticationConfigurationLister = (*filteredWorkspaceAuthenticationConfigurationLister)(nil)
+
+type filteredWorkspaceAuthenticationConfigurationInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.WorkspaceAuthenticationConfigurationInformer
+}
+
+type filteredWorkspaceAuthenticationConfigurationLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.WorkspaceAuthenticationConfigurationLister
+}
+
+func (i *filteredWorkspaceAuthenticationConfigurationInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspaceAuthenticationConfigurationInformer) Lister() tenancylisters.WorkspaceAuthenticationConfigurationLister {
+	return &filteredWorkspaceAuthenticationConfigurationLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspaceAuthenticationConfigurationLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.WorkspaceAuthenticationConfiguration, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceAuthenticationConfigurationLister) Get(name string) (*tenancyv1alpha1.WorkspaceAuthenticationConfiguration, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterWorkspaceMigrationInformer(clusterName logicalcluster.Name, informer tenancyinformers.WorkspaceMigrationInformer) tenancyinformers.WorkspaceMigrationInformer {
+	return &filteredWorkspaceMigrationInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspaceMigrationInformer = (*filteredWorkspaceMigrationInformer)(nil)
+var _ tenancylisters.WorkspaceMigrationLister = (*filteredWorkspaceMigrationLister)(nil)
+
+type filteredWorkspaceMigrationInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.WorkspaceMigrationInformer
+}
+
+type filteredWorkspaceMigrationLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.WorkspaceMigrationLister
+}
+
+func (i *filteredWorkspaceMigrationInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspaceMigrationInformer) Lister() tenancylisters.WorkspaceMigrationLister {
+	return &filteredWorkspaceMigrationLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspaceMigrationLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.WorkspaceMigration, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceMigrationLister) Get(name string) (*tenancyv1alpha1.WorkspaceMigration, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterWorkspacePolicyInformer(clusterName logicalcluster.Name, informer tenancyinformers.WorkspacePolicyInformer) tenancyinformers.WorkspacePolicyInformer {
+	return &filteredWorkspacePolicyInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspacePolicyInformer = (*filteredWorkspacePolicyInformer)(nil)
+var _ tenancylisters.WorkspacePolicyLister = (*filteredWorkspacePolicyLister)(nil)
+
+type filteredWorkspacePolicyInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.WorkspacePolicyInformer
+}
+
+type filteredWorkspacePolicyLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.WorkspacePolicyLister
+}
+
+func (i *filteredWorkspacePolicyInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspacePolicyInformer) Lister() tenancylisters.WorkspacePolicyLister {
+	return &filteredWorkspacePolicyLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspacePolicyLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.WorkspacePolicy, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspacePolicyLister) Get(name string) (*tenancyv1alpha1.WorkspacePolicy, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func FilterWorkspaceQuotaInformer(clusterName logicalcluster.Name, informer tenancyinformers.WorkspaceQuotaInformer) tenancyinformers.WorkspaceQuotaInformer {
+	return &filteredWorkspaceQuotaInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspaceQuotaInformer = (*filteredWorkspaceQuotaInformer)(nil)
+var _ tenancylisters.WorkspaceQuotaLister = (*filteredWorkspaceQuotaLister)(nil)
+
+type filteredWorkspaceQuotaInformer struct {
+	clusterName logicalcluster.Name
+	informer    tenancyinformers.WorkspaceQuotaInformer
+}
+
+type filteredWorkspaceQuotaLister struct {
+	clusterName logicalcluster.Name
+	lister      tenancylisters.WorkspaceQuotaLister
+}
+
+func (i *filteredWorkspaceQuotaInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspaceQuotaInformer) Lister() tenancylisters.WorkspaceQuotaLister {
+	return &filteredWorkspaceQuotaLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspaceQuotaLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.WorkspaceQuota, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceQuotaLister) Get(name string) (*tenancyv1alpha1.WorkspaceQuota, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package index provides the Index Virtual Workspace.
+//
+// It lists the virtual workspaces served next to it with their URL templates and the path
+// parameters required to fill them, so that clients can construct the URL of a virtual workspace
+// without knowing its path grammar. That is, a request for
+//
+//	GET /services/index
+//
+// by any authenticated user returns e.g.
+//
+//	{
+//	  "virtualWorkspaces": [
+//	    {
+//	      "name": "syncer",
+//	      "urlTemplate": "/services/syncer/{syncTargetWorkspace}/{syncTargetName}/{syncTargetUID}/clusters/{cluster}",
+//	      "parameters": ["syncTargetWorkspace", "syncTargetName", "syncTargetUID", "cluster"]
+//	    },
+//	    ...
+//	  ]
+//	}
+//
+// Whether the user is allowed to access a virtual workspace depends on the values of the path
+// parameters and is decided by the virtual workspace itself.
+package index
+
+const VirtualWorkspaceName string = "index"
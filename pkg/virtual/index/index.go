@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/handler"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+)
+
+// Index is the response of the index virtual workspace.
+type Index struct {
+	VirtualWorkspaces []VirtualWorkspace `json:"virtualWorkspaces"`
+}
+
+// VirtualWorkspace describes how to construct the URL of a virtual workspace.
+type VirtualWorkspace struct {
+	Name string `json:"name"`
+	// URLTemplate is the URL path of the virtual workspace with the path parameters in braces.
+	URLTemplate string `json:"urlTemplate"`
+	// Parameters are the names of the path parameters of the URL template, in order.
+	Parameters []string `json:"parameters"`
+}
+
+var parameterRE = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// BuildVirtualWorkspace returns the index virtual workspace served at <rootPathPrefix>/index, listing
+// the given virtual workspaces that have a URL template.
+func BuildVirtualWorkspace(rootPathPrefix string, virtualWorkspaces []rootapiserver.NamedVirtualWorkspace) rootapiserver.NamedVirtualWorkspace {
+	indexPath := path.Join(rootPathPrefix, VirtualWorkspaceName)
+	index := NewIndex(virtualWorkspaces)
+
+	return rootapiserver.NamedVirtualWorkspace{
+		Name:        VirtualWorkspaceName,
+		URLTemplate: indexPath,
+		VirtualWorkspace: &handler.VirtualWorkspace{
+			RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+				if urlPath != indexPath && urlPath != indexPath+"/" {
+					return false, "", requestContext
+				}
+				return true, indexPath, requestContext
+			}),
+			Authorizer: authorizer.AuthorizerFunc(authorize),
+			ReadyChecker: framework.ReadyFunc(func() error {
+				return nil
+			}),
+			HandlerFactory: func(rootAPIServerConfig genericapiserver.CompletedConfig) (http.Handler, error) {
+				return newHandler(index)
+			},
+		},
+	}
+}
+
+// NewIndex returns the index of the given virtual workspaces that have a URL template.
+func NewIndex(virtualWorkspaces []rootapiserver.NamedVirtualWorkspace) *Index {
+	index := &Index{VirtualWorkspaces: []VirtualWorkspace{}}
+	for _, vw := range virtualWorkspaces {
+		if vw.URLTemplate == "" {
+			continue
+		}
+		parameters := []string{}
+		for _, match := range parameterRE.FindAllStringSubmatch(vw.URLTemplate, -1) {
+			parameters = append(parameters, match[1])
+		}
+		index.VirtualWorkspaces = append(index.VirtualWorkspaces, VirtualWorkspace{
+			Name:        vw.Name,
+			URLTemplate: vw.URLTemplate,
+			Parameters:  parameters,
+		})
+	}
+	return index
+}
+
+// authorize allows any authenticated user to read the index.
+func authorize(ctx context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+	if attrs.GetVerb() != "get" {
+		return authorizer.DecisionNoOpinion, "the index can only be read", nil
+	}
+	if attrs.GetUser() == nil || !sets.NewString(attrs.GetUser().GetGroups()...).Has(user.AllAuthenticated) {
+		return authorizer.DecisionNoOpinion, "the index is only available to authenticated users", nil
+	}
+	return authorizer.DecisionAllow, "", nil
+}
+
+func newHandler(index *Index) (http.Handler, error) {
+	body, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}), nil
+}
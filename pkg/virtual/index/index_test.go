@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/handler"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+)
+
+func TestIndexVirtualWorkspace(t *testing.T) {
+	vw := BuildVirtualWorkspace("/services", []rootapiserver.NamedVirtualWorkspace{
+		{Name: "syncer", URLTemplate: "/services/syncer/{syncTargetWorkspace}/{syncTargetName}/{syncTargetUID}/clusters/{cluster}"},
+		{Name: "syncer-helper"},
+		{Name: "workspaces", URLTemplate: "/services/workspaces/{parentWorkspace}/{scope}"},
+	})
+	require.Equal(t, "index", vw.Name)
+
+	t.Run("root path", func(t *testing.T) {
+		for urlPath, expected := range map[string]bool{
+			"/services/index":          true,
+			"/services/index/":         true,
+			"/services/index/foo":      false,
+			"/services/indexes":        false,
+			"/services/syncer/root/st": false,
+		} {
+			accepted, prefixToStrip, _ := vw.ResolveRootPath(urlPath, context.Background())
+			require.Equal(t, expected, accepted, urlPath)
+			if accepted {
+				require.Equal(t, "/services/index", prefixToStrip)
+			}
+		}
+	})
+
+	t.Run("authorization", func(t *testing.T) {
+		authenticated := &user.DefaultInfo{Name: "user", Groups: []string{user.AllAuthenticated}}
+		anonymous := &user.DefaultInfo{Name: user.Anonymous, Groups: []string{user.AllUnauthenticated}}
+
+		decision, _, err := vw.Authorize(context.Background(), authorizer.AttributesRecord{User: authenticated, Verb: "get"})
+		require.NoError(t, err)
+		require.Equal(t, authorizer.DecisionAllow, decision)
+
+		decision, _, err = vw.Authorize(context.Background(), authorizer.AttributesRecord{User: authenticated, Verb: "post"})
+		require.NoError(t, err)
+		require.Equal(t, authorizer.DecisionNoOpinion, decision)
+
+		decision, _, err = vw.Authorize(context.Background(), authorizer.AttributesRecord{User: anonymous, Verb: "get"})
+		require.NoError(t, err)
+		require.Equal(t, authorizer.DecisionNoOpinion, decision)
+	})
+
+	t.Run("response", func(t *testing.T) {
+		h, err := vw.VirtualWorkspace.(*handler.VirtualWorkspace).HandlerFactory(genericapiserver.CompletedConfig{})
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/services/index", nil))
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+
+		var index Index
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &index))
+		require.Equal(t, Index{VirtualWorkspaces: []VirtualWorkspace{
+			{
+				Name:        "syncer",
+				URLTemplate: "/services/syncer/{syncTargetWorkspace}/{syncTargetName}/{syncTargetUID}/clusters/{cluster}",
+				Parameters:  []string{"syncTargetWorkspace", "syncTargetName", "syncTargetUID", "cluster"},
+			},
+			{
+				Name:        "workspaces",
+				URLTemplate: "/services/workspaces/{parentWorkspace}/{scope}",
+				Parameters:  []string{"parentWorkspace", "scope"},
+			},
+		}}, index)
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/services/index", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	})
+}
@@ -55,6 +55,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/handler"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/metrics"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
 	"github.com/kcp-dev/kcp/pkg/virtual/initializingworkspaces"
 )
@@ -198,6 +199,7 @@ func BuildVirtualWorkspace(
 				for name, informer := range map[string]cache.SharedIndexInformer{
 					"clusterworkspaces": wildcardKcpInformers.Tenancy().V1alpha1().ClusterWorkspaces().Informer(),
 				} {
+					informer.AddEventHandler(metrics.InformerLagHandler(workspaceContentName, name))
 					if !cache.WaitForNamedCacheSync(name, hookContext.StopCh, informer.HasSynced) {
 						klog.Errorf("informer not synced")
 						return nil
@@ -287,7 +289,7 @@ func BuildVirtualWorkspace(
 	}
 
 	return []rootapiserver.NamedVirtualWorkspace{
-		{Name: wildcardWorkspacesName, VirtualWorkspace: wildcardWorkspaces},
+		{Name: wildcardWorkspacesName, URLTemplate: rootPathPrefix + "{initializer}/clusters/{cluster}", VirtualWorkspace: wildcardWorkspaces},
 		{Name: workspacesName, VirtualWorkspace: workspaces},
 		{Name: workspaceContentName, VirtualWorkspace: workspaceContent},
 	}, nil
@@ -23,16 +23,17 @@ import (
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
 	"k8s.io/apiextensions-apiserver/pkg/registry/customresource"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/klog/v2"
 	"k8s.io/kube-openapi/pkg/validation/validate"
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/initialization"
@@ -42,6 +43,16 @@ import (
 )
 
 func provideFilteredReadOnlyRestStorage(ctx context.Context, clusterClient dynamic.ClusterInterface, initializer tenancyv1alpha1.ClusterWorkspaceInitializer) (apiserver.RestProviderFunc, error) {
+	requirements, err := initializingRequirements(initializer)
+	if err != nil {
+		return nil, err
+	}
+	return registry.ProvideReadOnlyRestStorage(ctx, clusterClient, registry.WithStaticLabelSelector(requirements))
+}
+
+// initializingRequirements returns the label requirements matching the ClusterWorkspaces which are
+// Initializing and wait for the given initializer.
+func initializingRequirements(initializer tenancyv1alpha1.ClusterWorkspaceInitializer) (labels.Requirements, error) {
 	labelSelector := map[string]string{
 		tenancyv1alpha1.ClusterWorkspacePhaseLabel: string(tenancyv1alpha1.ClusterWorkspacePhaseInitializing),
 	}
@@ -51,10 +62,22 @@ func provideFilteredReadOnlyRestStorage(ctx context.Context, clusterClient dynam
 	if !selectable {
 		return nil, fmt.Errorf("unable to create a selector from the provided labels")
 	}
-	return registry.ProvideReadOnlyRestStorage(ctx, clusterClient, registry.WithStaticLabelSelector(requirements))
+	return requirements, nil
 }
 
 func provideDelegatingRestStorage(ctx context.Context, clusterClient dynamic.ClusterInterface, initializer tenancyv1alpha1.ClusterWorkspaceInitializer) (apiserver.RestProviderFunc, error) {
+	requirements, err := initializingRequirements(initializer)
+	if err != nil {
+		return nil, err
+	}
+	// only ClusterWorkspaces waiting for the initializer can be read, and the updates of those are restricted
+	// to the removal of the initializer.
+	withLabelSelector := registry.WithStaticLabelSelector(requirements)
+	withInitializerRemoval := withUpdateValidation(initializer)
+	wrapper := registry.StorageWrapper(func(resource schema.GroupResource, storage *registry.StoreFuncs) *registry.StoreFuncs {
+		return withInitializerRemoval(resource, withLabelSelector(resource, storage))
+	})
+
 	return func(resource schema.GroupVersionResource, kind schema.GroupVersionKind, listKind schema.GroupVersionKind, typer runtime.ObjectTyper, tableConvertor rest.TableConvertor, namespaceScoped bool, schemaValidator *validate.SchemaValidator, subresourcesSchemaValidator map[string]*validate.SchemaValidator, structuralSchema *structuralschema.Structural) (mainStorage rest.Storage, subresourceStorages map[string]rest.Storage) {
 		statusSchemaValidate, statusEnabled := subresourcesSchemaValidator["status"]
 
@@ -88,7 +111,7 @@ func provideDelegatingRestStorage(ctx context.Context, clusterClient dynamic.Clu
 			nil,
 			clusterClient,
 			nil,
-			withUpdateValidation(initializer),
+			wrapper,
 		)
 
 		// we want to expose some but not all the allowed endpoints, so filter by exposing just the funcs we need
@@ -144,44 +167,67 @@ func provideDelegatingRestStorage(ctx context.Context, clusterClient dynamic.Clu
 }
 
 // withUpdateValidation adds further validation to ensure that a user of this virtual workspace can only
-// remove their own initializer from the list
+// remove their own initializer from the list, and cannot change anything else in the status. The forwarding
+// storage does not call the update validation, hence the validation is done when computing the updated object.
 func withUpdateValidation(initializer tenancyv1alpha1.ClusterWorkspaceInitializer) registry.StorageWrapper {
 	return func(resource schema.GroupResource, storage *registry.StoreFuncs) *registry.StoreFuncs {
 		delegateUpdater := storage.UpdaterFunc
 		storage.UpdaterFunc = func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
-			validation := rest.ValidateObjectUpdateFunc(func(ctx context.Context, obj, old runtime.Object) error {
-				logger := klog.FromContext(ctx)
-				previous, _, err := unstructured.NestedStringSlice(old.(*unstructured.Unstructured).UnstructuredContent(), "status", "initializers")
-				if err != nil {
-					return errors.NewInternalError(fmt.Errorf("error accessing initializers from old object: %w", err))
-				}
-				current, _, err := unstructured.NestedStringSlice(obj.(*unstructured.Unstructured).UnstructuredContent(), "status", "initializers")
-				if err != nil {
-					logger.Error(err, "error accessing initializers from new object")
-					return errors.NewInternalError(fmt.Errorf("error accessing initializers from old object: %w", err))
-				}
-				invalidUpdateErr := errors.NewInvalid(
-					tenancyv1alpha1.Kind("ClusterWorkspace"),
-					name,
-					field.ErrorList{field.Invalid(
-						field.NewPath("status", "initializers"),
-						current,
-						fmt.Sprintf("only removing the %q initializer is supported", initializer),
-					)},
-				)
-				if len(previous)-len(current) != 1 {
-					return invalidUpdateErr
+			validatingObjInfo := rest.WrapUpdatedObjectInfo(objInfo, func(ctx context.Context, obj, old runtime.Object) (runtime.Object, error) {
+				if old == nil {
+					return nil, errors.NewNotFound(resource, name)
 				}
-				for _, item := range current {
-					if item == string(initializer) {
-						return invalidUpdateErr
-					}
+				if err := validateInitializerRemoval(initializer, name, obj.(*unstructured.Unstructured), old.(*unstructured.Unstructured)); err != nil {
+					return nil, err
 				}
-				return updateValidation(ctx, obj, old)
+				return obj, nil
 			})
-			return delegateUpdater.Update(ctx, name, objInfo, createValidation, validation, forceAllowCreate, options)
+			return delegateUpdater.Update(ctx, name, validatingObjInfo, createValidation, updateValidation, false, options)
 		}
 
 		return storage
 	}
 }
+
+// validateInitializerRemoval returns an error unless the status of obj is the status of old without the given
+// initializer.
+func validateInitializerRemoval(initializer tenancyv1alpha1.ClusterWorkspaceInitializer, name string, obj, old *unstructured.Unstructured) error {
+	previous, _, err := unstructured.NestedStringSlice(old.UnstructuredContent(), "status", "initializers")
+	if err != nil {
+		return errors.NewInternalError(fmt.Errorf("error accessing initializers from old object: %w", err))
+	}
+	current, _, err := unstructured.NestedStringSlice(obj.UnstructuredContent(), "status", "initializers")
+	if err != nil {
+		return errors.NewInternalError(fmt.Errorf("error accessing initializers from new object: %w", err))
+	}
+
+	invalid := func(path *field.Path, value interface{}, detail string) error {
+		return errors.NewInvalid(tenancyv1alpha1.Kind("ClusterWorkspace"), name, field.ErrorList{field.Invalid(path, value, detail)})
+	}
+
+	var expected []string
+	found := false
+	for _, item := range previous {
+		if item == string(initializer) {
+			found = true
+			continue
+		}
+		expected = append(expected, item)
+	}
+	if !found {
+		return invalid(field.NewPath("status", "initializers"), current, fmt.Sprintf("the %q initializer is not present anymore", initializer))
+	}
+	if len(expected) != len(current) || !sets.NewString(expected...).Equal(sets.NewString(current...)) {
+		return invalid(field.NewPath("status", "initializers"), current, fmt.Sprintf("only removing the %q initializer is supported", initializer))
+	}
+
+	previousStatus, _, _ := unstructured.NestedMap(old.UnstructuredContent(), "status")
+	currentStatus, _, _ := unstructured.NestedMap(obj.UnstructuredContent(), "status")
+	delete(previousStatus, "initializers")
+	delete(currentStatus, "initializers")
+	if !equality.Semantic.DeepEqual(previousStatus, currentStatus) {
+		return invalid(field.NewPath("status"), "", fmt.Sprintf("only removing the %q initializer is supported", initializer))
+	}
+
+	return nil
+}
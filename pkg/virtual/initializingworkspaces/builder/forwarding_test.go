@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+func clusterWorkspace(phase string, initializers ...interface{}) *unstructured.Unstructured {
+	status := map[string]interface{}{"phase": phase}
+	if len(initializers) > 0 {
+		status["initializers"] = initializers
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "tenancy.kcp.dev/v1alpha1",
+		"kind":       "ClusterWorkspace",
+		"status":     status,
+	}}
+}
+
+func TestValidateInitializerRemoval(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     *unstructured.Unstructured
+		obj     *unstructured.Unstructured
+		wantErr bool
+	}{
+		{
+			name: "removing own initializer",
+			old:  clusterWorkspace("Initializing", "root:org:a", "root:org:b"),
+			obj:  clusterWorkspace("Initializing", "root:org:b"),
+		},
+		{
+			name: "removing last initializer",
+			old:  clusterWorkspace("Initializing", "root:org:a"),
+			obj:  clusterWorkspace("Initializing"),
+		},
+		{
+			name:    "removing another initializer",
+			old:     clusterWorkspace("Initializing", "root:org:b", "root:org:c"),
+			obj:     clusterWorkspace("Initializing", "root:org:b"),
+			wantErr: true,
+		},
+		{
+			name:    "removing own and another initializer",
+			old:     clusterWorkspace("Initializing", "root:org:a", "root:org:b"),
+			obj:     clusterWorkspace("Initializing"),
+			wantErr: true,
+		},
+		{
+			name:    "keeping own initializer",
+			old:     clusterWorkspace("Initializing", "root:org:a"),
+			obj:     clusterWorkspace("Initializing", "root:org:a"),
+			wantErr: true,
+		},
+		{
+			name:    "changing the phase",
+			old:     clusterWorkspace("Initializing", "root:org:a"),
+			obj:     clusterWorkspace("Ready"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInitializerRemoval("root:org:a", "ws", tt.obj, tt.old)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithUpdateValidation(t *testing.T) {
+	old := clusterWorkspace("Initializing", "root:org:a")
+	var updated runtime.Object
+	storage := withUpdateValidation("root:org:a")(schema.GroupResource{Resource: "clusterworkspaces"}, &registry.StoreFuncs{
+		// like the forwarding storage, the update validation is not called.
+		UpdaterFunc: func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+			obj, err := objInfo.UpdatedObject(ctx, old)
+			if err != nil {
+				return nil, false, err
+			}
+			updated = obj
+			return obj, false, nil
+		},
+	})
+
+	_, _, err := storage.Update(context.Background(), "ws", rest.DefaultUpdatedObjectInfo(clusterWorkspace("Ready")), nil, nil, false, &metav1.UpdateOptions{})
+	require.Error(t, err)
+	require.Nil(t, updated)
+
+	_, _, err = storage.Update(context.Background(), "ws", rest.DefaultUpdatedObjectInfo(clusterWorkspace("Initializing")), nil, nil, false, &metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, clusterWorkspace("Initializing"), updated)
+}
@@ -28,6 +28,10 @@ limitations under the License.
 // WATCH semantics are similar to (and implemented by) label selectors - a ClusterWorkspace that stops
 // matching the requirements to be served (not being in Initializing phase, not requesting initialization by
 // the controller) will be removed from the stream with a synthetic Deleted event.
+//
+// For a specific workspace, it serves GET of the ClusterWorkspace and updates of its status as long as it waits for
+// the <initializer>. Status updates can only remove the <initializer> from status.initializers, so that a controller
+// cannot finish the initialization on behalf of others.
 package initializingworkspaces
 
 const VirtualWorkspaceName string = "initializingworkspaces"
@@ -27,8 +27,11 @@ import (
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	apiexportoptions "github.com/kcp-dev/kcp/pkg/virtual/apiexport/options"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+	"github.com/kcp-dev/kcp/pkg/virtual/index"
 	initializingworkspacesoptions "github.com/kcp-dev/kcp/pkg/virtual/initializingworkspaces/options"
+	"github.com/kcp-dev/kcp/pkg/virtual/ratelimits"
 	synceroptions "github.com/kcp-dev/kcp/pkg/virtual/syncer/options"
+	typedworkspacesoptions "github.com/kcp-dev/kcp/pkg/virtual/typedworkspaces/options"
 	workspacesoptions "github.com/kcp-dev/kcp/pkg/virtual/workspaces/options"
 )
 
@@ -39,6 +42,8 @@ type Options struct {
 	Syncer                 *synceroptions.Syncer
 	APIExport              *apiexportoptions.APIExport
 	InitializingWorkspaces *initializingworkspacesoptions.InitializingWorkspaces
+	TypedWorkspaces        *typedworkspacesoptions.TypedWorkspaces
+	RateLimiting           *RateLimiting
 }
 
 func NewOptions() *Options {
@@ -47,6 +52,8 @@ func NewOptions() *Options {
 		Syncer:                 synceroptions.New(),
 		APIExport:              apiexportoptions.New(),
 		InitializingWorkspaces: initializingworkspacesoptions.New(),
+		TypedWorkspaces:        typedworkspacesoptions.New(),
+		RateLimiting:           NewRateLimiting(),
 	}
 }
 
@@ -57,13 +64,19 @@ func (v *Options) Validate() []error {
 	errs = append(errs, v.Syncer.Validate(virtualWorkspacesFlagPrefix)...)
 	errs = append(errs, v.APIExport.Validate(virtualWorkspacesFlagPrefix)...)
 	errs = append(errs, v.InitializingWorkspaces.Validate(virtualWorkspacesFlagPrefix)...)
+	errs = append(errs, v.TypedWorkspaces.Validate(virtualWorkspacesFlagPrefix)...)
+	errs = append(errs, v.RateLimiting.Validate(virtualWorkspacesFlagPrefix)...)
 
 	return errs
 }
 
 func (v *Options) AddFlags(fs *pflag.FlagSet) {
 	v.Workspaces.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	v.Syncer.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	v.APIExport.AddFlags(fs, virtualWorkspacesFlagPrefix)
 	v.InitializingWorkspaces.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	v.TypedWorkspaces.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	v.RateLimiting.AddFlags(fs, virtualWorkspacesFlagPrefix)
 }
 
 func (o *Options) NewVirtualWorkspaces(
@@ -77,7 +90,7 @@ func (o *Options) NewVirtualWorkspaces(
 		return nil, err
 	}
 
-	syncer, err := o.Syncer.NewVirtualWorkspaces(rootPathPrefix, config, wildcardKcpInformers)
+	syncer, err := o.Syncer.NewVirtualWorkspaces(rootPathPrefix, config, wildcardKubeInformers, wildcardKcpInformers)
 	if err != nil {
 		return nil, err
 	}
@@ -92,11 +105,16 @@ func (o *Options) NewVirtualWorkspaces(
 		return nil, err
 	}
 
-	all, err := merge(workspaces, syncer, apiexports, initializingworkspaces)
+	typedworkspaces, err := o.TypedWorkspaces.NewVirtualWorkspaces(rootPathPrefix, config)
 	if err != nil {
 		return nil, err
 	}
-	return all, nil
+
+	all, err := merge(workspaces, syncer, apiexports, initializingworkspaces, typedworkspaces, []rootapiserver.NamedVirtualWorkspace{ratelimits.BuildVirtualWorkspace(rootPathPrefix, o.RateLimiting.Limiter())})
+	if err != nil {
+		return nil, err
+	}
+	return merge(all, []rootapiserver.NamedVirtualWorkspace{index.BuildVirtualWorkspace(rootPathPrefix, all)})
 }
 
 func merge(sets ...[]rootapiserver.NamedVirtualWorkspace) ([]rootapiserver.NamedVirtualWorkspace, error) {
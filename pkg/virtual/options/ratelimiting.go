@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/ratelimiting"
+)
+
+type RateLimiting struct {
+	// QPS is the sustained rate of requests per second of every user to a virtual workspace. Zero disables
+	// the rate limiting.
+	QPS float32
+	// Burst is the number of requests of a user to a virtual workspace above the QPS.
+	Burst int
+	// Overrides are the limits of specific virtual workspaces, in the format <qps>:<burst>, by virtual
+	// workspace name.
+	Overrides map[string]string
+
+	limiterOnce sync.Once
+	limiter     *ratelimiting.Limiter
+}
+
+func NewRateLimiting() *RateLimiting {
+	return &RateLimiting{
+		Burst:     100,
+		Overrides: map[string]string{},
+	}
+}
+
+func (o *RateLimiting) AddFlags(flags *pflag.FlagSet, prefix string) {
+	if o == nil {
+		return
+	}
+
+	flags.Float32Var(&o.QPS, prefix+"rate-limit-qps", o.QPS,
+		"Maximum sustained requests per second of every user to a virtual workspace. Users in the system:masters group are exempt. Zero disables the rate limiting.")
+	flags.IntVar(&o.Burst, prefix+"rate-limit-burst", o.Burst,
+		"Maximum burst of requests of every user to a virtual workspace above --"+prefix+"rate-limit-qps.")
+	flags.StringToStringVar(&o.Overrides, prefix+"rate-limit-overrides", o.Overrides,
+		"Rate limits of specific virtual workspaces in the format <qps>:<burst>, by virtual workspace name, e.g. syncer=50:200. A QPS of zero disables the rate limiting of the virtual workspace.")
+}
+
+func (o *RateLimiting) Validate(flagPrefix string) []error {
+	if o == nil {
+		return nil
+	}
+	errs := []error{}
+
+	if o.QPS < 0 {
+		errs = append(errs, fmt.Errorf("--%srate-limit-qps must not be negative", flagPrefix))
+	}
+	if o.QPS > 0 && o.Burst < 1 {
+		errs = append(errs, fmt.Errorf("--%srate-limit-burst must be positive", flagPrefix))
+	}
+	for name, value := range o.Overrides {
+		if _, err := parseLimit(value); err != nil {
+			errs = append(errs, fmt.Errorf("--%srate-limit-overrides has an invalid limit for %q: %w", flagPrefix, name, err))
+		}
+	}
+
+	return errs
+}
+
+// Limiter returns the rate limiter of the virtual workspaces. The options must be valid.
+func (o *RateLimiting) Limiter() *ratelimiting.Limiter {
+	o.limiterOnce.Do(func() {
+		overrides := map[string]ratelimiting.Limit{}
+		for name, value := range o.Overrides {
+			limit, _ := parseLimit(value)
+			overrides[name] = limit
+		}
+		o.limiter = ratelimiting.NewLimiter(ratelimiting.Limit{QPS: o.QPS, Burst: o.Burst}, overrides)
+	})
+	return o.limiter
+}
+
+// parseLimit parses a limit in the format <qps>:<burst>.
+func parseLimit(value string) (ratelimiting.Limit, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return ratelimiting.Limit{}, fmt.Errorf("expected <qps>:<burst>, got %q", value)
+	}
+	qps, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil || qps < 0 {
+		return ratelimiting.Limit{}, fmt.Errorf("invalid qps %q", parts[0])
+	}
+	burst, err := strconv.Atoi(parts[1])
+	if err != nil || (qps > 0 && burst < 1) {
+		return ratelimiting.Limit{}, fmt.Errorf("invalid burst %q", parts[1])
+	}
+	return ratelimiting.Limit{QPS: float32(qps), Burst: burst}, nil
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ratelimits provides the RateLimits Virtual Workspace.
+//
+// It reports the rate limits of the virtual workspaces and the users whose requests have been
+// rejected because they exceeded them. That is, a request for
+//
+//	GET /services/ratelimits
+//
+// by a member of the system:masters group returns e.g.
+//
+//	{
+//	  "default": {"qps": 10, "burst": 100},
+//	  "overrides": {"syncer": {"qps": 50, "burst": 200}},
+//	  "throttled": [
+//	    {"virtualWorkspace": "syncer", "user": "system:serviceaccount:default:kcp-syncer-abc", "rejected": 42}
+//	  ]
+//	}
+package ratelimits
+
+const VirtualWorkspaceName string = "ratelimits"
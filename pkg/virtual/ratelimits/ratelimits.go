@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimits
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/handler"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/ratelimiting"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+)
+
+// BuildVirtualWorkspace returns the virtual workspace served at <rootPathPrefix>/ratelimits, reporting
+// the status of the given rate limiter.
+func BuildVirtualWorkspace(rootPathPrefix string, limiter *ratelimiting.Limiter) rootapiserver.NamedVirtualWorkspace {
+	statusPath := path.Join(rootPathPrefix, VirtualWorkspaceName)
+
+	return rootapiserver.NamedVirtualWorkspace{
+		Name: VirtualWorkspaceName,
+		VirtualWorkspace: &handler.VirtualWorkspace{
+			RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+				if urlPath != statusPath && urlPath != statusPath+"/" {
+					return false, "", requestContext
+				}
+				return true, statusPath, requestContext
+			}),
+			Authorizer: authorizer.AuthorizerFunc(authorize),
+			ReadyChecker: framework.ReadyFunc(func() error {
+				return nil
+			}),
+			HandlerFactory: func(rootAPIServerConfig genericapiserver.CompletedConfig) (http.Handler, error) {
+				return newHandler(limiter), nil
+			},
+		},
+	}
+}
+
+// authorize has no opinion, such that only the privileged groups allowed by the root authorizer, i.e.
+// system:masters, can read the status. It reveals the names of the users of all virtual workspaces.
+func authorize(ctx context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+	return authorizer.DecisionNoOpinion, "the rate limits are only available to privileged users", nil
+}
+
+func newHandler(limiter *ratelimiting.Limiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := json.Marshal(limiter.Status())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	})
+}
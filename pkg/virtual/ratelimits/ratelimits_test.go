@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimits
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/handler"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/ratelimiting"
+)
+
+func TestRateLimitsVirtualWorkspace(t *testing.T) {
+	limiter := ratelimiting.NewLimiter(ratelimiting.Limit{QPS: 1, Burst: 1}, map[string]ratelimiting.Limit{"index": {}})
+	vw := BuildVirtualWorkspace("/services", limiter)
+	require.Equal(t, "ratelimits", vw.Name)
+	require.Empty(t, vw.URLTemplate, "the status must not be listed in the index")
+
+	for urlPath, expected := range map[string]bool{
+		"/services/ratelimits":     true,
+		"/services/ratelimits/":    true,
+		"/services/ratelimits/foo": false,
+		"/services/index":          false,
+	} {
+		accepted, _, _ := vw.ResolveRootPath(urlPath, context.Background())
+		require.Equal(t, expected, accepted, urlPath)
+	}
+
+	syncer := &user.DefaultInfo{Name: "syncer"}
+	require.True(t, limiter.Allow("syncer", syncer))
+	require.False(t, limiter.Allow("syncer", syncer))
+
+	h, err := vw.VirtualWorkspace.(*handler.VirtualWorkspace).HandlerFactory(genericapiserver.CompletedConfig{})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status ratelimiting.Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Equal(t, []ratelimiting.ThrottledUser{{VirtualWorkspace: "syncer", User: "syncer", Rejected: 1}}, status.Throttled)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
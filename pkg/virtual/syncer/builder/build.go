@@ -24,12 +24,14 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/dynamic"
+	kubernetesinformers "k8s.io/client-go/informers"
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
@@ -41,11 +43,13 @@ import (
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualauthorization "github.com/kcp-dev/kcp/pkg/virtual/framework/authorization"
 	virtualworkspacesdynamic "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/metrics"
 	syncercontext "github.com/kcp-dev/kcp/pkg/virtual/syncer/context"
 	"github.com/kcp-dev/kcp/pkg/virtual/syncer/controllers/apireconciler"
 )
@@ -59,6 +63,7 @@ func BuildVirtualWorkspace(
 	kubeClusterClient kubernetesclient.ClusterInterface,
 	dynamicClusterClient dynamic.ClusterInterface,
 	kcpClusterClient kcpclient.ClusterInterface,
+	wildcardKubeInformers kubernetesinformers.SharedInformerFactory,
 	wildcardKcpInformers kcpinformers.SharedInformerFactory,
 ) framework.VirtualWorkspace {
 
@@ -69,96 +74,8 @@ func BuildVirtualWorkspace(
 	readyCh := make(chan struct{})
 
 	return &virtualworkspacesdynamic.DynamicVirtualWorkspace{
-		RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
-			select {
-			case <-readyCh:
-			default:
-				return
-			}
-
-			completedContext = requestContext
-			if !strings.HasPrefix(urlPath, rootPathPrefix) {
-				return
-			}
-			withoutRootPathPrefix := strings.TrimPrefix(urlPath, rootPathPrefix)
-
-			// Incoming requests to this virtual workspace will look like:
-			//  /services/syncer/root:org:ws/<sync-target-name>/<sync-target-uid>/clusters/*/api/v1/configmaps
-			//                  └───────────────────────────┐
-			// Where the withoutRootPathPrefix starts here: ┘
-			parts := strings.SplitN(withoutRootPathPrefix, "/", 4)
-			if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
-				return
-			}
-			workspace := parts[0]
-			workloadCusterName := parts[1]
-			syncTargetUID := parts[2]
-			apiDomainKey := dynamiccontext.APIDomainKey(clusters.ToClusterAwareKey(logicalcluster.New(parts[0]), workloadCusterName))
-
-			// In order to avoid conflicts with reusing deleted synctarget names, let's make sure that the synctarget name and synctarget UID match, if not,
-			// that likely means that a syncer is running with a stale synctarget that got deleted.
-			syncTarget, exists, err := wildcardKcpInformers.Workload().V1alpha1().SyncTargets().Informer().GetIndexer().GetByKey(clusters.ToClusterAwareKey(logicalcluster.New(workspace), workloadCusterName))
-			if !exists || err != nil {
-				runtime.HandleError(fmt.Errorf("failed to get synctarget %s|%s: %w", workspace, workloadCusterName, err))
-				return
-			}
-			syncTargetObj := syncTarget.(*workloadv1alpha1.SyncTarget)
-			if string(syncTargetObj.UID) != syncTargetUID {
-				runtime.HandleError(fmt.Errorf("sync target UID mismatch: %s != %s", syncTargetObj.UID, syncTargetUID))
-				return
-			}
-
-			realPath := "/"
-			if len(parts) > 3 {
-				realPath += parts[3]
-			}
-
-			//  /services/syncer/root:org:ws/<sync-target-name>/<sync-target-uid>/clusters/*/api/v1/configmaps
-			//                  ┌───────────────────────────────────────────────┘
-			// We are now here: ┘
-			// Now, we parse out the logical cluster.
-			if !strings.HasPrefix(realPath, "/clusters/") {
-				return // don't accept
-			}
-
-			withoutClustersPrefix := strings.TrimPrefix(realPath, "/clusters/")
-			parts = strings.SplitN(withoutClustersPrefix, "/", 2)
-			clusterName := parts[0]
-			realPath = "/"
-			if len(parts) > 1 {
-				realPath += parts[1]
-			}
-			cluster := genericapirequest.Cluster{Name: logicalcluster.New(clusterName)}
-			if clusterName == "*" {
-				cluster.Wildcard = true
-			}
-
-			completedContext = genericapirequest.WithCluster(requestContext, cluster)
-			completedContext = syncercontext.WithSyncTargetName(completedContext, workloadCusterName)
-			completedContext = dynamiccontext.WithAPIDomainKey(completedContext, apiDomainKey)
-			prefixToStrip = strings.TrimSuffix(urlPath, realPath)
-			accepted = true
-			return
-		}),
-		Authorizer: authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
-			syncTargetKey := dynamiccontext.APIDomainKeyFrom(ctx)
-			negotiationWorkspaceName, syncTargetName := clusters.SplitClusterAwareKey(string(syncTargetKey))
-
-			authz, err := delegated.NewDelegatedAuthorizer(negotiationWorkspaceName, kubeClusterClient)
-			if err != nil {
-				return authorizer.DecisionNoOpinion, "Error", err
-			}
-			SARAttributes := authorizer.AttributesRecord{
-				User:            a.GetUser(),
-				Verb:            "sync",
-				Name:            syncTargetName,
-				APIGroup:        workloadv1alpha1.SchemeGroupVersion.Group,
-				APIVersion:      workloadv1alpha1.SchemeGroupVersion.Version,
-				Resource:        "synctargets",
-				ResourceRequest: true,
-			}
-			return authz.Authorize(ctx, SARAttributes)
-		}),
+		RootPathResolver: newSyncTargetRootPathResolver(rootPathPrefix, readyCh, wildcardKcpInformers),
+		Authorizer:       newSyncTargetAuthorizer(kubeClusterClient, wildcardKubeInformers, wildcardKcpInformers, "sync"),
 		ReadyChecker: framework.ReadyFunc(func() error {
 			select {
 			case <-readyCh:
@@ -173,7 +90,7 @@ func BuildVirtualWorkspace(
 				wildcardKcpInformers.Workload().V1alpha1().SyncTargets(),
 				wildcardKcpInformers.Apis().V1alpha1().APIResourceSchemas(),
 				wildcardKcpInformers.Apis().V1alpha1().APIExports(),
-				func(syncTargetWorkspace logicalcluster.Name, syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, apiExportIdentityHash string) (apidefinition.APIDefinition, error) {
+				func(syncTargetWorkspace logicalcluster.Name, syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, apiExportIdentityHash string, access workloadv1alpha1.ResourceAccess) (apidefinition.APIDefinition, error) {
 					syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName)
 					requirements, selectable := labels.SelectorFromSet(map[string]string{
 						workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey: string(workloadv1alpha1.ResourceStateSync),
@@ -184,7 +101,13 @@ func BuildVirtualWorkspace(
 					storageWrapper := forwardingregistry.WithStaticLabelSelector(requirements)
 
 					ctx, cancelFn := context.WithCancel(context.Background())
-					storageBuilder := NewStorageBuilder(ctx, dynamicClusterClient, apiExportIdentityHash, storageWrapper)
+					var scale *apiextensionsv1.CustomResourceSubresourceScale
+					for i := range apiResourceSchema.Spec.Versions {
+						if v := &apiResourceSchema.Spec.Versions[i]; v.Name == version {
+							scale = v.Subresources.Scale
+						}
+					}
+					storageBuilder := NewStorageBuilder(ctx, dynamicClusterClient, apiExportIdentityHash, access, scale, storageWrapper)
 					def, err := apiserver.CreateServingInfoFor(mainConfig, apiResourceSchema, version, storageBuilder)
 					if err != nil {
 						cancelFn()
@@ -208,6 +131,7 @@ func BuildVirtualWorkspace(
 					"apiresourceschemas": wildcardKcpInformers.Apis().V1alpha1().APIResourceSchemas().Informer(),
 					"apiexports":         wildcardKcpInformers.Apis().V1alpha1().APIExports().Informer(),
 				} {
+					informer.AddEventHandler(metrics.InformerLagHandler(SyncerVirtualWorkspaceName, name))
 					if !cache.WaitForNamedCacheSync(name, hookContext.StopCh, informer.HasSynced) {
 						klog.Errorf("informer not synced")
 						return nil
@@ -225,6 +149,123 @@ func BuildVirtualWorkspace(
 	}
 }
 
+// newSyncTargetRootPathResolver returns a root path resolver accepting requests for the SyncTarget and logical cluster
+// in the path, once readyCh is closed. Requests look like:
+//
+//	<rootPathPrefix>/root:org:ws/<sync-target-name>/<sync-target-uid>/clusters/*/api/v1/configmaps
+func newSyncTargetRootPathResolver(rootPathPrefix string, readyCh <-chan struct{}, wildcardKcpInformers kcpinformers.SharedInformerFactory) framework.RootPathResolver {
+	return framework.RootPathResolverFunc(func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+		select {
+		case <-readyCh:
+		default:
+			return
+		}
+
+		completedContext = requestContext
+		if !strings.HasPrefix(urlPath, rootPathPrefix) {
+			return
+		}
+		withoutRootPathPrefix := strings.TrimPrefix(urlPath, rootPathPrefix)
+
+		// Incoming requests to this virtual workspace will look like:
+		//  /services/syncer/root:org:ws/<sync-target-name>/<sync-target-uid>/clusters/*/api/v1/configmaps
+		//                  └───────────────────────────┐
+		// Where the withoutRootPathPrefix starts here: ┘
+		parts := strings.SplitN(withoutRootPathPrefix, "/", 4)
+		if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return
+		}
+		workspace := parts[0]
+		workloadCusterName := parts[1]
+		syncTargetUID := parts[2]
+		apiDomainKey := dynamiccontext.APIDomainKey(clusters.ToClusterAwareKey(logicalcluster.New(parts[0]), workloadCusterName))
+
+		// In order to avoid conflicts with reusing deleted synctarget names, let's make sure that the synctarget name and synctarget UID match, if not,
+		// that likely means that a syncer is running with a stale synctarget that got deleted.
+		syncTarget, exists, err := wildcardKcpInformers.Workload().V1alpha1().SyncTargets().Informer().GetIndexer().GetByKey(clusters.ToClusterAwareKey(logicalcluster.New(workspace), workloadCusterName))
+		if !exists || err != nil {
+			runtime.HandleError(fmt.Errorf("failed to get synctarget %s|%s: %w", workspace, workloadCusterName, err))
+			return
+		}
+		syncTargetObj := syncTarget.(*workloadv1alpha1.SyncTarget)
+		if string(syncTargetObj.UID) != syncTargetUID {
+			runtime.HandleError(fmt.Errorf("sync target UID mismatch: %s != %s", syncTargetObj.UID, syncTargetUID))
+			return
+		}
+
+		realPath := "/"
+		if len(parts) > 3 {
+			realPath += parts[3]
+		}
+
+		//  /services/syncer/root:org:ws/<sync-target-name>/<sync-target-uid>/clusters/*/api/v1/configmaps
+		//                  ┌───────────────────────────────────────────────┘
+		// We are now here: ┘
+		// Now, we parse out the logical cluster.
+		if !strings.HasPrefix(realPath, "/clusters/") {
+			return // don't accept
+		}
+
+		withoutClustersPrefix := strings.TrimPrefix(realPath, "/clusters/")
+		parts = strings.SplitN(withoutClustersPrefix, "/", 2)
+		clusterName := parts[0]
+		realPath = "/"
+		if len(parts) > 1 {
+			realPath += parts[1]
+		}
+		cluster := genericapirequest.Cluster{Name: logicalcluster.New(clusterName)}
+		if clusterName == "*" {
+			cluster.Wildcard = true
+		}
+
+		completedContext = genericapirequest.WithCluster(requestContext, cluster)
+		completedContext = syncercontext.WithSyncTargetName(completedContext, workloadCusterName)
+		completedContext = dynamiccontext.WithAPIDomainKey(completedContext, apiDomainKey)
+		prefixToStrip = strings.TrimSuffix(urlPath, realPath)
+		accepted = true
+		return
+	})
+}
+
+// newSyncTargetAuthorizer returns an authorizer allowing users with the given verb on the SyncTarget of the request.
+// Decisions are cached until RBAC changes in the workspace of the SyncTarget or a SyncTarget is deleted there, as
+// syncers send many requests.
+func newSyncTargetAuthorizer(kubeClusterClient kubernetesclient.ClusterInterface, wildcardKubeInformers kubernetesinformers.SharedInformerFactory, wildcardKcpInformers kcpinformers.SharedInformerFactory, verb string) authorizer.Authorizer {
+	authz := virtualauthorization.NewCachingAuthorizer(newSyncTargetSARAuthorizer(kubeClusterClient, verb), syncTargetCluster,
+		virtualauthorization.DefaultAllowCacheTTL, virtualauthorization.DefaultDenyCacheTTL, virtualauthorization.DefaultCacheMaxEntries)
+	authz.InvalidateOnRBACChanges(wildcardKubeInformers.Rbac().V1())
+	authz.InvalidateOnDeletion(wildcardKcpInformers.Workload().V1alpha1().SyncTargets().Informer())
+	return authz
+}
+
+// syncTargetCluster returns the workspace of the SyncTarget of the request, whose RBAC the decisions depend on.
+func syncTargetCluster(ctx context.Context) logicalcluster.Name {
+	clusterName, _ := clusters.SplitClusterAwareKey(string(dynamiccontext.APIDomainKeyFrom(ctx)))
+	return clusterName
+}
+
+func newSyncTargetSARAuthorizer(kubeClusterClient kubernetesclient.ClusterInterface, verb string) authorizer.Authorizer {
+	return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		syncTargetKey := dynamiccontext.APIDomainKeyFrom(ctx)
+		negotiationWorkspaceName, syncTargetName := clusters.SplitClusterAwareKey(string(syncTargetKey))
+
+		authz, err := delegated.NewDelegatedAuthorizer(negotiationWorkspaceName, kubeClusterClient)
+		if err != nil {
+			return authorizer.DecisionNoOpinion, "Error", err
+		}
+		SARAttributes := authorizer.AttributesRecord{
+			User:            a.GetUser(),
+			Verb:            verb,
+			Name:            syncTargetName,
+			APIGroup:        workloadv1alpha1.SchemeGroupVersion.Group,
+			APIVersion:      workloadv1alpha1.SchemeGroupVersion.Version,
+			Resource:        "synctargets",
+			ResourceRequest: true,
+		}
+		return authz.Authorize(ctx, SARAttributes)
+	})
+}
+
 // apiDefinitionWithCancel calls the cancelFn on tear-down.
 type apiDefinitionWithCancel struct {
 	apidefinition.APIDefinition
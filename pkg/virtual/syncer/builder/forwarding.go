@@ -20,20 +20,26 @@ import (
 	"context"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
 	"k8s.io/apiextensions-apiserver/pkg/registry/customresource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/kube-openapi/pkg/validation/validate"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
 	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
 )
 
 // NewStorageBuilder returns a forwarding storage build function, with an optional storage wrapper e.g. to add label based filtering.
-func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterface, apiExportIdentityHash string, wrapper registry.StorageWrapper) apiserver.RestProviderFunc {
+// The verbs served for the resource and its status and scale subresources, and hence advertised in discovery, depend on the given
+// access. The scale subresource is served if scale is not nil. Updates, including server-side apply patches, never create objects.
+func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterface, apiExportIdentityHash string, access workloadv1alpha1.ResourceAccess, scale *apiextensionsv1.CustomResourceSubresourceScale, wrapper registry.StorageWrapper) apiserver.RestProviderFunc {
 	return func(resource schema.GroupVersionResource, kind schema.GroupVersionKind, listKind schema.GroupVersionKind, typer runtime.ObjectTyper, tableConvertor rest.TableConvertor, namespaceScoped bool, schemaValidator *validate.SchemaValidator, subresourcesSchemaValidator map[string]*validate.SchemaValidator, structuralSchema *structuralschema.Structural) (mainStorage rest.Storage, subresourceStorages map[string]rest.Storage) {
 		statusSchemaValidate, statusEnabled := subresourcesSchemaValidator["status"]
 
@@ -43,7 +49,6 @@ func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterfa
 		}
 
 		var scaleSpec *apiextensions.CustomResourceSubresourceScale
-		// TODO(sttts): implement scale subresource
 
 		strategy := customresource.NewStrategy(
 			typer,
@@ -68,38 +73,123 @@ func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterfa
 			nil,
 			clusterClient,
 			nil,
-			wrapper,
+			func(resource schema.GroupResource, storage *registry.StoreFuncs) *registry.StoreFuncs {
+				if wrapper != nil {
+					storage = wrapper(resource, storage)
+				}
+				return withoutCreateOnUpdate(resource, storage)
+			},
 		)
 
 		// we want to expose some but not all the allowed endpoints, so filter by exposing just the funcs we need
 		subresourceStorages = make(map[string]rest.Storage)
 		if statusEnabled {
-			subresourceStorages["status"] = &struct {
+			if access == workloadv1alpha1.ResourceAccessReadOnly {
+				subresourceStorages["status"] = &struct {
+					registry.FactoryFunc
+					registry.DestroyerFunc
+
+					registry.GetterFunc
+
+					registry.TableConvertorFunc
+					registry.CategoriesProviderFunc
+					registry.ResetFieldsStrategyFunc
+				}{
+					FactoryFunc:   statusStorage.FactoryFunc,
+					DestroyerFunc: statusStorage.DestroyerFunc,
+
+					GetterFunc: statusStorage.GetterFunc,
+
+					TableConvertorFunc:      statusStorage.TableConvertorFunc,
+					CategoriesProviderFunc:  statusStorage.CategoriesProviderFunc,
+					ResetFieldsStrategyFunc: statusStorage.ResetFieldsStrategyFunc,
+				}
+			} else {
+				subresourceStorages["status"] = &struct {
+					registry.FactoryFunc
+					registry.DestroyerFunc
+
+					registry.GetterFunc
+					registry.UpdaterFunc
+					// patch is implicit as we have get + update
+
+					registry.TableConvertorFunc
+					registry.CategoriesProviderFunc
+					registry.ResetFieldsStrategyFunc
+				}{
+					FactoryFunc:   statusStorage.FactoryFunc,
+					DestroyerFunc: statusStorage.DestroyerFunc,
+
+					GetterFunc:  statusStorage.GetterFunc,
+					UpdaterFunc: statusStorage.UpdaterFunc,
+
+					TableConvertorFunc:      statusStorage.TableConvertorFunc,
+					CategoriesProviderFunc:  statusStorage.CategoriesProviderFunc,
+					ResetFieldsStrategyFunc: statusStorage.ResetFieldsStrategyFunc,
+				}
+			}
+		}
+
+		if scale != nil {
+			scaleStorage := registry.NewScaleStorage(resource.GroupResource(), storage, scale)
+			if access == workloadv1alpha1.ResourceAccessReadWrite {
+				subresourceStorages["scale"] = &struct {
+					registry.FactoryFunc
+					registry.DestroyerFunc
+
+					registry.GetterFunc
+					registry.UpdaterFunc
+					// patch is implicit as we have get + update
+				}{
+					FactoryFunc:   scaleStorage.FactoryFunc,
+					DestroyerFunc: scaleStorage.DestroyerFunc,
+
+					GetterFunc:  scaleStorage.GetterFunc,
+					UpdaterFunc: scaleStorage.UpdaterFunc,
+				}
+			} else {
+				subresourceStorages["scale"] = &struct {
+					registry.FactoryFunc
+					registry.DestroyerFunc
+
+					registry.GetterFunc
+				}{
+					FactoryFunc:   scaleStorage.FactoryFunc,
+					DestroyerFunc: scaleStorage.DestroyerFunc,
+
+					GetterFunc: scaleStorage.GetterFunc,
+				}
+			}
+		}
+
+		if access != workloadv1alpha1.ResourceAccessReadWrite {
+			return &struct {
 				registry.FactoryFunc
+				registry.ListFactoryFunc
 				registry.DestroyerFunc
 
 				registry.GetterFunc
-				registry.UpdaterFunc
-				// patch is implicit as we have get + update
+				registry.ListerFunc
+				registry.WatcherFunc
 
 				registry.TableConvertorFunc
 				registry.CategoriesProviderFunc
 				registry.ResetFieldsStrategyFunc
 			}{
-				FactoryFunc:   statusStorage.FactoryFunc,
-				DestroyerFunc: statusStorage.DestroyerFunc,
-
-				GetterFunc:  statusStorage.GetterFunc,
-				UpdaterFunc: statusStorage.UpdaterFunc,
-
-				TableConvertorFunc:      statusStorage.TableConvertorFunc,
-				CategoriesProviderFunc:  statusStorage.CategoriesProviderFunc,
-				ResetFieldsStrategyFunc: statusStorage.ResetFieldsStrategyFunc,
-			}
+				FactoryFunc:     storage.FactoryFunc,
+				ListFactoryFunc: storage.ListFactoryFunc,
+				DestroyerFunc:   storage.DestroyerFunc,
+
+				GetterFunc:  storage.GetterFunc,
+				ListerFunc:  storage.ListerFunc,
+				WatcherFunc: storage.WatcherFunc,
+
+				TableConvertorFunc:      storage.TableConvertorFunc,
+				CategoriesProviderFunc:  storage.CategoriesProviderFunc,
+				ResetFieldsStrategyFunc: storage.ResetFieldsStrategyFunc,
+			}, subresourceStorages
 		}
 
-		// TODO(sttts): add scale subresource
-
 		return &struct {
 			registry.FactoryFunc
 			registry.ListFactoryFunc
@@ -108,6 +198,7 @@ func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterfa
 			registry.GetterFunc
 			registry.ListerFunc
 			registry.UpdaterFunc
+			// patch, including server-side apply, is implicit as we have get + update
 			registry.WatcherFunc
 
 			registry.TableConvertorFunc
@@ -129,3 +220,20 @@ func NewStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterfa
 		}, subresourceStorages
 	}
 }
+
+// withoutCreateOnUpdate makes updates of objects that do not exist, or are filtered out by the storage, fail with
+// NotFound. The forwarding storage otherwise creates the object for server-side apply patches, but syncers do not
+// create objects in kcp.
+func withoutCreateOnUpdate(resource schema.GroupResource, storage *registry.StoreFuncs) *registry.StoreFuncs {
+	delegateUpdater := storage.UpdaterFunc
+	storage.UpdaterFunc = func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+		existingObjInfo := rest.WrapUpdatedObjectInfo(objInfo, func(ctx context.Context, obj, old runtime.Object) (runtime.Object, error) {
+			if old == nil {
+				return nil, apierrors.NewNotFound(resource, name)
+			}
+			return obj, nil
+		})
+		return delegateUpdater.Update(ctx, name, existingObjInfo, createValidation, updateValidation, false, options)
+	}
+	return storage
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+func TestStorageBuilderVerbs(t *testing.T) {
+	scale := &apiextensionsv1.CustomResourceSubresourceScale{SpecReplicasPath: ".spec.replicas", StatusReplicasPath: ".status.replicas"}
+	tests := []struct {
+		access           workloadv1alpha1.ResourceAccess
+		wantUpdate       bool
+		wantStatusUpdate bool
+		wantScaleUpdate  bool
+	}{
+		{access: workloadv1alpha1.ResourceAccessReadOnly},
+		{access: workloadv1alpha1.ResourceAccessStatusOnly, wantStatusUpdate: true},
+		{access: workloadv1alpha1.ResourceAccessReadWrite, wantUpdate: true, wantStatusUpdate: true, wantScaleUpdate: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.access), func(t *testing.T) {
+			gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+			storage, subresources := NewStorageBuilder(context.Background(), nil, "", tt.access, scale, nil)(
+				gvr,
+				gvr.GroupVersion().WithKind("Deployment"),
+				gvr.GroupVersion().WithKind("DeploymentList"),
+				nil,
+				rest.NewDefaultTableConvertor(gvr.GroupResource()),
+				true,
+				nil,
+				map[string]*validate.SchemaValidator{"status": nil},
+				&structuralschema.Structural{},
+			)
+
+			_, canGet := storage.(rest.Getter)
+			require.True(t, canGet)
+			_, canUpdate := storage.(rest.Updater)
+			require.Equal(t, tt.wantUpdate, canUpdate)
+			_, canCreate := storage.(rest.Creater)
+			require.False(t, canCreate)
+
+			for name, wantUpdate := range map[string]bool{"status": tt.wantStatusUpdate, "scale": tt.wantScaleUpdate} {
+				subresource, found := subresources[name]
+				require.True(t, found, "%s should be served", name)
+				_, canGet := subresource.(rest.Getter)
+				require.True(t, canGet, "%s should be readable", name)
+				_, canUpdate := subresource.(rest.Updater)
+				require.Equal(t, wantUpdate, canUpdate, "unexpected update of %s", name)
+			}
+		})
+	}
+}
+
+func TestWithoutCreateOnUpdate(t *testing.T) {
+	resource := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	var updated runtime.Object
+	storage := withoutCreateOnUpdate(resource, &registry.StoreFuncs{
+		UpdaterFunc: func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+			// like the forwarding storage for server-side apply, the object is computed without an old object if it does not exist.
+			var old runtime.Object
+			if name == "existing" {
+				old = newPod("existing", nil)
+			}
+			obj, err := objInfo.UpdatedObject(ctx, old)
+			if err != nil {
+				return nil, false, err
+			}
+			updated = obj
+			return obj, old == nil, nil
+		},
+	})
+
+	_, _, err := storage.Update(context.Background(), "missing", rest.DefaultUpdatedObjectInfo(newPod("missing", nil)), nil, nil, true, &metav1.UpdateOptions{})
+	require.True(t, apierrors.IsNotFound(err), "unexpected error: %v", err)
+	require.Nil(t, updated)
+
+	_, _, err = storage.Update(context.Background(), "existing", rest.DefaultUpdatedObjectInfo(newPod("existing", map[string]string{"a": "b"})), nil, nil, false, &metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, newPod("existing", map[string]string{"a": "b"}), updated)
+}
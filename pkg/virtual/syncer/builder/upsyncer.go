@@ -0,0 +1,448 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/registry/customresource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/dynamic"
+	kubernetesinformers "k8s.io/client-go/informers"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+	"k8s.io/kube-openapi/pkg/common"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+	generatedopenapi "k8s.io/kubernetes/pkg/generated/openapi"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualworkspacesdynamic "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/internalapis"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/metrics"
+)
+
+const UpsyncerVirtualWorkspaceName string = "upsyncer"
+
+// BuildUpsyncerVirtualWorkspace builds the upsyncer virtual workspace, through which the syncer of a SyncTarget
+// creates and updates upstream the objects originating from the SyncTarget. Contrary to the syncer virtual workspace,
+// it only serves the upsyncable resources, and only the objects in Upsync state for the SyncTarget. Objects created or
+// updated through it must keep that state. Access requires the upsync verb on the SyncTarget, and requests to a
+// workspace require a Placement or a namespace in it to be scheduled to the SyncTarget.
+func BuildUpsyncerVirtualWorkspace(
+	rootPathPrefix string,
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	dynamicClusterClient dynamic.ClusterInterface,
+	wildcardKubeInformers kubernetesinformers.SharedInformerFactory,
+	wildcardKcpInformers kcpinformers.SharedInformerFactory,
+) framework.VirtualWorkspace {
+
+	if !strings.HasSuffix(rootPathPrefix, "/") {
+		rootPathPrefix += "/"
+	}
+
+	readyCh := make(chan struct{})
+
+	return &virtualworkspacesdynamic.DynamicVirtualWorkspace{
+		RootPathResolver: newSyncTargetRootPathResolver(rootPathPrefix, readyCh, wildcardKcpInformers),
+		Authorizer:       newUpsyncAuthorizer(newSyncTargetAuthorizer(kubeClusterClient, wildcardKubeInformers, wildcardKcpInformers, "upsync"), wildcardKubeInformers, wildcardKcpInformers),
+		ReadyChecker: framework.ReadyFunc(func() error {
+			select {
+			case <-readyCh:
+				return nil
+			default:
+				return errors.New("upsyncer virtual workspace informers are not synced")
+			}
+		}),
+		BootstrapAPISetManagement: func(mainConfig genericapiserver.CompletedConfig) (apidefinition.APIDefinitionSetGetter, error) {
+			apis := &upsyncerAPIs{
+				apiSets: map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet{},
+				createAPIDefinition: func(syncTargetWorkspace logicalcluster.Name, syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string) (apidefinition.APIDefinition, error) {
+					syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName)
+					ctx, cancelFn := context.WithCancel(context.Background())
+					storageBuilder := newUpsyncerStorageBuilder(ctx, dynamicClusterClient, withUpsyncState(syncTargetKey))
+					def, err := apiserver.CreateServingInfoFor(mainConfig, apiResourceSchema, version, storageBuilder)
+					if err != nil {
+						cancelFn()
+						return nil, err
+					}
+					return &apiDefinitionWithCancel{
+						APIDefinition: def,
+						cancelFn:      cancelFn,
+					}, nil
+				},
+			}
+
+			wildcardKcpInformers.Workload().V1alpha1().SyncTargets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+				DeleteFunc: func(obj interface{}) {
+					key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+					if err != nil {
+						utilruntime.HandleError(err)
+						return
+					}
+					apis.remove(dynamiccontext.APIDomainKey(key))
+				},
+			})
+
+			if err := mainConfig.AddPostStartHook("kcp-virtual-"+UpsyncerVirtualWorkspaceName, func(hookContext genericapiserver.PostStartHookContext) error {
+				defer close(readyCh)
+
+				syncTargetInformer := wildcardKcpInformers.Workload().V1alpha1().SyncTargets().Informer()
+				syncTargetInformer.AddEventHandler(metrics.InformerLagHandler(UpsyncerVirtualWorkspaceName, "synctargets"))
+				if !cache.WaitForNamedCacheSync("synctargets", hookContext.StopCh, syncTargetInformer.HasSynced) {
+					klog.Errorf("informer not synced")
+				}
+				if !cache.WaitForNamedCacheSync("placements and namespaces", hookContext.StopCh,
+					wildcardKcpInformers.Scheduling().V1alpha1().Placements().Informer().HasSynced,
+					wildcardKubeInformers.Core().V1().Namespaces().Informer().HasSynced,
+				) {
+					klog.Errorf("informer not synced")
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+
+			return apis, nil
+		},
+	}
+}
+
+// newUpsyncAuthorizer returns an authorizer denying requests to workspaces in which neither a Placement nor a
+// namespace is scheduled to the SyncTarget of the request, and delegating all other requests. Wildcard requests
+// are delegated, as they only return objects in Upsync state for the SyncTarget.
+func newUpsyncAuthorizer(delegate authorizer.Authorizer, wildcardKubeInformers kubernetesinformers.SharedInformerFactory, wildcardKcpInformers kcpinformers.SharedInformerFactory) authorizer.Authorizer {
+	placementIndexer := wildcardKcpInformers.Scheduling().V1alpha1().Placements().Informer().GetIndexer()
+	indexers.AddOrDie(placementIndexer, indexers.PlacementBySyncTargetKey)
+	namespaceIndexer := wildcardKubeInformers.Core().V1().Namespaces().Informer().GetIndexer()
+	indexers.AddOrDie(namespaceIndexer, indexers.NamespacesByClusterAndSyncTargetKey)
+
+	return &upsyncAuthorizer{
+		delegate: delegate,
+		isScheduled: func(clusterName logicalcluster.Name, syncTargetKey string) (bool, error) {
+			placements, err := indexers.ByIndex[*schedulingv1alpha1.Placement](placementIndexer, indexers.PlacementBySyncTargetKey, syncTargetKey)
+			if err != nil {
+				return false, err
+			}
+			for _, placement := range placements {
+				if logicalcluster.From(placement) == clusterName {
+					return true, nil
+				}
+			}
+			namespaces, err := namespaceIndexer.ByIndex(indexers.NamespacesByClusterAndSyncTargetKey, indexers.ClusterAndSyncTargetKey(clusterName, syncTargetKey))
+			return len(namespaces) > 0, err
+		},
+	}
+}
+
+type upsyncAuthorizer struct {
+	delegate    authorizer.Authorizer
+	isScheduled func(clusterName logicalcluster.Name, syncTargetKey string) (bool, error)
+}
+
+func (a *upsyncAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	cluster := genericapirequest.ClusterFrom(ctx)
+	if cluster == nil || cluster.Name.Empty() {
+		return authorizer.DecisionNoOpinion, "no workspace in the request", nil
+	}
+	if !cluster.Wildcard {
+		syncTargetWorkspace, syncTargetName := clusters.SplitClusterAwareKey(string(dynamiccontext.APIDomainKeyFrom(ctx)))
+		scheduled, err := a.isScheduled(cluster.Name, workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName))
+		if err != nil {
+			return authorizer.DecisionNoOpinion, "", err
+		}
+		if !scheduled {
+			return authorizer.DecisionDeny, fmt.Sprintf("workspace %s is not scheduled to SyncTarget %s|%s", cluster.Name, syncTargetWorkspace, syncTargetName), nil
+		}
+	}
+	return a.delegate.Authorize(ctx, attr)
+}
+
+// upsyncerAPIs serves the API definitions of the upsyncable resources for each SyncTarget. They are created on first
+// access, and torn down when the SyncTarget is deleted.
+type upsyncerAPIs struct {
+	createAPIDefinition func(syncTargetWorkspace logicalcluster.Name, syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string) (apidefinition.APIDefinition, error)
+
+	mutex   sync.Mutex // protects the map, not the values!
+	apiSets map[dynamiccontext.APIDomainKey]apidefinition.APIDefinitionSet
+}
+
+func (a *upsyncerAPIs) GetAPIDefinitionSet(_ context.Context, key dynamiccontext.APIDomainKey) (apidefinition.APIDefinitionSet, bool, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if apiSet, found := a.apiSets[key]; found {
+		return apiSet, true, nil
+	}
+
+	syncTargetWorkspace, syncTargetName := clusters.SplitClusterAwareKey(string(key))
+	apiSet := apidefinition.APIDefinitionSet{}
+	for _, apiResourceSchema := range upsyncerSchemas {
+		for _, version := range apiResourceSchema.Spec.Versions {
+			def, err := a.createAPIDefinition(syncTargetWorkspace, syncTargetName, apiResourceSchema, version.Name)
+			if err != nil {
+				for _, def := range apiSet {
+					def.TearDown()
+				}
+				return nil, false, err
+			}
+			apiSet[schema.GroupVersionResource{
+				Group:    apiResourceSchema.Spec.Group,
+				Version:  version.Name,
+				Resource: apiResourceSchema.Spec.Names.Plural,
+			}] = def
+		}
+	}
+	a.apiSets[key] = apiSet
+
+	return apiSet, true, nil
+}
+
+func (a *upsyncerAPIs) remove(key dynamiccontext.APIDomainKey) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, def := range a.apiSets[key] {
+		def.TearDown()
+	}
+	delete(a.apiSets, key)
+}
+
+// newUpsyncerStorageBuilder returns a forwarding storage build function serving get, list, watch, create, update
+// and delete for the resource, and get and update for its status.
+func newUpsyncerStorageBuilder(ctx context.Context, clusterClient dynamic.ClusterInterface, wrapper registry.StorageWrapper) apiserver.RestProviderFunc {
+	return func(resource schema.GroupVersionResource, kind schema.GroupVersionKind, listKind schema.GroupVersionKind, typer runtime.ObjectTyper, tableConvertor rest.TableConvertor, namespaceScoped bool, schemaValidator *validate.SchemaValidator, subresourcesSchemaValidator map[string]*validate.SchemaValidator, structuralSchema *structuralschema.Structural) (mainStorage rest.Storage, subresourceStorages map[string]rest.Storage) {
+		statusSchemaValidate, statusEnabled := subresourcesSchemaValidator["status"]
+
+		var statusSpec *apiextensions.CustomResourceSubresourceStatus
+		if statusEnabled {
+			statusSpec = &apiextensions.CustomResourceSubresourceStatus{}
+		}
+
+		strategy := customresource.NewStrategy(
+			typer,
+			namespaceScoped,
+			kind,
+			schemaValidator,
+			statusSchemaValidate,
+			map[string]*structuralschema.Structural{resource.Version: structuralSchema},
+			statusSpec,
+			nil,
+		)
+
+		storage, statusStorage := registry.NewStorage(
+			ctx,
+			resource,
+			"",
+			kind,
+			listKind,
+			strategy,
+			nil,
+			tableConvertor,
+			nil,
+			clusterClient,
+			nil,
+			wrapper,
+		)
+
+		subresourceStorages = make(map[string]rest.Storage)
+		if statusEnabled {
+			subresourceStorages["status"] = &struct {
+				registry.FactoryFunc
+				registry.DestroyerFunc
+
+				registry.GetterFunc
+				registry.UpdaterFunc
+				// patch is implicit as we have get + update
+
+				registry.TableConvertorFunc
+				registry.CategoriesProviderFunc
+				registry.ResetFieldsStrategyFunc
+			}{
+				FactoryFunc:   statusStorage.FactoryFunc,
+				DestroyerFunc: statusStorage.DestroyerFunc,
+
+				GetterFunc:  statusStorage.GetterFunc,
+				UpdaterFunc: statusStorage.UpdaterFunc,
+
+				TableConvertorFunc:      statusStorage.TableConvertorFunc,
+				CategoriesProviderFunc:  statusStorage.CategoriesProviderFunc,
+				ResetFieldsStrategyFunc: statusStorage.ResetFieldsStrategyFunc,
+			}
+		}
+
+		return &struct {
+			registry.FactoryFunc
+			registry.ListFactoryFunc
+			registry.DestroyerFunc
+
+			registry.GetterFunc
+			registry.ListerFunc
+			registry.CreaterFunc
+			registry.UpdaterFunc
+			registry.GracefulDeleterFunc
+			registry.WatcherFunc
+
+			registry.TableConvertorFunc
+			registry.CategoriesProviderFunc
+			registry.ResetFieldsStrategyFunc
+		}{
+			FactoryFunc:     storage.FactoryFunc,
+			ListFactoryFunc: storage.ListFactoryFunc,
+			DestroyerFunc:   storage.DestroyerFunc,
+
+			GetterFunc:          storage.GetterFunc,
+			ListerFunc:          storage.ListerFunc,
+			CreaterFunc:         storage.CreaterFunc,
+			UpdaterFunc:         storage.UpdaterFunc,
+			GracefulDeleterFunc: storage.GracefulDeleterFunc,
+			WatcherFunc:         storage.WatcherFunc,
+
+			TableConvertorFunc:      storage.TableConvertorFunc,
+			CategoriesProviderFunc:  storage.CategoriesProviderFunc,
+			ResetFieldsStrategyFunc: storage.ResetFieldsStrategyFunc,
+		}, subresourceStorages
+	}
+}
+
+// withUpsyncState restricts the storage to the objects in Upsync state for the given SyncTarget, and acts as an
+// admission for creates and updates: the objects must keep that state, i.e. they must have the
+// state.workload.kcp.dev/<sync-target-key> label with value Upsync.
+func withUpsyncState(syncTargetKey string) registry.StorageWrapper {
+	stateLabel := workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey
+	requirements, _ := labels.SelectorFromSet(map[string]string{
+		stateLabel: string(workloadv1alpha1.ResourceStateUpsync),
+	}).Requirements()
+	withLabelSelector := registry.WithStaticLabelSelector(requirements)
+
+	return func(resource schema.GroupResource, storage *registry.StoreFuncs) *registry.StoreFuncs {
+		storage = withLabelSelector(resource, storage)
+
+		admit := func(obj runtime.Object) error {
+			metaObj, err := meta.Accessor(obj)
+			if err != nil {
+				return err
+			}
+			if state := metaObj.GetLabels()[stateLabel]; state != string(workloadv1alpha1.ResourceStateUpsync) {
+				return apierrors.NewForbidden(resource, metaObj.GetName(), fmt.Errorf("label %s must be %q, got %q", stateLabel, workloadv1alpha1.ResourceStateUpsync, state))
+			}
+			return nil
+		}
+
+		delegateCreater := storage.CreaterFunc
+		storage.CreaterFunc = func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+			if err := admit(obj); err != nil {
+				return nil, err
+			}
+			return delegateCreater.Create(ctx, obj, createValidation, options)
+		}
+
+		delegateUpdater := storage.UpdaterFunc
+		storage.UpdaterFunc = func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+			return delegateUpdater.Update(ctx, name, &admittedObjectInfo{UpdatedObjectInfo: objInfo, admit: admit}, createValidation, updateValidation, forceAllowCreate, options)
+		}
+
+		// the getter filters by label selector, hence objects in another state are not found.
+		getter := storage.GetterFunc
+		delegateDeleter := storage.GracefulDeleterFunc
+		storage.GracefulDeleterFunc = func(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+			if _, err := getter.Get(ctx, name, &metav1.GetOptions{}); err != nil {
+				return nil, false, err
+			}
+			return delegateDeleter.Delete(ctx, name, deleteValidation, options)
+		}
+
+		return storage
+	}
+}
+
+// admittedObjectInfo admits the updated object before it is stored.
+type admittedObjectInfo struct {
+	rest.UpdatedObjectInfo
+	admit func(obj runtime.Object) error
+}
+
+func (i *admittedObjectInfo) UpdatedObject(ctx context.Context, oldObj runtime.Object) (runtime.Object, error) {
+	obj, err := i.UpdatedObjectInfo.UpdatedObject(ctx, oldObj)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.admit(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// upsyncerSchemas contains the internal APIs that can be synced up from any SyncTarget.
+var upsyncerSchemas []*apisv1alpha1.APIResourceSchema
+
+func init() {
+	schemes := []*runtime.Scheme{legacyscheme.Scheme}
+	openAPIDefinitionsGetters := []common.GetOpenAPIDefinitions{generatedopenapi.GetOpenAPIDefinitions}
+
+	if apis, err := internalapis.CreateAPIResourceSchemas(schemes, openAPIDefinitionsGetters, upsyncerInternalAPIs...); err != nil {
+		panic(err)
+	} else {
+		upsyncerSchemas = apis
+	}
+}
+
+// upsyncerInternalAPIs provides the list of built-in APIs that can be synced up through the upsyncer virtual workspace.
+var upsyncerInternalAPIs = []internalapis.InternalAPI{
+	{
+		Names: apiextensionsv1.CustomResourceDefinitionNames{
+			Plural:   "pods",
+			Singular: "pod",
+			Kind:     "Pod",
+		},
+		GroupVersion:  schema.GroupVersion{Group: "", Version: "v1"},
+		Instance:      &corev1.Pod{},
+		ResourceScope: apiextensionsv1.NamespaceScoped,
+		HasStatus:     true,
+	},
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+)
+
+func newPod(name string, labels map[string]string) *unstructured.Unstructured {
+	pod := &unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	pod.SetName(name)
+	pod.SetLabels(labels)
+	return pod
+}
+
+func TestWithUpsyncState(t *testing.T) {
+	upsynced := newPod("upsynced", map[string]string{"state.workload.kcp.dev/abc": "Upsync"})
+	synced := newPod("synced", map[string]string{"state.workload.kcp.dev/abc": "Sync"})
+	objects := map[string]*unstructured.Unstructured{"upsynced": upsynced, "synced": synced}
+
+	var stored, deleted string
+	storage := withUpsyncState("abc")(schema.GroupResource{Resource: "pods"}, &registry.StoreFuncs{
+		GetterFunc: func(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+			if obj, found := objects[name]; found {
+				return obj, nil
+			}
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, name)
+		},
+		CreaterFunc: func(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+			stored = obj.(*unstructured.Unstructured).GetName()
+			return obj, nil
+		},
+		UpdaterFunc: func(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+			obj, err := objInfo.UpdatedObject(ctx, objects[name])
+			if err != nil {
+				return nil, false, err
+			}
+			stored = name
+			return obj, false, nil
+		},
+		GracefulDeleterFunc: func(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+			deleted = name
+			return nil, true, nil
+		},
+	})
+	ctx := context.Background()
+
+	t.Run("create in Upsync state", func(t *testing.T) {
+		stored = ""
+		_, err := storage.Create(ctx, newPod("new", map[string]string{"state.workload.kcp.dev/abc": "Upsync"}), nil, &metav1.CreateOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "new", stored)
+	})
+
+	t.Run("create without Upsync state is forbidden", func(t *testing.T) {
+		stored = ""
+		_, err := storage.Create(ctx, newPod("new", map[string]string{"state.workload.kcp.dev/abc": "Sync"}), nil, &metav1.CreateOptions{})
+		require.True(t, apierrors.IsForbidden(err), "unexpected error: %v", err)
+		require.Empty(t, stored)
+	})
+
+	t.Run("update keeping Upsync state", func(t *testing.T) {
+		stored = ""
+		_, _, err := storage.Update(ctx, "upsynced", rest.DefaultUpdatedObjectInfo(upsynced.DeepCopy()), nil, nil, false, &metav1.UpdateOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "upsynced", stored)
+	})
+
+	t.Run("update removing Upsync state is forbidden", func(t *testing.T) {
+		stored = ""
+		_, _, err := storage.Update(ctx, "upsynced", rest.DefaultUpdatedObjectInfo(newPod("upsynced", nil)), nil, nil, false, &metav1.UpdateOptions{})
+		require.True(t, apierrors.IsForbidden(err), "unexpected error: %v", err)
+		require.Empty(t, stored)
+	})
+
+	t.Run("delete in Upsync state", func(t *testing.T) {
+		deleted = ""
+		_, _, err := storage.Delete(ctx, "upsynced", nil, &metav1.DeleteOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "upsynced", deleted)
+	})
+
+	t.Run("delete in other state is not found", func(t *testing.T) {
+		deleted = ""
+		_, _, err := storage.Delete(ctx, "synced", nil, &metav1.DeleteOptions{})
+		require.True(t, apierrors.IsNotFound(err), "unexpected error: %v", err)
+		require.Empty(t, deleted)
+	})
+}
+
+func TestUpsyncAuthorizer(t *testing.T) {
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(logicalcluster.New("root:org:compute"), "cluster1")
+	scheduled := logicalcluster.New("root:org:scheduled")
+
+	tests := map[string]struct {
+		cluster genericapirequest.Cluster
+		want    authorizer.Decision
+	}{
+		"requests to workspaces scheduled to the SyncTarget are delegated": {
+			cluster: genericapirequest.Cluster{Name: scheduled},
+			want:    authorizer.DecisionAllow,
+		},
+		"requests to other workspaces are denied": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("root:org:other")},
+			want:    authorizer.DecisionDeny,
+		},
+		"wildcard requests are delegated": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			want:    authorizer.DecisionAllow,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			a := &upsyncAuthorizer{
+				delegate: authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+					return authorizer.DecisionAllow, "", nil
+				}),
+				isScheduled: func(clusterName logicalcluster.Name, key string) (bool, error) {
+					require.Equal(t, syncTargetKey, key)
+					return clusterName == scheduled, nil
+				},
+			}
+			ctx := genericapirequest.WithCluster(context.Background(), tc.cluster)
+			ctx = dynamiccontext.WithAPIDomainKey(ctx, "root:org:compute|cluster1")
+			decision, _, err := a.Authorize(ctx, authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "syncer"}, Verb: "create", Resource: "persistentvolumes", ResourceRequest: true})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, decision)
+		})
+	}
+}
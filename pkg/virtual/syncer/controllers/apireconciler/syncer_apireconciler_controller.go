@@ -40,18 +40,19 @@ import (
 	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
 	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
 	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	kcpworkqueue "github.com/kcp-dev/kcp/pkg/workqueue"
 )
 
 const (
-	ControllerName                     = "kcp-virtual-syncer-api-reconciler"
-	indexSyncTargetsByExport           = ControllerName + "ByExport"
-	indexAPIExportsByAPIResourceSchema = ControllerName + "ByAPIResourceSchema"
+	ControllerName           = "kcp-virtual-syncer-api-reconciler"
+	indexSyncTargetsByExport = ControllerName + "ByExport"
 )
 
-type CreateAPIDefinitionFunc func(syncTargetWorkspace logicalcluster.Name, syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, identityHash string) (apidefinition.APIDefinition, error)
+type CreateAPIDefinitionFunc func(syncTargetWorkspace logicalcluster.Name, syncTargetName string, apiResourceSchema *apisv1alpha1.APIResourceSchema, version string, identityHash string, access workloadv1alpha1.ResourceAccess) (apidefinition.APIDefinition, error)
 
 func NewAPIReconciler(
 	kcpClusterClient kcpclient.ClusterInterface,
@@ -60,7 +61,7 @@ func NewAPIReconciler(
 	apiExportInformer apisinformers.APIExportInformer,
 	createAPIDefinition CreateAPIDefinitionFunc,
 ) (*APIReconciler, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+	queue := kcpworkqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
 
 	c := &APIReconciler{
 		kcpClusterClient: kcpClusterClient,
@@ -86,11 +87,7 @@ func NewAPIReconciler(
 		return nil, err
 	}
 
-	if err := apiExportInformer.Informer().AddIndexers(cache.Indexers{
-		indexAPIExportsByAPIResourceSchema: indexAPIExportsByAPIResourceSchemas,
-	}); err != nil {
-		return nil, err
-	}
+	indexers.AddOrDie(apiExportInformer.Informer().GetIndexer(), indexers.APIExportByAPIResourceSchema)
 
 	logger := logging.WithReconciler(klog.Background(), ControllerName)
 
@@ -100,8 +97,9 @@ func NewAPIReconciler(
 			oldCluster := old.(*workloadv1alpha1.SyncTarget)
 			newCluster := obj.(*workloadv1alpha1.SyncTarget)
 
-			// only enqueue when syncedResource is changed.
-			if !equality.Semantic.DeepEqual(oldCluster.Status.SyncedResources, newCluster.Status.SyncedResources) {
+			// only enqueue when syncedResource or resource policies are changed.
+			if !equality.Semantic.DeepEqual(oldCluster.Status.SyncedResources, newCluster.Status.SyncedResources) ||
+				!equality.Semantic.DeepEqual(oldCluster.Spec.ResourcePolicies, newCluster.Spec.ResourcePolicies) {
 				c.enqueueSyncTarget(obj, logger, "")
 			}
 		},
@@ -181,7 +179,7 @@ func (c *APIReconciler) enqueueAPIResourceSchema(obj interface{}, logger logr.Lo
 		return
 	}
 
-	apiExports, err := c.apiExportIndexer.ByIndex(indexAPIExportsByAPIResourceSchema, key)
+	apiExports, err := c.apiExportIndexer.ByIndex(indexers.APIExportByAPIResourceSchema, key)
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -23,26 +23,10 @@ import (
 
 	"k8s.io/client-go/tools/clusters"
 
-	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	reconcilerapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 )
 
-// indexAPIExportsByAPIResourceSchemasFunc is an index function that maps an APIExport to its spec.latestResourceSchemas.
-func indexAPIExportsByAPIResourceSchemas(obj interface{}) ([]string, error) {
-	apiExport, ok := obj.(*apisv1alpha1.APIExport)
-	if !ok {
-		return []string{}, fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj)
-	}
-
-	ret := make([]string, len(apiExport.Spec.LatestResourceSchemas))
-	for i := range apiExport.Spec.LatestResourceSchemas {
-		ret[i] = clusters.ToClusterAwareKey(logicalcluster.From(apiExport), apiExport.Spec.LatestResourceSchemas[i])
-	}
-
-	return ret, nil
-}
-
 func indexSyncTargetsByExports(obj interface{}) ([]string, error) {
 	synctarget, ok := obj.(*workloadv1alpha1.SyncTarget)
 	if !ok {
@@ -85,6 +85,8 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiDomainKey dynamicconte
 				Resource: gr.Resource,
 			}
 
+			access := syncTarget.ResourceAccess(gr.Group, gr.Resource)
+
 			oldDef, found := oldSet[gvr]
 			if found {
 				oldDef := oldDef.(apiResourceSchemaApiDefinition)
@@ -94,15 +96,18 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiDomainKey dynamicconte
 				if oldDef.IdentityHash != schemaIdentites[gr] {
 					logging.WithObject(logger, apiResourceSchema).V(4).Info("APIResourceSchema identity hash has changed", "oldIdentityHash", oldDef.IdentityHash, "newIdentityHash", schemaIdentites[gr])
 				}
-				if oldDef.UID == apiResourceSchema.UID && oldDef.IdentityHash == schemaIdentites[gr] {
-					// this is the same schema and identity as before. no need to update.
+				if oldDef.Access != access {
+					logging.WithObject(logger, apiResourceSchema).V(4).Info("resource access has changed", "oldAccess", oldDef.Access, "newAccess", access)
+				}
+				if oldDef.UID == apiResourceSchema.UID && oldDef.IdentityHash == schemaIdentites[gr] && oldDef.Access == access {
+					// this is the same schema, identity and access as before. no need to update.
 					newSet[gvr] = oldDef
 					preservedGVR = append(preservedGVR, gvrString(gvr))
 					continue
 				}
 			}
 
-			apiDefinition, err := c.createAPIDefinition(logicalcluster.From(syncTarget), syncTarget.Name, apiResourceSchema, version.Name, schemaIdentites[gr])
+			apiDefinition, err := c.createAPIDefinition(logicalcluster.From(syncTarget), syncTarget.Name, apiResourceSchema, version.Name, schemaIdentites[gr], access)
 			if err != nil {
 				logger.WithValues("gvr", gvr).Error(err, "failed to create API definition")
 				continue
@@ -112,6 +117,7 @@ func (c *APIReconciler) reconcile(ctx context.Context, apiDomainKey dynamicconte
 				APIDefinition: apiDefinition,
 				UID:           apiResourceSchema.UID,
 				IdentityHash:  schemaIdentites[gr],
+				Access:        access,
 			}
 			newGVRs = append(newGVRs, gvrString(gvr))
 		}
@@ -140,6 +146,7 @@ type apiResourceSchemaApiDefinition struct {
 
 	UID          types.UID
 	IdentityHash string
+	Access       workloadv1alpha1.ResourceAccess
 }
 
 func gvrString(gvr schema.GroupVersionResource) string {
@@ -33,4 +33,8 @@ limitations under the License.
 // The builder package is the place where all these components are combined together, especially in the
 // BuildVirtualWorkspace() function.
 //
+// The builder package also provides the Upsyncer Virtual Workspace in the BuildUpsyncerVirtualWorkspace() function.
+// It exposes a separate URL for each SyncTarget, through which the syncer can only create and update upstream the
+// objects of a fixed set of resources (e.g. Pods) originating from the SyncTarget, i.e. in Upsync state for it.
+//
 package syncer
@@ -22,6 +22,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"k8s.io/client-go/dynamic"
+	kubernetesinformers "k8s.io/client-go/informers"
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -31,6 +32,9 @@ import (
 	"github.com/kcp-dev/kcp/pkg/virtual/syncer/builder"
 )
 
+// syncTargetURLTemplate is the part of the URL path of the syncer virtual workspaces that follows their name.
+const syncTargetURLTemplate = "{syncTargetWorkspace}/{syncTargetName}/{syncTargetUID}/clusters/{cluster}"
+
 type Syncer struct{}
 
 func New() *Syncer {
@@ -55,6 +59,7 @@ func (o *Syncer) Validate(flagPrefix string) []error {
 func (o *Syncer) NewVirtualWorkspaces(
 	rootPathPrefix string,
 	config *rest.Config,
+	wildcardKubeInformers kubernetesinformers.SharedInformerFactory,
 	wildcardKcpInformers kcpinformers.SharedInformerFactory,
 ) (workspaces []rootapiserver.NamedVirtualWorkspace, err error) {
 	config = rest.AddUserAgent(rest.CopyConfig(config), "syncer-virtual-workspace")
@@ -72,6 +77,7 @@ func (o *Syncer) NewVirtualWorkspaces(
 	}
 
 	return []rootapiserver.NamedVirtualWorkspace{
-		{Name: builder.SyncerVirtualWorkspaceName, VirtualWorkspace: builder.BuildVirtualWorkspace(path.Join(rootPathPrefix, builder.SyncerVirtualWorkspaceName), kubeClusterClient, dynamicClusterClient, kcpClusterClient, wildcardKcpInformers)},
+		{Name: builder.SyncerVirtualWorkspaceName, URLTemplate: path.Join(rootPathPrefix, builder.SyncerVirtualWorkspaceName, syncTargetURLTemplate), VirtualWorkspace: builder.BuildVirtualWorkspace(path.Join(rootPathPrefix, builder.SyncerVirtualWorkspaceName), kubeClusterClient, dynamicClusterClient, kcpClusterClient, wildcardKubeInformers, wildcardKcpInformers)},
+		{Name: builder.UpsyncerVirtualWorkspaceName, URLTemplate: path.Join(rootPathPrefix, builder.UpsyncerVirtualWorkspaceName, syncTargetURLTemplate), VirtualWorkspace: builder.BuildUpsyncerVirtualWorkspace(path.Join(rootPathPrefix, builder.UpsyncerVirtualWorkspaceName), kubeClusterClient, dynamicClusterClient, wildcardKubeInformers, wildcardKcpInformers)},
 	}, nil
 }
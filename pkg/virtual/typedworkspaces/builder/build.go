@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/dynamic"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	rootphase0 "github.com/kcp-dev/kcp/config/root-phase0"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualworkspacesdynamic "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apidefinition"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/apiserver"
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+	registry "github.com/kcp-dev/kcp/pkg/virtual/framework/forwardingregistry"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+	"github.com/kcp-dev/kcp/pkg/virtual/typedworkspaces"
+)
+
+func BuildVirtualWorkspace(
+	rootPathPrefix string,
+	dynamicClusterClient dynamic.ClusterInterface,
+	kubeClusterClient kubernetesclient.ClusterInterface,
+) ([]rootapiserver.NamedVirtualWorkspace, error) {
+	if !strings.HasSuffix(rootPathPrefix, "/") {
+		rootPathPrefix += "/"
+	}
+
+	clusterWorkspaceResource := apisv1alpha1.APIResourceSchema{}
+	if err := rootphase0.Unmarshal("apiresourceschema-clusterworkspaces.tenancy.kcp.dev.yaml", &clusterWorkspaceResource); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal clusterworkspace resource: %w", err)
+	}
+	bs, err := json.Marshal(&apiextensionsv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: pointer.BoolPtr(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusterWorkspaceResource.Spec.Versions {
+		v := &clusterWorkspaceResource.Spec.Versions[i]
+		v.Schema.Raw = bs // wipe schemas. We don't want validation here.
+	}
+
+	workspaces := &virtualworkspacesdynamic.DynamicVirtualWorkspace{
+		RootPathResolver: framework.RootPathResolverFunc(func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+			cluster, apiDomain, prefixToStrip, ok := digestUrl(urlPath, rootPathPrefix)
+			if !ok {
+				return false, "", requestContext
+			}
+
+			completedContext = genericapirequest.WithCluster(requestContext, cluster)
+			completedContext = dynamiccontext.WithAPIDomainKey(completedContext, apiDomain)
+			return true, prefixToStrip, completedContext
+		}),
+		Authorizer: newAuthorizer(kubeClusterClient),
+		ReadyChecker: framework.ReadyFunc(func() error {
+			return nil
+		}),
+		BootstrapAPISetManagement: func(mainConfig genericapiserver.CompletedConfig) (apidefinition.APIDefinitionSetGetter, error) {
+			return &apiSetRetriever{
+				config:               mainConfig,
+				dynamicClusterClient: dynamicClusterClient,
+				resource:             &clusterWorkspaceResource,
+			}, nil
+		},
+	}
+
+	return []rootapiserver.NamedVirtualWorkspace{
+		{Name: typedworkspaces.VirtualWorkspaceName, URLTemplate: rootPathPrefix + "{clusterWorkspaceType}/clusters/{cluster}", VirtualWorkspace: workspaces},
+	}, nil
+}
+
+func digestUrl(urlPath, rootPathPrefix string) (
+	cluster genericapirequest.Cluster,
+	key dynamiccontext.APIDomainKey,
+	logicalPath string,
+	accepted bool,
+) {
+	if !strings.HasPrefix(urlPath, rootPathPrefix) {
+		return genericapirequest.Cluster{}, dynamiccontext.APIDomainKey(""), "", false
+	}
+	withoutRootPathPrefix := strings.TrimPrefix(urlPath, rootPathPrefix)
+
+	// Incoming requests to this virtual workspace will look like:
+	//  /services/typedworkspaces/<type-workspace>:<type-name>/clusters/*/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces
+	//                           └─────────────────┐
+	// Where the withoutRootPathPrefix starts here: ┘
+	parts := strings.SplitN(withoutRootPathPrefix, "/", 2)
+	if len(parts) < 2 {
+		return genericapirequest.Cluster{}, dynamiccontext.APIDomainKey(""), "", false
+	}
+
+	typeKey := dynamiccontext.APIDomainKey(parts[0])
+	if _, err := typeReferenceFrom(typeKey); err != nil {
+		return genericapirequest.Cluster{}, dynamiccontext.APIDomainKey(""), "", false
+	}
+
+	realPath := "/" + parts[1]
+
+	//  /services/typedworkspaces/<type-workspace>:<type-name>/clusters/*/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces
+	//                  ┌────────────────────────────────────┘
+	// We are now here: ┘
+	// Now, we parse out the logical cluster.
+	if !strings.HasPrefix(realPath, "/clusters/") {
+		return genericapirequest.Cluster{}, dynamiccontext.APIDomainKey(""), "", false // don't accept
+	}
+
+	withoutClustersPrefix := strings.TrimPrefix(realPath, "/clusters/")
+	parts = strings.SplitN(withoutClustersPrefix, "/", 2)
+	clusterName := logicalcluster.New(parts[0])
+	realPath = "/"
+	if len(parts) > 1 {
+		realPath += parts[1]
+	}
+
+	return genericapirequest.Cluster{Name: clusterName, Wildcard: clusterName == logicalcluster.Wildcard}, typeKey, strings.TrimSuffix(urlPath, realPath), true
+}
+
+// typeReferenceFrom parses an API domain key of the form <type-workspace>:<type-name>.
+func typeReferenceFrom(key dynamiccontext.APIDomainKey) (tenancyv1alpha1.ClusterWorkspaceTypeReference, error) {
+	separatorIndex := strings.LastIndex(string(key), ":")
+	if separatorIndex <= 0 || separatorIndex == len(key)-1 {
+		return tenancyv1alpha1.ClusterWorkspaceTypeReference{}, fmt.Errorf("expected cluster workspace type in form workspace:name, not %q", key)
+	}
+	return tenancyv1alpha1.ClusterWorkspaceTypeReference{
+		Path: string(key[:separatorIndex]),
+		Name: tenancyv1alpha1.ClusterWorkspaceTypeName(key[separatorIndex+1:]),
+	}, nil
+}
+
+// typeRequirements returns the label requirements matching the ClusterWorkspaces of the given type.
+func typeRequirements(ref tenancyv1alpha1.ClusterWorkspaceTypeReference) (labels.Requirements, error) {
+	requirements, selectable := labels.SelectorFromSet(labels.Set{
+		tenancyv1alpha1.ClusterWorkspaceTypeLabel: helper.TypeToLabel(ref),
+	}).Requirements()
+	if !selectable {
+		return nil, fmt.Errorf("unable to create a selector from the provided labels")
+	}
+	return requirements, nil
+}
+
+type apiSetRetriever struct {
+	config               genericapiserver.CompletedConfig
+	dynamicClusterClient dynamic.ClusterInterface
+	resource             *apisv1alpha1.APIResourceSchema
+}
+
+func (a *apiSetRetriever) GetAPIDefinitionSet(ctx context.Context, key dynamiccontext.APIDomainKey) (apis apidefinition.APIDefinitionSet, apisExist bool, err error) {
+	ref, err := typeReferenceFrom(key)
+	if err != nil {
+		return nil, false, err
+	}
+	requirements, err := typeRequirements(ref)
+	if err != nil {
+		return nil, false, err
+	}
+	restProvider, err := registry.ProvideReadOnlyRestStorage(ctx, a.dynamicClusterClient, registry.WithStaticLabelSelector(requirements))
+	if err != nil {
+		return nil, false, err
+	}
+
+	apiDefinition, err := apiserver.CreateServingInfoFor(
+		a.config,
+		a.resource,
+		tenancyv1alpha1.SchemeGroupVersion.Version,
+		restProvider,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create serving info: %w", err)
+	}
+
+	apis = apidefinition.APIDefinitionSet{
+		schema.GroupVersionResource{
+			Group:    tenancyv1alpha1.SchemeGroupVersion.Group,
+			Version:  tenancyv1alpha1.SchemeGroupVersion.Version,
+			Resource: "clusterworkspaces",
+		}: apiDefinition,
+	}
+
+	return apis, len(apis) > 0, nil
+}
+
+var _ apidefinition.APIDefinitionSetGetter = &apiSetRetriever{}
+
+// newAuthorizer authorizes the request verb on the workspaces subresource of the ClusterWorkspaceType, in the
+// workspace of the type.
+func newAuthorizer(client kubernetesclient.ClusterInterface) authorizer.AuthorizerFunc {
+	return func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		ref, err := typeReferenceFrom(dynamiccontext.APIDomainKeyFrom(ctx))
+		if err != nil {
+			klog.V(2).Info(err)
+			return authorizer.DecisionNoOpinion, "unable to determine cluster workspace type", fmt.Errorf("access not permitted")
+		}
+
+		authz, err := delegated.NewDelegatedAuthorizer(logicalcluster.New(ref.Path), client)
+		if err != nil {
+			return authorizer.DecisionNoOpinion, "error", err
+		}
+
+		SARAttributes := authorizer.AttributesRecord{
+			APIGroup:        tenancyv1alpha1.SchemeGroupVersion.Group,
+			APIVersion:      tenancyv1alpha1.SchemeGroupVersion.Version,
+			User:            attr.GetUser(),
+			Verb:            attr.GetVerb(),
+			Name:            tenancyv1alpha1.ObjectName(ref.Name),
+			Resource:        "clusterworkspacetypes",
+			ResourceRequest: true,
+			Subresource:     "workspaces",
+		}
+
+		return authz.Authorize(ctx, SARAttributes)
+	}
+}
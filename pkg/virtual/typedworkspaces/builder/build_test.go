@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	dynamiccontext "github.com/kcp-dev/kcp/pkg/virtual/framework/dynamic/context"
+)
+
+func TestDigestUrl(t *testing.T) {
+	tests := []struct {
+		urlPath           string
+		wantAccepted      bool
+		wantCluster       genericapirequest.Cluster
+		wantKey           dynamiccontext.APIDomainKey
+		wantPrefixToStrip string
+	}{
+		{
+			urlPath:           "/services/typedworkspaces/root:org:team/clusters/*/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces",
+			wantAccepted:      true,
+			wantCluster:       genericapirequest.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			wantKey:           "root:org:team",
+			wantPrefixToStrip: "/services/typedworkspaces/root:org:team/clusters/*",
+		},
+		{
+			urlPath:           "/services/typedworkspaces/root:org:team/clusters/root:org/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces/ws",
+			wantAccepted:      true,
+			wantCluster:       genericapirequest.Cluster{Name: logicalcluster.New("root:org")},
+			wantKey:           "root:org:team",
+			wantPrefixToStrip: "/services/typedworkspaces/root:org:team/clusters/root:org",
+		},
+		{urlPath: "/services/typedworkspaces/team/clusters/*/apis"},
+		{urlPath: "/services/typedworkspaces/root:org:/clusters/*/apis"},
+		{urlPath: "/services/typedworkspaces/root:org:team/apis"},
+		{urlPath: "/services/typedworkspaces/root:org:team"},
+		{urlPath: "/services/initializingworkspaces/root:org:team/clusters/*/apis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.urlPath, func(t *testing.T) {
+			cluster, key, prefixToStrip, accepted := digestUrl(tt.urlPath, "/services/typedworkspaces/")
+			require.Equal(t, tt.wantAccepted, accepted)
+			if !accepted {
+				return
+			}
+			require.Equal(t, tt.wantCluster, cluster)
+			require.Equal(t, tt.wantKey, key)
+			require.Equal(t, tt.wantPrefixToStrip, prefixToStrip)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package typedworkspaces and its sub-packages provide the Typed Workspaces Virtual Workspace.
+//
+// It allows the owner of a ClusterWorkspaceType to run fleet-wide controllers without wildcard access
+// to the clusters: it serves cross-cluster LIST + WATCH of the ClusterWorkspaces of the type.
+//
+// That is, a request for
+// GET /services/typedworkspaces/<type-workspace>:<type-name>/clusters/*/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces
+// will return a list of the ClusterWorkspace objects whose spec.type references the type <type-name> in
+// <type-workspace>. The ClusterWorkspaces of the type in a specific parent workspace can be read with the
+// parent workspace instead of the wildcard.
+//
+// Access is granted to users who have the verb of the request on the "workspaces" subresource of the
+// ClusterWorkspaceType, in the workspace of the type.
+package typedworkspaces
+
+const VirtualWorkspaceName string = "typedworkspaces"
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"path"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/client-go/dynamic"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+	"github.com/kcp-dev/kcp/pkg/virtual/typedworkspaces"
+	"github.com/kcp-dev/kcp/pkg/virtual/typedworkspaces/builder"
+)
+
+type TypedWorkspaces struct{}
+
+func New() *TypedWorkspaces {
+	return &TypedWorkspaces{}
+}
+
+func (o *TypedWorkspaces) AddFlags(flags *pflag.FlagSet, prefix string) {
+	if o == nil {
+		return
+	}
+}
+
+func (o *TypedWorkspaces) Validate(flagPrefix string) []error {
+	if o == nil {
+		return nil
+	}
+	errs := []error{}
+
+	return errs
+}
+
+func (o *TypedWorkspaces) NewVirtualWorkspaces(
+	rootPathPrefix string,
+	config *rest.Config,
+) (workspaces []rootapiserver.NamedVirtualWorkspace, err error) {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "typedworkspaces-virtual-workspace")
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClusterClient, err := dynamic.NewClusterForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.BuildVirtualWorkspace(path.Join(rootPathPrefix, typedworkspaces.VirtualWorkspaceName), dynamicClusterClient, kubeClusterClient)
+}
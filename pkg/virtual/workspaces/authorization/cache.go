@@ -484,8 +484,19 @@ func (ac *AuthorizationCache) List(userInfo user.Info, labelSelector labels.Sele
 		}
 	}
 
+	// a name in the field selector matches at most one of the keys, so the other workspaces are not
+	// read from the lister at all.
+	name, filterByName := fieldSelector.RequiresExactMatch("metadata.name")
+	predicate := workspaceutil.MatchWorkspace(labelSelector, fieldSelector)
+
 	workspaceList := &tenancyv1alpha1.ClusterWorkspaceList{}
 	for _, key := range keys.List() {
+		if filterByName {
+			if _, workspaceName := clusters.SplitClusterAwareKey(key); workspaceName != name {
+				continue
+			}
+		}
+
 		workspace, err := ac.workspaceLister.Get(key)
 		if apierrors.IsNotFound(err) {
 			continue
@@ -495,8 +506,6 @@ func (ac *AuthorizationCache) List(userInfo user.Info, labelSelector labels.Sele
 		}
 
 		// only match selected labels and fields
-
-		predicate := workspaceutil.MatchWorkspace(labelSelector, fieldSelector)
 		if matches, err := predicate.Matches(workspace); err != nil || !matches {
 			continue
 		}
@@ -148,6 +148,9 @@ func BuildVirtualWorkspace(cfg *clientrest.Config, rootPathPrefix string, wildca
 						"workspaces": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
 							return workspacesRest, nil
 						},
+						"workspaces/kubeconfig": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return registry.NewKubeconfigREST(workspacesRest, kubeClusterClient), nil
+						},
 					}, nil
 				},
 			},
@@ -190,9 +193,15 @@ func newAuthorizer(cfg *clientrest.Config) func(ctx context.Context, a authorize
 			klog.Errorf("failed to get delegated authorizer for logical cluster %s", a.GetUser().GetName(), clusterName)
 			return authorizer.DecisionNoOpinion, "", nil
 		}
+		verb := a.GetVerb()
+		if a.GetSubresource() == "kubeconfig" {
+			// everybody who can see a workspace can ask for a kubeconfig. Minting checks the permission
+			// to create a service account token inside the workspace.
+			verb = "get"
+		}
 		workspaceAttr := authorizer.AttributesRecord{
 			User:            a.GetUser(),
-			Verb:            a.GetVerb(),
+			Verb:            verb,
 			APIGroup:        tenancyv1beta1.SchemeGroupVersion.Group,
 			APIVersion:      tenancyv1beta1.SchemeGroupVersion.Version,
 			Resource:        "workspaces",
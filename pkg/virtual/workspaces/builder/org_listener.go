@@ -30,6 +30,7 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 	"github.com/kcp-dev/kcp/pkg/virtual/workspaces/authorization"
 	"github.com/kcp-dev/kcp/pkg/virtual/workspaces/registry"
 )
@@ -61,10 +62,7 @@ func NewOrgListener(informer tenancyinformers.ClusterWorkspaceInformer, newClust
 		clusterWorkspacesPerCluster: map[logicalcluster.Name]*preCreationClusterWorkspaces{},
 	}
 
-	// nolint: errcheck
-	informer.Informer().AddIndexers(cache.Indexers{
-		"parent": indexByLogicalCluster,
-	})
+	indexers.AddOrDie(informer.Informer().GetIndexer(), indexers.ByLogicalCluster)
 
 	informer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
@@ -174,7 +172,7 @@ func (l *orgListener) deleteClusterWorkspace(obj interface{}) {
 	}
 
 	// any other ClusterWorkspace in this logical cluster?
-	others, err := l.informer.GetIndexer().ByIndex("parent", parent.String())
+	others, err := l.informer.GetIndexer().ByIndex(indexers.ByLogicalCluster, parent.String())
 	if err != nil {
 		klog.Errorf("Failed to get ClusterWorkspace parent index %v: %v", parent, err)
 		return
@@ -69,6 +69,6 @@ func (o *Workspaces) NewVirtualWorkspaces(
 	}
 
 	return []rootapiserver.NamedVirtualWorkspace{
-		{Name: "workspaces", VirtualWorkspace: builder.BuildVirtualWorkspace(config, path.Join(rootPathPrefix, "workspaces"), wildcardKcpInformers.Tenancy().V1alpha1().ClusterWorkspaces(), wildcardKubeInformers.Rbac().V1(), kubeClusterClient, kcpClusterClient)},
+		{Name: "workspaces", URLTemplate: path.Join(rootPathPrefix, "workspaces", "{parentWorkspace}", "{scope}"), VirtualWorkspace: builder.BuildVirtualWorkspace(config, path.Join(rootPathPrefix, "workspaces"), wildcardKcpInformers.Tenancy().V1alpha1().ClusterWorkspaces(), wildcardKubeInformers.Rbac().V1(), kubeClusterClient, kcpClusterClient)},
 	}, nil
 }
@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+)
+
+// DefaultKubeconfigExpirationSeconds is the validity of minted kubeconfigs if not requested otherwise.
+const DefaultKubeconfigExpirationSeconds = int64(3600)
+
+// KubeconfigREST implements the kubeconfig subresource of workspaces. It mints kubeconfigs scoped
+// to the workspace with a short-lived service account token.
+type KubeconfigREST struct {
+	// workspaces gets the workspace, projected from the ClusterWorkspace in the org.
+	workspaces rest.Getter
+
+	kubeClusterClient kubernetesclient.ClusterInterface
+
+	// delegatedAuthz implements cluster-aware SubjectAccessReview
+	delegatedAuthz delegated.DelegatedAuthorizerFactory
+}
+
+var _ rest.NamedCreater = &KubeconfigREST{}
+
+// NewKubeconfigREST returns a RESTStorage object for the kubeconfig subresource of the given workspace storage.
+func NewKubeconfigREST(workspaces rest.Getter, kubeClusterClient kubernetesclient.ClusterInterface) *KubeconfigREST {
+	return &KubeconfigREST{
+		workspaces:        workspaces,
+		kubeClusterClient: kubeClusterClient,
+		delegatedAuthz:    delegated.NewDelegatedAuthorizer,
+	}
+}
+
+// New returns a new WorkspaceKubeconfig
+func (s *KubeconfigREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceKubeconfig{}
+}
+
+// Destroy implements rest.Storage
+func (s *KubeconfigREST) Destroy() {
+	// Do nothing
+}
+
+// Create mints a kubeconfig for the workspace with the given name. The user must be allowed to create
+// a token for the requested service account in the workspace.
+func (s *KubeconfigREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	req, ok := obj.(*tenancyv1beta1.WorkspaceKubeconfig)
+	if !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceKubeconfig: %T", obj))
+	}
+	req = req.DeepCopy()
+	if req.Spec.ServiceAccountNamespace == "" {
+		req.Spec.ServiceAccountNamespace = "default"
+	}
+	if req.Spec.ServiceAccountName == "" {
+		req.Spec.ServiceAccountName = "default"
+	}
+	if req.Spec.ExpirationSeconds == nil {
+		expirationSeconds := DefaultKubeconfigExpirationSeconds
+		req.Spec.ExpirationSeconds = &expirationSeconds
+	}
+	if errs := validateWorkspaceKubeconfig(req); len(errs) > 0 {
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceKubeconfig"), name, errs)
+	}
+
+	userInfo, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces/kubeconfig"), name, fmt.Errorf("unable to mint a kubeconfig without a user on the context"))
+	}
+	orgClusterName := ctx.Value(WorkspacesOrgKey).(logicalcluster.Name)
+
+	obj, err := s.workspaces.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	workspace := obj.(*tenancyv1beta1.Workspace)
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady || workspace.Status.URL == "" {
+		return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("workspace is not ready"))
+	}
+	clusterName := orgClusterName.Join(name)
+
+	// the user must be able to get the token on their own
+	authz, err := s.delegatedAuthz(clusterName, s.kubeClusterClient)
+	if err != nil {
+		return nil, err
+	}
+	decision, _, err := authz.Authorize(ctx, authorizer.AttributesRecord{
+		User:            userInfo,
+		Verb:            "create",
+		APIVersion:      "v1",
+		Resource:        "serviceaccounts",
+		Subresource:     "token",
+		Namespace:       req.Spec.ServiceAccountNamespace,
+		Name:            req.Spec.ServiceAccountName,
+		ResourceRequest: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if decision != authorizer.DecisionAllow {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces/kubeconfig"), name,
+			fmt.Errorf("user %q cannot create tokens for service account %s/%s in workspace %s", userInfo.GetName(), req.Spec.ServiceAccountNamespace, req.Spec.ServiceAccountName, clusterName))
+	}
+
+	tokenRequest, err := s.kubeClusterClient.Cluster(clusterName).CoreV1().ServiceAccounts(req.Spec.ServiceAccountNamespace).CreateToken(ctx, req.Spec.ServiceAccountName, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: req.Spec.ExpirationSeconds,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	server := req.Spec.Server
+	if server == "" {
+		server = workspace.Status.URL
+	}
+	kubeconfig, err := clientcmd.Write(newWorkspaceKubeconfig(clusterName, server, req.Spec.ServiceAccountNamespace, tokenRequest.Status.Token))
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+
+	req.Name = name
+	req.Status = tenancyv1beta1.WorkspaceKubeconfigStatus{
+		Kubeconfig:          string(kubeconfig),
+		ExpirationTimestamp: tokenRequest.Status.ExpirationTimestamp,
+	}
+	return req, nil
+}
+
+func validateWorkspaceKubeconfig(req *tenancyv1beta1.WorkspaceKubeconfig) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	if *req.Spec.ExpirationSeconds < 600 {
+		errs = append(errs, field.Invalid(specPath.Child("expirationSeconds"), *req.Spec.ExpirationSeconds, "must be at least 600"))
+	}
+	if req.Spec.Server != "" {
+		if u, err := url.Parse(req.Spec.Server); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, field.Invalid(specPath.Child("server"), req.Spec.Server, "must be an https URL"))
+		}
+	}
+	return errs
+}
+
+// newWorkspaceKubeconfig returns a kubeconfig with a single context for the given server and token.
+// The certificate authority is left to the client.
+func newWorkspaceKubeconfig(clusterName logicalcluster.Name, server, namespace, token string) clientcmdapi.Config {
+	name := clusterName.String()
+	config := clientcmdapi.NewConfig()
+	config.Clusters[name] = &clientcmdapi.Cluster{Server: server}
+	config.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: token}
+	config.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name, Namespace: namespace}
+	config.CurrentContext = name
+	return *config
+}
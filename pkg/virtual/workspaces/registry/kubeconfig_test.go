@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+type workspaceGetter func(name string) (*tenancyv1beta1.Workspace, error)
+
+func (g workspaceGetter) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	return g(name)
+}
+
+func TestCreateWorkspaceKubeconfig(t *testing.T) {
+	expiration := metav1.NewTime(time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC))
+	readyWorkspace := &tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Status: tenancyv1beta1.WorkspaceStatus{
+			URL:   "https://kcp.example.com/clusters/root:org:foo",
+			Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady,
+		},
+	}
+
+	tests := map[string]struct {
+		workspace  *tenancyv1beta1.Workspace
+		request    tenancyv1beta1.WorkspaceKubeconfigSpec
+		allowed    bool
+		wantServer string
+		wantErr    func(error) bool
+	}{
+		"defaults": {
+			workspace:  readyWorkspace,
+			allowed:    true,
+			wantServer: "https://kcp.example.com/clusters/root:org:foo",
+		},
+		"virtual workspace server": {
+			workspace:  readyWorkspace,
+			request:    tenancyv1beta1.WorkspaceKubeconfigSpec{Server: "https://kcp.example.com/services/apiexport/root:org:foo/export"},
+			allowed:    true,
+			wantServer: "https://kcp.example.com/services/apiexport/root:org:foo/export",
+		},
+		"not allowed to create tokens": {
+			workspace: readyWorkspace,
+			wantErr:   errors.IsForbidden,
+		},
+		"workspace not ready": {
+			workspace: &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}, Status: tenancyv1beta1.WorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing}},
+			allowed:   true,
+			wantErr:   errors.IsConflict,
+		},
+		"too short expiration": {
+			workspace: readyWorkspace,
+			request:   tenancyv1beta1.WorkspaceKubeconfigSpec{ExpirationSeconds: func() *int64 { v := int64(60); return &v }()},
+			allowed:   true,
+			wantErr:   errors.IsInvalid,
+		},
+		"insecure server": {
+			workspace: readyWorkspace,
+			request:   tenancyv1beta1.WorkspaceKubeconfigSpec{Server: "http://kcp.example.com"},
+			allowed:   true,
+			wantErr:   errors.IsInvalid,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var tokenCluster logicalcluster.Name
+			var tokenRequest *authenticationv1.TokenRequest
+			kubeClient := fake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				create := action.(clienttesting.CreateAction)
+				require.Equal(t, "token", create.GetSubresource())
+				require.Equal(t, "default", create.GetNamespace())
+				tokenRequest = create.GetObject().(*authenticationv1.TokenRequest).DeepCopy()
+				tokenRequest.Status = authenticationv1.TokenRequestStatus{Token: "secret-token", ExpirationTimestamp: expiration}
+				return true, tokenRequest, nil
+			})
+
+			var authorized authorizer.Attributes
+			storage := &KubeconfigREST{
+				workspaces: workspaceGetter(func(name string) (*tenancyv1beta1.Workspace, error) {
+					return tt.workspace, nil
+				}),
+				kubeClusterClient: mockKubeClusterClient(func(cluster logicalcluster.Name) kubernetes.Interface {
+					tokenCluster = cluster
+					return kubeClient
+				}),
+				delegatedAuthz: func(clusterName logicalcluster.Name, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
+					require.Equal(t, "root:org:foo", clusterName.String())
+					return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+						authorized = a
+						if tt.allowed {
+							return authorizer.DecisionAllow, "", nil
+						}
+						return authorizer.DecisionNoOpinion, "", nil
+					}), nil
+				},
+			}
+			var _ rest.NamedCreater = storage
+
+			ctx := apirequest.WithUser(context.Background(), &kuser.DefaultInfo{Name: "alice"})
+			ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, logicalcluster.New("root:org"))
+			obj, err := storage.Create(ctx, "foo", &tenancyv1beta1.WorkspaceKubeconfig{Spec: tt.request}, nil, &metav1.CreateOptions{})
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				require.True(t, tt.wantErr(err), "unexpected error: %v", err)
+				require.Nil(t, tokenRequest, "no token must be minted")
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, "alice", authorized.GetUser().GetName())
+			require.Equal(t, "create", authorized.GetVerb())
+			require.Equal(t, "serviceaccounts", authorized.GetResource())
+			require.Equal(t, "token", authorized.GetSubresource())
+			require.Equal(t, "default", authorized.GetName())
+
+			require.Equal(t, "root:org:foo", tokenCluster.String())
+			require.Equal(t, DefaultKubeconfigExpirationSeconds, *tokenRequest.Spec.ExpirationSeconds)
+
+			response := obj.(*tenancyv1beta1.WorkspaceKubeconfig)
+			require.Equal(t, expiration, response.Status.ExpirationTimestamp)
+			config, err := clientcmd.Load([]byte(response.Status.Kubeconfig))
+			require.NoError(t, err)
+			require.Equal(t, "root:org:foo", config.CurrentContext)
+			require.Equal(t, tt.wantServer, config.Clusters["root:org:foo"].Server)
+			require.Equal(t, "secret-token", config.AuthInfos["root:org:foo"].Token)
+			require.Equal(t, "default", config.Contexts["root:org:foo"].Namespace)
+		})
+	}
+}
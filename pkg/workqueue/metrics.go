@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	// register the standard workqueue metrics (depth, adds, latency, work duration, retries), partitioned by queue name
+	_ "k8s.io/component-base/metrics/prometheus/workqueue"
+)
+
+const subsystem = "workqueue"
+
+var (
+	clusterAdds = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "logical_cluster_adds_total",
+			Help:           "Number of adds handled by a workqueue, partitioned by queue name and organization of the logical cluster of the item.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"name", "logical_cluster"},
+	)
+
+	clusterRetries = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "logical_cluster_retries_total",
+			Help:           "Number of rate limited retries handled by a workqueue, partitioned by queue name and organization of the logical cluster of the item.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"name", "logical_cluster"},
+	)
+
+	clusterLatency = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subsystem,
+			Name:           "logical_cluster_queue_duration_seconds",
+			Help:           "How long in seconds an item stays in a workqueue before being requested, partitioned by queue name and organization of the logical cluster of the item.",
+			StabilityLevel: metrics.ALPHA,
+			Buckets:        metrics.ExponentialBuckets(10e-9, 10, 10),
+		},
+		[]string{"name", "logical_cluster"},
+	)
+
+	backpressureDelays = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "backpressure_delayed_adds_total",
+			Help:           "Number of adds a workqueue delayed because its depth exceeded the backpressure threshold, partitioned by queue name.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"name"},
+	)
+)
+
+var registerMetrics sync.Once
+
+// Register registers the logical cluster workqueue metrics in the legacy registry.
+func Register() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(clusterAdds)
+		legacyregistry.MustRegister(clusterRetries)
+		legacyregistry.MustRegister(clusterLatency)
+		legacyregistry.MustRegister(backpressureDelays)
+	})
+}
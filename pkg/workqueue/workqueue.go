@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workqueue provides rate limiting workqueues for kcp controllers, instrumented with metrics partitioned by
+// logical cluster, and with optional backpressure when a queue is overloaded.
+//
+// To keep the cardinality of the metrics bounded, the logical cluster label holds the organization of the logical
+// cluster of an item, e.g. root:org for root:org:team, unless SetFullLogicalClusterLabels is called.
+package workqueue
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// noLogicalCluster is the logical cluster label value of items that are not cluster-aware keys.
+const noLogicalCluster = "none"
+
+// fullLogicalClusterLabels makes the metrics use the full logical cluster name instead of the organization.
+var fullLogicalClusterLabels bool
+
+// SetFullLogicalClusterLabels makes the metrics of all queues partition by the full logical cluster name of the
+// items instead of by their organization. This is meant for debugging only, as the number of time series then
+// grows with the number of workspaces. It must be called before any queue is created.
+func SetFullLogicalClusterLabels(full bool) {
+	fullLogicalClusterLabels = full
+}
+
+// Backpressure configures a queue to delay adds while its depth exceeds Threshold. The delay starts at BaseDelay
+// and doubles with every further multiple of Threshold the depth reaches, up to MaxDelay. Adds through
+// AddRateLimited and AddAfter are never delayed further.
+type Backpressure struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// NewNamedRateLimitingQueue is like workqueue.NewNamedRateLimitingQueue, but additionally records the adds,
+// retries and queue latency of the queue by the logical cluster of the items.
+func NewNamedRateLimitingQueue(rateLimiter workqueue.RateLimiter, name string) workqueue.RateLimitingInterface {
+	return NewNamedRateLimitingQueueWithBackpressure(rateLimiter, name, Backpressure{})
+}
+
+// NewNamedRateLimitingQueueWithBackpressure is like NewNamedRateLimitingQueue, but delays adds according to
+// backpressure while the queue is overloaded, e.g. during a storm of informer events.
+func NewNamedRateLimitingQueueWithBackpressure(rateLimiter workqueue.RateLimiter, name string, backpressure Backpressure) workqueue.RateLimitingInterface {
+	Register()
+
+	return &instrumentedQueue{
+		RateLimitingInterface: workqueue.NewNamedRateLimitingQueue(rateLimiter, name),
+		name:                  name,
+		backpressure:          backpressure,
+		addTimes:              map[interface{}]time.Time{},
+	}
+}
+
+type instrumentedQueue struct {
+	workqueue.RateLimitingInterface
+
+	name         string
+	backpressure Backpressure
+
+	// addTimes holds the time of the first undelayed add of every queued item to record the queue latency.
+	// Items added with a delay are not recorded, their latency is dominated by the delay.
+	lock     sync.Mutex
+	addTimes map[interface{}]time.Time
+}
+
+func (q *instrumentedQueue) Add(item interface{}) {
+	clusterAdds.WithLabelValues(q.name, logicalClusterLabel(item)).Inc()
+
+	if delay := q.backpressure.delay(q.Len()); delay > 0 {
+		backpressureDelays.WithLabelValues(q.name).Inc()
+		q.RateLimitingInterface.AddAfter(item, delay)
+		return
+	}
+
+	q.lock.Lock()
+	if _, found := q.addTimes[item]; !found {
+		q.addTimes[item] = time.Now()
+	}
+	q.lock.Unlock()
+
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *instrumentedQueue) Get() (interface{}, bool) {
+	item, shutdown := q.RateLimitingInterface.Get()
+	if shutdown {
+		return item, shutdown
+	}
+
+	q.lock.Lock()
+	added, found := q.addTimes[item]
+	delete(q.addTimes, item)
+	q.lock.Unlock()
+
+	if found {
+		clusterLatency.WithLabelValues(q.name, logicalClusterLabel(item)).Observe(time.Since(added).Seconds())
+	}
+
+	return item, shutdown
+}
+
+func (q *instrumentedQueue) AddRateLimited(item interface{}) {
+	clusterRetries.WithLabelValues(q.name, logicalClusterLabel(item)).Inc()
+	q.RateLimitingInterface.AddRateLimited(item)
+}
+
+// delay returns how long to delay an add to a queue of the given depth.
+func (b Backpressure) delay(depth int) time.Duration {
+	if b.Threshold <= 0 || depth < b.Threshold {
+		return 0
+	}
+
+	delay := b.BaseDelay
+	for overload := depth / b.Threshold; overload > 1 && delay < b.MaxDelay; overload-- {
+		delay *= 2
+	}
+	if delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+
+	return delay
+}
+
+// logicalClusterOf returns the logical cluster of a queue item, if it is a cluster-aware key of the form
+// [<namespace>/]<cluster>|<name>.
+func logicalClusterOf(item interface{}) string {
+	key, ok := item.(string)
+	if !ok {
+		return noLogicalCluster
+	}
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return noLogicalCluster
+	}
+
+	clusterName, _ := clusters.SplitClusterAwareKey(name)
+	if clusterName.Empty() {
+		return noLogicalCluster
+	}
+
+	return clusterName.String()
+}
+
+// logicalClusterLabel returns the logical cluster label value of a queue item, i.e. the organization of its
+// logical cluster, e.g. root:org for root:org:team, unless SetFullLogicalClusterLabels is set.
+func logicalClusterLabel(item interface{}) string {
+	clusterName := logicalClusterOf(item)
+	if fullLogicalClusterLabels {
+		return clusterName
+	}
+	if segments := strings.SplitN(clusterName, ":", 3); len(segments) == 3 {
+		return segments[0] + ":" + segments[1]
+	}
+	return clusterName
+}
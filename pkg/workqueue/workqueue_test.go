@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestLogicalClusterOf(t *testing.T) {
+	tests := map[string]struct {
+		item interface{}
+		want string
+	}{
+		"cluster-scoped key":   {item: "root:org|foo", want: "root:org"},
+		"namespaced key":       {item: "default/root:org|foo", want: "root:org"},
+		"key without cluster":  {item: "default/foo", want: noLogicalCluster},
+		"plain string":         {item: "apps.v1.deployments", want: noLogicalCluster},
+		"invalid key":          {item: "a/b/c", want: noLogicalCluster},
+		"not a string":         {item: 42, want: noLogicalCluster},
+		"wildcard cluster key": {item: "*|foo", want: "*"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.want, logicalClusterOf(tt.item))
+		})
+	}
+}
+
+func TestLogicalClusterLabel(t *testing.T) {
+	tests := map[string]struct {
+		item interface{}
+		full bool
+		want string
+	}{
+		"root":                 {item: "root|foo", want: "root"},
+		"organization":         {item: "root:org|foo", want: "root:org"},
+		"team":                 {item: "default/root:org:team|foo", want: "root:org"},
+		"nested team":          {item: "root:org:team:sub|foo", want: "root:org"},
+		"full team":            {item: "root:org:team|foo", full: true, want: "root:org:team"},
+		"key without cluster":  {item: "default/foo", want: noLogicalCluster},
+		"wildcard cluster key": {item: "*|foo", want: "*"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			SetFullLogicalClusterLabels(tt.full)
+			defer SetFullLogicalClusterLabels(false)
+			require.Equal(t, tt.want, logicalClusterLabel(tt.item))
+		})
+	}
+}
+
+func TestQueueLatency(t *testing.T) {
+	q := NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test-latency").(*instrumentedQueue)
+	defer q.ShutDown()
+
+	q.Add("root:org|a")
+	q.Add("root:org|a")
+	require.Len(t, q.addTimes, 1, "expected the add time of a queued item to be recorded once")
+
+	item, _ := q.Get()
+	require.Equal(t, "root:org|a", item)
+	require.Empty(t, q.addTimes, "expected the add time to be forgotten when the item is requested")
+	q.Done(item)
+}
+
+func TestBackpressureDelay(t *testing.T) {
+	b := Backpressure{Threshold: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	require.Equal(t, time.Duration(0), Backpressure{}.delay(1000), "expected no delay without threshold")
+	require.Equal(t, time.Duration(0), b.delay(9))
+	require.Equal(t, time.Second, b.delay(10))
+	require.Equal(t, time.Second, b.delay(19))
+	require.Equal(t, 2*time.Second, b.delay(20))
+	require.Equal(t, 4*time.Second, b.delay(30))
+	require.Equal(t, 5*time.Second, b.delay(40))
+	require.Equal(t, 5*time.Second, b.delay(10000))
+}
+
+func TestBackpressure(t *testing.T) {
+	q := NewNamedRateLimitingQueueWithBackpressure(workqueue.DefaultControllerRateLimiter(), "test-backpressure", Backpressure{
+		Threshold: 2,
+		BaseDelay: time.Hour,
+		MaxDelay:  time.Hour,
+	})
+	defer q.ShutDown()
+
+	q.Add("root|a")
+	q.Add("root|b")
+	q.Add("root|c")
+	require.Equal(t, 2, q.Len(), "expected the add exceeding the threshold to be delayed")
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workspacearchive serializes the content of a workspace to a portable archive, and
+// recreates it in another workspace, possibly of another kcp instance.
+//
+// An archive is a gzip compressed tar file. Its first file is the manifest, followed by one file
+// per resource holding the JSON encoded objects, in the order they must be imported.
+package workspacearchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemove"
+)
+
+const (
+	// FormatVersion is the version of the archive format written by Export.
+	FormatVersion = "v1alpha1"
+
+	manifestFileName = "manifest.json"
+
+	// retryInterval is the interval in which objects of resources not served yet are retried on
+	// import, e.g. until the CRDs and APIBindings imported before are established.
+	retryInterval = time.Second
+)
+
+// Manifest describes the content of an archive.
+type Manifest struct {
+	// Version is the format version of the archive.
+	Version string `json:"version"`
+	// Workspace is the path of the exported workspace. References to it are rewritten to the target
+	// workspace on import.
+	Workspace string `json:"workspace"`
+	// Resources are the exported resources, in import order.
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is an exported resource whose objects are stored in a file of the archive.
+type Resource struct {
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	// File is the name of the file holding the objects.
+	File string `json:"file"`
+	// Count is the number of objects.
+	Count int `json:"count"`
+}
+
+func (r Resource) groupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// Export writes all objects of the given workspace to an archive. The resources are those discovered
+// in the workspace. Server-populated metadata and owner references are dropped, as on moves of
+// workspaces. APIExport identities are exported with their secrets, such that the identities of
+// exports are preserved on import.
+func Export(ctx context.Context, client dynamic.Interface, resources []*metav1.APIResourceList, source logicalcluster.Name, w io.Writer) error {
+	logger := klog.FromContext(ctx)
+
+	gvrs, err := clusterworkspacemove.CopyOrder(clusterworkspacemove.CopyableResources(resources))
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{Version: FormatVersion, Workspace: source.String()}
+	var files [][]byte
+	for _, gvr := range gvrs {
+		list, err := client.Resource(gvr).List(logicalcluster.WithCluster(ctx, source), metav1.ListOptions{})
+		if errors.IsNotFound(err) {
+			continue // resource went away after discovery
+		} else if err != nil {
+			return fmt.Errorf("failed to list %s in workspace %s: %w", gvr.GroupResource(), source, err)
+		}
+
+		objs := make([]map[string]interface{}, 0, len(list.Items))
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if clusterworkspacemove.SkipObject(gvr.GroupResource(), obj) {
+				continue
+			}
+			if err := clusterworkspacemove.PrepareForCopy(gvr.GroupResource(), obj, source, source); err != nil {
+				return err
+			}
+			objs = append(objs, obj.Object)
+		}
+		if len(objs) == 0 {
+			continue
+		}
+
+		data, err := json.Marshal(objs)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", gvr.GroupResource(), err)
+		}
+		manifest.Resources = append(manifest.Resources, Resource{
+			Group:    gvr.Group,
+			Version:  gvr.Version,
+			Resource: gvr.Resource,
+			File:     path.Join("resources", gvr.GroupResource().String(), gvr.Version+".json"),
+			Count:    len(objs),
+		})
+		files = append(files, data)
+		logger.V(4).Info("exported objects", "resource", gvr, "count", len(objs))
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := writeFile(tw, manifestFileName, manifestData); err != nil {
+		return err
+	}
+	for i, r := range manifest.Resources {
+		if err := writeFile(tw, r.File, files[i]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Import recreates the objects of an archive in the given workspace, in the order they were exported.
+// References to the exported workspace are rewritten to the target. Objects which exist already are
+// left untouched, such that an import can be retried. Objects of resources which are not served
+// yet are retried until the given timeout, waiting for imported CRDs and APIBindings.
+func Import(ctx context.Context, client dynamic.Interface, target logicalcluster.Name, r io.Reader, timeout time.Duration) (*Manifest, error) {
+	logger := klog.FromContext(ctx)
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gr.Close() // nolint: errcheck
+	tr := tar.NewReader(gr)
+
+	manifest := &Manifest{}
+	if err := readFile(tr, manifestFileName, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported archive version %q, expected %q", manifest.Version, FormatVersion)
+	}
+	source := logicalcluster.New(manifest.Workspace)
+
+	for _, resource := range manifest.Resources {
+		var objs []map[string]interface{}
+		if err := readFile(tr, resource.File, &objs); err != nil {
+			return nil, err
+		}
+
+		gvr := resource.groupVersionResource()
+		pending := make([]*unstructured.Unstructured, 0, len(objs))
+		for _, o := range objs {
+			obj := &unstructured.Unstructured{Object: o}
+			if err := clusterworkspacemove.PrepareForCopy(gvr.GroupResource(), obj, source, target); err != nil {
+				return nil, err
+			}
+			pending = append(pending, obj)
+		}
+
+		var lastErr error
+		if err := wait.PollImmediateWithContext(ctx, retryInterval, timeout, func(ctx context.Context) (bool, error) {
+			pending, lastErr = createObjects(ctx, client, gvr, target, pending)
+			if lastErr != nil {
+				return false, lastErr
+			}
+			return len(pending) == 0, nil
+		}); err != nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, fmt.Errorf("timed out waiting for %s to be served in workspace %s", gvr.GroupResource(), target)
+		}
+		logger.V(4).Info("imported objects", "resource", gvr, "count", len(objs))
+	}
+
+	return manifest, nil
+}
+
+// createObjects creates the given objects, and returns those whose resource is not served yet.
+func createObjects(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, target logicalcluster.Name, objs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	var retry []*unstructured.Unstructured
+	var errs []error
+	for _, obj := range objs {
+		_, err := client.Resource(gvr).Namespace(obj.GetNamespace()).Create(logicalcluster.WithCluster(ctx, target), obj, metav1.CreateOptions{})
+		switch {
+		case err == nil, errors.IsAlreadyExists(err):
+		case errors.IsNotFound(err):
+			retry = append(retry, obj)
+		default:
+			errs = append(errs, fmt.Errorf("failed to import %s %s/%s: %w", gvr.GroupResource(), obj.GetNamespace(), obj.GetName(), err))
+		}
+	}
+	return retry, utilerrors.NewAggregate(errs)
+}
+
+func readFile(tr *tar.Reader, name string, into interface{}) error {
+	header, err := tr.Next()
+	if err == io.EOF {
+		return fmt.Errorf("invalid archive: missing %s", name)
+	} else if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if header.Name != name {
+		return fmt.Errorf("invalid archive: expected %s, got %s", name, header.Name)
+	}
+	if err := json.NewDecoder(tr).Decode(into); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacearchive
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestExportImport(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	apiBindings := schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apibindings"}
+	listKinds := map[schema.GroupVersionResource]string{
+		configMaps:  "ConfigMapList",
+		secrets:     "SecretList",
+		apiBindings: "APIBindingList",
+	}
+	resources := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Namespaced: true, Verbs: metav1.Verbs{"list", "create"}},
+			{Name: "secrets", Namespaced: true, Verbs: metav1.Verbs{"list", "create"}},
+			{Name: "events", Namespaced: true, Verbs: metav1.Verbs{"list", "create"}},
+		}},
+		{GroupVersion: "apis.kcp.dev/v1alpha1", APIResources: []metav1.APIResource{
+			{Name: "apibindings", Verbs: metav1.Verbs{"list", "create"}},
+		}},
+	}
+
+	newObj := func(apiVersion, kind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": apiVersion, "kind": kind}}
+		for k, v := range fields {
+			obj.Object[k] = v
+		}
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		obj.SetUID("uid")
+		obj.SetResourceVersion("42")
+		obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org:source"})
+		return obj
+	}
+
+	source := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+		newObj("v1", "ConfigMap", "default", "config", map[string]interface{}{"data": map[string]interface{}{"key": "value"}}),
+		newObj("v1", "Secret", "default", "identity", map[string]interface{}{"type": "Opaque"}),
+		newObj("v1", "Secret", "default", "token", map[string]interface{}{"type": "kubernetes.io/service-account-token"}),
+		newObj("apis.kcp.dev/v1alpha1", "APIBinding", "", "self", map[string]interface{}{"spec": map[string]interface{}{
+			"reference": map[string]interface{}{"workspace": map[string]interface{}{"path": "root:org:source", "exportName": "widgets"}},
+		}}),
+		newObj("apis.kcp.dev/v1alpha1", "APIBinding", "", "other", map[string]interface{}{"spec": map[string]interface{}{
+			"reference": map[string]interface{}{"workspace": map[string]interface{}{"path": "root:compute", "exportName": "kubernetes"}},
+		}}),
+	)
+
+	var archive bytes.Buffer
+	require.NoError(t, Export(context.Background(), source, resources, logicalcluster.New("root:org:source"), &archive))
+
+	target := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+		newObj("v1", "ConfigMap", "default", "config", map[string]interface{}{"data": map[string]interface{}{"key": "existing"}}),
+	)
+	manifest, err := Import(context.Background(), target, logicalcluster.New("root:other:target"), bytes.NewReader(archive.Bytes()), time.Second)
+	require.NoError(t, err)
+
+	require.Equal(t, "root:org:source", manifest.Workspace)
+	var got []string
+	for _, r := range manifest.Resources {
+		got = append(got, r.File)
+	}
+	require.Equal(t, []string{
+		"resources/apibindings.apis.kcp.dev/v1alpha1.json",
+		"resources/configmaps/v1.json",
+		"resources/secrets/v1.json",
+	}, got, "APIBindings are imported first, service account tokens are skipped")
+
+	cm, err := target.Resource(configMaps).Namespace("default").Get(context.Background(), "config", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "existing", cm.Object["data"].(map[string]interface{})["key"], "existing objects are left untouched")
+
+	secret, err := target.Resource(secrets).Namespace("default").Get(context.Background(), "identity", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, secret.GetUID())
+	require.Empty(t, secret.GetAnnotations()[logicalcluster.AnnotationKey])
+
+	self, err := target.Resource(apiBindings).Get(context.Background(), "self", metav1.GetOptions{})
+	require.NoError(t, err)
+	path, _, _ := unstructured.NestedString(self.Object, "spec", "reference", "workspace", "path")
+	require.Equal(t, "root:other:target", path, "references to the exported workspace are rewritten")
+
+	other, err := target.Resource(apiBindings).Get(context.Background(), "other", metav1.GetOptions{})
+	require.NoError(t, err)
+	path, _, _ = unstructured.NestedString(other.Object, "spec", "reference", "workspace", "path")
+	require.Equal(t, "root:compute", path)
+}
+
+func TestImportInvalidArchive(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	_, err := Import(context.Background(), client, logicalcluster.New("root:ws"), bytes.NewReader([]byte("not an archive")), time.Second)
+	require.Error(t, err)
+}
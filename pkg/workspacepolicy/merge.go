@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workspacepolicy merges the WorkspacePolicies of the ancestors of a workspace into the policy
+// in effect for it. It is shared by the admission plugin and the controller applying the policies.
+package workspacepolicy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// Policy is the policy in effect for a workspace, merged from the WorkspacePolicies of its ancestors.
+type Policy struct {
+	// Objects are created in the workspace.
+	Objects []Object
+	// APIBindings are the APIExports bound in the workspace.
+	APIBindings []APIBinding
+	// WorkspaceQuota is the spec of the WorkspaceQuota of the workspace, or nil.
+	WorkspaceQuota *tenancyv1alpha1.WorkspaceQuotaSpec
+	// WorkspaceQuotaEnforced is whether an ancestor denies overrides of the WorkspaceQuota, i.e.
+	// WorkspaceQuotas not created from a policy are replaced.
+	WorkspaceQuotaEnforced bool
+	// AllowedTypes are the only types of ClusterWorkspaces which can be created in the workspace,
+	// or empty if all types are allowed.
+	AllowedTypes []tenancyv1alpha1.ClusterWorkspaceTypeReference
+	// DefaultType is the type of ClusterWorkspaces created in the workspace without a type, or nil.
+	DefaultType *tenancyv1alpha1.ClusterWorkspaceTypeReference
+}
+
+// Object is an object created in a workspace by policy.
+type Object struct {
+	*unstructured.Unstructured
+	// Policy is the WorkspacePolicy the object comes from. The object is applied impersonating
+	// the author of the policy.
+	Policy *tenancyv1alpha1.WorkspacePolicy
+}
+
+// APIBinding is an APIExport bound in a workspace by policy.
+type APIBinding struct {
+	tenancyv1alpha1.DefaultAPIBinding
+	// Enforced is whether an ancestor denies overrides of the APIBinding, i.e. the workspace
+	// cannot opt out of it.
+	Enforced bool
+}
+
+// objectKey identifies an object of a policy, such that later policies can replace it.
+type objectKey struct {
+	gk        schema.GroupKind
+	namespace string
+	name      string
+}
+
+// Ancestors returns the logical clusters whose WorkspacePolicies apply to the given workspace, from
+// the root down. The policies of a workspace apply to its descendants, but not to itself.
+func Ancestors(clusterName logicalcluster.Name) []logicalcluster.Name {
+	var ancestors []logicalcluster.Name
+	for parent, hasParent := clusterName.Parent(); hasParent; parent, hasParent = parent.Parent() {
+		ancestors = append([]logicalcluster.Name{parent}, ancestors...)
+	}
+	return ancestors
+}
+
+// EffectivePolicy returns the policy in effect for the given workspace, merging the WorkspacePolicies
+// of its ancestors.
+func EffectivePolicy(listPolicies func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error), clusterName logicalcluster.Name) (*Policy, error) {
+	var policies []*tenancyv1alpha1.WorkspacePolicy
+	for _, ancestor := range Ancestors(clusterName) {
+		ps, err := listPolicies(ancestor)
+		if err != nil {
+			return nil, err
+		}
+		ps = append([]*tenancyv1alpha1.WorkspacePolicy(nil), ps...)
+		sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+		policies = append(policies, ps...)
+	}
+	return Merge(policies)
+}
+
+// Merge merges the given WorkspacePolicies in order. Later policies override the fields set by earlier
+// ones, unless these deny overrides.
+func Merge(policies []*tenancyv1alpha1.WorkspacePolicy) (*Policy, error) {
+	merged := &Policy{}
+
+	var objectOrder []objectKey
+	objects := map[objectKey]Object{}
+	lockedObjects := map[objectKey]bool{}
+	var bindingOrder []string
+	bindings := map[string]APIBinding{}
+	quotaLocked, typesLocked, defaultTypeLocked := false, false, false
+
+	for _, policy := range policies {
+		locked := policy.Spec.OverridePolicy == tenancyv1alpha1.WorkspacePolicyOverrideDeny
+
+		for i, raw := range policy.Spec.Objects {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(raw.Raw); err != nil {
+				return nil, fmt.Errorf("invalid objects[%d] of WorkspacePolicy %s|%s: %w", i, logicalcluster.From(policy), policy.Name, err)
+			}
+			key := objectKey{gk: obj.GroupVersionKind().GroupKind(), namespace: obj.GetNamespace(), name: obj.GetName()}
+			if lockedObjects[key] {
+				continue
+			}
+			if _, found := objects[key]; !found {
+				objectOrder = append(objectOrder, key)
+			}
+			objects[key] = Object{Unstructured: obj, Policy: policy}
+			lockedObjects[key] = locked
+		}
+
+		for _, binding := range policy.Spec.DefaultAPIBindings {
+			// APIBindings are named after their APIExport, such that later policies replace those of the same name
+			if bindings[binding.ExportName].Enforced {
+				continue
+			}
+			if _, found := bindings[binding.ExportName]; !found {
+				bindingOrder = append(bindingOrder, binding.ExportName)
+			}
+			bindings[binding.ExportName] = APIBinding{DefaultAPIBinding: binding, Enforced: locked}
+		}
+
+		if policy.Spec.WorkspaceQuota != nil && !quotaLocked {
+			merged.WorkspaceQuota = policy.Spec.WorkspaceQuota.DeepCopy()
+			quotaLocked = locked
+		}
+
+		if len(policy.Spec.AllowedTypes) > 0 && !typesLocked {
+			merged.AllowedTypes = append([]tenancyv1alpha1.ClusterWorkspaceTypeReference(nil), policy.Spec.AllowedTypes...)
+			typesLocked = locked
+		}
+
+		if policy.Spec.DefaultType != nil && !defaultTypeLocked {
+			defaultType := *policy.Spec.DefaultType
+			merged.DefaultType = &defaultType
+			defaultTypeLocked = locked
+		}
+	}
+
+	for _, key := range objectOrder {
+		merged.Objects = append(merged.Objects, objects[key])
+	}
+	for _, name := range bindingOrder {
+		merged.APIBindings = append(merged.APIBindings, bindings[name])
+	}
+	merged.WorkspaceQuotaEnforced = quotaLocked
+
+	return merged, nil
+}
+
+// AllowsType returns whether ClusterWorkspaces of the given type can be created in the workspace.
+func (p *Policy) AllowsType(ref tenancyv1alpha1.ClusterWorkspaceTypeReference) bool {
+	if len(p.AllowedTypes) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedTypes {
+		if allowed.Name == ref.Name && (allowed.Path == "" || allowed.Path == ref.Path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacepolicy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestAncestors(t *testing.T) {
+	require.Equal(t, []logicalcluster.Name{logicalcluster.New("root"), logicalcluster.New("root:org")}, Ancestors(logicalcluster.New("root:org:ws")))
+	require.Empty(t, Ancestors(logicalcluster.New("root")))
+}
+
+func TestMerge(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+	object := func(kind, name, label string) tenancyv1alpha1.DefaultObject {
+		return tenancyv1alpha1.DefaultObject{RawExtension: runtime.RawExtension{
+			Raw: []byte(fmt.Sprintf(`{"apiVersion":"v1","kind":%q,"metadata":{"name":%q,"labels":{"from":%q}}}`, kind, name, label)),
+		}}
+	}
+	policy := func(name string, override tenancyv1alpha1.WorkspacePolicyOverridePolicy, spec tenancyv1alpha1.WorkspacePolicySpec) *tenancyv1alpha1.WorkspacePolicy {
+		spec.OverridePolicy = override
+		return &tenancyv1alpha1.WorkspacePolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+	}
+	universal := tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "universal", Path: "root"}
+	team := tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:org"}
+	binding := func(path, name string) tenancyv1alpha1.DefaultAPIBinding {
+		return tenancyv1alpha1.DefaultAPIBinding{Path: path, ExportName: name}
+	}
+
+	tests := map[string]struct {
+		policies []*tenancyv1alpha1.WorkspacePolicy
+
+		wantObjects  []string
+		wantBindings []string
+		wantQuota    *tenancyv1alpha1.WorkspaceQuotaSpec
+		wantEnforced bool
+		wantTypes    []tenancyv1alpha1.ClusterWorkspaceTypeReference
+		wantDefault  *tenancyv1alpha1.ClusterWorkspaceTypeReference
+		wantErr      bool
+	}{
+		"no policies": {},
+		"objects are merged by kind and name": {
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				policy("a", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{object("ConfigMap", "x", "a"), object("Secret", "x", "a")}}),
+				policy("b", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{object("ConfigMap", "x", "b"), object("ConfigMap", "y", "b")}}),
+			},
+			wantObjects: []string{"ConfigMap/x/b", "Secret/x/a", "ConfigMap/y/b"},
+		},
+		"denied objects are not overridden": {
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				policy("a", tenancyv1alpha1.WorkspacePolicyOverrideDeny, tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{object("ConfigMap", "x", "a")}}),
+				policy("b", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{object("ConfigMap", "x", "b"), object("ConfigMap", "y", "b")}}),
+			},
+			wantObjects: []string{"ConfigMap/x/a", "ConfigMap/y/b"},
+		},
+		"APIBindings are merged by name": {
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				policy("a", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: []tenancyv1alpha1.DefaultAPIBinding{binding("root", "x"), binding("root", "y")}}),
+				policy("b", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: []tenancyv1alpha1.DefaultAPIBinding{binding("root:org", "x"), binding("root:org", "z")}}),
+			},
+			wantBindings: []string{"root:org:x", "root:y", "root:org:z"},
+		},
+		"denied APIBindings are enforced": {
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				policy("a", tenancyv1alpha1.WorkspacePolicyOverrideDeny, tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: []tenancyv1alpha1.DefaultAPIBinding{binding("root", "x")}}),
+				policy("b", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{DefaultAPIBindings: []tenancyv1alpha1.DefaultAPIBinding{binding("root:org", "x"), binding("root:org", "z")}}),
+			},
+			wantBindings: []string{"root:x!", "root:org:z"},
+		},
+		"later quotas and types override earlier ones": {
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				policy("a", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{
+					WorkspaceQuota: &tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(10)},
+					AllowedTypes:   []tenancyv1alpha1.ClusterWorkspaceTypeReference{universal},
+					DefaultType:    &universal,
+				}),
+				policy("b", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{
+					WorkspaceQuota: &tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(5)},
+					AllowedTypes:   []tenancyv1alpha1.ClusterWorkspaceTypeReference{team},
+					DefaultType:    &team,
+				}),
+				policy("c", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{}),
+			},
+			wantQuota:   &tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(5)},
+			wantTypes:   []tenancyv1alpha1.ClusterWorkspaceTypeReference{team},
+			wantDefault: &team,
+		},
+		"denied quotas and types are enforced": {
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				policy("a", tenancyv1alpha1.WorkspacePolicyOverrideDeny, tenancyv1alpha1.WorkspacePolicySpec{
+					WorkspaceQuota: &tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(10)},
+					AllowedTypes:   []tenancyv1alpha1.ClusterWorkspaceTypeReference{universal},
+					DefaultType:    &universal,
+				}),
+				policy("b", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{
+					WorkspaceQuota: &tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(5)},
+					AllowedTypes:   []tenancyv1alpha1.ClusterWorkspaceTypeReference{team},
+					DefaultType:    &team,
+				}),
+			},
+			wantQuota:    &tenancyv1alpha1.WorkspaceQuotaSpec{ChildWorkspaces: int64Ptr(10)},
+			wantEnforced: true,
+			wantTypes:    []tenancyv1alpha1.ClusterWorkspaceTypeReference{universal},
+			wantDefault:  &universal,
+		},
+		"invalid objects": {
+			policies: []*tenancyv1alpha1.WorkspacePolicy{
+				policy("a", tenancyv1alpha1.WorkspacePolicyOverrideAllow, tenancyv1alpha1.WorkspacePolicySpec{Objects: []tenancyv1alpha1.DefaultObject{{RawExtension: runtime.RawExtension{Raw: []byte(`[]`)}}}}),
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			merged, err := Merge(tc.policies)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var objects []string
+			for _, obj := range merged.Objects {
+				require.Equal(t, obj.GetLabels()["from"], obj.Policy.Name, "object must be attributed to its policy")
+				objects = append(objects, obj.GetKind()+"/"+obj.GetName()+"/"+obj.GetLabels()["from"])
+			}
+			require.Equal(t, tc.wantObjects, objects)
+
+			var bindings []string
+			for _, b := range merged.APIBindings {
+				s := b.Path + ":" + b.ExportName
+				if b.Enforced {
+					s += "!"
+				}
+				bindings = append(bindings, s)
+			}
+			require.Equal(t, tc.wantBindings, bindings)
+			require.Equal(t, tc.wantQuota, merged.WorkspaceQuota)
+			require.Equal(t, tc.wantEnforced, merged.WorkspaceQuotaEnforced)
+			require.Equal(t, tc.wantTypes, merged.AllowedTypes)
+			require.Equal(t, tc.wantDefault, merged.DefaultType)
+		})
+	}
+}
+
+func TestEffectivePolicy(t *testing.T) {
+	listPolicies := func(clusterName logicalcluster.Name) ([]*tenancyv1alpha1.WorkspacePolicy, error) {
+		switch clusterName {
+		case logicalcluster.New("root"):
+			return []*tenancyv1alpha1.WorkspacePolicy{
+				{ObjectMeta: metav1.ObjectMeta{Name: "b"}, Spec: tenancyv1alpha1.WorkspacePolicySpec{AllowedTypes: []tenancyv1alpha1.ClusterWorkspaceTypeReference{{Name: "b"}}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: tenancyv1alpha1.WorkspacePolicySpec{AllowedTypes: []tenancyv1alpha1.ClusterWorkspaceTypeReference{{Name: "a"}}}},
+			}, nil
+		case logicalcluster.New("root:org"):
+			return []*tenancyv1alpha1.WorkspacePolicy{
+				{ObjectMeta: metav1.ObjectMeta{Name: "0"}, Spec: tenancyv1alpha1.WorkspacePolicySpec{AllowedTypes: []tenancyv1alpha1.ClusterWorkspaceTypeReference{{Name: "org"}}}},
+			}, nil
+		case logicalcluster.New("root:org:ws"):
+			require.Fail(t, "policies of the workspace itself must not apply")
+		}
+		return nil, nil
+	}
+
+	policy, err := EffectivePolicy(listPolicies, logicalcluster.New("root:org:ws"))
+	require.NoError(t, err)
+	require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceTypeReference{{Name: "org"}}, policy.AllowedTypes)
+
+	policy, err = EffectivePolicy(listPolicies, logicalcluster.New("root:org"))
+	require.NoError(t, err)
+	require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceTypeReference{{Name: "b"}}, policy.AllowedTypes)
+}
+
+func TestAllowsType(t *testing.T) {
+	require.True(t, (&Policy{}).AllowsType(tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "universal", Path: "root"}))
+
+	policy := &Policy{AllowedTypes: []tenancyv1alpha1.ClusterWorkspaceTypeReference{
+		{Name: "universal"},
+		{Name: "team", Path: "root:org"},
+	}}
+	require.True(t, policy.AllowsType(tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "universal", Path: "root"}))
+	require.True(t, policy.AllowsType(tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:org"}))
+	require.False(t, policy.AllowsType(tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:other"}))
+	require.False(t, policy.AllowsType(tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "organization", Path: "root"}))
+}
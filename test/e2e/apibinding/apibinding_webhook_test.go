@@ -192,8 +192,8 @@ func TestAPIBindingMutatingWebhook(t *testing.T) {
 		return testWebhooks[sourceWorkspace].Calls() >= 1
 	}, wait.ForeverTestTimeout, 100*time.Millisecond)
 
-	t.Logf("Check that the in-workspace webhook was NOT called")
-	require.Zero(t, testWebhooks[targetWorkspace].Calls(), "in-workspace webhook should not have been called")
+	t.Logf("Check that the in-workspace webhook was called as well")
+	require.NotZero(t, testWebhooks[targetWorkspace].Calls(), "in-workspace webhook should have been called")
 }
 
 func TestAPIBindingValidatingWebhook(t *testing.T) {
@@ -345,6 +345,6 @@ func TestAPIBindingValidatingWebhook(t *testing.T) {
 		return testWebhooks[sourceWorkspace].Calls() >= 1
 	}, wait.ForeverTestTimeout, 100*time.Millisecond)
 
-	t.Logf("Check that the in-workspace webhook was NOT called")
-	require.Zero(t, testWebhooks[targetWorkspace].Calls(), "in-workspace webhook should not have been called")
+	t.Logf("Check that the in-workspace webhook was called as well")
+	require.NotZero(t, testWebhooks[targetWorkspace].Calls(), "in-workspace webhook should have been called")
 }
@@ -219,6 +219,7 @@ type kcpConfig struct {
 type kcpServer struct {
 	name        string
 	args        []string
+	port        string
 	ctx         context.Context
 	dataDir     string
 	artifactDir string
@@ -267,6 +268,7 @@ func newKcpServer(t *testing.T, cfg kcpConfig, artifactDir, dataDir string) (*kc
 			"--feature-gates=" + fmt.Sprintf("%s", utilfeature.DefaultFeatureGate),
 		},
 			cfg.Args...),
+		port:        kcpListenPort,
 		dataDir:     dataDir,
 		artifactDir: artifactDir,
 		t:           t,
@@ -0,0 +1,379 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/kcp/cmd/sharded-test-server/third_party/library-go/crypto"
+	"github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
+	cacheauthorization "github.com/kcp-dev/kcp/pkg/cache/server/authorization"
+)
+
+// shardedStartupTimeout is how long the cache server and the front-proxy have to become ready. It includes
+// compiling them when they are started with `go run`.
+const shardedStartupTimeout = 3 * time.Minute
+
+// ShardedServer is a kcp topology of multiple shards which replicate into a shared cache server, behind a
+// front-proxy routing requests to the shard of their workspace. As a RunningServer it talks to the
+// front-proxy as kcp-admin, authenticated with a client certificate.
+//
+// The front-proxy only authenticates client certificates, hence the users of the token auth file can only
+// be used against the shards directly. Shards only authorize users in workspaces whose parent workspace is on
+// the same shard, hence the contents of organizations on other shards than root can only be accessed with the
+// system:admin of their shard, see Shard.
+type ShardedServer struct {
+	RunningServer
+
+	shardNames []string
+	shards     map[string]RunningServer
+
+	cacheServerKubeconfigPath string
+}
+
+// PrivateShardedKcpServer starts a root shard, numberOfShards-1 further shards named shard-<n>, a cache server
+// and a front-proxy, which are not intended to be shared between tests. The given args are passed to all
+// shards. The shards are always run as separate processes.
+func PrivateShardedKcpServer(t *testing.T, numberOfShards int, args ...string) *ShardedServer {
+	t.Helper()
+	require.Greater(t, numberOfShards, 0, "at least the root shard is required")
+
+	artifactDir, dataDir, err := ScratchDirs(t)
+	require.NoError(t, err, "failed to create scratch dirs: %v", err)
+
+	start := time.Now()
+	t.Log("Starting sharded kcp...")
+
+	pki := newShardedPKI(t, filepath.Join(dataDir, "pki"))
+
+	shardNames := []string{"root"}
+	for i := 1; i < numberOfShards; i++ {
+		shardNames = append(shardNames, fmt.Sprintf("shard-%d", i))
+	}
+
+	cacheServerKubeconfigPath, shardCacheKubeconfigPaths := startCacheServer(t, pki, artifactDir, dataDir, shardNames)
+
+	// the other shards need the admin kubeconfig of the root shard, so it is started first
+	shards := map[string]*kcpServer{}
+	tokenAuthFile := WriteTokenAuthFile(t)
+	for i, name := range shardNames {
+		certFile, keyFile := pki.serverCert(t, name)
+		shardArgs := append(TestServerArgsWithTokenAuthFile(tokenAuthFile),
+			"--external-hostname=localhost",
+			"--tls-cert-file="+certFile,
+			"--tls-private-key-file="+keyFile,
+			"--client-ca-file="+pki.path("client-ca.crt"),
+			"--requestheader-client-ca-file="+pki.path("requestheader-ca.crt"),
+			"--requestheader-username-headers=X-Remote-User",
+			"--requestheader-group-headers=X-Remote-Group",
+			"--service-account-key-file="+pki.path("service-account.crt"),
+			"--service-account-private-key-file="+pki.path("service-account.key"),
+			"--cache-server-kubeconfig-file="+shardCacheKubeconfigPaths[name],
+		)
+		if i > 0 {
+			shardArgs = append(shardArgs,
+				"--shard-name="+name,
+				"--root-shard-kubeconfig-file="+filepath.Join(shards["root"].dataDir, "admin.kubeconfig"),
+			)
+		}
+
+		shard, err := newKcpServer(t, kcpConfig{Name: name, Args: append(shardArgs, args...)}, artifactDir, dataDir)
+		require.NoError(t, err)
+		shards[name] = shard
+
+		if i == 0 {
+			runShards(t, shard)
+		}
+	}
+	var others []*kcpServer
+	for _, name := range shardNames[1:] {
+		others = append(others, shards[name])
+	}
+	runShards(t, others...)
+
+	kubeconfigPath := startFrontProxy(t, pki, artifactDir, dataDir, shards["root"])
+	frontProxy, err := newPersistentKCPServer("front-proxy", kubeconfigPath, shards["root"].KubeconfigPath())
+	require.NoError(t, err, "failed to create front-proxy fixture")
+
+	t.Logf("Started sharded kcp after %s", time.Since(start))
+
+	s := &ShardedServer{
+		RunningServer:             frontProxy,
+		shardNames:                shardNames,
+		shards:                    map[string]RunningServer{},
+		cacheServerKubeconfigPath: cacheServerKubeconfigPath,
+	}
+	for name, shard := range shards {
+		s.shards[name] = shard
+	}
+	return s
+}
+
+// ShardNames returns the names of the shards, the root shard first.
+func (s *ShardedServer) ShardNames() []string {
+	return s.shardNames
+}
+
+// Shard returns the server of the given shard, talking to it directly instead of through the front-proxy.
+func (s *ShardedServer) Shard(t *testing.T, name string) RunningServer {
+	shard, ok := s.shards[name]
+	require.Truef(t, ok, "unknown shard %q", name)
+	return shard
+}
+
+// CacheServerConfig returns a rest.Config for the cache server with system:masters permissions. Requests have to
+// be scoped to a shard, e.g. with the client.WithShardRoundTripper of the cache server.
+func (s *ShardedServer) CacheServerConfig(t *testing.T) *rest.Config {
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: s.cacheServerKubeconfigPath}, nil).ClientConfig()
+	require.NoError(t, err)
+	cfg.QPS = -1
+	return rest.AddUserAgent(cfg, t.Name())
+}
+
+// runShards runs the given shards and waits for them to become ready.
+func runShards(t *testing.T, shards ...*kcpServer) {
+	var opts []RunOption
+	if LogToConsoleEnvSet() {
+		opts = append(opts, WithLogStreaming)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(shards))
+	for _, shard := range shards {
+		err := shard.Run(opts...)
+		require.NoError(t, err)
+
+		go func(s *kcpServer) {
+			defer wg.Done()
+			err := s.Ready(true)
+			require.NoError(t, err, "kcp shard %s never became ready: %v", s.name, err)
+		}(shard)
+	}
+	wg.Wait()
+
+	if t.Failed() {
+		t.Fatal("Fixture setup failed: one or more shards did not become ready")
+	}
+}
+
+// startCacheServer starts a cache server and returns the path of its admin kubeconfig and of the kubeconfigs
+// the shards replicate with, keyed by shard name.
+func startCacheServer(t *testing.T, pki *shardedPKI, artifactDir, dataDir string, shardNames []string) (string, map[string]string) {
+	dataDir = filepath.Join(dataDir, "cache-server")
+	require.NoError(t, os.MkdirAll(dataDir, 0755), "could not create data dir")
+
+	port, err := GetFreePort(t)
+	require.NoError(t, err)
+	etcdClientPort, err := GetFreePort(t)
+	require.NoError(t, err)
+	etcdPeerPort, err := GetFreePort(t)
+	require.NoError(t, err)
+
+	certFile, keyFile := pki.serverCert(t, "cache-server")
+	host := "https://localhost:" + port
+
+	kubeconfigPath := filepath.Join(dataDir, "admin.kubeconfig")
+	writeClientCertKubeconfig(t, pki, kubeconfigPath, map[string]string{"base": host}, "cache-admin", &kuser.DefaultInfo{Name: "cache-admin", Groups: []string{kuser.SystemPrivilegedGroup}})
+	shardKubeconfigPaths := map[string]string{}
+	for _, name := range shardNames {
+		shardKubeconfigPaths[name] = filepath.Join(dataDir, name+".kubeconfig")
+		writeClientCertKubeconfig(t, pki, shardKubeconfigPaths[name], map[string]string{"base": host}, name, &kuser.DefaultInfo{Name: cacheauthorization.ShardUserPrefix + name})
+	}
+
+	cacheServer := NewAccessory(t, artifactDir, "cache-server", append(DirectOrGoRunCommand("cache-server"),
+		"--secure-port="+port,
+		"--cert-dir="+dataDir,
+		"--tls-cert-file="+certFile,
+		"--tls-private-key-file="+keyFile,
+		"--client-ca-file="+pki.path("client-ca.crt"),
+		"--embedded-etcd-directory="+filepath.Join(dataDir, "etcd-server"),
+		"--embedded-etcd-client-port="+etcdClientPort,
+		"--embedded-etcd-peer-port="+etcdPeerPort,
+	)...)
+	var opts []RunOption
+	if LogToConsoleEnvSet() {
+		opts = append(opts, WithLogStreaming)
+	}
+	require.NoError(t, cacheServer.Run(t, opts...), "failed to start the cache server")
+	waitForReadyz(t, "cache-server", kubeconfigPath)
+
+	return kubeconfigPath, shardKubeconfigPaths
+}
+
+// startFrontProxy starts a front-proxy in front of the given root shard and returns the path of a kubeconfig
+// for it with "base" and "root" contexts of kcp-admin.
+func startFrontProxy(t *testing.T, pki *shardedPKI, artifactDir, dataDir string, root *kcpServer) string {
+	dataDir = filepath.Join(dataDir, "front-proxy")
+	require.NoError(t, os.MkdirAll(dataDir, 0755), "could not create data dir")
+
+	port, err := GetFreePort(t)
+	require.NoError(t, err)
+
+	// the front-proxy watches ClusterWorkspaceShards on the root shard, and ClusterWorkspaces on all shards with
+	// the same credentials, hence with a client cert instead of the admin token of the root shard
+	rootKubeconfigPath := filepath.Join(dataDir, "root.kubeconfig")
+	writeClientCertKubeconfig(t, pki, rootKubeconfigPath, map[string]string{"base": "https://localhost:" + root.port}, "front-proxy-shard-admin",
+		&kuser.DefaultInfo{Name: "kcp-front-proxy", Groups: []string{kuser.SystemPrivilegedGroup}})
+
+	// requests are forwarded to the shards with the user in headers, authenticated by the requestheader cert
+	proxyCertFile, proxyKeyFile := pki.clientCert(t, "requestheader-ca", "front-proxy-requestheader", &kuser.DefaultInfo{Name: "kcp-front-proxy"})
+	type mappingEntry struct {
+		Path            string `json:"path"`
+		Backend         string `json:"backend"`
+		BackendServerCA string `json:"backend_server_ca"`
+		ProxyClientCert string `json:"proxy_client_cert"`
+		ProxyClientKey  string `json:"proxy_client_key"`
+	}
+	var mappings []mappingEntry
+	for _, path := range []string{"/services/", "/clusters/"} {
+		mappings = append(mappings, mappingEntry{
+			Path:            path,
+			Backend:         "https://localhost:" + root.port,
+			BackendServerCA: pki.path("serving-ca.crt"),
+			ProxyClientCert: proxyCertFile,
+			ProxyClientKey:  proxyKeyFile,
+		})
+	}
+	mappingsYAML, err := yaml.Marshal(mappings)
+	require.NoError(t, err)
+	mappingFile := filepath.Join(dataDir, "mapping.yaml")
+	require.NoError(t, os.WriteFile(mappingFile, mappingsYAML, 0644))
+
+	certFile, keyFile := pki.serverCert(t, "front-proxy")
+	host := "https://localhost:" + port
+	kubeconfigPath := filepath.Join(dataDir, "admin.kubeconfig")
+	writeClientCertKubeconfig(t, pki, kubeconfigPath, map[string]string{"base": host, "root": host + "/clusters/root"}, "kcp-admin", &kuser.DefaultInfo{
+		Name:   "kcp-admin",
+		Groups: []string{bootstrap.SystemKcpClusterWorkspaceAdminGroup, bootstrap.SystemKcpAdminGroup},
+	})
+
+	frontProxy := NewAccessory(t, artifactDir, "kcp-front-proxy", append(DirectOrGoRunCommand("kcp-front-proxy"),
+		"--secure-port="+port,
+		"--root-directory="+dataDir,
+		"--mapping-file="+mappingFile,
+		"--root-kubeconfig="+rootKubeconfigPath,
+		"--client-ca-file="+pki.path("client-ca.crt"),
+		"--tls-cert-file="+certFile,
+		"--tls-private-key-file="+keyFile,
+	)...)
+	var opts []RunOption
+	if LogToConsoleEnvSet() {
+		opts = append(opts, WithLogStreaming)
+	}
+	require.NoError(t, frontProxy.Run(t, opts...), "failed to start the front-proxy")
+	waitForReadyz(t, "kcp-front-proxy", kubeconfigPath)
+
+	return kubeconfigPath
+}
+
+// waitForReadyz waits for /readyz of the server of the given kubeconfig to succeed.
+func waitForReadyz(t *testing.T, name, kubeconfigPath string) {
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath}, nil).ClientConfig()
+	require.NoError(t, err)
+	client, err := kubernetesclient.NewForConfig(cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	Eventually(t, func() (bool, string) {
+		if _, err := client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	}, shardedStartupTimeout, time.Second, "%s never became ready", name)
+	t.Logf("%s is ready", name)
+}
+
+// writeClientCertKubeconfig writes a kubeconfig with the given contexts, mapped to their server URLs, using a
+// client certificate of the given user. The current context is "base".
+func writeClientCertKubeconfig(t *testing.T, pki *shardedPKI, kubeconfigPath string, servers map[string]string, certName string, user kuser.Info) {
+	certFile, keyFile := pki.clientCert(t, "client-ca", certName, user)
+
+	kubeConfig := clientcmdapi.Config{
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			user.GetName(): {ClientCertificate: certFile, ClientKey: keyFile},
+		},
+		Clusters:       map[string]*clientcmdapi.Cluster{},
+		Contexts:       map[string]*clientcmdapi.Context{},
+		CurrentContext: "base",
+	}
+	for name, server := range servers {
+		kubeConfig.Clusters[name] = &clientcmdapi.Cluster{Server: server, CertificateAuthority: pki.path("serving-ca.crt")}
+		kubeConfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: user.GetName()}
+	}
+	require.NoError(t, clientcmd.WriteToFile(kubeConfig, kubeconfigPath))
+}
+
+// shardedPKI holds the certificate authorities of a sharded topology:
+//   - serving-ca signs the serving certs of all components,
+//   - client-ca signs the client certs of users and of shards talking to the cache server,
+//   - requestheader-ca signs the client cert the front-proxy forwards requests to shards with.
+//
+// The service-account key pair is shared by all shards such that service account tokens are valid on all of them.
+type shardedPKI struct {
+	dir string
+	cas map[string]*crypto.CA
+}
+
+func newShardedPKI(t *testing.T, dir string) *shardedPKI {
+	require.NoError(t, os.MkdirAll(dir, 0755), "could not create pki dir")
+
+	p := &shardedPKI{dir: dir, cas: map[string]*crypto.CA{}}
+	for _, name := range []string{"serving-ca", "client-ca", "requestheader-ca", "service-account"} {
+		ca, err := crypto.MakeSelfSignedCA(p.path(name+".crt"), p.path(name+".key"), p.path(name+"-serial.txt"), "kcp-e2e-"+name, 1)
+		require.NoError(t, err, "failed to create %s", name)
+		p.cas[name] = ca
+	}
+	return p
+}
+
+func (p *shardedPKI) path(name string) string {
+	return filepath.Join(p.dir, name)
+}
+
+// serverCert creates a serving cert for localhost and returns the paths of the cert and key files.
+func (p *shardedPKI) serverCert(t *testing.T, name string) (string, string) {
+	certFile, keyFile := p.path(name+".crt"), p.path(name+".key")
+	_, err := p.cas["serving-ca"].MakeAndWriteServerCert(certFile, keyFile, sets.NewString("localhost", "127.0.0.1", "::1"), 1)
+	require.NoError(t, err, "failed to create serving cert for %s", name)
+	return certFile, keyFile
+}
+
+// clientCert creates a client cert of the given user signed by the given CA and returns the paths of the cert
+// and key files.
+func (p *shardedPKI) clientCert(t *testing.T, ca, name string, user kuser.Info) (string, string) {
+	certFile, keyFile := p.path(name+"-client.crt"), p.path(name+"-client.key")
+	_, err := p.cas[ca].MakeClientCertificate(certFile, keyFile, user, 1)
+	require.NoError(t, err, "failed to create client cert for %s", name)
+	return certFile, keyFile
+}
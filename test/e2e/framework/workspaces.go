@@ -42,6 +42,11 @@ func WithShardConstraints(c tenancyv1alpha1.ShardConstraints) ClusterWorkspaceOp
 	}
 }
 
+// WithShard pins the workspace to the shard of the given name.
+func WithShard(name string) ClusterWorkspaceOption {
+	return WithShardConstraints(tenancyv1alpha1.ShardConstraints{Name: name})
+}
+
 func WithType(path logicalcluster.Name, name tenancyv1alpha1.ClusterWorkspaceTypeName) ClusterWorkspaceOption {
 	return func(ws *tenancyv1alpha1.ClusterWorkspace) {
 		ws.Spec.Type = tenancyv1alpha1.ClusterWorkspaceTypeReference{
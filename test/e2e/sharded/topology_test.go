@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesclientset "k8s.io/client-go/kubernetes"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestShardedTopology(t *testing.T) {
+	t.Parallel()
+
+	server := framework.PrivateShardedKcpServer(t, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	kcpClusterClient, err := kcpclientset.NewForConfig(server.BaseConfig(t))
+	require.NoError(t, err)
+	kubeClusterClient, err := kubernetesclientset.NewForConfig(server.BaseConfig(t))
+	require.NoError(t, err)
+
+	for _, shard := range server.ShardNames() {
+		t.Logf("Creating an organization on shard %s through the front-proxy", shard)
+		org := framework.NewOrganizationFixture(t, server, framework.WithShard(shard))
+		ws, err := kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Get(logicalcluster.WithCluster(ctx, tenancyv1alpha1.RootCluster), org.Base(), metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, shard, ws.Status.Location.Current, "organization was scheduled onto the wrong shard")
+
+		t.Logf("Creating a ConfigMap in %s directly on shard %s", org, shard)
+		shardKubeClusterClient, err := kubernetesclientset.NewForConfig(server.Shard(t, shard).RootShardSystemMasterBaseConfig(t))
+		require.NoError(t, err)
+		_, err = shardKubeClusterClient.CoreV1().Namespaces().Create(logicalcluster.WithCluster(ctx, org), &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "sharded"},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+		_, err = shardKubeClusterClient.CoreV1().ConfigMaps("sharded").Create(logicalcluster.WithCluster(ctx, org), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "sharded"},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		if shard != tenancyv1alpha1.RootShard {
+			// kcp-admin is not authorized in workspaces whose parent is on another shard
+			continue
+		}
+		t.Logf("Expecting the ConfigMap in %s through the front-proxy", org)
+		_, err = kubeClusterClient.CoreV1().ConfigMaps("sharded").Get(logicalcluster.WithCluster(ctx, org), "sharded", metav1.GetOptions{})
+		require.NoError(t, err)
+	}
+}
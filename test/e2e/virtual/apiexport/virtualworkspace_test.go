@@ -304,6 +304,39 @@ func TestAPIExportVirtualWorkspace(t *testing.T) {
 	cowboys, err = wwUser1VC.WildwestV1alpha1().Cowboys("").List(logicalcluster.WithCluster(ctx, logicalcluster.Wildcard), metav1.ListOptions{})
 	require.NoError(t, err)
 	require.Equal(t, 0, len(cowboys.Items))
+
+	t.Logf("create the cluster role and bindings to allow user-1 to impersonate users through the virtual workspace")
+	cr, crb = createClusterRoleAndBindings("user-1-vw-impersonate", "user-1", "User", "apiexports/content", "", []string{"impersonate"})
+	_, err = kubeClusterClient.RbacV1().ClusterRoles().Create(logicalcluster.WithCluster(ctx, serviceProviderWorkspace), cr, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = kubeClusterClient.RbacV1().ClusterRoleBindings().Create(logicalcluster.WithCluster(ctx, serviceProviderWorkspace), crb, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("create a cowboy with user-1 impersonating user-3 via APIExport virtual workspace server")
+	impersonatingUser3Cfg := rest.CopyConfig(user1VWCfg)
+	impersonatingUser3Cfg.Impersonate = rest.ImpersonationConfig{UserName: "user-3"}
+	wwImpersonatingUser3VC, err := wildwestclientset.NewForConfig(impersonatingUser3Cfg)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		cowboy, err = wwImpersonatingUser3VC.WildwestV1alpha1().Cowboys("default").Create(logicalcluster.WithCluster(ctx, consumerWorkspace), newCowboy("default", "cowboy-via-impersonation"), metav1.CreateOptions{})
+		if err != nil {
+			t.Logf("error creating cowboy: %v", err)
+			return false
+		}
+		return true
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "expected user-1 to create a cowboy impersonating user-3")
+
+	t.Logf("make sure the cowboy created by impersonating user-3 exists in the consumer workspace")
+	_, err = wildwestClusterClient.WildwestV1alpha1().Cowboys("default").Get(logicalcluster.WithCluster(ctx, consumerWorkspace), "cowboy-via-impersonation", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	t.Logf("make sure user-1 cannot impersonate user-2, who has no access to the consumer workspace")
+	impersonatingUser2Cfg := rest.CopyConfig(user1VWCfg)
+	impersonatingUser2Cfg.Impersonate = rest.ImpersonationConfig{UserName: "user-2"}
+	wwImpersonatingUser2VC, err := wildwestclientset.NewForConfig(impersonatingUser2Cfg)
+	require.NoError(t, err)
+	_, err = wwImpersonatingUser2VC.WildwestV1alpha1().Cowboys("default").List(logicalcluster.WithCluster(ctx, consumerWorkspace), metav1.ListOptions{})
+	require.True(t, apierrors.IsForbidden(err), "expected forbidden error, got: %v", err)
 }
 
 func TestAPIExportPermissionClaims(t *testing.T) {